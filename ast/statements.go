@@ -3,6 +3,7 @@ package ast
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/risor-io/risor/token"
@@ -429,7 +430,11 @@ func (i *Import) Alias() *Ident { return i.alias }
 func (i *Import) String() string {
 	var out bytes.Buffer
 	out.WriteString(i.Literal() + " ")
-	out.WriteString(i.name.Literal())
+	if i.name.Token().Type == token.STRING {
+		out.WriteString(strconv.Quote(i.name.Literal()))
+	} else {
+		out.WriteString(i.name.Literal())
+	}
 	if i.alias != nil {
 		out.WriteString(" as " + i.alias.Literal())
 	}
@@ -471,6 +476,15 @@ func (i *FromImport) Parents() []*Ident { return i.parents }
 
 func (i *FromImport) Imports() []*Import { return i.imports }
 
+// IsGrouped returns true if the imports are surrounded by parentheses.
+func (i *FromImport) IsGrouped() bool { return i.isGrouped }
+
+// IsWildcard returns true for an import of everything a module exports, as
+// in "from mod import *".
+func (i *FromImport) IsWildcard() bool {
+	return len(i.imports) == 1 && i.imports[0].name.Token().Type == token.ASTERISK
+}
+
 func (i *FromImport) String() string {
 	var out bytes.Buffer
 	out.WriteString(i.Literal() + " ")
@@ -0,0 +1,154 @@
+// Package bench runs the benchmark functions of Risor scripts, scaling the
+// number of iterations of each until it runs long enough to be measured
+// reliably, as Go benchmarks do.
+//
+// Benchmark functions are the global functions whose names start with
+// "bench_". A function without parameters is called once per iteration. A
+// function with one parameter is called once with the number of iterations,
+// and runs the code being measured that many times, so it can exclude its
+// setup from the measurement.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/vm"
+)
+
+// Prefix starts the names of benchmark functions.
+const Prefix = "bench_"
+
+// DefaultTime is the time each benchmark runs for, unless configured
+// otherwise.
+const DefaultTime = time.Second
+
+// maxIterations bounds the iterations of a benchmark.
+const maxIterations = 1_000_000_000
+
+// Options configure how benchmarks run.
+type Options struct {
+	// Minimum time to run each benchmark for. Defaults to DefaultTime.
+	Time time.Duration
+
+	// Selects the benchmarks to run by name. All run if nil.
+	Filter *regexp.Regexp
+}
+
+// Result is the measurement of a benchmark.
+type Result struct {
+	Name string `json:"name"`
+
+	// Number of iterations measured
+	N int `json:"n"`
+
+	NsPerOp     float64 `json:"ns_per_op"`
+	BytesPerOp  uint64  `json:"bytes_per_op"`
+	AllocsPerOp uint64  `json:"allocs_per_op"`
+}
+
+// String formats the result in the format of Go benchmarks.
+func (r Result) String() string {
+	return fmt.Sprintf("%s\t%8d\t%12.0f ns/op\t%8d B/op\t%8d allocs/op",
+		r.Name, r.N, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp)
+}
+
+// Run runs the VM, which defines the benchmark functions of its code, and
+// then runs each benchmark in order of name, returning their results.
+func Run(ctx context.Context, machine *vm.VirtualMachine, opts Options) ([]Result, error) {
+	if opts.Time <= 0 {
+		opts.Time = DefaultTime
+	}
+	if err := machine.Run(ctx); err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range machine.GlobalNames() {
+		if !strings.HasPrefix(name, Prefix) {
+			continue
+		}
+		if opts.Filter != nil && !opts.Filter.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	results := make([]Result, 0, len(names))
+	for _, name := range names {
+		obj, err := machine.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		fn, ok := obj.(*object.Function)
+		if !ok {
+			continue
+		}
+		result, err := runBenchmark(ctx, machine, fn, opts.Time)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		result.Name = name
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// runBenchmark increases the iterations of a benchmark until they take at
+// least the given time.
+func runBenchmark(ctx context.Context, machine *vm.VirtualMachine, fn *object.Function, target time.Duration) (Result, error) {
+	if len(fn.Parameters()) > 1 {
+		return Result{}, fmt.Errorf("benchmark functions take at most one parameter")
+	}
+	n := 1
+	for {
+		result, elapsed, err := runIterations(ctx, machine, fn, n)
+		if err != nil {
+			return Result{}, err
+		}
+		if elapsed >= target || n >= maxIterations {
+			return result, nil
+		}
+		// Predict the iterations that take the target time, with some margin,
+		// growing at most a hundredfold so a slow start doesn't overshoot
+		next := 100 * n
+		if elapsed > 0 {
+			next = int(1.2 * float64(n) * float64(target) / float64(elapsed))
+		}
+		n = max(min(next, 100*n, maxIterations), n+1)
+	}
+}
+
+// runIterations measures n iterations of a benchmark.
+func runIterations(ctx context.Context, machine *vm.VirtualMachine, fn *object.Function, n int) (Result, time.Duration, error) {
+	// Start from a collected heap, so garbage from earlier runs isn't
+	// collected during the measurement
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	if len(fn.Parameters()) == 1 {
+		if _, err := machine.Call(ctx, fn, []object.Object{object.NewInt(int64(n))}); err != nil {
+			return Result{}, 0, err
+		}
+	} else {
+		for i := 0; i < n; i++ {
+			if _, err := machine.Call(ctx, fn, nil); err != nil {
+				return Result{}, 0, err
+			}
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return Result{
+		N:           n,
+		NsPerOp:     float64(elapsed.Nanoseconds()) / float64(n),
+		BytesPerOp:  (after.TotalAlloc - before.TotalAlloc) / uint64(n),
+		AllocsPerOp: (after.Mallocs - before.Mallocs) / uint64(n),
+	}, elapsed, nil
+}
@@ -0,0 +1,78 @@
+package bench
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func newVM(t *testing.T, src string) *vm.VirtualMachine {
+	t.Helper()
+	program, err := parser.Parse(context.Background(), src)
+	require.Nil(t, err)
+	code, err := compiler.Compile(program)
+	require.Nil(t, err)
+	return vm.New(code)
+}
+
+func TestRun(t *testing.T) {
+	machine := newVM(t, `
+calls := 0
+iterations := 0
+func bench_calls() {
+    calls++
+}
+func bench_loop(n) {
+    for i := 0; i < n; i++ {
+        iterations++
+    }
+}
+func helper() {}
+bench_value := 1
+`)
+	results, err := Run(context.Background(), machine, Options{Time: 10 * time.Millisecond})
+	require.Nil(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "bench_calls", results[0].Name)
+	require.Equal(t, "bench_loop", results[1].Name)
+	for _, result := range results {
+		require.Greater(t, result.N, 1)
+		require.Greater(t, result.NsPerOp, 0.0)
+	}
+	// The iterations grow until the time is reached, so all runs count
+	calls, err := machine.Get("calls")
+	require.Nil(t, err)
+	require.GreaterOrEqual(t, calls.Interface(), int64(results[0].N))
+}
+
+func TestRunFilter(t *testing.T) {
+	machine := newVM(t, "func bench_a() {}\nfunc bench_b() {}")
+	results, err := Run(context.Background(), machine, Options{
+		Time:   time.Millisecond,
+		Filter: regexp.MustCompile("_b$"),
+	})
+	require.Nil(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "bench_b", results[0].Name)
+}
+
+func TestRunErrors(t *testing.T) {
+	machine := newVM(t, "func bench_fail() { [1][5] }")
+	_, err := Run(context.Background(), machine, Options{Time: time.Millisecond})
+	require.ErrorContains(t, err, "bench_fail: ")
+
+	machine = newVM(t, "func bench_params(a, b) {}")
+	_, err = Run(context.Background(), machine, Options{Time: time.Millisecond})
+	require.EqualError(t, err, "bench_params: benchmark functions take at most one parameter")
+}
+
+func TestResultString(t *testing.T) {
+	result := Result{Name: "bench_x", N: 100, NsPerOp: 1234.4, BytesPerOp: 16, AllocsPerOp: 2}
+	require.Equal(t, "bench_x\t     100\t        1234 ns/op\t      16 B/op\t       2 allocs/op", result.String())
+}
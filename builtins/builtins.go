@@ -128,6 +128,9 @@ func List(ctx context.Context, args ...object.Object) object.Object {
 		}
 		items = append(items, val)
 	}
+	if s, ok := iter.(interface{ Err() error }); ok && s.Err() != nil {
+		return object.NewError(s.Err())
+	}
 	return object.NewList(items)
 }
 
@@ -505,9 +508,16 @@ func Bool(ctx context.Context, args ...object.Object) object.Object {
 }
 
 func Sorted(ctx context.Context, args ...object.Object) object.Object {
-	if err := arg.Require("sorted", 1, args); err != nil {
+	if err := arg.RequireRange("sorted", 1, 2, args); err != nil {
 		return err
 	}
+	var opts object.SortOptions
+	if len(args) == 2 {
+		var err *object.Error
+		if opts, err = object.NewSortOptions(args[1]); err != nil {
+			return err
+		}
+	}
 	arg := args[0]
 	if err := limits.TrackCost(ctx, arg.Cost()); err != nil {
 		return object.NewError(err)
@@ -529,7 +539,7 @@ func Sorted(ctx context.Context, args ...object.Object) object.Object {
 	}
 	resultItems := make([]object.Object, len(items))
 	copy(resultItems, items)
-	if err := object.Sort(resultItems); err != nil {
+	if err := object.SortWith(ctx, resultItems, opts); err != nil {
 		return err
 	}
 	return object.NewList(resultItems)
@@ -564,6 +574,13 @@ func GetAttr(ctx context.Context, args ...object.Object) object.Object {
 		return err
 	}
 	if attr, found := args[0].GetAttr(attrName); found {
+		if resolver, ok := attr.(object.AttrResolver); ok {
+			resolved, err := resolver.ResolveAttr(ctx, attrName)
+			if err != nil {
+				return object.NewError(err)
+			}
+			return resolved
+		}
 		return attr
 	}
 	if len(args) == 3 {
@@ -589,6 +606,9 @@ func Call(ctx context.Context, args ...object.Object) object.Object {
 		}
 		return result
 	case object.Callable:
+		if err := object.CheckCall(ctx, args[0], args[1:]); err != nil {
+			return object.NewError(err)
+		}
 		return fn.Call(ctx, args[1:]...)
 	default:
 		return object.Errorf("type error: call() unsupported argument (%s given)", args[0].Type())
@@ -711,13 +731,38 @@ func Ord(ctx context.Context, args ...object.Object) object.Object {
 	case *object.String:
 		runes := []rune(obj.Value())
 		if len(runes) != 1 {
-			return object.Errorf("value error: ord() expected a character, but string of length %d found", len(obj.Value()))
+			return object.Errorf("value error: ord() expected a character, but string of length %d found", len(runes))
 		}
 		return object.NewInt(int64(runes[0]))
+	case *object.Rune:
+		return object.NewInt(int64(obj.Value()))
 	}
 	return object.Errorf("type error: ord() expected a string of length 1 (%s given)", args[0].Type())
 }
 
+func Rune(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("rune", 1, args); err != nil {
+		return err
+	}
+	switch obj := args[0].(type) {
+	case *object.Rune:
+		return obj
+	case *object.Int:
+		v := obj.Value()
+		if v < 0 || v > unicode.MaxRune {
+			return object.Errorf("value error: rune() argument out of range (%d given)", v)
+		}
+		return object.NewRune(rune(v))
+	case *object.String:
+		runes := []rune(obj.Value())
+		if len(runes) != 1 {
+			return object.Errorf("value error: rune() expected a character, but string of length %d found", len(runes))
+		}
+		return object.NewRune(runes[0])
+	}
+	return object.Errorf("type error: rune() expected an int or a string of length 1 (%s given)", args[0].Type())
+}
+
 func Chr(ctx context.Context, args ...object.Object) object.Object {
 	if err := arg.Require("chr", 1, args); err != nil {
 		return err
@@ -773,6 +818,9 @@ func Try(ctx context.Context, args ...object.Object) object.Object {
 				return result, nil
 			}
 		case object.Callable:
+			if err := object.CheckCall(ctx, arg, nil); err != nil {
+				return nil, err
+			}
 			result := obj.Call(ctx)
 			switch result := result.(type) {
 			case *object.Error:
@@ -851,6 +899,17 @@ func Spawn(ctx context.Context, args ...object.Object) object.Object {
 	return thread
 }
 
+func Stream(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("stream", 1, args); err != nil {
+		return err
+	}
+	iter, err := object.AsIterator(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewIteratorStream(iter)
+}
+
 func Chan(ctx context.Context, args ...object.Object) object.Object {
 	if err := arg.RequireRange("chan", 0, 1, args); err != nil {
 		return err
@@ -958,10 +1017,12 @@ func Builtins() map[string]object.Object {
 		"map":         object.NewBuiltin("map", Map),
 		"ord":         object.NewBuiltin("ord", Ord),
 		"reversed":    object.NewBuiltin("reversed", Reversed),
+		"rune":        object.NewBuiltin("rune", Rune),
 		"set":         object.NewBuiltin("set", Set),
 		"sorted":      object.NewBuiltin("sorted", Sorted),
 		"spawn":       object.NewBuiltin("spawn", Spawn),
 		"sprintf":     object.NewBuiltin("sprintf", Sprintf),
+		"stream":      object.NewBuiltin("stream", Stream),
 		"string":      object.NewBuiltin("string", String),
 		"try":         object.NewBuiltin("try", Try),
 		"type":        object.NewBuiltin("type", Type),
@@ -1,28 +1,57 @@
 package risor
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/rand"
 	"sort"
+	"strings"
 
 	"github.com/risor-io/risor/builtins"
 	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/coverage"
 	"github.com/risor-io/risor/importer"
+	"github.com/risor-io/risor/modfile"
+	modArchive "github.com/risor-io/risor/modules/archive"
 	modBase64 "github.com/risor-io/risor/modules/base64"
 	modBytes "github.com/risor-io/risor/modules/bytes"
+	modCron "github.com/risor-io/risor/modules/cron"
+	modCsv "github.com/risor-io/risor/modules/csv"
 	modDns "github.com/risor-io/risor/modules/dns"
+	modEmail "github.com/risor-io/risor/modules/email"
 	modExec "github.com/risor-io/risor/modules/exec"
 	modFilepath "github.com/risor-io/risor/modules/filepath"
 	modFmt "github.com/risor-io/risor/modules/fmt"
+	modFuzzy "github.com/risor-io/risor/modules/fuzzy"
+	modGeo "github.com/risor-io/risor/modules/geo"
 	modHTTP "github.com/risor-io/risor/modules/http"
+	modIds "github.com/risor-io/risor/modules/ids"
 	modJSON "github.com/risor-io/risor/modules/json"
+	modLog "github.com/risor-io/risor/modules/log"
 	modMath "github.com/risor-io/risor/modules/math"
+	modNet "github.com/risor-io/risor/modules/net"
 	modOs "github.com/risor-io/risor/modules/os"
 	modRand "github.com/risor-io/risor/modules/rand"
+	modRandom "github.com/risor-io/risor/modules/random"
+	modRatelimit "github.com/risor-io/risor/modules/ratelimit"
 	modRegexp "github.com/risor-io/risor/modules/regexp"
+	modResult "github.com/risor-io/risor/modules/result"
+	modRetry "github.com/risor-io/risor/modules/retry"
+	modRuntime "github.com/risor-io/risor/modules/runtime"
+	modShlex "github.com/risor-io/risor/modules/shlex"
+	modSketch "github.com/risor-io/risor/modules/sketch"
+	modStats "github.com/risor-io/risor/modules/stats"
 	modStrconv "github.com/risor-io/risor/modules/strconv"
 	modStrings "github.com/risor-io/risor/modules/strings"
 	modTime "github.com/risor-io/risor/modules/time"
+	modTLS "github.com/risor-io/risor/modules/tls"
 	modYAML "github.com/risor-io/risor/modules/yaml"
 	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+	"github.com/risor-io/risor/profile"
 	"github.com/risor-io/risor/vm"
 )
 
@@ -32,8 +61,45 @@ type Config struct {
 	DefaultGlobals        map[string]object.Object
 	Importer              importer.Importer
 	LocalImportPath       string
+	ImportFS              fs.FS
+	Manifest              *modfile.File
+	Lock                  *importer.Lock
+	TrustedKeys           importer.TrustedKeys
+	Vendor                fs.FS
+	CompileCache          *importer.CompileCache
+	Credentials           importer.CredentialProvider
+	ImportAudit           *importer.AuditImporterOptions
 	WithoutDefaultGlobals bool
 	WithConcurrency       bool
+	HotReload             bool
+	LogHandler            slog.Handler
+	RandSource            rand.Source
+	Clock                 object.Clock
+	Filename              string
+	Coverage              *coverage.Profile
+	Debugger              vm.Debugger
+	Profiler              *profile.Profiler
+	Tracer                *profile.Tracer
+	Stdin                 io.Reader
+	Stdout                io.Writer
+	Stderr                io.Writer
+	Filesystem            ros.FS
+	Env                   map[string]string
+	ErrorHandler          func(report *vm.ErrorReport)
+	ErrorRedactor         func(name string, value object.Object) object.Object
+	ContextValues         map[string]any
+	Hooks                 vm.Hooks
+
+	// Source code of the script evaluated, if known, which error reports
+	// quote
+	source string
+
+	// Writers of WithOutputLines, flushed once evaluation ends
+	lineWriters []*ros.LineWriter
+
+	// The importer built from the options above, shared by the compiler
+	// and the VM
+	defaultImporter importer.Importer
 }
 
 func NewConfig() *Config {
@@ -95,23 +161,48 @@ func (cfg *Config) addDefaultGlobals() {
 	}
 	// Add default modules
 	modules := map[string]object.Object{
-		"base64":   modBase64.Module(),
-		"bytes":    modBytes.Module(),
-		"exec":     modExec.Module(),
-		"filepath": modFilepath.Module(),
-		"fmt":      modFmt.Module(),
-		"http":     modHTTP.Module(),
-		"json":     modJSON.Module(),
-		"math":     modMath.Module(),
-		"os":       modOs.Module(),
-		"rand":     modRand.Module(),
-		"regexp":   modRegexp.Module(),
-		"strconv":  modStrconv.Module(),
-		"strings":  modStrings.Module(),
-		"time":     modTime.Module(),
-		"yaml":     modYAML.Module(),
+		"archive":   modArchive.Module(),
+		"base64":    modBase64.Module(),
+		"bytes":     modBytes.Module(),
+		"cron":      modCron.Module(),
+		"csv":       modCsv.Module(),
+		"dns":       modDns.Module(),
+		"email":     modEmail.Module(),
+		"exec":      modExec.Module(),
+		"filepath":  modFilepath.Module(),
+		"fmt":       modFmt.Module(),
+		"fuzzy":     modFuzzy.Module(),
+		"geo":       modGeo.Module(),
+		"http":      modHTTP.Module(),
+		"ids":       modIds.Module(),
+		"json":      modJSON.Module(),
+		"log":       modLog.Module(),
+		"math":      modMath.Module(),
+		"net":       modNet.Module(),
+		"os":        modOs.Module(),
+		"rand":      modRand.Module(),
+		"random":    modRandom.Module(),
+		"ratelimit": modRatelimit.Module(),
+		"regexp":    modRegexp.Module(),
+		"result":    modResult.Module(),
+		"retry":     modRetry.Module(),
+		"runtime":   modRuntime.Module(),
+		"shlex":     modShlex.Module(),
+		"sketch":    modSketch.Module(),
+		"stats":     modStats.Module(),
+		"strconv":   modStrconv.Module(),
+		"strings":   modStrings.Module(),
+		"time":      modTime.Module(),
+		"tls":       modTLS.Module(),
+		"yaml":      modYAML.Module(),
 	}
 	addGlobals(modules)
+	// Add the modules of registered providers
+	for _, provider := range ModuleProviders() {
+		if module := provider.Module(); module != nil {
+			cfg.DefaultGlobals[provider.Name()] = module
+		}
+	}
 }
 
 // CompilerOpts returns compiler options derived from this configuration.
@@ -121,9 +212,59 @@ func (cfg *Config) CompilerOpts() []compiler.Option {
 	if len(globalNames) > 0 {
 		opts = append(opts, compiler.WithGlobalNames(globalNames))
 	}
+	opts = append(opts, compiler.WithExports(cfg.moduleExports))
 	return opts
 }
 
+// moduleExports returns the names of the attributes of a module, which are
+// what a wildcard import of the module binds.
+func (cfg *Config) moduleExports(name string) ([]string, error) {
+	var module *object.Module
+	switch global := cfg.CombinedGlobals()[name].(type) {
+	case *object.Module:
+		module = global
+	default:
+		im := cfg.getImporter()
+		if im == nil {
+			return nil, fmt.Errorf("exec error: imports are disabled")
+		}
+		var err error
+		if module, err = im.Import(context.Background(), name); err != nil {
+			return nil, err
+		}
+	}
+	return module.AttributeNames(), nil
+}
+
+// getImporter returns the importer to use for modules, which is either the
+// configured Importer or one built from the other import options, audited
+// when ImportAudit is set.
+func (cfg *Config) getImporter() importer.Importer {
+	if cfg.defaultImporter != nil {
+		return cfg.defaultImporter
+	}
+	im := cfg.Importer
+	if im == nil && (cfg.LocalImportPath != "" || cfg.ImportFS != nil || cfg.Manifest != nil) {
+		names := cfg.GlobalNames()
+		if cfg.ImportFS != nil {
+			im = newFSImporter(names, cfg.ImportFS, cfg.CompileCache)
+		} else if cfg.LocalImportPath != "" {
+			im = newLocalImporter(names, cfg.LocalImportPath, cfg.CompileCache)
+		}
+		if cfg.Manifest != nil {
+			im = newProjectImporter(names, im, cfg)
+		}
+	}
+	if im == nil {
+		return nil
+	}
+	if cfg.ImportAudit != nil {
+		im = importer.NewAuditImporter(im, *cfg.ImportAudit)
+	}
+	cfg.defaultImporter = im
+	return im
+}
+
 // VMOpts returns virtual machine options derived from this configuration.
 func (cfg *Config) VMOpts() []vm.Option {
 	var opts []vm.Option
@@ -131,27 +272,138 @@ func (cfg *Config) VMOpts() []vm.Option {
 	if len(combinedGlobals) > 0 {
 		opts = append(opts, vm.WithGlobals(combinedGlobals))
 	}
-	importer := cfg.Importer
-	if importer == nil && cfg.LocalImportPath != "" {
-		var names []string
-		for name := range combinedGlobals {
-			names = append(names, name)
-		}
-		importer = newLocalImporter(names, cfg.LocalImportPath)
-	}
-	if importer != nil {
-		opts = append(opts, vm.WithImporter(importer))
+	if im := cfg.getImporter(); im != nil {
+		opts = append(opts, vm.WithImporter(im))
 	}
 	if cfg.WithConcurrency {
 		opts = append(opts, vm.WithConcurrency())
 	}
+	if cfg.HotReload {
+		opts = append(opts, vm.WithHotReload())
+	}
+	if cfg.LogHandler != nil {
+		opts = append(opts, vm.WithLogHandler(cfg.LogHandler))
+	}
+	if cfg.RandSource != nil {
+		opts = append(opts, vm.WithRandSource(cfg.RandSource))
+	}
+	if cfg.Clock != nil {
+		opts = append(opts, vm.WithClock(cfg.Clock))
+	}
+	if cfg.Coverage != nil {
+		opts = append(opts, vm.WithCoverage(cfg.Coverage))
+	}
+	if cfg.Debugger != nil {
+		opts = append(opts, vm.WithDebugger(cfg.Debugger))
+	}
+	if cfg.Profiler != nil {
+		opts = append(opts, vm.WithProfiler(cfg.Profiler))
+	}
+	if cfg.Tracer != nil {
+		opts = append(opts, vm.WithTracer(cfg.Tracer))
+	}
+	if cfg.Stdin != nil {
+		opts = append(opts, vm.WithStdin(cfg.Stdin))
+	}
+	if cfg.Stdout != nil {
+		opts = append(opts, vm.WithStdout(cfg.Stdout))
+	}
+	if cfg.Stderr != nil {
+		opts = append(opts, vm.WithStderr(cfg.Stderr))
+	}
+	if cfg.Filesystem != nil {
+		opts = append(opts, vm.WithFilesystem(cfg.Filesystem))
+	}
+	if cfg.Env != nil {
+		opts = append(opts, vm.WithEnv(cfg.Env))
+	}
+	if cfg.ErrorHandler != nil {
+		opts = append(opts, vm.WithErrorHandler(cfg.reportError))
+	}
+	if cfg.ContextValues != nil {
+		opts = append(opts, vm.WithContextGlobals(cfg.contextGlobals))
+	}
+	if cfg.Hooks != nil {
+		opts = append(opts, vm.WithHooks(cfg.Hooks))
+	}
 	return opts
 }
 
-func newLocalImporter(globalNames []string, sourceDir string) importer.Importer {
+// flushOutput passes the final lines written by the evaluation, if they had
+// no line ending, to the function of WithOutputLines.
+func (cfg *Config) flushOutput() {
+	for _, w := range cfg.lineWriters {
+		w.Flush()
+	}
+}
+
+// contextGlobals returns the ctx global of a run or call, holding the values
+// of its context for the keys of WithContextValues.
+func (cfg *Config) contextGlobals(ctx context.Context) (map[string]object.Object, error) {
+	raw := make(map[string]any, len(cfg.ContextValues))
+	for name, key := range cfg.ContextValues {
+		if value := ctx.Value(key); value != nil {
+			raw[name] = value
+		}
+	}
+	values, err := object.AsObjects(raw)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]object.Object{"ctx": newContextModule(values)}, nil
+}
+
+func newContextModule(values map[string]object.Object) *object.Module {
+	return object.NewBuiltinsModule("ctx", map[string]object.Object{
+		"values": object.NewMap(values),
+	})
+}
+
+// reportError passes an error report to the error handler, with its globals
+// redacted and, when the error was raised in the script evaluated rather
+// than in a module, the line of source it was raised at.
+func (cfg *Config) reportError(report *vm.ErrorReport) {
+	if cfg.ErrorRedactor != nil {
+		for name, value := range report.Globals {
+			if redacted := cfg.ErrorRedactor(name, value); redacted != nil {
+				report.Globals[name] = redacted
+			} else {
+				delete(report.Globals, name)
+			}
+		}
+	}
+	span := &report.Span
+	if cfg.source != "" && span.File == cfg.Filename && span.Location.Line > 0 {
+		lines := strings.Split(cfg.source, "\n")
+		if span.Location.Line <= len(lines) {
+			span.Text = strings.TrimSuffix(lines[span.Location.Line-1], "\r")
+		}
+	}
+	cfg.ErrorHandler(report)
+}
+
+func newLocalImporter(globalNames []string, sourceDir string, cache *importer.CompileCache) importer.Importer {
 	return importer.NewLocalImporter(importer.LocalImporterOptions{
-		GlobalNames: globalNames,
-		SourceDir:   sourceDir,
-		Extensions:  []string{".risor", ".rsr"},
+		GlobalNames:  globalNames,
+		SourceDir:    sourceDir,
+		Extensions:   []string{".risor", ".rsr"},
+		CompileCache: cache,
 	})
 }
+
+func newProjectImporter(globalNames []string, local importer.Importer, cfg *Config) importer.Importer {
+	return importer.NewProjectImporter(importer.ProjectImporterOptions{
+		GlobalNames:  globalNames,
+		Local:        local,
+		Manifest:     cfg.Manifest,
+		Lock:         cfg.Lock,
+		TrustedKeys:  cfg.TrustedKeys,
+		Vendor:       cfg.Vendor,
+		CompileCache: cfg.CompileCache,
+		Credentials:  cfg.Credentials,
+	})
+}
+
+func newFSImporter(globalNames []string, fsys fs.FS, cache *importer.CompileCache) importer.Importer {
+	return importer.NewFSImporter(fsys, importer.WithGlobalNames(globalNames), importer.WithCompileCache(cache))
+}
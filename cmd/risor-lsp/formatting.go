@@ -2,10 +2,34 @@ package main
 
 import (
 	"context"
+	"strings"
 
 	"github.com/jdbaldry/go-language-server-protocol/lsp/protocol"
+	"github.com/risor-io/risor/format"
+	"github.com/rs/zerolog/log"
 )
 
 func (s *Server) Formatting(ctx context.Context, params *protocol.DocumentFormattingParams) ([]protocol.TextEdit, error) {
-	return nil, nil
+	doc, err := s.cache.get(params.TextDocument.URI)
+	if err != nil {
+		log.Error().Err(err).Str("call", "Formatting").Msg("failed to get document")
+		return nil, nil
+	}
+	text := doc.item.Text
+	out, err := format.Source(ctx, []byte(text))
+	if err != nil {
+		log.Error().Err(err).Str("call", "Formatting").Msg("document has error")
+		return nil, nil
+	}
+	if string(out) == text {
+		return nil, nil
+	}
+	// Replace the whole document, with an end past its last line
+	return []protocol.TextEdit{{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: 0, Character: 0},
+			End:   protocol.Position{Line: uint32(strings.Count(text, "\n") + 1), Character: 0},
+		},
+		NewText: string(out),
+	}}, nil
 }
@@ -19,7 +19,28 @@ func (s *Server) queueDiagnostics(uri protocol.DocumentURI) {}
 
 func (s *Server) DidChange(ctx context.Context, params *protocol.DidChangeTextDocumentParams) error {
 	defer s.queueDiagnostics(params.TextDocument.URI)
-	return nil
+	if len(params.ContentChanges) == 0 {
+		return nil
+	}
+	old, err := s.cache.get(params.TextDocument.URI)
+	if err != nil {
+		return err
+	}
+	// Documents are synced in full, so the last change holds the new text
+	item := old.item
+	item.Version = params.TextDocument.Version
+	item.Text = params.ContentChanges[len(params.ContentChanges)-1].Text
+	doc := &document{
+		item:                 item,
+		ast:                  old.ast,
+		linesChangedSinceAST: map[int]bool{},
+	}
+	if ast, err := parser.Parse(ctx, item.Text); err != nil {
+		doc.err = err
+	} else {
+		doc.ast = ast
+	}
+	return s.cache.put(doc)
 }
 
 func (s *Server) DidOpen(ctx context.Context, params *protocol.DidOpenTextDocumentParams) (err error) {
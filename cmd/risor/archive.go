@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/importer"
+)
+
+// isArchive returns true if the path is a zip archive bundling a project.
+func isArchive(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".zip", ".rbundle", importer.BundleExtension:
+		return true
+	}
+	return false
+}
+
+// openArchive opens the archive at the path, and returns an importer of its
+// modules, which are compiled with the global names given by the options.
+func openArchive(path string, opts []risor.Option) (*importer.ArchiveImporter, error) {
+	cfg := risor.NewConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return importer.OpenArchive(path, importer.WithGlobalNames(cfg.GlobalNames()))
+}
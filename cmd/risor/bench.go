@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/bench"
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/vm"
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench [path ...]",
+	Short: "Run the benchmark functions of scripts",
+	Long: `Run the benchmark functions of scripts, reporting the time and the memory
+allocated per iteration.
+
+Benchmark functions are the global functions whose names start with
+"bench_". A function without parameters is called once per iteration. A
+function with one parameter is called once with the number of iterations,
+and runs the code being measured that many times, so it can exclude its
+setup from the measurement. The number of iterations grows until each
+benchmark runs for at least --benchtime.
+
+Each path is a script, or a directory whose *_bench.risor and *_bench.rsr
+files are run, skipping hidden and vendor directories. Without a path, the
+current directory is searched.`,
+	Example: `  risor bench
+  risor bench --bench 'sort' --benchtime 3s lib/sort_bench.risor
+  risor bench --format json > new.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		pattern, _ := cmd.Flags().GetString("bench")
+		benchTime, _ := cmd.Flags().GetDuration("benchtime")
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			fatal(red("unknown format: %s", format))
+		}
+		opts := bench.Options{Time: benchTime}
+		if pattern != "" {
+			filter, err := regexp.Compile(pattern)
+			if err != nil {
+				fatal(red("invalid --bench: %s", err))
+			}
+			opts.Filter = filter
+		}
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		var files []string
+		for _, path := range args {
+			found, err := benchFiles(path)
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			files = append(files, found...)
+		}
+		type fileResults struct {
+			File    string         `json:"file"`
+			Results []bench.Result `json:"results"`
+		}
+		all := []fileResults{}
+		var failed bool
+		for _, file := range files {
+			results, err := benchFile(cmd, file, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", red("%s: %s", file, err))
+				failed = true
+				continue
+			}
+			all = append(all, fileResults{File: file, Results: results})
+			if format == "text" {
+				fmt.Println(file)
+				w := tabwriter.NewWriter(os.Stdout, 0, 8, 1, ' ', 0)
+				for _, result := range results {
+					fmt.Fprintln(w, result.String())
+				}
+				w.Flush()
+			}
+		}
+		if format == "json" {
+			out, err := json.MarshalIndent(all, "", "  ")
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			fmt.Println(string(out))
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	benchCmd.Flags().String("bench", "", "Run only the benchmarks whose names match this regular expression")
+	benchCmd.Flags().Duration("benchtime", bench.DefaultTime, "Minimum time to run each benchmark for")
+	benchCmd.Flags().String("format", "text", "Output format: text or json")
+}
+
+// benchFile runs the benchmarks of a script.
+func benchFile(cmd *cobra.Command, file string, opts bench.Options) ([]bench.Result, error) {
+	ctx := context.Background()
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	importOpts, projectDir, lock, err := importOptions(cmd, file)
+	if err != nil {
+		return nil, err
+	}
+	cfg := risor.NewConfig()
+	for _, opt := range append(globalOptions(), importOpts...) {
+		opt(cfg)
+	}
+	program, err := parser.Parse(ctx, string(src), parser.WithFile(file))
+	if err != nil {
+		return nil, err
+	}
+	main, err := compiler.Compile(program, cfg.CompilerOpts()...)
+	if err != nil {
+		return nil, err
+	}
+	results, err := bench.Run(ctx, vm.New(main, cfg.VMOpts()...), opts)
+	if lock != nil {
+		if err := saveLock(projectDir, lock); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+		}
+	}
+	return results, err
+}
+
+// benchFiles returns the path, if it's a file, or the benchmark files in
+// the directory.
+func benchFiles(path string) ([]string, error) {
+	found, err := sourceFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 1 && found[0] == path {
+		return found, nil
+	}
+	var files []string
+	for _, file := range found {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if strings.HasSuffix(name, "_bench") {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
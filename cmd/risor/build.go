@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/importer"
+	ros "github.com/risor-io/risor/os"
+	"github.com/spf13/cobra"
+)
+
+var buildCmd = &cobra.Command{
+	Use:   "build <script>",
+	Short: "Build a standalone executable from a script",
+	Long: `Build a standalone executable that runs a script, so that tools written in
+Risor can be distributed to machines without Risor or the project.
+
+The script and the modules it imports are bundled as with risor bundle, and
+the bundle is appended to a copy of the Risor executable, which runs it when
+started, passing it all its arguments. The executable targets the platform
+of the Risor executable used, which is this one unless --stub gives another,
+such as a Risor release for a different operating system.`,
+	Example: `  risor build main.risor -o mytool
+  risor build main.risor -o mytool.exe --stub ./risor-windows-amd64.exe`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		script := args[0]
+		source, err := os.ReadFile(script)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		stub, _ := cmd.Flags().GetString("stub")
+		output, _ := cmd.Flags().GetString("output")
+		if stub == "" {
+			if stub, err = os.Executable(); err != nil {
+				fatal(red(err.Error()))
+			}
+		}
+		if output == "" {
+			output = strings.TrimSuffix(filepath.Base(script), filepath.Ext(script))
+			if strings.EqualFold(filepath.Ext(stub), ".exe") || (runtime.GOOS == "windows" && !cmd.Flags().Changed("stub")) {
+				output += ".exe"
+			}
+		}
+		precompile, _ := cmd.Flags().GetBool("precompile")
+		opts := globalOptions()
+		importOpts, projectDir, lock, err := importOptions(cmd, script)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		opts = append(opts, importOpts...)
+		var bundle bytes.Buffer
+		err = risor.Bundle(cmd.Context(), &bundle, string(source), precompile, opts...)
+		if lock != nil {
+			if err := saveLock(projectDir, lock); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+			}
+		}
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		var exe bytes.Buffer
+		if err := importer.WriteExecutable(&exe, stub, bundle.Bytes()); err != nil {
+			fatal(red(err.Error()))
+		}
+		if err := os.WriteFile(output, exe.Bytes(), 0o755); err != nil {
+			fatal(red(err.Error()))
+		}
+		fmt.Printf("built %s into %s\n", script, output)
+	},
+}
+
+func init() {
+	buildCmd.Flags().StringP("output", "o", "", "Path of the executable (default is the script name without its extension)")
+	buildCmd.Flags().String("stub", "", "Risor executable to build from (default is this one)")
+	buildCmd.Flags().Bool("precompile", false, "Bundle the project's modules as compiled code")
+}
+
+// runExecutable runs the bundle appended to the executable by risor build,
+// passing it the command line arguments. Unlike risor run, the result of
+// the script isn't printed, as a standalone program reports what it needs
+// to itself.
+func runExecutable(path string) {
+	ctx := context.Background()
+	ros.SetScriptArgs(os.Args[1:])
+	opts := append(globalOptions(), risor.WithConcurrency())
+	cfg := risor.NewConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	archive, err := importer.OpenExecutable(path, importer.WithGlobalNames(cfg.GlobalNames()))
+	if err != nil {
+		fatal(red(err.Error()))
+	}
+	defer archive.Close()
+	opts = append(opts, risor.WithImporter(archive))
+	mainCode, source, err := archive.Main()
+	if err != nil {
+		fatal(red(err.Error()))
+	}
+	if mainCode != nil {
+		_, err = risor.EvalCode(ctx, mainCode, opts...)
+	} else {
+		_, err = risor.Eval(ctx, source, opts...)
+	}
+	if err != nil {
+		printError(os.Stderr, err, newErrorSources("", source))
+		archive.Close()
+		os.Exit(1)
+	}
+}
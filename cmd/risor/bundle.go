@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/importer"
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle <script>",
+	Short: "Bundle a script and the modules it imports into a single file",
+	Long: `Bundle a script and every module it imports, including remote modules,
+into a single file that runs without the project or network access:
+
+  risor run app.rsb
+
+The project's own modules are bundled as source code, unless --precompile
+is given, and remote modules are bundled precompiled. Precompiled bundles
+start faster, but only run with the version of Risor that created them.`,
+	Example: `  risor bundle main.risor -o app.rsb
+  risor bundle tools/deploy.risor -o deploy.rsb --precompile`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		script := args[0]
+		source, err := os.ReadFile(script)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			output = strings.TrimSuffix(filepath.Base(script), filepath.Ext(script)) + importer.BundleExtension
+		}
+		precompile, _ := cmd.Flags().GetBool("precompile")
+		opts := globalOptions()
+		importOpts, projectDir, lock, err := importOptions(cmd, script)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		opts = append(opts, importOpts...)
+		var buf bytes.Buffer
+		err = risor.Bundle(cmd.Context(), &buf, string(source), precompile, opts...)
+		if lock != nil {
+			if err := saveLock(projectDir, lock); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+			}
+		}
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		if err := os.WriteFile(output, buf.Bytes(), 0o644); err != nil {
+			fatal(red(err.Error()))
+		}
+		fmt.Printf("bundled %s into %s\n", script, output)
+	},
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <script> [args...]",
+	Short: "Run a script, or a bundle created by risor bundle",
+	Example: `  risor run main.risor
+  risor run app.rsb -- --verbose`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runScript,
+}
+
+func init() {
+	bundleCmd.Flags().StringP("output", "o", "", "Path of the bundle (default is the script name with a .rsb extension)")
+	bundleCmd.Flags().Bool("precompile", false, "Bundle the project's modules as compiled code")
+	runCmd.Flags().SetInterspersed(false)
+}
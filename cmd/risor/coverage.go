@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor/coverage"
+)
+
+// writeCoverage writes the coverage report of a run to the given path, as
+// HTML if the path ends in .html, or else as an LCOV tracefile, and prints a
+// summary to stderr.
+func writeCoverage(path string, profile *coverage.Profile) error {
+	files := profile.Files()
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".html") {
+		err = coverage.WriteHTML(f, files)
+	} else {
+		err = coverage.WriteLCOV(f, files)
+	}
+	if err != nil {
+		return err
+	}
+	if err := coverage.WriteSummary(os.Stderr, files); err != nil {
+		return err
+	}
+	return f.Close()
+}
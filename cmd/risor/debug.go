@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+	"github.com/risor-io/risor/vm"
+	"github.com/spf13/cobra"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug <script> [-- args]",
+	Short: "Debug a script interactively",
+	Long: `Run a script under a debugger that pauses at its first line, at breakpoints
+and while stepping, to inspect its variables and call stack.
+
+Commands, which may be abbreviated as shown, are:
+
+  break (b) [file:]line [if expr]  Set a breakpoint, which pauses only when
+                                   the condition holds if one is given
+  delete (d) <id>                  Delete a breakpoint
+  info                             List the breakpoints
+  continue (c)                     Run until a breakpoint
+  step (s)                         Run to the next line, entering calls
+  next (n)                         Run to the next line, stepping over calls
+  finish (f)                       Run until the current function returns
+  print (p) <expr>                 Evaluate an expression in the current frame
+  locals                           Show the local variables of the frame
+  bt                               Show the call stack
+  list (l)                         Show the source around the current line
+  quit (q)                         Stop the script
+
+An empty command repeats the last one.`,
+	Example: `  risor debug main.risor
+  risor debug -b lib/util.risor:12 main.risor -- --verbose`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		args, passedargs, _ := getpassthruargs(args)
+		if len(args) == 0 {
+			fatal(red("no script given"))
+		}
+		script := args[0]
+		ros.SetScriptArgs(passedargs)
+		src, err := os.ReadFile(script)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		d := newDebugger(os.Stdin, os.Stderr)
+		breaks, _ := cmd.Flags().GetStringArray("break")
+		for _, spec := range breaks {
+			if err := d.addBreakpoint(spec, script); err != nil {
+				fatal(red(err.Error()))
+			}
+		}
+		importOpts, projectDir, lock, err := importOptions(cmd, script)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		opts := append(globalOptions(), importOpts...)
+		opts = append(opts,
+			risor.WithConcurrency(),
+			risor.WithFilename(script),
+			risor.WithDebugger(d))
+		result, err := risor.Eval(ctx, string(src), opts...)
+		if lock != nil {
+			if err := saveLock(projectDir, lock); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+			}
+		}
+		// Errors raised in calls made by builtins, such as list.map, may
+		// not wrap the one the debugger stopped with
+		if d.quit {
+			return
+		}
+		if err != nil {
+			printError(os.Stderr, err, newErrorSources(script, string(src)))
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "script finished: %s\n", result.Inspect())
+	},
+}
+
+func init() {
+	debugCmd.Flags().StringArrayP("break", "b", nil, "Set a breakpoint at [file:]line before starting")
+}
+
+// errDebugQuit stops a script being debugged.
+var errDebugQuit = errors.New("quit")
+
+type stepMode int
+
+const (
+	// Run until a breakpoint
+	modeContinue stepMode = iota
+
+	// Pause at the next line, in any frame
+	modeStep
+
+	// Pause at the next line of the frame or its callers
+	modeNext
+
+	// Pause at the next line of a caller
+	modeFinish
+)
+
+type breakpoint struct {
+	id        int
+	file      string
+	line      int
+	condition string
+}
+
+// debugger implements vm.Debugger with a command prompt.
+type debugger struct {
+	in          *bufio.Scanner
+	out         io.Writer
+	breakpoints []*breakpoint
+	lastID      int
+	mode        stepMode
+
+	// Frame count when stepping started
+	depth int
+
+	// The last command, which an empty command repeats
+	last string
+
+	// Lines of the source files shown
+	sources map[string][]string
+
+	// Set once the script is stopped
+	quit bool
+}
+
+func newDebugger(in io.Reader, out io.Writer) *debugger {
+	return &debugger{
+		in:      bufio.NewScanner(in),
+		out:     out,
+		mode:    modeStep,
+		sources: map[string][]string{},
+	}
+}
+
+// Line pauses at breakpoints and while stepping, reading commands until
+// one resumes execution.
+func (d *debugger) Line(ctx context.Context, machine *vm.VirtualMachine) error {
+	// The error may have been caught, as with try
+	if d.quit {
+		return errDebugQuit
+	}
+	frames := machine.Frames()
+	if len(frames) == 0 {
+		return nil
+	}
+	top := frames[0]
+	var pause bool
+	switch d.mode {
+	case modeStep:
+		pause = true
+	case modeNext:
+		pause = len(frames) <= d.depth
+	case modeFinish:
+		pause = len(frames) < d.depth
+	}
+	for _, bp := range d.breakpoints {
+		if pause || bp.line != top.Location.Line || !sameFile(bp.file, top.File) {
+			continue
+		}
+		if bp.condition != "" {
+			value, err := d.eval(ctx, machine, top, bp.condition)
+			if err != nil {
+				fmt.Fprintf(d.out, "breakpoint %d: condition failed: %s\n", bp.id, err)
+			} else if !value.IsTruthy() {
+				continue
+			}
+		}
+		fmt.Fprintf(d.out, "breakpoint %d\n", bp.id)
+		pause = true
+	}
+	if !pause {
+		return nil
+	}
+	d.mode = modeContinue
+	d.list(top, 0)
+	return d.prompt(ctx, machine, frames)
+}
+
+// prompt runs commands until one resumes execution.
+func (d *debugger) prompt(ctx context.Context, machine *vm.VirtualMachine, frames []vm.Frame) error {
+	top := frames[0]
+	for {
+		fmt.Fprint(d.out, "(risor) ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			d.quit = true
+			return errDebugQuit
+		}
+		line := strings.TrimSpace(d.in.Text())
+		if line == "" {
+			line = d.last
+		}
+		d.last = line
+		name, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+		switch name {
+		case "":
+		case "c", "continue":
+			d.mode = modeContinue
+			return nil
+		case "s", "step":
+			d.mode = modeStep
+			return nil
+		case "n", "next":
+			d.mode = modeNext
+			d.depth = len(frames)
+			return nil
+		case "f", "finish":
+			d.mode = modeFinish
+			d.depth = len(frames)
+			return nil
+		case "b", "break":
+			if err := d.addBreakpoint(arg, top.File); err != nil {
+				fmt.Fprintln(d.out, err)
+			}
+		case "d", "delete":
+			if err := d.deleteBreakpoint(arg); err != nil {
+				fmt.Fprintln(d.out, err)
+			}
+		case "info":
+			if len(d.breakpoints) == 0 {
+				fmt.Fprintln(d.out, "no breakpoints")
+			}
+			for _, bp := range d.breakpoints {
+				fmt.Fprintf(d.out, "%d\t%s:%d", bp.id, bp.file, bp.line)
+				if bp.condition != "" {
+					fmt.Fprintf(d.out, " if %s", bp.condition)
+				}
+				fmt.Fprintln(d.out)
+			}
+		case "p", "print":
+			if arg == "" {
+				fmt.Fprintln(d.out, "usage: print <expr>")
+				break
+			}
+			value, err := d.eval(ctx, machine, top, arg)
+			if err != nil {
+				fmt.Fprintln(d.out, err)
+				break
+			}
+			fmt.Fprintln(d.out, value.Inspect())
+		case "locals":
+			names := make([]string, 0, len(top.Locals))
+			for name := range top.Locals {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Fprintf(d.out, "%s = %s\n", name, top.Locals[name].Inspect())
+			}
+		case "bt", "backtrace":
+			for i, frame := range frames {
+				name := frame.Name
+				if name == "" {
+					name = "<main>"
+				}
+				fmt.Fprintf(d.out, "#%d %s at %s\n", i, name, location(frame))
+			}
+		case "l", "list":
+			d.list(top, 5)
+		case "q", "quit":
+			d.quit = true
+			return errDebugQuit
+		case "h", "help":
+			fmt.Fprintln(d.out, "commands: break, delete, info, continue, step, next, finish, print, locals, bt, list, quit")
+		default:
+			fmt.Fprintf(d.out, "unknown command: %s\n", name)
+		}
+	}
+}
+
+// addBreakpoint adds a breakpoint given as "[file:]line [if expr]", where
+// the file defaults to the one given.
+func (d *debugger) addBreakpoint(spec, file string) error {
+	spec, condition, _ := strings.Cut(spec, " if ")
+	spec = strings.TrimSpace(spec)
+	lineText := spec
+	if i := strings.LastIndex(spec, ":"); i >= 0 {
+		file, lineText = spec[:i], spec[i+1:]
+	}
+	line, err := strconv.Atoi(lineText)
+	if err != nil || line < 1 {
+		return fmt.Errorf("invalid breakpoint: %q (expected [file:]line)", spec)
+	}
+	d.lastID++
+	bp := &breakpoint{
+		id:        d.lastID,
+		file:      file,
+		line:      line,
+		condition: strings.TrimSpace(condition),
+	}
+	d.breakpoints = append(d.breakpoints, bp)
+	fmt.Fprintf(d.out, "breakpoint %d at %s:%d\n", bp.id, bp.file, bp.line)
+	return nil
+}
+
+func (d *debugger) deleteBreakpoint(arg string) error {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("usage: delete <id>")
+	}
+	for i, bp := range d.breakpoints {
+		if bp.id == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("no breakpoint %d", id)
+}
+
+// eval evaluates an expression with the globals of the VM and the locals of
+// the frame.
+func (d *debugger) eval(ctx context.Context, machine *vm.VirtualMachine, frame vm.Frame, expr string) (object.Object, error) {
+	globals := map[string]any{}
+	for _, name := range machine.GlobalNames() {
+		if value, err := machine.Get(name); err == nil && value != nil {
+			globals[name] = value
+		}
+	}
+	for name, value := range frame.Locals {
+		globals[name] = value
+	}
+	return risor.Eval(ctx, expr, risor.WithoutDefaultGlobals(), risor.WithGlobals(globals))
+}
+
+// list shows the current line of the frame and the given number of lines
+// around it.
+func (d *debugger) list(frame vm.Frame, context int) {
+	fmt.Fprintf(d.out, "> %s\n", location(frame))
+	lines, ok := d.sources[frame.File]
+	if !ok {
+		if data, err := os.ReadFile(frame.File); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		d.sources[frame.File] = lines
+	}
+	current := frame.Location.Line
+	for n := max(current-context, 1); n <= min(current+context, len(lines)); n++ {
+		marker := " "
+		if n == current {
+			marker = ">"
+		}
+		fmt.Fprintf(d.out, "%s %4d  %s\n", marker, n, lines[n-1])
+	}
+}
+
+// location formats the file and line of a frame.
+func location(frame vm.Frame) string {
+	file := frame.File
+	if file == "" {
+		file = "<unknown>"
+	}
+	if frame.Location.Line == 0 {
+		return file
+	}
+	return fmt.Sprintf("%s:%d", file, frame.Location.Line)
+}
+
+// sameFile reports whether a breakpoint's file names the given file, which
+// it may do with a shorter path.
+func sameFile(spec, file string) bool {
+	if file == "" {
+		return false
+	}
+	if a, err := filepath.Abs(spec); err == nil {
+		if b, err := filepath.Abs(file); err == nil && a == b {
+			return true
+		}
+	}
+	spec, file = filepath.Clean(spec), filepath.Clean(file)
+	return file == spec || strings.HasSuffix(file, string(filepath.Separator)+spec)
+}
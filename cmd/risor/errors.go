@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/fatih/color"
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/vm"
+)
+
+// Lines of source shown before and after the line of an error
+const errorContext = 2
+
+var (
+	errorHeader = color.New(color.FgRed, color.Bold).SprintFunc()
+	errorCaret  = color.New(color.FgRed, color.Bold).SprintFunc()
+	errorGutter = color.New(color.FgBlue).SprintFunc()
+	errorFaint  = color.New(color.Faint).SprintFunc()
+)
+
+// errorSources finds the source of the files named by errors. The script
+// run, which may have no file name, has its source given; others are read
+// from disk.
+type errorSources struct {
+	script string
+	source string
+	files  map[string][]string
+}
+
+func newErrorSources(script, source string) *errorSources {
+	return &errorSources{script: script, source: source, files: map[string][]string{}}
+}
+
+// lines returns the lines of the named file, or nil if it can't be read.
+func (s *errorSources) lines(file string) []string {
+	if lines, ok := s.files[file]; ok {
+		return lines
+	}
+	var text string
+	if file == s.script || (s.script != "" && sameFile(s.script, file)) {
+		text = s.source
+	} else if data, err := os.ReadFile(file); err == nil {
+		text = string(data)
+	}
+	var lines []string
+	if text != "" {
+		lines = strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	}
+	s.files[file] = lines
+	return lines
+}
+
+// printError writes an error with the source it points at and, for errors
+// raised while running, the call stack. Other errors are written as their
+// message.
+func printError(w io.Writer, err error, sources *errorSources) {
+	var parseErr parser.ParserError
+	var runtimeErr *vm.RuntimeError
+	switch {
+	case errors.As(err, &parseErr):
+		start, end := parseErr.StartPosition(), parseErr.EndPosition()
+		endColumn := start.ColumnNumber()
+		if end.LineNumber() == start.LineNumber() && end.ColumnNumber() > endColumn {
+			endColumn = end.ColumnNumber()
+		}
+		fmt.Fprintln(w, errorHeader(parseErr.Error()))
+		writeExcerpt(w, sources, parseErr.File(), start.LineNumber(), start.ColumnNumber(), endColumn)
+	case errors.As(err, &runtimeErr):
+		fmt.Fprintln(w, errorHeader(err.Error()))
+		stack := runtimeErr.Stack()
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[0]
+		writeExcerpt(w, sources, top.File, top.Location.Line, top.Location.Column, 0)
+		fmt.Fprintf(w, "\n%s\n", errorFaint("stack trace:"))
+		for _, frame := range stack {
+			where := frameLocation(frame)
+			if frame.Name == "" {
+				fmt.Fprintf(w, "  at %s\n", where)
+			} else {
+				fmt.Fprintf(w, "  at %s %s\n", frame.Name, errorFaint("("+where+")"))
+			}
+		}
+	default:
+		if friendlyErr, ok := err.(errz.FriendlyError); ok {
+			fmt.Fprintln(w, red(friendlyErr.FriendlyErrorMessage()))
+		} else {
+			fmt.Fprintln(w, red(err.Error()))
+		}
+	}
+}
+
+// writeExcerpt writes the location of an error and the source around it,
+// underlining the columns from start to end, or, if end is zero, the word
+// at start.
+func writeExcerpt(w io.Writer, sources *errorSources, file string, line, start, end int) {
+	if line < 1 {
+		return
+	}
+	name := file
+	if name == "" {
+		name = "<code>"
+	}
+	fmt.Fprintf(w, "  %s %s:%d:%d\n", errorGutter("-->"), filepath.ToSlash(name), line, start)
+	lines := sources.lines(file)
+	if line > len(lines) {
+		return
+	}
+	first, last := max(line-errorContext, 1), min(line+errorContext, len(lines))
+	width := len(fmt.Sprint(last))
+	gutter := func(label string) string {
+		return errorGutter(fmt.Sprintf("%*s |", width, label))
+	}
+	fmt.Fprintln(w, gutter(""))
+	for n := first; n <= last; n++ {
+		text := lines[n-1]
+		if n != line {
+			fmt.Fprintf(w, "%s %s\n", gutter(fmt.Sprint(n)), errorFaint(text))
+			continue
+		}
+		fmt.Fprintf(w, "%s %s\n", gutter(fmt.Sprint(n)), text)
+		fmt.Fprintf(w, "%s %s\n", gutter(""), underline(text, start, end))
+	}
+}
+
+// underline returns the line placed under source text to mark the columns
+// from start to end, or the word at start if end is zero. Tabs are kept so
+// the marks line up.
+func underline(text string, start, end int) string {
+	runes := []rune(text)
+	start = min(max(start, 1), len(runes)+1)
+	if end < start {
+		end = start
+		for end < len(runes) && isWordRune(runes[end-1]) && isWordRune(runes[end]) {
+			end++
+		}
+	}
+	var pad strings.Builder
+	for _, r := range runes[:start-1] {
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+	return pad.String() + errorCaret(strings.Repeat("^", end-start+1))
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// frameLocation formats the file, line and column of a frame.
+func frameLocation(frame vm.Frame) string {
+	if frame.File == "" {
+		frame.File = "<code>"
+	}
+	if frame.Location.Line == 0 {
+		return location(frame)
+	}
+	return fmt.Sprintf("%s:%d", location(frame), frame.Location.Column)
+}
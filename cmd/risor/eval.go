@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/risor-io/risor"
+	modCsv "github.com/risor-io/risor/modules/csv"
+	modJSON "github.com/risor-io/risor/modules/json"
+	modYAML "github.com/risor-io/risor/modules/yaml"
+	"github.com/risor-io/risor/object"
+	"github.com/spf13/cobra"
+)
+
+var inputFormatsCompletion = []string{"json", "yaml", "csv", "lines"}
+
+var evalCmd = &cobra.Command{
+	Use:   "eval <code>",
+	Short: "Evaluate code on data read from stdin",
+	Long: `Evaluate code, typically a one-liner in a shell pipeline, and print its result.
+
+With --input, stdin is parsed into the "input" global:
+
+  json   the decoded JSON value
+  yaml   the decoded YAML value
+  csv    a list of maps keyed by the header row, with numbers and booleans
+         converted
+  lines  a list of the lines, without their line endings
+
+With --output, the result is printed as json, yaml, text, or a table: lists
+of maps have a column per key, lists of lists a column per item, and maps a
+row per key.`,
+	Example: `  cat data.json | risor eval --input json 'input["items"].filter(func(i) { i.count > 1 })'
+  ps aux | risor eval --input lines -o text 'len(input)'
+  risor eval --input csv -o table 'input.filter(func(r) { r.age >= 30 })' < people.csv`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		code := args[0]
+		inputFormat, _ := cmd.Flags().GetString("input")
+		outputFormat, _ := cmd.Flags().GetString("output")
+		opts := globalOptions()
+		importOpts, projectDir, lock, err := importOptions(cmd, "")
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		opts = append(opts, importOpts...)
+		opts = append(opts, risor.WithConcurrency())
+		if inputFormat != "" {
+			input, err := readInput(ctx, os.Stdin, inputFormat)
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			opts = append(opts, risor.WithGlobal("input", input))
+		}
+		result, err := risor.Eval(ctx, code, opts...)
+		if lock != nil {
+			if err := saveLock(projectDir, lock); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+			}
+		}
+		if err != nil {
+			printError(os.Stderr, err, newErrorSources("", code))
+			os.Exit(1)
+		}
+		// Streams are printed as the list of their items
+		if stream, ok := result.(*object.Stream); ok {
+			result = stream.Collect(ctx)
+		}
+		if err, ok := result.(*object.Error); ok {
+			fatal(red(err.Value().Error()))
+		}
+		output, err := getOutput(result, outputFormat)
+		if err != nil {
+			fatal(red(err.Error()))
+		} else if output != "" {
+			fmt.Println(strings.TrimSuffix(output, "\n"))
+		}
+	},
+}
+
+func init() {
+	evalCmd.Flags().StringP("input", "i", "", "Parse stdin into the input global, as json, yaml, csv, or lines")
+	evalCmd.Flags().StringP("output", "o", "", "Set the output format: json, yaml, text, or table")
+	evalCmd.RegisterFlagCompletionFunc("input",
+		cobra.FixedCompletions(inputFormatsCompletion, cobra.ShellCompDirectiveNoFileComp))
+	evalCmd.RegisterFlagCompletionFunc("output",
+		cobra.FixedCompletions(outputFormatsCompletion, cobra.ShellCompDirectiveNoFileComp))
+}
+
+// readInput parses the input read from r in the given format.
+func readInput(ctx context.Context, r io.Reader, format string) (object.Object, error) {
+	if strings.ToLower(format) == "lines" {
+		var lines []object.Object
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, 1<<30)
+		for scanner.Scan() {
+			lines = append(lines, object.NewString(strings.TrimSuffix(scanner.Text(), "\r")))
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return object.NewList(lines), nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var result object.Object
+	switch strings.ToLower(format) {
+	case "json":
+		result = modJSON.Unmarshal(ctx, object.NewByteSlice(data))
+	case "yaml":
+		result = modYAML.Unmarshal(ctx, object.NewByteSlice(data))
+	case "csv":
+		options := object.NewMap(map[string]object.Object{"infer": object.True})
+		result = modCsv.Reader(ctx, object.NewByteSlice(data), options)
+		if stream, ok := result.(*object.Stream); ok {
+			result = stream.Collect(ctx)
+		}
+	default:
+		return nil, fmt.Errorf("unknown input format: %s", format)
+	}
+	if err, ok := result.(*object.Error); ok {
+		return nil, fmt.Errorf("invalid %s input: %w", format, err.Value())
+	}
+	return result, nil
+}
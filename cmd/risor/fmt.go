@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/risor-io/risor/format"
+	"github.com/risor-io/risor/importer"
+	"github.com/spf13/cobra"
+)
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [path ...]",
+	Short: "Format Risor source code",
+	Long: `Format Risor source code in the canonical style, keeping its comments.
+
+Each path is a module file or a directory, whose .risor and .rsr files are
+formatted, skipping hidden and vendor directories. Without a path, standard
+input is formatted to standard output. Formatted code is printed, unless
+--write, --diff, or --list is given.
+
+With --diff or --list, risor fmt exits with status 1 if any file isn't
+formatted, so it can check the formatting of a project in CI.`,
+	Example: `  risor fmt -w .
+  risor fmt -d main.risor lib/`,
+	Run: func(cmd *cobra.Command, args []string) {
+		write, _ := cmd.Flags().GetBool("write")
+		diff, _ := cmd.Flags().GetBool("diff")
+		list, _ := cmd.Flags().GetBool("list")
+		ctx := cmd.Context()
+		if len(args) == 0 {
+			if write {
+				fatal(red("cannot use --write with standard input"))
+			}
+			src, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			changed, err := formatSource(ctx, "<stdin>", src, false, diff, list)
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			if changed && (diff || list) {
+				os.Exit(1)
+			}
+			return
+		}
+		var files []string
+		for _, path := range args {
+			found, err := sourceFiles(path)
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			files = append(files, found...)
+		}
+		var unformatted, failed bool
+		for _, file := range files {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			changed, err := formatSource(ctx, file, src, write, diff, list)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", red("%s: %s", file, err))
+				failed = true
+				continue
+			}
+			unformatted = unformatted || changed
+		}
+		if failed || (unformatted && (diff || list)) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	fmtCmd.Flags().BoolP("write", "w", false, "Write the formatted code to the source files")
+	fmtCmd.Flags().BoolP("diff", "d", false, "Print the changes formatting would make, as unified diffs")
+	fmtCmd.Flags().BoolP("list", "l", false, "List the files that aren't formatted")
+}
+
+// formatSource formats the source of a file and prints or writes it as
+// requested. It returns true if the file wasn't formatted.
+func formatSource(ctx context.Context, file string, src []byte, write, diff, list bool) (bool, error) {
+	out, err := format.Source(ctx, src)
+	if err != nil {
+		return false, err
+	}
+	changed := !bytes.Equal(src, out)
+	if list && changed {
+		fmt.Println(file)
+	}
+	if diff && changed {
+		text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(src)),
+			B:        difflib.SplitLines(string(out)),
+			FromFile: file + ".orig",
+			ToFile:   file,
+			Context:  3,
+		})
+		if err != nil {
+			return false, err
+		}
+		fmt.Print(text)
+	}
+	if write && changed {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(file, out, info.Mode().Perm()); err != nil {
+			return false, err
+		}
+	}
+	if !write && !diff && !list {
+		os.Stdout.Write(out)
+	}
+	return changed, nil
+}
+
+// sourceFiles returns the path, if it's a file, or the Risor source files
+// in the directory, skipping hidden and vendor directories.
+func sourceFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	var files []string
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if file != path && (strings.HasPrefix(name, ".") || name == importer.VendorDir) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(file); ext == ".risor" || ext == ".rsr" {
+			files = append(files, file)
+		}
+		return nil
+	})
+	return files, err
+}
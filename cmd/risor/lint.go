@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor/lint"
+	"github.com/spf13/cobra"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [path ...]",
+	Short: "Find likely mistakes in Risor source code",
+	Long: `Find likely mistakes in Risor source code, such as unused variables and
+imports, shadowed variables, unreachable code, suspicious comparisons, and
+calls that may fail made outside of try.
+
+Each path is a module file or a directory, whose .risor and .rsr files are
+checked, skipping hidden and vendor directories. Without a path, the current
+directory is checked.
+
+Rules are configured by a .risor-lint.json file in the project directory or
+the current directory, or by the file given with --config, such as:
+
+  {
+    "rules": {"shadow": "off", "unhandled-error": "warning"},
+    "error_calls": ["fetch", "os.read_file"]
+  }
+
+Each rule has the severity off, info, warning, or error, which --rule
+overrides. Findings are ignored by a "lint:ignore <rule>" comment on their
+line or the line before them.
+
+risor lint exits with status 1 if any finding is a warning or an error.`,
+	Example: `  risor lint
+  risor lint --rule shadow=off --format json main.risor lib/
+  risor lint --format github .`,
+	Run: func(cmd *cobra.Command, args []string) {
+		format, _ := cmd.Flags().GetString("format")
+		switch format {
+		case "text", "json", "github":
+		default:
+			fatal(red("unknown format: %s", format))
+		}
+		cfg, err := lintConfig(cmd)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		var files []string
+		for _, path := range args {
+			found, err := sourceFiles(path)
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			files = append(files, found...)
+		}
+		ctx := cmd.Context()
+		diagnostics := []lint.Diagnostic{}
+		var failed bool
+		for _, file := range files {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			found, err := lint.Source(ctx, file, src, cfg)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", red("%s: %s", file, err))
+				failed = true
+				continue
+			}
+			diagnostics = append(diagnostics, found...)
+		}
+		switch format {
+		case "json":
+			out, err := json.MarshalIndent(diagnostics, "", "  ")
+			if err != nil {
+				fatal(red(err.Error()))
+			}
+			fmt.Println(string(out))
+		case "github":
+			for _, d := range diagnostics {
+				fmt.Println(githubAnnotation(d))
+			}
+		default:
+			for _, d := range diagnostics {
+				fmt.Println(d)
+			}
+		}
+		for _, d := range diagnostics {
+			if d.Severity == lint.Warning || d.Severity == lint.Error {
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lintCmd.Flags().String("config", "", "Path to a lint configuration file")
+	lintCmd.Flags().String("format", "text", "Output format: text, json, or github")
+	lintCmd.Flags().StringArray("rule", nil, "Set the severity of a rule, as name=severity")
+}
+
+// lintConfig returns the configuration given with --config, or found in the
+// project or the working directory, with the severities given with --rule.
+func lintConfig(cmd *cobra.Command) (lint.Config, error) {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		_, projectDir, err := findManifest("")
+		if err != nil {
+			return lint.Config{}, err
+		}
+		for _, dir := range []string{projectDir, "."} {
+			if dir == "" {
+				continue
+			}
+			candidate := filepath.Join(dir, lint.ConfigFile)
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return lint.Config{}, err
+			}
+		}
+	}
+	var cfg lint.Config
+	if path != "" {
+		var err error
+		if cfg, err = lint.ReadConfig(path); err != nil {
+			return cfg, err
+		}
+	}
+	rules, _ := cmd.Flags().GetStringArray("rule")
+	for _, rule := range rules {
+		name, severity, ok := strings.Cut(rule, "=")
+		if !ok {
+			return cfg, fmt.Errorf("invalid rule: %s (expected name=severity)", rule)
+		}
+		if cfg.Rules == nil {
+			cfg.Rules = map[string]lint.Severity{}
+		}
+		cfg.Rules[name] = lint.Severity(severity)
+	}
+	return cfg, cfg.Validate()
+}
+
+// githubAnnotation formats a finding as a GitHub Actions workflow command,
+// which annotates the line in the changes of a pull request.
+func githubAnnotation(d lint.Diagnostic) string {
+	level := "warning"
+	switch d.Severity {
+	case lint.Error:
+		level = "error"
+	case lint.Info:
+		level = "notice"
+	}
+	return fmt.Sprintf("::%s file=%s,line=%d,col=%d,endLine=%d,endColumn=%d,title=%s::%s",
+		level, d.File, d.Line, d.Column, d.EndLine, d.EndColumn, d.Rule, d.Message)
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/risor-io/risor/importer"
 	"github.com/spf13/cobra"
 )
 
@@ -16,13 +17,10 @@ var (
 )
 
 func main() {
-	cmdServe := &cobra.Command{
-		Use:   "serve",
-		Short: "Run the Risor API server",
-		Long:  ``,
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Println("Server")
-		},
+	// Executables built by risor build run their script instead
+	if exe, err := os.Executable(); err == nil && importer.HasArchive(exe) {
+		runExecutable(exe)
+		return
 	}
 
 	cmdVersion := &cobra.Command{
@@ -52,7 +50,21 @@ func main() {
 	cmdVersion.RegisterFlagCompletionFunc("output",
 		cobra.FixedCompletions(outputFormatsCompletion, cobra.ShellCompDirectiveNoFileComp))
 
-	rootCmd.AddCommand(cmdServe)
+	rootCmd.AddCommand(addCmd)
+	rootCmd.AddCommand(benchCmd)
+	rootCmd.AddCommand(buildCmd)
+	rootCmd.AddCommand(bundleCmd)
+	rootCmd.AddCommand(debugCmd)
+	rootCmd.AddCommand(evalCmd)
+	rootCmd.AddCommand(fmtCmd)
+	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(pushCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(tidyCmd)
+	rootCmd.AddCommand(vendorCmd)
 	rootCmd.AddCommand(cmdVersion)
 
 	if err := rootCmd.Execute(); err != nil {
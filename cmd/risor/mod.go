@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/importer"
+	"github.com/risor-io/risor/modfile"
+	"github.com/spf13/cobra"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init [module]",
+	Short: "Create a project in the current directory",
+	Long: `Create a project in the current directory, by writing its risor.mod with
+the given module name, or the name of the directory, and the version of
+Risor running. A main.risor script is created too, unless the directory
+already holds Risor source files.`,
+	Example: `  risor init
+  risor init example.com/deploy-tools`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := os.Getwd()
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		name := filepath.Base(dir)
+		if len(args) > 0 {
+			name = args[0]
+		}
+		if name == "" || strings.ContainsAny(name, " \t\r\n") || strings.HasPrefix(name, "//") {
+			fatal(red("invalid module name %q", name))
+		}
+		path := filepath.Join(dir, modfile.Name)
+		if _, err := os.Stat(path); err == nil {
+			fatal(red("%s already exists", modfile.Name))
+		}
+		manifest := &modfile.File{Module: name, Risor: releaseVersion(version)}
+		if err := os.WriteFile(path, manifest.Format(), 0o644); err != nil {
+			fatal(red(err.Error()))
+		}
+		fmt.Printf("created %s for module %s\n", modfile.Name, name)
+		files, err := sourceFiles(dir)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		if len(files) > 0 {
+			return
+		}
+		script := "print(\"Hello from " + name + "!\")\n"
+		if err := os.WriteFile(filepath.Join(dir, "main.risor"), []byte(script), 0o644); err != nil {
+			fatal(red(err.Error()))
+		}
+		fmt.Println("created main.risor")
+	},
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add <module>@<version> ...",
+	Short: "Require remote modules in the project's risor.mod",
+	Long: `Require each remote module at the given version in the project's
+risor.mod, replacing the version it was required at, if any. Git modules
+are required at a tag, branch, or commit, OCI modules at a tag or a
+"sha256:" digest, and modules fetched over HTTPS at the "sha256:" checksum
+of their source.
+
+The required modules, and the modules they require in turn, are downloaded
+into the cache before risor.mod is written, so a version that doesn't
+exist is never required.`,
+	Example: `  risor add github.com/org/lib@v1.2.0
+  risor add oci://ghcr.io/org/tools@v2
+  risor add https://example.com/util.risor@sha256:9f86d08...`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, projectDir := projectManifest()
+		for _, arg := range args {
+			at := strings.LastIndex(arg, "@")
+			if at <= 0 || at == len(arg)-1 {
+				fatal(red("missing version for %s (as in %s@v1.2.0)", arg, strings.TrimSuffix(arg, "@")))
+			}
+			if err := manifest.AddRequire(arg[:at], arg[at+1:]); err != nil {
+				fatal(red(err.Error()))
+			}
+		}
+		if _, err := downloadModules(cmd, manifest); err != nil {
+			fatal(red(err.Error()))
+		}
+		if err := writeManifest(projectDir, manifest); err != nil {
+			fatal(red(err.Error()))
+		}
+		for _, arg := range args {
+			at := strings.LastIndex(arg, "@")
+			fmt.Printf("added %s %s\n", arg[:at], arg[at+1:])
+		}
+	},
+}
+
+var getCmd = &cobra.Command{
+	Use:   "get",
+	Short: "Download the remote modules of the project",
+	Long: `Download the remote modules required by the project's risor.mod, directly
+or through the manifests of the modules it requires, into the cache, so
+the project's scripts run without fetching them.
+
+The modules imported by the project's scripts are then verified against
+risor.lock, and the checksums of those it doesn't hold yet are added to
+it, or replaced with --update-lock.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, projectDir := projectManifest()
+		requires, err := downloadModules(cmd, manifest)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		_, lock, err := importProject(cmd, projectDir)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		if err := saveLock(projectDir, lock); err != nil {
+			fatal(red(err.Error()))
+		}
+		fmt.Printf("downloaded %d module(s)\n", len(requires))
+	},
+}
+
+var tidyCmd = &cobra.Command{
+	Use:   "tidy",
+	Short: "Remove the modules the project doesn't import from risor.mod and risor.lock",
+	Long: `Import the modules of every script of the project, skipping hidden and
+vendor directories, to find the remote modules it uses. Requirements of
+risor.mod that no script imports are removed, as are the checksums of
+risor.lock that no import verified. Remote modules that are imported but
+not required by any manifest are reported, to be required with risor add.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, projectDir := projectManifest()
+		requires, err := downloadModules(cmd, manifest)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		imported, lock, err := importProject(cmd, projectDir)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		matches := func(req modfile.Require) bool {
+			for _, name := range imported {
+				if req.Matches(name) {
+					return true
+				}
+			}
+			return false
+		}
+		var removed []modfile.Require
+		for _, req := range append([]modfile.Require(nil), manifest.Requires...) {
+			if !matches(req) {
+				manifest.DropRequire(req.Path)
+				removed = append(removed, req)
+			}
+		}
+		if len(removed) > 0 {
+			if err := writeManifest(projectDir, manifest); err != nil {
+				fatal(red(err.Error()))
+			}
+		}
+		lock.Prune()
+		if err := saveLock(projectDir, lock); err != nil {
+			fatal(red(err.Error()))
+		}
+		for _, req := range removed {
+			fmt.Printf("removed %s %s\n", req.Path, req.Version)
+		}
+		for _, name := range imported {
+			required := false
+			for _, req := range requires {
+				if req.Matches(name) {
+					required = true
+					break
+				}
+			}
+			if !required {
+				fmt.Fprintf(os.Stderr, "%s\n", yellow("%s is imported but not required by %s", name, modfile.Name))
+			}
+		}
+	},
+}
+
+// projectManifest returns the manifest of the project in the working
+// directory, and the directory holding it, or exits if there is none.
+func projectManifest() (*modfile.File, string) {
+	manifest, projectDir, err := findManifest("")
+	if err != nil {
+		fatal(red(err.Error()))
+	}
+	if manifest == nil {
+		fatal(red("no %s found (create one with risor init)", modfile.Name))
+	}
+	return manifest, projectDir
+}
+
+// writeManifest writes the project's manifest in its canonical form.
+func writeManifest(projectDir string, manifest *modfile.File) error {
+	path := filepath.Join(projectDir, modfile.Name)
+	return os.WriteFile(path, manifest.Format(), 0o644)
+}
+
+// downloadModules fetches the remote modules required by the manifest into
+// the cache, and returns the requirements resolved across the manifests of
+// the modules it requires.
+func downloadModules(cmd *cobra.Command, manifest *modfile.File) ([]modfile.Require, error) {
+	keys, err := trustedKeys()
+	if err != nil {
+		return nil, err
+	}
+	return importer.Download(cmd.Context(), importer.ProjectImporterOptions{
+		Manifest:    manifest,
+		TrustedKeys: keys,
+		Credentials: credentials(),
+	})
+}
+
+// importProject imports the modules of every script of the project, as
+// risor bundle does, so its remote modules are verified against the lock.
+// It returns the sorted names of the remote modules imported, and the lock
+// holding their checksums.
+func importProject(cmd *cobra.Command, projectDir string) ([]string, *importer.Lock, error) {
+	scripts, err := sourceFiles(projectDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := globalOptions()
+	importOpts, _, lock, err := importOptions(cmd, filepath.Join(projectDir, modfile.Name))
+	if err != nil {
+		return nil, nil, err
+	}
+	var mutex sync.Mutex
+	seen := map[string]bool{}
+	opts = append(opts, importOpts...)
+	opts = append(opts, risor.WithImportAudit(importer.AuditImporterOptions{
+		Report: func(ctx context.Context, event importer.ImportEvent) {
+			if event.Err != nil {
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			seen[event.Name] = true
+		},
+	}))
+	for _, script := range scripts {
+		source, err := os.ReadFile(script)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := risor.Bundle(cmd.Context(), io.Discard, string(source), false, opts...); err != nil {
+			rel, _ := filepath.Rel(projectDir, script)
+			return nil, nil, fmt.Errorf("%s: %w", rel, err)
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, lock, nil
+}
+
+// releaseVersion returns the major and minor numbers of a release version
+// of Risor, such as "1.5" for "v1.5.2", or an empty string for development
+// builds.
+func releaseVersion(v string) string {
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	for _, part := range parts[:2] {
+		if _, err := strconv.Atoi(part); err != nil {
+			return ""
+		}
+	}
+	return parts[0] + "." + parts[1]
+}
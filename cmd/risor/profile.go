@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor/profile"
+)
+
+// writeProfile writes a profile of the given kind, as folded stacks if the
+// path ends in .folded or .txt, or else in the pprof format.
+func writeProfile(path string, profiler *profile.Profiler, kind profile.Kind) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".folded", ".txt":
+		err = profiler.WriteFolded(f, kind)
+	default:
+		err = profiler.WritePprof(f, kind)
+	}
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeTrace writes the calls recorded by the tracer.
+func writeTrace(path string, tracer *profile.Tracer) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := tracer.Write(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor/importer"
+	"github.com/risor-io/risor/modfile"
+	"github.com/spf13/viper"
+)
+
+// findManifest returns the project manifest that applies to the given
+// script, found in the script's directory or the nearest of its parents.
+// Without a script, the search starts in the working directory. It returns
+// nil if there is no manifest.
+func findManifest(script string) (*modfile.File, string, error) {
+	dir := "."
+	if script != "" {
+		dir = filepath.Dir(script)
+	}
+	path, err := modfile.Find(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	} else if err != nil {
+		return nil, "", err
+	}
+	manifest, err := modfile.Read(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := manifest.CheckRisorVersion(version); err != nil {
+		return nil, "", err
+	}
+	return manifest, filepath.Dir(path), nil
+}
+
+// loadLock returns a lock holding the checksums in the project's lockfile,
+// or no checksums if the project doesn't have a lockfile yet.
+func loadLock(projectDir string, update bool) (*importer.Lock, error) {
+	lockfile, err := modfile.ReadLock(filepath.Join(projectDir, modfile.LockName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return importer.NewLock(lockfile, update), nil
+}
+
+// saveLock writes the project's lockfile if checksums were added or replaced.
+func saveLock(projectDir string, lock *importer.Lock) error {
+	if !lock.Changed() {
+		return nil
+	}
+	path := filepath.Join(projectDir, modfile.LockName)
+	return os.WriteFile(path, lock.File().Format(), 0o644)
+}
+
+// trustedKeys returns the keys given with --trusted-key, if any.
+func trustedKeys() (importer.TrustedKeys, error) {
+	paths := viper.GetStringSlice("trusted-key")
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	return importer.ReadTrustedKeys(paths...)
+}
+
+// vendorDir returns the vendor directory of the project, if it has one
+// written by risor vendor.
+func vendorDir(projectDir string) fs.FS {
+	dir := filepath.Join(projectDir, importer.VendorDir)
+	if _, err := os.Stat(filepath.Join(dir, importer.VendorIndex)); err != nil {
+		return nil
+	}
+	return os.DirFS(dir)
+}
+
+// credentials returns the provider of credentials for remote modules. It
+// reads tokens from RISOR_TOKEN_<HOST> variables, then logins from the netrc
+// file, and finally uses the OIDC token of the CI job for the hosts listed
+// in RISOR_OIDC_HOSTS, with the audience in RISOR_OIDC_AUDIENCE.
+func credentials() importer.CredentialProvider {
+	providers := []importer.CredentialProvider{
+		importer.EnvCredentials(),
+		importer.NetrcCredentials(""),
+	}
+	if hosts := os.Getenv("RISOR_OIDC_HOSTS"); hosts != "" {
+		providers = append(providers, importer.OIDCCredentials(importer.OIDCOptions{
+			Hosts:    strings.Split(hosts, ","),
+			Audience: os.Getenv("RISOR_OIDC_AUDIENCE"),
+			TokenEnv: "RISOR_OIDC_TOKEN",
+		}))
+	}
+	return importer.ChainCredentials(providers...)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor/importer"
+	"github.com/spf13/cobra"
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push <reference> <path>...",
+	Short: "Push Risor modules to an OCI registry",
+	Long: `Push Risor modules to an OCI registry as a bundle, which scripts can
+then import, such as with: import "oci://ghcr.io/org/lib:v1.2.0"
+
+Each path is a module file, or a directory whose module files are pushed
+with their paths relative to it. The minisign signatures of the module
+files in a directory are pushed too, for scripts that only trust signed
+modules. The digest printed on success can be used to pin imports to
+exactly this bundle.
+
+Registry credentials are read from the --username and --password flags, or
+the RISOR_REGISTRY_USERNAME and RISOR_REGISTRY_PASSWORD variables.`,
+	Example: `  risor push oci://ghcr.io/org/lib:v1.2.0 lib.risor
+  risor push oci://localhost:5000/tools:latest ./tools --plain-http`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		files := map[string][]byte{}
+		for _, path := range args[1:] {
+			if err := readModules(path, files); err != nil {
+				fatal(red(err.Error()))
+			}
+		}
+		username, _ := cmd.Flags().GetString("username")
+		password, _ := cmd.Flags().GetString("password")
+		plainHTTP, _ := cmd.Flags().GetBool("plain-http")
+		if username == "" {
+			username = os.Getenv("RISOR_REGISTRY_USERNAME")
+		}
+		if password == "" {
+			password = os.Getenv("RISOR_REGISTRY_PASSWORD")
+		}
+		digest, err := importer.PushOCI(cmd.Context(), args[0], files, importer.OCIRegistryOptions{
+			Username:    username,
+			Password:    password,
+			Credentials: credentials(),
+			PlainHTTP:   plainHTTP,
+		})
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		fmt.Printf("pushed %d module(s) to %s\n", len(files), args[0])
+		fmt.Println(digest)
+	},
+}
+
+func init() {
+	pushCmd.Flags().String("username", "", "Registry username")
+	pushCmd.Flags().String("password", "", "Registry password or token")
+	pushCmd.Flags().Bool("plain-http", false, "Reach the registry over plain HTTP")
+}
+
+// readModules reads the module file at the path, or the module files in the
+// directory at the path, into files.
+func readModules(path string, files map[string][]byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.Base(path)] = data
+		return nil
+	}
+	return filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		moduleFile := strings.TrimSuffix(file, importer.SignatureExtension)
+		if ext := filepath.Ext(moduleFile); ext != ".risor" && ext != ".rsr" {
+			return nil
+		}
+		name, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(name)] = data
+		return nil
+	})
+}
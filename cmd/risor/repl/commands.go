@@ -0,0 +1,142 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/risor-io/risor/modules"
+	"github.com/risor-io/risor/object"
+)
+
+// commands are the meta-commands of the REPL, entered with a leading colon.
+var commands = []struct {
+	name string
+	args string
+	help string
+}{
+	{":type", "<expr>", "Show the type of an expression"},
+	{":doc", "<name>", "Show the documentation of a module or function"},
+	{":time", "<expr>", "Evaluate an expression and show how long it took"},
+	{":load", "<path>", "Run a script in the session, keeping its globals"},
+	{":reset", "", "Discard the globals defined in the session"},
+	{":help", "", "List the commands"},
+}
+
+// command runs a meta-command, such as ":type x".
+func (e *evaluator) command(ctx context.Context, line string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	for _, c := range commands {
+		if c.name == name && c.args != "" && arg == "" {
+			color.Red("usage: %s %s", c.name, c.args)
+			return
+		}
+	}
+	switch name {
+	case ":type":
+		result, err := e.run(ctx, arg)
+		if err != nil {
+			color.Red(err.Error())
+			return
+		}
+		fmt.Println(result.Type())
+	case ":doc":
+		doc, ok := e.doc(arg)
+		if !ok {
+			color.Red("no documentation for %s", arg)
+			return
+		}
+		fmt.Println(doc)
+	case ":time":
+		start := time.Now()
+		e.eval(ctx, arg)
+		fmt.Printf("time: %s\n", time.Since(start))
+	case ":load":
+		source, err := os.ReadFile(arg)
+		if err != nil {
+			color.Red(err.Error())
+			return
+		}
+		if _, err := e.run(ctx, string(source)); err != nil {
+			color.Red("%s: %s", arg, err)
+		}
+	case ":reset":
+		e.reset()
+	case ":help":
+		for _, c := range commands {
+			fmt.Printf("%-8s %-8s %s\n", c.name, c.args, c.help)
+		}
+	default:
+		color.Red("unknown command: %s (see :help)", name)
+	}
+}
+
+// doc returns the documentation of a name: the Markdown documentation of
+// built-in modules and their functions, or the signature of a function
+// defined in the session and the comment preceding it.
+func (e *evaluator) doc(name string) (string, bool) {
+	var text string
+	var ok bool
+	switch value := resolve(e, strings.Split(name, ".")).(type) {
+	case *object.Module:
+		text, ok = modules.Docs(value.Name().Value())
+	case *object.Builtin:
+		// The names of builtins may or may not include their module
+		module, _ := value.GetAttr("__module__")
+		if module, isModule := module.(*object.Module); isModule {
+			function := value.Name()[strings.LastIndex(value.Name(), ".")+1:]
+			text, ok = modules.FunctionDocs(module.Name().Value(), function)
+		}
+	case *object.Function:
+		text = signature(name, value)
+		if comment := e.comment(value.Name()); comment != "" {
+			text += "\n\n" + comment
+		}
+		return text, true
+	}
+	if !ok {
+		return "", false
+	}
+	// Code fences only clutter a terminal
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, "```") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// comment returns the comment that precedes the latest declaration of the
+// named function in the code that ran.
+func (e *evaluator) comment(name string) string {
+	if name == "" {
+		return ""
+	}
+	for i := len(e.sources) - 1; i >= 0; i-- {
+		lines := strings.Split(e.sources[i], "\n")
+		for j := len(lines) - 1; j >= 0; j-- {
+			if !strings.HasPrefix(strings.TrimSpace(lines[j]), "func "+name+"(") {
+				continue
+			}
+			var comment []string
+			for k := j - 1; k >= 0; k-- {
+				line := strings.TrimSpace(lines[k])
+				if strings.HasPrefix(line, "//") {
+					line = strings.TrimPrefix(line, "//")
+				} else if strings.HasPrefix(line, "#") {
+					line = strings.TrimPrefix(line, "#")
+				} else {
+					break
+				}
+				comment = append([]string{strings.TrimSpace(line)}, comment...)
+			}
+			return strings.Join(comment, "\n")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package repl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoc(t *testing.T) {
+	e := newEvaluator(risor.NewConfig())
+	_, err := e.run(context.Background(), `
+// Doubles a number.
+# Works on floats too.
+func double(n, factor=2) {
+    return n * factor
+}`)
+	require.Nil(t, err)
+
+	doc, ok := e.doc("double")
+	require.True(t, ok)
+	require.Equal(t, "double(n, factor=2)\n\nDoubles a number.\nWorks on floats too.", doc)
+
+	doc, ok = e.doc("strings.compare")
+	require.True(t, ok)
+	require.Contains(t, doc, "### compare\n\ncompare(s1, s2 string) int\n")
+
+	doc, ok = e.doc("json")
+	require.True(t, ok)
+	require.Contains(t, doc, "# json")
+
+	_, ok = e.doc("missing")
+	require.False(t, ok)
+}
+
+func TestReset(t *testing.T) {
+	ctx := context.Background()
+	e := newEvaluator(risor.NewConfig())
+	_, err := e.run(ctx, "x := 1")
+	require.Nil(t, err)
+	require.Contains(t, e.globalNames(), "x")
+
+	e.reset()
+	require.NotContains(t, e.globalNames(), "x")
+	_, err = e.run(ctx, "x")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,304 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/token"
+)
+
+// scope gives the completer access to the globals of the session.
+type scope interface {
+	globalNames() []string
+	lookup(name string) (object.Object, bool)
+}
+
+// completion is a candidate for the word being completed.
+type completion struct {
+	// Text that replaces the word
+	text string
+
+	// Shown when listing the candidates
+	label string
+}
+
+// methodNames are the attributes of the built-in types, which, unlike those
+// of modules, can't be listed. They are probed with GetAttr instead.
+var methodNames = []string{
+	"add", "after", "append", "before", "byte_len", "bytes", "clear", "clone",
+	"close", "contains", "contains_any", "contains_rune", "copy", "count",
+	"each", "equals", "extend", "fields", "filter", "format", "get", "grow",
+	"has_prefix", "has_suffix", "index", "index_any", "index_byte",
+	"index_rune", "insert", "intersection", "items", "join", "keys",
+	"last_index", "len", "map", "name", "pop", "position", "read",
+	"read_lines", "remove", "repeat", "replace", "replace_all", "reset",
+	"reverse", "rune_index", "rune_last_index", "runes", "seek", "setdefault",
+	"sort", "spawn", "split", "stat", "string", "to_lower", "to_upper", "trim",
+	"trim_prefix", "trim_space", "trim_suffix", "truncate", "union", "unix",
+	"update", "utc", "values", "wait", "write", "write_byte",
+}
+
+// complete returns the candidates for the word that ends the line, and the
+// offset in the line where that word starts:
+//
+//   - in a string literal, the file paths starting with its contents;
+//   - after "value.", the attributes of the value;
+//   - right after "fn(", the signature of the function, as a hint that
+//     inserts nothing;
+//   - otherwise, the globals and keywords starting with the word.
+//
+// Meta-commands are completed at the start of the line.
+func complete(s scope, line string) (int, []completion) {
+	if strings.HasPrefix(line, ":") && !strings.Contains(line, " ") {
+		var completions []completion
+		for _, c := range commands {
+			if strings.HasPrefix(c.name, line) {
+				completions = append(completions, completion{text: c.name + " ", label: c.name})
+			}
+		}
+		return 0, completions
+	}
+	if prefix, ok := openString(line); ok {
+		_, base := filepath.Split(prefix)
+		return len(line) - len(base), completePath(prefix)
+	}
+	if strings.HasSuffix(line, "(") {
+		chain, ok := trailingChain(line[:len(line)-1])
+		if !ok || strings.HasSuffix(chain, ".") {
+			return len(line), nil
+		}
+		if fn, ok := resolve(s, strings.Split(chain, ".")).(*object.Function); ok {
+			return len(line), []completion{{label: signature(chain, fn)}}
+		}
+		return len(line), nil
+	}
+	chain, ok := trailingChain(line)
+	if !ok {
+		return len(line), nil
+	}
+	parts := strings.Split(chain, ".")
+	word := parts[len(parts)-1]
+	start := len(line) - len(word)
+	if len(parts) == 1 {
+		if word == "" {
+			return start, nil
+		}
+		return start, completeGlobals(s, word)
+	}
+	value := resolve(s, parts[:len(parts)-1])
+	if value == nil {
+		return start, nil
+	}
+	var completions []completion
+	for _, name := range attributeNames(value) {
+		if !strings.HasPrefix(name, word) {
+			continue
+		}
+		attr, _ := value.GetAttr(name)
+		completions = append(completions, completion{text: name, label: label(name, attr)})
+	}
+	return start, completions
+}
+
+// completeGlobals returns the globals and keywords starting with the prefix.
+// A function that is the only candidate is completed with its opening
+// parenthesis.
+func completeGlobals(s scope, prefix string) []completion {
+	var completions []completion
+	seen := map[string]bool{}
+	for _, name := range s.globalNames() {
+		if !strings.HasPrefix(name, prefix) || seen[name] {
+			continue
+		}
+		seen[name] = true
+		value, _ := s.lookup(name)
+		completions = append(completions, completion{text: name, label: label(name, value)})
+	}
+	for _, name := range token.Keywords() {
+		if strings.HasPrefix(name, prefix) && !seen[name] {
+			completions = append(completions, completion{text: name, label: name})
+		}
+	}
+	sort.Slice(completions, func(i, j int) bool {
+		return completions[i].text < completions[j].text
+	})
+	if len(completions) == 1 {
+		if value, ok := s.lookup(completions[0].text); ok && isCallable(value) {
+			completions[0].text += "("
+		}
+	}
+	return completions
+}
+
+// completePath returns the names of the files starting with the path, where
+// directories end with a separator. Hidden files are only listed when the
+// name being completed starts with a dot.
+func completePath(path string) []completion {
+	dir, base := filepath.Split(path)
+	entries, err := os.ReadDir(filepath.Clean(dir + "."))
+	if err != nil {
+		return nil
+	}
+	var completions []completion
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) {
+			continue
+		}
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			name += string(filepath.Separator)
+		}
+		completions = append(completions, completion{text: name, label: name})
+	}
+	return completions
+}
+
+// openString returns the contents of the string literal left open at the
+// end of the line, if any.
+func openString(line string) (string, bool) {
+	var quote rune
+	start := 0
+	escaped := false
+	for i, r := range line {
+		switch {
+		case quote == 0:
+			if r == '"' || r == '\'' || r == '`' {
+				quote = r
+				start = i + 1
+			}
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '`':
+			escaped = true
+		case r == quote:
+			quote = 0
+		}
+	}
+	if quote == 0 {
+		return "", false
+	}
+	return line[start:], true
+}
+
+// trailingChain returns the dotted identifiers that end the line, such as
+// "strings.to_" in "x := strings.to_". It fails if the chain doesn't start
+// with an identifier, as in "1.5".
+func trailingChain(line string) (string, bool) {
+	start := len(line)
+	for start > 0 && (isIdentChar(line[start-1]) || line[start-1] == '.') {
+		start--
+	}
+	chain := line[start:]
+	if chain == "" {
+		return "", true
+	}
+	if c := chain[0]; c == '.' || (c >= '0' && c <= '9') {
+		return "", false
+	}
+	return chain, true
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// resolve looks up a global and then its attributes, in turn, returning nil
+// if any is missing.
+func resolve(s scope, names []string) object.Object {
+	value, ok := s.lookup(names[0])
+	if !ok {
+		return nil
+	}
+	for _, name := range names[1:] {
+		if value, ok = value.GetAttr(name); !ok || value == nil {
+			return nil
+		}
+	}
+	return value
+}
+
+// attributeNames returns the sorted names of the attributes of the value.
+func attributeNames(value object.Object) []string {
+	names := map[string]bool{}
+	if v, ok := value.(interface{ AttributeNames() []string }); ok {
+		for _, name := range v.AttributeNames() {
+			names[name] = true
+		}
+	}
+	switch value := value.(type) {
+	case *object.Map:
+		for _, key := range value.SortedKeys() {
+			names[key] = true
+		}
+	case *object.Proxy:
+		for _, name := range value.GoType().AttributeNames() {
+			names[name] = true
+		}
+	}
+	for _, name := range methodNames {
+		if !names[name] {
+			if _, ok := value.GetAttr(name); ok {
+				names[name] = true
+			}
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// label describes a candidate, showing the parameters of functions.
+func label(name string, value object.Object) string {
+	switch value := value.(type) {
+	case *object.Function:
+		return signature(name, value)
+	case *object.Builtin:
+		return name + "()"
+	}
+	return name
+}
+
+// signature formats the parameters of a function, with their defaults.
+func signature(name string, fn *object.Function) string {
+	defaults := fn.Defaults()
+	params := make([]string, 0, len(fn.Parameters()))
+	for i, param := range fn.Parameters() {
+		if i < len(defaults) && defaults[i] != nil {
+			param += "=" + defaults[i].Inspect()
+		}
+		params = append(params, param)
+	}
+	return name + "(" + strings.Join(params, ", ") + ")"
+}
+
+func isCallable(value object.Object) bool {
+	switch value.(type) {
+	case *object.Function, *object.Builtin:
+		return true
+	}
+	return false
+}
+
+// commonPrefix returns the longest prefix shared by the texts of the
+// completions.
+func commonPrefix(completions []completion) string {
+	if len(completions) == 0 {
+		return ""
+	}
+	prefix := completions[0].text
+	for _, c := range completions[1:] {
+		for !strings.HasPrefix(c.text, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
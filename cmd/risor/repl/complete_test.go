@@ -0,0 +1,83 @@
+package repl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/risor-io/risor"
+	"github.com/stretchr/testify/require"
+)
+
+func texts(completions []completion) []string {
+	var result []string
+	for _, c := range completions {
+		result = append(result, c.text)
+	}
+	return result
+}
+
+func TestComplete(t *testing.T) {
+	e := newEvaluator(risor.NewConfig())
+	_, err := e.eval(context.Background(), `
+config := {"name": "x", "port": 80}
+func greet(name, greeting="hello") { return greeting + name }
+`)
+	require.Nil(t, err)
+
+	start, completions := complete(e, "x := gre")
+	require.Equal(t, 5, start)
+	require.Equal(t, []string{"greet("}, texts(completions))
+
+	_, completions = complete(e, "fo")
+	require.Equal(t, []string{"for"}, texts(completions))
+
+	start, completions = complete(e, "strings.to_")
+	require.Equal(t, 8, start)
+	require.Equal(t, []string{"to_lower", "to_upper"}, texts(completions))
+
+	_, completions = complete(e, "config.p")
+	require.Equal(t, []string{"pop", "port"}, texts(completions))
+
+	_, completions = complete(e, `"abc".has_`)
+	require.Nil(t, completions)
+
+	_, completions = complete(e, "greet(")
+	require.Equal(t, []completion{{label: `greet(name, greeting="hello")`}}, completions)
+
+	_, completions = complete(e, "1.5")
+	require.Nil(t, completions)
+}
+
+func TestCompletePath(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.Mkdir(filepath.Join(dir, "data"), 0o755))
+	for _, name := range []string{"data.json", ".hidden", "other.txt"} {
+		require.Nil(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	e := newEvaluator(risor.NewConfig())
+
+	line := `open("` + dir + "/da"
+	start, completions := complete(e, line)
+	require.Equal(t, len(line)-2, start)
+	names := texts(completions)
+	sort.Strings(names)
+	require.Equal(t, []string{"data.json", "data/"}, names)
+
+	_, completions = complete(e, `open("`+dir+"/.")
+	require.Equal(t, []string{".hidden"}, texts(completions))
+}
+
+func TestCommonPrefix(t *testing.T) {
+	require.Equal(t, "to_", commonPrefix([]completion{{text: "to_lower"}, {text: "to_upper"}}))
+	require.Equal(t, "", commonPrefix(nil))
+}
+
+func TestCompleteCommand(t *testing.T) {
+	e := newEvaluator(risor.NewConfig())
+	start, completions := complete(e, ":t")
+	require.Equal(t, 0, start)
+	require.Equal(t, []string{":type ", ":time "}, texts(completions))
+}
@@ -0,0 +1,100 @@
+package repl
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxHistory bounds the entries kept in the history file.
+const maxHistory = 1000
+
+// history holds the entries of this and past sessions, which are persisted
+// in a file with one entry per line. Entries that span lines are stored
+// quoted.
+type history struct {
+	path    string
+	entries []string
+}
+
+// loadHistory reads the history file, if any. The history isn't persisted
+// if the path is empty.
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return h
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			h.entries = append(h.entries, decodeEntry(line))
+		}
+	}
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		h.save()
+	}
+	return h
+}
+
+// add appends an entry to the history, unless it's blank or repeats the
+// last entry.
+func (h *history) add(entry string) {
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	f.WriteString(encodeEntry(entry) + "\n")
+}
+
+// save rewrites the history file with the current entries.
+func (h *history) save() {
+	var sb strings.Builder
+	for _, entry := range h.entries {
+		sb.WriteString(encodeEntry(entry) + "\n")
+	}
+	os.WriteFile(h.path, []byte(sb.String()), 0o644)
+}
+
+// search returns the index of the latest entry before the given index that
+// contains the query, or -1 if there is none.
+func (h *history) search(query string, before int) int {
+	for i := min(before, len(h.entries)) - 1; i >= 0; i-- {
+		if strings.Contains(h.entries[i], query) {
+			return i
+		}
+	}
+	return -1
+}
+
+func encodeEntry(entry string) string {
+	if strings.Contains(entry, "\n") {
+		return strconv.Quote(entry)
+	}
+	return entry
+}
+
+// decodeEntry reverses encodeEntry. Lines that merely look quoted, such as
+// a string literal entered on its own, are kept as they are.
+func decodeEntry(line string) string {
+	if strings.HasPrefix(line, `"`) {
+		if entry, err := strconv.Unquote(line); err == nil && strings.Contains(entry, "\n") {
+			return entry
+		}
+	}
+	return line
+}
@@ -0,0 +1,61 @@
+package repl
+
+import "strings"
+
+// incomplete reports whether the source ends inside braces, brackets or
+// parentheses, a raw string or a block comment, so that the entry continues
+// on the next line. Unbalanced closing delimiters are left to the parser to
+// report.
+func incomplete(source string) bool {
+	depth := 0
+	runes := []rune(source)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+		case '"', '\'':
+			// Quoted strings end at the end of the line
+			for i++; i < len(runes) && runes[i] != r && runes[i] != '\n'; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+		case '`':
+			for i++; i < len(runes) && runes[i] != '`'; i++ {
+			}
+			if i >= len(runes) {
+				return true
+			}
+		case '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case '/':
+			if i+1 >= len(runes) {
+				break
+			}
+			if runes[i+1] == '/' {
+				for i < len(runes) && runes[i] != '\n' {
+					i++
+				}
+			} else if runes[i+1] == '*' {
+				for i += 2; i+1 < len(runes) && (runes[i] != '*' || runes[i+1] != '/'); i++ {
+				}
+				if i+1 >= len(runes) {
+					return true
+				}
+				i++
+			}
+		}
+	}
+	return depth > 0
+}
+
+// pasteLines splits pasted text into lines, whatever the line endings.
+func pasteLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return strings.Split(text, "\n")
+}
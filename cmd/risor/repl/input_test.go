@@ -0,0 +1,60 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"x := 1", false},
+		{"func f() {", true},
+		{"func f() {\n  return 1\n}", false},
+		{"x := [1, 2,", true},
+		{"print(\"(\")", false},
+		{"x := '{'", false},
+		{"x := `multi", true},
+		{"x := `multi\nline`", false},
+		{"x := 1 // {", false},
+		{"x := 1 # (", false},
+		{"/* {", true},
+		{"/* { */ x", false},
+		{"}", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			require.Equal(t, tt.want, incomplete(tt.source))
+		})
+	}
+}
+
+func TestPasteLines(t *testing.T) {
+	require.Equal(t, []string{"a", "b", "c", ""}, pasteLines("a\r\nb\rc\n"))
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h := loadHistory(path)
+	h.add("x := 1")
+	h.add("x := 1")
+	h.add("  ")
+	h.add("func f() {\n  return x\n}")
+	h.add(`"quoted"`)
+	require.Equal(t, []string{"x := 1", "func f() {\n  return x\n}", `"quoted"`}, h.entries)
+
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Equal(t, "x := 1\n\"func f() {\\n  return x\\n}\"\n\"quoted\"\n", string(data))
+
+	h = loadHistory(path)
+	require.Equal(t, []string{"x := 1", "func f() {\n  return x\n}", `"quoted"`}, h.entries)
+	require.Equal(t, 1, h.search("x", 3))
+	require.Equal(t, 0, h.search("x", 1))
+	require.Equal(t, -1, h.search("y", 3))
+}
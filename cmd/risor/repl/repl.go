@@ -20,8 +20,10 @@ import (
 
 const (
 	clearLine   = "\033[2K\r"
+	clearBelow  = "\r\033[J"
 	moveBack    = "\033[%dD"
 	moveForward = "\033[%dC"
+	moveUp      = "\033[%dA"
 )
 
 func Run(ctx context.Context, options []risor.Option) error {
@@ -29,36 +31,85 @@ func Run(ctx context.Context, options []risor.Option) error {
 	fmt.Println("")
 	fmt.Printf(">>> ")
 
+	// The line being edited, and the lines before it when the entry spans
+	// several, because of unbalanced braces or a paste
 	var column int
-	var historyIndex int
-	var history []string
 	var accumulate string
+	var pending []string
+
+	// The screen lines above the cursor showing the entry
+	var drawn int
 
 	// Read execution history just like Python's REPL.
 	var historyPath string
-	homeDir, err := os.UserHomeDir()
-	if err == nil {
+	if homeDir, err := os.UserHomeDir(); err == nil {
 		historyPath = path.Join(homeDir, ".risor_history")
-		historyData, err := os.ReadFile(historyPath)
-		if err == nil {
-			history = strings.Split(string(historyData), "\n")
-			historyIndex = len(history) - 1
-		}
 	}
+	hist := loadHistory(historyPath)
+	historyIndex := len(hist.entries)
 
-	appendToHistory := func(line string) {
-		if historyPath != "" {
-			f, err := os.OpenFile(historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
-			if err != nil {
-				return
-			}
-			defer f.Close()
-			f.WriteString(line + "\n")
+	// State of a reverse search of the history, started with Ctrl-R
+	var searching bool
+	var query string
+	var match int
+
+	prompt := func(line int) string {
+		if line == 0 {
+			return ">>> "
 		}
+		return "... "
 	}
 
 	getLineText := func() string {
-		return clearLine + ">>> " + accumulate
+		return clearLine + prompt(len(pending)) + accumulate
+	}
+
+	// redraw prints the entry again, from its first line
+	redraw := func() {
+		if drawn > 0 {
+			fmt.Printf(moveUp, drawn)
+		}
+		fmt.Print(clearBelow)
+		for i, line := range pending {
+			fmt.Print("\r" + prompt(i) + line + "\n")
+		}
+		fmt.Print(getLineText())
+		if rest := len(accumulate) - column; rest > 0 {
+			fmt.Printf(moveBack, rest)
+		}
+		drawn = len(pending)
+	}
+
+	// setEntry replaces the entry being edited
+	setEntry := func(entry string) {
+		lines := strings.Split(entry, "\n")
+		pending = lines[:len(lines)-1]
+		accumulate = lines[len(lines)-1]
+		column = len(accumulate)
+		redraw()
+	}
+
+	drawSearch := func() {
+		if drawn > 0 {
+			fmt.Printf(moveUp, drawn)
+		}
+		drawn = 0
+		var found string
+		if match >= 0 && match < len(hist.entries) {
+			found = strings.ReplaceAll(hist.entries[match], "\n", " ")
+		}
+		fmt.Printf("%s(reverse-i-search)`%s': %s", clearBelow, query, found)
+	}
+
+	// endSearch leaves the search, editing the entry found unless cancelled
+	endSearch := func(accept bool) {
+		searching = false
+		if accept && match >= 0 {
+			historyIndex = match
+			setEntry(hist.entries[match])
+		} else {
+			redraw()
+		}
 	}
 
 	r := risor.NewConfig()
@@ -66,31 +117,137 @@ func Run(ctx context.Context, options []risor.Option) error {
 		opt(r)
 	}
 
-	evalFunc := getEvaluator(r)
+	e := newEvaluator(r)
+
+	insert := func(text string) {
+		if column < len(accumulate) {
+			rest := accumulate[column:]
+			restLen := len(rest)
+			accumulate = accumulate[:column] + text + rest
+			fmt.Print(getLineText() + fmt.Sprintf(moveBack, restLen))
+		} else {
+			accumulate += text
+			fmt.Print(getLineText())
+		}
+		column += len(text)
+	}
+
+	// newLine continues the entry on the next line
+	newLine := func() {
+		pending = append(pending, accumulate)
+		accumulate = ""
+		column = 0
+		fmt.Print("\n")
+		drawn = len(pending)
+		fmt.Print(getLineText())
+	}
+
+	// submit runs the entry, unless it's incomplete
+	submit := func() {
+		source := strings.Join(append(pending, accumulate), "\n")
+		isCommand := strings.HasPrefix(strings.TrimSpace(source), ":")
+		if !isCommand && incomplete(source) {
+			newLine()
+			return
+		}
+		fmt.Printf("\n")
+		if isCommand {
+			e.command(ctx, source)
+		} else {
+			e.eval(ctx, source)
+		}
+		hist.add(source)
+		historyIndex = len(hist.entries)
+		pending = nil
+		accumulate = ""
+		column = 0
+		drawn = 0
+		fmt.Print(getLineText())
+	}
 
 	// This could certainly use a refactor! But it works for now.
 	return keyboard.Listen(func(key keys.Key) (stop bool, err error) {
+		if searching {
+			switch key.Code {
+			case keys.RuneKey, keys.Space:
+				query += string(key.Runes)
+				match = hist.search(query, len(hist.entries))
+				drawSearch()
+				return false, nil
+			case keys.Backspace:
+				if query != "" {
+					query = query[:len(query)-1]
+					match = hist.search(query, len(hist.entries))
+				}
+				drawSearch()
+				return false, nil
+			case keys.CtrlR:
+				if found := hist.search(query, match); found >= 0 {
+					match = found
+				}
+				drawSearch()
+				return false, nil
+			case keys.Escape, keys.CtrlG, keys.CtrlC:
+				endSearch(false)
+				return false, nil
+			case keys.Enter:
+				endSearch(true)
+				submit()
+				return false, nil
+			default:
+				// Other keys edit the entry found
+				endSearch(true)
+			}
+		}
 		switch key.Code {
 		case keys.Enter:
-			fmt.Printf("\n")
-			evalFunc(ctx, accumulate)
-			appendToHistory(accumulate)
-			history = append(history, accumulate)
-			historyIndex = len(history)
-			accumulate = ""
-			fmt.Print(getLineText())
-			column = 0
-		case keys.RuneKey, keys.Space, keys.Tab:
-			if column < len(accumulate) {
-				rest := accumulate[column:]
-				restLen := len(rest)
-				accumulate = accumulate[:column] + string(key.Runes) + rest
-				fmt.Print(getLineText() + fmt.Sprintf(moveBack, restLen))
-			} else {
-				accumulate += string(key.Runes)
-				fmt.Print(getLineText())
+			submit()
+		case keys.CtrlR:
+			searching = true
+			query = ""
+			match = -1
+			drawSearch()
+		case keys.Tab:
+			start, completions := complete(e, accumulate[:column])
+			word := accumulate[start:column]
+			if len(completions) == 0 {
+				// Nothing to complete, as at the start of a line, so indent
+				if start == column {
+					insert(string(key.Runes))
+				}
+				break
+			}
+			if prefix := commonPrefix(completions); len(prefix) > len(word) {
+				insert(prefix[len(word):])
+				break
+			}
+			labels := make([]string, 0, len(completions))
+			for _, c := range completions {
+				labels = append(labels, c.label)
+			}
+			fmt.Printf("\n%s\n", strings.Join(labels, "  "))
+			drawn = len(pending)
+			redraw()
+		case keys.RuneKey, keys.Space:
+			if len(key.Runes) == 1 {
+				insert(string(key.Runes))
+				break
+			}
+			// The runes read at once, as from a paste, arrive as one key.
+			// Pasted lines are added to the entry rather than run one by
+			// one, and the entry runs if the paste ends a line.
+			lines := pasteLines(string(key.Runes))
+			for i, line := range lines {
+				insert(line)
+				if i == len(lines)-1 {
+					break
+				}
+				if i == len(lines)-2 && lines[i+1] == "" {
+					submit()
+					break
+				}
+				newLine()
 			}
-			column += len(key.Runes)
 		case keys.Backspace:
 			if len(accumulate) > 0 {
 				if column < len(accumulate) {
@@ -107,6 +264,15 @@ func Run(ctx context.Context, options []risor.Option) error {
 				if column > 0 {
 					column--
 				}
+			} else if len(pending) > 0 {
+				// Go back to the end of the line before
+				accumulate = pending[len(pending)-1]
+				pending = pending[:len(pending)-1]
+				column = len(accumulate)
+				fmt.Print(clearLine)
+				fmt.Printf(moveUp, 1)
+				drawn--
+				redraw()
 			}
 		case keys.Delete:
 			if len(accumulate) > 0 {
@@ -126,23 +292,16 @@ func Run(ctx context.Context, options []risor.Option) error {
 			if historyIndex > 0 {
 				historyIndex--
 			}
-			if historyIndex < len(history) {
-				accumulate = history[historyIndex]
-				column = len(accumulate)
-				fmt.Print(getLineText())
+			if historyIndex < len(hist.entries) {
+				setEntry(hist.entries[historyIndex])
 			}
 		case keys.Down:
-			if historyIndex < len(history)-1 {
+			if historyIndex < len(hist.entries)-1 {
 				historyIndex++
-			}
-			if historyIndex < len(history) {
-				accumulate = history[historyIndex]
-				column = len(accumulate)
-				fmt.Print(getLineText())
+				setEntry(hist.entries[historyIndex])
 			} else {
-				column = 0
-				accumulate = ""
-				fmt.Print(getLineText())
+				historyIndex = len(hist.entries)
+				setEntry("")
 			}
 		case keys.Left:
 			if column > 0 {
@@ -158,9 +317,23 @@ func Run(ctx context.Context, options []risor.Option) error {
 			fmt.Print(getLineText() + strings.Repeat("\b", len(accumulate)))
 			column = 0
 		case keys.CtrlE:
-			fmt.Printf(moveForward, len(accumulate)-column)
+			if column < len(accumulate) {
+				fmt.Printf(moveForward, len(accumulate)-column)
+			}
 			column = len(accumulate)
-		case keys.CtrlC, keys.CtrlD:
+		case keys.CtrlC:
+			// Discard the entry, or exit if there is none
+			if len(pending) == 0 && accumulate == "" {
+				fmt.Println()
+				return true, nil
+			}
+			fmt.Print("\n")
+			pending = nil
+			accumulate = ""
+			column = 0
+			drawn = 0
+			fmt.Print(getLineText())
+		case keys.CtrlD:
 			fmt.Println()
 			return true, nil
 		}
@@ -168,54 +341,99 @@ func Run(ctx context.Context, options []risor.Option) error {
 	})
 }
 
-func getEvaluator(cfg *risor.Config) func(ctx context.Context, source string) (object.Object, error) {
-	var c *compiler.Compiler
-	var v *vm.VirtualMachine
+// evaluator compiles and runs the code entered in the REPL, keeping the
+// globals it defines between entries.
+type evaluator struct {
+	cfg      *risor.Config
+	compiler *compiler.Compiler
+	vm       *vm.VirtualMachine
 
-	return func(ctx context.Context, source string) (object.Object, error) {
-		if c == nil {
-			var err error
-			c, err = compiler.New(cfg.CompilerOpts()...)
-			if err != nil {
-				return nil, err
-			}
-		}
+	// The code that ran, for finding the comments on functions
+	sources []string
+}
 
-		ast, err := parser.Parse(ctx, source)
-		if err != nil {
-			color.Red(err.Error())
-			return nil, err
-		}
+func newEvaluator(cfg *risor.Config) *evaluator {
+	return &evaluator{cfg: cfg}
+}
+
+// eval runs the source and prints the value it evaluates to, or the error
+// it fails with.
+func (e *evaluator) eval(ctx context.Context, source string) (object.Object, error) {
+	result, err := e.run(ctx, source)
+	if err != nil {
+		color.Red(err.Error())
+		return nil, err
+	}
+	switch result := result.(type) {
+	case *object.Error:
+		color.Red(result.Value().Error())
+	case *object.NilType:
+	default:
+		fmt.Println(result.Inspect())
+	}
+	return result, nil
+}
 
-		code, err := c.Compile(ast)
+// run runs the source and returns the value it evaluates to.
+func (e *evaluator) run(ctx context.Context, source string) (object.Object, error) {
+	if e.compiler == nil {
+		var err error
+		e.compiler, err = compiler.New(e.cfg.CompilerOpts()...)
 		if err != nil {
-			color.Red(err.Error())
 			return nil, err
 		}
+	}
 
-		if v == nil {
-			v = vm.New(code, cfg.VMOpts()...)
-		}
-		if err := v.Run(ctx); err != nil {
-			// Update the IP to be after the last instruction, so that next
-			// time around we start in the right location.
-			v.SetIP(code.InstructionCount())
-			color.Red(err.Error())
-			return nil, err
-		}
+	ast, err := parser.Parse(ctx, source)
+	if err != nil {
+		return nil, err
+	}
 
-		result, ok := v.TOS()
-		if !ok || result == nil {
-			return object.Nil, nil
-		}
+	code, err := e.compiler.Compile(ast)
+	if err != nil {
+		return nil, err
+	}
+	e.sources = append(e.sources, source)
 
-		switch result := result.(type) {
-		case *object.Error:
-			color.Red(result.Value().Error())
-		case *object.NilType:
-		default:
-			fmt.Println(result.Inspect())
-		}
-		return result, nil
+	if e.vm == nil {
+		e.vm = vm.New(code, e.cfg.VMOpts()...)
+	}
+	if err := e.vm.Run(ctx); err != nil {
+		// Update the IP to be after the last instruction, so that next
+		// time around we start in the right location.
+		e.vm.SetIP(code.InstructionCount())
+		return nil, err
+	}
+
+	result, ok := e.vm.TOS()
+	if !ok || result == nil {
+		return object.Nil, nil
+	}
+	return result, nil
+}
+
+// reset discards the globals defined in the session.
+func (e *evaluator) reset() {
+	e.compiler = nil
+	e.vm = nil
+	e.sources = nil
+}
+
+// globalNames returns the names of the globals, including those defined by
+// the code entered so far.
+func (e *evaluator) globalNames() []string {
+	if e.vm != nil {
+		return e.vm.GlobalNames()
+	}
+	return e.cfg.GlobalNames()
+}
+
+// lookup returns the value of a global.
+func (e *evaluator) lookup(name string) (object.Object, bool) {
+	if e.vm != nil {
+		value, err := e.vm.Get(name)
+		return value, err == nil && value != nil
 	}
+	value, ok := e.cfg.CombinedGlobals()[name].(object.Object)
+	return value, ok
 }
@@ -19,21 +19,47 @@ import (
 	"github.com/mitchellh/go-homedir"
 	"github.com/risor-io/risor"
 	"github.com/risor-io/risor/cmd/risor/repl"
-	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/coverage"
+	"github.com/risor-io/risor/importer"
 	"github.com/risor-io/risor/modules/aws"
+	"github.com/risor-io/risor/modules/azure"
+	"github.com/risor-io/risor/modules/cbor"
 	"github.com/risor-io/risor/modules/cli"
+	"github.com/risor-io/risor/modules/collate"
+	"github.com/risor-io/risor/modules/compress"
+	"github.com/risor-io/risor/modules/crypto"
+	"github.com/risor-io/risor/modules/gcp"
 	"github.com/risor-io/risor/modules/gha"
+	"github.com/risor-io/risor/modules/git"
+	"github.com/risor-io/risor/modules/grpc"
+	"github.com/risor-io/risor/modules/helm"
+	"github.com/risor-io/risor/modules/html"
 	"github.com/risor-io/risor/modules/image"
 	"github.com/risor-io/risor/modules/jmespath"
 	k8s "github.com/risor-io/risor/modules/kubernetes"
+	"github.com/risor-io/risor/modules/metrics"
+	"github.com/risor-io/risor/modules/mqtt"
+	"github.com/risor-io/risor/modules/msgpack"
+	"github.com/risor-io/risor/modules/oauth2"
+	"github.com/risor-io/risor/modules/otel"
+	"github.com/risor-io/risor/modules/parquet"
+	"github.com/risor-io/risor/modules/pdf"
 	"github.com/risor-io/risor/modules/pgx"
+	"github.com/risor-io/risor/modules/proto"
+	"github.com/risor-io/risor/modules/qrcode"
+	modS3 "github.com/risor-io/risor/modules/s3"
 	"github.com/risor-io/risor/modules/sql"
+	"github.com/risor-io/risor/modules/ssh"
 	"github.com/risor-io/risor/modules/template"
 	"github.com/risor-io/risor/modules/uuid"
 	"github.com/risor-io/risor/modules/vault"
+	"github.com/risor-io/risor/modules/xlsx"
+	modYAML "github.com/risor-io/risor/modules/yaml"
 	"github.com/risor-io/risor/object"
 	ros "github.com/risor-io/risor/os"
 	"github.com/risor-io/risor/os/s3fs"
+	"github.com/risor-io/risor/profile"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -41,6 +67,7 @@ import (
 var (
 	cfgFile string
 	red     = color.New(color.FgRed).SprintfFunc()
+	yellow  = color.New(color.FgYellow).SprintfFunc()
 )
 
 func init() {
@@ -52,12 +79,15 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "Config file (default is $HOME/.risor.yaml)")
 	rootCmd.PersistentFlags().StringP("code", "c", "", "Code to evaluate")
 	rootCmd.PersistentFlags().Bool("stdin", false, "Read code from stdin")
-	rootCmd.PersistentFlags().String("cpu-profile", "", "Capture a CPU profile")
+	rootCmd.PersistentFlags().String("cpu-profile", "", "Capture a CPU profile of the Risor executable, by Go function")
 	rootCmd.PersistentFlags().Bool("no-color", false, "Disable colored output")
 	rootCmd.PersistentFlags().Bool("virtual-os", false, "Enable a virtual operating system")
 	rootCmd.PersistentFlags().StringArrayP("mount", "m", []string{}, "Mount a filesystem")
 	rootCmd.PersistentFlags().Bool("no-default-globals", false, "Disable the default globals")
 	rootCmd.PersistentFlags().String("modules", ".", "Path to library modules")
+	rootCmd.PersistentFlags().Bool("no-compile-cache", false, "Disable the cache of compiled modules")
+	rootCmd.PersistentFlags().StringArray("trusted-key", []string{}, "Require remote modules to be signed by this minisign public key file")
+	rootCmd.PersistentFlags().StringArray("plugin", []string{}, "Load a module from a Go plugin exporting a ModuleProvider")
 	rootCmd.PersistentFlags().BoolP("help", "h", false, "Help for Risor")
 
 	viper.BindPFlag("code", rootCmd.PersistentFlags().Lookup("code"))
@@ -68,17 +98,33 @@ func init() {
 	viper.BindPFlag("mount", rootCmd.PersistentFlags().Lookup("mount"))
 	viper.BindPFlag("no-default-globals", rootCmd.PersistentFlags().Lookup("no-default-globals"))
 	viper.BindPFlag("modules", rootCmd.PersistentFlags().Lookup("modules"))
+	viper.BindPFlag("no-compile-cache", rootCmd.PersistentFlags().Lookup("no-compile-cache"))
+	viper.BindPFlag("trusted-key", rootCmd.PersistentFlags().Lookup("trusted-key"))
+	viper.BindPFlag("plugin", rootCmd.PersistentFlags().Lookup("plugin"))
 	viper.BindPFlag("help", rootCmd.PersistentFlags().Lookup("help"))
 
 	// Root command flags
 
 	rootCmd.Flags().Bool("timing", false, "Show timing information")
 	rootCmd.Flags().StringP("output", "o", "", "Set the output format")
+	rootCmd.Flags().Bool("update-lock", false, "Accept remote modules that changed since they were locked")
+	rootCmd.Flags().String("coverage", "", "Write a coverage report of the lines run, as HTML if the path ends in .html, or else LCOV")
+	rootCmd.Flags().String("cpuprofile", "", "Write a CPU profile by Risor function, as folded stacks if the path ends in .folded, or else pprof")
+	rootCmd.Flags().String("memprofile", "", "Write a memory allocation profile by Risor function, as folded stacks if the path ends in .folded, or else pprof")
+	rootCmd.Flags().String("trace", "", "Write a trace of the Risor function calls, for Perfetto or chrome://tracing")
 	rootCmd.RegisterFlagCompletionFunc("output",
 		cobra.FixedCompletions(outputFormatsCompletion, cobra.ShellCompDirectiveNoFileComp))
 	rootCmd.Flags().SetInterspersed(false)
 	viper.BindPFlag("timing", rootCmd.Flags().Lookup("timing"))
 	viper.BindPFlag("output", rootCmd.Flags().Lookup("output"))
+	viper.BindPFlag("update-lock", rootCmd.Flags().Lookup("update-lock"))
+	viper.BindPFlag("coverage", rootCmd.Flags().Lookup("coverage"))
+	viper.BindPFlag("cpuprofile", rootCmd.Flags().Lookup("cpuprofile"))
+	viper.BindPFlag("memprofile", rootCmd.Flags().Lookup("memprofile"))
+	viper.BindPFlag("trace", rootCmd.Flags().Lookup("trace"))
+
+	// Scripts run the same with risor run
+	runCmd.Flags().AddFlagSet(rootCmd.Flags())
 
 	viper.AutomaticEnv()
 }
@@ -152,6 +198,130 @@ func getpassthruargs(args []string) (argsout []string, passedargs []string, drop
 	return
 }
 
+// globalOptions returns the options providing the globals of the CLI,
+// unless the default globals are disabled, and the modules of plugins.
+func globalOptions() []risor.Option {
+	var opts []risor.Option
+	if viper.GetBool("no-default-globals") {
+		opts = append(opts, risor.WithoutDefaultGlobals())
+	} else {
+		globals := map[string]any{
+			"azure":    azure.Module(),
+			"cbor":     cbor.Module(),
+			"cli":      cli.Module(),
+			"collate":  collate.Module(),
+			"compress": compress.Module(),
+			"crypto":   crypto.Module(),
+			"gcp":      gcp.Module(),
+			"gha":      gha.Module(),
+			"git":      git.Module(),
+			"grpc":     grpc.Module(),
+			"html":     html.Module(),
+			"image":    image.Module(),
+			"metrics":  metrics.Module(),
+			"mqtt":     mqtt.Module(),
+			"msgpack":  msgpack.Module(),
+			"oauth2":   oauth2.Module(),
+			"otel":     otel.Module(),
+			"parquet":  parquet.Module(),
+			"pdf":      pdf.Module(),
+			"pgx":      pgx.Module(),
+			"proto":    proto.Module(),
+			"qrcode":   qrcode.Module(),
+			"s3":       modS3.Module(),
+			"sql":      sql.Module(),
+			"ssh":      ssh.Module(),
+			"template": template.Module(),
+			"uuid":     uuid.Module(),
+			"xlsx":     xlsx.Module(),
+		}
+
+		for k, v := range jmespath.Builtins() {
+			globals[k] = v
+		}
+		for k, v := range template.Builtins() {
+			globals[k] = v
+		}
+		opts = append(opts, risor.WithGlobals(globals))
+
+		// AWS support may or may not be compiled in based on build tags
+		if aws := aws.Module(); aws != nil {
+			opts = append(opts, risor.WithGlobal("aws", aws))
+		}
+		// Helm support may or may not be compiled in based on build tags
+		if helm := helm.Module(); helm != nil {
+			opts = append(opts, risor.WithGlobal("helm", helm))
+		}
+		// K8S support may or may not be compiled in based on build tags
+		if k8s := k8s.Module(); k8s != nil {
+			opts = append(opts, risor.WithGlobal("k8s", k8s))
+		}
+		// Vault support may or may not be compiled in based on build tags
+		if vault := vault.Module(); vault != nil {
+			opts = append(opts, risor.WithGlobal("vault", vault))
+		}
+	}
+	// Modules of plugins are added even without the default globals, since
+	// they're asked for explicitly
+	for _, path := range viper.GetStringSlice("plugin") {
+		provider, err := risor.LoadModulePlugin(path)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		opts = append(opts, risor.WithModuleProvider(provider))
+	}
+	return opts
+}
+
+// importOptions returns the options configuring the imports of the script,
+// which is either a module file or an archive. Scripts in a project import
+// remote modules at the versions required by its manifest, in which case
+// the project directory and its lock are returned too.
+func importOptions(cmd *cobra.Command, script string) ([]risor.Option, string, *importer.Lock, error) {
+	var opts []risor.Option
+	modulesDir := viper.GetString("modules")
+	var projectDir string
+	var lock *importer.Lock
+	if script == "" || !isArchive(script) {
+		manifest, dir, err := findManifest(script)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		if manifest != nil {
+			projectDir = dir
+			if lock, err = loadLock(projectDir, viper.GetBool("update-lock")); err != nil {
+				return nil, "", nil, err
+			}
+			opts = append(opts,
+				risor.WithManifest(manifest),
+				risor.WithLock(lock),
+				risor.WithCredentials(credentials()))
+			keys, err := trustedKeys()
+			if err != nil {
+				return nil, "", nil, err
+			}
+			if keys != nil {
+				opts = append(opts, risor.WithTrustedKeys(keys))
+			}
+			if vendor := vendorDir(projectDir); vendor != nil {
+				opts = append(opts, risor.WithVendor(vendor))
+			}
+			// Import the project's own modules from its root directory,
+			// unless told otherwise
+			if !cmd.Flags().Lookup("modules").Changed {
+				modulesDir = projectDir
+			}
+		}
+	}
+	if modulesDir != "" {
+		opts = append(opts, risor.WithLocalImporter(modulesDir))
+	}
+	if !viper.GetBool("no-compile-cache") {
+		opts = append(opts, risor.WithCompileCache(importer.NewCompileCache("")))
+	}
+	return opts, projectDir, lock, nil
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "risor",
 	Short: "Fast and flexible scripting for Go developers and DevOps",
@@ -193,159 +363,209 @@ var rootCmd = &cobra.Command{
 		return files, cobra.ShellCompDirectiveNoSpace
 	},
 
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := context.Background()
-		var passedargs []string
-
-		args, passedargs, _ = getpassthruargs(args)
-		// pass the 'passthru' args to risor's os package
-		ros.SetScriptArgs(passedargs)
-		// Optionally enable a virtual operating system and add it to
-		// the context so that it's made available to Risor VM.
-		if viper.GetBool("virtual-os") {
-			mounts := map[string]*ros.Mount{}
-			m := viper.GetStringSlice("mount")
-			for _, v := range m {
-				fs, dst, err := mountFromSpec(ctx, v)
-				if err != nil {
-					fatal(err.Error())
-				}
-				mounts[dst] = &ros.Mount{
-					Source: fs,
-					Target: dst,
-				}
-			}
-			vos := ros.NewVirtualOS(ctx, ros.WithMounts(mounts), ros.WithArgs(passedargs))
-			ctx = ros.WithOS(ctx, vos)
-		}
-
-		// Disable colored output if no-color is specified
-		if viper.GetBool("no-color") {
-			color.NoColor = true
-		}
+	Run: runScript,
+}
 
-		// Optionally capture a CPU profile to the given path
-		if path := viper.GetString("cpu-profile"); path != "" {
-			f, err := os.Create(path)
+// runScript runs the script or archive given as the first argument, or else
+// the code supplied by the --code or --stdin flags, or otherwise starts the
+// REPL.
+func runScript(cmd *cobra.Command, args []string) {
+	ctx := context.Background()
+	var passedargs []string
+
+	args, passedargs, _ = getpassthruargs(args)
+	// pass the 'passthru' args to risor's os package
+	ros.SetScriptArgs(passedargs)
+	// Optionally enable a virtual operating system and add it to
+	// the context so that it's made available to Risor VM.
+	if viper.GetBool("virtual-os") {
+		mounts := map[string]*ros.Mount{}
+		m := viper.GetStringSlice("mount")
+		for _, v := range m {
+			fs, dst, err := mountFromSpec(ctx, v)
 			if err != nil {
-				fatal(red(err.Error()))
+				fatal(err.Error())
 			}
-			pprof.StartCPUProfile(f)
-			defer pprof.StopCPUProfile()
-		}
-
-		// Build up a list of options to pass to the VM
-		var opts []risor.Option
-		if viper.GetBool("no-default-globals") {
-			opts = append(opts, risor.WithoutDefaultGlobals())
-		} else {
-			globals := map[string]any{
-				"cli":      cli.Module(),
-				"gha":      gha.Module(),
-				"image":    image.Module(),
-				"pgx":      pgx.Module(),
-				"sql":      sql.Module(),
-				"template": template.Module(),
-				"uuid":     uuid.Module(),
+			mounts[dst] = &ros.Mount{
+				Source: fs,
+				Target: dst,
 			}
+		}
+		vos := ros.NewVirtualOS(ctx, ros.WithMounts(mounts), ros.WithArgs(passedargs))
+		ctx = ros.WithOS(ctx, vos)
+	}
 
-			for k, v := range jmespath.Builtins() {
-				globals[k] = v
-			}
-			for k, v := range template.Builtins() {
-				globals[k] = v
-			}
-			opts = append(opts, risor.WithGlobals(globals))
+	// Disable colored output if no-color is specified
+	if viper.GetBool("no-color") {
+		color.NoColor = true
+	}
 
-			// AWS support may or may not be compiled in based on build tags
-			if aws := aws.Module(); aws != nil {
-				opts = append(opts, risor.WithGlobal("aws", aws))
-			}
-			// K8S support may or may not be compiled in based on build tags
-			if k8s := k8s.Module(); k8s != nil {
-				opts = append(opts, risor.WithGlobal("k8s", k8s))
-			}
-			// Vault support may or may not be compiled in based on build tags
-			if vault := vault.Module(); vault != nil {
-				opts = append(opts, risor.WithGlobal("vault", vault))
-			}
-		}
-		if modulesDir := viper.GetString("modules"); modulesDir != "" {
-			opts = append(opts, risor.WithLocalImporter(modulesDir))
+	// Optionally capture a CPU profile to the given path
+	if path := viper.GetString("cpu-profile"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			fatal(red(err.Error()))
 		}
-		opts = append(opts, risor.WithConcurrency())
+		pprof.StartCPUProfile(f)
+		defer pprof.StopCPUProfile()
+	}
 
-		// Determine what code is to be executed. The code may be supplied
-		// via the --code option, a path supplied as an arg, or stdin.
-		codeWasSupplied := cmd.Flags().Lookup("code").Changed
-		code := viper.GetString("code")
-		if len(args) > 0 && codeWasSupplied {
-			fatal(red("cannot specify both code and a filepath"))
+	// Build up a list of options to pass to the VM
+	var script string
+	if len(args) > 0 {
+		script = args[0]
+	} else if len(passedargs) > 0 {
+		script = passedargs[0]
+	}
+	opts := globalOptions()
+	importOpts, projectDir, lock, err := importOptions(cmd, script)
+	if err != nil {
+		fatal(red(err.Error()))
+	}
+	opts = append(opts, importOpts...)
+	opts = append(opts, risor.WithConcurrency())
+
+	// Determine what code is to be executed. The code may be supplied
+	// via the --code option, a path supplied as an arg, or stdin.
+	codeWasSupplied := cmd.Flags().Lookup("code").Changed
+	code := viper.GetString("code")
+	var mainCode *compiler.Code
+	if len(args) > 0 && codeWasSupplied {
+		fatal(red("cannot specify both code and a filepath"))
+	}
+	if len(args) == 0 && !codeWasSupplied && !viper.GetBool("stdin") && len(passedargs) == 0 {
+		if !isTerminalIO() {
+			fatal("cannot show repl: stdin or stdout is not a terminal")
 		}
-		if len(args) == 0 && !codeWasSupplied && !viper.GetBool("stdin") && len(passedargs) == 0 {
-			if !isTerminalIO() {
-				fatal("cannot show repl: stdin or stdout is not a terminal")
-			}
-			if err := repl.Run(ctx, opts); err != nil {
-				fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
-				os.Exit(1)
-			}
-			return
+		// Pick up edits to imported modules between evaluations
+		opts = append(opts, risor.WithHotReload())
+		if err := repl.Run(ctx, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+			os.Exit(1)
 		}
-		if viper.GetBool("stdin") {
-			data, err := io.ReadAll(os.Stdin)
-			if err != nil {
-				fatal(red(err.Error()))
-			}
-			if len(data) == 0 {
-				fatal(red("no code supplied"))
-			}
-			code = string(data)
-		} else if len(args) > 0 {
-			bytes, err := os.ReadFile(args[0])
-			if err != nil {
-				fatal(red(err.Error()))
-			}
-			code = string(bytes)
-		} else if len(passedargs) > 0 {
-			bytes, err := os.ReadFile(passedargs[0])
-			if err != nil {
-				fatal(red(err.Error()))
-			}
-			code = string(bytes)
+		return
+	}
+	if viper.GetBool("stdin") {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fatal(red(err.Error()))
 		}
+		if len(data) == 0 {
+			fatal(red("no code supplied"))
+		}
+		code = string(data)
+	} else if len(args) > 0 && isArchive(args[0]) {
+		archive, err := openArchive(args[0], opts)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		defer archive.Close()
+		if mainCode, code, err = archive.Main(); err != nil {
+			fatal(red("%s: %s", args[0], err))
+		}
+		opts = append(opts, risor.WithImporter(archive))
+	} else if len(args) > 0 {
+		bytes, err := os.ReadFile(args[0])
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		code = string(bytes)
+		opts = append(opts, risor.WithFilename(args[0]))
+	} else if len(passedargs) > 0 {
+		bytes, err := os.ReadFile(passedargs[0])
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		code = string(bytes)
+		opts = append(opts, risor.WithFilename(passedargs[0]))
+	}
 
-		start := time.Now()
+	// Optionally record which lines run, for a coverage report
+	var coverageProfile *coverage.Profile
+	coveragePath := viper.GetString("coverage")
+	if coveragePath != "" {
+		coverageProfile = coverage.NewProfile()
+		opts = append(opts, risor.WithCoverage(coverageProfile))
+	}
 
-		// Execute the code
-		result, err := risor.Eval(ctx, code, opts...)
-		if err != nil {
-			if friendlyErr, ok := err.(errz.FriendlyError); ok {
-				fmt.Fprintf(os.Stderr, "%s\n", red(friendlyErr.FriendlyErrorMessage()))
-			} else {
+	// Optionally profile the Risor functions that run
+	var profiler *profile.Profiler
+	cpuProfilePath := viper.GetString("cpuprofile")
+	memProfilePath := viper.GetString("memprofile")
+	if cpuProfilePath != "" || memProfilePath != "" {
+		profiler = profile.New(0)
+		opts = append(opts, risor.WithProfiler(profiler))
+	}
+	var tracer *profile.Tracer
+	tracePath := viper.GetString("trace")
+	if tracePath != "" {
+		tracer = profile.NewTracer()
+		opts = append(opts, risor.WithTracer(tracer))
+	}
+
+	start := time.Now()
+	if profiler != nil {
+		profiler.Start()
+	}
+
+	// Execute the code
+	var result object.Object
+	if mainCode != nil {
+		result, err = risor.EvalCode(ctx, mainCode, opts...)
+	} else {
+		result, err = risor.Eval(ctx, code, opts...)
+	}
+	if lock != nil {
+		if err := saveLock(projectDir, lock); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+		}
+	}
+	// The reports cover failed runs too, up to the failure
+	if coverageProfile != nil {
+		if err := writeCoverage(coveragePath, coverageProfile); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+		}
+	}
+	if profiler != nil {
+		profiler.Stop()
+		if cpuProfilePath != "" {
+			if err := writeProfile(cpuProfilePath, profiler, profile.CPU); err != nil {
 				fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
 			}
-			os.Exit(1)
 		}
+		if memProfilePath != "" {
+			if err := writeProfile(memProfilePath, profiler, profile.Memory); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+			}
+		}
+	}
+	if tracer != nil {
+		if err := writeTrace(tracePath, tracer); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+		}
+	}
+	if err != nil {
+		printError(os.Stderr, err, newErrorSources(script, code))
+		os.Exit(1)
+	}
 
-		dt := time.Since(start)
+	dt := time.Since(start)
 
-		// Print the result
-		output, err := getOutput(result, viper.GetString("output"))
-		if err != nil {
-			fatal(red(err.Error()))
-		} else if output != "" {
-			fmt.Println(output)
-		}
+	// Print the result
+	output, err := getOutput(result, viper.GetString("output"))
+	if err != nil {
+		fatal(red(err.Error()))
+	} else if output != "" {
+		fmt.Println(output)
+	}
 
-		// Optionally print the execution time
-		if viper.GetBool("timing") {
-			fmt.Printf("%v\n", dt)
-		}
-	},
+	// Optionally print the execution time
+	if viper.GetBool("timing") {
+		fmt.Printf("%v\n", dt)
+	}
 }
 
-var outputFormatsCompletion = []string{"json", "text"}
+var outputFormatsCompletion = []string{"json", "yaml", "table", "text"}
 
 func getOutput(result object.Object, format string) (string, error) {
 	switch strings.ToLower(format) {
@@ -368,6 +588,14 @@ func getOutput(result object.Object, format string) (string, error) {
 			return "", err
 		}
 		return string(output), nil
+	case "yaml":
+		output := modYAML.Marshal(context.Background(), result)
+		if err, ok := output.(*object.Error); ok {
+			return "", err.Value()
+		}
+		return strings.TrimSuffix(output.(*object.String).Value(), "\n"), nil
+	case "table":
+		return getOutputTable(result), nil
 	case "text":
 		return fmt.Sprintf("%v", result), nil
 	default:
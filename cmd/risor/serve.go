@@ -0,0 +1,453 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/vm"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve <script>",
+	Short: "Serve the functions of a script over HTTP",
+	Long: `Run a script as an HTTP service, calling its functions to handle requests.
+
+The script routes requests with a global "routes" map from patterns to
+functions, or else handles them all with a global "handler" function.
+Patterns are a path, optionally preceded by a method, where segments in
+braces match any segment:
+
+  routes := {
+      "GET /users/{id}": get_user,
+      "/hook": hook,
+  }
+
+Handlers are called with a request map, with keys method, path, query,
+headers, params (the segments matched by braces), body (a string), and
+json (the decoded body, if it's JSON). A string result is sent as text, nil
+as no content, and other values as JSON. A map with a status key, and
+optionally headers and body keys, sets the response.
+
+Each request is handled in its own clone of the VM that ran the script, so
+handlers may run concurrently; they share the script's globals. The script
+is run again when a .risor file in its directory changes.`,
+	Example: `  risor serve hooks.risor
+  risor serve --addr localhost:9000 api.risor`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		script := args[0]
+		addr, _ := cmd.Flags().GetString("addr")
+		reload, _ := cmd.Flags().GetBool("reload")
+		maxBody, _ := cmd.Flags().GetInt64("max-body")
+		importOpts, projectDir, lock, err := importOptions(cmd, script)
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		opts := append(globalOptions(), importOpts...)
+		opts = append(opts, risor.WithConcurrency(), risor.WithFilename(script))
+		s := &server{script: script, opts: opts, maxBody: maxBody}
+		app, err := s.load(cmd.Context())
+		if lock != nil {
+			if err := saveLock(projectDir, lock); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", red(err.Error()))
+			}
+		}
+		if err != nil {
+			printError(os.Stderr, err, newErrorSources(script, s.source))
+			os.Exit(1)
+		}
+		s.app = app
+		if reload {
+			go s.watch(cmd.Context(), filepath.Dir(script))
+		}
+		log.Printf("serving %s on %s", script, addr)
+		if err := http.ListenAndServe(addr, s); err != nil {
+			fatal(red(err.Error()))
+		}
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("addr", ":8080", "Address to listen on")
+	serveCmd.Flags().Bool("reload", true, "Run the script again when a .risor file in its directory changes")
+	serveCmd.Flags().Int64("max-body", 10<<20, "Largest request body to read, in bytes")
+}
+
+// server serves the handlers of a script.
+type server struct {
+	script  string
+	opts    []risor.Option
+	source  string
+	maxBody int64
+	mutex   sync.RWMutex
+	app     *serveApp
+}
+
+// serveApp is a run of the script and the handlers it defines.
+type serveApp struct {
+	vm      *vm.VirtualMachine
+	routes  []route
+	handler *object.Function
+}
+
+type route struct {
+	method   string
+	segments []string
+	fn       *object.Function
+}
+
+// load runs the script and finds its handlers.
+func (s *server) load(ctx context.Context) (*serveApp, error) {
+	data, err := os.ReadFile(s.script)
+	if err != nil {
+		return nil, err
+	}
+	s.source = string(data)
+	cfg := risor.NewConfig()
+	for _, opt := range s.opts {
+		opt(cfg)
+	}
+	ast, err := parser.Parse(ctx, s.source, parser.WithFile(s.script))
+	if err != nil {
+		return nil, err
+	}
+	main, err := compiler.Compile(ast, cfg.CompilerOpts()...)
+	if err != nil {
+		return nil, err
+	}
+	machine := vm.New(main, cfg.VMOpts()...)
+	if err := machine.Run(ctx); err != nil {
+		return nil, err
+	}
+	app := &serveApp{vm: machine}
+	if value, err := machine.Get("routes"); err == nil {
+		routes, ok := value.(*object.Map)
+		if !ok {
+			return nil, fmt.Errorf("routes must be a map (got %s)", value.Type())
+		}
+		for _, pattern := range routes.SortedKeys() {
+			fn, ok := routes.Get(pattern).(*object.Function)
+			if !ok {
+				return nil, fmt.Errorf("route %q must be a function (got %s)", pattern, routes.Get(pattern).Type())
+			}
+			app.routes = append(app.routes, newRoute(pattern, fn))
+		}
+		// Literal segments take precedence over braces
+		sort.SliceStable(app.routes, func(i, j int) bool {
+			return app.routes[i].literals() > app.routes[j].literals()
+		})
+	} else if value, err := machine.Get("handler"); err == nil {
+		fn, ok := value.(*object.Function)
+		if !ok {
+			return nil, fmt.Errorf("handler must be a function (got %s)", value.Type())
+		}
+		app.handler = fn
+	} else {
+		return nil, errors.New("the script defines neither routes nor a handler function")
+	}
+	return app, nil
+}
+
+func newRoute(pattern string, fn *object.Function) route {
+	r := route{fn: fn}
+	if method, path, ok := strings.Cut(strings.TrimSpace(pattern), " "); ok {
+		r.method, pattern = strings.ToUpper(method), strings.TrimSpace(path)
+	}
+	r.segments = strings.Split(strings.Trim(pattern, "/"), "/")
+	return r
+}
+
+// literals counts the segments of the route that aren't braces.
+func (r route) literals() int {
+	var n int
+	for _, segment := range r.segments {
+		if !strings.HasPrefix(segment, "{") {
+			n++
+		}
+	}
+	return n
+}
+
+// match returns the segments matched by the braces of the route, or false if
+// the path doesn't match it.
+func (r route) match(method, path string) (map[string]object.Object, bool) {
+	if r.method != "" && r.method != method {
+		return nil, false
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+	params := map[string]object.Object{}
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[segment[1:len(segment)-1]] = object.NewString(segments[i])
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// watch runs the script again when a .risor file in the directory changes,
+// keeping the previous run if it fails.
+func (s *server) watch(ctx context.Context, dir string) {
+	last := latestChange(dir)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		changed := latestChange(dir)
+		if !changed.After(last) {
+			continue
+		}
+		last = changed
+		app, err := s.load(ctx)
+		if err != nil {
+			log.Printf("reloading %s failed, serving the previous version", s.script)
+			printError(os.Stderr, err, newErrorSources(s.script, s.source))
+			continue
+		}
+		s.mutex.Lock()
+		s.app = app
+		s.mutex.Unlock()
+		log.Printf("reloaded %s", s.script)
+	}
+}
+
+// latestChange returns the latest modification time of the .risor files in
+// the directory and its subdirectories, skipping hidden ones.
+func latestChange(dir string) time.Time {
+	var latest time.Time
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && path != dir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || filepath.Ext(path) != ".risor" {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.handle(rec, r)
+	log.Printf("%s %s %s %d %s", r.RemoteAddr, r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Microsecond))
+}
+
+func (s *server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.RLock()
+	app := s.app
+	s.mutex.RUnlock()
+
+	fn := app.handler
+	params := map[string]object.Object{}
+	for _, route := range app.routes {
+		if p, ok := route.match(r.Method, r.URL.Path); ok {
+			fn, params = route.fn, p
+			break
+		}
+	}
+	if fn == nil {
+		writeJSONError(w, http.StatusNotFound, "not found")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxBody)
+	request, err := requestObject(r, params)
+	if err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	clone, err := app.vm.Clone()
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var args []object.Object
+	if len(fn.Parameters()) > 0 {
+		args = []object.Object{request}
+	}
+	result, err := clone.Call(r.Context(), fn, args)
+	if err == nil {
+		if errObj, ok := result.(*object.Error); ok {
+			err = errObj.Value()
+		}
+	}
+	if err != nil {
+		log.Printf("%s %s: %s", r.Method, r.URL.Path, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := writeResult(w, result); err != nil {
+		log.Printf("%s %s: %s", r.Method, r.URL.Path, err)
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// requestObject returns the map handlers are called with.
+func requestObject(r *http.Request, params map[string]object.Object) (*object.Map, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	query := map[string]object.Object{}
+	for name, values := range r.URL.Query() {
+		query[name] = object.NewString(values[0])
+	}
+	headers := map[string]object.Object{}
+	for name, values := range r.Header {
+		headers[strings.ToLower(name)] = object.NewString(strings.Join(values, ", "))
+	}
+	var decoded object.Object = object.Nil
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") && len(body) > 0 {
+		var value any
+		if err := json.Unmarshal(body, &value); err != nil {
+			return nil, fmt.Errorf("invalid json body: %w", err)
+		}
+		decoded = object.FromGoType(value)
+	}
+	return object.NewMap(map[string]object.Object{
+		"method":      object.NewString(r.Method),
+		"path":        object.NewString(r.URL.Path),
+		"query":       object.NewMap(query),
+		"headers":     object.NewMap(headers),
+		"params":      object.NewMap(params),
+		"body":        object.NewString(string(body)),
+		"json":        decoded,
+		"remote_addr": object.NewString(r.RemoteAddr),
+	}), nil
+}
+
+// writeResult writes the result of a handler as the response.
+func writeResult(w http.ResponseWriter, result object.Object) error {
+	status := http.StatusOK
+	if m, ok := result.(*object.Map); ok && isResponseMap(m) {
+		if value, ok := m.Value()["status"]; ok {
+			code, err := object.AsInt(value)
+			if err != nil {
+				return err.Value()
+			}
+			// net/http panics when writing a status outside of this range
+			if code < 100 || code > 999 {
+				return fmt.Errorf("invalid response status %d", code)
+			}
+			status = int(code)
+		}
+		if value, ok := m.Value()["headers"]; ok {
+			headers, err := object.AsMap(value)
+			if err != nil {
+				return err.Value()
+			}
+			for name, value := range headers.Value() {
+				if s, ok := value.(*object.String); ok {
+					w.Header().Set(name, s.Value())
+				} else {
+					w.Header().Set(name, value.Inspect())
+				}
+			}
+		}
+		result = m.Get("body")
+	}
+	switch result := result.(type) {
+	case *object.NilType:
+		if status == http.StatusOK {
+			status = http.StatusNoContent
+		}
+		w.WriteHeader(status)
+		return nil
+	case *object.String:
+		setDefaultHeader(w, "Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		_, err := io.WriteString(w, result.Value())
+		return err
+	case *object.ByteSlice:
+		setDefaultHeader(w, "Content-Type", "application/octet-stream")
+		w.WriteHeader(status)
+		_, err := w.Write(result.Value())
+		return err
+	default:
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		setDefaultHeader(w, "Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, err = w.Write(append(data, '\n'))
+		return err
+	}
+}
+
+// isResponseMap reports whether a map returned by a handler describes the
+// response rather than being its body: it has a status key, and no keys
+// other than status, headers and body.
+func isResponseMap(m *object.Map) bool {
+	if _, ok := m.Value()["status"]; !ok {
+		return false
+	}
+	for key := range m.Value() {
+		switch key {
+		case "status", "headers", "body":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func setDefaultHeader(w http.ResponseWriter, name, value string) {
+	if w.Header().Get(name) == "" {
+		w.Header().Set(name, value)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// statusRecorder records the status of a response, for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
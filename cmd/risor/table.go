@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/risor-io/risor/object"
+)
+
+// getOutputTable renders a result as a table. Lists of maps have a column
+// per key, in the order the keys are first seen with each map's keys sorted,
+// lists of lists have a column per item, and maps have a row per key. Other
+// values are rendered as text.
+func getOutputTable(result object.Object) string {
+	var header []string
+	var rows [][]string
+	switch result := result.(type) {
+	case *object.List:
+		items := result.Value()
+		if isListOf[*object.Map](items) {
+			columns := map[string]int{}
+			for _, item := range items {
+				for _, key := range item.(*object.Map).SortedKeys() {
+					if _, ok := columns[key]; !ok {
+						columns[key] = len(header)
+						header = append(header, key)
+					}
+				}
+			}
+			for _, item := range items {
+				row := make([]string, len(header))
+				for key, value := range item.(*object.Map).Value() {
+					row[columns[key]] = tableCell(value)
+				}
+				rows = append(rows, row)
+			}
+		} else {
+			for _, item := range items {
+				var row []string
+				if list, ok := item.(*object.List); ok {
+					for _, value := range list.Value() {
+						row = append(row, tableCell(value))
+					}
+				} else {
+					row = []string{tableCell(item)}
+				}
+				rows = append(rows, row)
+			}
+		}
+	case *object.Map:
+		header = []string{"KEY", "VALUE"}
+		for _, key := range result.SortedKeys() {
+			rows = append(rows, []string{key, tableCell(result.Get(key))})
+		}
+	default:
+		return tableCell(result)
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// isListOf reports whether the items are all of the given type, and there
+// is at least one.
+func isListOf[T object.Object](items []object.Object) bool {
+	for _, item := range items {
+		if _, ok := item.(T); !ok {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+// tableCell renders a value in a table cell, on a single line.
+func tableCell(value object.Object) string {
+	var text string
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case *object.NilType:
+		return ""
+	case *object.String:
+		text = value.Value()
+	default:
+		text = value.Inspect()
+	}
+	return strings.NewReplacer("\t", " ", "\n", " ", "\r", "").Replace(text)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/risor-io/risor/importer"
+	"github.com/risor-io/risor/modfile"
+	"github.com/spf13/cobra"
+)
+
+var vendorCmd = &cobra.Command{
+	Use:   "vendor",
+	Short: "Copy the remote modules of the project into its vendor directory",
+	Long: `Copy the remote modules required by the project's risor.mod, directly or
+through the manifests of the modules it requires, into the vendor directory
+next to it. Scripts of the project then import the vendored copies rather
+than fetching them, so they run without network access.
+
+The vendored modules are still verified against risor.lock, and against
+the --trusted-key keys, whose signatures are vendored too.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, projectDir, err := findManifest("")
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		if manifest == nil {
+			fatal(red("no %s found", modfile.Name))
+		}
+		keys, err := trustedKeys()
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		dir := filepath.Join(projectDir, importer.VendorDir)
+		requires, err := importer.Vendor(cmd.Context(), dir, importer.ProjectImporterOptions{
+			Manifest:    manifest,
+			TrustedKeys: keys,
+			Credentials: credentials(),
+		})
+		if err != nil {
+			fatal(red(err.Error()))
+		}
+		fmt.Printf("vendored %d module(s) into %s\n", len(requires), dir)
+	},
+}
@@ -12,6 +12,17 @@ type loop struct {
 	breakPos    []int
 }
 
+// Location is the position in the source code that an instruction was
+// compiled from. Lines and columns are one-indexed, and zero if unknown.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+
+	// File is set when the instruction was compiled from a file other than
+	// that of the code, as in a directory package, which is made of several.
+	File string `json:"file,omitempty"`
+}
+
 type Code struct {
 	id           string
 	name         string
@@ -23,6 +34,8 @@ type Code struct {
 	constants    []any
 	names        []string
 	source       string
+	file         string
+	locations    []Location
 	functionID   string
 
 	// Used during compilation only
@@ -63,6 +76,7 @@ func (c *Code) newChild(name, source, funcID string) *Code {
 		parent:     c,
 		symbols:    c.symbols.NewChild(),
 		source:     source,
+		file:       c.file,
 		functionID: funcID,
 	}
 	c.children = append(c.children, child)
@@ -97,6 +111,31 @@ func (c *Code) Source() string {
 	return c.source
 }
 
+// File returns the name of the file the code was compiled from, if the
+// parser was given one.
+func (c *Code) File() string {
+	return c.file
+}
+
+// FileAt returns the name of the file that the instruction at the given
+// index was compiled from, which is that of the code unless the code was
+// compiled from several files.
+func (c *Code) FileAt(index int) string {
+	if file := c.Location(index).File; file != "" {
+		return file
+	}
+	return c.file
+}
+
+// Location returns the source location of the instruction at the given
+// index, which is zero if the code was compiled without locations.
+func (c *Code) Location(index int) Location {
+	if index < 0 || index >= len(c.locations) {
+		return Location{}
+	}
+	return c.locations[index]
+}
+
 func (c *Code) LocalsCount() int {
 	return int(c.symbols.Count())
 }
@@ -105,6 +144,17 @@ func (c *Code) Local(index int) *Symbol {
 	return c.symbols.Symbol(uint16(index))
 }
 
+// FreeCount returns the number of variables the code uses from the
+// functions enclosing it.
+func (c *Code) FreeCount() int {
+	return int(c.symbols.FreeCount())
+}
+
+// Free returns the resolution of the free variable at the given index.
+func (c *Code) Free(index int) *Resolution {
+	return c.symbols.Free(uint16(index))
+}
+
 func (c *Code) GlobalsCount() int {
 	return int(c.symbols.Root().Count())
 }
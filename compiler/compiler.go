@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strings"
 
 	"github.com/risor-io/risor/ast"
 	"github.com/risor-io/risor/op"
@@ -38,8 +39,15 @@ type Compiler struct {
 	// Names of globals to be available during compilation
 	globalNames []string
 
+	// Finds the names exported by modules, for wildcard imports
+	exports ExportsFunc
+
 	// Increments with each function compiled
 	funcIndex int
+
+	// Source location of the node being compiled, recorded for each
+	// instruction emitted
+	location Location
 }
 
 // Option is a configuration function for a Compiler.
@@ -53,6 +61,18 @@ func WithGlobalNames(names []string) Option {
 	}
 }
 
+// ExportsFunc returns the names of the attributes a module exports, given
+// the name of the module as it would be imported.
+type ExportsFunc func(module string) ([]string, error)
+
+// WithExports configures how the compiler finds the names a module exports,
+// which wildcard imports such as "from mod import *" need at compile time.
+func WithExports(fn ExportsFunc) Option {
+	return func(c *Compiler) {
+		c.exports = fn
+	}
+}
+
 // WithCode configures the compiler to compile into the given code object.
 func WithCode(code *Code) Option {
 	return func(c *Compiler) {
@@ -128,6 +148,21 @@ func (c *Compiler) Compile(node ast.Node) (*Code, error) {
 
 // compile the given AST node and all its children.
 func (c *Compiler) compile(node ast.Node) error {
+	// Instructions emitted for a node are attributed to its location, and
+	// those emitted after its children to the location of the node again.
+	// Those a block emits itself are attributed to the enclosing statement,
+	// rather than to the line of its brace.
+	if _, isBlock := node.(*ast.Block); !isBlock && node.Token().Type != "" {
+		tok := node.Token()
+		defer func(location Location) { c.location = location }(c.location)
+		pos := tok.StartPosition
+		c.location = Location{Line: pos.LineNumber(), Column: pos.ColumnNumber()}
+		if c.main.file == "" && pos.File != "" {
+			c.main.file = pos.File
+		} else if pos.File != c.main.file {
+			c.location.File = pos.File
+		}
+	}
 	switch node := node.(type) {
 	case *ast.Nil:
 		if err := c.compileNil(node); err != nil {
@@ -592,6 +627,9 @@ func (c *Compiler) compileSwitch(node *ast.Switch) error {
 
 func (c *Compiler) compileImport(node *ast.Import) error {
 	name := node.Name().String()
+	if node.Name().Token().Type == token.IDENT {
+		name = strings.ReplaceAll(name, ".", "/")
+	}
 	c.emit(op.LoadConst, c.constant(name))
 	c.emit(op.Import)
 	if node.Alias() != nil {
@@ -619,6 +657,9 @@ func (c *Compiler) compileFromImport(node *ast.FromImport) error {
 	if len(node.Parents()) > 255 {
 		return fmt.Errorf("compile error: too many parents in from-import")
 	}
+	if node.IsWildcard() {
+		return c.compileWildcardImport(node)
+	}
 	for _, parent := range node.Parents() {
 		c.emit(op.LoadConst, c.constant(parent.String()))
 	}
@@ -655,6 +696,50 @@ func (c *Compiler) compileFromImport(node *ast.FromImport) error {
 	return nil
 }
 
+// compileWildcardImport imports a module and binds each name it exports,
+// except names starting with an underscore, which are private to the module,
+// and names of globals, which are already bound.
+func (c *Compiler) compileWildcardImport(node *ast.FromImport) error {
+	var parents []string
+	for _, parent := range node.Parents() {
+		parents = append(parents, parent.String())
+	}
+	name := strings.Join(parents, "/")
+	if c.exports == nil {
+		return fmt.Errorf("compile error: wildcard import of %q requires an importer", name)
+	}
+	exports, err := c.exports(name)
+	if err != nil {
+		return err
+	}
+	globals := make(map[string]bool, len(c.globalNames))
+	for _, global := range c.globalNames {
+		globals[global] = true
+	}
+	c.emit(op.LoadConst, c.constant(name))
+	c.emit(op.Import)
+	for _, export := range exports {
+		if strings.HasPrefix(export, "_") || globals[export] {
+			continue
+		}
+		sym, found := c.current.symbols.Get(export)
+		if !found {
+			if sym, err = c.current.symbols.InsertConstant(export); err != nil {
+				return err
+			}
+		}
+		c.emit(op.Copy, 0)
+		c.emit(op.LoadAttr, c.current.addName(export))
+		if c.current.parent == nil {
+			c.emit(op.StoreGlobal, sym.Index())
+		} else {
+			c.emit(op.StoreFast, sym.Index())
+		}
+	}
+	c.emit(op.PopTop)
+	return nil
+}
+
 func (c *Compiler) compileSlice(node *ast.Slice) error {
 	if err := c.compile(node.Left()); err != nil {
 		return err
@@ -1782,6 +1867,9 @@ func (c *Compiler) emit(opcode op.Code, operands ...uint16) int {
 	code := c.current
 	pos := len(code.instructions)
 	code.instructions = append(code.instructions, inst...)
+	for range inst {
+		code.locations = append(code.locations, c.location)
+	}
 	return pos
 }
 
@@ -1,10 +1,12 @@
 package compiler
 
 import (
+	"context"
 	"testing"
 
 	"github.com/risor-io/risor/ast"
 	"github.com/risor-io/risor/op"
+	"github.com/risor-io/risor/parser"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,3 +19,22 @@ func TestNil(t *testing.T) {
 	instr := scope.Instruction(0)
 	require.Equal(t, op.Nil, op.Code(instr))
 }
+
+func TestLocations(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "x := 1\nfunc f() {\n    return [x,\n        2]\n}\n", parser.WithFile("main.risor"))
+	require.Nil(t, err)
+	code, err := Compile(program)
+	require.Nil(t, err)
+	require.Equal(t, "main.risor", code.File())
+	// Loading the 1 of x := 1
+	require.Equal(t, Location{Line: 1, Column: 6}, code.Location(0))
+	require.Equal(t, Location{}, code.Location(code.InstructionCount()))
+
+	fn := code.Flatten()[1]
+	require.Equal(t, "main.risor", fn.File())
+	lines := map[int]bool{}
+	for i := 0; i < fn.InstructionCount(); i++ {
+		lines[fn.Location(i).Line] = true
+	}
+	require.Equal(t, map[int]bool{3: true, 4: true}, lines)
+}
@@ -99,6 +99,8 @@ type codeDef struct {
 	Constants     []json.RawMessage `json:"constants,omitempty"`
 	Names         []string          `json:"names,omitempty"`
 	Source        string            `json:"source,omitempty"`
+	File          string            `json:"file,omitempty"`
+	Locations     []Location        `json:"locations,omitempty"`
 }
 
 // A representation of a Code object that can be marshalled more easily.
@@ -140,6 +142,8 @@ func codeFromState(state *state) (*Code, error) {
 			constants:    constants,
 			names:        copyStrings(c.Names),
 			source:       c.Source,
+			file:         c.File,
+			locations:    copyLocations(c.Locations),
 		}
 		codesByID[code.id] = code
 		codes = append(codes, code)
@@ -344,6 +348,8 @@ func stateFromCode(code *Code) (*state, error) {
 			Name:          code.name,
 			Names:         copyStrings(code.names),
 			Source:        code.source,
+			File:          code.file,
+			Locations:     copyLocations(code.locations),
 		}
 		if code.parent != nil {
 			cdef.ParentID = code.parent.id
@@ -420,6 +426,15 @@ func copyStrings(src []string) []string {
 	return dst
 }
 
+func copyLocations(src []Location) []Location {
+	if src == nil {
+		return nil
+	}
+	dst := make([]Location, len(src))
+	copy(dst, src)
+	return dst
+}
+
 func CopyInstructions(src []op.Code) []op.Code {
 	dst := make([]op.Code, len(src))
 	copy(dst, src)
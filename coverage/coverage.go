@@ -0,0 +1,143 @@
+// Package coverage records which lines of Risor source code run, and reports
+// them in the LCOV format, read by most coverage services, or as HTML.
+//
+// A Profile is given to the VM with vm.WithCoverage, which counts the
+// executions of the instructions of the code it loads. Each instruction is
+// attributed to the source line it was compiled from, so only code parsed
+// with a file name, such as with parser.WithFile, is recorded.
+package coverage
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/op"
+)
+
+// Profile holds the execution counts of the instructions of code, shared by
+// all the VMs recording to it. It is safe for concurrent use.
+type Profile struct {
+	mutex  sync.Mutex
+	counts map[*compiler.Code][]uint64
+	order  []*compiler.Code
+}
+
+// NewProfile returns an empty Profile.
+func NewProfile() *Profile {
+	return &Profile{counts: map[*compiler.Code][]uint64{}}
+}
+
+// Counters returns the counters of the executions of the instructions of
+// the code, by instruction index, which are incremented atomically. It
+// returns nil if the code has no file, since its lines can't be reported.
+func (p *Profile) Counters(code *compiler.Code) []uint64 {
+	if code.File() == "" {
+		return nil
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if counts, ok := p.counts[code]; ok {
+		return counts
+	}
+	counts := make([]uint64, code.InstructionCount())
+	p.counts[code] = counts
+	p.order = append(p.order, code)
+	return counts
+}
+
+// Line is the execution count of a line of source code.
+type Line struct {
+	Number int
+	Count  uint64
+}
+
+// File is the coverage of a source file.
+type File struct {
+	Name string
+
+	// Lines holding code, in order. Lines without code, such as comments,
+	// aren't included.
+	Lines []Line
+}
+
+// Covered returns the number of lines that ran.
+func (f File) Covered() int {
+	covered := 0
+	for _, line := range f.Lines {
+		if line.Count > 0 {
+			covered++
+		}
+	}
+	return covered
+}
+
+// Percent returns the percentage of the lines holding code that ran.
+func (f File) Percent() float64 {
+	if len(f.Lines) == 0 {
+		return 100
+	}
+	return 100 * float64(f.Covered()) / float64(len(f.Lines))
+}
+
+// Files returns the coverage of the files recorded so far, sorted by name.
+// The count of a line is that of the instruction on it that ran the most.
+func (p *Profile) Files() []File {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	// Functions that were never called weren't loaded, and are found as
+	// children of the code that declares them
+	var codes []*compiler.Code
+	seen := map[*compiler.Code]bool{}
+	for _, code := range p.order {
+		root := code.Root()
+		if !seen[root] {
+			seen[root] = true
+			codes = append(codes, root.Flatten()...)
+		}
+	}
+	lines := map[string]map[int]uint64{}
+	for _, code := range codes {
+		if code.File() == "" {
+			continue
+		}
+		counts := p.counts[code]
+		// Operands share the location of their instruction, and aren't
+		// counted themselves
+		count := code.InstructionCount()
+		for i := 0; i < count; i += 1 + op.GetInfo(code.Instruction(i)).OperandCount {
+			number := code.Location(i).Line
+			if number == 0 {
+				continue
+			}
+			fileLines, ok := lines[code.FileAt(i)]
+			if !ok {
+				fileLines = map[int]uint64{}
+				lines[code.FileAt(i)] = fileLines
+			}
+			var executions uint64
+			if counts != nil {
+				executions = atomic.LoadUint64(&counts[i])
+			}
+			if current, found := fileLines[number]; !found || executions > current {
+				fileLines[number] = executions
+			}
+		}
+	}
+	files := make([]File, 0, len(lines))
+	for name, fileLines := range lines {
+		file := File{Name: name, Lines: make([]Line, 0, len(fileLines))}
+		for number, count := range fileLines {
+			file.Lines = append(file.Lines, Line{Number: number, Count: count})
+		}
+		sort.Slice(file.Lines, func(i, j int) bool {
+			return file.Lines[i].Number < file.Lines[j].Number
+		})
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files
+}
@@ -0,0 +1,97 @@
+package coverage_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/coverage"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/vm"
+	"github.com/stretchr/testify/require"
+)
+
+const source = `func check(n) {
+    if n > 1 {
+        return "big"
+    }
+    return "small"
+}
+
+func unused() {
+    return 1
+}
+
+// comment
+for i := 0; i < 3; i++ {
+    check(5)
+}
+`
+
+func run(t *testing.T, file, src string, profile *coverage.Profile) {
+	t.Helper()
+	ctx := context.Background()
+	program, err := parser.Parse(ctx, src, parser.WithFile(file))
+	require.Nil(t, err)
+	code, err := compiler.Compile(program)
+	require.Nil(t, err)
+	_, err = vm.Run(ctx, code, vm.WithCoverage(profile))
+	require.Nil(t, err)
+}
+
+func TestProfile(t *testing.T) {
+	profile := coverage.NewProfile()
+	run(t, "main.risor", source, profile)
+	files := profile.Files()
+	require.Len(t, files, 1)
+	require.Equal(t, "main.risor", files[0].Name)
+	require.Equal(t, []coverage.Line{
+		{Number: 1, Count: 1},
+		{Number: 2, Count: 3},
+		{Number: 3, Count: 3},
+		{Number: 5, Count: 0},
+		{Number: 8, Count: 1},
+		{Number: 9, Count: 0},
+		{Number: 13, Count: 4},
+		{Number: 14, Count: 3},
+	}, files[0].Lines)
+	require.Equal(t, 6, files[0].Covered())
+	require.Equal(t, 75.0, files[0].Percent())
+
+	// Code without a file isn't recorded
+	run(t, "", "x := 1", profile)
+	require.Len(t, profile.Files(), 1)
+}
+
+func TestWriteLCOV(t *testing.T) {
+	files := []coverage.File{{
+		Name:  "main.risor",
+		Lines: []coverage.Line{{Number: 1, Count: 2}, {Number: 3, Count: 0}},
+	}}
+	var buf bytes.Buffer
+	require.Nil(t, coverage.WriteLCOV(&buf, files))
+	require.Equal(t, "TN:\nSF:main.risor\nDA:1,2\nDA:3,0\nLF:2\nLH:1\nend_of_record\n", buf.String())
+
+	buf.Reset()
+	require.Nil(t, coverage.WriteSummary(&buf, files))
+	require.Equal(t, "main.risor\t50.0% of lines\ntotal\t50.0% of lines\n", buf.String())
+}
+
+func TestWriteHTML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.risor")
+	require.Nil(t, os.WriteFile(path, []byte("x := 1\n// <comment>\nif x > 1 {\n    x = 2\n}\n"), 0o644))
+	profile := coverage.NewProfile()
+	src, err := os.ReadFile(path)
+	require.Nil(t, err)
+	run(t, path, string(src), profile)
+
+	var buf bytes.Buffer
+	require.Nil(t, coverage.WriteHTML(&buf, profile.Files()))
+	html := buf.String()
+	require.Contains(t, html, `<tr class="covered"><td class="number">1</td><td class="count">1</td><td class="text">x := 1</td></tr>`)
+	require.Contains(t, html, `<tr class=""><td class="number">2</td><td class="count"></td><td class="text">// &lt;comment&gt;</td></tr>`)
+	require.Contains(t, html, `<tr class="uncovered"><td class="number">4</td><td class="count">0</td><td class="text">    x = 2</td></tr>`)
+}
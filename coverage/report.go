@@ -0,0 +1,123 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+)
+
+// WriteLCOV writes the coverage of the files in the LCOV tracefile format.
+func WriteLCOV(w io.Writer, files []File) error {
+	bw := bufio.NewWriter(w)
+	for _, file := range files {
+		fmt.Fprintf(bw, "TN:\nSF:%s\n", file.Name)
+		for _, line := range file.Lines {
+			fmt.Fprintf(bw, "DA:%d,%d\n", line.Number, line.Count)
+		}
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\nend_of_record\n", len(file.Lines), file.Covered())
+	}
+	return bw.Flush()
+}
+
+// WriteSummary writes the percentage of the lines of each file that ran,
+// and of all the files, as text.
+func WriteSummary(w io.Writer, files []File) error {
+	bw := bufio.NewWriter(w)
+	var total File
+	for _, file := range files {
+		fmt.Fprintf(bw, "%s\t%.1f%% of lines\n", file.Name, file.Percent())
+		total.Lines = append(total.Lines, file.Lines...)
+	}
+	fmt.Fprintf(bw, "total\t%.1f%% of lines\n", total.Percent())
+	return bw.Flush()
+}
+
+type htmlLine struct {
+	Number int
+	Text   string
+
+	// "covered", "uncovered", or empty for lines without code
+	Class string
+	Count uint64
+}
+
+type htmlFile struct {
+	Name    string
+	Percent float64
+	Lines   []htmlLine
+}
+
+var htmlTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Risor coverage</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; font-family: monospace; width: 100%; }
+td { padding: 0 0.5em; white-space: pre; vertical-align: top; }
+td.number, td.count { color: #888; text-align: right; width: 1%; }
+tr.covered td.text { background: #dfd; }
+tr.uncovered td.text { background: #fdd; }
+</style>
+</head>
+<body>
+<h1>Coverage</h1>
+<ul>
+{{- range $i, $f := .}}
+<li><a href="#file{{$i}}">{{$f.Name}}</a> {{printf "%.1f" $f.Percent}}%</li>
+{{- end}}
+</ul>
+{{- range $i, $f := .}}
+<h2 id="file{{$i}}">{{$f.Name}} {{printf "%.1f" $f.Percent}}%</h2>
+<table>
+{{- range $f.Lines}}
+<tr class="{{.Class}}"><td class="number">{{.Number}}</td><td class="count">{{if .Class}}{{.Count}}{{end}}</td><td class="text">{{.Text}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the coverage of the files as an HTML page showing their
+// source, with the lines that ran and those that didn't highlighted. The
+// sources are read from the files, and only the lines holding code are
+// shown for files that can't be read.
+func WriteHTML(w io.Writer, files []File) error {
+	pages := make([]htmlFile, 0, len(files))
+	for _, file := range files {
+		page := htmlFile{Name: file.Name, Percent: file.Percent()}
+		counts := map[int]uint64{}
+		for _, line := range file.Lines {
+			counts[line.Number] = line.Count
+		}
+		if src, err := os.ReadFile(file.Name); err == nil {
+			text := strings.TrimSuffix(string(src), "\n")
+			for i, lineText := range strings.Split(text, "\n") {
+				page.Lines = append(page.Lines, htmlLine{Number: i + 1, Text: lineText})
+			}
+		} else {
+			for _, line := range file.Lines {
+				page.Lines = append(page.Lines, htmlLine{Number: line.Number})
+			}
+		}
+		for i, line := range page.Lines {
+			count, ok := counts[line.Number]
+			if !ok {
+				continue
+			}
+			page.Lines[i].Count = count
+			if count > 0 {
+				page.Lines[i].Class = "covered"
+			} else {
+				page.Lines[i].Class = "uncovered"
+			}
+		}
+		pages = append(pages, page)
+	}
+	return htmlTemplate.Execute(w, pages)
+}
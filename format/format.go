@@ -0,0 +1,840 @@
+// Package format prints Risor source code in a canonical style.
+//
+// Statements are printed one per line, indented by four spaces per level,
+// with single spaces around binary operators and after commas, and no more
+// than one blank line between statements. Comments and the parentheses
+// written in the source are kept. Lists, maps, sets, and call arguments
+// whose first item starts on a new line are printed one item per line, each
+// followed by a comma, and otherwise on a single line.
+package format
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/lexer"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/token"
+)
+
+// Indent is the indentation of each level of nested blocks.
+const Indent = "    "
+
+// Source formats the given Risor source code. An error is returned if the
+// source doesn't parse.
+func Source(ctx context.Context, src []byte) ([]byte, error) {
+	program, err := parser.Parse(ctx, string(src))
+	if err != nil {
+		return nil, err
+	}
+	p, err := newPrinter(string(src))
+	if err != nil {
+		return nil, err
+	}
+	p.statements(program.Statements(), len(p.src))
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.out.Bytes(), nil
+}
+
+// printer prints a parsed program, using the tokens of its source to find
+// the comments, parentheses, and line breaks the AST doesn't hold.
+type printer struct {
+	src      []rune
+	toks     []token.Token       // tokens of the source, without newlines
+	comments []token.Token       // comments of the source
+	next     int                 // index of the next comment to print
+	index    map[int]int         // index of each token by its start character
+	closing  map[int]int         // index of the closing token of each opening token
+	calls    map[int]bool        // opening parentheses of call arguments
+	spans    map[ast.Node][2]int // spans of nodes, without enclosing parentheses
+	out      bytes.Buffer
+	indent   int
+	bol      bool // whether the output is at the beginning of a line
+	last     int  // source line of the last code printed
+	err      error
+}
+
+func newPrinter(src string) (*printer, error) {
+	l := lexer.New(src, lexer.WithComments())
+	p := &printer{
+		src:     []rune(src),
+		index:   map[int]int{},
+		closing: map[int]int{},
+		calls:   map[int]bool{},
+		spans:   map[ast.Node][2]int{},
+		bol:     true,
+	}
+	var open []int
+	for {
+		tok, err := l.Next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Type == token.EOF {
+			break
+		}
+		if tok.Type == token.NEWLINE {
+			continue
+		}
+		i := len(p.toks)
+		p.toks = append(p.toks, tok)
+		p.index[tok.StartPosition.Char] = i
+		switch tok.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			open = append(open, i)
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			if len(open) > 0 {
+				p.closing[open[len(open)-1]] = i
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	p.comments = l.Comments()
+	return p, nil
+}
+
+// write writes code, indenting it if it starts a line.
+func (p *printer) write(s string) {
+	if p.bol {
+		p.out.WriteString(strings.Repeat(Indent, p.indent))
+		p.bol = false
+	}
+	p.out.WriteString(s)
+}
+
+// newline ends the current line.
+func (p *printer) newline() {
+	p.out.WriteByte('\n')
+	p.bol = true
+}
+
+// at returns the index of the given token.
+func (p *printer) at(tok token.Token) int {
+	i, ok := p.index[tok.StartPosition.Char]
+	if !ok {
+		p.fail(tok, "unknown token %q", tok.Literal)
+	}
+	return i
+}
+
+// char returns the position of the token with the given index in the
+// source, or the end of the source if there is no such token.
+func (p *printer) char(i int) int {
+	if i < 0 || i >= len(p.toks) {
+		return len(p.src)
+	}
+	return p.toks[i].StartPosition.Char
+}
+
+// line returns the source line on which the token with the given index
+// ends.
+func (p *printer) line(i int) int {
+	if i < 0 || i >= len(p.toks) {
+		return p.last
+	}
+	return p.toks[i].EndPosition.Line
+}
+
+// text returns the source text of the token with the given index.
+func (p *printer) text(i int) string {
+	tok := p.toks[i]
+	end := tok.EndPosition.Char + 1
+	if end > len(p.src) {
+		end = len(p.src)
+	}
+	return string(p.src[tok.StartPosition.Char:end])
+}
+
+func (p *printer) fail(tok token.Token, msg string, args ...interface{}) {
+	if p.err == nil {
+		p.err = fmt.Errorf("format error: line %d: %s", tok.StartPosition.LineNumber(), fmt.Sprintf(msg, args...))
+	}
+}
+
+// pending returns true if a comment that wasn't printed yet starts before
+// the given position.
+func (p *printer) pending(char int) bool {
+	return p.next < len(p.comments) && p.comments[p.next].StartPosition.Char < char
+}
+
+// leading prints the comments before the given position, each on its own
+// line, and then the blank line, if any, before the code at that position.
+// No blank line is printed before the first item of a block.
+func (p *printer) leading(char, line int, first bool) {
+	for p.pending(char) {
+		c := p.comments[p.next]
+		if !first && c.StartPosition.Line > p.last+1 {
+			p.newline()
+		}
+		p.write(c.Literal)
+		p.newline()
+		p.last = c.EndPosition.Line
+		p.next++
+		first = false
+	}
+	if !first && line > p.last+1 {
+		p.newline()
+	}
+}
+
+// trailing prints the comments before the given position that are on the
+// line of the last code printed, or before it, at the end of the line.
+func (p *printer) trailing(char int) {
+	for p.pending(char) && p.comments[p.next].StartPosition.Line <= p.last {
+		p.write(" " + p.comments[p.next].Literal)
+		p.next++
+	}
+}
+
+// statements prints a list of statements, each on its own line, followed
+// by the comments before the given end position.
+func (p *printer) statements(stmts []ast.Node, end int) {
+	for i, stmt := range stmts {
+		// The parser follows an increment or a decrement with the name it
+		// applies to, which is printed once as part of the Postfix
+		if i+1 < len(stmts) && isPostfixName(stmt, stmts[i+1]) {
+			continue
+		}
+		first, _ := p.ext(stmt)
+		p.leading(p.char(first), p.toks[first].StartPosition.Line, i == 0)
+		p.node(stmt)
+		limit := end
+		if i+1 < len(stmts) {
+			next, _ := p.ext(stmts[i+1])
+			limit = p.char(next)
+		}
+		p.trailing(limit)
+		p.newline()
+	}
+	p.leading(end, p.last, len(stmts) == 0)
+}
+
+func isPostfixName(node, next ast.Node) bool {
+	ident, ok := node.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	postfix, ok := next.(*ast.Postfix)
+	return ok && postfix.Token().StartPosition.Char == ident.Token().StartPosition.Char
+}
+
+// block prints a block of statements between braces.
+func (p *printer) block(b *ast.Block) {
+	open := p.at(b.Token())
+	close := p.closing[open]
+	stmts := b.Statements()
+	p.write("{")
+	p.last = p.line(open)
+	if len(stmts) == 0 && !p.pending(p.char(close)) {
+		p.write("}")
+		p.last = p.line(close)
+		return
+	}
+	limit := p.char(close)
+	if len(stmts) > 0 {
+		first, _ := p.ext(stmts[0])
+		limit = p.char(first)
+	}
+	p.trailing(limit)
+	p.newline()
+	p.indent++
+	p.statements(stmts, p.char(close))
+	p.indent--
+	p.write("}")
+	p.last = p.line(close)
+}
+
+// list prints items between an opening token, with the given index, and
+// its closing token. The items are printed on one line or, if the first
+// starts on a later line than the opening token, each on its own line
+// followed by a comma. Each item is given by the index of its first token
+// and printed by the given function.
+func (p *printer) list(openIdx int, open, close string, starts []int, item func(i int)) {
+	closeIdx := p.closing[openIdx]
+	p.write(open)
+	p.last = p.line(openIdx)
+	multiline := len(starts) > 0 && p.toks[starts[0]].StartPosition.Line > p.last
+	if len(starts) == 0 {
+		multiline = p.pending(p.char(closeIdx))
+	}
+	if !multiline {
+		for i := range starts {
+			if i > 0 {
+				p.write(", ")
+			}
+			item(i)
+		}
+		p.write(close)
+		p.last = p.line(closeIdx)
+		return
+	}
+	limit := p.char(closeIdx)
+	if len(starts) > 0 {
+		limit = p.char(starts[0])
+	}
+	p.trailing(limit)
+	p.newline()
+	p.indent++
+	for i, start := range starts {
+		p.leading(p.char(start), p.toks[start].StartPosition.Line, i == 0)
+		item(i)
+		p.write(",")
+		limit := p.char(closeIdx)
+		if i+1 < len(starts) {
+			limit = p.char(starts[i+1])
+		}
+		p.trailing(limit)
+		p.newline()
+	}
+	p.leading(p.char(closeIdx), p.last, len(starts) == 0)
+	p.indent--
+	p.write(close)
+	p.last = p.line(closeIdx)
+}
+
+// nodes returns the indices of the first tokens of the given nodes.
+func (p *printer) nodes(nodes []ast.Expression) []int {
+	starts := make([]int, 0, len(nodes))
+	for _, node := range nodes {
+		first, _ := p.ext(node)
+		starts = append(starts, first)
+	}
+	return starts
+}
+
+// ext returns the indices of the first and last tokens of a node, including
+// the parentheses around it in the source.
+func (p *printer) ext(node ast.Node) (int, int) {
+	first, last := p.span(node)
+	for first > 0 && last+1 < len(p.toks) &&
+		p.toks[first-1].Type == token.LPAREN && !p.calls[first-1] &&
+		p.closing[first-1] == last+1 {
+		first--
+		last++
+	}
+	return first, last
+}
+
+// span returns the indices of the first and last tokens of a node, without
+// any parentheses around it.
+func (p *printer) span(node ast.Node) (int, int) {
+	if s, ok := p.spans[node]; ok {
+		return s[0], s[1]
+	}
+	first, last := p.spanOf(node)
+	p.spans[node] = [2]int{first, last}
+	return first, last
+}
+
+func (p *printer) spanOf(node ast.Node) (int, int) {
+	start := p.at(node.Token())
+	switch n := node.(type) {
+	case *ast.Prefix:
+		_, last := p.ext(n.Right())
+		return start, last
+	case *ast.Infix:
+		first, _ := p.ext(n.Left())
+		_, last := p.ext(n.Right())
+		return first, last
+	case *ast.If:
+		alt := n.Alternative()
+		if alt == nil {
+			return start, p.closing[p.at(n.Consequence().Token())]
+		}
+		if alt.Token().Type == token.IF {
+			_, last := p.ext(alt.Statements()[0])
+			return start, last
+		}
+		return start, p.closing[p.at(alt.Token())]
+	case *ast.Ternary:
+		first, _ := p.ext(n.Condition())
+		_, last := p.ext(n.IfFalse())
+		return first, last
+	case *ast.Call:
+		first, _ := p.ext(n.Function())
+		return first, p.closing[start]
+	case *ast.GetAttr:
+		first, _ := p.ext(n.Object())
+		return first, start + 1
+	case *ast.Pipe:
+		exprs := n.Expressions()
+		first, _ := p.ext(exprs[0])
+		_, last := p.ext(exprs[len(exprs)-1])
+		return first, last
+	case *ast.ObjectCall:
+		first, _ := p.ext(n.Object())
+		_, last := p.ext(n.Call())
+		return first, last
+	case *ast.Index:
+		first, _ := p.ext(n.Left())
+		return first, p.closing[start]
+	case *ast.Slice:
+		first, _ := p.ext(n.Left())
+		return first, p.closing[start]
+	case *ast.Switch:
+		_, last := p.ext(n.Value())
+		return start, p.closing[last+1]
+	case *ast.In:
+		first, _ := p.ext(n.Left())
+		_, last := p.ext(n.Right())
+		return first, last
+	case *ast.Range:
+		_, last := p.ext(n.Container())
+		return start, last
+	case *ast.Receive:
+		_, last := p.ext(n.Channel())
+		return start, last
+	case *ast.Func:
+		return start, p.closing[p.at(n.Body().Token())]
+	case *ast.List, *ast.Map, *ast.Set, *ast.Block:
+		return start, p.closing[start]
+	case *ast.Var:
+		_, value := n.Value()
+		_, last := p.ext(value)
+		return start, last
+	case *ast.MultiVar:
+		_, value := n.Value()
+		_, last := p.ext(value)
+		return start, last
+	case *ast.Const:
+		_, value := n.Value()
+		_, last := p.ext(value)
+		return start, last
+	case *ast.Return:
+		if n.Value() == nil {
+			return start, start
+		}
+		_, last := p.ext(n.Value())
+		return start, last
+	case *ast.For:
+		return start, p.closing[p.at(n.Consequence().Token())]
+	case *ast.Assign:
+		first := start - 1
+		if n.Index() != nil {
+			first, _ = p.ext(n.Index())
+		}
+		_, last := p.ext(n.Value())
+		return first, last
+	case *ast.Import:
+		if n.Alias() != nil {
+			return start, p.at(n.Alias().Token())
+		}
+		return start, p.at(n.Name().Token())
+	case *ast.FromImport:
+		imports := n.Imports()
+		if n.IsGrouped() {
+			return start, p.closing[p.at(imports[0].Token())+1]
+		}
+		im := imports[len(imports)-1]
+		if im.Alias() != nil {
+			return start, p.at(im.Alias().Token())
+		}
+		return start, p.at(im.Name().Token())
+	case *ast.Postfix:
+		return start, start + 1
+	case *ast.SetAttr:
+		first, _ := p.ext(n.Object())
+		_, last := p.ext(n.Value())
+		return first, last
+	case *ast.Go:
+		_, last := p.ext(n.Call())
+		return start, last
+	case *ast.Defer:
+		_, last := p.ext(n.Call())
+		return start, last
+	case *ast.Send:
+		first, _ := p.ext(n.Channel())
+		_, last := p.ext(n.Value())
+		return first, last
+	}
+	return start, start
+}
+
+// node prints a statement or an expression, with the parentheses around it
+// in the source.
+func (p *printer) node(node ast.Node) {
+	if p.err != nil {
+		return
+	}
+	first, last := p.ext(node)
+	inner, _ := p.span(node)
+	parens := first != inner
+	if parens {
+		p.write("(")
+	}
+	switch n := node.(type) {
+	case *ast.Ident:
+		p.write(n.Literal())
+	case *ast.Int, *ast.Float, *ast.String:
+		p.write(p.text(inner))
+	case *ast.Nil, *ast.Bool, *ast.Control:
+		p.write(n.Literal())
+	case *ast.Prefix:
+		p.write(n.Operator())
+		p.node(n.Right())
+	case *ast.Infix:
+		p.node(n.Left())
+		p.write(" " + n.Operator() + " ")
+		p.node(n.Right())
+	case *ast.Ternary:
+		p.node(n.Condition())
+		p.write(" ? ")
+		p.node(n.IfTrue())
+		p.write(" : ")
+		p.node(n.IfFalse())
+	case *ast.If:
+		p.ifElse(n)
+	case *ast.Call:
+		p.call(n)
+	case *ast.GetAttr:
+		p.node(n.Object())
+		p.write("." + n.Name())
+	case *ast.ObjectCall:
+		p.node(n.Object())
+		p.write(".")
+		p.node(n.Call())
+	case *ast.Pipe:
+		p.pipe(n)
+	case *ast.Index:
+		p.node(n.Left())
+		p.write("[")
+		p.node(n.Index())
+		p.write("]")
+	case *ast.Slice:
+		p.node(n.Left())
+		p.write("[")
+		if n.FromIndex() != nil {
+			p.node(n.FromIndex())
+		}
+		p.write(":")
+		if n.ToIndex() != nil {
+			p.node(n.ToIndex())
+		}
+		p.write("]")
+	case *ast.Switch:
+		p.switchCases(n)
+	case *ast.In:
+		p.node(n.Left())
+		p.write(" in ")
+		p.node(n.Right())
+	case *ast.Range:
+		p.write("range ")
+		p.node(n.Container())
+	case *ast.Receive:
+		p.write("<-")
+		p.node(n.Channel())
+	case *ast.Func:
+		p.function(n)
+	case *ast.List:
+		p.list(inner, "[", "]", p.nodes(n.Items()), func(i int) {
+			p.node(n.Items()[i])
+		})
+	case *ast.Set:
+		p.list(inner, "{", "}", p.nodes(n.Items()), func(i int) {
+			p.node(n.Items()[i])
+		})
+	case *ast.Map:
+		p.mapItems(n, inner)
+	case *ast.Var:
+		name, value := n.Value()
+		if n.IsWalrus() {
+			p.write(name + " := ")
+		} else {
+			p.write("var " + name + " = ")
+		}
+		p.node(value)
+	case *ast.MultiVar:
+		names, value := n.Value()
+		if n.IsWalrus() {
+			p.write(strings.Join(names, ", ") + " := ")
+		} else {
+			p.write("var " + strings.Join(names, ", ") + " = ")
+		}
+		p.node(value)
+	case *ast.Const:
+		name, value := n.Value()
+		p.write("const " + name + " = ")
+		p.node(value)
+	case *ast.Return:
+		p.write("return")
+		if n.Value() != nil {
+			p.write(" ")
+			p.node(n.Value())
+		}
+	case *ast.For:
+		p.forLoop(n)
+	case *ast.Assign:
+		if n.Index() != nil {
+			p.node(n.Index())
+		} else {
+			p.write(n.Name())
+		}
+		p.write(" " + n.Operator() + " ")
+		p.node(n.Value())
+	case *ast.Import:
+		p.importModule(n)
+	case *ast.FromImport:
+		p.fromImport(n)
+	case *ast.Postfix:
+		p.write(n.Literal() + n.Operator())
+	case *ast.SetAttr:
+		p.node(n.Object())
+		p.write("." + n.Name() + " = ")
+		p.node(n.Value())
+	case *ast.Go:
+		p.write("go ")
+		p.node(n.Call())
+	case *ast.Defer:
+		p.write("defer ")
+		p.node(n.Call())
+	case *ast.Send:
+		p.node(n.Channel())
+		p.write(" <- ")
+		p.node(n.Value())
+	default:
+		p.fail(node.Token(), "unexpected %T", node)
+		return
+	}
+	if parens {
+		p.write(")")
+	}
+	p.last = p.line(last)
+}
+
+func (p *printer) ifElse(n *ast.If) {
+	p.write("if ")
+	p.node(n.Condition())
+	p.write(" ")
+	p.block(n.Consequence())
+	alt := n.Alternative()
+	if alt == nil {
+		return
+	}
+	p.write(" else ")
+	if alt.Token().Type == token.IF {
+		p.node(alt.Statements()[0])
+	} else {
+		p.block(alt)
+	}
+}
+
+func (p *printer) call(n *ast.Call) {
+	p.node(n.Function())
+	open := p.at(n.Token())
+	p.calls[open] = true
+	args := n.Arguments()
+	starts := make([]int, 0, len(args))
+	for _, arg := range args {
+		first, _ := p.ext(arg)
+		starts = append(starts, first)
+	}
+	p.list(open, "(", ")", starts, func(i int) {
+		p.node(args[i])
+	})
+}
+
+// pipe prints a pipe expression, keeping the line breaks after its "|"
+// operators.
+func (p *printer) pipe(n *ast.Pipe) {
+	exprs := n.Expressions()
+	indented := false
+	for i, expr := range exprs {
+		if i > 0 {
+			_, prev := p.ext(exprs[i-1])
+			first, _ := p.ext(expr)
+			if p.toks[first].StartPosition.Line > p.line(prev+1) {
+				p.write(" |")
+				p.last = p.line(prev + 1)
+				p.trailing(p.char(first))
+				p.newline()
+				if !indented {
+					p.indent++
+					indented = true
+				}
+			} else {
+				p.write(" | ")
+			}
+		}
+		p.node(expr)
+	}
+	if indented {
+		p.indent--
+	}
+}
+
+func (p *printer) switchCases(n *ast.Switch) {
+	p.write("switch ")
+	p.node(n.Value())
+	p.write(" {")
+	_, last := p.ext(n.Value())
+	close := p.closing[last+1]
+	p.last = p.line(last + 1)
+	cases := n.Choices()
+	starts := make([]int, 0, len(cases))
+	for _, c := range cases {
+		starts = append(starts, p.at(c.Token()))
+	}
+	limit := p.char(close)
+	if len(starts) > 0 {
+		limit = p.char(starts[0])
+	}
+	p.trailing(limit)
+	p.newline()
+	for i, c := range cases {
+		p.leading(p.char(starts[i]), p.toks[starts[i]].StartPosition.Line, i == 0)
+		colon := starts[i] + 1
+		if c.IsDefault() {
+			p.write("default:")
+		} else {
+			p.write("case ")
+			for j, expr := range c.Expressions() {
+				if j > 0 {
+					p.write(", ")
+				}
+				p.node(expr)
+			}
+			p.write(":")
+			_, last := p.ext(c.Expressions()[len(c.Expressions())-1])
+			colon = last + 1
+		}
+		p.last = p.line(colon)
+		end := p.char(close)
+		if i+1 < len(cases) {
+			end = p.char(starts[i+1])
+		}
+		var stmts []ast.Node
+		if c.Block() != nil {
+			stmts = c.Block().Statements()
+		}
+		limit := end
+		if len(stmts) > 0 {
+			first, _ := p.ext(stmts[0])
+			limit = p.char(first)
+		}
+		p.trailing(limit)
+		p.newline()
+		p.indent++
+		p.statements(stmts, end)
+		p.indent--
+	}
+	p.leading(p.char(close), p.last, len(cases) == 0)
+	p.write("}")
+	p.last = p.line(close)
+}
+
+func (p *printer) function(n *ast.Func) {
+	p.write("func")
+	if n.Name() != nil {
+		p.write(" " + n.Name().Literal())
+	}
+	p.write("(")
+	defaults := n.Defaults()
+	for i, param := range n.Parameters() {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(param.Literal())
+		if value, ok := defaults[param.Literal()]; ok {
+			p.write("=")
+			p.node(value)
+		}
+	}
+	p.write(") ")
+	p.block(n.Body())
+}
+
+// mapItems prints the items of a map in the order of the source, which the
+// AST doesn't keep.
+func (p *printer) mapItems(n *ast.Map, open int) {
+	items := n.Items()
+	keys := make([]ast.Expression, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := p.ext(keys[i])
+		b, _ := p.ext(keys[j])
+		return a < b
+	})
+	p.list(open, "{", "}", p.nodes(keys), func(i int) {
+		p.node(keys[i])
+		p.write(": ")
+		p.node(items[keys[i]])
+	})
+}
+
+func (p *printer) forLoop(n *ast.For) {
+	p.write("for ")
+	if n.Init() != nil {
+		p.node(n.Init())
+		p.write("; ")
+		if n.Condition() != nil {
+			p.node(n.Condition())
+		}
+		p.write("; ")
+		if n.Post() != nil {
+			p.node(n.Post())
+		}
+		p.write(" ")
+	} else if n.Condition() != nil {
+		p.node(n.Condition())
+		p.write(" ")
+	}
+	p.block(n.Consequence())
+}
+
+func (p *printer) importModule(n *ast.Import) {
+	p.write("import ")
+	name := n.Name()
+	if name.Token().Type == token.STRING {
+		p.write(p.text(p.at(name.Token())))
+	} else {
+		p.write(name.Literal())
+	}
+	// Without an alias in the source, the parser binds the module to a name
+	// derived from its own
+	if alias := n.Alias(); alias != nil {
+		if i := p.at(alias.Token()); p.toks[i-1].Type == token.AS {
+			p.write(" as " + alias.Literal())
+		}
+	}
+}
+
+func (p *printer) fromImport(n *ast.FromImport) {
+	parents := make([]string, 0, len(n.Parents()))
+	for _, parent := range n.Parents() {
+		parents = append(parents, parent.Literal())
+	}
+	p.write("from " + strings.Join(parents, ".") + " import ")
+	imports := n.Imports()
+	item := func(i int) {
+		im := imports[i]
+		p.write(im.Name().Literal())
+		last := p.at(im.Name().Token())
+		if im.Alias() != nil {
+			p.write(" as " + im.Alias().Literal())
+			last = p.at(im.Alias().Token())
+		}
+		p.last = p.line(last)
+	}
+	if n.IsGrouped() {
+		starts := make([]int, 0, len(imports))
+		for _, im := range imports {
+			starts = append(starts, p.at(im.Name().Token()))
+		}
+		p.list(p.at(imports[0].Token())+1, "(", ")", starts, item)
+		return
+	}
+	for i := range imports {
+		if i > 0 {
+			p.write(", ")
+		}
+		item(i)
+	}
+}
@@ -0,0 +1,223 @@
+package format
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "spacing",
+			input:    "x:=1;y  =x+2*  3\nz := !true ? -1 : f( x,y )\n",
+			expected: "x := 1\ny = x + 2 * 3\nz := !true ? -1 : f(x, y)\n",
+		},
+		{
+			name:     "blank lines",
+			input:    "\n\nx := 1\n\n\n\ny := 2",
+			expected: "x := 1\n\ny := 2\n",
+		},
+		{
+			name:     "parentheses",
+			input:    "x := ((a + b)) * (c)\ny := f((a || b) && c)\n",
+			expected: "x := (a + b) * (c)\ny := f((a || b) && c)\n",
+		},
+		{
+			name: "blocks",
+			input: `func add(a,b=1){return a+b}
+if x>1 { print("big") } else if x==0 {
+print("zero")
+} else {}
+for i:=0;i<3;i++ { continue }
+for k, v := range m {
+print(k, v) }
+`,
+			expected: `func add(a, b=1) {
+    return a + b
+}
+if x > 1 {
+    print("big")
+} else if x == 0 {
+    print("zero")
+} else {}
+for i := 0; i < 3; i++ {
+    continue
+}
+for k, v := range m {
+    print(k, v)
+}
+`,
+		},
+		{
+			name: "switch",
+			input: `switch x {
+  case 1,2: // small
+    print("small")
+  default:
+    print("large")
+}
+`,
+			expected: `switch x {
+case 1, 2: // small
+    print("small")
+default:
+    print("large")
+}
+`,
+		},
+		{
+			name: "containers",
+			input: `m := {b: 1,"a": [1,2,
+3]}
+n := {
+  b: 1, // b
+  // a
+  a: {1, 2}
+}
+call(
+  "x", func() { return 1 })
+`,
+			expected: `m := {b: 1, "a": [1, 2, 3]}
+n := {
+    b: 1, // b
+    // a
+    a: {1, 2},
+}
+call(
+    "x",
+    func() {
+        return 1
+    },
+)
+`,
+		},
+		{
+			name: "comments",
+			input: `#!/usr/bin/env risor
+// about x
+x := 1 # one
+
+/* block
+   comment */
+func f() { // f
+    return 1
+    // done
+}
+// the end`,
+			expected: `#!/usr/bin/env risor
+// about x
+x := 1 # one
+
+/* block
+   comment */
+func f() { // f
+    return 1
+    // done
+}
+// the end
+`,
+		},
+		{
+			name: "imports",
+			input: `import   json
+import "github.com/org/lib@v1.2.0"
+import a.b as c
+from os.path import (join as j,
+  base)
+from x import (
+  a,
+  b as c,
+)
+`,
+			expected: `import json
+import "github.com/org/lib@v1.2.0"
+import a.b as c
+from os.path import (join as j, base)
+from x import (
+    a,
+    b as c,
+)
+`,
+		},
+		{
+			name: "statements",
+			input: `x++
+o.a.b=1
+l[0]+=2
+ch<-1
+v := <-ch
+defer f()
+go g(1)
+s := l[1:] | filter(ok) |
+  len
+const C=0x1F
+var a,b=[1,2]
+`,
+			expected: `x++
+o.a.b = 1
+l[0] += 2
+ch <- 1
+v := <-ch
+defer f()
+go g(1)
+s := l[1:] | filter(ok) |
+    len
+const C = 0x1F
+var a, b = [1, 2]
+`,
+		},
+		{
+			name:     "strings",
+			input:    "a := 'x {y+1}'\nb := `raw\n  text`\nc := \"q\\\"\"\n",
+			expected: "a := 'x {y+1}'\nb := `raw\n  text`\nc := \"q\\\"\"\n",
+		},
+		{
+			name:     "empty",
+			input:    "\n\n",
+			expected: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Source(context.Background(), []byte(tt.input))
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, string(out))
+			// Formatting is idempotent
+			again, err := Source(context.Background(), out)
+			require.Nil(t, err)
+			require.Equal(t, string(out), string(again))
+		})
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	_, err := Source(context.Background(), []byte("x := ("))
+	require.NotNil(t, err)
+}
+
+func TestSourceExamples(t *testing.T) {
+	ctx := context.Background()
+	files, err := filepath.Glob("../examples/scripts/*.risor")
+	require.Nil(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		require.Nil(t, err)
+		out, err := Source(ctx, src)
+		require.Nil(t, err, file)
+		_, err = parser.Parse(ctx, string(out))
+		require.Nil(t, err, file)
+		again, err := Source(ctx, out)
+		require.Nil(t, err, file)
+		require.Equal(t, string(out), string(again), file)
+	}
+}
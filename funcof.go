@@ -0,0 +1,222 @@
+package risor
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/vm"
+)
+
+var (
+	objectType  = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// FuncOf returns a Go function of type F that calls the given function of
+// a script, so that hosts can keep script callbacks in ordinary Go values,
+// such as an http.HandlerFunc or a func(Event) error:
+//
+//	obj, err := machine.Get("handle")
+//	...
+//	handle, err := risor.FuncOf[func(Event) error](machine, obj.(*object.Function))
+//
+// Arguments are converted to Risor objects, and results back to Go values,
+// with the type converters of their types, as NewGoFunction does the other
+// way around. A first parameter of type context.Context is the context of
+// the call rather than an argument. Values of type object.Object are passed
+// as they are. A function with several results other than an error expects
+// the script function to return a list of as many items.
+//
+// When the last result of F is an error, errors raised by the call are
+// returned there, as are error values the script function returns.
+// Otherwise, the Go function panics when the call fails.
+//
+// Each call runs in a clone of the VM, which must have run, so the Go
+// function is safe for concurrent use and its calls share the globals of
+// the VM.
+func FuncOf[F any](machine *vm.VirtualMachine, fn *object.Function) (F, error) {
+	var zero F
+	typ := reflect.TypeOf(&zero).Elem()
+	if typ.Kind() != reflect.Func {
+		return zero, fmt.Errorf("type error: expected a function type (%s given)", typ)
+	}
+	if fn == nil {
+		return zero, fmt.Errorf("type error: expected a function (nil given)")
+	}
+	adapter := &funcAdapter{machine: machine, fn: fn, typ: typ, errIndex: -1}
+	for i := 0; i < typ.NumIn(); i++ {
+		paramType := typ.In(i)
+		if i == 0 && paramType == contextType {
+			adapter.takesCtx = true
+			continue
+		}
+		if typ.IsVariadic() && i == typ.NumIn()-1 {
+			paramType = paramType.Elem()
+		}
+		conv, err := converterOf(paramType)
+		if err != nil {
+			return zero, fmt.Errorf("type error: unsupported type of parameter %d: %w", i+1, err)
+		}
+		adapter.params = append(adapter.params, conv)
+	}
+	for i := 0; i < typ.NumOut(); i++ {
+		outType := typ.Out(i)
+		if outType == errorType && i == typ.NumOut()-1 {
+			adapter.errIndex = i
+			continue
+		}
+		conv, err := converterOf(outType)
+		if err != nil {
+			return zero, fmt.Errorf("type error: unsupported type of result %d: %w", i+1, err)
+		}
+		adapter.results = append(adapter.results, conv)
+	}
+	return reflect.MakeFunc(typ, adapter.call).Interface().(F), nil
+}
+
+// converterOf returns the converter of a Go type, which is nil for Objects.
+func converterOf(typ reflect.Type) (object.TypeConverter, error) {
+	if typ == objectType {
+		return nil, nil
+	}
+	return object.NewTypeConverter(typ)
+}
+
+// funcAdapter calls a Risor function on behalf of a Go function.
+type funcAdapter struct {
+	machine  *vm.VirtualMachine
+	fn       *object.Function
+	typ      reflect.Type
+	takesCtx bool
+	params   []object.TypeConverter
+	results  []object.TypeConverter
+	errIndex int
+}
+
+func (a *funcAdapter) call(inputs []reflect.Value) []reflect.Value {
+	result, err := a.invoke(inputs)
+	outputs := make([]reflect.Value, a.typ.NumOut())
+	if err == nil {
+		err = a.convertResults(result, outputs)
+	}
+	if err != nil && a.errIndex < 0 {
+		panic(err)
+	}
+	for i := range outputs {
+		if !outputs[i].IsValid() || err != nil {
+			outputs[i] = reflect.Zero(a.typ.Out(i))
+		}
+	}
+	if a.errIndex >= 0 && err != nil {
+		outputs[a.errIndex] = reflect.ValueOf(&err).Elem()
+	}
+	return outputs
+}
+
+// invoke converts the arguments of the Go function and calls the Risor
+// function in a clone of the VM.
+func (a *funcAdapter) invoke(inputs []reflect.Value) (object.Object, error) {
+	ctx := context.Background()
+	if a.takesCtx {
+		if c, ok := inputs[0].Interface().(context.Context); ok && c != nil {
+			ctx = c
+		}
+		inputs = inputs[1:]
+	}
+	if a.typ.IsVariadic() {
+		last := inputs[len(inputs)-1]
+		expanded := append([]reflect.Value{}, inputs[:len(inputs)-1]...)
+		for i := 0; i < last.Len(); i++ {
+			expanded = append(expanded, last.Index(i))
+		}
+		inputs = expanded
+	}
+	args := make([]object.Object, 0, len(inputs))
+	for i, input := range inputs {
+		param := min(i, len(a.params)-1)
+		arg, err := convertInput(a.params[param], input)
+		if err != nil {
+			return nil, fmt.Errorf("type error: failed to convert argument %d in %s() call: %w", i+1, a.fn.Name(), err)
+		}
+		args = append(args, arg)
+	}
+	clone, err := a.machine.Clone()
+	if err != nil {
+		return nil, err
+	}
+	result, err := clone.Call(ctx, a.fn, args)
+	if err != nil {
+		return nil, err
+	}
+	if errObj, ok := result.(*object.Error); ok && a.errIndex >= 0 {
+		return nil, errObj.Value()
+	}
+	return result, nil
+}
+
+func convertInput(conv object.TypeConverter, input reflect.Value) (object.Object, error) {
+	if (input.Kind() == reflect.Interface || input.Kind() == reflect.Pointer) && input.IsNil() {
+		return object.Nil, nil
+	}
+	if conv == nil {
+		return input.Interface().(object.Object), nil
+	}
+	return conv.From(input.Interface())
+}
+
+// convertResults converts the result of the Risor function to the results
+// of the Go function other than the error.
+func (a *funcAdapter) convertResults(result object.Object, outputs []reflect.Value) error {
+	values := []object.Object{result}
+	switch len(a.results) {
+	case 0:
+		return nil
+	case 1:
+	default:
+		list, ok := result.(*object.List)
+		if !ok || len(list.Value()) != len(a.results) {
+			return fmt.Errorf("type error: expected %s() to return a list of %d items (got %s)",
+				a.fn.Name(), len(a.results), result.Inspect())
+		}
+		values = list.Value()
+	}
+	// The error is the last result, so the others come first
+	for i, value := range values {
+		converted, err := convertOutput(a.results[i], a.typ.Out(i), value)
+		if err != nil {
+			return fmt.Errorf("type error: failed to convert result %d of %s() call: %w", i+1, a.fn.Name(), err)
+		}
+		outputs[i] = converted
+	}
+	return nil
+}
+
+func convertOutput(conv object.TypeConverter, typ reflect.Type, value object.Object) (reflect.Value, error) {
+	if conv == nil {
+		return reflect.ValueOf(&value).Elem(), nil
+	}
+	if value == object.Nil {
+		switch typ.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+			return reflect.Zero(typ), nil
+		}
+	}
+	goValue, err := conv.To(value)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if goValue == nil {
+		return reflect.Zero(typ), nil
+	}
+	converted := reflect.ValueOf(goValue)
+	if converted.Type() != typ {
+		if !converted.Type().ConvertibleTo(typ) {
+			return reflect.Value{}, fmt.Errorf("expected %s (%s given)", typ, converted.Type())
+		}
+		converted = converted.Convert(typ)
+	}
+	return converted, nil
+}
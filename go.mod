@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/risor-io/risor/modules/gha v0.0.0-20240213105055-b1d3a53935e5
 	github.com/stretchr/testify v1.8.4
+	golang.org/x/crypto v0.21.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -13,6 +14,7 @@ require (
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rogpeppe/go-internal v1.10.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 )
 
@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/risor-io/risor/compiler"
+)
+
+// ArchiveImporter is an FSImporter reading modules from a zip archive, such
+// as a ".zip" or ".rbundle" file shipping a multi-file project, or a ".rsb"
+// bundle written by WriteBundle.
+type ArchiveImporter struct {
+	*FSImporter
+	closer io.Closer
+}
+
+// OpenArchive returns an Importer that reads Risor code modules from the zip
+// archive at the given path, which should be closed when no longer needed.
+// The archive may hold precompiled modules, as described for FSImporter,
+// and the remote modules listed in its BundleIndex.
+func OpenArchive(path string, opts ...FSImporterOption) (*ArchiveImporter, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	a, err := newArchiveImporter(&r.Reader, r, opts)
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return a, nil
+}
+
+func newArchiveImporter(r *zip.Reader, closer io.Closer, opts []FSImporterOption) (*ArchiveImporter, error) {
+	fsys, err := ArchiveRoot(r)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := fs.ReadFile(fsys, BundleIndex); err == nil {
+		var index bundleIndex
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", BundleIndex, err)
+		}
+		opts = append(opts, WithAliases(index.Modules))
+	}
+	return &ArchiveImporter{FSImporter: NewFSImporter(fsys, opts...), closer: closer}, nil
+}
+
+// Main returns the main module of the archive, which is either precompiled,
+// or else its source code is returned.
+func (a *ArchiveImporter) Main() (*compiler.Code, string, error) {
+	if data, err := fs.ReadFile(a.fsys, "main"+CompiledExtension); err == nil {
+		code, err := compiler.UnmarshalCode(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid compiled main module: %w", err)
+		}
+		return code, "", nil
+	}
+	for _, name := range []string{"main.risor", "main.rsr"} {
+		if data, err := fs.ReadFile(a.fsys, name); err == nil {
+			return nil, string(data), nil
+		}
+	}
+	return nil, "", errors.New("no main module in archive")
+}
+
+// Close closes the archive.
+func (a *ArchiveImporter) Close() error {
+	return a.closer.Close()
+}
+
+// ArchiveRoot returns the directory of an archive holding its modules. This
+// is the top-level directory if it is the only entry of the archive, as in
+// archives created with "zip -r app.zip app/", or else the archive itself.
+func ArchiveRoot(fsys fs.FS) (fs.FS, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return fs.Sub(fsys, entries[0].Name())
+	}
+	return fsys, nil
+}
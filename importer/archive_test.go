@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+// writeArchive writes the files to a zip archive, and returns its path.
+func writeArchive(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.rbundle")
+	f, err := os.Create(path)
+	require.Nil(t, err)
+	w := zip.NewWriter(f)
+	for name, data := range files {
+		fw, err := w.Create(name)
+		require.Nil(t, err)
+		_, err = fw.Write(data)
+		require.Nil(t, err)
+	}
+	require.Nil(t, w.Close())
+	require.Nil(t, f.Close())
+	return path
+}
+
+func TestArchiveImporter(t *testing.T) {
+	ast, err := parser.Parse(context.Background(), "compiled := true")
+	require.Nil(t, err)
+	code, err := compiler.Compile(ast)
+	require.Nil(t, err)
+	compiled, err := compiler.MarshalCode(code)
+	require.Nil(t, err)
+
+	for _, dir := range []string{"", "app/"} {
+		path := writeArchive(t, map[string][]byte{
+			dir + "main.risor":         []byte("import lib"),
+			dir + "lib.risor":          []byte("version := 1"),
+			dir + "util/fast.risor":    []byte("compiled := false"),
+			dir + "util/fast.risorc":   compiled,
+			dir + "util/broken.risorc": []byte("{"),
+		})
+		im, err := OpenArchive(path)
+		require.Nil(t, err)
+		ctx := context.Background()
+
+		module, err := im.Import(ctx, "lib")
+		require.Nil(t, err)
+		require.Equal(t, "version := 1", module.Code().Source())
+
+		module, err = im.Import(ctx, filepath.Join("util", "fast"))
+		require.Nil(t, err)
+		require.Equal(t, "compiled := true", module.Code().Source())
+
+		_, err = im.Import(ctx, "util/broken")
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), `import error: invalid compiled module "util/broken"`)
+
+		_, err = im.Import(ctx, "missing")
+		require.NotNil(t, err)
+		require.Equal(t, `import error: module "missing" not found`, err.Error())
+		require.Nil(t, im.Close())
+	}
+
+	_, err = OpenArchive(filepath.Join(t.TempDir(), "missing.zip"))
+	require.NotNil(t, err)
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/risor-io/risor/object"
+)
+
+// ImportEvent describes an attempt to import a module.
+type ImportEvent struct {
+	// The name of the module, as given to the importer.
+	Name string
+
+	// The name of the module that was imported, which includes its resolved
+	// version or location, such as "github.com/org/lib/x@v1.2.0" for a
+	// module required by a project manifest.
+	Resolved string
+
+	// The checksum of the module's code, as "sha256:<hex>".
+	Hash string
+
+	// How long the import took.
+	Duration time.Duration
+
+	// The error of a failed or denied import.
+	Err error
+}
+
+// AuditImporterOptions configure an Importer that audits the imports of
+// another.
+type AuditImporterOptions struct {
+	// Optional policy called before each import. Returning an error denies
+	// the import.
+	Allow func(ctx context.Context, name string) error
+
+	// Optional function called after each import attempt, including failed
+	// and denied ones.
+	Report func(ctx context.Context, event ImportEvent)
+}
+
+// AuditImporter reports the imports of another importer, and enforces a
+// policy on the modules it may import.
+type AuditImporter struct {
+	base   Importer
+	allow  func(ctx context.Context, name string) error
+	report func(ctx context.Context, event ImportEvent)
+}
+
+// NewAuditImporter returns an Importer that imports modules with the given
+// importer, once allowed by the policy, and reports every attempt. This
+// provides audit trails of the code an embedded program runs, and limits
+// sandboxed programs to trusted modules:
+//
+//	im := importer.NewAuditImporter(base, importer.AuditImporterOptions{
+//		Allow: func(ctx context.Context, name string) error {
+//			if strings.HasPrefix(name, "github.com/acme/") {
+//				return nil
+//			}
+//			return errors.New("only modules of acme are allowed")
+//		},
+//		Report: func(ctx context.Context, event importer.ImportEvent) {
+//			slog.Info("import", "name", event.Name, "hash", event.Hash)
+//		},
+//	})
+//
+// Since VMs cache the modules they import, each module is reported once per
+// VM.
+func NewAuditImporter(base Importer, opts AuditImporterOptions) *AuditImporter {
+	return &AuditImporter{base: base, allow: opts.Allow, report: opts.Report}
+}
+
+func (i *AuditImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	start := time.Now()
+	event := ImportEvent{Name: name}
+	var module *object.Module
+	if i.allow != nil {
+		if err := i.allow(ctx, name); err != nil {
+			event.Err = fmt.Errorf("import error: import of %q denied: %w", name, err)
+		}
+	}
+	if event.Err == nil {
+		module, event.Err = i.base.Import(ctx, name)
+	}
+	if module != nil {
+		event.Resolved = module.Name().Value()
+		event.Hash = "sha256:" + checksum([]byte(module.Code().Source()))
+	}
+	event.Duration = time.Since(start)
+	if i.report != nil {
+		i.report(ctx, event)
+	}
+	return module, event.Err
+}
+
+// Changed returns the names of the changed modules among those imported by
+// the audited importer, if it can detect changes.
+func (i *AuditImporter) Changed() []string {
+	return changed(i.base)
+}
@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditImporter(t *testing.T) {
+	ctx := context.Background()
+	base := &recordingImporter{Importer: NewFSImporter(fstest.MapFS{
+		"lib.risor":    {Data: []byte("version := 1")},
+		"secret.risor": {Data: []byte("key := 42")},
+	})}
+	var events []ImportEvent
+	im := NewAuditImporter(base, AuditImporterOptions{
+		Allow: func(ctx context.Context, name string) error {
+			if strings.HasPrefix(name, "secret") {
+				return errors.New("not allowed")
+			}
+			return nil
+		},
+		Report: func(ctx context.Context, event ImportEvent) {
+			events = append(events, event)
+		},
+	})
+
+	module, err := im.Import(ctx, "lib")
+	require.Nil(t, err)
+	require.Equal(t, "lib", module.Name().Value())
+
+	_, err = im.Import(ctx, "secret")
+	require.EqualError(t, err, `import error: import of "secret" denied: not allowed`)
+
+	_, err = im.Import(ctx, "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Denied modules are never imported
+	require.Equal(t, []string{"lib", "missing"}, base.names)
+
+	require.Len(t, events, 3)
+	require.Equal(t, "lib", events[0].Name)
+	require.Equal(t, "lib", events[0].Resolved)
+	require.Equal(t, "sha256:"+checksum([]byte(module.Code().Source())), events[0].Hash)
+	require.Nil(t, events[0].Err)
+	require.Equal(t, "secret", events[1].Name)
+	require.Equal(t, "", events[1].Resolved)
+	require.EqualError(t, events[1].Err, `import error: import of "secret" denied: not allowed`)
+	require.Equal(t, "missing", events[2].Name)
+	require.ErrorIs(t, events[2].Err, ErrNotFound)
+}
@@ -0,0 +1,284 @@
+package importer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/op"
+)
+
+// BundleIndex is the file of a bundle mapping the names of the remote
+// modules it holds to their paths in the bundle.
+const BundleIndex = "bundle.json"
+
+// BundleExtension is the file extension of bundles written by WriteBundle.
+const BundleExtension = ".rsb"
+
+// BundleOptions configure the bundling of a program with WriteBundle.
+type BundleOptions struct {
+	// Global names that should be available when modules are compiled.
+	// Imports of global names, such as modules provided by the host program,
+	// aren't bundled.
+	GlobalNames []string
+
+	// Optional function returning the names exported by a module, needed to
+	// compile wildcard imports.
+	Exports compiler.ExportsFunc
+
+	// The project's own modules. Their files are bundled as they are, unless
+	// Precompile is set.
+	Local fs.FS
+
+	// Optional importer for the modules that aren't in Local, such as remote
+	// modules. These are bundled precompiled.
+	Importer Importer
+
+	// Bundle the main module and the project's own modules precompiled,
+	// rather than as source code.
+	Precompile bool
+
+	// Optional list of file extensions of module source files. Defaults to
+	// ".risor" and ".rsr".
+	Extensions []string
+}
+
+type bundleIndex struct {
+	Modules map[string]string `json:"modules"`
+}
+
+type bundler struct {
+	opts  BundleOptions
+	files map[string][]byte
+	index map[string]string
+	seen  map[string]bool
+}
+
+// WriteBundle writes a program to w as a single zip archive, holding its
+// main module with the given source code, and every module it imports
+// directly or indirectly. The bundle runs without access to the project or
+// to remote hosts, and is opened with OpenArchive like any other archive.
+//
+// Remote modules are bundled precompiled, and listed in the BundleIndex of
+// the bundle under the names the program imports them by. Bundles written
+// with Precompile set run with the same version of Risor only.
+func WriteBundle(ctx context.Context, w io.Writer, source string, opts BundleOptions) error {
+	if len(opts.Extensions) == 0 {
+		opts.Extensions = []string{".risor", ".rsr"}
+	}
+	b := &bundler{
+		opts:  opts,
+		files: map[string][]byte{},
+		index: map[string]string{},
+		seen:  map[string]bool{},
+	}
+	for _, name := range opts.GlobalNames {
+		b.seen[name] = true
+	}
+	main, err := b.compile(ctx, []sourceFile{{source: source}})
+	if err != nil {
+		return err
+	}
+	if opts.Precompile {
+		data, err := compiler.MarshalCode(main)
+		if err != nil {
+			return err
+		}
+		b.files["main"+CompiledExtension] = data
+	} else {
+		b.files["main.risor"] = []byte(source)
+	}
+	if err := b.addImports(ctx, main); err != nil {
+		return err
+	}
+	if len(b.index) > 0 {
+		data, err := json.MarshalIndent(bundleIndex{Modules: b.index}, "", "  ")
+		if err != nil {
+			return err
+		}
+		b.files[BundleIndex] = data
+	}
+	return writeZip(w, b.files)
+}
+
+func (b *bundler) compile(ctx context.Context, files []sourceFile) (*compiler.Code, error) {
+	program, err := parseFiles(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	opts := []compiler.Option{compiler.WithGlobalNames(b.opts.GlobalNames)}
+	if b.opts.Exports != nil {
+		opts = append(opts, compiler.WithExports(b.opts.Exports))
+	}
+	return compiler.Compile(program, opts...)
+}
+
+// addImports bundles the modules imported by the code. Of the candidates for
+// a from-import, the first module found is bundled.
+func (b *bundler) addImports(ctx context.Context, code *compiler.Code) error {
+	for _, candidates := range imports(code) {
+		var err error
+		for _, name := range candidates {
+			if err = b.add(ctx, name); !errors.Is(err, ErrNotFound) {
+				break
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add bundles the named module and the modules it imports.
+func (b *bundler) add(ctx context.Context, name string) error {
+	if b.seen[name] {
+		return nil
+	}
+	code, err := b.addLocal(ctx, name)
+	if errors.Is(err, ErrNotFound) && b.opts.Importer != nil {
+		code, err = b.addRemote(ctx, name)
+	}
+	if err != nil {
+		return err
+	}
+	b.seen[name] = true
+	return b.addImports(ctx, code)
+}
+
+// addLocal bundles the named module from the project's own modules.
+func (b *bundler) addLocal(ctx context.Context, name string) (*compiler.Code, error) {
+	modulePath := path.Clean(name)
+	if b.opts.Local == nil || !fs.ValidPath(modulePath) {
+		return nil, notFound(name)
+	}
+	if data, err := fs.ReadFile(b.opts.Local, modulePath+CompiledExtension); err == nil {
+		code, err := compiler.UnmarshalCode(data)
+		if err != nil {
+			return nil, fmt.Errorf("import error: invalid compiled module %q: %w", modulePath, err)
+		}
+		b.files[modulePath+CompiledExtension] = data
+		return code, nil
+	}
+	for _, ext := range b.opts.Extensions {
+		source, err := fs.ReadFile(b.opts.Local, modulePath+ext)
+		if err != nil {
+			continue
+		}
+		code, err := b.compile(ctx, []sourceFile{{source: string(source)}})
+		if err != nil {
+			return nil, err
+		}
+		if !b.opts.Precompile {
+			b.files[modulePath+ext] = source
+			return code, nil
+		}
+		data, err := compiler.MarshalCode(code)
+		if err != nil {
+			return nil, err
+		}
+		b.files[modulePath+CompiledExtension] = data
+		return code, nil
+	}
+	files, err := readPackage(b.opts.Local, modulePath, b.opts.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, notFound(name)
+	}
+	code, err := b.compile(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	if b.opts.Precompile {
+		data, err := compiler.MarshalCode(code)
+		if err != nil {
+			return nil, err
+		}
+		b.files[modulePath+CompiledExtension] = data
+		return code, nil
+	}
+	for _, file := range files {
+		b.files[file.name] = []byte(file.source)
+	}
+	return code, nil
+}
+
+// addRemote bundles the named module from the importer, precompiled, since
+// importers don't keep the source of the modules they compile.
+func (b *bundler) addRemote(ctx context.Context, name string) (*compiler.Code, error) {
+	module, err := b.opts.Importer.Import(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := compiler.MarshalCode(module.Code())
+	if err != nil {
+		return nil, err
+	}
+	modulePath := "_modules/" + checksum([]byte(name))[:16]
+	b.files[modulePath+CompiledExtension] = data
+	b.index[name] = modulePath
+	return module.Code(), nil
+}
+
+// imports returns the names of the modules imported by the code and its
+// functions. A from-import yields two candidates, since "from a import b"
+// imports the module "a/b" if there is one, or else the attribute "b" of
+// the module "a".
+func imports(code *compiler.Code) [][]string {
+	var result [][]string
+	for _, c := range code.Flatten() {
+		// Import names are the constants loaded right before the import
+		var loaded []string
+		for _, instr := range compiler.NewInstructionIter(c).All() {
+			switch instr[0] {
+			case op.LoadConst:
+				name, _ := c.Constant(int(instr[1])).(string)
+				loaded = append(loaded, name)
+				continue
+			case op.Import:
+				if len(loaded) > 0 {
+					result = append(result, []string{loaded[len(loaded)-1]})
+				}
+			case op.FromImport:
+				parentLen, count := int(instr[1]), int(instr[2])
+				if len(loaded) >= parentLen+count {
+					names := loaded[len(loaded)-parentLen-count:]
+					parent := path.Join(names[:parentLen]...)
+					for _, name := range names[parentLen:] {
+						result = append(result, []string{path.Join(parent, name), parent})
+					}
+				}
+			}
+			loaded = loaded[:0]
+		}
+	}
+	return result
+}
+
+func writeZip(w io.Writer, files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	zw := zip.NewWriter(w)
+	for _, name := range names {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeBundle bundles the program, and returns the path of the bundle.
+func writeBundle(t *testing.T, source string, opts BundleOptions) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.Nil(t, WriteBundle(context.Background(), &buf, source, opts))
+	path := filepath.Join(t.TempDir(), "app"+BundleExtension)
+	require.Nil(t, os.WriteFile(path, buf.Bytes(), 0o644))
+	return path
+}
+
+func TestWriteBundle(t *testing.T) {
+	local := fstest.MapFS{
+		"lib/util.risor":    {Data: []byte("import \"github.com/org/remote\" as r\nname := r.name")},
+		"lib/helpers.risor": {Data: []byte("func greet(n) { return 'hello ' + n }")},
+		"unused.risor":      {Data: []byte("unused := true")},
+	}
+	remote := NewFSImporter(fstest.MapFS{
+		"github.com/org/remote.risor": {Data: []byte("name := 'world'")},
+	})
+	source := "import lib.util\nfrom lib.helpers import greet\ngreet(util.name)"
+
+	for _, precompile := range []bool{false, true} {
+		path := writeBundle(t, source, BundleOptions{
+			Local:      local,
+			Importer:   remote,
+			Precompile: precompile,
+		})
+		im, err := OpenArchive(path)
+		require.Nil(t, err)
+		defer im.Close()
+
+		code, mainSource, err := im.Main()
+		require.Nil(t, err)
+		if precompile {
+			require.NotNil(t, code)
+		} else {
+			require.Equal(t, source, mainSource)
+		}
+		for _, name := range []string{"lib/util", "lib/helpers", "github.com/org/remote"} {
+			module, err := im.Import(context.Background(), name)
+			require.Nil(t, err)
+			require.Equal(t, name, module.Name().Value())
+		}
+		_, err = im.Import(context.Background(), "unused")
+		require.ErrorIs(t, err, ErrNotFound)
+	}
+}
+
+func TestWriteBundleMissingModule(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteBundle(context.Background(), &buf, "import missing", BundleOptions{
+		Local: fstest.MapFS{},
+	})
+	require.EqualError(t, err, `import error: module "missing" not found`)
+
+	// Imports of globals are left to the program running the bundle
+	err = WriteBundle(context.Background(), &buf, "import missing", BundleOptions{
+		GlobalNames: []string{"missing"},
+	})
+	require.Nil(t, err)
+}
@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/risor-io/risor/compiler"
+)
+
+// compileCacheFormat changes whenever cached code from an older release
+// could be misread by a newer one.
+const compileCacheFormat = "2"
+
+// CompileCache stores compiled modules on disk, so later runs of a program
+// skip parsing and compiling modules whose source hasn't changed. Modules
+// are keyed by a hash of their source, the file they were read from, the
+// global names they were compiled with, and the version of Risor that
+// compiled them.
+//
+// A nil *CompileCache is valid, and compiles every module.
+type CompileCache struct {
+	dir string
+}
+
+// NewCompileCache returns a cache of compiled modules in the given
+// directory. The directory defaults to "risor/code" in the user cache
+// directory.
+func NewCompileCache(dir string) *CompileCache {
+	if dir == "" {
+		if cacheDir, err := os.UserCacheDir(); err == nil {
+			dir = filepath.Join(cacheDir, "risor", "code")
+		}
+	}
+	return &CompileCache{dir: dir}
+}
+
+// compile returns the compiled code for the module source, from the cache
+// if possible. Failing to use the cache isn't an error, since the module can
+// always be compiled again.
+func (c *CompileCache) compile(ctx context.Context, files []sourceFile, globalNames []string) (*compiler.Code, error) {
+	if c == nil || c.dir == "" {
+		return compile(ctx, files, globalNames)
+	}
+	key := cacheKey(files, globalNames)
+	path := filepath.Join(c.dir, key[:2], key+CompiledExtension)
+	if data, err := os.ReadFile(path); err == nil {
+		if code, err := compiler.UnmarshalCode(data); err == nil {
+			return code, nil
+		}
+	}
+	code, err := compile(ctx, files, globalNames)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := compiler.MarshalCode(code); err == nil {
+		c.write(path, data)
+	}
+	return code, nil
+}
+
+func (c *CompileCache) write(path string, data []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+func cacheKey(files []sourceFile, globalNames []string) string {
+	names := make([]string, len(globalNames))
+	copy(names, globalNames)
+	sort.Strings(names)
+	h := sha256.New()
+	for _, part := range []string{compileCacheFormat, risorVersion(), strings.Join(names, ",")} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	for _, file := range files {
+		h.Write([]byte(file.name))
+		h.Write([]byte{0})
+		h.Write([]byte(file.source))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+var (
+	risorVersionOnce  sync.Once
+	risorVersionValue string
+)
+
+// risorVersion returns the version of Risor built into the program, or
+// identifies the program itself for development builds, whose bytecode may
+// change from one build to the next.
+func risorVersion() string {
+	risorVersionOnce.Do(func() {
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/risor-io/risor" && dep.Replace == nil {
+				risorVersionValue = dep.Version
+				return
+			}
+		}
+		if exe, err := os.Executable(); err == nil {
+			if stat, err := os.Stat(exe); err == nil {
+				risorVersionValue = exe + "@" + stat.ModTime().String()
+			}
+		}
+	})
+	return risorVersionValue
+}
@@ -0,0 +1,71 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cache := NewCompileCache(dir)
+	code, err := cache.compile(ctx, []sourceFile{{source: "version := 1"}}, []string{"len"})
+	require.Nil(t, err)
+	require.Equal(t, "version := 1", code.Source())
+
+	key := cacheKey([]sourceFile{{source: "version := 1"}}, []string{"len"})
+	path := filepath.Join(dir, key[:2], key+CompiledExtension)
+	_, err = os.Stat(path)
+	require.Nil(t, err)
+
+	// The order of the global names doesn't matter, but the names do
+	require.Equal(t, key, cacheKey([]sourceFile{{source: "version := 1"}}, []string{"len"}))
+	require.Equal(t, cacheKey([]sourceFile{{source: "x := 1"}}, []string{"a", "b"}), cacheKey([]sourceFile{{source: "x := 1"}}, []string{"b", "a"}))
+	require.NotEqual(t, key, cacheKey([]sourceFile{{source: "version := 1"}}, nil))
+	require.NotEqual(t, key, cacheKey([]sourceFile{{source: "version := 2"}}, []string{"len"}))
+	require.NotEqual(t, key, cacheKey([]sourceFile{{name: "version.risor", source: "version := 1"}}, []string{"len"}))
+
+	// Cached code is used by later compilations, which skip compiling
+	other, err := compile(ctx, []sourceFile{{source: "version := 2"}}, []string{"len"})
+	require.Nil(t, err)
+	data, err := compiler.MarshalCode(other)
+	require.Nil(t, err)
+	require.Nil(t, os.WriteFile(path, data, 0o644))
+	code, err = NewCompileCache(dir).compile(ctx, []sourceFile{{source: "version := 1"}}, []string{"len"})
+	require.Nil(t, err)
+	require.Equal(t, "version := 2", code.Source())
+
+	// Damaged cache entries are replaced
+	require.Nil(t, os.WriteFile(path, []byte("{"), 0o644))
+	code, err = cache.compile(ctx, []sourceFile{{source: "version := 1"}}, []string{"len"})
+	require.Nil(t, err)
+	require.Equal(t, "version := 1", code.Source())
+	data, err = os.ReadFile(path)
+	require.Nil(t, err)
+	require.NotEqual(t, "{", string(data))
+
+	// Modules that fail to compile aren't cached
+	_, err = cache.compile(ctx, []sourceFile{{source: "func {"}}, nil)
+	require.NotNil(t, err)
+	var nilCache *CompileCache
+	_, err = nilCache.compile(ctx, []sourceFile{{source: "version := 1"}}, nil)
+	require.Nil(t, err)
+}
+
+func TestLocalImporterCompileCache(t *testing.T) {
+	src := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(src, "lib.risor"), []byte("version := 1"), 0o644))
+	cacheDir := t.TempDir()
+	im := NewLocalImporter(LocalImporterOptions{SourceDir: src, CompileCache: NewCompileCache(cacheDir)})
+	module, err := im.Import(context.Background(), "lib")
+	require.Nil(t, err)
+	require.Equal(t, "version := 1", module.Code().Source())
+	entries, err := filepath.Glob(filepath.Join(cacheDir, "*", "*"+CompiledExtension))
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+}
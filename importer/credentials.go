@@ -0,0 +1,317 @@
+package importer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Credentials authenticate requests for the modules on a host.
+type Credentials struct {
+	// Username and password for basic authentication.
+	Username string
+	Password string
+
+	// A token, such as a personal access token or an OIDC identity token.
+	// HTTPImporters send it as a bearer token. Git and OCI importers send it
+	// as the password of basic authentication, with the Username defaulting
+	// to "x-access-token".
+	Token string
+}
+
+// basicAuth returns the username and password for basic authentication.
+func (c *Credentials) basicAuth() (string, string) {
+	if c.Token == "" {
+		return c.Username, c.Password
+	}
+	if c.Username == "" {
+		return "x-access-token", c.Token
+	}
+	return c.Username, c.Token
+}
+
+// authorization returns the value of an Authorization header carrying the
+// credentials.
+func (c *Credentials) authorization() string {
+	if c.Token != "" {
+		return "Bearer " + c.Token
+	}
+	auth := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+	return "Basic " + auth
+}
+
+// CredentialProvider supplies the credentials for hosts serving modules,
+// such as "github.com" or "ghcr.io".
+type CredentialProvider interface {
+	// Credentials returns the credentials for the host, or nil if there are
+	// none.
+	Credentials(ctx context.Context, host string) (*Credentials, error)
+}
+
+// CredentialProviderFunc adapts a function to a CredentialProvider.
+type CredentialProviderFunc func(ctx context.Context, host string) (*Credentials, error)
+
+func (f CredentialProviderFunc) Credentials(ctx context.Context, host string) (*Credentials, error) {
+	return f(ctx, host)
+}
+
+// lookupCredentials returns the credentials for the host from a provider,
+// which may be nil.
+func lookupCredentials(ctx context.Context, provider CredentialProvider, host string) (*Credentials, error) {
+	if provider == nil {
+		return nil, nil
+	}
+	creds, err := provider.Credentials(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentials for %s: %w", host, err)
+	}
+	return creds, nil
+}
+
+// ChainCredentials returns a CredentialProvider that consults the given
+// providers in order, and returns the first credentials found.
+func ChainCredentials(providers ...CredentialProvider) CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context, host string) (*Credentials, error) {
+		for _, provider := range providers {
+			creds, err := provider.Credentials(ctx, host)
+			if err != nil || creds != nil {
+				return creds, err
+			}
+		}
+		return nil, nil
+	})
+}
+
+// EnvCredentials returns a CredentialProvider that reads a token for each
+// host from an environment variable named after the host, such as
+// RISOR_TOKEN_GITHUB_COM for "github.com", or RISOR_TOKEN_LOCALHOST_5000 for
+// "localhost:5000".
+func EnvCredentials() CredentialProvider {
+	return CredentialProviderFunc(func(ctx context.Context, host string) (*Credentials, error) {
+		if token := os.Getenv(TokenEnvVar(host)); token != "" {
+			return &Credentials{Token: token}, nil
+		}
+		return nil, nil
+	})
+}
+
+// TokenEnvVar returns the name of the environment variable EnvCredentials
+// reads the token for a host from.
+func TokenEnvVar(host string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, host)
+	return "RISOR_TOKEN_" + name
+}
+
+// NetrcCredentials returns a CredentialProvider that reads logins from a
+// netrc file, as used by curl and git. The path defaults to the NETRC
+// environment variable, or else ".netrc" in the home directory. A missing
+// file provides no credentials.
+func NetrcCredentials(path string) CredentialProvider {
+	var once sync.Once
+	var machines map[string]*Credentials
+	var loadErr error
+	return CredentialProviderFunc(func(ctx context.Context, host string) (*Credentials, error) {
+		once.Do(func() {
+			if path == "" {
+				path = defaultNetrcPath()
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				if !os.IsNotExist(err) {
+					loadErr = err
+				}
+				return
+			}
+			machines = parseNetrc(string(data))
+		})
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		if creds, ok := machines[host]; ok {
+			return creds, nil
+		}
+		// Entries for a host without its port still apply
+		if name, _, ok := strings.Cut(host, ":"); ok {
+			if creds, ok := machines[name]; ok {
+				return creds, nil
+			}
+		}
+		return machines[""], nil
+	})
+}
+
+func defaultNetrcPath() string {
+	if path := os.Getenv("NETRC"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc")
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// parseNetrc parses the machines of a netrc file, with the default entry
+// under an empty name.
+func parseNetrc(data string) map[string]*Credentials {
+	machines := map[string]*Credentials{}
+	var current *Credentials
+	fields := strings.Fields(data)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine", "default":
+			name := ""
+			if fields[i] == "machine" && i+1 < len(fields) {
+				i++
+				name = fields[i]
+			}
+			current = &Credentials{}
+			if _, ok := machines[name]; !ok {
+				machines[name] = current
+			}
+		case "login", "password", "account":
+			if i+1 >= len(fields) {
+				break
+			}
+			i++
+			if current == nil {
+				continue
+			}
+			if fields[i-1] == "login" {
+				current.Username = fields[i]
+			} else if fields[i-1] == "password" {
+				current.Password = fields[i]
+			}
+		case "macdef":
+			// Macros run to the end of the file in this simple parser, since
+			// their blank line terminator is lost when splitting into fields
+			return machines
+		}
+	}
+	return machines
+}
+
+// OIDCOptions configure a CredentialProvider that authenticates with the
+// OIDC identity token of a CI job.
+type OIDCOptions struct {
+	// The hosts the token is sent to. Other hosts get no credentials.
+	Hosts []string
+
+	// The audience of the token, as expected by the hosts.
+	Audience string
+
+	// An environment variable holding the token, such as one of the
+	// id_tokens of a GitLab CI job. Without it, or when it's empty, the token
+	// is requested from GitHub Actions, which requires the job to have the
+	// "id-token: write" permission.
+	TokenEnv string
+
+	// Optional HTTP client used to request tokens.
+	Client *http.Client
+}
+
+// OIDCCredentials returns a CredentialProvider that sends the OIDC identity
+// token of the current CI job to the given hosts, for module servers that
+// trust the CI provider. Tokens are requested once, and reused until they
+// are about to expire.
+func OIDCCredentials(opts OIDCOptions) CredentialProvider {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	hosts := map[string]bool{}
+	for _, host := range opts.Hosts {
+		hosts[host] = true
+	}
+	var mutex sync.Mutex
+	var token string
+	var expires time.Time
+	return CredentialProviderFunc(func(ctx context.Context, host string) (*Credentials, error) {
+		if !hosts[host] {
+			return nil, nil
+		}
+		if opts.TokenEnv != "" {
+			if token := os.Getenv(opts.TokenEnv); token != "" {
+				return &Credentials{Token: token}, nil
+			}
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		if token == "" || time.Now().After(expires) {
+			var err error
+			if token, err = requestGitHubOIDCToken(ctx, opts.Client, opts.Audience); err != nil {
+				return nil, err
+			}
+			// GitHub Actions tokens are valid for at least five minutes
+			expires = time.Now().Add(4 * time.Minute)
+		}
+		return &Credentials{Token: token}, nil
+	})
+}
+
+// requestGitHubOIDCToken requests an identity token for the audience from
+// GitHub Actions.
+func requestGitHubOIDCToken(ctx context.Context, client *http.Client, audience string) (string, error) {
+	requestURL := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+	requestToken := os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	if requestURL == "" || requestToken == "" {
+		return "", fmt.Errorf("no OIDC token is available outside of a CI job with id-token permissions")
+	}
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid OIDC token request URL: %w", err)
+	}
+	if audience != "" {
+		query := u.Query()
+		query.Set("audience", audience)
+		u.RawQuery = query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+requestToken)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request an OIDC token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request an OIDC token: %s", resp.Status)
+	}
+	var body struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil || body.Value == "" {
+		return "", fmt.Errorf("failed to request an OIDC token: invalid response")
+	}
+	return body.Value, nil
+}
+
+// SSHRepoURL returns the URL to clone a repository over SSH, for the
+// RepoURL option of a GitImporter. Authentication is then left to SSH, which
+// uses the keys held by the SSH agent, so "github.com/org/lib" is cloned
+// from "ssh://git@github.com/org/lib".
+func SSHRepoURL(repo string) string {
+	return "ssh://git@" + repo
+}
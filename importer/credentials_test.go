@@ -0,0 +1,173 @@
+package importer
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "RISOR_TOKEN_GITHUB_COM", TokenEnvVar("github.com"))
+	require.Equal(t, "RISOR_TOKEN_LOCALHOST_5000", TokenEnvVar("localhost:5000"))
+
+	t.Setenv("RISOR_TOKEN_GITHUB_COM", "ghp_secret")
+	creds, err := EnvCredentials().Credentials(ctx, "github.com")
+	require.Nil(t, err)
+	require.Equal(t, &Credentials{Token: "ghp_secret"}, creds)
+	creds, err = EnvCredentials().Credentials(ctx, "gitlab.com")
+	require.Nil(t, err)
+	require.Nil(t, creds)
+}
+
+func TestNetrcCredentials(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), ".netrc")
+	require.Nil(t, os.WriteFile(path, []byte(`
+machine git.example.com login ci password s3cret
+machine registry.example.com
+	login robot
+	password t0ken
+default login anonymous password guest
+`), 0o600))
+	provider := NetrcCredentials(path)
+	tests := map[string]*Credentials{
+		"git.example.com":           {Username: "ci", Password: "s3cret"},
+		"registry.example.com:5000": {Username: "robot", Password: "t0ken"},
+		"other.example.com":         {Username: "anonymous", Password: "guest"},
+	}
+	for host, expected := range tests {
+		creds, err := provider.Credentials(ctx, host)
+		require.Nil(t, err)
+		require.Equal(t, expected, creds)
+	}
+
+	creds, err := NetrcCredentials(filepath.Join(t.TempDir(), "missing")).Credentials(ctx, "git.example.com")
+	require.Nil(t, err)
+	require.Nil(t, creds)
+}
+
+func TestChainCredentials(t *testing.T) {
+	ctx := context.Background()
+	none := CredentialProviderFunc(func(ctx context.Context, host string) (*Credentials, error) {
+		return nil, nil
+	})
+	fixed := CredentialProviderFunc(func(ctx context.Context, host string) (*Credentials, error) {
+		return &Credentials{Token: host}, nil
+	})
+	creds, err := ChainCredentials(none, fixed).Credentials(ctx, "example.com")
+	require.Nil(t, err)
+	require.Equal(t, &Credentials{Token: "example.com"}, creds)
+	creds, err = ChainCredentials(none).Credentials(ctx, "example.com")
+	require.Nil(t, err)
+	require.Nil(t, creds)
+}
+
+func TestOIDCCredentials(t *testing.T) {
+	ctx := context.Background()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, "Bearer request-token", r.Header.Get("Authorization"))
+		require.Equal(t, "modules", r.URL.Query().Get("audience"))
+		w.Write([]byte(`{"value": "id-token"}`))
+	}))
+	defer server.Close()
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", server.URL+"/token?api-version=2.0")
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request-token")
+
+	provider := OIDCCredentials(OIDCOptions{
+		Hosts:    []string{"modules.example.com"},
+		Audience: "modules",
+		TokenEnv: "TEST_OIDC_TOKEN",
+	})
+	for i := 0; i < 2; i++ {
+		creds, err := provider.Credentials(ctx, "modules.example.com")
+		require.Nil(t, err)
+		require.Equal(t, &Credentials{Token: "id-token"}, creds)
+	}
+	require.Equal(t, 1, requests)
+
+	creds, err := provider.Credentials(ctx, "other.example.com")
+	require.Nil(t, err)
+	require.Nil(t, creds)
+
+	t.Setenv("TEST_OIDC_TOKEN", "gitlab-token")
+	creds, err = provider.Credentials(ctx, "modules.example.com")
+	require.Nil(t, err)
+	require.Equal(t, &Credentials{Token: "gitlab-token"}, creds)
+
+	t.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", "")
+	_, err = OIDCCredentials(OIDCOptions{Hosts: []string{"x"}}).Credentials(ctx, "x")
+	require.NotNil(t, err)
+}
+
+func TestHTTPImporterCredentials(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(libSource))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	opts := HTTPImporterOptions{CacheDir: t.TempDir(), Client: server.Client()}
+	_, err := NewHTTPImporter(opts).Import(ctx, server.URL+"/lib.risor")
+	require.NotNil(t, err)
+	require.Equal(t, `import error: failed to fetch "`+server.URL+`/lib.risor": 401 Unauthorized`, err.Error())
+
+	opts.Credentials = CredentialProviderFunc(func(ctx context.Context, host string) (*Credentials, error) {
+		require.Equal(t, server.Listener.Addr().String(), host)
+		return &Credentials{Token: "t0ken"}, nil
+	})
+	_, err = NewHTTPImporter(opts).Import(ctx, server.URL+"/lib.risor")
+	require.Nil(t, err)
+}
+
+func TestOCIImporterCredentials(t *testing.T) {
+	reg := newFakeRegistry(t)
+	ctx := context.Background()
+	registryOpts := OCIRegistryOptions{
+		Client: reg.server.Client(),
+		Credentials: CredentialProviderFunc(func(ctx context.Context, host string) (*Credentials, error) {
+			require.Equal(t, reg.host(), host)
+			return &Credentials{Username: "ci", Token: "secret"}, nil
+		}),
+	}
+	files := map[string][]byte{"lib.risor": []byte("version := 1")}
+	_, err := PushOCI(ctx, "oci://"+reg.host()+"/team/lib:v1", files, registryOpts)
+	require.Nil(t, err)
+	im := NewOCIImporter(OCIImporterOptions{CacheDir: t.TempDir(), Registry: registryOpts})
+	module, err := im.Import(ctx, "oci://"+reg.host()+"/team/lib:v1")
+	require.Nil(t, err)
+	require.Equal(t, "version := 1", module.Code().Source())
+}
+
+func TestGitImporterCredentials(t *testing.T) {
+	im := NewGitImporter(GitImporterOptions{
+		Credentials: CredentialProviderFunc(func(ctx context.Context, host string) (*Credentials, error) {
+			require.Equal(t, "github.com", host)
+			return &Credentials{Token: "ghp_secret"}, nil
+		}),
+	})
+	env, err := im.authEnv(context.Background(), "https://github.com/org/lib")
+	require.Nil(t, err)
+	auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:ghp_secret"))
+	require.Equal(t, []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + auth,
+	}, env)
+
+	// Repositories cloned over SSH are authenticated by SSH
+	env, err = im.authEnv(context.Background(), SSHRepoURL("github.com/org/lib"))
+	require.Nil(t, err)
+	require.Nil(t, env)
+}
@@ -0,0 +1,112 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// executableMagic ends executables that have an archive appended to them,
+// after the size of the archive.
+const executableMagic = "risor\x00exe"
+
+// executableTrailerSize is the size of the size of the archive and the magic.
+const executableTrailerSize = 8 + len(executableMagic)
+
+// ErrNoArchive is returned by OpenExecutable for executables without an
+// appended archive.
+var ErrNoArchive = errors.New("no archive appended to the executable")
+
+// WriteExecutable writes the executable at the stub path with the archive,
+// such as a bundle written by WriteBundle, appended to it. An archive the
+// stub already has is replaced. The stub is typically the Risor executable,
+// which runs the archive appended to it rather than its usual commands.
+func WriteExecutable(w io.Writer, stub string, archive []byte) error {
+	f, err := os.Open(stub)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	size, _, err := executableSize(f)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, io.NewSectionReader(f, 0, size)); err != nil {
+		return err
+	}
+	if _, err := w.Write(archive); err != nil {
+		return err
+	}
+	trailer := binary.BigEndian.AppendUint64(nil, uint64(len(archive)))
+	_, err = w.Write(append(trailer, executableMagic...))
+	return err
+}
+
+// OpenExecutable returns an importer of the modules of the archive appended
+// to the executable at the given path by WriteExecutable, which should be
+// closed when no longer needed. It returns ErrNoArchive if there is none.
+func OpenExecutable(path string, opts ...FSImporterOption) (*ArchiveImporter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	size, archiveSize, err := executableSize(f)
+	if err == nil && archiveSize == 0 {
+		err = ErrNoArchive
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r, err := zip.NewReader(io.NewSectionReader(f, size, archiveSize), archiveSize)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	a, err := newArchiveImporter(r, f, opts)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// HasArchive reports whether the executable at the given path has an
+// archive appended to it by WriteExecutable.
+func HasArchive(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	_, archiveSize, err := executableSize(f)
+	return err == nil && archiveSize > 0
+}
+
+// executableSize returns the size of the executable without its appended
+// archive, and the size of the archive, which is zero if there is none.
+func executableSize(f *os.File) (int64, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+	total := info.Size()
+	if total < int64(executableTrailerSize) {
+		return total, 0, nil
+	}
+	trailer := make([]byte, executableTrailerSize)
+	if _, err := f.ReadAt(trailer, total-int64(len(trailer))); err != nil {
+		return 0, 0, err
+	}
+	if !bytes.Equal(trailer[8:], []byte(executableMagic)) {
+		return total, 0, nil
+	}
+	archiveSize := int64(binary.BigEndian.Uint64(trailer[:8]))
+	if archiveSize > total-int64(executableTrailerSize) {
+		return 0, 0, errors.New("invalid archive appended to the executable")
+	}
+	return total - int64(executableTrailerSize) - archiveSize, archiveSize, nil
+}
@@ -0,0 +1,61 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutable(t *testing.T) {
+	dir := t.TempDir()
+	stub := filepath.Join(dir, "stub")
+	require.Nil(t, os.WriteFile(stub, []byte("#!/bin/stub\n"), 0o755))
+
+	_, err := OpenExecutable(stub)
+	require.ErrorIs(t, err, ErrNoArchive)
+	require.False(t, HasArchive(stub))
+
+	build := func(stub, source string) string {
+		var bundle bytes.Buffer
+		require.Nil(t, WriteBundle(context.Background(), &bundle, source, BundleOptions{
+			Local: fstest.MapFS{"lib.risor": {Data: []byte("x := 1")}},
+		}))
+		var exe bytes.Buffer
+		require.Nil(t, WriteExecutable(&exe, stub, bundle.Bytes()))
+		path := filepath.Join(dir, "app")
+		require.Nil(t, os.WriteFile(path, exe.Bytes(), 0o755))
+		return path
+	}
+
+	path := build(stub, "import lib\nlib.x")
+	require.True(t, HasArchive(path))
+	a, err := OpenExecutable(path)
+	require.Nil(t, err)
+	_, source, err := a.Main()
+	require.Nil(t, err)
+	require.Equal(t, "import lib\nlib.x", source)
+	_, err = a.Import(context.Background(), "lib")
+	require.Nil(t, err)
+	require.Nil(t, a.Close())
+
+	// Building from a built executable replaces its archive
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	rebuilt := filepath.Join(dir, "rebuilt")
+	require.Nil(t, os.WriteFile(rebuilt, data, 0o755))
+	path = build(rebuilt, "2")
+	data, err = os.ReadFile(path)
+	require.Nil(t, err)
+	require.True(t, bytes.HasPrefix(data, []byte("#!/bin/stub\nPK")))
+	a, err = OpenExecutable(path)
+	require.Nil(t, err)
+	defer a.Close()
+	_, source, err = a.Main()
+	require.Nil(t, err)
+	require.Equal(t, "2", source)
+}
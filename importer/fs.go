@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/object"
+)
+
+// CompiledExtension is the file extension of precompiled modules, holding
+// code serialized by compiler.MarshalCode.
+const CompiledExtension = ".risorc"
+
+type FSImporter struct {
+	fsys        fs.FS
+	globalNames []string
+	extensions  []string
+	aliases     map[string]string
+	codeCache   map[string]*compiler.Code
+	cache       *CompileCache
+	mutex       sync.Mutex
+}
+
+// FSImporterOption configures an FSImporter.
+type FSImporterOption func(*FSImporter)
+
+// WithGlobalNames sets the global names that should be available when
+// modules are compiled.
+func WithGlobalNames(names []string) FSImporterOption {
+	return func(i *FSImporter) {
+		i.globalNames = names
+	}
+}
+
+// WithExtensions sets the file extensions to try when locating a module.
+func WithExtensions(extensions []string) FSImporterOption {
+	return func(i *FSImporter) {
+		i.extensions = extensions
+	}
+}
+
+// WithCompileCache caches compiled modules on disk.
+func WithCompileCache(cache *CompileCache) FSImporterOption {
+	return func(i *FSImporter) {
+		i.cache = cache
+	}
+}
+
+// WithAliases imports the named modules from other paths in the
+// filesystem, as archives written by WriteBundle do for remote modules.
+func WithAliases(aliases map[string]string) FSImporterOption {
+	return func(i *FSImporter) {
+		i.aliases = aliases
+	}
+}
+
+// NewFSImporter returns an Importer that reads Risor code modules from a
+// filesystem, such as an embed.FS holding modules bundled into a program:
+//
+//	//go:embed lib
+//	var lib embed.FS
+//
+//	modules, _ := fs.Sub(lib, "lib")
+//	im := importer.NewFSImporter(modules)
+//
+// A module may be precompiled, in a file with the CompiledExtension, which is
+// then used instead of its source code. A module may also be a directory
+// package, made of the module files in a directory. Like the LocalImporter, it caches
+// compiled code, and is safe to reuse across VMs and evaluations.
+func NewFSImporter(fsys fs.FS, opts ...FSImporterOption) *FSImporter {
+	i := &FSImporter{
+		fsys:       fsys,
+		extensions: []string{".risor", ".rsr"},
+		codeCache:  map[string]*compiler.Code{},
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+func (i *FSImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if code, ok := i.codeCache[name]; ok {
+		return object.NewModule(name, code), nil
+	}
+	// Names of nested modules are joined with the OS path separator
+	modulePath := path.Clean(filepath.ToSlash(name))
+	if alias, ok := i.aliases[name]; ok {
+		modulePath = alias
+	}
+	if !fs.ValidPath(modulePath) {
+		return nil, notFound(name)
+	}
+	code, err := i.load(ctx, modulePath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, notFound(name)
+		}
+		return nil, err
+	}
+	i.codeCache[name] = code
+	return object.NewModule(name, code), nil
+}
+
+// load reads precompiled code for the module if there is any, or else
+// compiles its source code.
+func (i *FSImporter) load(ctx context.Context, modulePath string) (*compiler.Code, error) {
+	if data, err := fs.ReadFile(i.fsys, modulePath+CompiledExtension); err == nil {
+		code, err := compiler.UnmarshalCode(data)
+		if err != nil {
+			return nil, fmt.Errorf("import error: invalid compiled module %q: %w", modulePath, err)
+		}
+		return code, nil
+	}
+	for _, ext := range i.extensions {
+		if source, err := fs.ReadFile(i.fsys, modulePath+ext); err == nil {
+			return i.cache.compile(ctx, []sourceFile{{source: string(source)}}, i.globalNames)
+		}
+	}
+	files, err := readPackage(i.fsys, modulePath, i.extensions)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		return i.cache.compile(ctx, files, i.globalNames)
+	}
+	return nil, fs.ErrNotExist
+}
@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSImporter(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lib.risor":          {Data: []byte("version := 1")},
+		"text/strings.rsr":   {Data: []byte(`sep := ", "`)},
+		"uses_globals.risor": {Data: []byte("total := len([1, 2])")},
+		"text/ignored.txt":   {Data: []byte("notes := 1")},
+	}
+	ctx := context.Background()
+	im := NewFSImporter(fsys, WithGlobalNames([]string{"len"}))
+
+	module, err := im.Import(ctx, "lib")
+	require.Nil(t, err)
+	require.Equal(t, "version := 1", module.Code().Source())
+
+	module, err = im.Import(ctx, filepath.Join("text", "strings"))
+	require.Nil(t, err)
+	require.Equal(t, `sep := ", "`, module.Code().Source())
+
+	_, err = im.Import(ctx, "uses_globals")
+	require.Nil(t, err)
+
+	for _, name := range []string{"missing", "text/ignored", "../lib", "/lib"} {
+		_, err = im.Import(ctx, name)
+		require.NotNil(t, err)
+		require.Equal(t, `import error: module "`+name+`" not found`, err.Error())
+	}
+
+	im = NewFSImporter(fsys, WithExtensions([]string{".txt"}))
+	_, err = im.Import(ctx, "text/ignored")
+	require.Nil(t, err)
+}
+
+func TestFSImporterPackage(t *testing.T) {
+	fsys := fstest.MapFS{
+		"text/b.risor":       {Data: []byte("func shout(s) { return s + '!' }")},
+		"text/a.risor":       {Data: []byte("sep := ', '")},
+		"text/index.risor":   {Data: []byte("greeting := shout('hi')")},
+		"text/.hidden.risor": {Data: []byte("hidden := true")},
+		"text/notes.txt":     {Data: []byte("notes := 1")},
+		"text/sub/x.risor":   {Data: []byte("nested := true")},
+		"lib.risor":          {Data: []byte("where := 'file'")},
+		"lib/x.risor":        {Data: []byte("where := 'package'")},
+		"empty/notes.txt":    {Data: []byte("notes := 1")},
+	}
+	ctx := context.Background()
+	im := NewFSImporter(fsys)
+
+	// Files are combined in order of their names, with the index last
+	module, err := im.Import(ctx, "text")
+	require.Nil(t, err)
+	require.Equal(t, []string{"greeting", "sep", "shout"}, module.AttributeNames())
+	require.Equal(t, []string{"text/a.risor", "text/b.risor", "text/index.risor"},
+		packageFiles(fsys, "text", []string{".risor", ".rsr"}))
+
+	module, err = im.Import(ctx, "text/sub")
+	require.Nil(t, err)
+	require.Equal(t, []string{"nested"}, module.AttributeNames())
+
+	// Module files take precedence over directories
+	module, err = im.Import(ctx, "lib")
+	require.Nil(t, err)
+	require.Equal(t, "where := \"file\"", module.Code().Source())
+
+	_, err = im.Import(ctx, "empty")
+	require.NotNil(t, err)
+	require.Equal(t, `import error: module "empty" not found`, err.Error())
+}
+
+// unreadableFS fails to read one of its files.
+type unreadableFS struct {
+	fstest.MapFS
+	file string
+}
+
+func (f unreadableFS) ReadFile(name string) ([]byte, error) {
+	if name == f.file {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.ReadFile(name)
+}
+
+func TestFSImporterPackageFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pkg/one.risor": {Data: []byte("a := 1\nb := 2\nfunc f() { return 1 }\n")},
+		"pkg/two.risor": {Data: []byte("c := 3\nd := )\n")},
+		"ok/one.risor":  {Data: []byte("a := 1\nb := 2\n")},
+		"ok/two.risor":  {Data: []byte("c := 3\nfunc g() {\n  return c\n}\n")},
+	}
+	ctx := context.Background()
+
+	// Errors and locations refer to the file and line of the code
+	_, err := NewFSImporter(fsys).Import(ctx, "pkg")
+	var parseErr parser.ParserError
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, "pkg/two.risor", parseErr.File())
+	require.Equal(t, 2, parseErr.StartPosition().LineNumber())
+
+	module, err := NewFSImporter(fsys).Import(ctx, "ok")
+	require.Nil(t, err)
+	codes := module.Code().Flatten()
+	require.Equal(t, "ok/one.risor", codes[0].FileAt(0))
+	require.Equal(t, "g", codes[1].CodeName())
+	require.Equal(t, "ok/two.risor", codes[1].FileAt(0))
+	require.Equal(t, 3, codes[1].Location(0).Line)
+
+	// Files that can't be read fail the import
+	_, err = NewFSImporter(unreadableFS{fsys, "ok/two.risor"}).Import(ctx, "ok")
+	require.NotNil(t, err)
+	require.ErrorIs(t, err, fs.ErrPermission)
+}
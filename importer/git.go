@@ -0,0 +1,383 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/modfile"
+	"github.com/risor-io/risor/object"
+)
+
+type GitImporter struct {
+	globalNames []string
+	codeCache   map[string]*compiler.Code
+	fetched     map[string]bool
+	cacheDir    string
+	extensions  []string
+	gitPath     string
+	repoURL     func(repo string) string
+	verify      func(name string, source []byte) error
+	trustedKeys TrustedKeys
+	cache       *CompileCache
+	credentials CredentialProvider
+	mutex       sync.Mutex
+}
+
+// GitImporterOptions configure an Importer that fetches modules from git
+// repositories.
+type GitImporterOptions struct {
+	// Global names that should be available when the module is compiled.
+	GlobalNames []string
+
+	// The directory where repositories are checked out. Defaults to
+	// "risor/git" in the user cache directory.
+	CacheDir string
+
+	// Optional list of file extensions to try when locating a Risor module.
+	Extensions []string
+
+	// The git executable. Defaults to "git", found in the PATH.
+	GitPath string
+
+	// Optional function returning the URL to clone a repository from, given
+	// its path such as "github.com/org/lib". Defaults to cloning over HTTPS.
+	// Use SSHRepoURL to clone over SSH instead.
+	RepoURL func(repo string) string
+
+	// Optional credentials for the hosts of repositories cloned over HTTPS.
+	Credentials CredentialProvider
+
+	// Optional function checking the source of each module before it is
+	// compiled, such as the Verify method of a Lock.
+	Verify func(name string, source []byte) error
+
+	// Optional keys, one of which must have signed each module. The
+	// signature of a module file is read from the file next to it in the
+	// repository, named with SignatureExtension appended. Each file of a
+	// directory package must be signed.
+	TrustedKeys TrustedKeys
+
+	// Optional cache of compiled modules on disk.
+	CompileCache *CompileCache
+}
+
+// NewGitImporter returns an Importer that fetches Risor code modules from git
+// repositories, such as in `import "github.com/org/lib@v1.2.0"`.
+//
+// A module path names a repository with its first three elements, and a
+// module file within the repository with the rest. Without the rest, the
+// module file is named after the repository, so "github.com/org/lib" refers
+// to "lib.risor" at the root of the repository, while "github.com/org/lib/x"
+// refers to "x.risor". The version after the "@" may be a tag, a branch, or
+// a commit hash.
+//
+// Each version of a repository is checked out once into the cache directory,
+// and reused afterwards. Modules imported without a version track the
+// default branch of the repository, and are fetched again by each importer.
+func NewGitImporter(opts GitImporterOptions) *GitImporter {
+	if opts.CacheDir == "" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			opts.CacheDir = filepath.Join(dir, "risor", "git")
+		}
+	}
+	if opts.Extensions == nil {
+		opts.Extensions = []string{".risor", ".rsr"}
+	}
+	if opts.GitPath == "" {
+		opts.GitPath = "git"
+	}
+	if opts.RepoURL == nil {
+		opts.RepoURL = func(repo string) string { return "https://" + repo }
+	}
+	return &GitImporter{
+		globalNames: opts.GlobalNames,
+		codeCache:   map[string]*compiler.Code{},
+		fetched:     map[string]bool{},
+		cacheDir:    opts.CacheDir,
+		extensions:  opts.Extensions,
+		gitPath:     opts.GitPath,
+		repoURL:     opts.RepoURL,
+		verify:      opts.Verify,
+		trustedKeys: opts.TrustedKeys,
+		cache:       opts.CompileCache,
+		credentials: opts.Credentials,
+	}
+}
+
+// GitModule is a module path split into its parts.
+type GitModule struct {
+	// The repository, such as "github.com/org/lib".
+	Repo string
+	// The module file within the repository, without an extension.
+	File string
+	// The requested version, or an empty string for the default branch.
+	Ref string
+}
+
+// ParseGitModule splits a module path such as "github.com/org/lib/x@v1.2.0"
+// into its repository, module file, and version.
+func ParseGitModule(name string) (GitModule, error) {
+	var m GitModule
+	modulePath, ref, hasRef := strings.Cut(name, "@")
+	if hasRef {
+		if ref == "" || strings.HasPrefix(ref, "-") || strings.ContainsAny(ref, " \t\n:~^?*[\\") ||
+			strings.Contains(ref, "..") {
+			return m, fmt.Errorf("import error: invalid version %q in module %q", ref, name)
+		}
+		m.Ref = ref
+	}
+	parts := strings.Split(modulePath, "/")
+	if len(parts) < 3 || !strings.Contains(parts[0], ".") {
+		return m, notFound(name)
+	}
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." || strings.HasPrefix(part, "-") {
+			return m, fmt.Errorf("import error: invalid module path %q", name)
+		}
+	}
+	m.Repo = strings.Join(parts[:3], "/")
+	if len(parts) > 3 {
+		m.File = path.Join(parts[3:]...)
+	} else {
+		m.File = parts[2]
+	}
+	return m, nil
+}
+
+func (i *GitImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if code, ok := i.codeCache[name]; ok {
+		return object.NewModule(name, code), nil
+	}
+	m, err := ParseGitModule(name)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := i.checkout(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	files, err := readFileWithExtensions(dir, filepath.FromSlash(m.File), i.extensions)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("import error: module %q not found in %s", m.File, m.Repo)
+	} else if err != nil {
+		return nil, err
+	}
+	// Name the files by their paths in the repository
+	for j := range files {
+		if rel, err := filepath.Rel(dir, files[j].name); err == nil {
+			files[j].name = filepath.ToSlash(rel)
+		}
+	}
+	if i.verify != nil {
+		if err := i.verify(name, []byte(joinSources(files))); err != nil {
+			return nil, err
+		}
+	}
+	if i.trustedKeys != nil {
+		fsys := os.DirFS(dir)
+		files := moduleFiles(fsys, m.File, i.extensions)
+		if err := i.trustedKeys.verifyFiles(name, fsys, files); err != nil {
+			return nil, err
+		}
+	}
+	code, err := i.cache.compile(ctx, files, i.globalNames)
+	if err != nil {
+		return nil, err
+	}
+	i.codeCache[name] = code
+	return object.NewModule(name, code), nil
+}
+
+// Manifest returns the project manifest at the root of the repository of a
+// required module, at the required version, or nil if there is none. It is
+// meant to be the function that resolves the dependencies of a project, as
+// given to WithDependencies. With a Verify option, the manifest is checked
+// under the name "<repo>@<version>/risor.mod".
+func (i *GitImporter) Manifest(ctx context.Context, req modfile.Require) (*modfile.File, error) {
+	if req.IsURL() || req.IsOCI() {
+		return nil, nil
+	}
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	m, err := ParseGitModule(req.Path + "@" + req.Version)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := i.checkout(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, modfile.Name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if i.verify != nil {
+		if err := i.verify(m.Repo+"@"+m.Ref+"/"+modfile.Name, data); err != nil {
+			return nil, err
+		}
+	}
+	return modfile.Parse(m.Repo+"@"+m.Ref+"/"+modfile.Name, data)
+}
+
+// vendor copies the module files of a required repository, with their
+// signatures and the repository's manifest, into the vendor directory. With
+// no vendor directory, the repository is only checked out into the cache.
+func (i *GitImporter) vendor(ctx context.Context, req modfile.Require, vendorDir string) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	m, err := ParseGitModule(req.Path + "@" + req.Version)
+	if err != nil {
+		return err
+	}
+	dir, err := i.checkout(ctx, m)
+	if err != nil || vendorDir == "" {
+		return err
+	}
+	dst := vendorPath(m.Repo + "@" + m.Ref)
+	return filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && file != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !i.isVendored(d.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		return writeVendorFile(vendorDir, path.Join(dst, filepath.ToSlash(rel)), data)
+	})
+}
+
+// isVendored returns true if the file of a repository is vendored.
+func (i *GitImporter) isVendored(name string) bool {
+	if name == modfile.Name {
+		return true
+	}
+	name = strings.TrimSuffix(name, SignatureExtension)
+	for _, ext := range i.extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkout returns the directory holding the requested version of the
+// repository, fetching it if needed.
+func (i *GitImporter) checkout(ctx context.Context, m GitModule) (string, error) {
+	if i.cacheDir == "" {
+		return "", fmt.Errorf("import error: no cache directory for git modules")
+	}
+	ref := m.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	dir := filepath.Join(i.cacheDir, filepath.FromSlash(m.Repo)+"@"+ref)
+	key := m.Repo + "@" + ref
+	if _, err := os.Stat(dir); err == nil && (m.Ref != "" || i.fetched[key]) {
+		return dir, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", fmt.Errorf("import error: %w", err)
+	}
+	// Check out into a temporary directory, so a failed or concurrent fetch
+	// never leaves a partial checkout behind
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".fetch-*")
+	if err != nil {
+		return "", fmt.Errorf("import error: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	url := i.repoURL(m.Repo)
+	env, err := i.authEnv(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("import error: %w", err)
+	}
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", "--", url, ref},
+		{"-c", "advice.detachedHead=false", "checkout", "--quiet", "--detach", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		if err := i.git(ctx, tmp, env, args...); err != nil {
+			return "", fmt.Errorf("import error: failed to fetch %s at %s: %w", m.Repo, ref, err)
+		}
+	}
+	os.RemoveAll(filepath.Join(tmp, ".git"))
+	if m.Ref == "" {
+		// Replace the previous checkout of the default branch
+		os.RemoveAll(dir)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		// Another process may have checked out the same version first
+		if _, statErr := os.Stat(dir); statErr != nil {
+			return "", fmt.Errorf("import error: %w", err)
+		}
+	}
+	i.fetched[key] = true
+	return dir, nil
+}
+
+// authEnv returns the environment variables that authenticate git with the
+// host of a repository cloned over HTTPS. The credentials are passed as git
+// configuration in the environment, which unlike arguments isn't visible to
+// other users of the system.
+func (i *GitImporter) authEnv(ctx context.Context, repoURL string) ([]string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Scheme != "https" {
+		return nil, nil
+	}
+	creds, err := lookupCredentials(ctx, i.credentials, u.Host)
+	if err != nil || creds == nil {
+		return nil, err
+	}
+	username, password := creds.basicAuth()
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: Basic " + auth,
+	}, nil
+}
+
+func (i *GitImporter) git(ctx context.Context, dir string, env []string, args ...string) error {
+	cmd := exec.CommandContext(ctx, i.gitPath, args...)
+	cmd.Dir = dir
+	// Never prompt for credentials
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s", msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/modfile"
+	"github.com/stretchr/testify/require"
+)
+
+// newGitRepo creates a repository holding lib.risor, with the version tagged
+// v1.0.0 returning 1 and the latest version returning 2. It returns the
+// repository directory and the commit hash of v1.0.0.
+func newGitRepo(t *testing.T) (string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.Nil(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, source string) {
+		require.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755))
+		require.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644))
+	}
+	run("init", "--quiet", "--initial-branch=main")
+	write("lib.risor", "version := 1")
+	write("util/strings.risor", `sep := ", "`)
+	run("add", ".")
+	run("commit", "--quiet", "-m", "v1")
+	run("tag", "v1.0.0")
+	commit := run("rev-parse", "HEAD")
+	write("lib.risor", "version := 2")
+	run("commit", "--quiet", "-am", "v2")
+	return dir, commit
+}
+
+func TestParseGitModule(t *testing.T) {
+	m, err := ParseGitModule("github.com/org/lib@v1.2.0")
+	require.Nil(t, err)
+	require.Equal(t, GitModule{Repo: "github.com/org/lib", File: "lib", Ref: "v1.2.0"}, m)
+
+	m, err = ParseGitModule("github.com/org/tools/text/strings")
+	require.Nil(t, err)
+	require.Equal(t, GitModule{Repo: "github.com/org/tools", File: "text/strings"}, m)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"lib", `import error: module "lib" not found`},
+		{"org/lib/x", `import error: module "org/lib/x" not found`},
+		{"github.com/org/../lib", `import error: invalid module path "github.com/org/../lib"`},
+		{"github.com/org/lib@--upload-pack=x", `import error: invalid version "--upload-pack=x" in module "github.com/org/lib@--upload-pack=x"`},
+		{"github.com/org/lib@", `import error: invalid version "" in module "github.com/org/lib@"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGitModule(tt.name)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestGitImporter(t *testing.T) {
+	repo, commit := newGitRepo(t)
+	ctx := context.Background()
+	opts := GitImporterOptions{
+		CacheDir: t.TempDir(),
+		RepoURL: func(name string) string {
+			require.Equal(t, "example.com/org/lib", name)
+			return repo
+		},
+	}
+	im := NewGitImporter(opts)
+	tests := map[string]string{
+		"example.com/org/lib@v1.0.0":       "version := 1",
+		"example.com/org/lib@" + commit:    "version := 1",
+		"example.com/org/lib@main":         "version := 2",
+		"example.com/org/lib":              "version := 2",
+		"example.com/org/lib/lib@v1.0.0":   "version := 1",
+		"example.com/org/lib/util/strings": `sep := ", "`,
+		"example.com/org/lib/missing@v1":   "",
+		"example.com/org/lib@v9.9.9":       "",
+	}
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			module, err := im.Import(ctx, name)
+			if source == "" {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, source, module.Code().Source())
+		})
+	}
+
+	// Versions are checked out once, and reused by other importers
+	_, err := os.Stat(filepath.Join(opts.CacheDir, "example.com", "org", "lib@v1.0.0", "lib.risor"))
+	require.Nil(t, err)
+	opts.RepoURL = func(string) string { return filepath.Join(t.TempDir(), "gone") }
+	module, err := NewGitImporter(opts).Import(ctx, "example.com/org/lib@v1.0.0")
+	require.Nil(t, err)
+	require.Equal(t, "version := 1", module.Code().Source())
+}
+
+func TestGitImporterManifest(t *testing.T) {
+	repo, _ := newGitRepo(t)
+	require.Nil(t, os.WriteFile(filepath.Join(repo, modfile.Name),
+		[]byte("module example.com/org/lib\n\nrequire example.com/org/dep v1.0.0\n"), 0o644))
+	for _, args := range [][]string{{"add", "."}, {"commit", "--quiet", "-m", "v3"}, {"tag", "v1.1.0"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		require.Nil(t, err, string(out))
+	}
+	ctx := context.Background()
+	im := NewGitImporter(GitImporterOptions{
+		CacheDir: t.TempDir(),
+		RepoURL:  func(string) string { return repo },
+	})
+	manifest, err := im.Manifest(ctx, modfile.Require{Path: "example.com/org/lib", Version: "v1.1.0"})
+	require.Nil(t, err)
+	require.Equal(t, []modfile.Require{{Path: "example.com/org/dep", Version: "v1.0.0"}}, manifest.Requires)
+
+	// Versions without a manifest have no dependencies
+	manifest, err = im.Manifest(ctx, modfile.Require{Path: "example.com/org/lib", Version: "v1.0.0"})
+	require.Nil(t, err)
+	require.Nil(t, manifest)
+}
@@ -0,0 +1,395 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/modfile"
+	"github.com/risor-io/risor/object"
+)
+
+// DefaultMaxModuleSize is the largest module an HTTPImporter fetches by
+// default.
+const DefaultMaxModuleSize = 10 * 1024 * 1024
+
+type HTTPImporter struct {
+	globalNames   []string
+	codeCache     map[string]*compiler.Code
+	cacheDir      string
+	pins          map[string]string
+	requirePins   bool
+	allowInsecure bool
+	client        *http.Client
+	maxSize       int64
+	verify        func(name string, source []byte) error
+	trustedKeys   TrustedKeys
+	cache         *CompileCache
+	credentials   CredentialProvider
+	mutex         sync.Mutex
+}
+
+// HTTPImporterOptions configure an Importer that fetches modules from URLs.
+type HTTPImporterOptions struct {
+	// Global names that should be available when the module is compiled.
+	GlobalNames []string
+
+	// The directory where fetched modules are cached by their checksum.
+	// Defaults to "risor/modules" in the user cache directory.
+	CacheDir string
+
+	// SHA-256 checksums of modules, as hex strings keyed by URL. These may
+	// be read from a pin file with ReadPins.
+	Pins map[string]string
+
+	// Refuse to import modules that don't have a checksum.
+	RequirePins bool
+
+	// Allow importing modules over plain HTTP, including by following
+	// redirects from HTTPS to HTTP, which are refused otherwise.
+	AllowInsecure bool
+
+	// Optional HTTP client used to fetch modules.
+	Client *http.Client
+
+	// Optional credentials for the hosts serving modules.
+	Credentials CredentialProvider
+
+	// The largest module that may be fetched, in bytes. Defaults to
+	// DefaultMaxModuleSize.
+	MaxSize int64
+
+	// Optional function checking the source of each module before it is
+	// compiled, such as the Verify method of a Lock.
+	Verify func(name string, source []byte) error
+
+	// Optional keys, one of which must have signed each module. The
+	// signature of a module is fetched from its URL with SignatureExtension
+	// appended.
+	TrustedKeys TrustedKeys
+
+	// Optional cache of compiled modules on disk.
+	CompileCache *CompileCache
+}
+
+// NewHTTPImporter returns an Importer that fetches Risor code modules from
+// HTTPS URLs, such as in `import "https://example.com/lib.risor" as lib`.
+//
+// A module may be pinned to the SHA-256 checksum of its source, either at the
+// import site with a "#sha256=<hex>" fragment on the URL, or in the Pins
+// option. Modules are refused if their source doesn't match the checksum.
+// Pinned modules are cached on disk, so they are only fetched once, while
+// modules without a checksum are fetched once per importer.
+func NewHTTPImporter(opts HTTPImporterOptions) *HTTPImporter {
+	if opts.CacheDir == "" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			opts.CacheDir = filepath.Join(dir, "risor", "modules")
+		}
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if !opts.AllowInsecure {
+		// Copy the client so that the one given in the options is unchanged
+		client := *opts.Client
+		client.CheckRedirect = refuseDowngrade(client.CheckRedirect)
+		opts.Client = &client
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultMaxModuleSize
+	}
+	pins := map[string]string{}
+	for u, sum := range opts.Pins {
+		pins[u] = strings.ToLower(sum)
+	}
+	return &HTTPImporter{
+		globalNames:   opts.GlobalNames,
+		codeCache:     map[string]*compiler.Code{},
+		cacheDir:      opts.CacheDir,
+		pins:          pins,
+		requirePins:   opts.RequirePins,
+		allowInsecure: opts.AllowInsecure,
+		client:        opts.Client,
+		maxSize:       opts.MaxSize,
+		verify:        opts.Verify,
+		trustedKeys:   opts.TrustedKeys,
+		cache:         opts.CompileCache,
+		credentials:   opts.Credentials,
+	}
+}
+
+func (i *HTTPImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	location, pin, err := i.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	if code, ok := i.codeCache[name]; ok {
+		return object.NewModule(location, code), nil
+	}
+	source, err := i.readCache(pin)
+	if err != nil {
+		if source, err = i.fetch(ctx, location); err != nil {
+			return nil, err
+		}
+		sum := checksum(source)
+		if pin != "" && sum != pin {
+			return nil, fmt.Errorf("import error: checksum mismatch for %q (expected sha256:%s, got sha256:%s)",
+				location, pin, sum)
+		}
+		i.writeCache(sum, source)
+	}
+	if i.verify != nil {
+		if err := i.verify(location, source); err != nil {
+			return nil, err
+		}
+	}
+	if i.trustedKeys != nil {
+		signature, err := i.fetch(ctx, location+SignatureExtension)
+		if err != nil {
+			return nil, err
+		}
+		if err := i.trustedKeys.Verify(location, source, signature); err != nil {
+			return nil, err
+		}
+	}
+	code, err := i.cache.compile(ctx, []sourceFile{{source: string(source)}}, i.globalNames)
+	if err != nil {
+		return nil, err
+	}
+	i.codeCache[name] = code
+	return object.NewModule(location, code), nil
+}
+
+// vendor copies a required module into the vendor directory, with its
+// signature when there are trusted keys. With no vendor directory, the
+// module is only fetched into the cache.
+func (i *HTTPImporter) vendor(ctx context.Context, req modfile.Require, vendorDir string) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	location, pin, err := i.resolve(req.Path + "#sha256=" + strings.TrimPrefix(req.Version, "sha256:"))
+	if err != nil {
+		return err
+	}
+	source, err := i.readCache(pin)
+	if err != nil {
+		if source, err = i.fetch(ctx, location); err != nil {
+			return err
+		}
+		if sum := checksum(source); sum != pin {
+			return fmt.Errorf("import error: checksum mismatch for %q (expected sha256:%s, got sha256:%s)",
+				location, pin, sum)
+		}
+		i.writeCache(pin, source)
+	}
+	if vendorDir == "" {
+		return nil
+	}
+	u, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	file := vendorPath(u.Host + u.Path)
+	if err := writeVendorFile(vendorDir, file, source); err != nil {
+		return err
+	}
+	if i.trustedKeys == nil {
+		return nil
+	}
+	signature, err := i.fetch(ctx, location+SignatureExtension)
+	if err != nil {
+		return err
+	}
+	return writeVendorFile(vendorDir, file+SignatureExtension, signature)
+}
+
+// resolve returns the URL of the named module, without any fragment, and
+// the checksum it is pinned to, if any.
+func (i *HTTPImporter) resolve(name string) (string, string, error) {
+	u, err := url.Parse(name)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", "", notFound(name)
+	}
+	if u.Scheme == "http" && !i.allowInsecure {
+		return "", "", fmt.Errorf("import error: module %q must be imported over https", name)
+	}
+	var sitePin string
+	if u.Fragment != "" {
+		var ok bool
+		sitePin, ok = strings.CutPrefix(u.Fragment, "sha256=")
+		if !ok || !isChecksum(sitePin) {
+			return "", "", fmt.Errorf("import error: invalid checksum %q for module %q", u.Fragment, name)
+		}
+		sitePin = strings.ToLower(sitePin)
+	}
+	u.Fragment = ""
+	location := u.String()
+	pin := i.pins[location]
+	if sitePin != "" {
+		if pin != "" && pin != sitePin {
+			return "", "", fmt.Errorf("import error: conflicting checksums for %q (sha256:%s at the import, sha256:%s pinned)",
+				location, sitePin, pin)
+		}
+		pin = sitePin
+	}
+	if pin == "" && i.requirePins {
+		return "", "", fmt.Errorf("import error: module %q has no checksum", location)
+	}
+	return location, pin, nil
+}
+
+func (i *HTTPImporter) fetch(ctx context.Context, location string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	creds, err := lookupCredentials(ctx, i.credentials, req.URL.Host)
+	if err != nil {
+		return nil, fmt.Errorf("import error: %w", err)
+	}
+	if creds != nil {
+		req.Header.Set("Authorization", creds.authorization())
+	}
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("import error: failed to fetch %q: %w", location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("import error: failed to fetch %q: %s", location, resp.Status)
+	}
+	source, err := io.ReadAll(io.LimitReader(resp.Body, i.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("import error: failed to fetch %q: %w", location, err)
+	}
+	if int64(len(source)) > i.maxSize {
+		return nil, fmt.Errorf("import error: module %q is larger than %d bytes", location, i.maxSize)
+	}
+	return source, nil
+}
+
+// refuseDowngrade returns a redirect policy that refuses redirects from
+// https to plain http, and otherwise applies the given policy or, if it is
+// nil, the default policy of http.Client.
+func refuseDowngrade(next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" && via[len(via)-1].URL.Scheme == "https" {
+			return fmt.Errorf("refusing redirect from https to %q", req.URL.Redacted())
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+// readCache returns the cached module source with the given checksum.
+func (i *HTTPImporter) readCache(sum string) ([]byte, error) {
+	if sum == "" || i.cacheDir == "" {
+		return nil, os.ErrNotExist
+	}
+	source, err := os.ReadFile(filepath.Join(i.cacheDir, sum+".risor"))
+	if err != nil {
+		return nil, err
+	}
+	// Ignore cache entries that were changed on disk
+	if checksum(source) != sum {
+		return nil, os.ErrNotExist
+	}
+	return source, nil
+}
+
+// writeCache caches the module source by its checksum. Failing to cache a
+// module isn't an error, since it can be fetched again.
+func (i *HTTPImporter) writeCache(sum string, source []byte) {
+	if i.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(i.cacheDir, 0o755); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(i.cacheDir, sum+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(source)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(i.cacheDir, sum+".risor"))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func isChecksum(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// ReadPins reads the module checksums in a pin file, for the Pins option of
+// an HTTPImporter. Each line of the file holds a module URL and its checksum,
+// such as "https://example.com/lib.risor sha256:<hex>". Blank lines and lines
+// starting with "#" are ignored.
+func ReadPins(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParsePins(f)
+}
+
+// ParsePins parses module checksums in the format read by ReadPins.
+func ParsePins(r io.Reader) (map[string]string, error) {
+	pins := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected a module URL and its checksum", n)
+		}
+		sum, ok := strings.CutPrefix(fields[1], "sha256:")
+		if !ok || !isChecksum(sum) {
+			return nil, fmt.Errorf("line %d: invalid checksum %q", n, fields[1])
+		}
+		if _, ok := pins[fields[0]]; ok {
+			return nil, fmt.Errorf("line %d: duplicate checksum for %q", n, fields[0])
+		}
+		pins[fields[0]] = strings.ToLower(sum)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return pins, nil
+}
@@ -0,0 +1,197 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const libSource = `func greet(name) { return "hello " + name }`
+
+// newModuleServer serves libSource at /lib.risor, and counts the requests.
+func newModuleServer(t *testing.T) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/lib.risor" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(libSource))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestHTTPImporter(t *testing.T) {
+	server, requests := newModuleServer(t)
+	ctx := context.Background()
+	sum := checksum([]byte(libSource))
+	cacheDir := t.TempDir()
+	opts := HTTPImporterOptions{CacheDir: cacheDir, Client: server.Client()}
+	lib := server.URL + "/lib.risor"
+
+	im := NewHTTPImporter(opts)
+	module, err := im.Import(ctx, lib+"#sha256="+sum)
+	require.Nil(t, err)
+	require.Equal(t, lib, module.Name().Value())
+	_, ok := module.GetAttr("greet")
+	require.True(t, ok)
+	require.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	// Pinned modules come from the cache after the first fetch
+	cached, err := os.ReadFile(filepath.Join(cacheDir, sum+".risor"))
+	require.Nil(t, err)
+	require.Equal(t, libSource, string(cached))
+	_, err = NewHTTPImporter(opts).Import(ctx, lib+"#sha256="+strings.ToUpper(sum))
+	require.Nil(t, err)
+	opts.Pins = map[string]string{lib: sum}
+	_, err = NewHTTPImporter(opts).Import(ctx, lib)
+	require.Nil(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	// Modules without a checksum are fetched by each importer
+	opts.Pins = nil
+	_, err = NewHTTPImporter(opts).Import(ctx, lib)
+	require.Nil(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(requests))
+}
+
+func TestHTTPImporterErrors(t *testing.T) {
+	server, _ := newModuleServer(t)
+	ctx := context.Background()
+	lib := server.URL + "/lib.risor"
+	sum := checksum([]byte(libSource))
+	wrong := checksum([]byte("other"))
+	opts := HTTPImporterOptions{CacheDir: t.TempDir(), Client: server.Client()}
+	tests := []struct {
+		name     string
+		opts     func(o *HTTPImporterOptions)
+		module   string
+		expected string
+	}{
+		{
+			"mismatch at the import",
+			nil,
+			lib + "#sha256=" + wrong,
+			`import error: checksum mismatch for "` + lib + `" (expected sha256:` + wrong + `, got sha256:` + sum + `)`,
+		},
+		{
+			"mismatch in pins",
+			func(o *HTTPImporterOptions) { o.Pins = map[string]string{lib: wrong} },
+			lib,
+			`import error: checksum mismatch for "` + lib + `" (expected sha256:` + wrong + `, got sha256:` + sum + `)`,
+		},
+		{
+			"conflicting pins",
+			func(o *HTTPImporterOptions) { o.Pins = map[string]string{lib: wrong} },
+			lib + "#sha256=" + sum,
+			`import error: conflicting checksums for "` + lib + `" (sha256:` + sum + ` at the import, sha256:` + wrong + ` pinned)`,
+		},
+		{
+			"invalid checksum",
+			nil,
+			lib + "#md5=abc",
+			`import error: invalid checksum "md5=abc" for module "` + lib + `#md5=abc"`,
+		},
+		{
+			"required pin",
+			func(o *HTTPImporterOptions) { o.RequirePins = true },
+			lib,
+			`import error: module "` + lib + `" has no checksum`,
+		},
+		{
+			"not found",
+			nil,
+			server.URL + "/missing.risor",
+			`import error: failed to fetch "` + server.URL + `/missing.risor": 404 Not Found`,
+		},
+		{
+			"too large",
+			func(o *HTTPImporterOptions) { o.MaxSize = 10 },
+			lib,
+			`import error: module "` + lib + `" is larger than 10 bytes`,
+		},
+		{
+			"insecure",
+			nil,
+			"http://example.com/lib.risor",
+			`import error: module "http://example.com/lib.risor" must be imported over https`,
+		},
+		{
+			"not a url",
+			nil,
+			"lib",
+			`import error: module "lib" not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := opts
+			if tt.opts != nil {
+				tt.opts(&o)
+			}
+			_, err := NewHTTPImporter(o).Import(ctx, tt.module)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestHTTPImporterRedirects(t *testing.T) {
+	ctx := context.Background()
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(libSource))
+	}))
+	t.Cleanup(plain.Close)
+	secure, _ := newModuleServer(t)
+	redirect := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := secure.URL
+		if r.URL.Path == "/insecure.risor" {
+			target = plain.URL
+		}
+		http.Redirect(w, r, target+"/lib.risor", http.StatusFound)
+	}))
+	t.Cleanup(redirect.Close)
+	opts := HTTPImporterOptions{CacheDir: t.TempDir(), Client: redirect.Client()}
+
+	// Redirects between https URLs are followed
+	_, err := NewHTTPImporter(opts).Import(ctx, redirect.URL+"/secure.risor")
+	require.Nil(t, err)
+
+	// Redirects from https to http are refused unless insecure imports are
+	// allowed
+	_, err = NewHTTPImporter(opts).Import(ctx, redirect.URL+"/insecure.risor")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "refusing redirect from https to \""+plain.URL+"/lib.risor\"")
+	require.Nil(t, redirect.Client().CheckRedirect)
+	opts.AllowInsecure = true
+	_, err = NewHTTPImporter(opts).Import(ctx, redirect.URL+"/insecure.risor")
+	require.Nil(t, err)
+}
+
+func TestParsePins(t *testing.T) {
+	sum := checksum([]byte(libSource))
+	pins, err := ParsePins(strings.NewReader(`
+# Shared modules
+https://example.com/lib.risor sha256:` + strings.ToUpper(sum) + `
+`))
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"https://example.com/lib.risor": sum}, pins)
+
+	_, err = ParsePins(strings.NewReader("https://example.com/lib.risor " + sum))
+	require.NotNil(t, err)
+	require.Equal(t, `line 1: invalid checksum "`+sum+`"`, err.Error())
+
+	_, err = ParsePins(strings.NewReader("https://example.com/lib.risor"))
+	require.NotNil(t, err)
+	require.Equal(t, "line 1: expected a module URL and its checksum", err.Error())
+}
@@ -3,27 +3,60 @@ package importer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
+	"github.com/risor-io/risor/ast"
 	"github.com/risor-io/risor/compiler"
 	"github.com/risor-io/risor/object"
 	"github.com/risor-io/risor/parser"
 )
 
+// PackageIndex is the name of the optional index file of a directory
+// package, without its extension.
+const PackageIndex = "index"
+
 // Importer is an interface used to import Risor code modules
 type Importer interface {
 	// Import a module by name
 	Import(ctx context.Context, name string) (*object.Module, error)
 }
 
+// ErrNotFound is matched by the errors of importers that don't provide the
+// requested module, as opposed to failing to load it.
+var ErrNotFound = errors.New("module not found")
+
+// NotFoundError is returned by importers that don't provide a module.
+type NotFoundError struct {
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("import error: module %q not found", e.Name)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+func notFound(name string) error {
+	return &NotFoundError{Name: name}
+}
+
 type LocalImporter struct {
 	globalNames []string
 	codeCache   map[string]*compiler.Code
 	sourceDir   string
 	extensions  []string
+	cache       *CompileCache
+	stamps      map[string]map[string]fileStamp
 	mutex       sync.Mutex
 }
 
@@ -38,6 +71,9 @@ type LocalImporterOptions struct {
 
 	// Optional list of file extensions to try when locating a Risor module.
 	Extensions []string
+
+	// Optional cache of compiled modules on disk.
+	CompileCache *CompileCache
 }
 
 // NewLocalImporter returns an Importer that can read Risor code modules from
@@ -55,6 +91,8 @@ func NewLocalImporter(opts LocalImporterOptions) *LocalImporter {
 		codeCache:   map[string]*compiler.Code{},
 		sourceDir:   opts.SourceDir,
 		extensions:  opts.Extensions,
+		cache:       opts.CompileCache,
+		stamps:      map[string]map[string]fileStamp{},
 	}
 }
 
@@ -64,33 +102,178 @@ func (i *LocalImporter) Import(ctx context.Context, name string) (*object.Module
 	if code, ok := i.codeCache[name]; ok {
 		return object.NewModule(name, code), nil
 	}
-	source, found := readFileWithExtensions(i.sourceDir, name, i.extensions)
-	if !found {
-		return nil, fmt.Errorf("import error: module %q not found", name)
-	}
-	ast, err := parser.Parse(ctx, source)
+	stamps := fileStamps(i.sourceDir, name, i.extensions)
+	files, err := readFileWithExtensions(i.sourceDir, name, i.extensions)
 	if err != nil {
 		return nil, err
 	}
-	var opts []compiler.Option
-	if len(i.globalNames) > 0 {
-		opts = append(opts, compiler.WithGlobalNames(i.globalNames))
-	}
-	code, err := compiler.Compile(ast, opts...)
+	code, err := i.cache.compile(ctx, files, i.globalNames)
 	if err != nil {
 		return nil, err
 	}
 	i.codeCache[name] = code
+	i.stamps[name] = stamps
 	return object.NewModule(name, code), nil
 }
 
-func readFileWithExtensions(dir, name string, extensions []string) (string, bool) {
+// Changed returns the names of the imported modules whose files changed
+// since they were imported, and forgets their code, so that importing them
+// again reads the changed files.
+func (i *LocalImporter) Changed() []string {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	var names []string
+	for name, stamps := range i.stamps {
+		if !sameStamps(stamps, fileStamps(i.sourceDir, name, i.extensions)) {
+			names = append(names, name)
+			delete(i.codeCache, name)
+			delete(i.stamps, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sourceFile is the source code of one of the files of a module, which is
+// named if the file is known.
+type sourceFile struct {
+	name   string
+	source string
+}
+
+// joinSources returns the source code of the files of a module as one
+// string, as its checksum is computed.
+func joinSources(files []sourceFile) string {
+	sources := make([]string, 0, len(files))
+	for _, file := range files {
+		sources = append(sources, file.source)
+	}
+	return strings.Join(sources, "\n")
+}
+
+// compile parses and compiles the files of a module.
+func compile(ctx context.Context, files []sourceFile, globalNames []string) (*compiler.Code, error) {
+	program, err := parseFiles(ctx, files)
+	if err != nil {
+		return nil, err
+	}
+	var opts []compiler.Option
+	if len(globalNames) > 0 {
+		opts = append(opts, compiler.WithGlobalNames(globalNames))
+	}
+	return compiler.Compile(program, opts...)
+}
+
+// parseFiles parses the files of a module as one program. Each file is
+// parsed on its own, so that errors and the locations of the code refer to
+// the file and line they come from.
+func parseFiles(ctx context.Context, files []sourceFile) (*ast.Program, error) {
+	if len(files) == 1 {
+		return parser.Parse(ctx, files[0].source, parser.WithFile(files[0].name))
+	}
+	var statements []ast.Node
+	for _, file := range files {
+		program, err := parser.Parse(ctx, file.source, parser.WithFile(file.name))
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, program.Statements()...)
+	}
+	return ast.NewProgram(statements), nil
+}
+
+// readFileWithExtensions reads the files of the named module in the
+// directory, which is either a module file with one of the extensions or a
+// directory package. The files are named by their paths.
+func readFileWithExtensions(dir, name string, extensions []string) ([]sourceFile, error) {
 	for _, ext := range extensions {
 		fullPath := filepath.Join(dir, name+ext)
 		bytes, err := os.ReadFile(fullPath)
 		if err == nil {
-			return string(bytes), true
+			return []sourceFile{{name: fullPath, source: string(bytes)}}, nil
+		}
+	}
+	packagePath := filepath.ToSlash(name)
+	if !fs.ValidPath(packagePath) {
+		return nil, notFound(name)
+	}
+	if dir == "" {
+		dir = "."
+	}
+	files, err := readPackage(os.DirFS(dir), packagePath, extensions)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, notFound(name)
+	}
+	for i := range files {
+		files[i].name = filepath.Join(dir, filepath.FromSlash(files[i].name))
+	}
+	return files, nil
+}
+
+// readPackage reads the files of a directory package, which is a module
+// made of the module files in a directory, so a large module can be split
+// into files. The files share their globals, as if they were one file made
+// of them in order of their names, with the index file last so it can use
+// what the other files define. Nested directories are modules of their
+// own. It returns no files if the directory holds no module files.
+func readPackage(fsys fs.FS, dir string, extensions []string) ([]sourceFile, error) {
+	paths := packageFiles(fsys, dir, extensions)
+	files := make([]sourceFile, 0, len(paths))
+	for _, file := range paths {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("import error: %w", err)
 		}
+		files = append(files, sourceFile{name: file, source: string(data)})
+	}
+	return files, nil
+}
+
+// moduleFiles returns the files of the named module, which is either a
+// module file with one of the extensions, or a directory package.
+func moduleFiles(fsys fs.FS, name string, extensions []string) []string {
+	for _, ext := range extensions {
+		if info, err := fs.Stat(fsys, name+ext); err == nil && !info.IsDir() {
+			return []string{name + ext}
+		}
+	}
+	return packageFiles(fsys, name, extensions)
+}
+
+// packageFiles returns the paths of the module files of a directory package,
+// sorted by name with the index file last.
+func packageFiles(fsys fs.FS, dir string, extensions []string) []string {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+	isModule := func(name string) bool {
+		for _, ext := range extensions {
+			if strings.HasSuffix(name, ext) {
+				return true
+			}
+		}
+		return false
+	}
+	isIndex := func(name string) bool {
+		return strings.TrimSuffix(name, path.Ext(name)) == PackageIndex
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !isModule(name) {
+			continue
+		}
+		files = append(files, name)
+	}
+	sort.SliceStable(files, func(a, b int) bool {
+		return !isIndex(files[a]) && isIndex(files[b])
+	})
+	for i, file := range files {
+		files[i] = path.Join(dir, file)
 	}
-	return "", false
+	return files
 }
@@ -0,0 +1,84 @@
+package importer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/risor-io/risor/modfile"
+)
+
+// Lock verifies remote modules against the checksums in a lockfile, and
+// records the checksums of modules the lockfile doesn't know yet. Its Verify
+// method is meant for the Verify option of the remote importers.
+type Lock struct {
+	sums    map[string]string
+	used    map[string]bool
+	update  bool
+	changed bool
+	mutex   sync.Mutex
+}
+
+// NewLock returns a Lock holding the checksums in the given lockfile, which
+// may be nil for a project without one. With update set, modules that no
+// longer match the lockfile are accepted, and their checksums replaced.
+func NewLock(lockfile *modfile.Lock, update bool) *Lock {
+	sums := map[string]string{}
+	if lockfile != nil {
+		for name, sum := range lockfile.Sums {
+			sums[name] = sum
+		}
+	}
+	return &Lock{sums: sums, used: map[string]bool{}, update: update}
+}
+
+// Verify checks the source of the named module against the lockfile.
+func (l *Lock) Verify(name string, source []byte) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	sum := "sha256:" + checksum(source)
+	expected, ok := l.sums[name]
+	if ok && expected == sum {
+		l.used[name] = true
+		return nil
+	}
+	if ok && !l.update {
+		return fmt.Errorf("import error: module %q doesn't match %s (expected %s, got %s)",
+			name, modfile.LockName, expected, sum)
+	}
+	l.sums[name] = sum
+	l.used[name] = true
+	l.changed = true
+	return nil
+}
+
+// Prune removes the checksums of the modules that weren't verified since
+// the Lock was created, once every module of a project has been imported.
+func (l *Lock) Prune() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for name := range l.sums {
+		if !l.used[name] {
+			delete(l.sums, name)
+			l.changed = true
+		}
+	}
+}
+
+// Changed returns true if checksums were added or replaced since the Lock
+// was created, so the lockfile needs to be written again.
+func (l *Lock) Changed() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.changed
+}
+
+// File returns the lockfile holding the current checksums.
+func (l *Lock) File() *modfile.Lock {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	sums := make(map[string]string, len(l.sums))
+	for name, sum := range l.sums {
+		sums[name] = sum
+	}
+	return &modfile.Lock{Sums: sums}
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/modfile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	v1 := "sha256:" + checksum([]byte("version := 1"))
+	lock := NewLock(&modfile.Lock{Sums: map[string]string{"example.com/org/lib@v1": v1}}, false)
+	require.Nil(t, lock.Verify("example.com/org/lib@v1", []byte("version := 1")))
+	require.False(t, lock.Changed())
+
+	// Modules the lockfile doesn't know yet are recorded
+	require.Nil(t, lock.Verify("example.com/org/lib@v2", []byte("version := 2")))
+	require.True(t, lock.Changed())
+	require.Equal(t, map[string]string{
+		"example.com/org/lib@v1": v1,
+		"example.com/org/lib@v2": "sha256:" + checksum([]byte("version := 2")),
+	}, lock.File().Sums)
+
+	// Modules that changed since they were locked are refused
+	err := lock.Verify("example.com/org/lib@v1", []byte("version := 3"))
+	require.NotNil(t, err)
+	require.Equal(t, `import error: module "example.com/org/lib@v1" doesn't match risor.lock (expected `+
+		v1+`, got sha256:`+checksum([]byte("version := 3"))+`)`, err.Error())
+
+	// Unless the lock is being updated
+	lock = NewLock(&modfile.Lock{Sums: map[string]string{"example.com/org/lib@v1": v1}}, true)
+	require.Nil(t, lock.Verify("example.com/org/lib@v1", []byte("version := 3")))
+	require.True(t, lock.Changed())
+	require.Equal(t, "sha256:"+checksum([]byte("version := 3")), lock.File().Sums["example.com/org/lib@v1"])
+}
+
+func TestLockPrune(t *testing.T) {
+	v1 := "sha256:" + checksum([]byte("version := 1"))
+	v2 := "sha256:" + checksum([]byte("version := 2"))
+	lock := NewLock(&modfile.Lock{Sums: map[string]string{
+		"example.com/org/lib@v1": v1,
+		"example.com/org/lib@v2": v2,
+	}}, false)
+	require.Nil(t, lock.Verify("example.com/org/lib@v2", []byte("version := 2")))
+	require.False(t, lock.Changed())
+
+	// Only the checksums of the modules verified are kept
+	lock.Prune()
+	require.True(t, lock.Changed())
+	require.Equal(t, map[string]string{"example.com/org/lib@v2": v2}, lock.File().Sums)
+}
+
+func TestGitImporterLock(t *testing.T) {
+	repo, _ := newGitRepo(t)
+	ctx := context.Background()
+	lock := NewLock(&modfile.Lock{Sums: map[string]string{
+		"example.com/org/lib@main": "sha256:" + checksum([]byte("version := 1")),
+	}}, false)
+	im := NewGitImporter(GitImporterOptions{
+		CacheDir: t.TempDir(),
+		RepoURL:  func(string) string { return repo },
+		Verify:   lock.Verify,
+	})
+	_, err := im.Import(ctx, "example.com/org/lib@v1.0.0")
+	require.Nil(t, err)
+	require.Contains(t, lock.File().Sums, "example.com/org/lib@v1.0.0")
+
+	// The main branch moved on since it was locked
+	_, err = im.Import(ctx, "example.com/org/lib@main")
+	require.NotNil(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), `import error: module "example.com/org/lib@main" doesn't match risor.lock`))
+}
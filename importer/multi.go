@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/risor-io/risor/object"
+)
+
+// Route sends the imports of modules whose names start with Prefix to an
+// importer, such as "oci://" to an OCIImporter.
+type Route struct {
+	Prefix   string
+	Importer Importer
+}
+
+type MultiImporter struct {
+	importers []Importer
+	routes    []Route
+	prefixes  []string
+	overrides map[string]string
+}
+
+// MultiImporterOptions configure an Importer that consults other importers.
+type MultiImporterOptions struct {
+	// Importers consulted in order for modules no route matches, such as a
+	// local directory, then a vendor directory, then a remote source. The
+	// first importer that provides the module wins.
+	Importers []Importer
+
+	// Routes send modules to specific importers by the prefix of their name.
+	// The longest matching prefix wins, and its importer is the only one
+	// consulted.
+	Routes []Route
+
+	// Overrides rename modules before they are imported, by the path of the
+	// module without any version. An override of "github.com/org/lib" to
+	// "lib" imports "github.com/org/lib/x@v1.2.0" as "lib/x", which is
+	// useful for working on a local copy of a shared module.
+	Overrides map[string]string
+}
+
+// NewMultiImporter returns an Importer that consults an ordered list of
+// importers, with routing of modules to importers by their name.
+func NewMultiImporter(opts MultiImporterOptions) *MultiImporter {
+	routes := make([]Route, len(opts.Routes))
+	copy(routes, opts.Routes)
+	sort.SliceStable(routes, func(a, b int) bool {
+		return len(routes[a].Prefix) > len(routes[b].Prefix)
+	})
+	var prefixes []string
+	for prefix := range opts.Overrides {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(a, b int) bool {
+		return len(prefixes[a]) > len(prefixes[b])
+	})
+	return &MultiImporter{
+		importers: opts.Importers,
+		routes:    routes,
+		prefixes:  prefixes,
+		overrides: opts.Overrides,
+	}
+}
+
+func (i *MultiImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	resolved := i.override(name)
+	for _, route := range i.routes {
+		if strings.HasPrefix(resolved, route.Prefix) {
+			return route.Importer.Import(ctx, resolved)
+		}
+	}
+	for _, im := range i.importers {
+		module, err := im.Import(ctx, resolved)
+		if err == nil {
+			return module, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, notFound(name)
+}
+
+// Changed returns the names of the changed modules among those imported by
+// the routes and importers that can detect changes.
+func (i *MultiImporter) Changed() []string {
+	importers := append([]Importer{}, i.importers...)
+	for _, route := range i.routes {
+		importers = append(importers, route.Importer)
+	}
+	return changed(importers...)
+}
+
+// override returns the name of the module to import in place of the named
+// one, which is the name itself unless an override applies.
+func (i *MultiImporter) override(name string) string {
+	modulePath := name
+	if at := strings.LastIndex(name, "@"); at > strings.LastIndex(name, "/") {
+		modulePath = name[:at]
+	}
+	for _, prefix := range i.prefixes {
+		rest, ok := strings.CutPrefix(modulePath, prefix)
+		if ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+			return i.overrides[prefix] + rest
+		}
+	}
+	return name
+}
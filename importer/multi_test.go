@@ -0,0 +1,77 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingImporter records the names it's asked to import.
+type recordingImporter struct {
+	Importer
+	names []string
+}
+
+func (r *recordingImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	r.names = append(r.names, name)
+	return r.Importer.Import(ctx, name)
+}
+
+func TestMultiImporter(t *testing.T) {
+	ctx := context.Background()
+	local := NewFSImporter(fstest.MapFS{
+		"app.risor":    {Data: []byte(`where := "local"`)},
+		"lib/x.risor":  {Data: []byte(`where := "local lib"`)},
+		"broken.risor": {Data: []byte(`func {`)},
+	})
+	vendor := &recordingImporter{Importer: NewFSImporter(fstest.MapFS{
+		"app.risor":                    {Data: []byte(`where := "vendor"`)},
+		"broken.risor":                 {Data: []byte(`where := "vendor"`)},
+		"github.com/org/lib/x.risor":   {Data: []byte(`where := "vendor lib"`)},
+		"github.com/org/lib/y.risor":   {Data: []byte(`where := "vendor lib"`)},
+		"github.com/org/other.risor":   {Data: []byte(`where := "vendor other"`)},
+		"github.com/org/library.risor": {Data: []byte(`where := "vendor library"`)},
+	})}
+	remote := &recordingImporter{Importer: NewFSImporter(fstest.MapFS{})}
+	im := NewMultiImporter(MultiImporterOptions{
+		Importers: []Importer{local, vendor},
+		Routes:    []Route{{Prefix: "oci://", Importer: remote}},
+		Overrides: map[string]string{"github.com/org/lib": "lib"},
+	})
+
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"app", `where := "local"`},
+		{"github.com/org/other", `where := "vendor other"`},
+		{"github.com/org/lib/x@v1.2.0", `where := "local lib"`},
+		{"github.com/org/library", `where := "vendor library"`},
+	}
+	for _, tt := range tests {
+		module, err := im.Import(ctx, tt.name)
+		require.Nil(t, err, tt.name)
+		require.Equal(t, tt.source, module.Code().Source(), tt.name)
+	}
+
+	// Errors other than missing modules stop the search
+	_, err := im.Import(ctx, "broken")
+	require.NotNil(t, err)
+	require.False(t, errors.Is(err, ErrNotFound))
+
+	// The override applies, and the local importer doesn't have the module
+	_, err = im.Import(ctx, "github.com/org/lib/y")
+	require.True(t, errors.Is(err, ErrNotFound))
+	require.Equal(t, `import error: module "github.com/org/lib/y" not found`, err.Error())
+	require.Equal(t, "lib/y", vendor.names[len(vendor.names)-1])
+
+	// Routed modules only go to their importer
+	_, err = im.Import(ctx, "oci://reg/app")
+	require.True(t, errors.Is(err, ErrNotFound))
+	require.Equal(t, []string{"oci://reg/app"}, remote.names)
+	require.NotContains(t, vendor.names, "oci://reg/app")
+}
@@ -0,0 +1,755 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/modfile"
+	"github.com/risor-io/risor/object"
+)
+
+// Media types of Risor module bundles stored as OCI artifacts.
+const (
+	OCIArtifactType    = "application/vnd.risor.module.v1"
+	OCIModuleMediaType = "application/vnd.risor.module.source.v1"
+
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	ociEmptyMediaType    = "application/vnd.oci.empty.v1+json"
+	ociTitleAnnotation   = "org.opencontainers.image.title"
+)
+
+// OCIReference identifies a module bundle in an OCI registry, and optionally
+// a module file within it.
+type OCIReference struct {
+	// The registry host, such as "ghcr.io".
+	Registry string
+	// The repository in the registry, such as "org/lib".
+	Repository string
+	// The tag of the bundle, if there is no digest.
+	Tag string
+	// The digest of the bundle manifest, such as "sha256:<hex>".
+	Digest string
+	// The module file within the bundle, without an extension.
+	File string
+}
+
+// ParseOCIReference parses a reference such as
+// "oci://ghcr.io/org/lib:v1.2.0" or "oci://ghcr.io/org/lib@sha256:<hex>",
+// which may be followed by the path of a module file in the bundle, as in
+// "oci://ghcr.io/org/tools:v1/strings". The tag defaults to "latest".
+func ParseOCIReference(name string) (OCIReference, error) {
+	var r OCIReference
+	rest, ok := strings.CutPrefix(name, "oci://")
+	if !ok {
+		return r, notFound(name)
+	}
+	r.Registry, rest, _ = strings.Cut(rest, "/")
+	i := strings.IndexAny(rest, ":@")
+	if i < 0 {
+		r.Repository, r.Tag = rest, "latest"
+	} else {
+		r.Repository = rest[:i]
+		var ref string
+		ref, r.File, _ = strings.Cut(rest[i+1:], "/")
+		if rest[i] == '@' {
+			r.Digest = ref
+			if !isDigest(ref) {
+				return r, fmt.Errorf("import error: invalid digest %q in module %q", ref, name)
+			}
+		} else {
+			r.Tag = ref
+			if !validTag(ref) {
+				return r, fmt.Errorf("import error: invalid tag %q in module %q", ref, name)
+			}
+		}
+	}
+	if r.Registry == "" || r.Repository == "" {
+		return r, fmt.Errorf("import error: invalid module %q", name)
+	}
+	for _, part := range strings.Split(r.Repository, "/") {
+		if part == "" || part != strings.ToLower(part) || strings.Trim(part, "abcdefghijklmnopqrstuvwxyz0123456789._-") != "" {
+			return r, fmt.Errorf("import error: invalid repository %q in module %q", r.Repository, name)
+		}
+	}
+	if r.File != "" {
+		for _, part := range strings.Split(r.File, "/") {
+			if part == "" || part == "." || part == ".." {
+				return r, fmt.Errorf("import error: invalid module path %q", name)
+			}
+		}
+	}
+	return r, nil
+}
+
+func validTag(tag string) bool {
+	if tag == "" || len(tag) > 128 || tag[0] == '.' || tag[0] == '-' {
+		return false
+	}
+	for _, c := range tag {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// ref returns the digest of the bundle if there is one, or else its tag.
+func (r OCIReference) ref() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// OCIRegistryOptions configure access to OCI registries.
+type OCIRegistryOptions struct {
+	// Optional HTTP client used to reach registries.
+	Client *http.Client
+
+	// Optional credentials for registries that require them.
+	Username string
+	Password string
+
+	// Optional credentials for each registry, used without a Username and
+	// Password.
+	Credentials CredentialProvider
+
+	// Reach registries over plain HTTP, such as a local test registry.
+	PlainHTTP bool
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Data        []byte            `json:"data,omitempty"`
+}
+
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        ociDescriptor     `json:"config"`
+	Layers        []ociDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// registry is a minimal client of the OCI distribution API.
+type registry struct {
+	opts   OCIRegistryOptions
+	tokens map[string]string
+}
+
+func newRegistry(opts OCIRegistryOptions) *registry {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &registry{opts: opts, tokens: map[string]string{}}
+}
+
+func (r *registry) url(ref OCIReference, kind, id string) string {
+	scheme := "https"
+	if r.opts.PlainHTTP {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s/%s", scheme, ref.Registry, ref.Repository, kind, id)
+}
+
+// do sends a request built by newRequest, authenticating with the registry
+// and sending it again if the registry asks for credentials.
+func (r *registry) do(ctx context.Context, ref OCIReference, scope string, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	key := ref.Registry + " " + scope
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		req = req.WithContext(ctx)
+		if token, ok := r.tokens[key]; ok {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := r.opts.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return resp, nil
+		}
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		token, err := r.authenticate(ctx, ref.Registry, challenge, scope)
+		if err != nil {
+			return nil, err
+		}
+		r.tokens[key] = token
+	}
+}
+
+// authenticate answers a challenge from a registry, and returns the value of
+// the Authorization header to send.
+func (r *registry) authenticate(ctx context.Context, host, challenge, scope string) (string, error) {
+	username, password := r.opts.Username, r.opts.Password
+	if username == "" && password == "" {
+		creds, err := lookupCredentials(ctx, r.opts.Credentials, host)
+		if err != nil {
+			return "", err
+		}
+		if creds != nil {
+			username, password = creds.basicAuth()
+		}
+	}
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if username == "" && password == "" {
+			return "", fmt.Errorf("registry requires credentials")
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(username, password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || realm.Host == "" {
+			return "", fmt.Errorf("registry sent an invalid token realm %q", params["realm"])
+		}
+		query := realm.Query()
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		query.Set("scope", scope)
+		realm.RawQuery = query.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if username != "" || password != "" {
+			req.SetBasicAuth(username, password)
+		}
+		resp, err := r.opts.Client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("failed to get a registry token: %s", resp.Status)
+		}
+		var body struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+			return "", fmt.Errorf("failed to get a registry token: %w", err)
+		}
+		if body.Token == "" {
+			body.Token = body.AccessToken
+		}
+		return "Bearer " + body.Token, nil
+	default:
+		return "", fmt.Errorf("registry requires unsupported authentication %q", scheme)
+	}
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example.com/token",service="registry"`.
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, ", "), "=")
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return scheme, params
+}
+
+func statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	var registryErr struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &registryErr) == nil && len(registryErr.Errors) > 0 && registryErr.Errors[0].Message != "" {
+		return fmt.Errorf("%s: %s", resp.Status, registryErr.Errors[0].Message)
+	}
+	return fmt.Errorf("%s", resp.Status)
+}
+
+func digestOf(data []byte) string {
+	return "sha256:" + checksum(data)
+}
+
+// manifest fetches the manifest of a bundle.
+func (r *registry) manifest(ctx context.Context, ref OCIReference) ([]byte, error) {
+	resp, err := r.do(ctx, ref, "repository:"+ref.Repository+":pull", func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodGet, r.url(ref, "manifests", ref.ref()), nil)
+		if err == nil {
+			req.Header.Set("Accept", ociManifestMediaType)
+		}
+		return req, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, err
+	}
+	if digest := digestOf(data); ref.Digest != "" && digest != ref.Digest {
+		return nil, fmt.Errorf("manifest digest mismatch (expected %s, got %s)", ref.Digest, digest)
+	}
+	return data, nil
+}
+
+// blob fetches a blob, and verifies it matches its descriptor.
+func (r *registry) blob(ctx context.Context, ref OCIReference, desc ociDescriptor) ([]byte, error) {
+	resp, err := r.do(ctx, ref, "repository:"+ref.Repository+":pull", func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, r.url(ref, "blobs", desc.Digest), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, desc.Size+1))
+	if err != nil {
+		return nil, err
+	}
+	if digest := digestOf(data); digest != desc.Digest || int64(len(data)) != desc.Size {
+		return nil, fmt.Errorf("blob digest mismatch (expected %s, got %s)", desc.Digest, digest)
+	}
+	return data, nil
+}
+
+// upload stores a blob in the repository, unless it is already there.
+func (r *registry) upload(ctx context.Context, ref OCIReference, data []byte) error {
+	scope := "repository:" + ref.Repository + ":pull,push"
+	digest := digestOf(data)
+	resp, err := r.do(ctx, ref, scope, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodHead, r.url(ref, "blobs", digest), nil)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	resp, err = r.do(ctx, ref, scope, func() (*http.Request, error) {
+		return http.NewRequest(http.MethodPost, r.url(ref, "blobs", "uploads/"), nil)
+	})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return statusError(resp)
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("registry sent an invalid upload location: %w", err)
+	}
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+	resp, err = r.do(ctx, ref, scope, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, location.String(), bytes.NewReader(data))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/octet-stream")
+		}
+		return req, err
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return statusError(resp)
+	}
+	return nil
+}
+
+// PushOCI stores module files, keyed by their path, as a bundle in an OCI
+// registry under the given reference, such as "oci://ghcr.io/org/lib:v1.2.0".
+// It returns the digest of the bundle manifest, which imports can be pinned
+// to.
+func PushOCI(ctx context.Context, reference string, files map[string][]byte, opts OCIRegistryOptions) (string, error) {
+	ref, err := ParseOCIReference(reference)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" || ref.File != "" {
+		return "", fmt.Errorf("push error: reference %q must be a repository and tag", reference)
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("push error: no files to push")
+	}
+	r := newRegistry(opts)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	m := ociManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		ArtifactType:  OCIArtifactType,
+		Config: ociDescriptor{
+			MediaType: ociEmptyMediaType,
+			Digest:    digestOf([]byte("{}")),
+			Size:      2,
+			Data:      []byte("{}"),
+		},
+	}
+	if err := r.upload(ctx, ref, []byte("{}")); err != nil {
+		return "", fmt.Errorf("push error: %w", err)
+	}
+	for _, name := range names {
+		data := files[name]
+		if err := r.upload(ctx, ref, data); err != nil {
+			return "", fmt.Errorf("push error: failed to upload %s: %w", name, err)
+		}
+		m.Layers = append(m.Layers, ociDescriptor{
+			MediaType:   OCIModuleMediaType,
+			Digest:      digestOf(data),
+			Size:        int64(len(data)),
+			Annotations: map[string]string{ociTitleAnnotation: path.Clean(filepath.ToSlash(name))},
+		})
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.do(ctx, ref, "repository:"+ref.Repository+":pull,push", func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, r.url(ref, "manifests", ref.Tag), bytes.NewReader(data))
+		if err == nil {
+			req.Header.Set("Content-Type", ociManifestMediaType)
+		}
+		return req, err
+	})
+	if err != nil {
+		return "", fmt.Errorf("push error: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("push error: %w", statusError(resp))
+	}
+	return digestOf(data), nil
+}
+
+type OCIImporter struct {
+	globalNames []string
+	codeCache   map[string]*compiler.Code
+	cacheDir    string
+	extensions  []string
+	registry    *registry
+	verify      func(name string, source []byte) error
+	trustedKeys TrustedKeys
+	cache       *CompileCache
+	mutex       sync.Mutex
+}
+
+// OCIImporterOptions configure an Importer that pulls modules from OCI
+// registries.
+type OCIImporterOptions struct {
+	// Global names that should be available when the module is compiled.
+	GlobalNames []string
+
+	// The directory where pulled module files are cached by their digest.
+	// Defaults to "risor/oci" in the user cache directory.
+	CacheDir string
+
+	// Optional list of file extensions to try when locating a Risor module.
+	Extensions []string
+
+	// Access to the registries.
+	Registry OCIRegistryOptions
+
+	// Optional function checking the source of each module before it is
+	// compiled, such as the Verify method of a Lock.
+	Verify func(name string, source []byte) error
+
+	// Optional keys, one of which must have signed each module. The
+	// signature of a module file is read from the file of the bundle named
+	// with SignatureExtension appended, as pushed alongside it.
+	TrustedKeys TrustedKeys
+
+	// Optional cache of compiled modules on disk.
+	CompileCache *CompileCache
+}
+
+// NewOCIImporter returns an Importer that pulls Risor code modules from
+// bundles stored in OCI registries by PushOCI, such as in
+// `import "oci://ghcr.io/org/lib:v1.2.0"`.
+//
+// Without a module file in the reference, the bundle's only file is
+// imported, or else the file named after the last element of the
+// repository. Pinning the bundle to a digest, as in
+// `import "oci://ghcr.io/org/lib@sha256:<hex>" as lib`, guarantees the
+// imported code never changes. Pulled files are verified against their
+// digests and cached on disk.
+func NewOCIImporter(opts OCIImporterOptions) *OCIImporter {
+	if opts.CacheDir == "" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			opts.CacheDir = filepath.Join(dir, "risor", "oci")
+		}
+	}
+	if opts.Extensions == nil {
+		opts.Extensions = []string{".risor", ".rsr"}
+	}
+	return &OCIImporter{
+		globalNames: opts.GlobalNames,
+		codeCache:   map[string]*compiler.Code{},
+		cacheDir:    opts.CacheDir,
+		extensions:  opts.Extensions,
+		registry:    newRegistry(opts.Registry),
+		verify:      opts.Verify,
+		trustedKeys: opts.TrustedKeys,
+		cache:       opts.CompileCache,
+	}
+}
+
+func (i *OCIImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	if code, ok := i.codeCache[name]; ok {
+		return object.NewModule(name, code), nil
+	}
+	ref, err := ParseOCIReference(name)
+	if err != nil {
+		return nil, err
+	}
+	m, err := i.manifest(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("import error: failed to pull %q: %w", name, err)
+	}
+	desc, err := i.layer(ref, m)
+	if err != nil {
+		return nil, err
+	}
+	source, err := i.readCache(desc.Digest)
+	if err != nil {
+		if source, err = i.registry.blob(ctx, ref, desc); err != nil {
+			return nil, fmt.Errorf("import error: failed to pull %q: %w", name, err)
+		}
+		i.writeCache(source)
+	}
+	if i.verify != nil {
+		if err := i.verify(name, source); err != nil {
+			return nil, err
+		}
+	}
+	if i.trustedKeys != nil {
+		signature, err := i.signature(ctx, name, ref, m, desc)
+		if err != nil {
+			return nil, err
+		}
+		if err := i.trustedKeys.Verify(name, source, signature); err != nil {
+			return nil, err
+		}
+	}
+	code, err := i.cache.compile(ctx, []sourceFile{{source: string(source)}}, i.globalNames)
+	if err != nil {
+		return nil, err
+	}
+	i.codeCache[name] = code
+	return object.NewModule(name, code), nil
+}
+
+// manifest returns the manifest of a bundle. Manifests pinned by digest
+// never change, so they are cached.
+func (i *OCIImporter) manifest(ctx context.Context, ref OCIReference) (*ociManifest, error) {
+	var raw []byte
+	err := os.ErrNotExist
+	if ref.Digest != "" {
+		raw, err = i.readCache(ref.Digest)
+	}
+	if err != nil {
+		if raw, err = i.registry.manifest(ctx, ref); err != nil {
+			return nil, err
+		}
+		if ref.Digest != "" {
+			i.writeCache(raw)
+		}
+	}
+	var m ociManifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// layer returns the descriptor of the module file the reference selects.
+func (i *OCIImporter) layer(ref OCIReference, m *ociManifest) (ociDescriptor, error) {
+	var layers []ociDescriptor
+	for _, layer := range m.Layers {
+		// Signatures pushed alongside the module files aren't modules
+		if layer.MediaType == OCIModuleMediaType &&
+			!strings.HasSuffix(layer.Annotations[ociTitleAnnotation], SignatureExtension) {
+			layers = append(layers, layer)
+		}
+	}
+	if len(layers) == 0 {
+		return ociDescriptor{}, fmt.Errorf("import error: %s/%s is not a Risor module bundle", ref.Registry, ref.Repository)
+	}
+	file := ref.File
+	if file == "" {
+		if len(layers) == 1 {
+			return layers[0], nil
+		}
+		file = path.Base(ref.Repository)
+	}
+	for _, ext := range i.extensions {
+		for _, layer := range layers {
+			if layer.Annotations[ociTitleAnnotation] == file+ext {
+				return layer, nil
+			}
+		}
+	}
+	return ociDescriptor{}, fmt.Errorf("import error: module %q not found in %s/%s", file, ref.Registry, ref.Repository)
+}
+
+// vendor copies the module files of a required bundle into the vendor
+// directory, along with their signatures when there are trusted keys. With
+// no vendor directory, the module files are only pulled into the cache.
+func (i *OCIImporter) vendor(ctx context.Context, req modfile.Require, vendorDir string) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	name := req.Path + ":" + req.Version
+	if strings.HasPrefix(req.Version, "sha256:") {
+		name = req.Path + "@" + req.Version
+	}
+	ref, err := ParseOCIReference(name)
+	if err != nil {
+		return err
+	}
+	m, err := i.manifest(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("import error: failed to pull %q: %w", name, err)
+	}
+	dir := vendorPath(ref.Registry + "/" + ref.Repository + "@" + ref.ref())
+	for _, layer := range m.Layers {
+		title := layer.Annotations[ociTitleAnnotation]
+		if layer.MediaType != OCIModuleMediaType ||
+			(i.trustedKeys == nil && strings.HasSuffix(title, SignatureExtension)) {
+			continue
+		}
+		if !fs.ValidPath(title) {
+			return fmt.Errorf("import error: invalid file %q in %q", title, name)
+		}
+		data, err := i.readCache(layer.Digest)
+		if err != nil {
+			if data, err = i.registry.blob(ctx, ref, layer); err != nil {
+				return fmt.Errorf("import error: failed to pull %q: %w", name, err)
+			}
+			i.writeCache(data)
+		}
+		if vendorDir == "" {
+			continue
+		}
+		if err := writeVendorFile(vendorDir, path.Join(dir, title), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signature returns the signature of a module file, from the bundle.
+func (i *OCIImporter) signature(ctx context.Context, name string, ref OCIReference, m *ociManifest, desc ociDescriptor) ([]byte, error) {
+	title := desc.Annotations[ociTitleAnnotation] + SignatureExtension
+	for _, layer := range m.Layers {
+		if layer.Annotations[ociTitleAnnotation] != title {
+			continue
+		}
+		data, err := i.readCache(layer.Digest)
+		if err != nil {
+			if data, err = i.registry.blob(ctx, ref, layer); err != nil {
+				return nil, fmt.Errorf("import error: failed to pull %q: %w", name, err)
+			}
+			i.writeCache(data)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("import error: module %q is not signed (no %s)", name, title)
+}
+
+func (i *OCIImporter) blobPath(digest string) string {
+	return filepath.Join(i.cacheDir, "blobs", strings.Replace(digest, ":", string(filepath.Separator), 1))
+}
+
+// readCache returns the cached manifest or file with the given digest.
+func (i *OCIImporter) readCache(digest string) ([]byte, error) {
+	if i.cacheDir == "" {
+		return nil, os.ErrNotExist
+	}
+	data, err := os.ReadFile(i.blobPath(digest))
+	if err != nil {
+		return nil, err
+	}
+	if digestOf(data) != digest {
+		return nil, os.ErrNotExist
+	}
+	return data, nil
+}
+
+// writeCache caches a pulled manifest or file. Failing to cache it isn't an
+// error, since it can be pulled again.
+func (i *OCIImporter) writeCache(data []byte) {
+	if i.cacheDir == "" {
+		return
+	}
+	dst := i.blobPath(digestOf(data))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(dst), ".pull-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// isDigest returns true if s is a digest such as "sha256:<hex>".
+func isDigest(s string) bool {
+	sum, ok := strings.CutPrefix(s, "sha256:")
+	return ok && isChecksum(sum) && sum == strings.ToLower(sum)
+}
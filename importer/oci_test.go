@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry is an in-memory OCI registry that requires a bearer token,
+// which it hands out to the user "ci" with the password "secret".
+type fakeRegistry struct {
+	mutex     sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	pulls     int
+	server    *httptest.Server
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+	reg := &fakeRegistry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	reg.server = httptest.NewTLSServer(http.HandlerFunc(reg.serve))
+	t.Cleanup(reg.server.Close)
+	return reg
+}
+
+func (reg *fakeRegistry) host() string {
+	return strings.TrimPrefix(reg.server.URL, "https://")
+}
+
+func (reg *fakeRegistry) serve(w http.ResponseWriter, r *http.Request) {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+	if r.URL.Path == "/token" {
+		if user, password, _ := r.BasicAuth(); user != "ci" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"token": "t0ken"}`))
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer t0ken" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+reg.server.URL+`/token",service="fake"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/v2/team/lib/")
+	switch {
+	case path == "blobs/uploads/" && r.Method == http.MethodPost:
+		w.Header().Set("Location", "/v2/team/lib/blobs/uploads/1")
+		w.WriteHeader(http.StatusAccepted)
+	case path == "blobs/uploads/1" && r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if digestOf(data) != r.URL.Query().Get("digest") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reg.blobs[digestOf(data)] = data
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(path, "blobs/"):
+		data, ok := reg.blobs[strings.TrimPrefix(path, "blobs/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			reg.pulls++
+			w.Write(data)
+		}
+	case strings.HasPrefix(path, "manifests/") && r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		reg.manifests[strings.TrimPrefix(path, "manifests/")] = data
+		reg.manifests[digestOf(data)] = data
+		w.WriteHeader(http.StatusCreated)
+	case strings.HasPrefix(path, "manifests/"):
+		data, ok := reg.manifests[strings.TrimPrefix(path, "manifests/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors": [{"code": "MANIFEST_UNKNOWN", "message": "manifest unknown"}]}`))
+			return
+		}
+		reg.pulls++
+		w.Write(data)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	ref, err := ParseOCIReference("oci://ghcr.io/org/lib:v1.2.0")
+	require.Nil(t, err)
+	require.Equal(t, OCIReference{Registry: "ghcr.io", Repository: "org/lib", Tag: "v1.2.0"}, ref)
+
+	digest := digestOf([]byte("manifest"))
+	ref, err = ParseOCIReference("oci://localhost:5000/tools@" + digest + "/text/strings")
+	require.Nil(t, err)
+	require.Equal(t, OCIReference{Registry: "localhost:5000", Repository: "tools", Digest: digest, File: "text/strings"}, ref)
+
+	ref, err = ParseOCIReference("oci://ghcr.io/org/lib")
+	require.Nil(t, err)
+	require.Equal(t, "latest", ref.Tag)
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"lib", `import error: module "lib" not found`},
+		{"oci://ghcr.io/org/lib@sha256:abc", `import error: invalid digest "sha256:abc" in module "oci://ghcr.io/org/lib@sha256:abc"`},
+		{"oci://ghcr.io/org/lib:-v1", `import error: invalid tag "-v1" in module "oci://ghcr.io/org/lib:-v1"`},
+		{"oci://ghcr.io/Org/lib:v1", `import error: invalid repository "Org/lib" in module "oci://ghcr.io/Org/lib:v1"`},
+		{"oci://ghcr.io/org/lib:v1/../x", `import error: invalid module path "oci://ghcr.io/org/lib:v1/../x"`},
+		{"oci://ghcr.io", `import error: invalid module "oci://ghcr.io"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseOCIReference(tt.name)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestOCIImporter(t *testing.T) {
+	reg := newFakeRegistry(t)
+	ctx := context.Background()
+	registryOpts := OCIRegistryOptions{Client: reg.server.Client(), Username: "ci", Password: "secret"}
+	files := map[string][]byte{
+		"lib.risor":          []byte("version := 1"),
+		"text/strings.risor": []byte(`sep := ", "`),
+	}
+	digest, err := PushOCI(ctx, "oci://"+reg.host()+"/team/lib:v1", files, registryOpts)
+	require.Nil(t, err)
+	require.True(t, isDigest(digest))
+
+	opts := OCIImporterOptions{CacheDir: t.TempDir(), Registry: registryOpts}
+	im := NewOCIImporter(opts)
+	base := "oci://" + reg.host() + "/team/lib"
+	module, err := im.Import(ctx, base+":v1")
+	require.Nil(t, err)
+	require.Equal(t, "version := 1", module.Code().Source())
+	module, err = im.Import(ctx, base+":v1/text/strings")
+	require.Nil(t, err)
+	require.Equal(t, `sep := ", "`, module.Code().Source())
+
+	// Bundles pinned by digest are pulled once, and then come from the cache
+	_, err = im.Import(ctx, base+"@"+digest)
+	require.Nil(t, err)
+	pulls := reg.pulls
+	_, err = NewOCIImporter(opts).Import(ctx, base+"@"+digest)
+	require.Nil(t, err)
+	require.Equal(t, pulls, reg.pulls)
+
+	_, err = im.Import(ctx, base+":v2")
+	require.NotNil(t, err)
+	require.Equal(t, `import error: failed to pull "`+base+`:v2": 404 Not Found: manifest unknown`, err.Error())
+
+	_, err = im.Import(ctx, base+":v1/missing")
+	require.NotNil(t, err)
+	require.Equal(t, `import error: module "missing" not found in `+reg.host()+`/team/lib`, err.Error())
+
+	wrong := digestOf([]byte("other"))
+	_, err = im.Import(ctx, base+"@"+wrong)
+	require.NotNil(t, err)
+
+	opts.Registry.Password = "wrong"
+	_, err = NewOCIImporter(opts).Import(ctx, base+":v1")
+	require.NotNil(t, err)
+	require.Equal(t, `import error: failed to pull "`+base+`:v1": failed to get a registry token: 401 Unauthorized`, err.Error())
+}
@@ -0,0 +1,272 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/risor-io/risor/modfile"
+	"github.com/risor-io/risor/object"
+)
+
+// ManifestImporter imports remote modules at the versions required by a
+// project manifest.
+type ManifestImporter struct {
+	base     Importer
+	manifest *modfile.File
+	load     modfile.LoadFunc
+	once     sync.Once
+	requires []modfile.Require
+	err      error
+}
+
+// ManifestImporterOption configures a ManifestImporter.
+type ManifestImporterOption func(*ManifestImporter)
+
+// WithDependencies resolves the versions of remote modules across the
+// manifests of the modules the project requires, as returned by the given
+// function, such as the Manifest method of a GitImporter. The versions are
+// selected with modfile.Resolve, so every module of the program imports the
+// same version of a shared dependency, or else the import fails with the
+// conflict.
+func WithDependencies(load modfile.LoadFunc) ManifestImporterOption {
+	return func(i *ManifestImporter) {
+		i.load = load
+	}
+}
+
+// NewManifestImporter returns an Importer that adds the versions required by
+// the manifest to the names of the modules it imports, before importing them
+// with the given importer. With "github.com/org/lib v1.2.0" required,
+// `import "github.com/org/lib/x"` imports "github.com/org/lib/x@v1.2.0".
+// Importing a required module at an older release with the same major
+// version imports the required version instead, while importing it at any
+// other version is an error.
+func NewManifestImporter(base Importer, manifest *modfile.File, opts ...ManifestImporterOption) *ManifestImporter {
+	i := &ManifestImporter{base: base, manifest: manifest}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+func (i *ManifestImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	resolved, err := i.Resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return i.base.Import(ctx, resolved)
+}
+
+// Changed returns the names of the changed modules among those imported by
+// the underlying importer, if it can detect changes.
+func (i *ManifestImporter) Changed() []string {
+	return changed(i.base)
+}
+
+// Requires returns the versions of the remote modules the program may
+// import, which are those required by the manifest, or with dependencies,
+// those selected across the manifests of the modules it requires. They are
+// resolved on the first call.
+func (i *ManifestImporter) Requires(ctx context.Context) ([]modfile.Require, error) {
+	i.once.Do(func() {
+		requires := i.manifest.Requires
+		if i.load != nil {
+			if requires, i.err = modfile.Resolve(ctx, i.manifest, i.load); i.err != nil {
+				i.err = fmt.Errorf("import error: %w", i.err)
+				return
+			}
+		}
+		i.requires = make([]modfile.Require, len(requires))
+		copy(i.requires, requires)
+		sort.SliceStable(i.requires, func(a, b int) bool {
+			return len(i.requires[a].Path) > len(i.requires[b].Path)
+		})
+	})
+	return i.requires, i.err
+}
+
+// Resolve returns the name of the module at the version the manifest
+// requires, or the name itself if the manifest doesn't require the module.
+func (i *ManifestImporter) Resolve(ctx context.Context, name string) (string, error) {
+	requires, err := i.Requires(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, req := range requires {
+		if !req.Matches(name) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, req.Path)
+		var version, file, resolved string
+		switch {
+		case req.IsURL():
+			if sum, ok := strings.CutPrefix(rest, "#sha256="); ok {
+				version = "sha256:" + sum
+			} else if rest != "" {
+				version = rest
+			}
+			resolved = req.Path + "#sha256=" + strings.TrimPrefix(req.Version, "sha256:")
+		case req.IsOCI():
+			file = rest
+			if !strings.HasPrefix(rest, "/") && rest != "" {
+				version, file, _ = strings.Cut(rest[1:], "/")
+				if file != "" {
+					file = "/" + file
+				}
+			}
+			if strings.HasPrefix(req.Version, "sha256:") {
+				resolved = req.Path + "@" + req.Version + file
+			} else {
+				resolved = req.Path + ":" + req.Version + file
+			}
+		default:
+			file = rest
+			if at := strings.LastIndex(rest, "@"); at >= 0 {
+				file, version = rest[:at], rest[at+1:]
+			}
+			resolved = req.Path + file + "@" + req.Version
+		}
+		if version != "" && version != req.Version && !modfile.IsUpgrade(version, req.Version) {
+			return "", fmt.Errorf("import error: module %q conflicts with %s %s required by %s",
+				name, req.Path, req.Version, modfile.Name)
+		}
+		return resolved, nil
+	}
+	return name, nil
+}
+
+// ProjectImporterOptions configure an Importer for the modules of a project.
+type ProjectImporterOptions struct {
+	// Global names that should be available when the module is compiled.
+	GlobalNames []string
+
+	// The importer for the project's own modules, tried before remote ones.
+	Local Importer
+
+	// Optional manifest declaring the versions of remote modules.
+	Manifest *modfile.File
+
+	// Optional lock verifying remote modules against their checksums.
+	Lock *Lock
+
+	// Optional keys, one of which must have signed each remote module.
+	TrustedKeys TrustedKeys
+
+	// Optional vendor directory, as written by Vendor, holding copies of
+	// remote modules that are imported rather than fetched.
+	Vendor fs.FS
+
+	// Optional cache of compiled remote modules on disk.
+	CompileCache *CompileCache
+
+	// Optional credentials for the hosts of remote modules.
+	Credentials CredentialProvider
+
+	// The directory where remote modules are cached. Defaults to "risor" in
+	// the user cache directory.
+	CacheDir string
+}
+
+// NewProjectImporter returns an Importer for the modules of a project. It
+// imports the project's own modules with the local importer, modules named
+// by an "https://" URL with an HTTPImporter, modules named by an "oci://"
+// reference with an OCIImporter, and other modules from git repositories.
+// With a manifest, remote modules are imported at the versions it requires,
+// resolved across the manifests of the git repositories it requires, and
+// modules fetched over HTTPS must be pinned to a checksum. With a lock,
+// remote modules must match the checksums it holds. With trusted keys,
+// remote modules must be signed by one of them, as described for each
+// importer. With a vendor directory, the remote modules it holds are
+// imported from it, and the others are still fetched.
+func NewProjectImporter(opts ProjectImporterOptions) Importer {
+	web, git, oci := remoteImporters(opts)
+	var importers []Importer
+	if opts.Local != nil {
+		importers = append(importers, opts.Local)
+	}
+	remote := func(im Importer) Importer { return im }
+	load := git.Manifest
+	if opts.Vendor != nil {
+		vendor := NewVendorImporter(opts.Vendor, VendorImporterOptions{
+			GlobalNames:  opts.GlobalNames,
+			Verify:       verifier(opts),
+			TrustedKeys:  opts.TrustedKeys,
+			CompileCache: opts.CompileCache,
+		})
+		importers = append(importers, vendor)
+		remote = func(im Importer) Importer {
+			return NewMultiImporter(MultiImporterOptions{Importers: []Importer{vendor, im}})
+		}
+		load = func(ctx context.Context, req modfile.Require) (*modfile.File, error) {
+			manifest, err := vendor.Manifest(ctx, req)
+			if errors.Is(err, ErrNotFound) {
+				return git.Manifest(ctx, req)
+			}
+			return manifest, err
+		}
+	}
+	importers = append(importers, git)
+	var im Importer = NewMultiImporter(MultiImporterOptions{
+		Importers: importers,
+		Routes: []Route{
+			{Prefix: "https://", Importer: remote(web)},
+			{Prefix: "http://", Importer: remote(web)},
+			{Prefix: "oci://", Importer: remote(oci)},
+		},
+	})
+	if opts.Manifest != nil {
+		im = NewManifestImporter(im, opts.Manifest, WithDependencies(load))
+	}
+	return im
+}
+
+// remoteImporters returns the importers of the remote modules of a project.
+func remoteImporters(opts ProjectImporterOptions) (*HTTPImporter, *GitImporter, *OCIImporter) {
+	cacheDir := func(name string) string {
+		if opts.CacheDir == "" {
+			return ""
+		}
+		return filepath.Join(opts.CacheDir, name)
+	}
+	web := NewHTTPImporter(HTTPImporterOptions{
+		GlobalNames:  opts.GlobalNames,
+		CacheDir:     cacheDir("modules"),
+		RequirePins:  opts.Manifest != nil,
+		Verify:       verifier(opts),
+		TrustedKeys:  opts.TrustedKeys,
+		CompileCache: opts.CompileCache,
+		Credentials:  opts.Credentials,
+	})
+	git := NewGitImporter(GitImporterOptions{
+		GlobalNames:  opts.GlobalNames,
+		CacheDir:     cacheDir("git"),
+		Verify:       verifier(opts),
+		TrustedKeys:  opts.TrustedKeys,
+		CompileCache: opts.CompileCache,
+		Credentials:  opts.Credentials,
+	})
+	oci := NewOCIImporter(OCIImporterOptions{
+		GlobalNames:  opts.GlobalNames,
+		CacheDir:     cacheDir("oci"),
+		Verify:       verifier(opts),
+		TrustedKeys:  opts.TrustedKeys,
+		CompileCache: opts.CompileCache,
+		Registry:     OCIRegistryOptions{Credentials: opts.Credentials},
+	})
+	return web, git, oci
+}
+
+// verifier returns the function checking remote modules against the lock
+// of a project, if it has one.
+func verifier(opts ProjectImporterOptions) func(name string, source []byte) error {
+	if opts.Lock == nil {
+		return nil
+	}
+	return opts.Lock.Verify
+}
@@ -0,0 +1,130 @@
+package importer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/risor-io/risor/modfile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestImporter(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	digest := "sha256:" + strings.Repeat("cd", 32)
+	manifest := &modfile.File{
+		Module: "app",
+		Requires: []modfile.Require{
+			{Path: "github.com/org/lib", Version: "v1.2.0"},
+			{Path: "github.com/org/lib/nested", Version: "v2.0.0"},
+			{Path: "https://example.com/util.risor", Version: "sha256:" + sum},
+			{Path: "oci://ghcr.io/org/tools", Version: "v2"},
+			{Path: "oci://ghcr.io/org/pinned", Version: digest},
+		},
+	}
+	im := NewManifestImporter(NewFSImporter(fstest.MapFS{}), manifest)
+	tests := map[string]string{
+		"app":                                "app",
+		"github.com/org/lib":                 "github.com/org/lib@v1.2.0",
+		"github.com/org/lib@v1.2.0":          "github.com/org/lib@v1.2.0",
+		"github.com/org/lib/x@v1.1.0":        "github.com/org/lib/x@v1.2.0",
+		"github.com/org/lib/x":               "github.com/org/lib/x@v1.2.0",
+		"github.com/org/lib/nested/x":        "github.com/org/lib/nested/x@v2.0.0",
+		"github.com/org/library":             "github.com/org/library",
+		"https://example.com/util.risor":     "https://example.com/util.risor#sha256=" + sum,
+		"https://example.com/util.risorx":    "https://example.com/util.risorx",
+		"oci://ghcr.io/org/tools":            "oci://ghcr.io/org/tools:v2",
+		"oci://ghcr.io/org/tools:v2/text":    "oci://ghcr.io/org/tools:v2/text",
+		"oci://ghcr.io/org/tools/text":       "oci://ghcr.io/org/tools:v2/text",
+		"oci://ghcr.io/org/pinned/x":         "oci://ghcr.io/org/pinned@" + digest + "/x",
+		"oci://ghcr.io/org/pinned@" + digest: "oci://ghcr.io/org/pinned@" + digest,
+	}
+	for name, expected := range tests {
+		t.Run(name, func(t *testing.T) {
+			resolved, err := im.Resolve(context.Background(), name)
+			require.Nil(t, err)
+			require.Equal(t, expected, resolved)
+		})
+	}
+
+	for _, name := range []string{
+		"github.com/org/lib/x@v1.3.0",
+		"github.com/org/lib/x@v0.9.0",
+		"https://example.com/util.risor#sha256=" + strings.Repeat("00", 32),
+		"oci://ghcr.io/org/tools:v3",
+	} {
+		_, err := im.Resolve(context.Background(), name)
+		require.NotNil(t, err)
+		require.Contains(t, err.Error(), "required by risor.mod")
+	}
+
+	_, err := im.Import(context.Background(), "github.com/org/lib")
+	require.NotNil(t, err)
+	require.Equal(t, `import error: module "github.com/org/lib@v1.2.0" not found`, err.Error())
+}
+
+func TestManifestImporterDependencies(t *testing.T) {
+	manifest := &modfile.File{
+		Module: "app",
+		Requires: []modfile.Require{
+			{Path: "github.com/org/lib", Version: "v1.0.0"},
+			{Path: "github.com/org/shared", Version: "v1.1.0"},
+		},
+	}
+	var loaded []string
+	load := func(ctx context.Context, req modfile.Require) (*modfile.File, error) {
+		loaded = append(loaded, req.Path+"@"+req.Version)
+		if req.Path != "github.com/org/lib" {
+			return nil, nil
+		}
+		return &modfile.File{Module: req.Path, Requires: []modfile.Require{
+			{Path: "github.com/org/shared", Version: "v1.3.0"},
+		}}, nil
+	}
+	im := NewManifestImporter(NewFSImporter(fstest.MapFS{}), manifest, WithDependencies(load))
+	ctx := context.Background()
+
+	// The project and the library share the newest version they require
+	for _, name := range []string{"github.com/org/shared/x", "github.com/org/shared/x@v1.1.0"} {
+		resolved, err := im.Resolve(ctx, name)
+		require.Nil(t, err)
+		require.Equal(t, "github.com/org/shared/x@v1.3.0", resolved)
+	}
+	require.Equal(t, []string{
+		"github.com/org/lib@v1.0.0",
+		"github.com/org/shared@v1.1.0",
+		"github.com/org/shared@v1.3.0",
+	}, loaded)
+
+	conflicting := func(ctx context.Context, req modfile.Require) (*modfile.File, error) {
+		return &modfile.File{Module: req.Path, Requires: []modfile.Require{
+			{Path: "github.com/org/shared", Version: "v2.0.0"},
+		}}, nil
+	}
+	im = NewManifestImporter(NewFSImporter(fstest.MapFS{}), manifest, WithDependencies(conflicting))
+	_, err := im.Import(ctx, "github.com/org/lib")
+	require.NotNil(t, err)
+	require.Equal(t, "import error: conflicting versions of github.com/org/shared: "+
+		"v1.1.0 required by app, v2.0.0 required by github.com/org/lib@v1.0.0", err.Error())
+}
+
+func TestProjectImporter(t *testing.T) {
+	ctx := context.Background()
+	local := NewFSImporter(fstest.MapFS{
+		"app.risor": {Data: []byte(`where := "local"`)},
+	})
+	im := NewProjectImporter(ProjectImporterOptions{
+		Local:    local,
+		Manifest: &modfile.File{Module: "app"},
+		CacheDir: t.TempDir(),
+	})
+	module, err := im.Import(ctx, "app")
+	require.Nil(t, err)
+	require.Equal(t, `where := "local"`, module.Code().Source())
+
+	// Unpinned modules fetched over HTTPS are refused
+	_, err = im.Import(ctx, "https://example.com/lib.risor")
+	require.NotNil(t, err)
+	require.Equal(t, `import error: module "https://example.com/lib.risor" has no checksum`, err.Error())
+}
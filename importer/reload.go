@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ChangeDetector is implemented by importers able to tell which of the
+// modules they imported have changed since, so that programs can reload
+// them during development, as VMs do with the WithHotReload option.
+type ChangeDetector interface {
+	// Changed returns the names of the imported modules whose source changed
+	// since they were imported, and forgets their code, so that importing
+	// them again reads their new source.
+	Changed() []string
+}
+
+// changed returns the names of the changed modules among those imported by
+// the given importers.
+func changed(importers ...Importer) []string {
+	var names []string
+	for _, im := range importers {
+		if detector, ok := im.(ChangeDetector); ok {
+			names = append(names, detector.Changed()...)
+		}
+	}
+	return names
+}
+
+// fileStamp identifies a version of a file, which is missing if modTime is
+// the zero time.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileStamps returns the stamps of the files a module is read from, or could
+// be read from if they were created, such as a file with a preferred
+// extension, or a file added to a directory package.
+func fileStamps(dir, name string, extensions []string) map[string]fileStamp {
+	paths := make([]string, 0, len(extensions)+1)
+	for _, ext := range extensions {
+		paths = append(paths, filepath.Join(dir, name+ext))
+	}
+	// The directory changes when package files are added or removed
+	packageDir := filepath.Join(dir, name)
+	paths = append(paths, packageDir)
+	if packagePath := filepath.ToSlash(name); fs.ValidPath(packagePath) {
+		if dir == "" {
+			dir = "."
+		}
+		for _, file := range packageFiles(os.DirFS(dir), packagePath, extensions) {
+			paths = append(paths, filepath.Join(dir, filepath.FromSlash(file)))
+		}
+	}
+	stamps := make(map[string]fileStamp, len(paths))
+	for _, path := range paths {
+		var stamp fileStamp
+		if info, err := os.Stat(path); err == nil {
+			stamp = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		stamps[path] = stamp
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, stamp := range a {
+		if other, ok := b[path]; !ok || !other.modTime.Equal(stamp.modTime) || other.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
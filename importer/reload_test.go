@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// touch writes the file with a modification time in the future, so that
+// rewriting a file within the resolution of the filesystem clock is seen.
+func touch(t *testing.T, path, source string) {
+	t.Helper()
+	require.Nil(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.Nil(t, os.WriteFile(path, []byte(source), 0o644))
+	future := time.Now().Add(time.Hour)
+	require.Nil(t, os.Chtimes(path, future, future))
+}
+
+func TestLocalImporterChanged(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "lib.risor"), []byte("version := 1"), 0o644))
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0o755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "pkg", "a.risor"), []byte("a := 1"), 0o644))
+	ctx := context.Background()
+	local := NewLocalImporter(LocalImporterOptions{SourceDir: dir})
+	im := NewMultiImporter(MultiImporterOptions{Importers: []Importer{local}})
+
+	for _, name := range []string{"lib", "pkg"} {
+		_, err := im.Import(ctx, name)
+		require.Nil(t, err)
+	}
+	require.Empty(t, im.Changed())
+
+	touch(t, filepath.Join(dir, "lib.risor"), "version := 2")
+	touch(t, filepath.Join(dir, "pkg", "b.risor"), "b := 2")
+	require.Equal(t, []string{"lib", "pkg"}, im.Changed())
+	require.Empty(t, im.Changed())
+
+	module, err := im.Import(ctx, "lib")
+	require.Nil(t, err)
+	require.Equal(t, "version := 2", module.Code().Source())
+	module, err = im.Import(ctx, "pkg")
+	require.Nil(t, err)
+	require.Equal(t, []string{"a", "b"}, module.AttributeNames())
+
+	// Removing a file is a change too
+	require.Nil(t, os.Remove(filepath.Join(dir, "lib.risor")))
+	require.Equal(t, []string{"lib"}, im.Changed())
+	_, err = im.Import(ctx, "lib")
+	require.ErrorIs(t, err, ErrNotFound)
+}
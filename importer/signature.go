@@ -0,0 +1,153 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// SignatureExtension is appended to the name of a module file to name the
+// file holding its signature, such as "lib.risor.minisig".
+const SignatureExtension = ".minisig"
+
+// PublicKey is a minisign public key, trusted to sign modules.
+type PublicKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a minisign public key, either as the contents of a
+// ".pub" file written by "minisign -G", or as the base64 key alone, such as
+// given to "minisign -P".
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	lines := signatureLines(data)
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "untrusted comment:") {
+		lines = lines[1:]
+	}
+	if len(lines) != 1 {
+		return nil, errors.New("invalid public key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return nil, errors.New("invalid public key")
+	}
+	k := &PublicKey{key: ed25519.PublicKey(raw[10:])}
+	copy(k.id[:], raw[2:10])
+	return k, nil
+}
+
+// ID returns the key ID, as shown by minisign.
+func (k *PublicKey) ID() string {
+	// Minisign stores key IDs little-endian
+	id := make([]byte, len(k.id))
+	for i, b := range k.id {
+		id[len(id)-1-i] = b
+	}
+	return strings.ToUpper(hex.EncodeToString(id))
+}
+
+// TrustedKeys are the public keys whose signatures are accepted on modules.
+type TrustedKeys []*PublicKey
+
+// ReadTrustedKeys reads the minisign public keys in the files at the given
+// paths.
+func ReadTrustedKeys(paths ...string) (TrustedKeys, error) {
+	keys := make(TrustedKeys, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		key, err := ParsePublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Verify checks that the source of the named module is signed by one of the
+// keys, given the contents of its minisign signature file. Both the
+// signature of the source and that of the trusted comment are checked, and
+// prehashed signatures as well as legacy ones are accepted.
+func (keys TrustedKeys) Verify(name string, source, signature []byte) error {
+	lines := signatureLines(signature)
+	if len(lines) != 4 ||
+		!strings.HasPrefix(lines[0], "untrusted comment:") ||
+		!strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("import error: invalid signature for module %q", name)
+	}
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return fmt.Errorf("import error: invalid signature for module %q", name)
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("import error: invalid signature for module %q", name)
+	}
+	var message []byte
+	switch string(sig[:2]) {
+	case "Ed":
+		message = source
+	case "ED":
+		sum := blake2b.Sum512(source)
+		message = sum[:]
+	default:
+		return fmt.Errorf("import error: unsupported signature algorithm for module %q", name)
+	}
+	var key *PublicKey
+	for _, k := range keys {
+		if bytes.Equal(k.id[:], sig[2:10]) {
+			key = k
+			break
+		}
+	}
+	if key == nil {
+		return fmt.Errorf("import error: module %q is not signed by a trusted key", name)
+	}
+	// The global signature covers the signature and the trusted comment
+	global := append(append([]byte{}, sig[10:]...), strings.TrimPrefix(lines[2], "trusted comment: ")...)
+	if !ed25519.Verify(key.key, message, sig[10:]) || !ed25519.Verify(key.key, global, globalSig) {
+		return fmt.Errorf("import error: invalid signature for module %q (key %s)", name, key.ID())
+	}
+	return nil
+}
+
+// verifyFiles checks the signatures of the files of a module, which are
+// stored next to them with SignatureExtension appended.
+func (keys TrustedKeys) verifyFiles(name string, fsys fs.FS, files []string) error {
+	for _, file := range files {
+		source, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("import error: %w", err)
+		}
+		signature, err := fs.ReadFile(fsys, file+SignatureExtension)
+		if err != nil {
+			return fmt.Errorf("import error: module %q is not signed (no %s)", name, file+SignatureExtension)
+		}
+		if err := keys.Verify(name, source, signature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signatureLines returns the non-empty lines of a key or signature file.
+func signatureLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
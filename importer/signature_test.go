@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+)
+
+// signingKey is a minisign key pair for tests.
+type signingKey struct {
+	id      [8]byte
+	private ed25519.PrivateKey
+	public  []byte
+}
+
+func newSigningKey(t *testing.T, id byte) *signingKey {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(nil)
+	require.Nil(t, err)
+	k := &signingKey{private: private}
+	for i := range k.id {
+		k.id[i] = id
+	}
+	raw := append([]byte("Ed"), k.id[:]...)
+	raw = append(raw, public...)
+	k.public = []byte("untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n")
+	return k
+}
+
+func (k *signingKey) trusted(t *testing.T) TrustedKeys {
+	t.Helper()
+	key, err := ParsePublicKey(k.public)
+	require.Nil(t, err)
+	return TrustedKeys{key}
+}
+
+// sign returns a minisign signature of the source, as written by
+// "minisign -S", or by "minisign -S -l" for legacy signatures.
+func (k *signingKey) sign(source []byte, legacy bool) []byte {
+	alg, message := "ED", source
+	if legacy {
+		alg = "Ed"
+	} else {
+		sum := blake2b.Sum512(source)
+		message = sum[:]
+	}
+	sig := append([]byte(alg), k.id[:]...)
+	sig = append(sig, ed25519.Sign(k.private, message)...)
+	trusted := "timestamp:1700000000\tfile:lib.risor"
+	globalSig := ed25519.Sign(k.private, append(append([]byte{}, sig[10:]...), trusted...))
+	return []byte("untrusted comment: signature from minisign secret key\n" +
+		base64.StdEncoding.EncodeToString(sig) + "\n" +
+		"trusted comment: " + trusted + "\n" +
+		base64.StdEncoding.EncodeToString(globalSig) + "\n")
+}
+
+func TestParsePublicKey(t *testing.T) {
+	k := newSigningKey(t, 0xab)
+	key, err := ParsePublicKey(k.public)
+	require.Nil(t, err)
+	require.Equal(t, "ABABABABABABABAB", key.ID())
+
+	// The base64 key alone is accepted too
+	lines := strings.Split(string(k.public), "\n")
+	_, err = ParsePublicKey([]byte(lines[1]))
+	require.Nil(t, err)
+
+	_, err = ParsePublicKey([]byte("untrusted comment: x\nnot a key"))
+	require.EqualError(t, err, "invalid public key")
+
+	path := filepath.Join(t.TempDir(), "key.pub")
+	require.Nil(t, os.WriteFile(path, k.public, 0o644))
+	keys, err := ReadTrustedKeys(path)
+	require.Nil(t, err)
+	require.Len(t, keys, 1)
+}
+
+func TestTrustedKeysVerify(t *testing.T) {
+	k := newSigningKey(t, 1)
+	other := newSigningKey(t, 2)
+	keys := k.trusted(t)
+	source := []byte(libSource)
+
+	require.Nil(t, keys.Verify("lib", source, k.sign(source, false)))
+	require.Nil(t, keys.Verify("lib", source, k.sign(source, true)))
+
+	err := keys.Verify("lib", []byte("changed := true"), k.sign(source, false))
+	require.EqualError(t, err, `import error: invalid signature for module "lib" (key 0101010101010101)`)
+
+	err = keys.Verify("lib", source, other.sign(source, false))
+	require.EqualError(t, err, `import error: module "lib" is not signed by a trusted key`)
+
+	// Same key ID, different key
+	impostor := newSigningKey(t, 1)
+	err = keys.Verify("lib", source, impostor.sign(source, false))
+	require.EqualError(t, err, `import error: invalid signature for module "lib" (key 0101010101010101)`)
+
+	// The trusted comment is signed too
+	tampered := strings.Replace(string(k.sign(source, false)), "file:lib.risor", "file:other.risor", 1)
+	err = keys.Verify("lib", source, []byte(tampered))
+	require.EqualError(t, err, `import error: invalid signature for module "lib" (key 0101010101010101)`)
+
+	err = keys.Verify("lib", source, []byte("not a signature"))
+	require.EqualError(t, err, `import error: invalid signature for module "lib"`)
+}
+
+func TestHTTPImporterSignatures(t *testing.T) {
+	k := newSigningKey(t, 1)
+	signature := k.sign([]byte(libSource), false)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/lib.risor", "/unsigned.risor":
+			w.Write([]byte(libSource))
+		case "/lib.risor" + SignatureExtension:
+			w.Write(signature)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	im := NewHTTPImporter(HTTPImporterOptions{
+		CacheDir:    t.TempDir(),
+		Client:      server.Client(),
+		TrustedKeys: k.trusted(t),
+	})
+
+	_, err := im.Import(context.Background(), server.URL+"/lib.risor")
+	require.Nil(t, err)
+
+	_, err = im.Import(context.Background(), server.URL+"/unsigned.risor")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unsigned.risor"+SignatureExtension)
+	require.Contains(t, err.Error(), "404 Not Found")
+}
+
+func TestOCIImporterSignatures(t *testing.T) {
+	reg := newFakeRegistry(t)
+	ctx := context.Background()
+	k := newSigningKey(t, 1)
+	registryOpts := OCIRegistryOptions{Client: reg.server.Client(), Username: "ci", Password: "secret"}
+	source := []byte("version := 1")
+	_, err := PushOCI(ctx, "oci://"+reg.host()+"/team/lib:signed", map[string][]byte{
+		"tool.risor":                      source,
+		"tool.risor" + SignatureExtension: k.sign(source, false),
+	}, registryOpts)
+	require.Nil(t, err)
+	_, err = PushOCI(ctx, "oci://"+reg.host()+"/team/lib:unsigned", map[string][]byte{
+		"tool.risor": source,
+	}, registryOpts)
+	require.Nil(t, err)
+	im := NewOCIImporter(OCIImporterOptions{
+		CacheDir:    t.TempDir(),
+		Registry:    registryOpts,
+		TrustedKeys: k.trusted(t),
+	})
+
+	// The signature isn't a module, so the bundle's only module is imported
+	base := "oci://" + reg.host() + "/team/lib"
+	module, err := im.Import(ctx, base+":signed")
+	require.Nil(t, err)
+	require.Equal(t, "version := 1", module.Code().Source())
+
+	_, err = im.Import(ctx, base+":unsigned")
+	require.NotNil(t, err)
+	require.Equal(t, `import error: module "`+base+`:unsigned" is not signed (no tool.risor.minisig)`, err.Error())
+}
@@ -0,0 +1,292 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/modfile"
+	"github.com/risor-io/risor/object"
+)
+
+// VendorDir is the directory of a project holding its vendored modules.
+const VendorDir = "vendor"
+
+// VendorIndex is the file of a vendor directory listing the modules it
+// holds, which marks the directory as written by Vendor.
+const VendorIndex = "modules.txt"
+
+// Vendor copies the remote modules required by the manifest of a project,
+// directly or through the manifests of the modules it requires, into the
+// given directory, replacing its contents. It returns the requirements
+// that were vendored.
+//
+// Git repositories are copied with their module files, signatures, and
+// manifest. The module files of OCI bundles are copied as they were pushed,
+// and modules fetched over HTTPS are copied after checking their checksum.
+// Signatures of OCI bundles and of modules fetched over HTTPS are copied
+// when TrustedKeys are configured. Vendored modules are imported through the
+// Vendor option of a project importer, which still verifies them against
+// the lock and the trusted keys.
+func Vendor(ctx context.Context, dir string, opts ProjectImporterOptions) ([]modfile.Require, error) {
+	if opts.Manifest == nil {
+		return nil, errors.New("vendor error: no project manifest")
+	}
+	web, git, oci := remoteImporters(opts)
+	requires, err := modfile.Resolve(ctx, opts.Manifest, git.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("vendor error: %w", err)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		if _, err := os.Stat(filepath.Join(dir, VendorIndex)); err != nil {
+			return nil, fmt.Errorf("vendor error: refusing to replace %s, which has no %s", dir, VendorIndex)
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, fmt.Errorf("vendor error: %w", err)
+		}
+	}
+	var index strings.Builder
+	index.WriteString("# Modules vendored by risor vendor. Do not edit.\n")
+	for _, req := range requires {
+		if err := vendorModule(ctx, web, git, oci, req, dir); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&index, "%s %s\n", req.Path, req.Version)
+	}
+	if err := writeVendorFile(dir, VendorIndex, []byte(index.String())); err != nil {
+		return nil, err
+	}
+	return requires, nil
+}
+
+// Download fetches the remote modules required by the manifest of a project,
+// directly or through the manifests of the modules it requires, into the
+// cache of remote modules, so they are imported without network access. It
+// returns the requirements that were downloaded.
+func Download(ctx context.Context, opts ProjectImporterOptions) ([]modfile.Require, error) {
+	if opts.Manifest == nil {
+		return nil, errors.New("download error: no project manifest")
+	}
+	web, git, oci := remoteImporters(opts)
+	requires, err := modfile.Resolve(ctx, opts.Manifest, git.Manifest)
+	if err != nil {
+		return nil, fmt.Errorf("download error: %w", err)
+	}
+	for _, req := range requires {
+		if err := vendorModule(ctx, web, git, oci, req, ""); err != nil {
+			return nil, err
+		}
+	}
+	return requires, nil
+}
+
+// vendorModule copies a required module into the vendor directory with the
+// importer of its kind, or only fetches it into the cache if dir is empty.
+func vendorModule(ctx context.Context, web *HTTPImporter, git *GitImporter, oci *OCIImporter, req modfile.Require, dir string) error {
+	switch {
+	case req.IsURL():
+		return web.vendor(ctx, req, dir)
+	case req.IsOCI():
+		return oci.vendor(ctx, req, dir)
+	default:
+		return git.vendor(ctx, req, dir)
+	}
+}
+
+// vendorPath returns the path of a vendored module or directory, given its
+// location without a scheme, such as "github.com/org/lib@v1.2.0". Colons
+// are replaced, since they aren't allowed in paths on every platform.
+func vendorPath(location string) string {
+	return strings.ReplaceAll(location, ":", "-")
+}
+
+// writeVendorFile writes a file into the vendor directory.
+func writeVendorFile(dir, name string, data []byte) error {
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return fmt.Errorf("vendor error: %w", err)
+	}
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		return fmt.Errorf("vendor error: %w", err)
+	}
+	return nil
+}
+
+type VendorImporter struct {
+	fsys        fs.FS
+	globalNames []string
+	extensions  []string
+	codeCache   map[string]*compiler.Code
+	verify      func(name string, source []byte) error
+	trustedKeys TrustedKeys
+	cache       *CompileCache
+	mutex       sync.Mutex
+}
+
+// VendorImporterOptions configure an Importer of vendored modules.
+type VendorImporterOptions struct {
+	// Global names that should be available when the module is compiled.
+	GlobalNames []string
+
+	// Optional list of file extensions to try when locating a Risor module.
+	Extensions []string
+
+	// Optional function checking the source of each module before it is
+	// compiled, such as the Verify method of a Lock.
+	Verify func(name string, source []byte) error
+
+	// Optional keys, one of which must have signed each module, with the
+	// signatures vendored next to the module files.
+	TrustedKeys TrustedKeys
+
+	// Optional cache of compiled modules on disk.
+	CompileCache *CompileCache
+}
+
+// NewVendorImporter returns an Importer of the remote modules copied into a
+// vendor directory by Vendor, so programs run without access to the hosts
+// serving them. Modules are named as for the importer of their kind, with a
+// version, such as "github.com/org/lib/x@v1.2.0", and modules that aren't
+// vendored aren't found, so a MultiImporter may fetch them instead.
+func NewVendorImporter(fsys fs.FS, opts VendorImporterOptions) *VendorImporter {
+	if opts.Extensions == nil {
+		opts.Extensions = []string{".risor", ".rsr"}
+	}
+	return &VendorImporter{
+		fsys:        fsys,
+		globalNames: opts.GlobalNames,
+		extensions:  opts.Extensions,
+		codeCache:   map[string]*compiler.Code{},
+		verify:      opts.Verify,
+		trustedKeys: opts.TrustedKeys,
+		cache:       opts.CompileCache,
+	}
+}
+
+func (i *VendorImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	moduleName, file, extensions, pin, err := i.locate(name)
+	if err != nil {
+		return nil, err
+	}
+	if code, ok := i.codeCache[name]; ok {
+		return object.NewModule(moduleName, code), nil
+	}
+	files := moduleFiles(i.fsys, file, extensions)
+	if len(files) == 0 {
+		return nil, notFound(name)
+	}
+	sources := make([]sourceFile, 0, len(files))
+	for _, file := range files {
+		data, err := fs.ReadFile(i.fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("import error: %w", err)
+		}
+		sources = append(sources, sourceFile{name: file, source: string(data)})
+	}
+	source := joinSources(sources)
+	if sum := checksum([]byte(source)); pin != "" && sum != pin {
+		return nil, fmt.Errorf("import error: checksum mismatch for vendored module %q (expected sha256:%s, got sha256:%s)",
+			moduleName, pin, sum)
+	}
+	if i.verify != nil {
+		if err := i.verify(moduleName, []byte(source)); err != nil {
+			return nil, err
+		}
+	}
+	if i.trustedKeys != nil {
+		if err := i.trustedKeys.verifyFiles(moduleName, i.fsys, files); err != nil {
+			return nil, err
+		}
+	}
+	code, err := i.cache.compile(ctx, sources, i.globalNames)
+	if err != nil {
+		return nil, err
+	}
+	i.codeCache[name] = code
+	return object.NewModule(moduleName, code), nil
+}
+
+// locate returns the name of the module as the importer of its kind names
+// it, its path in the vendor directory, the extensions its files may have,
+// and the checksum it is pinned to, if any.
+func (i *VendorImporter) locate(name string) (string, string, []string, string, error) {
+	switch {
+	case strings.HasPrefix(name, "https://") || strings.HasPrefix(name, "http://"):
+		u, err := url.Parse(name)
+		if err != nil || u.Host == "" {
+			return "", "", nil, "", notFound(name)
+		}
+		pin, _ := strings.CutPrefix(u.Fragment, "sha256=")
+		u.Fragment = ""
+		file := path.Clean(vendorPath(u.Host + u.Path))
+		return u.String(), file, []string{""}, strings.ToLower(pin), nil
+	case strings.HasPrefix(name, "oci://"):
+		ref, err := ParseOCIReference(name)
+		if err != nil {
+			return "", "", nil, "", err
+		}
+		dir := vendorPath(ref.Registry + "/" + ref.Repository + "@" + ref.ref())
+		if ref.File != "" {
+			return name, path.Join(dir, ref.File), i.extensions, "", nil
+		}
+		// As when pulling the bundle, its only module file is imported,
+		// or else the file named after the repository
+		var files []string
+		fs.WalkDir(i.fsys, dir, func(file string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && !strings.HasSuffix(file, SignatureExtension) {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if len(files) == 1 {
+			return name, files[0], []string{""}, "", nil
+		}
+		return name, path.Join(dir, path.Base(ref.Repository)), i.extensions, "", nil
+	default:
+		m, err := ParseGitModule(name)
+		if err != nil || m.Ref == "" {
+			return "", "", nil, "", notFound(name)
+		}
+		return name, path.Join(vendorPath(m.Repo+"@"+m.Ref), m.File), i.extensions, "", nil
+	}
+}
+
+// Manifest returns the vendored manifest of a required git repository, or
+// nil if the repository has none. It returns an error satisfying
+// errors.Is(err, ErrNotFound) if the repository isn't vendored.
+func (i *VendorImporter) Manifest(ctx context.Context, req modfile.Require) (*modfile.File, error) {
+	if req.IsURL() || req.IsOCI() {
+		return nil, nil
+	}
+	m, err := ParseGitModule(req.Path + "@" + req.Version)
+	if err != nil {
+		return nil, err
+	}
+	dir := vendorPath(m.Repo + "@" + m.Ref)
+	if _, err := fs.Stat(i.fsys, dir); err != nil {
+		return nil, notFound(req.Path)
+	}
+	data, err := fs.ReadFile(i.fsys, path.Join(dir, modfile.Name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	name := m.Repo + "@" + m.Ref + "/" + modfile.Name
+	if i.verify != nil {
+		if err := i.verify(name, data); err != nil {
+			return nil, err
+		}
+	}
+	return modfile.Parse(name, data)
+}
@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/risor-io/risor/modfile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVendorImporter(t *testing.T) {
+	ctx := context.Background()
+	util := []byte(`sep := ", "`)
+	sum := checksum(util)
+	im := NewVendorImporter(fstest.MapFS{
+		"github.com/org/lib@v1.2.0/lib.risor": {Data: []byte("version := 1")},
+		"github.com/org/lib@v1.2.0/x.risor":   {Data: []byte("x := 1")},
+		"github.com/org/lib@v1.2.0/risor.mod": {Data: []byte("module github.com/org/lib\n")},
+		"example.com/util.risor":              {Data: util},
+		"ghcr.io/org/tools@v2/tool.risor":     {Data: []byte("tool := 1")},
+	}, VendorImporterOptions{})
+
+	tests := map[string]string{
+		"github.com/org/lib@v1.2.0":                    "version := 1",
+		"github.com/org/lib/x@v1.2.0":                  "x := 1",
+		"https://example.com/util.risor#sha256=" + sum: `sep := ", "`,
+		"oci://ghcr.io/org/tools:v2":                   "tool := 1",
+		"oci://ghcr.io/org/tools:v2/tool":              "tool := 1",
+	}
+	for name, source := range tests {
+		module, err := im.Import(ctx, name)
+		require.Nil(t, err, name)
+		require.Equal(t, source, module.Code().Source())
+	}
+	module, err := im.Import(ctx, "https://example.com/util.risor#sha256="+sum)
+	require.Nil(t, err)
+	require.Equal(t, "https://example.com/util.risor", module.Name().Value())
+
+	// Modules that aren't vendored are left to other importers
+	for _, name := range []string{"github.com/org/lib@v1.3.0", "github.com/org/lib", "oci://ghcr.io/org/tools:v3", "lib"} {
+		_, err := im.Import(ctx, name)
+		require.ErrorIs(t, err, ErrNotFound, name)
+	}
+
+	wrong := checksum([]byte("other"))
+	_, err = im.Import(ctx, "https://example.com/util.risor#sha256="+wrong)
+	require.EqualError(t, err, `import error: checksum mismatch for vendored module "https://example.com/util.risor" (expected sha256:`+
+		wrong+`, got sha256:`+sum+`)`)
+
+	manifest, err := im.Manifest(ctx, modfile.Require{Path: "github.com/org/lib", Version: "v1.2.0"})
+	require.Nil(t, err)
+	require.Equal(t, "github.com/org/lib", manifest.Module)
+	_, err = im.Manifest(ctx, modfile.Require{Path: "github.com/org/lib", Version: "v1.3.0"})
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestVendor(t *testing.T) {
+	ctx := context.Background()
+	util := []byte(`sep := ", "`)
+	sum := checksum(util)
+	manifest, err := modfile.Parse(modfile.Name, []byte("module example.com/app\n\nrequire (\n"+
+		"\texample.com/org/lib v1.0.0\n"+
+		"\thttps://example.com/util.risor sha256:"+sum+"\n)\n"))
+	require.Nil(t, err)
+
+	// Remote modules come from the cache, rather than the network
+	cacheDir := t.TempDir()
+	repo := filepath.Join(cacheDir, "git", "example.com", "org", "lib@v1.0.0")
+	for name, data := range map[string]string{
+		"lib.risor":           "version := 1",
+		"text/strings.risor":  `sep := ", "`,
+		"README.md":           "# lib",
+		".git/config":         "[core]",
+		"lib.risor.minisig":   "signature",
+		"text/strings.risor~": "backup",
+	} {
+		require.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(repo, name)), 0o755))
+		require.Nil(t, os.WriteFile(filepath.Join(repo, name), []byte(data), 0o644))
+	}
+	require.Nil(t, os.MkdirAll(filepath.Join(cacheDir, "modules"), 0o755))
+	require.Nil(t, os.WriteFile(filepath.Join(cacheDir, "modules", sum+".risor"), util, 0o644))
+
+	dir := filepath.Join(t.TempDir(), VendorDir)
+	opts := ProjectImporterOptions{Manifest: manifest, CacheDir: cacheDir}
+	requires, err := Vendor(ctx, dir, opts)
+	require.Nil(t, err)
+	require.Len(t, requires, 2)
+
+	var files []string
+	filepath.WalkDir(dir, func(file string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dir, file)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	require.ElementsMatch(t, []string{
+		"example.com/org/lib@v1.0.0/lib.risor",
+		"example.com/org/lib@v1.0.0/lib.risor.minisig",
+		"example.com/org/lib@v1.0.0/text/strings.risor",
+		"example.com/util.risor",
+		VendorIndex,
+	}, files)
+
+	// The project imports the vendored modules, even without the cache
+	opts.CacheDir = t.TempDir()
+	opts.Vendor = os.DirFS(dir)
+	im := NewProjectImporter(opts)
+	module, err := im.Import(ctx, "example.com/org/lib")
+	require.Nil(t, err)
+	require.Equal(t, "example.com/org/lib@v1.0.0", module.Name().Value())
+	module, err = im.Import(ctx, "example.com/org/lib/text/strings")
+	require.Nil(t, err)
+	require.Equal(t, `sep := ", "`, module.Code().Source())
+	_, err = im.Import(ctx, "https://example.com/util.risor")
+	require.Nil(t, err)
+
+	// Vendoring again replaces the vendor directory, but nothing else
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "stale.risor"), nil, 0o644))
+	_, err = Vendor(ctx, dir, ProjectImporterOptions{Manifest: manifest, CacheDir: cacheDir})
+	require.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "stale.risor"))
+	require.True(t, os.IsNotExist(err))
+	require.Nil(t, os.Remove(filepath.Join(dir, VendorIndex)))
+	_, err = Vendor(ctx, dir, ProjectImporterOptions{Manifest: manifest, CacheDir: cacheDir})
+	require.EqualError(t, err, "vendor error: refusing to replace "+dir+", which has no "+VendorIndex)
+}
+
+func TestDownload(t *testing.T) {
+	ctx := context.Background()
+	util := []byte(`sep := ", "`)
+	sum := checksum(util)
+	manifest, err := modfile.Parse(modfile.Name, []byte("module example.com/app\n\nrequire (\n"+
+		"\texample.com/org/lib v1.0.0\n"+
+		"\thttps://example.com/util.risor sha256:"+sum+"\n)\n"))
+	require.Nil(t, err)
+
+	// Modules already in the cache aren't fetched again
+	cacheDir := t.TempDir()
+	repo := filepath.Join(cacheDir, "git", "example.com", "org", "lib@v1.0.0")
+	require.Nil(t, os.MkdirAll(repo, 0o755))
+	require.Nil(t, os.WriteFile(filepath.Join(repo, "lib.risor"), []byte("version := 1"), 0o644))
+	require.Nil(t, os.MkdirAll(filepath.Join(cacheDir, "modules"), 0o755))
+	require.Nil(t, os.WriteFile(filepath.Join(cacheDir, "modules", sum+".risor"), util, 0o644))
+
+	requires, err := Download(ctx, ProjectImporterOptions{Manifest: manifest, CacheDir: cacheDir})
+	require.Nil(t, err)
+	require.Equal(t, manifest.Requires, requires)
+
+	_, err = Download(ctx, ProjectImporterOptions{})
+	require.EqualError(t, err, "download error: no project manifest")
+}
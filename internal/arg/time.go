@@ -0,0 +1,60 @@
+package arg
+
+import (
+	"context"
+	"time"
+
+	"github.com/risor-io/risor/object"
+)
+
+// Duration converts a duration given to a function to a time.Duration. A
+// string is parsed by time.ParseDuration, as in "1.5s" or "100ms", while an
+// int or a float is a number of seconds. Durations must not be negative. The
+// name of the argument or option is used in errors.
+func Duration(name string, obj object.Object) (time.Duration, *object.Error) {
+	var d time.Duration
+	switch obj := obj.(type) {
+	case *object.String:
+		var err error
+		if d, err = time.ParseDuration(obj.Value()); err != nil {
+			return 0, object.Errorf("value error: invalid %s %q", name, obj.Value())
+		}
+	default:
+		seconds, err := object.AsFloat(obj)
+		if err != nil {
+			return 0, err
+		}
+		d = time.Duration(seconds * float64(time.Second))
+	}
+	if d < 0 {
+		return 0, object.Errorf("value error: %s must not be negative (got %s)", name, d)
+	}
+	return d, nil
+}
+
+// Time converts a time given to a function, which is either a time or an
+// RFC 3339 string, to a time.Time. The name of the argument or option is
+// used in errors.
+func Time(name string, obj object.Object) (time.Time, *object.Error) {
+	if s, ok := obj.(*object.String); ok {
+		t, err := time.Parse(time.RFC3339, s.Value())
+		if err != nil {
+			return time.Time{}, object.Errorf("value error: invalid %s %q", name, s.Value())
+		}
+		return t, nil
+	}
+	return object.AsTime(obj)
+}
+
+// OptionalTime returns the time given as the only argument of a function,
+// converted as by Time, or the current time of the clock of the context if
+// there are no arguments.
+func OptionalTime(ctx context.Context, funcName string, args []object.Object) (time.Time, *object.Error) {
+	if err := RequireRange(funcName, 0, 1, args); err != nil {
+		return time.Time{}, err
+	}
+	if len(args) == 0 {
+		return object.GetDefaultClock(ctx).Now(), nil
+	}
+	return Time("time", args[0])
+}
@@ -0,0 +1,63 @@
+package arg_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuration(t *testing.T) {
+	d, err := arg.Duration("timeout", object.NewString("1m30s"))
+	require.Nil(t, err)
+	require.Equal(t, 90*time.Second, d)
+
+	// Numbers are seconds
+	d, err = arg.Duration("timeout", object.NewInt(5))
+	require.Nil(t, err)
+	require.Equal(t, 5*time.Second, d)
+	d, err = arg.Duration("timeout", object.NewFloat(0.25))
+	require.Nil(t, err)
+	require.Equal(t, 250*time.Millisecond, d)
+
+	_, err = arg.Duration("timeout", object.NewString("soon"))
+	require.Equal(t, `value error: invalid timeout "soon"`, err.Message().Value())
+	_, err = arg.Duration("timeout", object.NewInt(-1))
+	require.Equal(t, "value error: timeout must not be negative (got -1s)", err.Message().Value())
+	_, err = arg.Duration("timeout", object.True)
+	require.NotNil(t, err)
+}
+
+func TestTime(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	got, err := arg.Time("since", object.NewString("2024-03-01T12:00:00Z"))
+	require.Nil(t, err)
+	require.True(t, want.Equal(got))
+
+	got, err = arg.Time("since", object.NewTime(want))
+	require.Nil(t, err)
+	require.Equal(t, want, got)
+
+	_, err = arg.Time("since", object.NewString("yesterday"))
+	require.Equal(t, `value error: invalid since "yesterday"`, err.Message().Value())
+}
+
+func TestOptionalTime(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	ctx := object.WithClock(context.Background(), object.NewManualClock(now))
+
+	got, err := arg.OptionalTime(ctx, "next", nil)
+	require.Nil(t, err)
+	require.Equal(t, now, got)
+
+	later := now.Add(time.Hour)
+	got, err = arg.OptionalTime(ctx, "next", []object.Object{object.NewTime(later)})
+	require.Nil(t, err)
+	require.Equal(t, later, got)
+
+	_, err = arg.OptionalTime(ctx, "next", []object.Object{object.Nil, object.Nil})
+	require.NotNil(t, err)
+}
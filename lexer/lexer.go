@@ -52,6 +52,12 @@ type Lexer struct {
 
 	// Name of the file be read
 	file string
+
+	// Whether comments are recorded as they are skipped
+	keepComments bool
+
+	// The comments skipped so far, if keepComments is set
+	comments []token.Token
 }
 
 // Option is a configuration function for a Lexer.
@@ -64,6 +70,14 @@ func WithFile(file string) Option {
 	}
 }
 
+// WithComments makes the Lexer record the comments it skips, which are then
+// returned by Comments.
+func WithComments() Option {
+	return func(l *Lexer) {
+		l.keepComments = true
+	}
+}
+
 // New returns a Lexer instance for the given string input.
 func New(input string, options ...Option) *Lexer {
 	l := &Lexer{
@@ -89,6 +103,14 @@ func (l *Lexer) SetFilename(file string) {
 	l.file = file
 }
 
+// Comments returns the comments skipped so far, in the order they appear in
+// the input, if the Lexer was created with WithComments. Each is returned as
+// a COMMENT token whose literal is the text of the comment, including its
+// delimiters.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
 // Position returns the current read position of the Lexer as a Position object.
 func (l *Lexer) Position() token.Position {
 	return token.Position{
@@ -117,6 +139,7 @@ func (l *Lexer) Next() (token.Token, error) {
 	// multi-line comments
 	if l.ch == rune('/') && l.peekChar() == rune('*') {
 		l.skipMultiLineComment()
+		return l.Next()
 	}
 
 	if l.prevToken.Type == token.EOF {
@@ -401,6 +424,7 @@ func (l *Lexer) skipComment() {
 	for l.ch != '\n' && l.ch != rune(0) {
 		l.readChar()
 	}
+	l.recordComment()
 	l.skipTabsAndSpaces()
 }
 
@@ -420,9 +444,29 @@ func (l *Lexer) skipMultiLineComment() {
 		}
 		l.readChar()
 	}
+	l.recordComment()
 	l.skipTabsAndSpaces()
 }
 
+// Record the comment that started at the start of the current token and ends
+// just before the current character, if comments are kept
+func (l *Lexer) recordComment() {
+	if !l.keepComments {
+		return
+	}
+	end := l.position
+	if end > len(l.characters) {
+		end = len(l.characters)
+	}
+	text := strings.TrimRight(string(l.characters[l.tokenStartPosition.Char:end]), " \t\r")
+	l.comments = append(l.comments, token.Token{
+		Type:          token.COMMENT,
+		Literal:       text,
+		StartPosition: l.tokenStartPosition,
+		EndPosition:   l.Position(),
+	})
+}
+
 // Read a decimal, hex, or octal number
 func (l *Lexer) readNumber(onlyDecimal bool) (NumberType, string, error) {
 	str := string(l.ch)
@@ -365,6 +365,42 @@ var a = 1;
 	}
 }
 
+func TestComments(t *testing.T) {
+	input := `# shebang
+x := 1 // trailing
+/* multi
+   line */ y := 2`
+	l := New(input, WithComments())
+	var types []token.Type
+	for {
+		tok, err := l.Next()
+		require.Nil(t, err)
+		if tok.Type == token.EOF {
+			break
+		}
+		types = append(types, tok.Type)
+	}
+	require.Equal(t, []token.Type{
+		token.NEWLINE,
+		token.IDENT, token.DECLARE, token.INT, token.NEWLINE,
+		token.IDENT, token.DECLARE, token.INT,
+	}, types)
+	comments := l.Comments()
+	require.Len(t, comments, 3)
+	require.Equal(t, "# shebang", comments[0].Literal)
+	require.Equal(t, "// trailing", comments[1].Literal)
+	require.Equal(t, 1, comments[1].StartPosition.Line)
+	require.Equal(t, 7, comments[1].StartPosition.Column)
+	require.Equal(t, "/* multi\n   line */", comments[2].Literal)
+	require.Equal(t, 3, comments[2].EndPosition.Line)
+
+	// Comments aren't recorded by default
+	l = New(input)
+	for tok, _ := l.Next(); tok.Type != token.EOF; tok, _ = l.Next() {
+	}
+	require.Empty(t, l.Comments())
+}
+
 func TestIntegers(t *testing.T) {
 	input := `10 0x10 0xF0 0xFE 00101 0xFF 0101 0xFF;`
 
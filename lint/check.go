@@ -0,0 +1,567 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/token"
+)
+
+// kind is the kind of declaration of a variable.
+type kind int
+
+const (
+	variableKind kind = iota
+	constantKind
+	functionKind
+	parameterKind
+	importKind
+)
+
+// variable is a name declared in the source.
+type variable struct {
+	name string
+	tok  token.Token
+	kind kind
+	used bool
+}
+
+// scope holds the variables of a program, a function, or a block, like the
+// symbol tables of the compiler.
+type scope struct {
+	parent *scope
+	global bool
+	vars   map[string]*variable
+	order  []*variable
+}
+
+// checker walks a program, resolving names as the compiler does, and
+// reports the findings of the rules.
+type checker struct {
+	cfg         Config
+	file        string
+	errorCalls  map[string]bool
+	scope       *scope
+	diagnostics []Diagnostic
+
+	// Depth of function literals given to try, whose errors are handled
+	tried int
+
+	// Whether the next function literal is given to try
+	tryArg bool
+
+	// Depth of template strings, whose expressions have no positions in the
+	// source, so only their uses of variables are tracked
+	template int
+}
+
+func (c *checker) report(rule string, start, end token.Token, msg string, args ...interface{}) {
+	severity := c.cfg.severity(rule)
+	if severity == Off || c.template > 0 {
+		return
+	}
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Rule:      rule,
+		Severity:  severity,
+		Message:   fmt.Sprintf(msg, args...),
+		File:      c.file,
+		Line:      start.StartPosition.LineNumber(),
+		Column:    start.StartPosition.ColumnNumber(),
+		EndLine:   end.EndPosition.LineNumber(),
+		EndColumn: end.EndPosition.ColumnNumber() + 1,
+	})
+}
+
+func (c *checker) push(global bool) {
+	c.scope = &scope{parent: c.scope, global: global, vars: map[string]*variable{}}
+}
+
+// pop closes the current scope, reporting the variables it declares that
+// weren't used. Variables declared at the top level of a program are
+// exported, so only its imports are reported, and parameters and constants
+// aren't reported.
+func (c *checker) pop() {
+	for _, v := range c.scope.order {
+		if v.used {
+			continue
+		}
+		switch {
+		case v.kind == importKind:
+			c.report("unused-import", v.tok, v.tok, "%s is imported and not used", v.name)
+		case c.scope.global:
+		case v.kind == variableKind:
+			c.report("unused-variable", v.tok, v.tok, "%s is declared and not used", v.name)
+		case v.kind == functionKind:
+			c.report("unused-variable", v.tok, v.tok, "function %s is declared and not used", v.name)
+		}
+	}
+	c.scope = c.scope.parent
+}
+
+// declare adds a variable to the current scope, reporting it if it hides a
+// variable of an enclosing scope.
+func (c *checker) declare(name string, tok token.Token, k kind) {
+	if name == "_" {
+		return
+	}
+	if k != parameterKind {
+		if outer := c.lookup(c.scope.parent, name); outer != nil {
+			c.report("shadow", tok, tok, "declaration of %s shadows the one at line %d",
+				name, outer.tok.StartPosition.LineNumber())
+		}
+	}
+	v := &variable{name: name, tok: tok, kind: k}
+	c.scope.vars[name] = v
+	c.scope.order = append(c.scope.order, v)
+}
+
+func (c *checker) lookup(s *scope, name string) *variable {
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// isGlobal returns true if the name isn't declared in the source, so it
+// refers to a builtin or a module provided by the host.
+func (c *checker) isGlobal(name string) bool {
+	return c.lookup(c.scope, name) == nil
+}
+
+// isModule returns true if the name refers to an imported or a builtin
+// module.
+func (c *checker) isModule(name string) bool {
+	v := c.lookup(c.scope, name)
+	return v == nil || v.kind == importKind
+}
+
+func (c *checker) use(name string) {
+	if v := c.lookup(c.scope, name); v != nil {
+		v.used = true
+	}
+}
+
+func (c *checker) program(program *ast.Program) {
+	c.push(true)
+	c.statements(program.Statements())
+	c.pop()
+}
+
+func (c *checker) block(block *ast.Block) {
+	if block == nil {
+		return
+	}
+	c.push(false)
+	c.statements(block.Statements())
+	c.pop()
+}
+
+// statements checks a list of statements, reporting the first statement
+// that can't be reached.
+func (c *checker) statements(stmts []ast.Node) {
+	terminated := false
+	for i, stmt := range stmts {
+		// The parser follows an increment or a decrement with the name it
+		// applies to, which isn't a use of the variable
+		if i+1 < len(stmts) && isPostfixName(stmt, stmts[i+1]) {
+			continue
+		}
+		if terminated {
+			tok := start(stmt)
+			c.report("unreachable", tok, tok, "unreachable code")
+			terminated = false
+		}
+		c.node(stmt)
+		if c.terminates(stmt) {
+			terminated = true
+		}
+	}
+}
+
+// terminates returns true if execution never continues past a statement.
+func (c *checker) terminates(stmt ast.Node) bool {
+	switch stmt := stmt.(type) {
+	case *ast.Return, *ast.Control:
+		return true
+	case *ast.Call:
+		// Errors returned by builtins are raised
+		fn, ok := stmt.Function().(*ast.Ident)
+		return ok && fn.Literal() == "error" && c.isGlobal("error")
+	case *ast.If:
+		if stmt.Alternative() == nil {
+			return false
+		}
+		return c.blockTerminates(stmt.Consequence()) && c.blockTerminates(stmt.Alternative())
+	}
+	return false
+}
+
+func (c *checker) blockTerminates(block *ast.Block) bool {
+	stmts := block.Statements()
+	return len(stmts) > 0 && c.terminates(stmts[len(stmts)-1])
+}
+
+func (c *checker) nodes(nodes []ast.Expression) {
+	for _, node := range nodes {
+		c.node(node)
+	}
+}
+
+func (c *checker) node(node ast.Node) {
+	switch n := node.(type) {
+	case nil:
+	case *ast.Ident:
+		c.use(n.Literal())
+	case *ast.String:
+		c.template++
+		for _, expr := range n.TemplateExpressions() {
+			if expr != nil {
+				c.node(expr)
+			}
+		}
+		c.template--
+	case *ast.Prefix:
+		c.node(n.Right())
+	case *ast.Infix:
+		c.node(n.Left())
+		c.node(n.Right())
+		c.comparison(n)
+	case *ast.Ternary:
+		c.node(n.Condition())
+		c.node(n.IfTrue())
+		c.node(n.IfFalse())
+	case *ast.If:
+		c.node(n.Condition())
+		c.block(n.Consequence())
+		c.block(n.Alternative())
+	case *ast.Call:
+		c.call(n)
+	case *ast.ObjectCall:
+		c.objectCall(n)
+	case *ast.GetAttr:
+		c.node(n.Object())
+	case *ast.Pipe:
+		c.nodes(n.Expressions())
+	case *ast.Index:
+		c.node(n.Left())
+		c.node(n.Index())
+	case *ast.Slice:
+		c.node(n.Left())
+		c.node(n.FromIndex())
+		c.node(n.ToIndex())
+	case *ast.Switch:
+		c.node(n.Value())
+		for _, choice := range n.Choices() {
+			c.nodes(choice.Expressions())
+			c.block(choice.Block())
+		}
+	case *ast.In:
+		c.node(n.Left())
+		c.node(n.Right())
+	case *ast.Range:
+		c.node(n.Container())
+	case *ast.Receive:
+		c.node(n.Channel())
+	case *ast.Func:
+		c.function(n)
+	case *ast.List:
+		c.nodes(n.Items())
+	case *ast.Set:
+		c.nodes(n.Items())
+	case *ast.Map:
+		for key, value := range n.Items() {
+			c.node(key)
+			c.node(value)
+		}
+	case *ast.Var:
+		name, value := n.Value()
+		c.node(value)
+		c.declare(name, n.Token(), variableKind)
+	case *ast.MultiVar:
+		names, value := n.Value()
+		c.node(value)
+		// Without ":=" the names are assigned, as by the compiler
+		if n.IsWalrus() {
+			c.declareNames(n.Token(), names)
+		}
+	case *ast.Const:
+		name, value := n.Value()
+		c.node(value)
+		c.declare(name, n.Token(), constantKind)
+	case *ast.Return:
+		c.node(n.Value())
+	case *ast.For:
+		c.forLoop(n)
+	case *ast.Assign:
+		// Assigning to a variable isn't a use of it, but assigning to an
+		// item of a container is
+		if n.Index() != nil {
+			c.node(n.Index())
+		}
+		c.node(n.Value())
+	case *ast.SetAttr:
+		c.node(n.Object())
+		c.node(n.Value())
+	case *ast.Import:
+		name := n.Name()
+		if n.Alias() != nil {
+			name = n.Alias()
+		}
+		c.declare(name.Literal(), name.Token(), importKind)
+	case *ast.FromImport:
+		if n.IsWildcard() {
+			return
+		}
+		for _, im := range n.Imports() {
+			name := im.Name()
+			if im.Alias() != nil {
+				name = im.Alias()
+			}
+			c.declare(name.Literal(), name.Token(), importKind)
+		}
+	case *ast.Go:
+		c.node(n.Call())
+	case *ast.Defer:
+		c.node(n.Call())
+	case *ast.Send:
+		c.node(n.Channel())
+		c.node(n.Value())
+	}
+}
+
+// declareNames declares the names of a multiple assignment, whose tokens
+// the AST doesn't hold but the first.
+func (c *checker) declareNames(tok token.Token, names []string) {
+	for _, name := range names {
+		nameTok := tok
+		nameTok.Literal = name
+		c.declare(name, nameTok, variableKind)
+	}
+}
+
+func (c *checker) function(n *ast.Func) {
+	tried := c.tried
+	if c.tryArg {
+		c.tried++
+	} else {
+		// The function may be called outside of try
+		c.tried = 0
+	}
+	c.tryArg = false
+	c.push(false)
+	for _, param := range n.Parameters() {
+		c.declare(param.Literal(), param.Token(), parameterKind)
+	}
+	if name := n.Name(); name != nil {
+		// Recursive calls use the name the function has in its own scope
+		c.scope.vars[name.Literal()] = &variable{name: name.Literal(), tok: name.Token(), kind: constantKind}
+	}
+	c.block(n.Body())
+	c.pop()
+	c.tried = tried
+	if name := n.Name(); name != nil {
+		c.declare(name.Literal(), name.Token(), functionKind)
+	}
+}
+
+func (c *checker) forLoop(n *ast.For) {
+	c.push(false)
+	defer c.pop()
+	switch cond := n.Condition().(type) {
+	case *ast.Var:
+		// for x := range container
+		if r, ok := rangeOf(cond); ok && n.Init() == nil {
+			name, _ := cond.Value()
+			c.node(r)
+			c.declare(name, cond.Token(), variableKind)
+			c.block(n.Consequence())
+			return
+		}
+	case *ast.MultiVar:
+		// for k, v := range container
+		names, value := cond.Value()
+		if _, ok := value.(*ast.Range); ok && cond.IsWalrus() && n.Init() == nil {
+			c.node(value)
+			c.declareNames(cond.Token(), names)
+			c.block(n.Consequence())
+			return
+		}
+	}
+	c.node(n.Init())
+	c.node(n.Condition())
+	c.node(n.Post())
+	c.block(n.Consequence())
+}
+
+func rangeOf(v *ast.Var) (*ast.Range, bool) {
+	_, value := v.Value()
+	r, ok := value.(*ast.Range)
+	return r, ok
+}
+
+func (c *checker) call(n *ast.Call) {
+	c.node(n.Function())
+	fn, isIdent := n.Function().(*ast.Ident)
+	isTry := isIdent && fn.Literal() == "try" && c.isGlobal("try")
+	for _, arg := range n.Arguments() {
+		if _, ok := arg.(*ast.Func); ok && isTry {
+			c.tryArg = true
+		}
+		c.node(arg)
+		c.tryArg = false
+	}
+	if isIdent && c.tried == 0 && c.errorCalls[fn.Literal()] && c.isGlobal(fn.Literal()) {
+		c.report("unhandled-error", fn.Token(), fn.Token(),
+			"%s may fail and isn't called within try", fn.Literal())
+	}
+}
+
+func (c *checker) objectCall(n *ast.ObjectCall) {
+	c.node(n.Object())
+	call, ok := n.Call().(*ast.Call)
+	if !ok {
+		c.node(n.Call())
+		return
+	}
+	// The name of the method isn't a use of a variable
+	for _, arg := range call.Arguments() {
+		c.node(arg)
+	}
+	module, ok := n.Object().(*ast.Ident)
+	if !ok || c.tried > 0 || !c.isModule(module.Literal()) {
+		return
+	}
+	name := module.Literal() + "." + call.Function().Literal()
+	if c.errorCalls[module.Literal()] || c.errorCalls[name] {
+		c.report("unhandled-error", module.Token(), call.Function().Token(),
+			"%s may fail and isn't called within try", name)
+	}
+}
+
+var comparisons = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+// comparison reports comparisons whose result is known in advance.
+func (c *checker) comparison(n *ast.Infix) {
+	operator := n.Operator()
+	if !comparisons[operator] {
+		return
+	}
+	left, right := n.Left(), n.Right()
+	switch {
+	case isConstant(left) && isConstant(right):
+		c.report("suspicious-comparison", start(n), end(n), "comparison of constant values")
+	case isPure(left) && left.String() == right.String():
+		always := operator == "==" || operator == "<=" || operator == ">="
+		c.report("suspicious-comparison", start(n), end(n),
+			"comparison of %s with itself is always %t", left.String(), always)
+	case isLen(left) && isZero(right) && (operator == "<" || operator == ">="):
+		c.report("suspicious-comparison", start(n), end(n),
+			"length compared with 0 using %s is always %t", operator, operator == ">=")
+	case isZero(left) && isLen(right) && (operator == ">" || operator == "<="):
+		c.report("suspicious-comparison", start(n), end(n),
+			"length compared with 0 using %s is always %t", operator, operator == "<=")
+	}
+}
+
+func isConstant(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.Int, *ast.Float, *ast.Bool, *ast.Nil:
+		return true
+	case *ast.String:
+		return expr.Template() == nil
+	}
+	return false
+}
+
+// isPure returns true if evaluating an expression has no side effects, so
+// it has the same value each time.
+func isPure(expr ast.Expression) bool {
+	switch expr := expr.(type) {
+	case *ast.Ident, *ast.Int, *ast.Float, *ast.Bool, *ast.Nil:
+		return true
+	case *ast.String:
+		return expr.Template() == nil
+	case *ast.GetAttr:
+		return isPure(expr.Object())
+	case *ast.Index:
+		return isPure(expr.Left()) && isPure(expr.Index())
+	case *ast.Prefix:
+		return isPure(expr.Right())
+	case *ast.Infix:
+		return isPure(expr.Left()) && isPure(expr.Right())
+	}
+	return false
+}
+
+func isLen(expr ast.Expression) bool {
+	call, ok := expr.(*ast.Call)
+	if !ok {
+		return false
+	}
+	fn, ok := call.Function().(*ast.Ident)
+	return ok && fn.Literal() == "len" && len(call.Arguments()) == 1
+}
+
+func isZero(expr ast.Expression) bool {
+	i, ok := expr.(*ast.Int)
+	return ok && i.Value() == 0
+}
+
+func isPostfixName(node, next ast.Node) bool {
+	ident, ok := node.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	postfix, ok := next.(*ast.Postfix)
+	return ok && postfix.Token().StartPosition.Char == ident.Token().StartPosition.Char
+}
+
+// start returns the leftmost token of a node that the AST holds.
+func start(node ast.Node) token.Token {
+	switch n := node.(type) {
+	case *ast.Infix:
+		return start(n.Left())
+	case *ast.Ternary:
+		return start(n.Condition())
+	case *ast.Call:
+		return start(n.Function())
+	case *ast.ObjectCall:
+		return start(n.Object())
+	case *ast.GetAttr:
+		return start(n.Object())
+	case *ast.Index:
+		return start(n.Left())
+	case *ast.Slice:
+		return start(n.Left())
+	case *ast.Pipe:
+		return start(n.Expressions()[0])
+	case *ast.In:
+		return start(n.Left())
+	case *ast.Send:
+		return start(n.Channel())
+	case *ast.SetAttr:
+		return start(n.Object())
+	case *ast.Assign:
+		if n.Index() != nil {
+			return start(n.Index())
+		}
+	}
+	return node.Token()
+}
+
+// end returns the rightmost token of an expression that the AST holds.
+func end(node ast.Node) token.Token {
+	switch n := node.(type) {
+	case *ast.Infix:
+		return end(n.Right())
+	case *ast.Prefix:
+		return end(n.Right())
+	case *ast.GetAttr:
+		return end(n.Object())
+	case *ast.Index:
+		return end(n.Index())
+	}
+	return node.Token()
+}
@@ -0,0 +1,269 @@
+// Package lint finds likely mistakes in Risor source code.
+//
+// Each finding is reported by a rule, whose severity may be configured, or
+// which may be turned off. A finding is also ignored if a comment on its
+// line, or alone on the line before it, contains "lint:ignore" followed by
+// the name of its rule, such as:
+//
+//	// lint:ignore shadow the outer value isn't needed here
+//	value := transform(value)
+package lint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/risor-io/risor/lexer"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/token"
+)
+
+// ConfigFile is the name of the file configuring the linter for a project.
+const ConfigFile = ".risor-lint.json"
+
+// Severity is the severity of a finding.
+type Severity string
+
+const (
+	Off     Severity = "off"
+	Info    Severity = "info"
+	Warning Severity = "warning"
+	Error   Severity = "error"
+)
+
+// Rule describes a check of the linter.
+type Rule struct {
+	Name        string
+	Description string
+
+	// Severity of the findings of the rule, unless configured otherwise.
+	Severity Severity
+}
+
+// Rules are the checks of the linter.
+var Rules = []Rule{
+	{
+		Name:        "unused-variable",
+		Description: "Variables and functions declared in a function or a block and never used",
+		Severity:    Warning,
+	},
+	{
+		Name:        "unused-import",
+		Description: "Modules imported and never used",
+		Severity:    Warning,
+	},
+	{
+		Name:        "shadow",
+		Description: "Declarations hiding a variable of an enclosing scope",
+		Severity:    Warning,
+	},
+	{
+		Name:        "unreachable",
+		Description: "Statements following a return, break, continue, or error",
+		Severity:    Warning,
+	},
+	{
+		Name:        "suspicious-comparison",
+		Description: "Comparisons whose result doesn't depend on the values compared",
+		Severity:    Warning,
+	},
+	{
+		Name:        "unhandled-error",
+		Description: "Calls that may fail, such as those doing I/O, made outside of try",
+		Severity:    Info,
+	},
+}
+
+// DefaultErrorCalls are the calls checked by the unhandled-error rule,
+// unless configured otherwise.
+var DefaultErrorCalls = []string{
+	"exec",
+	"fetch",
+	"os.chdir",
+	"os.create",
+	"os.mkdir",
+	"os.mkdir_all",
+	"os.mkdir_temp",
+	"os.open",
+	"os.read_dir",
+	"os.read_file",
+	"os.remove",
+	"os.remove_all",
+	"os.rename",
+	"os.stat",
+	"os.symlink",
+	"os.write_file",
+	"sql",
+}
+
+// Config configures the linter.
+type Config struct {
+	// Severity of the findings of each rule, by name, overriding that of the
+	// rule. Rules configured as Off aren't checked.
+	Rules map[string]Severity `json:"rules,omitempty"`
+
+	// Calls checked by the unhandled-error rule, each the name of a global
+	// function, such as "fetch", of a module, such as "http", to check the
+	// calls of all its functions, or of a function of a module, such as
+	// "os.read_file". Defaults to DefaultErrorCalls.
+	ErrorCalls []string `json:"error_calls,omitempty"`
+}
+
+// ReadConfig reads a JSON configuration file, such as a ConfigFile.
+func ReadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validate checks that the configured rules and severities exist.
+func (c Config) Validate() error {
+	for name, severity := range c.Rules {
+		if _, ok := findRule(name); !ok {
+			return fmt.Errorf("lint error: unknown rule %q", name)
+		}
+		switch severity {
+		case Off, Info, Warning, Error:
+		default:
+			return fmt.Errorf("lint error: invalid severity %q for rule %q", severity, name)
+		}
+	}
+	return nil
+}
+
+// severity returns the configured severity of a rule.
+func (c Config) severity(name string) Severity {
+	if severity, ok := c.Rules[name]; ok {
+		return severity
+	}
+	rule, _ := findRule(name)
+	return rule.Severity
+}
+
+func findRule(name string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Diagnostic is a finding of the linter.
+type Diagnostic struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	File     string   `json:"file,omitempty"`
+
+	// One-indexed position of the code the finding is about.
+	Line      int `json:"line"`
+	Column    int `json:"column"`
+	EndLine   int `json:"end_line"`
+	EndColumn int `json:"end_column"`
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", d.File, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// Source lints the given Risor source code, read from the named file, and
+// returns the findings ordered by position. An error is returned if the
+// source doesn't parse.
+func Source(ctx context.Context, file string, src []byte, cfg Config) ([]Diagnostic, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	program, err := parser.Parse(ctx, string(src), parser.WithFile(file))
+	if err != nil {
+		return nil, err
+	}
+	calls := cfg.ErrorCalls
+	if calls == nil {
+		calls = DefaultErrorCalls
+	}
+	c := &checker{
+		cfg:        cfg,
+		file:       file,
+		errorCalls: map[string]bool{},
+	}
+	for _, call := range calls {
+		c.errorCalls[call] = true
+	}
+	c.program(program)
+	ignored, err := ignoredRules(string(src))
+	if err != nil {
+		return nil, err
+	}
+	diagnostics := make([]Diagnostic, 0, len(c.diagnostics))
+	for _, d := range c.diagnostics {
+		if ignored[d.Line][d.Rule] {
+			continue
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return diagnostics, nil
+}
+
+// ignoredRules returns the rules ignored by "lint:ignore" comments, by the
+// one-indexed line they apply to: that of the comment, and the next one if
+// the comment is on a line of its own.
+func ignoredRules(src string) (map[int]map[string]bool, error) {
+	l := lexer.New(src, lexer.WithComments())
+	for {
+		tok, err := l.Next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+	// Positions index the runes of the source
+	runes := []rune(src)
+	ignored := map[int]map[string]bool{}
+	for _, comment := range l.Comments() {
+		_, rest, ok := strings.Cut(comment.Literal, "lint:ignore ")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		lines := []int{comment.StartPosition.LineNumber()}
+		pos := comment.StartPosition
+		if strings.TrimSpace(string(runes[pos.LineStart:pos.Char])) == "" {
+			lines = append(lines, comment.EndPosition.LineNumber()+1)
+		}
+		for _, line := range lines {
+			if ignored[line] == nil {
+				ignored[line] = map[string]bool{}
+			}
+			for _, rule := range strings.Split(fields[0], ",") {
+				ignored[line][rule] = true
+			}
+		}
+	}
+	return ignored, nil
+}
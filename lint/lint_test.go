@@ -0,0 +1,238 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func lintStrings(t *testing.T, src string, cfg Config) []string {
+	t.Helper()
+	diagnostics, err := Source(context.Background(), "test.risor", []byte(src), cfg)
+	require.Nil(t, err)
+	result := []string{}
+	for _, d := range diagnostics {
+		result = append(result, d.String())
+	}
+	return result
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			name: "unused variable",
+			input: `x := 1
+func f(a, b) {
+    y := 2
+    z := 3
+    for i := 0; i < 3; i++ {}
+    for _, v := range [1] { print(v) }
+    func g() {}
+    return z
+}
+f(1, 2)`,
+			expected: []string{
+				"test.risor:3:5: warning: y is declared and not used (unused-variable)",
+				"test.risor:7:10: warning: function g is declared and not used (unused-variable)",
+			},
+		},
+		{
+			name: "assignment is not a use",
+			input: `func f() {
+    count := 0
+    count = 1
+    count += 2
+    count++
+    items := []
+    items[0] = 1
+}
+f()`,
+			expected: []string{
+				"test.risor:2:5: warning: count is declared and not used (unused-variable)",
+			},
+		},
+		{
+			name: "template use",
+			input: `func f() {
+    name := "world"
+    return 'hello {name}'
+}
+f()`,
+			expected: []string{},
+		},
+		{
+			name: "recursion",
+			input: `func f() {
+    func loop(n) {
+        return n > 0 ? loop(n - 1) : 0
+    }
+}
+f()`,
+			expected: []string{
+				"test.risor:2:10: warning: function loop is declared and not used (unused-variable)",
+			},
+		},
+		{
+			name: "unused import",
+			input: `import json
+import strings as s
+from os import (getenv, setenv as set)
+print(json.marshal(1), getenv("HOME"))`,
+			expected: []string{
+				"test.risor:2:19: warning: s is imported and not used (unused-import)",
+				"test.risor:3:35: warning: set is imported and not used (unused-import)",
+			},
+		},
+		{
+			name: "shadow",
+			input: `x := 1
+func f(x) {
+    if x {
+        x := 2
+        print(x)
+    }
+    for _, x := range [1] { print(x) }
+}
+f(x)`,
+			expected: []string{
+				"test.risor:4:9: warning: declaration of x shadows the one at line 2 (shadow)",
+				"test.risor:7:9: warning: declaration of x shadows the one at line 2 (shadow)",
+			},
+		},
+		{
+			name: "unreachable",
+			input: `func f(x) {
+    if x {
+        return 1
+    } else {
+        error("no")
+    }
+    print("never")
+    print("again")
+}
+for {
+    break
+    print("never")
+}
+f(1)`,
+			expected: []string{
+				"test.risor:7:5: warning: unreachable code (unreachable)",
+				"test.risor:12:5: warning: unreachable code (unreachable)",
+			},
+		},
+		{
+			name: "reachable",
+			input: `func f(x) {
+    if x {
+        return 1
+    }
+    print("maybe")
+}
+f(1)`,
+			expected: []string{},
+		},
+		{
+			name: "suspicious comparison",
+			input: `x := [1]
+print(1 == 1, x == x, len(x) < 0, 0 <= len(x), x[0] != x[0])
+print(x == 1, f() == f(), len(x) > 0)`,
+			expected: []string{
+				"test.risor:2:7: warning: comparison of constant values (suspicious-comparison)",
+				"test.risor:2:15: warning: comparison of x with itself is always true (suspicious-comparison)",
+				"test.risor:2:23: warning: length compared with 0 using < is always false (suspicious-comparison)",
+				"test.risor:2:35: warning: length compared with 0 using <= is always true (suspicious-comparison)",
+				"test.risor:2:48: warning: comparison of (x[0]) with itself is always false (suspicious-comparison)",
+			},
+		},
+		{
+			name: "unhandled error",
+			input: `data := os.read_file("a")
+os.getenv("HOME")
+try(func() { os.read_file("b") }, func(e) { fetch("c") })
+func fetch_all() { fetch("d") }
+try(fetch_all)`,
+			expected: []string{
+				"test.risor:1:9: info: os.read_file may fail and isn't called within try (unhandled-error)",
+				"test.risor:4:20: info: fetch may fail and isn't called within try (unhandled-error)",
+			},
+		},
+		{
+			name: "local names are not modules",
+			input: `os := {read_file: func(name) { return name }}
+print(os.read_file("a"))`,
+			expected: []string{},
+		},
+		{
+			name: "ignore comments",
+			input: `func f() {
+    // lint:ignore unused-variable,shadow kept for debugging
+    a := 1
+    b := 2 // lint:ignore unused-variable
+    c := 3 // lint:ignore shadow
+}
+f()`,
+			expected: []string{
+				"test.risor:5:5: warning: c is declared and not used (unused-variable)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, lintStrings(t, tt.input, Config{}))
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	src := `import json
+func f() {
+    x := fetch("a")
+}
+f()`
+	cfg := Config{
+		Rules: map[string]Severity{
+			"unused-import":   Off,
+			"unused-variable": Error,
+			"unhandled-error": Warning,
+		},
+	}
+	require.Equal(t, []string{
+		"test.risor:3:5: error: x is declared and not used (unused-variable)",
+		"test.risor:3:10: warning: fetch may fail and isn't called within try (unhandled-error)",
+	}, lintStrings(t, src, cfg))
+
+	cfg = Config{ErrorCalls: []string{"json"}}
+	require.Equal(t, []string{
+		"test.risor:2:1: info: json.unmarshal may fail and isn't called within try (unhandled-error)",
+	}, lintStrings(t, "import json\njson.unmarshal(fetch(\"a\"))", cfg))
+}
+
+func TestConfigValidate(t *testing.T) {
+	_, err := Source(context.Background(), "", nil, Config{Rules: map[string]Severity{"nope": Warning}})
+	require.EqualError(t, err, `lint error: unknown rule "nope"`)
+	_, err = Source(context.Background(), "", nil, Config{Rules: map[string]Severity{"shadow": "loud"}})
+	require.EqualError(t, err, `lint error: invalid severity "loud" for rule "shadow"`)
+}
+
+func TestReadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFile)
+	require.Nil(t, os.WriteFile(path, []byte(`{"rules": {"shadow": "off"}, "error_calls": ["exec"]}`), 0o644))
+	cfg, err := ReadConfig(path)
+	require.Nil(t, err)
+	require.Equal(t, Config{
+		Rules:      map[string]Severity{"shadow": Off},
+		ErrorCalls: []string{"exec"},
+	}, cfg)
+}
+
+func TestSourceError(t *testing.T) {
+	_, err := Source(context.Background(), "test.risor", []byte("x := ("), Config{})
+	require.NotNil(t, err)
+}
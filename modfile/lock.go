@@ -0,0 +1,77 @@
+package modfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LockName is the file name of a project lockfile, which sits next to the
+// project manifest.
+const LockName = "risor.lock"
+
+// Lock is a parsed lockfile. It records the checksum of each remote module
+// a project imported, by the name of the module at its resolved version,
+// such as "github.com/org/lib/x@v1.2.0".
+//
+// Each line of a lockfile holds a module and the checksum of its source:
+//
+//	github.com/org/lib/x@v1.2.0 sha256:9f86d08...
+type Lock struct {
+	Sums map[string]string
+}
+
+// ReadLock parses the lockfile at the given path.
+func ReadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLock(path, data)
+}
+
+// ParseLock parses the lockfile data. The file name is only used in errors.
+// Blank lines and lines starting with "#" are ignored.
+func ParseLock(filename string, data []byte) (*Lock, error) {
+	lock := &Lock{Sums: map[string]string{}}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected a module and its checksum", filename, n)
+		}
+		if !isChecksum(fields[1]) {
+			return nil, fmt.Errorf("%s:%d: invalid checksum %q", filename, n, fields[1])
+		}
+		if _, ok := lock.Sums[fields[0]]; ok {
+			return nil, fmt.Errorf("%s:%d: duplicate checksum for %s", filename, n, fields[0])
+		}
+		lock.Sums[fields[0]] = fields[1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Format returns the lockfile in its canonical form, sorted by module.
+func (l *Lock) Format() []byte {
+	names := make([]string, 0, len(l.Sums))
+	for name := range l.Sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by risor. Do not edit.\n")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%s %s\n", name, l.Sums[name])
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,371 @@
+// Package modfile parses risor.mod project manifests.
+//
+// A manifest names a project, the Risor version it needs, and the versions
+// of the remote modules it imports:
+//
+//	module example.com/deploy-tools
+//
+//	risor 1.5
+//
+//	require (
+//		github.com/org/lib v1.2.0
+//		oci://ghcr.io/org/tools v2
+//		https://example.com/util.risor sha256:9f86d08...
+//	)
+//
+// Git modules are required at a tag, branch, or commit. OCI modules are
+// required at a tag or a "sha256:" digest. Modules fetched over HTTPS are
+// required at the "sha256:" checksum of their source. Git repositories may
+// hold a manifest of their own, whose requirements are reconciled with those
+// of the project by Resolve.
+package modfile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Name is the file name of a project manifest.
+const Name = "risor.mod"
+
+// File is a parsed project manifest.
+type File struct {
+	// The name of the project, such as "example.com/deploy-tools".
+	Module string
+
+	// The oldest Risor version able to run the project, such as "1.5", or
+	// an empty string if any version will do.
+	Risor string
+
+	// The remote modules the project imports, sorted by path.
+	Requires []Require
+}
+
+// Require declares the version of a remote module.
+type Require struct {
+	// The module path, such as "github.com/org/lib".
+	Path string
+
+	// The version, such as "v1.2.0" or "sha256:9f86d08...".
+	Version string
+}
+
+// Read parses the manifest at the given path.
+func Read(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data)
+}
+
+// Find returns the path of the manifest in the given directory or the
+// nearest of its parents. It returns an error satisfying
+// errors.Is(err, fs.ErrNotExist) if there is none.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, Name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no %s found: %w", Name, os.ErrNotExist)
+		}
+		dir = parent
+	}
+}
+
+// Parse parses the manifest data. The file name is only used in errors.
+func Parse(filename string, data []byte) (*File, error) {
+	f := &File{}
+	seen := map[string]int{}
+	inBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNumber := 0
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("%s:%d: %s", filename, lineNumber, fmt.Sprintf(format, args...))
+	}
+	addRequire := func(fields []string) error {
+		if len(fields) != 2 {
+			return fail("usage: require <module> <version>")
+		}
+		req := Require{Path: fields[0], Version: fields[1]}
+		if line, ok := seen[req.Path]; ok {
+			return fail("module %s is already required on line %d", req.Path, line)
+		}
+		if err := req.validate(); err != nil {
+			return fail("%s", err)
+		}
+		seen[req.Path] = lineNumber
+		f.Requires = append(f.Requires, req)
+		return nil
+	}
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(stripComment(scanner.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+		if inBlock {
+			if len(fields) == 1 && fields[0] == ")" {
+				inBlock = false
+				continue
+			}
+			if err := addRequire(fields); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		switch fields[0] {
+		case "module":
+			if len(fields) != 2 {
+				return nil, fail("usage: module <name>")
+			}
+			if f.Module != "" {
+				return nil, fail("repeated module directive")
+			}
+			f.Module = fields[1]
+		case "risor":
+			if len(fields) != 2 {
+				return nil, fail("usage: risor <version>")
+			}
+			if f.Risor != "" {
+				return nil, fail("repeated risor directive")
+			}
+			if _, err := parseVersion(fields[1]); err != nil {
+				return nil, fail("invalid risor version %q", fields[1])
+			}
+			f.Risor = fields[1]
+		case "require":
+			if len(fields) == 2 && fields[1] == "(" {
+				inBlock = true
+				continue
+			}
+			if err := addRequire(fields[1:]); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fail("unknown directive %q", fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inBlock {
+		return nil, fail("unterminated require block")
+	}
+	if f.Module == "" {
+		return nil, fmt.Errorf("%s: missing module directive", filename)
+	}
+	sort.Slice(f.Requires, func(a, b int) bool {
+		return f.Requires[a].Path < f.Requires[b].Path
+	})
+	return f, nil
+}
+
+// Require returns the requirement for the module with the given path.
+func (f *File) Require(path string) (Require, bool) {
+	i := sort.Search(len(f.Requires), func(i int) bool {
+		return f.Requires[i].Path >= path
+	})
+	if i < len(f.Requires) && f.Requires[i].Path == path {
+		return f.Requires[i], true
+	}
+	return Require{}, false
+}
+
+// AddRequire requires the module with the given path at the given version,
+// replacing the version it was required at, if any.
+func (f *File) AddRequire(path, version string) error {
+	req := Require{Path: path, Version: version}
+	if err := req.validate(); err != nil {
+		return err
+	}
+	i := sort.Search(len(f.Requires), func(i int) bool {
+		return f.Requires[i].Path >= path
+	})
+	if i < len(f.Requires) && f.Requires[i].Path == path {
+		f.Requires[i] = req
+		return nil
+	}
+	f.Requires = append(f.Requires, Require{})
+	copy(f.Requires[i+1:], f.Requires[i:])
+	f.Requires[i] = req
+	return nil
+}
+
+// DropRequire removes the requirement for the module with the given path.
+// It returns false if the module wasn't required.
+func (f *File) DropRequire(path string) bool {
+	for i, req := range f.Requires {
+		if req.Path == path {
+			f.Requires = append(f.Requires[:i], f.Requires[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRisorVersion returns an error if the given Risor version is older
+// than the one the project requires. Development builds, with a version
+// that isn't a release number, satisfy any requirement.
+func (f *File) CheckRisorVersion(current string) error {
+	if f.Risor == "" {
+		return nil
+	}
+	have, err := parseVersion(current)
+	if err != nil {
+		return nil
+	}
+	want, _ := parseVersion(f.Risor)
+	for i := range want {
+		if have[i] != want[i] {
+			if have[i] < want[i] {
+				return fmt.Errorf("%s requires risor %s or newer (running %s)", f.Module, f.Risor, current)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// Format returns the manifest in its canonical form.
+func (f *File) Format() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "module %s\n", f.Module)
+	if f.Risor != "" {
+		fmt.Fprintf(&buf, "\nrisor %s\n", f.Risor)
+	}
+	switch len(f.Requires) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&buf, "\nrequire %s %s\n", f.Requires[0].Path, f.Requires[0].Version)
+	default:
+		buf.WriteString("\nrequire (\n")
+		for _, req := range f.Requires {
+			fmt.Fprintf(&buf, "\t%s %s\n", req.Path, req.Version)
+		}
+		buf.WriteString(")\n")
+	}
+	return buf.Bytes()
+}
+
+// IsURL returns true if the module is fetched over HTTP or HTTPS.
+func (r Require) IsURL() bool {
+	return strings.HasPrefix(r.Path, "https://") || strings.HasPrefix(r.Path, "http://")
+}
+
+// IsOCI returns true if the module is pulled from an OCI registry.
+func (r Require) IsOCI() bool {
+	return strings.HasPrefix(r.Path, "oci://")
+}
+
+// Matches returns true if the import name refers to the module or one of its
+// files, with or without a version, such as "github.com/org/lib/x@v1.2.0"
+// for the module "github.com/org/lib".
+func (r Require) Matches(name string) bool {
+	rest, ok := strings.CutPrefix(name, r.Path)
+	if !ok {
+		return false
+	}
+	switch {
+	case rest == "":
+		return true
+	case r.IsURL():
+		return strings.HasPrefix(rest, "#")
+	case r.IsOCI():
+		return strings.ContainsAny(rest[:1], "/:@")
+	default:
+		return strings.ContainsAny(rest[:1], "/@")
+	}
+}
+
+func (r Require) validate() error {
+	if strings.ContainsAny(r.Path, "@#?") {
+		return fmt.Errorf("module path %q must not include a version", r.Path)
+	}
+	switch {
+	case r.IsURL():
+		if !isChecksum(r.Version) {
+			return fmt.Errorf("module %s must be required at a sha256 checksum", r.Path)
+		}
+	case r.IsOCI():
+		rest := strings.TrimPrefix(r.Path, "oci://")
+		if i := strings.Index(rest, "/"); i >= 0 && strings.Contains(rest[i:], ":") {
+			return fmt.Errorf("module path %q must not include a tag", r.Path)
+		}
+		if strings.HasPrefix(r.Version, "sha256:") {
+			if !isChecksum(r.Version) {
+				return fmt.Errorf("invalid digest %q for module %s", r.Version, r.Path)
+			}
+		} else if strings.HasPrefix(r.Version, "-") || strings.ContainsAny(r.Version, ":/") {
+			return fmt.Errorf("invalid tag %q for module %s", r.Version, r.Path)
+		}
+	default:
+		if strings.HasPrefix(r.Version, "-") || strings.ContainsAny(r.Version, ":~^?*[\\") ||
+			strings.Contains(r.Version, "..") {
+			return fmt.Errorf("invalid version %q for module %s", r.Version, r.Path)
+		}
+	}
+	return nil
+}
+
+// stripComment removes a "//" comment from the line. Comments start at the
+// beginning of a line or after whitespace, so URLs are left intact.
+func stripComment(line string) string {
+	for i := strings.Index(line, "//"); i >= 0; {
+		if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+			return line[:i]
+		}
+		next := strings.Index(line[i+2:], "//")
+		if next < 0 {
+			break
+		}
+		i += 2 + next
+	}
+	return line
+}
+
+// isChecksum returns true if s is a "sha256:" checksum in hex.
+func isChecksum(s string) bool {
+	sum, ok := strings.CutPrefix(s, "sha256:")
+	if !ok || len(sum) != 64 {
+		return false
+	}
+	for _, c := range sum {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseVersion parses a release number such as "1.5" or "v1.5.2" into its
+// major, minor, and patch numbers.
+func parseVersion(s string) ([3]int, error) {
+	var v [3]int
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	if len(parts) > 3 {
+		return v, errors.New("too many version parts")
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part != strconv.Itoa(n) {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
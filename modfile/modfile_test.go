@@ -0,0 +1,173 @@
+package modfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var sum = "sha256:" + strings.Repeat("ab", 32)
+
+func TestParse(t *testing.T) {
+	data := `// Deployment tooling
+module example.com/deploy-tools
+
+risor 1.5
+
+require github.com/org/lib v1.2.0
+
+require (
+	oci://ghcr.io/org/tools v2 // shared tools
+	https://example.com/util.risor ` + sum + `
+)
+`
+	f, err := Parse(Name, []byte(data))
+	require.Nil(t, err)
+	require.Equal(t, &File{
+		Module: "example.com/deploy-tools",
+		Risor:  "1.5",
+		Requires: []Require{
+			{Path: "github.com/org/lib", Version: "v1.2.0"},
+			{Path: "https://example.com/util.risor", Version: sum},
+			{Path: "oci://ghcr.io/org/tools", Version: "v2"},
+		},
+	}, f)
+
+	req, ok := f.Require("oci://ghcr.io/org/tools")
+	require.True(t, ok)
+	require.Equal(t, "v2", req.Version)
+	_, ok = f.Require("github.com/org/other")
+	require.False(t, ok)
+
+	// Formatting produces a manifest that parses to the same result
+	formatted, err := Parse(Name, f.Format())
+	require.Nil(t, err)
+	require.Equal(t, f, formatted)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{"risor 1.5", "risor.mod: missing module directive"},
+		{"module a\nmodule b", "risor.mod:2: repeated module directive"},
+		{"module a\nrisor one", `risor.mod:2: invalid risor version "one"`},
+		{"module a\nreplace x => y", `risor.mod:2: unknown directive "replace"`},
+		{"module a\nrequire x", "risor.mod:2: usage: require <module> <version>"},
+		{"module a\nrequire (\nx v1", "risor.mod:3: unterminated require block"},
+		{"module a\nrequire x.com/a/b v1\nrequire x.com/a/b v2", "risor.mod:3: module x.com/a/b is already required on line 2"},
+		{"module a\nrequire x.com/a/b@v1 v1", `risor.mod:2: module path "x.com/a/b@v1" must not include a version`},
+		{"module a\nrequire x.com/a/b --upload-pack", `risor.mod:2: invalid version "--upload-pack" for module x.com/a/b`},
+		{"module a\nrequire https://x.com/a.risor v1", "risor.mod:2: module https://x.com/a.risor must be required at a sha256 checksum"},
+		{"module a\nrequire oci://ghcr.io/org/lib:v1 v1", `risor.mod:2: module path "oci://ghcr.io/org/lib:v1" must not include a tag`},
+		{"module a\nrequire oci://ghcr.io/org/lib sha256:ab", `risor.mod:2: invalid digest "sha256:ab" for module oci://ghcr.io/org/lib`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			_, err := Parse(Name, []byte(tt.data))
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestAddRequire(t *testing.T) {
+	f := &File{Module: "app"}
+	require.Nil(t, f.AddRequire("github.com/org/lib", "v1.2.0"))
+	require.Nil(t, f.AddRequire("oci://ghcr.io/org/tools", "v2"))
+	require.Nil(t, f.AddRequire("https://example.com/util.risor", sum))
+	require.Nil(t, f.AddRequire("github.com/org/lib", "v1.3.0"))
+	require.Equal(t, []Require{
+		{Path: "github.com/org/lib", Version: "v1.3.0"},
+		{Path: "https://example.com/util.risor", Version: sum},
+		{Path: "oci://ghcr.io/org/tools", Version: "v2"},
+	}, f.Requires)
+
+	err := f.AddRequire("https://example.com/other.risor", "v1")
+	require.NotNil(t, err)
+	require.Equal(t, "module https://example.com/other.risor must be required at a sha256 checksum", err.Error())
+	require.Len(t, f.Requires, 3)
+
+	require.True(t, f.DropRequire("https://example.com/util.risor"))
+	require.False(t, f.DropRequire("https://example.com/util.risor"))
+	require.Equal(t, []Require{
+		{Path: "github.com/org/lib", Version: "v1.3.0"},
+		{Path: "oci://ghcr.io/org/tools", Version: "v2"},
+	}, f.Requires)
+}
+
+func TestRequireMatches(t *testing.T) {
+	tests := []struct {
+		req      Require
+		name     string
+		expected bool
+	}{
+		{Require{Path: "github.com/org/lib"}, "github.com/org/lib", true},
+		{Require{Path: "github.com/org/lib"}, "github.com/org/lib/x", true},
+		{Require{Path: "github.com/org/lib"}, "github.com/org/lib/x@v1.2.0", true},
+		{Require{Path: "github.com/org/lib"}, "github.com/org/lib@v1.2.0", true},
+		{Require{Path: "github.com/org/lib"}, "github.com/org/library", false},
+		{Require{Path: "https://example.com/util.risor"}, "https://example.com/util.risor", true},
+		{Require{Path: "https://example.com/util.risor"}, "https://example.com/util.risor#sha256=ab", true},
+		{Require{Path: "https://example.com/util.risor"}, "https://example.com/util.risor.bak", false},
+		{Require{Path: "oci://ghcr.io/org/tools"}, "oci://ghcr.io/org/tools:v2/x", true},
+		{Require{Path: "oci://ghcr.io/org/tools"}, "oci://ghcr.io/org/tools-extra", false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, tt.req.Matches(tt.name), tt.name)
+	}
+}
+
+func TestCheckRisorVersion(t *testing.T) {
+	f := &File{Module: "app", Risor: "1.5"}
+	require.Nil(t, f.CheckRisorVersion("1.5.0"))
+	require.Nil(t, f.CheckRisorVersion("v1.6.2"))
+	require.Nil(t, f.CheckRisorVersion("2.0"))
+	require.Nil(t, f.CheckRisorVersion("dev"))
+	err := f.CheckRisorVersion("1.4.9")
+	require.NotNil(t, err)
+	require.Equal(t, "app requires risor 1.5 or newer (running 1.4.9)", err.Error())
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "cmd", "tool")
+	require.Nil(t, os.MkdirAll(nested, 0o755))
+	_, err := Find(nested)
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+
+	require.Nil(t, os.WriteFile(filepath.Join(dir, Name), []byte("module app\n"), 0o644))
+	path, err := Find(nested)
+	require.Nil(t, err)
+	require.Equal(t, filepath.Join(dir, Name), path)
+}
+
+func TestParseLock(t *testing.T) {
+	data := "# Generated by risor. Do not edit.\ngithub.com/org/lib@v1.2.0 " + sum + "\n"
+	lock, err := ParseLock(LockName, []byte(data))
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"github.com/org/lib@v1.2.0": sum}, lock.Sums)
+	require.Equal(t, data, string(lock.Format()))
+
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{"github.com/org/lib@v1", "risor.lock:1: expected a module and its checksum"},
+		{"github.com/org/lib@v1 md5:abc", `risor.lock:1: invalid checksum "md5:abc"`},
+		{"a " + sum + "\na " + sum, "risor.lock:2: duplicate checksum for a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			_, err := ParseLock(LockName, []byte(tt.data))
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package modfile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// LoadFunc returns the manifest of a required module at its version, or nil
+// if the module has none.
+type LoadFunc func(ctx context.Context, req Require) (*File, error)
+
+// ConflictError is returned by Resolve when a module is required at versions
+// that can't be reconciled.
+type ConflictError struct {
+	// The path of the module.
+	Path string
+
+	// The conflicting versions, and the modules requiring them.
+	Versions [2]string
+	By       [2]string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflicting versions of %s: %s required by %s, %s required by %s",
+		e.Path, e.Versions[0], e.By[0], e.Versions[1], e.By[1])
+}
+
+// Resolve selects the versions of the modules a project requires, directly
+// or through the manifests of the modules it requires, with minimal version
+// selection: each module is used at the highest version any manifest
+// requires of it, which is the oldest version that satisfies them all. The
+// requirements are returned sorted by path.
+//
+// Only release versions with the same major version, such as "v1.2.0" and
+// "v1.4.1", are reconciled this way. Requiring a module at any other pair of
+// versions, such as two branches, two checksums, or "v1.2.0" and "v2.0.0",
+// is a conflict, reported with a *ConflictError.
+func Resolve(ctx context.Context, project *File, load LoadFunc) ([]Require, error) {
+	type pending struct {
+		file *File
+		by   string
+	}
+	selected := map[string]Require{}
+	// The first module requiring each version, for reporting conflicts
+	requiredBy := map[Require]string{}
+	queue := []pending{{file: project, by: project.Module}}
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		for _, req := range item.file.Requires {
+			if _, ok := requiredBy[req]; ok {
+				continue
+			}
+			requiredBy[req] = item.by
+			current, ok := selected[req.Path]
+			if !ok {
+				selected[req.Path] = req
+			} else {
+				cmp, ok := compareCompatible(current.Version, req.Version)
+				if !ok {
+					return nil, &ConflictError{
+						Path:     req.Path,
+						Versions: [2]string{current.Version, req.Version},
+						By:       [2]string{requiredBy[current], item.by},
+					}
+				}
+				if cmp < 0 {
+					selected[req.Path] = req
+				}
+			}
+			dep, err := load(ctx, req)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the manifest of %s %s: %w", req.Path, req.Version, err)
+			}
+			if dep != nil {
+				queue = append(queue, pending{file: dep, by: req.Path + "@" + req.Version})
+			}
+		}
+	}
+	requires := make([]Require, 0, len(selected))
+	for _, req := range selected {
+		requires = append(requires, req)
+	}
+	sort.Slice(requires, func(a, b int) bool {
+		return requires[a].Path < requires[b].Path
+	})
+	return requires, nil
+}
+
+// IsUpgrade returns true if version b is a newer release than version a,
+// with the same major version, as chosen by Resolve.
+func IsUpgrade(a, b string) bool {
+	cmp, ok := compareCompatible(a, b)
+	return ok && cmp < 0
+}
+
+// compareCompatible compares two release versions with the same major
+// version, returning -1, 0, or +1. It returns false if either version isn't
+// a release, or if their major versions differ.
+func compareCompatible(a, b string) (int, bool) {
+	if a == b {
+		return 0, true
+	}
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, false
+	}
+	vb, err := parseVersion(b)
+	if err != nil || va[0] != vb[0] {
+		return 0, false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, true
+}
@@ -0,0 +1,89 @@
+package modfile
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// manifests returns a LoadFunc serving the given manifests, keyed by
+// "<path>@<version>".
+func manifests(files map[string]*File) LoadFunc {
+	return func(ctx context.Context, req Require) (*File, error) {
+		return files[req.Path+"@"+req.Version], nil
+	}
+}
+
+func TestResolve(t *testing.T) {
+	project := &File{
+		Module: "app",
+		Requires: []Require{
+			{Path: "github.com/org/a", Version: "v1.0.0"},
+			{Path: "github.com/org/b", Version: "v1.1.0"},
+		},
+	}
+	load := manifests(map[string]*File{
+		"github.com/org/a@v1.0.0": {Module: "github.com/org/a", Requires: []Require{
+			{Path: "github.com/org/shared", Version: "v1.2.0"},
+		}},
+		"github.com/org/b@v1.1.0": {Module: "github.com/org/b", Requires: []Require{
+			{Path: "github.com/org/shared", Version: "v1.4.1"},
+			{Path: "github.com/org/a", Version: "v1.0.0"},
+		}},
+		"github.com/org/shared@v1.4.1": {Module: "github.com/org/shared", Requires: []Require{
+			{Path: "github.com/org/leaf", Version: "v0.3.0"},
+		}},
+	})
+	requires, err := Resolve(context.Background(), project, load)
+	require.Nil(t, err)
+	require.Equal(t, []Require{
+		{Path: "github.com/org/a", Version: "v1.0.0"},
+		{Path: "github.com/org/b", Version: "v1.1.0"},
+		{Path: "github.com/org/leaf", Version: "v0.3.0"},
+		{Path: "github.com/org/shared", Version: "v1.4.1"},
+	}, requires)
+
+	// Without manifests, the project's requirements are used as they are
+	requires, err = Resolve(context.Background(), project, manifests(nil))
+	require.Nil(t, err)
+	require.Equal(t, project.Requires, requires)
+}
+
+func TestResolveConflicts(t *testing.T) {
+	tests := map[string]string{
+		"v2.0.0": "conflicting versions of github.com/org/shared: v1.2.0 required by app, v2.0.0 required by github.com/org/a@v1.0.0",
+		"main":   "conflicting versions of github.com/org/shared: v1.2.0 required by app, main required by github.com/org/a@v1.0.0",
+	}
+	for version, expected := range tests {
+		t.Run(version, func(t *testing.T) {
+			project := &File{
+				Module: "app",
+				Requires: []Require{
+					{Path: "github.com/org/a", Version: "v1.0.0"},
+					{Path: "github.com/org/shared", Version: "v1.2.0"},
+				},
+			}
+			load := manifests(map[string]*File{
+				"github.com/org/a@v1.0.0": {Module: "github.com/org/a", Requires: []Require{
+					{Path: "github.com/org/shared", Version: version},
+				}},
+			})
+			_, err := Resolve(context.Background(), project, load)
+			var conflict *ConflictError
+			require.True(t, errors.As(err, &conflict))
+			require.Equal(t, "github.com/org/shared", conflict.Path)
+			require.EqualError(t, err, expected)
+		})
+	}
+}
+
+func TestIsUpgrade(t *testing.T) {
+	require.True(t, IsUpgrade("v1.2.0", "v1.3.0"))
+	require.True(t, IsUpgrade("v1.2", "v1.2.1"))
+	require.False(t, IsUpgrade("v1.3.0", "v1.2.0"))
+	require.False(t, IsUpgrade("v1.2.0", "v1.2.0"))
+	require.False(t, IsUpgrade("v1.2.0", "v2.0.0"))
+	require.False(t, IsUpgrade("main", "v1.2.0"))
+}
@@ -2,43 +2,81 @@ package all
 
 import (
 	"github.com/risor-io/risor/builtins"
+	modArchive "github.com/risor-io/risor/modules/archive"
 	modBase64 "github.com/risor-io/risor/modules/base64"
 	modBytes "github.com/risor-io/risor/modules/bytes"
+	modCron "github.com/risor-io/risor/modules/cron"
+	modCsv "github.com/risor-io/risor/modules/csv"
+	modDns "github.com/risor-io/risor/modules/dns"
+	modEmail "github.com/risor-io/risor/modules/email"
 	modExec "github.com/risor-io/risor/modules/exec"
 	modFilepath "github.com/risor-io/risor/modules/filepath"
 	modFmt "github.com/risor-io/risor/modules/fmt"
+	modFuzzy "github.com/risor-io/risor/modules/fuzzy"
+	modGeo "github.com/risor-io/risor/modules/geo"
 	modGha "github.com/risor-io/risor/modules/gha"
 	modHTTP "github.com/risor-io/risor/modules/http"
+	modIds "github.com/risor-io/risor/modules/ids"
 	modJSON "github.com/risor-io/risor/modules/json"
+	modLog "github.com/risor-io/risor/modules/log"
 	modMath "github.com/risor-io/risor/modules/math"
+	modNet "github.com/risor-io/risor/modules/net"
 	modOs "github.com/risor-io/risor/modules/os"
 	modRand "github.com/risor-io/risor/modules/rand"
+	modRandom "github.com/risor-io/risor/modules/random"
+	modRatelimit "github.com/risor-io/risor/modules/ratelimit"
 	modRegexp "github.com/risor-io/risor/modules/regexp"
+	modResult "github.com/risor-io/risor/modules/result"
+	modRetry "github.com/risor-io/risor/modules/retry"
+	modRuntime "github.com/risor-io/risor/modules/runtime"
+	modShlex "github.com/risor-io/risor/modules/shlex"
+	modSketch "github.com/risor-io/risor/modules/sketch"
+	modStats "github.com/risor-io/risor/modules/stats"
 	modStrconv "github.com/risor-io/risor/modules/strconv"
 	modStrings "github.com/risor-io/risor/modules/strings"
 	modTime "github.com/risor-io/risor/modules/time"
+	modTLS "github.com/risor-io/risor/modules/tls"
 	modYAML "github.com/risor-io/risor/modules/yaml"
 	"github.com/risor-io/risor/object"
 )
 
 func Builtins() map[string]object.Object {
 	result := map[string]object.Object{
-		"base64":   modBase64.Module(),
-		"bytes":    modBytes.Module(),
-		"exec":     modExec.Module(),
-		"filepath": modFilepath.Module(),
-		"fmt":      modFmt.Module(),
-		"gha":      modGha.Module(),
-		"http":     modHTTP.Module(),
-		"json":     modJSON.Module(),
-		"math":     modMath.Module(),
-		"os":       modOs.Module(),
-		"rand":     modRand.Module(),
-		"regexp":   modRegexp.Module(),
-		"strconv":  modStrconv.Module(),
-		"strings":  modStrings.Module(),
-		"time":     modTime.Module(),
-		"yaml":     modYAML.Module(),
+		"archive":   modArchive.Module(),
+		"base64":    modBase64.Module(),
+		"bytes":     modBytes.Module(),
+		"cron":      modCron.Module(),
+		"csv":       modCsv.Module(),
+		"dns":       modDns.Module(),
+		"email":     modEmail.Module(),
+		"exec":      modExec.Module(),
+		"filepath":  modFilepath.Module(),
+		"fmt":       modFmt.Module(),
+		"fuzzy":     modFuzzy.Module(),
+		"geo":       modGeo.Module(),
+		"gha":       modGha.Module(),
+		"http":      modHTTP.Module(),
+		"ids":       modIds.Module(),
+		"json":      modJSON.Module(),
+		"log":       modLog.Module(),
+		"math":      modMath.Module(),
+		"net":       modNet.Module(),
+		"os":        modOs.Module(),
+		"rand":      modRand.Module(),
+		"random":    modRandom.Module(),
+		"ratelimit": modRatelimit.Module(),
+		"regexp":    modRegexp.Module(),
+		"result":    modResult.Module(),
+		"retry":     modRetry.Module(),
+		"runtime":   modRuntime.Module(),
+		"shlex":     modShlex.Module(),
+		"sketch":    modSketch.Module(),
+		"stats":     modStats.Module(),
+		"strconv":   modStrconv.Module(),
+		"strings":   modStrings.Module(),
+		"time":      modTime.Module(),
+		"tls":       modTLS.Module(),
+		"yaml":      modYAML.Module(),
 	}
 	for k, v := range modHTTP.Builtins() {
 		result[k] = v
@@ -0,0 +1,440 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+)
+
+// filter selects archive entries using glob patterns. A pattern matches an
+// entry if it matches the entry's full name, its base name, or one of its
+// parent directories.
+type filter struct {
+	include []string
+	exclude []string
+}
+
+func matchPattern(pattern, name string) bool {
+	name = strings.Trim(name, "/")
+	for name != "." && name != "" {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return true
+		}
+		name = path.Dir(name)
+	}
+	return false
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *filter) excluded(name string) bool {
+	return matchAny(f.exclude, name)
+}
+
+func (f *filter) match(name string) bool {
+	if len(f.include) > 0 && !matchAny(f.include, name) {
+		return false
+	}
+	return !f.excluded(name)
+}
+
+func asPatterns(obj object.Object) ([]string, *object.Error) {
+	var patterns []string
+	if s, ok := obj.(*object.String); ok {
+		patterns = []string{s.Value()}
+	} else {
+		var err *object.Error
+		if patterns, err = object.AsStringSlice(obj); err != nil {
+			return nil, err
+		}
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, object.Errorf("value error: invalid pattern %q", pattern)
+		}
+	}
+	return patterns, nil
+}
+
+type options struct {
+	format          string
+	dir             string
+	stripComponents int
+	filter          filter
+}
+
+// parseOptions parses the options map, if one is given, accepting only the
+// named keys.
+func parseOptions(fn string, args []object.Object, allowed ...string) (*options, *object.Error) {
+	opts := &options{}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range m.Value() {
+		found := false
+		for _, name := range allowed {
+			if key == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		switch key {
+		case "format":
+			if opts.format, err = object.AsString(value); err != nil {
+				return nil, err
+			}
+			if err := checkFormat(opts.format); err != nil {
+				return nil, err
+			}
+		case "dir":
+			if opts.dir, err = object.AsString(value); err != nil {
+				return nil, err
+			}
+		case "strip_components":
+			n, err := object.AsInt(value)
+			if err != nil {
+				return nil, err
+			}
+			if n < 0 {
+				return nil, object.Errorf("value error: strip_components must not be negative (got %d)", n)
+			}
+			opts.stripComponents = int(n)
+		case "include":
+			if opts.filter.include, err = asPatterns(value); err != nil {
+				return nil, err
+			}
+		case "exclude":
+			if opts.filter.exclude, err = asPatterns(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return opts, nil
+}
+
+// cleanName returns the cleaned name of an entry, or an error if the entry
+// would be written outside of the destination directory.
+func cleanName(name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(cleaned) || filepath.IsAbs(name) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("archive error: entry %q is outside the destination", name)
+	}
+	return cleaned, nil
+}
+
+// stripComponents removes the given number of leading path elements from a
+// name. It returns an empty string if nothing remains.
+func stripComponents(name string, n int) string {
+	for i := 0; i < n; i++ {
+		idx := strings.Index(name, "/")
+		if idx < 0 {
+			return ""
+		}
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// Create writes an archive containing the given files and directories.
+func Create(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("archive.create", 2, 3, args); err != nil {
+		return err
+	}
+	var sources []string
+	if s, ok := args[1].(*object.String); ok {
+		sources = []string{s.Value()}
+	} else {
+		var err *object.Error
+		if sources, err = object.AsStringSlice(args[1]); err != nil {
+			return err
+		}
+	}
+	opts, err := parseOptions("archive.create", args[2:], "format", "dir", "include", "exclude")
+	if err != nil {
+		return err
+	}
+	w, err := newWriterFor(ctx, args[0], opts.format)
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		filePath, name := source, filepath.Base(source)
+		if opts.dir != "" {
+			filePath = filepath.Join(opts.dir, source)
+			name = filepath.Clean(source)
+		}
+		if err := w.AddFile(ctx, filePath, filepath.ToSlash(name), &opts.filter); err != nil {
+			w.Close()
+			return object.NewError(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return object.NewError(err)
+	}
+	return object.NewInt(w.Count())
+}
+
+// Extract writes the entries of an archive into a destination directory and
+// returns the names of the extracted entries.
+func Extract(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("archive.extract", 2, 3, args); err != nil {
+		return err
+	}
+	dest, err := object.AsString(args[1])
+	if err != nil {
+		return err
+	}
+	opts, err := parseOptions("archive.extract", args[2:], "format", "include", "exclude", "strip_components")
+	if err != nil {
+		return err
+	}
+	r, openErr := openArchive(ctx, args[0], opts.format)
+	if openErr != nil {
+		return object.NewError(openErr)
+	}
+	defer r.Close()
+	names, extractErr := extract(ctx, r, dest, opts)
+	if extractErr != nil {
+		return object.NewError(extractErr)
+	}
+	return object.NewStringList(names)
+}
+
+func extract(ctx context.Context, r entryReader, dest string, opts *options) ([]string, error) {
+	vos := ros.GetDefaultOS(ctx)
+	if err := vos.MkdirAll(dest, 0755); err != nil {
+		return nil, err
+	}
+	// Symlinks are created as they are read, so entries must not be written
+	// through a symlink created earlier in the same archive.
+	links := map[string]bool{}
+	throughLink := func(name string) bool {
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if links[dir] {
+				return true
+			}
+		}
+		return false
+	}
+	names := []string{}
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		name, err := cleanName(e.name)
+		if err != nil {
+			return nil, err
+		}
+		if name = stripComponents(name, opts.stripComponents); name == "" || name == "." {
+			continue
+		}
+		if !opts.filter.match(name) {
+			continue
+		}
+		if throughLink(name) {
+			return nil, fmt.Errorf("archive error: entry %q is inside a symlink", e.name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+		switch {
+		case e.isDir():
+			if err := vos.MkdirAll(target, 0755); err != nil {
+				return nil, err
+			}
+		case e.isSymlink():
+			// Link targets are resolved relative to the entry's directory
+			resolved := path.Join(path.Dir(name), filepath.ToSlash(e.link))
+			if _, err := cleanName(resolved); err != nil || path.IsAbs(e.link) || filepath.IsAbs(e.link) {
+				return nil, fmt.Errorf("archive error: symlink %q points outside the destination", e.name)
+			}
+			if err := vos.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return nil, err
+			}
+			if err := vos.Symlink(e.link, target); err != nil {
+				return nil, err
+			}
+			links[name] = true
+		case e.hardlink:
+			// Hard links name another entry in the archive, which is copied
+			linked, err := cleanName(e.link)
+			if err != nil {
+				return nil, fmt.Errorf("archive error: link %q points outside the destination", e.name)
+			}
+			if linked = stripComponents(linked, opts.stripComponents); linked == "" || throughLink(linked) {
+				return nil, fmt.Errorf("archive error: link %q points outside the destination", e.name)
+			}
+			data, err := vos.ReadFile(filepath.Join(dest, filepath.FromSlash(linked)))
+			if err != nil {
+				return nil, err
+			}
+			if err := vos.WriteFile(target, data, e.mode.Perm()); err != nil {
+				return nil, err
+			}
+		default:
+			if err := writeEntry(vos, e, target); err != nil {
+				return nil, err
+			}
+		}
+		names = append(names, name)
+	}
+}
+
+func writeEntry(vos ros.OS, e *entry, target string) error {
+	if err := vos.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	perm := e.mode.Perm()
+	if perm == 0 {
+		perm = 0644
+	}
+	f, err := vos.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	src, err := e.open()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// List returns information about the entries of an archive.
+func List(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("archive.list", 1, 2, args); err != nil {
+		return err
+	}
+	opts, err := parseOptions("archive.list", args[1:], "format", "include", "exclude")
+	if err != nil {
+		return err
+	}
+	r, openErr := openArchive(ctx, args[0], opts.format)
+	if openErr != nil {
+		return object.NewError(openErr)
+	}
+	defer r.Close()
+	items := []object.Object{}
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return object.NewList(items)
+		}
+		if err != nil {
+			return object.NewError(err)
+		}
+		if opts.filter.match(e.name) {
+			items = append(items, e.toMap())
+		}
+	}
+}
+
+// Entries returns a stream of the entries of an archive, including their
+// content. The archive is read as the stream is consumed.
+func Entries(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("archive.entries", 1, 2, args); err != nil {
+		return err
+	}
+	opts, err := parseOptions("archive.entries", args[1:], "format", "include", "exclude")
+	if err != nil {
+		return err
+	}
+	r, openErr := openArchive(ctx, args[0], opts.format)
+	if openErr != nil {
+		return object.NewError(openErr)
+	}
+	done := false
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		if done {
+			return nil, false, nil
+		}
+		for {
+			e, err := r.Next()
+			if err != nil {
+				done = true
+				r.Close()
+				if err == io.EOF {
+					return nil, false, nil
+				}
+				return nil, false, err
+			}
+			if !opts.filter.match(e.name) {
+				continue
+			}
+			m := e.toMap()
+			var data []byte
+			if !e.isDir() && !e.isSymlink() && !e.hardlink {
+				src, err := e.open()
+				if err != nil {
+					done = true
+					r.Close()
+					return nil, false, err
+				}
+				if data, err = io.ReadAll(src); err != nil {
+					done = true
+					r.Close()
+					return nil, false, err
+				}
+			}
+			m.Set("data", object.NewByteSlice(data))
+			return m, true, nil
+		}
+	})
+}
+
+// NewArchiveWriter returns an archive.writer that writes to a path or writer.
+func NewArchiveWriter(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("archive.writer", 1, 2, args); err != nil {
+		return err
+	}
+	opts, err := parseOptions("archive.writer", args[1:], "format")
+	if err != nil {
+		return err
+	}
+	w, err := newWriterFor(ctx, args[0], opts.format)
+	if err != nil {
+		return err
+	}
+	return w
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("archive", map[string]object.Object{
+		"create":  object.NewBuiltin("create", Create),
+		"entries": object.NewBuiltin("entries", Entries),
+		"extract": object.NewBuiltin("extract", Extract),
+		"list":    object.NewBuiltin("list", List),
+		"writer":  object.NewBuiltin("writer", NewArchiveWriter),
+	})
+}
@@ -0,0 +1,200 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.Nil(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.Nil(t, os.WriteFile(p, []byte(content), 0644))
+	}
+	return dir
+}
+
+func listNames(t *testing.T, src object.Object) []string {
+	t.Helper()
+	result := List(context.Background(), src)
+	list, ok := result.(*object.List)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	var names []string
+	for _, item := range list.Value() {
+		name, _ := item.(*object.Map).Get("name").(*object.String)
+		names = append(names, name.Value())
+	}
+	return names
+}
+
+func TestCreateAndExtract(t *testing.T) {
+	ctx := context.Background()
+	src := writeTree(t, map[string]string{
+		"app/main.go":              "package main",
+		"app/README.md":            "# app",
+		"app/node_modules/x/x.js":  "x",
+		"app/internal/util.go":     "package internal",
+		"app/internal/util.go.bak": "old",
+	})
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		t.Run(ext, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "backup"+ext)
+			result := Create(ctx, object.NewString(dest), object.NewString("app"), object.NewMap(map[string]object.Object{
+				"dir":     object.NewString(src),
+				"exclude": object.NewStringList([]string{"node_modules", "*.bak"}),
+			}))
+			require.Equal(t, object.NewInt(5), result)
+
+			names := listNames(t, object.NewString(dest))
+			sort.Strings(names)
+			require.Equal(t, []string{"app/", "app/README.md", "app/internal/", "app/internal/util.go", "app/main.go"}, names)
+
+			out := t.TempDir()
+			result = Extract(ctx, object.NewString(dest), object.NewString(out), object.NewMap(map[string]object.Object{
+				"include":          object.NewString("*.go"),
+				"strip_components": object.NewInt(1),
+			}))
+			extracted, ok := result.(*object.List)
+			require.True(t, ok, "unexpected result: %s", result.Inspect())
+			require.Len(t, extracted.Value(), 2)
+			data, err := os.ReadFile(filepath.Join(out, "internal", "util.go"))
+			require.Nil(t, err)
+			require.Equal(t, "package internal", string(data))
+			_, err = os.Stat(filepath.Join(out, "README.md"))
+			require.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestWriterAndEntries(t *testing.T) {
+	ctx := context.Background()
+	buf := object.NewBuffer(nil)
+	result := NewArchiveWriter(ctx, buf, object.NewMap(map[string]object.Object{"format": object.NewString("tar.gz")}))
+	w, ok := result.(*Writer)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	add, _ := w.GetAttr("add")
+	require.Equal(t, object.Nil, add.(*object.Builtin).Call(ctx, object.NewString("bin/run.sh"), object.NewString("#!/bin/sh\n"),
+		object.NewMap(map[string]object.Object{"mode": object.NewInt(0755), "mod_time": object.NewTime(modTime)})))
+	addDir, _ := w.GetAttr("add_dir")
+	require.Equal(t, object.Nil, addDir.(*object.Builtin).Call(ctx, object.NewString("empty")))
+	closeFn, _ := w.GetAttr("close")
+	require.Equal(t, object.Nil, closeFn.(*object.Builtin).Call(ctx))
+	count, _ := w.GetAttr("count")
+	require.Equal(t, object.NewInt(2), count)
+
+	// The format is detected from the content
+	data := object.NewByteSlice(buf.Value().Bytes())
+	stream, ok := Entries(ctx, data).(*object.Stream)
+	require.True(t, ok)
+	collected, ok := stream.Collect(ctx).(*object.List)
+	require.True(t, ok)
+	entries := collected.Value()
+	require.Len(t, entries, 2)
+	first := entries[0].(*object.Map)
+	require.Equal(t, object.NewString("bin/run.sh"), first.Get("name"))
+	require.Equal(t, object.NewInt(0755), first.Get("mode"))
+	require.Equal(t, object.NewByteSlice([]byte("#!/bin/sh\n")), first.Get("data"))
+	require.True(t, first.Get("mod_time").(*object.Time).Value().Equal(modTime))
+	second := entries[1].(*object.Map)
+	require.Equal(t, object.NewString("empty/"), second.Get("name"))
+	require.Equal(t, object.True, second.Get("is_dir"))
+}
+
+func TestExtractRejectsTraversal(t *testing.T) {
+	ctx := context.Background()
+
+	var zipData bytes.Buffer
+	zw := zip.NewWriter(&zipData)
+	fw, err := zw.Create("../evil.txt")
+	require.Nil(t, err)
+	fw.Write([]byte("evil"))
+	require.Nil(t, zw.Close())
+
+	tarWith := func(headers ...*tar.Header) []byte {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, hdr := range headers {
+			require.Nil(t, tw.WriteHeader(hdr))
+			if hdr.Size > 0 {
+				tw.Write(bytes.Repeat([]byte("x"), int(hdr.Size)))
+			}
+		}
+		require.Nil(t, tw.Close())
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"zip parent", zipData.Bytes(), `archive error: entry "../evil.txt" is outside the destination`},
+		{"absolute", tarWith(&tar.Header{Name: "/etc/evil", Typeflag: tar.TypeReg, Size: 1, Mode: 0644}),
+			`archive error: entry "/etc/evil" is outside the destination`},
+		{"symlink target", tarWith(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../../etc"}),
+			`archive error: symlink "link" points outside the destination`},
+		{"through symlink", tarWith(
+			&tar.Header{Name: "dir", Typeflag: tar.TypeSymlink, Linkname: "."},
+			&tar.Header{Name: "dir/file", Typeflag: tar.TypeReg, Size: 1, Mode: 0644},
+		), `archive error: entry "dir/file" is inside a symlink`},
+		{"hard link", tarWith(&tar.Header{Name: "passwd", Typeflag: tar.TypeLink, Linkname: "../../etc/passwd"}),
+			`archive error: link "passwd" points outside the destination`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := t.TempDir()
+			result := Extract(ctx, object.NewByteSlice(tt.data), object.NewString(dest))
+			errObj, ok := result.(*object.Error)
+			require.True(t, ok, "unexpected result: %s", result.Inspect())
+			require.Equal(t, tt.err, errObj.Message().Value())
+		})
+	}
+}
+
+func TestOptionErrors(t *testing.T) {
+	ctx := context.Background()
+	result := Create(ctx, object.NewString("out.rar"), object.NewString("src"))
+	require.Equal(t, `value error: unable to determine archive format of "out.rar"`, result.(*object.Error).Message().Value())
+
+	result = List(ctx, object.NewString("x.zip"), object.NewMap(map[string]object.Object{"format": object.NewString("7z")}))
+	require.Equal(t, `value error: archive format must be "zip", "tar", or "tar.gz" (got "7z")`, result.(*object.Error).Message().Value())
+
+	result = List(ctx, object.NewString("x.zip"), object.NewMap(map[string]object.Object{"include": object.NewString("[")}))
+	require.Equal(t, `value error: invalid pattern "["`, result.(*object.Error).Message().Value())
+
+	result = List(ctx, object.NewString("x.zip"), object.NewMap(map[string]object.Object{"dir": object.NewString(".")}))
+	require.Equal(t, `value error: unknown archive.list option "dir"`, result.(*object.Error).Message().Value())
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/util.go", true},
+		{"pkg/*.go", "pkg/util.go", true},
+		{"pkg/*.go", "other/util.go", false},
+		{"node_modules", "app/node_modules/x/x.js", true},
+		{"app", "app/", true},
+		{"*.md", "docs/", false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, matchPattern(tt.pattern, tt.name), "%s %s", tt.pattern, tt.name)
+	}
+}
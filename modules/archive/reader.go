@@ -0,0 +1,255 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+)
+
+const (
+	formatZip   = "zip"
+	formatTar   = "tar"
+	formatTarGz = "tar.gz"
+)
+
+// entry is a file, directory, or link within an archive.
+type entry struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+	link    string
+	// hardlink is set for tar hard links, whose link is the name of
+	// another entry in the archive
+	hardlink bool
+	open     func() (io.Reader, error)
+}
+
+func (e *entry) isDir() bool {
+	return e.mode.IsDir()
+}
+
+func (e *entry) isSymlink() bool {
+	return e.mode&fs.ModeSymlink != 0
+}
+
+func (e *entry) toMap() *object.Map {
+	return object.NewMap(map[string]object.Object{
+		"name":       object.NewString(e.name),
+		"size":       object.NewInt(e.size),
+		"mode":       object.NewInt(int64(e.mode.Perm())),
+		"mod_time":   object.NewTime(e.modTime),
+		"is_dir":     object.NewBool(e.isDir()),
+		"is_symlink": object.NewBool(e.isSymlink()),
+		"link":       object.NewString(e.link),
+	})
+}
+
+// entryReader iterates over the entries of an archive. Next returns io.EOF
+// once all entries have been read.
+type entryReader interface {
+	Next() (*entry, error)
+	Close() error
+}
+
+// formatFromName returns the archive format implied by a file name, or an
+// empty string if it is not recognized.
+func formatFromName(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return formatZip
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return formatTarGz
+	case strings.HasSuffix(name, ".tar"):
+		return formatTar
+	}
+	return ""
+}
+
+func checkFormat(format string) *object.Error {
+	switch format {
+	case formatZip, formatTar, formatTarGz:
+		return nil
+	}
+	return object.Errorf("value error: archive format must be \"zip\", \"tar\", or \"tar.gz\" (got %q)", format)
+}
+
+// openArchive opens the archive at a path or contained in a byte_slice or
+// reader. The format is detected from the content if it is not given.
+func openArchive(ctx context.Context, src object.Object, format string) (entryReader, error) {
+	var r io.Reader
+	var closer io.Closer
+	switch src := src.(type) {
+	case *object.String:
+		f, err := ros.GetDefaultOS(ctx).Open(src.Value())
+		if err != nil {
+			return nil, err
+		}
+		r, closer = f, f
+	case *object.ByteSlice:
+		r = bytes.NewReader(src.Value())
+	default:
+		var errObj *object.Error
+		if r, errObj = object.AsReader(src); errObj != nil {
+			return nil, errObj.Value()
+		}
+	}
+	br := bufio.NewReader(r)
+	if format == "" {
+		magic, _ := br.Peek(4)
+		switch {
+		case bytes.HasPrefix(magic, []byte("PK\x03\x04")), bytes.HasPrefix(magic, []byte("PK\x05\x06")):
+			format = formatZip
+		case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+			format = formatTarGz
+		default:
+			format = formatTar
+		}
+	}
+	reader, err := newEntryReader(br, format)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
+	}
+	if closer != nil {
+		return &closingReader{entryReader: reader, closer: closer}, nil
+	}
+	return reader, nil
+}
+
+func newEntryReader(r io.Reader, format string) (entryReader, error) {
+	switch format {
+	case formatZip:
+		// Zip archives are indexed at the end, so the content is buffered
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("archive error: %w", err)
+		}
+		return &zipReader{files: zr.File}, nil
+	case formatTarGz:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("archive error: %w", err)
+		}
+		return &tarReader{tr: tar.NewReader(gz), closer: gz}, nil
+	case formatTar:
+		return &tarReader{tr: tar.NewReader(r)}, nil
+	}
+	return nil, fmt.Errorf("archive error: unsupported format %q", format)
+}
+
+type closingReader struct {
+	entryReader
+	closer io.Closer
+}
+
+func (r *closingReader) Close() error {
+	err := r.entryReader.Close()
+	if closeErr := r.closer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type zipReader struct {
+	files []*zip.File
+	pos   int
+}
+
+func (z *zipReader) Next() (*entry, error) {
+	if z.pos >= len(z.files) {
+		return nil, io.EOF
+	}
+	f := z.files[z.pos]
+	z.pos++
+	e := &entry{
+		name:    f.Name,
+		size:    int64(f.UncompressedSize64),
+		mode:    f.Mode(),
+		modTime: f.Modified,
+	}
+	var rc io.ReadCloser
+	e.open = func() (io.Reader, error) {
+		if rc != nil {
+			rc.Close()
+		}
+		var err error
+		rc, err = f.Open()
+		return rc, err
+	}
+	if e.isSymlink() {
+		r, err := e.open()
+		if err != nil {
+			return nil, err
+		}
+		target, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		e.link = string(target)
+	}
+	return e, nil
+}
+
+func (z *zipReader) Close() error {
+	return nil
+}
+
+type tarReader struct {
+	tr     *tar.Reader
+	closer io.Closer
+}
+
+func (t *tarReader) Next() (*entry, error) {
+	for {
+		hdr, err := t.tr.Next()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("archive error: %w", err)
+		}
+		e := &entry{
+			name:    hdr.Name,
+			size:    hdr.Size,
+			mode:    hdr.FileInfo().Mode(),
+			modTime: hdr.ModTime,
+			link:    hdr.Linkname,
+			open:    func() (io.Reader, error) { return t.tr, nil },
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeDir, tar.TypeSymlink:
+		case tar.TypeLink:
+			e.hardlink = true
+		default:
+			// Device files, fifos, and extended headers are skipped
+			continue
+		}
+		return e, nil
+	}
+}
+
+func (t *tarReader) Close() error {
+	if t.closer != nil {
+		return t.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+)
+
+const WRITER object.Type = "archive.writer"
+
+// Writer adds entries to a zip or tar archive.
+type Writer struct {
+	format string
+	zw     *zip.Writer
+	tw     *tar.Writer
+	gz     *gzip.Writer
+	closer io.Closer
+	count  int64
+	closed bool
+}
+
+// NewWriter returns a Writer that writes an archive in the given format to w.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	aw := &Writer{format: format}
+	switch format {
+	case formatZip:
+		aw.zw = zip.NewWriter(w)
+	case formatTarGz:
+		aw.gz = gzip.NewWriter(w)
+		aw.tw = tar.NewWriter(aw.gz)
+	case formatTar:
+		aw.tw = tar.NewWriter(w)
+	default:
+		return nil, fmt.Errorf("archive error: unsupported format %q", format)
+	}
+	return aw, nil
+}
+
+// Add writes an entry to the archive. The content is ignored for directories
+// and symlinks.
+func (w *Writer) Add(name string, mode fs.FileMode, modTime time.Time, link string, content []byte) error {
+	if w.closed {
+		return errors.New("archive error: writer is closed")
+	}
+	name = strings.TrimPrefix(path.Clean(strings.ReplaceAll(name, "\\", "/")), "/")
+	if name == "." || name == "" {
+		return errors.New("value error: archive entry name must not be empty")
+	}
+	isDir := mode.IsDir()
+	isLink := mode&fs.ModeSymlink != 0
+	if isDir {
+		name += "/"
+	}
+	if w.zw != nil {
+		hdr := &zip.FileHeader{Name: name, Modified: modTime, Method: zip.Deflate}
+		hdr.SetMode(mode)
+		if isDir {
+			hdr.Method = zip.Store
+		}
+		fw, err := w.zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if isLink {
+			content = []byte(link)
+		}
+		if !isDir {
+			if _, err := fw.Write(content); err != nil {
+				return err
+			}
+		}
+	} else {
+		hdr := &tar.Header{Name: name, Mode: int64(mode.Perm()), ModTime: modTime, Format: tar.FormatPAX}
+		switch {
+		case isDir:
+			hdr.Typeflag = tar.TypeDir
+		case isLink:
+			hdr.Typeflag = tar.TypeSymlink
+			hdr.Linkname = link
+		default:
+			hdr.Typeflag = tar.TypeReg
+			hdr.Size = int64(len(content))
+		}
+		if err := w.tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			if _, err := w.tw.Write(content); err != nil {
+				return err
+			}
+		}
+	}
+	w.count++
+	return nil
+}
+
+// AddFile adds the file or directory at the given path to the archive under
+// the given name. Directories are added recursively. Only entries accepted by
+// the filter are added.
+func (w *Writer) AddFile(ctx context.Context, filePath, name string, f *filter) error {
+	vos := ros.GetDefaultOS(ctx)
+	info, err := vos.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if !f.match(name) {
+			return nil
+		}
+		data, err := vos.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		return w.Add(name, info.Mode(), info.ModTime(), "", data)
+	}
+	return vos.WalkDir(filePath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, filePath), "/")
+		entryName := path.Join(name, rel)
+		if entryName == "" || entryName == "." {
+			return nil
+		}
+		if !f.match(entryName) {
+			if d.IsDir() && f.excluded(entryName) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			// Symlinked files are archived with their content, and
+			// symlinked directories are not followed
+			if info, err = vos.Stat(p); err != nil || info.IsDir() {
+				return nil
+			}
+		}
+		if d.IsDir() {
+			return w.Add(entryName, info.Mode(), info.ModTime(), "", nil)
+		}
+		data, err := vos.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return w.Add(entryName, info.Mode(), info.ModTime(), "", data)
+	})
+}
+
+// Close finishes writing the archive.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	var err error
+	if w.zw != nil {
+		err = w.zw.Close()
+	} else {
+		err = w.tw.Close()
+		if w.gz != nil {
+			if gzErr := w.gz.Close(); err == nil {
+				err = gzErr
+			}
+		}
+	}
+	if w.closer != nil {
+		if closeErr := w.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Count returns the number of entries written to the archive.
+func (w *Writer) Count() int64 {
+	return w.count
+}
+
+func (w *Writer) Type() object.Type {
+	return WRITER
+}
+
+func (w *Writer) Inspect() string {
+	return fmt.Sprintf("archive.writer(format=%q, count=%d)", w.format, w.count)
+}
+
+func (w *Writer) Interface() interface{} {
+	return nil
+}
+
+func (w *Writer) Equals(other object.Object) object.Object {
+	return object.NewBool(w == other)
+}
+
+func (w *Writer) IsTruthy() bool {
+	return true
+}
+
+func (w *Writer) Cost() int {
+	return 0
+}
+
+func (w *Writer) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: archive.writer object has no attribute %q", name)
+}
+
+func (w *Writer) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for archive.writer: %v", opType)
+}
+
+func (w *Writer) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("type error: unable to marshal archive.writer")
+}
+
+func (w *Writer) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "format":
+		return object.NewString(w.format), true
+	case "count":
+		return object.NewInt(w.count), true
+	case "add":
+		return object.NewBuiltin("archive.writer.add", w.add), true
+	case "add_dir":
+		return object.NewBuiltin("archive.writer.add_dir", w.addDir), true
+	case "add_file":
+		return object.NewBuiltin("archive.writer.add_file", w.addFile), true
+	case "close":
+		return object.NewBuiltin("archive.writer.close", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("archive.writer.close", 0, args); err != nil {
+				return err
+			}
+			if err := w.Close(); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// entryOptions parses the mode and mod_time options of an added entry.
+func entryOptions(name string, args []object.Object, mode fs.FileMode) (fs.FileMode, time.Time, *object.Error) {
+	modTime := time.Now()
+	if len(args) == 0 {
+		return mode, modTime, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return 0, modTime, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "mode":
+			perm, err := object.AsInt(value)
+			if err != nil {
+				return 0, modTime, err
+			}
+			mode = mode.Type() | fs.FileMode(perm).Perm()
+		case "mod_time":
+			if modTime, err = object.AsTime(value); err != nil {
+				return 0, modTime, err
+			}
+		default:
+			return 0, modTime, object.Errorf("value error: unknown %s option %q", name, key)
+		}
+	}
+	return mode, modTime, nil
+}
+
+func (w *Writer) add(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("archive.writer.add", 2, 3, args); err != nil {
+		return err
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	content, err := object.AsBytes(args[1])
+	if err != nil {
+		return err
+	}
+	mode, modTime, err := entryOptions("archive.writer.add", args[2:], 0o644)
+	if err != nil {
+		return err
+	}
+	if err := w.Add(name, mode, modTime, "", content); err != nil {
+		return object.NewError(err)
+	}
+	return object.Nil
+}
+
+func (w *Writer) addDir(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("archive.writer.add_dir", 1, 2, args); err != nil {
+		return err
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	mode, modTime, err := entryOptions("archive.writer.add_dir", args[1:], fs.ModeDir|0o755)
+	if err != nil {
+		return err
+	}
+	if err := w.Add(name, mode, modTime, "", nil); err != nil {
+		return object.NewError(err)
+	}
+	return object.Nil
+}
+
+func (w *Writer) addFile(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("archive.writer.add_file", 1, 2, args); err != nil {
+		return err
+	}
+	filePath, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	name := path.Base(filePath)
+	f := &filter{}
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "name":
+				if name, err = object.AsString(value); err != nil {
+					return err
+				}
+			case "include":
+				if f.include, err = asPatterns(value); err != nil {
+					return err
+				}
+			case "exclude":
+				if f.exclude, err = asPatterns(value); err != nil {
+					return err
+				}
+			default:
+				return object.Errorf("value error: unknown archive.writer.add_file option %q", key)
+			}
+		}
+	}
+	if err := w.AddFile(ctx, filePath, name, f); err != nil {
+		return object.NewError(err)
+	}
+	return object.Nil
+}
+
+// newWriterFor creates a Writer for a destination path or writer object.
+func newWriterFor(ctx context.Context, dest object.Object, format string) (*Writer, *object.Error) {
+	var w io.Writer
+	var closer io.Closer
+	switch dest := dest.(type) {
+	case *object.String:
+		if format == "" {
+			format = formatFromName(dest.Value())
+		}
+		if format == "" {
+			return nil, object.Errorf("value error: unable to determine archive format of %q", dest.Value())
+		}
+		if err := checkFormat(format); err != nil {
+			return nil, err
+		}
+		f, err := ros.GetDefaultOS(ctx).Create(dest.Value())
+		if err != nil {
+			return nil, object.NewError(err)
+		}
+		w, closer = f, f
+	default:
+		var err *object.Error
+		if w, err = object.AsWriter(dest); err != nil {
+			return nil, err
+		}
+	}
+	if format == "" {
+		format = formatZip
+	}
+	if err := checkFormat(format); err != nil {
+		return nil, err
+	}
+	aw, err := NewWriter(w, format)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, object.NewError(err)
+	}
+	// Files created by the writer are closed along with it
+	aw.closer = closer
+	return aw, nil
+}
@@ -0,0 +1,131 @@
+package azure
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/risor-io/risor/object"
+)
+
+// credentialOptions holds the options that select how to authenticate with
+// Microsoft Entra ID.
+type credentialOptions struct {
+	tenantID        string
+	clientID        string
+	clientSecret    string
+	managedIdentity bool
+}
+
+// parse sets the credential option with the given name,
+// returning false if it isn't one.
+func (opts *credentialOptions) parse(key string, value object.Object) (bool, *object.Error) {
+	var err *object.Error
+	switch key {
+	case "tenant_id":
+		opts.tenantID, err = object.AsString(value)
+	case "client_id":
+		opts.clientID, err = object.AsString(value)
+	case "client_secret":
+		opts.clientSecret, err = object.AsString(value)
+	case "managed_identity":
+		opts.managedIdentity, err = object.AsBool(value)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// tokenCredential returns the credential selected by the options. A client
+// secret selects a service principal and managed_identity a managed
+// identity, optionally user-assigned with a client id. Otherwise the
+// default credential chain is used, which tries the environment, workload
+// identity, managed identity, and the Azure CLI in turn.
+func (opts credentialOptions) tokenCredential() (azcore.TokenCredential, error) {
+	switch {
+	case opts.clientSecret != "":
+		return azidentity.NewClientSecretCredential(opts.tenantID, opts.clientID, opts.clientSecret, nil)
+	case opts.managedIdentity:
+		var miOpts azidentity.ManagedIdentityCredentialOptions
+		if opts.clientID != "" {
+			miOpts.ID = azidentity.ClientID(opts.clientID)
+		}
+		return azidentity.NewManagedIdentityCredential(&miOpts)
+	default:
+		return azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{
+			TenantID: opts.tenantID,
+		})
+	}
+}
+
+func azureError(err error) *object.Error {
+	return object.NewError(fmt.Errorf("azure error: %w", err))
+}
+
+// parseConnectionString returns the key-value pairs of a storage
+// connection string.
+func parseConnectionString(s string) map[string]string {
+	values := map[string]string{}
+	for _, part := range strings.Split(s, ";") {
+		if key, value, ok := strings.Cut(part, "="); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return values
+}
+
+func timeValue(t *time.Time) object.Object {
+	if t == nil {
+		return object.Nil
+	}
+	return object.NewTime(*t)
+}
+
+func stringValue(s *string) object.Object {
+	if s == nil {
+		return object.NewString("")
+	}
+	return object.NewString(*s)
+}
+
+func int64Value(n *int64) object.Object {
+	if n == nil {
+		return object.NewInt(0)
+	}
+	return object.NewInt(*n)
+}
+
+func etagValue(etag *azcore.ETag) object.Object {
+	if etag == nil {
+		return object.NewString("")
+	}
+	return object.NewString(strings.Trim(string(*etag), `"`))
+}
+
+func stringMap(m map[string]*string) *object.Map {
+	items := make(map[string]object.Object, len(m))
+	for k, v := range m {
+		items[k] = stringValue(v)
+	}
+	return object.NewMap(items)
+}
+
+// metadataMap returns blob metadata with lowercase names. Names are case
+// insensitive, and are returned in canonical header form by the service.
+func metadataMap(m map[string]*string) *object.Map {
+	items := make(map[string]object.Object, len(m))
+	for k, v := range m {
+		items[strings.ToLower(k)] = stringValue(v)
+	}
+	return object.NewMap(items)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("azure", map[string]object.Object{
+		"blob":      object.NewBuiltin("blob", NewBlobBuiltin),
+		"key_vault": object.NewBuiltin("key_vault", NewKeyVaultBuiltin),
+		"secret":    object.NewBuiltin("secret", Secret),
+	})
+}
@@ -0,0 +1,332 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+// The well-known development storage account used by Azurite
+const (
+	devAccount = "devstoreaccount1"
+	devKey     = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+func call(t *testing.T, obj object.Object, method string, args ...object.Object) object.Object {
+	t.Helper()
+	fn, ok := obj.GetAttr(method)
+	require.True(t, ok)
+	return fn.(*object.Builtin).Call(context.Background(), args...)
+}
+
+func collect(t *testing.T, result object.Object) []object.Object {
+	t.Helper()
+	stream, ok := result.(*object.Stream)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	var items []object.Object
+	for {
+		item, ok := stream.Next(context.Background())
+		if !ok {
+			break
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+type fakeBlob struct {
+	data    []byte
+	headers http.Header
+}
+
+// fakeBlobService is an in-memory Blob Storage server supporting the
+// requests made by the client library.
+type fakeBlobService struct {
+	mu     sync.Mutex
+	blobs  map[string]*fakeBlob
+	blocks map[string][]byte
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/"+devAccount+"/")
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodGet && query.Get("comp") == "list":
+		f.list(w, path, query.Get("prefix"))
+	case r.Method == http.MethodPut && query.Get("comp") == "block":
+		f.blocks[query.Get("blockid")] = body
+		w.WriteHeader(http.StatusCreated)
+	case r.Method == http.MethodPut && query.Get("comp") == "blocklist":
+		var list struct {
+			IDs []string `xml:",any"`
+		}
+		xml.Unmarshal(body, &list)
+		var data []byte
+		for _, id := range list.IDs {
+			data = append(data, f.blocks[id]...)
+		}
+		f.put(w, r, path, data)
+	case r.Method == http.MethodPut:
+		f.put(w, r, path, body)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		b, ok := f.blobs[path]
+		if !ok {
+			w.Header().Set("x-ms-error-code", "BlobNotFound")
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		for name, values := range b.headers {
+			w.Header()[name] = values
+		}
+		w.Header().Set("ETag", `"0x1"`)
+		w.Header().Set("x-ms-blob-type", "BlockBlob")
+		w.Header().Set("Content-Length", strconv.Itoa(len(b.data)))
+		if r.Method == http.MethodGet {
+			w.Write(b.data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.blobs, path)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeBlobService) put(w http.ResponseWriter, r *http.Request, path string, data []byte) {
+	b := &fakeBlob{data: data, headers: http.Header{}}
+	for name, values := range r.Header {
+		if strings.HasPrefix(name, "X-Ms-Meta-") {
+			b.headers[name] = values
+		}
+	}
+	b.headers.Set("Content-Type", r.Header.Get("X-Ms-Blob-Content-Type"))
+	f.blobs[path] = b
+	w.Header().Set("ETag", `"0x1"`)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (f *fakeBlobService) list(w http.ResponseWriter, containerName, prefix string) {
+	var names []string
+	for path := range f.blobs {
+		if name, ok := strings.CutPrefix(path, containerName+"/"); ok && strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var buf strings.Builder
+	buf.WriteString(`<?xml version="1.0" encoding="utf-8"?><EnumerationResults><Blobs>`)
+	for _, name := range names {
+		b := f.blobs[containerName+"/"+name]
+		fmt.Fprintf(&buf, "<Blob><Name>%s</Name><Properties><Content-Length>%d</Content-Length><Content-Type>%s</Content-Type><Etag>0x1</Etag><BlobType>BlockBlob</BlobType></Properties></Blob>",
+			name, len(b.data), b.headers.Get("Content-Type"))
+	}
+	buf.WriteString("</Blobs><NextMarker /></EnumerationResults>")
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(buf.String()))
+}
+
+func TestBlob(t *testing.T) {
+	f := &fakeBlobService{blobs: map[string]*fakeBlob{}, blocks: map[string][]byte{}}
+	server := httptest.NewServer(f)
+	defer server.Close()
+	client := NewBlobBuiltin(context.Background(), object.NewMap(map[string]object.Object{
+		"account":     object.NewString(devAccount),
+		"account_key": object.NewString(devKey),
+		"url":         object.NewString(server.URL + "/" + devAccount + "/"),
+		"insecure":    object.True,
+	}))
+	b, ok := client.(*Blob)
+	require.True(t, ok, "unexpected result: %s", client.Inspect())
+
+	result := call(t, b, "put", object.NewString("logs"), object.NewString("2024/a.txt"), object.NewString("hello"),
+		object.NewMap(map[string]object.Object{
+			"content_type": object.NewString("text/plain"),
+			"metadata":     object.NewMap(map[string]object.Object{"owner": object.NewString("ops")}),
+		}))
+	require.Equal(t, object.NewString("0x1"), result.(*object.Map).Get("etag"))
+	require.Equal(t, []byte("hello"), f.blobs["logs/2024/a.txt"].data)
+
+	// Readers larger than a block are staged one block at a time
+	data := strings.Repeat("0123456789abcdef", 5*1024*1024/32)
+	reader := object.NewReader(strings.NewReader(data))
+	call(t, b, "put", object.NewString("logs"), object.NewString("2024/b.txt"), reader,
+		object.NewMap(map[string]object.Object{"block_size": object.NewInt(1024 * 1024)}))
+	require.Len(t, f.blocks, 3)
+	require.Equal(t, data, string(f.blobs["logs/2024/b.txt"].data))
+
+	require.Equal(t, object.NewByteSlice([]byte("hello")), call(t, b, "get", object.NewString("logs"), object.NewString("2024/a.txt")))
+
+	props := call(t, b, "properties", object.NewString("logs"), object.NewString("2024/a.txt")).(*object.Map)
+	require.Equal(t, object.NewInt(5), props.Get("size"))
+	require.Equal(t, object.NewString("text/plain"), props.Get("content_type"))
+	require.Equal(t, object.NewString("ops"), props.Get("metadata").(*object.Map).Get("owner"))
+
+	require.Equal(t, object.True, call(t, b, "exists", object.NewString("logs"), object.NewString("2024/a.txt")))
+	require.Equal(t, object.False, call(t, b, "exists", object.NewString("logs"), object.NewString("2024/c.txt")))
+
+	var names []string
+	for _, item := range collect(t, call(t, b, "list", object.NewString("logs"),
+		object.NewMap(map[string]object.Object{"prefix": object.NewString("2024/")}))) {
+		names = append(names, item.(*object.Map).Get("name").(*object.String).Value())
+	}
+	require.Equal(t, []string{"2024/a.txt", "2024/b.txt"}, names)
+
+	require.Equal(t, object.Nil, call(t, b, "delete", object.NewString("logs"),
+		object.NewList([]object.Object{object.NewString("2024/a.txt"), object.NewString("2024/b.txt")})))
+	require.Empty(t, f.blobs)
+
+	result = call(t, b, "get", object.NewString("logs"), object.NewString("2024/a.txt"))
+	require.Contains(t, result.(*object.Error).Message().Value(), "azure error:")
+	require.Contains(t, result.(*object.Error).Message().Value(), "BlobNotFound")
+}
+
+func TestSAS(t *testing.T) {
+	b := NewBlobBuiltin(context.Background(), object.NewMap(map[string]object.Object{
+		"account":     object.NewString(devAccount),
+		"account_key": object.NewString(devKey),
+	})).(*Blob)
+	result := call(t, b, "sas", object.NewString("reports"), object.NewString("q1.pdf"),
+		object.NewMap(map[string]object.Object{"permissions": object.NewString("rw"), "expires": object.NewString("1h")}))
+	u, err := url.Parse(result.(*object.String).Value())
+	require.Nil(t, err)
+	require.Equal(t, "devstoreaccount1.blob.core.windows.net", u.Host)
+	require.Equal(t, "/reports/q1.pdf", u.Path)
+	require.Equal(t, "rw", u.Query().Get("sp"))
+	require.Equal(t, "b", u.Query().Get("sr"))
+	require.Equal(t, "https", u.Query().Get("spr"))
+	require.NotEmpty(t, u.Query().Get("sig"))
+	expiry, err := time.Parse(time.RFC3339, u.Query().Get("se"))
+	require.Nil(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), expiry, time.Minute)
+
+	result = call(t, b, "sas", object.NewString("reports"), object.NewString("q1.pdf"),
+		object.NewMap(map[string]object.Object{"permissions": object.NewString("rz")}))
+	require.Equal(t, `value error: invalid SAS permission 'z' (expected some of "racwdxyltmeop")`,
+		result.(*object.Error).Message().Value())
+}
+
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// fakeKeyVault is an in-memory Key Vault server that requires the bearer
+// token challenge to be answered.
+type fakeKeyVault struct {
+	mu      sync.Mutex
+	secrets map[string][]string
+}
+
+func (f *fakeKeyVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.Header().Set("WWW-Authenticate", `Bearer authorization="https://login.microsoftonline.com/tenant", resource="https://vault.azure.net"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	base := "https://" + r.Host
+	w.Header().Set("Content-Type", "application/json")
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 1:
+		var items []any
+		for name := range f.secrets {
+			items = append(items, map[string]any{"id": base + "/secrets/" + name, "attributes": map[string]any{"enabled": true}})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"value": items})
+	case r.Method == http.MethodPut:
+		var params struct{ Value string }
+		json.NewDecoder(r.Body).Decode(&params)
+		f.secrets[parts[1]] = append(f.secrets[parts[1]], params.Value)
+		version := strconv.Itoa(len(f.secrets[parts[1]]))
+		json.NewEncoder(w).Encode(map[string]any{"value": params.Value, "id": base + "/secrets/" + parts[1] + "/" + version})
+	case r.Method == http.MethodGet:
+		versions := f.secrets[parts[1]]
+		index := len(versions)
+		if len(parts) > 2 && parts[2] != "" {
+			index, _ = strconv.Atoi(parts[2])
+		}
+		if index < 1 || index > len(versions) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": "SecretNotFound", "message": "Secret not found"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"value": versions[index-1], "id": base + "/secrets/" + parts[1] + "/" + strconv.Itoa(index)})
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestKeyVault(t *testing.T) {
+	server := httptest.NewTLSServer(&fakeKeyVault{secrets: map[string][]string{}})
+	defer server.Close()
+	client, err := azsecrets.NewClient(server.URL, fakeCredential{}, &azsecrets.ClientOptions{
+		ClientOptions:                        azcore.ClientOptions{Transport: server.Client()},
+		DisableChallengeResourceVerification: true,
+	})
+	require.Nil(t, err)
+	kv := NewKeyVault(client, server.URL)
+
+	require.Equal(t, object.NewString("1"), call(t, kv, "set", object.NewString("db-password"), object.NewString("hunter1")))
+	require.Equal(t, object.NewString("2"), call(t, kv, "set", object.NewString("db-password"), object.NewString("hunter2")))
+	require.Equal(t, object.NewString("hunter2"), call(t, kv, "get", object.NewString("db-password")))
+	require.Equal(t, object.NewString("hunter1"), call(t, kv, "get", object.NewString("db-password"), object.NewString("1")))
+
+	items := collect(t, call(t, kv, "list"))
+	require.Len(t, items, 1)
+	require.Equal(t, object.NewString("db-password"), items[0].(*object.Map).Get("name"))
+
+	result := call(t, kv, "get", object.NewString("api-key"))
+	require.Contains(t, result.(*object.Error).Message().Value(), "azure error:")
+	require.Contains(t, result.(*object.Error).Message().Value(), "SecretNotFound")
+}
+
+func TestOptionErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		result object.Object
+		err    string
+	}{
+		{NewBlobBuiltin(ctx), "value error: azure.blob requires an account, url, or connection_string"},
+		{NewBlobBuiltin(ctx, object.NewMap(map[string]object.Object{"container": object.NewString("c")})),
+			`value error: unknown azure.blob option "container"`},
+		{NewBlobBuiltin(ctx, object.NewMap(map[string]object.Object{
+			"url":         object.NewString("https://example.blob.core.windows.net/"),
+			"account_key": object.NewString(devKey),
+		})), "value error: azure.blob requires an account with an account_key"},
+		{NewKeyVaultBuiltin(ctx, object.NewString("my-vault"), object.NewMap(map[string]object.Object{
+			"region": object.NewString("westeurope"),
+		})), `value error: unknown azure.key_vault option "region"`},
+	}
+	for _, tt := range tests {
+		errObj, ok := tt.result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", tt.result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+	kv := NewKeyVaultBuiltin(ctx, object.NewString("my-vault"), object.NewMap(map[string]object.Object{
+		"managed_identity": object.True,
+	}))
+	require.Equal(t, "azure.key_vault_client(url=https://my-vault.vault.azure.net/)", kv.Inspect())
+}
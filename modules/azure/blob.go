@@ -0,0 +1,689 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/limits"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+)
+
+const BLOB object.Type = "azure.blob_client"
+
+// sasPermissions are the permission characters accepted in a blob SAS.
+const sasPermissions = "racwdxyltmeop"
+
+// Blob wraps a Blob Storage client. The shared key, if the client was
+// created with one, is kept to sign SAS tokens. Otherwise tokens are signed
+// with a user delegation key, which requires an Entra ID credential.
+type Blob struct {
+	client      *azblob.Client
+	sharedKey   *azblob.SharedKeyCredential
+	blockSize   int64
+	concurrency int
+}
+
+// NewBlob returns a Blob for the given client. The shared key may be nil,
+// and a block size or concurrency of 0 selects the client library's default.
+func NewBlob(client *azblob.Client, sharedKey *azblob.SharedKeyCredential, blockSize int64, concurrency int) *Blob {
+	return &Blob{
+		client:      client,
+		sharedKey:   sharedKey,
+		blockSize:   blockSize,
+		concurrency: concurrency,
+	}
+}
+
+// NewBlobBuiltin creates a Blob Storage client.
+func NewBlobBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.blob", 0, 1, args); err != nil {
+		return err
+	}
+	var creds credentialOptions
+	var account, serviceURL, connectionString, accountKey, sasToken string
+	var blockSize, concurrency int64
+	var insecure bool
+	if len(args) > 0 {
+		m, err := object.AsMap(args[0])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			var ok bool
+			if ok, err = creds.parse(key, value); ok {
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			switch key {
+			case "account":
+				account, err = object.AsString(value)
+			case "url":
+				serviceURL, err = object.AsString(value)
+			case "connection_string":
+				connectionString, err = object.AsString(value)
+			case "account_key":
+				accountKey, err = object.AsString(value)
+			case "sas":
+				sasToken, err = object.AsString(value)
+			case "insecure":
+				insecure, err = object.AsBool(value)
+			case "block_size":
+				if blockSize, err = object.AsInt(value); err == nil && blockSize < 0 {
+					err = object.Errorf("value error: block_size must not be negative (got %d)", blockSize)
+				}
+			case "concurrency":
+				if concurrency, err = object.AsInt(value); err == nil && concurrency < 1 {
+					err = object.Errorf("value error: concurrency must be at least 1 (got %d)", concurrency)
+				}
+			default:
+				err = object.Errorf("value error: unknown azure.blob option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if serviceURL == "" && account != "" {
+		serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net/", account)
+	}
+	if serviceURL == "" && connectionString == "" {
+		return object.Errorf("value error: azure.blob requires an account, url, or connection_string")
+	}
+	// Credentials are only sent over plain HTTP when allowed explicitly,
+	// for emulators such as Azurite
+	clientOpts := &azblob.ClientOptions{}
+	clientOpts.InsecureAllowCredentialWithHTTP = insecure
+	var client *azblob.Client
+	var sharedKey *azblob.SharedKeyCredential
+	var err error
+	switch {
+	case connectionString != "":
+		if client, err = azblob.NewClientFromConnectionString(connectionString, clientOpts); err != nil {
+			return azureError(err)
+		}
+		values := parseConnectionString(connectionString)
+		if values["AccountName"] != "" && values["AccountKey"] != "" {
+			if sharedKey, err = azblob.NewSharedKeyCredential(values["AccountName"], values["AccountKey"]); err != nil {
+				return azureError(err)
+			}
+		}
+	case accountKey != "":
+		if account == "" {
+			return object.Errorf("value error: azure.blob requires an account with an account_key")
+		}
+		if sharedKey, err = azblob.NewSharedKeyCredential(account, accountKey); err != nil {
+			return azureError(err)
+		}
+		client, err = azblob.NewClientWithSharedKeyCredential(serviceURL, sharedKey, clientOpts)
+	case sasToken != "":
+		client, err = azblob.NewClientWithNoCredential(strings.TrimSuffix(serviceURL, "?")+"?"+strings.TrimPrefix(sasToken, "?"), clientOpts)
+	default:
+		cred, credErr := creds.tokenCredential()
+		if credErr != nil {
+			return azureError(credErr)
+		}
+		client, err = azblob.NewClient(serviceURL, cred, clientOpts)
+	}
+	if err != nil {
+		return azureError(err)
+	}
+	return NewBlob(client, sharedKey, blockSize, int(concurrency))
+}
+
+// Value returns the underlying client.
+func (b *Blob) Value() *azblob.Client {
+	return b.client
+}
+
+func (b *Blob) Type() object.Type {
+	return BLOB
+}
+
+func (b *Blob) Inspect() string {
+	return fmt.Sprintf("azure.blob_client(url=%s)", b.client.URL())
+}
+
+func (b *Blob) String() string {
+	return b.Inspect()
+}
+
+func (b *Blob) Interface() interface{} {
+	return b.client
+}
+
+func (b *Blob) Equals(other object.Object) object.Object {
+	return object.NewBool(b == other)
+}
+
+func (b *Blob) IsTruthy() bool {
+	return true
+}
+
+func (b *Blob) Cost() int {
+	return 0
+}
+
+func (b *Blob) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", BLOB)
+}
+
+func (b *Blob) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", BLOB, opType)
+}
+
+func (b *Blob) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", BLOB, name)
+}
+
+func (b *Blob) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "url":
+		return object.NewString(b.client.URL()), true
+	case "list":
+		return object.NewBuiltin("azure.blob_client.list", b.list), true
+	case "get":
+		return object.NewBuiltin("azure.blob_client.get", b.get), true
+	case "reader":
+		return object.NewBuiltin("azure.blob_client.reader", b.reader), true
+	case "download":
+		return object.NewBuiltin("azure.blob_client.download", b.downloadFile), true
+	case "put":
+		return object.NewBuiltin("azure.blob_client.put", b.put), true
+	case "upload":
+		return object.NewBuiltin("azure.blob_client.upload", b.upload), true
+	case "properties":
+		return object.NewBuiltin("azure.blob_client.properties", b.properties), true
+	case "exists":
+		return object.NewBuiltin("azure.blob_client.exists", b.exists), true
+	case "delete":
+		return object.NewBuiltin("azure.blob_client.delete", b.delete), true
+	case "sas":
+		return object.NewBuiltin("azure.blob_client.sas", b.sas), true
+	}
+	return nil, false
+}
+
+// containerAndName returns the first two arguments as strings.
+func containerAndName(args []object.Object) (string, string, *object.Error) {
+	containerName, err := object.AsString(args[0])
+	if err != nil {
+		return "", "", err
+	}
+	name, err := object.AsString(args[1])
+	if err != nil {
+		return "", "", err
+	}
+	return containerName, name, nil
+}
+
+func (b *Blob) blobClient(containerName, name string) *blob.Client {
+	return b.client.ServiceClient().NewContainerClient(containerName).NewBlobClient(name)
+}
+
+// list returns a stream of the blobs in a container. Pages are requested as
+// the stream is consumed.
+func (b *Blob) list(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.blob_client.list", 1, 2, args); err != nil {
+		return err
+	}
+	containerName, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	listOpts := &container.ListBlobsFlatOptions{
+		Include: container.ListBlobsInclude{Metadata: true},
+	}
+	var max int64
+	if len(args) > 1 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "prefix":
+				var prefix string
+				prefix, err = object.AsString(value)
+				listOpts.Prefix = to.Ptr(prefix)
+			case "max":
+				if max, err = object.AsInt(value); err == nil && max < 0 {
+					err = object.Errorf("value error: max must not be negative (got %d)", max)
+				}
+			default:
+				err = object.Errorf("value error: unknown azure.blob_client.list option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	pager := b.client.NewListBlobsFlatPager(containerName, listOpts)
+	var page []object.Object
+	var count int64
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		if max > 0 && count >= max {
+			return nil, false, nil
+		}
+		for len(page) == 0 {
+			if !pager.More() {
+				return nil, false, nil
+			}
+			resp, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, false, fmt.Errorf("azure error: %w", err)
+			}
+			if resp.Segment == nil {
+				continue
+			}
+			for _, item := range resp.Segment.BlobItems {
+				result := map[string]object.Object{
+					"name":     stringValue(item.Name),
+					"metadata": metadataMap(item.Metadata),
+				}
+				if p := item.Properties; p != nil {
+					result["size"] = int64Value(p.ContentLength)
+					result["content_type"] = stringValue(p.ContentType)
+					result["etag"] = etagValue(p.ETag)
+					result["last_modified"] = timeValue(p.LastModified)
+					result["tier"] = stringValue((*string)(p.AccessTier))
+				}
+				page = append(page, object.NewMap(result))
+			}
+		}
+		item := page[0]
+		page = page[1:]
+		count++
+		return item, true, nil
+	})
+}
+
+// download starts reading a blob. The caller must close the body.
+func (b *Blob) download(ctx context.Context, fn string, args []object.Object) (io.ReadCloser, *object.Error) {
+	if err := arg.RequireRange(fn, 2, 3, args); err != nil {
+		return nil, err
+	}
+	containerName, name, errObj := containerAndName(args)
+	if errObj != nil {
+		return nil, errObj
+	}
+	var byteRange blob.HTTPRange
+	if len(args) > 2 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "offset":
+				if byteRange.Offset, err = object.AsInt(value); err == nil && byteRange.Offset < 0 {
+					err = object.Errorf("value error: offset must not be negative (got %d)", byteRange.Offset)
+				}
+			case "length":
+				if byteRange.Count, err = object.AsInt(value); err == nil && byteRange.Count < 0 {
+					err = object.Errorf("value error: length must not be negative (got %d)", byteRange.Count)
+				}
+			default:
+				err = object.Errorf("value error: unknown %s option %q", fn, key)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	resp, err := b.client.DownloadStream(ctx, containerName, name, &azblob.DownloadStreamOptions{Range: byteRange})
+	if err != nil {
+		return nil, azureError(err)
+	}
+	// The retry reader resumes the download if the connection drops
+	return resp.NewRetryReader(ctx, nil), nil
+}
+
+func (b *Blob) get(ctx context.Context, args ...object.Object) object.Object {
+	body, errObj := b.download(ctx, "azure.blob_client.get", args)
+	if errObj != nil {
+		return errObj
+	}
+	defer body.Close()
+	var data []byte
+	var err error
+	if lim, ok := limits.GetLimits(ctx); ok {
+		data, err = lim.ReadAll(body)
+	} else {
+		data, err = io.ReadAll(body)
+	}
+	if err != nil {
+		return azureError(err)
+	}
+	return object.NewByteSlice(data)
+}
+
+func (b *Blob) reader(ctx context.Context, args ...object.Object) object.Object {
+	body, errObj := b.download(ctx, "azure.blob_client.reader", args)
+	if errObj != nil {
+		return errObj
+	}
+	return object.NewReader(body)
+}
+
+func (b *Blob) downloadFile(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.blob_client.download", 3, 4, args); err != nil {
+		return err
+	}
+	path, errObj := object.AsString(args[2])
+	if errObj != nil {
+		return errObj
+	}
+	body, errObj := b.download(ctx, "azure.blob_client.download", append(args[:2:2], args[3:]...))
+	if errObj != nil {
+		return errObj
+	}
+	defer body.Close()
+	f, err := ros.GetDefaultOS(ctx).Create(path)
+	if err != nil {
+		return object.NewError(err)
+	}
+	n, err := io.Copy(f, body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return azureError(err)
+	}
+	return object.NewInt(n)
+}
+
+// uploadOptions are the options accepted when writing a blob.
+var uploadOptions = []string{
+	"content_type", "cache_control", "content_encoding", "metadata", "tier",
+	"block_size", "concurrency",
+}
+
+func (b *Blob) parseUploadOptions(fn string, args []object.Object) (*azblob.UploadStreamOptions, *object.Error) {
+	opts := &azblob.UploadStreamOptions{
+		BlockSize:   b.blockSize,
+		Concurrency: b.concurrency,
+		HTTPHeaders: &blob.HTTPHeaders{},
+	}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range m.Value() {
+		var s string
+		switch key {
+		case "content_type":
+			s, err = object.AsString(value)
+			opts.HTTPHeaders.BlobContentType = to.Ptr(s)
+		case "cache_control":
+			s, err = object.AsString(value)
+			opts.HTTPHeaders.BlobCacheControl = to.Ptr(s)
+		case "content_encoding":
+			s, err = object.AsString(value)
+			opts.HTTPHeaders.BlobContentEncoding = to.Ptr(s)
+		case "tier":
+			s, err = object.AsString(value)
+			opts.AccessTier = to.Ptr(blob.AccessTier(s))
+		case "metadata":
+			var metadata *object.Map
+			if metadata, err = object.AsMap(value); err != nil {
+				break
+			}
+			opts.Metadata = map[string]*string{}
+			for name, value := range metadata.Value() {
+				if s, err = object.AsString(value); err != nil {
+					break
+				}
+				opts.Metadata[name] = to.Ptr(s)
+			}
+		case "block_size":
+			if opts.BlockSize, err = object.AsInt(value); err == nil && opts.BlockSize < 0 {
+				err = object.Errorf("value error: block_size must not be negative (got %d)", opts.BlockSize)
+			}
+		case "concurrency":
+			var n int64
+			if n, err = object.AsInt(value); err == nil && n < 1 {
+				err = object.Errorf("value error: concurrency must be at least 1 (got %d)", n)
+			}
+			opts.Concurrency = int(n)
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// write streams the body to a block blob, staging one block at a time and
+// then committing the block list, so bodies of any size may be uploaded.
+func (b *Blob) write(ctx context.Context, containerName, name string, body io.Reader, opts *azblob.UploadStreamOptions) object.Object {
+	resp, err := b.client.UploadStream(ctx, containerName, name, body, opts)
+	if err != nil {
+		return azureError(err)
+	}
+	return object.NewMap(map[string]object.Object{
+		"etag":          etagValue(resp.ETag),
+		"last_modified": timeValue(resp.LastModified),
+		"version_id":    stringValue(resp.VersionID),
+	})
+}
+
+func (b *Blob) put(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.blob_client.put", 3, 4, args); err != nil {
+		return err
+	}
+	containerName, name, errObj := containerAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	var body io.Reader
+	switch data := args[2].(type) {
+	case *object.String:
+		body = strings.NewReader(data.Value())
+	default:
+		if body, errObj = object.AsReader(data); errObj != nil {
+			return errObj
+		}
+	}
+	opts, errObj := b.parseUploadOptions("azure.blob_client.put", args[3:])
+	if errObj != nil {
+		return errObj
+	}
+	return b.write(ctx, containerName, name, body, opts)
+}
+
+func (b *Blob) upload(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.blob_client.upload", 3, 4, args); err != nil {
+		return err
+	}
+	containerName, name, errObj := containerAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	path, errObj := object.AsString(args[2])
+	if errObj != nil {
+		return errObj
+	}
+	opts, errObj := b.parseUploadOptions("azure.blob_client.upload", args[3:])
+	if errObj != nil {
+		return errObj
+	}
+	f, err := ros.GetDefaultOS(ctx).Open(path)
+	if err != nil {
+		return object.NewError(err)
+	}
+	defer f.Close()
+	return b.write(ctx, containerName, name, f, opts)
+}
+
+func (b *Blob) properties(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("azure.blob_client.properties", 2, args); err != nil {
+		return err
+	}
+	containerName, name, errObj := containerAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	resp, err := b.blobClient(containerName, name).GetProperties(ctx, nil)
+	if err != nil {
+		return azureError(err)
+	}
+	return object.NewMap(map[string]object.Object{
+		"size":             int64Value(resp.ContentLength),
+		"content_type":     stringValue(resp.ContentType),
+		"content_encoding": stringValue(resp.ContentEncoding),
+		"cache_control":    stringValue(resp.CacheControl),
+		"etag":             etagValue(resp.ETag),
+		"last_modified":    timeValue(resp.LastModified),
+		"created":          timeValue(resp.CreationTime),
+		"tier":             stringValue(resp.AccessTier),
+		"version_id":       stringValue(resp.VersionID),
+		"metadata":         metadataMap(resp.Metadata),
+	})
+}
+
+func (b *Blob) exists(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("azure.blob_client.exists", 2, args); err != nil {
+		return err
+	}
+	containerName, name, errObj := containerAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	if _, err := b.blobClient(containerName, name).GetProperties(ctx, nil); err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound, bloberror.ContainerNotFound) {
+			return object.False
+		}
+		return azureError(err)
+	}
+	return object.True
+}
+
+// delete removes one blob, or a list of blobs.
+func (b *Blob) delete(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("azure.blob_client.delete", 2, args); err != nil {
+		return err
+	}
+	containerName, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	var names []string
+	if name, ok := args[1].(*object.String); ok {
+		names = []string{name.Value()}
+	} else if names, errObj = object.AsStringSlice(args[1]); errObj != nil {
+		return errObj
+	}
+	for _, name := range names {
+		if _, err := b.client.DeleteBlob(ctx, containerName, name, nil); err != nil {
+			return azureError(fmt.Errorf("failed to delete %q: %w", name, err))
+		}
+	}
+	return object.Nil
+}
+
+// sas returns the URL of a blob with a SAS token granting the given
+// permissions until it expires.
+func (b *Blob) sas(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.blob_client.sas", 2, 3, args); err != nil {
+		return err
+	}
+	containerName, name, errObj := containerAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	permissions := "r"
+	expires := 15 * time.Minute
+	var start time.Time
+	if len(args) > 2 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "permissions":
+				if permissions, err = object.AsString(value); err == nil {
+					for _, c := range permissions {
+						if !strings.ContainsRune(sasPermissions, c) {
+							err = object.Errorf("value error: invalid SAS permission %q (expected some of %q)", c, sasPermissions)
+							break
+						}
+					}
+				}
+			case "expires":
+				if expires, err = arg.Duration(key, value); err == nil && expires <= 0 {
+					err = object.Errorf("value error: expires must be positive (got %s)", expires)
+				}
+			case "start":
+				start, err = object.AsTime(value)
+			default:
+				err = object.Errorf("value error: unknown azure.blob_client.sas option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if permissions == "" {
+		return object.Errorf("value error: SAS permissions must not be empty")
+	}
+	blobClient := b.blobClient(containerName, name)
+	blobURL, err := url.Parse(blobClient.URL())
+	if err != nil {
+		return azureError(err)
+	}
+	if start.IsZero() {
+		// Allow for clock skew between the client and the service
+		start = time.Now().Add(-5 * time.Minute)
+	}
+	values := sas.BlobSignatureValues{
+		Protocol:      sas.ProtocolHTTPS,
+		StartTime:     start.UTC(),
+		ExpiryTime:    time.Now().Add(expires).UTC(),
+		Permissions:   permissions,
+		ContainerName: containerName,
+		BlobName:      name,
+	}
+	if blobURL.Scheme == "http" {
+		values.Protocol = sas.ProtocolHTTPSandHTTP
+	}
+	var params sas.QueryParameters
+	if b.sharedKey != nil {
+		params, err = values.SignWithSharedKey(b.sharedKey)
+	} else {
+		var cred *service.UserDelegationCredential
+		cred, err = b.client.ServiceClient().GetUserDelegationCredential(ctx, service.KeyInfo{
+			Start:  to.Ptr(values.StartTime.Format(sas.TimeFormat)),
+			Expiry: to.Ptr(values.ExpiryTime.Format(sas.TimeFormat)),
+		}, nil)
+		if err == nil {
+			params, err = values.SignWithUserDelegation(cred)
+		}
+	}
+	if err != nil {
+		return azureError(err)
+	}
+	blobURL.RawQuery = params.Encode()
+	return object.NewString(blobURL.String())
+}
@@ -0,0 +1,307 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const KEY_VAULT object.Type = "azure.key_vault_client"
+
+// KeyVault wraps a Key Vault secrets client.
+type KeyVault struct {
+	client *azsecrets.Client
+	url    string
+}
+
+// NewKeyVault returns a KeyVault for the given client and vault URL.
+func NewKeyVault(client *azsecrets.Client, vaultURL string) *KeyVault {
+	return &KeyVault{client: client, url: vaultURL}
+}
+
+// vaultURL returns the URL of a vault given by URL or by name.
+func vaultURL(vault string) string {
+	if strings.Contains(vault, "://") {
+		return vault
+	}
+	return fmt.Sprintf("https://%s.vault.azure.net/", vault)
+}
+
+func newKeyVault(fn string, args []object.Object) (*KeyVault, *object.Error) {
+	vault, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return nil, errObj
+	}
+	var creds credentialOptions
+	if len(args) > 1 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range m.Value() {
+			ok, err := creds.parse(key, value)
+			if !ok {
+				err = object.Errorf("value error: unknown %s option %q", fn, key)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	cred, err := creds.tokenCredential()
+	if err != nil {
+		return nil, azureError(err)
+	}
+	u := vaultURL(vault)
+	client, err := azsecrets.NewClient(u, cred, nil)
+	if err != nil {
+		return nil, azureError(err)
+	}
+	return NewKeyVault(client, u), nil
+}
+
+// NewKeyVaultBuiltin creates a Key Vault client for a vault given by URL or
+// by name.
+func NewKeyVaultBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.key_vault", 1, 2, args); err != nil {
+		return err
+	}
+	kv, err := newKeyVault("azure.key_vault", args)
+	if err != nil {
+		return err
+	}
+	return kv
+}
+
+// Secret returns the latest version of a secret in a vault.
+func Secret(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.secret", 2, 3, args); err != nil {
+		return err
+	}
+	name, errObj := object.AsString(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	kv, errObj := newKeyVault("azure.secret", append(args[:1:1], args[2:]...))
+	if errObj != nil {
+		return errObj
+	}
+	value, err := kv.Get(ctx, name, "")
+	if err != nil {
+		return azureError(err)
+	}
+	return object.NewString(value)
+}
+
+// Get returns the value of a secret version, or of the latest version if
+// the version is empty.
+func (kv *KeyVault) Get(ctx context.Context, name, version string) (string, error) {
+	resp, err := kv.client.GetSecret(ctx, name, version, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp.Value == nil {
+		return "", nil
+	}
+	return *resp.Value, nil
+}
+
+// Value returns the underlying client.
+func (kv *KeyVault) Value() *azsecrets.Client {
+	return kv.client
+}
+
+func (kv *KeyVault) Type() object.Type {
+	return KEY_VAULT
+}
+
+func (kv *KeyVault) Inspect() string {
+	return fmt.Sprintf("azure.key_vault_client(url=%s)", kv.url)
+}
+
+func (kv *KeyVault) String() string {
+	return kv.Inspect()
+}
+
+func (kv *KeyVault) Interface() interface{} {
+	return kv.client
+}
+
+func (kv *KeyVault) Equals(other object.Object) object.Object {
+	return object.NewBool(kv == other)
+}
+
+func (kv *KeyVault) IsTruthy() bool {
+	return true
+}
+
+func (kv *KeyVault) Cost() int {
+	return 0
+}
+
+func (kv *KeyVault) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", KEY_VAULT)
+}
+
+func (kv *KeyVault) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", KEY_VAULT, opType)
+}
+
+func (kv *KeyVault) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", KEY_VAULT, name)
+}
+
+func (kv *KeyVault) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "url":
+		return object.NewString(kv.url), true
+	case "get":
+		return object.NewBuiltin("azure.key_vault_client.get", kv.get), true
+	case "set":
+		return object.NewBuiltin("azure.key_vault_client.set", kv.set), true
+	case "delete":
+		return object.NewBuiltin("azure.key_vault_client.delete", kv.delete), true
+	case "list":
+		return object.NewBuiltin("azure.key_vault_client.list", kv.list), true
+	}
+	return nil, false
+}
+
+func (kv *KeyVault) get(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.key_vault_client.get", 1, 2, args); err != nil {
+		return err
+	}
+	name, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	var version string
+	if len(args) > 1 {
+		if version, errObj = object.AsString(args[1]); errObj != nil {
+			return errObj
+		}
+	}
+	value, err := kv.Get(ctx, name, version)
+	if err != nil {
+		return azureError(err)
+	}
+	return object.NewString(value)
+}
+
+// set stores a new version of a secret, creating the secret if needed, and
+// returns the version.
+func (kv *KeyVault) set(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("azure.key_vault_client.set", 2, 3, args); err != nil {
+		return err
+	}
+	name, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	value, errObj := object.AsString(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	params := azsecrets.SetSecretParameters{Value: to.Ptr(value)}
+	if len(args) > 2 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "content_type":
+				var s string
+				s, err = object.AsString(value)
+				params.ContentType = to.Ptr(s)
+			case "tags":
+				var tags *object.Map
+				if tags, err = object.AsMap(value); err != nil {
+					break
+				}
+				params.Tags = map[string]*string{}
+				for k, v := range tags.Value() {
+					var s string
+					if s, err = object.AsString(v); err != nil {
+						break
+					}
+					params.Tags[k] = to.Ptr(s)
+				}
+			default:
+				err = object.Errorf("value error: unknown azure.key_vault_client.set option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	resp, err := kv.client.SetSecret(ctx, name, params, nil)
+	if err != nil {
+		return azureError(err)
+	}
+	if resp.ID == nil {
+		return object.NewString("")
+	}
+	return object.NewString(resp.ID.Version())
+}
+
+// delete deletes a secret. Vaults with soft delete enabled keep it until it
+// is purged or its retention period ends.
+func (kv *KeyVault) delete(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("azure.key_vault_client.delete", 1, args); err != nil {
+		return err
+	}
+	name, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	if _, err := kv.client.DeleteSecret(ctx, name, nil); err != nil {
+		return azureError(err)
+	}
+	return object.Nil
+}
+
+// list returns a stream of the secrets in the vault, without their values.
+func (kv *KeyVault) list(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("azure.key_vault_client.list", 0, args); err != nil {
+		return err
+	}
+	pager := kv.client.NewListSecretPropertiesPager(nil)
+	var page []object.Object
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		for len(page) == 0 {
+			if !pager.More() {
+				return nil, false, nil
+			}
+			resp, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, false, fmt.Errorf("azure error: %w", err)
+			}
+			for _, props := range resp.Value {
+				result := map[string]object.Object{
+					"content_type": stringValue(props.ContentType),
+					"tags":         stringMap(props.Tags),
+				}
+				if props.ID != nil {
+					result["name"] = object.NewString(props.ID.Name())
+				}
+				if attrs := props.Attributes; attrs != nil {
+					result["enabled"] = object.NewBool(attrs.Enabled == nil || *attrs.Enabled)
+					result["created"] = timeValue(attrs.Created)
+					result["updated"] = timeValue(attrs.Updated)
+					result["expires"] = timeValue(attrs.Expires)
+				}
+				page = append(page, object.NewMap(result))
+			}
+		}
+		item := page[0]
+		page = page[1:]
+		return item, true, nil
+	})
+}
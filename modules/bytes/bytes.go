@@ -180,8 +180,18 @@ func ReplaceAll(ctx context.Context, args ...object.Object) object.Object {
 	return b.ReplaceAll(args[1], args[2])
 }
 
+// Buffer returns a new buffer, optionally initialized with the given values.
+func Buffer(ctx context.Context, args ...object.Object) object.Object {
+	buf := object.NewBuffer(nil)
+	if _, err := buf.WriteObjects(ctx, args...); err != nil {
+		return err
+	}
+	return buf
+}
+
 func Module() *object.Module {
 	return object.NewBuiltinsModule("bytes", map[string]object.Object{
+		"buffer":        object.NewBuiltin("buffer", Buffer),
 		"clone":         object.NewBuiltin("clone", Clone),
 		"contains_any":  object.NewBuiltin("contains_any", ContainsAny),
 		"contains_rune": object.NewBuiltin("contains_rune", ContainsRune),
@@ -0,0 +1,163 @@
+package cbor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+var (
+	// encMode sorts map keys so output is deterministic and encodes times
+	// as tagged RFC 3339 strings so they decode back to times.
+	encMode, _ = cbor.EncOptions{
+		Sort:    cbor.SortCanonical,
+		Time:    cbor.TimeRFC3339Nano,
+		TimeTag: cbor.EncTagRequired,
+	}.EncMode()
+
+	decMode, _ = cbor.DecOptions{}.DecMode()
+)
+
+func Unmarshal(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("cbor.unmarshal", 1, args); err != nil {
+		return err
+	}
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	var value interface{}
+	if err := decMode.Unmarshal(data, &value); err != nil {
+		return object.Errorf("value error: cbor.unmarshal failed with: %s", err)
+	}
+	return toObject(value)
+}
+
+func Marshal(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("cbor.marshal", 1, args); err != nil {
+		return err
+	}
+	data, err := encMode.Marshal(args[0].Interface())
+	if err != nil {
+		return object.Errorf("value error: cbor.marshal failed: %s", err)
+	}
+	return object.NewByteSlice(data)
+}
+
+func Valid(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("cbor.valid", 1, args); err != nil {
+		return err
+	}
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewBool(decMode.Wellformed(data) == nil)
+}
+
+// Reader returns a stream of the values read from a source containing a
+// sequence of concatenated CBOR values.
+func Reader(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("cbor.reader", 1, args); err != nil {
+		return err
+	}
+	r, err := object.AsReader(args[0])
+	if err != nil {
+		return err
+	}
+	dec := decMode.NewDecoder(r)
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		var value interface{}
+		err := dec.Decode(&value)
+		if errors.Is(err, io.EOF) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("cbor error: %w", err)
+		}
+		return toObject(value), true, nil
+	})
+}
+
+// Write writes each value from an iterable to the destination as a sequence
+// of CBOR values. Returns the number of values written.
+func Write(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("cbor.write", 2, args); err != nil {
+		return err
+	}
+	w, err := object.AsWriter(args[0])
+	if err != nil {
+		return err
+	}
+	iter, err := object.AsIterator(args[1])
+	if err != nil {
+		return err
+	}
+	enc := encMode.NewEncoder(w)
+	var count int64
+	for {
+		value, ok := iter.Next(ctx)
+		if !ok {
+			break
+		}
+		if err := enc.Encode(value.Interface()); err != nil {
+			return object.Errorf("value error: cbor.write failed: %s", err)
+		}
+		count++
+	}
+	if s, ok := iter.(interface{ Err() error }); ok && s.Err() != nil {
+		return object.NewError(s.Err())
+	}
+	return object.NewInt(count)
+}
+
+// toObject converts a decoded value to a Risor object. Map keys that aren't
+// strings are converted to strings, and unrecognized tags are decoded as
+// their content.
+func toObject(value interface{}) object.Object {
+	switch value := value.(type) {
+	case nil:
+		return object.Nil
+	case uint64:
+		return object.NewInt(int64(value))
+	case big.Int:
+		if value.IsInt64() {
+			return object.NewInt(value.Int64())
+		}
+		f, _ := new(big.Float).SetInt(&value).Float64()
+		return object.NewFloat(f)
+	case cbor.Tag:
+		return toObject(value.Content)
+	case cbor.SimpleValue:
+		return object.NewInt(int64(value))
+	case []interface{}:
+		items := make([]object.Object, len(value))
+		for i, item := range value {
+			items[i] = toObject(item)
+		}
+		return object.NewList(items)
+	case map[interface{}]interface{}:
+		m := make(map[string]object.Object, len(value))
+		for k, v := range value {
+			m[fmt.Sprint(k)] = toObject(v)
+		}
+		return object.NewMap(m)
+	}
+	return object.FromGoType(value)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("cbor", map[string]object.Object{
+		"marshal":   object.NewBuiltin("marshal", Marshal),
+		"unmarshal": object.NewBuiltin("unmarshal", Unmarshal),
+		"valid":     object.NewBuiltin("valid", Valid),
+		"reader":    object.NewBuiltin("reader", Reader),
+		"write":     object.NewBuiltin("write", Write),
+	})
+}
@@ -0,0 +1,69 @@
+package cbor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	value := object.NewMap(map[string]object.Object{
+		"int":   object.NewInt(7),
+		"neg":   object.NewInt(-300),
+		"float": object.NewFloat(1.5),
+		"str":   object.NewString("hi"),
+		"bool":  object.True,
+		"nil":   object.Nil,
+		"bytes": object.NewByteSlice([]byte{1, 2}),
+		"list":  object.NewList([]object.Object{object.NewInt(1), object.NewString("a")}),
+		"time":  object.NewTime(ts),
+	})
+	data := Marshal(ctx, value)
+	require.IsType(t, &object.ByteSlice{}, data)
+	require.Equal(t, object.True, Valid(ctx, data))
+
+	result := Unmarshal(ctx, data)
+	require.Equal(t, value.Get("int"), result.(*object.Map).Get("int"))
+	require.Equal(t, value.Get("bytes"), result.(*object.Map).Get("bytes"))
+	require.True(t, result.(*object.Map).Get("time").(*object.Time).Value().Equal(ts))
+	result.(*object.Map).Delete("time")
+	value.Delete("time")
+	require.Equal(t, value, result)
+}
+
+func TestNonStringKeys(t *testing.T) {
+	ctx := context.Background()
+	// {1: "a"}
+	result := Unmarshal(ctx, object.NewByteSlice([]byte{0xa1, 0x01, 0x61, 'a'}))
+	require.Equal(t, object.NewMap(map[string]object.Object{"1": object.NewString("a")}), result)
+}
+
+func TestStreaming(t *testing.T) {
+	ctx := context.Background()
+	buf := object.NewBuffer(nil)
+	values := object.NewList([]object.Object{
+		object.NewInt(1),
+		object.NewMap(map[string]object.Object{"a": object.NewString("b")}),
+		object.NewString("c"),
+	})
+	require.Equal(t, object.NewInt(3), Write(ctx, buf, values))
+
+	stream := Reader(ctx, object.NewByteSlice(buf.Value().Bytes())).(*object.Stream)
+	require.Equal(t, values, stream.Collect(ctx))
+
+	stream = Reader(ctx, object.NewByteSlice([]byte{0x01, 0x65, 'a'})).(*object.Stream)
+	require.True(t, object.IsError(stream.Collect(ctx)))
+}
+
+func TestInvalid(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, object.False, Valid(ctx, object.NewByteSlice([]byte{0x65, 'a'})))
+	require.Equal(t, object.False, Valid(ctx, object.NewByteSlice([]byte{0x01, 0x02})))
+	require.True(t, object.IsError(Unmarshal(ctx, object.NewByteSlice([]byte{0x01, 0x02}))))
+	require.True(t, object.IsError(Unmarshal(ctx, object.NewByteSlice(nil))))
+}
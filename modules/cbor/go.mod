@@ -0,0 +1,19 @@
+module github.com/risor-io/risor/modules/cbor
+
+go 1.21
+
+replace github.com/risor-io/risor => ../..
+
+require (
+	github.com/fxamacker/cbor/v2 v2.6.0
+	github.com/risor-io/risor v1.1.0
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,305 @@
+package collate
+
+import (
+	"context"
+	"sort"
+	"unicode"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/text/width"
+)
+
+// twoStrings returns the two string arguments of a comparison.
+func twoStrings(fn string, args []object.Object) (string, string, *object.Error) {
+	if err := arg.Require(fn, 2, args); err != nil {
+		return "", "", err
+	}
+	a, err := object.AsString(args[0])
+	if err != nil {
+		return "", "", err
+	}
+	b, err := object.AsString(args[1])
+	if err != nil {
+		return "", "", err
+	}
+	return a, b, nil
+}
+
+// parseCollatorOptions reads the options map given as the only argument,
+// if there is one.
+func parseCollatorOptions(fn string, args []object.Object) (collatorOptions, *object.Error) {
+	var opts collatorOptions
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		ok, err := opts.parse(key, value)
+		if err != nil {
+			return opts, err
+		}
+		if !ok {
+			return opts, object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+	}
+	return opts, nil
+}
+
+type sortOptions struct {
+	reverse bool
+	key     string
+}
+
+// parseSortOptions reads the options map of a sort function, if there is
+// one. Options other than reverse and key are passed to extra, if it is
+// given, which reports whether it accepted them.
+func parseSortOptions(fn string, args []object.Object, extra func(key string, value object.Object) (bool, *object.Error)) (sortOptions, *object.Error) {
+	var opts sortOptions
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "reverse":
+			opts.reverse, err = object.AsBool(value)
+		case "key":
+			opts.key, err = object.AsString(value)
+		default:
+			ok := false
+			if extra != nil {
+				ok, err = extra(key, value)
+			}
+			if !ok {
+				err = object.Errorf("value error: unknown %s option %q", fn, key)
+			}
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// sortList returns a sorted copy of a list of strings, or of maps sorted by
+// the string at the given key. The sort is stable.
+func sortList(obj object.Object, opts sortOptions, compare func(a, b string) int) object.Object {
+	list, err := object.AsList(obj)
+	if err != nil {
+		return err
+	}
+	items := list.Value()
+	keys := make([]string, len(items))
+	for i, item := range items {
+		value := item
+		if opts.key != "" {
+			m, err := object.AsMap(item)
+			if err != nil {
+				return err
+			}
+			var ok bool
+			if value, ok = m.Value()[opts.key]; !ok {
+				return object.Errorf("value error: item %d has no key %q", i, opts.key)
+			}
+		}
+		if keys[i], err = object.AsString(value); err != nil {
+			return err
+		}
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		c := compare(keys[order[i]], keys[order[j]])
+		if opts.reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+	sorted := make([]object.Object, len(items))
+	for i, idx := range order {
+		sorted[i] = items[idx]
+	}
+	return object.NewList(sorted)
+}
+
+func NewCollatorBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("collate.collator", 0, 1, args); err != nil {
+		return err
+	}
+	opts, err := parseCollatorOptions("collate.collator", args)
+	if err != nil {
+		return err
+	}
+	return NewCollator(opts)
+}
+
+func Compare(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("collate.compare", 2, 3, args); err != nil {
+		return err
+	}
+	a, b, err := twoStrings("collate.compare", args[:2])
+	if err != nil {
+		return err
+	}
+	opts, err := parseCollatorOptions("collate.compare", args[2:])
+	if err != nil {
+		return err
+	}
+	return object.NewInt(int64(NewCollator(opts).Compare(a, b)))
+}
+
+func Equal(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("collate.equal", 2, 3, args); err != nil {
+		return err
+	}
+	a, b, err := twoStrings("collate.equal", args[:2])
+	if err != nil {
+		return err
+	}
+	opts, err := parseCollatorOptions("collate.equal", args[2:])
+	if err != nil {
+		return err
+	}
+	return object.NewBool(NewCollator(opts).Compare(a, b) == 0)
+}
+
+func Sort(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("collate.sort", 1, 2, args); err != nil {
+		return err
+	}
+	var collator collatorOptions
+	opts, err := parseSortOptions("collate.sort", args[1:], collator.parse)
+	if err != nil {
+		return err
+	}
+	return sortList(args[0], opts, NewCollator(collator).Compare)
+}
+
+func NaturalCompareBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("collate.natural_compare", 2, 3, args); err != nil {
+		return err
+	}
+	a, b, err := twoStrings("collate.natural_compare", args[:2])
+	if err != nil {
+		return err
+	}
+	var ignoreCase bool
+	if len(args) == 3 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "ignore_case":
+				ignoreCase, err = object.AsBool(value)
+			default:
+				err = object.Errorf("value error: unknown collate.natural_compare option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return object.NewInt(int64(NaturalCompare(a, b, ignoreCase)))
+}
+
+func NaturalSort(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("collate.natural_sort", 1, 2, args); err != nil {
+		return err
+	}
+	var ignoreCase bool
+	opts, err := parseSortOptions("collate.natural_sort", args[1:], func(key string, value object.Object) (bool, *object.Error) {
+		if key != "ignore_case" {
+			return false, nil
+		}
+		var err *object.Error
+		ignoreCase, err = object.AsBool(value)
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	return sortList(args[0], opts, func(a, b string) int {
+		return NaturalCompare(a, b, ignoreCase)
+	})
+}
+
+// Fold returns the string with case, diacritics, and width differences
+// removed, as selected by the options, which all default to true.
+func Fold(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("collate.fold", 1, 2, args); err != nil {
+		return err
+	}
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	foldCase, foldDiacritics, foldWidth := true, true, true
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "case":
+				foldCase, err = object.AsBool(value)
+			case "diacritics":
+				foldDiacritics, err = object.AsBool(value)
+			case "width":
+				foldWidth, err = object.AsBool(value)
+			default:
+				err = object.Errorf("value error: unknown collate.fold option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var transformers []transform.Transformer
+	if foldDiacritics {
+		// Decompose characters so accents become separate combining marks,
+		// drop the marks, and recompose what is left
+		transformers = append(transformers, norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	}
+	if foldWidth {
+		transformers = append(transformers, width.Fold)
+	}
+	if foldCase {
+		transformers = append(transformers, cases.Fold())
+	}
+	if len(transformers) == 0 {
+		return object.NewString(s)
+	}
+	result, _, transformErr := transform.String(transform.Chain(transformers...), s)
+	if transformErr != nil {
+		return object.NewError(transformErr)
+	}
+	return object.NewString(result)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("collate", map[string]object.Object{
+		"collator":        object.NewBuiltin("collator", NewCollatorBuiltin),
+		"compare":         object.NewBuiltin("compare", Compare),
+		"equal":           object.NewBuiltin("equal", Equal),
+		"fold":            object.NewBuiltin("fold", Fold),
+		"natural_compare": object.NewBuiltin("natural_compare", NaturalCompareBuiltin),
+		"natural_sort":    object.NewBuiltin("natural_sort", NaturalSort),
+		"sort":            object.NewBuiltin("sort", Sort),
+	})
+}
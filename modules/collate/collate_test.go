@@ -0,0 +1,166 @@
+package collate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func opts(m map[string]interface{}) object.Object {
+	return object.FromGoType(m)
+}
+
+func stringsOf(t *testing.T, obj object.Object) []string {
+	t.Helper()
+	values, err := object.AsStringSlice(obj)
+	require.Nil(t, err, obj.Inspect())
+	return values
+}
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"file2", "file10", -1},
+		{"file10", "file2", 1},
+		{"file10", "file10", 0},
+		{"v1.2.10", "v1.10.0", -1},
+		{"a01", "a1", -1},
+		{"a001b", "a1c", -1},
+		{"file", "file1", -1},
+		{"", "a", -1},
+		{"B", "a", -1},
+		{"x99999999999999999999999", "x100000000000000000000000", -1},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, NaturalCompare(tt.a, tt.b, false), "%q %q", tt.a, tt.b)
+	}
+	require.Equal(t, 1, NaturalCompare("b", "A", true))
+	require.Equal(t, 0, NaturalCompare("File2", "file2", true))
+}
+
+func TestNaturalSort(t *testing.T) {
+	ctx := context.Background()
+	files := object.NewStringList([]string{"img12.png", "img10.png", "IMG2.png", "img1.png"})
+	require.Equal(t,
+		[]string{"IMG2.png", "img1.png", "img10.png", "img12.png"},
+		stringsOf(t, NaturalSort(ctx, files)))
+	require.Equal(t,
+		[]string{"img1.png", "IMG2.png", "img10.png", "img12.png"},
+		stringsOf(t, NaturalSort(ctx, files, opts(map[string]interface{}{"ignore_case": true}))))
+	require.Equal(t,
+		[]string{"img12.png", "img10.png", "IMG2.png", "img1.png"},
+		stringsOf(t, NaturalSort(ctx, files, opts(map[string]interface{}{"ignore_case": true, "reverse": true}))))
+	// The original list is left as it was
+	require.Equal(t, "img12.png", stringsOf(t, files)[0])
+
+	rows := object.FromGoType([]interface{}{
+		map[string]interface{}{"name": "node10"},
+		map[string]interface{}{"name": "node9"},
+	})
+	sorted := NaturalSort(ctx, rows, opts(map[string]interface{}{"key": "name"})).(*object.List)
+	require.Equal(t, object.NewString("node9"), sorted.Value()[0].(*object.Map).Get("name"))
+}
+
+func TestSort(t *testing.T) {
+	ctx := context.Background()
+	words := object.NewStringList([]string{"zebra", "Äpfel", "apple", "Zürich", "éclair", "eclair"})
+	// By default, letters with accents sort with the plain letters
+	require.Equal(t,
+		[]string{"Äpfel", "apple", "eclair", "éclair", "zebra", "Zürich"},
+		stringsOf(t, Sort(ctx, words)))
+
+	// Swedish sorts Ä after Z
+	require.Equal(t,
+		[]string{"apple", "eclair", "éclair", "zebra", "Zürich", "Äpfel"},
+		stringsOf(t, Sort(ctx, words, opts(map[string]interface{}{"locale": "sv"}))))
+
+	require.Equal(t,
+		[]string{"file1", "file2", "file10"},
+		stringsOf(t, Sort(ctx, object.NewStringList([]string{"file10", "file2", "file1"}), opts(map[string]interface{}{"natural": true}))))
+}
+
+func TestCompare(t *testing.T) {
+	ctx := context.Background()
+	a, b := object.NewString("Resume"), object.NewString("résumé")
+	require.Equal(t, object.NewInt(-1), Compare(ctx, a, b))
+	require.Equal(t, object.False, Equal(ctx, a, b))
+	require.Equal(t, object.False, Equal(ctx, a, b, opts(map[string]interface{}{"ignore_case": true})))
+	require.Equal(t, object.True, Equal(ctx, a, b, opts(map[string]interface{}{"ignore_case": true, "ignore_diacritics": true})))
+	require.Equal(t, object.True, Equal(ctx, object.NewString("ＡＢＣ"), object.NewString("abc"), opts(map[string]interface{}{"ignore_case": true})))
+
+	c := NewCollatorBuiltin(ctx, opts(map[string]interface{}{"locale": "de", "natural": true}))
+	collator, ok := c.(*Collator)
+	require.True(t, ok, c.Inspect())
+	require.Equal(t, "collate.collator(locale=de)", collator.Inspect())
+	require.Equal(t, -1, collator.Compare("Schritt 9", "Schritt 10"))
+	// Sort keys order the same way as the strings they came from
+	k1, k2 := collator.Key("Straße 9"), collator.Key("Strasse 10")
+	require.Equal(t, collator.Compare("Straße 9", "Strasse 10") < 0, string(k1) < string(k2))
+}
+
+func TestFold(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, object.NewString("creme brulee"), Fold(ctx, object.NewString("Crème Brûlée")))
+	require.Equal(t, object.NewString("Creme Brulee"), Fold(ctx, object.NewString("Crème Brûlée"), opts(map[string]interface{}{"case": false})))
+	require.Equal(t, object.NewString("crème brûlée"), Fold(ctx, object.NewString("Crème Brûlée"), opts(map[string]interface{}{"diacritics": false})))
+	require.Equal(t, object.NewString("abc123"), Fold(ctx, object.NewString("ＡＢＣ１２３")))
+}
+
+func TestOptionErrors(t *testing.T) {
+	ctx := context.Background()
+	list := object.NewStringList([]string{"a"})
+	attr, _ := NewCollator(collatorOptions{}).GetAttr("sort")
+	collatorSort := attr.(*object.Builtin)
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"invalid locale",
+			Sort(ctx, list, opts(map[string]interface{}{"locale": "not a locale"})),
+			`value error: invalid locale "not a locale"`,
+		},
+		{
+			"unknown sort option",
+			Sort(ctx, list, opts(map[string]interface{}{"numeric": true})),
+			`value error: unknown collate.sort option "numeric"`,
+		},
+		{
+			"locale on natural_sort",
+			NaturalSort(ctx, list, opts(map[string]interface{}{"locale": "en"})),
+			`value error: unknown collate.natural_sort option "locale"`,
+		},
+		{
+			"reverse on compare",
+			Compare(ctx, object.NewString("a"), object.NewString("b"), opts(map[string]interface{}{"reverse": true})),
+			`value error: unknown collate.compare option "reverse"`,
+		},
+		{
+			"missing key",
+			Sort(ctx, object.FromGoType([]interface{}{map[string]interface{}{"id": 1}}), opts(map[string]interface{}{"key": "name"})),
+			`value error: item 0 has no key "name"`,
+		},
+		{
+			"ignore_case on collator sort",
+			collatorSort.Call(ctx, list, opts(map[string]interface{}{"ignore_case": true})),
+			`value error: unknown collate.collator.sort option "ignore_case"`,
+		},
+		{
+			"unknown fold option",
+			Fold(ctx, object.NewString("a"), opts(map[string]interface{}{"accents": false})),
+			`value error: unknown collate.fold option "accents"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
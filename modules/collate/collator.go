@@ -0,0 +1,193 @@
+package collate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+const COLLATOR object.Type = "collate.collator"
+
+// collatorOptions describe how strings are compared.
+type collatorOptions struct {
+	locale           language.Tag
+	natural          bool
+	ignoreCase       bool
+	ignoreDiacritics bool
+}
+
+// parse sets the option with the given key, if it is a collator option,
+// and reports whether it was.
+func (o *collatorOptions) parse(key string, value object.Object) (bool, *object.Error) {
+	var err *object.Error
+	switch key {
+	case "locale":
+		var locale string
+		if locale, err = object.AsString(value); err == nil {
+			tag, parseErr := language.Parse(locale)
+			if parseErr != nil {
+				err = object.Errorf("value error: invalid locale %q", locale)
+			} else {
+				o.locale = tag
+			}
+		}
+	case "natural":
+		o.natural, err = object.AsBool(value)
+	case "ignore_case":
+		o.ignoreCase, err = object.AsBool(value)
+	case "ignore_diacritics":
+		o.ignoreDiacritics, err = object.AsBool(value)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+func (o *collatorOptions) options() []collate.Option {
+	var opts []collate.Option
+	if o.natural {
+		opts = append(opts, collate.Numeric)
+	}
+	if o.ignoreCase {
+		opts = append(opts, collate.IgnoreCase)
+	}
+	if o.ignoreDiacritics {
+		opts = append(opts, collate.IgnoreDiacritics)
+	}
+	return opts
+}
+
+// Collator compares strings according to the rules of a locale.
+type Collator struct {
+	// The underlying collator reuses internal buffers, so it must not be
+	// used by more than one goroutine at a time
+	mu       sync.Mutex
+	collator *collate.Collator
+	opts     collatorOptions
+}
+
+// NewCollator returns a Collator with the given options.
+func NewCollator(opts collatorOptions) *Collator {
+	return &Collator{
+		collator: collate.New(opts.locale, opts.options()...),
+		opts:     opts,
+	}
+}
+
+// Compare returns -1, 0, or 1, depending on whether a sorts before, the
+// same as, or after b.
+func (c *Collator) Compare(a, b string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.collator.CompareString(a, b)
+}
+
+// Key returns a sort key for the string. Comparing the keys of two strings
+// byte by byte gives the same result as comparing the strings.
+func (c *Collator) Key(s string) []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var buf collate.Buffer
+	key := c.collator.KeyFromString(&buf, s)
+	return append([]byte(nil), key...)
+}
+
+func (c *Collator) Type() object.Type {
+	return COLLATOR
+}
+
+func (c *Collator) Inspect() string {
+	return fmt.Sprintf("collate.collator(locale=%s)", c.opts.locale)
+}
+
+func (c *Collator) String() string {
+	return c.Inspect()
+}
+
+func (c *Collator) Interface() interface{} {
+	return c.collator
+}
+
+func (c *Collator) Equals(other object.Object) object.Object {
+	return object.NewBool(c == other)
+}
+
+func (c *Collator) IsTruthy() bool {
+	return true
+}
+
+func (c *Collator) Cost() int {
+	return 0
+}
+
+func (c *Collator) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", COLLATOR)
+}
+
+func (c *Collator) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", COLLATOR, opType)
+}
+
+func (c *Collator) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", COLLATOR, name)
+}
+
+func (c *Collator) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "locale":
+		return object.NewString(c.opts.locale.String()), true
+	case "compare":
+		return object.NewBuiltin("collate.collator.compare", c.compare), true
+	case "equal":
+		return object.NewBuiltin("collate.collator.equal", c.equal), true
+	case "key":
+		return object.NewBuiltin("collate.collator.key", c.key), true
+	case "sort":
+		return object.NewBuiltin("collate.collator.sort", c.sort), true
+	}
+	return nil, false
+}
+
+func (c *Collator) compare(ctx context.Context, args ...object.Object) object.Object {
+	a, b, err := twoStrings("collate.collator.compare", args)
+	if err != nil {
+		return err
+	}
+	return object.NewInt(int64(c.Compare(a, b)))
+}
+
+func (c *Collator) equal(ctx context.Context, args ...object.Object) object.Object {
+	a, b, err := twoStrings("collate.collator.equal", args)
+	if err != nil {
+		return err
+	}
+	return object.NewBool(c.Compare(a, b) == 0)
+}
+
+func (c *Collator) key(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("collate.collator.key", 1, args); err != nil {
+		return err
+	}
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewByteSlice(c.Key(s))
+}
+
+func (c *Collator) sort(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("collate.collator.sort", 1, 2, args); err != nil {
+		return err
+	}
+	opts, err := parseSortOptions("collate.collator.sort", args[1:], nil)
+	if err != nil {
+		return err
+	}
+	return sortList(args[0], opts, c.Compare)
+}
@@ -0,0 +1,74 @@
+package collate
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NaturalCompare compares two strings, treating runs of digits as numbers,
+// so that "file2" sorts before "file10". Other characters are compared by
+// code point, ignoring case if ignoreCase is true. It returns -1, 0, or 1.
+func NaturalCompare(a, b string, ignoreCase bool) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		ra, sa := utf8.DecodeRuneInString(a[i:])
+		rb, sb := utf8.DecodeRuneInString(b[j:])
+		if isDigit(ra) && isDigit(rb) {
+			na, nb := digitRun(a[i:]), digitRun(b[j:])
+			if c := compareNumbers(na, nb); c != 0 {
+				return c
+			}
+			i += len(na)
+			j += len(nb)
+			continue
+		}
+		if ignoreCase {
+			ra, rb = unicode.ToLower(ra), unicode.ToLower(rb)
+		}
+		if ra != rb {
+			if ra < rb {
+				return -1
+			}
+			return 1
+		}
+		i += sa
+		j += sb
+	}
+	switch {
+	case i < len(a):
+		return 1
+	case j < len(b):
+		return -1
+	}
+	// Strings that differ only in leading zeros or case are still ordered,
+	// so that sorting is deterministic
+	if ignoreCase {
+		return 0
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func digitRun(s string) string {
+	n := 0
+	for n < len(s) && isDigit(rune(s[n])) {
+		n++
+	}
+	return s[:n]
+}
+
+// compareNumbers compares two runs of decimal digits by their value.
+func compareNumbers(a, b string) int {
+	ta, tb := strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(ta) != len(tb) {
+		if len(ta) < len(tb) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(ta, tb)
+}
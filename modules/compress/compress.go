@@ -0,0 +1,295 @@
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/limits"
+	"github.com/risor-io/risor/object"
+)
+
+const (
+	formatGzip  = "gzip"
+	formatZstd  = "zstd"
+	formatBzip2 = "bzip2"
+)
+
+var magics = []struct {
+	format string
+	prefix []byte
+}{
+	{formatGzip, []byte{0x1f, 0x8b}},
+	{formatZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{formatBzip2, []byte("BZh")},
+}
+
+// detectFormat returns the compression format of data that starts with the
+// given bytes, or an empty string if it is not recognized.
+func detectFormat(header []byte) string {
+	for _, magic := range magics {
+		if bytes.HasPrefix(header, magic.prefix) {
+			return magic.format
+		}
+	}
+	return ""
+}
+
+func checkFormat(format string) *object.Error {
+	switch format {
+	case formatGzip, formatZstd, formatBzip2:
+		return nil
+	}
+	return object.Errorf("value error: compression format must be \"gzip\", \"zstd\", or \"bzip2\" (got %q)", format)
+}
+
+// NewCompressor returns a WriteCloser that compresses data written to it in
+// the given format and writes it to w. A level of zero selects the default
+// level of the format. Closing the compressor does not close w.
+func NewCompressor(w io.Writer, format string, level int) (io.WriteCloser, error) {
+	switch format {
+	case formatGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	case formatZstd:
+		opts := []zstd.EOption{}
+		if level != 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		return zstd.NewWriter(w, opts...)
+	case formatBzip2:
+		return bzip2.NewWriter(w, &bzip2.WriterConfig{Level: level})
+	}
+	return nil, fmt.Errorf("value error: unsupported compression format %q", format)
+}
+
+// NewDecompressor returns a ReadCloser that decompresses data read from r. If
+// the format is empty, it is detected from the data.
+func NewDecompressor(r io.Reader, format string) (io.ReadCloser, error) {
+	if format == "" {
+		br := bufio.NewReader(r)
+		header, _ := br.Peek(4)
+		if format = detectFormat(header); format == "" {
+			return nil, fmt.Errorf("value error: unable to detect compression format")
+		}
+		r = br
+	}
+	switch format {
+	case formatGzip:
+		return gzip.NewReader(r)
+	case formatZstd:
+		dec, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	case formatBzip2:
+		return bzip2.NewReader(r, nil)
+	}
+	return nil, fmt.Errorf("value error: unsupported compression format %q", format)
+}
+
+func asLevel(format string, args []object.Object) (int, *object.Error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return 0, err
+	}
+	level := 0
+	for key, value := range m.Value() {
+		switch key {
+		case "level":
+			n, err := object.AsInt(value)
+			if err != nil {
+				return 0, err
+			}
+			min, max := int64(1), int64(9)
+			if format == formatZstd {
+				max = 22
+			}
+			if n < min || n > max {
+				return 0, object.Errorf("value error: %s compression level must be between %d and %d (got %d)", format, min, max, n)
+			}
+			level = int(n)
+		default:
+			return 0, object.Errorf("value error: unknown compression option %q", key)
+		}
+	}
+	return level, nil
+}
+
+func asInput(obj object.Object) (io.Reader, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.ByteSlice:
+		return bytes.NewReader(obj.Value()), nil
+	case *object.String:
+		return bytes.NewReader([]byte(obj.Value())), nil
+	}
+	return object.AsReader(obj)
+}
+
+func readAll(ctx context.Context, r io.Reader) ([]byte, error) {
+	if lim, ok := limits.GetLimits(ctx); ok {
+		return lim.ReadAll(r)
+	}
+	return io.ReadAll(r)
+}
+
+// Compress compresses data in the given format.
+func Compress(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("compress.compress", 2, 3, args); err != nil {
+		return err
+	}
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	format, err := object.AsString(args[1])
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	level, err := asLevel(format, args[2:])
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w, compressErr := NewCompressor(&buf, format, level)
+	if compressErr != nil {
+		return object.NewError(compressErr)
+	}
+	if _, err := w.Write(data); err != nil {
+		return object.NewError(err)
+	}
+	if err := w.Close(); err != nil {
+		return object.NewError(err)
+	}
+	return object.NewByteSlice(buf.Bytes())
+}
+
+// Decompress decompresses data. The format is detected if it is not given.
+func Decompress(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("compress.decompress", 1, 2, args); err != nil {
+		return err
+	}
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	format, err := optionalFormat(args[1:])
+	if err != nil {
+		return err
+	}
+	r, decompressErr := NewDecompressor(bytes.NewReader(data), format)
+	if decompressErr != nil {
+		return object.NewError(decompressErr)
+	}
+	defer r.Close()
+	result, readErr := readAll(ctx, r)
+	if readErr != nil {
+		return object.NewError(readErr)
+	}
+	return object.NewByteSlice(result)
+}
+
+func optionalFormat(args []object.Object) (string, *object.Error) {
+	if len(args) == 0 || args[0] == object.Nil {
+		return "", nil
+	}
+	format, err := object.AsString(args[0])
+	if err != nil {
+		return "", err
+	}
+	if err := checkFormat(format); err != nil {
+		return "", err
+	}
+	return format, nil
+}
+
+// NewReader returns a reader that decompresses the given reader or data as
+// it is read.
+func NewReader(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("compress.reader", 1, 2, args); err != nil {
+		return err
+	}
+	src, err := asInput(args[0])
+	if err != nil {
+		return err
+	}
+	format, err := optionalFormat(args[1:])
+	if err != nil {
+		return err
+	}
+	r, decompressErr := NewDecompressor(src, format)
+	if decompressErr != nil {
+		return object.NewError(decompressErr)
+	}
+	return object.NewReader(r)
+}
+
+// NewWriter returns a writer that compresses data written to it and writes
+// the result to the given writer. The writer must be closed to finish the
+// compressed stream.
+func NewWriter(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("compress.writer", 2, 3, args); err != nil {
+		return err
+	}
+	dst, err := object.AsWriter(args[0])
+	if err != nil {
+		return err
+	}
+	format, err := object.AsString(args[1])
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	level, err := asLevel(format, args[2:])
+	if err != nil {
+		return err
+	}
+	w, compressErr := NewCompressor(dst, format, level)
+	if compressErr != nil {
+		return object.NewError(compressErr)
+	}
+	return object.NewWriter(w)
+}
+
+// Detect returns the compression format of the data, or nil if the format
+// is not recognized.
+func Detect(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("compress.detect", 1, args); err != nil {
+		return err
+	}
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	if format := detectFormat(data); format != "" {
+		return object.NewString(format)
+	}
+	return object.Nil
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("compress", map[string]object.Object{
+		"compress":   object.NewBuiltin("compress", Compress),
+		"decompress": object.NewBuiltin("decompress", Decompress),
+		"detect":     object.NewBuiltin("detect", Detect),
+		"reader":     object.NewBuiltin("reader", NewReader),
+		"writer":     object.NewBuiltin("writer", NewWriter),
+	})
+}
@@ -0,0 +1,86 @@
+package compress
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	input := object.NewString(strings.Repeat("GET /index.html 200\n", 100))
+	for _, format := range []string{"gzip", "zstd", "bzip2"} {
+		t.Run(format, func(t *testing.T) {
+			compressed, ok := Compress(ctx, input, object.NewString(format),
+				object.NewMap(map[string]object.Object{"level": object.NewInt(9)})).(*object.ByteSlice)
+			require.True(t, ok)
+			require.Less(t, len(compressed.Value()), 2000)
+			require.Equal(t, object.NewString(format), Detect(ctx, compressed))
+
+			// Detected from the content
+			result := Decompress(ctx, compressed)
+			require.Equal(t, object.NewByteSlice([]byte(input.Value())), result)
+			result = Decompress(ctx, compressed, object.NewString(format))
+			require.Equal(t, object.NewByteSlice([]byte(input.Value())), result)
+		})
+	}
+}
+
+func TestStreaming(t *testing.T) {
+	ctx := context.Background()
+	for _, format := range []string{"gzip", "zstd", "bzip2"} {
+		t.Run(format, func(t *testing.T) {
+			buf := object.NewBuffer(nil)
+			w, ok := NewWriter(ctx, buf, object.NewString(format)).(*object.Writer)
+			require.True(t, ok)
+			for _, line := range []string{"first\n", "second\n", "third\n"} {
+				_, err := w.Write([]byte(line))
+				require.Nil(t, err)
+			}
+			require.Nil(t, w.Close())
+
+			r, ok := NewReader(ctx, object.NewReader(bytes.NewReader(buf.Value().Bytes()))).(*object.Reader)
+			require.True(t, ok)
+			var lines []string
+			for {
+				line, ok, err := r.ReadLine()
+				require.Nil(t, err)
+				if !ok {
+					break
+				}
+				lines = append(lines, line)
+			}
+			require.Equal(t, []string{"first", "second", "third"}, lines)
+			require.Nil(t, r.Close())
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	data := object.NewString("plain text")
+	tests := []struct {
+		result object.Object
+		err    string
+	}{
+		{Compress(ctx, data, object.NewString("lz4")), `value error: compression format must be "gzip", "zstd", or "bzip2" (got "lz4")`},
+		{Compress(ctx, data, object.NewString("gzip"), object.NewMap(map[string]object.Object{"level": object.NewInt(10)})),
+			"value error: gzip compression level must be between 1 and 9 (got 10)"},
+		{Compress(ctx, data, object.NewString("zstd"), object.NewMap(map[string]object.Object{"speed": object.NewInt(1)})),
+			`value error: unknown compression option "speed"`},
+		{Decompress(ctx, data), "value error: unable to detect compression format"},
+		{NewReader(ctx, data), "value error: unable to detect compression format"},
+	}
+	for _, tt := range tests {
+		errObj, ok := tt.result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", tt.result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+	require.Equal(t, object.Nil, Detect(ctx, data))
+	_, ok := Decompress(ctx, data, object.NewString("gzip")).(*object.Error)
+	require.True(t, ok)
+}
@@ -0,0 +1,321 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const EXPRESSION object.Type = "cron.expression"
+
+// Expression is a Risor object wrapping a parsed cron expression.
+type Expression struct {
+	expr *Expr
+}
+
+// NewExpression returns an Expression for a parsed cron expression.
+func NewExpression(expr *Expr) *Expression {
+	return &Expression{expr: expr}
+}
+
+func (e *Expression) Type() object.Type {
+	return EXPRESSION
+}
+
+func (e *Expression) Inspect() string {
+	return fmt.Sprintf("cron.expression(%q)", e.expr.Spec())
+}
+
+func (e *Expression) Interface() interface{} {
+	return nil
+}
+
+// Value returns the parsed cron expression.
+func (e *Expression) Value() *Expr {
+	return e.expr
+}
+
+func (e *Expression) Equals(other object.Object) object.Object {
+	o, ok := other.(*Expression)
+	return object.NewBool(ok && o.expr.Spec() == e.expr.Spec() && o.expr.Location() == e.expr.Location())
+}
+
+func (e *Expression) IsTruthy() bool {
+	return true
+}
+
+func (e *Expression) Cost() int {
+	return 0
+}
+
+func (e *Expression) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", e.expr.Spec())), nil
+}
+
+func (e *Expression) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", EXPRESSION, opType)
+}
+
+func (e *Expression) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", EXPRESSION, name)
+}
+
+func (e *Expression) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "spec":
+		return object.NewString(e.expr.Spec()), true
+	case "timezone":
+		return object.NewString(e.expr.Location().String()), true
+	case "next":
+		return object.NewBuiltin("cron.expression.next", func(ctx context.Context, args ...object.Object) object.Object {
+			t, err := arg.OptionalTime(ctx, "cron.expression.next", args)
+			if err != nil {
+				return err
+			}
+			return timeResult(e.expr.Next(t))
+		}), true
+	case "prev":
+		return object.NewBuiltin("cron.expression.prev", func(ctx context.Context, args ...object.Object) object.Object {
+			t, err := arg.OptionalTime(ctx, "cron.expression.prev", args)
+			if err != nil {
+				return err
+			}
+			return timeResult(e.expr.Prev(t))
+		}), true
+	case "upcoming":
+		return object.NewBuiltin("cron.expression.upcoming", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("cron.expression.upcoming", 1, 2, args); err != nil {
+				return err
+			}
+			n, err := object.AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			if n < 0 || n > 1000 {
+				return object.Errorf("value error: count must be between 0 and 1000 (got %d)", n)
+			}
+			t, err := arg.OptionalTime(ctx, "cron.expression.upcoming", args[1:])
+			if err != nil {
+				return err
+			}
+			times := make([]object.Object, 0, n)
+			for i := int64(0); i < n; i++ {
+				next, err := e.expr.Next(t)
+				if err != nil {
+					return object.NewError(err)
+				}
+				times = append(times, object.NewTime(next))
+				t = next
+			}
+			return object.NewList(times)
+		}), true
+	case "matches":
+		return object.NewBuiltin("cron.expression.matches", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("cron.expression.matches", 1, args); err != nil {
+				return err
+			}
+			t, err := object.AsTime(args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewBool(e.expr.Matches(t))
+		}), true
+	}
+	return nil, false
+}
+
+func timeResult(t time.Time, err error) object.Object {
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewTime(t)
+}
+
+// parseOptions returns the options given in a map. The allowed keys are
+// checked, and the location defaults to the local time zone.
+func parseOptions(fn string, args []object.Object, allowed ...string) (*time.Location, int64, *object.Error) {
+	location, count := time.Local, int64(0)
+	if len(args) == 0 {
+		return location, count, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return nil, 0, err
+	}
+	for key, value := range m.Value() {
+		if !contains(allowed, key) {
+			return nil, 0, object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		switch key {
+		case "timezone":
+			name, err := object.AsString(value)
+			if err != nil {
+				return nil, 0, err
+			}
+			loc, ioErr := time.LoadLocation(name)
+			if ioErr != nil {
+				return nil, 0, object.Errorf("value error: invalid time zone %q", name)
+			}
+			location = loc
+		case "count":
+			if count, err = object.AsInt(value); err != nil {
+				return nil, 0, err
+			}
+			if count < 0 {
+				return nil, 0, object.Errorf("value error: count must not be negative (got %d)", count)
+			}
+		}
+	}
+	return location, count, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func parse(fn string, spec object.Object, options []object.Object, allowed ...string) (*Expr, int64, *object.Error) {
+	text, errObj := object.AsString(spec)
+	if errObj != nil {
+		return nil, 0, errObj
+	}
+	location, count, errObj := parseOptions(fn, options, allowed...)
+	if errObj != nil {
+		return nil, 0, errObj
+	}
+	expr, err := Parse(text, location)
+	if err != nil {
+		return nil, 0, object.NewError(err)
+	}
+	return expr, count, nil
+}
+
+// ParseBuiltin parses a cron expression.
+func ParseBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("cron.parse", 1, 2, args); err != nil {
+		return err
+	}
+	expr, _, err := parse("cron.parse", args[0], args[1:], "timezone")
+	if err != nil {
+		return err
+	}
+	return NewExpression(expr)
+}
+
+// IsValid returns true if the string is a valid cron expression.
+func IsValid(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("cron.is_valid", 1, args); err != nil {
+		return err
+	}
+	spec, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	_, parseErr := Parse(spec, time.Local)
+	return object.NewBool(parseErr == nil)
+}
+
+// Next returns the first time matching a cron expression after the given
+// time, or after the current time.
+func Next(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("cron.next", 1, 2, args); err != nil {
+		return err
+	}
+	expr, _, err := parse("cron.next", args[0], nil)
+	if err != nil {
+		return err
+	}
+	t, err := arg.OptionalTime(ctx, "cron.next", args[1:])
+	if err != nil {
+		return err
+	}
+	return timeResult(expr.Next(t))
+}
+
+// Prev returns the last time matching a cron expression before the given
+// time, or before the current time.
+func Prev(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("cron.prev", 1, 2, args); err != nil {
+		return err
+	}
+	expr, _, err := parse("cron.prev", args[0], nil)
+	if err != nil {
+		return err
+	}
+	t, err := arg.OptionalTime(ctx, "cron.prev", args[1:])
+	if err != nil {
+		return err
+	}
+	return timeResult(expr.Prev(t))
+}
+
+// Schedule calls a function each time a cron expression matches, until the
+// context is cancelled or the optional count of runs is reached. Returns the
+// number of runs. An error raised by the function stops the schedule.
+func Schedule(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("cron.schedule", 2, 3, args); err != nil {
+		return err
+	}
+	fn := args[0]
+	if _, ok := fn.(*object.Function); !ok {
+		if _, ok := fn.(object.Callable); !ok {
+			return object.Errorf("type error: cron.schedule() expected a function (%s given)", fn.Type())
+		}
+	}
+	var expr *Expr
+	var count int64
+	var errObj *object.Error
+	if e, ok := args[1].(*Expression); ok {
+		expr = e.Value()
+		_, count, errObj = parseOptions("cron.schedule", args[2:], "count")
+	} else {
+		expr, count, errObj = parse("cron.schedule", args[1], args[2:], "timezone", "count")
+	}
+	if errObj != nil {
+		return errObj
+	}
+	var runs int64
+	clock := object.GetDefaultClock(ctx)
+	last := clock.Now()
+	for count == 0 || runs < count {
+		next, err := expr.Next(last)
+		if err != nil {
+			return object.NewError(err)
+		}
+		timer := clock.NewTimer(next.Sub(clock.Now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return object.NewInt(runs)
+		case <-timer.C():
+		}
+		if _, err := object.Call(ctx, fn, nil); err != nil {
+			return object.NewError(err)
+		}
+		runs++
+		// Runs that were due while the function was running are skipped
+		last = next
+		if now := clock.Now(); now.Sub(last) >= expr.every {
+			last = now
+		}
+	}
+	return object.NewInt(runs)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("cron", map[string]object.Object{
+		"is_valid": object.NewBuiltin("is_valid", IsValid),
+		"next":     object.NewBuiltin("next", Next),
+		"parse":    object.NewBuiltin("parse", ParseBuiltin),
+		"prev":     object.NewBuiltin("prev", Prev),
+		"schedule": object.NewBuiltin("schedule", Schedule),
+	})
+}
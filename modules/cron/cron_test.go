@@ -0,0 +1,147 @@
+package cron
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, spec string, loc *time.Location) *Expr {
+	t.Helper()
+	expr, err := Parse(spec, loc)
+	require.Nil(t, err)
+	return expr
+}
+
+func TestNext(t *testing.T) {
+	start := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next string
+		prev string
+	}{
+		{"*/15 * * * *", "2024-01-31T10:30:00Z", "2024-01-31T10:15:00Z"},
+		{"0 9-17 * * mon-fri", "2024-01-31T11:00:00Z", "2024-01-31T10:00:00Z"},
+		{"30 0 29 2 *", "2024-02-29T00:30:00Z", "2020-02-29T00:30:00Z"},
+		{"0 0 1,15 * *", "2024-02-01T00:00:00Z", "2024-01-15T00:00:00Z"},
+		// The day of month and day of week match either way when both are set
+		{"0 12 13 * FRI", "2024-02-02T12:00:00Z", "2024-01-26T12:00:00Z"},
+		{"0 0 * * 7", "2024-02-04T00:00:00Z", "2024-01-28T00:00:00Z"},
+		{"10/20 * * * * *", "2024-01-31T10:17:50Z", "2024-01-31T10:17:10Z"},
+		{"@monthly", "2024-02-01T00:00:00Z", "2024-01-01T00:00:00Z"},
+		{"@every 90m", "2024-01-31T11:47:30Z", "2024-01-31T08:47:30Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			expr := mustParse(t, tt.spec, time.UTC)
+			next, err := expr.Next(start)
+			require.Nil(t, err)
+			require.Equal(t, tt.next, next.Format(time.RFC3339))
+			prev, err := expr.Prev(start)
+			require.Nil(t, err)
+			require.Equal(t, tt.prev, prev.Format(time.RFC3339))
+		})
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.Nil(t, err)
+	expr := mustParse(t, "0 9 * * *", ny)
+	next, err := expr.Next(time.Date(2024, 3, 9, 20, 0, 0, 0, time.UTC))
+	require.Nil(t, err)
+	require.Equal(t, "2024-03-10T09:00:00-04:00", next.Format(time.RFC3339))
+
+	// The time zone may be given in the expression
+	expr = mustParse(t, "CRON_TZ=Asia/Kolkata 30 6 * * *", time.UTC)
+	require.Equal(t, "Asia/Kolkata", expr.Location().String())
+	prev, err := expr.Prev(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	require.Nil(t, err)
+	require.Equal(t, "2024-06-01T06:30:00+05:30", prev.Format(time.RFC3339))
+
+	// 2:30 doesn't exist when daylight saving time starts, so that day is
+	// skipped
+	expr = mustParse(t, "30 2 * * *", ny)
+	next, err = expr.Next(time.Date(2024, 3, 10, 0, 0, 0, 0, ny))
+	require.Nil(t, err)
+	require.Equal(t, "2024-03-11T02:30:00-04:00", next.Format(time.RFC3339))
+}
+
+func TestExpression(t *testing.T) {
+	ctx := context.Background()
+	expr, ok := ParseBuiltin(ctx, object.NewString("0 */6 * * *"), object.NewMap(map[string]object.Object{
+		"timezone": object.NewString("Europe/Paris"),
+	})).(*Expression)
+	require.True(t, ok)
+	require.Equal(t, `cron.expression("0 */6 * * *")`, expr.Inspect())
+	tz, _ := expr.GetAttr("timezone")
+	require.Equal(t, object.NewString("Europe/Paris"), tz)
+
+	upcoming, _ := expr.GetAttr("upcoming")
+	start := time.Date(2024, 7, 1, 5, 0, 0, 0, time.UTC)
+	result := upcoming.(*object.Builtin).Call(ctx, object.NewInt(3), object.NewTime(start))
+	var times []string
+	for _, item := range result.(*object.List).Value() {
+		times = append(times, item.(*object.Time).Value().Format(time.RFC3339))
+	}
+	require.Equal(t, []string{"2024-07-01T12:00:00+02:00", "2024-07-01T18:00:00+02:00", "2024-07-02T00:00:00+02:00"}, times)
+
+	matches, _ := expr.GetAttr("matches")
+	require.Equal(t, object.True, matches.(*object.Builtin).Call(ctx, object.NewTime(time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC))))
+	require.Equal(t, object.False, matches.(*object.Builtin).Call(ctx, object.NewTime(start)))
+}
+
+func TestSchedule(t *testing.T) {
+	var runs int
+	fn := object.NewBuiltin("job", func(ctx context.Context, args ...object.Object) object.Object {
+		runs++
+		return object.Nil
+	})
+	result := Schedule(context.Background(), fn, object.NewString("* * * * * *"),
+		object.NewMap(map[string]object.Object{"count": object.NewInt(1)}))
+	require.Equal(t, object.NewInt(1), result)
+	require.Equal(t, 1, runs)
+
+	// The schedule stops when the context is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result = Schedule(ctx, fn, object.NewString("@hourly"))
+	require.Equal(t, object.NewInt(0), result)
+
+	// Errors raised by the function stop the schedule
+	failing := object.NewBuiltin("job", func(ctx context.Context, args ...object.Object) object.Object {
+		return object.Errorf("job failed")
+	})
+	result = Schedule(context.Background(), failing, object.NewString("* * * * * *"))
+	require.Equal(t, "job failed", result.(*object.Error).Message().Value())
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		result object.Object
+		err    string
+	}{
+		{ParseBuiltin(ctx, object.NewString("* * *")), `value error: cron expression "* * *" must have 5 or 6 fields (got 3)`},
+		{ParseBuiltin(ctx, object.NewString("61 * * * *")), "value error: minute 61 is out of range (0-59)"},
+		{ParseBuiltin(ctx, object.NewString("0 0 * JANUARY *")), `value error: invalid month "JANUARY"`},
+		{ParseBuiltin(ctx, object.NewString("0 17-9 * * *")), `value error: invalid hour range "17-9"`},
+		{ParseBuiltin(ctx, object.NewString("*/0 * * * *")), `value error: invalid minute step "0"`},
+		{ParseBuiltin(ctx, object.NewString("@fortnightly")), `value error: unknown cron macro "@fortnightly"`},
+		{ParseBuiltin(ctx, object.NewString("* * * * *"), object.NewMap(map[string]object.Object{
+			"timezone": object.NewString("Mars/Olympus"),
+		})), `value error: invalid time zone "Mars/Olympus"`},
+		{Next(ctx, object.NewString("0 0 30 2 *")), `value error: cron expression "0 0 30 2 *" never matches`},
+		{Schedule(ctx, object.NewInt(1), object.NewString("@daily")), "type error: cron.schedule() expected a function (int given)"},
+	}
+	for _, tt := range tests {
+		errObj, ok := tt.result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", tt.result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+	require.Equal(t, object.True, IsValid(ctx, object.NewString("@every 5m")))
+	require.Equal(t, object.False, IsValid(ctx, object.NewString("@every 5ms")))
+}
@@ -0,0 +1,289 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field describes the range of values allowed in a cron field.
+type field struct {
+	name  string
+	min   uint
+	max   uint
+	names map[string]uint
+}
+
+var (
+	secondField = field{name: "second", min: 0, max: 59}
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Day of week 7 is accepted as an alias for Sunday
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// searchLimit bounds the search for a matching time, so that expressions
+// that can never match, such as "0 0 30 2 *", fail rather than loop.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Expr is a parsed cron expression. Each field is a bit set of the values
+// it matches.
+type Expr struct {
+	spec     string
+	location *time.Location
+	second   uint64
+	minute   uint64
+	hour     uint64
+	dom      uint64
+	month    uint64
+	dow      uint64
+	// When both the day of month and day of week are restricted, a day
+	// matches if either of them matches
+	domStar bool
+	dowStar bool
+	// every is the interval of an "@every" expression
+	every time.Duration
+}
+
+// Parse parses a cron expression with five fields (minute, hour, day of
+// month, month, and day of week), or six with a leading seconds field. The
+// expression may also be a macro such as "@daily", or "@every" followed by
+// a duration. A "CRON_TZ=" or "TZ=" prefix sets the time zone, which
+// otherwise defaults to the given location.
+func Parse(spec string, location *time.Location) (*Expr, error) {
+	text := strings.TrimSpace(spec)
+	if strings.HasPrefix(text, "CRON_TZ=") || strings.HasPrefix(text, "TZ=") {
+		name, rest, _ := strings.Cut(text, " ")
+		_, name, _ = strings.Cut(name, "=")
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("value error: invalid time zone %q", name)
+		}
+		location = loc
+		text = strings.TrimSpace(rest)
+	}
+	e := &Expr{spec: spec, location: location}
+	if strings.HasPrefix(text, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(text[len("@every "):]))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("value error: invalid interval in cron expression %q", spec)
+		}
+		e.every = d
+		return e, nil
+	}
+	if macro, ok := macros[strings.ToLower(text)]; ok {
+		text = macro
+	} else if strings.HasPrefix(text, "@") {
+		return nil, fmt.Errorf("value error: unknown cron macro %q", text)
+	}
+	fields := strings.Fields(text)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("value error: cron expression %q must have 5 or 6 fields (got %d)", spec, len(fields))
+	}
+	var err error
+	specs := []struct {
+		set   *uint64
+		field field
+	}{
+		{&e.second, secondField},
+		{&e.minute, minuteField},
+		{&e.hour, hourField},
+		{&e.dom, domField},
+		{&e.month, monthField},
+		{&e.dow, dowField},
+	}
+	for i, s := range specs {
+		if *s.set, err = parseField(fields[i], s.field); err != nil {
+			return nil, err
+		}
+	}
+	if e.dow&(1<<7) != 0 {
+		e.dow = e.dow&^(1<<7) | 1
+	}
+	e.domStar = fields[3] == "*" || fields[3] == "?"
+	e.dowStar = fields[5] == "*" || fields[5] == "?"
+	return e, nil
+}
+
+// parseField parses a comma separated list of values, ranges, and steps.
+func parseField(text string, f field) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(part, "/")
+		var start, end uint
+		switch {
+		case rangeText == "*" || rangeText == "?":
+			start, end = f.min, f.max
+		case strings.Contains(rangeText, "-"):
+			low, high, _ := strings.Cut(rangeText, "-")
+			var err error
+			if start, err = parseValue(low, f); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(high, f); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("value error: invalid %s range %q", f.name, rangeText)
+			}
+		default:
+			var err error
+			if start, err = parseValue(rangeText, f); err != nil {
+				return 0, err
+			}
+			end = start
+			if hasStep {
+				// "5/15" means every 15 starting at 5
+				end = f.max
+			}
+		}
+		step := uint(1)
+		if hasStep {
+			n, err := strconv.ParseUint(stepText, 10, 8)
+			if err != nil || n == 0 {
+				return 0, fmt.Errorf("value error: invalid %s step %q", f.name, stepText)
+			}
+			step = uint(n)
+		}
+		for v := start; v <= end; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func parseValue(text string, f field) (uint, error) {
+	if v, ok := f.names[strings.ToLower(text)]; ok {
+		return v, nil
+	}
+	n, err := strconv.ParseUint(text, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("value error: invalid %s %q", f.name, text)
+	}
+	if uint(n) < f.min || uint(n) > f.max {
+		return 0, fmt.Errorf("value error: %s %d is out of range (%d-%d)", f.name, n, f.min, f.max)
+	}
+	return uint(n), nil
+}
+
+// Spec returns the expression as it was given.
+func (e *Expr) Spec() string {
+	return e.spec
+}
+
+// Location returns the time zone the expression is evaluated in.
+func (e *Expr) Location() *time.Location {
+	return e.location
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+func (e *Expr) dayMatches(t time.Time) bool {
+	dom := has(e.dom, t.Day())
+	dow := has(e.dow, int(t.Weekday()))
+	if e.domStar || e.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Matches returns true if the expression matches the time, to the second.
+func (e *Expr) Matches(t time.Time) bool {
+	if e.every > 0 {
+		return false
+	}
+	t = t.In(e.location)
+	return has(e.month, int(t.Month())) && e.dayMatches(t) && has(e.hour, t.Hour()) &&
+		has(e.minute, t.Minute()) && has(e.second, t.Second())
+}
+
+// Next returns the first time matching the expression after the given time.
+// For "@every" expressions, this is the given time plus the interval.
+func (e *Expr) Next(after time.Time) (time.Time, error) {
+	if e.every > 0 {
+		return after.Add(e.every), nil
+	}
+	loc := e.location
+	t := after.In(loc).Truncate(time.Second).Add(time.Second)
+	limit := t.Add(searchLimit)
+	for t.Before(limit) {
+		switch {
+		case !has(e.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !e.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !has(e.hour, t.Hour()):
+			t = startOfHour(t).Add(time.Hour)
+		case !has(e.minute, t.Minute()):
+			t = t.Truncate(time.Minute).Add(time.Minute)
+		case !has(e.second, t.Second()):
+			t = t.Add(time.Second)
+		default:
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("value error: cron expression %q never matches", e.spec)
+}
+
+// startOfHour returns the start of the hour of t, in local time.
+func startOfHour(t time.Time) time.Time {
+	return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second -
+		time.Duration(t.Nanosecond()))
+}
+
+// Prev returns the last time matching the expression before the given time.
+// For "@every" expressions, this is the given time minus the interval.
+func (e *Expr) Prev(before time.Time) (time.Time, error) {
+	if e.every > 0 {
+		return before.Add(-e.every), nil
+	}
+	loc := e.location
+	t := before.In(loc)
+	if t.Truncate(time.Second).Equal(t) {
+		t = t.Add(-time.Second)
+	} else {
+		t = t.Truncate(time.Second)
+	}
+	limit := t.Add(-searchLimit)
+	for t.After(limit) {
+		switch {
+		case !has(e.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc).Add(-time.Second)
+		case !e.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc).Add(-time.Second)
+		case !has(e.hour, t.Hour()):
+			t = startOfHour(t).Add(-time.Second)
+		case !has(e.minute, t.Minute()):
+			t = t.Truncate(time.Minute).Add(-time.Second)
+		case !has(e.second, t.Second()):
+			t = t.Add(-time.Second)
+		default:
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("value error: cron expression %q never matches", e.spec)
+}
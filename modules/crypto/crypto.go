@@ -0,0 +1,332 @@
+package crypto
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"io"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+
+	// Register the hash implementations used by hashes
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+var hashes = map[string]crypto.Hash{
+	"sha1":   crypto.SHA1,
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+var ciphers = map[string]func(key []byte) (cipher.AEAD, error){
+	"aes-gcm": func(key []byte) (cipher.AEAD, error) {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	},
+	"chacha20-poly1305":  chacha20poly1305.New,
+	"xchacha20-poly1305": chacha20poly1305.NewX,
+}
+
+func RandomBytes(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("crypto.random_bytes", 1, args); err != nil {
+		return err
+	}
+	n, err := object.AsInt(args[0])
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return object.Errorf("value error: crypto.random_bytes length must be non-negative (got %d)", n)
+	}
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return object.NewError(err)
+	}
+	return object.NewByteSlice(b)
+}
+
+// cipherOptions holds the options given to encrypt and decrypt.
+type cipherOptions struct {
+	cipher string
+	aad    []byte
+}
+
+func parseCipherOptions(args []object.Object) (cipherOptions, *object.Error) {
+	opts := cipherOptions{cipher: "aes-gcm"}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "cipher":
+			if opts.cipher, err = object.AsString(value); err != nil {
+				return opts, err
+			}
+			if _, ok := ciphers[opts.cipher]; !ok {
+				return opts, object.Errorf("value error: unknown cipher %q", opts.cipher)
+			}
+		case "aad":
+			if opts.aad, err = object.AsBytes(value); err != nil {
+				return opts, err
+			}
+		default:
+			return opts, object.Errorf("value error: unknown crypto option %q", key)
+		}
+	}
+	return opts, nil
+}
+
+// Encrypt encrypts and authenticates data with a symmetric key. A random
+// nonce is generated and prepended to the returned ciphertext.
+func Encrypt(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("crypto.encrypt", 2, 3, args); err != nil {
+		return err
+	}
+	key, errObj := object.AsBytes(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	plaintext, errObj := object.AsBytes(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	opts, errObj := parseCipherOptions(args[2:])
+	if errObj != nil {
+		return errObj
+	}
+	aead, err := ciphers[opts.cipher](key)
+	if err != nil {
+		return object.Errorf("value error: %s: %s", opts.cipher, err)
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return object.NewError(err)
+	}
+	return object.NewByteSlice(aead.Seal(nonce, nonce, plaintext, opts.aad))
+}
+
+// Decrypt decrypts and authenticates data produced by Encrypt.
+func Decrypt(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("crypto.decrypt", 2, 3, args); err != nil {
+		return err
+	}
+	key, errObj := object.AsBytes(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	data, errObj := object.AsBytes(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	opts, errObj := parseCipherOptions(args[2:])
+	if errObj != nil {
+		return errObj
+	}
+	aead, err := ciphers[opts.cipher](key)
+	if err != nil {
+		return object.Errorf("value error: %s: %s", opts.cipher, err)
+	}
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return object.Errorf("value error: ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, opts.aad)
+	if err != nil {
+		return object.Errorf("value error: decryption failed: %s", err)
+	}
+	return object.NewByteSlice(plaintext)
+}
+
+func asHash(obj object.Object) (crypto.Hash, *object.Error) {
+	name, err := object.AsString(obj)
+	if err != nil {
+		return 0, err
+	}
+	h, ok := hashes[name]
+	if !ok {
+		return 0, object.Errorf("value error: unknown hash %q (expected sha1, sha256, sha384, or sha512)", name)
+	}
+	return h, nil
+}
+
+// HKDF derives a key from a secret using HKDF.
+func HKDF(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("crypto.hkdf", 1, 2, args); err != nil {
+		return err
+	}
+	secret, errObj := object.AsBytes(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	hash := crypto.SHA256
+	var salt, info []byte
+	length := int64(32)
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "salt":
+				salt, err = object.AsBytes(value)
+			case "info":
+				info, err = object.AsBytes(value)
+			case "length":
+				length, err = object.AsInt(value)
+			case "hash":
+				hash, err = asHash(value)
+			default:
+				err = object.Errorf("value error: unknown crypto.hkdf option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if length < 1 || length > 255*int64(hash.Size()) {
+		return object.Errorf("value error: invalid hkdf length %d", length)
+	}
+	key := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(hash.New, secret, salt, info), key); err != nil {
+		return object.NewError(err)
+	}
+	return object.NewByteSlice(key)
+}
+
+// PBKDF2 derives a key from a password using PBKDF2.
+func PBKDF2(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("crypto.pbkdf2", 2, 3, args); err != nil {
+		return err
+	}
+	password, errObj := object.AsBytes(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	salt, errObj := object.AsBytes(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	hash := crypto.SHA256
+	iterations := int64(600000)
+	length := int64(32)
+	if len(args) == 3 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "iterations":
+				iterations, err = object.AsInt(value)
+			case "length":
+				length, err = object.AsInt(value)
+			case "hash":
+				hash, err = asHash(value)
+			default:
+				err = object.Errorf("value error: unknown crypto.pbkdf2 option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if iterations < 1 || length < 1 {
+		return object.Errorf("value error: pbkdf2 iterations and length must be positive")
+	}
+	return object.NewByteSlice(pbkdf2.Key(password, salt, int(iterations), int(length), hash.New))
+}
+
+// BcryptHash returns the bcrypt hash of a password.
+func BcryptHash(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("crypto.bcrypt_hash", 1, 2, args); err != nil {
+		return err
+	}
+	password, errObj := object.AsBytes(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	cost := int64(bcrypt.DefaultCost)
+	if len(args) == 2 {
+		if cost, errObj = object.AsInt(args[1]); errObj != nil {
+			return errObj
+		}
+	}
+	hash, err := bcrypt.GenerateFromPassword(password, int(cost))
+	if err != nil {
+		return object.Errorf("value error: %s", err)
+	}
+	return object.NewString(string(hash))
+}
+
+// BcryptVerify reports whether a password matches a bcrypt hash.
+func BcryptVerify(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("crypto.bcrypt_verify", 2, args); err != nil {
+		return err
+	}
+	password, errObj := object.AsBytes(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	hash, errObj := object.AsBytes(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	err := bcrypt.CompareHashAndPassword(hash, password)
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return object.False
+	}
+	if err != nil {
+		return object.Errorf("value error: %s", err)
+	}
+	return object.True
+}
+
+// Compare reports whether two values are equal, in constant time.
+func Compare(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("crypto.compare", 2, args); err != nil {
+		return err
+	}
+	a, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := object.AsBytes(args[1])
+	if err != nil {
+		return err
+	}
+	return object.NewBool(subtle.ConstantTimeCompare(a, b) == 1)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("crypto", map[string]object.Object{
+		"random_bytes":  object.NewBuiltin("random_bytes", RandomBytes),
+		"encrypt":       object.NewBuiltin("encrypt", Encrypt),
+		"decrypt":       object.NewBuiltin("decrypt", Decrypt),
+		"generate_key":  object.NewBuiltin("generate_key", GenerateKey),
+		"load_key":      object.NewBuiltin("load_key", LoadKey),
+		"hkdf":          object.NewBuiltin("hkdf", HKDF),
+		"pbkdf2":        object.NewBuiltin("pbkdf2", PBKDF2),
+		"bcrypt_hash":   object.NewBuiltin("bcrypt_hash", BcryptHash),
+		"bcrypt_verify": object.NewBuiltin("bcrypt_verify", BcryptVerify),
+		"compare":       object.NewBuiltin("compare", Compare),
+	})
+}
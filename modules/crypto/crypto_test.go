@@ -0,0 +1,116 @@
+package crypto
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func method(obj object.Object, name string) *object.Builtin {
+	attr, _ := obj.GetAttr(name)
+	return attr.(*object.Builtin)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	for _, cipher := range []string{"aes-gcm", "chacha20-poly1305", "xchacha20-poly1305"} {
+		t.Run(cipher, func(t *testing.T) {
+			key := RandomBytes(ctx, object.NewInt(32))
+			opts := object.NewMap(map[string]object.Object{
+				"cipher": object.NewString(cipher),
+				"aad":    object.NewString("header"),
+			})
+			ciphertext := Encrypt(ctx, key, object.NewString("secret"), opts)
+			require.IsType(t, &object.ByteSlice{}, ciphertext)
+			plaintext := Decrypt(ctx, key, ciphertext, opts)
+			require.Equal(t, object.NewByteSlice([]byte("secret")), plaintext)
+
+			// Tampered data and a different key both fail to decrypt
+			data := ciphertext.(*object.ByteSlice).Value()
+			data[len(data)-1] ^= 1
+			require.True(t, object.IsError(Decrypt(ctx, key, object.NewByteSlice(data), opts)))
+			require.True(t, object.IsError(Decrypt(ctx, RandomBytes(ctx, object.NewInt(32)), ciphertext, opts)))
+		})
+	}
+	result := Encrypt(ctx, object.NewByteSlice(make([]byte, 5)), object.NewString("x"))
+	require.Equal(t, object.Errorf("value error: aes-gcm: crypto/aes: invalid key size 5"), result)
+}
+
+func TestSignVerify(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		typ  string
+		opts map[string]object.Object
+		sign map[string]object.Object
+	}{
+		{"rsa", map[string]object.Object{"bits": object.NewInt(1024)}, nil},
+		{"rsa", map[string]object.Object{"bits": object.NewInt(1024)}, map[string]object.Object{"padding": object.NewString("pss"), "hash": object.NewString("sha256")}},
+		{"ecdsa", map[string]object.Object{"curve": object.NewString("P-384")}, map[string]object.Object{"hash": object.NewString("sha384")}},
+		{"ed25519", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			key := GenerateKey(ctx, object.NewString(tt.typ), object.NewMap(tt.opts))
+			require.IsType(t, &PrivateKey{}, key)
+			keyType, _ := key.GetAttr("type")
+			require.Equal(t, object.NewString(tt.typ), keyType)
+
+			data := object.NewString("message")
+			sig := method(key, "sign").Call(ctx, data, object.NewMap(tt.sign))
+			require.IsType(t, &object.ByteSlice{}, sig)
+
+			// Round trip the public key through PEM
+			pub, _ := key.GetAttr("public_key")
+			pub = LoadKey(ctx, method(pub, "to_pem").Call(ctx))
+			require.IsType(t, &PublicKey{}, pub)
+			verify := method(pub, "verify")
+			require.Equal(t, object.True, verify.Call(ctx, data, sig, object.NewMap(tt.sign)))
+			require.Equal(t, object.False, verify.Call(ctx, object.NewString("other"), sig, object.NewMap(tt.sign)))
+
+			loaded := LoadKey(ctx, method(key, "to_pem").Call(ctx))
+			require.Equal(t, object.True, key.Equals(loaded))
+		})
+	}
+	require.Equal(t, object.Errorf(`value error: unknown key type "dsa" (expected rsa, ecdsa, or ed25519)`),
+		GenerateKey(ctx, object.NewString("dsa")))
+	require.Equal(t, object.Errorf("value error: no PEM data found"), LoadKey(ctx, object.NewString("nope")))
+}
+
+func TestKDF(t *testing.T) {
+	ctx := context.Background()
+	// RFC 5869 test case 1
+	secret, _ := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	okm, _ := hex.DecodeString("3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865")
+	result := HKDF(ctx, object.NewByteSlice(secret), object.NewMap(map[string]object.Object{
+		"salt":   object.NewByteSlice(salt),
+		"info":   object.NewByteSlice(info),
+		"length": object.NewInt(42),
+	}))
+	require.Equal(t, object.NewByteSlice(okm), result)
+
+	// RFC 6070 test case 2
+	dk, _ := hex.DecodeString("ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957")
+	result = PBKDF2(ctx, object.NewString("password"), object.NewString("salt"), object.NewMap(map[string]object.Object{
+		"iterations": object.NewInt(2),
+		"length":     object.NewInt(20),
+		"hash":       object.NewString("sha1"),
+	}))
+	require.Equal(t, object.NewByteSlice(dk), result)
+
+	hash := BcryptHash(ctx, object.NewString("hunter2"), object.NewInt(4))
+	require.IsType(t, &object.String{}, hash)
+	require.Equal(t, object.True, BcryptVerify(ctx, object.NewString("hunter2"), hash))
+	require.Equal(t, object.False, BcryptVerify(ctx, object.NewString("hunter3"), hash))
+}
+
+func TestCompare(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, object.True, Compare(ctx, object.NewString("abc"), object.NewByteSlice([]byte("abc"))))
+	require.Equal(t, object.False, Compare(ctx, object.NewString("abc"), object.NewString("abd")))
+	require.Equal(t, object.False, Compare(ctx, object.NewString("abc"), object.NewString("ab")))
+}
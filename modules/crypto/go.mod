@@ -0,0 +1,19 @@
+module github.com/risor-io/risor/modules/crypto
+
+go 1.21
+
+replace github.com/risor-io/risor => ../..
+
+require (
+	github.com/risor-io/risor v1.1.0
+	github.com/stretchr/testify v1.8.4
+	golang.org/x/crypto v0.18.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,400 @@
+package crypto
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const (
+	PRIVATE_KEY object.Type = "crypto.private_key"
+	PUBLIC_KEY  object.Type = "crypto.public_key"
+)
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// GenerateKey generates a new "rsa", "ecdsa", or "ed25519" private key.
+func GenerateKey(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("crypto.generate_key", 1, 2, args); err != nil {
+		return err
+	}
+	typ, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	bits := int64(2048)
+	curve := "P-256"
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "bits":
+				bits, err = object.AsInt(value)
+			case "curve":
+				curve, err = object.AsString(value)
+			default:
+				err = object.Errorf("value error: unknown crypto.generate_key option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var key crypto.Signer
+	var err error
+	switch typ {
+	case "rsa":
+		if bits < 1024 {
+			return object.Errorf("value error: rsa keys must be at least 1024 bits (got %d)", bits)
+		}
+		key, err = rsa.GenerateKey(rand.Reader, int(bits))
+	case "ecdsa":
+		c, ok := curves[curve]
+		if !ok {
+			return object.Errorf("value error: unknown curve %q (expected P-256, P-384, or P-521)", curve)
+		}
+		key, err = ecdsa.GenerateKey(c, rand.Reader)
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return object.Errorf("value error: unknown key type %q (expected rsa, ecdsa, or ed25519)", typ)
+	}
+	if err != nil {
+		return object.NewError(err)
+	}
+	return &PrivateKey{key: key}
+}
+
+// LoadKey parses a PEM encoded private key, public key, or certificate. For
+// certificates, the public key of the certificate is returned.
+func LoadKey(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("crypto.load_key", 1, args); err != nil {
+		return err
+	}
+	data, errObj := object.AsBytes(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return object.Errorf("value error: no PEM data found")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return object.Errorf("value error: unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return object.Errorf("value error: %s", err)
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return &PrivateKey{key: key}
+	case *ecdsa.PrivateKey:
+		return &PrivateKey{key: key}
+	case ed25519.PrivateKey:
+		return &PrivateKey{key: key}
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return &PublicKey{key: key}
+	}
+	return object.Errorf("value error: unsupported key type %T", key)
+}
+
+// PrivateKey is an RSA, ECDSA, or Ed25519 private key.
+type PrivateKey struct {
+	key crypto.Signer
+}
+
+func (k *PrivateKey) Type() object.Type {
+	return PRIVATE_KEY
+}
+
+func (k *PrivateKey) Inspect() string {
+	return fmt.Sprintf("crypto.private_key(%s)", keyType(k.key.Public()))
+}
+
+// Interface returns nil so that printing a private key doesn't reveal it.
+// Use Key to access the underlying key.
+func (k *PrivateKey) Interface() interface{} {
+	return nil
+}
+
+// Key returns the underlying *rsa.PrivateKey, *ecdsa.PrivateKey, or
+// ed25519.PrivateKey.
+func (k *PrivateKey) Key() crypto.Signer {
+	return k.key
+}
+
+func (k *PrivateKey) IsTruthy() bool {
+	return true
+}
+
+func (k *PrivateKey) Cost() int {
+	return 0
+}
+
+func (k *PrivateKey) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", PRIVATE_KEY)
+}
+
+func (k *PrivateKey) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", PRIVATE_KEY, opType)
+}
+
+func (k *PrivateKey) Equals(other object.Object) object.Object {
+	if o, ok := other.(*PrivateKey); ok {
+		if key, ok := k.key.(interface{ Equal(crypto.PrivateKey) bool }); ok {
+			return object.NewBool(key.Equal(o.key))
+		}
+	}
+	return object.False
+}
+
+func (k *PrivateKey) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", PRIVATE_KEY, name)
+}
+
+func (k *PrivateKey) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "type":
+		return object.NewString(keyType(k.key.Public())), true
+	case "public_key":
+		return &PublicKey{key: k.key.Public()}, true
+	case "sign":
+		return object.NewBuiltin("crypto.private_key.sign", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("crypto.private_key.sign", 1, 2, args); err != nil {
+				return err
+			}
+			data, errObj := object.AsBytes(args[0])
+			if errObj != nil {
+				return errObj
+			}
+			opts, errObj := parseSignOptions(args[1:])
+			if errObj != nil {
+				return errObj
+			}
+			sig, err := k.sign(data, opts)
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewByteSlice(sig)
+		}), true
+	case "to_pem":
+		return object.NewBuiltin("crypto.private_key.to_pem", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("crypto.private_key.to_pem", 0, args); err != nil {
+				return err
+			}
+			der, err := x509.MarshalPKCS8PrivateKey(k.key)
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewString(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+		}), true
+	}
+	return nil, false
+}
+
+func (k *PrivateKey) sign(data []byte, opts signOptions) ([]byte, error) {
+	if _, ok := k.key.(ed25519.PrivateKey); ok {
+		return k.key.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	h := opts.hash.New()
+	h.Write(data)
+	var signerOpts crypto.SignerOpts = opts.hash
+	if opts.pss {
+		if _, ok := k.key.(*rsa.PrivateKey); !ok {
+			return nil, fmt.Errorf("value error: pss padding requires an rsa key")
+		}
+		signerOpts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: opts.hash}
+	}
+	return k.key.Sign(rand.Reader, h.Sum(nil), signerOpts)
+}
+
+// PublicKey is an RSA, ECDSA, or Ed25519 public key.
+type PublicKey struct {
+	key crypto.PublicKey
+}
+
+func (k *PublicKey) Type() object.Type {
+	return PUBLIC_KEY
+}
+
+func (k *PublicKey) Inspect() string {
+	return fmt.Sprintf("crypto.public_key(%s)", keyType(k.key))
+}
+
+func (k *PublicKey) Interface() interface{} {
+	return k.key
+}
+
+func (k *PublicKey) IsTruthy() bool {
+	return true
+}
+
+func (k *PublicKey) Cost() int {
+	return 0
+}
+
+func (k *PublicKey) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", PUBLIC_KEY)
+}
+
+func (k *PublicKey) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", PUBLIC_KEY, opType)
+}
+
+func (k *PublicKey) Equals(other object.Object) object.Object {
+	if o, ok := other.(*PublicKey); ok {
+		if key, ok := k.key.(interface{ Equal(crypto.PublicKey) bool }); ok {
+			return object.NewBool(key.Equal(o.key))
+		}
+	}
+	return object.False
+}
+
+func (k *PublicKey) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", PUBLIC_KEY, name)
+}
+
+func (k *PublicKey) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "type":
+		return object.NewString(keyType(k.key)), true
+	case "verify":
+		return object.NewBuiltin("crypto.public_key.verify", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("crypto.public_key.verify", 2, 3, args); err != nil {
+				return err
+			}
+			data, errObj := object.AsBytes(args[0])
+			if errObj != nil {
+				return errObj
+			}
+			sig, errObj := object.AsBytes(args[1])
+			if errObj != nil {
+				return errObj
+			}
+			opts, errObj := parseSignOptions(args[2:])
+			if errObj != nil {
+				return errObj
+			}
+			return object.NewBool(k.verify(data, sig, opts))
+		}), true
+	case "to_pem":
+		return object.NewBuiltin("crypto.public_key.to_pem", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("crypto.public_key.to_pem", 0, args); err != nil {
+				return err
+			}
+			der, err := x509.MarshalPKIXPublicKey(k.key)
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewString(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+		}), true
+	}
+	return nil, false
+}
+
+func (k *PublicKey) verify(data, sig []byte, opts signOptions) bool {
+	if key, ok := k.key.(ed25519.PublicKey); ok {
+		return ed25519.Verify(key, data, sig)
+	}
+	h := opts.hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+	switch key := k.key.(type) {
+	case *rsa.PublicKey:
+		if opts.pss {
+			return rsa.VerifyPSS(key, opts.hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) == nil
+		}
+		return rsa.VerifyPKCS1v15(key, opts.hash, digest, sig) == nil
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest, sig)
+	}
+	return false
+}
+
+// signOptions holds the options given to sign and verify.
+type signOptions struct {
+	hash crypto.Hash
+	pss  bool
+}
+
+func parseSignOptions(args []object.Object) (signOptions, *object.Error) {
+	opts := signOptions{hash: crypto.SHA256}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "hash":
+			if opts.hash, err = asHash(value); err != nil {
+				return opts, err
+			}
+		case "padding":
+			padding, err := object.AsString(value)
+			if err != nil {
+				return opts, err
+			}
+			switch padding {
+			case "pkcs1v15":
+			case "pss":
+				opts.pss = true
+			default:
+				return opts, object.Errorf("value error: unknown padding %q (expected pkcs1v15 or pss)", padding)
+			}
+		default:
+			return opts, object.Errorf("value error: unknown crypto option %q", key)
+		}
+	}
+	return opts, nil
+}
+
+func keyType(key crypto.PublicKey) string {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return "rsa"
+	case *ecdsa.PublicKey:
+		return "ecdsa"
+	case ed25519.PublicKey:
+		return "ed25519"
+	}
+	return "unknown"
+}
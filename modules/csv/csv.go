@@ -0,0 +1,289 @@
+package csv
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// converter turns a field into a Risor value.
+type converter func(ctx context.Context, field string) (object.Object, error)
+
+// readOptions configure a reader.
+type readOptions struct {
+	delimiter        rune
+	comment          rune
+	lazyQuotes       bool
+	trimLeadingSpace bool
+	header           bool
+	columns          []string
+	infer            bool
+	types            map[string]converter
+	typesByIndex     []converter
+}
+
+// Reader returns a stream of the records read from a CSV source. Records are
+// maps keyed by the header row by default, or lists when header is false.
+func Reader(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("csv.reader", 1, 2, args); err != nil {
+		return err
+	}
+	r, err := object.AsReader(args[0])
+	if err != nil {
+		return err
+	}
+	opts := readOptions{delimiter: ',', header: true}
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		if err := parseReadOptions(m, &opts); err != nil {
+			return err
+		}
+	}
+	reader := csv.NewReader(r)
+	reader.Comma = opts.delimiter
+	reader.Comment = opts.comment
+	reader.LazyQuotes = opts.lazyQuotes
+	reader.TrimLeadingSpace = opts.trimLeadingSpace
+	reader.ReuseRecord = true
+
+	columns := opts.columns
+	var converters []converter
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil, false, nil
+		} else if err != nil {
+			return nil, false, err
+		}
+		if opts.header && columns == nil {
+			columns = append([]string(nil), record...)
+			if record, err = reader.Read(); errors.Is(err, io.EOF) {
+				return nil, false, nil
+			} else if err != nil {
+				return nil, false, err
+			}
+		}
+		if converters == nil {
+			converters = opts.converters(columns, len(record))
+		}
+		if columns != nil && len(record) != len(columns) {
+			line, _ := reader.FieldPos(0)
+			return nil, false, fmt.Errorf("csv error: record on line %d has %d fields, expected %d",
+				line, len(record), len(columns))
+		}
+		values := make([]object.Object, len(record))
+		for i, field := range record {
+			var value object.Object = object.NewString(field)
+			if i < len(converters) && converters[i] != nil {
+				if value, err = converters[i](ctx, field); err != nil {
+					line, col := reader.FieldPos(i)
+					return nil, false, fmt.Errorf("csv error: line %d, column %d: %w", line, col, err)
+				}
+			}
+			values[i] = value
+		}
+		if columns == nil {
+			return object.NewList(values), true, nil
+		}
+		row := make(map[string]object.Object, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		return object.NewMap(row), true, nil
+	})
+}
+
+// converters returns the converter for each column, given the column names
+// (nil if there is no header) and the number of fields in the first record.
+func (o readOptions) converters(columns []string, count int) []converter {
+	if columns != nil {
+		count = len(columns)
+	}
+	result := make([]converter, count)
+	for i := range result {
+		if i < len(o.typesByIndex) && o.typesByIndex[i] != nil {
+			result[i] = o.typesByIndex[i]
+		} else if i < len(columns) && o.types[columns[i]] != nil {
+			result[i] = o.types[columns[i]]
+		} else if o.infer {
+			result[i] = inferType
+		}
+	}
+	return result
+}
+
+func parseReadOptions(m *object.Map, opts *readOptions) *object.Error {
+	for key, value := range m.Value() {
+		var err *object.Error
+		switch key {
+		case "delimiter":
+			opts.delimiter, err = asRune(key, value)
+		case "comment":
+			opts.comment, err = asRune(key, value)
+		case "lazy_quotes":
+			opts.lazyQuotes, err = object.AsBool(value)
+		case "trim_leading_space":
+			opts.trimLeadingSpace, err = object.AsBool(value)
+		case "infer":
+			opts.infer, err = object.AsBool(value)
+		case "header":
+			switch value := value.(type) {
+			case *object.Bool:
+				opts.header = value.Value()
+			case *object.List:
+				opts.columns, err = object.AsStringSlice(value)
+			default:
+				err = object.Errorf("type error: header must be a bool or list (got %s)", value.Type())
+			}
+		case "types":
+			err = parseTypes(value, opts)
+		default:
+			err = object.Errorf("value error: unknown csv option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTypes reads the column types, given either as a map keyed by column
+// name or as a list ordered by column position.
+func parseTypes(obj object.Object, opts *readOptions) *object.Error {
+	switch obj := obj.(type) {
+	case *object.Map:
+		opts.types = make(map[string]converter, obj.Size())
+		for key, value := range obj.Value() {
+			conv, err := asConverter(value)
+			if err != nil {
+				return err
+			}
+			opts.types[key] = conv
+		}
+	case *object.List:
+		opts.typesByIndex = make([]converter, obj.Size())
+		for i, value := range obj.Value() {
+			if value == object.Nil {
+				continue
+			}
+			conv, err := asConverter(value)
+			if err != nil {
+				return err
+			}
+			opts.typesByIndex[i] = conv
+		}
+	default:
+		return object.Errorf("type error: types must be a map or list (got %s)", obj.Type())
+	}
+	return nil
+}
+
+// asConverter returns the converter for a type name, or one that calls the
+// given function with each field.
+func asConverter(obj object.Object) (converter, *object.Error) {
+	switch obj.(type) {
+	case *object.Function, *object.Partial, object.Callable:
+		return func(ctx context.Context, field string) (object.Object, error) {
+			return object.Call(ctx, obj, []object.Object{object.NewString(field)})
+		}, nil
+	}
+	name, err := object.AsString(obj)
+	if err != nil {
+		return nil, object.Errorf("type error: column types must be type names or functions (got %s)", obj.Type())
+	}
+	switch name {
+	case "string":
+		return nil, nil
+	case "int":
+		return nullable(func(field string) (object.Object, error) {
+			n, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid int %q", field)
+			}
+			return object.NewInt(n), nil
+		}), nil
+	case "float":
+		return nullable(func(field string) (object.Object, error) {
+			f, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid float %q", field)
+			}
+			return object.NewFloat(f), nil
+		}), nil
+	case "bool":
+		return nullable(func(field string) (object.Object, error) {
+			b, err := strconv.ParseBool(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bool %q", field)
+			}
+			return object.NewBool(b), nil
+		}), nil
+	case "time":
+		return nullable(func(field string) (object.Object, error) {
+			t, err := time.Parse(time.RFC3339, field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid time %q", field)
+			}
+			return object.NewTime(t), nil
+		}), nil
+	}
+	return nil, object.Errorf("value error: unknown column type %q", name)
+}
+
+// nullable wraps a conversion so that empty fields become nil.
+func nullable(fn func(field string) (object.Object, error)) converter {
+	return func(ctx context.Context, field string) (object.Object, error) {
+		if field == "" {
+			return object.Nil, nil
+		}
+		return fn(field)
+	}
+}
+
+// inferType converts fields that look like ints, floats, or bools, and
+// leaves other fields as strings.
+func inferType(ctx context.Context, field string) (object.Object, error) {
+	if n, err := strconv.ParseInt(field, 10, 64); err == nil {
+		return object.NewInt(n), nil
+	}
+	if f, err := strconv.ParseFloat(field, 64); err == nil {
+		return object.NewFloat(f), nil
+	}
+	switch field {
+	case "true":
+		return object.True, nil
+	case "false":
+		return object.False, nil
+	}
+	return object.NewString(field), nil
+}
+
+func asRune(name string, obj object.Object) (rune, *object.Error) {
+	s, err := object.AsString(obj)
+	if err != nil {
+		return 0, err
+	}
+	if utf8.RuneCountInString(s) != 1 {
+		return 0, object.Errorf("value error: %s must be a single character (got %q)", name, s)
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r, nil
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("csv", map[string]object.Object{
+		"reader": object.NewBuiltin("reader", Reader),
+		"writer": object.NewBuiltin("writer", Writer),
+	})
+}
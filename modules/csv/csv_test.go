@@ -0,0 +1,114 @@
+package csv
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, ctx context.Context, stream object.Object) []object.Object {
+	t.Helper()
+	s, ok := stream.(*object.Stream)
+	require.True(t, ok, "unexpected result: %s", stream.Inspect())
+	items, ok := s.Collect(ctx).(*object.List)
+	require.True(t, ok)
+	return items.Value()
+}
+
+func TestReaderMaps(t *testing.T) {
+	ctx := context.Background()
+	data := object.NewString("name,age,score\nalice,30,9.5\nbob,,7\n")
+	opts := object.NewMap(map[string]object.Object{
+		"types": object.NewMap(map[string]object.Object{
+			"age":   object.NewString("int"),
+			"score": object.NewString("float"),
+		}),
+	})
+	rows := collect(t, ctx, Reader(ctx, data, opts))
+	require.Equal(t, []object.Object{
+		object.NewMap(map[string]object.Object{
+			"name":  object.NewString("alice"),
+			"age":   object.NewInt(30),
+			"score": object.NewFloat(9.5),
+		}),
+		object.NewMap(map[string]object.Object{
+			"name":  object.NewString("bob"),
+			"age":   object.Nil,
+			"score": object.NewFloat(7),
+		}),
+	}, rows)
+}
+
+func TestReaderLists(t *testing.T) {
+	ctx := context.Background()
+	data := object.NewString("a\t1\ttrue\n# comment\nb\t2.5\tno\n")
+	opts := object.NewMap(map[string]object.Object{
+		"header":    object.False,
+		"delimiter": object.NewString("\t"),
+		"comment":   object.NewString("#"),
+		"infer":     object.True,
+	})
+	rows := collect(t, ctx, Reader(ctx, data, opts))
+	require.Equal(t, []object.Object{
+		object.NewList([]object.Object{object.NewString("a"), object.NewInt(1), object.True}),
+		object.NewList([]object.Object{object.NewString("b"), object.NewFloat(2.5), object.NewString("no")}),
+	}, rows)
+}
+
+func TestReaderHeaderList(t *testing.T) {
+	ctx := context.Background()
+	opts := object.NewMap(map[string]object.Object{
+		"header": object.NewStringList([]string{"x", "y"}),
+		"types":  object.NewList([]object.Object{object.NewString("int")}),
+	})
+	rows := collect(t, ctx, Reader(ctx, object.NewString("1,2\n"), opts))
+	require.Equal(t, []object.Object{
+		object.NewMap(map[string]object.Object{"x": object.NewInt(1), "y": object.NewString("2")}),
+	}, rows)
+}
+
+func TestReaderErrors(t *testing.T) {
+	ctx := context.Background()
+	opts := object.NewMap(map[string]object.Object{
+		"types": object.NewMap(map[string]object.Object{"n": object.NewString("int")}),
+	})
+	stream := Reader(ctx, object.NewString("n\n1\nx\n"), opts).(*object.Stream)
+	result := stream.Collect(ctx)
+	require.True(t, object.IsError(result))
+	require.Equal(t, `csv error: line 3, column 1: invalid int "x"`, result.(*object.Error).Message().Value())
+
+	result = Reader(ctx, object.NewString(""), object.NewMap(map[string]object.Object{
+		"delimiter": object.NewString("::"),
+	}))
+	require.Equal(t, object.Errorf(`value error: delimiter must be a single character (got "::")`), result)
+}
+
+func TestWriter(t *testing.T) {
+	ctx := context.Background()
+	buf := object.NewBuffer(nil)
+	w, ok := Writer(ctx, buf).(*CSVWriter)
+	require.True(t, ok)
+	writeAll, _ := w.GetAttr("write_all")
+	result := writeAll.(*object.Builtin).Call(ctx, object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{"b": object.NewInt(1), "a": object.NewString("x,y")}),
+		object.NewMap(map[string]object.Object{"a": object.Nil}),
+	}))
+	require.Equal(t, object.NewInt(2), result)
+	require.Equal(t, "a,b\n\"x,y\",1\n,\n", buf.Value().String())
+
+	buf = object.NewBuffer(nil)
+	w = Writer(ctx, buf, object.NewMap(map[string]object.Object{
+		"delimiter": object.NewString("\t"),
+		"header":    object.NewStringList([]string{"n", "sq"}),
+	})).(*CSVWriter)
+	rows := Reader(ctx, object.NewString("1\n2\n"), object.NewMap(map[string]object.Object{
+		"header": object.False,
+		"types":  object.NewList([]object.Object{object.NewString("int")}),
+	}))
+	writeAll, _ = w.GetAttr("write_all")
+	result = writeAll.(*object.Builtin).Call(ctx, rows)
+	require.Equal(t, object.NewInt(2), result)
+	require.Equal(t, "n\tsq\n1\n2\n", buf.Value().String())
+}
@@ -0,0 +1,223 @@
+package csv
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const WRITER object.Type = "csv.writer"
+
+// CSVWriter writes records to an underlying writer. Records may be lists or
+// maps; the columns for maps are taken from the header option or from the
+// sorted keys of the first map written.
+type CSVWriter struct {
+	writer      *csv.Writer
+	columns     []string
+	writeHeader bool
+	started     bool
+}
+
+func (w *CSVWriter) Type() object.Type {
+	return WRITER
+}
+
+func (w *CSVWriter) Inspect() string {
+	return "csv.writer()"
+}
+
+func (w *CSVWriter) Interface() interface{} {
+	return w.writer
+}
+
+func (w *CSVWriter) IsTruthy() bool {
+	return true
+}
+
+func (w *CSVWriter) Cost() int {
+	return 8
+}
+
+func (w *CSVWriter) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", WRITER)
+}
+
+func (w *CSVWriter) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", WRITER, opType)
+}
+
+func (w *CSVWriter) Equals(other object.Object) object.Object {
+	if w == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (w *CSVWriter) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", WRITER, name)
+}
+
+func (w *CSVWriter) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "columns":
+		if w.columns == nil {
+			return object.Nil, true
+		}
+		return object.NewStringList(w.columns), true
+	case "write":
+		return object.NewBuiltin("csv.writer.write", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("csv.writer.write", 1, args); err != nil {
+				return err
+			}
+			if err := w.Write(args[0]); err != nil {
+				return err
+			}
+			return object.Nil
+		}), true
+	case "write_all":
+		return object.NewBuiltin("csv.writer.write_all", w.WriteAll), true
+	case "flush":
+		return object.NewBuiltin("csv.writer.flush", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("csv.writer.flush", 0, args); err != nil {
+				return err
+			}
+			if err := w.Flush(); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// Write writes one record. The header row, if any, is written first.
+func (w *CSVWriter) Write(row object.Object) *object.Error {
+	var record []string
+	switch row := row.(type) {
+	case *object.List:
+		record = make([]string, 0, row.Size())
+		for _, item := range row.Value() {
+			record = append(record, formatField(item))
+		}
+	case *object.Map:
+		if w.columns == nil {
+			w.columns = row.StringKeys()
+			sort.Strings(w.columns)
+		}
+		record = make([]string, 0, len(w.columns))
+		for _, column := range w.columns {
+			record = append(record, formatField(row.GetWithDefault(column, object.Nil)))
+		}
+	default:
+		return object.Errorf("type error: csv records must be lists or maps (got %s)", row.Type())
+	}
+	if !w.started {
+		w.started = true
+		if w.writeHeader && w.columns != nil {
+			if err := w.writer.Write(w.columns); err != nil {
+				return object.NewError(err)
+			}
+		}
+	}
+	if err := w.writer.Write(record); err != nil {
+		return object.NewError(err)
+	}
+	return nil
+}
+
+// WriteAll writes every record produced by an iterable, such as a list or a
+// stream, then flushes. Returns the number of records written.
+func (w *CSVWriter) WriteAll(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("csv.writer.write_all", 1, args); err != nil {
+		return err
+	}
+	iter, err := object.AsIterator(args[0])
+	if err != nil {
+		return err
+	}
+	var count int64
+	for {
+		value, ok := iter.Next(ctx)
+		if !ok {
+			break
+		}
+		if err := w.Write(value); err != nil {
+			return err
+		}
+		count++
+	}
+	if s, ok := iter.(interface{ Err() error }); ok && s.Err() != nil {
+		return object.NewError(s.Err())
+	}
+	if err := w.Flush(); err != nil {
+		return object.NewError(err)
+	}
+	return object.NewInt(count)
+}
+
+// Flush writes any buffered records to the underlying writer.
+func (w *CSVWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// Writer returns a csv.writer that writes to the given destination.
+func Writer(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("csv.writer", 1, 2, args); err != nil {
+		return err
+	}
+	dst, err := object.AsWriter(args[0])
+	if err != nil {
+		return err
+	}
+	w := &CSVWriter{writer: csv.NewWriter(dst), writeHeader: true}
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "delimiter":
+				w.writer.Comma, err = asRune(key, value)
+			case "crlf":
+				w.writer.UseCRLF, err = object.AsBool(value)
+			case "header":
+				switch value := value.(type) {
+				case *object.Bool:
+					w.writeHeader = value.Value()
+				case *object.List:
+					w.columns, err = object.AsStringSlice(value)
+				default:
+					err = object.Errorf("type error: header must be a bool or list (got %s)", value.Type())
+				}
+			default:
+				err = object.Errorf("value error: unknown csv option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return w
+}
+
+func formatField(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.String:
+		return obj.Value()
+	case *object.NilType:
+		return ""
+	case *object.Time:
+		return obj.Value().Format(time.RFC3339)
+	case *object.ByteSlice:
+		return string(obj.Value())
+	}
+	return obj.Inspect()
+}
@@ -38,15 +38,7 @@ func NSLookup(ctx context.Context, args ...object.Object) object.Object {
 
 	resolver := net.DefaultResolver
 	if resolverAddr != "" {
-		resolver = &net.Resolver{
-			PreferGo: true,
-			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
-				d := net.Dialer{
-					Timeout: 30 * time.Second,
-				}
-				return d.DialContext(ctx, network, resolverAddr)
-			},
-		}
+		resolver = newResolver(resolverAddr, "", 30*time.Second)
 	}
 
 	var addrs []string
@@ -0,0 +1,221 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// lookupFunc performs a query for the given name using the resolver.
+type lookupFunc func(ctx context.Context, r *net.Resolver, name string) (object.Object, error)
+
+// newResolver returns a resolver that sends queries to the given server. The
+// port defaults to 53 and the network to the one chosen by the resolver.
+func newResolver(server, network string, timeout time.Duration) *net.Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, defaultNetwork, address string) (net.Conn, error) {
+			if network != "" {
+				defaultNetwork = network
+			}
+			d := net.Dialer{Timeout: timeout}
+			return d.DialContext(ctx, defaultNetwork, server)
+		},
+	}
+}
+
+// newLookup returns a builtin that performs a query for the name given as the
+// first argument. An optional map configures the resolver used and the query
+// timeout.
+func newLookup(name string, fn lookupFunc) *object.Builtin {
+	fullName := "dns." + name
+	return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+		if err := arg.RequireRange(fullName, 1, 2, args); err != nil {
+			return err
+		}
+		host, errObj := object.AsString(args[0])
+		if errObj != nil {
+			return errObj
+		}
+		resolver := net.DefaultResolver
+		timeout := defaultTimeout
+		var server, network string
+		if len(args) == 2 {
+			opts, errObj := object.AsMap(args[1])
+			if errObj != nil {
+				return errObj
+			}
+			for key, value := range opts.Value() {
+				switch key {
+				case "resolver":
+					server, errObj = object.AsString(value)
+				case "network":
+					network, errObj = object.AsString(value)
+					if errObj == nil && network != "udp" && network != "tcp" {
+						errObj = object.Errorf("value error: network must be \"udp\" or \"tcp\" (got %q)", network)
+					}
+				case "timeout":
+					timeout, errObj = arg.Duration(key, value)
+					if errObj == nil && timeout == 0 {
+						errObj = object.Errorf("value error: timeout must be positive (got %s)", timeout)
+					}
+				default:
+					errObj = object.Errorf("value error: unknown %s option %q", fullName, key)
+				}
+				if errObj != nil {
+					return errObj
+				}
+			}
+		}
+		if server != "" {
+			resolver = newResolver(server, network, timeout)
+		} else if network != "" {
+			return object.Errorf("value error: network requires a resolver")
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		result, err := fn(ctx, resolver, host)
+		if err != nil {
+			return object.NewError(err)
+		}
+		return result
+	})
+}
+
+func lookupIP(network string) lookupFunc {
+	return func(ctx context.Context, r *net.Resolver, name string) (object.Object, error) {
+		ips, err := r.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		addrs := make([]string, len(ips))
+		for i, ip := range ips {
+			addrs[i] = ip.String()
+		}
+		return object.NewStringList(addrs), nil
+	}
+}
+
+func lookupTXT(ctx context.Context, r *net.Resolver, name string) (object.Object, error) {
+	records, err := r.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return object.NewStringList(records), nil
+}
+
+func lookupCNAME(ctx context.Context, r *net.Resolver, name string) (object.Object, error) {
+	cname, err := r.LookupCNAME(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return object.NewString(cname), nil
+}
+
+func lookupNS(ctx context.Context, r *net.Resolver, name string) (object.Object, error) {
+	records, err := r.LookupNS(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, len(records))
+	for i, ns := range records {
+		hosts[i] = ns.Host
+	}
+	return object.NewStringList(hosts), nil
+}
+
+func lookupMX(ctx context.Context, r *net.Resolver, name string) (object.Object, error) {
+	records, err := r.LookupMX(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]object.Object, len(records))
+	for i, mx := range records {
+		items[i] = object.NewMap(map[string]object.Object{
+			"host": object.NewString(mx.Host),
+			"pref": object.NewInt(int64(mx.Pref)),
+		})
+	}
+	return object.NewList(items), nil
+}
+
+func lookupSRV(ctx context.Context, r *net.Resolver, name string) (object.Object, error) {
+	_, records, err := r.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]object.Object, len(records))
+	for i, srv := range records {
+		items[i] = object.NewMap(map[string]object.Object{
+			"target":   object.NewString(srv.Target),
+			"port":     object.NewInt(int64(srv.Port)),
+			"priority": object.NewInt(int64(srv.Priority)),
+			"weight":   object.NewInt(int64(srv.Weight)),
+		})
+	}
+	return object.NewList(items), nil
+}
+
+// lookupPTR returns the names an address maps to. The address may be an IP
+// address or a name in the in-addr.arpa or ip6.arpa domains.
+func lookupPTR(ctx context.Context, r *net.Resolver, addr string) (object.Object, error) {
+	names, err := r.LookupAddr(ctx, arpaToIP(addr))
+	if err != nil {
+		return nil, err
+	}
+	return object.NewStringList(names), nil
+}
+
+func isArpa(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	return strings.HasSuffix(name, ".in-addr.arpa") || strings.HasSuffix(name, ".ip6.arpa")
+}
+
+// arpaToIP converts a reverse lookup name to the address it represents, so
+// it may be passed to the resolver.
+func arpaToIP(name string) string {
+	if !isArpa(name) {
+		return name
+	}
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if prefix, ok := strings.CutSuffix(name, ".in-addr.arpa"); ok {
+		parts := strings.Split(prefix, ".")
+		for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+			parts[i], parts[j] = parts[j], parts[i]
+		}
+		return strings.Join(parts, ".")
+	}
+	prefix, _ := strings.CutSuffix(name, ".ip6.arpa")
+	nibbles := strings.Split(prefix, ".")
+	var b strings.Builder
+	for i := len(nibbles) - 1; i >= 0; i-- {
+		b.WriteString(nibbles[i])
+		if i%4 == 0 && i > 0 {
+			b.WriteByte(':')
+		}
+	}
+	return b.String()
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("dns", map[string]object.Object{
+		"lookup":  newLookup("lookup", lookupIP("ip")),
+		"a":       newLookup("a", lookupIP("ip4")),
+		"aaaa":    newLookup("aaaa", lookupIP("ip6")),
+		"cname":   newLookup("cname", lookupCNAME),
+		"mx":      newLookup("mx", lookupMX),
+		"ns":      newLookup("ns", lookupNS),
+		"reverse": newLookup("reverse", lookupPTR),
+		"srv":     newLookup("srv", lookupSRV),
+		"txt":     newLookup("txt", lookupTXT),
+	})
+}
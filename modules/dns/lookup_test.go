@@ -0,0 +1,188 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	typeA     = 1
+	typeNS    = 2
+	typeCNAME = 5
+	typePTR   = 12
+	typeMX    = 15
+	typeTXT   = 16
+	typeAAAA  = 28
+	typeSRV   = 33
+)
+
+type record struct {
+	typ  uint16
+	data []byte
+}
+
+func encodeName(name string) []byte {
+	var b []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+func u16(values ...uint16) []byte {
+	var b []byte
+	for _, v := range values {
+		b = binary.BigEndian.AppendUint16(b, v)
+	}
+	return b
+}
+
+// records served by the test server, keyed by lowercase name
+var records = map[string][]record{
+	"example.test.": {
+		{typeA, net.ParseIP("192.0.2.1").To4()},
+		{typeAAAA, net.ParseIP("2001:db8::1")},
+		{typeTXT, append([]byte{11}, "v=spf1 -all"...)},
+		{typeMX, append(u16(10), encodeName("mail.example.test")...)},
+		{typeNS, encodeName("ns1.example.test")},
+	},
+	"www.example.test.": {
+		{typeCNAME, encodeName("example.test")},
+	},
+	"_sip._tcp.example.test.": {
+		{typeSRV, append(u16(5, 20, 5060), encodeName("sip.example.test")...)},
+	},
+	"1.2.0.192.in-addr.arpa.": {
+		{typePTR, encodeName("host.example.test")},
+	},
+}
+
+// startServer starts a UDP DNS server that answers from the records map.
+func startServer(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := answer(buf[:n]); resp != nil {
+				conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func answer(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+	// Read the question name
+	var labels []string
+	pos := 12
+	for pos < len(query) && query[pos] != 0 {
+		length := int(query[pos])
+		labels = append(labels, string(query[pos+1:pos+1+length]))
+		pos += 1 + length
+	}
+	pos++
+	qtype := binary.BigEndian.Uint16(query[pos:])
+	question := query[12 : pos+4]
+	name := strings.ToLower(strings.Join(labels, ".")) + "."
+
+	var answers []byte
+	var count uint16
+	rcode := uint16(3) // NXDOMAIN
+	if rrs, ok := records[name]; ok {
+		rcode = 0
+		for _, rr := range rrs {
+			if rr.typ != qtype && rr.typ != typeCNAME {
+				continue
+			}
+			answers = append(answers, 0xc0, 12) // pointer to the question name
+			answers = append(answers, u16(rr.typ, 1)...)
+			answers = binary.BigEndian.AppendUint32(answers, 60)
+			answers = append(answers, u16(uint16(len(rr.data)))...)
+			answers = append(answers, rr.data...)
+			count++
+		}
+	}
+	resp := append([]byte{}, query[:2]...)
+	resp = append(resp, u16(0x8400|rcode, 1, count, 0, 0)...)
+	resp = append(resp, question...)
+	return append(resp, answers...)
+}
+
+func lookup(t *testing.T, fn, name string, opts map[string]object.Object) object.Object {
+	t.Helper()
+	builtin, ok := Module().GetAttr(fn)
+	require.True(t, ok)
+	return builtin.(*object.Builtin).Call(context.Background(), object.NewString(name), object.NewMap(opts))
+}
+
+func TestLookups(t *testing.T) {
+	opts := map[string]object.Object{"resolver": object.NewString(startServer(t))}
+
+	require.Equal(t, object.NewStringList([]string{"192.0.2.1"}), lookup(t, "a", "example.test", opts))
+	require.Equal(t, object.NewStringList([]string{"2001:db8::1"}), lookup(t, "aaaa", "example.test", opts))
+	require.Equal(t, object.NewStringList([]string{"v=spf1 -all"}), lookup(t, "txt", "example.test", opts))
+	require.Equal(t, object.NewStringList([]string{"ns1.example.test."}), lookup(t, "ns", "example.test", opts))
+	require.Equal(t, object.NewString("example.test."), lookup(t, "cname", "www.example.test", opts))
+	require.Equal(t, object.NewStringList([]string{"host.example.test."}), lookup(t, "reverse", "192.0.2.1", opts))
+	require.Equal(t, object.NewStringList([]string{"host.example.test."}), lookup(t, "reverse", "1.2.0.192.in-addr.arpa.", opts))
+
+	ips := lookup(t, "lookup", "example.test", opts)
+	require.ElementsMatch(t, []string{"192.0.2.1", "2001:db8::1"}, ips.Interface())
+
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{
+			"host": object.NewString("mail.example.test."),
+			"pref": object.NewInt(10),
+		}),
+	}), lookup(t, "mx", "example.test", opts))
+
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{
+			"target":   object.NewString("sip.example.test."),
+			"port":     object.NewInt(5060),
+			"priority": object.NewInt(5),
+			"weight":   object.NewInt(20),
+		}),
+	}), lookup(t, "srv", "_sip._tcp.example.test", opts))
+}
+
+func TestLookupErrors(t *testing.T) {
+	server := startServer(t)
+	result := lookup(t, "a", "missing.test", map[string]object.Object{"resolver": object.NewString(server)})
+	require.True(t, object.IsError(result))
+	require.Contains(t, result.(*object.Error).Message().Value(), "no such host")
+
+	result = lookup(t, "a", "example.test", map[string]object.Object{"network": object.NewString("udp")})
+	require.Equal(t, "value error: network requires a resolver", result.(*object.Error).Message().Value())
+
+	result = lookup(t, "a", "example.test", map[string]object.Object{"timeout": object.NewInt(0)})
+	require.Equal(t, "value error: timeout must be positive (got 0s)", result.(*object.Error).Message().Value())
+
+	result = lookup(t, "a", "example.test", map[string]object.Object{"port": object.NewInt(53)})
+	require.Equal(t, `value error: unknown dns.a option "port"`, result.(*object.Error).Message().Value())
+}
+
+func TestArpaToIP(t *testing.T) {
+	require.Equal(t, "192.0.2.1", arpaToIP("1.2.0.192.in-addr.arpa."))
+	require.Equal(t, "10.0.0.1", arpaToIP("10.0.0.1"))
+	name := "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"
+	require.Equal(t, "2001:0db8:0000:0000:0000:0000:0000:0001", arpaToIP(name))
+	require.Equal(t, "2001:db8::1", net.ParseIP(arpaToIP(name)).String())
+}
@@ -0,0 +1,72 @@
+// Package modules holds the documentation of the modules built into Risor,
+// which are the packages of this directory. Modules maintained as separate
+// Go modules, such as aws, embed their own.
+package modules
+
+import (
+	"embed"
+	"strings"
+	"sync"
+)
+
+//go:embed archive/*.md base64/*.md bytes/*.md cron/*.md csv/*.md dns/*.md
+//go:embed email/*.md exec/*.md filepath/*.md fmt/*.md fuzzy/*.md geo/*.md
+//go:embed http/*.md ids/*.md json/*.md log/*.md math/*.md net/*.md os/*.md
+//go:embed rand/*.md random/*.md ratelimit/*.md regexp/*.md result/*.md
+//go:embed retry/*.md runtime/*.md shlex/*.md sketch/*.md stats/*.md
+//go:embed strconv/*.md strings/*.md time/*.md tls/*.md yaml/*.md
+var docs embed.FS
+
+// Documentation registered for modules that aren't built into Risor
+var (
+	registeredMutex sync.RWMutex
+	registeredDocs  = map[string]string{}
+)
+
+// RegisterDocs sets the documentation of a module that isn't built into
+// Risor, such as that of a module provider, for Docs and FunctionDocs to
+// return. The documentation of built-in modules takes precedence.
+func RegisterDocs(module, text string) {
+	registeredMutex.Lock()
+	defer registeredMutex.Unlock()
+	registeredDocs[module] = text
+}
+
+// Docs returns the documentation of a module, in Markdown.
+func Docs(module string) (string, bool) {
+	data, err := docs.ReadFile(module + "/" + module + ".md")
+	if err == nil {
+		return string(data), true
+	}
+	registeredMutex.RLock()
+	defer registeredMutex.RUnlock()
+	text, ok := registeredDocs[module]
+	return text, ok && text != ""
+}
+
+// FunctionDocs returns the section of the documentation of a module that
+// describes one of its functions, in Markdown.
+func FunctionDocs(module, function string) (string, bool) {
+	text, ok := Docs(module)
+	if !ok {
+		return "", false
+	}
+	var section []string
+	var found bool
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "#") {
+			if found {
+				break
+			}
+			found = strings.TrimSpace(strings.TrimLeft(line, "#")) == function &&
+				strings.HasPrefix(line, "### ")
+		}
+		if found {
+			section = append(section, line)
+		}
+	}
+	if !found {
+		return "", false
+	}
+	return strings.TrimSpace(strings.Join(section, "\n")), true
+}
@@ -0,0 +1,29 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocs(t *testing.T) {
+	text, ok := Docs("strings")
+	require.True(t, ok)
+	require.Contains(t, text, "# strings")
+
+	text, ok = FunctionDocs("strings", "compare")
+	require.True(t, ok)
+	require.True(t, len(text) > 0)
+	require.Equal(t, "### compare", text[:len("### compare")])
+	require.NotContains(t, text, "### contains")
+
+	_, ok = FunctionDocs("strings", "missing")
+	require.False(t, ok)
+	_, ok = Docs("missing")
+	require.False(t, ok)
+
+	RegisterDocs("missing", "# missing\n\n### find\n\nFinds things.\n")
+	text, ok = FunctionDocs("missing", "find")
+	require.True(t, ok)
+	require.Equal(t, "### find\n\nFinds things.", text)
+}
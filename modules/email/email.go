@@ -0,0 +1,214 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Options configures the connection to an SMTP server.
+type Options struct {
+	Host               string
+	Port               int
+	Username           string
+	Password           string
+	Security           string
+	LocalName          string
+	Timeout            time.Duration
+	InsecureSkipVerify bool
+}
+
+func NewMessage(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("email.message", 1, args); err != nil {
+		return err
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return err
+	}
+	msg, err := parseMessage(ctx, m)
+	if err != nil {
+		return err
+	}
+	return msg
+}
+
+// Send delivers a message using an SMTP server. The message may be an
+// email.message or a map of its fields.
+func Send(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("email.send", 2, args); err != nil {
+		return err
+	}
+	var msg *Message
+	switch value := args[0].(type) {
+	case *Message:
+		msg = value
+	case *object.Map:
+		var err *object.Error
+		if msg, err = parseMessage(ctx, value); err != nil {
+			return err
+		}
+	default:
+		return object.Errorf("type error: email.send() expected an email.message or map (%s given)", args[0].Type())
+	}
+	m, errObj := object.AsMap(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	opts := Options{Security: "starttls", Timeout: defaultTimeout}
+	if err := parseOptions(m, &opts); err != nil {
+		return err
+	}
+	if err := SendMessage(ctx, msg, opts); err != nil {
+		return object.NewError(err)
+	}
+	return object.Nil
+}
+
+// SendMessage delivers the message to its recipients using the SMTP server
+// described by the options.
+func SendMessage(ctx context.Context, msg *Message, opts Options) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	port := opts.Port
+	if port == 0 {
+		switch opts.Security {
+		case "tls":
+			port = 465
+		case "starttls":
+			port = 587
+		default:
+			port = 25
+		}
+	}
+	address := net.JoinHostPort(opts.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: opts.Host, InsecureSkipVerify: opts.InsecureSkipVerify}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	var conn net.Conn
+	dialer := &net.Dialer{}
+	if opts.Security == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, opts.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if opts.LocalName != "" {
+		if err := client.Hello(opts.LocalName); err != nil {
+			return err
+		}
+	}
+	if opts.Security == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("email error: server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if opts.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("email error: server does not support authentication")
+		}
+		auth := smtp.PlainAuth("", opts.Username, opts.Password, opts.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range msg.Recipients() {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func parseOptions(m *object.Map, opts *Options) *object.Error {
+	for key, value := range m.Value() {
+		var err *object.Error
+		switch key {
+		case "host":
+			opts.Host, err = object.AsString(value)
+		case "port":
+			var port int64
+			port, err = object.AsInt(value)
+			opts.Port = int(port)
+		case "username":
+			opts.Username, err = object.AsString(value)
+		case "password":
+			opts.Password, err = object.AsString(value)
+		case "security":
+			opts.Security, err = object.AsString(value)
+			if err == nil && opts.Security != "starttls" && opts.Security != "tls" && opts.Security != "none" {
+				err = object.Errorf("value error: security must be \"starttls\", \"tls\", or \"none\" (got %q)", opts.Security)
+			}
+		case "local_name":
+			opts.LocalName, err = object.AsString(value)
+		case "timeout":
+			var ms int64
+			ms, err = object.AsInt(value)
+			if err == nil && ms <= 0 {
+				err = object.Errorf("value error: timeout must be positive (got %d)", ms)
+			}
+			opts.Timeout = time.Duration(ms) * time.Millisecond
+		case "insecure_skip_verify":
+			opts.InsecureSkipVerify, err = object.AsBool(value)
+		default:
+			err = object.Errorf("value error: unknown email.send option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if opts.Host == "" {
+		return object.Errorf("value error: email.send() requires a host")
+	}
+	return nil
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("email", map[string]object.Object{
+		"message": object.NewBuiltin("message", NewMessage),
+		"send":    object.NewBuiltin("send", Send),
+	})
+}
@@ -0,0 +1,284 @@
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func newMessage(t *testing.T, fields map[string]object.Object) *Message {
+	t.Helper()
+	result := NewMessage(context.Background(), object.NewMap(fields))
+	msg, ok := result.(*Message)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	return msg
+}
+
+func readMessage(t *testing.T, msg *Message) *mail.Message {
+	t.Helper()
+	data, err := msg.Bytes()
+	require.Nil(t, err)
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	require.Nil(t, err)
+	return parsed
+}
+
+func TestTextMessage(t *testing.T) {
+	msg := newMessage(t, map[string]object.Object{
+		"sender":  object.NewString("Alerts <alerts@example.com>"),
+		"to":      object.NewString("ops@example.com"),
+		"bcc":     object.NewStringList([]string{"audit@example.com"}),
+		"subject": object.NewString("Disk usage at 95% ⚠"),
+		"text":    object.NewString("Volume /data is almost full."),
+		"headers": object.NewMap(map[string]object.Object{"X-Priority": object.NewString("1")}),
+		"date":    object.NewTime(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)),
+	})
+	require.Equal(t, []string{"ops@example.com", "audit@example.com"}, msg.Recipients())
+
+	parsed := readMessage(t, msg)
+	require.Equal(t, `"Alerts" <alerts@example.com>`, parsed.Header.Get("From"))
+	require.Equal(t, "<ops@example.com>", parsed.Header.Get("To"))
+	require.Equal(t, "", parsed.Header.Get("Bcc"))
+	require.Equal(t, "1", parsed.Header.Get("X-Priority"))
+	require.Equal(t, "Fri, 01 Mar 2024 12:00:00 +0000", parsed.Header.Get("Date"))
+	require.True(t, strings.HasSuffix(parsed.Header.Get("Message-ID"), "@example.com>"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.Nil(t, err)
+	require.Equal(t, "Disk usage at 95% ⚠", subject)
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "text/plain", mediaType)
+	require.Equal(t, "utf-8", params["charset"])
+	require.Equal(t, "quoted-printable", parsed.Header.Get("Content-Transfer-Encoding"))
+}
+
+func TestMultipartMessage(t *testing.T) {
+	ctx := context.Background()
+	msg := newMessage(t, map[string]object.Object{
+		"sender":  object.NewString("reports@example.com"),
+		"to":      object.NewStringList([]string{"a@example.com", "b@example.com"}),
+		"subject": object.NewString("Weekly report"),
+		"text":    object.NewString("See attached."),
+		"html":    object.NewString(`<p>See attached.</p><img src="cid:logo.png">`),
+		"attachments": object.NewList([]object.Object{
+			object.NewMap(map[string]object.Object{
+				"filename": object.NewString("logo.png"),
+				"content":  object.NewByteSlice([]byte{0x89, 'P', 'N', 'G'}),
+				"inline":   object.True,
+			}),
+		}),
+	})
+	attach, _ := msg.GetAttr("attach")
+	result := attach.(*object.Builtin).Call(ctx, object.NewString("report.csv"), object.NewString("a,b\n1,2\n"),
+		object.NewMap(map[string]object.Object{"content_type": object.NewString("text/csv")}))
+	require.Equal(t, object.Nil, result)
+	attachments, _ := msg.GetAttr("attachments")
+	require.Equal(t, object.NewStringList([]string{"logo.png", "report.csv"}), attachments)
+
+	parsed := readMessage(t, msg)
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "multipart/mixed", mediaType)
+
+	mixed := multipart.NewReader(parsed.Body, params["boundary"])
+	body, err := mixed.NextPart()
+	require.Nil(t, err)
+	mediaType, params, err = mime.ParseMediaType(body.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "multipart/alternative", mediaType)
+
+	alternative := multipart.NewReader(body, params["boundary"])
+	text, err := alternative.NextPart()
+	require.Nil(t, err)
+	require.Equal(t, "text/plain; charset=utf-8", text.Header.Get("Content-Type"))
+	content, err := io.ReadAll(text) // decodes quoted-printable
+	require.Nil(t, err)
+	require.Equal(t, "See attached.", string(content))
+
+	related, err := alternative.NextPart()
+	require.Nil(t, err)
+	mediaType, params, err = mime.ParseMediaType(related.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "multipart/related", mediaType)
+	relatedReader := multipart.NewReader(related, params["boundary"])
+	html, err := relatedReader.NextPart()
+	require.Nil(t, err)
+	require.Equal(t, "text/html; charset=utf-8", html.Header.Get("Content-Type"))
+	logo, err := relatedReader.NextPart()
+	require.Nil(t, err)
+	require.Equal(t, "image/png", logo.Header.Get("Content-Type"))
+	require.Equal(t, "<logo.png>", logo.Header.Get("Content-Id"))
+	require.Equal(t, "inline; filename=logo.png", logo.Header.Get("Content-Disposition"))
+
+	csv, err := mixed.NextPart()
+	require.Nil(t, err)
+	require.Equal(t, "report.csv", csv.FileName())
+	require.Equal(t, "text/csv", csv.Header.Get("Content-Type"))
+	encoded, err := io.ReadAll(csv)
+	require.Nil(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	require.Nil(t, err)
+	require.Equal(t, "a,b\n1,2\n", string(decoded))
+
+	_, err = mixed.NextPart()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestMessageErrors(t *testing.T) {
+	tests := []struct {
+		fields map[string]object.Object
+		err    string
+	}{
+		{map[string]object.Object{"to": object.NewString("a@example.com")}, "value error: email message requires a sender"},
+		{map[string]object.Object{"sender": object.NewString("a@example.com")}, "value error: email message requires at least one recipient"},
+		{map[string]object.Object{"sender": object.NewString("a@example.com"), "to": object.NewString("not an address")}, `value error: invalid address "not an address": mail: no angle-addr`},
+		{map[string]object.Object{"sender": object.NewString("a@example.com"), "color": object.NewString("red")}, `value error: unknown email message field "color"`},
+		{map[string]object.Object{
+			"sender":      object.NewString("a@example.com"),
+			"to":          object.NewString("b@example.com"),
+			"attachments": object.NewList([]object.Object{object.NewMap(map[string]object.Object{"filename": object.NewString("x")})}),
+		}, "value error: email attachment requires either content or a path"},
+	}
+	for _, tt := range tests {
+		result := NewMessage(context.Background(), object.NewMap(tt.fields))
+		errObj, ok := result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+}
+
+// smtpServer is a minimal SMTP server that records the messages it receives.
+type smtpServer struct {
+	listener net.Listener
+	commands []string
+	data     string
+	done     chan bool
+}
+
+func startSMTPServer(t *testing.T) *smtpServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+	s := &smtpServer{listener: listener, done: make(chan bool)}
+	go func() {
+		defer close(s.done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		reply("220 localhost ready")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			s.commands = append(s.commands, line)
+			switch verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); verb {
+			case "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case "AUTH":
+				reply("235 accepted")
+			case "MAIL", "RCPT":
+				reply("250 ok")
+			case "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				s.data = data.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("502 unsupported")
+			}
+		}
+	}()
+	return s
+}
+
+func TestSend(t *testing.T) {
+	server := startSMTPServer(t)
+	_, port, err := net.SplitHostPort(server.listener.Addr().String())
+	require.Nil(t, err)
+	portNum, err := strconv.Atoi(port)
+	require.Nil(t, err)
+
+	result := Send(context.Background(), object.NewMap(map[string]object.Object{
+		"sender":  object.NewString("Alerts <alerts@example.com>"),
+		"to":      object.NewString("ops@example.com"),
+		"cc":      object.NewString("Lead <lead@example.com>"),
+		"subject": object.NewString("Test"),
+		"text":    object.NewString("hello"),
+	}), object.NewMap(map[string]object.Object{
+		"host":     object.NewString("127.0.0.1"),
+		"port":     object.NewInt(int64(portNum)),
+		"security": object.NewString("none"),
+		"username": object.NewString("alerts"),
+		"password": object.NewString("secret"),
+	}))
+	require.Equal(t, object.Nil, result)
+	<-server.done
+
+	require.Contains(t, server.commands, "AUTH PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00alerts\x00secret")))
+	require.Contains(t, server.commands, "MAIL FROM:<alerts@example.com>")
+	require.Contains(t, server.commands, "RCPT TO:<ops@example.com>")
+	require.Contains(t, server.commands, "RCPT TO:<lead@example.com>")
+	parsed, err := mail.ReadMessage(strings.NewReader(server.data))
+	require.Nil(t, err)
+	require.Equal(t, "Test", parsed.Header.Get("Subject"))
+	body, err := io.ReadAll(parsed.Body)
+	require.Nil(t, err)
+	require.Equal(t, "hello\r\n", string(body))
+}
+
+func TestSendErrors(t *testing.T) {
+	ctx := context.Background()
+	msg := object.NewMap(map[string]object.Object{
+		"sender": object.NewString("a@example.com"),
+		"to":     object.NewString("b@example.com"),
+	})
+	result := Send(ctx, msg, object.NewMap(map[string]object.Object{}))
+	require.Equal(t, "value error: email.send() requires a host", result.(*object.Error).Message().Value())
+
+	result = Send(ctx, msg, object.NewMap(map[string]object.Object{
+		"host":     object.NewString("localhost"),
+		"security": object.NewString("ssl"),
+	}))
+	require.Equal(t, `value error: security must be "starttls", "tls", or "none" (got "ssl")`, result.(*object.Error).Message().Value())
+
+	server := startSMTPServer(t)
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+	portNum, _ := strconv.Atoi(port)
+	result = Send(ctx, msg, object.NewMap(map[string]object.Object{
+		"host": object.NewString(host),
+		"port": object.NewInt(int64(portNum)),
+	}))
+	require.Equal(t, "email error: server does not support STARTTLS", result.(*object.Error).Message().Value())
+}
@@ -0,0 +1,532 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+)
+
+const MESSAGE object.Type = "email.message"
+
+// Attachment is a file attached to a message. Inline attachments are shown
+// within the HTML body and referenced by their content ID.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	Inline      bool
+	ContentID   string
+}
+
+// Message is an email message that may contain plain text and HTML bodies
+// along with attachments.
+type Message struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	Headers     map[string]string
+	Attachments []*Attachment
+	Date        time.Time
+}
+
+func (m *Message) Type() object.Type {
+	return MESSAGE
+}
+
+func (m *Message) Inspect() string {
+	return fmt.Sprintf("email.message(subject=%q)", m.Subject)
+}
+
+func (m *Message) Interface() interface{} {
+	return nil
+}
+
+func (m *Message) IsTruthy() bool {
+	return true
+}
+
+func (m *Message) Cost() int {
+	return 0
+}
+
+func (m *Message) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", MESSAGE)
+}
+
+func (m *Message) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", MESSAGE, opType)
+}
+
+func (m *Message) Equals(other object.Object) object.Object {
+	if m == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (m *Message) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", MESSAGE, name)
+}
+
+func (m *Message) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "sender":
+		return object.NewString(m.From), true
+	case "to":
+		return object.NewStringList(m.To), true
+	case "cc":
+		return object.NewStringList(m.Cc), true
+	case "bcc":
+		return object.NewStringList(m.Bcc), true
+	case "subject":
+		return object.NewString(m.Subject), true
+	case "text":
+		return object.NewString(m.Text), true
+	case "html":
+		return object.NewString(m.HTML), true
+	case "recipients":
+		return object.NewStringList(m.Recipients()), true
+	case "attachments":
+		names := make([]string, len(m.Attachments))
+		for i, a := range m.Attachments {
+			names[i] = a.Filename
+		}
+		return object.NewStringList(names), true
+	case "attach":
+		return object.NewBuiltin("email.message.attach", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("email.message.attach", 2, 3, args); err != nil {
+				return err
+			}
+			filename, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			spec := map[string]object.Object{
+				"filename": object.NewString(filename),
+				"content":  args[1],
+			}
+			if len(args) == 3 {
+				opts, err := object.AsMap(args[2])
+				if err != nil {
+					return err
+				}
+				for k, v := range opts.Value() {
+					spec[k] = v
+				}
+			}
+			a, err := parseAttachment(ctx, object.NewMap(spec))
+			if err != nil {
+				return err
+			}
+			m.Attachments = append(m.Attachments, a)
+			return object.Nil
+		}), true
+	case "bytes":
+		return object.NewBuiltin("email.message.bytes", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("email.message.bytes", 0, args); err != nil {
+				return err
+			}
+			data, err := m.Bytes()
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewByteSlice(data)
+		}), true
+	}
+	return nil, false
+}
+
+// Recipients returns the addresses the message is delivered to, including
+// blind copies.
+func (m *Message) Recipients() []string {
+	var result []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		for _, addr := range list {
+			if parsed, err := mail.ParseAddress(addr); err == nil {
+				result = append(result, parsed.Address)
+			}
+		}
+	}
+	return result
+}
+
+// Bytes returns the message encoded in MIME format. Blind copy recipients
+// are omitted from the headers.
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	from, err := formatAddresses([]string{m.From})
+	if err != nil {
+		return nil, err
+	}
+	header.Set("From", from)
+	for name, list := range map[string][]string{"To": m.To, "Cc": m.Cc} {
+		if len(list) == 0 {
+			continue
+		}
+		value, err := formatAddresses(list)
+		if err != nil {
+			return nil, err
+		}
+		header.Set(name, value)
+	}
+	if m.ReplyTo != "" {
+		value, err := formatAddresses([]string{m.ReplyTo})
+		if err != nil {
+			return nil, err
+		}
+		header.Set("Reply-To", value)
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	header.Set("Date", date.Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID(m.From))
+	header.Set("MIME-Version", "1.0")
+	for name, value := range m.Headers {
+		header.Set(name, mime.QEncoding.Encode("utf-8", value))
+	}
+
+	var inline, attached []*Attachment
+	for _, a := range m.Attachments {
+		if a.Inline {
+			inline = append(inline, a)
+		} else {
+			attached = append(attached, a)
+		}
+	}
+
+	// The body is nested as mixed(alternative(text, related(html, inline)),
+	// attachments), omitting any level that has only one part.
+	body := func(w partWriter) error {
+		alternative := func(w partWriter) error {
+			var parts []func(partWriter) error
+			if m.Text != "" || m.HTML == "" {
+				parts = append(parts, textPart("text/plain", m.Text))
+			}
+			if m.HTML != "" {
+				html := textPart("text/html", m.HTML)
+				if len(inline) > 0 {
+					related := []func(partWriter) error{html}
+					for _, a := range inline {
+						related = append(related, attachmentPart(a))
+					}
+					html = multipartPart("related", related)
+				}
+				parts = append(parts, html)
+			}
+			if len(parts) == 1 {
+				return parts[0](w)
+			}
+			return multipartPart("alternative", parts)(w)
+		}
+		if len(attached) == 0 {
+			return alternative(w)
+		}
+		parts := []func(partWriter) error{alternative}
+		for _, a := range attached {
+			parts = append(parts, attachmentPart(a))
+		}
+		return multipartPart("mixed", parts)(w)
+	}
+	if err := body(&rootWriter{w: &buf, header: header}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// partWriter creates a MIME part with the given headers and returns the
+// writer for its body.
+type partWriter interface {
+	CreatePart(header textproto.MIMEHeader) (io.Writer, error)
+}
+
+// rootWriter writes the top-level message headers followed by those of the
+// outermost part.
+type rootWriter struct {
+	w      io.Writer
+	header textproto.MIMEHeader
+}
+
+func (r *rootWriter) CreatePart(header textproto.MIMEHeader) (io.Writer, error) {
+	for k, v := range header {
+		r.header[k] = v
+	}
+	if err := writeHeader(r.w, r.header); err != nil {
+		return nil, err
+	}
+	return r.w, nil
+}
+
+func writeHeader(w io.Writer, header textproto.MIMEHeader) error {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			if _, err := fmt.Fprintf(w, "%s: %s\r\n", k, v); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := io.WriteString(w, "\r\n")
+	return err
+}
+
+func multipartPart(subtype string, parts []func(partWriter) error) func(partWriter) error {
+	return func(w partWriter) error {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for _, part := range parts {
+			if err := part(mw); err != nil {
+				return err
+			}
+		}
+		if err := mw.Close(); err != nil {
+			return err
+		}
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", fmt.Sprintf("multipart/%s; boundary=%q", subtype, mw.Boundary()))
+		out, err := w.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(buf.Bytes())
+		return err
+	}
+}
+
+func textPart(contentType, text string) func(partWriter) error {
+	return func(w partWriter) error {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", contentType+"; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		out, err := w.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		qp := quotedprintable.NewWriter(out)
+		if _, err := qp.Write([]byte(text)); err != nil {
+			return err
+		}
+		return qp.Close()
+	}
+}
+
+func attachmentPart(a *Attachment) func(partWriter) error {
+	return func(w partWriter) error {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", a.ContentType)
+		header.Set("Content-Transfer-Encoding", "base64")
+		disposition := "attachment"
+		if a.Inline {
+			disposition = "inline"
+			header.Set("Content-ID", "<"+a.ContentID+">")
+		}
+		header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+		out, err := w.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			if _, err := io.WriteString(out, encoded[:76]+"\r\n"); err != nil {
+				return err
+			}
+			encoded = encoded[76:]
+		}
+		_, err = io.WriteString(out, encoded+"\r\n")
+		return err
+	}
+}
+
+func formatAddresses(list []string) (string, error) {
+	formatted := make([]string, len(list))
+	for i, addr := range list {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return "", fmt.Errorf("value error: invalid address %q: %w", addr, err)
+		}
+		formatted[i] = parsed.String()
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		if _, host, ok := strings.Cut(parsed.Address, "@"); ok {
+			domain = host
+		}
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("<%s@%s>", hex.EncodeToString(b), domain)
+}
+
+// parseMessage creates a message from a map of its fields.
+func parseMessage(ctx context.Context, m *object.Map) (*Message, *object.Error) {
+	msg := &Message{Headers: map[string]string{}}
+	for key, value := range m.Value() {
+		var err *object.Error
+		switch key {
+		case "sender":
+			msg.From, err = object.AsString(value)
+		case "to":
+			msg.To, err = asAddressList(value)
+		case "cc":
+			msg.Cc, err = asAddressList(value)
+		case "bcc":
+			msg.Bcc, err = asAddressList(value)
+		case "reply_to":
+			msg.ReplyTo, err = object.AsString(value)
+		case "subject":
+			msg.Subject, err = object.AsString(value)
+		case "text":
+			msg.Text, err = object.AsString(value)
+		case "html":
+			msg.HTML, err = object.AsString(value)
+		case "date":
+			msg.Date, err = object.AsTime(value)
+		case "headers":
+			var headers *object.Map
+			if headers, err = object.AsMap(value); err == nil {
+				for name, v := range headers.Value() {
+					if msg.Headers[name], err = object.AsString(v); err != nil {
+						break
+					}
+				}
+			}
+		case "attachments":
+			var items []object.Object
+			if list, ok := value.(*object.List); ok {
+				items = list.Value()
+			} else {
+				err = object.Errorf("type error: attachments must be a list (got %s)", value.Type())
+			}
+			for _, item := range items {
+				spec, err := object.AsMap(item)
+				if err != nil {
+					return nil, err
+				}
+				a, err := parseAttachment(ctx, spec)
+				if err != nil {
+					return nil, err
+				}
+				msg.Attachments = append(msg.Attachments, a)
+			}
+		default:
+			err = object.Errorf("value error: unknown email message field %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if msg.From == "" {
+		return nil, object.Errorf("value error: email message requires a sender")
+	}
+	if len(msg.To)+len(msg.Cc)+len(msg.Bcc) == 0 {
+		return nil, object.Errorf("value error: email message requires at least one recipient")
+	}
+	for _, list := range [][]string{{msg.From}, msg.To, msg.Cc, msg.Bcc} {
+		if _, err := formatAddresses(list); err != nil {
+			return nil, object.NewError(err)
+		}
+	}
+	return msg, nil
+}
+
+// parseAttachment creates an attachment from a map containing the filename
+// and either its content or the path of a file to read it from.
+func parseAttachment(ctx context.Context, m *object.Map) (*Attachment, *object.Error) {
+	a := &Attachment{}
+	var path string
+	var hasContent bool
+	for key, value := range m.Value() {
+		var err *object.Error
+		switch key {
+		case "filename":
+			a.Filename, err = object.AsString(value)
+		case "content":
+			hasContent = true
+			if s, ok := value.(*object.String); ok {
+				a.Data = []byte(s.Value())
+			} else {
+				a.Data, err = object.AsBytes(value)
+			}
+		case "path":
+			path, err = object.AsString(value)
+		case "content_type":
+			a.ContentType, err = object.AsString(value)
+		case "inline":
+			a.Inline, err = object.AsBool(value)
+		case "content_id":
+			a.ContentID, err = object.AsString(value)
+		default:
+			err = object.Errorf("value error: unknown email attachment field %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hasContent == (path != "") {
+		return nil, object.Errorf("value error: email attachment requires either content or a path")
+	}
+	if path != "" {
+		data, err := ros.GetDefaultOS(ctx).ReadFile(path)
+		if err != nil {
+			return nil, object.NewError(err)
+		}
+		a.Data = data
+		if a.Filename == "" {
+			a.Filename = filepath.Base(path)
+		}
+	}
+	if a.Filename == "" {
+		return nil, object.Errorf("value error: email attachment requires a filename")
+	}
+	if a.ContentType == "" {
+		a.ContentType = mime.TypeByExtension(filepath.Ext(a.Filename))
+		if a.ContentType == "" {
+			a.ContentType = "application/octet-stream"
+		}
+	}
+	if a.Inline && a.ContentID == "" {
+		a.ContentID = a.Filename
+	}
+	return a, nil
+}
+
+func asAddressList(obj object.Object) ([]string, *object.Error) {
+	if s, ok := obj.(*object.String); ok {
+		return []string{s.Value()}, nil
+	}
+	return object.AsStringSlice(obj)
+}
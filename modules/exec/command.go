@@ -93,6 +93,22 @@ func (c *Command) GetAttr(name string) (object.Object, bool) {
 			}
 			return object.Nil
 		}), true
+	case "stdout_pipe":
+		return object.NewBuiltin("exec.command.stdout_pipe", func(ctx context.Context, args ...object.Object) object.Object {
+			pipe, err := c.value.StdoutPipe()
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewReader(pipe)
+		}), true
+	case "stderr_pipe":
+		return object.NewBuiltin("exec.command.stderr_pipe", func(ctx context.Context, args ...object.Object) object.Object {
+			pipe, err := c.value.StderrPipe()
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewReader(pipe)
+		}), true
 	}
 	return nil, false
 }
@@ -1,14 +1,12 @@
 package exec
 
 import (
-	"bytes"
 	"context"
-	"fmt"
-	"io"
 	"os/exec"
 
 	"github.com/risor-io/risor/internal/arg"
 	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
 )
 
 func CommandFunc(ctx context.Context, args ...object.Object) object.Object {
@@ -27,7 +25,11 @@ func CommandFunc(ctx context.Context, args ...object.Object) object.Object {
 		}
 		strArgs = append(strArgs, argStr)
 	}
-	return NewCommand(exec.CommandContext(ctx, name, strArgs...))
+	cmd := exec.CommandContext(ctx, name, strArgs...)
+	if env, ok := ros.GetEnv(ctx); ok {
+		cmd.Env = env.Environ()
+	}
+	return NewCommand(cmd)
 }
 
 func LookPath(ctx context.Context, args ...object.Object) object.Object {
@@ -53,88 +55,58 @@ func Exec(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	var optArgs []string
+	argv := []string{program}
 	if len(args) > 1 {
-		optArgs, err = object.AsStringSlice(args[1])
+		optArgs, err := object.AsStringSlice(args[1])
 		if err != nil {
 			return err
 		}
+		argv = append(argv, optArgs...)
 	}
-	cmd := exec.CommandContext(ctx, program, optArgs...)
-
+	opts := &options{}
 	if len(args) > 2 {
-		var params *object.Map
-		var errObj *object.Error
-		params, errObj = object.AsMap(args[2])
-		if errObj != nil {
-			return errObj
-		}
-		if stdoutObj := params.GetWithDefault("stdout", nil); stdoutObj != nil {
-			stdoutBuf, ok := stdoutObj.(io.Writer)
-			if !ok {
-				return object.Errorf("eval error: exec expected io.Writer for stdout (%T given)", stdoutObj)
-			}
-			cmd.Stdout = stdoutBuf
-		}
-		if stderrObj := params.GetWithDefault("stderr", nil); stderrObj != nil {
-			stderrBuf, ok := stderrObj.(io.Writer)
-			if !ok {
-				return object.Errorf("eval error: exec expected io.Writer for stderr (%T given)", stderrObj)
-			}
-			cmd.Stderr = stderrBuf
-		}
-		if stdinObj := params.GetWithDefault("stdin", nil); stdinObj != nil {
-			switch stdinObj := stdinObj.(type) {
-			case *object.ByteSlice:
-				cmd.Stdin = bytes.NewBuffer(stdinObj.Value())
-			case *object.String:
-				cmd.Stdin = bytes.NewBufferString(stdinObj.Value())
-			case io.Reader:
-				cmd.Stdin = stdinObj
-			default:
-				return object.Errorf("eval error: exec expected io.Reader for stdin (%T given)", stdinObj)
-			}
-		}
-		if dirObj := params.GetWithDefault("dir", nil); dirObj != nil {
-			dirStr, err := object.AsString(dirObj)
-			if err != nil {
-				return err
-			}
-			cmd.Dir = dirStr
-		}
-		if envObj := params.GetWithDefault("env", nil); envObj != nil {
-			envMap, err := object.AsMap(envObj)
-			if err != nil {
-				return err
-			}
-			var env []string
-			for key, value := range envMap.Value() {
-				valueStr, err := object.AsString(value)
-				if err != nil {
-					return err
-				}
-				env = append(env, fmt.Sprintf("%s=%s", key, valueStr))
-			}
-			cmd.Env = env
+		if opts, err = parseOptions("exec", args[2]); err != nil {
+			return err
 		}
 	}
+	return runCommands(ctx, "exec", opts, [][]string{argv})
+}
 
-	if cmd.Stdout == nil {
-		cmd.Stdout = object.NewBuffer(nil)
+// Pipeline runs commands with the output of each one connected to the input
+// of the next, and returns the result of the last one.
+func Pipeline(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("exec.pipeline", 1, 1000, args); err != nil {
+		return err
+	}
+	opts := &options{}
+	if m, ok := args[len(args)-1].(*object.Map); ok {
+		var err *object.Error
+		if opts, err = parseOptions("exec.pipeline", m); err != nil {
+			return err
+		}
+		args = args[:len(args)-1]
 	}
-	if cmd.Stderr == nil {
-		cmd.Stderr = object.NewBuffer(nil)
+	if len(args) == 0 {
+		return object.Errorf("value error: exec.pipeline requires at least one command")
 	}
-	cmdObj := NewCommand(cmd)
-	if err := cmdObj.Run(ctx); err != nil {
-		return object.NewError(err)
+	argvs := make([][]string, len(args))
+	for i, a := range args {
+		argv, err := object.AsStringSlice(a)
+		if err != nil {
+			return err
+		}
+		if len(argv) == 0 {
+			return object.Errorf("value error: exec.pipeline command %d is empty", i+1)
+		}
+		argvs[i] = argv
 	}
-	return NewResult(cmd)
+	return runCommands(ctx, "exec.pipeline", opts, argvs)
 }
 
 func Module() *object.Module {
 	return object.NewBuiltinsModule("exec", map[string]object.Object{
 		"command":   object.NewBuiltin("exec.command", CommandFunc),
 		"look_path": object.NewBuiltin("exec.look_path", LookPath),
+		"pipeline":  object.NewBuiltin("exec.pipeline", Pipeline),
 	}, Exec)
 }
@@ -0,0 +1,223 @@
+package exec
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+	"github.com/stretchr/testify/require"
+)
+
+func opts(m map[string]interface{}) object.Object {
+	return object.FromGoType(m)
+}
+
+func argv(args ...string) object.Object {
+	return object.NewStringList(args)
+}
+
+func requireUnix(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a unix shell")
+	}
+}
+
+// collect returns a builtin that appends the lines it's called with.
+func collect(lines *[]string) object.Object {
+	return object.NewBuiltin("collect", func(ctx context.Context, args ...object.Object) object.Object {
+		*lines = append(*lines, args[0].(*object.String).Value())
+		return object.Nil
+	})
+}
+
+func result(t *testing.T, obj object.Object) *Result {
+	t.Helper()
+	r, ok := obj.(*Result)
+	require.True(t, ok, obj.Inspect())
+	return r
+}
+
+func TestExec(t *testing.T) {
+	requireUnix(t)
+	ctx := context.Background()
+	r := result(t, Exec(ctx, object.NewString("sh"), argv("-c", "echo out; echo err >&2")))
+	require.Equal(t, object.NewString("out\n"), r.Stdout())
+	require.Equal(t, object.NewString("err\n"), r.Stderr())
+	code, _ := r.GetAttr("exit_code")
+	require.Equal(t, object.NewInt(0), code)
+
+	r = result(t, Exec(ctx, object.NewString("cat"), argv(), opts(map[string]interface{}{"stdin": "piped"})))
+	require.Equal(t, object.NewString("piped"), r.Stdout())
+
+	buf := object.NewBuffer(nil)
+	r = result(t, Exec(ctx, object.NewString("echo"), argv("hi"), object.NewMap(map[string]object.Object{"stdout": buf})))
+	require.Equal(t, "hi\n", buf.Value().String())
+	require.Equal(t, object.NewString("hi\n"), r.Stdout())
+}
+
+func TestExecStreaming(t *testing.T) {
+	requireUnix(t)
+	ctx := context.Background()
+	var stdout, stderr []string
+	r := result(t, Exec(ctx, object.NewString("sh"), argv("-c", "echo a; echo b >&2; echo c; printf d"),
+		object.NewMap(map[string]object.Object{
+			"stdout": collect(&stdout),
+			"stderr": collect(&stderr),
+		})))
+	require.Equal(t, []string{"a", "c", "d"}, stdout)
+	require.Equal(t, []string{"b"}, stderr)
+	require.Equal(t, object.Nil, r.Stdout())
+
+	// An error from the function stops the command
+	stop := object.NewBuiltin("stop", func(ctx context.Context, args ...object.Object) object.Object {
+		return object.Errorf("value error: stop")
+	})
+	err, ok := Exec(ctx, object.NewString("sh"), argv("-c", "echo a; exec sleep 10"),
+		object.NewMap(map[string]object.Object{"stdout": stop})).(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "value error: stop", err.Message().Value())
+}
+
+func TestExecEnv(t *testing.T) {
+	requireUnix(t)
+	ctx := context.Background()
+	t.Setenv("RISOR_EXEC_TEST", "inherited")
+	script := argv("-c", `echo "$RISOR_EXEC_TEST $EXTRA"`)
+	r := result(t, Exec(ctx, object.NewString("sh"), script))
+	require.Equal(t, object.NewString("inherited \n"), r.Stdout())
+
+	r = result(t, Exec(ctx, object.NewString("/bin/sh"), script, opts(map[string]interface{}{
+		"env": map[string]interface{}{"EXTRA": "x"},
+	})))
+	require.Equal(t, object.NewString(" x\n"), r.Stdout())
+
+	r = result(t, Exec(ctx, object.NewString("sh"), script, opts(map[string]interface{}{
+		"env":         map[string]interface{}{"EXTRA": "x"},
+		"inherit_env": true,
+	})))
+	require.Equal(t, object.NewString("inherited x\n"), r.Stdout())
+
+	// Commands inherit the isolated environment of the script instead
+	ctx = ros.WithEnv(ctx, ros.NewEnv(map[string]string{"RISOR_EXEC_TEST": "isolated"}))
+	r = result(t, Exec(ctx, object.NewString("/bin/sh"), script))
+	require.Equal(t, object.NewString("isolated \n"), r.Stdout())
+
+	r = result(t, Exec(ctx, object.NewString("/bin/sh"), script, opts(map[string]interface{}{
+		"env":         map[string]interface{}{"EXTRA": "x"},
+		"inherit_env": true,
+	})))
+	require.Equal(t, object.NewString("isolated x\n"), r.Stdout())
+}
+
+func TestExecTimeout(t *testing.T) {
+	requireUnix(t)
+	ctx := context.Background()
+	err, ok := Exec(ctx, object.NewString("sleep"), argv("10"), opts(map[string]interface{}{"timeout": "100ms"})).(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "exec error: sleep timed out after 100ms", err.Message().Value())
+
+	r := result(t, Exec(ctx, object.NewString("echo"), argv("fast"), opts(map[string]interface{}{"timeout": 5})))
+	require.Equal(t, object.NewString("fast\n"), r.Stdout())
+}
+
+func TestPipeline(t *testing.T) {
+	requireUnix(t)
+	ctx := context.Background()
+	r := result(t, Pipeline(ctx, argv("printf", "b\na\nb\n"), argv("sort"), argv("uniq", "-c")))
+	require.Equal(t, []string{"1", "a", "2", "b"}, strings.Fields(r.Stdout().(*object.String).Value()))
+
+	// Commands stopped early by a later one aren't failures
+	r = result(t, Pipeline(ctx, argv("yes"), argv("head", "-n", "2")))
+	require.Equal(t, object.NewString("y\ny\n"), r.Stdout())
+
+	var lines []string
+	Pipeline(ctx, argv("cat"), argv("tr", "a-z", "A-Z"), object.NewMap(map[string]object.Object{
+		"stdin":  object.NewString("one\ntwo\n"),
+		"stdout": collect(&lines),
+	}))
+	require.Equal(t, []string{"ONE", "TWO"}, lines)
+
+	err, ok := Pipeline(ctx, argv("sh", "-c", "exit 3"), argv("cat")).(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "sh: exit status 3", err.Message().Value())
+}
+
+func TestPTY(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("pty is only supported on linux")
+	}
+	if _, err := os.Stat("/dev/ptmx"); err != nil {
+		t.Skip("no /dev/ptmx")
+	}
+	ctx := context.Background()
+	r := result(t, Exec(ctx, object.NewString("sh"), argv("-c", "test -t 0 && test -t 1 && echo tty"),
+		opts(map[string]interface{}{"pty": true})))
+	require.Equal(t, object.NewString("tty\r\n"), r.Stdout())
+
+	r = result(t, Exec(ctx, object.NewString("sh"), argv("-c", "test -t 1 || echo pipe")))
+	require.Equal(t, object.NewString("pipe\n"), r.Stdout())
+}
+
+func TestCommandPipes(t *testing.T) {
+	requireUnix(t)
+	ctx := context.Background()
+	cmd := CommandFunc(ctx, object.NewString("sh"), object.NewString("-c"), object.NewString("echo 1; echo 2"))
+	pipe, ok := cmd.GetAttr("stdout_pipe")
+	require.True(t, ok)
+	reader, ok := pipe.(*object.Builtin).Call(ctx).(*object.Reader)
+	require.True(t, ok)
+	start, _ := cmd.GetAttr("start")
+	require.Equal(t, object.Nil, start.(*object.Builtin).Call(ctx))
+	line, _ := reader.GetAttr("read_line")
+	require.Equal(t, object.NewString("1"), line.(*object.Builtin).Call(ctx))
+	require.Equal(t, object.NewString("2"), line.(*object.Builtin).Call(ctx))
+	wait, _ := cmd.GetAttr("wait")
+	require.Equal(t, object.Nil, wait.(*object.Builtin).Call(ctx))
+}
+
+func TestOptionErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"unknown option",
+			Exec(ctx, object.NewString("echo"), argv(), opts(map[string]interface{}{"cwd": "/"})),
+			`value error: unknown exec option "cwd"`,
+		},
+		{
+			"bad timeout",
+			Exec(ctx, object.NewString("echo"), argv(), opts(map[string]interface{}{"timeout": -1})),
+			"value error: timeout must not be negative (got -1s)",
+		},
+		{
+			"bad stdout",
+			Exec(ctx, object.NewString("echo"), argv(), opts(map[string]interface{}{"stdout": 1})),
+			"type error: exec stdout must be a writer or a function (int given)",
+		},
+		{
+			"pty in pipeline",
+			Pipeline(ctx, argv("echo"), argv("cat"), opts(map[string]interface{}{"pty": true})),
+			"value error: exec.pipeline doesn't support the pty option",
+		},
+		{
+			"empty command",
+			Pipeline(ctx, argv("echo"), argv()),
+			"value error: exec.pipeline command 2 is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package exec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+)
+
+// options configures how commands run. Output goes to a writer, or line by
+// line to a Risor function, or else is captured in memory.
+type options struct {
+	dir        string
+	env        map[string]string
+	inheritEnv bool
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	onStdout   object.Object
+	onStderr   object.Object
+	timeout    time.Duration
+	pty        bool
+}
+
+func isCallable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Partial, object.Callable:
+		return true
+	}
+	return false
+}
+
+// output reads the stdout or stderr option, which is either a writer or a
+// function called with each line.
+func output(fn, name string, value object.Object) (io.Writer, object.Object, *object.Error) {
+	if isCallable(value) {
+		return nil, value, nil
+	}
+	// Keep buffers as they are, so results can read what they captured
+	if b, ok := value.(*object.Buffer); ok {
+		return b, nil, nil
+	}
+	w, err := object.AsWriter(value)
+	if err != nil {
+		return nil, nil, object.Errorf("type error: %s %s must be a writer or a function (%s given)", fn, name, value.Type())
+	}
+	return w, nil, nil
+}
+
+func parseOptions(fn string, obj object.Object) (*options, *object.Error) {
+	opts := &options{}
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "dir":
+			opts.dir, err = object.AsString(value)
+		case "env":
+			var env *object.Map
+			if env, err = object.AsMap(value); err != nil {
+				break
+			}
+			opts.env = map[string]string{}
+			for k, v := range env.Value() {
+				if opts.env[k], err = object.AsString(v); err != nil {
+					break
+				}
+			}
+		case "inherit_env":
+			opts.inheritEnv, err = object.AsBool(value)
+		case "stdin":
+			opts.stdin, err = object.AsReader(value)
+		case "stdout":
+			opts.stdout, opts.onStdout, err = output(fn, key, value)
+		case "stderr":
+			opts.stderr, opts.onStderr, err = output(fn, key, value)
+		case "timeout":
+			if opts.timeout, err = arg.Duration(key, value); err == nil && opts.timeout == 0 {
+				err = object.Errorf("value error: %s timeout must be positive (got %s)", fn, opts.timeout)
+			}
+		case "pty":
+			opts.pty, err = object.AsBool(value)
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// environ returns the environment given to commands, or nil for commands to
+// inherit the environment of this process. Scripts with an environment of
+// their own, set by ros.WithEnv, never pass on that of the process.
+func (opts *options) environ(ctx context.Context) []string {
+	isolated, ok := ros.GetEnv(ctx)
+	if opts.env == nil {
+		if ok {
+			return isolated.Environ()
+		}
+		return nil
+	}
+	var env []string
+	if opts.inheritEnv {
+		if ok {
+			env = isolated.Environ()
+		} else {
+			env = os.Environ()
+		}
+	}
+	keys := make([]string, 0, len(opts.env))
+	for k := range opts.env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	// Later entries take precedence over inherited ones
+	for _, k := range keys {
+		env = append(env, fmt.Sprintf("%s=%s", k, opts.env[k]))
+	}
+	return env
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func openPTY() (*os.File, *os.File, error) {
+	return nil, nil, errors.New("exec error: pty is not supported on this platform")
+}
+
+func ptyProcAttr() *syscall.SysProcAttr {
+	return nil
+}
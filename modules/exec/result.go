@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os/exec"
 
 	"github.com/risor-io/risor/object"
@@ -12,7 +13,9 @@ import (
 )
 
 type Result struct {
-	cmd *exec.Cmd
+	cmd    *exec.Cmd
+	stdout io.Writer
+	stderr io.Writer
 }
 
 func (r *Result) Type() object.Type {
@@ -31,6 +34,8 @@ func (r *Result) GetAttr(name string) (object.Object, bool) {
 		return r.Stdout(), true
 	case "stderr":
 		return r.Stderr(), true
+	case "exit_code":
+		return object.NewInt(int64(r.cmd.ProcessState.ExitCode())), true
 	case "json":
 		return object.NewBuiltin("exec.result.json",
 			func(ctx context.Context, args ...object.Object) object.Object {
@@ -44,9 +49,17 @@ func (r *Result) GetAttr(name string) (object.Object, bool) {
 	return nil, false
 }
 
+// writtenTo returns where the command wrote to, which differs from the stdout
+// or stderr of the command when it ran in a pty.
+func writtenTo(w, cmdWriter io.Writer) io.Writer {
+	if w != nil {
+		return w
+	}
+	return cmdWriter
+}
+
 func (r *Result) Stdout() object.Object {
-	value := r.cmd.Stdout
-	switch value := value.(type) {
+	switch value := writtenTo(r.stdout, r.cmd.Stdout).(type) {
 	case *object.Buffer:
 		return object.NewString(value.Value().String())
 	default:
@@ -55,8 +68,7 @@ func (r *Result) Stdout() object.Object {
 }
 
 func (r *Result) Stderr() object.Object {
-	value := r.cmd.Stderr
-	switch value := value.(type) {
+	switch value := writtenTo(r.stderr, r.cmd.Stderr).(type) {
 	case *object.Buffer:
 		return object.NewString(value.Value().String())
 	default:
@@ -66,7 +78,7 @@ func (r *Result) Stderr() object.Object {
 
 func (r *Result) JSON() object.Object {
 	var data []byte
-	switch stdout := r.cmd.Stdout.(type) {
+	switch stdout := writtenTo(r.stdout, r.cmd.Stdout).(type) {
 	case *object.Buffer:
 		data = stdout.Value().Bytes()
 	default:
@@ -0,0 +1,264 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/risor-io/risor/object"
+)
+
+// line is a line of output destined for a Risor function.
+type line struct {
+	fn   object.Object
+	text string
+}
+
+// lineWriter splits output into lines and sends each one to the goroutine
+// running the commands, which calls the function. Calling Risor functions
+// from that goroutine only keeps them from running concurrently.
+type lineWriter struct {
+	fn      object.Object
+	lines   chan<- line
+	stopped <-chan struct{}
+	buf     []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		select {
+		case w.lines <- line{fn: w.fn, text: string(bytes.TrimSuffix(w.buf[:i], []byte("\r")))}:
+		case <-w.stopped:
+			// Nothing reads the lines once the commands are given up on
+			return 0, io.ErrClosedPipe
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// runner runs one command, or a pipeline of commands, with the options.
+type runner struct {
+	fn       string
+	opts     *options
+	cmds     []*exec.Cmd
+	stdout   io.Writer
+	stderr   io.Writer
+	lines    chan line
+	stopped  chan struct{}
+	writers  []*lineWriter
+	closers  []io.Closer
+	copyDone chan struct{}
+}
+
+// destination returns where the stdout or stderr of the commands goes.
+func (r *runner) destination(w io.Writer, fn object.Object) io.Writer {
+	if fn != nil {
+		lw := &lineWriter{fn: fn, lines: r.lines, stopped: r.stopped}
+		r.writers = append(r.writers, lw)
+		return lw
+	}
+	if w != nil {
+		return w
+	}
+	return object.NewBuffer(nil)
+}
+
+// newRunner prepares the commands, each given as a program and its
+// arguments, to run in a pipeline.
+func newRunner(ctx context.Context, fn string, opts *options, argvs [][]string) (*runner, error) {
+	r := &runner{fn: fn, opts: opts, lines: make(chan line), stopped: make(chan struct{})}
+	r.stdout = r.destination(opts.stdout, opts.onStdout)
+	r.stderr = r.destination(opts.stderr, opts.onStderr)
+	env := opts.environ(ctx)
+	for _, argv := range argvs {
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		cmd.Dir = opts.dir
+		cmd.Env = env
+		cmd.Stderr = r.stderr
+		if opts.timeout > 0 {
+			// Don't wait forever for processes that inherited the output
+			// of a killed command
+			cmd.WaitDelay = time.Second
+		}
+		r.cmds = append(r.cmds, cmd)
+	}
+	r.cmds[0].Stdin = opts.stdin
+	r.cmds[len(r.cmds)-1].Stdout = r.stdout
+	// Connect the output of each command to the input of the next one
+	for i := 0; i < len(r.cmds)-1; i++ {
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			r.close()
+			return nil, err
+		}
+		r.cmds[i].Stdout = pw
+		r.cmds[i+1].Stdin = pr
+		r.closers = append(r.closers, pr, pw)
+	}
+	if opts.pty {
+		if err := r.attachPTY(); err != nil {
+			r.close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// attachPTY connects the command to a new pseudo-terminal, and copies what
+// it writes to stdout.
+func (r *runner) attachPTY() error {
+	if len(r.cmds) > 1 {
+		return fmt.Errorf("value error: %s doesn't support the pty option", r.fn)
+	}
+	master, slave, err := openPTY()
+	if err != nil {
+		return err
+	}
+	cmd := r.cmds[0]
+	stdin := cmd.Stdin
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = slave, slave, slave
+	cmd.SysProcAttr = ptyProcAttr()
+	r.closers = append(r.closers, slave)
+	r.copyDone = make(chan struct{})
+	go func() {
+		defer close(r.copyDone)
+		defer master.Close()
+		if stdin != nil {
+			go func() {
+				io.Copy(master, stdin)
+				// End of input for a terminal in canonical mode
+				master.Write([]byte{4})
+			}()
+		}
+		// Reads fail once the command exits and the terminal is closed
+		io.Copy(r.stdout, master)
+	}()
+	return nil
+}
+
+func (r *runner) close() {
+	for _, c := range r.closers {
+		c.Close()
+	}
+	r.closers = nil
+}
+
+// brokenPipe returns true if the command was killed because a later command
+// in the pipeline stopped reading its output, which isn't a failure.
+func brokenPipe(err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && status.Signaled() && status.Signal() == syscall.SIGPIPE
+}
+
+// wait waits for every command, and returns the first failure.
+func (r *runner) wait() error {
+	var first error
+	for i, cmd := range r.cmds {
+		err := cmd.Wait()
+		if err != nil && i < len(r.cmds)-1 && brokenPipe(err) {
+			err = nil
+		}
+		if err != nil && first == nil {
+			if len(r.cmds) > 1 {
+				err = fmt.Errorf("%s: %w", cmd.Args[0], err)
+			}
+			first = err
+		}
+	}
+	if r.copyDone != nil {
+		<-r.copyDone
+	}
+	return first
+}
+
+// run starts the commands and waits for them, passing lines of output to
+// functions as they arrive.
+func (r *runner) run(ctx context.Context, cancel context.CancelFunc) error {
+	defer close(r.stopped)
+	for _, cmd := range r.cmds {
+		if err := cmd.Start(); err != nil {
+			cancel()
+			r.close()
+			for _, started := range r.cmds {
+				if started.Process != nil {
+					started.Wait()
+				}
+			}
+			return err
+		}
+	}
+	// The commands hold their own copies of the pipes and terminal
+	r.close()
+	done := make(chan error, 1)
+	go func() {
+		done <- r.wait()
+	}()
+	var callErr error
+	for {
+		select {
+		case l := <-r.lines:
+			if callErr != nil {
+				continue
+			}
+			if _, err := object.Call(ctx, l.fn, []object.Object{object.NewString(l.text)}); err != nil {
+				// Stop the commands, but keep reading their output until
+				// they exit
+				callErr = err
+				cancel()
+			}
+		case err := <-done:
+			if callErr != nil {
+				return callErr
+			}
+			// Pass on the last line even if it didn't end with a newline
+			for _, w := range r.writers {
+				if len(w.buf) > 0 {
+					if _, err := object.Call(ctx, w.fn, []object.Object{object.NewString(string(w.buf))}); err != nil {
+						return err
+					}
+				}
+			}
+			if err != nil && ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("exec error: %s timed out after %s", r.cmds[len(r.cmds)-1].Args[0], r.opts.timeout)
+			}
+			return err
+		}
+	}
+}
+
+// runCommands runs the commands in a pipeline, and returns the result of
+// the last one.
+func runCommands(ctx context.Context, fn string, opts *options, argvs [][]string) object.Object {
+	var cancel context.CancelFunc
+	if opts.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+	r, err := newRunner(ctx, fn, opts, argvs)
+	if err != nil {
+		return object.NewError(err)
+	}
+	if err := r.run(ctx, cancel); err != nil {
+		return object.NewError(err)
+	}
+	last := r.cmds[len(r.cmds)-1]
+	return &Result{cmd: last, stdout: r.stdout, stderr: r.stderr}
+}
@@ -24,13 +24,13 @@ func Abs(ctx context.Context, args ...object.Object) object.Object {
 	}
 	osObj := GetOS(ctx)
 	if filepath.IsAbs(path) {
-		return object.NewString(filepath.Clean(path))
+		return pathResult(args[0], filepath.Clean(path))
 	}
 	wd, wdErr := osObj.Getwd()
 	if wdErr != nil {
 		return object.NewError(wdErr)
 	}
-	return object.NewString(filepath.Join(wd, path))
+	return pathResult(args[0], filepath.Join(wd, path))
 }
 
 func Base(ctx context.Context, args ...object.Object) object.Object {
@@ -54,7 +54,7 @@ func Clean(ctx context.Context, args ...object.Object) object.Object {
 		return err
 	}
 	cleanPath := filepath.Clean(path)
-	return object.NewString(cleanPath)
+	return pathResult(args[0], cleanPath)
 }
 
 func Dir(ctx context.Context, args ...object.Object) object.Object {
@@ -66,7 +66,7 @@ func Dir(ctx context.Context, args ...object.Object) object.Object {
 		return err
 	}
 	dirPath := filepath.Dir(path)
-	return object.NewString(dirPath)
+	return pathResult(args[0], dirPath)
 }
 
 func Ext(ctx context.Context, args ...object.Object) object.Object {
@@ -102,6 +102,9 @@ func Join(ctx context.Context, args ...object.Object) object.Object {
 		}
 		paths[i] = path
 	}
+	if len(args) > 0 {
+		return pathResult(args[0], filepath.Join(paths...))
+	}
 	return object.NewString(filepath.Join(paths...))
 }
 
@@ -140,7 +143,34 @@ func Rel(ctx context.Context, args ...object.Object) object.Object {
 	if relErr != nil {
 		return object.NewError(relErr)
 	}
-	return object.NewString(relativePath)
+	return pathResult(args[1], relativePath)
+}
+
+func Path(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return object.NewPath(".")
+	}
+	paths := make([]string, len(args))
+	for i, arg := range args {
+		path, err := object.AsString(arg)
+		if err != nil {
+			return err
+		}
+		paths[i] = path
+	}
+	if len(paths) == 1 {
+		return object.NewPath(paths[0])
+	}
+	return object.NewPath(filepath.Join(paths...))
+}
+
+// pathResult returns the given path as a path object if the input was a path
+// object, or as a string otherwise.
+func pathResult(input object.Object, path string) object.Object {
+	if _, ok := input.(*object.Path); ok {
+		return object.NewPath(path)
+	}
+	return object.NewString(path)
 }
 
 func Split(ctx context.Context, args ...object.Object) object.Object {
@@ -242,6 +272,7 @@ func Module() *object.Module {
 		"is_abs":     object.NewBuiltin("is_abs", IsAbs),
 		"join":       object.NewBuiltin("join", Join),
 		"match":      object.NewBuiltin("match", Match),
+		"path":       object.NewBuiltin("path", Path),
 		"rel":        object.NewBuiltin("rel", Rel),
 		"split_list": object.NewBuiltin("split_list", SplitList),
 		"split":      object.NewBuiltin("split", Split),
@@ -138,3 +138,15 @@ func TestWalkDir(t *testing.T) {
 	})
 	require.Equal(t, goldenItems, items)
 }
+
+func TestPath(t *testing.T) {
+	ctx := context.Background()
+	p := Path(ctx, object.NewString("/foo"), object.NewString("bar.txt"))
+	require.Equal(t, object.NewPath("/foo/bar.txt"), p)
+
+	// Path inputs produce path outputs, while strings still produce strings
+	require.Equal(t, object.NewPath("/foo"), Dir(ctx, p))
+	require.Equal(t, object.NewString("/foo"), Dir(ctx, object.NewString("/foo/bar.txt")))
+	require.Equal(t, object.NewPath("/foo/bar.txt/baz"), Join(ctx, p, object.NewString("baz")))
+	require.Equal(t, object.NewString("bar.txt"), Base(ctx, p))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"net/url"
 
 	"github.com/risor-io/risor/object"
 	"github.com/risor-io/risor/os"
@@ -11,6 +12,10 @@ import (
 
 func printableValue(obj object.Object) interface{} {
 	iface := obj.Interface()
+	// URLs are printed without their passwords
+	if u, ok := iface.(*url.URL); ok {
+		return u.Redacted()
+	}
 	if iface != nil {
 		return iface
 	}
@@ -35,7 +40,7 @@ func Printf(ctx context.Context, args ...object.Object) object.Object {
 	for _, arg := range args[1:] {
 		values = append(values, printableValue(arg))
 	}
-	stdout := os.GetDefaultOS(ctx).Stdout()
+	stdout := os.Stdout(ctx)
 	if _, ioErr := fmt.Fprintf(stdout, format, values...); ioErr != nil {
 		return object.Errorf("io error: %v", ioErr)
 	}
@@ -47,7 +52,7 @@ func Println(ctx context.Context, args ...object.Object) object.Object {
 	for _, arg := range args {
 		values = append(values, printableValue(arg))
 	}
-	stdout := os.GetDefaultOS(ctx).Stdout()
+	stdout := os.Stdout(ctx)
 	if _, ioErr := fmt.Fprintln(stdout, values...); ioErr != nil {
 		return object.Errorf("io error: %v", ioErr)
 	}
@@ -0,0 +1,216 @@
+package fuzzy
+
+import (
+	"context"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// twoStrings returns the two string arguments of a distance function.
+func twoStrings(fn string, args []object.Object) (string, string, *object.Error) {
+	if err := arg.Require(fn, 2, args); err != nil {
+		return "", "", err
+	}
+	a, err := object.AsString(args[0])
+	if err != nil {
+		return "", "", err
+	}
+	b, err := object.AsString(args[1])
+	if err != nil {
+		return "", "", err
+	}
+	return a, b, nil
+}
+
+func LevenshteinBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	a, b, err := twoStrings("fuzzy.levenshtein", args)
+	if err != nil {
+		return err
+	}
+	return object.NewInt(int64(Levenshtein(a, b)))
+}
+
+func SimilarityBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	a, b, err := twoStrings("fuzzy.similarity", args)
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Similarity(a, b))
+}
+
+func JaroBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	a, b, err := twoStrings("fuzzy.jaro", args)
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Jaro(a, b))
+}
+
+func JaroWinklerBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	a, b, err := twoStrings("fuzzy.jaro_winkler", args)
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(JaroWinkler(a, b))
+}
+
+type matchOptions struct {
+	caseSensitive bool
+	limit         int64
+	key           string
+}
+
+// parseMatchOptions reads the options map given as the last argument, if
+// there is one. Options other than case_sensitive must be listed in allowed.
+func parseMatchOptions(fn string, args []object.Object, allowed ...string) (matchOptions, *object.Error) {
+	var opts matchOptions
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch {
+		case key == "case_sensitive":
+			opts.caseSensitive, err = object.AsBool(value)
+		case key == "limit" && contains(allowed, key):
+			if opts.limit, err = object.AsInt(value); err == nil && opts.limit < 0 {
+				err = object.Errorf("value error: %s limit must be zero or more (got %d)", fn, opts.limit)
+			}
+		case key == "key" && contains(allowed, key):
+			opts.key, err = object.AsString(value)
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func matchValue(m Match) *object.Map {
+	positions := make([]object.Object, len(m.Positions))
+	for i, p := range m.Positions {
+		positions[i] = object.NewInt(int64(p))
+	}
+	return object.NewMap(map[string]object.Object{
+		"score":     object.NewInt(int64(m.Score)),
+		"positions": object.NewList(positions),
+	})
+}
+
+func MatchBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("fuzzy.match", 2, 3, args); err != nil {
+		return err
+	}
+	pattern, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	text, err := object.AsString(args[1])
+	if err != nil {
+		return err
+	}
+	opts, err := parseMatchOptions("fuzzy.match", args[2:])
+	if err != nil {
+		return err
+	}
+	m, ok := MatchString(pattern, text, opts.caseSensitive)
+	if !ok {
+		return object.Nil
+	}
+	return matchValue(m)
+}
+
+// Find matches the needle against each item of the haystack, and returns
+// the items that match, best first.
+func Find(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("fuzzy.find", 2, 3, args); err != nil {
+		return err
+	}
+	needle, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	haystack, err := object.AsList(args[1])
+	if err != nil {
+		return err
+	}
+	opts, err := parseMatchOptions("fuzzy.find", args[2:], "limit", "key")
+	if err != nil {
+		return err
+	}
+	type result struct {
+		index int
+		item  object.Object
+		text  string
+		match Match
+	}
+	var results []result
+	for i, item := range haystack.Value() {
+		var text string
+		if opts.key != "" {
+			m, err := object.AsMap(item)
+			if err != nil {
+				return err
+			}
+			value, ok := m.Value()[opts.key]
+			if !ok {
+				continue
+			}
+			if text, err = object.AsString(value); err != nil {
+				return err
+			}
+		} else if text, err = object.AsString(item); err != nil {
+			return err
+		}
+		if m, ok := MatchString(needle, text, opts.caseSensitive); ok {
+			results = append(results, result{index: i, item: item, text: text, match: m})
+		}
+	}
+	// Ties go to the shorter text, which the needle covers more of, and
+	// then to the earlier item
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].match.Score != results[j].match.Score {
+			return results[i].match.Score > results[j].match.Score
+		}
+		return utf8.RuneCountInString(results[i].text) < utf8.RuneCountInString(results[j].text)
+	})
+	if opts.limit > 0 && int64(len(results)) > opts.limit {
+		results = results[:opts.limit]
+	}
+	items := make([]object.Object, len(results))
+	for i, r := range results {
+		m := matchValue(r.match)
+		m.Set("value", r.item)
+		m.Set("index", object.NewInt(int64(r.index)))
+		items[i] = m
+	}
+	return object.NewList(items)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("fuzzy", map[string]object.Object{
+		"find":         object.NewBuiltin("find", Find),
+		"jaro":         object.NewBuiltin("jaro", JaroBuiltin),
+		"jaro_winkler": object.NewBuiltin("jaro_winkler", JaroWinklerBuiltin),
+		"levenshtein":  object.NewBuiltin("levenshtein", LevenshteinBuiltin),
+		"match":        object.NewBuiltin("match", MatchBuiltin),
+		"similarity":   object.NewBuiltin("similarity", SimilarityBuiltin),
+	})
+}
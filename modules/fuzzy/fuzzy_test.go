@@ -0,0 +1,157 @@
+package fuzzy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"flaw", "lawn", 2},
+		{"héllo", "hello", 1},
+		{"same", "same", 0},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, Levenshtein(tt.a, tt.b), "%q %q", tt.a, tt.b)
+		require.Equal(t, tt.want, Levenshtein(tt.b, tt.a), "%q %q", tt.b, tt.a)
+	}
+	require.Equal(t, 1.0, Similarity("", ""))
+	require.InDelta(t, 1-3.0/7, Similarity("kitten", "sitting"), 1e-9)
+}
+
+func TestJaroWinkler(t *testing.T) {
+	tests := []struct {
+		a, b        string
+		jaro, jaroW float64
+	}{
+		{"MARTHA", "MARHTA", 0.944, 0.961},
+		{"DWAYNE", "DUANE", 0.822, 0.840},
+		{"DIXON", "DICKSONX", 0.767, 0.813},
+		{"abc", "xyz", 0, 0},
+		{"", "", 1, 1},
+		{"a", "", 0, 0},
+	}
+	for _, tt := range tests {
+		require.InDelta(t, tt.jaro, Jaro(tt.a, tt.b), 0.001, "%q %q", tt.a, tt.b)
+		require.InDelta(t, tt.jaroW, JaroWinkler(tt.a, tt.b), 0.001, "%q %q", tt.a, tt.b)
+	}
+}
+
+func TestMatchString(t *testing.T) {
+	m, ok := MatchString("fb", "foo_bar", false)
+	require.True(t, ok)
+	require.Equal(t, []int{0, 4}, m.Positions)
+
+	// Matches at word boundaries are preferred over earlier ones
+	m, ok = MatchString("ab", "xaxb a_b", false)
+	require.True(t, ok)
+	require.Equal(t, []int{5, 7}, m.Positions)
+
+	// As are runs of consecutive matches
+	m, ok = MatchString("bar", "b_a_r bar", false)
+	require.True(t, ok)
+	require.Equal(t, []int{6, 7, 8}, m.Positions)
+
+	m, ok = MatchString("GoF", "goFile", true)
+	require.False(t, ok)
+	m, ok = MatchString("GoF", "goFile", false)
+	require.True(t, ok)
+	require.Equal(t, []int{0, 1, 2}, m.Positions)
+
+	_, ok = MatchString("abc", "acb", false)
+	require.False(t, ok)
+	m, ok = MatchString("", "anything", false)
+	require.True(t, ok)
+	require.Empty(t, m.Positions)
+
+	exact, _ := MatchString("main", "main.go", false)
+	spread, _ := MatchString("main", "my_app_init_n", false)
+	require.Greater(t, exact.Score, spread.Score)
+}
+
+func TestFind(t *testing.T) {
+	ctx := context.Background()
+	haystack := object.NewStringList([]string{
+		"internal/config/config.go",
+		"cmd/fmt/go.mod",
+		"cfg.go",
+		"README.md",
+		"docs/cfg.go.md",
+	})
+	// Matches at the start of words rank above ones in the middle
+	result := Find(ctx, object.NewString("cfg"), haystack)
+	list, ok := result.(*object.List)
+	require.True(t, ok, result.Inspect())
+	var values []string
+	for _, item := range list.Value() {
+		values = append(values, item.(*object.Map).Get("value").(*object.String).Value())
+	}
+	require.Equal(t, []string{"cfg.go", "docs/cfg.go.md", "cmd/fmt/go.mod", "internal/config/config.go"}, values)
+
+	first := list.Value()[0].(*object.Map)
+	require.Equal(t, object.NewInt(2), first.Get("index"))
+	require.Equal(t, object.NewList([]object.Object{object.NewInt(0), object.NewInt(1), object.NewInt(2)}), first.Get("positions"))
+
+	result = Find(ctx, object.NewString("cfg"), haystack, object.FromGoType(map[string]interface{}{"limit": 1}))
+	require.Len(t, result.(*object.List).Value(), 1)
+
+	// Items may be maps, matched by one of their keys
+	people := object.FromGoType([]interface{}{
+		map[string]interface{}{"name": "Ada Lovelace", "id": 1},
+		map[string]interface{}{"name": "Alan Turing", "id": 2},
+		map[string]interface{}{"id": 3},
+	})
+	result = Find(ctx, object.NewString("at"), people, object.FromGoType(map[string]interface{}{"key": "name"}))
+	list = result.(*object.List)
+	require.Len(t, list.Value(), 1)
+	person := list.Value()[0].(*object.Map).Get("value").(*object.Map)
+	require.Equal(t, object.NewString("Alan Turing"), person.Get("name"))
+}
+
+func TestOptionErrors(t *testing.T) {
+	ctx := context.Background()
+	haystack := object.NewStringList([]string{"a"})
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"unknown find option",
+			Find(ctx, object.NewString("a"), haystack, object.FromGoType(map[string]interface{}{"max": 1})),
+			`value error: unknown fuzzy.find option "max"`,
+		},
+		{
+			"negative limit",
+			Find(ctx, object.NewString("a"), haystack, object.FromGoType(map[string]interface{}{"limit": -1})),
+			"value error: fuzzy.find limit must be zero or more (got -1)",
+		},
+		{
+			"limit on match",
+			MatchBuiltin(ctx, object.NewString("a"), object.NewString("a"), object.FromGoType(map[string]interface{}{"limit": 1})),
+			`value error: unknown fuzzy.match option "limit"`,
+		},
+		{
+			"non-string item",
+			Find(ctx, object.NewString("a"), object.NewList([]object.Object{object.NewInt(1)})),
+			"type error: expected a string (int given)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package fuzzy
+
+import (
+	"math"
+	"unicode"
+)
+
+// Scores follow the scheme popularized by fzf: each matched character is
+// worth the same, gaps between matches cost a little, and matches at the
+// start of words, or continuing a run of matches, earn a bonus.
+const (
+	scoreMatch        = 16
+	scoreGapStart     = -3
+	scoreGapExtension = -1
+	bonusBoundary     = scoreMatch / 2
+	bonusCamelCase    = bonusBoundary + scoreGapExtension
+	bonusConsecutive  = -(scoreGapStart + scoreGapExtension)
+	bonusFirstChar    = 2
+)
+
+const noScore = math.MinInt32 / 2
+
+// Match is the result of matching a pattern against a string.
+type Match struct {
+	Score int
+	// Positions holds the rune indexes of the matched characters.
+	Positions []int
+}
+
+type charClass int
+
+const (
+	classOther charClass = iota
+	classLower
+	classUpper
+	classDigit
+)
+
+func classOf(r rune) charClass {
+	switch {
+	case unicode.IsLower(r):
+		return classLower
+	case unicode.IsUpper(r):
+		return classUpper
+	case unicode.IsDigit(r):
+		return classDigit
+	case unicode.IsLetter(r):
+		return classLower
+	}
+	return classOther
+}
+
+// bonusAt returns the bonus for matching the character at index i, based on
+// whether it starts a word.
+func bonusAt(text []rune, i int) int {
+	curr := classOf(text[i])
+	if curr == classOther {
+		return 0
+	}
+	if i == 0 {
+		return bonusBoundary
+	}
+	prev := classOf(text[i-1])
+	switch {
+	case prev == classOther:
+		return bonusBoundary
+	case prev == classLower && curr == classUpper,
+		prev != classDigit && curr == classDigit:
+		return bonusCamelCase
+	}
+	return 0
+}
+
+// MatchString reports whether the characters of pattern appear in text in
+// order, though not necessarily next to each other, and if so, returns the
+// best scoring way to match them. Matching ignores case unless
+// caseSensitive is true.
+func MatchString(pattern, text string, caseSensitive bool) (Match, bool) {
+	p, t := []rune(pattern), []rune(text)
+	if len(p) == 0 {
+		return Match{Positions: []int{}}, true
+	}
+	if len(p) > len(t) {
+		return Match{}, false
+	}
+	fold := func(r rune) rune { return r }
+	if !caseSensitive {
+		fold = unicode.ToLower
+	}
+	// Quickly rule out texts that don't contain the pattern at all
+	j := 0
+	for _, r := range t {
+		if j < len(p) && fold(r) == fold(p[j]) {
+			j++
+		}
+	}
+	if j < len(p) {
+		return Match{}, false
+	}
+
+	n := len(t)
+	bonus := make([]int, n)
+	for i := range t {
+		bonus[i] = bonusAt(t, i)
+	}
+	// score[i][j] is the best score for matching p[:i+1] with p[i] matched
+	// at t[j], from[i][j] is where p[i-1] was matched in that case, and
+	// run[i][j] is the bonus of the first match in the run ending at t[j].
+	score := make([][]int, len(p))
+	from := make([][]int, len(p))
+	run := make([][]int, len(p))
+	for i := range p {
+		score[i] = make([]int, n)
+		from[i] = make([]int, n)
+		run[i] = make([]int, n)
+		pr := fold(p[i])
+		// The best score for a match of p[i-1] more than one character
+		// back, less the cost of the gap, is carried along the row
+		gapScore, gapFrom := noScore, -1
+		for j := 0; j < n; j++ {
+			score[i][j] = noScore
+			if i > 0 && j >= 2 {
+				gapScore += scoreGapExtension
+				if s := score[i-1][j-2] + scoreGapStart; s > gapScore {
+					gapScore, gapFrom = s, j-2
+				}
+			}
+			if fold(t[j]) != pr {
+				continue
+			}
+			run[i][j] = bonus[j]
+			if i == 0 {
+				score[i][j] = scoreMatch + bonus[j]*bonusFirstChar
+				from[i][j] = -1
+				continue
+			}
+			best, bestFrom, bestBonus := gapScore, gapFrom, bonus[j]
+			if j >= 1 && score[i-1][j-1] > noScore/2 {
+				// Continuing a run of matches earns the bonus of the
+				// match that started it, if that is larger, so whole
+				// words beat scattered word starts
+				b := max3(bonus[j], run[i-1][j-1], bonusConsecutive)
+				if s := score[i-1][j-1] + b - bonus[j]; s >= best {
+					best, bestFrom, bestBonus = s, j-1, max3(bonus[j], run[i-1][j-1], 0)
+				}
+			}
+			if best <= noScore/2 {
+				continue
+			}
+			score[i][j] = best + scoreMatch + bonus[j]
+			from[i][j] = bestFrom
+			run[i][j] = bestBonus
+		}
+	}
+
+	last := len(p) - 1
+	end := -1
+	for j := 0; j < n; j++ {
+		if score[last][j] > noScore/2 && (end < 0 || score[last][j] > score[last][end]) {
+			end = j
+		}
+	}
+	if end < 0 {
+		return Match{}, false
+	}
+	positions := make([]int, len(p))
+	for i, j := last, end; i >= 0; i-- {
+		positions[i] = j
+		j = from[i][j]
+	}
+	return Match{Score: score[last][end], Positions: positions}, true
+}
+
+func max3(a, b, c int) int {
+	if b > a {
+		a = b
+	}
+	if c > a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,180 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// clientOptions holds the options shared by all clients.
+type clientOptions struct {
+	project         string
+	credentialsFile string
+	credentialsJSON string
+	endpoint        string
+	insecure        bool
+	extra           map[string]object.Object
+}
+
+// parseClientOptions parses the shared options. Any other keys are returned
+// in extra if they are named in allowed.
+func parseClientOptions(fn string, args []object.Object, allowed ...string) (clientOptions, *object.Error) {
+	opts := clientOptions{extra: map[string]object.Object{}}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "project":
+			opts.project, err = object.AsString(value)
+		case "credentials_file":
+			opts.credentialsFile, err = object.AsString(value)
+		case "credentials_json":
+			opts.credentialsJSON, err = object.AsString(value)
+		case "endpoint":
+			opts.endpoint, err = object.AsString(value)
+		case "insecure":
+			opts.insecure, err = object.AsBool(value)
+		default:
+			if !contains(allowed, key) {
+				return opts, object.Errorf("value error: unknown %s option %q", fn, key)
+			}
+			opts.extra[key] = value
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// clientOptions returns the options for creating a Google Cloud client.
+// Credentials are found with Application Default Credentials unless they
+// are given explicitly. Insecure clients skip authentication, and gRPC
+// clients also connect without TLS, as needed for local emulators.
+func (opts clientOptions) clientOptions(grpcClient bool) []option.ClientOption {
+	var result []option.ClientOption
+	if opts.endpoint != "" {
+		result = append(result, option.WithEndpoint(opts.endpoint))
+	}
+	switch {
+	case opts.insecure:
+		result = append(result, option.WithoutAuthentication())
+		if grpcClient {
+			result = append(result, option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())))
+		}
+	case opts.credentialsFile != "":
+		result = append(result, option.WithCredentialsFile(opts.credentialsFile))
+	case opts.credentialsJSON != "":
+		result = append(result, option.WithCredentialsJSON([]byte(opts.credentialsJSON)))
+	}
+	return result
+}
+
+// detectProject returns the project given in the options, in the
+// environment, or in the credentials, in that order.
+func (opts clientOptions) detectProject(ctx context.Context) (string, error) {
+	if opts.project != "" {
+		return opts.project, nil
+	}
+	for _, name := range []string{"GOOGLE_CLOUD_PROJECT", "GCLOUD_PROJECT"} {
+		if project := os.Getenv(name); project != "" {
+			return project, nil
+		}
+	}
+	if !opts.insecure {
+		var creds *google.Credentials
+		var err error
+		switch {
+		case opts.credentialsFile != "":
+			var data []byte
+			if data, err = os.ReadFile(opts.credentialsFile); err == nil {
+				creds, err = google.CredentialsFromJSON(ctx, data, cloudPlatformScope)
+			}
+		case opts.credentialsJSON != "":
+			creds, err = google.CredentialsFromJSON(ctx, []byte(opts.credentialsJSON), cloudPlatformScope)
+		default:
+			creds, err = google.FindDefaultCredentials(ctx, cloudPlatformScope)
+		}
+		if err == nil && creds.ProjectID != "" {
+			return creds.ProjectID, nil
+		}
+	}
+	return "", fmt.Errorf("gcp error: no project was given and none could be detected")
+}
+
+func gcpError(err error) *object.Error {
+	return object.NewError(fmt.Errorf("gcp error: %w", err))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func timeValue(t time.Time) object.Object {
+	if t.IsZero() {
+		return object.Nil
+	}
+	return object.NewTime(t)
+}
+
+func stringMap(m map[string]string) *object.Map {
+	items := make(map[string]object.Object, len(m))
+	for k, v := range m {
+		items[k] = object.NewString(v)
+	}
+	return object.NewMap(items)
+}
+
+func asStringMap(obj object.Object) (map[string]string, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, m.Size())
+	for k, v := range m.Value() {
+		if result[k], err = object.AsString(v); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// asKey returns the bytes of a string or byte_slice.
+func asKey(obj object.Object) ([]byte, *object.Error) {
+	if b, ok := obj.(*object.ByteSlice); ok {
+		return b.Value(), nil
+	}
+	s, err := object.AsString(obj)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("gcp", map[string]object.Object{
+		"pubsub":  object.NewBuiltin("pubsub", NewPubSubBuiltin),
+		"secret":  object.NewBuiltin("secret", Secret),
+		"secrets": object.NewBuiltin("secrets", NewSecretsBuiltin),
+		"storage": object.NewBuiltin("storage", NewStorageBuiltin),
+	})
+}
@@ -0,0 +1,324 @@
+package gcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func call(t *testing.T, obj object.Object, method string, args ...object.Object) object.Object {
+	t.Helper()
+	fn, ok := obj.GetAttr(method)
+	require.True(t, ok)
+	return fn.(*object.Builtin).Call(context.Background(), args...)
+}
+
+func collect(t *testing.T, result object.Object) []object.Object {
+	t.Helper()
+	stream, ok := result.(*object.Stream)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	var items []object.Object
+	for {
+		item, ok := stream.Next(context.Background())
+		if !ok {
+			break
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// fakeGCS is an in-memory Cloud Storage server supporting the JSON API
+// requests, multipart uploads, and XML reads made by the client library.
+type fakeGCS struct {
+	mu      sync.Mutex
+	objects map[string]map[string]any
+	data    map[string][]byte
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/upload/storage/v1/b/"):
+		bucket := strings.TrimSuffix(strings.TrimPrefix(path, "/upload/storage/v1/b/"), "/o")
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		mr := multipart.NewReader(r.Body, params["boundary"])
+		part, _ := mr.NextPart()
+		var attrs map[string]any
+		json.NewDecoder(part).Decode(&attrs)
+		part, _ = mr.NextPart()
+		data, _ := io.ReadAll(part)
+		name := attrs["name"].(string)
+		attrs["bucket"] = bucket
+		attrs["size"] = strconv.Itoa(len(data))
+		attrs["generation"] = "1"
+		attrs["updated"] = "2024-01-02T03:04:05Z"
+		f.objects[bucket+"/"+name] = attrs
+		f.data[bucket+"/"+name] = data
+		json.NewEncoder(w).Encode(attrs)
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/storage/v1/b/") && strings.HasSuffix(path, "/o"):
+		bucket := strings.TrimSuffix(strings.TrimPrefix(path, "/storage/v1/b/"), "/o")
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for key := range f.objects {
+			if strings.HasPrefix(key, bucket+"/"+prefix) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		items := []any{}
+		for _, key := range keys {
+			items = append(items, f.objects[key])
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case strings.HasPrefix(path, "/storage/v1/b/"):
+		bucket, name, _ := strings.Cut(strings.TrimPrefix(path, "/storage/v1/b/"), "/o/")
+		key := bucket + "/" + name
+		attrs, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]any{"error": map[string]any{"code": 404, "message": "No such object"}})
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.objects, key)
+			delete(f.data, key)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(attrs)
+	case r.Method == http.MethodGet:
+		data, ok := f.data[strings.TrimPrefix(path, "/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Goog-Generation", "1")
+		w.Write(data)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	f := &fakeGCS{objects: map[string]map[string]any{}, data: map[string][]byte{}}
+	server := httptest.NewServer(f)
+	defer server.Close()
+	client := NewStorageBuiltin(context.Background(), object.NewMap(map[string]object.Object{
+		"endpoint": object.NewString(server.URL + "/storage/v1/"),
+		"insecure": object.True,
+	}))
+	s, ok := client.(*Storage)
+	require.True(t, ok, "unexpected result: %s", client.Inspect())
+
+	attrs := call(t, s, "put", object.NewString("bucket"), object.NewString("logs/a.txt"), object.NewString("hello"),
+		object.NewMap(map[string]object.Object{
+			"content_type": object.NewString("text/plain"),
+			"metadata":     object.NewMap(map[string]object.Object{"owner": object.NewString("ops")}),
+		}))
+	require.IsType(t, &object.Map{}, attrs, attrs.Inspect())
+	require.Equal(t, object.NewString("logs/a.txt"), attrs.(*object.Map).Get("name"))
+	require.Equal(t, "text/plain", f.objects["bucket/logs/a.txt"]["contentType"])
+	require.Equal(t, map[string]any{"owner": "ops"}, f.objects["bucket/logs/a.txt"]["metadata"])
+
+	reader := object.NewReader(strings.NewReader("world"))
+	call(t, s, "put", object.NewString("bucket"), object.NewString("logs/b.txt"), reader)
+	require.Equal(t, []byte("world"), f.data["bucket/logs/b.txt"])
+
+	require.Equal(t, object.NewByteSlice([]byte("hello")), call(t, s, "get", object.NewString("bucket"), object.NewString("logs/a.txt")))
+
+	attrs = call(t, s, "attrs", object.NewString("bucket"), object.NewString("logs/a.txt"))
+	require.Equal(t, object.NewInt(5), attrs.(*object.Map).Get("size"))
+	require.Equal(t, object.NewString("ops"), attrs.(*object.Map).Get("metadata").(*object.Map).Get("owner"))
+
+	require.Equal(t, object.True, call(t, s, "exists", object.NewString("bucket"), object.NewString("logs/a.txt")))
+	require.Equal(t, object.False, call(t, s, "exists", object.NewString("bucket"), object.NewString("logs/c.txt")))
+
+	var names []string
+	for _, item := range collect(t, call(t, s, "list", object.NewString("bucket"),
+		object.NewMap(map[string]object.Object{"prefix": object.NewString("logs/")}))) {
+		names = append(names, item.(*object.Map).Get("name").(*object.String).Value())
+	}
+	require.Equal(t, []string{"logs/a.txt", "logs/b.txt"}, names)
+
+	require.Equal(t, object.Nil, call(t, s, "delete", object.NewString("bucket"),
+		object.NewList([]object.Object{object.NewString("logs/a.txt"), object.NewString("logs/b.txt")})))
+	require.Empty(t, f.objects)
+
+	result := call(t, s, "get", object.NewString("bucket"), object.NewString("logs/a.txt"))
+	require.Equal(t, "gcp error: storage: object doesn't exist", result.(*object.Error).Message().Value())
+}
+
+func TestSignedURL(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	creds, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "test",
+		"client_email": "signer@test.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+		"token_uri":    "https://oauth2.googleapis.com/token",
+	})
+	require.Nil(t, err)
+	s := NewStorageBuiltin(context.Background(), object.NewMap(map[string]object.Object{
+		"credentials_json": object.NewString(string(creds)),
+	})).(*Storage)
+	result := call(t, s, "signed_url", object.NewString("bucket"), object.NewString("report.pdf"),
+		object.NewMap(map[string]object.Object{"expires": object.NewString("1h")}))
+	u, err := url.Parse(result.(*object.String).Value())
+	require.Nil(t, err)
+	require.Equal(t, "/bucket/report.pdf", u.Path)
+	// The expiry is measured from when the URL is signed
+	require.Contains(t, []string{"3599", "3600"}, u.Query().Get("X-Goog-Expires"))
+	require.True(t, strings.HasPrefix(u.Query().Get("X-Goog-Credential"), "signer@test.iam.gserviceaccount.com/"))
+
+	result = call(t, s, "signed_url", object.NewString("bucket"), object.NewString("report.pdf"),
+		object.NewMap(map[string]object.Object{"method": object.NewString("post")}))
+	require.Equal(t, `value error: signed_url method must be GET, PUT, HEAD, or DELETE (got "POST")`,
+		result.(*object.Error).Message().Value())
+}
+
+func TestPubSub(t *testing.T) {
+	ctx := context.Background()
+	server := pstest.NewServer()
+	defer server.Close()
+	client := NewPubSubBuiltin(ctx, object.NewMap(map[string]object.Object{
+		"project":  object.NewString("test"),
+		"endpoint": object.NewString(server.Addr),
+		"insecure": object.True,
+	}))
+	p, ok := client.(*PubSub)
+	require.True(t, ok, "unexpected result: %s", client.Inspect())
+	defer p.Close()
+	topic, err := p.Value().CreateTopic(ctx, "events")
+	require.Nil(t, err)
+	_, err = p.Value().CreateSubscription(ctx, "worker", pubsub.SubscriptionConfig{Topic: topic})
+	require.Nil(t, err)
+
+	for _, body := range []string{"one", "two", "three"} {
+		id := call(t, p, "publish", object.NewString("events"), object.NewString(body),
+			object.NewMap(map[string]object.Object{
+				"attributes": object.NewMap(map[string]object.Object{"source": object.NewString("test")}),
+			}))
+		require.IsType(t, &object.String{}, id)
+	}
+
+	messages := collect(t, call(t, p, "subscribe", object.NewString("worker"),
+		object.NewMap(map[string]object.Object{"max": object.NewInt(3), "timeout": object.NewInt(10)})))
+	var bodies []string
+	for _, msg := range messages {
+		m := msg.(*Message)
+		data, _ := m.GetAttr("data")
+		bodies = append(bodies, string(data.(*object.ByteSlice).Value()))
+		attributes, _ := m.GetAttr("attributes")
+		require.Equal(t, object.NewString("test"), attributes.(*object.Map).Get("source"))
+		call(t, m, "ack")
+	}
+	sort.Strings(bodies)
+	require.Equal(t, []string{"one", "three", "two"}, bodies)
+
+	// The stream ends when the timeout is reached
+	messages = collect(t, call(t, p, "subscribe", object.NewString("worker"),
+		object.NewMap(map[string]object.Object{"timeout": object.NewString("200ms")})))
+	require.Empty(t, messages)
+}
+
+type fakeSecretManager struct {
+	secretmanagerpb.UnimplementedSecretManagerServiceServer
+	mu       sync.Mutex
+	versions map[string][][]byte
+}
+
+func (f *fakeSecretManager) AccessSecretVersion(ctx context.Context, req *secretmanagerpb.AccessSecretVersionRequest) (*secretmanagerpb.AccessSecretVersionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	secret, version, _ := strings.Cut(req.Name, "/versions/")
+	versions := f.versions[secret]
+	if len(versions) == 0 || version != "latest" {
+		return nil, status.Errorf(codes.NotFound, "secret version %s not found", req.Name)
+	}
+	return &secretmanagerpb.AccessSecretVersionResponse{
+		Name:    req.Name,
+		Payload: &secretmanagerpb.SecretPayload{Data: versions[len(versions)-1]},
+	}, nil
+}
+
+func (f *fakeSecretManager) AddSecretVersion(ctx context.Context, req *secretmanagerpb.AddSecretVersionRequest) (*secretmanagerpb.SecretVersion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.versions[req.Parent] = append(f.versions[req.Parent], req.Payload.Data)
+	return &secretmanagerpb.SecretVersion{Name: req.Parent + "/versions/" + string(rune('0'+len(f.versions[req.Parent])))}, nil
+}
+
+func TestSecrets(t *testing.T) {
+	ctx := context.Background()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	server := grpc.NewServer()
+	secretmanagerpb.RegisterSecretManagerServiceServer(server, &fakeSecretManager{versions: map[string][][]byte{}})
+	go server.Serve(lis)
+	defer server.Stop()
+	opts := object.NewMap(map[string]object.Object{
+		"project":  object.NewString("test"),
+		"endpoint": object.NewString(lis.Addr().String()),
+		"insecure": object.True,
+	})
+	client := NewSecretsBuiltin(ctx, opts)
+	s, ok := client.(*Secrets)
+	require.True(t, ok, "unexpected result: %s", client.Inspect())
+	require.Equal(t, object.NewString("projects/test/secrets/db-password/versions/1"),
+		call(t, s, "add_version", object.NewString("db-password"), object.NewString("hunter2")))
+	require.Equal(t, object.NewByteSlice([]byte("hunter2")), call(t, s, "access", object.NewString("db-password")))
+	require.Equal(t, object.NewString("hunter2"), Secret(ctx, object.NewString("projects/test/secrets/db-password"), opts))
+
+	result := call(t, s, "access", object.NewString("api-key"))
+	require.Contains(t, result.(*object.Error).Message().Value(), "gcp error:")
+	require.Contains(t, result.(*object.Error).Message().Value(), "NotFound")
+}
+
+func TestOptionErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		result object.Object
+		err    string
+	}{
+		{NewStorageBuiltin(ctx, object.NewMap(map[string]object.Object{"bucket": object.NewString("b")})),
+			`value error: unknown gcp.storage option "bucket"`},
+		{NewStorageBuiltin(ctx, object.NewMap(map[string]object.Object{"chunk_size": object.NewInt(-1)})),
+			"value error: chunk_size must not be negative (got -1)"},
+		{NewPubSubBuiltin(ctx, object.NewMap(map[string]object.Object{"topic": object.NewString("t")})),
+			`value error: unknown gcp.pubsub option "topic"`},
+	}
+	for _, tt := range tests {
+		errObj, ok := tt.result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", tt.result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+}
@@ -0,0 +1,370 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const (
+	PUBSUB  object.Type = "gcp.pubsub_client"
+	MESSAGE object.Type = "gcp.pubsub_message"
+)
+
+// PubSub wraps a Pub/Sub client. Topics are kept so their publishing
+// goroutines are reused, and are stopped when the client is closed.
+type PubSub struct {
+	client *pubsub.Client
+	mu     sync.Mutex
+	topics map[string]*pubsub.Topic
+}
+
+// NewPubSub returns a PubSub for the given client.
+func NewPubSub(client *pubsub.Client) *PubSub {
+	return &PubSub{client: client, topics: map[string]*pubsub.Topic{}}
+}
+
+// NewPubSubBuiltin creates a Pub/Sub client.
+func NewPubSubBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.pubsub", 0, 1, args); err != nil {
+		return err
+	}
+	opts, errObj := parseClientOptions("gcp.pubsub", args)
+	if errObj != nil {
+		return errObj
+	}
+	project, err := opts.detectProject(ctx)
+	if err != nil {
+		return object.NewError(err)
+	}
+	client, err := pubsub.NewClient(ctx, project, opts.clientOptions(true)...)
+	if err != nil {
+		return gcpError(err)
+	}
+	return NewPubSub(client)
+}
+
+// Value returns the underlying client.
+func (p *PubSub) Value() *pubsub.Client {
+	return p.client
+}
+
+func (p *PubSub) Type() object.Type {
+	return PUBSUB
+}
+
+func (p *PubSub) Inspect() string {
+	return fmt.Sprintf("gcp.pubsub_client(project=%s)", p.client.Project())
+}
+
+func (p *PubSub) String() string {
+	return p.Inspect()
+}
+
+func (p *PubSub) Interface() interface{} {
+	return p.client
+}
+
+func (p *PubSub) Equals(other object.Object) object.Object {
+	return object.NewBool(p == other)
+}
+
+func (p *PubSub) IsTruthy() bool {
+	return true
+}
+
+func (p *PubSub) Cost() int {
+	return 0
+}
+
+func (p *PubSub) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", PUBSUB)
+}
+
+func (p *PubSub) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", PUBSUB, opType)
+}
+
+func (p *PubSub) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", PUBSUB, name)
+}
+
+func (p *PubSub) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "project":
+		return object.NewString(p.client.Project()), true
+	case "publish":
+		return object.NewBuiltin("gcp.pubsub_client.publish", p.publish), true
+	case "subscribe":
+		return object.NewBuiltin("gcp.pubsub_client.subscribe", p.subscribe), true
+	case "close":
+		return object.NewBuiltin("gcp.pubsub_client.close", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("gcp.pubsub_client.close", 0, args); err != nil {
+				return err
+			}
+			if err := p.Close(); err != nil {
+				return gcpError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// Close flushes and stops the topics that were published to, then closes
+// the client.
+func (p *PubSub) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, topic := range p.topics {
+		topic.Stop()
+	}
+	p.topics = map[string]*pubsub.Topic{}
+	return p.client.Close()
+}
+
+func (p *PubSub) topic(name string, ordered bool) *pubsub.Topic {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	topic, ok := p.topics[name]
+	if !ok {
+		topic = p.client.Topic(name)
+		p.topics[name] = topic
+	}
+	if ordered {
+		topic.EnableMessageOrdering = true
+	}
+	return topic
+}
+
+// publish sends a message and waits for the server to acknowledge it.
+func (p *PubSub) publish(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.pubsub_client.publish", 2, 3, args); err != nil {
+		return err
+	}
+	name, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	data, errObj := object.AsBytes(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	msg := &pubsub.Message{Data: data}
+	if len(args) > 2 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "attributes":
+				msg.Attributes, err = asStringMap(value)
+			case "ordering_key":
+				msg.OrderingKey, err = object.AsString(value)
+			default:
+				err = object.Errorf("value error: unknown gcp.pubsub_client.publish option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	topic := p.topic(name, msg.OrderingKey != "")
+	id, err := topic.Publish(ctx, msg).Get(ctx)
+	if err != nil {
+		if msg.OrderingKey != "" {
+			// Publishing for the key is paused after a failure until resumed
+			topic.ResumePublish(msg.OrderingKey)
+		}
+		return gcpError(err)
+	}
+	return object.NewString(id)
+}
+
+// subscribe returns a stream of messages received from a subscription.
+// Messages are pulled in the background while the stream is consumed, and
+// those not yet handed to the script when receiving stops are nacked so
+// they are redelivered.
+func (p *PubSub) subscribe(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.pubsub_client.subscribe", 1, 2, args); err != nil {
+		return err
+	}
+	name, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	sub := p.client.Subscription(name)
+	var max int64
+	var timeout time.Duration
+	var autoAck bool
+	if len(args) > 1 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "max":
+				if max, err = object.AsInt(value); err == nil && max < 0 {
+					err = object.Errorf("value error: max must not be negative (got %d)", max)
+				}
+			case "timeout":
+				timeout, err = arg.Duration(key, value)
+			case "ack":
+				autoAck, err = object.AsBool(value)
+			case "max_outstanding":
+				var n int64
+				if n, err = object.AsInt(value); err == nil && n < 1 {
+					err = object.Errorf("value error: max_outstanding must be at least 1 (got %d)", n)
+				}
+				sub.ReceiveSettings.MaxOutstandingMessages = int(n)
+			default:
+				err = object.Errorf("value error: unknown gcp.pubsub_client.subscribe option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var receiveCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		receiveCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		receiveCtx, cancel = context.WithCancel(ctx)
+	}
+	messages := make(chan *pubsub.Message)
+	var receiveErr error
+	go func() {
+		// Receive waits for all callbacks to return, so nothing is sent on
+		// the channel after it is closed
+		receiveErr = sub.Receive(receiveCtx, func(ctx context.Context, msg *pubsub.Message) {
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				msg.Nack()
+			}
+		})
+		close(messages)
+	}()
+	var count int64
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		if max > 0 && count >= max {
+			cancel()
+			return nil, false, nil
+		}
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				cancel()
+				if receiveErr != nil {
+					return nil, false, fmt.Errorf("gcp error: %w", receiveErr)
+				}
+				return nil, false, nil
+			}
+			count++
+			if autoAck {
+				msg.Ack()
+			}
+			return NewMessage(msg), true, nil
+		case <-ctx.Done():
+			cancel()
+			return nil, false, ctx.Err()
+		}
+	})
+}
+
+// Message is a message received from a subscription.
+type Message struct {
+	msg *pubsub.Message
+}
+
+// NewMessage returns a Message wrapping the given message.
+func NewMessage(msg *pubsub.Message) *Message {
+	return &Message{msg: msg}
+}
+
+func (m *Message) Type() object.Type {
+	return MESSAGE
+}
+
+func (m *Message) Inspect() string {
+	return fmt.Sprintf("gcp.pubsub_message(id=%s)", m.msg.ID)
+}
+
+func (m *Message) String() string {
+	return m.Inspect()
+}
+
+func (m *Message) Interface() interface{} {
+	return m.msg
+}
+
+func (m *Message) Equals(other object.Object) object.Object {
+	o, ok := other.(*Message)
+	return object.NewBool(ok && o.msg == m.msg)
+}
+
+func (m *Message) IsTruthy() bool {
+	return true
+}
+
+func (m *Message) Cost() int {
+	return 0
+}
+
+func (m *Message) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", MESSAGE)
+}
+
+func (m *Message) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", MESSAGE, opType)
+}
+
+func (m *Message) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", MESSAGE, name)
+}
+
+func (m *Message) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "id":
+		return object.NewString(m.msg.ID), true
+	case "data":
+		return object.NewByteSlice(m.msg.Data), true
+	case "attributes":
+		return stringMap(m.msg.Attributes), true
+	case "ordering_key":
+		return object.NewString(m.msg.OrderingKey), true
+	case "publish_time":
+		return timeValue(m.msg.PublishTime), true
+	case "delivery_attempt":
+		if m.msg.DeliveryAttempt == nil {
+			return object.Nil, true
+		}
+		return object.NewInt(int64(*m.msg.DeliveryAttempt)), true
+	case "ack":
+		return object.NewBuiltin("gcp.pubsub_message.ack", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("gcp.pubsub_message.ack", 0, args); err != nil {
+				return err
+			}
+			m.msg.Ack()
+			return object.Nil
+		}), true
+	case "nack":
+		return object.NewBuiltin("gcp.pubsub_message.nack", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("gcp.pubsub_message.nack", 0, args); err != nil {
+				return err
+			}
+			m.msg.Nack()
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
@@ -0,0 +1,288 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"google.golang.org/api/iterator"
+)
+
+const SECRETS object.Type = "gcp.secrets_client"
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// Secrets wraps a Secret Manager client. Secrets may be named in full, as
+// "projects/p/secrets/s", or by their id alone within the client's project.
+type Secrets struct {
+	client  *secretmanager.Client
+	project string
+}
+
+// NewSecrets returns a Secrets for the given client and default project.
+func NewSecrets(client *secretmanager.Client, project string) *Secrets {
+	return &Secrets{client: client, project: project}
+}
+
+func newSecrets(ctx context.Context, fn string, args []object.Object) (*Secrets, *object.Error) {
+	opts, errObj := parseClientOptions(fn, args)
+	if errObj != nil {
+		return nil, errObj
+	}
+	// The project is only needed for secrets named by their id
+	project, _ := opts.detectProject(ctx)
+	client, err := secretmanager.NewClient(ctx, opts.clientOptions(true)...)
+	if err != nil {
+		return nil, gcpError(err)
+	}
+	return NewSecrets(client, project), nil
+}
+
+// NewSecretsBuiltin creates a Secret Manager client.
+func NewSecretsBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.secrets", 0, 1, args); err != nil {
+		return err
+	}
+	s, err := newSecrets(ctx, "gcp.secrets", args)
+	if err != nil {
+		return err
+	}
+	return s
+}
+
+// Secret returns the value of a secret version as a string, using a
+// short-lived client.
+func Secret(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.secret", 1, 2, args); err != nil {
+		return err
+	}
+	name, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	s, errObj := newSecrets(ctx, "gcp.secret", args[1:])
+	if errObj != nil {
+		return errObj
+	}
+	defer s.client.Close()
+	data, err := s.Access(ctx, name)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewString(string(data))
+}
+
+// secretName returns the full name of a secret.
+func (s *Secrets) secretName(name string) (string, error) {
+	if strings.HasPrefix(name, "projects/") {
+		return name, nil
+	}
+	if s.project == "" {
+		return "", fmt.Errorf("gcp error: no project was given for secret %q", name)
+	}
+	return fmt.Sprintf("projects/%s/secrets/%s", s.project, name), nil
+}
+
+// versionName returns the full name of a secret version. Names without a
+// version refer to the latest one.
+func (s *Secrets) versionName(name string) (string, error) {
+	name, err := s.secretName(name)
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+	return name, nil
+}
+
+// Access returns the payload of a secret version, after checking it against
+// its checksum.
+func (s *Secrets) Access(ctx context.Context, name string) ([]byte, error) {
+	name, err := s.versionName(name)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.AccessSecretVersion(ctx, &secretmanagerpb.AccessSecretVersionRequest{Name: name})
+	if err != nil {
+		return nil, fmt.Errorf("gcp error: %w", err)
+	}
+	data := resp.GetPayload().GetData()
+	if sum := resp.GetPayload().DataCrc32C; sum != nil && int64(crc32.Checksum(data, crc32c)) != *sum {
+		return nil, fmt.Errorf("gcp error: checksum mismatch for %s", resp.GetName())
+	}
+	return data, nil
+}
+
+// Value returns the underlying client.
+func (s *Secrets) Value() *secretmanager.Client {
+	return s.client
+}
+
+func (s *Secrets) Type() object.Type {
+	return SECRETS
+}
+
+func (s *Secrets) Inspect() string {
+	return fmt.Sprintf("gcp.secrets_client(project=%s)", s.project)
+}
+
+func (s *Secrets) String() string {
+	return s.Inspect()
+}
+
+func (s *Secrets) Interface() interface{} {
+	return s.client
+}
+
+func (s *Secrets) Equals(other object.Object) object.Object {
+	return object.NewBool(s == other)
+}
+
+func (s *Secrets) IsTruthy() bool {
+	return true
+}
+
+func (s *Secrets) Cost() int {
+	return 0
+}
+
+func (s *Secrets) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", SECRETS)
+}
+
+func (s *Secrets) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", SECRETS, opType)
+}
+
+func (s *Secrets) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", SECRETS, name)
+}
+
+func (s *Secrets) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "project":
+		return object.NewString(s.project), true
+	case "access":
+		return object.NewBuiltin("gcp.secrets_client.access", s.access), true
+	case "add_version":
+		return object.NewBuiltin("gcp.secrets_client.add_version", s.addVersion), true
+	case "list":
+		return object.NewBuiltin("gcp.secrets_client.list", s.list), true
+	case "close":
+		return object.NewBuiltin("gcp.secrets_client.close", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("gcp.secrets_client.close", 0, args); err != nil {
+				return err
+			}
+			if err := s.client.Close(); err != nil {
+				return gcpError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+func (s *Secrets) access(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.secrets_client.access", 1, 2, args); err != nil {
+		return err
+	}
+	name, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	if len(args) > 1 {
+		version, err := object.AsString(args[1])
+		if err != nil {
+			return err
+		}
+		name += "/versions/" + version
+	}
+	data, err := s.Access(ctx, name)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewByteSlice(data)
+}
+
+func (s *Secrets) addVersion(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("gcp.secrets_client.add_version", 2, args); err != nil {
+		return err
+	}
+	name, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	data, errObj := object.AsBytes(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	parent, err := s.secretName(name)
+	if err != nil {
+		return object.NewError(err)
+	}
+	sum := int64(crc32.Checksum(data, crc32c))
+	version, err := s.client.AddSecretVersion(ctx, &secretmanagerpb.AddSecretVersionRequest{
+		Parent:  parent,
+		Payload: &secretmanagerpb.SecretPayload{Data: data, DataCrc32C: &sum},
+	})
+	if err != nil {
+		return gcpError(err)
+	}
+	return object.NewString(version.GetName())
+}
+
+// list returns a stream of the secrets in the project, as maps.
+func (s *Secrets) list(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.secrets_client.list", 0, 1, args); err != nil {
+		return err
+	}
+	if s.project == "" {
+		return object.Errorf("gcp error: no project was given")
+	}
+	req := &secretmanagerpb.ListSecretsRequest{Parent: "projects/" + s.project}
+	if len(args) > 0 {
+		m, err := object.AsMap(args[0])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "filter":
+				req.Filter, err = object.AsString(value)
+			default:
+				err = object.Errorf("value error: unknown gcp.secrets_client.list option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var it *secretmanager.SecretIterator
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		if it == nil {
+			it = s.client.ListSecrets(ctx, req)
+		}
+		secret, err := it.Next()
+		if err == iterator.Done {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("gcp error: %w", err)
+		}
+		result := map[string]object.Object{
+			"name":   object.NewString(secret.GetName()),
+			"labels": stringMap(secret.GetLabels()),
+		}
+		if t := secret.GetCreateTime(); t != nil {
+			result["created"] = object.NewTime(t.AsTime())
+		}
+		return object.NewMap(result), true, nil
+	})
+}
@@ -0,0 +1,619 @@
+package gcp
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/limits"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+	"google.golang.org/api/iterator"
+)
+
+const STORAGE object.Type = "gcp.storage_client"
+
+// Storage wraps a Cloud Storage client.
+type Storage struct {
+	client    *storage.Client
+	chunkSize int
+}
+
+// NewStorage returns a Storage for the given client. A chunk size of 0
+// selects the client library's default.
+func NewStorage(client *storage.Client, chunkSize int) *Storage {
+	return &Storage{client: client, chunkSize: chunkSize}
+}
+
+// NewStorageBuiltin creates a Cloud Storage client.
+func NewStorageBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.storage", 0, 1, args); err != nil {
+		return err
+	}
+	opts, errObj := parseClientOptions("gcp.storage", args, "chunk_size")
+	if errObj != nil {
+		return errObj
+	}
+	var chunkSize int64
+	if value, ok := opts.extra["chunk_size"]; ok {
+		if chunkSize, errObj = object.AsInt(value); errObj != nil {
+			return errObj
+		}
+		if chunkSize < 0 {
+			return object.Errorf("value error: chunk_size must not be negative (got %d)", chunkSize)
+		}
+	}
+	client, err := storage.NewClient(ctx, opts.clientOptions(false)...)
+	if err != nil {
+		return gcpError(err)
+	}
+	return NewStorage(client, int(chunkSize))
+}
+
+// Value returns the underlying client.
+func (s *Storage) Value() *storage.Client {
+	return s.client
+}
+
+func (s *Storage) Type() object.Type {
+	return STORAGE
+}
+
+func (s *Storage) Inspect() string {
+	return "gcp.storage_client()"
+}
+
+func (s *Storage) String() string {
+	return s.Inspect()
+}
+
+func (s *Storage) Interface() interface{} {
+	return s.client
+}
+
+func (s *Storage) Equals(other object.Object) object.Object {
+	return object.NewBool(s == other)
+}
+
+func (s *Storage) IsTruthy() bool {
+	return true
+}
+
+func (s *Storage) Cost() int {
+	return 0
+}
+
+func (s *Storage) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", STORAGE)
+}
+
+func (s *Storage) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", STORAGE, opType)
+}
+
+func (s *Storage) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", STORAGE, name)
+}
+
+func (s *Storage) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "list":
+		return object.NewBuiltin("gcp.storage_client.list", s.list), true
+	case "get":
+		return object.NewBuiltin("gcp.storage_client.get", s.get), true
+	case "reader":
+		return object.NewBuiltin("gcp.storage_client.reader", s.reader), true
+	case "download":
+		return object.NewBuiltin("gcp.storage_client.download", s.download), true
+	case "put":
+		return object.NewBuiltin("gcp.storage_client.put", s.put), true
+	case "upload":
+		return object.NewBuiltin("gcp.storage_client.upload", s.upload), true
+	case "attrs":
+		return object.NewBuiltin("gcp.storage_client.attrs", s.attrs), true
+	case "exists":
+		return object.NewBuiltin("gcp.storage_client.exists", s.exists), true
+	case "delete":
+		return object.NewBuiltin("gcp.storage_client.delete", s.delete), true
+	case "copy":
+		return object.NewBuiltin("gcp.storage_client.copy", s.copy), true
+	case "signed_url":
+		return object.NewBuiltin("gcp.storage_client.signed_url", s.signedURL), true
+	case "close":
+		return object.NewBuiltin("gcp.storage_client.close", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("gcp.storage_client.close", 0, args); err != nil {
+				return err
+			}
+			if err := s.client.Close(); err != nil {
+				return gcpError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// objectOptions holds the options for reading and writing objects.
+type objectOptions struct {
+	contentType     string
+	cacheControl    string
+	contentEncoding string
+	storageClass    string
+	kmsKeyName      string
+	metadata        map[string]string
+	encryptionKey   []byte
+	generation      int64
+	offset          int64
+	length          int64
+	chunkSize       *int
+}
+
+// uploadOptions are the options accepted when writing an object.
+var uploadOptions = []string{
+	"content_type", "cache_control", "content_encoding", "storage_class",
+	"metadata", "kms_key_name", "encryption_key", "chunk_size",
+}
+
+// parseObjectOptions parses the options of a method, allowing only those
+// named in allowed.
+func parseObjectOptions(fn string, args []object.Object, allowed ...string) (objectOptions, *object.Error) {
+	opts := objectOptions{length: -1}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		if !contains(allowed, key) {
+			return opts, object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		switch key {
+		case "content_type":
+			opts.contentType, err = object.AsString(value)
+		case "cache_control":
+			opts.cacheControl, err = object.AsString(value)
+		case "content_encoding":
+			opts.contentEncoding, err = object.AsString(value)
+		case "storage_class":
+			opts.storageClass, err = object.AsString(value)
+		case "kms_key_name":
+			opts.kmsKeyName, err = object.AsString(value)
+		case "metadata":
+			opts.metadata, err = asStringMap(value)
+		case "encryption_key":
+			if opts.encryptionKey, err = asKey(value); err == nil && len(opts.encryptionKey) != 32 {
+				err = object.Errorf("value error: encryption_key must be 32 bytes (got %d)", len(opts.encryptionKey))
+			}
+		case "generation":
+			opts.generation, err = object.AsInt(value)
+		case "offset":
+			if opts.offset, err = object.AsInt(value); err == nil && opts.offset < 0 {
+				err = object.Errorf("value error: offset must not be negative (got %d)", opts.offset)
+			}
+		case "length":
+			opts.length, err = object.AsInt(value)
+		case "chunk_size":
+			var chunkSize int64
+			if chunkSize, err = object.AsInt(value); err == nil && chunkSize < 0 {
+				err = object.Errorf("value error: chunk_size must not be negative (got %d)", chunkSize)
+			}
+			size := int(chunkSize)
+			opts.chunkSize = &size
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// handle returns the handle of an object with the given options applied.
+func (s *Storage) handle(bucket, name string, opts objectOptions) *storage.ObjectHandle {
+	obj := s.client.Bucket(bucket).Object(name)
+	if opts.generation != 0 {
+		obj = obj.Generation(opts.generation)
+	}
+	if opts.encryptionKey != nil {
+		obj = obj.Key(opts.encryptionKey)
+	}
+	return obj
+}
+
+// bucketAndName returns the first two arguments as strings.
+func bucketAndName(args []object.Object) (string, string, *object.Error) {
+	bucket, err := object.AsString(args[0])
+	if err != nil {
+		return "", "", err
+	}
+	name, err := object.AsString(args[1])
+	if err != nil {
+		return "", "", err
+	}
+	return bucket, name, nil
+}
+
+func attrsMap(attrs *storage.ObjectAttrs) *object.Map {
+	return object.NewMap(map[string]object.Object{
+		"bucket":           object.NewString(attrs.Bucket),
+		"name":             object.NewString(attrs.Name),
+		"size":             object.NewInt(attrs.Size),
+		"content_type":     object.NewString(attrs.ContentType),
+		"content_encoding": object.NewString(attrs.ContentEncoding),
+		"cache_control":    object.NewString(attrs.CacheControl),
+		"md5":              object.NewString(hex.EncodeToString(attrs.MD5)),
+		"crc32c":           object.NewInt(int64(attrs.CRC32C)),
+		"etag":             object.NewString(attrs.Etag),
+		"generation":       object.NewInt(attrs.Generation),
+		"storage_class":    object.NewString(attrs.StorageClass),
+		"kms_key_name":     object.NewString(attrs.KMSKeyName),
+		"created":          timeValue(attrs.Created),
+		"updated":          timeValue(attrs.Updated),
+		"metadata":         stringMap(attrs.Metadata),
+	})
+}
+
+// list returns a stream of the objects in a bucket. Pages are requested as
+// the stream is consumed.
+func (s *Storage) list(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.storage_client.list", 1, 2, args); err != nil {
+		return err
+	}
+	bucket, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	query := &storage.Query{}
+	var max int64
+	if len(args) > 1 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "prefix":
+				query.Prefix, err = object.AsString(value)
+			case "delimiter":
+				query.Delimiter, err = object.AsString(value)
+			case "start_offset":
+				query.StartOffset, err = object.AsString(value)
+			case "end_offset":
+				query.EndOffset, err = object.AsString(value)
+			case "versions":
+				query.Versions, err = object.AsBool(value)
+			case "max":
+				if max, err = object.AsInt(value); err == nil && max < 0 {
+					err = object.Errorf("value error: max must not be negative (got %d)", max)
+				}
+			default:
+				err = object.Errorf("value error: unknown gcp.storage_client.list option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var it *storage.ObjectIterator
+	var count int64
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		if max > 0 && count >= max {
+			return nil, false, nil
+		}
+		if it == nil {
+			it = s.client.Bucket(bucket).Objects(ctx, query)
+		}
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("gcp error: %w", err)
+		}
+		count++
+		if attrs.Prefix != "" {
+			return object.NewMap(map[string]object.Object{
+				"prefix": object.NewString(attrs.Prefix),
+			}), true, nil
+		}
+		return attrsMap(attrs), true, nil
+	})
+}
+
+// newReader starts reading an object. The caller must close the reader.
+func (s *Storage) newReader(ctx context.Context, fn string, args []object.Object) (*storage.Reader, *object.Error) {
+	if err := arg.RequireRange(fn, 2, 3, args); err != nil {
+		return nil, err
+	}
+	bucket, name, errObj := bucketAndName(args)
+	if errObj != nil {
+		return nil, errObj
+	}
+	opts, errObj := parseObjectOptions(fn, args[2:], "generation", "encryption_key", "offset", "length")
+	if errObj != nil {
+		return nil, errObj
+	}
+	r, err := s.handle(bucket, name, opts).NewRangeReader(ctx, opts.offset, opts.length)
+	if err != nil {
+		return nil, gcpError(err)
+	}
+	return r, nil
+}
+
+func (s *Storage) get(ctx context.Context, args ...object.Object) object.Object {
+	r, errObj := s.newReader(ctx, "gcp.storage_client.get", args)
+	if errObj != nil {
+		return errObj
+	}
+	defer r.Close()
+	var data []byte
+	var err error
+	if lim, ok := limits.GetLimits(ctx); ok {
+		data, err = lim.ReadAll(r)
+	} else {
+		data, err = io.ReadAll(r)
+	}
+	if err != nil {
+		return gcpError(err)
+	}
+	return object.NewByteSlice(data)
+}
+
+func (s *Storage) reader(ctx context.Context, args ...object.Object) object.Object {
+	r, errObj := s.newReader(ctx, "gcp.storage_client.reader", args)
+	if errObj != nil {
+		return errObj
+	}
+	return object.NewReader(r)
+}
+
+func (s *Storage) download(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.storage_client.download", 3, 4, args); err != nil {
+		return err
+	}
+	path, errObj := object.AsString(args[2])
+	if errObj != nil {
+		return errObj
+	}
+	r, errObj := s.newReader(ctx, "gcp.storage_client.download", append(args[:2:2], args[3:]...))
+	if errObj != nil {
+		return errObj
+	}
+	defer r.Close()
+	f, err := ros.GetDefaultOS(ctx).Create(path)
+	if err != nil {
+		return object.NewError(err)
+	}
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return gcpError(err)
+	}
+	return object.NewInt(n)
+}
+
+// write streams the body to an object. Bodies larger than the chunk size
+// are sent in a resumable upload, one chunk at a time.
+func (s *Storage) write(ctx context.Context, bucket, name string, body io.Reader, opts objectOptions) object.Object {
+	w := s.handle(bucket, name, opts).NewWriter(ctx)
+	w.ContentType = opts.contentType
+	w.CacheControl = opts.cacheControl
+	w.ContentEncoding = opts.contentEncoding
+	w.StorageClass = opts.storageClass
+	w.KMSKeyName = opts.kmsKeyName
+	w.Metadata = opts.metadata
+	if opts.chunkSize != nil {
+		w.ChunkSize = *opts.chunkSize
+	} else if s.chunkSize > 0 {
+		w.ChunkSize = s.chunkSize
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		w.CloseWithError(err)
+		return gcpError(err)
+	}
+	if err := w.Close(); err != nil {
+		return gcpError(err)
+	}
+	return attrsMap(w.Attrs())
+}
+
+func (s *Storage) put(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.storage_client.put", 3, 4, args); err != nil {
+		return err
+	}
+	bucket, name, errObj := bucketAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	var body io.Reader
+	switch data := args[2].(type) {
+	case *object.String:
+		body = strings.NewReader(data.Value())
+	default:
+		if body, errObj = object.AsReader(data); errObj != nil {
+			return errObj
+		}
+	}
+	opts, errObj := parseObjectOptions("gcp.storage_client.put", args[3:], uploadOptions...)
+	if errObj != nil {
+		return errObj
+	}
+	return s.write(ctx, bucket, name, body, opts)
+}
+
+func (s *Storage) upload(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.storage_client.upload", 3, 4, args); err != nil {
+		return err
+	}
+	bucket, name, errObj := bucketAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	path, errObj := object.AsString(args[2])
+	if errObj != nil {
+		return errObj
+	}
+	opts, errObj := parseObjectOptions("gcp.storage_client.upload", args[3:], uploadOptions...)
+	if errObj != nil {
+		return errObj
+	}
+	f, err := ros.GetDefaultOS(ctx).Open(path)
+	if err != nil {
+		return object.NewError(err)
+	}
+	defer f.Close()
+	return s.write(ctx, bucket, name, f, opts)
+}
+
+func (s *Storage) attrs(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.storage_client.attrs", 2, 3, args); err != nil {
+		return err
+	}
+	bucket, name, errObj := bucketAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	opts, errObj := parseObjectOptions("gcp.storage_client.attrs", args[2:], "generation", "encryption_key")
+	if errObj != nil {
+		return errObj
+	}
+	attrs, err := s.handle(bucket, name, opts).Attrs(ctx)
+	if err != nil {
+		return gcpError(err)
+	}
+	return attrsMap(attrs)
+}
+
+func (s *Storage) exists(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("gcp.storage_client.exists", 2, args); err != nil {
+		return err
+	}
+	bucket, name, errObj := bucketAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	if _, err := s.client.Bucket(bucket).Object(name).Attrs(ctx); err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return object.False
+		}
+		return gcpError(err)
+	}
+	return object.True
+}
+
+// delete removes one object, or a list of objects.
+func (s *Storage) delete(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("gcp.storage_client.delete", 2, args); err != nil {
+		return err
+	}
+	bucket, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	var names []string
+	if name, ok := args[1].(*object.String); ok {
+		names = []string{name.Value()}
+	} else if names, errObj = object.AsStringSlice(args[1]); errObj != nil {
+		return errObj
+	}
+	for _, name := range names {
+		if err := s.client.Bucket(bucket).Object(name).Delete(ctx); err != nil {
+			return gcpError(fmt.Errorf("failed to delete %q: %w", name, err))
+		}
+	}
+	return object.Nil
+}
+
+func (s *Storage) copy(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.storage_client.copy", 4, 5, args); err != nil {
+		return err
+	}
+	srcBucket, srcName, errObj := bucketAndName(args[:2])
+	if errObj != nil {
+		return errObj
+	}
+	dstBucket, dstName, errObj := bucketAndName(args[2:4])
+	if errObj != nil {
+		return errObj
+	}
+	opts, errObj := parseObjectOptions("gcp.storage_client.copy", args[4:],
+		"content_type", "metadata", "storage_class", "kms_key_name")
+	if errObj != nil {
+		return errObj
+	}
+	copier := s.client.Bucket(dstBucket).Object(dstName).CopierFrom(s.client.Bucket(srcBucket).Object(srcName))
+	copier.ContentType = opts.contentType
+	copier.Metadata = opts.metadata
+	copier.StorageClass = opts.storageClass
+	copier.DestinationKMSKeyName = opts.kmsKeyName
+	attrs, err := copier.Run(ctx)
+	if err != nil {
+		return gcpError(err)
+	}
+	return attrsMap(attrs)
+}
+
+// signedURL returns a URL that grants temporary access to an object without
+// credentials. Signing requires service account credentials, or permission
+// to sign blobs as the service account through the IAM API.
+func (s *Storage) signedURL(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("gcp.storage_client.signed_url", 2, 3, args); err != nil {
+		return err
+	}
+	bucket, name, errObj := bucketAndName(args)
+	if errObj != nil {
+		return errObj
+	}
+	opts := &storage.SignedURLOptions{
+		Scheme: storage.SigningSchemeV4,
+		Method: http.MethodGet,
+	}
+	expires := 15 * time.Minute
+	if len(args) > 2 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "method":
+				if opts.Method, err = object.AsString(value); err == nil {
+					opts.Method = strings.ToUpper(opts.Method)
+				}
+			case "expires":
+				if expires, err = arg.Duration(key, value); err == nil && (expires <= 0 || expires > 7*24*time.Hour) {
+					err = object.Errorf("value error: expires must be between 0 and 7 days (got %s)", expires)
+				}
+			case "content_type":
+				opts.ContentType, err = object.AsString(value)
+			default:
+				err = object.Errorf("value error: unknown gcp.storage_client.signed_url option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	switch opts.Method {
+	case http.MethodGet, http.MethodPut, http.MethodHead, http.MethodDelete:
+	default:
+		return object.Errorf("value error: signed_url method must be GET, PUT, HEAD, or DELETE (got %q)", opts.Method)
+	}
+	opts.Expires = time.Now().Add(expires)
+	url, err := s.client.Bucket(bucket).SignedURL(name, opts)
+	if err != nil {
+		return gcpError(err)
+	}
+	return object.NewString(url)
+}
@@ -0,0 +1,188 @@
+package geo
+
+import (
+	"math"
+	"strings"
+)
+
+// EarthRadius is the mean radius of the Earth in kilometers.
+const EarthRadius = 6371.0088
+
+// Point is a position in degrees.
+type Point struct {
+	Lat, Lon float64
+}
+
+// Box is the area between two latitudes and two longitudes, in degrees. A
+// box that crosses the antimeridian has a MinLon greater than its MaxLon.
+type Box struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// Distance returns the great-circle distance between two points in
+// kilometers, using the haversine formula.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+// Bearing returns the initial bearing from a to b, in degrees clockwise
+// from north, between 0 and 360.
+func Bearing(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLon := radians(b.Lon - a.Lon)
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// normalizeLon wraps a longitude to the range [-180, 180].
+func normalizeLon(lon float64) float64 {
+	if lon >= -180 && lon <= 180 {
+		return lon
+	}
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+// BoxAround returns the smallest box that contains every point within the
+// given distance in kilometers of the center.
+func BoxAround(center Point, distance float64) Box {
+	// The angular distance along a great circle
+	d := distance / EarthRadius
+	lat := radians(center.Lat)
+	minLat, maxLat := lat-d, lat+d
+	if minLat <= -math.Pi/2 || maxLat >= math.Pi/2 {
+		// The circle contains a pole, so it spans every longitude
+		return Box{
+			MinLat: math.Max(degrees(minLat), -90),
+			MinLon: -180,
+			MaxLat: math.Min(degrees(maxLat), 90),
+			MaxLon: 180,
+		}
+	}
+	dLon := math.Asin(math.Min(math.Sin(d)/math.Cos(lat), 1))
+	minLon, maxLon := center.Lon-degrees(dLon), center.Lon+degrees(dLon)
+	if maxLon-minLon >= 360 {
+		minLon, maxLon = -180, 180
+	}
+	return Box{
+		MinLat: degrees(minLat),
+		MinLon: normalizeLon(minLon),
+		MaxLat: degrees(maxLat),
+		MaxLon: normalizeLon(maxLon),
+	}
+}
+
+// Bounds returns the smallest box containing the points, which must not be
+// empty. The box doesn't cross the antimeridian.
+func Bounds(points []Point) Box {
+	box := Box{MinLat: points[0].Lat, MinLon: points[0].Lon, MaxLat: points[0].Lat, MaxLon: points[0].Lon}
+	for _, p := range points[1:] {
+		box.MinLat = math.Min(box.MinLat, p.Lat)
+		box.MaxLat = math.Max(box.MaxLat, p.Lat)
+		box.MinLon = math.Min(box.MinLon, p.Lon)
+		box.MaxLon = math.Max(box.MaxLon, p.Lon)
+	}
+	return box
+}
+
+// Contains returns true if the point is inside the box or on its edge.
+func (b Box) Contains(p Point) bool {
+	if p.Lat < b.MinLat || p.Lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon > b.MaxLon {
+		return p.Lon >= b.MinLon || p.Lon <= b.MaxLon
+	}
+	return p.Lon >= b.MinLon && p.Lon <= b.MaxLon
+}
+
+// InPolygon returns true if the point is inside the polygon, whose vertices
+// are given in order. The polygon is closed implicitly, and is treated as
+// flat, which is accurate enough for areas that don't span large distances.
+func InPolygon(p Point, polygon []Point) bool {
+	// Count the edges crossed by a ray going east from the point
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) {
+			lon := a.Lon + (p.Lat-a.Lat)*(b.Lon-a.Lon)/(b.Lat-a.Lat)
+			if p.Lon < lon {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashEncode returns the geohash of the point with the given number of
+// characters.
+func GeohashEncode(p Point, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	var sb strings.Builder
+	even := true
+	bit, ch := 0, 0
+	for sb.Len() < precision {
+		// Bits alternate between halving the longitude and latitude ranges
+		r, v := &latRange, p.Lat
+		if even {
+			r, v = &lonRange, p.Lon
+		}
+		mid := (r[0] + r[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			r[0] = mid
+		} else {
+			r[1] = mid
+		}
+		even = !even
+		if bit++; bit == 5 {
+			sb.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// GeohashBox returns the box covered by a geohash, or false if the geohash
+// contains an invalid character.
+func GeohashBox(hash string) (Box, bool) {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+	even := true
+	for _, c := range strings.ToLower(hash) {
+		idx := strings.IndexRune(geohashAlphabet, c)
+		if idx < 0 {
+			return Box{}, false
+		}
+		for shift := 4; shift >= 0; shift-- {
+			r := &latRange
+			if even {
+				r = &lonRange
+			}
+			mid := (r[0] + r[1]) / 2
+			if idx>>shift&1 == 1 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+	}
+	return Box{MinLat: latRange[0], MinLon: lonRange[0], MaxLat: latRange[1], MaxLon: lonRange[1]}, true
+}
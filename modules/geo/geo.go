@@ -0,0 +1,312 @@
+package geo
+
+import (
+	"context"
+	"math"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// units gives the number of kilometers in each supported unit of distance.
+var units = map[string]float64{
+	"m":   0.001,
+	"km":  1,
+	"mi":  1.609344,
+	"nmi": 1.852,
+}
+
+const defaultGeohashPrecision = 9
+
+// asPoint converts a map with lat and lon keys, or a [lat, lon] list, to a
+// Point. Other keys of the map are ignored, so records with a position can
+// be passed as they are.
+func asPoint(fn string, obj object.Object) (Point, *object.Error) {
+	var lat, lon object.Object
+	switch obj := obj.(type) {
+	case *object.Map:
+		lat, lon = obj.Get("lat"), obj.Get("lon")
+		if lat == object.Nil || lon == object.Nil {
+			return Point{}, object.Errorf("value error: %s expected a point with lat and lon keys", fn)
+		}
+	case *object.List:
+		if len(obj.Value()) != 2 {
+			return Point{}, object.Errorf("value error: %s expected a point as a [lat, lon] list (got %d items)", fn, len(obj.Value()))
+		}
+		lat, lon = obj.Value()[0], obj.Value()[1]
+	default:
+		return Point{}, object.Errorf("type error: %s expected a point as a map or list (%s given)", fn, obj.Type())
+	}
+	var p Point
+	var err *object.Error
+	if p.Lat, err = object.AsFloat(lat); err != nil {
+		return Point{}, err
+	}
+	if p.Lon, err = object.AsFloat(lon); err != nil {
+		return Point{}, err
+	}
+	if !(p.Lat >= -90 && p.Lat <= 90) {
+		return Point{}, object.Errorf("value error: %s latitude must be between -90 and 90 (got %v)", fn, p.Lat)
+	}
+	if !(p.Lon >= -180 && p.Lon <= 180) {
+		return Point{}, object.Errorf("value error: %s longitude must be between -180 and 180 (got %v)", fn, p.Lon)
+	}
+	return p, nil
+}
+
+// asPoints converts a list of points.
+func asPoints(fn string, obj object.Object) ([]Point, *object.Error) {
+	list, err := object.AsList(obj)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]Point, len(list.Value()))
+	for i, item := range list.Value() {
+		if points[i], err = asPoint(fn, item); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+func pointObject(p Point) *object.Map {
+	return object.NewMap(map[string]object.Object{
+		"lat": object.NewFloat(p.Lat),
+		"lon": object.NewFloat(p.Lon),
+	})
+}
+
+// asBox converts a map with min_lat, min_lon, max_lat, and max_lon keys to
+// a Box.
+func asBox(fn string, obj object.Object) (Box, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return Box{}, err
+	}
+	var box Box
+	for _, field := range []struct {
+		key  string
+		dest *float64
+	}{
+		{"min_lat", &box.MinLat},
+		{"min_lon", &box.MinLon},
+		{"max_lat", &box.MaxLat},
+		{"max_lon", &box.MaxLon},
+	} {
+		value := m.Get(field.key)
+		if value == object.Nil {
+			return Box{}, object.Errorf("value error: %s expected a box with a %s key", fn, field.key)
+		}
+		if *field.dest, err = object.AsFloat(value); err != nil {
+			return Box{}, err
+		}
+	}
+	return box, nil
+}
+
+func boxObject(b Box) *object.Map {
+	return object.NewMap(map[string]object.Object{
+		"min_lat": object.NewFloat(b.MinLat),
+		"min_lon": object.NewFloat(b.MinLon),
+		"max_lat": object.NewFloat(b.MaxLat),
+		"max_lon": object.NewFloat(b.MaxLon),
+	})
+}
+
+// unit reads the options map of the functions measuring distances, if there
+// is one, and returns the number of kilometers in the chosen unit.
+func unit(fn string, args []object.Object) (float64, *object.Error) {
+	km := 1.0
+	if len(args) == 0 {
+		return km, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return km, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "unit":
+			var name string
+			if name, err = object.AsString(value); err != nil {
+				return km, err
+			}
+			var ok bool
+			if km, ok = units[name]; !ok {
+				return km, object.Errorf("value error: %s unit must be one of m, km, mi, or nmi (got %q)", fn, name)
+			}
+		default:
+			return km, object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+	}
+	return km, nil
+}
+
+func DistanceBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("geo.distance", 2, 3, args); err != nil {
+		return err
+	}
+	km, err := unit("geo.distance", args[2:])
+	if err != nil {
+		return err
+	}
+	a, err := asPoint("geo.distance", args[0])
+	if err != nil {
+		return err
+	}
+	b, err := asPoint("geo.distance", args[1])
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Distance(a, b) / km)
+}
+
+func BearingBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("geo.bearing", 2, args); err != nil {
+		return err
+	}
+	a, err := asPoint("geo.bearing", args[0])
+	if err != nil {
+		return err
+	}
+	b, err := asPoint("geo.bearing", args[1])
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Bearing(a, b))
+}
+
+func BBox(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("geo.bbox", 1, args); err != nil {
+		return err
+	}
+	points, err := asPoints("geo.bbox", args[0])
+	if err != nil {
+		return err
+	}
+	if len(points) == 0 {
+		return object.Errorf("value error: geo.bbox requires at least one point")
+	}
+	return boxObject(Bounds(points))
+}
+
+func BBoxAround(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("geo.bbox_around", 2, 3, args); err != nil {
+		return err
+	}
+	km, err := unit("geo.bbox_around", args[2:])
+	if err != nil {
+		return err
+	}
+	center, err := asPoint("geo.bbox_around", args[0])
+	if err != nil {
+		return err
+	}
+	radius, err := object.AsFloat(args[1])
+	if err != nil {
+		return err
+	}
+	if radius < 0 || math.IsNaN(radius) {
+		return object.Errorf("value error: geo.bbox_around radius must not be negative (got %v)", radius)
+	}
+	return boxObject(BoxAround(center, radius*km))
+}
+
+func InBBox(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("geo.in_bbox", 2, args); err != nil {
+		return err
+	}
+	p, err := asPoint("geo.in_bbox", args[0])
+	if err != nil {
+		return err
+	}
+	box, err := asBox("geo.in_bbox", args[1])
+	if err != nil {
+		return err
+	}
+	return object.NewBool(box.Contains(p))
+}
+
+func InPolygonBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("geo.in_polygon", 2, args); err != nil {
+		return err
+	}
+	p, err := asPoint("geo.in_polygon", args[0])
+	if err != nil {
+		return err
+	}
+	polygon, err := asPoints("geo.in_polygon", args[1])
+	if err != nil {
+		return err
+	}
+	if len(polygon) < 3 {
+		return object.Errorf("value error: geo.in_polygon requires a polygon with at least 3 points (got %d)", len(polygon))
+	}
+	return object.NewBool(InPolygon(p, polygon))
+}
+
+func GeohashEncodeBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("geo.geohash_encode", 1, 2, args); err != nil {
+		return err
+	}
+	p, err := asPoint("geo.geohash_encode", args[0])
+	if err != nil {
+		return err
+	}
+	precision := int64(defaultGeohashPrecision)
+	if len(args) == 2 {
+		if precision, err = object.AsInt(args[1]); err != nil {
+			return err
+		}
+		if precision < 1 || precision > 12 {
+			return object.Errorf("value error: geo.geohash_encode precision must be between 1 and 12 (got %d)", precision)
+		}
+	}
+	return object.NewString(GeohashEncode(p, int(precision)))
+}
+
+// geohashBox reads the geohash given as the only argument.
+func geohashBox(fn string, args []object.Object) (Box, *object.Error) {
+	if err := arg.Require(fn, 1, args); err != nil {
+		return Box{}, err
+	}
+	hash, err := object.AsString(args[0])
+	if err != nil {
+		return Box{}, err
+	}
+	box, ok := GeohashBox(hash)
+	if !ok || hash == "" {
+		return Box{}, object.Errorf("value error: %s invalid geohash %q", fn, hash)
+	}
+	return box, nil
+}
+
+func GeohashDecode(ctx context.Context, args ...object.Object) object.Object {
+	box, err := geohashBox("geo.geohash_decode", args)
+	if err != nil {
+		return err
+	}
+	return pointObject(Point{Lat: (box.MinLat + box.MaxLat) / 2, Lon: (box.MinLon + box.MaxLon) / 2})
+}
+
+func GeohashBBox(ctx context.Context, args ...object.Object) object.Object {
+	box, err := geohashBox("geo.geohash_bbox", args)
+	if err != nil {
+		return err
+	}
+	return boxObject(box)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("geo", map[string]object.Object{
+		"bbox":           object.NewBuiltin("bbox", BBox),
+		"bbox_around":    object.NewBuiltin("bbox_around", BBoxAround),
+		"bearing":        object.NewBuiltin("bearing", BearingBuiltin),
+		"distance":       object.NewBuiltin("distance", DistanceBuiltin),
+		"geohash_bbox":   object.NewBuiltin("geohash_bbox", GeohashBBox),
+		"geohash_decode": object.NewBuiltin("geohash_decode", GeohashDecode),
+		"geohash_encode": object.NewBuiltin("geohash_encode", GeohashEncodeBuiltin),
+		"in_bbox":        object.NewBuiltin("in_bbox", InBBox),
+		"in_polygon":     object.NewBuiltin("in_polygon", InPolygonBuiltin),
+	})
+}
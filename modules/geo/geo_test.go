@@ -0,0 +1,174 @@
+package geo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func point(lat, lon float64) object.Object {
+	return object.NewList([]object.Object{object.NewFloat(lat), object.NewFloat(lon)})
+}
+
+func opts(m map[string]interface{}) object.Object {
+	return object.FromGoType(m)
+}
+
+func value(t *testing.T, obj object.Object) float64 {
+	t.Helper()
+	f, ok := obj.(*object.Float)
+	require.True(t, ok, obj.Inspect())
+	return f.Value()
+}
+
+var (
+	london = point(51.5074, -0.1278)
+	paris  = point(48.8566, 2.3522)
+)
+
+func TestDistance(t *testing.T) {
+	ctx := context.Background()
+	require.InDelta(t, 343.6, value(t, DistanceBuiltin(ctx, london, paris)), 0.1)
+	require.InDelta(t, 213.5, value(t, DistanceBuiltin(ctx, london, paris, opts(map[string]interface{}{"unit": "mi"}))), 0.1)
+	require.Equal(t, 0.0, value(t, DistanceBuiltin(ctx, paris, paris)))
+
+	// Points may also be maps, with other keys ignored
+	jfk := object.FromGoType(map[string]interface{}{"name": "JFK", "lat": 40.6413, "lon": -73.7781})
+	lax := object.FromGoType(map[string]interface{}{"name": "LAX", "lat": 33.9416, "lon": -118.4085})
+	require.InDelta(t, 3974, value(t, DistanceBuiltin(ctx, jfk, lax)), 1)
+	// Half way around the world along the equator
+	require.InDelta(t, 20015.1, value(t, DistanceBuiltin(ctx, point(0, 0), point(0, 180))), 0.1)
+}
+
+func TestBearing(t *testing.T) {
+	ctx := context.Background()
+	require.InDelta(t, 148.1, value(t, BearingBuiltin(ctx, london, paris)), 0.1)
+	require.InDelta(t, 0, value(t, BearingBuiltin(ctx, point(0, 0), point(10, 0))), 1e-9)
+	require.InDelta(t, 270, value(t, BearingBuiltin(ctx, point(0, 0), point(0, -10))), 1e-9)
+}
+
+func TestBoxes(t *testing.T) {
+	ctx := context.Background()
+	box := BBox(ctx, object.NewList([]object.Object{london, paris, point(50, 1)}))
+	require.Equal(t, object.FromGoType(map[string]interface{}{
+		"min_lat": 48.8566, "min_lon": -0.1278, "max_lat": 51.5074, "max_lon": 2.3522,
+	}), box)
+	require.Equal(t, object.True, InBBox(ctx, point(50, 1), box))
+	require.Equal(t, object.False, InBBox(ctx, point(52, 1), box))
+
+	around := BoxAround(Point{Lat: 0, Lon: 0}, 111.195)
+	require.InDelta(t, -1, around.MinLat, 1e-3)
+	require.InDelta(t, 1, around.MaxLon, 1e-3)
+
+	// Boxes crossing the antimeridian wrap around
+	around = BoxAround(Point{Lat: 0, Lon: 179.5}, 111.195)
+	require.InDelta(t, 178.5, around.MinLon, 1e-3)
+	require.InDelta(t, -179.5, around.MaxLon, 1e-3)
+	require.True(t, around.Contains(Point{Lat: 0, Lon: -179.9}))
+	require.False(t, around.Contains(Point{Lat: 0, Lon: 0}))
+
+	// Boxes around a pole span every longitude
+	around = BoxAround(Point{Lat: 89.5, Lon: 10}, 111.195)
+	require.Equal(t, Box{MinLat: around.MinLat, MinLon: -180, MaxLat: 90, MaxLon: 180}, around)
+
+	result := BBoxAround(ctx, paris, object.NewInt(10), opts(map[string]interface{}{"unit": "km"})).(*object.Map)
+	require.Equal(t, object.True, InBBox(ctx, point(48.9, 2.4), result))
+}
+
+func TestInPolygon(t *testing.T) {
+	ctx := context.Background()
+	// An L-shaped area
+	polygon := object.NewList([]object.Object{
+		point(0, 0), point(0, 10), point(5, 10), point(5, 5), point(10, 5), point(10, 0),
+	})
+	tests := []struct {
+		p      object.Object
+		inside bool
+	}{
+		{point(2, 2), true},
+		{point(8, 2), true},
+		{point(2, 8), true},
+		{point(8, 8), false},
+		{point(-1, 5), false},
+		{point(11, 2), false},
+	}
+	for _, tt := range tests {
+		require.Equal(t, object.NewBool(tt.inside), InPolygonBuiltin(ctx, tt.p, polygon), tt.p.Inspect())
+	}
+}
+
+func TestGeohash(t *testing.T) {
+	ctx := context.Background()
+	p := point(57.64911, 10.40744)
+	require.Equal(t, object.NewString("u4pruydqqvj"), GeohashEncodeBuiltin(ctx, p, object.NewInt(11)))
+	require.Equal(t, object.NewString("u4pruydqq"), GeohashEncodeBuiltin(ctx, p))
+	require.Equal(t, object.NewString("gcpvj"), GeohashEncodeBuiltin(ctx, london, object.NewInt(5)))
+
+	decoded := GeohashDecode(ctx, object.NewString("u4pruydqqvj")).(*object.Map)
+	require.InDelta(t, 57.64911, value(t, decoded.Get("lat")), 1e-5)
+	require.InDelta(t, 10.40744, value(t, decoded.Get("lon")), 1e-5)
+
+	box := GeohashBBox(ctx, object.NewString("U4")).(*object.Map)
+	require.Equal(t, object.FromGoType(map[string]interface{}{
+		"min_lat": 56.25, "min_lon": 0.0, "max_lat": 61.875, "max_lon": 11.25,
+	}), box)
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"latitude out of range",
+			DistanceBuiltin(ctx, point(91, 0), paris),
+			"value error: geo.distance latitude must be between -90 and 90 (got 91)",
+		},
+		{
+			"map without lon",
+			BearingBuiltin(ctx, object.FromGoType(map[string]interface{}{"lat": 1}), paris),
+			"value error: geo.bearing expected a point with lat and lon keys",
+		},
+		{
+			"list of three",
+			DistanceBuiltin(ctx, object.FromGoType([]interface{}{1, 2, 3}), paris),
+			"value error: geo.distance expected a point as a [lat, lon] list (got 3 items)",
+		},
+		{
+			"unknown unit",
+			DistanceBuiltin(ctx, london, paris, opts(map[string]interface{}{"unit": "ft"})),
+			`value error: geo.distance unit must be one of m, km, mi, or nmi (got "ft")`,
+		},
+		{
+			"incomplete box",
+			InBBox(ctx, paris, object.FromGoType(map[string]interface{}{"min_lat": 1, "max_lat": 2, "max_lon": 3})),
+			"value error: geo.in_bbox expected a box with a min_lon key",
+		},
+		{
+			"degenerate polygon",
+			InPolygonBuiltin(ctx, paris, object.NewList([]object.Object{london, paris})),
+			"value error: geo.in_polygon requires a polygon with at least 3 points (got 2)",
+		},
+		{
+			"invalid geohash",
+			GeohashDecode(ctx, object.NewString("u4pa")),
+			`value error: geo.geohash_decode invalid geohash "u4pa"`,
+		},
+		{
+			"geohash precision",
+			GeohashEncodeBuiltin(ctx, paris, object.NewInt(13)),
+			"value error: geo.geohash_encode precision must be between 1 and 12 (got 13)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
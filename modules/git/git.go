@@ -0,0 +1,286 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	gitobject "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"golang.org/x/crypto/ssh"
+)
+
+// authOptions hold the credentials used to talk to a remote.
+type authOptions struct {
+	username              string
+	password              string
+	token                 string
+	sshKey                []byte
+	sshKeyFile            string
+	passphrase            string
+	insecureIgnoreHostKey bool
+	insecureSkipTLS       bool
+}
+
+// parse sets the option with the given key, if it is an auth option, and
+// reports whether it was.
+func (a *authOptions) parse(key string, value object.Object) (bool, *object.Error) {
+	var err *object.Error
+	switch key {
+	case "username":
+		a.username, err = object.AsString(value)
+	case "password":
+		a.password, err = object.AsString(value)
+	case "token":
+		a.token, err = object.AsString(value)
+	case "ssh_key":
+		a.sshKey, err = object.AsBytes(value)
+	case "ssh_key_file":
+		a.sshKeyFile, err = object.AsString(value)
+	case "passphrase":
+		a.passphrase, err = object.AsString(value)
+	case "insecure_ignore_host_key":
+		a.insecureIgnoreHostKey, err = object.AsBool(value)
+	case "insecure_skip_tls":
+		a.insecureSkipTLS, err = object.AsBool(value)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// method returns the transport auth method for the options. It returns nil
+// when no credentials were given, in which case go-git falls back to the SSH
+// agent for SSH URLs.
+func (a *authOptions) method() (transport.AuthMethod, error) {
+	switch {
+	case len(a.sshKey) > 0 || a.sshKeyFile != "":
+		user := a.username
+		if user == "" {
+			user = "git"
+		}
+		var keys *gitssh.PublicKeys
+		var err error
+		if len(a.sshKey) > 0 {
+			keys, err = gitssh.NewPublicKeys(user, a.sshKey, a.passphrase)
+		} else {
+			keys, err = gitssh.NewPublicKeysFromFile(user, a.sshKeyFile, a.passphrase)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if a.insecureIgnoreHostKey {
+			keys.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		}
+		return keys, nil
+	case a.token != "":
+		user := a.username
+		if user == "" {
+			user = "x-access-token"
+		}
+		return &http.BasicAuth{Username: user, Password: a.token}, nil
+	case a.username != "" || a.password != "":
+		return &http.BasicAuth{Username: a.username, Password: a.password}, nil
+	case a.insecureIgnoreHostKey:
+		agent, err := gitssh.NewSSHAgentAuth("git")
+		if err != nil {
+			return nil, err
+		}
+		agent.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		return agent, nil
+	}
+	return nil, nil
+}
+
+func Clone(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.clone", 1, 2, args); err != nil {
+		return err
+	}
+	url, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	var (
+		dir  string
+		bare bool
+		auth authOptions
+	)
+	opts := &git.CloneOptions{URL: url}
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			if ok, err := auth.parse(key, value); ok {
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			switch key {
+			case "dir":
+				dir, err = object.AsString(value)
+			case "bare":
+				bare, err = object.AsBool(value)
+			case "branch":
+				var branch string
+				if branch, err = object.AsString(value); err == nil {
+					opts.ReferenceName = referenceName(branch)
+				}
+			case "depth":
+				var depth int64
+				if depth, err = object.AsInt(value); err == nil && depth < 0 {
+					err = object.Errorf("value error: depth must not be negative (got %d)", depth)
+				}
+				opts.Depth = int(depth)
+			case "single_branch":
+				opts.SingleBranch, err = object.AsBool(value)
+			case "no_checkout":
+				opts.NoCheckout, err = object.AsBool(value)
+			case "tags":
+				var tags bool
+				if tags, err = object.AsBool(value); err == nil {
+					opts.Tags = git.NoTags
+					if tags {
+						opts.Tags = git.AllTags
+					}
+				}
+			case "recurse_submodules":
+				var recurse bool
+				if recurse, err = object.AsBool(value); err == nil && recurse {
+					opts.RecurseSubmodules = git.DefaultSubmoduleRecursionDepth
+				}
+			case "remote":
+				opts.RemoteName, err = object.AsString(value)
+			default:
+				err = object.Errorf("value error: unknown git.clone option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	method, authErr := auth.method()
+	if authErr != nil {
+		return gitError(authErr)
+	}
+	opts.Auth = method
+	opts.InsecureSkipTLS = auth.insecureSkipTLS
+	var (
+		repo     *git.Repository
+		cloneErr error
+	)
+	if dir == "" {
+		// Without a directory the repository is kept in memory, which is
+		// enough to read its history and refs, or to tag and push.
+		if bare {
+			repo, cloneErr = git.CloneContext(ctx, memory.NewStorage(), nil, opts)
+		} else {
+			repo, cloneErr = git.CloneContext(ctx, memory.NewStorage(), memfs.New(), opts)
+		}
+	} else {
+		repo, cloneErr = git.PlainCloneContext(ctx, dir, bare, opts)
+	}
+	if cloneErr != nil {
+		return gitError(cloneErr)
+	}
+	return NewRepository(repo, dir)
+}
+
+func Open(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.open", 0, 1, args); err != nil {
+		return err
+	}
+	dir := "."
+	if len(args) == 1 {
+		var err *object.Error
+		if dir, err = object.AsString(args[0]); err != nil {
+			return err
+		}
+	}
+	repo, err := git.PlainOpenWithOptions(dir, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return gitError(err)
+	}
+	return NewRepository(repo, dir)
+}
+
+func Init(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.init", 1, 2, args); err != nil {
+		return err
+	}
+	dir, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts := &git.PlainInitOptions{}
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "bare":
+				opts.Bare, err = object.AsBool(value)
+			case "branch":
+				var branch string
+				if branch, err = object.AsString(value); err == nil {
+					opts.InitOptions.DefaultBranch = plumbing.NewBranchReferenceName(branch)
+				}
+			default:
+				err = object.Errorf("value error: unknown git.init option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return gitError(err)
+	}
+	repo, initErr := git.PlainInitWithOptions(dir, opts)
+	if initErr != nil {
+		return gitError(initErr)
+	}
+	return NewRepository(repo, dir)
+}
+
+// referenceName returns the full name of a branch or tag. Names that are
+// already full, such as "refs/tags/v1.0.0", are returned unchanged.
+func referenceName(name string) plumbing.ReferenceName {
+	ref := plumbing.ReferenceName(name)
+	if ref.IsBranch() || ref.IsTag() || ref.IsRemote() || ref == plumbing.HEAD {
+		return ref
+	}
+	return plumbing.NewBranchReferenceName(name)
+}
+
+func gitError(err error) *object.Error {
+	return object.NewError(fmt.Errorf("git error: %w", err))
+}
+
+func signatureValue(sig gitobject.Signature) object.Object {
+	return object.NewMap(map[string]object.Object{
+		"name":  object.NewString(sig.Name),
+		"email": object.NewString(sig.Email),
+		"time":  object.NewTime(sig.When),
+	})
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("git", map[string]object.Object{
+		"clone": object.NewBuiltin("clone", Clone),
+		"init":  object.NewBuiltin("init", Init),
+		"open":  object.NewBuiltin("open", Open),
+	})
+}
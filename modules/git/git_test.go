@@ -0,0 +1,339 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	gitobject "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// Serve file URLs in process, rather than with the git binary
+	client.InstallProtocol("file", server.DefaultServer)
+}
+
+var epoch = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// fixture creates a repository with three commits on main, and tags v1.0.0
+// on the second.
+func fixture(t *testing.T) (string, *git.Repository) {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInitWithOptions(dir, &git.PlainInitOptions{
+		InitOptions: git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName("main")},
+	})
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	commit := func(i int, msg string, files map[string]string) plumbing.Hash {
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+			_, err := wt.Add(name)
+			require.NoError(t, err)
+		}
+		sig := &gitobject.Signature{Name: "Ada", Email: "ada@example.com", When: epoch.Add(time.Duration(i) * time.Hour)}
+		hash, err := wt.Commit(msg, &git.CommitOptions{Author: sig, Committer: sig})
+		require.NoError(t, err)
+		return hash
+	}
+	commit(0, "Initial commit\n", map[string]string{"README.md": "# demo\n"})
+	second := commit(1, "Add app\n\nWith a body.\n", map[string]string{"app/main.go": "package main\n", "README.md": "# demo\n\nAn app.\n"})
+	_, err = repo.CreateTag("v1.0.0", second, nil)
+	require.NoError(t, err)
+	commit(2, "Fix docs\n", map[string]string{"docs/index.md": "docs\n"})
+	return dir, repo
+}
+
+func call(t *testing.T, obj object.Object, method string, args ...object.Object) object.Object {
+	t.Helper()
+	attr, ok := obj.GetAttr(method)
+	require.True(t, ok, method)
+	fn, ok := attr.(*object.Builtin)
+	require.True(t, ok)
+	return fn.Call(context.Background(), args...)
+}
+
+func opts(m map[string]interface{}) object.Object {
+	return object.FromGoType(m)
+}
+
+func str(t *testing.T, obj object.Object, key string) string {
+	t.Helper()
+	m, ok := obj.(*object.Map)
+	require.True(t, ok, obj.Inspect())
+	s, ok := m.Get(key).(*object.String)
+	require.True(t, ok, m.Inspect())
+	return s.Value()
+}
+
+func collect(t *testing.T, obj object.Object) []object.Object {
+	t.Helper()
+	stream, ok := obj.(*object.Stream)
+	require.True(t, ok, obj.Inspect())
+	var items []object.Object
+	iter := stream.Iter()
+	for {
+		item, ok := iter.Next(context.Background())
+		if !ok {
+			break
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func TestOpen(t *testing.T) {
+	dir, repo := fixture(t)
+	r := Open(context.Background(), object.NewString(filepath.Join(dir, "app")))
+	require.Equal(t, REPOSITORY, r.Type(), r.Inspect())
+
+	head := call(t, r, "head")
+	ref, err := repo.Head()
+	require.NoError(t, err)
+	require.Equal(t, "refs/heads/main", str(t, head, "name"))
+	require.Equal(t, "main", str(t, head, "short_name"))
+	require.Equal(t, ref.Hash().String(), str(t, head, "hash"))
+
+	c := call(t, r, "commit", object.NewString("HEAD~1"))
+	require.Equal(t, "Add app", str(t, c, "subject"))
+	require.Equal(t, "With a body.", str(t, c, "body"))
+	require.Equal(t, str(t, c, "hash")[:7], str(t, c, "short_hash"))
+	author := c.(*object.Map).Get("author")
+	require.Equal(t, "ada@example.com", str(t, author, "email"))
+	require.True(t, epoch.Add(time.Hour).Equal(author.(*object.Map).Get("time").(*object.Time).Value()))
+	require.Len(t, c.(*object.Map).Get("parents").(*object.List).Value(), 1)
+
+	require.Equal(t, object.NewString(str(t, c, "hash")), call(t, r, "resolve", object.NewString("v1.0.0")))
+
+	result := call(t, r, "resolve", object.NewString("nope"))
+	require.True(t, object.IsError(result))
+	require.Equal(t, "git error: reference not found: nope", result.(*object.Error).Value().Error())
+
+	result = Open(context.Background(), object.NewString(t.TempDir()))
+	require.True(t, object.IsError(result))
+	require.Equal(t, "git error: repository does not exist", result.(*object.Error).Value().Error())
+}
+
+func TestLog(t *testing.T) {
+	dir, _ := fixture(t)
+	r := Open(context.Background(), object.NewString(dir))
+
+	var subjects []string
+	for _, c := range collect(t, call(t, r, "log")) {
+		subjects = append(subjects, str(t, c, "subject"))
+	}
+	require.Equal(t, []string{"Fix docs", "Add app", "Initial commit"}, subjects)
+
+	commits := collect(t, call(t, r, "log", object.NewString("v1.0.0..HEAD")))
+	require.Len(t, commits, 1)
+	require.Equal(t, "Fix docs", str(t, commits[0], "subject"))
+
+	commits = collect(t, call(t, r, "log", object.NewString("HEAD"), opts(map[string]interface{}{"max": 2})))
+	require.Len(t, commits, 2)
+
+	commits = collect(t, call(t, r, "log", object.NewString("HEAD"), opts(map[string]interface{}{"path": "app/"})))
+	require.Len(t, commits, 1)
+	require.Equal(t, "Add app", str(t, commits[0], "subject"))
+
+	commits = collect(t, call(t, r, "log", object.NewString("HEAD"), opts(map[string]interface{}{
+		"since": "2024-01-01T12:30:00Z",
+	})))
+	require.Len(t, commits, 2)
+}
+
+func TestDiff(t *testing.T) {
+	dir, _ := fixture(t)
+	r := Open(context.Background(), object.NewString(dir))
+	result := call(t, r, "diff", object.NewString("HEAD~2"))
+	files, ok := result.(*object.List)
+	require.True(t, ok, result.Inspect())
+	require.Len(t, files.Value(), 3)
+
+	byPath := map[string]*object.Map{}
+	for _, f := range files.Value() {
+		byPath[str(t, f, "path")] = f.(*object.Map)
+	}
+	readme := byPath["README.md"]
+	require.Equal(t, "modified", str(t, readme, "change"))
+	require.Equal(t, object.NewInt(2), readme.Get("additions"))
+	require.Equal(t, object.NewInt(0), readme.Get("deletions"))
+	require.Contains(t, str(t, readme, "patch"), "--- a/README.md\n+++ b/README.md\n")
+	require.Contains(t, str(t, readme, "patch"), "+An app.\n")
+	require.Equal(t, "added", str(t, byPath["app/main.go"], "change"))
+	require.Equal(t, "", str(t, byPath["app/main.go"], "from_path"))
+
+	// Reversing the revisions reports the files as deleted
+	result = call(t, r, "diff", object.NewString("HEAD"), object.NewString("v1.0.0"))
+	files = result.(*object.List)
+	require.Len(t, files.Value(), 1)
+	require.Equal(t, "deleted", str(t, files.Value()[0], "change"))
+	require.Equal(t, "docs/index.md", str(t, files.Value()[0], "path"))
+}
+
+func TestBranchesAndTags(t *testing.T) {
+	dir, repo := fixture(t)
+	r := Open(context.Background(), object.NewString(dir))
+
+	result := call(t, r, "create_branch", object.NewString("release/1.0"), opts(map[string]interface{}{
+		"rev":      "v1.0.0",
+		"checkout": true,
+	}))
+	require.Equal(t, "refs/heads/release/1.0", str(t, result, "name"))
+	head, err := repo.Head()
+	require.NoError(t, err)
+	require.Equal(t, "release/1.0", head.Name().Short())
+	_, err = os.Stat(filepath.Join(dir, "docs", "index.md"))
+	require.True(t, os.IsNotExist(err))
+
+	result = call(t, r, "create_branch", object.NewString("release/1.0"))
+	require.True(t, object.IsError(result))
+	require.Equal(t, `git error: branch "release/1.0" already exists`, result.(*object.Error).Value().Error())
+
+	var names []string
+	for _, b := range call(t, r, "branches").(*object.List).Value() {
+		names = append(names, str(t, b, "short_name"))
+	}
+	require.Equal(t, []string{"main", "release/1.0"}, names)
+
+	result = call(t, r, "create_tag", object.NewString("v1.1.0"), opts(map[string]interface{}{
+		"rev":          "main",
+		"message":      "Release 1.1.0",
+		"tagger_name":  "Bob",
+		"tagger_email": "bob@example.com",
+	}))
+	tag, ok := result.(*object.Map)
+	require.True(t, ok, result.Inspect())
+	require.Equal(t, object.True, tag.Get("annotated"))
+	require.Equal(t, "Release 1.1.0\n", str(t, tag, "message"))
+	require.Equal(t, "Bob", str(t, tag.Get("tagger"), "name"))
+	mainRef, err := repo.Reference(plumbing.NewBranchReferenceName("main"), true)
+	require.NoError(t, err)
+	require.Equal(t, mainRef.Hash().String(), str(t, tag, "hash"))
+
+	tags := call(t, r, "tags").(*object.List).Value()
+	require.Len(t, tags, 2)
+	require.Equal(t, "v1.0.0", str(t, tags[0], "short_name"))
+	require.Equal(t, object.False, tags[0].(*object.Map).Get("annotated"))
+	require.Equal(t, "v1.1.0", str(t, tags[1], "short_name"))
+
+	result = call(t, r, "create_tag", object.NewString("v1.1.0"))
+	require.True(t, object.IsError(result))
+	require.Equal(t, "git error: tag already exists", result.(*object.Error).Value().Error())
+
+	result = call(t, r, "create_tag", object.NewString("v1.1.0"), opts(map[string]interface{}{"force": true}))
+	require.Equal(t, object.False, result.(*object.Map).Get("annotated"))
+}
+
+func TestCloneAndPush(t *testing.T) {
+	_, srcRepo := fixture(t)
+	originDir := t.TempDir()
+	origin, err := git.PlainInit(originDir, true)
+	require.NoError(t, err)
+	require.NoError(t, origin.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName("main"))))
+	_, err = srcRepo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{originDir}})
+	require.NoError(t, err)
+	require.NoError(t, srcRepo.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/heads/*:refs/heads/*", "refs/tags/*:refs/tags/*"}}))
+
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "clone")
+	r := Clone(ctx, object.NewString(originDir), opts(map[string]interface{}{"dir": dir}))
+	require.Equal(t, REPOSITORY, r.Type(), r.Inspect())
+	require.Equal(t, object.NewString(dir), mustAttr(t, r, "path"))
+	_, err = os.Stat(filepath.Join(dir, "app", "main.go"))
+	require.NoError(t, err)
+
+	remotes := call(t, r, "remotes").(*object.List).Value()
+	require.Len(t, remotes, 1)
+	require.Equal(t, "origin", str(t, remotes[0], "name"))
+
+	var names []string
+	for _, b := range call(t, r, "branches", opts(map[string]interface{}{"remote": true})).(*object.List).Value() {
+		names = append(names, str(t, b, "short_name"))
+	}
+	require.Equal(t, []string{"origin/main"}, names)
+
+	call(t, r, "create_tag", object.NewString("v2.0.0"))
+	require.Equal(t, object.True, call(t, r, "push", opts(map[string]interface{}{"tags": true})))
+	ref, err := origin.Reference(plumbing.NewTagReferenceName("v2.0.0"), true)
+	require.NoError(t, err)
+	head, err := srcRepo.Head()
+	require.NoError(t, err)
+	require.Equal(t, head.Hash(), ref.Hash())
+	require.Equal(t, object.False, call(t, r, "push", opts(map[string]interface{}{"tags": true})))
+	require.Equal(t, object.False, call(t, r, "fetch"))
+
+	// A clone kept in memory. The in-process server doesn't support shallow
+	// clones, so depth isn't covered here.
+	r = Clone(ctx, object.NewString("file://"+originDir), opts(map[string]interface{}{
+		"branch":        "main",
+		"single_branch": true,
+		"tags":          false,
+	}))
+	require.Equal(t, "git.repository(memory)", r.Inspect())
+	commits := collect(t, call(t, r, "log"))
+	require.Len(t, commits, 3)
+	require.Equal(t, "Fix docs", str(t, commits[0], "subject"))
+	require.Len(t, call(t, r, "tags").(*object.List).Value(), 0)
+}
+
+func mustAttr(t *testing.T, obj object.Object, name string) object.Object {
+	t.Helper()
+	attr, ok := obj.GetAttr(name)
+	require.True(t, ok, name)
+	return attr
+}
+
+func TestOptionErrors(t *testing.T) {
+	dir, _ := fixture(t)
+	ctx := context.Background()
+	r := Open(ctx, object.NewString(dir))
+	tests := []struct {
+		name   string
+		result func() object.Object
+		err    string
+	}{
+		{"clone", func() object.Object {
+			return Clone(ctx, object.NewString(dir), opts(map[string]interface{}{"shallow": true}))
+		}, `value error: unknown git.clone option "shallow"`},
+		{"clone depth", func() object.Object {
+			return Clone(ctx, object.NewString(dir), opts(map[string]interface{}{"depth": -1}))
+		}, "value error: depth must not be negative (got -1)"},
+		{"init", func() object.Object {
+			return Init(ctx, object.NewString(dir), opts(map[string]interface{}{"template": "x"}))
+		}, `value error: unknown git.init option "template"`},
+		{"log", func() object.Object {
+			return call(t, r, "log", object.NewString("HEAD"), opts(map[string]interface{}{"all": true}))
+		}, `value error: unknown git.repository.log option "all"`},
+		{"log since", func() object.Object {
+			return call(t, r, "log", object.NewString("HEAD"), opts(map[string]interface{}{"since": "yesterday"}))
+		}, `value error: invalid since "yesterday"`},
+		{"diff", func() object.Object {
+			return call(t, r, "diff", object.NewString("HEAD"), object.NewString("HEAD"), opts(map[string]interface{}{"stat": true}))
+		}, `value error: unknown git.repository.diff option "stat"`},
+		{"branch name", func() object.Object { return call(t, r, "create_branch", object.NewString("bad..name")) }, `value error: invalid branch name "bad..name"`},
+		{"create_tag", func() object.Object {
+			return call(t, r, "create_tag", object.NewString("x"), opts(map[string]interface{}{"sign": true}))
+		}, `value error: unknown git.repository.create_tag option "sign"`},
+		{"push", func() object.Object { return call(t, r, "push", opts(map[string]interface{}{"depth": 1})) }, `value error: unknown git.repository.push option "depth"`},
+		{"refspec", func() object.Object { return call(t, r, "push", opts(map[string]interface{}{"refspecs": "main"})) }, `value error: invalid refspec "main"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.result()
+			require.True(t, object.IsError(result), result.Inspect())
+			require.Equal(t, tt.err, result.(*object.Error).Value().Error())
+		})
+	}
+}
@@ -0,0 +1,897 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	gitobject "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const REPOSITORY object.Type = "git.repository"
+
+// Repository wraps a go-git repository, which may be kept on disk or in
+// memory.
+type Repository struct {
+	repo *git.Repository
+	path string
+}
+
+// NewRepository returns a Repository for the given go-git repository. The
+// path is empty for repositories kept in memory.
+func NewRepository(repo *git.Repository, path string) *Repository {
+	return &Repository{repo: repo, path: path}
+}
+
+// Value returns the underlying go-git repository.
+func (r *Repository) Value() *git.Repository {
+	return r.repo
+}
+
+func (r *Repository) Type() object.Type {
+	return REPOSITORY
+}
+
+func (r *Repository) Inspect() string {
+	if r.path == "" {
+		return "git.repository(memory)"
+	}
+	return fmt.Sprintf("git.repository(path=%s)", r.path)
+}
+
+func (r *Repository) String() string {
+	return r.Inspect()
+}
+
+func (r *Repository) Interface() interface{} {
+	return r.repo
+}
+
+func (r *Repository) Equals(other object.Object) object.Object {
+	return object.NewBool(r == other)
+}
+
+func (r *Repository) IsTruthy() bool {
+	return true
+}
+
+func (r *Repository) Cost() int {
+	return 0
+}
+
+func (r *Repository) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", REPOSITORY)
+}
+
+func (r *Repository) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", REPOSITORY, opType)
+}
+
+func (r *Repository) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", REPOSITORY, name)
+}
+
+func (r *Repository) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "path":
+		return object.NewString(r.path), true
+	case "head":
+		return object.NewBuiltin("git.repository.head", r.head), true
+	case "resolve":
+		return object.NewBuiltin("git.repository.resolve", r.resolve), true
+	case "commit":
+		return object.NewBuiltin("git.repository.commit", r.commit), true
+	case "log":
+		return object.NewBuiltin("git.repository.log", r.log), true
+	case "branches":
+		return object.NewBuiltin("git.repository.branches", r.branches), true
+	case "tags":
+		return object.NewBuiltin("git.repository.tags", r.tags), true
+	case "remotes":
+		return object.NewBuiltin("git.repository.remotes", r.remotes), true
+	case "diff":
+		return object.NewBuiltin("git.repository.diff", r.diff), true
+	case "create_branch":
+		return object.NewBuiltin("git.repository.create_branch", r.createBranch), true
+	case "create_tag":
+		return object.NewBuiltin("git.repository.create_tag", r.createTag), true
+	case "fetch":
+		return object.NewBuiltin("git.repository.fetch", r.fetch), true
+	case "push":
+		return object.NewBuiltin("git.repository.push", r.push), true
+	}
+	return nil, false
+}
+
+// resolveCommit returns the commit a revision, such as "HEAD~2", a branch,
+// a tag, or a hash, points to.
+func (r *Repository) resolveCommit(rev string) (*gitobject.Commit, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, rev)
+	}
+	return r.repo.CommitObject(*hash)
+}
+
+func refValue(ref *plumbing.Reference) object.Object {
+	return object.NewMap(map[string]object.Object{
+		"name":       object.NewString(ref.Name().String()),
+		"short_name": object.NewString(ref.Name().Short()),
+		"hash":       object.NewString(ref.Hash().String()),
+	})
+}
+
+func commitValue(c *gitobject.Commit) object.Object {
+	parents := make([]object.Object, 0, len(c.ParentHashes))
+	for _, p := range c.ParentHashes {
+		parents = append(parents, object.NewString(p.String()))
+	}
+	subject, body, _ := strings.Cut(c.Message, "\n")
+	hash := c.Hash.String()
+	return object.NewMap(map[string]object.Object{
+		"hash":       object.NewString(hash),
+		"short_hash": object.NewString(hash[:7]),
+		"message":    object.NewString(c.Message),
+		"subject":    object.NewString(strings.TrimSpace(subject)),
+		"body":       object.NewString(strings.TrimSpace(body)),
+		"author":     signatureValue(c.Author),
+		"committer":  signatureValue(c.Committer),
+		"parents":    object.NewList(parents),
+	})
+}
+
+func (r *Repository) head(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("git.repository.head", 0, args); err != nil {
+		return err
+	}
+	ref, err := r.repo.Head()
+	if err != nil {
+		return gitError(err)
+	}
+	return refValue(ref)
+}
+
+func (r *Repository) resolve(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("git.repository.resolve", 1, args); err != nil {
+		return err
+	}
+	rev, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	hash, resolveErr := r.repo.ResolveRevision(plumbing.Revision(rev))
+	if resolveErr != nil {
+		return gitError(fmt.Errorf("%w: %s", resolveErr, rev))
+	}
+	return object.NewString(hash.String())
+}
+
+func (r *Repository) commit(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.repository.commit", 0, 1, args); err != nil {
+		return err
+	}
+	rev := "HEAD"
+	if len(args) == 1 {
+		var err *object.Error
+		if rev, err = object.AsString(args[0]); err != nil {
+			return err
+		}
+	}
+	c, err := r.resolveCommit(rev)
+	if err != nil {
+		return gitError(err)
+	}
+	return commitValue(c)
+}
+
+func (r *Repository) log(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.repository.log", 0, 2, args); err != nil {
+		return err
+	}
+	rev := "HEAD"
+	if len(args) > 0 {
+		var err *object.Error
+		if rev, err = object.AsString(args[0]); err != nil {
+			return err
+		}
+	}
+	var max int64
+	opts := &git.LogOptions{}
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "max":
+				max, err = object.AsInt(value)
+			case "path":
+				var path string
+				if path, err = object.AsString(value); err == nil {
+					path = strings.TrimSuffix(path, "/")
+					opts.PathFilter = func(p string) bool {
+						return p == path || strings.HasPrefix(p, path+"/")
+					}
+				}
+			case "since":
+				var t time.Time
+				if t, err = arg.Time("since", value); err == nil {
+					opts.Since = &t
+				}
+			case "until":
+				var t time.Time
+				if t, err = arg.Time("until", value); err == nil {
+					opts.Until = &t
+				}
+			default:
+				err = object.Errorf("value error: unknown git.repository.log option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// A range such as "v1.0.0..HEAD" lists the commits reachable from the
+	// second revision but not from the first.
+	exclude := map[plumbing.Hash]bool{}
+	if from, to, ok := strings.Cut(rev, ".."); ok {
+		if to == "" {
+			to = "HEAD"
+		}
+		rev = to
+		start, err := r.resolveCommit(from)
+		if err != nil {
+			return gitError(err)
+		}
+		iter := gitobject.NewCommitPreorderIter(start, nil, nil)
+		err = iter.ForEach(func(c *gitobject.Commit) error {
+			exclude[c.Hash] = true
+			return nil
+		})
+		if err != nil {
+			return gitError(err)
+		}
+	}
+	start, err := r.resolveCommit(rev)
+	if err != nil {
+		return gitError(err)
+	}
+	opts.From = start.Hash
+	iter, err := r.repo.Log(opts)
+	if err != nil {
+		return gitError(err)
+	}
+	var count int64
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		for {
+			if max > 0 && count >= max {
+				iter.Close()
+				return nil, false, nil
+			}
+			c, err := iter.Next()
+			if err != nil {
+				iter.Close()
+				if errors.Is(err, io.EOF) {
+					return nil, false, nil
+				}
+				return nil, false, fmt.Errorf("git error: %w", err)
+			}
+			if exclude[c.Hash] {
+				continue
+			}
+			count++
+			return commitValue(c), true, nil
+		}
+	})
+}
+
+// sortedRefs collects the references from the iterator, sorted by name.
+func sortedRefs(iter storer.ReferenceIter) ([]*plumbing.Reference, error) {
+	var refs []*plumbing.Reference
+	err := iter.ForEach(func(ref *plumbing.Reference) error {
+		refs = append(refs, ref)
+		return nil
+	})
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Name() < refs[j].Name()
+	})
+	return refs, err
+}
+
+func (r *Repository) branches(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.repository.branches", 0, 1, args); err != nil {
+		return err
+	}
+	var remote bool
+	if len(args) == 1 {
+		m, err := object.AsMap(args[0])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "remote":
+				remote, err = object.AsBool(value)
+			default:
+				err = object.Errorf("value error: unknown git.repository.branches option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	var (
+		iter storer.ReferenceIter
+		err  error
+	)
+	if remote {
+		iter, err = r.repo.References()
+		if err == nil {
+			iter = storer.NewReferenceFilteredIter(func(ref *plumbing.Reference) bool {
+				return ref.Name().IsRemote() && !strings.HasSuffix(ref.Name().String(), "/HEAD")
+			}, iter)
+		}
+	} else {
+		iter, err = r.repo.Branches()
+	}
+	if err != nil {
+		return gitError(err)
+	}
+	refs, err := sortedRefs(iter)
+	if err != nil {
+		return gitError(err)
+	}
+	items := make([]object.Object, 0, len(refs))
+	for _, ref := range refs {
+		items = append(items, refValue(ref))
+	}
+	return object.NewList(items)
+}
+
+// tagValue describes a tag reference. For annotated tags, the hash is that
+// of the tagged commit and the tag's message and tagger are included.
+func (r *Repository) tagValue(ref *plumbing.Reference) object.Object {
+	m := map[string]object.Object{
+		"name":       object.NewString(ref.Name().String()),
+		"short_name": object.NewString(ref.Name().Short()),
+		"hash":       object.NewString(ref.Hash().String()),
+		"annotated":  object.False,
+		"message":    object.NewString(""),
+		"tagger":     object.Nil,
+	}
+	if tag, err := r.repo.TagObject(ref.Hash()); err == nil {
+		m["hash"] = object.NewString(tag.Target.String())
+		m["annotated"] = object.True
+		m["message"] = object.NewString(tag.Message)
+		m["tagger"] = signatureValue(tag.Tagger)
+	}
+	return object.NewMap(m)
+}
+
+func (r *Repository) tags(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("git.repository.tags", 0, args); err != nil {
+		return err
+	}
+	iter, err := r.repo.Tags()
+	if err != nil {
+		return gitError(err)
+	}
+	refs, err := sortedRefs(iter)
+	if err != nil {
+		return gitError(err)
+	}
+	items := make([]object.Object, 0, len(refs))
+	for _, ref := range refs {
+		items = append(items, r.tagValue(ref))
+	}
+	return object.NewList(items)
+}
+
+func (r *Repository) remotes(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("git.repository.remotes", 0, args); err != nil {
+		return err
+	}
+	remotes, err := r.repo.Remotes()
+	if err != nil {
+		return gitError(err)
+	}
+	sort.Slice(remotes, func(i, j int) bool {
+		return remotes[i].Config().Name < remotes[j].Config().Name
+	})
+	items := make([]object.Object, 0, len(remotes))
+	for _, remote := range remotes {
+		cfg := remote.Config()
+		items = append(items, object.NewMap(map[string]object.Object{
+			"name": object.NewString(cfg.Name),
+			"urls": object.NewStringList(cfg.URLs),
+		}))
+	}
+	return object.NewList(items)
+}
+
+// filePatch adapts a single file patch to the fdiff.Patch interface, so it
+// can be encoded on its own.
+type filePatch struct {
+	fdiff.FilePatch
+	chunks []fdiff.Chunk
+}
+
+func (p filePatch) Chunks() []fdiff.Chunk {
+	return p.chunks
+}
+
+func (p filePatch) FilePatches() []fdiff.FilePatch {
+	return []fdiff.FilePatch{p}
+}
+
+func (p filePatch) Message() string {
+	return ""
+}
+
+// chunk is a diff chunk computed by lineChunks.
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c chunk) Content() string {
+	return c.content
+}
+
+func (c chunk) Type() fdiff.Operation {
+	return c.op
+}
+
+// lineChunks diffs again, line by line, the contents of a file patch. The
+// chunks computed by go-git depend on the version of go-diff it's built
+// with, some of which report unchanged lines as deleted and added again.
+func lineChunks(chunks []fdiff.Chunk) []fdiff.Chunk {
+	var from, to strings.Builder
+	for _, c := range chunks {
+		switch c.Type() {
+		case fdiff.Equal:
+			from.WriteString(c.Content())
+			to.WriteString(c.Content())
+		case fdiff.Delete:
+			from.WriteString(c.Content())
+		case fdiff.Add:
+			to.WriteString(c.Content())
+		}
+	}
+	// Each distinct line is encoded as a rune, so lines are diffed as
+	// characters. Surrogates aren't valid runes and are skipped.
+	runes := map[string]rune{}
+	lines := map[rune]string{}
+	next := rune(1)
+	encode := func(s string) []rune {
+		var result []rune
+		for _, line := range strings.SplitAfter(s, "\n") {
+			if line == "" {
+				continue
+			}
+			r, ok := runes[line]
+			if !ok {
+				if next == 0xD800 {
+					next = 0xE000
+				}
+				r = next
+				next++
+				runes[line] = r
+				lines[r] = line
+			}
+			result = append(result, r)
+		}
+		return result
+	}
+	fromRunes, toRunes := encode(from.String()), encode(to.String())
+	diffs := diffmatchpatch.New().DiffMainRunes(fromRunes, toRunes, false)
+	result := make([]fdiff.Chunk, 0, len(diffs))
+	for _, d := range diffs {
+		var text strings.Builder
+		for _, r := range d.Text {
+			text.WriteString(lines[r])
+		}
+		op := fdiff.Equal
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = fdiff.Add
+		case diffmatchpatch.DiffDelete:
+			op = fdiff.Delete
+		}
+		result = append(result, chunk{content: text.String(), op: op})
+	}
+	return result
+}
+
+// countLines returns the number of lines in a diff chunk.
+func countLines(s string) int64 {
+	n := int64(strings.Count(s, "\n"))
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return n
+}
+
+func (r *Repository) diff(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.repository.diff", 1, 3, args); err != nil {
+		return err
+	}
+	fromRev, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	toRev := "HEAD"
+	if len(args) > 1 {
+		if toRev, err = object.AsString(args[1]); err != nil {
+			return err
+		}
+	}
+	contextLines := int64(fdiff.DefaultContextLines)
+	if len(args) == 3 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "context":
+				if contextLines, err = object.AsInt(value); err == nil && contextLines < 0 {
+					err = object.Errorf("value error: context must not be negative (got %d)", contextLines)
+				}
+			default:
+				err = object.Errorf("value error: unknown git.repository.diff option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	from, resolveErr := r.resolveCommit(fromRev)
+	if resolveErr != nil {
+		return gitError(resolveErr)
+	}
+	to, resolveErr := r.resolveCommit(toRev)
+	if resolveErr != nil {
+		return gitError(resolveErr)
+	}
+	patch, patchErr := from.PatchContext(ctx, to)
+	if patchErr != nil {
+		return gitError(patchErr)
+	}
+	items := make([]object.Object, 0, len(patch.FilePatches()))
+	for _, fp := range patch.FilePatches() {
+		fromFile, toFile := fp.Files()
+		var fromPath, toPath, change string
+		if fromFile != nil {
+			fromPath = fromFile.Path()
+		}
+		if toFile != nil {
+			toPath = toFile.Path()
+		}
+		switch {
+		case fromFile == nil:
+			change = "added"
+		case toFile == nil:
+			change = "deleted"
+		case fromPath != toPath:
+			change = "renamed"
+		default:
+			change = "modified"
+		}
+		chunks := fp.Chunks()
+		if !fp.IsBinary() {
+			chunks = lineChunks(chunks)
+		}
+		var additions, deletions int64
+		for _, chunk := range chunks {
+			switch chunk.Type() {
+			case fdiff.Add:
+				additions += countLines(chunk.Content())
+			case fdiff.Delete:
+				deletions += countLines(chunk.Content())
+			}
+		}
+		var buf bytes.Buffer
+		enc := fdiff.NewUnifiedEncoder(&buf, int(contextLines))
+		if err := enc.Encode(filePatch{FilePatch: fp, chunks: chunks}); err != nil {
+			return gitError(err)
+		}
+		path := toPath
+		if path == "" {
+			path = fromPath
+		}
+		items = append(items, object.NewMap(map[string]object.Object{
+			"path":      object.NewString(path),
+			"from_path": object.NewString(fromPath),
+			"to_path":   object.NewString(toPath),
+			"change":    object.NewString(change),
+			"binary":    object.NewBool(fp.IsBinary()),
+			"additions": object.NewInt(additions),
+			"deletions": object.NewInt(deletions),
+			"patch":     object.NewString(buf.String()),
+		}))
+	}
+	return object.NewList(items)
+}
+
+func (r *Repository) createBranch(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.repository.create_branch", 1, 2, args); err != nil {
+		return err
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	rev := "HEAD"
+	var checkout, force bool
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "rev":
+				rev, err = object.AsString(value)
+			case "checkout":
+				checkout, err = object.AsBool(value)
+			case "force":
+				force, err = object.AsBool(value)
+			default:
+				err = object.Errorf("value error: unknown git.repository.create_branch option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	c, resolveErr := r.resolveCommit(rev)
+	if resolveErr != nil {
+		return gitError(resolveErr)
+	}
+	refName := plumbing.NewBranchReferenceName(name)
+	if err := refName.Validate(); err != nil {
+		return object.Errorf("value error: invalid branch name %q", name)
+	}
+	if _, err := r.repo.Reference(refName, false); err == nil && !force {
+		return gitError(fmt.Errorf("branch %q already exists", name))
+	}
+	ref := plumbing.NewHashReference(refName, c.Hash)
+	if err := r.repo.Storer.SetReference(ref); err != nil {
+		return gitError(err)
+	}
+	if checkout {
+		wt, err := r.repo.Worktree()
+		if err != nil {
+			return gitError(err)
+		}
+		if err := wt.Checkout(&git.CheckoutOptions{Branch: refName}); err != nil {
+			return gitError(err)
+		}
+	}
+	return refValue(ref)
+}
+
+// tagger returns the signature for annotated tags, taken from the options
+// or from the user in the repository and global git configuration.
+func (r *Repository) tagger(name, email string) (*gitobject.Signature, error) {
+	if name == "" || email == "" {
+		cfg, err := r.repo.ConfigScoped(config.GlobalScope)
+		if err == nil {
+			if name == "" {
+				name = cfg.User.Name
+			}
+			if email == "" {
+				email = cfg.User.Email
+			}
+		}
+	}
+	if name == "" || email == "" {
+		return nil, errors.New("annotated tags require a tagger name and email")
+	}
+	return &gitobject.Signature{Name: name, Email: email, When: time.Now()}, nil
+}
+
+func (r *Repository) createTag(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.repository.create_tag", 1, 2, args); err != nil {
+		return err
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	rev := "HEAD"
+	var message, taggerName, taggerEmail string
+	var force bool
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "rev":
+				rev, err = object.AsString(value)
+			case "message":
+				message, err = object.AsString(value)
+			case "tagger_name":
+				taggerName, err = object.AsString(value)
+			case "tagger_email":
+				taggerEmail, err = object.AsString(value)
+			case "force":
+				force, err = object.AsBool(value)
+			default:
+				err = object.Errorf("value error: unknown git.repository.create_tag option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	c, resolveErr := r.resolveCommit(rev)
+	if resolveErr != nil {
+		return gitError(resolveErr)
+	}
+	var opts *git.CreateTagOptions
+	if message != "" {
+		tagger, err := r.tagger(taggerName, taggerEmail)
+		if err != nil {
+			return object.Errorf("value error: %s", err)
+		}
+		opts = &git.CreateTagOptions{Tagger: tagger, Message: message}
+	}
+	if force {
+		if err := r.repo.DeleteTag(name); err != nil && !errors.Is(err, git.ErrTagNotFound) {
+			return gitError(err)
+		}
+	}
+	ref, tagErr := r.repo.CreateTag(name, c.Hash, opts)
+	if tagErr != nil {
+		return gitError(tagErr)
+	}
+	return r.tagValue(ref)
+}
+
+// parseRemoteOptions reads the options shared by fetch and push.
+func parseRemoteOptions(fn string, args []object.Object, auth *authOptions) (remote string, refSpecs []config.RefSpec, tags, force bool, depth int, err *object.Error) {
+	remote = git.DefaultRemoteName
+	if len(args) == 0 {
+		return
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return
+	}
+	for key, value := range m.Value() {
+		var ok bool
+		if ok, err = auth.parse(key, value); ok {
+			if err != nil {
+				return
+			}
+			continue
+		}
+		switch {
+		case key == "remote":
+			remote, err = object.AsString(value)
+		case key == "refspecs":
+			var specs []string
+			if specs, err = asStrings(value); err == nil {
+				for _, spec := range specs {
+					refSpec := config.RefSpec(spec)
+					if validateErr := refSpec.Validate(); validateErr != nil {
+						err = object.Errorf("value error: invalid refspec %q", spec)
+						break
+					}
+					refSpecs = append(refSpecs, refSpec)
+				}
+			}
+		case key == "tags":
+			tags, err = object.AsBool(value)
+		case key == "force":
+			force, err = object.AsBool(value)
+		case key == "depth" && fn == "git.repository.fetch":
+			var n int64
+			if n, err = object.AsInt(value); err == nil && n < 0 {
+				err = object.Errorf("value error: depth must not be negative (got %d)", n)
+			}
+			depth = int(n)
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (r *Repository) fetch(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.repository.fetch", 0, 1, args); err != nil {
+		return err
+	}
+	var auth authOptions
+	remote, refSpecs, tags, force, depth, err := parseRemoteOptions("git.repository.fetch", args, &auth)
+	if err != nil {
+		return err
+	}
+	method, authErr := auth.method()
+	if authErr != nil {
+		return gitError(authErr)
+	}
+	opts := &git.FetchOptions{
+		RemoteName:      remote,
+		RefSpecs:        refSpecs,
+		Auth:            method,
+		Force:           force,
+		Depth:           depth,
+		InsecureSkipTLS: auth.insecureSkipTLS,
+	}
+	if tags {
+		opts.Tags = git.AllTags
+	}
+	if fetchErr := r.repo.FetchContext(ctx, opts); fetchErr != nil {
+		if errors.Is(fetchErr, git.NoErrAlreadyUpToDate) {
+			return object.False
+		}
+		return gitError(fetchErr)
+	}
+	return object.True
+}
+
+func (r *Repository) push(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("git.repository.push", 0, 1, args); err != nil {
+		return err
+	}
+	var auth authOptions
+	remote, refSpecs, tags, force, _, err := parseRemoteOptions("git.repository.push", args, &auth)
+	if err != nil {
+		return err
+	}
+	method, authErr := auth.method()
+	if authErr != nil {
+		return gitError(authErr)
+	}
+	if tags {
+		// Pushing tags as well as, rather than instead of, the branches
+		if len(refSpecs) == 0 {
+			refSpecs = append(refSpecs, config.RefSpec(config.DefaultPushRefSpec))
+		}
+		refSpecs = append(refSpecs, config.RefSpec("refs/tags/*:refs/tags/*"))
+	}
+	opts := &git.PushOptions{
+		RemoteName:      remote,
+		RefSpecs:        refSpecs,
+		Auth:            method,
+		Force:           force,
+		InsecureSkipTLS: auth.insecureSkipTLS,
+	}
+	if pushErr := r.repo.PushContext(ctx, opts); pushErr != nil {
+		if errors.Is(pushErr, git.NoErrAlreadyUpToDate) {
+			return object.False
+		}
+		return gitError(pushErr)
+	}
+	return object.True
+}
+
+func asStrings(obj object.Object) ([]string, *object.Error) {
+	if s, ok := obj.(*object.String); ok {
+		return []string{s.Value()}, nil
+	}
+	return object.AsStringSlice(obj)
+}
@@ -0,0 +1,343 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	modProto "github.com/risor-io/risor/modules/proto"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const CLIENT object.Type = "grpc.client"
+
+// Client is a connection to a gRPC server. Requests and responses are
+// described by descriptors loaded from .proto files or fetched using server
+// reflection, so no generated code is needed.
+type Client struct {
+	target  string
+	conn    *grpc.ClientConn
+	source  descriptorSource
+	headers metadata.MD
+	timeout time.Duration
+}
+
+// callOptions holds the options for a single call.
+type callOptions struct {
+	headers metadata.MD
+	timeout time.Duration
+}
+
+func (c *Client) Type() object.Type {
+	return CLIENT
+}
+
+func (c *Client) Inspect() string {
+	return fmt.Sprintf("grpc.client(%q)", c.target)
+}
+
+func (c *Client) Interface() interface{} {
+	return c.conn
+}
+
+func (c *Client) IsTruthy() bool {
+	return true
+}
+
+func (c *Client) Cost() int {
+	return 8
+}
+
+func (c *Client) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", CLIENT)
+}
+
+func (c *Client) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", CLIENT, opType)
+}
+
+func (c *Client) Equals(other object.Object) object.Object {
+	if c == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (c *Client) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", CLIENT, name)
+}
+
+func (c *Client) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "target":
+		return object.NewString(c.target), true
+	case "call":
+		return object.NewBuiltin("grpc.client.call", c.Call), true
+	case "services":
+		return object.NewBuiltin("grpc.client.services", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.NewArgsError("grpc.client.services", 0, len(args))
+			}
+			names, err := c.source.ListServices(ctx)
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewStringList(names)
+		}), true
+	case "methods":
+		return object.NewBuiltin("grpc.client.methods", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewArgsError("grpc.client.methods", 1, len(args))
+			}
+			name, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			service, findErr := c.source.FindService(ctx, name)
+			if findErr != nil {
+				return object.NewError(findErr)
+			}
+			methods := service.Methods()
+			items := make([]object.Object, methods.Len())
+			for i := 0; i < methods.Len(); i++ {
+				items[i] = methodInfo(methods.Get(i))
+			}
+			return object.NewList(items)
+		}), true
+	case "close":
+		return object.NewBuiltin("grpc.client.close", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 0 {
+				return object.NewArgsError("grpc.client.close", 0, len(args))
+			}
+			if err := c.conn.Close(); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// Call invokes an RPC. Unary and client streaming RPCs return the response
+// map. Server and bidirectional streaming RPCs return a stream of response
+// maps. For client and bidirectional streaming RPCs, the request is an
+// iterable of maps.
+func (c *Client) Call(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return object.NewArgsRangeError("grpc.client.call", 1, 3, len(args))
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	var request object.Object = object.Nil
+	if len(args) > 1 {
+		request = args[1]
+	}
+	opts := callOptions{headers: c.headers, timeout: c.timeout}
+	if len(args) > 2 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		if err := parseCallOptions(m, &opts); err != nil {
+			return err
+		}
+	}
+	method, findErr := c.findMethod(ctx, name)
+	if findErr != nil {
+		return object.NewError(findErr)
+	}
+	fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+	ctx, cancel := opts.context(ctx)
+
+	if !method.IsStreamingClient() && !method.IsStreamingServer() {
+		defer cancel()
+		req, err := modProto.ToMessage(method.Input(), request, nil)
+		if err != nil {
+			return err
+		}
+		resp := dynamicpb.NewMessage(method.Output())
+		if err := c.conn.Invoke(ctx, fullMethod, req, resp); err != nil {
+			return object.NewError(err)
+		}
+		return modProto.MessageToObject(resp, nil)
+	}
+
+	// Build all request messages up front so that Risor iterators are only
+	// consumed on the calling goroutine
+	var requests []*dynamicpb.Message
+	if method.IsStreamingClient() {
+		iter, err := object.AsIterator(request)
+		if err != nil {
+			cancel()
+			return err
+		}
+		for {
+			item, ok := iter.Next(ctx)
+			if !ok {
+				break
+			}
+			req, err := modProto.ToMessage(method.Input(), item, nil)
+			if err != nil {
+				cancel()
+				return err
+			}
+			requests = append(requests, req)
+		}
+	} else {
+		req, err := modProto.ToMessage(method.Input(), request, nil)
+		if err != nil {
+			cancel()
+			return err
+		}
+		requests = append(requests, req)
+	}
+
+	desc := &grpc.StreamDesc{
+		StreamName:    string(method.Name()),
+		ClientStreams: method.IsStreamingClient(),
+		ServerStreams: method.IsStreamingServer(),
+	}
+	stream, streamErr := c.conn.NewStream(ctx, desc, fullMethod)
+	if streamErr != nil {
+		cancel()
+		return object.NewError(streamErr)
+	}
+	sendErr := make(chan error, 1)
+	go func() {
+		for _, req := range requests {
+			if err := stream.SendMsg(req); err != nil {
+				sendErr <- err
+				return
+			}
+		}
+		sendErr <- stream.CloseSend()
+	}()
+
+	if !method.IsStreamingServer() {
+		defer cancel()
+		resp := dynamicpb.NewMessage(method.Output())
+		if err := stream.RecvMsg(resp); err != nil {
+			return object.NewError(err)
+		}
+		if err := <-sendErr; err != nil && err != io.EOF {
+			return object.NewError(err)
+		}
+		return modProto.MessageToObject(resp, nil)
+	}
+
+	return object.NewStream(func(_ context.Context) (object.Object, bool, error) {
+		resp := dynamicpb.NewMessage(method.Output())
+		if err := stream.RecvMsg(resp); err != nil {
+			cancel()
+			if err == io.EOF {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+		return modProto.MessageToObject(resp, nil), true, nil
+	})
+}
+
+func (c *Client) findMethod(ctx context.Context, name string) (protoreflect.MethodDescriptor, error) {
+	serviceName, methodName, err := splitMethod(name)
+	if err != nil {
+		return nil, err
+	}
+	service, err := c.source.FindService(ctx, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("value error: service %s has no method %q", serviceName, methodName)
+	}
+	return method, nil
+}
+
+func (o callOptions) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if len(o.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, o.headers)
+	}
+	if o.timeout > 0 {
+		return context.WithTimeout(ctx, o.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func parseCallOptions(m *object.Map, opts *callOptions) *object.Error {
+	for key, value := range m.Value() {
+		switch key {
+		case "headers":
+			headers, err := parseHeaders(value)
+			if err != nil {
+				return err
+			}
+			opts.headers = metadata.Join(opts.headers, headers)
+		case "timeout":
+			timeout, err := parseTimeout(value)
+			if err != nil {
+				return err
+			}
+			opts.timeout = timeout
+		default:
+			return object.Errorf("value error: unknown grpc call option %q", key)
+		}
+	}
+	return nil
+}
+
+func parseHeaders(obj object.Object) (metadata.MD, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	headers := metadata.MD{}
+	for key, value := range m.Value() {
+		switch value := value.(type) {
+		case *object.List:
+			values, err := object.AsStringSlice(value)
+			if err != nil {
+				return nil, err
+			}
+			headers.Append(key, values...)
+		default:
+			s, err := object.AsString(value)
+			if err != nil {
+				return nil, err
+			}
+			headers.Append(key, s)
+		}
+	}
+	return headers, nil
+}
+
+// parseTimeout returns a timeout given in milliseconds.
+func parseTimeout(obj object.Object) (time.Duration, *object.Error) {
+	ms, err := object.AsInt(obj)
+	if err != nil {
+		return 0, err
+	}
+	if ms < 0 {
+		return 0, object.Errorf("value error: timeout must be non-negative (got %d)", ms)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+func methodInfo(method protoreflect.MethodDescriptor) *object.Map {
+	return object.NewMap(map[string]object.Object{
+		"name":             object.NewString(string(method.Name())),
+		"full_name":        object.NewString(fmt.Sprintf("%s/%s", method.Parent().FullName(), method.Name())),
+		"input":            object.NewString(string(method.Input().FullName())),
+		"output":           object.NewString(string(method.Output().FullName())),
+		"client_streaming": object.NewBool(method.IsStreamingClient()),
+		"server_streaming": object.NewBool(method.IsStreamingServer()),
+	})
+}
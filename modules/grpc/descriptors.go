@@ -0,0 +1,283 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// descriptorSource provides the service descriptors used to build requests
+// and decode responses.
+type descriptorSource interface {
+	FindService(ctx context.Context, name string) (protoreflect.ServiceDescriptor, error)
+	ListServices(ctx context.Context) ([]string, error)
+}
+
+// fileSource resolves services from .proto files compiled at dial time.
+type fileSource struct {
+	files *protoregistry.Files
+}
+
+func newFileSource(ctx context.Context, paths, importPaths []string) (*fileSource, error) {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: importPaths,
+		}),
+	}
+	compiled, err := compiler.Compile(ctx, paths...)
+	if err != nil {
+		return nil, err
+	}
+	files := &protoregistry.Files{}
+	for _, file := range compiled {
+		if err := registerFile(files, file); err != nil {
+			return nil, err
+		}
+	}
+	return &fileSource{files: files}, nil
+}
+
+// registerFile registers the file and its transitive imports.
+func registerFile(files *protoregistry.Files, file protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(file.Path()); err == nil {
+		return nil
+	}
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerFile(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(file)
+}
+
+func (s *fileSource) FindService(ctx context.Context, name string) (protoreflect.ServiceDescriptor, error) {
+	desc, err := s.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("value error: service %q not found", name)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("value error: %q is not a service", name)
+	}
+	return service, nil
+}
+
+func (s *fileSource) ListServices(ctx context.Context) ([]string, error) {
+	var names []string
+	s.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		services := file.Services()
+		for i := 0; i < services.Len(); i++ {
+			names = append(names, string(services.Get(i).FullName()))
+		}
+		return true
+	})
+	sort.Strings(names)
+	return names, nil
+}
+
+// reflectionSource resolves services using the gRPC server reflection
+// protocol. Descriptors are fetched on demand and cached.
+type reflectionSource struct {
+	conn  *grpc.ClientConn
+	mutex sync.Mutex
+	files *protoregistry.Files
+}
+
+func newReflectionSource(conn *grpc.ClientConn) *reflectionSource {
+	return &reflectionSource{conn: conn, files: &protoregistry.Files{}}
+}
+
+func (s *reflectionSource) FindService(ctx context.Context, name string) (protoreflect.ServiceDescriptor, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if desc, err := s.files.FindDescriptorByName(protoreflect.FullName(name)); err == nil {
+		if service, ok := desc.(protoreflect.ServiceDescriptor); ok {
+			return service, nil
+		}
+		return nil, fmt.Errorf("value error: %q is not a service", name)
+	}
+	stream, err := rpb.NewServerReflectionClient(s.conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+	resp, err := s.request(stream, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.registerResponse(stream, resp); err != nil {
+		return nil, err
+	}
+	desc, err := s.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("value error: service %q not found", name)
+	}
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("value error: %q is not a service", name)
+	}
+	return service, nil
+}
+
+func (s *reflectionSource) ListServices(ctx context.Context) ([]string, error) {
+	stream, err := rpb.NewServerReflectionClient(s.conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+	resp, err := s.request(stream, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{ListServices: "*"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := resp.GetListServicesResponse()
+	if list == nil {
+		return nil, errors.New("grpc error: unexpected server reflection response")
+	}
+	var names []string
+	for _, service := range list.GetService() {
+		names = append(names, service.GetName())
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *reflectionSource) request(
+	stream rpb.ServerReflection_ServerReflectionInfoClient,
+	req *rpb.ServerReflectionRequest,
+) (*rpb.ServerReflectionResponse, error) {
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return nil, fmt.Errorf("grpc error: server reflection: %s", errResp.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// registerResponse registers the file descriptors in a reflection response,
+// fetching any dependencies the server did not include.
+func (s *reflectionSource) registerResponse(
+	stream rpb.ServerReflection_ServerReflectionInfoClient,
+	resp *rpb.ServerReflectionResponse,
+) error {
+	pending := map[string]*descriptorpb.FileDescriptorProto{}
+	if err := addFileProtos(pending, resp); err != nil {
+		return err
+	}
+	var register func(name string) error
+	register = func(name string) error {
+		if _, err := s.files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		fdp, ok := pending[name]
+		if !ok {
+			if _, err := protoregistry.GlobalFiles.FindFileByPath(name); err == nil {
+				return nil
+			}
+			resp, err := s.request(stream, &rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name},
+			})
+			if err != nil {
+				return err
+			}
+			if err := addFileProtos(pending, resp); err != nil {
+				return err
+			}
+			if fdp, ok = pending[name]; !ok {
+				return fmt.Errorf("grpc error: server reflection did not return %q", name)
+			}
+		}
+		for _, dep := range fdp.GetDependency() {
+			if err := register(dep); err != nil {
+				return err
+			}
+		}
+		file, err := protodesc.NewFile(fdp, reflectionResolver{s.files})
+		if err != nil {
+			return err
+		}
+		return s.files.RegisterFile(file)
+	}
+	names := make([]string, 0, len(pending))
+	for name := range pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := register(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addFileProtos(pending map[string]*descriptorpb.FileDescriptorProto, resp *rpb.ServerReflectionResponse) error {
+	files := resp.GetFileDescriptorResponse()
+	if files == nil {
+		return errors.New("grpc error: unexpected server reflection response")
+	}
+	for _, data := range files.GetFileDescriptorProto() {
+		fdp := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(data, fdp); err != nil {
+			return err
+		}
+		pending[fdp.GetName()] = fdp
+	}
+	return nil
+}
+
+// reflectionResolver resolves imports from the descriptors fetched so far,
+// falling back to the well-known types linked into the binary.
+type reflectionResolver struct {
+	files *protoregistry.Files
+}
+
+func (r reflectionResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if file, err := r.files.FindFileByPath(path); err == nil {
+		return file, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r reflectionResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if desc, err := r.files.FindDescriptorByName(name); err == nil {
+		return desc, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// splitMethod splits a method name into its service and method parts. The
+// forms "pkg.Service/Method", "/pkg.Service/Method", and "pkg.Service.Method"
+// are accepted.
+func splitMethod(name string) (string, string, error) {
+	name = strings.TrimPrefix(name, "/")
+	sep := strings.LastIndex(name, "/")
+	if sep < 0 {
+		sep = strings.LastIndex(name, ".")
+	}
+	if sep <= 0 || sep == len(name)-1 {
+		return "", "", fmt.Errorf("value error: invalid method name %q (expected \"package.Service/Method\")", name)
+	}
+	return name[:sep], name[sep+1:], nil
+}
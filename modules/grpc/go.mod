@@ -0,0 +1,30 @@
+module github.com/risor-io/risor/modules/grpc
+
+go 1.21
+
+replace (
+	github.com/risor-io/risor => ../..
+	github.com/risor-io/risor/modules/proto => ../proto
+)
+
+require (
+	github.com/bufbuild/protocompile v0.6.0
+	github.com/risor-io/risor v1.1.0
+	github.com/risor-io/risor/modules/proto v0.0.0-00010101000000-000000000000
+	github.com/stretchr/testify v1.8.4
+	google.golang.org/grpc v1.59.0
+	google.golang.org/protobuf v1.31.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.14.0 // indirect
+	golang.org/x/sync v0.3.0 // indirect
+	golang.org/x/sys v0.11.0 // indirect
+	golang.org/x/text v0.12.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// dialOptions holds the options given to grpc.dial.
+type dialOptions struct {
+	plaintext          bool
+	insecureSkipVerify bool
+	caFile             string
+	authority          string
+	protos             []string
+	importPaths        []string
+	headers            metadata.MD
+	timeout            time.Duration
+}
+
+func Dial(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return object.NewArgsRangeError("grpc.dial", 1, 2, len(args))
+	}
+	target, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	var opts dialOptions
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		if err := parseDialOptions(m, &opts); err != nil {
+			return err
+		}
+	}
+	client, dialErr := NewClient(ctx, target, opts)
+	if dialErr != nil {
+		return object.NewError(dialErr)
+	}
+	return client
+}
+
+// NewClient connects to the given target. The connection is established
+// lazily, on the first call.
+func NewClient(ctx context.Context, target string, opts dialOptions) (*Client, error) {
+	var grpcOpts []grpc.DialOption
+	if opts.plaintext {
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	} else {
+		tlsConfig := &tls.Config{InsecureSkipVerify: opts.insecureSkipVerify}
+		if opts.caFile != "" {
+			pem, err := os.ReadFile(opts.caFile)
+			if err != nil {
+				return nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("value error: no certificates found in %s", opts.caFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		grpcOpts = append(grpcOpts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	if opts.authority != "" {
+		grpcOpts = append(grpcOpts, grpc.WithAuthority(opts.authority))
+	}
+	conn, err := grpc.DialContext(ctx, target, grpcOpts...)
+	if err != nil {
+		return nil, err
+	}
+	client := &Client{
+		target:  target,
+		conn:    conn,
+		headers: opts.headers,
+		timeout: opts.timeout,
+	}
+	if len(opts.protos) > 0 {
+		source, err := newFileSource(ctx, opts.protos, opts.importPaths)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		client.source = source
+	} else {
+		client.source = newReflectionSource(conn)
+	}
+	return client, nil
+}
+
+func parseDialOptions(m *object.Map, opts *dialOptions) *object.Error {
+	for key, value := range m.Value() {
+		var err *object.Error
+		switch key {
+		case "plaintext":
+			opts.plaintext, err = object.AsBool(value)
+		case "insecure_skip_verify":
+			opts.insecureSkipVerify, err = object.AsBool(value)
+		case "ca_file":
+			opts.caFile, err = object.AsString(value)
+		case "authority":
+			opts.authority, err = object.AsString(value)
+		case "protos":
+			opts.protos, err = stringOrList(value)
+		case "import_paths":
+			opts.importPaths, err = stringOrList(value)
+		case "headers":
+			opts.headers, err = parseHeaders(value)
+		case "timeout":
+			opts.timeout, err = parseTimeout(value)
+		default:
+			err = object.Errorf("value error: unknown grpc dial option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stringOrList(obj object.Object) ([]string, *object.Error) {
+	if s, ok := obj.(*object.String); ok {
+		return []string{s.Value()}, nil
+	}
+	return object.AsStringSlice(obj)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("grpc", map[string]object.Object{
+		"dial": object.NewBuiltin("grpc.dial", Dial),
+	})
+}
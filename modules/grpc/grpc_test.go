@@ -0,0 +1,110 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func startServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("db", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func dial(t *testing.T, ctx context.Context, opts map[string]object.Object) *Client {
+	t.Helper()
+	opts["plaintext"] = object.True
+	result := Dial(ctx, object.NewString(startServer(t)), object.NewMap(opts))
+	client, ok := result.(*Client)
+	require.True(t, ok, result.Inspect())
+	t.Cleanup(func() { client.conn.Close() })
+	return client
+}
+
+func call(ctx context.Context, client *Client, name string, args ...object.Object) object.Object {
+	method, _ := client.GetAttr(name)
+	return method.(*object.Builtin).Call(ctx, args...)
+}
+
+func TestUnaryWithReflection(t *testing.T) {
+	ctx := context.Background()
+	client := dial(t, ctx, map[string]object.Object{})
+
+	result := call(ctx, client, "call", object.NewString("grpc.health.v1.Health/Check"), object.NewMap(nil))
+	require.Equal(t, object.NewMap(map[string]object.Object{
+		"status": object.NewString("SERVING"),
+	}), result)
+
+	result = call(ctx, client, "call", object.NewString("grpc.health.v1.Health.Check"),
+		object.NewMap(map[string]object.Object{"service": object.NewString("db")}))
+	require.Equal(t, object.NewString("NOT_SERVING"), result.(*object.Map).Get("status"))
+
+	services := call(ctx, client, "services")
+	require.Contains(t, services.(*object.List).Value(), object.NewString("grpc.health.v1.Health"))
+
+	methods := call(ctx, client, "methods", object.NewString("grpc.health.v1.Health"))
+	require.Len(t, methods.(*object.List).Value(), 2)
+}
+
+func TestServerStreamWithProtoFile(t *testing.T) {
+	ctx := context.Background()
+	client := dial(t, ctx, map[string]object.Object{
+		"protos":       object.NewString("health.proto"),
+		"import_paths": object.NewStringList([]string{"testdata"}),
+	})
+	result := call(ctx, client, "call", object.NewString("grpc.health.v1.Health/Watch"),
+		object.NewMap(map[string]object.Object{"service": object.NewString("db")}),
+		object.NewMap(map[string]object.Object{"timeout": object.NewInt(5000)}))
+	stream, ok := result.(*object.Stream)
+	require.True(t, ok, result.Inspect())
+	first, ok := stream.Next(ctx)
+	require.True(t, ok)
+	require.Equal(t, object.NewString("NOT_SERVING"), first.(*object.Map).Get("status"))
+}
+
+func TestCallErrors(t *testing.T) {
+	ctx := context.Background()
+	client := dial(t, ctx, map[string]object.Object{})
+
+	result := call(ctx, client, "call", object.NewString("grpc.health.v1.Health/Nope"))
+	require.True(t, object.IsError(result))
+
+	result = call(ctx, client, "call", object.NewString("grpc.health.v1.Health/Check"),
+		object.NewMap(map[string]object.Object{"bogus": object.NewInt(1)}))
+	require.True(t, object.IsError(result))
+
+	result = call(ctx, client, "call", object.NewString("grpc.health.v1.Health/Check"),
+		object.NewMap(map[string]object.Object{"service": object.NewString("missing")}))
+	require.True(t, object.IsError(result))
+
+	result = Dial(ctx, object.NewString("localhost:1"),
+		object.NewMap(map[string]object.Object{"retries": object.NewInt(1)}))
+	require.True(t, object.IsError(result))
+}
+
+func TestSplitMethod(t *testing.T) {
+	for _, name := range []string{"/a.B/C", "a.B/C", "a.B.C"} {
+		service, method, err := splitMethod(name)
+		require.Nil(t, err)
+		require.Equal(t, "a.B", service)
+		require.Equal(t, "C", method)
+	}
+	_, _, err := splitMethod("C")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,212 @@
+//go:build helm
+// +build helm
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gopkg.in/yaml.v3"
+	"helm.sh/helm/v3/pkg/releaseutil"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// manifest holds the identifying fields of a Kubernetes resource.
+type manifest struct {
+	apiVersion string
+	kind       string
+	name       string
+	namespace  string
+	value      map[string]interface{}
+}
+
+// parseManifest decodes a single YAML document. It reports false if the
+// document isn't a mapping.
+func parseManifest(content string) (manifest, bool) {
+	var value map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &value); err != nil || value == nil {
+		return manifest{}, false
+	}
+	m := manifest{value: value}
+	m.apiVersion, _ = value["apiVersion"].(string)
+	m.kind, _ = value["kind"].(string)
+	if metadata, ok := value["metadata"].(map[string]interface{}); ok {
+		m.name, _ = metadata["name"].(string)
+		m.namespace, _ = metadata["namespace"].(string)
+	}
+	return m, true
+}
+
+// key identifies the resource across renders, as its namespace, kind, and
+// name.
+func (m manifest) key() string {
+	if m.kind == "" || m.name == "" {
+		return ""
+	}
+	if m.namespace == "" {
+		return m.kind + "/" + m.name
+	}
+	return m.namespace + "/" + m.kind + "/" + m.name
+}
+
+// diffOptions control how rendered manifests are compared.
+type diffOptions struct {
+	context   int
+	normalize bool
+}
+
+func parseDiffOptions(args []object.Object) (diffOptions, *object.Error) {
+	opts := diffOptions{context: 3, normalize: true}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "context":
+			var n int64
+			if n, err = object.AsInt(value); err == nil {
+				if n < 0 {
+					err = object.Errorf("value error: context must not be negative (got %d)", n)
+				}
+				opts.context = int(n)
+			}
+		case "normalize":
+			opts.normalize, err = object.AsBool(value)
+		default:
+			err = object.Errorf("value error: unknown helm.diff option %q", key)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// asDocuments accepts a string of YAML documents, such as the output of
+// helm.template, or a list of the documents returned by helm.render or of
+// strings, and returns the documents keyed by resource.
+func asDocuments(obj object.Object, opts diffOptions) (map[string]string, *object.Error) {
+	var contents []string
+	switch obj := obj.(type) {
+	case *object.String:
+		manifests := releaseutil.SplitManifests(obj.Value())
+		keys := make([]string, 0, len(manifests))
+		for key := range manifests {
+			keys = append(keys, key)
+		}
+		sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+		for _, key := range keys {
+			contents = append(contents, manifests[key])
+		}
+	case *object.List:
+		for _, item := range obj.Value() {
+			switch item := item.(type) {
+			case *object.String:
+				contents = append(contents, item.Value())
+			case *object.Map:
+				content, err := object.AsString(item.GetWithDefault("content", object.Nil))
+				if err != nil {
+					return nil, err
+				}
+				contents = append(contents, content)
+			default:
+				return nil, object.Errorf("type error: helm.diff expected a list of strings or maps (%s found)", item.Type())
+			}
+		}
+	default:
+		return nil, object.Errorf("type error: helm.diff expected a string or list (%s given)", obj.Type())
+	}
+	docs := make(map[string]string, len(contents))
+	for i, content := range contents {
+		source, content := splitSource(content)
+		parsed, ok := parseManifest(content)
+		key := parsed.key()
+		if key == "" {
+			key = source
+		}
+		if key == "" {
+			key = fmt.Sprintf("document-%d", i)
+		}
+		if ok && opts.normalize {
+			var buf bytes.Buffer
+			enc := yaml.NewEncoder(&buf)
+			enc.SetIndent(2)
+			if err := enc.Encode(parsed.value); err != nil {
+				return nil, object.NewError(err)
+			}
+			content = buf.String()
+		}
+		docs[key] = content
+	}
+	return docs, nil
+}
+
+func Diff(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("helm.diff", 2, 3, args); err != nil {
+		return err
+	}
+	opts, err := parseDiffOptions(args[2:])
+	if err != nil {
+		return err
+	}
+	oldDocs, err := asDocuments(args[0], opts)
+	if err != nil {
+		return err
+	}
+	newDocs, err := asDocuments(args[1], opts)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	for key := range oldDocs {
+		keys = append(keys, key)
+	}
+	for key := range newDocs {
+		if _, ok := oldDocs[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	changes := make([]object.Object, 0)
+	for _, key := range keys {
+		oldContent, inOld := oldDocs[key]
+		newContent, inNew := newDocs[key]
+		var change string
+		switch {
+		case !inOld:
+			change = "added"
+		case !inNew:
+			change = "removed"
+		case oldContent != newContent:
+			change = "modified"
+		default:
+			continue
+		}
+		text, diffErr := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(oldContent),
+			B:        difflib.SplitLines(newContent),
+			FromFile: "a/" + key,
+			ToFile:   "b/" + key,
+			Context:  opts.context,
+		})
+		if diffErr != nil {
+			return object.NewError(diffErr)
+		}
+		changes = append(changes, object.NewMap(map[string]object.Object{
+			"key":    object.NewString(key),
+			"change": object.NewString(change),
+			"diff":   object.NewString(text),
+		}))
+	}
+	return object.NewList(changes)
+}
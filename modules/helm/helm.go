@@ -0,0 +1,505 @@
+//go:build helm
+// +build helm
+
+package helm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/lint/support"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/releaseutil"
+	"helm.sh/helm/v3/pkg/strvals"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+)
+
+// chartOptions hold the options used to locate and render a chart.
+type chartOptions struct {
+	values       map[string]interface{}
+	valuesFiles  []string
+	set          []string
+	setString    []string
+	releaseName  string
+	namespace    string
+	kubeVersion  string
+	apiVersions  []string
+	includeCRDs  bool
+	disableHooks bool
+	isUpgrade    bool
+	repo         string
+	version      string
+	username     string
+	password     string
+	strict       bool
+	subcharts    bool
+}
+
+// parseChartOptions reads the options map of the named function. Options
+// other than the values options must be listed in allowed.
+func parseChartOptions(fn string, args []object.Object, allowed ...string) (*chartOptions, *object.Error) {
+	opts := &chartOptions{releaseName: "release-name", namespace: "default"}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range m.Value() {
+		switch {
+		case key == "values":
+			var values *object.Map
+			if values, err = object.AsMap(value); err == nil {
+				opts.values = values.Interface().(map[string]interface{})
+			}
+		case key == "values_files":
+			opts.valuesFiles, err = asStrings(value)
+		case key == "set":
+			opts.set, err = asStrings(value)
+		case key == "set_string":
+			opts.setString, err = asStrings(value)
+		case !contains(allowed, key):
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		case key == "release_name":
+			opts.releaseName, err = object.AsString(value)
+		case key == "namespace":
+			opts.namespace, err = object.AsString(value)
+		case key == "kube_version":
+			opts.kubeVersion, err = object.AsString(value)
+		case key == "api_versions":
+			opts.apiVersions, err = asStrings(value)
+		case key == "include_crds":
+			opts.includeCRDs, err = object.AsBool(value)
+		case key == "disable_hooks":
+			opts.disableHooks, err = object.AsBool(value)
+		case key == "is_upgrade":
+			opts.isUpgrade, err = object.AsBool(value)
+		case key == "repo":
+			opts.repo, err = object.AsString(value)
+		case key == "version":
+			opts.version, err = object.AsString(value)
+		case key == "username":
+			opts.username, err = object.AsString(value)
+		case key == "password":
+			opts.password, err = object.AsString(value)
+		case key == "strict":
+			opts.strict, err = object.AsBool(value)
+		case key == "with_subcharts":
+			opts.subcharts, err = object.AsBool(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// mergedValues combines the values files, the values map, and the set
+// expressions, in that order, with later values taking precedence.
+func (opts *chartOptions) mergedValues(ctx context.Context) (map[string]interface{}, *object.Error) {
+	base := map[string]interface{}{}
+	for _, path := range opts.valuesFiles {
+		data, err := ros.GetDefaultOS(ctx).ReadFile(path)
+		if err != nil {
+			return nil, object.NewError(err)
+		}
+		values, err := chartutil.ReadValues(data)
+		if err != nil {
+			return nil, object.Errorf("value error: invalid values file %q: %s", path, err)
+		}
+		base = mergeMaps(base, values)
+	}
+	if opts.values != nil {
+		base = mergeMaps(base, opts.values)
+	}
+	for _, expr := range opts.set {
+		if err := strvals.ParseInto(expr, base); err != nil {
+			return nil, object.Errorf("value error: invalid set expression %q: %s", expr, err)
+		}
+	}
+	for _, expr := range opts.setString {
+		if err := strvals.ParseIntoString(expr, base); err != nil {
+			return nil, object.Errorf("value error: invalid set_string expression %q: %s", expr, err)
+		}
+	}
+	return base, nil
+}
+
+// mergeMaps merges b into a, recursing into nested maps.
+func mergeMaps(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		if v, ok := v.(map[string]interface{}); ok {
+			if bv, ok := out[k]; ok {
+				if bv, ok := bv.(map[string]interface{}); ok {
+					out[k] = mergeMaps(bv, v)
+					continue
+				}
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// newConfiguration returns an action configuration suitable for client-only
+// operations, with a registry client so that charts may be pulled from OCI
+// registries.
+func newConfiguration() (*action.Configuration, error) {
+	registryClient, err := registry.NewClient(registry.ClientOptWriter(io.Discard))
+	if err != nil {
+		return nil, err
+	}
+	return &action.Configuration{
+		RegistryClient: registryClient,
+		Log:            func(string, ...interface{}) {},
+	}, nil
+}
+
+// loadChart locates the chart, downloading it if a repository or an OCI
+// reference is given, and loads it.
+func loadChart(name string, pathOpts *action.ChartPathOptions, opts *chartOptions) (*chart.Chart, error) {
+	pathOpts.RepoURL = opts.repo
+	pathOpts.Version = opts.version
+	pathOpts.Username = opts.username
+	pathOpts.Password = opts.password
+	path, err := pathOpts.LocateChart(name, cli.New())
+	if err != nil {
+		return nil, err
+	}
+	ch, err := loader.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := action.CheckDependencies(ch, ch.Metadata.Dependencies); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// document is a single manifest rendered from a chart template.
+type document struct {
+	source  string
+	content string
+	hook    string
+}
+
+// render renders the chart without contacting a cluster and returns its
+// manifests, followed by its hooks, in the order Helm would install them.
+func render(ctx context.Context, fn string, args []object.Object) ([]document, *object.Error) {
+	if err := arg.RequireRange(fn, 1, 2, args); err != nil {
+		return nil, err
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	opts, err := parseChartOptions(fn, args[1:], "release_name", "namespace",
+		"kube_version", "api_versions", "include_crds", "disable_hooks",
+		"is_upgrade", "repo", "version", "username", "password")
+	if err != nil {
+		return nil, err
+	}
+	cfg, cfgErr := newConfiguration()
+	if cfgErr != nil {
+		return nil, helmError(cfgErr)
+	}
+	install := action.NewInstall(cfg)
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = opts.releaseName
+	install.Namespace = opts.namespace
+	install.IncludeCRDs = opts.includeCRDs
+	install.DisableHooks = opts.disableHooks
+	install.IsUpgrade = opts.isUpgrade
+	install.APIVersions = chartutil.VersionSet(opts.apiVersions)
+	if opts.kubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(opts.kubeVersion)
+		if err != nil {
+			return nil, object.Errorf("value error: invalid kube_version %q", opts.kubeVersion)
+		}
+		install.KubeVersion = kubeVersion
+	}
+	ch, loadErr := loadChart(name, &install.ChartPathOptions, opts)
+	if loadErr != nil {
+		return nil, helmError(loadErr)
+	}
+	values, err := opts.mergedValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rel, runErr := install.RunWithContext(ctx, ch, values)
+	if runErr != nil {
+		return nil, helmError(runErr)
+	}
+	manifests := releaseutil.SplitManifests(rel.Manifest)
+	keys := make([]string, 0, len(manifests))
+	for key := range manifests {
+		keys = append(keys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(keys))
+	var docs []document
+	for _, key := range keys {
+		source, content := splitSource(manifests[key])
+		docs = append(docs, document{source: source, content: content})
+	}
+	for _, hook := range rel.Hooks {
+		if opts.disableHooks {
+			break
+		}
+		events := make([]string, 0, len(hook.Events))
+		for _, event := range hook.Events {
+			events = append(events, event.String())
+		}
+		_, content := splitSource(hook.Manifest)
+		docs = append(docs, document{
+			source:  hook.Path,
+			content: content,
+			hook:    strings.Join(events, ","),
+		})
+	}
+	return docs, nil
+}
+
+// splitSource separates the "# Source:" comment Helm places at the top of
+// each manifest from its content.
+func splitSource(manifest string) (string, string) {
+	manifest = strings.TrimLeft(manifest, "\n")
+	if !strings.HasPrefix(manifest, "# Source: ") {
+		return "", strings.TrimSpace(manifest) + "\n"
+	}
+	line, rest, _ := strings.Cut(manifest, "\n")
+	return strings.TrimPrefix(line, "# Source: "), strings.TrimSpace(rest) + "\n"
+}
+
+func (d document) object() object.Object {
+	m := map[string]object.Object{
+		"source":      object.NewString(d.source),
+		"content":     object.NewString(d.content),
+		"hook":        object.NewString(d.hook),
+		"api_version": object.NewString(""),
+		"kind":        object.NewString(""),
+		"name":        object.NewString(""),
+		"namespace":   object.NewString(""),
+		"object":      object.Nil,
+	}
+	if parsed, ok := parseManifest(d.content); ok {
+		m["api_version"] = object.NewString(parsed.apiVersion)
+		m["kind"] = object.NewString(parsed.kind)
+		m["name"] = object.NewString(parsed.name)
+		m["namespace"] = object.NewString(parsed.namespace)
+		m["object"] = object.FromGoType(parsed.value)
+	}
+	return object.NewMap(m)
+}
+
+func Render(ctx context.Context, args ...object.Object) object.Object {
+	docs, err := render(ctx, "helm.render", args)
+	if err != nil {
+		return err
+	}
+	items := make([]object.Object, 0, len(docs))
+	for _, doc := range docs {
+		items = append(items, doc.object())
+	}
+	return object.NewList(items)
+}
+
+func Template(ctx context.Context, args ...object.Object) object.Object {
+	docs, err := render(ctx, "helm.template", args)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, doc := range docs {
+		sb.WriteString("---\n")
+		if doc.source != "" {
+			fmt.Fprintf(&sb, "# Source: %s\n", doc.source)
+		}
+		sb.WriteString(doc.content)
+	}
+	return object.NewString(sb.String())
+}
+
+var severities = map[int]string{
+	support.UnknownSev: "unknown",
+	support.InfoSev:    "info",
+	support.WarningSev: "warning",
+	support.ErrorSev:   "error",
+}
+
+func Lint(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("helm.lint", 1, 2, args); err != nil {
+		return err
+	}
+	path, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts, err := parseChartOptions("helm.lint", args[1:], "namespace", "strict", "with_subcharts")
+	if err != nil {
+		return err
+	}
+	values, err := opts.mergedValues(ctx)
+	if err != nil {
+		return err
+	}
+	lint := action.NewLint()
+	lint.Namespace = opts.namespace
+	lint.Strict = opts.strict
+	lint.WithSubcharts = opts.subcharts
+	paths := []string{path}
+	if opts.subcharts {
+		// The linter only descends into subcharts that are given as paths
+		// alongside the parent chart, so find them as the helm CLI does.
+		filepath.Walk(filepath.Join(path, "charts"), func(p string, info os.FileInfo, err error) error {
+			if info == nil {
+				return nil
+			}
+			if info.Name() == "Chart.yaml" {
+				paths = append(paths, filepath.Dir(p))
+			} else if strings.HasSuffix(p, ".tgz") || strings.HasSuffix(p, ".tar.gz") {
+				paths = append(paths, p)
+			}
+			return nil
+		})
+	}
+	result := &action.LintResult{}
+	for _, p := range paths {
+		r := lint.Run([]string{p}, values)
+		if r.TotalChartsLinted == 0 && len(r.Errors) > 0 {
+			return helmError(r.Errors[0])
+		}
+		result.Messages = append(result.Messages, r.Messages...)
+		result.Errors = append(result.Errors, r.Errors...)
+	}
+	messages := make([]object.Object, 0, len(result.Messages))
+	for _, msg := range result.Messages {
+		messages = append(messages, object.NewMap(map[string]object.Object{
+			"severity": object.NewString(severities[msg.Severity]),
+			"path":     object.NewString(msg.Path),
+			"message":  object.NewString(msg.Err.Error()),
+		}))
+	}
+	return object.NewMap(map[string]object.Object{
+		"ok":       object.NewBool(len(result.Errors) == 0),
+		"messages": object.NewList(messages),
+	})
+}
+
+func Load(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("helm.load", 1, 2, args); err != nil {
+		return err
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts, err := parseChartOptions("helm.load", args[1:], "repo", "version", "username", "password")
+	if err != nil {
+		return err
+	}
+	if len(opts.values) > 0 || len(opts.valuesFiles) > 0 || len(opts.set) > 0 || len(opts.setString) > 0 {
+		return object.Errorf("value error: helm.load does not accept values")
+	}
+	cfg, cfgErr := newConfiguration()
+	if cfgErr != nil {
+		return helmError(cfgErr)
+	}
+	pathOpts := action.NewInstall(cfg).ChartPathOptions
+	ch, loadErr := loadChart(name, &pathOpts, opts)
+	if loadErr != nil {
+		return helmError(loadErr)
+	}
+	return chartObject(ch)
+}
+
+func chartObject(ch *chart.Chart) object.Object {
+	md := ch.Metadata
+	deps := make([]object.Object, 0, len(md.Dependencies))
+	for _, dep := range md.Dependencies {
+		deps = append(deps, object.NewMap(map[string]object.Object{
+			"name":       object.NewString(dep.Name),
+			"version":    object.NewString(dep.Version),
+			"repository": object.NewString(dep.Repository),
+			"condition":  object.NewString(dep.Condition),
+			"alias":      object.NewString(dep.Alias),
+		}))
+	}
+	templates := make([]string, 0, len(ch.Templates))
+	for _, tpl := range ch.Templates {
+		templates = append(templates, tpl.Name)
+	}
+	crds := make([]string, 0)
+	for _, crd := range ch.CRDObjects() {
+		crds = append(crds, crd.Filename)
+	}
+	chartType := md.Type
+	if chartType == "" {
+		chartType = "application"
+	}
+	values := object.FromGoType(map[string]interface{}(ch.Values))
+	if ch.Values == nil {
+		values = object.NewMap(map[string]object.Object{})
+	}
+	return object.NewMap(map[string]object.Object{
+		"name":         object.NewString(md.Name),
+		"version":      object.NewString(md.Version),
+		"app_version":  object.NewString(md.AppVersion),
+		"api_version":  object.NewString(md.APIVersion),
+		"description":  object.NewString(md.Description),
+		"type":         object.NewString(chartType),
+		"dependencies": object.NewList(deps),
+		"templates":    object.NewStringList(templates),
+		"crds":         object.NewStringList(crds),
+		"values":       values,
+	})
+}
+
+func helmError(err error) *object.Error {
+	return object.Errorf("helm error: %w", err)
+}
+
+func asStrings(obj object.Object) ([]string, *object.Error) {
+	if s, ok := obj.(*object.String); ok {
+		return []string{s.Value()}, nil
+	}
+	return object.AsStringSlice(obj)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("helm", map[string]object.Object{
+		"diff":     object.NewBuiltin("diff", Diff),
+		"lint":     object.NewBuiltin("lint", Lint),
+		"load":     object.NewBuiltin("load", Load),
+		"render":   object.NewBuiltin("render", Render),
+		"template": object.NewBuiltin("template", Template),
+	})
+}
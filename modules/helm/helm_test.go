@@ -0,0 +1,358 @@
+//go:build helm
+// +build helm
+
+package helm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+var chartFiles = map[string]string{
+	"Chart.yaml": `apiVersion: v2
+name: web
+description: A test chart
+version: 1.2.3
+appVersion: "4.5"
+`,
+	"values.yaml": `replicas: 1
+image:
+  repository: nginx
+  tag: "1.25"
+config:
+  greeting: hello
+`,
+	"templates/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-config
+  namespace: {{ .Release.Namespace }}
+data:
+  greeting: {{ .Values.config.greeting | quote }}
+`,
+	"templates/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  replicas: {{ .Values.replicas }}
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag }}"
+`,
+	"templates/job.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .Release.Name }}-migrate
+  annotations:
+    "helm.sh/hook": pre-install
+spec:
+  template:
+    spec:
+      restartPolicy: Never
+      containers:
+        - name: migrate
+          image: busybox
+`,
+	"templates/empty.yaml": `{{- if .Values.extra }}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: extra
+{{- end }}
+`,
+}
+
+func writeChart(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "web")
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func opts(m map[string]interface{}) object.Object {
+	return object.FromGoType(m)
+}
+
+func findDoc(t *testing.T, docs *object.List, kind string) *object.Map {
+	t.Helper()
+	for _, item := range docs.Value() {
+		doc := item.(*object.Map)
+		if doc.Get("kind").(*object.String).Value() == kind {
+			return doc
+		}
+	}
+	t.Fatalf("no %s document", kind)
+	return nil
+}
+
+func TestRender(t *testing.T) {
+	dir := writeChart(t, chartFiles)
+	result := Render(context.Background(), object.NewString(dir), opts(map[string]interface{}{
+		"release_name": "shop",
+		"namespace":    "prod",
+	}))
+	docs, ok := result.(*object.List)
+	require.True(t, ok, result.Inspect())
+	require.Len(t, docs.Value(), 3)
+
+	cm := findDoc(t, docs, "ConfigMap")
+	require.Equal(t, "web/templates/configmap.yaml", cm.Get("source").(*object.String).Value())
+	require.Equal(t, "shop-config", cm.Get("name").(*object.String).Value())
+	require.Equal(t, "prod", cm.Get("namespace").(*object.String).Value())
+	require.Equal(t, "v1", cm.Get("api_version").(*object.String).Value())
+	require.Equal(t, "", cm.Get("hook").(*object.String).Value())
+	data := cm.Get("object").(*object.Map).Get("data").(*object.Map)
+	require.Equal(t, object.NewString("hello"), data.Get("greeting"))
+
+	deploy := findDoc(t, docs, "Deployment")
+	spec := deploy.Get("object").(*object.Map).Get("spec").(*object.Map)
+	require.Equal(t, object.NewInt(1), spec.Get("replicas"))
+
+	job := findDoc(t, docs, "Job")
+	require.Equal(t, "pre-install", job.Get("hook").(*object.String).Value())
+	require.Equal(t, "shop-migrate", job.Get("name").(*object.String).Value())
+	// Hooks follow the regular manifests
+	require.Equal(t, job, docs.Value()[2])
+}
+
+func TestRenderValues(t *testing.T) {
+	dir := writeChart(t, chartFiles)
+	valuesFile := filepath.Join(t.TempDir(), "prod.yaml")
+	require.NoError(t, os.WriteFile(valuesFile, []byte("replicas: 3\nimage:\n  tag: \"1.26\"\nconfig:\n  greeting: hi\n"), 0o644))
+
+	result := Render(context.Background(), object.NewString(dir), opts(map[string]interface{}{
+		"values_files": []interface{}{valuesFile},
+		"values":       map[string]interface{}{"replicas": 5, "extra": true},
+		"set":          []interface{}{"config.greeting=hey"},
+	}))
+	docs, ok := result.(*object.List)
+	require.True(t, ok, result.Inspect())
+	require.Len(t, docs.Value(), 4)
+
+	deploy := findDoc(t, docs, "Deployment").Get("content").(*object.String).Value()
+	require.Contains(t, deploy, "replicas: 5")
+	require.Contains(t, deploy, `image: "nginx:1.26"`)
+	cm := findDoc(t, docs, "ConfigMap").Get("content").(*object.String).Value()
+	require.Contains(t, cm, `greeting: "hey"`)
+	findDoc(t, docs, "Secret")
+}
+
+func TestRenderOptions(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml": chartFiles["Chart.yaml"],
+		"templates/caps.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: caps
+data:
+  kube: {{ .Capabilities.KubeVersion.Version | quote }}
+  policy: {{ .Capabilities.APIVersions.Has "policy/v1beta1" | quote }}
+  upgrade: {{ .Release.IsUpgrade | quote }}
+`,
+	})
+	result := Render(context.Background(), object.NewString(dir), opts(map[string]interface{}{
+		"kube_version": "1.22.0",
+		"api_versions": []interface{}{"policy/v1beta1"},
+		"is_upgrade":   true,
+	}))
+	docs, ok := result.(*object.List)
+	require.True(t, ok, result.Inspect())
+	content := docs.Value()[0].(*object.Map).Get("content").(*object.String).Value()
+	require.Contains(t, content, `kube: "v1.22.0"`)
+	require.Contains(t, content, `policy: "true"`)
+	require.Contains(t, content, `upgrade: "true"`)
+}
+
+func TestRenderErrors(t *testing.T) {
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":            chartFiles["Chart.yaml"],
+		"templates/broken.yaml": "kind: {{ .Values.missing.field }}\n",
+	})
+	result := Render(context.Background(), object.NewString(dir))
+	require.True(t, object.IsError(result))
+	require.Contains(t, result.(*object.Error).Value().Error(), "helm error: ")
+	require.Contains(t, result.(*object.Error).Value().Error(), "nil pointer evaluating")
+
+	result = Render(context.Background(), object.NewString(filepath.Join(dir, "nope")))
+	require.True(t, object.IsError(result))
+	require.Contains(t, result.(*object.Error).Value().Error(), "not found")
+}
+
+func TestTemplate(t *testing.T) {
+	dir := writeChart(t, chartFiles)
+	result := Template(context.Background(), object.NewString(dir), opts(map[string]interface{}{
+		"disable_hooks": true,
+	}))
+	out, ok := result.(*object.String)
+	require.True(t, ok, result.Inspect())
+	require.True(t, strings.HasPrefix(out.Value(), "---\n# Source: web/templates/configmap.yaml\napiVersion: v1\n"))
+	require.Contains(t, out.Value(), "---\n# Source: web/templates/deployment.yaml\n")
+	require.Contains(t, out.Value(), "name: release-name\n  namespace: default\n")
+	require.NotContains(t, out.Value(), "kind: Job")
+}
+
+func TestDiff(t *testing.T) {
+	dir := writeChart(t, chartFiles)
+	ctx := context.Background()
+	before := Render(ctx, object.NewString(dir))
+	require.False(t, object.IsError(before), before.Inspect())
+	after := Template(ctx, object.NewString(dir), opts(map[string]interface{}{
+		"set": "replicas=2,extra=true",
+	}))
+	require.False(t, object.IsError(after), after.Inspect())
+
+	result := Diff(ctx, before, after)
+	changes, ok := result.(*object.List)
+	require.True(t, ok, result.Inspect())
+	require.Len(t, changes.Value(), 2)
+
+	// Changes are ordered by key
+	added := changes.Value()[0].(*object.Map)
+	require.Equal(t, "Secret/extra", added.Get("key").(*object.String).Value())
+	require.Equal(t, "added", added.Get("change").(*object.String).Value())
+
+	modified := changes.Value()[1].(*object.Map)
+	require.Equal(t, "default/Deployment/release-name", modified.Get("key").(*object.String).Value())
+	require.Equal(t, "modified", modified.Get("change").(*object.String).Value())
+	diff := modified.Get("diff").(*object.String).Value()
+	require.Contains(t, diff, "--- a/default/Deployment/release-name\n+++ b/default/Deployment/release-name\n")
+	require.Contains(t, diff, "\n-  replicas: 1\n+  replicas: 2\n")
+
+	// Swapping the arguments reports the secret as removed
+	result = Diff(ctx, after, before, opts(map[string]interface{}{"context": 0}))
+	changes = result.(*object.List)
+	require.Equal(t, "removed", changes.Value()[0].(*object.Map).Get("change").(*object.String).Value())
+	require.NotContains(t, changes.Value()[1].(*object.Map).Get("diff").(*object.String).Value(), "kind: Deployment")
+}
+
+func TestDiffNormalize(t *testing.T) {
+	ctx := context.Background()
+	a := object.NewString("kind: ConfigMap\nmetadata:\n  name: a\ndata: {x: '1', y: '2'}\n")
+	b := object.NewList([]object.Object{
+		object.NewString("# a comment\nmetadata:\n    name: a\nkind: ConfigMap\ndata:\n    y: '2'\n    x: '1'\n"),
+	})
+	require.Equal(t, object.NewList([]object.Object{}), Diff(ctx, a, b))
+
+	result := Diff(ctx, a, b, opts(map[string]interface{}{"normalize": false}))
+	changes := result.(*object.List)
+	require.Len(t, changes.Value(), 1)
+	require.Equal(t, "modified", changes.Value()[0].(*object.Map).Get("change").(*object.String).Value())
+}
+
+func TestLint(t *testing.T) {
+	dir := writeChart(t, chartFiles)
+	result := Lint(context.Background(), object.NewString(dir))
+	m, ok := result.(*object.Map)
+	require.True(t, ok, result.Inspect())
+	require.Equal(t, object.True, m.Get("ok"), m.Inspect())
+	messages := m.Get("messages").(*object.List).Value()
+	require.Len(t, messages, 1)
+	msg := messages[0].(*object.Map)
+	require.Equal(t, "info", msg.Get("severity").(*object.String).Value())
+	require.Equal(t, "Chart.yaml", msg.Get("path").(*object.String).Value())
+	require.Contains(t, msg.Get("message").(*object.String).Value(), "icon is recommended")
+
+	// Warnings only fail the lint in strict mode
+	bare := writeChart(t, map[string]string{"Chart.yaml": chartFiles["Chart.yaml"]})
+	result = Lint(context.Background(), object.NewString(bare))
+	require.Equal(t, object.True, result.(*object.Map).Get("ok"))
+	result = Lint(context.Background(), object.NewString(bare), opts(map[string]interface{}{"strict": true}))
+	m = result.(*object.Map)
+	require.Equal(t, object.False, m.Get("ok"))
+	var warnings []string
+	for _, item := range m.Get("messages").(*object.List).Value() {
+		msg := item.(*object.Map)
+		if msg.Get("severity").(*object.String).Value() == "warning" {
+			warnings = append(warnings, msg.Get("path").(*object.String).Value())
+		}
+	}
+	require.Equal(t, []string{"templates/"}, warnings)
+
+	broken := writeChart(t, map[string]string{
+		"Chart.yaml": chartFiles["Chart.yaml"],
+		"templates/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n" +
+			"{{- with .Values.app }}\n  selector:\n    matchLabels:\n      app: {{ . }}\n{{- end }}\n",
+	})
+	result = Lint(context.Background(), object.NewString(broken))
+	m = result.(*object.Map)
+	require.Equal(t, object.False, m.Get("ok"))
+	var errorMessages []string
+	for _, item := range m.Get("messages").(*object.List).Value() {
+		msg := item.(*object.Map)
+		if msg.Get("severity").(*object.String).Value() == "error" {
+			errorMessages = append(errorMessages, msg.Get("message").(*object.String).Value())
+		}
+	}
+	require.Len(t, errorMessages, 1)
+	require.Contains(t, errorMessages[0], "a Deployment must contain matchLabels or matchExpressions")
+
+	result = Lint(context.Background(), object.NewString(broken), opts(map[string]interface{}{
+		"set": []interface{}{"app=web"},
+	}))
+	require.Equal(t, object.True, result.(*object.Map).Get("ok"))
+
+	result = Lint(context.Background(), object.NewString(filepath.Join(broken, "nope")))
+	require.True(t, object.IsError(result))
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeChart(t, chartFiles)
+	result := Load(context.Background(), object.NewString(dir))
+	m, ok := result.(*object.Map)
+	require.True(t, ok, result.Inspect())
+	require.Equal(t, object.NewString("web"), m.Get("name"))
+	require.Equal(t, object.NewString("1.2.3"), m.Get("version"))
+	require.Equal(t, object.NewString("4.5"), m.Get("app_version"))
+	require.Equal(t, object.NewString("application"), m.Get("type"))
+	require.Len(t, m.Get("templates").(*object.List).Value(), 4)
+	values := m.Get("values").(*object.Map)
+	require.Equal(t, object.NewString("nginx"), values.Get("image").(*object.Map).Get("repository"))
+}
+
+func TestOptionErrors(t *testing.T) {
+	dir := object.NewString(writeChart(t, chartFiles))
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		fn   func(context.Context, ...object.Object) object.Object
+		args []object.Object
+		err  string
+	}{
+		{"render", Render, []object.Object{dir, opts(map[string]interface{}{"strict": true})}, `value error: unknown helm.render option "strict"`},
+		{"template", Template, []object.Object{dir, opts(map[string]interface{}{"bogus": 1})}, `value error: unknown helm.template option "bogus"`},
+		{"lint", Lint, []object.Object{dir, opts(map[string]interface{}{"release_name": "x"})}, `value error: unknown helm.lint option "release_name"`},
+		{"load", Load, []object.Object{dir, opts(map[string]interface{}{"values": map[string]interface{}{"a": 1}})}, "value error: helm.load does not accept values"},
+		{"diff", Diff, []object.Object{object.NewString(""), object.NewString(""), opts(map[string]interface{}{"color": true})}, `value error: unknown helm.diff option "color"`},
+		{"set", Render, []object.Object{dir, opts(map[string]interface{}{"set": "a"})}, `value error: invalid set expression "a": key "a" has no value`},
+		{"kube_version", Render, []object.Object{dir, opts(map[string]interface{}{"kube_version": "x"})}, `value error: invalid kube_version "x"`},
+		{"context", Diff, []object.Object{object.NewString(""), object.NewString(""), opts(map[string]interface{}{"context": -1})}, "value error: context must not be negative (got -1)"},
+		{"diff type", Diff, []object.Object{object.NewInt(1), object.NewString("")}, "type error: helm.diff expected a string or list (int given)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.fn(ctx, tt.args...)
+			require.True(t, object.IsError(result), result.Inspect())
+			require.Equal(t, tt.err, result.(*object.Error).Value().Error())
+		})
+	}
+}
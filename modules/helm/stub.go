@@ -0,0 +1,12 @@
+//go:build !helm
+// +build !helm
+
+package helm
+
+import (
+	"github.com/risor-io/risor/object"
+)
+
+func Module() *object.Module {
+	return nil
+}
@@ -0,0 +1,56 @@
+package html
+
+import (
+	"bytes"
+	"context"
+	"html"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+func Parse(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("html.parse", 1, args); err != nil {
+		return err
+	}
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	doc, perr := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if perr != nil {
+		return object.NewError(perr)
+	}
+	return NewSelection(doc.Selection)
+}
+
+func Escape(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("html.escape", 1, args); err != nil {
+		return err
+	}
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewString(html.EscapeString(s))
+}
+
+func Unescape(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("html.unescape", 1, args); err != nil {
+		return err
+	}
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewString(html.UnescapeString(s))
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("html", map[string]object.Object{
+		"escape":   object.NewBuiltin("escape", Escape),
+		"parse":    object.NewBuiltin("parse", Parse),
+		"unescape": object.NewBuiltin("unescape", Unescape),
+	})
+}
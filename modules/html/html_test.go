@@ -0,0 +1,134 @@
+package html
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+const page = `<html><head><title>Products</title></head><body>
+<ul id="products">
+  <li class="product" data-sku="A1"><a href="/a">Anvil</a> <span class="price">10</span></li>
+  <li class="product sale" data-sku="B2"><a href="/b">Bucket</a> <span class="price">5</span></li>
+</ul>
+</body></html>`
+
+func call(t *testing.T, obj object.Object, method string, args ...object.Object) object.Object {
+	t.Helper()
+	attr, ok := obj.GetAttr(method)
+	require.True(t, ok, method)
+	fn, ok := attr.(*object.Builtin)
+	require.True(t, ok)
+	return fn.Call(context.Background(), args...)
+}
+
+func parse(t *testing.T, source string) *Selection {
+	t.Helper()
+	doc, ok := Parse(context.Background(), object.NewString(source)).(*Selection)
+	require.True(t, ok)
+	return doc
+}
+
+func str(s string) object.Object {
+	return object.NewString(s)
+}
+
+func TestSelect(t *testing.T) {
+	doc := parse(t, page)
+	require.Equal(t, str("Products"), call(t, call(t, doc, "find", str("title")), "text"))
+
+	products := call(t, doc, "find", str("li.product"))
+	length, _ := products.GetAttr("length")
+	require.Equal(t, object.NewInt(2), length)
+
+	var names []object.Object
+	for _, node := range call(t, products, "nodes").(*object.List).Value() {
+		names = append(names, call(t, call(t, node, "find", str("a")), "text"))
+	}
+	require.Equal(t, []object.Object{str("Anvil"), str("Bucket")}, names)
+
+	sale := call(t, products, "filter", str(".sale"))
+	require.Equal(t, str("B2"), call(t, sale, "attr", str("data-sku")))
+	require.Equal(t, object.Nil, call(t, sale, "attr", str("title")))
+	require.Equal(t, str("none"), call(t, sale, "attr", str("title"), str("none")))
+	require.Equal(t, object.FromGoType(map[string]interface{}{"class": "product sale", "data-sku": "B2"}), call(t, sale, "attrs"))
+	require.Equal(t, object.True, call(t, sale, "has_class", str("sale")))
+	require.Equal(t, object.True, call(t, sale, "is", str("li")))
+	require.Equal(t, str("li"), call(t, sale, "tag"))
+
+	first := call(t, products, "first")
+	require.Equal(t, object.True, call(t, first, "next").Equals(call(t, products, "last")))
+	require.Equal(t, str("A1"), call(t, call(t, products, "not", str(".sale")), "attr", str("data-sku")))
+	require.Equal(t, str("products"), call(t, call(t, first, "parent"), "attr", str("id")))
+	require.Equal(t, str("products"), call(t, call(t, call(t, doc, "find", str(".price")), "closest", str("ul")), "attr", str("id")))
+	require.Equal(t, str("10"), call(t, call(t, call(t, products, "eq", object.NewInt(0)), "children", str(".price")), "text"))
+
+	// Empty selections are falsy
+	missing := call(t, doc, "find", str("table"))
+	require.False(t, missing.IsTruthy())
+	require.Equal(t, object.Nil, call(t, missing, "html"))
+	require.Equal(t, str(""), call(t, missing, "text"))
+}
+
+func TestRewrite(t *testing.T) {
+	doc := parse(t, `<div><p class="a">Hello</p><p>Old</p><script>x()</script></div>`)
+	div := call(t, doc, "find", str("div"))
+	p := call(t, div, "find", str("p"))
+	call(t, call(t, p, "first"), "set_text", str("Hi <there>"))
+	call(t, call(t, p, "first"), "remove_class", str("a"))
+	call(t, call(t, p, "last"), "replace_with", str("<p>New</p>"))
+	call(t, call(t, doc, "find", str("script")), "remove")
+	call(t, div, "set_attr", str("id"), str("main"))
+	call(t, div, "add_class", str("x"))
+	call(t, div, "append", str("<footer>End</footer>"))
+	call(t, div, "prepend", str("<h1>Title</h1>"))
+	require.Equal(t,
+		str(`<div id="main" class="x"><h1>Title</h1><p>Hi &lt;there&gt;</p><p>New</p><footer>End</footer></div>`),
+		call(t, div, "outer_html"))
+
+	call(t, call(t, doc, "find", str("footer")), "set_html", str("<b>Bye</b>"))
+	require.Equal(t, str("<b>Bye</b>"), call(t, call(t, doc, "find", str("footer")), "html"))
+	require.Equal(t,
+		str(`<html><head></head><body><div id="main" class="x"><h1>Title</h1><p>Hi &lt;there&gt;</p><p>New</p><footer><b>Bye</b></footer></div></body></html>`),
+		call(t, doc, "html"))
+}
+
+func TestEscape(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, str("&lt;a href=&#34;x&#34;&gt;Tom &amp; Jerry&lt;/a&gt;"), Escape(ctx, str(`<a href="x">Tom & Jerry</a>`)))
+	require.Equal(t, str("Tom & Jerry é"), Unescape(ctx, str("Tom &amp; Jerry &eacute;")))
+}
+
+func TestErrors(t *testing.T) {
+	doc := parse(t, page)
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"invalid selector",
+			call(t, doc, "find", str("li[")),
+			`value error: html.selection.find invalid selector "li[": expected identifier, found EOF instead`,
+		},
+		{
+			"selector type",
+			call(t, doc, "filter", object.NewInt(1)),
+			"type error: expected a string (int given)",
+		},
+		{
+			"parse type",
+			Parse(context.Background(), object.NewInt(1)),
+			"type error: expected bytes (int given)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package html
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const SELECTION object.Type = "html.selection"
+
+// Selection is a set of nodes of a parsed HTML document.
+type Selection struct {
+	sel *goquery.Selection
+}
+
+func NewSelection(sel *goquery.Selection) *Selection {
+	return &Selection{sel: sel}
+}
+
+// Value returns the underlying goquery selection.
+func (s *Selection) Value() *goquery.Selection {
+	return s.sel
+}
+
+func (s *Selection) Type() object.Type {
+	return SELECTION
+}
+
+func (s *Selection) Inspect() string {
+	return fmt.Sprintf("html.selection(length=%d)", s.sel.Length())
+}
+
+func (s *Selection) Interface() interface{} {
+	return nil
+}
+
+func (s *Selection) Equals(other object.Object) object.Object {
+	o, ok := other.(*Selection)
+	if !ok || o.sel.Length() != s.sel.Length() {
+		return object.False
+	}
+	for i, node := range s.sel.Nodes {
+		if o.sel.Nodes[i] != node {
+			return object.False
+		}
+	}
+	return object.True
+}
+
+// IsTruthy returns true if the selection isn't empty.
+func (s *Selection) IsTruthy() bool {
+	return s.sel.Length() > 0
+}
+
+func (s *Selection) Cost() int {
+	return 0
+}
+
+func (s *Selection) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", SELECTION)
+}
+
+func (s *Selection) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", SELECTION, opType)
+}
+
+func (s *Selection) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", SELECTION, name)
+}
+
+// matcher compiles a CSS selector.
+func matcher(fn string, obj object.Object) (goquery.Matcher, *object.Error) {
+	selector, err := object.AsString(obj)
+	if err != nil {
+		return nil, err
+	}
+	m, cerr := cascadia.Compile(selector)
+	if cerr != nil {
+		return nil, object.Errorf("value error: %s invalid selector %q: %v", fn, selector, cerr)
+	}
+	return m, nil
+}
+
+// selectorMethod returns a method taking a CSS selector.
+func (s *Selection) selectorMethod(name string, fn func(m goquery.Matcher) object.Object) *object.Builtin {
+	name = "html.selection." + name
+	return object.NewBuiltin(name, func(ctx context.Context, args ...object.Object) object.Object {
+		if err := arg.Require(name, 1, args); err != nil {
+			return err
+		}
+		m, err := matcher(name, args[0])
+		if err != nil {
+			return err
+		}
+		return fn(m)
+	})
+}
+
+// traversal returns a method taking no arguments that returns a new
+// selection.
+func (s *Selection) traversal(name string, fn func() *goquery.Selection) *object.Builtin {
+	name = "html.selection." + name
+	return object.NewBuiltin(name, func(ctx context.Context, args ...object.Object) object.Object {
+		if err := arg.Require(name, 0, args); err != nil {
+			return err
+		}
+		return NewSelection(fn())
+	})
+}
+
+// stringMethod returns a method taking one string argument, which modifies
+// the selection and returns it.
+func (s *Selection) stringMethod(name string, fn func(string)) *object.Builtin {
+	name = "html.selection." + name
+	return object.NewBuiltin(name, func(ctx context.Context, args ...object.Object) object.Object {
+		if err := arg.Require(name, 1, args); err != nil {
+			return err
+		}
+		value, err := object.AsString(args[0])
+		if err != nil {
+			return err
+		}
+		fn(value)
+		return s
+	})
+}
+
+func (s *Selection) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "length":
+		return object.NewInt(int64(s.sel.Length())), true
+
+	// Traversal
+	case "find":
+		return s.selectorMethod(name, func(m goquery.Matcher) object.Object {
+			return NewSelection(s.sel.FindMatcher(m))
+		}), true
+	case "filter":
+		return s.selectorMethod(name, func(m goquery.Matcher) object.Object {
+			return NewSelection(s.sel.FilterMatcher(m))
+		}), true
+	case "not":
+		return s.selectorMethod(name, func(m goquery.Matcher) object.Object {
+			return NewSelection(s.sel.NotMatcher(m))
+		}), true
+	case "closest":
+		return s.selectorMethod(name, func(m goquery.Matcher) object.Object {
+			return NewSelection(s.sel.ClosestMatcher(m))
+		}), true
+	case "is":
+		return s.selectorMethod(name, func(m goquery.Matcher) object.Object {
+			return object.NewBool(s.sel.IsMatcher(m))
+		}), true
+	case "children":
+		return object.NewBuiltin("html.selection.children", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("html.selection.children", 0, 1, args); err != nil {
+				return err
+			}
+			if len(args) == 0 {
+				return NewSelection(s.sel.Children())
+			}
+			m, err := matcher("html.selection.children", args[0])
+			if err != nil {
+				return err
+			}
+			return NewSelection(s.sel.ChildrenMatcher(m))
+		}), true
+	case "first":
+		return s.traversal(name, s.sel.First), true
+	case "last":
+		return s.traversal(name, s.sel.Last), true
+	case "parent":
+		return s.traversal(name, s.sel.Parent), true
+	case "next":
+		return s.traversal(name, s.sel.Next), true
+	case "prev":
+		return s.traversal(name, s.sel.Prev), true
+	case "eq":
+		return object.NewBuiltin("html.selection.eq", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("html.selection.eq", 1, args); err != nil {
+				return err
+			}
+			i, err := object.AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			return NewSelection(s.sel.Eq(int(i)))
+		}), true
+	case "nodes":
+		return object.NewBuiltin("html.selection.nodes", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("html.selection.nodes", 0, args); err != nil {
+				return err
+			}
+			nodes := make([]object.Object, s.sel.Length())
+			s.sel.Each(func(i int, node *goquery.Selection) {
+				nodes[i] = NewSelection(node)
+			})
+			return object.NewList(nodes)
+		}), true
+
+	// Content
+	case "text":
+		return object.NewBuiltin("html.selection.text", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("html.selection.text", 0, args); err != nil {
+				return err
+			}
+			return object.NewString(s.sel.Text())
+		}), true
+	case "html":
+		return object.NewBuiltin("html.selection.html", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("html.selection.html", 0, args); err != nil {
+				return err
+			}
+			if s.sel.Length() == 0 {
+				return object.Nil
+			}
+			result, err := s.sel.Html()
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewString(result)
+		}), true
+	case "outer_html":
+		return object.NewBuiltin("html.selection.outer_html", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("html.selection.outer_html", 0, args); err != nil {
+				return err
+			}
+			if s.sel.Length() == 0 {
+				return object.Nil
+			}
+			result, err := goquery.OuterHtml(s.sel.First())
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewString(result)
+		}), true
+	case "tag":
+		return object.NewBuiltin("html.selection.tag", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("html.selection.tag", 0, args); err != nil {
+				return err
+			}
+			if s.sel.Length() == 0 {
+				return object.Nil
+			}
+			return object.NewString(goquery.NodeName(s.sel.First()))
+		}), true
+	case "attr":
+		return object.NewBuiltin("html.selection.attr", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("html.selection.attr", 1, 2, args); err != nil {
+				return err
+			}
+			name, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			if value, ok := s.sel.Attr(name); ok {
+				return object.NewString(value)
+			}
+			if len(args) == 2 {
+				return args[1]
+			}
+			return object.Nil
+		}), true
+	case "attrs":
+		return object.NewBuiltin("html.selection.attrs", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("html.selection.attrs", 0, args); err != nil {
+				return err
+			}
+			attrs := map[string]object.Object{}
+			if s.sel.Length() > 0 {
+				for _, a := range s.sel.Nodes[0].Attr {
+					attrs[a.Key] = object.NewString(a.Val)
+				}
+			}
+			return object.NewMap(attrs)
+		}), true
+	case "has_class":
+		return object.NewBuiltin("html.selection.has_class", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("html.selection.has_class", 1, args); err != nil {
+				return err
+			}
+			class, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewBool(s.sel.HasClass(class))
+		}), true
+
+	// Manipulation
+	case "set_attr":
+		return object.NewBuiltin("html.selection.set_attr", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("html.selection.set_attr", 2, args); err != nil {
+				return err
+			}
+			name, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			value, err := object.AsString(args[1])
+			if err != nil {
+				return err
+			}
+			s.sel.SetAttr(name, value)
+			return s
+		}), true
+	case "remove_attr":
+		return s.stringMethod(name, func(v string) { s.sel.RemoveAttr(v) }), true
+	case "add_class":
+		return s.stringMethod(name, func(v string) { s.sel.AddClass(v) }), true
+	case "remove_class":
+		return s.stringMethod(name, func(v string) { s.sel.RemoveClass(v) }), true
+	case "set_text":
+		return s.stringMethod(name, func(v string) { s.sel.SetText(v) }), true
+	case "set_html":
+		return s.stringMethod(name, func(v string) { s.sel.SetHtml(v) }), true
+	case "append":
+		return s.stringMethod(name, func(v string) { s.sel.AppendHtml(v) }), true
+	case "prepend":
+		return s.stringMethod(name, func(v string) { s.sel.PrependHtml(v) }), true
+	case "before":
+		return s.stringMethod(name, func(v string) { s.sel.BeforeHtml(v) }), true
+	case "after":
+		return s.stringMethod(name, func(v string) { s.sel.AfterHtml(v) }), true
+	case "replace_with":
+		return s.stringMethod(name, func(v string) { s.sel.ReplaceWithHtml(v) }), true
+	case "remove":
+		return s.traversal(name, s.sel.Remove), true
+	}
+	return nil, false
+}
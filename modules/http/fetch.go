@@ -11,7 +11,7 @@ func Fetch(ctx context.Context, args ...object.Object) object.Object {
 	if numArgs < 1 || numArgs > 2 {
 		return object.NewArgsRangeError("fetch", 1, 2, numArgs)
 	}
-	urlArg, argErr := object.AsString(args[0])
+	urlArg, argErr := urlString(args[0])
 	if argErr != nil {
 		return argErr
 	}
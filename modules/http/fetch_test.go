@@ -94,3 +94,27 @@ func TestBasicFetch(t *testing.T) {
 		"User-Agent":      []string{"Go-http-client/1.1"},
 	}, gotHeaders)
 }
+
+func TestFetchRequestHooks(t *testing.T) {
+	var gotHeader []string
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Values("Traceparent")
+	}))
+	defer svr.Close()
+
+	ctx := limits.WithLimits(context.Background(), limits.New())
+	req, ok := NewHttpRequest(ctx, object.NewString(svr.URL)).(*HttpRequest)
+	require.True(t, ok)
+
+	hooked := WithRequestHook(ctx, func(req *http.Request) {
+		req.Header.Add("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	})
+	result := req.Send(hooked)
+	require.IsType(t, &HttpResponse{}, result)
+	require.Equal(t, []string{"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}, gotHeader)
+
+	// The header isn't kept on the request
+	result = req.Send(ctx)
+	require.IsType(t, &HttpResponse{}, result)
+	require.Empty(t, gotHeader)
+}
@@ -0,0 +1,27 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestHook is called with each request sent by this module, just before
+// it is sent. Hooks may add headers, for example to propagate a trace.
+type RequestHook func(req *http.Request)
+
+type contextKey string
+
+const requestHooksKey = contextKey("risor:http.request_hooks")
+
+// WithRequestHook returns a context that adds the hook to those called for
+// requests sent with it. Hooks already in the context are called first.
+func WithRequestHook(ctx context.Context, hook RequestHook) context.Context {
+	hooks := append([]RequestHook{}, GetRequestHooks(ctx)...)
+	return context.WithValue(ctx, requestHooksKey, append(hooks, hook))
+}
+
+// GetRequestHooks returns the request hooks associated with the context.
+func GetRequestHooks(ctx context.Context) []RequestHook {
+	hooks, _ := ctx.Value(requestHooksKey).([]RequestHook)
+	return hooks
+}
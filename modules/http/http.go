@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"net/http"
+	"net/url"
 
 	"github.com/risor-io/risor/object"
 )
@@ -12,7 +13,7 @@ func NewHttpRequest(ctx context.Context, args ...object.Object) object.Object {
 	if numArgs < 1 || numArgs > 2 {
 		return object.NewArgsRangeError("fetch", 1, 2, numArgs)
 	}
-	urlArg, argErr := object.AsString(args[0])
+	urlArg, argErr := urlString(args[0])
 	if argErr != nil {
 		return argErr
 	}
@@ -32,13 +33,22 @@ func NewHttpRequest(ctx context.Context, args ...object.Object) object.Object {
 	return req
 }
 
+// urlString returns the URL held by the given object, which may be a string
+// or a URL object such as those created by net.url().
+func urlString(obj object.Object) (string, *object.Error) {
+	if u, ok := obj.Interface().(*url.URL); ok {
+		return u.String(), nil
+	}
+	return object.AsString(obj)
+}
+
 func MethodCmd(method string) object.BuiltinFunction {
 	return func(ctx context.Context, args ...object.Object) object.Object {
 		numArgs := len(args)
 		if numArgs < 1 || numArgs > 3 {
 			return object.NewArgsRangeError("fetch", 1, 3, numArgs)
 		}
-		urlArg, argErr := object.AsString(args[0])
+		urlArg, argErr := urlString(args[0])
 		if argErr != nil {
 			return argErr
 		}
@@ -345,6 +345,14 @@ func (r *HttpRequest) Send(ctx context.Context) object.Object {
 		}
 	}
 	req := r.req.WithContext(ctx)
+	if hooks := GetRequestHooks(ctx); len(hooks) > 0 {
+		// Clone the request so that headers added by hooks don't stick to it
+		// if it's sent again
+		req = r.req.Clone(ctx)
+		for _, hook := range hooks {
+			hook(req)
+		}
+	}
 	if err := lim.TrackHTTPRequest(req); err != nil {
 		return object.NewError(err)
 	}
@@ -73,6 +73,14 @@ func (r *HttpResponse) GetAttr(name string) (object.Object, bool) {
 				}
 				return r.Text()
 			}), true
+	case "reader":
+		return object.NewBuiltin("http.response.reader",
+			func(ctx context.Context, args ...object.Object) object.Object {
+				if len(args) != 0 {
+					return object.NewArgsError("reader", 0, len(args))
+				}
+				return object.NewReader(r.resp.Body)
+			}), true
 	case "close":
 		return object.NewBuiltin("http.response.close",
 			func(ctx context.Context, args ...object.Object) object.Object {
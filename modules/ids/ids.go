@@ -0,0 +1,272 @@
+package ids
+
+import (
+	"context"
+	"crypto/rand"
+	"math/bits"
+	"strings"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// nanoidAlphabet is the URL-safe alphabet used for nanoids by default.
+const nanoidAlphabet = "_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+const nanoidSize = 21
+
+func UUID4(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("ids.uuid4", 0, args); err != nil {
+		return err
+	}
+	u, err := NewUUID4()
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewString(u.String())
+}
+
+func UUID7(ctx context.Context, args ...object.Object) object.Object {
+	t, errObj := arg.OptionalTime(ctx, "ids.uuid7", args)
+	if errObj != nil {
+		return errObj
+	}
+	u, err := NewUUID7(t)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewString(u.String())
+}
+
+func ULIDBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	t, errObj := arg.OptionalTime(ctx, "ids.ulid", args)
+	if errObj != nil {
+		return errObj
+	}
+	u, err := NewULID(t)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewString(u.String())
+}
+
+// NewNanoid returns a random string of the given size, drawn from the
+// characters of the alphabet.
+func NewNanoid(size int, alphabet string) (string, error) {
+	// Random bytes are masked to the smallest power of two that covers the
+	// alphabet, and values past its end are discarded, so that every
+	// character is equally likely
+	mask := byte(1<<bits.Len(uint(len(alphabet)-1)) - 1)
+	step := 1 + 8*size/5
+	result := make([]byte, 0, size)
+	buf := make([]byte, step)
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if i := int(b & mask); i < len(alphabet) {
+				result = append(result, alphabet[i])
+				if len(result) == size {
+					return string(result), nil
+				}
+			}
+		}
+	}
+}
+
+// nanoidOptions returns the size and alphabet given in an options map.
+func nanoidOptions(fn string, args []object.Object) (int, string, *object.Error) {
+	size, alphabet := nanoidSize, nanoidAlphabet
+	if len(args) == 0 {
+		return size, alphabet, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return 0, "", err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "size":
+			var n int64
+			if n, err = object.AsInt(value); err != nil {
+				return 0, "", err
+			}
+			if n < 1 || n > 1024 {
+				return 0, "", object.Errorf("value error: nanoid size must be between 1 and 1024 (got %d)", n)
+			}
+			size = int(n)
+		case "alphabet":
+			if alphabet, err = object.AsString(value); err != nil {
+				return 0, "", err
+			}
+			if len(alphabet) < 2 || len(alphabet) > 256 {
+				return 0, "", object.Errorf("value error: nanoid alphabet must have between 2 and 256 characters (got %d)", len(alphabet))
+			}
+			seen := map[byte]bool{}
+			for i := 0; i < len(alphabet); i++ {
+				if alphabet[i] >= 0x80 {
+					return 0, "", object.Errorf("value error: nanoid alphabet must be ASCII")
+				}
+				if seen[alphabet[i]] {
+					return 0, "", object.Errorf("value error: nanoid alphabet has a repeated character %q", alphabet[i])
+				}
+				seen[alphabet[i]] = true
+			}
+		default:
+			return 0, "", object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+	}
+	return size, alphabet, nil
+}
+
+func Nanoid(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("ids.nanoid", 0, 1, args); err != nil {
+		return err
+	}
+	size, alphabet, errObj := nanoidOptions("ids.nanoid", args)
+	if errObj != nil {
+		return errObj
+	}
+	id, err := NewNanoid(size, alphabet)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewString(id)
+}
+
+// Parse returns a map describing a UUID or a ULID.
+func Parse(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("ids.parse", 1, args); err != nil {
+		return err
+	}
+	s, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	if len(s) == 26 {
+		u, err := ParseULID(s)
+		if err != nil {
+			return object.NewError(err)
+		}
+		return object.NewMap(map[string]object.Object{
+			"type":   object.NewString("ulid"),
+			"string": object.NewString(u.String()),
+			"time":   object.NewTime(u.Time()),
+			"bytes":  object.NewByteSlice(u[:]),
+		})
+	}
+	u, err := ParseUUID(s)
+	if err != nil {
+		return object.Errorf("value error: invalid uuid or ulid %q", s)
+	}
+	var ts object.Object = object.Nil
+	if t, ok := u.Time(); ok {
+		ts = object.NewTime(t)
+	}
+	return object.NewMap(map[string]object.Object{
+		"type":    object.NewString("uuid"),
+		"string":  object.NewString(u.String()),
+		"version": object.NewInt(int64(u.Version())),
+		"variant": object.NewString(u.Variant()),
+		"time":    ts,
+		"bytes":   object.NewByteSlice(u[:]),
+	})
+}
+
+// Timestamp returns the time a UUID or ULID was created.
+func Timestamp(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("ids.timestamp", 1, args); err != nil {
+		return err
+	}
+	s, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	if len(s) == 26 {
+		u, err := ParseULID(s)
+		if err != nil {
+			return object.NewError(err)
+		}
+		return object.NewTime(u.Time())
+	}
+	u, err := ParseUUID(s)
+	if err != nil {
+		return object.Errorf("value error: invalid uuid or ulid %q", s)
+	}
+	t, ok := u.Time()
+	if !ok {
+		return object.Errorf("value error: version %d uuids don't hold a timestamp", u.Version())
+	}
+	return object.NewTime(t)
+}
+
+// IsValid returns true if the string is an id of the given kind: "uuid",
+// "uuid4", "uuid7", "ulid", or "nanoid". With no kind, any UUID or ULID
+// is valid.
+func IsValid(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("ids.is_valid", 1, 3, args); err != nil {
+		return err
+	}
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	kind := ""
+	if len(args) > 1 {
+		if kind, err = object.AsString(args[1]); err != nil {
+			return err
+		}
+	}
+	if len(args) == 3 && kind != "nanoid" {
+		return object.Errorf("value error: ids.is_valid() only accepts options for nanoids")
+	}
+	switch kind {
+	case "":
+		_, uuidErr := ParseUUID(s)
+		_, ulidErr := ParseULID(s)
+		return object.NewBool(uuidErr == nil || ulidErr == nil)
+	case "uuid", "uuid4", "uuid7":
+		u, err := ParseUUID(s)
+		if err != nil {
+			return object.False
+		}
+		switch kind {
+		case "uuid4":
+			return object.NewBool(u.Version() == 4 && u.Variant() == "rfc9562")
+		case "uuid7":
+			return object.NewBool(u.Version() == 7 && u.Variant() == "rfc9562")
+		}
+		return object.True
+	case "ulid":
+		_, err := ParseULID(s)
+		return object.NewBool(err == nil)
+	case "nanoid":
+		size, alphabet, errObj := nanoidOptions("ids.is_valid", args[2:])
+		if errObj != nil {
+			return errObj
+		}
+		if len(s) != size {
+			return object.False
+		}
+		for i := 0; i < len(s); i++ {
+			if strings.IndexByte(alphabet, s[i]) < 0 {
+				return object.False
+			}
+		}
+		return object.True
+	}
+	return object.Errorf("value error: unknown id type %q (expected uuid, uuid4, uuid7, ulid, or nanoid)", kind)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("ids", map[string]object.Object{
+		"is_valid":  object.NewBuiltin("is_valid", IsValid),
+		"nanoid":    object.NewBuiltin("nanoid", Nanoid),
+		"parse":     object.NewBuiltin("parse", Parse),
+		"timestamp": object.NewBuiltin("timestamp", Timestamp),
+		"ulid":      object.NewBuiltin("ulid", ULIDBuiltin),
+		"uuid4":     object.NewBuiltin("uuid4", UUID4),
+		"uuid7":     object.NewBuiltin("uuid7", UUID7),
+	})
+}
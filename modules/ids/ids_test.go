@@ -0,0 +1,121 @@
+package ids
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUUID(t *testing.T) {
+	ctx := context.Background()
+	id := UUID4(ctx).(*object.String).Value()
+	require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, id)
+	require.Equal(t, object.True, IsValid(ctx, object.NewString(id), object.NewString("uuid4")))
+	require.Equal(t, object.False, IsValid(ctx, object.NewString(id), object.NewString("uuid7")))
+
+	when := time.Date(2024, 5, 1, 12, 0, 0, 123_000_000, time.UTC)
+	id = UUID7(ctx, object.NewTime(when)).(*object.String).Value()
+	require.Equal(t, "018f3406-9e7b", id[:13])
+	require.Equal(t, object.NewTime(when), Timestamp(ctx, object.NewString(id)))
+
+	parsed := Parse(ctx, object.NewString("{"+id+"}")).(*object.Map)
+	require.Equal(t, object.NewString("uuid"), parsed.Get("type"))
+	require.Equal(t, object.NewString(id), parsed.Get("string"))
+	require.Equal(t, object.NewInt(7), parsed.Get("version"))
+	require.Equal(t, object.NewString("rfc9562"), parsed.Get("variant"))
+	require.Equal(t, object.NewTime(when), parsed.Get("time"))
+
+	// Version 1 and 6 UUIDs hold a timestamp in 100ns intervals since 1582
+	v1 := Timestamp(ctx, object.NewString("C232AB00-9414-11EC-B3C8-9F6BDECED846"))
+	require.Equal(t, object.NewTime(time.Date(2022, 2, 22, 19, 22, 22, 0, time.UTC)), v1)
+	v6 := Timestamp(ctx, object.NewString("urn:uuid:1EC9414C-232A-6B00-B3C8-9F6BDECED846"))
+	require.Equal(t, v1, v6)
+}
+
+func TestUUID7Order(t *testing.T) {
+	start := time.Now()
+	var ids []string
+	for i := 0; i < 100; i++ {
+		u, err := NewUUID7(start.Add(time.Duration(i) * time.Microsecond))
+		require.Nil(t, err)
+		ids = append(ids, u.String())
+	}
+	require.True(t, sort.StringsAreSorted(ids))
+}
+
+func TestULID(t *testing.T) {
+	ctx := context.Background()
+	// Example from the ULID specification
+	u, err := ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	require.Nil(t, err)
+	require.Equal(t, "01ARZ3NDEKTSV4RRFFQ69G5FAV", u.String())
+	require.Equal(t, int64(1469922850259), u.Time().UnixMilli())
+
+	lower, err := ParseULID("01arz3ndektsv4rrffq69g5fav")
+	require.Nil(t, err)
+	require.Equal(t, u, lower)
+
+	// ULIDs created in the same millisecond are monotonic
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var ids []string
+	for i := 0; i < 1000; i++ {
+		id := ULIDBuiltin(ctx, object.NewTime(when)).(*object.String).Value()
+		ids = append(ids, id)
+	}
+	require.True(t, sort.StringsAreSorted(ids))
+	require.Equal(t, object.NewTime(when), Timestamp(ctx, object.NewString(ids[0])))
+
+	parsed := Parse(ctx, object.NewString(ids[0])).(*object.Map)
+	require.Equal(t, object.NewString("ulid"), parsed.Get("type"))
+	require.Len(t, parsed.Get("bytes").(*object.ByteSlice).Value(), 16)
+
+	require.Equal(t, object.True, IsValid(ctx, object.NewString(ids[0]), object.NewString("ulid")))
+	require.Equal(t, object.True, IsValid(ctx, object.NewString(ids[0])))
+	// The first character of a ULID is at most 7, and I, L, O, and U are
+	// not used
+	require.Equal(t, object.False, IsValid(ctx, object.NewString("81ARZ3NDEKTSV4RRFFQ69G5FAV"), object.NewString("ulid")))
+	require.Equal(t, object.False, IsValid(ctx, object.NewString("01ARZ3NDEKTSV4RRFFQ69G5FAU"), object.NewString("ulid")))
+}
+
+func TestNanoid(t *testing.T) {
+	ctx := context.Background()
+	id := Nanoid(ctx).(*object.String).Value()
+	require.Regexp(t, `^[A-Za-z0-9_-]{21}$`, id)
+	require.Equal(t, object.True, IsValid(ctx, object.NewString(id), object.NewString("nanoid")))
+
+	opts := object.NewMap(map[string]object.Object{
+		"size":     object.NewInt(8),
+		"alphabet": object.NewString("0123456789abcdef"),
+	})
+	id = Nanoid(ctx, opts).(*object.String).Value()
+	require.Regexp(t, `^[0-9a-f]{8}$`, id)
+	require.Equal(t, object.True, IsValid(ctx, object.NewString(id), object.NewString("nanoid"), opts))
+	require.Equal(t, object.False, IsValid(ctx, object.NewString("0123456g"), object.NewString("nanoid"), opts))
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		result object.Object
+		err    string
+	}{
+		{Parse(ctx, object.NewString("not-an-id")), `value error: invalid uuid or ulid "not-an-id"`},
+		{Timestamp(ctx, object.NewString("9b2e4d0c-6f35-4c1b-9a43-2f0d8e1c7a55")), "value error: version 4 uuids don't hold a timestamp"},
+		{ULIDBuiltin(ctx, object.NewTime(time.Unix(-1, 0))), "value error: time 1969-12-31T23:59:59Z is outside the range of a ulid"},
+		{Nanoid(ctx, object.NewMap(map[string]object.Object{"alphabet": object.NewString("aba")})),
+			`value error: nanoid alphabet has a repeated character 'a'`},
+		{Nanoid(ctx, object.NewMap(map[string]object.Object{"size": object.NewInt(0)})),
+			"value error: nanoid size must be between 1 and 1024 (got 0)"},
+		{IsValid(ctx, object.NewString("x"), object.NewString("snowflake")),
+			`value error: unknown id type "snowflake" (expected uuid, uuid4, uuid7, ulid, or nanoid)`},
+	}
+	for _, tt := range tests {
+		errObj, ok := tt.result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", tt.result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+}
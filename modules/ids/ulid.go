@@ -0,0 +1,137 @@
+package ids
+
+import (
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ULID is a Universally Unique Lexicographically Sortable Identifier: a
+// 48-bit millisecond timestamp followed by 80 random bits, written as 26
+// characters of Crockford's base32.
+type ULID [16]byte
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// maxULIDTime is the largest timestamp that fits in a ULID.
+const maxULIDTime = 1<<48 - 1
+
+var crockfordValues [256]byte
+
+func init() {
+	for i := range crockfordValues {
+		crockfordValues[i] = 0xff
+	}
+	for i := 0; i < len(crockford); i++ {
+		crockfordValues[crockford[i]] = byte(i)
+		crockfordValues[crockford[i]|0x20] = byte(i)
+	}
+}
+
+// ulidEntropy makes the ULIDs generated within the same millisecond
+// increase monotonically, by incrementing the random part of the previous
+// ULID rather than drawing a new one.
+var ulidEntropy struct {
+	sync.Mutex
+	ms   uint64
+	last [10]byte
+}
+
+// NewULID returns a ULID for the given time. ULIDs created in the same
+// millisecond sort in the order they were created.
+func NewULID(t time.Time) (ULID, error) {
+	var u ULID
+	ms := uint64(t.UnixMilli())
+	if t.UnixMilli() < 0 || ms > maxULIDTime {
+		return u, fmt.Errorf("value error: time %s is outside the range of a ulid", t.Format(time.RFC3339))
+	}
+	for i := 0; i < 6; i++ {
+		u[i] = byte(ms >> (40 - 8*i))
+	}
+	ulidEntropy.Lock()
+	defer ulidEntropy.Unlock()
+	if ms == ulidEntropy.ms {
+		i := len(ulidEntropy.last) - 1
+		for ; i >= 0; i-- {
+			ulidEntropy.last[i]++
+			if ulidEntropy.last[i] != 0 {
+				break
+			}
+		}
+		if i < 0 {
+			return u, fmt.Errorf("ulid error: too many ulids generated in the same millisecond")
+		}
+	} else {
+		if _, err := rand.Read(ulidEntropy.last[:]); err != nil {
+			return u, err
+		}
+		ulidEntropy.ms = ms
+	}
+	copy(u[6:], ulidEntropy.last[:])
+	return u, nil
+}
+
+// ParseULID parses a ULID. Letters may be in either case.
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 {
+		return u, fmt.Errorf("value error: invalid ulid %q", s)
+	}
+	// The first character holds only 3 bits, so it can be at most 7
+	if crockfordValues[s[0]] > 7 {
+		return u, fmt.Errorf("value error: invalid ulid %q", s)
+	}
+	var bits uint
+	var acc uint32
+	pos := 0
+	// 26 characters hold 130 bits, so the 2 leading bits are skipped
+	for i := 0; i < len(s); i++ {
+		v := crockfordValues[s[i]]
+		if v == 0xff {
+			return u, fmt.Errorf("value error: invalid ulid %q", s)
+		}
+		acc = acc<<5 | uint32(v)
+		bits += 5
+		if i == 0 {
+			bits -= 2
+			acc &= 0x7
+		}
+		for bits >= 8 {
+			bits -= 8
+			u[pos] = byte(acc >> bits)
+			pos++
+			acc &= 1<<bits - 1
+		}
+	}
+	return u, nil
+}
+
+// Time returns the time the ULID was created, with millisecond precision.
+func (u ULID) Time() time.Time {
+	var ms int64
+	for i := 0; i < 6; i++ {
+		ms = ms<<8 | int64(u[i])
+	}
+	return time.UnixMilli(ms).UTC()
+}
+
+// String returns the ULID as 26 uppercase characters.
+func (u ULID) String() string {
+	var buf [26]byte
+	// Read the 128 bits in groups of 5, after 2 bits of leading padding
+	var acc uint32
+	var bits uint = 2
+	pos := 0
+	for _, b := range u {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			buf[pos] = crockford[acc>>bits&0x1f]
+			pos++
+		}
+		acc &= 1<<bits - 1
+	}
+	return string(buf[:])
+}
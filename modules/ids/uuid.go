@@ -0,0 +1,147 @@
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UUID is a 128-bit universally unique identifier, as described by RFC 9562.
+type UUID [16]byte
+
+// gregorianOffset is the number of 100 nanosecond intervals between the
+// start of the Gregorian calendar, used by version 1 and 6 UUIDs, and the
+// Unix epoch.
+const gregorianOffset = 122192928000000000
+
+// NewUUID4 returns a random UUID.
+func NewUUID4() (UUID, error) {
+	var u UUID
+	if _, err := rand.Read(u[:]); err != nil {
+		return u, err
+	}
+	u.setVersion(4)
+	return u, nil
+}
+
+// NewUUID7 returns a UUID that starts with the given time, so that UUIDs
+// sort in the order they were created. The 12 bits following the millisecond
+// timestamp hold the fraction of the millisecond, and the rest are random.
+func NewUUID7(t time.Time) (UUID, error) {
+	var u UUID
+	if _, err := rand.Read(u[6:]); err != nil {
+		return u, err
+	}
+	ms := uint64(t.UnixMilli())
+	for i := 0; i < 6; i++ {
+		u[i] = byte(ms >> (40 - 8*i))
+	}
+	fraction := uint64(t.Nanosecond()%int(time.Millisecond)) * 4096 / uint64(time.Millisecond)
+	u[6] = byte(fraction >> 8)
+	u[7] = byte(fraction)
+	u.setVersion(7)
+	return u, nil
+}
+
+func (u *UUID) setVersion(version byte) {
+	u[6] = u[6]&0x0f | version<<4
+	u[8] = u[8]&0x3f | 0x80
+}
+
+// ParseUUID parses a UUID in its canonical form, as 32 hex digits, or with
+// a "urn:uuid:" prefix or surrounding braces.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	text := s
+	switch {
+	case strings.HasPrefix(strings.ToLower(text), "urn:uuid:"):
+		text = text[len("urn:uuid:"):]
+	case strings.HasPrefix(text, "{") && strings.HasSuffix(text, "}"):
+		text = text[1 : len(text)-1]
+	}
+	if len(text) == 36 {
+		if text[8] != '-' || text[13] != '-' || text[18] != '-' || text[23] != '-' {
+			return u, fmt.Errorf("value error: invalid uuid %q", s)
+		}
+		text = text[:8] + text[9:13] + text[14:18] + text[19:23] + text[24:]
+	}
+	if len(text) != 32 {
+		return u, fmt.Errorf("value error: invalid uuid %q", s)
+	}
+	if _, err := hex.Decode(u[:], []byte(text)); err != nil {
+		return u, fmt.Errorf("value error: invalid uuid %q", s)
+	}
+	return u, nil
+}
+
+// Version returns the version number of the UUID.
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// Variant returns the name of the variant of the UUID, which describes its
+// layout.
+func (u UUID) Variant() string {
+	switch {
+	case u[8]&0x80 == 0:
+		return "ncs"
+	case u[8]&0xc0 == 0x80:
+		return "rfc9562"
+	case u[8]&0xe0 == 0xc0:
+		return "microsoft"
+	}
+	return "future"
+}
+
+// Time returns the time a version 1, 6, or 7 UUID was created. The second
+// result is false for other versions, which don't hold a time.
+func (u UUID) Time() (time.Time, bool) {
+	if u.Variant() != "rfc9562" {
+		return time.Time{}, false
+	}
+	switch u.Version() {
+	case 1:
+		low := uint64(u[0])<<24 | uint64(u[1])<<16 | uint64(u[2])<<8 | uint64(u[3])
+		mid := uint64(u[4])<<8 | uint64(u[5])
+		high := uint64(u[6]&0x0f)<<8 | uint64(u[7])
+		return gregorianTime(high<<48 | mid<<32 | low), true
+	case 6:
+		var ts uint64
+		for i := 0; i < 6; i++ {
+			ts = ts<<8 | uint64(u[i])
+		}
+		ts = ts<<12 | uint64(u[6]&0x0f)<<8 | uint64(u[7])
+		return gregorianTime(ts), true
+	case 7:
+		var ms int64
+		for i := 0; i < 6; i++ {
+			ms = ms<<8 | int64(u[i])
+		}
+		return time.UnixMilli(ms).UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// gregorianTime converts a count of 100 nanosecond intervals since the start
+// of the Gregorian calendar to a time.
+func gregorianTime(ts uint64) time.Time {
+	unix := int64(ts) - gregorianOffset
+	return time.Unix(unix/1e7, (unix%1e7)*100).UTC()
+}
+
+// String returns the canonical, lowercase form of the UUID.
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
@@ -0,0 +1,165 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+)
+
+var levels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// asLevel converts a level name, such as "warn", or a slog level number, to
+// a level.
+func asLevel(obj object.Object) (slog.Level, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Int:
+		return slog.Level(obj.Value()), nil
+	case *object.String:
+		level, ok := levels[strings.ToLower(obj.Value())]
+		if !ok {
+			return 0, object.Errorf("value error: invalid log level %q (expected \"debug\", \"info\", \"warn\", or \"error\")", obj.Value())
+		}
+		return level, nil
+	}
+	return 0, object.Errorf("type error: expected a log level string or int (%s given)", obj.Type())
+}
+
+// toAttrs converts a map of fields to slog attributes, sorted by key.
+func toAttrs(obj object.Object) ([]slog.Attr, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make([]slog.Attr, 0, m.Size())
+	for _, key := range m.SortedKeys() {
+		attrs = append(attrs, slog.Attr{Key: key, Value: toValue(m.Get(key))})
+	}
+	return attrs, nil
+}
+
+// toValue converts a Risor value to a slog value. Maps become groups.
+func toValue(obj object.Object) slog.Value {
+	switch obj := obj.(type) {
+	case *object.String:
+		return slog.StringValue(obj.Value())
+	case *object.Int:
+		return slog.Int64Value(obj.Value())
+	case *object.Float:
+		return slog.Float64Value(obj.Value())
+	case *object.Bool:
+		return slog.BoolValue(obj.Value())
+	case *object.Time:
+		return slog.TimeValue(obj.Value())
+	case *object.Error:
+		return slog.StringValue(obj.Value().Error())
+	case *object.NilType:
+		return slog.AnyValue(nil)
+	case *object.Map:
+		attrs, _ := toAttrs(obj)
+		return slog.GroupValue(attrs...)
+	}
+	if value := obj.Interface(); value != nil {
+		return slog.AnyValue(value)
+	}
+	return slog.StringValue(obj.Inspect())
+}
+
+// handlerOptions holds the options for creating a logger with its own
+// handler.
+type handlerOptions struct {
+	format string
+	level  slog.Level
+	output io.Writer
+}
+
+func parseHandlerOptions(ctx context.Context, args []object.Object) (handlerOptions, *object.Error) {
+	opts := handlerOptions{format: "text", level: slog.LevelInfo, output: ros.Stderr(ctx)}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "format":
+			if opts.format, err = object.AsString(value); err == nil && opts.format != "text" && opts.format != "json" {
+				err = object.Errorf("value error: log format must be \"text\" or \"json\" (got %q)", opts.format)
+			}
+		case "level":
+			opts.level, err = asLevel(value)
+		case "output":
+			opts.output, err = object.AsWriter(value)
+		default:
+			err = object.Errorf("value error: unknown log.new option %q", key)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// New creates a logger with its own handler, which writes text or JSON
+// records to a writer.
+func New(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("log.new", 0, 1, args); err != nil {
+		return err
+	}
+	opts, err := parseHandlerOptions(ctx, args)
+	if err != nil {
+		return err
+	}
+	handlerOpts := &slog.HandlerOptions{Level: opts.level}
+	if opts.format == "json" {
+		return NewLogger(slog.NewJSONHandler(opts.output, handlerOpts))
+	}
+	return NewLogger(slog.NewTextHandler(opts.output, handlerOpts))
+}
+
+// Module returns the log module. Its functions write to the handler given to
+// the VM with the log handler option, or to the default slog handler.
+func Module() *object.Module {
+	l := NewLogger(nil)
+	funcs := map[string]object.Object{
+		"new": object.NewBuiltin("new", New),
+		"log": object.NewBuiltin("log", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("log.log", 2, 3, args); err != nil {
+				return err
+			}
+			level, err := asLevel(args[0])
+			if err != nil {
+				return err
+			}
+			return l.logBuiltin(ctx, "log.log", level, args[1:])
+		}),
+		"with": object.NewBuiltin("with", func(ctx context.Context, args ...object.Object) object.Object {
+			return l.withBuiltin("log.with", args)
+		}),
+		"group": object.NewBuiltin("group", func(ctx context.Context, args ...object.Object) object.Object {
+			return l.groupBuiltin("log.group", args)
+		}),
+		"enabled": object.NewBuiltin("enabled", func(ctx context.Context, args ...object.Object) object.Object {
+			return l.enabledBuiltin(ctx, "log.enabled", args)
+		}),
+	}
+	for name, level := range levels {
+		name, level := name, level
+		funcs[name] = object.NewBuiltin(name, func(ctx context.Context, args ...object.Object) object.Object {
+			return l.logBuiltin(ctx, fmt.Sprintf("log.%s", name), level, args)
+		})
+	}
+	return object.NewBuiltinsModule("log", funcs)
+}
@@ -0,0 +1,125 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+// setup returns a context whose log handler writes JSON records, without
+// times, to the returned buffer.
+func setup(level slog.Level) (context.Context, *bytes.Buffer) {
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	return object.WithLogHandler(context.Background(), h), &buf
+}
+
+func call(t *testing.T, ctx context.Context, obj object.Object, name string, args ...object.Object) object.Object {
+	t.Helper()
+	attr, ok := obj.GetAttr(name)
+	require.True(t, ok, name)
+	result := attr.(*object.Builtin).Call(ctx, args...)
+	if errObj, ok := result.(*object.Error); ok {
+		t.Fatalf("%s: %s", name, errObj.Value())
+	}
+	return result
+}
+
+func records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		require.Nil(t, json.Unmarshal([]byte(line), &record))
+		result = append(result, record)
+	}
+	return result
+}
+
+func TestLevels(t *testing.T) {
+	ctx, buf := setup(slog.LevelInfo)
+	mod := Module()
+	call(t, ctx, mod, "debug", object.NewString("hidden"))
+	call(t, ctx, mod, "info", object.NewString("started"), object.NewMap(map[string]object.Object{
+		"job":     object.NewString("sync"),
+		"workers": object.NewInt(4),
+	}))
+	call(t, ctx, mod, "log", object.NewString("error"), object.NewString("failed"))
+	require.Equal(t, []map[string]any{
+		{"level": "INFO", "msg": "started", "job": "sync", "workers": float64(4)},
+		{"level": "ERROR", "msg": "failed"},
+	}, records(t, buf))
+
+	require.Equal(t, object.False, call(t, ctx, mod, "enabled", object.NewString("debug")))
+	require.Equal(t, object.True, call(t, ctx, mod, "enabled", object.NewString("WARN")))
+
+	attr, _ := mod.GetAttr("log")
+	result := attr.(*object.Builtin).Call(ctx, object.NewString("loud"), object.NewString("x"))
+	require.Equal(t, object.Errorf(`value error: invalid log level "loud" (expected "debug", "info", "warn", or "error")`), result)
+}
+
+func TestChildLoggers(t *testing.T) {
+	ctx, buf := setup(slog.LevelDebug)
+	mod := Module()
+	child := call(t, ctx, mod, "with", object.NewMap(map[string]object.Object{
+		"request_id": object.NewString("abc"),
+	}))
+	require.Equal(t, "log.logger(request_id=abc)", child.Inspect())
+	call(t, ctx, child, "debug", object.NewString("fetching"))
+
+	db := call(t, ctx, child, "group", object.NewString("db"))
+	db = call(t, ctx, db, "with", object.NewMap(map[string]object.Object{
+		"table": object.NewString("users"),
+	}))
+	require.Equal(t, "log.logger(request_id=abc db.table=users)", db.Inspect())
+	call(t, ctx, db, "warn", object.NewString("slow query"), object.NewMap(map[string]object.Object{
+		"elapsed": object.NewFloat(1.5),
+		"params":  object.NewMap(map[string]object.Object{"id": object.NewInt(7)}),
+	}))
+	require.Equal(t, []map[string]any{
+		{"level": "DEBUG", "msg": "fetching", "request_id": "abc"},
+		{"level": "WARN", "msg": "slow query", "request_id": "abc", "db": map[string]any{
+			"table":   "users",
+			"elapsed": 1.5,
+			"params":  map[string]any{"id": float64(7)},
+		}},
+	}, records(t, buf))
+}
+
+func TestNew(t *testing.T) {
+	buf := object.NewBuffer(nil)
+	logger := New(context.Background(), object.NewMap(map[string]object.Object{
+		"format": object.NewString("text"),
+		"level":  object.NewString("warn"),
+		"output": buf,
+	}))
+	require.IsType(t, &Logger{}, logger)
+	// The logger's own handler is used, rather than the one in the context
+	ctx, ctxBuf := setup(slog.LevelDebug)
+	call(t, ctx, logger, "info", object.NewString("ignored"))
+	call(t, ctx, logger, "error", object.NewString("disk full"), object.NewMap(map[string]object.Object{
+		"at": object.NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)),
+	}))
+	require.Empty(t, ctxBuf.String())
+	require.Contains(t, string(buf.Value().Bytes()), `level=ERROR msg="disk full" at=2024-05-01T12:00:00.000Z`)
+
+	result := New(context.Background(), object.NewMap(map[string]object.Object{
+		"format": object.NewString("xml"),
+	}))
+	require.Equal(t, object.Errorf(`value error: log format must be "text" or "json" (got "xml")`), result)
+}
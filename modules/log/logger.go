@@ -0,0 +1,218 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+)
+
+const LOGGER object.Type = "log.logger"
+
+// Logger writes structured log records. A Logger without a handler of its
+// own writes to the handler associated with the context of each call, or to
+// the standard error of the context, or else to the default slog handler.
+type Logger struct {
+	handler slog.Handler
+	steps   []step
+}
+
+// step adds either fields or a group to a logger. Steps are applied to the
+// handler in order, so fields added after a group belong to it.
+type step struct {
+	group  string
+	fields []slog.Attr
+}
+
+func NewLogger(handler slog.Handler) *Logger {
+	return &Logger{handler: handler}
+}
+
+// Handler returns the handler that records written in the given context are
+// sent to, including the logger's fields and groups.
+func (l *Logger) Handler(ctx context.Context) slog.Handler {
+	h := l.handler
+	if h == nil {
+		var ok bool
+		if h, ok = object.GetLogHandler(ctx); ok {
+			// Use the handler of the context
+		} else if stderr, ok := ros.GetStderrWriter(ctx); ok {
+			h = slog.NewTextHandler(stderr, nil)
+		} else {
+			h = slog.Default().Handler()
+		}
+	}
+	for _, s := range l.steps {
+		if s.group != "" {
+			h = h.WithGroup(s.group)
+		} else {
+			h = h.WithAttrs(s.fields)
+		}
+	}
+	return h
+}
+
+// Log writes a record with the given level, message, and fields.
+func (l *Logger) Log(ctx context.Context, level slog.Level, msg string, fields []slog.Attr) {
+	slog.New(l.Handler(ctx)).LogAttrs(ctx, level, msg, fields...)
+}
+
+func (l *Logger) with(s step) *Logger {
+	steps := make([]step, 0, len(l.steps)+1)
+	return &Logger{handler: l.handler, steps: append(append(steps, l.steps...), s)}
+}
+
+// With returns a child logger that adds the given fields to every record.
+func (l *Logger) With(fields []slog.Attr) *Logger {
+	return l.with(step{fields: fields})
+}
+
+// WithGroup returns a child logger that nests the fields of every record,
+// and fields added to the child later, in a group with the given name.
+func (l *Logger) WithGroup(name string) *Logger {
+	return l.with(step{group: name})
+}
+
+func (l *Logger) Type() object.Type {
+	return LOGGER
+}
+
+func (l *Logger) Inspect() string {
+	var prefix string
+	var fields []string
+	for _, s := range l.steps {
+		if s.group != "" {
+			prefix += s.group + "."
+			continue
+		}
+		for _, field := range s.fields {
+			fields = append(fields, prefix+field.String())
+		}
+	}
+	return fmt.Sprintf("log.logger(%s)", strings.Join(fields, " "))
+}
+
+func (l *Logger) String() string {
+	return l.Inspect()
+}
+
+func (l *Logger) Interface() interface{} {
+	return nil
+}
+
+func (l *Logger) Equals(other object.Object) object.Object {
+	return object.NewBool(l == other)
+}
+
+func (l *Logger) IsTruthy() bool {
+	return true
+}
+
+func (l *Logger) Cost() int {
+	return 0
+}
+
+func (l *Logger) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", LOGGER)
+}
+
+func (l *Logger) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", LOGGER, opType)
+}
+
+func (l *Logger) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", LOGGER, name)
+}
+
+func (l *Logger) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "debug", "info", "warn", "error":
+		level := levels[name]
+		return object.NewBuiltin("log.logger."+name, func(ctx context.Context, args ...object.Object) object.Object {
+			return l.logBuiltin(ctx, "log.logger."+name, level, args)
+		}), true
+	case "log":
+		return object.NewBuiltin("log.logger.log", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("log.logger.log", 2, 3, args); err != nil {
+				return err
+			}
+			level, err := asLevel(args[0])
+			if err != nil {
+				return err
+			}
+			return l.logBuiltin(ctx, "log.logger.log", level, args[1:])
+		}), true
+	case "with":
+		return object.NewBuiltin("log.logger.with", func(ctx context.Context, args ...object.Object) object.Object {
+			return l.withBuiltin("log.logger.with", args)
+		}), true
+	case "group":
+		return object.NewBuiltin("log.logger.group", func(ctx context.Context, args ...object.Object) object.Object {
+			return l.groupBuiltin("log.logger.group", args)
+		}), true
+	case "enabled":
+		return object.NewBuiltin("log.logger.enabled", func(ctx context.Context, args ...object.Object) object.Object {
+			return l.enabledBuiltin(ctx, "log.logger.enabled", args)
+		}), true
+	}
+	return nil, false
+}
+
+func (l *Logger) logBuiltin(ctx context.Context, name string, level slog.Level, args []object.Object) object.Object {
+	if err := arg.RequireRange(name, 1, 2, args); err != nil {
+		return err
+	}
+	msg, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	var fields []slog.Attr
+	if len(args) > 1 {
+		if fields, err = toAttrs(args[1]); err != nil {
+			return err
+		}
+	}
+	l.Log(ctx, level, msg, fields)
+	return object.Nil
+}
+
+func (l *Logger) withBuiltin(name string, args []object.Object) object.Object {
+	if err := arg.Require(name, 1, args); err != nil {
+		return err
+	}
+	fields, err := toAttrs(args[0])
+	if err != nil {
+		return err
+	}
+	return l.With(fields)
+}
+
+func (l *Logger) groupBuiltin(name string, args []object.Object) object.Object {
+	if err := arg.Require(name, 1, args); err != nil {
+		return err
+	}
+	group, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	if group == "" {
+		return object.Errorf("value error: %s() group name must not be empty", name)
+	}
+	return l.WithGroup(group)
+}
+
+func (l *Logger) enabledBuiltin(ctx context.Context, name string, args []object.Object) object.Object {
+	if err := arg.Require(name, 1, args); err != nil {
+		return err
+	}
+	level, err := asLevel(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewBool(l.Handler(ctx).Enabled(ctx, level))
+}
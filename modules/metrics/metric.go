@@ -0,0 +1,244 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const (
+	COUNTER   object.Type = "metrics.counter"
+	GAUGE     object.Type = "metrics.gauge"
+	HISTOGRAM object.Type = "metrics.histogram"
+)
+
+// Metric is a counter, gauge, or histogram, with or without labels.
+type Metric struct {
+	typ    object.Type
+	name   string
+	labels []string
+	vec    *prometheus.MetricVec
+}
+
+func (m *Metric) Type() object.Type {
+	return m.typ
+}
+
+func (m *Metric) Inspect() string {
+	if len(m.labels) == 0 {
+		return fmt.Sprintf("%s(%q)", m.typ, m.name)
+	}
+	return fmt.Sprintf("%s(%q, labels=[%s])", m.typ, m.name, strings.Join(m.labels, ", "))
+}
+
+func (m *Metric) Interface() interface{} {
+	return nil
+}
+
+func (m *Metric) Equals(other object.Object) object.Object {
+	o, ok := other.(*Metric)
+	return object.NewBool(ok && o.vec == m.vec)
+}
+
+func (m *Metric) IsTruthy() bool {
+	return true
+}
+
+func (m *Metric) Cost() int {
+	return 0
+}
+
+func (m *Metric) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", m.typ)
+}
+
+func (m *Metric) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", m.typ, opType)
+}
+
+func (m *Metric) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", m.typ, name)
+}
+
+// child returns the metric for the label values in the optional trailing
+// labels map.
+func (m *Metric) child(args []object.Object) (prometheus.Metric, *object.Error) {
+	values := map[string]string{}
+	if len(args) > 0 {
+		labels, err := object.AsMap(args[0])
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range labels.Value() {
+			if s, ok := value.(*object.String); ok {
+				values[key] = s.Value()
+			} else {
+				values[key] = value.Inspect()
+			}
+		}
+	}
+	child, err := m.vec.GetMetricWith(values)
+	if err != nil {
+		return nil, object.Errorf("value error: %s: %s", m.name, err)
+	}
+	return child, nil
+}
+
+// method returns a builtin that takes the given number of arguments, followed
+// by an optional labels map, and applies fn to the labelled metric.
+func (m *Metric) method(name string, nargs int, fn func(ctx context.Context, child prometheus.Metric, args []object.Object) object.Object) *object.Builtin {
+	fullName := fmt.Sprintf("%s.%s", m.typ, name)
+	return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+		if err := arg.RequireRange(fullName, nargs, nargs+1, args); err != nil {
+			return err
+		}
+		child, err := m.child(args[nargs:])
+		if err != nil {
+			return err
+		}
+		return fn(ctx, child, args[:nargs])
+	})
+}
+
+func (m *Metric) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "name":
+		return object.NewString(m.name), true
+	case "labels":
+		return object.NewStringList(m.labels), true
+	case "get":
+		return m.method(name, 0, func(ctx context.Context, child prometheus.Metric, args []object.Object) object.Object {
+			return m.value(child)
+		}), true
+	}
+	switch m.typ {
+	case COUNTER:
+		return m.counterAttr(name)
+	case GAUGE:
+		return m.gaugeAttr(name)
+	case HISTOGRAM:
+		return m.histogramAttr(name)
+	}
+	return nil, false
+}
+
+func (m *Metric) counterAttr(name string) (object.Object, bool) {
+	switch name {
+	case "inc":
+		return m.method(name, 0, func(ctx context.Context, child prometheus.Metric, args []object.Object) object.Object {
+			child.(prometheus.Counter).Inc()
+			return object.Nil
+		}), true
+	case "add":
+		return m.method(name, 1, func(ctx context.Context, child prometheus.Metric, args []object.Object) object.Object {
+			value, err := object.AsFloat(args[0])
+			if err != nil {
+				return err
+			}
+			if value < 0 {
+				return object.Errorf("value error: %s.add() value must not be negative (got %g)", COUNTER, value)
+			}
+			child.(prometheus.Counter).Add(value)
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+func (m *Metric) gaugeAttr(name string) (object.Object, bool) {
+	switch name {
+	case "inc", "dec", "set_to_current_time":
+		return m.method(name, 0, func(ctx context.Context, child prometheus.Metric, args []object.Object) object.Object {
+			gauge := child.(prometheus.Gauge)
+			switch name {
+			case "inc":
+				gauge.Inc()
+			case "dec":
+				gauge.Dec()
+			default:
+				gauge.SetToCurrentTime()
+			}
+			return object.Nil
+		}), true
+	case "set", "add", "sub":
+		return m.method(name, 1, func(ctx context.Context, child prometheus.Metric, args []object.Object) object.Object {
+			value, err := object.AsFloat(args[0])
+			if err != nil {
+				return err
+			}
+			gauge := child.(prometheus.Gauge)
+			switch name {
+			case "set":
+				gauge.Set(value)
+			case "add":
+				gauge.Add(value)
+			default:
+				gauge.Sub(value)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+func (m *Metric) histogramAttr(name string) (object.Object, bool) {
+	switch name {
+	case "observe":
+		return m.method(name, 1, func(ctx context.Context, child prometheus.Metric, args []object.Object) object.Object {
+			value, err := object.AsFloat(args[0])
+			if err != nil {
+				return err
+			}
+			child.(prometheus.Histogram).Observe(value)
+			return object.Nil
+		}), true
+	case "time":
+		return m.method(name, 1, func(ctx context.Context, child prometheus.Metric, args []object.Object) object.Object {
+			start := time.Now()
+			result, err := object.Call(ctx, args[0], nil)
+			child.(prometheus.Histogram).Observe(time.Since(start).Seconds())
+			if err != nil {
+				return object.NewError(err)
+			}
+			return result
+		}), true
+	}
+	return nil, false
+}
+
+// value returns the current value of a counter or gauge, or the count, sum,
+// and cumulative bucket counts of a histogram.
+func (m *Metric) value(child prometheus.Metric) object.Object {
+	var metric dto.Metric
+	if err := child.Write(&metric); err != nil {
+		return object.NewError(err)
+	}
+	switch m.typ {
+	case COUNTER:
+		return object.NewFloat(metric.GetCounter().GetValue())
+	case GAUGE:
+		return object.NewFloat(metric.GetGauge().GetValue())
+	}
+	h := metric.GetHistogram()
+	buckets := h.GetBucket()
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].GetUpperBound() < buckets[j].GetUpperBound()
+	})
+	counts := object.NewMap(map[string]object.Object{})
+	for _, b := range buckets {
+		counts.Set(fmt.Sprintf("%g", b.GetUpperBound()), object.NewInt(int64(b.GetCumulativeCount())))
+	}
+	return object.NewMap(map[string]object.Object{
+		"count":   object.NewInt(int64(h.GetSampleCount())),
+		"sum":     object.NewFloat(h.GetSampleSum()),
+		"buckets": counts,
+	})
+}
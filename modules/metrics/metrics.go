@@ -0,0 +1,357 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/prometheus/common/expfmt"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// metricOptions holds the options for creating a metric.
+type metricOptions struct {
+	help        string
+	namespace   string
+	subsystem   string
+	labels      []string
+	constLabels prometheus.Labels
+	buckets     []float64
+}
+
+func parseMetricOptions(fn string, args []object.Object, allowed ...string) (metricOptions, *object.Error) {
+	var opts metricOptions
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch {
+		case key == "help":
+			opts.help, err = object.AsString(value)
+		case key == "namespace":
+			opts.namespace, err = object.AsString(value)
+		case key == "subsystem":
+			opts.subsystem, err = object.AsString(value)
+		case key == "labels":
+			opts.labels, err = object.AsStringSlice(value)
+		case key == "const_labels":
+			var labels *object.Map
+			if labels, err = object.AsMap(value); err != nil {
+				break
+			}
+			opts.constLabels = prometheus.Labels{}
+			for name, value := range labels.Value() {
+				if opts.constLabels[name], err = object.AsString(value); err != nil {
+					break
+				}
+			}
+		case key == "buckets" && contains(allowed, key):
+			opts.buckets, err = asFloats(value)
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func asFloats(obj object.Object) ([]float64, *object.Error) {
+	list, err := object.AsList(obj)
+	if err != nil {
+		return nil, err
+	}
+	var floats []float64
+	for _, item := range list.Value() {
+		f, err := object.AsFloat(item)
+		if err != nil {
+			return nil, err
+		}
+		floats = append(floats, f)
+	}
+	return floats, nil
+}
+
+// registry holds the metrics created by a module.
+type registry struct {
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
+}
+
+// register registers a collector, or returns the collector that was already
+// registered with the same name and labels. This lets a script that runs
+// more than once in the same process define its metrics each time.
+func (r *registry) register(c prometheus.Collector) (prometheus.Collector, *object.Error) {
+	if err := r.registerer.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector, nil
+		}
+		return nil, object.NewError(fmt.Errorf("metrics error: %w", err))
+	}
+	return c, nil
+}
+
+func (r *registry) newMetric(typ object.Type, args []object.Object) object.Object {
+	fn := string(typ)
+	if err := arg.RequireRange(fn, 1, 2, args); err != nil {
+		return err
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	var allowed []string
+	if typ == HISTOGRAM {
+		allowed = append(allowed, "buckets")
+	}
+	opts, err := parseMetricOptions(fn, args[1:], allowed...)
+	if err != nil {
+		return err
+	}
+	if opts.help == "" {
+		opts.help = name
+	}
+	var collector prometheus.Collector
+	switch typ {
+	case COUNTER:
+		collector = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.namespace,
+			Subsystem:   opts.subsystem,
+			Name:        name,
+			Help:        opts.help,
+			ConstLabels: opts.constLabels,
+		}, opts.labels)
+	case GAUGE:
+		collector = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   opts.namespace,
+			Subsystem:   opts.subsystem,
+			Name:        name,
+			Help:        opts.help,
+			ConstLabels: opts.constLabels,
+		}, opts.labels)
+	case HISTOGRAM:
+		collector = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.namespace,
+			Subsystem:   opts.subsystem,
+			Name:        name,
+			Help:        opts.help,
+			ConstLabels: opts.constLabels,
+			Buckets:     opts.buckets,
+		}, opts.labels)
+	}
+	registered, err := r.register(collector)
+	if err != nil {
+		return err
+	}
+	metric := &Metric{
+		typ:    typ,
+		name:   prometheus.BuildFQName(opts.namespace, opts.subsystem, name),
+		labels: opts.labels,
+	}
+	switch c := registered.(type) {
+	case *prometheus.CounterVec:
+		metric.vec = c.MetricVec
+	case *prometheus.GaugeVec:
+		metric.vec = c.MetricVec
+	case *prometheus.HistogramVec:
+		metric.vec = c.MetricVec
+	}
+	if typeOf(registered) != typ {
+		return object.Errorf("value error: metric %q is already registered as another type", metric.name)
+	}
+	return metric
+}
+
+func typeOf(c prometheus.Collector) object.Type {
+	switch c.(type) {
+	case *prometheus.CounterVec:
+		return COUNTER
+	case *prometheus.GaugeVec:
+		return GAUGE
+	case *prometheus.HistogramVec:
+		return HISTOGRAM
+	}
+	return ""
+}
+
+func (r *registry) text(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("metrics.text", 0, args); err != nil {
+		return err
+	}
+	families, err := r.gatherer.Gather()
+	if err != nil {
+		return object.NewError(fmt.Errorf("metrics error: %w", err))
+	}
+	var buf bytes.Buffer
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&buf, family); err != nil {
+			return object.NewError(fmt.Errorf("metrics error: %w", err))
+		}
+	}
+	return object.NewString(buf.String())
+}
+
+func (r *registry) push(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("metrics.push", 2, 3, args); err != nil {
+		return err
+	}
+	url, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	job, err := object.AsString(args[1])
+	if err != nil {
+		return err
+	}
+	pusher := push.New(url, job).Gatherer(r.gatherer)
+	var add bool
+	if len(args) > 2 {
+		opts, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		var username, password string
+		for key, value := range opts.Value() {
+			switch key {
+			case "grouping":
+				var grouping *object.Map
+				if grouping, err = object.AsMap(value); err != nil {
+					break
+				}
+				for name, value := range grouping.Value() {
+					var s string
+					if s, err = object.AsString(value); err != nil {
+						break
+					}
+					pusher = pusher.Grouping(name, s)
+				}
+			case "username":
+				username, err = object.AsString(value)
+			case "password":
+				password, err = object.AsString(value)
+			case "add":
+				add, err = object.AsBool(value)
+			default:
+				err = object.Errorf("value error: unknown metrics.push option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if username != "" || password != "" {
+			pusher = pusher.BasicAuth(username, password)
+		}
+	}
+	var pushErr error
+	if add {
+		pushErr = pusher.AddContext(ctx)
+	} else {
+		pushErr = pusher.PushContext(ctx)
+	}
+	if pushErr != nil {
+		return object.NewError(fmt.Errorf("metrics error: %w", pushErr))
+	}
+	return object.Nil
+}
+
+func LinearBuckets(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("metrics.linear_buckets", 3, args); err != nil {
+		return err
+	}
+	start, width, count, err := bucketArgs(args)
+	if err != nil {
+		return err
+	}
+	if width <= 0 {
+		return object.Errorf("value error: metrics.linear_buckets() width must be greater than 0 (got %g)", width)
+	}
+	return floatList(prometheus.LinearBuckets(start, width, count))
+}
+
+func ExponentialBuckets(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("metrics.exponential_buckets", 3, args); err != nil {
+		return err
+	}
+	start, factor, count, err := bucketArgs(args)
+	if err != nil {
+		return err
+	}
+	if start <= 0 || factor <= 1 {
+		return object.Errorf("value error: metrics.exponential_buckets() start must be greater than 0 and factor greater than 1")
+	}
+	return floatList(prometheus.ExponentialBuckets(start, factor, count))
+}
+
+func bucketArgs(args []object.Object) (float64, float64, int, *object.Error) {
+	start, err := object.AsFloat(args[0])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	step, err := object.AsFloat(args[1])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	count, err := object.AsInt(args[2])
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if count < 1 || count > 1000 {
+		return 0, 0, 0, object.Errorf("value error: bucket count must be between 1 and 1000 (got %d)", count)
+	}
+	return start, step, int(count), nil
+}
+
+func floatList(values []float64) *object.List {
+	items := make([]object.Object, 0, len(values))
+	for _, v := range values {
+		items = append(items, object.NewFloat(v))
+	}
+	return object.NewList(items)
+}
+
+// Module returns a metrics module that registers metrics with the default
+// Prometheus registry, which is what promhttp.Handler serves.
+func Module() *object.Module {
+	return NewModule(prometheus.DefaultRegisterer, prometheus.DefaultGatherer)
+}
+
+// NewModule returns a metrics module that registers metrics with the given
+// registerer, and gathers them from the given gatherer for metrics.text and
+// metrics.push. A *prometheus.Registry may be given for both.
+func NewModule(registerer prometheus.Registerer, gatherer prometheus.Gatherer) *object.Module {
+	r := &registry{registerer: registerer, gatherer: gatherer}
+	return object.NewBuiltinsModule("metrics", map[string]object.Object{
+		"counter": object.NewBuiltin("counter", func(ctx context.Context, args ...object.Object) object.Object {
+			return r.newMetric(COUNTER, args)
+		}),
+		"exponential_buckets": object.NewBuiltin("exponential_buckets", ExponentialBuckets),
+		"gauge": object.NewBuiltin("gauge", func(ctx context.Context, args ...object.Object) object.Object {
+			return r.newMetric(GAUGE, args)
+		}),
+		"histogram": object.NewBuiltin("histogram", func(ctx context.Context, args ...object.Object) object.Object {
+			return r.newMetric(HISTOGRAM, args)
+		}),
+		"linear_buckets": object.NewBuiltin("linear_buckets", LinearBuckets),
+		"push":           object.NewBuiltin("push", r.push),
+		"text":           object.NewBuiltin("text", r.text),
+	})
+}
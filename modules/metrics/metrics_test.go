@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func call(t *testing.T, obj object.Object, name string, args ...object.Object) object.Object {
+	t.Helper()
+	attr, ok := obj.GetAttr(name)
+	require.True(t, ok, name)
+	result := attr.(*object.Builtin).Call(context.Background(), args...)
+	if errObj, ok := result.(*object.Error); ok {
+		t.Fatalf("%s: %s", name, errObj.Value())
+	}
+	return result
+}
+
+func labels(values map[string]string) *object.Map {
+	m := map[string]object.Object{}
+	for k, v := range values {
+		m[k] = object.NewString(v)
+	}
+	return object.NewMap(m)
+}
+
+func TestCounter(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	mod := NewModule(reg, reg)
+	counter := call(t, mod, "counter", object.NewString("requests_total"), object.NewMap(map[string]object.Object{
+		"help":      object.NewString("Requests handled"),
+		"namespace": object.NewString("app"),
+		"labels":    object.NewStringList([]string{"method"}),
+	}))
+	require.Equal(t, `metrics.counter("app_requests_total", labels=[method])`, counter.Inspect())
+	get := labels(map[string]string{"method": "GET"})
+	call(t, counter, "inc", get)
+	call(t, counter, "add", object.NewInt(2), get)
+	require.Equal(t, object.NewFloat(3), call(t, counter, "get", get))
+
+	text := call(t, mod, "text").(*object.String).Value()
+	require.Contains(t, text, "# HELP app_requests_total Requests handled\n")
+	require.Contains(t, text, `app_requests_total{method="GET"} 3`)
+
+	// Labels must match those the counter was defined with
+	inc, _ := counter.GetAttr("inc")
+	result := inc.(*object.Builtin).Call(context.Background())
+	require.IsType(t, &object.Error{}, result)
+
+	add, _ := counter.GetAttr("add")
+	require.Equal(t,
+		object.Errorf("value error: metrics.counter.add() value must not be negative (got -1)"),
+		add.(*object.Builtin).Call(context.Background(), object.NewInt(-1), get))
+}
+
+func TestRedefine(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	mod := NewModule(reg, reg)
+	first := call(t, mod, "gauge", object.NewString("queue_depth"))
+	call(t, first, "set", object.NewInt(7))
+	// Defining the same metric again returns the one already registered
+	second := call(t, mod, "gauge", object.NewString("queue_depth"))
+	require.Equal(t, object.NewFloat(7), call(t, second, "get"))
+	require.Equal(t, object.True, first.Equals(second))
+
+	counter, _ := mod.GetAttr("counter")
+	result := counter.(*object.Builtin).Call(context.Background(), object.NewString("queue_depth"))
+	require.Equal(t, object.Errorf(`value error: metric "queue_depth" is already registered as another type`), result)
+}
+
+func TestGauge(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gauge := call(t, NewModule(reg, reg), "gauge", object.NewString("workers"))
+	call(t, gauge, "set", object.NewInt(5))
+	call(t, gauge, "inc")
+	call(t, gauge, "sub", object.NewFloat(2.5))
+	call(t, gauge, "dec")
+	require.Equal(t, object.NewFloat(2.5), call(t, gauge, "get"))
+}
+
+func TestHistogram(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	mod := NewModule(reg, reg)
+	buckets := call(t, mod, "linear_buckets", object.NewInt(1), object.NewInt(1), object.NewInt(3))
+	require.Equal(t, "[1, 2, 3]", buckets.Inspect())
+	hist := call(t, mod, "histogram", object.NewString("size"), object.NewMap(map[string]object.Object{
+		"buckets": buckets,
+	}))
+	for _, v := range []float64{0.5, 1.5, 2.5, 10} {
+		call(t, hist, "observe", object.NewFloat(v))
+	}
+	fn := object.NewBuiltin("fn", func(ctx context.Context, args ...object.Object) object.Object {
+		return object.NewString("done")
+	})
+	require.Equal(t, object.NewString("done"), call(t, hist, "time", fn))
+	value := call(t, hist, "get").(*object.Map)
+	require.Equal(t, object.NewInt(5), value.Get("count"))
+	require.Equal(t, `{"1": 2, "2": 3, "3": 4}`, value.Get("buckets").Inspect())
+
+	exp := call(t, mod, "exponential_buckets", object.NewFloat(0.1), object.NewInt(10), object.NewInt(3))
+	require.Equal(t, "[0.1, 1, 10]", exp.Inspect())
+}
+
+func TestPush(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.Method + " " + r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	mod := NewModule(reg, reg)
+	counter := call(t, mod, "counter", object.NewString("jobs_total"))
+	call(t, counter, "inc")
+	call(t, mod, "push", object.NewString(server.URL), object.NewString("nightly"), object.NewMap(map[string]object.Object{
+		"grouping": labels(map[string]string{"instance": "db1"}),
+	}))
+	require.Equal(t, "PUT /metrics/job/nightly/instance/db1", path)
+	require.True(t, strings.Contains(body, "jobs_total"))
+}
@@ -0,0 +1,291 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const CLIENT object.Type = "mqtt.client"
+
+const defaultBufferSize = 100
+
+// Client is a connection to an MQTT broker.
+type Client struct {
+	broker  string
+	client  paho.Client
+	timeout time.Duration
+	once    sync.Once
+	closed  chan bool
+	mutex   sync.Mutex
+	subs    map[string]*subscription
+}
+
+// subscription delivers the messages received on a topic filter to a stream.
+type subscription struct {
+	messages chan paho.Message
+	done     chan bool
+	once     sync.Once
+}
+
+func (s *subscription) stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+func (c *Client) Type() object.Type {
+	return CLIENT
+}
+
+func (c *Client) Inspect() string {
+	return fmt.Sprintf("mqtt.client(%q)", c.broker)
+}
+
+func (c *Client) Interface() interface{} {
+	return c.client
+}
+
+func (c *Client) IsTruthy() bool {
+	return true
+}
+
+func (c *Client) Cost() int {
+	return 8
+}
+
+func (c *Client) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", CLIENT)
+}
+
+func (c *Client) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", CLIENT, opType)
+}
+
+func (c *Client) Equals(other object.Object) object.Object {
+	if c == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (c *Client) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", CLIENT, name)
+}
+
+func (c *Client) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "broker":
+		return object.NewString(c.broker), true
+	case "publish":
+		return object.NewBuiltin("mqtt.client.publish", c.Publish), true
+	case "subscribe":
+		return object.NewBuiltin("mqtt.client.subscribe", c.Subscribe), true
+	case "unsubscribe":
+		return object.NewBuiltin("mqtt.client.unsubscribe", c.Unsubscribe), true
+	case "is_connected":
+		return object.NewBuiltin("mqtt.client.is_connected", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("mqtt.client.is_connected", 0, args); err != nil {
+				return err
+			}
+			return object.NewBool(c.client.IsConnected())
+		}), true
+	case "disconnect", "close":
+		return object.NewBuiltin("mqtt.client."+name, func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("mqtt.client."+name, 0, args); err != nil {
+				return err
+			}
+			c.Close()
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// Publish sends a message. The payload may be a string, byte_slice, or any
+// value that can be encoded as JSON.
+func (c *Client) Publish(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("mqtt.client.publish", 2, 3, args); err != nil {
+		return err
+	}
+	topic, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	payload, err := asPayload(args[1])
+	if err != nil {
+		return err
+	}
+	msg := &Message{Topic: topic, Payload: payload}
+	if len(args) == 3 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		if err := parsePublishOptions(m, msg); err != nil {
+			return err
+		}
+	}
+	token := c.client.Publish(msg.Topic, msg.QoS, msg.Retain, msg.Payload)
+	if err := c.wait(token); err != nil {
+		return object.NewError(err)
+	}
+	return object.Nil
+}
+
+// Subscribe subscribes to a topic filter and returns a stream of the
+// messages received on it. The stream ends when the topic is unsubscribed,
+// the client is closed, or no message arrives within the optional timeout.
+func (c *Client) Subscribe(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("mqtt.client.subscribe", 1, 2, args); err != nil {
+		return err
+	}
+	topic, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	var qos byte
+	var idleTimeout time.Duration
+	bufferSize := defaultBufferSize
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "qos":
+				qos, err = asQoS(value)
+			case "timeout":
+				idleTimeout, err = arg.Duration(key, value)
+			case "buffer":
+				var n int64
+				n, err = object.AsInt(value)
+				if err == nil && n < 0 {
+					err = object.Errorf("value error: buffer must be non-negative (got %d)", n)
+				}
+				bufferSize = int(n)
+			default:
+				err = object.Errorf("value error: unknown subscribe option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	sub := &subscription{
+		messages: make(chan paho.Message, bufferSize),
+		done:     make(chan bool),
+	}
+	handler := func(_ paho.Client, msg paho.Message) {
+		select {
+		case sub.messages <- msg:
+		case <-sub.done:
+		}
+	}
+	if err := c.wait(c.client.Subscribe(topic, qos, handler)); err != nil {
+		return object.NewError(err)
+	}
+	c.mutex.Lock()
+	if previous, ok := c.subs[topic]; ok {
+		previous.stop()
+	}
+	c.subs[topic] = sub
+	c.mutex.Unlock()
+
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		var idle <-chan time.Time
+		if idleTimeout > 0 {
+			timer := time.NewTimer(idleTimeout)
+			defer timer.Stop()
+			idle = timer.C
+		}
+		select {
+		case msg := <-sub.messages:
+			return messageToMap(msg), true, nil
+		case <-sub.done:
+			return nil, false, nil
+		case <-c.closed:
+			return nil, false, nil
+		case <-idle:
+			return nil, false, nil
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	})
+}
+
+// Unsubscribe removes the subscriptions to the given topic filters and ends
+// their streams.
+func (c *Client) Unsubscribe(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) == 0 {
+		return object.NewArgsRangeError("mqtt.client.unsubscribe", 1, 1, 0)
+	}
+	topics := make([]string, 0, len(args))
+	for _, topicArg := range args {
+		topic, err := object.AsString(topicArg)
+		if err != nil {
+			return err
+		}
+		topics = append(topics, topic)
+	}
+	if err := c.wait(c.client.Unsubscribe(topics...)); err != nil {
+		return object.NewError(err)
+	}
+	c.mutex.Lock()
+	for _, topic := range topics {
+		if sub, ok := c.subs[topic]; ok {
+			sub.stop()
+			delete(c.subs, topic)
+		}
+	}
+	c.mutex.Unlock()
+	return object.Nil
+}
+
+// Close disconnects from the broker and ends all subscription streams.
+func (c *Client) Close() {
+	c.once.Do(func() {
+		c.client.Disconnect(250)
+		close(c.closed)
+	})
+}
+
+func (c *Client) waitToClose(ctx context.Context) {
+	go func() {
+		select {
+		case <-c.closed:
+		case <-ctx.Done():
+			c.Close()
+		}
+	}()
+}
+
+// wait blocks until the token completes or the client timeout elapses.
+func (c *Client) wait(token paho.Token) error {
+	if c.timeout > 0 {
+		if !token.WaitTimeout(c.timeout) {
+			return errors.New("mqtt error: operation timed out")
+		}
+	} else {
+		token.Wait()
+	}
+	return token.Error()
+}
+
+func messageToMap(msg paho.Message) *object.Map {
+	return object.NewMap(map[string]object.Object{
+		"topic":      object.NewString(msg.Topic()),
+		"payload":    object.NewByteSlice(msg.Payload()),
+		"qos":        object.NewInt(int64(msg.Qos())),
+		"retained":   object.NewBool(msg.Retained()),
+		"duplicate":  object.NewBool(msg.Duplicate()),
+		"message_id": object.NewInt(int64(msg.MessageID())),
+	})
+}
@@ -0,0 +1,260 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Options configures a connection to a broker.
+type Options struct {
+	ClientID           string
+	Username           string
+	Password           string
+	CleanSession       bool
+	KeepAlive          time.Duration
+	Timeout            time.Duration
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+	Will               *Message
+}
+
+// Message is an outgoing message.
+type Message struct {
+	Topic   string
+	Payload []byte
+	QoS     byte
+	Retain  bool
+}
+
+func Connect(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return object.NewArgsRangeError("mqtt.connect", 1, 2, len(args))
+	}
+	broker, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts := Options{CleanSession: true, Timeout: defaultTimeout}
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		if err := parseOptions(m, &opts); err != nil {
+			return err
+		}
+	}
+	client, connErr := NewClient(ctx, broker, opts)
+	if connErr != nil {
+		return object.NewError(connErr)
+	}
+	return client
+}
+
+// NewClient connects to the broker at the given URL, e.g.
+// "tcp://localhost:1883" or "ssl://broker.example.com:8883".
+func NewClient(ctx context.Context, broker string, opts Options) (*Client, error) {
+	clientOpts := paho.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(opts.ClientID).
+		SetCleanSession(opts.CleanSession).
+		SetConnectTimeout(opts.Timeout).
+		SetAutoReconnect(true)
+	if opts.Username != "" {
+		clientOpts.SetUsername(opts.Username)
+		clientOpts.SetPassword(opts.Password)
+	}
+	if opts.KeepAlive > 0 {
+		clientOpts.SetKeepAlive(opts.KeepAlive)
+	}
+	if opts.Will != nil {
+		clientOpts.SetBinaryWill(opts.Will.Topic, opts.Will.Payload, opts.Will.QoS, opts.Will.Retain)
+	}
+	tlsConfig, err := opts.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		clientOpts.SetTLSConfig(tlsConfig)
+	}
+	client := &Client{
+		broker:  broker,
+		client:  paho.NewClient(clientOpts),
+		timeout: opts.Timeout,
+		closed:  make(chan bool),
+		subs:    map[string]*subscription{},
+	}
+	if err := client.wait(client.client.Connect()); err != nil {
+		return nil, err
+	}
+	client.waitToClose(ctx)
+	return client, nil
+}
+
+func (o Options) tlsConfig() (*tls.Config, error) {
+	if o.CAFile == "" && o.CertFile == "" && !o.InsecureSkipVerify {
+		return nil, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("value error: no certificates found in %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+func parseOptions(m *object.Map, opts *Options) *object.Error {
+	for key, value := range m.Value() {
+		var err *object.Error
+		switch key {
+		case "client_id":
+			opts.ClientID, err = object.AsString(value)
+		case "username":
+			opts.Username, err = object.AsString(value)
+		case "password":
+			opts.Password, err = object.AsString(value)
+		case "clean_session":
+			opts.CleanSession, err = object.AsBool(value)
+		case "keep_alive":
+			opts.KeepAlive, err = arg.Duration(key, value)
+		case "timeout":
+			opts.Timeout, err = arg.Duration(key, value)
+		case "ca_file":
+			opts.CAFile, err = object.AsString(value)
+		case "cert_file":
+			opts.CertFile, err = object.AsString(value)
+		case "key_file":
+			opts.KeyFile, err = object.AsString(value)
+		case "insecure_skip_verify":
+			opts.InsecureSkipVerify, err = object.AsBool(value)
+		case "will":
+			opts.Will, err = parseWill(value)
+		default:
+			err = object.Errorf("value error: unknown mqtt option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseWill(obj object.Object) (*Message, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	topic, err := object.AsString(m.Get("topic"))
+	if err != nil {
+		return nil, object.Errorf("value error: will requires a topic")
+	}
+	payload, err := asPayload(m.GetWithDefault("payload", object.NewString("")))
+	if err != nil {
+		return nil, err
+	}
+	will := &Message{Topic: topic, Payload: payload}
+	if err := parsePublishOptions(m, will, "topic", "payload"); err != nil {
+		return nil, err
+	}
+	return will, nil
+}
+
+// parsePublishOptions reads the "qos" and "retain" keys of the map. Keys
+// listed in ignore are skipped; any other key is an error.
+func parsePublishOptions(m *object.Map, msg *Message, ignore ...string) *object.Error {
+	for key, value := range m.Value() {
+		switch key {
+		case "qos":
+			qos, err := asQoS(value)
+			if err != nil {
+				return err
+			}
+			msg.QoS = qos
+		case "retain":
+			retain, err := object.AsBool(value)
+			if err != nil {
+				return err
+			}
+			msg.Retain = retain
+		default:
+			if !contains(ignore, key) {
+				return object.Errorf("value error: unknown publish option %q", key)
+			}
+		}
+	}
+	return nil
+}
+
+func asQoS(obj object.Object) (byte, *object.Error) {
+	qos, err := object.AsInt(obj)
+	if err != nil {
+		return 0, err
+	}
+	if qos < 0 || qos > 2 {
+		return 0, object.Errorf("value error: qos must be 0, 1, or 2 (got %d)", qos)
+	}
+	return byte(qos), nil
+}
+
+// asPayload returns the bytes to publish. Strings and byte slices are sent
+// as-is; other values are encoded as JSON.
+func asPayload(obj object.Object) ([]byte, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.String:
+		return []byte(obj.Value()), nil
+	case *object.ByteSlice:
+		return obj.Value(), nil
+	case *object.Buffer:
+		return obj.Value().Bytes(), nil
+	}
+	data, err := obj.(interface{ MarshalJSON() ([]byte, error) })
+	if !err {
+		return nil, object.Errorf("type error: unsupported payload type %s", obj.Type())
+	}
+	payload, marshalErr := data.MarshalJSON()
+	if marshalErr != nil {
+		return nil, object.NewError(marshalErr)
+	}
+	return payload, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("mqtt", map[string]object.Object{
+		"connect": object.NewBuiltin("mqtt.connect", Connect),
+	})
+}
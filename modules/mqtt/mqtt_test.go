@@ -0,0 +1,135 @@
+package mqtt
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func startBroker(t *testing.T) string {
+	t.Helper()
+	server := mochi.New(&mochi.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.Nil(t, server.AddHook(new(auth.AllowHook), nil))
+	listener := listeners.NewTCP("tcp", "127.0.0.1:0", nil)
+	require.Nil(t, server.AddListener(listener))
+	require.Nil(t, server.Serve())
+	t.Cleanup(func() { server.Close() })
+	return "tcp://" + listener.Address()
+}
+
+func connect(t *testing.T, ctx context.Context, broker string, opts map[string]object.Object) *Client {
+	t.Helper()
+	args := []object.Object{object.NewString(broker)}
+	if opts != nil {
+		args = append(args, object.NewMap(opts))
+	}
+	result := Connect(ctx, args...)
+	client, ok := result.(*Client)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	t.Cleanup(client.Close)
+	return client
+}
+
+func call(ctx context.Context, client *Client, name string, args ...object.Object) object.Object {
+	method, ok := client.GetAttr(name)
+	if !ok {
+		panic("missing method: " + name)
+	}
+	return method.(*object.Builtin).Call(ctx, args...)
+}
+
+func next(t *testing.T, ctx context.Context, stream object.Object) *object.Map {
+	t.Helper()
+	iter := stream.(*object.Stream).Iter()
+	value, ok := iter.Next(ctx)
+	require.True(t, ok)
+	msg, isMap := value.(*object.Map)
+	require.True(t, isMap, "unexpected value: %s", value.Inspect())
+	return msg
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	ctx := context.Background()
+	broker := startBroker(t)
+	client := connect(t, ctx, broker, map[string]object.Object{
+		"client_id": object.NewString("test-client"),
+	})
+	require.Equal(t, object.True, call(ctx, client, "is_connected"))
+
+	stream := call(ctx, client, "subscribe", object.NewString("sensors/+"),
+		object.NewMap(map[string]object.Object{"qos": object.NewInt(1)}))
+	require.IsType(t, &object.Stream{}, stream)
+
+	result := call(ctx, client, "publish", object.NewString("sensors/temp"), object.NewString("21.5"),
+		object.NewMap(map[string]object.Object{"qos": object.NewInt(1)}))
+	require.Equal(t, object.Nil, result)
+
+	msg := next(t, ctx, stream)
+	require.Equal(t, object.NewString("sensors/temp"), msg.Get("topic"))
+	require.Equal(t, object.NewByteSlice([]byte("21.5")), msg.Get("payload"))
+	require.Equal(t, object.NewInt(1), msg.Get("qos"))
+	require.Equal(t, object.False, msg.Get("retained"))
+
+	// Non-string payloads are encoded as JSON
+	call(ctx, client, "publish", object.NewString("sensors/door"),
+		object.NewMap(map[string]object.Object{"open": object.True}))
+	msg = next(t, ctx, stream)
+	require.Equal(t, object.NewByteSlice([]byte(`{"open":true}`)), msg.Get("payload"))
+
+	// Unsubscribing ends the stream
+	require.Equal(t, object.Nil, call(ctx, client, "unsubscribe", object.NewString("sensors/+")))
+	_, ok := stream.(*object.Stream).Iter().Next(ctx)
+	require.False(t, ok)
+}
+
+func TestRetainedMessages(t *testing.T) {
+	ctx := context.Background()
+	broker := startBroker(t)
+	publisher := connect(t, ctx, broker, nil)
+	result := call(ctx, publisher, "publish", object.NewString("devices/lamp/state"), object.NewString("on"),
+		object.NewMap(map[string]object.Object{"qos": object.NewInt(1), "retain": object.True}))
+	require.Equal(t, object.Nil, result)
+
+	subscriber := connect(t, ctx, broker, nil)
+	stream := call(ctx, subscriber, "subscribe", object.NewString("devices/#"),
+		object.NewMap(map[string]object.Object{"timeout": object.NewString("200ms")}))
+	msg := next(t, ctx, stream)
+	require.Equal(t, object.NewString("devices/lamp/state"), msg.Get("topic"))
+	require.Equal(t, object.NewByteSlice([]byte("on")), msg.Get("payload"))
+	require.Equal(t, object.True, msg.Get("retained"))
+
+	// The stream ends once no message arrives within the timeout
+	_, ok := stream.(*object.Stream).Iter().Next(ctx)
+	require.False(t, ok)
+}
+
+func TestCloseEndsStreams(t *testing.T) {
+	ctx := context.Background()
+	client := connect(t, ctx, startBroker(t), nil)
+	stream := call(ctx, client, "subscribe", object.NewString("a/b"))
+	require.Equal(t, object.Nil, call(ctx, client, "close"))
+	require.Equal(t, object.False, call(ctx, client, "is_connected"))
+	_, ok := stream.(*object.Stream).Iter().Next(ctx)
+	require.False(t, ok)
+}
+
+func TestOptionErrors(t *testing.T) {
+	ctx := context.Background()
+	result := Connect(ctx, object.NewString("tcp://127.0.0.1:1"),
+		object.NewMap(map[string]object.Object{"bogus": object.True}))
+	require.Equal(t, object.Errorf("value error: unknown mqtt option \"bogus\""), result)
+
+	client := connect(t, ctx, startBroker(t), nil)
+	result = call(ctx, client, "publish", object.NewString("x"), object.NewString("y"),
+		object.NewMap(map[string]object.Object{"qos": object.NewInt(3)}))
+	require.Equal(t, object.Errorf("value error: qos must be 0, 1, or 2 (got 3)"), result)
+}
@@ -0,0 +1,195 @@
+package msgpack
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func Unmarshal(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("msgpack.unmarshal", 1, args); err != nil {
+		return err
+	}
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	dec := newDecoder(bytes.NewReader(data))
+	value, decodeErr := decode(dec)
+	if decodeErr != nil {
+		return object.Errorf("value error: msgpack.unmarshal failed with: %s", decodeErr)
+	}
+	if _, err := dec.Buffered().Read(make([]byte, 1)); err != io.EOF {
+		return object.Errorf("value error: msgpack.unmarshal failed with: unexpected data after value")
+	}
+	return value
+}
+
+func Marshal(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("msgpack.marshal", 1, args); err != nil {
+		return err
+	}
+	data, err := marshal(args[0])
+	if err != nil {
+		return object.Errorf("value error: msgpack.marshal failed: %s", err)
+	}
+	return object.NewByteSlice(data)
+}
+
+func Valid(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("msgpack.valid", 1, args); err != nil {
+		return err
+	}
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	if err := dec.Skip(); err != nil {
+		return object.False
+	}
+	_, readErr := dec.Buffered().Read(make([]byte, 1))
+	return object.NewBool(readErr == io.EOF)
+}
+
+// Reader returns a stream of the values read from a source containing a
+// sequence of concatenated msgpack values.
+func Reader(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("msgpack.reader", 1, args); err != nil {
+		return err
+	}
+	r, err := object.AsReader(args[0])
+	if err != nil {
+		return err
+	}
+	dec := newDecoder(r)
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		value, err := decode(dec)
+		if errors.Is(err, io.EOF) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("msgpack error: %w", err)
+		}
+		return value, true, nil
+	})
+}
+
+// Write writes each value from an iterable to the destination as a sequence
+// of msgpack values. Returns the number of values written.
+func Write(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("msgpack.write", 2, args); err != nil {
+		return err
+	}
+	w, err := object.AsWriter(args[0])
+	if err != nil {
+		return err
+	}
+	iter, err := object.AsIterator(args[1])
+	if err != nil {
+		return err
+	}
+	enc := newEncoder(w)
+	var count int64
+	for {
+		value, ok := iter.Next(ctx)
+		if !ok {
+			break
+		}
+		if err := enc.Encode(value.Interface()); err != nil {
+			return object.Errorf("value error: msgpack.write failed: %s", err)
+		}
+		count++
+	}
+	if s, ok := iter.(interface{ Err() error }); ok && s.Err() != nil {
+		return object.NewError(s.Err())
+	}
+	return object.NewInt(count)
+}
+
+func newEncoder(w io.Writer) *msgpack.Encoder {
+	enc := msgpack.NewEncoder(w)
+	enc.SetSortMapKeys(true)
+	enc.UseCompactInts(true)
+	return enc
+}
+
+func newDecoder(r io.Reader) *msgpack.Decoder {
+	dec := msgpack.NewDecoder(r)
+	dec.SetMapDecoder(func(dec *msgpack.Decoder) (interface{}, error) {
+		return dec.DecodeUntypedMap()
+	})
+	return dec
+}
+
+func marshal(obj object.Object) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := newEncoder(&buf).Encode(obj.Interface()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decode(dec *msgpack.Decoder) (object.Object, error) {
+	value, err := dec.DecodeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return toObject(value), nil
+}
+
+// toObject converts a decoded value to a Risor object. Map keys that aren't
+// strings are converted to strings.
+func toObject(value interface{}) object.Object {
+	switch value := value.(type) {
+	case nil:
+		return object.Nil
+	case int8:
+		return object.NewInt(int64(value))
+	case int16:
+		return object.NewInt(int64(value))
+	case int32:
+		return object.NewInt(int64(value))
+	case int64:
+		return object.NewInt(value)
+	case uint8:
+		return object.NewInt(int64(value))
+	case uint16:
+		return object.NewInt(int64(value))
+	case uint32:
+		return object.NewInt(int64(value))
+	case uint64:
+		return object.NewInt(int64(value))
+	case float32:
+		return object.NewFloat(float64(value))
+	case []interface{}:
+		items := make([]object.Object, len(value))
+		for i, item := range value {
+			items[i] = toObject(item)
+		}
+		return object.NewList(items)
+	case map[interface{}]interface{}:
+		m := make(map[string]object.Object, len(value))
+		for k, v := range value {
+			m[fmt.Sprint(k)] = toObject(v)
+		}
+		return object.NewMap(m)
+	}
+	return object.FromGoType(value)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("msgpack", map[string]object.Object{
+		"marshal":   object.NewBuiltin("marshal", Marshal),
+		"unmarshal": object.NewBuiltin("unmarshal", Unmarshal),
+		"valid":     object.NewBuiltin("valid", Valid),
+		"reader":    object.NewBuiltin("reader", Reader),
+		"write":     object.NewBuiltin("write", Write),
+	})
+}
@@ -0,0 +1,318 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const CIDR object.Type = "net.cidr"
+
+// Prefix wraps a netip.Prefix and implements object.Object. The prefix is
+// always stored in its masked, canonical form.
+type Prefix struct {
+	prefix netip.Prefix
+}
+
+func (p *Prefix) Type() object.Type {
+	return CIDR
+}
+
+func (p *Prefix) Inspect() string {
+	return fmt.Sprintf("net.cidr(%q)", p.prefix.String())
+}
+
+func (p *Prefix) String() string {
+	return p.prefix.String()
+}
+
+func (p *Prefix) Value() netip.Prefix {
+	return p.prefix
+}
+
+func (p *Prefix) Interface() interface{} {
+	return p.prefix
+}
+
+func (p *Prefix) HashKey() object.HashKey {
+	return object.HashKey{Type: p.Type(), StrValue: p.prefix.String()}
+}
+
+func (p *Prefix) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "network":
+		return cidrMethod(name, func() object.Object {
+			return NewIP(p.prefix.Addr())
+		}), true
+	case "broadcast", "last":
+		return cidrMethod(name, func() object.Object {
+			return NewIP(p.lastAddr())
+		}), true
+	case "netmask":
+		return cidrMethod(name, func() object.Object {
+			return NewIP(p.netmask())
+		}), true
+	case "prefix_len":
+		return cidrMethod(name, func() object.Object {
+			return object.NewInt(int64(p.prefix.Bits()))
+		}), true
+	case "version":
+		return cidrMethod(name, func() object.Object {
+			if p.prefix.Addr().Is4() {
+				return object.NewInt(4)
+			}
+			return object.NewInt(6)
+		}), true
+	case "size":
+		return cidrMethod(name, func() object.Object {
+			size := p.size()
+			if !size.IsInt64() {
+				return object.Errorf("value error: %s has more addresses than fit in an int", p.prefix)
+			}
+			return object.NewInt(size.Int64())
+		}), true
+	case "contains":
+		return object.NewBuiltin("net.cidr.contains", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewArgsError("net.cidr.contains", 1, len(args))
+			}
+			return p.Contains(args[0])
+		}), true
+	case "overlaps":
+		return object.NewBuiltin("net.cidr.overlaps", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewArgsError("net.cidr.overlaps", 1, len(args))
+			}
+			other, err := AsPrefix(args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewBool(p.prefix.Overlaps(other))
+		}), true
+	case "hosts":
+		return cidrMethod(name, func() object.Object {
+			return p.Hosts()
+		}), true
+	case "subnets":
+		return object.NewBuiltin("net.cidr.subnets", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewArgsError("net.cidr.subnets", 1, len(args))
+			}
+			bits, err := object.AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			return p.Subnets(int(bits))
+		}), true
+	}
+	return nil, false
+}
+
+func (p *Prefix) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: net.cidr object has no attribute %q", name)
+}
+
+func (p *Prefix) Equals(other object.Object) object.Object {
+	switch other := other.(type) {
+	case *Prefix:
+		return object.NewBool(p.prefix == other.prefix)
+	case *object.String:
+		prefix, err := netip.ParsePrefix(other.Value())
+		return object.NewBool(err == nil && prefix.Masked() == p.prefix)
+	}
+	return object.False
+}
+
+func (p *Prefix) IsTruthy() bool {
+	return true
+}
+
+func (p *Prefix) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for net.cidr: %v on type %s",
+		opType, right.Type())
+}
+
+func (p *Prefix) Cost() int {
+	return 0
+}
+
+func (p *Prefix) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.prefix.String())
+}
+
+// Contains returns true if the given address or network lies within this
+// network. Strings are parsed as either an address or a CIDR.
+func (p *Prefix) Contains(item object.Object) *object.Bool {
+	switch item := item.(type) {
+	case *IPAddr:
+		return object.NewBool(p.prefix.Contains(item.addr))
+	case *Prefix:
+		return object.NewBool(p.containsPrefix(item.prefix))
+	case *object.String:
+		if addr, err := netip.ParseAddr(item.Value()); err == nil {
+			return object.NewBool(p.prefix.Contains(addr))
+		}
+		if prefix, err := netip.ParsePrefix(item.Value()); err == nil {
+			return object.NewBool(p.containsPrefix(prefix.Masked()))
+		}
+	}
+	return object.False
+}
+
+func (p *Prefix) containsPrefix(other netip.Prefix) bool {
+	return other.Bits() >= p.prefix.Bits() && p.prefix.Contains(other.Addr())
+}
+
+// GetItem returns the address at the given offset within the network.
+// Negative offsets count back from the last address.
+func (p *Prefix) GetItem(key object.Object) (object.Object, *object.Error) {
+	index, err := object.AsInt(key)
+	if err != nil {
+		return nil, err
+	}
+	offset := big.NewInt(index)
+	if index < 0 {
+		offset.Add(offset, p.size())
+	}
+	if offset.Sign() < 0 || offset.Cmp(p.size()) >= 0 {
+		return nil, object.Errorf("index error: index out of range: %d", index)
+	}
+	addr, addErr := addOffset(p.prefix.Addr(), offset)
+	if addErr != nil {
+		return nil, object.NewError(addErr)
+	}
+	return NewIP(addr), nil
+}
+
+func (p *Prefix) GetSlice(s object.Slice) (object.Object, *object.Error) {
+	return nil, object.Errorf("type error: net.cidr does not support slicing")
+}
+
+func (p *Prefix) SetItem(key, value object.Object) *object.Error {
+	return object.Errorf("type error: net.cidr does not support item assignment")
+}
+
+func (p *Prefix) DelItem(key object.Object) *object.Error {
+	return object.Errorf("type error: net.cidr does not support item deletion")
+}
+
+// Len returns the number of addresses in the network, capped at the
+// largest int for very large IPv6 networks.
+func (p *Prefix) Len() *object.Int {
+	size := p.size()
+	if !size.IsInt64() {
+		return object.NewInt(math.MaxInt64)
+	}
+	return object.NewInt(size.Int64())
+}
+
+// Iter returns a stream over every address in the network.
+func (p *Prefix) Iter() object.Iterator {
+	return p.addrStream(p.prefix.Addr(), p.lastAddr())
+}
+
+// Hosts returns a stream over the usable host addresses in the network. For
+// IPv4 networks larger than /31, the network and broadcast addresses are
+// excluded.
+func (p *Prefix) Hosts() *object.Stream {
+	first, last := p.prefix.Addr(), p.lastAddr()
+	if p.prefix.Addr().Is4() && p.prefix.Bits() < 31 {
+		first, last = first.Next(), last.Prev()
+	}
+	return p.addrStream(first, last)
+}
+
+// Subnets splits the network into subnets with the given prefix length.
+func (p *Prefix) Subnets(bits int) object.Object {
+	if bits < p.prefix.Bits() || bits > p.prefix.Addr().BitLen() {
+		return object.Errorf("value error: invalid subnet prefix length %d for %s", bits, p.prefix)
+	}
+	if bits-p.prefix.Bits() > 16 {
+		return object.Errorf("value error: too many subnets (/%d in %s)", bits, p.prefix)
+	}
+	count := 1 << (bits - p.prefix.Bits())
+	step := new(big.Int).Lsh(big.NewInt(1), uint(p.prefix.Addr().BitLen()-bits))
+	items := make([]object.Object, 0, count)
+	addr := p.prefix.Addr()
+	for i := 0; i < count; i++ {
+		items = append(items, NewPrefix(netip.PrefixFrom(addr, bits)))
+		if i < count-1 {
+			next, err := addOffset(addr, step)
+			if err != nil {
+				return object.NewError(err)
+			}
+			addr = next
+		}
+	}
+	return object.NewList(items)
+}
+
+func (p *Prefix) addrStream(first, last netip.Addr) *object.Stream {
+	current := first
+	done := !first.IsValid() || !last.IsValid() || last.Less(first)
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		if done {
+			return nil, false, nil
+		}
+		addr := current
+		if addr == last {
+			done = true
+		} else {
+			current = current.Next()
+		}
+		return NewIP(addr), true, nil
+	})
+}
+
+func (p *Prefix) size() *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(p.prefix.Addr().BitLen()-p.prefix.Bits()))
+}
+
+func (p *Prefix) lastAddr() netip.Addr {
+	last, _ := addOffset(p.prefix.Addr(), new(big.Int).Sub(p.size(), big.NewInt(1)))
+	return last
+}
+
+func (p *Prefix) netmask() netip.Addr {
+	bitLen := p.prefix.Addr().BitLen()
+	mask := new(big.Int).Lsh(big.NewInt(1), uint(bitLen))
+	mask.Sub(mask, p.size())
+	addr, _ := intToAddr(mask, bitLen)
+	return addr
+}
+
+func NewPrefix(prefix netip.Prefix) *Prefix {
+	return &Prefix{prefix: prefix.Masked()}
+}
+
+// AsPrefix returns the network represented by the given object, which may
+// be a net.cidr or a string in CIDR notation.
+func AsPrefix(obj object.Object) (netip.Prefix, *object.Error) {
+	switch obj := obj.(type) {
+	case *Prefix:
+		return obj.prefix, nil
+	case *object.String:
+		prefix, err := netip.ParsePrefix(obj.Value())
+		if err != nil {
+			return netip.Prefix{}, object.Errorf("value error: %s", err)
+		}
+		return prefix.Masked(), nil
+	}
+	return netip.Prefix{}, object.Errorf("type error: expected a net.cidr or string (%s given)", obj.Type())
+}
+
+func cidrMethod(name string, fn func() object.Object) *object.Builtin {
+	fullName := "net.cidr." + name
+	return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+		if len(args) != 0 {
+			return object.NewArgsError(fullName, 0, len(args))
+		}
+		return fn()
+	})
+}
@@ -0,0 +1,216 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/netip"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const IP object.Type = "net.ip"
+
+// IPAddr wraps a netip.Addr and implements object.Object.
+type IPAddr struct {
+	addr netip.Addr
+}
+
+func (ip *IPAddr) Type() object.Type {
+	return IP
+}
+
+func (ip *IPAddr) Inspect() string {
+	return fmt.Sprintf("net.ip(%q)", ip.addr.String())
+}
+
+func (ip *IPAddr) String() string {
+	return ip.addr.String()
+}
+
+func (ip *IPAddr) Value() netip.Addr {
+	return ip.addr
+}
+
+func (ip *IPAddr) Interface() interface{} {
+	return ip.addr
+}
+
+func (ip *IPAddr) HashKey() object.HashKey {
+	return object.HashKey{Type: ip.Type(), StrValue: ip.addr.String()}
+}
+
+func (ip *IPAddr) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "version":
+		return ipMethod(name, func() object.Object {
+			if ip.addr.Is4() {
+				return object.NewInt(4)
+			}
+			return object.NewInt(6)
+		}), true
+	case "is_v4":
+		return ipPredicate(name, ip.addr.Is4), true
+	case "is_v6":
+		return ipPredicate(name, ip.addr.Is6), true
+	case "is_private":
+		return ipPredicate(name, ip.addr.IsPrivate), true
+	case "is_loopback":
+		return ipPredicate(name, ip.addr.IsLoopback), true
+	case "is_multicast":
+		return ipPredicate(name, ip.addr.IsMulticast), true
+	case "is_unspecified":
+		return ipPredicate(name, ip.addr.IsUnspecified), true
+	case "is_global_unicast":
+		return ipPredicate(name, ip.addr.IsGlobalUnicast), true
+	case "next":
+		return ipMethod(name, func() object.Object {
+			next := ip.addr.Next()
+			if !next.IsValid() {
+				return object.Errorf("value error: address overflow after %s", ip.addr)
+			}
+			return NewIP(next)
+		}), true
+	case "prev":
+		return ipMethod(name, func() object.Object {
+			prev := ip.addr.Prev()
+			if !prev.IsValid() {
+				return object.Errorf("value error: address underflow before %s", ip.addr)
+			}
+			return NewIP(prev)
+		}), true
+	case "to_int":
+		return ipMethod(name, func() object.Object {
+			n := addrToInt(ip.addr)
+			if !n.IsInt64() {
+				return object.Errorf("value error: address %s does not fit in an int", ip.addr)
+			}
+			return object.NewInt(n.Int64())
+		}), true
+	case "bytes":
+		return ipMethod(name, func() object.Object {
+			return object.NewByteSlice(ip.addr.AsSlice())
+		}), true
+	case "unmap":
+		return ipMethod(name, func() object.Object {
+			return NewIP(ip.addr.Unmap())
+		}), true
+	}
+	return nil, false
+}
+
+func (ip *IPAddr) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: net.ip object has no attribute %q", name)
+}
+
+func (ip *IPAddr) Compare(other object.Object) (int, error) {
+	otherIP, ok := other.(*IPAddr)
+	if !ok {
+		return 0, fmt.Errorf("type error: unable to compare net.ip and %s", other.Type())
+	}
+	return ip.addr.Compare(otherIP.addr), nil
+}
+
+func (ip *IPAddr) Equals(other object.Object) object.Object {
+	switch other := other.(type) {
+	case *IPAddr:
+		return object.NewBool(ip.addr == other.addr)
+	case *object.String:
+		addr, err := netip.ParseAddr(other.Value())
+		return object.NewBool(err == nil && addr == ip.addr)
+	}
+	return object.False
+}
+
+func (ip *IPAddr) IsTruthy() bool {
+	return !ip.addr.IsUnspecified()
+}
+
+func (ip *IPAddr) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Int:
+		switch opType {
+		case op.Add:
+			return ip.offset(big.NewInt(right.Value()))
+		case op.Subtract:
+			return ip.offset(big.NewInt(-right.Value()))
+		}
+	case *IPAddr:
+		if opType == op.Subtract {
+			if ip.addr.BitLen() != right.addr.BitLen() {
+				return object.Errorf("value error: unable to subtract addresses of different versions")
+			}
+			diff := new(big.Int).Sub(addrToInt(ip.addr), addrToInt(right.addr))
+			if !diff.IsInt64() {
+				return object.Errorf("value error: address difference does not fit in an int")
+			}
+			return object.NewInt(diff.Int64())
+		}
+	}
+	return object.Errorf("eval error: unsupported operation for net.ip: %v on type %s",
+		opType, right.Type())
+}
+
+func (ip *IPAddr) offset(n *big.Int) object.Object {
+	addr, err := addOffset(ip.addr, n)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return NewIP(addr)
+}
+
+func (ip *IPAddr) Cost() int {
+	return 0
+}
+
+func (ip *IPAddr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ip.addr.String())
+}
+
+func NewIP(addr netip.Addr) *IPAddr {
+	return &IPAddr{addr: addr}
+}
+
+func ipMethod(name string, fn func() object.Object) *object.Builtin {
+	fullName := "net.ip." + name
+	return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+		if len(args) != 0 {
+			return object.NewArgsError(fullName, 0, len(args))
+		}
+		return fn()
+	})
+}
+
+func ipPredicate(name string, fn func() bool) *object.Builtin {
+	return ipMethod(name, func() object.Object {
+		return object.NewBool(fn())
+	})
+}
+
+// addrToInt returns the address as an unsigned integer.
+func addrToInt(addr netip.Addr) *big.Int {
+	return new(big.Int).SetBytes(addr.AsSlice())
+}
+
+// intToAddr converts an unsigned integer to an address with the given
+// number of bits.
+func intToAddr(n *big.Int, bits int) (netip.Addr, error) {
+	if n.Sign() < 0 || n.BitLen() > bits {
+		return netip.Addr{}, fmt.Errorf("value error: address out of range")
+	}
+	buf := make([]byte, bits/8)
+	n.FillBytes(buf)
+	addr, _ := netip.AddrFromSlice(buf)
+	return addr, nil
+}
+
+func addOffset(addr netip.Addr, n *big.Int) (netip.Addr, error) {
+	sum := new(big.Int).Add(addrToInt(addr), n)
+	result, err := intToAddr(sum, addr.BitLen())
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("value error: %s %+d is out of range", addr, n)
+	}
+	return result.WithZone(addr.Zone()), nil
+}
@@ -0,0 +1,119 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const MAC object.Type = "net.mac"
+
+// HardwareAddr wraps a net.HardwareAddr and implements object.Object.
+type HardwareAddr struct {
+	addr net.HardwareAddr
+}
+
+func (m *HardwareAddr) Type() object.Type {
+	return MAC
+}
+
+func (m *HardwareAddr) Inspect() string {
+	return fmt.Sprintf("net.mac(%q)", m.addr.String())
+}
+
+func (m *HardwareAddr) String() string {
+	return m.addr.String()
+}
+
+func (m *HardwareAddr) Value() net.HardwareAddr {
+	return m.addr
+}
+
+func (m *HardwareAddr) Interface() interface{} {
+	return m.addr
+}
+
+func (m *HardwareAddr) HashKey() object.HashKey {
+	return object.HashKey{Type: m.Type(), StrValue: m.addr.String()}
+}
+
+func (m *HardwareAddr) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "bytes":
+		return macMethod(name, func() object.Object {
+			return object.NewByteSlice(bytes.Clone(m.addr))
+		}), true
+	case "oui":
+		return macMethod(name, func() object.Object {
+			return object.NewString(m.addr[:3].String())
+		}), true
+	case "is_unicast":
+		return macMethod(name, func() object.Object {
+			return object.NewBool(m.addr[0]&0x01 == 0)
+		}), true
+	case "is_local":
+		return macMethod(name, func() object.Object {
+			return object.NewBool(m.addr[0]&0x02 != 0)
+		}), true
+	}
+	return nil, false
+}
+
+func (m *HardwareAddr) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: net.mac object has no attribute %q", name)
+}
+
+func (m *HardwareAddr) Compare(other object.Object) (int, error) {
+	otherMAC, ok := other.(*HardwareAddr)
+	if !ok {
+		return 0, fmt.Errorf("type error: unable to compare net.mac and %s", other.Type())
+	}
+	return bytes.Compare(m.addr, otherMAC.addr), nil
+}
+
+func (m *HardwareAddr) Equals(other object.Object) object.Object {
+	switch other := other.(type) {
+	case *HardwareAddr:
+		return object.NewBool(bytes.Equal(m.addr, other.addr))
+	case *object.String:
+		addr, err := net.ParseMAC(other.Value())
+		return object.NewBool(err == nil && bytes.Equal(m.addr, addr))
+	}
+	return object.False
+}
+
+func (m *HardwareAddr) IsTruthy() bool {
+	return len(m.addr) > 0
+}
+
+func (m *HardwareAddr) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for net.mac: %v on type %s",
+		opType, right.Type())
+}
+
+func (m *HardwareAddr) Cost() int {
+	return 0
+}
+
+func (m *HardwareAddr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.addr.String())
+}
+
+func NewMAC(addr net.HardwareAddr) *HardwareAddr {
+	return &HardwareAddr{addr: addr}
+}
+
+func macMethod(name string, fn func() object.Object) *object.Builtin {
+	fullName := "net.mac." + name
+	return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+		if len(args) != 0 {
+			return object.NewArgsError(fullName, 0, len(args))
+		}
+		return fn()
+	})
+}
@@ -0,0 +1,134 @@
+package net
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"net/netip"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+func IPFunc(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("net.ip", 1, args); err != nil {
+		return err
+	}
+	switch value := args[0].(type) {
+	case *IPAddr:
+		return value
+	case *object.String:
+		addr, err := netip.ParseAddr(value.Value())
+		if err != nil {
+			return object.Errorf("value error: %s", err)
+		}
+		return NewIP(addr)
+	case *object.Int:
+		addr, err := intToAddr(big.NewInt(value.Value()), 32)
+		if err != nil {
+			return object.Errorf("value error: net.ip() integer out of range for IPv4 (%d given)", value.Value())
+		}
+		return NewIP(addr)
+	case *object.ByteSlice:
+		addr, ok := netip.AddrFromSlice(value.Value())
+		if !ok {
+			return object.Errorf("value error: net.ip() expected 4 or 16 bytes (%d given)", len(value.Value()))
+		}
+		return NewIP(addr)
+	}
+	return object.Errorf("type error: net.ip() expected a string, int, or byte_slice (%s given)", args[0].Type())
+}
+
+func CIDRFunc(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("net.cidr", 1, 2, args); err != nil {
+		return err
+	}
+	if len(args) == 2 {
+		addr, ok := args[0].(*IPAddr)
+		if !ok {
+			s, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			parsed, parseErr := netip.ParseAddr(s)
+			if parseErr != nil {
+				return object.Errorf("value error: %s", parseErr)
+			}
+			addr = NewIP(parsed)
+		}
+		bits, err := object.AsInt(args[1])
+		if err != nil {
+			return err
+		}
+		prefix, prefixErr := addr.addr.Prefix(int(bits))
+		if prefixErr != nil {
+			return object.Errorf("value error: %s", prefixErr)
+		}
+		return NewPrefix(prefix)
+	}
+	prefix, err := AsPrefix(args[0])
+	if err != nil {
+		return err
+	}
+	return NewPrefix(prefix)
+}
+
+func MACFunc(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("net.mac", 1, args); err != nil {
+		return err
+	}
+	switch value := args[0].(type) {
+	case *HardwareAddr:
+		return value
+	case *object.String:
+		addr, err := net.ParseMAC(value.Value())
+		if err != nil {
+			return object.Errorf("value error: %s", err)
+		}
+		return NewMAC(addr)
+	}
+	return object.Errorf("type error: net.mac() expected a string (%s given)", args[0].Type())
+}
+
+func URLFunc(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("net.url", 1, args); err != nil {
+		return err
+	}
+	switch value := args[0].(type) {
+	case *URLValue:
+		return value
+	case *object.Map:
+		u, err := urlFromMap(value)
+		if err != nil {
+			return err
+		}
+		return NewURL(u)
+	}
+	u, err := AsURL(args[0])
+	if err != nil {
+		return err
+	}
+	return NewURL(u)
+}
+
+func IsIP(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("net.is_ip", 1, args); err != nil {
+		return err
+	}
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	_, parseErr := netip.ParseAddr(s)
+	return object.NewBool(parseErr == nil)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("net", map[string]object.Object{
+		"cidr":  object.NewBuiltin("cidr", CIDRFunc),
+		"ip":    object.NewBuiltin("ip", IPFunc),
+		"is_ip": object.NewBuiltin("is_ip", IsIP),
+		"mac":   object.NewBuiltin("mac", MACFunc),
+		"url":   object.NewBuiltin("url", URLFunc),
+	})
+}
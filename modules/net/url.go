@@ -0,0 +1,371 @@
+package net
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const URL object.Type = "net.url"
+
+// URLValue wraps a url.URL and implements object.Object. URL objects are
+// immutable: the with_* methods return modified copies.
+type URLValue struct {
+	url *url.URL
+}
+
+func (u *URLValue) Type() object.Type {
+	return URL
+}
+
+// Inspect returns a representation of the URL with any password redacted.
+func (u *URLValue) Inspect() string {
+	return fmt.Sprintf("net.url(%q)", u.url.Redacted())
+}
+
+// String returns the URL with any password redacted, as it's printed. The
+// string method of the URL returns it in full.
+func (u *URLValue) String() string {
+	return u.url.Redacted()
+}
+
+func (u *URLValue) Value() *url.URL {
+	return u.url
+}
+
+func (u *URLValue) Interface() interface{} {
+	return u.url
+}
+
+func (u *URLValue) HashKey() object.HashKey {
+	return object.HashKey{Type: u.Type(), StrValue: u.url.String()}
+}
+
+func (u *URLValue) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "scheme":
+		return object.NewString(u.url.Scheme), true
+	case "host":
+		return object.NewString(u.url.Host), true
+	case "hostname":
+		return object.NewString(u.url.Hostname()), true
+	case "port":
+		return object.NewString(u.url.Port()), true
+	case "path":
+		return object.NewString(u.url.Path), true
+	case "raw_query":
+		return object.NewString(u.url.RawQuery), true
+	case "fragment":
+		return object.NewString(u.url.Fragment), true
+	case "username":
+		return object.NewString(u.url.User.Username()), true
+	case "query":
+		return queryToMap(u.url.Query()), true
+	case "query_all":
+		return queryToMultiMap(u.url.Query()), true
+	case "string":
+		return urlMethod(name, func() object.Object {
+			return object.NewString(u.url.String())
+		}), true
+	case "redacted":
+		return urlMethod(name, func() object.Object {
+			return object.NewString(u.url.Redacted())
+		}), true
+	case "is_abs":
+		return urlMethod(name, func() object.Object {
+			return object.NewBool(u.url.IsAbs())
+		}), true
+	case "join":
+		return object.NewBuiltin("net.url.join", func(ctx context.Context, args ...object.Object) object.Object {
+			elems := make([]string, 0, len(args))
+			for _, arg := range args {
+				s, err := object.AsString(arg)
+				if err != nil {
+					return err
+				}
+				elems = append(elems, s)
+			}
+			return NewURL(u.url.JoinPath(elems...))
+		}), true
+	case "resolve":
+		return object.NewBuiltin("net.url.resolve", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewArgsError("net.url.resolve", 1, len(args))
+			}
+			ref, err := AsURL(args[0])
+			if err != nil {
+				return err
+			}
+			return NewURL(u.url.ResolveReference(ref))
+		}), true
+	case "with_scheme", "with_host", "with_path", "with_fragment", "with_user":
+		return u.withString(name), true
+	case "with_query":
+		return object.NewBuiltin("net.url.with_query", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewArgsError("net.url.with_query", 1, len(args))
+			}
+			values, err := mapToQuery(args[0])
+			if err != nil {
+				return err
+			}
+			result := u.clone()
+			result.RawQuery = values.Encode()
+			return NewURL(result)
+		}), true
+	case "set_param", "add_param":
+		fullName := "net.url." + name
+		return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return object.NewArgsError(fullName, 2, len(args))
+			}
+			key, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			value, err := queryValue(args[1])
+			if err != nil {
+				return err
+			}
+			result := u.clone()
+			query := result.Query()
+			if name == "set_param" {
+				query.Set(key, value)
+			} else {
+				query.Add(key, value)
+			}
+			result.RawQuery = query.Encode()
+			return NewURL(result)
+		}), true
+	case "del_param":
+		return object.NewBuiltin("net.url.del_param", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewArgsError("net.url.del_param", 1, len(args))
+			}
+			key, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			result := u.clone()
+			query := result.Query()
+			query.Del(key)
+			result.RawQuery = query.Encode()
+			return NewURL(result)
+		}), true
+	}
+	return nil, false
+}
+
+func (u *URLValue) withString(name string) *object.Builtin {
+	fullName := "net.url." + name
+	return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+		if name == "with_user" {
+			if len(args) < 1 || len(args) > 2 {
+				return object.NewArgsRangeError(fullName, 1, 2, len(args))
+			}
+		} else if len(args) != 1 {
+			return object.NewArgsError(fullName, 1, len(args))
+		}
+		value, err := object.AsString(args[0])
+		if err != nil {
+			return err
+		}
+		result := u.clone()
+		switch name {
+		case "with_scheme":
+			result.Scheme = value
+		case "with_host":
+			result.Host = value
+		case "with_path":
+			result.Path = value
+			result.RawPath = ""
+		case "with_fragment":
+			result.Fragment = value
+		case "with_user":
+			if len(args) == 2 {
+				password, err := object.AsString(args[1])
+				if err != nil {
+					return err
+				}
+				result.User = url.UserPassword(value, password)
+			} else if value == "" {
+				result.User = nil
+			} else {
+				result.User = url.User(value)
+			}
+		}
+		return NewURL(result)
+	})
+}
+
+func (u *URLValue) clone() *url.URL {
+	result := *u.url
+	if u.url.User != nil {
+		user := *u.url.User
+		result.User = &user
+	}
+	return &result
+}
+
+func (u *URLValue) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: net.url objects are immutable (use with_%s)", name)
+}
+
+func (u *URLValue) Equals(other object.Object) object.Object {
+	switch other := other.(type) {
+	case *URLValue:
+		return object.NewBool(u.url.String() == other.url.String())
+	case *object.String:
+		return object.NewBool(u.url.String() == other.Value())
+	}
+	return object.False
+}
+
+func (u *URLValue) IsTruthy() bool {
+	return u.url.String() != ""
+}
+
+func (u *URLValue) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for net.url: %v on type %s",
+		opType, right.Type())
+}
+
+func (u *URLValue) Cost() int {
+	return 0
+}
+
+func (u *URLValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.url.String())
+}
+
+func NewURL(u *url.URL) *URLValue {
+	return &URLValue{url: u}
+}
+
+// AsURL returns the URL represented by the given object, which may be a
+// net.url or a string.
+func AsURL(obj object.Object) (*url.URL, *object.Error) {
+	switch obj := obj.(type) {
+	case *URLValue:
+		return obj.url, nil
+	case *object.String:
+		u, err := url.Parse(obj.Value())
+		if err != nil {
+			return nil, object.NewError(err)
+		}
+		return u, nil
+	}
+	return nil, object.Errorf("type error: expected a net.url or string (%s given)", obj.Type())
+}
+
+func urlFromMap(m *object.Map) (*url.URL, *object.Error) {
+	u := &url.URL{}
+	for key, value := range m.Value() {
+		if key == "query" {
+			values, err := mapToQuery(value)
+			if err != nil {
+				return nil, err
+			}
+			u.RawQuery = values.Encode()
+			continue
+		}
+		s, err := object.AsString(value)
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "scheme":
+			u.Scheme = s
+		case "host":
+			u.Host = s
+		case "path":
+			u.Path = s
+		case "fragment":
+			u.Fragment = s
+		case "username":
+			if u.User != nil {
+				password, _ := u.User.Password()
+				u.User = url.UserPassword(s, password)
+			} else {
+				u.User = url.User(s)
+			}
+		case "password":
+			username := ""
+			if u.User != nil {
+				username = u.User.Username()
+			}
+			u.User = url.UserPassword(username, s)
+		default:
+			return nil, object.Errorf("value error: unknown url component %q", key)
+		}
+	}
+	return u, nil
+}
+
+func queryToMap(values url.Values) *object.Map {
+	result := make(map[string]object.Object, len(values))
+	for key := range values {
+		result[key] = object.NewString(values.Get(key))
+	}
+	return object.NewMap(result)
+}
+
+func queryToMultiMap(values url.Values) *object.Map {
+	result := make(map[string]object.Object, len(values))
+	for key, items := range values {
+		result[key] = object.NewStringList(items)
+	}
+	return object.NewMap(result)
+}
+
+// mapToQuery converts a map to URL query values. List values produce a
+// repeated parameter.
+func mapToQuery(obj object.Object) (url.Values, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	for key, value := range m.Value() {
+		if list, ok := value.(*object.List); ok {
+			for _, item := range list.Value() {
+				s, err := queryValue(item)
+				if err != nil {
+					return nil, err
+				}
+				values.Add(key, s)
+			}
+			continue
+		}
+		s, err := queryValue(value)
+		if err != nil {
+			return nil, err
+		}
+		values.Set(key, s)
+	}
+	return values, nil
+}
+
+func queryValue(obj object.Object) (string, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.String:
+		return obj.Value(), nil
+	case *object.Int, *object.Float, *object.Bool:
+		return obj.Inspect(), nil
+	}
+	return "", object.Errorf("type error: unsupported query value type (%s given)", obj.Type())
+}
+
+func urlMethod(name string, fn func() object.Object) *object.Builtin {
+	fullName := "net.url." + name
+	return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+		if len(args) != 0 {
+			return object.NewArgsError(fullName, 0, len(args))
+		}
+		return fn()
+	})
+}
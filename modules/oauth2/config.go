@@ -0,0 +1,321 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"golang.org/x/oauth2"
+)
+
+const CONFIG object.Type = "oauth2.config"
+
+// Config describes an OAuth2 client and the provider's endpoints, for the
+// flows that act on behalf of a user.
+type Config struct {
+	cfg *oauth2.Config
+}
+
+// NewConfigObject returns a Config for the given OAuth2 configuration.
+func NewConfigObject(cfg *oauth2.Config) *Config {
+	return &Config{cfg: cfg}
+}
+
+// Value returns the underlying OAuth2 configuration.
+func (c *Config) Value() *oauth2.Config {
+	return c.cfg
+}
+
+func (c *Config) Type() object.Type {
+	return CONFIG
+}
+
+func (c *Config) Inspect() string {
+	return fmt.Sprintf("oauth2.config(client_id=%s)", c.cfg.ClientID)
+}
+
+func (c *Config) String() string {
+	return c.Inspect()
+}
+
+func (c *Config) Interface() interface{} {
+	return c.cfg
+}
+
+func (c *Config) Equals(other object.Object) object.Object {
+	return object.NewBool(c == other)
+}
+
+func (c *Config) IsTruthy() bool {
+	return true
+}
+
+func (c *Config) Cost() int {
+	return 0
+}
+
+func (c *Config) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", CONFIG)
+}
+
+func (c *Config) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", CONFIG, opType)
+}
+
+func (c *Config) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", CONFIG, name)
+}
+
+func (c *Config) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "client_id":
+		return object.NewString(c.cfg.ClientID), true
+	case "scopes":
+		return object.NewStringList(c.cfg.Scopes), true
+	case "auth_url":
+		return object.NewString(c.cfg.Endpoint.AuthURL), true
+	case "token_url":
+		return object.NewString(c.cfg.Endpoint.TokenURL), true
+	case "device_auth_url":
+		return object.NewString(c.cfg.Endpoint.DeviceAuthURL), true
+	case "redirect_url":
+		return object.NewString(c.cfg.RedirectURL), true
+	case "auth_code_url":
+		return object.NewBuiltin("oauth2.config.auth_code_url", c.authCodeURL), true
+	case "exchange":
+		return object.NewBuiltin("oauth2.config.exchange", c.exchange), true
+	case "device_auth":
+		return object.NewBuiltin("oauth2.config.device_auth", c.deviceAuth), true
+	case "device_token":
+		return object.NewBuiltin("oauth2.config.device_token", c.deviceToken), true
+	case "refresh":
+		return object.NewBuiltin("oauth2.config.refresh", c.refresh), true
+	case "token_source":
+		return object.NewBuiltin("oauth2.config.token_source", c.tokenSource), true
+	}
+	return nil, false
+}
+
+// parseAuthParams reads the options that add parameters to authorization
+// and token requests. Options other than verifier and params must be listed
+// in allowed.
+func parseAuthParams(fn string, args []object.Object, allowed ...string) ([]oauth2.AuthCodeOption, *object.Error) {
+	var opts []oauth2.AuthCodeOption
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range m.Value() {
+		switch {
+		case key == "verifier":
+			var verifier string
+			if verifier, err = object.AsString(value); err == nil {
+				if fn == "oauth2.config.auth_code_url" {
+					opts = append(opts, oauth2.S256ChallengeOption(verifier))
+				} else {
+					opts = append(opts, oauth2.VerifierOption(verifier))
+				}
+			}
+		case key == "params":
+			var params map[string]string
+			if params, err = asStringMap(value); err == nil {
+				for k, v := range params {
+					opts = append(opts, oauth2.SetAuthURLParam(k, v))
+				}
+			}
+		case key == "offline" && contains(allowed, key):
+			var offline bool
+			if offline, err = object.AsBool(value); err == nil && offline {
+				opts = append(opts, oauth2.AccessTypeOffline)
+			}
+		case key == "nonce" && contains(allowed, key):
+			var nonce string
+			if nonce, err = object.AsString(value); err == nil {
+				opts = append(opts, oauth2.SetAuthURLParam("nonce", nonce))
+			}
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) authCodeURL(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("oauth2.config.auth_code_url", 1, 2, args); err != nil {
+		return err
+	}
+	state, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts, err := parseAuthParams("oauth2.config.auth_code_url", args[1:], "offline", "nonce")
+	if err != nil {
+		return err
+	}
+	return object.NewString(c.cfg.AuthCodeURL(state, opts...))
+}
+
+func (c *Config) exchange(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("oauth2.config.exchange", 1, 2, args); err != nil {
+		return err
+	}
+	code, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts, err := parseAuthParams("oauth2.config.exchange", args[1:])
+	if err != nil {
+		return err
+	}
+	t, exchangeErr := c.cfg.Exchange(ctx, code, opts...)
+	if exchangeErr != nil {
+		return oauth2Error(exchangeErr)
+	}
+	return tokenValue(t)
+}
+
+func (c *Config) deviceAuth(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("oauth2.config.device_auth", 0, 1, args); err != nil {
+		return err
+	}
+	opts, err := parseAuthParams("oauth2.config.device_auth", args)
+	if err != nil {
+		return err
+	}
+	if c.cfg.Endpoint.DeviceAuthURL == "" {
+		return object.Errorf("value error: oauth2.config has no device_auth_url")
+	}
+	resp, authErr := c.cfg.DeviceAuth(ctx, opts...)
+	if authErr != nil {
+		return oauth2Error(authErr)
+	}
+	m := map[string]object.Object{
+		"device_code":               object.NewString(resp.DeviceCode),
+		"user_code":                 object.NewString(resp.UserCode),
+		"verification_uri":          object.NewString(resp.VerificationURI),
+		"verification_uri_complete": object.NewString(resp.VerificationURIComplete),
+		"interval":                  object.NewInt(resp.Interval),
+		"expiry":                    object.Nil,
+	}
+	if !resp.Expiry.IsZero() {
+		m["expiry"] = object.NewTime(resp.Expiry)
+	}
+	return object.NewMap(m)
+}
+
+func (c *Config) deviceToken(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("oauth2.config.device_token", 1, 2, args); err != nil {
+		return err
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return err
+	}
+	resp := &oauth2.DeviceAuthResponse{}
+	if resp.DeviceCode, err = object.AsString(m.GetWithDefault("device_code", object.NewString(""))); err != nil {
+		return err
+	}
+	if resp.DeviceCode == "" {
+		return object.Errorf("value error: device authorization has no device_code")
+	}
+	if interval, ok := m.Get("interval").(*object.Int); ok {
+		resp.Interval = interval.Value()
+	}
+	if expiry, ok := m.Get("expiry").(*object.Time); ok {
+		resp.Expiry = expiry.Value()
+	}
+	opts, err := parseAuthParams("oauth2.config.device_token", args[1:])
+	if err != nil {
+		return err
+	}
+	// Polls the token endpoint until the user approves or denies the
+	// request, the device code expires, or the context is cancelled.
+	t, tokenErr := c.cfg.DeviceAccessToken(ctx, resp, opts...)
+	if tokenErr != nil {
+		return oauth2Error(tokenErr)
+	}
+	return tokenValue(t)
+}
+
+func (c *Config) refresh(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("oauth2.config.refresh", 1, args); err != nil {
+		return err
+	}
+	t, err := asToken(args[0])
+	if err != nil {
+		return err
+	}
+	if t.RefreshToken == "" {
+		return object.Errorf("value error: token has no refresh_token")
+	}
+	// Clearing the access token forces a refresh, even if the token hasn't
+	// expired yet.
+	t.AccessToken = ""
+	refreshed, refreshErr := c.cfg.TokenSource(ctx, t).Token()
+	if refreshErr != nil {
+		return oauth2Error(refreshErr)
+	}
+	return tokenValue(refreshed)
+}
+
+func (c *Config) tokenSource(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("oauth2.config.token_source", 0, 2, args); err != nil {
+		return err
+	}
+	var (
+		initial   *oauth2.Token
+		cacheFile string
+		err       *object.Error
+	)
+	if len(args) > 0 && args[0] != object.Nil {
+		if initial, err = asToken(args[0]); err != nil {
+			return err
+		}
+	}
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "cache_file":
+				cacheFile, err = object.AsString(value)
+			default:
+				err = object.Errorf("value error: unknown oauth2.config.token_source option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	ts := newTokenSource(ctx, nil, initial, cacheFile)
+	source, ok := ts.(*TokenSource)
+	if !ok {
+		return ts
+	}
+	if source.last == nil {
+		return object.Errorf("value error: oauth2.config.token_source requires a token or a cache_file holding one")
+	}
+	// Expired tokens are refreshed with the refresh token of the most
+	// recent one.
+	source.source = c.cfg.TokenSource(ctx, source.last)
+	return source
+}
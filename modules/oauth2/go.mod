@@ -0,0 +1,24 @@
+module github.com/risor-io/risor/modules/oauth2
+
+go 1.21
+
+replace github.com/risor-io/risor => ../..
+
+require (
+	github.com/coreos/go-oidc/v3 v3.9.0
+	github.com/go-jose/go-jose/v3 v3.0.1
+	github.com/risor-io/risor v1.1.0
+	github.com/stretchr/testify v1.8.4
+	golang.org/x/oauth2 v0.16.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/crypto v0.14.0 // indirect
+	google.golang.org/appengine v1.6.8 // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
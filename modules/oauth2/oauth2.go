@@ -0,0 +1,423 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// endpointOptions hold the options shared by config and client_credentials
+// that describe the client and the provider's endpoints.
+type endpointOptions struct {
+	clientID      string
+	clientSecret  string
+	issuer        string
+	authURL       string
+	tokenURL      string
+	deviceAuthURL string
+	scopes        []string
+	authStyle     oauth2.AuthStyle
+}
+
+// parse sets the option with the given key, if it is an endpoint option,
+// and reports whether it was.
+func (o *endpointOptions) parse(key string, value object.Object) (bool, *object.Error) {
+	var err *object.Error
+	switch key {
+	case "client_id":
+		o.clientID, err = object.AsString(value)
+	case "client_secret":
+		o.clientSecret, err = object.AsString(value)
+	case "issuer":
+		o.issuer, err = object.AsString(value)
+	case "auth_url":
+		o.authURL, err = object.AsString(value)
+	case "token_url":
+		o.tokenURL, err = object.AsString(value)
+	case "device_auth_url":
+		o.deviceAuthURL, err = object.AsString(value)
+	case "scopes":
+		o.scopes, err = asStrings(value)
+	case "auth_style":
+		var style string
+		if style, err = object.AsString(value); err == nil {
+			switch style {
+			case "auto":
+				o.authStyle = oauth2.AuthStyleAutoDetect
+			case "header":
+				o.authStyle = oauth2.AuthStyleInHeader
+			case "params":
+				o.authStyle = oauth2.AuthStyleInParams
+			default:
+				err = object.Errorf("value error: invalid auth_style %q (expected auto, header, or params)", style)
+			}
+		}
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// endpoint returns the provider's endpoints. Endpoints that weren't given
+// are discovered from the issuer, if there is one.
+func (o *endpointOptions) endpoint(ctx context.Context) (oauth2.Endpoint, error) {
+	endpoint := oauth2.Endpoint{
+		AuthURL:       o.authURL,
+		TokenURL:      o.tokenURL,
+		DeviceAuthURL: o.deviceAuthURL,
+		AuthStyle:     o.authStyle,
+	}
+	if o.issuer != "" && (endpoint.AuthURL == "" || endpoint.TokenURL == "" || endpoint.DeviceAuthURL == "") {
+		provider, err := oidc.NewProvider(ctx, o.issuer)
+		if err != nil {
+			return endpoint, err
+		}
+		discovered := provider.Endpoint()
+		if endpoint.AuthURL == "" {
+			endpoint.AuthURL = discovered.AuthURL
+		}
+		if endpoint.TokenURL == "" {
+			endpoint.TokenURL = discovered.TokenURL
+		}
+		if endpoint.DeviceAuthURL == "" {
+			endpoint.DeviceAuthURL = discovered.DeviceAuthURL
+		}
+	}
+	return endpoint, nil
+}
+
+func NewConfig(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("oauth2.config", 1, args); err != nil {
+		return err
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return err
+	}
+	var opts endpointOptions
+	var redirectURL string
+	for key, value := range m.Value() {
+		if ok, err := opts.parse(key, value); ok {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		switch key {
+		case "redirect_url":
+			redirectURL, err = object.AsString(value)
+		default:
+			err = object.Errorf("value error: unknown oauth2.config option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if opts.clientID == "" {
+		return object.Errorf("value error: oauth2.config requires a client_id")
+	}
+	endpoint, endpointErr := opts.endpoint(ctx)
+	if endpointErr != nil {
+		return oauth2Error(endpointErr)
+	}
+	return NewConfigObject(&oauth2.Config{
+		ClientID:     opts.clientID,
+		ClientSecret: opts.clientSecret,
+		Endpoint:     endpoint,
+		RedirectURL:  redirectURL,
+		Scopes:       opts.scopes,
+	})
+}
+
+func ClientCredentials(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("oauth2.client_credentials", 1, args); err != nil {
+		return err
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return err
+	}
+	var opts endpointOptions
+	var cacheFile string
+	params := url.Values{}
+	for key, value := range m.Value() {
+		if ok, err := opts.parse(key, value); ok {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		switch key {
+		case "audience":
+			var audience string
+			if audience, err = object.AsString(value); err == nil {
+				params.Set("audience", audience)
+			}
+		case "params":
+			var extra map[string]string
+			if extra, err = asStringMap(value); err == nil {
+				for k, v := range extra {
+					params.Set(k, v)
+				}
+			}
+		case "cache_file":
+			cacheFile, err = object.AsString(value)
+		default:
+			err = object.Errorf("value error: unknown oauth2.client_credentials option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if opts.clientID == "" {
+		return object.Errorf("value error: oauth2.client_credentials requires a client_id")
+	}
+	if opts.tokenURL == "" && opts.issuer == "" {
+		return object.Errorf("value error: oauth2.client_credentials requires a token_url or an issuer")
+	}
+	endpoint, endpointErr := opts.endpoint(ctx)
+	if endpointErr != nil {
+		return oauth2Error(endpointErr)
+	}
+	cfg := &clientcredentials.Config{
+		ClientID:       opts.clientID,
+		ClientSecret:   opts.clientSecret,
+		TokenURL:       endpoint.TokenURL,
+		Scopes:         opts.scopes,
+		EndpointParams: params,
+		AuthStyle:      endpoint.AuthStyle,
+	}
+	return newTokenSource(ctx, cfg.TokenSource(ctx), nil, cacheFile)
+}
+
+func Discover(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("oauth2.discover", 1, args); err != nil {
+		return err
+	}
+	issuer, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	provider, providerErr := oidc.NewProvider(ctx, issuer)
+	if providerErr != nil {
+		return oauth2Error(providerErr)
+	}
+	var metadata map[string]interface{}
+	if err := provider.Claims(&metadata); err != nil {
+		return oauth2Error(err)
+	}
+	return object.FromGoType(metadata)
+}
+
+func VerifyIDToken(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("oauth2.verify_id_token", 2, args); err != nil {
+		return err
+	}
+	raw, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	m, err := object.AsMap(args[1])
+	if err != nil {
+		return err
+	}
+	var issuer, nonce string
+	cfg := &oidc.Config{}
+	for key, value := range m.Value() {
+		switch key {
+		case "issuer":
+			issuer, err = object.AsString(value)
+		case "client_id":
+			cfg.ClientID, err = object.AsString(value)
+		case "nonce":
+			nonce, err = object.AsString(value)
+		case "algorithms":
+			cfg.SupportedSigningAlgs, err = asStrings(value)
+		case "skip_client_id_check":
+			cfg.SkipClientIDCheck, err = object.AsBool(value)
+		case "skip_expiry_check":
+			cfg.SkipExpiryCheck, err = object.AsBool(value)
+		default:
+			err = object.Errorf("value error: unknown oauth2.verify_id_token option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if issuer == "" {
+		return object.Errorf("value error: oauth2.verify_id_token requires an issuer")
+	}
+	if cfg.ClientID == "" && !cfg.SkipClientIDCheck {
+		return object.Errorf("value error: oauth2.verify_id_token requires a client_id, unless skip_client_id_check is set")
+	}
+	provider, providerErr := oidc.NewProvider(ctx, issuer)
+	if providerErr != nil {
+		return oauth2Error(providerErr)
+	}
+	token, verifyErr := provider.Verifier(cfg).Verify(ctx, raw)
+	if verifyErr != nil {
+		return oauth2Error(verifyErr)
+	}
+	if nonce != "" && token.Nonce != nonce {
+		return oauth2Error(fmt.Errorf("oidc: nonce did not match"))
+	}
+	var claims map[string]interface{}
+	if err := token.Claims(&claims); err != nil {
+		return oauth2Error(err)
+	}
+	return object.FromGoType(claims)
+}
+
+func PKCE(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("oauth2.pkce", 0, args); err != nil {
+		return err
+	}
+	verifier := oauth2.GenerateVerifier()
+	return object.NewMap(map[string]object.Object{
+		"verifier":  object.NewString(verifier),
+		"challenge": object.NewString(oauth2.S256ChallengeFromVerifier(verifier)),
+		"method":    object.NewString("S256"),
+	})
+}
+
+// tokenValue converts a token to a map, including the ID token and scope the
+// provider may have returned alongside it.
+func tokenValue(t *oauth2.Token) object.Object {
+	m := map[string]object.Object{
+		"access_token":  object.NewString(t.AccessToken),
+		"token_type":    object.NewString(t.Type()),
+		"refresh_token": object.NewString(t.RefreshToken),
+		"expiry":        object.Nil,
+		"id_token":      object.NewString(""),
+		"scope":         object.NewString(""),
+	}
+	if !t.Expiry.IsZero() {
+		m["expiry"] = object.NewTime(t.Expiry)
+	}
+	if idToken, ok := t.Extra("id_token").(string); ok {
+		m["id_token"] = object.NewString(idToken)
+	}
+	if scope, ok := t.Extra("scope").(string); ok {
+		m["scope"] = object.NewString(scope)
+	}
+	return object.NewMap(m)
+}
+
+// asToken converts a map, as returned by tokenValue, back to a token.
+func asToken(obj object.Object) (*oauth2.Token, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	t := &oauth2.Token{}
+	extra := map[string]interface{}{}
+	for key, value := range m.Value() {
+		if value == object.Nil {
+			continue
+		}
+		switch key {
+		case "access_token":
+			t.AccessToken, err = object.AsString(value)
+		case "token_type":
+			t.TokenType, err = object.AsString(value)
+		case "refresh_token":
+			t.RefreshToken, err = object.AsString(value)
+		case "expiry":
+			t.Expiry, err = arg.Time("expiry", value)
+		case "id_token", "scope":
+			var s string
+			if s, err = object.AsString(value); err == nil && s != "" {
+				extra[key] = s
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if t.AccessToken == "" && t.RefreshToken == "" {
+		return nil, object.Errorf("value error: token has no access_token or refresh_token")
+	}
+	return t.WithExtra(extra), nil
+}
+
+// asStrings accepts a list of strings, or a single string of values
+// separated by spaces, as scopes are written in OAuth2 requests.
+func asStrings(obj object.Object) ([]string, *object.Error) {
+	if s, ok := obj.(*object.String); ok {
+		return strings.Fields(s.Value()), nil
+	}
+	return object.AsStringSlice(obj)
+}
+
+func asStringMap(obj object.Object) (map[string]string, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, m.Size())
+	for k, v := range m.Value() {
+		s, err := object.AsString(v)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = s
+	}
+	return result, nil
+}
+
+// oauth2Error wraps an error, including the error code and description
+// returned by the provider's token endpoint, if there are any.
+func oauth2Error(err error) *object.Error {
+	if re, ok := err.(*oauth2.RetrieveError); ok && re.ErrorCode != "" {
+		if re.ErrorDescription != "" {
+			return object.NewError(fmt.Errorf("oauth2 error: %s: %s", re.ErrorCode, re.ErrorDescription))
+		}
+		return object.NewError(fmt.Errorf("oauth2 error: %s", re.ErrorCode))
+	}
+	return object.NewError(fmt.Errorf("oauth2 error: %w", err))
+}
+
+// cachedToken is the form tokens are saved in by token sources with a
+// cache file.
+type cachedToken struct {
+	*oauth2.Token
+	IDToken string `json:"id_token,omitempty"`
+}
+
+func encodeToken(t *oauth2.Token) ([]byte, error) {
+	cached := cachedToken{Token: t}
+	cached.IDToken, _ = t.Extra("id_token").(string)
+	return json.MarshalIndent(cached, "", "  ")
+}
+
+func decodeToken(data []byte) (*oauth2.Token, error) {
+	var cached cachedToken
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+	if cached.Token == nil {
+		return nil, fmt.Errorf("invalid token cache")
+	}
+	if cached.IDToken != "" {
+		return cached.Token.WithExtra(map[string]interface{}{"id_token": cached.IDToken}), nil
+	}
+	return cached.Token, nil
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("oauth2", map[string]object.Object{
+		"client_credentials": object.NewBuiltin("client_credentials", ClientCredentials),
+		"config":             object.NewBuiltin("config", NewConfig),
+		"discover":           object.NewBuiltin("discover", Discover),
+		"pkce":               object.NewBuiltin("pkce", PKCE),
+		"verify_id_token":    object.NewBuiltin("verify_id_token", VerifyIDToken),
+	})
+}
@@ -0,0 +1,392 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// provider is a fake OpenID Connect provider.
+type provider struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	issued atomic.Int32
+	forms  chan url.Values
+}
+
+func newProvider(t *testing.T) *provider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	p := &provider{key: key, forms: make(chan url.Values, 10)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"device_authorization_endpoint":         p.URL + "/device",
+			"jwks_uri":                              p.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"device_code":      "device-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": p.URL + "/activate",
+			"expires_in":       60,
+			"interval":         1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		p.forms <- r.PostForm
+		if id, _, ok := r.BasicAuth(); ok {
+			r.PostForm.Set("client_id", id)
+		}
+		if r.PostForm.Get("grant_type") == "authorization_code" && r.PostForm.Get("code") != "good-code" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{
+				"error":             "invalid_grant",
+				"error_description": "unknown code",
+			})
+			return
+		}
+		n := p.issued.Add(1)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"access_token":  "access-" + string(rune('0'+n)),
+			"token_type":    "Bearer",
+			"refresh_token": "refresh-token",
+			"expires_in":    3600,
+			"scope":         r.PostForm.Get("scope"),
+			"id_token":      p.idToken(t, r.PostForm.Get("client_id"), "", time.Hour),
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *provider) idToken(t *testing.T, audience, nonce string, ttl time.Duration) string {
+	t.Helper()
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: p.key},
+		(&jose.SignerOptions{}).WithHeader("kid", "test"),
+	)
+	require.NoError(t, err)
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss":   p.URL,
+		"sub":   "user-1",
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(ttl).Unix(),
+		"email": "ada@example.com",
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	raw, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(t, err)
+	return raw
+}
+
+func (p *provider) lastForm(t *testing.T) url.Values {
+	t.Helper()
+	select {
+	case form := <-p.forms:
+		return form
+	default:
+		t.Fatal("no token request was made")
+		return nil
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func call(t *testing.T, obj object.Object, method string, args ...object.Object) object.Object {
+	t.Helper()
+	attr, ok := obj.GetAttr(method)
+	require.True(t, ok, method)
+	fn, ok := attr.(*object.Builtin)
+	require.True(t, ok)
+	return fn.Call(context.Background(), args...)
+}
+
+func opts(m map[string]interface{}) object.Object {
+	return object.FromGoType(m)
+}
+
+func str(t *testing.T, obj object.Object, key string) string {
+	t.Helper()
+	m, ok := obj.(*object.Map)
+	require.True(t, ok, obj.Inspect())
+	s, ok := m.Get(key).(*object.String)
+	require.True(t, ok, m.Inspect())
+	return s.Value()
+}
+
+func TestClientCredentials(t *testing.T) {
+	p := newProvider(t)
+	ctx := context.Background()
+	ts := ClientCredentials(ctx, opts(map[string]interface{}{
+		"client_id":     "svc",
+		"client_secret": "secret",
+		"issuer":        p.URL,
+		"scopes":        "read write",
+		"audience":      "https://api.example.com",
+	}))
+	require.IsType(t, &TokenSource{}, ts, ts.Inspect())
+
+	token := call(t, ts, "token")
+	require.Equal(t, "access-1", str(t, token, "access_token"))
+	require.Equal(t, "Bearer", str(t, token, "token_type"))
+	require.Equal(t, "read write", str(t, token, "scope"))
+	require.IsType(t, &object.Time{}, token.(*object.Map).Get("expiry"))
+
+	form := p.lastForm(t)
+	require.Equal(t, "client_credentials", form.Get("grant_type"))
+	require.Equal(t, "https://api.example.com", form.Get("audience"))
+
+	// The token is reused until it expires
+	require.Equal(t, object.NewString("access-1"), call(t, ts, "access_token"))
+	headers := call(t, ts, "headers")
+	require.Equal(t, "Bearer access-1", str(t, headers, "Authorization"))
+	require.Equal(t, int32(1), p.issued.Load())
+}
+
+func TestTokenCache(t *testing.T) {
+	p := newProvider(t)
+	ctx := context.Background()
+	cacheFile := filepath.Join(t.TempDir(), "token.json")
+	options := opts(map[string]interface{}{
+		"client_id":  "svc",
+		"token_url":  p.URL + "/token",
+		"cache_file": cacheFile,
+	})
+	ts := ClientCredentials(ctx, options)
+	require.Equal(t, object.NewString("access-1"), call(t, ts, "access_token"))
+
+	// A second token source picks up the cached token
+	ts = ClientCredentials(ctx, options)
+	token := call(t, ts, "token")
+	require.Equal(t, "access-1", str(t, token, "access_token"))
+	require.NotEmpty(t, str(t, token, "id_token"))
+	require.Equal(t, int32(1), p.issued.Load())
+}
+
+func TestAuthCodeFlow(t *testing.T) {
+	p := newProvider(t)
+	ctx := context.Background()
+	cfg := NewConfig(ctx, opts(map[string]interface{}{
+		"client_id":     "app",
+		"client_secret": "secret",
+		"issuer":        p.URL,
+		"redirect_url":  "http://localhost:8080/callback",
+		"scopes":        []interface{}{"openid", "email"},
+	}))
+	require.IsType(t, &Config{}, cfg, cfg.Inspect())
+	require.Equal(t, object.NewString(p.URL+"/authorize"), mustAttr(t, cfg, "auth_url"))
+	require.Equal(t, object.NewString(p.URL+"/device"), mustAttr(t, cfg, "device_auth_url"))
+	require.Equal(t, object.NewStringList([]string{"openid", "email"}), mustAttr(t, cfg, "scopes"))
+
+	pkce := PKCE(ctx)
+	verifier := str(t, pkce, "verifier")
+	require.Equal(t, oauth2.S256ChallengeFromVerifier(verifier), str(t, pkce, "challenge"))
+
+	authURL := call(t, cfg, "auth_code_url", object.NewString("state-1"), opts(map[string]interface{}{
+		"verifier": verifier,
+		"nonce":    "n-1",
+		"offline":  true,
+	}))
+	u, err := url.Parse(authURL.(*object.String).Value())
+	require.NoError(t, err)
+	q := u.Query()
+	require.Equal(t, "state-1", q.Get("state"))
+	require.Equal(t, "n-1", q.Get("nonce"))
+	require.Equal(t, "offline", q.Get("access_type"))
+	require.Equal(t, str(t, pkce, "challenge"), q.Get("code_challenge"))
+	require.Equal(t, "S256", q.Get("code_challenge_method"))
+	require.Equal(t, "openid email", q.Get("scope"))
+
+	token := call(t, cfg, "exchange", object.NewString("good-code"), opts(map[string]interface{}{"verifier": verifier}))
+	require.Equal(t, "access-1", str(t, token, "access_token"))
+	require.Equal(t, verifier, p.lastForm(t).Get("code_verifier"))
+
+	claims := VerifyIDToken(ctx, object.NewString(str(t, token, "id_token")), opts(map[string]interface{}{
+		"issuer":    p.URL,
+		"client_id": "app",
+	}))
+	require.Equal(t, "user-1", str(t, claims, "sub"))
+	require.Equal(t, "ada@example.com", str(t, claims, "email"))
+
+	bad := call(t, cfg, "exchange", object.NewString("bad-code"))
+	require.IsType(t, &object.Error{}, bad)
+	require.Equal(t, "oauth2 error: invalid_grant: unknown code", bad.(*object.Error).Message().Value())
+	p.lastForm(t)
+
+	refreshed := call(t, cfg, "refresh", token)
+	require.Equal(t, "access-2", str(t, refreshed, "access_token"))
+	require.Equal(t, "refresh_token", p.lastForm(t).Get("grant_type"))
+
+	ts := call(t, cfg, "token_source", token)
+	require.Equal(t, object.NewString("access-1"), call(t, ts, "access_token"))
+	require.Equal(t, int32(2), p.issued.Load())
+}
+
+func TestDeviceFlow(t *testing.T) {
+	p := newProvider(t)
+	ctx := context.Background()
+	cfg := NewConfig(ctx, opts(map[string]interface{}{
+		"client_id": "cli",
+		"issuer":    p.URL,
+	}))
+	auth := call(t, cfg, "device_auth")
+	require.Equal(t, "ABCD-EFGH", str(t, auth, "user_code"))
+	require.Equal(t, p.URL+"/activate", str(t, auth, "verification_uri"))
+	require.Equal(t, object.NewInt(1), auth.(*object.Map).Get("interval"))
+
+	token := call(t, cfg, "device_token", auth)
+	require.Equal(t, "access-1", str(t, token, "access_token"))
+	form := p.lastForm(t)
+	require.Equal(t, "urn:ietf:params:oauth:grant-type:device_code", form.Get("grant_type"))
+	require.Equal(t, "device-code", form.Get("device_code"))
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	p := newProvider(t)
+	ctx := context.Background()
+	verify := func(raw string, options map[string]interface{}) object.Object {
+		options["issuer"] = p.URL
+		return VerifyIDToken(ctx, object.NewString(raw), opts(options))
+	}
+
+	claims := verify(p.idToken(t, "app", "n-1", time.Hour), map[string]interface{}{"client_id": "app", "nonce": "n-1"})
+	require.Equal(t, "user-1", str(t, claims, "sub"))
+
+	result := verify(p.idToken(t, "app", "n-1", time.Hour), map[string]interface{}{"client_id": "app", "nonce": "n-2"})
+	require.IsType(t, &object.Error{}, result)
+	require.Equal(t, "oauth2 error: oidc: nonce did not match", result.(*object.Error).Message().Value())
+
+	result = verify(p.idToken(t, "other", "", time.Hour), map[string]interface{}{"client_id": "app"})
+	require.IsType(t, &object.Error{}, result)
+	require.Contains(t, result.(*object.Error).Message().Value(), "audience")
+
+	expired := p.idToken(t, "app", "", -time.Hour)
+	result = verify(expired, map[string]interface{}{"client_id": "app"})
+	require.IsType(t, &object.Error{}, result)
+	require.Contains(t, result.(*object.Error).Message().Value(), "expired")
+
+	claims = verify(expired, map[string]interface{}{"skip_client_id_check": true, "skip_expiry_check": true})
+	require.Equal(t, "user-1", str(t, claims, "sub"))
+}
+
+func TestDiscover(t *testing.T) {
+	p := newProvider(t)
+	metadata := Discover(context.Background(), object.NewString(p.URL))
+	require.Equal(t, p.URL+"/token", str(t, metadata, "token_endpoint"))
+	require.Equal(t, p.URL+"/keys", str(t, metadata, "jwks_uri"))
+}
+
+func mustAttr(t *testing.T, obj object.Object, name string) object.Object {
+	t.Helper()
+	attr, ok := obj.GetAttr(name)
+	require.True(t, ok, name)
+	return attr
+}
+
+func TestOptionErrors(t *testing.T) {
+	ctx := context.Background()
+	cfg := NewConfigObject(&oauth2.Config{ClientID: "app"})
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"unknown config option",
+			NewConfig(ctx, opts(map[string]interface{}{"client_id": "app", "bogus": 1})),
+			`value error: unknown oauth2.config option "bogus"`,
+		},
+		{
+			"missing client_id",
+			NewConfig(ctx, opts(map[string]interface{}{"token_url": "http://localhost/token"})),
+			"value error: oauth2.config requires a client_id",
+		},
+		{
+			"invalid auth_style",
+			NewConfig(ctx, opts(map[string]interface{}{"client_id": "app", "auth_style": "basic"})),
+			`value error: invalid auth_style "basic" (expected auto, header, or params)`,
+		},
+		{
+			"unknown client_credentials option",
+			ClientCredentials(ctx, opts(map[string]interface{}{"client_id": "svc", "redirect_url": "x"})),
+			`value error: unknown oauth2.client_credentials option "redirect_url"`,
+		},
+		{
+			"missing token_url",
+			ClientCredentials(ctx, opts(map[string]interface{}{"client_id": "svc"})),
+			"value error: oauth2.client_credentials requires a token_url or an issuer",
+		},
+		{
+			"missing issuer",
+			VerifyIDToken(ctx, object.NewString("x"), opts(map[string]interface{}{"client_id": "app"})),
+			"value error: oauth2.verify_id_token requires an issuer",
+		},
+		{
+			"unknown auth_code_url option",
+			call(t, cfg, "auth_code_url", object.NewString("state"), opts(map[string]interface{}{"prompt": "consent"})),
+			`value error: unknown oauth2.config.auth_code_url option "prompt"`,
+		},
+		{
+			"nonce on exchange",
+			call(t, cfg, "exchange", object.NewString("code"), opts(map[string]interface{}{"nonce": "n"})),
+			`value error: unknown oauth2.config.exchange option "nonce"`,
+		},
+		{
+			"no device_auth_url",
+			call(t, cfg, "device_auth"),
+			"value error: oauth2.config has no device_auth_url",
+		},
+		{
+			"refresh without refresh_token",
+			call(t, cfg, "refresh", opts(map[string]interface{}{"access_token": "a"})),
+			"value error: token has no refresh_token",
+		},
+		{
+			"token_source without token",
+			call(t, cfg, "token_source"),
+			"value error: oauth2.config.token_source requires a token or a cache_file holding one",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+	"golang.org/x/oauth2"
+)
+
+const TOKEN_SOURCE object.Type = "oauth2.token_source"
+
+// TokenSource hands out tokens, reusing each one until it expires and then
+// fetching or refreshing a new one. With a cache file, tokens are saved so
+// they can be reused by later runs of a script.
+type TokenSource struct {
+	source    oauth2.TokenSource
+	cacheFile string
+	mu        sync.Mutex
+	last      *oauth2.Token
+}
+
+// newTokenSource returns a TokenSource that reuses the initial token, or the
+// token in the cache file, while it is valid, and then gets new tokens from
+// the given source.
+func newTokenSource(ctx context.Context, source oauth2.TokenSource, initial *oauth2.Token, cacheFile string) object.Object {
+	if initial == nil && cacheFile != "" {
+		data, err := ros.GetDefaultOS(ctx).ReadFile(cacheFile)
+		if err == nil {
+			if initial, err = decodeToken(data); err != nil {
+				return object.Errorf("value error: invalid token cache %q: %s", cacheFile, err)
+			}
+		}
+	}
+	return &TokenSource{
+		source:    oauth2.ReuseTokenSource(initial, source),
+		cacheFile: cacheFile,
+		last:      initial,
+	}
+}
+
+// Token returns a valid token, saving it to the cache file if it is new.
+func (ts *TokenSource) Token(ctx context.Context) (*oauth2.Token, error) {
+	t, err := ts.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.cacheFile != "" && (ts.last == nil || ts.last.AccessToken != t.AccessToken) {
+		data, err := encodeToken(t)
+		if err != nil {
+			return nil, err
+		}
+		if err := ros.GetDefaultOS(ctx).WriteFile(ts.cacheFile, data, 0o600); err != nil {
+			return nil, err
+		}
+	}
+	ts.last = t
+	return t, nil
+}
+
+func (ts *TokenSource) Type() object.Type {
+	return TOKEN_SOURCE
+}
+
+func (ts *TokenSource) Inspect() string {
+	if ts.cacheFile != "" {
+		return fmt.Sprintf("oauth2.token_source(cache_file=%s)", ts.cacheFile)
+	}
+	return "oauth2.token_source()"
+}
+
+func (ts *TokenSource) String() string {
+	return ts.Inspect()
+}
+
+func (ts *TokenSource) Interface() interface{} {
+	return ts.source
+}
+
+func (ts *TokenSource) Equals(other object.Object) object.Object {
+	return object.NewBool(ts == other)
+}
+
+func (ts *TokenSource) IsTruthy() bool {
+	return true
+}
+
+func (ts *TokenSource) Cost() int {
+	return 0
+}
+
+func (ts *TokenSource) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", TOKEN_SOURCE)
+}
+
+func (ts *TokenSource) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", TOKEN_SOURCE, opType)
+}
+
+func (ts *TokenSource) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", TOKEN_SOURCE, name)
+}
+
+func (ts *TokenSource) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "token":
+		return object.NewBuiltin("oauth2.token_source.token", ts.token), true
+	case "access_token":
+		return object.NewBuiltin("oauth2.token_source.access_token", ts.accessToken), true
+	case "headers":
+		return object.NewBuiltin("oauth2.token_source.headers", ts.headers), true
+	}
+	return nil, false
+}
+
+func (ts *TokenSource) token(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("oauth2.token_source.token", 0, args); err != nil {
+		return err
+	}
+	t, err := ts.Token(ctx)
+	if err != nil {
+		return oauth2Error(err)
+	}
+	return tokenValue(t)
+}
+
+func (ts *TokenSource) accessToken(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("oauth2.token_source.access_token", 0, args); err != nil {
+		return err
+	}
+	t, err := ts.Token(ctx)
+	if err != nil {
+		return oauth2Error(err)
+	}
+	return object.NewString(t.AccessToken)
+}
+
+func (ts *TokenSource) headers(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("oauth2.token_source.headers", 0, args); err != nil {
+		return err
+	}
+	t, err := ts.Token(ctx)
+	if err != nil {
+		return oauth2Error(err)
+	}
+	return object.NewMap(map[string]object.Object{
+		"Authorization": object.NewString(t.Type() + " " + t.AccessToken),
+	})
+}
@@ -3,6 +3,7 @@ package os
 import (
 	"bytes"
 	"context"
+	"io"
 
 	"github.com/risor-io/risor/internal/arg"
 	"github.com/risor-io/risor/object"
@@ -200,6 +201,50 @@ func Create(ctx context.Context, args ...object.Object) object.Object {
 	return object.NewFile(ctx, file, name)
 }
 
+// Reader returns a reader for the named file, or wraps another readable
+// object such as a file, byte_slice, or http response.
+func Reader(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("os.reader", 1, args); err != nil {
+		return err
+	}
+	switch obj := args[0].(type) {
+	case *object.String, *object.Path:
+		name, _ := object.AsString(obj)
+		file, ioErr := GetOS(ctx).Open(name)
+		if ioErr != nil {
+			return object.NewError(ioErr)
+		}
+		return object.NewReader(file)
+	}
+	reader, err := object.AsReader(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewReader(reader)
+}
+
+// Writer returns a writer that creates or truncates the named file, or wraps
+// another writable object such as a file or buffer.
+func Writer(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("os.writer", 1, args); err != nil {
+		return err
+	}
+	switch obj := args[0].(type) {
+	case *object.String, *object.Path:
+		name, _ := object.AsString(obj)
+		file, ioErr := GetOS(ctx).Create(name)
+		if ioErr != nil {
+			return object.NewError(ioErr)
+		}
+		return object.NewWriter(file)
+	}
+	writer, err := object.AsWriter(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewWriter(writer)
+}
+
 func Setenv(ctx context.Context, args ...object.Object) object.Object {
 	if err := arg.Require("os.setenv", 2, args); err != nil {
 		return err
@@ -290,6 +335,28 @@ func WriteFile(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
+	var perm int64 = 0o644
+	if len(args) == 3 {
+		perm, err = object.AsInt(args[2])
+		if err != nil {
+			return err
+		}
+	}
+	// Readers are streamed to the file rather than read into memory
+	if reader, ok := args[1].(*object.Reader); ok {
+		file, ioErr := GetOS(ctx).OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, os.FileMode(perm))
+		if ioErr != nil {
+			return object.NewError(ioErr)
+		}
+		if _, ioErr := io.Copy(file, reader); ioErr != nil {
+			file.Close()
+			return object.NewError(ioErr)
+		}
+		if ioErr := file.Close(); ioErr != nil {
+			return object.NewError(ioErr)
+		}
+		return object.Nil
+	}
 	var data []byte
 	switch arg := args[1].(type) {
 	case *object.ByteSlice:
@@ -297,14 +364,7 @@ func WriteFile(ctx context.Context, args ...object.Object) object.Object {
 	case *object.String:
 		data = []byte(arg.Value())
 	default:
-		return object.Errorf("type error: expected byte_slice or string (got %s)", args[1].Type())
-	}
-	var perm int64 = 0o644
-	if len(args) == 3 {
-		perm, err = object.AsInt(args[2])
-		if err != nil {
-			return err
-		}
+		return object.Errorf("type error: expected byte_slice, string, or reader (got %s)", args[1].Type())
 	}
 	if err := GetOS(ctx).WriteFile(filename, data, os.FileMode(perm)); err != nil {
 		return object.NewError(err)
@@ -503,6 +563,7 @@ func Module() *object.Module {
 		"open":            object.NewBuiltin("open", Open),
 		"read_dir":        object.NewBuiltin("read_dir", ReadDir),
 		"read_file":       object.NewBuiltin("read_file", ReadFile),
+		"reader":          object.NewBuiltin("reader", Reader),
 		"remove":          object.NewBuiltin("remove", Remove),
 		"remove_all":      object.NewBuiltin("remove_all", RemoveAll),
 		"rename":          object.NewBuiltin("rename", Rename),
@@ -515,14 +576,19 @@ func Module() *object.Module {
 		"user_config_dir": object.NewBuiltin("user_config_dir", UserConfigDir),
 		"user_home_dir":   object.NewBuiltin("user_home_dir", UserHomeDir),
 		"write_file":      object.NewBuiltin("write_file", WriteFile),
+		"writer":          object.NewBuiltin("writer", Writer),
 		"stdin": object.NewDynamicAttr("stdin", func(ctx context.Context, name string) (object.Object, error) {
-			f := GetOS(ctx).Stdin()
+			f := os.Stdin(ctx)
 			return object.NewFile(ctx, f, "/dev/stdin"), nil
 		}),
 		"stdout": object.NewDynamicAttr("stdout", func(ctx context.Context, name string) (object.Object, error) {
-			f := GetOS(ctx).Stdout()
+			f := os.Stdout(ctx)
 			return object.NewFile(ctx, f, "/dev/stdout"), nil
 		}),
+		"stderr": object.NewDynamicAttr("stderr", func(ctx context.Context, name string) (object.Object, error) {
+			f := os.Stderr(ctx)
+			return object.NewFile(ctx, f, "/dev/stderr"), nil
+		}),
 	})
 }
 
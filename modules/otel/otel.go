@@ -0,0 +1,227 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/risor-io/risor/internal/arg"
+	modHttp "github.com/risor-io/risor/modules/http"
+	"github.com/risor-io/risor/object"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/risor-io/risor/modules/otel"
+
+var spanKinds = map[string]trace.SpanKind{
+	"internal": trace.SpanKindInternal,
+	"server":   trace.SpanKindServer,
+	"client":   trace.SpanKindClient,
+	"producer": trace.SpanKindProducer,
+	"consumer": trace.SpanKindConsumer,
+}
+
+type contextKey string
+
+const injectingKey = contextKey("risor:otel.injecting")
+
+// module creates spans with a tracer provider and propagator. When either is
+// nil, the global one is used at the time each span is created, so a host
+// may configure OpenTelemetry after the module is created.
+type module struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+func (m *module) tracer() trace.Tracer {
+	provider := m.provider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(instrumentationName)
+}
+
+func (m *module) textMapPropagator() propagation.TextMapPropagator {
+	if m.propagator != nil {
+		return m.propagator
+	}
+	propagator := otel.GetTextMapPropagator()
+	if len(propagator.Fields()) == 0 {
+		// No global propagator was set, so use the W3C standard ones
+		propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+	return propagator
+}
+
+// start starts a span as a child of the span in the context, or of the span
+// described by the parent option.
+func (m *module) start(ctx context.Context, fn string, name string, args []object.Object) (context.Context, trace.Span, *object.Error) {
+	var opts []trace.SpanStartOption
+	if len(args) > 0 {
+		params, err := object.AsMap(args[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		for key, value := range params.Value() {
+			switch key {
+			case "kind":
+				var kind string
+				if kind, err = object.AsString(value); err != nil {
+					break
+				}
+				spanKind, ok := spanKinds[kind]
+				if !ok {
+					err = object.Errorf("value error: invalid span kind %q", kind)
+					break
+				}
+				opts = append(opts, trace.WithSpanKind(spanKind))
+			case "attributes":
+				var attrs []attribute.KeyValue
+				if attrs, err = toAttributes(value); err == nil {
+					opts = append(opts, trace.WithAttributes(attrs...))
+				}
+			case "parent":
+				var carrier propagation.MapCarrier
+				if carrier, err = asCarrier(value); err == nil {
+					ctx = m.textMapPropagator().Extract(ctx, carrier)
+				}
+			default:
+				err = object.Errorf("value error: unknown %s option %q", fn, key)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	ctx, span := m.tracer().Start(ctx, name, opts...)
+	return ctx, span, nil
+}
+
+func asCarrier(obj object.Object) (propagation.MapCarrier, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	carrier := propagation.MapCarrier{}
+	for key, value := range m.Value() {
+		s, err := object.AsString(value)
+		if err != nil {
+			return nil, err
+		}
+		carrier.Set(key, s)
+	}
+	return carrier, nil
+}
+
+// withInjection returns a context that adds trace headers to requests sent
+// by the http module.
+func (m *module) withInjection(ctx context.Context) context.Context {
+	if ctx.Value(injectingKey) != nil {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, injectingKey, true)
+	return modHttp.WithRequestHook(ctx, func(req *http.Request) {
+		m.textMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+	})
+}
+
+// Span calls a function within a new span, which is the current span for
+// everything the function does. The span ends when the function returns.
+func (m *module) Span(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("otel.span", 2, 3, args); err != nil {
+		return err
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	fn := args[1]
+	ctx, span, err := m.start(ctx, "otel.span", name, args[2:])
+	if err != nil {
+		return err
+	}
+	defer span.End()
+	s := NewSpan(name, span)
+	var fnArgs []object.Object
+	if f, ok := fn.(*object.Function); !ok || len(f.Parameters()) > 0 {
+		fnArgs = []object.Object{s}
+	}
+	result, callErr := object.Call(m.withInjection(ctx), fn, fnArgs)
+	if callErr != nil {
+		s.recordError(callErr)
+		return object.NewError(callErr)
+	}
+	return result
+}
+
+// StartSpan starts a span that the caller must end. The span is a child of
+// the current span, but doesn't become the current span itself.
+func (m *module) StartSpan(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("otel.start_span", 1, 2, args); err != nil {
+		return err
+	}
+	name, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	_, span, err := m.start(ctx, "otel.start_span", name, args[1:])
+	if err != nil {
+		return err
+	}
+	return NewSpan(name, span)
+}
+
+// CurrentSpan returns the current span, or nil if there isn't one.
+func (m *module) CurrentSpan(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("otel.current_span", 0, args); err != nil {
+		return err
+	}
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return object.Nil
+	}
+	return NewSpan("", span)
+}
+
+// Inject returns the headers that propagate the current span, or the given
+// span, to another service.
+func (m *module) Inject(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("otel.inject", 0, 1, args); err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		s, ok := args[0].(*Span)
+		if !ok {
+			return object.Errorf("type error: otel.inject() expected a %s (%s given)", SPAN, args[0].Type())
+		}
+		ctx = trace.ContextWithSpan(ctx, s.Value())
+	}
+	carrier := propagation.MapCarrier{}
+	m.textMapPropagator().Inject(ctx, carrier)
+	headers := map[string]object.Object{}
+	for _, key := range carrier.Keys() {
+		headers[key] = object.NewString(carrier.Get(key))
+	}
+	return object.NewMap(headers)
+}
+
+// Module returns an otel module that uses the global tracer provider and
+// propagator.
+func Module() *object.Module {
+	return NewModule(nil, nil)
+}
+
+// NewModule returns an otel module that creates spans with the given tracer
+// provider, and propagates them with the given propagator. If either is nil,
+// the global one is used.
+func NewModule(provider trace.TracerProvider, propagator propagation.TextMapPropagator) *object.Module {
+	m := &module{provider: provider, propagator: propagator}
+	return object.NewBuiltinsModule("otel", map[string]object.Object{
+		"current_span": object.NewBuiltin("current_span", m.CurrentSpan),
+		"inject":       object.NewBuiltin("inject", m.Inject),
+		"span":         object.NewBuiltin("span", m.Span),
+		"start_span":   object.NewBuiltin("start_span", m.StartSpan),
+	})
+}
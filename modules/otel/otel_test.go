@@ -0,0 +1,108 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setup() (*tracetest.SpanRecorder, *object.Module) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return recorder, NewModule(provider, propagation.TraceContext{})
+}
+
+func TestSpan(t *testing.T) {
+	recorder, mod := setup()
+	result, err := risor.Eval(context.Background(), `
+	otel.span("outer", func(span) {
+		span.set_attribute("items", [1, 2, 3])
+		otel.span("inner", func() {
+			otel.current_span().add_event("cache miss", {key: "users"})
+		}, {kind: "client", attributes: {retries: 2}})
+		return span.trace_id
+	})
+	`, risor.WithGlobal("otel", mod))
+	require.Nil(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	inner, outer := spans[0], spans[1]
+	require.Equal(t, "outer", outer.Name())
+	require.Equal(t, outer.SpanContext().TraceID().String(), result.(*object.String).Value())
+	require.Equal(t, []attribute.KeyValue{attribute.Int64Slice("items", []int64{1, 2, 3})}, outer.Attributes())
+	require.Equal(t, "inner", inner.Name())
+	require.Equal(t, outer.SpanContext().SpanID(), inner.Parent().SpanID())
+	require.Equal(t, "client", inner.SpanKind().String())
+	require.Equal(t, []attribute.KeyValue{attribute.Int64("retries", 2)}, inner.Attributes())
+	require.Len(t, inner.Events(), 1)
+	require.Equal(t, "cache miss", inner.Events()[0].Name)
+}
+
+func TestSpanError(t *testing.T) {
+	recorder, mod := setup()
+	_, err := risor.Eval(context.Background(), `
+	otel.span("job", func() { error("disk full") })
+	`, risor.WithGlobal("otel", mod))
+	require.EqualError(t, err, "disk full")
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, "disk full", spans[0].Status().Description)
+	require.Equal(t, "exception", spans[0].Events()[0].Name)
+}
+
+func TestPropagation(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+	}))
+	defer server.Close()
+
+	recorder, mod := setup()
+	result, err := risor.Eval(context.Background(), `
+	parent := {traceparent: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"}
+	otel.span("sync", func(span) {
+		fetch(url)
+		return otel.inject()
+	}, {parent: parent})
+	`, risor.WithGlobal("otel", mod), risor.WithGlobal("url", server.URL))
+	require.Nil(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	sc := spans[0].SpanContext()
+	require.Equal(t, "0af7651916cd43dd8448eb211c80319c", sc.TraceID().String())
+	require.Equal(t, "b7ad6b7169203331", spans[0].Parent().SpanID().String())
+	expected := "00-0af7651916cd43dd8448eb211c80319c-" + sc.SpanID().String() + "-01"
+	require.Equal(t, expected, traceparent)
+	require.Equal(t, expected, result.(*object.Map).Get("traceparent").(*object.String).Value())
+}
+
+func TestStartSpan(t *testing.T) {
+	recorder, mod := setup()
+	_, err := risor.Eval(context.Background(), `
+	span := otel.start_span("batch", {attributes: {size: 10}})
+	span.set_status("ok")
+	span.end()
+	`, risor.WithGlobal("otel", mod))
+	require.Nil(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Ok, spans[0].Status().Code)
+
+	_, err = risor.Eval(context.Background(), `otel.start_span("x", {kind: "sideways"})`,
+		risor.WithGlobal("otel", mod))
+	require.EqualError(t, err, `value error: invalid span kind "sideways"`)
+}
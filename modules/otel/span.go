@@ -0,0 +1,274 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const SPAN object.Type = "otel.span"
+
+// Span wraps an OpenTelemetry span.
+type Span struct {
+	name string
+	span trace.Span
+}
+
+func NewSpan(name string, span trace.Span) *Span {
+	return &Span{name: name, span: span}
+}
+
+func (s *Span) Value() trace.Span {
+	return s.span
+}
+
+func (s *Span) Type() object.Type {
+	return SPAN
+}
+
+func (s *Span) Inspect() string {
+	sc := s.span.SpanContext()
+	if !sc.IsValid() {
+		return fmt.Sprintf("otel.span(%q)", s.name)
+	}
+	return fmt.Sprintf("otel.span(%q, trace_id=%s, span_id=%s)", s.name, sc.TraceID(), sc.SpanID())
+}
+
+func (s *Span) String() string {
+	return s.Inspect()
+}
+
+func (s *Span) Interface() interface{} {
+	return nil
+}
+
+func (s *Span) Equals(other object.Object) object.Object {
+	o, ok := other.(*Span)
+	return object.NewBool(ok && o.span == s.span)
+}
+
+func (s *Span) IsTruthy() bool {
+	return true
+}
+
+func (s *Span) Cost() int {
+	return 0
+}
+
+func (s *Span) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", SPAN)
+}
+
+func (s *Span) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", SPAN, opType)
+}
+
+func (s *Span) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", SPAN, name)
+}
+
+func (s *Span) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "name":
+		return object.NewString(s.name), true
+	case "trace_id":
+		return object.NewString(s.span.SpanContext().TraceID().String()), true
+	case "span_id":
+		return object.NewString(s.span.SpanContext().SpanID().String()), true
+	case "is_recording":
+		return object.NewBool(s.span.IsRecording()), true
+	case "set_attribute":
+		return object.NewBuiltin("otel.span.set_attribute", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("otel.span.set_attribute", 2, args); err != nil {
+				return err
+			}
+			key, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			s.span.SetAttributes(toAttribute(key, args[1]))
+			return object.Nil
+		}), true
+	case "set_attributes":
+		return object.NewBuiltin("otel.span.set_attributes", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("otel.span.set_attributes", 1, args); err != nil {
+				return err
+			}
+			attrs, err := toAttributes(args[0])
+			if err != nil {
+				return err
+			}
+			s.span.SetAttributes(attrs...)
+			return object.Nil
+		}), true
+	case "add_event":
+		return object.NewBuiltin("otel.span.add_event", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("otel.span.add_event", 1, 2, args); err != nil {
+				return err
+			}
+			name, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			var attrs []attribute.KeyValue
+			if len(args) > 1 {
+				if attrs, err = toAttributes(args[1]); err != nil {
+					return err
+				}
+			}
+			s.span.AddEvent(name, trace.WithAttributes(attrs...))
+			return object.Nil
+		}), true
+	case "record_error":
+		return object.NewBuiltin("otel.span.record_error", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("otel.span.record_error", 1, args); err != nil {
+				return err
+			}
+			s.recordError(asError(args[0]))
+			return object.Nil
+		}), true
+	case "set_status":
+		return object.NewBuiltin("otel.span.set_status", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("otel.span.set_status", 1, 2, args); err != nil {
+				return err
+			}
+			status, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			var description string
+			if len(args) > 1 {
+				if description, err = object.AsString(args[1]); err != nil {
+					return err
+				}
+			}
+			switch status {
+			case "unset":
+				s.span.SetStatus(codes.Unset, description)
+			case "ok":
+				s.span.SetStatus(codes.Ok, description)
+			case "error":
+				s.span.SetStatus(codes.Error, description)
+			default:
+				return object.Errorf("value error: status must be \"unset\", \"ok\", or \"error\" (got %q)", status)
+			}
+			return object.Nil
+		}), true
+	case "end":
+		return object.NewBuiltin("otel.span.end", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("otel.span.end", 0, args); err != nil {
+				return err
+			}
+			s.span.End()
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// recordError records the error as an event on the span, and sets the
+// span's status to error.
+func (s *Span) recordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// asError returns the Go error held by an error object, or an error with the
+// string form of any other object.
+func asError(obj object.Object) error {
+	if errObj, ok := obj.(*object.Error); ok {
+		return errObj.Value()
+	}
+	if s, ok := obj.(*object.String); ok {
+		return fmt.Errorf("%s", s.Value())
+	}
+	return fmt.Errorf("%s", obj.Inspect())
+}
+
+// toAttribute converts a Risor value to a span attribute. Lists of strings,
+// bools, ints, or floats become array attributes, and other values are
+// recorded as their string form.
+func toAttribute(key string, obj object.Object) attribute.KeyValue {
+	switch obj := obj.(type) {
+	case *object.String:
+		return attribute.String(key, obj.Value())
+	case *object.Bool:
+		return attribute.Bool(key, obj.Value())
+	case *object.Int:
+		return attribute.Int64(key, obj.Value())
+	case *object.Float:
+		return attribute.Float64(key, obj.Value())
+	case *object.List:
+		if kv, ok := listAttribute(key, obj.Value()); ok {
+			return kv
+		}
+	}
+	return attribute.String(key, obj.Inspect())
+}
+
+func listAttribute(key string, items []object.Object) (attribute.KeyValue, bool) {
+	if len(items) == 0 {
+		return attribute.StringSlice(key, nil), true
+	}
+	switch items[0].(type) {
+	case *object.String:
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := item.(*object.String)
+			if !ok {
+				return attribute.KeyValue{}, false
+			}
+			values = append(values, s.Value())
+		}
+		return attribute.StringSlice(key, values), true
+	case *object.Bool:
+		values := make([]bool, 0, len(items))
+		for _, item := range items {
+			b, ok := item.(*object.Bool)
+			if !ok {
+				return attribute.KeyValue{}, false
+			}
+			values = append(values, b.Value())
+		}
+		return attribute.BoolSlice(key, values), true
+	case *object.Int:
+		values := make([]int64, 0, len(items))
+		for _, item := range items {
+			i, ok := item.(*object.Int)
+			if !ok {
+				return attribute.KeyValue{}, false
+			}
+			values = append(values, i.Value())
+		}
+		return attribute.Int64Slice(key, values), true
+	case *object.Float:
+		values := make([]float64, 0, len(items))
+		for _, item := range items {
+			f, ok := item.(*object.Float)
+			if !ok {
+				return attribute.KeyValue{}, false
+			}
+			values = append(values, f.Value())
+		}
+		return attribute.Float64Slice(key, values), true
+	}
+	return attribute.KeyValue{}, false
+}
+
+func toAttributes(obj object.Object) ([]attribute.KeyValue, *object.Error) {
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return nil, err
+	}
+	attrs := make([]attribute.KeyValue, 0, m.Size())
+	for _, key := range m.SortedKeys() {
+		attrs = append(attrs, toAttribute(key, m.Get(key)))
+	}
+	return attrs, nil
+}
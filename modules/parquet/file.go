@@ -0,0 +1,217 @@
+package parquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const FILE object.Type = "parquet.file"
+
+// rowBatchSize is the number of rows read from a row group at a time.
+const rowBatchSize = 128
+
+// File is an open parquet file.
+type File struct {
+	file   *pq.File
+	closer io.Closer
+}
+
+func (f *File) Type() object.Type {
+	return FILE
+}
+
+func (f *File) Inspect() string {
+	return fmt.Sprintf("parquet.file(num_rows=%d)", f.file.NumRows())
+}
+
+func (f *File) Interface() interface{} {
+	return f.file
+}
+
+func (f *File) IsTruthy() bool {
+	return true
+}
+
+func (f *File) Cost() int {
+	return 8
+}
+
+func (f *File) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", FILE)
+}
+
+func (f *File) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", FILE, opType)
+}
+
+func (f *File) Equals(other object.Object) object.Object {
+	if f == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (f *File) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", FILE, name)
+}
+
+func (f *File) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "num_rows":
+		return object.NewInt(f.file.NumRows()), true
+	case "num_row_groups":
+		return object.NewInt(int64(len(f.file.RowGroups()))), true
+	case "columns":
+		return object.NewStringList(columnNames(f.file.Schema())), true
+	case "schema":
+		return describeSchema(f.file.Schema()), true
+	case "metadata":
+		metadata := map[string]object.Object{}
+		for _, kv := range f.file.Metadata().KeyValueMetadata {
+			metadata[kv.Key] = object.NewString(kv.Value)
+		}
+		return object.NewMap(metadata), true
+	case "rows":
+		return object.NewBuiltin("parquet.file.rows", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("parquet.file.rows", 0, 1, args); err != nil {
+				return err
+			}
+			columns, err := parseReadOptions(args)
+			if err != nil {
+				return err
+			}
+			return f.Rows(f.file.RowGroups(), columns)
+		}), true
+	case "row_group":
+		return object.NewBuiltin("parquet.file.row_group", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("parquet.file.row_group", 1, 2, args); err != nil {
+				return err
+			}
+			index, err := object.AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			rowGroups := f.file.RowGroups()
+			if index < 0 || index >= int64(len(rowGroups)) {
+				return object.Errorf("index error: row group index out of range: %d", index)
+			}
+			columns, err := parseReadOptions(args[1:])
+			if err != nil {
+				return err
+			}
+			return f.Rows(rowGroups[index:index+1], columns)
+		}), true
+	case "close":
+		return object.NewBuiltin("parquet.file.close", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("parquet.file.close", 0, args); err != nil {
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// Rows returns a stream of the rows in the given row groups as maps. If
+// columns is non-empty, only those columns are included.
+func (f *File) Rows(rowGroups []pq.RowGroup, columns []string) object.Object {
+	schema := f.file.Schema()
+	for _, column := range columns {
+		if !hasColumn(schema, column) {
+			return object.Errorf("value error: parquet file has no column %q", column)
+		}
+	}
+	var rows pq.Rows
+	var batch []pq.Row
+	var pos, count int
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		for pos >= count {
+			if rows == nil {
+				if len(rowGroups) == 0 {
+					return nil, false, nil
+				}
+				rows = rowGroups[0].Rows()
+				rowGroups = rowGroups[1:]
+				batch = make([]pq.Row, rowBatchSize)
+			}
+			n, err := rows.ReadRows(batch)
+			if err != nil && !errors.Is(err, io.EOF) {
+				rows.Close()
+				return nil, false, err
+			}
+			pos, count = 0, n
+			if errors.Is(err, io.EOF) {
+				rows.Close()
+				rows = nil
+			}
+		}
+		row := batch[pos]
+		pos++
+		var value interface{}
+		if err := schema.Reconstruct(&value, row); err != nil {
+			return nil, false, err
+		}
+		m, ok := fromParquet(schema, value).(map[string]interface{})
+		if !ok {
+			return nil, false, fmt.Errorf("parquet error: unexpected row type %T", value)
+		}
+		if len(columns) > 0 {
+			selected := make(map[string]interface{}, len(columns))
+			for _, column := range columns {
+				selected[column] = m[column]
+			}
+			m = selected
+		}
+		return object.FromGoType(m), true, nil
+	})
+}
+
+// Close closes the underlying file, if the parquet file was opened by path.
+func (f *File) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+func hasColumn(schema *pq.Schema, name string) bool {
+	for _, field := range schema.Fields() {
+		if field.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func parseReadOptions(args []object.Object) ([]string, *object.Error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var columns []string
+	for key, value := range m.Value() {
+		switch key {
+		case "columns":
+			columns, err = object.AsStringSlice(value)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, object.Errorf("value error: unknown parquet option %q", key)
+		}
+	}
+	return columns, nil
+}
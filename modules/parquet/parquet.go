@@ -0,0 +1,278 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/os"
+)
+
+var codecs = map[string]compress.Codec{
+	"none":   &pq.Uncompressed,
+	"snappy": &pq.Snappy,
+	"gzip":   &pq.Gzip,
+	"zstd":   &pq.Zstd,
+	"lz4":    &pq.Lz4Raw,
+	"brotli": &pq.Brotli,
+}
+
+// writeBatchSize is the number of rows buffered before they are written.
+const writeBatchSize = 128
+
+// Open opens a parquet file. The source may be a path, a byte_slice, a
+// file, or any reader.
+func Open(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("parquet.open", 1, args); err != nil {
+		return err
+	}
+	file, err := open(ctx, args[0])
+	if err != nil {
+		return object.NewError(err)
+	}
+	return file
+}
+
+// Reader returns a stream of the rows of a parquet file as maps.
+func Reader(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("parquet.reader", 1, 2, args); err != nil {
+		return err
+	}
+	columns, errObj := parseReadOptions(args[1:])
+	if errObj != nil {
+		return errObj
+	}
+	file, err := open(ctx, args[0])
+	if err != nil {
+		return object.NewError(err)
+	}
+	return file.Rows(file.file.RowGroups(), columns)
+}
+
+func open(ctx context.Context, source object.Object) (*File, error) {
+	var input io.ReaderAt
+	var size int64
+	var closer io.Closer
+	switch source := source.(type) {
+	case *object.String:
+		f, err := os.GetDefaultOS(ctx).Open(source.Value())
+		if err != nil {
+			return nil, err
+		}
+		input, size, err = readerAt(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		closer = f
+	case *object.ByteSlice:
+		input, size = bytes.NewReader(source.Value()), int64(len(source.Value()))
+	case *object.File:
+		var err error
+		if input, size, err = readerAt(source.Value()); err != nil {
+			return nil, err
+		}
+	default:
+		r, errObj := object.AsReader(source)
+		if errObj != nil {
+			return nil, errObj.Value()
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		input, size = bytes.NewReader(data), int64(len(data))
+	}
+	file, err := pq.OpenFile(input, size)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, fmt.Errorf("parquet error: %w", err)
+	}
+	return &File{file: file, closer: closer}, nil
+}
+
+// readerAt returns a random access reader for the file and its size. Files
+// that don't support random access are read into memory.
+func readerAt(f os.File) (io.ReaderAt, int64, error) {
+	if r, ok := f.(io.ReaderAt); ok {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		return r, info.Size(), nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// Write writes rows to a parquet file using the given schema. Returns the
+// number of rows written.
+func Write(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("parquet.write", 3, 4, args); err != nil {
+		return err
+	}
+	iter, errObj := object.AsIterator(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	schemaMap, errObj := object.AsMap(args[2])
+	if errObj != nil {
+		return errObj
+	}
+	schema, errObj := parseSchema(schemaMap)
+	if errObj != nil {
+		return errObj
+	}
+	options := []pq.WriterOption{schema}
+	if len(args) == 4 {
+		m, errObj := object.AsMap(args[3])
+		if errObj != nil {
+			return errObj
+		}
+		writerOpts, errObj := parseWriteOptions(m)
+		if errObj != nil {
+			return errObj
+		}
+		options = append(options, writerOpts...)
+	}
+
+	var dst io.Writer
+	if path, ok := args[0].(*object.String); ok {
+		f, err := os.GetDefaultOS(ctx).Create(path.Value())
+		if err != nil {
+			return object.NewError(err)
+		}
+		defer f.Close()
+		dst = f
+	} else {
+		var errObj *object.Error
+		if dst, errObj = object.AsWriter(args[0]); errObj != nil {
+			return errObj
+		}
+	}
+
+	writer := pq.NewWriter(dst, options...)
+	count, err := writeRows(ctx, writer, schema, iter)
+	if err != nil {
+		return object.NewError(err)
+	}
+	if err := writer.Close(); err != nil {
+		return object.NewError(err)
+	}
+	return object.NewInt(count)
+}
+
+func writeRows(ctx context.Context, writer *pq.Writer, schema *pq.Schema, iter object.Iterator) (int64, error) {
+	var count int64
+	batch := make([]pq.Row, 0, writeBatchSize)
+	flush := func() error {
+		if _, err := writer.WriteRows(batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		value, ok := iter.Next(ctx)
+		if !ok {
+			break
+		}
+		m, ok := value.(*object.Map)
+		if !ok {
+			return count, fmt.Errorf("type error: parquet rows must be maps (got %s)", value.Type())
+		}
+		row, err := deconstruct(schema, m)
+		if err != nil {
+			return count, fmt.Errorf("parquet error: row %d: %w", count, err)
+		}
+		batch = append(batch, row)
+		count++
+		if len(batch) == cap(batch) {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if s, ok := iter.(interface{ Err() error }); ok && s.Err() != nil {
+		return count, s.Err()
+	}
+	return count, flush()
+}
+
+// deconstruct converts a map to a parquet row, recovering from the panics
+// raised by the parquet library when a value doesn't match the schema.
+func deconstruct(schema *pq.Schema, m *object.Map) (row pq.Row, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	value, err := toParquet(schema, m.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return schema.Deconstruct(nil, value), nil
+}
+
+func parseWriteOptions(m *object.Map) ([]pq.WriterOption, *object.Error) {
+	var options []pq.WriterOption
+	for key, value := range m.Value() {
+		switch key {
+		case "compression":
+			name, err := object.AsString(value)
+			if err != nil {
+				return nil, err
+			}
+			codec, ok := codecs[name]
+			if !ok {
+				return nil, object.Errorf("value error: unknown parquet compression %q", name)
+			}
+			options = append(options, pq.Compression(codec))
+		case "row_group_size":
+			n, err := object.AsInt(value)
+			if err != nil {
+				return nil, err
+			}
+			if n < 1 {
+				return nil, object.Errorf("value error: row_group_size must be positive (got %d)", n)
+			}
+			options = append(options, pq.MaxRowsPerRowGroup(n))
+		case "metadata":
+			metadata, err := object.AsMap(value)
+			if err != nil {
+				return nil, err
+			}
+			keys := metadata.StringKeys()
+			sort.Strings(keys)
+			for _, k := range keys {
+				v, err := object.AsString(metadata.Get(k))
+				if err != nil {
+					return nil, err
+				}
+				options = append(options, pq.KeyValueMetadata(k, v))
+			}
+		default:
+			return nil, object.Errorf("value error: unknown parquet option %q", key)
+		}
+	}
+	return options, nil
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("parquet", map[string]object.Object{
+		"open":   object.NewBuiltin("open", Open),
+		"reader": object.NewBuiltin("reader", Reader),
+		"write":  object.NewBuiltin("write", Write),
+	})
+}
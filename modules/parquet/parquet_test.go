@@ -0,0 +1,153 @@
+package parquet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, ctx context.Context, stream object.Object) []object.Object {
+	t.Helper()
+	s, ok := stream.(*object.Stream)
+	require.True(t, ok, "unexpected result: %s", stream.Inspect())
+	items, ok := s.Collect(ctx).(*object.List)
+	require.True(t, ok)
+	return items.Value()
+}
+
+func testSchema() *object.Map {
+	return object.NewMap(map[string]object.Object{
+		"name": object.NewString("string"),
+		"age":  object.NewString("int?"),
+		"tags": object.NewString("[]string"),
+		"ts":   object.NewString("time"),
+		"addr": object.NewMap(map[string]object.Object{
+			"city": object.NewString("string"),
+		}),
+		"meta": object.NewString("json"),
+	})
+}
+
+func testRows(ts time.Time) *object.List {
+	return object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{
+			"name": object.NewString("alice"),
+			"age":  object.NewInt(30),
+			"tags": object.NewStringList([]string{"a", "b"}),
+			"ts":   object.NewTime(ts),
+			"addr": object.NewMap(map[string]object.Object{"city": object.NewString("NYC")}),
+			"meta": object.NewMap(map[string]object.Object{"k": object.NewString("v")}),
+		}),
+		object.NewMap(map[string]object.Object{
+			"name": object.NewString("bob"),
+			"age":  object.Nil,
+			"tags": object.NewList(nil),
+			"ts":   object.NewTime(ts),
+			"addr": object.NewMap(map[string]object.Object{"city": object.NewString("SF")}),
+			"meta": object.NewList([]object.Object{object.NewInt(1)}),
+		}),
+	})
+}
+
+func TestWriteAndRead(t *testing.T) {
+	ctx := context.Background()
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	buf := object.NewBuffer(nil)
+	result := Write(ctx, buf, testRows(ts), testSchema(), object.NewMap(map[string]object.Object{
+		"compression": object.NewString("zstd"),
+		"metadata":    object.NewMap(map[string]object.Object{"owner": object.NewString("me")}),
+	}))
+	require.Equal(t, object.NewInt(2), result)
+
+	f, ok := Open(ctx, object.NewByteSlice(buf.Value().Bytes())).(*File)
+	require.True(t, ok)
+	numRows, _ := f.GetAttr("num_rows")
+	require.Equal(t, object.NewInt(2), numRows)
+	columns, _ := f.GetAttr("columns")
+	require.Equal(t, object.NewStringList([]string{"addr", "age", "meta", "name", "tags", "ts"}), columns)
+	schema, _ := f.GetAttr("schema")
+	require.Equal(t, testSchema(), schema)
+	metadata, _ := f.GetAttr("metadata")
+	require.Equal(t, object.NewMap(map[string]object.Object{"owner": object.NewString("me")}), metadata)
+
+	rows := collect(t, ctx, f.Rows(f.file.RowGroups(), nil))
+	require.Len(t, rows, 2)
+	alice := rows[0].(*object.Map)
+	require.Equal(t, object.NewString("alice"), alice.Get("name"))
+	require.Equal(t, object.NewInt(30), alice.Get("age"))
+	require.Equal(t, object.NewStringList([]string{"a", "b"}), alice.Get("tags"))
+	require.Equal(t, object.NewTime(ts), alice.Get("ts"))
+	require.Equal(t, object.NewMap(map[string]object.Object{"city": object.NewString("NYC")}), alice.Get("addr"))
+	require.Equal(t, object.NewMap(map[string]object.Object{"k": object.NewString("v")}), alice.Get("meta"))
+	bob := rows[1].(*object.Map)
+	require.Equal(t, object.Nil, bob.Get("age"))
+	require.Equal(t, object.NewList([]object.Object{object.NewFloat(1)}), bob.Get("meta"))
+}
+
+func TestReaderColumns(t *testing.T) {
+	ctx := context.Background()
+	buf := object.NewBuffer(nil)
+	Write(ctx, buf, testRows(time.Now()), testSchema())
+	data := object.NewByteSlice(buf.Value().Bytes())
+
+	rows := collect(t, ctx, Reader(ctx, data, object.NewMap(map[string]object.Object{
+		"columns": object.NewStringList([]string{"name"}),
+	})))
+	require.Equal(t, []object.Object{
+		object.NewMap(map[string]object.Object{"name": object.NewString("alice")}),
+		object.NewMap(map[string]object.Object{"name": object.NewString("bob")}),
+	}, rows)
+
+	result := Reader(ctx, data, object.NewMap(map[string]object.Object{
+		"columns": object.NewStringList([]string{"missing"}),
+	}))
+	require.Equal(t, object.Errorf(`value error: parquet file has no column "missing"`), result)
+}
+
+func TestRowGroups(t *testing.T) {
+	ctx := context.Background()
+	var items []object.Object
+	for i := 0; i < 5; i++ {
+		items = append(items, object.NewMap(map[string]object.Object{"n": object.NewInt(int64(i))}))
+	}
+	buf := object.NewBuffer(nil)
+	schema := object.NewMap(map[string]object.Object{"n": object.NewString("int")})
+	result := Write(ctx, buf, object.NewList(items), schema, object.NewMap(map[string]object.Object{
+		"row_group_size": object.NewInt(2),
+	}))
+	require.Equal(t, object.NewInt(5), result)
+
+	f := Open(ctx, object.NewByteSlice(buf.Value().Bytes())).(*File)
+	numGroups, _ := f.GetAttr("num_row_groups")
+	require.Equal(t, object.NewInt(3), numGroups)
+	rowGroup, _ := f.GetAttr("row_group")
+	rows := collect(t, ctx, rowGroup.(*object.Builtin).Call(ctx, object.NewInt(2)))
+	require.Equal(t, []object.Object{
+		object.NewMap(map[string]object.Object{"n": object.NewInt(4)}),
+	}, rows)
+	result = rowGroup.(*object.Builtin).Call(ctx, object.NewInt(3))
+	require.Equal(t, object.Errorf("index error: row group index out of range: 3"), result)
+}
+
+func TestWriteErrors(t *testing.T) {
+	ctx := context.Background()
+	rows := object.NewList([]object.Object{object.NewMap(map[string]object.Object{"n": object.NewInt(1)})})
+
+	result := Write(ctx, object.NewBuffer(nil), rows, object.NewMap(map[string]object.Object{
+		"n": object.NewString("decimal"),
+	}))
+	require.Equal(t, object.Errorf(`value error: unknown parquet type "decimal" for column "n"`), result)
+
+	result = Write(ctx, object.NewBuffer(nil), rows, object.NewMap(map[string]object.Object{
+		"n": object.NewString("int"),
+	}), object.NewMap(map[string]object.Object{"compression": object.NewString("xz")}))
+	require.Equal(t, object.Errorf(`value error: unknown parquet compression "xz"`), result)
+
+	result = Write(ctx, object.NewBuffer(nil), object.NewList([]object.Object{object.NewInt(1)}),
+		object.NewMap(map[string]object.Object{"n": object.NewString("int")}))
+	require.True(t, object.IsError(result))
+	require.Equal(t, "type error: parquet rows must be maps (got int)", result.(*object.Error).Message().Value())
+}
@@ -0,0 +1,249 @@
+package parquet
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	pq "github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+	"github.com/risor-io/risor/object"
+)
+
+// leafTypes maps the type names accepted in schemas to parquet nodes.
+var leafTypes = map[string]func() pq.Node{
+	"string":  pq.String,
+	"bool":    func() pq.Node { return pq.Leaf(pq.BooleanType) },
+	"int":     func() pq.Node { return pq.Int(64) },
+	"int32":   func() pq.Node { return pq.Int(32) },
+	"float":   func() pq.Node { return pq.Leaf(pq.DoubleType) },
+	"float32": func() pq.Node { return pq.Leaf(pq.FloatType) },
+	"bytes":   func() pq.Node { return pq.Leaf(pq.ByteArrayType) },
+	"time":    func() pq.Node { return pq.Timestamp(pq.Microsecond) },
+	"date":    pq.Date,
+	"json":    pq.JSON,
+}
+
+// parseSchema converts a map describing columns into a parquet schema.
+func parseSchema(m *object.Map) (*pq.Schema, *object.Error) {
+	group, err := parseGroup(m, "")
+	if err != nil {
+		return nil, err
+	}
+	return pq.NewSchema("risor", group), nil
+}
+
+func parseGroup(m *object.Map, path string) (pq.Group, *object.Error) {
+	if m.Size() == 0 {
+		return nil, object.Errorf("value error: parquet schema group %q has no columns", path)
+	}
+	group := pq.Group{}
+	for name, value := range m.Value() {
+		node, err := parseNode(value, path+name)
+		if err != nil {
+			return nil, err
+		}
+		group[name] = node
+	}
+	return group, nil
+}
+
+// parseNode converts a column type. Types are names such as "string" or
+// "int", optionally prefixed with "[]" for repeated columns and suffixed with
+// "?" for nullable columns. Nested maps describe groups.
+func parseNode(obj object.Object, path string) (pq.Node, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Map:
+		return parseGroup(obj, path+".")
+	case *object.String:
+		name := obj.Value()
+		repeated := strings.HasPrefix(name, "[]")
+		name = strings.TrimPrefix(name, "[]")
+		optional := strings.HasSuffix(name, "?")
+		name = strings.TrimSuffix(name, "?")
+		if repeated && optional {
+			return nil, object.Errorf("value error: parquet column %q can't be both repeated and optional", path)
+		}
+		newNode, ok := leafTypes[name]
+		if !ok {
+			return nil, object.Errorf("value error: unknown parquet type %q for column %q", name, path)
+		}
+		node := newNode()
+		if repeated {
+			node = pq.Repeated(node)
+		} else if optional {
+			node = pq.Optional(node)
+		}
+		return node, nil
+	}
+	return nil, object.Errorf("type error: parquet column %q must be a type name or map (got %s)", path, obj.Type())
+}
+
+// describeSchema returns a map describing the columns of a schema, using
+// the same format accepted by parseSchema where possible.
+func describeSchema(node pq.Node) object.Object {
+	if !node.Leaf() {
+		fields := node.Fields()
+		m := make(map[string]object.Object, len(fields))
+		for _, field := range fields {
+			m[field.Name()] = describeSchema(field)
+		}
+		if node.Repeated() {
+			return object.NewList([]object.Object{object.NewMap(m)})
+		}
+		return object.NewMap(m)
+	}
+	name := leafTypeName(node.Type())
+	if node.Repeated() {
+		name = "[]" + name
+	} else if node.Optional() {
+		name += "?"
+	}
+	return object.NewString(name)
+}
+
+func leafTypeName(typ pq.Type) string {
+	if lt := typ.LogicalType(); lt != nil {
+		switch {
+		case lt.UTF8 != nil, lt.Enum != nil:
+			return "string"
+		case lt.Timestamp != nil:
+			return "time"
+		case lt.Date != nil:
+			return "date"
+		case lt.Json != nil:
+			return "json"
+		}
+	}
+	switch typ.Kind() {
+	case pq.Boolean:
+		return "bool"
+	case pq.Int32:
+		return "int32"
+	case pq.Int64:
+		return "int"
+	case pq.Float:
+		return "float32"
+	case pq.Double:
+		return "float"
+	case pq.ByteArray, pq.FixedLenByteArray:
+		return "bytes"
+	}
+	return strings.ToLower(typ.String())
+}
+
+// columnNames returns the sorted names of the top-level columns.
+func columnNames(schema *pq.Schema) []string {
+	fields := schema.Fields()
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fromParquet adjusts a reconstructed value to the Go types that best
+// represent it, so that timestamps and dates become times.
+func fromParquet(node pq.Node, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	if node.Repeated() {
+		if items, ok := value.([]interface{}); ok {
+			elem := pq.Required(node)
+			for i, item := range items {
+				items[i] = fromParquet(elem, item)
+			}
+		}
+		return value
+	}
+	if !node.Leaf() {
+		if m, ok := value.(map[string]interface{}); ok {
+			for _, field := range node.Fields() {
+				m[field.Name()] = fromParquet(field, m[field.Name()])
+			}
+		}
+		return value
+	}
+	lt := node.Type().LogicalType()
+	switch {
+	case lt == nil:
+	case lt.Json != nil:
+		var data []byte
+		switch v := value.(type) {
+		case string:
+			data = []byte(v)
+		case []byte:
+			data = v
+		}
+		var decoded interface{}
+		if err := json.Unmarshal(data, &decoded); err == nil {
+			return decoded
+		}
+	case lt.Timestamp != nil:
+		if n, ok := value.(int64); ok {
+			return timestamp(n, lt.Timestamp.Unit)
+		}
+	case lt.Date != nil:
+		if n, ok := value.(int32); ok {
+			return time.Unix(int64(n)*24*60*60, 0).UTC()
+		}
+	}
+	return value
+}
+
+// toParquet prepares a value for writing, encoding the values of JSON
+// columns and converting times in date columns to days since the epoch.
+func toParquet(node pq.Node, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if node.Repeated() {
+		if items, ok := value.([]interface{}); ok {
+			elem := pq.Required(node)
+			for i, item := range items {
+				converted, err := toParquet(elem, item)
+				if err != nil {
+					return nil, err
+				}
+				items[i] = converted
+			}
+		}
+		return value, nil
+	}
+	if !node.Leaf() {
+		if m, ok := value.(map[string]interface{}); ok {
+			for _, field := range node.Fields() {
+				converted, err := toParquet(field, m[field.Name()])
+				if err != nil {
+					return nil, err
+				}
+				m[field.Name()] = converted
+			}
+		}
+		return value, nil
+	}
+	lt := node.Type().LogicalType()
+	switch {
+	case lt == nil:
+	case lt.Json != nil:
+		return json.Marshal(value)
+	case lt.Date != nil:
+		if t, ok := value.(time.Time); ok {
+			return int32(t.Unix() / (24 * 60 * 60)), nil
+		}
+	}
+	return value, nil
+}
+
+func timestamp(n int64, unit format.TimeUnit) time.Time {
+	switch {
+	case unit.Millis != nil:
+		return time.UnixMilli(n).UTC()
+	case unit.Micros != nil:
+		return time.UnixMicro(n).UTC()
+	}
+	return time.Unix(0, n).UTC()
+}
@@ -0,0 +1,702 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+)
+
+const DOCUMENT object.Type = "pdf.document"
+
+var headingSizes = map[int64]float64{1: 20, 2: 16, 3: 13}
+
+// rgb is a color with 8-bit components.
+type rgb struct {
+	r, g, b int
+}
+
+var (
+	black = rgb{0, 0, 0}
+	gray  = rgb{110, 110, 110}
+)
+
+func parseColor(s string) (rgb, *object.Error) {
+	hex := strings.TrimPrefix(s, "#")
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return rgb{}, object.Errorf("value error: invalid color %q (expected \"#rrggbb\")", s)
+	}
+	return rgb{int(value >> 16 & 0xff), int(value >> 8 & 0xff), int(value & 0xff)}, nil
+}
+
+// Document is a PDF document that is assembled one element at a time.
+// Elements flow down the page, and new pages are added as needed.
+type Document struct {
+	pdf      *fpdf.Fpdf
+	tr       func(string) string
+	font     string
+	fontSize float64
+	header   string
+	footer   string
+	images   int
+	output   []byte
+}
+
+// DocumentOptions configures a new Document.
+type DocumentOptions struct {
+	Orientation string
+	PageSize    string
+	Margin      float64
+	Font        string
+	FontSize    float64
+	Title       string
+	Author      string
+	Header      string
+	Footer      string
+}
+
+// NewDocument returns a Document with a single empty page.
+func NewDocument(opts DocumentOptions) *Document {
+	orientation := "P"
+	if opts.Orientation == "landscape" {
+		orientation = "L"
+	}
+	pdf := fpdf.New(orientation, "mm", opts.PageSize, "")
+	d := &Document{
+		pdf:      pdf,
+		tr:       pdf.UnicodeTranslatorFromDescriptor(""),
+		font:     opts.Font,
+		fontSize: opts.FontSize,
+		header:   opts.Header,
+		footer:   opts.Footer,
+	}
+	pdf.SetMargins(opts.Margin, opts.Margin, opts.Margin)
+	pdf.SetAutoPageBreak(true, opts.Margin)
+	if opts.Title != "" {
+		pdf.SetTitle(opts.Title, true)
+	}
+	if opts.Author != "" {
+		pdf.SetAuthor(opts.Author, true)
+	}
+	pdf.SetCreator("Risor", true)
+	pdf.AliasNbPages("{pages}")
+	if d.header != "" {
+		pdf.SetHeaderFunc(d.drawHeader)
+	}
+	if d.footer != "" {
+		pdf.SetFooterFunc(d.drawFooter)
+	}
+	pdf.AddPage()
+	d.setFont("", d.fontSize, black)
+	return d
+}
+
+func (d *Document) setFont(style string, size float64, color rgb) {
+	d.pdf.SetFont(d.font, style, size)
+	d.pdf.SetTextColor(color.r, color.g, color.b)
+}
+
+func (d *Document) pageText(s string) string {
+	return d.tr(strings.ReplaceAll(s, "{page}", strconv.Itoa(d.pdf.PageNo())))
+}
+
+func (d *Document) drawHeader() {
+	d.setFont("", d.fontSize*0.8, gray)
+	d.pdf.CellFormat(0, d.lineHeight(d.fontSize*0.8), d.pageText(d.header), "B", 1, "L", false, 0, "")
+	d.pdf.Ln(d.lineHeight(d.fontSize) / 2)
+	d.setFont("", d.fontSize, black)
+}
+
+func (d *Document) drawFooter() {
+	_, _, _, bottom := d.pdf.GetMargins()
+	d.pdf.SetY(-bottom + 2)
+	d.setFont("", d.fontSize*0.8, gray)
+	d.pdf.CellFormat(0, d.lineHeight(d.fontSize*0.8), d.pageText(d.footer), "", 0, "C", false, 0, "")
+	d.setFont("", d.fontSize, black)
+}
+
+// lineHeight returns the height in millimeters of a line of text in the
+// given font size in points.
+func (d *Document) lineHeight(size float64) float64 {
+	return size * 0.5
+}
+
+// contentWidth returns the width of the page between the margins.
+func (d *Document) contentWidth() float64 {
+	width, _ := d.pdf.GetPageSize()
+	left, _, right, _ := d.pdf.GetMargins()
+	return width - left - right
+}
+
+// ensureSpace starts a new page if less than the given height remains on
+// the current page.
+func (d *Document) ensureSpace(height float64) bool {
+	_, pageHeight := d.pdf.GetPageSize()
+	_, margin := d.pdf.GetAutoPageBreak()
+	if d.pdf.GetY()+height > pageHeight-margin {
+		d.pdf.AddPage()
+		return true
+	}
+	return false
+}
+
+// wrap splits translated text into lines no wider than the given width.
+func (d *Document) wrap(text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if d.pdf.GetStringWidth(candidate) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			// Words wider than a line are broken between characters
+			for len(word) > 1 && d.pdf.GetStringWidth(word) > width {
+				n := len(word) - 1
+				for n > 1 && d.pdf.GetStringWidth(word[:n]) > width {
+					n--
+				}
+				lines = append(lines, word[:n])
+				word = word[n:]
+			}
+			line = word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Bytes returns the encoded document. Once a document is encoded, no more
+// content can be added to it.
+func (d *Document) Bytes() ([]byte, error) {
+	if d.output != nil {
+		return d.output, nil
+	}
+	var buf bytes.Buffer
+	if err := d.pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("pdf error: %w", err)
+	}
+	d.output = buf.Bytes()
+	return d.output, nil
+}
+
+func (d *Document) Type() object.Type {
+	return DOCUMENT
+}
+
+func (d *Document) Inspect() string {
+	return fmt.Sprintf("pdf.document(pages=%d)", d.pdf.PageCount())
+}
+
+func (d *Document) Interface() interface{} {
+	return nil
+}
+
+// Value returns the underlying fpdf document.
+func (d *Document) Value() *fpdf.Fpdf {
+	return d.pdf
+}
+
+func (d *Document) Equals(other object.Object) object.Object {
+	return object.NewBool(d == other)
+}
+
+func (d *Document) IsTruthy() bool {
+	return true
+}
+
+func (d *Document) Cost() int {
+	return 0
+}
+
+func (d *Document) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: pdf.document object has no attribute %q", name)
+}
+
+func (d *Document) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for pdf.document: %v", opType)
+}
+
+func (d *Document) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("type error: unable to marshal pdf.document")
+}
+
+func (d *Document) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "page_count":
+		return object.NewInt(int64(d.pdf.PageCount())), true
+	case "add_page":
+		return d.method("add_page", d.addPage), true
+	case "heading":
+		return d.method("heading", d.heading), true
+	case "text":
+		return d.method("text", d.text), true
+	case "table":
+		return d.method("table", d.table), true
+	case "image":
+		return d.method("image", d.image), true
+	case "space":
+		return d.method("space", d.space), true
+	case "bytes":
+		return object.NewBuiltin("pdf.document.bytes", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("pdf.document.bytes", 0, args); err != nil {
+				return err
+			}
+			data, err := d.Bytes()
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewByteSlice(data)
+		}), true
+	case "save":
+		return object.NewBuiltin("pdf.document.save", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("pdf.document.save", 1, args); err != nil {
+				return err
+			}
+			path, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			data, ioErr := d.Bytes()
+			if ioErr != nil {
+				return object.NewError(ioErr)
+			}
+			if ioErr := ros.GetDefaultOS(ctx).WriteFile(path, data, 0644); ioErr != nil {
+				return object.NewError(ioErr)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// method returns a builtin that adds content to the document, which fails
+// once the document has been encoded.
+func (d *Document) method(name string, fn object.BuiltinFunction) *object.Builtin {
+	name = "pdf.document." + name
+	return object.NewBuiltin(name, func(ctx context.Context, args ...object.Object) object.Object {
+		if d.output != nil {
+			return object.Errorf("value error: %s() called after the document was written", name)
+		}
+		return fn(ctx, args...)
+	})
+}
+
+// result returns nil, or an error if the document is in an error state.
+func (d *Document) result() object.Object {
+	if err := d.pdf.Error(); err != nil {
+		return object.NewError(fmt.Errorf("pdf error: %w", err))
+	}
+	return object.Nil
+}
+
+func (d *Document) addPage(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("pdf.document.add_page", 0, args); err != nil {
+		return err
+	}
+	d.pdf.AddPage()
+	return d.result()
+}
+
+// textOptions holds the options accepted by text and heading.
+type textOptions struct {
+	size  float64
+	style string
+	align string
+	color rgb
+	level int64
+}
+
+func parseTextOptions(fn string, args []object.Object, opts *textOptions, allowed ...string) *object.Error {
+	if len(args) == 0 {
+		return nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return err
+	}
+	for key, value := range m.Value() {
+		found := false
+		for _, name := range allowed {
+			found = found || name == key
+		}
+		if !found {
+			return object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		switch key {
+		case "size":
+			if opts.size, err = object.AsFloat(value); err != nil {
+				return err
+			}
+			if opts.size <= 0 {
+				return object.Errorf("value error: size must be positive (got %v)", opts.size)
+			}
+		case "bold", "italic", "underline":
+			enabled, err := object.AsBool(value)
+			if err != nil {
+				return err
+			}
+			if enabled {
+				opts.style += strings.ToUpper(key[:1])
+			}
+		case "align":
+			align, err := object.AsString(value)
+			if err != nil {
+				return err
+			}
+			codes := map[string]string{"left": "L", "center": "C", "right": "R", "justify": "J"}
+			if opts.align = codes[align]; opts.align == "" {
+				return object.Errorf("value error: align must be \"left\", \"center\", \"right\", or \"justify\" (got %q)", align)
+			}
+		case "color":
+			s, err := object.AsString(value)
+			if err != nil {
+				return err
+			}
+			if opts.color, err = parseColor(s); err != nil {
+				return err
+			}
+		case "level":
+			if opts.level, err = object.AsInt(value); err != nil {
+				return err
+			}
+			if _, ok := headingSizes[opts.level]; !ok {
+				return object.Errorf("value error: heading level must be 1, 2, or 3 (got %d)", opts.level)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Document) heading(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("pdf.document.heading", 1, 2, args); err != nil {
+		return err
+	}
+	text, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts := textOptions{level: 1, align: "L", color: black}
+	if err := parseTextOptions("pdf.document.heading", args[1:], &opts, "level", "align", "color"); err != nil {
+		return err
+	}
+	size := headingSizes[opts.level]
+	// Keep the heading together with at least two lines of what follows
+	d.ensureSpace(d.lineHeight(size) + 2*d.lineHeight(d.fontSize))
+	d.pdf.Ln(d.lineHeight(size) / 3)
+	d.setFont("B", size, opts.color)
+	d.pdf.MultiCell(0, d.lineHeight(size), d.tr(text), "", opts.align, false)
+	d.pdf.Ln(d.lineHeight(size) / 3)
+	d.setFont("", d.fontSize, black)
+	return d.result()
+}
+
+func (d *Document) text(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("pdf.document.text", 1, 2, args); err != nil {
+		return err
+	}
+	text, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts := textOptions{size: d.fontSize, align: "L", color: black}
+	if err := parseTextOptions("pdf.document.text", args[1:], &opts, "size", "bold", "italic", "underline", "align", "color"); err != nil {
+		return err
+	}
+	d.setFont(opts.style, opts.size, opts.color)
+	d.pdf.MultiCell(0, d.lineHeight(opts.size), d.tr(text), "", opts.align, false)
+	d.pdf.Ln(d.lineHeight(opts.size) / 2)
+	d.setFont("", d.fontSize, black)
+	return d.result()
+}
+
+func (d *Document) space(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("pdf.document.space", 0, 1, args); err != nil {
+		return err
+	}
+	height := d.lineHeight(d.fontSize)
+	if len(args) == 1 {
+		var err *object.Error
+		if height, err = object.AsFloat(args[0]); err != nil {
+			return err
+		}
+	}
+	d.pdf.Ln(height)
+	return d.result()
+}
+
+// cellString returns the text displayed for a table cell.
+func cellString(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.String:
+		return obj.Value()
+	case *object.NilType:
+		return ""
+	}
+	return obj.Inspect()
+}
+
+// tableRows converts rows given as lists or maps into strings. Columns of
+// map rows are taken from the columns argument, or from the sorted keys of
+// the first row.
+func tableRows(rows []object.Object, columns []string) ([]string, [][]string, *object.Error) {
+	var result [][]string
+	for i, row := range rows {
+		switch row := row.(type) {
+		case *object.List:
+			var cells []string
+			for _, cell := range row.Value() {
+				cells = append(cells, cellString(cell))
+			}
+			result = append(result, cells)
+		case *object.Map:
+			if columns == nil {
+				columns = row.StringKeys()
+				sort.Strings(columns)
+			}
+			var cells []string
+			for _, column := range columns {
+				cells = append(cells, cellString(row.Get(column)))
+			}
+			result = append(result, cells)
+		default:
+			return nil, nil, object.Errorf("type error: table row %d must be a list or map (%s given)", i, row.Type())
+		}
+	}
+	return columns, result, nil
+}
+
+func (d *Document) table(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("pdf.document.table", 1, 2, args); err != nil {
+		return err
+	}
+	list, err := object.AsList(args[0])
+	if err != nil {
+		return err
+	}
+	var header, columns []string
+	var widths []float64
+	size := d.fontSize
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "header":
+				if header, err = object.AsStringSlice(value); err != nil {
+					return err
+				}
+			case "columns":
+				if columns, err = object.AsStringSlice(value); err != nil {
+					return err
+				}
+			case "widths":
+				items, err := object.AsList(value)
+				if err != nil {
+					return err
+				}
+				for _, item := range items.Value() {
+					width, err := object.AsFloat(item)
+					if err != nil {
+						return err
+					}
+					widths = append(widths, width)
+				}
+			case "size":
+				if size, err = object.AsFloat(value); err != nil {
+					return err
+				}
+			default:
+				return object.Errorf("value error: unknown pdf.document.table option %q", key)
+			}
+		}
+	}
+	columns, rows, err := tableRows(list.Value(), columns)
+	if err != nil {
+		return err
+	}
+	if header == nil && columns != nil {
+		header = columns
+	}
+	count := len(header)
+	for _, row := range rows {
+		count = max(count, len(row))
+	}
+	if count == 0 {
+		return object.Nil
+	}
+	if widths == nil {
+		// Columns share the width of the page equally
+		for i := 0; i < count; i++ {
+			widths = append(widths, d.contentWidth()/float64(count))
+		}
+	} else if len(widths) != count {
+		return object.Errorf("value error: table has %d columns but %d widths were given", count, len(widths))
+	}
+
+	lineHeight := d.lineHeight(size)
+	padding := 1.5
+	var drawRow func(cells []string, isHeader bool)
+	drawRow = func(cells []string, isHeader bool) {
+		style := ""
+		if isHeader {
+			style = "B"
+		}
+		d.setFont(style, size, black)
+		wrapped := make([][]string, count)
+		lines := 1
+		for i := 0; i < count; i++ {
+			text := ""
+			if i < len(cells) {
+				text = d.tr(cells[i])
+			}
+			wrapped[i] = d.wrap(text, widths[i]-2*padding)
+			lines = max(lines, len(wrapped[i]))
+		}
+		height := float64(lines)*lineHeight + padding
+		if d.ensureSpace(height) && !isHeader && header != nil {
+			// The header is repeated at the top of each page
+			drawRow(header, true)
+			d.setFont(style, size, black)
+		}
+		x, y := d.pdf.GetXY()
+		for i := 0; i < count; i++ {
+			if isHeader {
+				d.pdf.SetFillColor(230, 230, 230)
+				d.pdf.Rect(x, y, widths[i], height, "FD")
+			} else {
+				d.pdf.Rect(x, y, widths[i], height, "D")
+			}
+			for j, line := range wrapped[i] {
+				d.pdf.SetXY(x+padding, y+padding/2+float64(j)*lineHeight)
+				d.pdf.CellFormat(widths[i]-2*padding, lineHeight, line, "", 0, "L", false, 0, "")
+			}
+			x += widths[i]
+		}
+		left, _, _, _ := d.pdf.GetMargins()
+		d.pdf.SetXY(left, y+height)
+	}
+	if header != nil {
+		drawRow(header, true)
+	}
+	for _, row := range rows {
+		drawRow(row, false)
+	}
+	d.pdf.Ln(lineHeight / 2)
+	d.setFont("", d.fontSize, black)
+	return d.result()
+}
+
+// imageType returns the fpdf image type of the given image data.
+func imageType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG")):
+		return "PNG"
+	case bytes.HasPrefix(data, []byte{0xff, 0xd8}):
+		return "JPG"
+	case bytes.HasPrefix(data, []byte("GIF8")):
+		return "GIF"
+	}
+	return ""
+}
+
+func (d *Document) image(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("pdf.document.image", 1, 2, args); err != nil {
+		return err
+	}
+	var data []byte
+	switch src := args[0].(type) {
+	case *object.String:
+		var err error
+		if data, err = ros.GetDefaultOS(ctx).ReadFile(src.Value()); err != nil {
+			return object.NewError(err)
+		}
+	case *object.ByteSlice:
+		data = src.Value()
+	default:
+		return object.Errorf("type error: pdf.document.image expected a path or byte_slice (%s given)", args[0].Type())
+	}
+	kind := imageType(data)
+	if kind == "" {
+		return object.Errorf("value error: unsupported image format (expected png, jpeg, or gif)")
+	}
+	var width, height float64
+	align := "L"
+	if len(args) == 2 {
+		opts := textOptions{}
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "width":
+				if width, err = object.AsFloat(value); err != nil {
+					return err
+				}
+			case "height":
+				if height, err = object.AsFloat(value); err != nil {
+					return err
+				}
+			case "align":
+				if err := parseTextOptions("pdf.document.image", []object.Object{
+					object.NewMap(map[string]object.Object{"align": value}),
+				}, &opts, "align"); err != nil {
+					return err
+				}
+				align = opts.align
+			default:
+				return object.Errorf("value error: unknown pdf.document.image option %q", key)
+			}
+		}
+	}
+	d.images++
+	name := fmt.Sprintf("image%d", d.images)
+	options := fpdf.ImageOptions{ImageType: kind, ReadDpi: true}
+	info := d.pdf.RegisterImageOptionsReader(name, options, bytes.NewReader(data))
+	if info == nil {
+		return d.result()
+	}
+	// Images keep their aspect ratio and are scaled down to fit the page
+	ratio := info.Height() / info.Width()
+	switch {
+	case width == 0 && height == 0:
+		width = min(info.Width(), d.contentWidth())
+		height = width * ratio
+	case width == 0:
+		width = height / ratio
+	case height == 0:
+		height = width * ratio
+	}
+	d.ensureSpace(height)
+	left, _, _, _ := d.pdf.GetMargins()
+	x := left
+	switch align {
+	case "C":
+		x += (d.contentWidth() - width) / 2
+	case "R":
+		x += d.contentWidth() - width
+	}
+	d.pdf.ImageOptions(name, x, d.pdf.GetY(), width, height, true, options, 0, "")
+	d.pdf.Ln(d.lineHeight(d.fontSize) / 2)
+	return d.result()
+}
@@ -0,0 +1,222 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+)
+
+var fonts = map[string]string{
+	"helvetica": "Helvetica",
+	"times":     "Times",
+	"courier":   "Courier",
+}
+
+// NewDocumentBuiltin creates a pdf.document from an options map.
+func NewDocumentBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("pdf.document", 0, 1, args); err != nil {
+		return err
+	}
+	opts := DocumentOptions{
+		PageSize: "A4",
+		Margin:   20,
+		Font:     "Helvetica",
+		FontSize: 11,
+	}
+	if len(args) == 1 {
+		m, err := object.AsMap(args[0])
+		if err != nil {
+			return err
+		}
+		if err := parseDocumentOptions(m, &opts); err != nil {
+			return err
+		}
+	}
+	d := NewDocument(opts)
+	if err := d.pdf.Error(); err != nil {
+		return object.NewError(fmt.Errorf("pdf error: %w", err))
+	}
+	return d
+}
+
+func parseDocumentOptions(m *object.Map, opts *DocumentOptions) *object.Error {
+	for key, value := range m.Value() {
+		var err *object.Error
+		switch key {
+		case "orientation":
+			opts.Orientation, err = object.AsString(value)
+			if err == nil && opts.Orientation != "portrait" && opts.Orientation != "landscape" {
+				err = object.Errorf("value error: orientation must be \"portrait\" or \"landscape\" (got %q)", opts.Orientation)
+			}
+		case "page_size":
+			opts.PageSize, err = object.AsString(value)
+			switch strings.ToLower(opts.PageSize) {
+			case "a3", "a4", "a5", "letter", "legal", "tabloid":
+			default:
+				if err == nil {
+					err = object.Errorf("value error: unsupported page size %q", opts.PageSize)
+				}
+			}
+		case "margin":
+			opts.Margin, err = object.AsFloat(value)
+			if err == nil && opts.Margin < 0 {
+				err = object.Errorf("value error: margin must not be negative (got %v)", opts.Margin)
+			}
+		case "font":
+			var name string
+			name, err = object.AsString(value)
+			if opts.Font = fonts[strings.ToLower(name)]; err == nil && opts.Font == "" {
+				names := make([]string, 0, len(fonts))
+				for name := range fonts {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				err = object.Errorf("value error: font must be one of %s (got %q)", strings.Join(names, ", "), name)
+			}
+		case "font_size":
+			opts.FontSize, err = object.AsFloat(value)
+			if err == nil && opts.FontSize <= 0 {
+				err = object.Errorf("value error: font_size must be positive (got %v)", opts.FontSize)
+			}
+		case "title":
+			opts.Title, err = object.AsString(value)
+		case "author":
+			opts.Author, err = object.AsString(value)
+		case "header":
+			opts.Header, err = object.AsString(value)
+		case "footer":
+			opts.Footer, err = object.AsString(value)
+		default:
+			err = object.Errorf("value error: unknown pdf.document option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPages returns the text of each page of a PDF document.
+func readPages(data []byte) ([]string, error) {
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("pdf error: %w", err)
+	}
+	var pages []string
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			pages = append(pages, "")
+			continue
+		}
+		pages = append(pages, pageText(page.Content().Text))
+	}
+	return pages, nil
+}
+
+// pageText arranges the text drawn on a page into lines, from the top of
+// the page. Each text item is a single character, tagged with the position
+// of the run it was drawn in. Runs on the same line are separated by spaces.
+func pageText(texts []pdf.Text) string {
+	type run struct {
+		x    float64
+		text strings.Builder
+	}
+	type line struct {
+		y    float64
+		runs []*run
+	}
+	var lines []*line
+	byY := map[float64]*line{}
+	var last *run
+	var lastX, lastY float64
+	for _, text := range texts {
+		if last != nil && text.X == lastX && text.Y == lastY {
+			last.text.WriteString(text.S)
+			continue
+		}
+		l, ok := byY[text.Y]
+		if !ok {
+			l = &line{y: text.Y}
+			byY[text.Y] = l
+			lines = append(lines, l)
+		}
+		last = &run{x: text.X}
+		last.text.WriteString(text.S)
+		l.runs = append(l.runs, last)
+		lastX, lastY = text.X, text.Y
+	}
+	sort.SliceStable(lines, func(a, b int) bool { return lines[a].y > lines[b].y })
+	result := make([]string, 0, len(lines))
+	for _, l := range lines {
+		sort.SliceStable(l.runs, func(a, b int) bool { return l.runs[a].x < l.runs[b].x })
+		parts := make([]string, 0, len(l.runs))
+		for _, r := range l.runs {
+			parts = append(parts, r.text.String())
+		}
+		result = append(result, strings.Join(parts, " "))
+	}
+	return strings.Join(result, "\n")
+}
+
+func readSource(ctx context.Context, fn string, src object.Object) ([]byte, *object.Error) {
+	switch src := src.(type) {
+	case *object.String:
+		data, err := ros.GetDefaultOS(ctx).ReadFile(src.Value())
+		if err != nil {
+			return nil, object.NewError(err)
+		}
+		return data, nil
+	case *object.ByteSlice:
+		return src.Value(), nil
+	}
+	return nil, object.Errorf("type error: %s() expected a path or byte_slice (%s given)", fn, src.Type())
+}
+
+// ExtractText returns the text of a PDF document, with pages separated by
+// form feed characters.
+func ExtractText(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("pdf.extract_text", 1, args); err != nil {
+		return err
+	}
+	data, errObj := readSource(ctx, "pdf.extract_text", args[0])
+	if errObj != nil {
+		return errObj
+	}
+	pages, err := readPages(data)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewString(strings.Join(pages, "\f"))
+}
+
+// ExtractPages returns a list with the text of each page of a PDF document.
+func ExtractPages(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("pdf.extract_pages", 1, args); err != nil {
+		return err
+	}
+	data, errObj := readSource(ctx, "pdf.extract_pages", args[0])
+	if errObj != nil {
+		return errObj
+	}
+	pages, err := readPages(data)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewStringList(pages)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("pdf", map[string]object.Object{
+		"document":      object.NewBuiltin("document", NewDocumentBuiltin),
+		"extract_pages": object.NewBuiltin("extract_pages", ExtractPages),
+		"extract_text":  object.NewBuiltin("extract_text", ExtractText),
+	})
+}
@@ -0,0 +1,156 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func call(t *testing.T, d *Document, name string, args ...object.Object) object.Object {
+	t.Helper()
+	fn, ok := d.GetAttr(name)
+	require.True(t, ok)
+	return fn.(*object.Builtin).Call(context.Background(), args...)
+}
+
+func newDocument(t *testing.T, opts map[string]object.Object) *Document {
+	t.Helper()
+	result := NewDocumentBuiltin(context.Background(), object.NewMap(opts))
+	d, ok := result.(*Document)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	return d
+}
+
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		img.Set(x, 10, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	require.Nil(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestReport(t *testing.T) {
+	ctx := context.Background()
+	d := newDocument(t, map[string]object.Object{
+		"title":  object.NewString("Weekly report"),
+		"header": object.NewString("ACME Corp – weekly report"),
+		"footer": object.NewString("Page {page} of {pages}"),
+	})
+	require.Equal(t, object.Nil, call(t, d, "heading", object.NewString("Summary")))
+	require.Equal(t, object.Nil, call(t, d, "text", object.NewString("All systems were operational."),
+		object.NewMap(map[string]object.Object{"italic": object.True, "color": object.NewString("#333333")})))
+	require.Equal(t, object.Nil, call(t, d, "image", object.NewByteSlice(pngImage(t)),
+		object.NewMap(map[string]object.Object{"width": object.NewInt(40), "align": object.NewString("center")})))
+
+	var rows []object.Object
+	for i := 0; i < 80; i++ {
+		rows = append(rows, object.NewMap(map[string]object.Object{
+			"host":   object.NewString(fmt.Sprintf("web-%02d", i)),
+			"uptime": object.NewFloat(99.5),
+			"note":   object.Nil,
+		}))
+	}
+	require.Equal(t, object.Nil, call(t, d, "table", object.NewList(rows),
+		object.NewMap(map[string]object.Object{"columns": object.NewStringList([]string{"host", "uptime"})})))
+	pageCount, _ := d.GetAttr("page_count")
+	require.Equal(t, object.NewInt(3), pageCount)
+
+	data, ok := call(t, d, "bytes").(*object.ByteSlice)
+	require.True(t, ok)
+	require.True(t, bytes.HasPrefix(data.Value(), []byte("%PDF-")))
+
+	pages, ok := ExtractPages(ctx, data).(*object.List)
+	require.True(t, ok)
+	require.Len(t, pages.Value(), 3)
+	first := strings.Split(pages.Value()[0].(*object.String).Value(), "\n")
+	require.Equal(t, "ACME Corp – weekly report", first[0])
+	require.Equal(t, "Summary", first[1])
+	require.Equal(t, "All systems were operational.", first[2])
+	require.Equal(t, "host uptime", first[3])
+	require.Equal(t, "web-00 99.5", first[4])
+	require.Equal(t, "Page 1 of 3", first[len(first)-1])
+
+	// The table header is repeated on each page
+	second := strings.Split(pages.Value()[1].(*object.String).Value(), "\n")
+	require.Equal(t, "host uptime", second[1])
+	require.Equal(t, "Page 2 of 3", second[len(second)-1])
+
+	text, ok := ExtractText(ctx, data).(*object.String)
+	require.True(t, ok)
+	require.Equal(t, 2, strings.Count(text.Value(), "\f"))
+	require.Contains(t, text.Value(), "web-79 99.5")
+
+	// Content can't be added once the document is written
+	result := call(t, d, "text", object.NewString("late"))
+	require.Equal(t, "value error: pdf.document.text() called after the document was written",
+		result.(*object.Error).Message().Value())
+}
+
+func TestTableRows(t *testing.T) {
+	d := newDocument(t, map[string]object.Object{"orientation": object.NewString("landscape")})
+	result := call(t, d, "table", object.NewList([]object.Object{
+		object.NewList([]object.Object{object.NewString("a"), object.NewInt(1)}),
+		object.NewString("oops"),
+	}))
+	require.Equal(t, "type error: table row 1 must be a list or map (string given)", result.(*object.Error).Message().Value())
+
+	result = call(t, d, "table", object.NewList([]object.Object{
+		object.NewList([]object.Object{object.NewString("a"), object.NewInt(1)}),
+	}), object.NewMap(map[string]object.Object{"widths": object.NewList([]object.Object{object.NewInt(50)})}))
+	require.Equal(t, "value error: table has 2 columns but 1 widths were given", result.(*object.Error).Message().Value())
+
+	long := strings.Repeat("word ", 60)
+	result = call(t, d, "table", object.NewList([]object.Object{
+		object.NewList([]object.Object{object.NewString(long), object.NewString("x")}),
+	}), object.NewMap(map[string]object.Object{"header": object.NewStringList([]string{"text", "value"})}))
+	require.Equal(t, object.Nil, result)
+	data, err := d.Bytes()
+	require.Nil(t, err)
+	pages, err := readPages(data)
+	require.Nil(t, err)
+	// The long cell wraps onto several lines
+	lines := strings.Split(pages[0], "\n")
+	require.Equal(t, "text value", lines[0])
+	require.Greater(t, len(lines), 3)
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		result object.Object
+		err    string
+	}{
+		{NewDocumentBuiltin(ctx, object.NewMap(map[string]object.Object{"page_size": object.NewString("B7")})),
+			`value error: unsupported page size "B7"`},
+		{NewDocumentBuiltin(ctx, object.NewMap(map[string]object.Object{"font": object.NewString("Comic Sans")})),
+			`value error: font must be one of courier, helvetica, times (got "Comic Sans")`},
+		{NewDocumentBuiltin(ctx, object.NewMap(map[string]object.Object{"colour": object.NewString("red")})),
+			`value error: unknown pdf.document option "colour"`},
+		{ExtractText(ctx, object.NewByteSlice([]byte("not a pdf"))), "pdf error: not a PDF file: invalid header"},
+		{ExtractText(ctx, object.NewInt(1)), "type error: pdf.extract_text() expected a path or byte_slice (int given)"},
+	}
+	for _, tt := range tests {
+		errObj, ok := tt.result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", tt.result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+
+	d := newDocument(t, map[string]object.Object{})
+	result := call(t, d, "heading", object.NewString("x"), object.NewMap(map[string]object.Object{"level": object.NewInt(4)}))
+	require.Equal(t, "value error: heading level must be 1, 2, or 3 (got 4)", result.(*object.Error).Message().Value())
+	result = call(t, d, "text", object.NewString("x"), object.NewMap(map[string]object.Object{"level": object.NewInt(1)}))
+	require.Equal(t, `value error: unknown pdf.document.text option "level"`, result.(*object.Error).Message().Value())
+	result = call(t, d, "image", object.NewByteSlice([]byte("BM....")))
+	require.Equal(t, "value error: unsupported image format (expected png, jpeg, or gif)", result.(*object.Error).Message().Value())
+}
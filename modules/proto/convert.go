@@ -0,0 +1,160 @@
+package proto
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ToMessage builds a message of the given type from a Risor map. The map is
+// interpreted using the protobuf JSON mapping, so enums may be given by name,
+// fields by either their proto or JSON names, and well-known types by their
+// JSON form, e.g. "1.5s" for a duration. Well-known types may also be given
+// directly as their JSON form rather than as a map. The resolver resolves
+// the types of google.protobuf.Any values, and may be nil to use the types
+// linked into the binary.
+func ToMessage(desc protoreflect.MessageDescriptor, obj object.Object, resolver TypeResolver) (*dynamicpb.Message, *object.Error) {
+	msg := dynamicpb.NewMessage(desc)
+	if obj == nil || obj == object.Nil {
+		return msg, nil
+	}
+	_, isMap := obj.(*object.Map)
+	if !isMap && !strings.HasPrefix(string(desc.FullName()), "google.protobuf.") {
+		return nil, object.Errorf("type error: expected a map for message %s (%s given)", desc.FullName(), obj.Type())
+	}
+	data, err := json.Marshal(obj.Interface())
+	if err != nil {
+		return nil, object.NewError(err)
+	}
+	opts := protojson.UnmarshalOptions{Resolver: resolver}
+	if err := opts.Unmarshal(data, msg); err != nil {
+		return nil, object.Errorf("value error: invalid %s message: %s", desc.FullName(), err)
+	}
+	return msg, nil
+}
+
+// MessageToObject converts a message to a Risor map keyed by proto field
+// name. Unset scalar fields are included with their default values, while
+// unset message fields are nil. Timestamps become times and other well-known
+// types use their JSON form. The resolver is used as by ToMessage.
+func MessageToObject(msg protoreflect.Message, resolver TypeResolver) object.Object {
+	desc := msg.Descriptor()
+	if desc.FullName() == "google.protobuf.Timestamp" {
+		fields := desc.Fields()
+		seconds := msg.Get(fields.ByName("seconds")).Int()
+		nanos := msg.Get(fields.ByName("nanos")).Int()
+		return object.NewTime(time.Unix(seconds, nanos).UTC())
+	}
+	if strings.HasPrefix(string(desc.FullName()), "google.protobuf.") {
+		opts := protojson.MarshalOptions{Resolver: resolver, UseProtoNames: true}
+		if data, err := opts.Marshal(msg.Interface()); err == nil {
+			var value interface{}
+			if err := json.Unmarshal(data, &value); err == nil {
+				return object.FromGoType(value)
+			}
+		}
+	}
+	result := map[string]object.Object{}
+	fields := desc.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && !msg.Has(field) {
+			continue
+		}
+		if field.Message() != nil && !field.IsList() && !field.IsMap() && !msg.Has(field) {
+			result[string(field.Name())] = object.Nil
+			continue
+		}
+		result[string(field.Name())] = fieldToObject(field, msg.Get(field), resolver)
+	}
+	return object.NewMap(result)
+}
+
+func fieldToObject(field protoreflect.FieldDescriptor, value protoreflect.Value, resolver TypeResolver) object.Object {
+	switch {
+	case field.IsList():
+		list := value.List()
+		items := make([]object.Object, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			items[i] = singularToObject(field, list.Get(i), resolver)
+		}
+		return object.NewList(items)
+	case field.IsMap():
+		result := map[string]object.Object{}
+		valueField := field.MapValue()
+		value.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+			result[key.String()] = singularToObject(valueField, value, resolver)
+			return true
+		})
+		return object.NewMap(result)
+	}
+	return singularToObject(field, value, resolver)
+}
+
+func singularToObject(field protoreflect.FieldDescriptor, value protoreflect.Value, resolver TypeResolver) object.Object {
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		return object.NewBool(value.Bool())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return object.NewInt(value.Int())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return object.NewInt(int64(value.Uint()))
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return object.NewFloat(value.Float())
+	case protoreflect.StringKind:
+		return object.NewString(value.String())
+	case protoreflect.BytesKind:
+		return object.NewByteSlice(value.Bytes())
+	case protoreflect.EnumKind:
+		if enumValue := field.Enum().Values().ByNumber(value.Enum()); enumValue != nil {
+			return object.NewString(string(enumValue.Name()))
+		}
+		return object.NewInt(int64(value.Enum()))
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return MessageToObject(value.Message(), resolver)
+	}
+	return object.Nil
+}
+
+// describeMessage returns a map describing the fields of a message type.
+func describeMessage(desc protoreflect.MessageDescriptor) *object.Map {
+	fields := desc.Fields()
+	items := make([]object.Object, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		info := map[string]object.Object{
+			"name":      object.NewString(string(field.Name())),
+			"json_name": object.NewString(field.JSONName()),
+			"number":    object.NewInt(int64(field.Number())),
+			"type":      object.NewString(fieldTypeName(field)),
+			"repeated":  object.NewBool(field.IsList()),
+		}
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() {
+			info["oneof"] = object.NewString(string(oneof.Name()))
+		}
+		items[i] = object.NewMap(info)
+	}
+	return object.NewMap(map[string]object.Object{
+		"name":   object.NewString(string(desc.FullName())),
+		"fields": object.NewList(items),
+	})
+}
+
+func fieldTypeName(field protoreflect.FieldDescriptor) string {
+	switch {
+	case field.IsMap():
+		return "map<" + fieldTypeName(field.MapKey()) + ", " + fieldTypeName(field.MapValue()) + ">"
+	case field.Enum() != nil:
+		return string(field.Enum().FullName())
+	case field.Message() != nil:
+		return string(field.Message().FullName())
+	}
+	return field.Kind().String()
+}
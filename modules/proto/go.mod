@@ -0,0 +1,20 @@
+module github.com/risor-io/risor/modules/proto
+
+go 1.21
+
+replace github.com/risor-io/risor => ../..
+
+require (
+	github.com/bufbuild/protocompile v0.6.0
+	github.com/risor-io/risor v1.1.0
+	github.com/stretchr/testify v1.8.4
+	google.golang.org/protobuf v1.31.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sync v0.3.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,286 @@
+package proto
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	// Link the well-known types so they resolve without being loaded
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Load loads message descriptors and returns a registry used to encode and
+// decode messages. The source may be a path to a .proto file or to a
+// serialized FileDescriptorSet, a list of paths, or a byte_slice containing
+// a FileDescriptorSet.
+func Load(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return object.NewArgsRangeError("proto.load", 1, 2, len(args))
+	}
+	var importPaths []string
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "import_paths":
+				if importPaths, err = stringOrList(value); err != nil {
+					return err
+				}
+			default:
+				return object.Errorf("value error: unknown proto load option %q", key)
+			}
+		}
+	}
+	files := &protoregistry.Files{}
+	if data, ok := args[0].(*object.ByteSlice); ok {
+		if err := loadDescriptorSet(files, data.Value()); err != nil {
+			return object.NewError(err)
+		}
+		return NewRegistry(files)
+	}
+	paths, errObj := stringOrList(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	var protos []string
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".proto") {
+			protos = append(protos, path)
+			continue
+		}
+		data, err := readFile(ctx, path)
+		if err != nil {
+			return object.NewError(err)
+		}
+		if err := loadDescriptorSet(files, data); err != nil {
+			return object.Errorf("value error: %s: %s", path, err)
+		}
+	}
+	if len(protos) > 0 {
+		if err := compileProtos(ctx, files, protos, importPaths); err != nil {
+			return object.NewError(err)
+		}
+	}
+	return NewRegistry(files)
+}
+
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	f, err := ros.GetDefaultOS(ctx).Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// loadDescriptorSet registers the files in a serialized FileDescriptorSet.
+// Imports missing from the set are resolved from the well-known types.
+func loadDescriptorSet(files *protoregistry.Files, data []byte) error {
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return fmt.Errorf("invalid FileDescriptorSet: %w", err)
+	}
+	pending := map[string]*descriptorpb.FileDescriptorProto{}
+	names := make([]string, 0, len(set.GetFile()))
+	for _, fdp := range set.GetFile() {
+		pending[fdp.GetName()] = fdp
+		names = append(names, fdp.GetName())
+	}
+	var register func(name string) error
+	register = func(name string) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		fdp, ok := pending[name]
+		if !ok {
+			if _, err := protoregistry.GlobalFiles.FindFileByPath(name); err == nil {
+				return nil
+			}
+			return fmt.Errorf("missing import %q", name)
+		}
+		for _, dep := range fdp.GetDependency() {
+			if err := register(dep); err != nil {
+				return err
+			}
+		}
+		file, err := protodesc.NewFile(fdp, fileResolver{files})
+		if err != nil {
+			return err
+		}
+		return files.RegisterFile(file)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := register(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compileProtos compiles .proto files and registers them along with their
+// transitive imports.
+func compileProtos(ctx context.Context, files *protoregistry.Files, paths, importPaths []string) error {
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: importPaths,
+		}),
+	}
+	compiled, err := compiler.Compile(ctx, paths...)
+	if err != nil {
+		return err
+	}
+	for _, file := range compiled {
+		if err := registerFile(files, file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func registerFile(files *protoregistry.Files, file protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(file.Path()); err == nil {
+		return nil
+	}
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerFile(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+	return files.RegisterFile(file)
+}
+
+// fileResolver resolves descriptors from the loaded files, falling back to
+// the well-known types linked into the binary.
+type fileResolver struct {
+	files *protoregistry.Files
+}
+
+func (r fileResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if file, err := r.files.FindFileByPath(path); err == nil {
+		return file, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r fileResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if desc, err := r.files.FindDescriptorByName(name); err == nil {
+		return desc, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+var wireTypes = map[protowire.Type]string{
+	protowire.VarintType:     "varint",
+	protowire.Fixed32Type:    "fixed32",
+	protowire.Fixed64Type:    "fixed64",
+	protowire.BytesType:      "bytes",
+	protowire.StartGroupType: "group",
+}
+
+// DecodeRaw decodes protobuf wire format without a schema. Returns a list of
+// maps with the number, wire type, and value of each field. Varints and fixed
+// width values are returned as ints, and length-delimited values as
+// byte_slices.
+func DecodeRaw(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewArgsError("proto.decode_raw", 1, len(args))
+	}
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	fields, decodeErr := decodeRaw(data)
+	if decodeErr != nil {
+		return object.NewError(decodeErr)
+	}
+	return fields
+}
+
+func decodeRaw(data []byte) (*object.List, error) {
+	var fields []object.Object
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return nil, fmt.Errorf("proto error: invalid field tag: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+		var value object.Object
+		switch typ {
+		case protowire.VarintType:
+			var v uint64
+			v, n = protowire.ConsumeVarint(data)
+			value = object.NewInt(int64(v))
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(data)
+			value = object.NewInt(int64(v))
+		case protowire.Fixed64Type:
+			var v uint64
+			v, n = protowire.ConsumeFixed64(data)
+			value = object.NewInt(int64(v))
+		case protowire.BytesType:
+			var v []byte
+			v, n = protowire.ConsumeBytes(data)
+			value = object.NewByteSlice(v)
+		case protowire.StartGroupType:
+			var v []byte
+			v, n = protowire.ConsumeGroup(num, data)
+			if n >= 0 {
+				group, err := decodeRaw(v)
+				if err != nil {
+					return nil, err
+				}
+				value = group
+			}
+		default:
+			return nil, fmt.Errorf("proto error: field %d: unsupported wire type %d", num, typ)
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("proto error: field %d: %w", num, protowire.ParseError(n))
+		}
+		data = data[n:]
+		fields = append(fields, object.NewMap(map[string]object.Object{
+			"number":    object.NewInt(int64(num)),
+			"wire_type": object.NewString(wireTypes[typ]),
+			"value":     value,
+		}))
+	}
+	return object.NewList(fields), nil
+}
+
+func stringOrList(obj object.Object) ([]string, *object.Error) {
+	if s, ok := obj.(*object.String); ok {
+		return []string{s.Value()}, nil
+	}
+	return object.AsStringSlice(obj)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("proto", map[string]object.Object{
+		"load":       object.NewBuiltin("proto.load", Load),
+		"decode_raw": object.NewBuiltin("proto.decode_raw", DecodeRaw),
+	})
+}
@@ -0,0 +1,157 @@
+package proto
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func call(ctx context.Context, registry *Registry, name string, args ...object.Object) object.Object {
+	method, _ := registry.GetAttr(name)
+	return method.(*object.Builtin).Call(ctx, args...)
+}
+
+func load(t *testing.T, ctx context.Context, args ...object.Object) *Registry {
+	t.Helper()
+	result := Load(ctx, args...)
+	registry, ok := result.(*Registry)
+	require.True(t, ok, result.Inspect())
+	return registry
+}
+
+func TestRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	registry := load(t, ctx, object.NewString("testdata/event.proto"))
+	messages, _ := registry.GetAttr("messages")
+	require.Equal(t, object.NewStringList([]string{"events.v1.Detail", "events.v1.Event"}), messages)
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	event := object.NewMap(map[string]object.Object{
+		"id":         object.NewString("e1"),
+		"level":      object.NewString("LEVEL_ERROR"),
+		"time":       object.NewTime(ts),
+		"elapsed":    object.NewString("1.5s"),
+		"tags":       object.NewStringList([]string{"a", "b"}),
+		"counts":     object.NewMap(map[string]object.Object{"x": object.NewInt(2)}),
+		"attributes": object.NewMap(map[string]object.Object{"k": object.NewString("v")}),
+		"note":       object.NewString("hi"),
+		"detail": object.NewMap(map[string]object.Object{
+			"@type":   object.NewString("type.googleapis.com/events.v1.Detail"),
+			"message": object.NewString("boom"),
+		}),
+		"payload": object.NewByteSlice([]byte("xy")),
+		"pid":     object.NewInt(7),
+	})
+	data := call(ctx, registry, "encode", object.NewString("events.v1.Event"), event)
+	require.IsType(t, &object.ByteSlice{}, data)
+
+	result := call(ctx, registry, "decode", object.NewString("events.v1.Event"), data)
+	require.Equal(t, object.NewMap(map[string]object.Object{
+		"id":         object.NewString("e1"),
+		"level":      object.NewString("LEVEL_ERROR"),
+		"time":       object.NewTime(ts),
+		"elapsed":    object.NewString("1.500s"),
+		"tags":       object.NewStringList([]string{"a", "b"}),
+		"counts":     object.NewMap(map[string]object.Object{"x": object.NewInt(2)}),
+		"attributes": object.NewMap(map[string]object.Object{"k": object.NewString("v")}),
+		"note":       object.NewString("hi"),
+		"detail": object.NewMap(map[string]object.Object{
+			"@type":   object.NewString("type.googleapis.com/events.v1.Detail"),
+			"message": object.NewString("boom"),
+		}),
+		"payload": object.NewByteSlice([]byte("xy")),
+		"pid":     object.NewInt(7),
+	}), result)
+}
+
+func TestDescriptorSet(t *testing.T) {
+	ctx := context.Background()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{}),
+	}
+	compiled, err := compiler.Compile(ctx, "testdata/event.proto")
+	require.Nil(t, err)
+	set := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(compiled[0])},
+	}
+	data, err := proto.Marshal(set)
+	require.Nil(t, err)
+
+	registry := load(t, ctx, object.NewByteSlice(data))
+	messages, _ := registry.GetAttr("messages")
+	require.Equal(t, object.NewStringList([]string{"events.v1.Detail", "events.v1.Event"}), messages)
+
+	detail := call(ctx, registry, "describe", object.NewString("events.v1.Detail"))
+	require.Equal(t, object.NewMap(map[string]object.Object{
+		"name": object.NewString("events.v1.Detail"),
+		"fields": object.NewList([]object.Object{
+			object.NewMap(map[string]object.Object{
+				"name":      object.NewString("message"),
+				"json_name": object.NewString("message"),
+				"number":    object.NewInt(1),
+				"type":      object.NewString("string"),
+				"repeated":  object.False,
+			}),
+		}),
+	}), detail)
+}
+
+func TestWellKnownTypes(t *testing.T) {
+	ctx := context.Background()
+	registry := NewRegistry(nil)
+	data := call(ctx, registry, "encode", object.NewString("google.protobuf.Duration"), object.NewString("3s"))
+	require.Equal(t, object.NewByteSlice([]byte{8, 3}), data)
+	result := call(ctx, registry, "decode", object.NewString("google.protobuf.Duration"), data)
+	require.Equal(t, object.NewString("3s"), result)
+}
+
+func TestDecodeRaw(t *testing.T) {
+	ctx := context.Background()
+	result := DecodeRaw(ctx, object.NewByteSlice([]byte{8, 150, 1, 18, 2, 'h', 'i', 29, 1, 0, 0, 0}))
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{
+			"number":    object.NewInt(1),
+			"wire_type": object.NewString("varint"),
+			"value":     object.NewInt(150),
+		}),
+		object.NewMap(map[string]object.Object{
+			"number":    object.NewInt(2),
+			"wire_type": object.NewString("bytes"),
+			"value":     object.NewByteSlice([]byte("hi")),
+		}),
+		object.NewMap(map[string]object.Object{
+			"number":    object.NewInt(3),
+			"wire_type": object.NewString("fixed32"),
+			"value":     object.NewInt(1),
+		}),
+	}), result)
+
+	result = DecodeRaw(ctx, object.NewByteSlice([]byte{18, 5, 'h'}))
+	require.True(t, object.IsError(result))
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	registry := load(t, ctx, object.NewString("testdata/event.proto"))
+
+	result := call(ctx, registry, "encode", object.NewString("events.v1.Missing"), object.NewMap(nil))
+	require.Equal(t, object.Errorf(`value error: message type "events.v1.Missing" not found`), result)
+
+	result = call(ctx, registry, "encode", object.NewString("events.v1.Level"), object.NewMap(nil))
+	require.Equal(t, object.Errorf(`value error: "events.v1.Level" is not a message type`), result)
+
+	result = call(ctx, registry, "encode", object.NewString("events.v1.Detail"), object.NewInt(1))
+	require.Equal(t, object.Errorf("type error: expected a map for message events.v1.Detail (int given)"), result)
+
+	result = call(ctx, registry, "decode", object.NewString("events.v1.Detail"), object.NewByteSlice([]byte{10, 5}))
+	require.True(t, object.IsError(result))
+
+	result = Load(ctx, object.NewByteSlice([]byte{0xff}))
+	require.True(t, object.IsError(result))
+}
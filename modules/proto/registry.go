@@ -0,0 +1,250 @@
+package proto
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const REGISTRY object.Type = "proto.registry"
+
+// TypeResolver resolves message and extension types, as needed to encode and
+// decode google.protobuf.Any values.
+type TypeResolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// Registry holds loaded message descriptors. The well-known types are always
+// available.
+type Registry struct {
+	files *protoregistry.Files
+	types *resolver
+}
+
+func NewRegistry(files *protoregistry.Files) *Registry {
+	if files == nil {
+		files = &protoregistry.Files{}
+	}
+	return &Registry{files: files, types: &resolver{dynamicpb.NewTypes(files)}}
+}
+
+func (r *Registry) Type() object.Type {
+	return REGISTRY
+}
+
+func (r *Registry) Inspect() string {
+	return fmt.Sprintf("proto.registry(files=%d)", r.files.NumFiles())
+}
+
+func (r *Registry) Interface() interface{} {
+	return r.files
+}
+
+func (r *Registry) IsTruthy() bool {
+	return true
+}
+
+func (r *Registry) Cost() int {
+	return 0
+}
+
+func (r *Registry) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", REGISTRY)
+}
+
+func (r *Registry) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", REGISTRY, opType)
+}
+
+func (r *Registry) Equals(other object.Object) object.Object {
+	if r == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (r *Registry) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", REGISTRY, name)
+}
+
+func (r *Registry) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "messages":
+		return object.NewStringList(r.messages()), true
+	case "encode":
+		return object.NewBuiltin("proto.registry.encode", r.Encode), true
+	case "decode":
+		return object.NewBuiltin("proto.registry.decode", r.Decode), true
+	case "describe":
+		return object.NewBuiltin("proto.registry.describe", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewArgsError("proto.registry.describe", 1, len(args))
+			}
+			desc, err := r.findMessage(args[0])
+			if err != nil {
+				return err
+			}
+			return describeMessage(desc)
+		}), true
+	}
+	return nil, false
+}
+
+// Encode converts a map to a message of the given type and returns its wire
+// format encoding.
+func (r *Registry) Encode(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 2 || len(args) > 3 {
+		return object.NewArgsRangeError("proto.registry.encode", 2, 3, len(args))
+	}
+	desc, errObj := r.findMessage(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	var opts proto.MarshalOptions
+	if len(args) == 3 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "deterministic":
+				if opts.Deterministic, err = object.AsBool(value); err != nil {
+					return err
+				}
+			default:
+				return object.Errorf("value error: unknown proto encode option %q", key)
+			}
+		}
+	}
+	msg, errObj := ToMessage(desc, args[1], r.types)
+	if errObj != nil {
+		return errObj
+	}
+	data, err := opts.Marshal(msg)
+	if err != nil {
+		return object.Errorf("proto error: %s", err)
+	}
+	return object.NewByteSlice(data)
+}
+
+// Decode parses wire format data as a message of the given type and returns
+// it as a map.
+func (r *Registry) Decode(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 2 || len(args) > 3 {
+		return object.NewArgsRangeError("proto.registry.decode", 2, 3, len(args))
+	}
+	desc, errObj := r.findMessage(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	data, errObj := object.AsBytes(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	opts := proto.UnmarshalOptions{Resolver: r.types}
+	if len(args) == 3 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "discard_unknown":
+				if opts.DiscardUnknown, err = object.AsBool(value); err != nil {
+					return err
+				}
+			default:
+				return object.Errorf("value error: unknown proto decode option %q", key)
+			}
+		}
+	}
+	msg := dynamicpb.NewMessage(desc)
+	if err := opts.Unmarshal(data, msg); err != nil {
+		return object.Errorf("proto error: invalid %s message: %s", desc.FullName(), err)
+	}
+	return MessageToObject(msg, r.types)
+}
+
+func (r *Registry) findMessage(obj object.Object) (protoreflect.MessageDescriptor, *object.Error) {
+	name, err := object.AsString(obj)
+	if err != nil {
+		return nil, err
+	}
+	desc, findErr := fileResolver{r.files}.FindDescriptorByName(protoreflect.FullName(name))
+	if findErr != nil {
+		return nil, object.Errorf("value error: message type %q not found", name)
+	}
+	msg, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, object.Errorf("value error: %q is not a message type", name)
+	}
+	return msg, nil
+}
+
+// messages returns the sorted names of the message types in the loaded
+// files, including nested types. The well-known types are omitted.
+func (r *Registry) messages() []string {
+	var names []string
+	var add func(messages protoreflect.MessageDescriptors)
+	add = func(messages protoreflect.MessageDescriptors) {
+		for i := 0; i < messages.Len(); i++ {
+			msg := messages.Get(i)
+			if msg.IsMapEntry() {
+				continue
+			}
+			names = append(names, string(msg.FullName()))
+			add(msg.Messages())
+		}
+	}
+	r.files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		if file.Package() != "google.protobuf" {
+			add(file.Messages())
+		}
+		return true
+	})
+	sort.Strings(names)
+	return names
+}
+
+// resolver resolves types from the loaded files, falling back to the
+// well-known types linked into the binary.
+type resolver struct {
+	types *dynamicpb.Types
+}
+
+func (r *resolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if mt, err := r.types.FindMessageByName(name); err == nil {
+		return mt, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByName(name)
+}
+
+func (r *resolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	if mt, err := r.types.FindMessageByURL(url); err == nil {
+		return mt, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByURL(url)
+}
+
+func (r *resolver) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	if xt, err := r.types.FindExtensionByName(name); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByName(name)
+}
+
+func (r *resolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	if xt, err := r.types.FindExtensionByNumber(message, field); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
@@ -0,0 +1,20 @@
+module github.com/risor-io/risor/modules/qrcode
+
+go 1.21
+
+replace github.com/risor-io/risor => ../..
+
+require (
+	github.com/makiuchi-d/gozxing v0.1.1
+	github.com/risor-io/risor v1.1.0
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/text v0.3.7 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
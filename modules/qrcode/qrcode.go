@@ -0,0 +1,300 @@
+package qrcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+type barcodeType struct {
+	format gozxing.BarcodeFormat
+	writer func() gozxing.Writer
+}
+
+var barcodeTypes = map[string]barcodeType{
+	"codabar": {gozxing.BarcodeFormat_CODABAR, oned.NewCodaBarWriter},
+	"code128": {gozxing.BarcodeFormat_CODE_128, oned.NewCode128Writer},
+	"code39":  {gozxing.BarcodeFormat_CODE_39, oned.NewCode39Writer},
+	"code93":  {gozxing.BarcodeFormat_CODE_93, oned.NewCode93Writer},
+	"ean13":   {gozxing.BarcodeFormat_EAN_13, oned.NewEAN13Writer},
+	"ean8":    {gozxing.BarcodeFormat_EAN_8, oned.NewEAN8Writer},
+	"itf":     {gozxing.BarcodeFormat_ITF, oned.NewITFWriter},
+	"upca":    {gozxing.BarcodeFormat_UPC_A, oned.NewUPCAWriter},
+	"upce":    {gozxing.BarcodeFormat_UPC_E, oned.NewUPCEWriter},
+}
+
+// options holds the options accepted by encode and barcode.
+type options struct {
+	format string
+	level  string
+	kind   string
+	border int
+	render renderOptions
+}
+
+func parseColor(s string) (color.RGBA, *object.Error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return color.RGBA{}, object.Errorf("value error: invalid color %q (expected \"#rrggbb\")", s)
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}, nil
+}
+
+func parseOptions(fn string, args []object.Object, opts *options) *object.Error {
+	if len(args) == 0 {
+		return nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "format":
+			if opts.format, err = object.AsString(value); err != nil {
+				return err
+			}
+			if opts.format != "png" && opts.format != "svg" && opts.format != "text" {
+				return object.Errorf("value error: format must be \"png\", \"svg\", or \"text\" (got %q)", opts.format)
+			}
+		case "size", "height", "border":
+			n, err := object.AsInt(value)
+			if err != nil {
+				return err
+			}
+			if n < 0 || (key != "border" && n == 0) {
+				return object.Errorf("value error: %s must be positive (got %d)", key, n)
+			}
+			switch key {
+			case "size":
+				opts.render.width = int(n)
+			case "height":
+				opts.render.height = int(n)
+			case "border":
+				opts.border = int(n)
+			}
+		case "foreground", "background":
+			s, err := object.AsString(value)
+			if err != nil {
+				return err
+			}
+			c, err := parseColor(s)
+			if err != nil {
+				return err
+			}
+			if key == "foreground" {
+				opts.render.foreground = c
+			} else {
+				opts.render.background = c
+			}
+		case "level":
+			if fn != "qrcode.encode" {
+				return object.Errorf("value error: unknown %s option %q", fn, key)
+			}
+			if opts.level, err = object.AsString(value); err != nil {
+				return err
+			}
+			opts.level = strings.ToUpper(opts.level)
+			if opts.level != "L" && opts.level != "M" && opts.level != "Q" && opts.level != "H" {
+				return object.Errorf("value error: level must be \"L\", \"M\", \"Q\", or \"H\" (got %q)", opts.level)
+			}
+		case "type":
+			if fn != "qrcode.barcode" {
+				return object.Errorf("value error: unknown %s option %q", fn, key)
+			}
+			if opts.kind, err = object.AsString(value); err != nil {
+				return err
+			}
+			if _, ok := barcodeTypes[opts.kind]; !ok {
+				names := make([]string, 0, len(barcodeTypes))
+				for name := range barcodeTypes {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				return object.Errorf("value error: unknown barcode type %q (expected one of %s)", opts.kind, strings.Join(names, ", "))
+			}
+		default:
+			return object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+	}
+	return nil
+}
+
+func defaultOptions() options {
+	return options{
+		format: "png",
+		render: renderOptions{
+			foreground: color.RGBA{A: 0xff},
+			background: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		},
+	}
+}
+
+func render(s *symbol, opts options) object.Object {
+	switch opts.format {
+	case "svg":
+		return object.NewString(s.svg(opts.render))
+	case "text":
+		return object.NewString(s.text())
+	}
+	data, err := s.png(opts.render)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewByteSlice(data)
+}
+
+// writerError strips the exception prefixes from gozxing errors.
+func writerError(err error) *object.Error {
+	msg := err.Error()
+	if idx := strings.LastIndex(msg, "Exception: "); idx >= 0 {
+		msg = msg[idx+len("Exception: "):]
+	}
+	return object.Errorf("qrcode error: %s", msg)
+}
+
+// Encode renders text as a QR code.
+func Encode(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("qrcode.encode", 1, 2, args); err != nil {
+		return err
+	}
+	text, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts := defaultOptions()
+	opts.level, opts.border, opts.render.width = "M", 4, 256
+	if err := parseOptions("qrcode.encode", args[1:], &opts); err != nil {
+		return err
+	}
+	hints := map[gozxing.EncodeHintType]interface{}{
+		gozxing.EncodeHintType_ERROR_CORRECTION: opts.level,
+		gozxing.EncodeHintType_CHARACTER_SET:    "UTF-8",
+		gozxing.EncodeHintType_MARGIN:           opts.border,
+	}
+	matrix, encodeErr := qrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, 0, 0, hints)
+	if encodeErr != nil {
+		return writerError(encodeErr)
+	}
+	opts.render.height = opts.render.width
+	return render(&symbol{matrix: matrix, rows: matrix.GetHeight()}, opts)
+}
+
+// Barcode renders text as a one-dimensional barcode.
+func Barcode(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("qrcode.barcode", 1, 2, args); err != nil {
+		return err
+	}
+	text, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts := defaultOptions()
+	opts.kind, opts.border, opts.render.height = "code128", 10, 80
+	if err := parseOptions("qrcode.barcode", args[1:], &opts); err != nil {
+		return err
+	}
+	kind := barcodeTypes[opts.kind]
+	hints := map[gozxing.EncodeHintType]interface{}{
+		gozxing.EncodeHintType_MARGIN: opts.border * 2,
+	}
+	matrix, encodeErr := kind.writer().Encode(text, kind.format, 0, 0, hints)
+	if encodeErr != nil {
+		return writerError(encodeErr)
+	}
+	if opts.render.width == 0 {
+		opts.render.width = matrix.GetWidth() * 2
+	}
+	return render(&symbol{matrix: matrix, rows: 1}, opts)
+}
+
+var barcodeNames = map[gozxing.BarcodeFormat]string{
+	gozxing.BarcodeFormat_QR_CODE: "qr",
+}
+
+func init() {
+	for name, kind := range barcodeTypes {
+		barcodeNames[kind.format] = name
+	}
+}
+
+// decodeImage finds and decodes a QR code or barcode in an image.
+func decodeImage(img image.Image) (*gozxing.Result, error) {
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return nil, err
+	}
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+	readers := []gozxing.Reader{
+		qrcode.NewQRCodeReader(),
+		oned.NewMultiFormatUPCEANReader(hints),
+		oned.NewCode128Reader(),
+		oned.NewCode39Reader(),
+		oned.NewCode93Reader(),
+		oned.NewITFReader(),
+		oned.NewCodaBarReader(),
+	}
+	for _, reader := range readers {
+		if result, err := reader.Decode(bitmap, hints); err == nil {
+			return result, nil
+		}
+	}
+	return nil, errors.New("qrcode error: no code found in image")
+}
+
+// Decode returns the text of the QR code or barcode in an image.
+func Decode(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("qrcode.decode", 1, args); err != nil {
+		return err
+	}
+	var r io.Reader
+	if data, ok := args[0].(*object.ByteSlice); ok {
+		r = bytes.NewReader(data.Value())
+	} else {
+		var err *object.Error
+		if r, err = object.AsReader(args[0]); err != nil {
+			return err
+		}
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return object.Errorf("value error: unable to decode image: %v", err)
+	}
+	result, err := decodeImage(img)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewMap(map[string]object.Object{
+		"text": object.NewString(result.GetText()),
+		"type": object.NewString(barcodeNames[result.GetBarcodeFormat()]),
+	})
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("qrcode", map[string]object.Object{
+		"barcode": object.NewBuiltin("barcode", Barcode),
+		"decode":  object.NewBuiltin("decode", Decode),
+		"encode":  object.NewBuiltin("encode", Encode),
+	})
+}
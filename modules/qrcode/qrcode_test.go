@@ -0,0 +1,117 @@
+package qrcode
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	ctx := context.Background()
+	uri := "otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example"
+	result := Encode(ctx, object.NewString(uri), object.NewMap(map[string]object.Object{
+		"size":  object.NewInt(300),
+		"level": object.NewString("h"),
+	}))
+	data, ok := result.(*object.ByteSlice)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+
+	img, err := png.Decode(bytes.NewReader(data.Value()))
+	require.Nil(t, err)
+	require.Equal(t, 300, img.Bounds().Dx())
+	require.Equal(t, 300, img.Bounds().Dy())
+
+	decoded := Decode(ctx, data)
+	require.Equal(t, object.NewMap(map[string]object.Object{
+		"text": object.NewString(uri),
+		"type": object.NewString("qr"),
+	}), decoded)
+}
+
+func TestBarcode(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		kind string
+		text string
+	}{
+		{"code128", "PKG-2024-0042"},
+		{"ean13", "4006381333931"},
+		{"code39", "ASSET42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			result := Barcode(ctx, object.NewString(tt.text), object.NewMap(map[string]object.Object{
+				"type":   object.NewString(tt.kind),
+				"height": object.NewInt(60),
+			}))
+			data, ok := result.(*object.ByteSlice)
+			require.True(t, ok, "unexpected result: %s", result.Inspect())
+			img, err := png.Decode(bytes.NewReader(data.Value()))
+			require.Nil(t, err)
+			require.Equal(t, 60, img.Bounds().Dy())
+
+			decoded, ok := Decode(ctx, data).(*object.Map)
+			require.True(t, ok)
+			require.Equal(t, object.NewString(tt.text), decoded.Get("text"))
+			require.Equal(t, object.NewString(tt.kind), decoded.Get("type"))
+		})
+	}
+}
+
+func TestSVGAndText(t *testing.T) {
+	ctx := context.Background()
+	result := Encode(ctx, object.NewString("hello"), object.NewMap(map[string]object.Object{
+		"format":     object.NewString("svg"),
+		"size":       object.NewInt(100),
+		"foreground": object.NewString("#336699"),
+	}))
+	svg, ok := result.(*object.String)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	require.True(t, strings.HasPrefix(svg.Value(), `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100"`))
+	require.Contains(t, svg.Value(), `fill="#336699"`)
+	require.Contains(t, svg.Value(), `<rect width="100%" height="100%" fill="#ffffff"/>`)
+
+	result = Encode(ctx, object.NewString("hello"), object.NewMap(map[string]object.Object{
+		"format": object.NewString("text"),
+		"border": object.NewInt(1),
+	}))
+	text, ok := result.(*object.String)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	lines := strings.Split(strings.TrimSuffix(text.Value(), "\n"), "\n")
+	// A version 1 code has 21 modules, plus a border of 1 on each side
+	require.Len(t, lines, 12)
+	require.Equal(t, 23, len([]rune(lines[0])))
+	// The first line has the light border above the top of the finder pattern
+	require.True(t, strings.HasPrefix(lines[0], "█▀▀▀▀▀▀▀"), lines[0])
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		result object.Object
+		err    string
+	}{
+		{Encode(ctx, object.NewString("x"), object.NewMap(map[string]object.Object{"level": object.NewString("Z")})),
+			`value error: level must be "L", "M", "Q", or "H" (got "Z")`},
+		{Encode(ctx, object.NewString("x"), object.NewMap(map[string]object.Object{"format": object.NewString("jpg")})),
+			`value error: format must be "png", "svg", or "text" (got "jpg")`},
+		{Encode(ctx, object.NewString("x"), object.NewMap(map[string]object.Object{"type": object.NewString("ean13")})),
+			`value error: unknown qrcode.encode option "type"`},
+		{Encode(ctx, object.NewString("x"), object.NewMap(map[string]object.Object{"background": object.NewString("white")})),
+			`value error: invalid color "white" (expected "#rrggbb")`},
+		{Encode(ctx, object.NewString("")), "qrcode error: Found empty contents"},
+		{Barcode(ctx, object.NewString("123"), object.NewMap(map[string]object.Object{"type": object.NewString("pdf417")})),
+			`value error: unknown barcode type "pdf417" (expected one of codabar, code128, code39, code93, ean13, ean8, itf, upca, upce)`},
+		{Decode(ctx, object.NewByteSlice([]byte("not an image"))), "value error: unable to decode image: image: unknown format"},
+	}
+	for _, tt := range tests {
+		errObj, ok := tt.result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", tt.result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+}
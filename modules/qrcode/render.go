@@ -0,0 +1,141 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"github.com/makiuchi-d/gozxing"
+)
+
+// symbol is a grid of dark and light modules, including its quiet zone.
+type symbol struct {
+	matrix *gozxing.BitMatrix
+	// rows is the number of rows to render. One-dimensional barcodes have a
+	// single row in their matrix, which is repeated.
+	rows int
+}
+
+func (s *symbol) width() int {
+	return s.matrix.GetWidth()
+}
+
+func (s *symbol) dark(x, y int) bool {
+	if s.matrix.GetHeight() == 1 {
+		y = 0
+	}
+	return s.matrix.Get(x, y)
+}
+
+// renderOptions describes the size and colors of a rendered symbol.
+type renderOptions struct {
+	width      int
+	height     int
+	foreground color.RGBA
+	background color.RGBA
+}
+
+// layout returns the size of each module in pixels and the offsets that
+// center the symbol within the image.
+func (s *symbol) layout(opts renderOptions) (scaleX, scaleY, width, height, left, top int) {
+	scaleX = max(1, opts.width/s.width())
+	scaleY = scaleX
+	if s.matrix.GetHeight() == 1 {
+		// Bars span the full height of the image
+		scaleY = max(1, opts.height/s.rows)
+	}
+	width = max(opts.width, s.width()*scaleX)
+	height = max(opts.height, s.rows*scaleY)
+	left = (width - s.width()*scaleX) / 2
+	top = (height - s.rows*scaleY) / 2
+	return
+}
+
+func (s *symbol) png(opts renderOptions) ([]byte, error) {
+	scaleX, scaleY, width, height, left, top := s.layout(opts)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, opts.background)
+		}
+	}
+	for my := 0; my < s.rows; my++ {
+		for mx := 0; mx < s.width(); mx++ {
+			if !s.dark(mx, my) {
+				continue
+			}
+			for y := top + my*scaleY; y < top+(my+1)*scaleY; y++ {
+				for x := left + mx*scaleX; x < left+(mx+1)*scaleX; x++ {
+					img.SetRGBA(x, y, opts.foreground)
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (s *symbol) svg(opts renderOptions) string {
+	scaleX, scaleY, width, height, left, top := s.layout(opts)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, hexColor(opts.background))
+	fmt.Fprintf(&b, `<path fill="%s" d="`, hexColor(opts.foreground))
+	for my := 0; my < s.rows; my++ {
+		// Consecutive dark modules in a row are drawn as a single rectangle
+		for mx := 0; mx < s.width(); {
+			if !s.dark(mx, my) {
+				mx++
+				continue
+			}
+			run := 1
+			for mx+run < s.width() && s.dark(mx+run, my) {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv%dh-%dz", left+mx*scaleX, top+my*scaleY, run*scaleX, scaleY, run*scaleX)
+			mx += run
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
+
+// text renders the symbol using Unicode block characters, two rows of
+// modules per line. Light modules are drawn, so the result is readable on a
+// terminal with a dark background.
+func (s *symbol) text() string {
+	rows := s.rows
+	if s.matrix.GetHeight() == 1 {
+		rows = 8
+	}
+	var b strings.Builder
+	for y := 0; y < rows; y += 2 {
+		for x := 0; x < s.width(); x++ {
+			top := !s.dark(x, y)
+			bottom := y+1 < rows && !s.dark(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,230 @@
+package random
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// The functions below implement the operations shared by the module and its
+// generators. Each one draws from the given generator, and reports errors
+// under the given function name.
+
+// int64Range returns a value in [min, max), even if the range doesn't fit in
+// an int64.
+func int64Range(r *rand.Rand, min, max int64) int64 {
+	span := uint64(max - min)
+	if span <= math.MaxInt64 {
+		return min + r.Int63n(int64(span))
+	}
+	// Rejection sampling keeps the result uniform; at least half of the
+	// draws are accepted
+	for {
+		if v := r.Uint64(); v < span {
+			return min + int64(v)
+		}
+	}
+}
+
+func drawInt(fn string, r *rand.Rand, args []object.Object) object.Object {
+	if err := arg.RequireRange(fn, 1, 2, args); err != nil {
+		return err
+	}
+	var min, max int64
+	var err *object.Error
+	if len(args) == 1 {
+		if max, err = object.AsInt(args[0]); err != nil {
+			return err
+		}
+	} else {
+		if min, err = object.AsInt(args[0]); err != nil {
+			return err
+		}
+		if max, err = object.AsInt(args[1]); err != nil {
+			return err
+		}
+	}
+	if min >= max {
+		return object.Errorf("value error: %s requires min to be less than max (got %d and %d)", fn, min, max)
+	}
+	return object.NewInt(int64Range(r, min, max))
+}
+
+func drawFloat(fn string, r *rand.Rand, args []object.Object) object.Object {
+	if err := arg.RequireRange(fn, 0, 2, args); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return object.NewFloat(r.Float64())
+	}
+	if len(args) == 1 {
+		return object.Errorf("value error: %s requires both min and max, or neither", fn)
+	}
+	min, err := object.AsFloat(args[0])
+	if err != nil {
+		return err
+	}
+	max, err := object.AsFloat(args[1])
+	if err != nil {
+		return err
+	}
+	if !(min < max) || math.IsInf(max-min, 0) {
+		return object.Errorf("value error: %s requires min to be less than max (got %v and %v)", fn, min, max)
+	}
+	return object.NewFloat(min + r.Float64()*(max-min))
+}
+
+func drawNormal(fn string, r *rand.Rand, args []object.Object) object.Object {
+	if err := arg.RequireRange(fn, 0, 2, args); err != nil {
+		return err
+	}
+	mean, stddev := 0.0, 1.0
+	var err *object.Error
+	if len(args) > 0 {
+		if mean, err = object.AsFloat(args[0]); err != nil {
+			return err
+		}
+	}
+	if len(args) > 1 {
+		if stddev, err = object.AsFloat(args[1]); err != nil {
+			return err
+		}
+		if stddev < 0 {
+			return object.Errorf("value error: %s requires a non-negative standard deviation (got %v)", fn, stddev)
+		}
+	}
+	return object.NewFloat(mean + r.NormFloat64()*stddev)
+}
+
+// items returns the items of a list, or the characters of a string.
+func items(fn string, obj object.Object) ([]object.Object, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.List:
+		return obj.Value(), nil
+	case *object.String:
+		runes := []rune(obj.Value())
+		result := make([]object.Object, len(runes))
+		for i, r := range runes {
+			result[i] = object.NewString(string(r))
+		}
+		return result, nil
+	default:
+		return nil, object.Errorf("type error: %s expected a list or string (%s given)", fn, obj.Type())
+	}
+}
+
+func drawChoice(fn string, r *rand.Rand, args []object.Object) object.Object {
+	if err := arg.Require(fn, 1, args); err != nil {
+		return err
+	}
+	values, err := items(fn, args[0])
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return object.Errorf("value error: %s requires a non-empty %s", fn, args[0].Type())
+	}
+	return values[r.Intn(len(values))]
+}
+
+func drawShuffle(fn string, r *rand.Rand, args []object.Object) object.Object {
+	if err := arg.Require(fn, 1, args); err != nil {
+		return err
+	}
+	list, err := object.AsList(args[0])
+	if err != nil {
+		return err
+	}
+	shuffled := make([]object.Object, len(list.Value()))
+	copy(shuffled, list.Value())
+	r.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return object.NewList(shuffled)
+}
+
+func drawSample(fn string, r *rand.Rand, args []object.Object) object.Object {
+	if err := arg.Require(fn, 2, args); err != nil {
+		return err
+	}
+	values, err := items(fn, args[0])
+	if err != nil {
+		return err
+	}
+	k, err := object.AsInt(args[1])
+	if err != nil {
+		return err
+	}
+	if k < 0 || k > int64(len(values)) {
+		return object.Errorf("value error: %s sample size must be between 0 and %d (got %d)", fn, len(values), k)
+	}
+	// A partial Fisher-Yates shuffle picks k distinct positions
+	pool := make([]object.Object, len(values))
+	copy(pool, values)
+	for i := 0; i < int(k); i++ {
+		j := i + r.Intn(len(pool)-i)
+		pool[i], pool[j] = pool[j], pool[i]
+	}
+	return object.NewList(pool[:k])
+}
+
+func drawWeightedChoice(fn string, r *rand.Rand, args []object.Object) object.Object {
+	if err := arg.RequireRange(fn, 1, 2, args); err != nil {
+		return err
+	}
+	var values, weights []object.Object
+	if len(args) == 1 {
+		// A map gives the weight of each key; sorting the keys keeps seeded
+		// draws reproducible
+		m, err := object.AsMap(args[0])
+		if err != nil {
+			return err
+		}
+		for _, key := range m.SortedKeys() {
+			values = append(values, object.NewString(key))
+			weights = append(weights, m.Get(key))
+		}
+	} else {
+		var err *object.Error
+		if values, err = items(fn, args[0]); err != nil {
+			return err
+		}
+		list, err := object.AsList(args[1])
+		if err != nil {
+			return err
+		}
+		weights = list.Value()
+		if len(values) != len(weights) {
+			return object.Errorf("value error: %s requires as many weights as items (got %d and %d)", fn, len(weights), len(values))
+		}
+	}
+	cumulative := make([]float64, len(weights))
+	var total float64
+	for i, w := range weights {
+		weight, err := object.AsFloat(w)
+		if err != nil {
+			return err
+		}
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return object.Errorf("value error: %s weights must be non-negative numbers (got %v)", fn, weight)
+		}
+		total += weight
+		cumulative[i] = total
+	}
+	if total == 0 {
+		return object.Errorf("value error: %s requires at least one positive weight", fn)
+	}
+	target := r.Float64() * total
+	// The first cumulative weight above the target picks the item, which
+	// skips items with no weight
+	i := sort.Search(len(cumulative), func(i int) bool { return cumulative[i] > target })
+	for i == len(cumulative) || (i > 0 && cumulative[i] == cumulative[i-1]) {
+		// Rounding put the target at the total; step back to an item with
+		// weight
+		i--
+	}
+	return values[i]
+}
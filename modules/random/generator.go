@@ -0,0 +1,106 @@
+package random
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const GENERATOR object.Type = "random.generator"
+
+// Generator draws random values from its own source, which is either seeded
+// or secure. It is safe to share between threads.
+type Generator struct {
+	rng    *rand.Rand
+	seed   int64
+	secure bool
+}
+
+// NewGenerator returns a Generator drawing from a pseudo-random source with
+// the given seed.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{rng: rand.New(NewSource(seed)), seed: seed}
+}
+
+// NewSecureGenerator returns a Generator drawing from the operating system's
+// secure random number generator.
+func NewSecureGenerator() *Generator {
+	return &Generator{rng: rand.New(cryptoSource{}), secure: true}
+}
+
+func (g *Generator) Type() object.Type {
+	return GENERATOR
+}
+
+func (g *Generator) Inspect() string {
+	if g.secure {
+		return "random.generator(secure)"
+	}
+	return fmt.Sprintf("random.generator(seed=%d)", g.seed)
+}
+
+func (g *Generator) Interface() interface{} {
+	return nil
+}
+
+func (g *Generator) Equals(other object.Object) object.Object {
+	return object.NewBool(g == other)
+}
+
+func (g *Generator) IsTruthy() bool {
+	return true
+}
+
+func (g *Generator) Cost() int {
+	return 0
+}
+
+func (g *Generator) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", GENERATOR)
+}
+
+func (g *Generator) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", GENERATOR, opType)
+}
+
+func (g *Generator) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", GENERATOR, name)
+}
+
+// method wraps one of the draw functions as a method of the generator.
+func (g *Generator) method(name string, draw func(string, *rand.Rand, []object.Object) object.Object) *object.Builtin {
+	fn := "random.generator." + name
+	return object.NewBuiltin(fn, func(ctx context.Context, args ...object.Object) object.Object {
+		return draw(fn, g.rng, args)
+	})
+}
+
+func (g *Generator) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "secure":
+		return object.NewBool(g.secure), true
+	case "seed":
+		if g.secure {
+			return object.Nil, true
+		}
+		return object.NewInt(g.seed), true
+	case "int":
+		return g.method(name, drawInt), true
+	case "float":
+		return g.method(name, drawFloat), true
+	case "normal":
+		return g.method(name, drawNormal), true
+	case "choice":
+		return g.method(name, drawChoice), true
+	case "shuffle":
+		return g.method(name, drawShuffle), true
+	case "sample":
+		return g.method(name, drawSample), true
+	case "weighted_choice":
+		return g.method(name, drawWeightedChoice), true
+	}
+	return nil, false
+}
@@ -0,0 +1,117 @@
+package random
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"math/rand"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// tokenSize is the default number of random bytes in a token, which is
+// plenty to make tokens unguessable.
+const tokenSize = 32
+
+// defaultRand is used when the host didn't provide a source.
+var defaultRand = rand.New(NewSource(randomSeed()))
+
+// secure is exposed as the module's secure generator.
+var secure = NewSecureGenerator()
+
+// fromContext returns the generator drawing from the host's source, if it
+// provided one, so that seeded evaluations are reproducible.
+func fromContext(ctx context.Context) *rand.Rand {
+	if src, ok := object.GetRandSource(ctx); ok {
+		return rand.New(src)
+	}
+	return defaultRand
+}
+
+// function wraps one of the draw functions as a module function.
+func function(name string, draw func(string, *rand.Rand, []object.Object) object.Object) *object.Builtin {
+	fn := "random." + name
+	return object.NewBuiltin(name, func(ctx context.Context, args ...object.Object) object.Object {
+		return draw(fn, fromContext(ctx), args)
+	})
+}
+
+func GeneratorBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("random.generator", 0, 1, args); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return NewGenerator(fromContext(ctx).Int63())
+	}
+	seed, err := object.AsInt(args[0])
+	if err != nil {
+		return err
+	}
+	return NewGenerator(seed)
+}
+
+// tokenBytes returns the given number of secure random bytes, which defaults
+// to tokenSize.
+func tokenBytes(fn string, args []object.Object) ([]byte, *object.Error) {
+	if err := arg.RequireRange(fn, 0, 1, args); err != nil {
+		return nil, err
+	}
+	n := int64(tokenSize)
+	if len(args) == 1 {
+		var err *object.Error
+		if n, err = object.AsInt(args[0]); err != nil {
+			return nil, err
+		}
+		if n < 1 || n > 1024*1024 {
+			return nil, object.Errorf("value error: %s size must be between 1 and 1048576 (got %d)", fn, n)
+		}
+	}
+	b := make([]byte, n)
+	if _, err := crand.Read(b); err != nil {
+		return nil, object.NewError(err)
+	}
+	return b, nil
+}
+
+func TokenBytes(ctx context.Context, args ...object.Object) object.Object {
+	b, err := tokenBytes("random.token_bytes", args)
+	if err != nil {
+		return err
+	}
+	return object.NewByteSlice(b)
+}
+
+func TokenHex(ctx context.Context, args ...object.Object) object.Object {
+	b, err := tokenBytes("random.token_hex", args)
+	if err != nil {
+		return err
+	}
+	return object.NewString(hex.EncodeToString(b))
+}
+
+func TokenURLSafe(ctx context.Context, args ...object.Object) object.Object {
+	b, err := tokenBytes("random.token_urlsafe", args)
+	if err != nil {
+		return err
+	}
+	return object.NewString(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("random", map[string]object.Object{
+		"choice":          function("choice", drawChoice),
+		"float":           function("float", drawFloat),
+		"generator":       object.NewBuiltin("generator", GeneratorBuiltin),
+		"int":             function("int", drawInt),
+		"normal":          function("normal", drawNormal),
+		"sample":          function("sample", drawSample),
+		"secure":          secure,
+		"shuffle":         function("shuffle", drawShuffle),
+		"token_bytes":     object.NewBuiltin("token_bytes", TokenBytes),
+		"token_hex":       object.NewBuiltin("token_hex", TokenHex),
+		"token_urlsafe":   object.NewBuiltin("token_urlsafe", TokenURLSafe),
+		"weighted_choice": function("weighted_choice", drawWeightedChoice),
+	})
+}
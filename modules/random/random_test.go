@@ -0,0 +1,209 @@
+package random
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func call(t *testing.T, g *Generator, method string, args ...object.Object) object.Object {
+	t.Helper()
+	attr, ok := g.GetAttr(method)
+	require.True(t, ok, method)
+	fn, ok := attr.(*object.Builtin)
+	require.True(t, ok)
+	return fn.Call(context.Background(), args...)
+}
+
+func list(values ...interface{}) *object.List {
+	return object.FromGoType(values).(*object.List)
+}
+
+func TestSeededGenerator(t *testing.T) {
+	a, b := NewGenerator(42), NewGenerator(42)
+	tests := []struct {
+		method string
+		args   []object.Object
+	}{
+		{"int", []object.Object{object.NewInt(1000)}},
+		{"int", []object.Object{object.NewInt(-5), object.NewInt(5)}},
+		{"float", nil},
+		{"normal", []object.Object{object.NewFloat(10), object.NewFloat(2)}},
+		{"choice", []object.Object{list("a", "b", "c", "d")}},
+		{"shuffle", []object.Object{list(1, 2, 3, 4, 5)}},
+		{"sample", []object.Object{list(1, 2, 3, 4, 5), object.NewInt(3)}},
+		{"weighted_choice", []object.Object{list("a", "b"), list(1, 3)}},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 10; i++ {
+			require.Equal(t, call(t, a, tt.method, tt.args...), call(t, b, tt.method, tt.args...), tt.method)
+		}
+	}
+	seed, ok := a.GetAttr("seed")
+	require.True(t, ok)
+	require.Equal(t, object.NewInt(42), seed)
+	require.Equal(t, "random.generator(seed=42)", a.Inspect())
+}
+
+func TestRanges(t *testing.T) {
+	g := NewGenerator(1)
+	for i := 0; i < 1000; i++ {
+		n := call(t, g, "int", object.NewInt(-3), object.NewInt(3)).(*object.Int).Value()
+		require.True(t, n >= -3 && n < 3, n)
+		f := call(t, g, "float", object.NewFloat(2.5), object.NewInt(3)).(*object.Float).Value()
+		require.True(t, f >= 2.5 && f < 3, f)
+	}
+	// Ranges wider than an int64 are still drawn from
+	r := rand.New(NewSource(1))
+	for i := 0; i < 100; i++ {
+		int64Range(r, -1<<63, 1<<63-1)
+	}
+	require.Equal(t, int64(7), int64Range(r, 7, 8))
+}
+
+func TestShuffleAndSample(t *testing.T) {
+	g := NewGenerator(7)
+	original := list(1, 2, 3, 4, 5, 6, 7, 8)
+	shuffled := call(t, g, "shuffle", original).(*object.List)
+	// The given list is left alone
+	require.Equal(t, list(1, 2, 3, 4, 5, 6, 7, 8), original)
+	require.ElementsMatch(t, original.Value(), shuffled.Value())
+
+	sample := call(t, g, "sample", object.NewString("abcdef"), object.NewInt(6)).(*object.List)
+	var letters []string
+	for _, item := range sample.Value() {
+		letters = append(letters, item.(*object.String).Value())
+	}
+	sort.Strings(letters)
+	require.Equal(t, []string{"a", "b", "c", "d", "e", "f"}, letters)
+	require.Equal(t, list(), call(t, g, "sample", original, object.NewInt(0)))
+}
+
+func TestWeightedChoice(t *testing.T) {
+	g := NewGenerator(3)
+	counts := map[string]int{}
+	weights := object.FromGoType(map[string]interface{}{"a": 1, "b": 3, "never": 0})
+	for i := 0; i < 4000; i++ {
+		counts[call(t, g, "weighted_choice", weights).(*object.String).Value()]++
+	}
+	require.Zero(t, counts["never"])
+	require.InDelta(t, 3000, counts["b"], 150)
+
+	for i := 0; i < 100; i++ {
+		choice := call(t, g, "weighted_choice", list("x", "y", "z"), list(0, 0.5, 0))
+		require.Equal(t, object.NewString("y"), choice)
+	}
+}
+
+func TestSecure(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, object.True, object.NewBool(secure.secure))
+	seed, _ := secure.GetAttr("seed")
+	require.Equal(t, object.Nil, seed)
+	n := call(t, secure, "int", object.NewInt(10)).(*object.Int).Value()
+	require.True(t, n >= 0 && n < 10)
+
+	token := TokenHex(ctx).(*object.String).Value()
+	b, err := hex.DecodeString(token)
+	require.NoError(t, err)
+	require.Len(t, b, 32)
+	require.NotEqual(t, token, TokenHex(ctx).(*object.String).Value())
+
+	b, err = base64.RawURLEncoding.DecodeString(TokenURLSafe(ctx, object.NewInt(16)).(*object.String).Value())
+	require.NoError(t, err)
+	require.Len(t, b, 16)
+	require.Len(t, TokenBytes(ctx, object.NewInt(8)).(*object.ByteSlice).Value(), 8)
+}
+
+func TestContextSource(t *testing.T) {
+	draw := func() []object.Object {
+		ctx := object.WithRandSource(context.Background(), NewSource(99))
+		fn := Module().GetAttr
+		var results []object.Object
+		for _, name := range []string{"int", "choice", "shuffle"} {
+			attr, ok := fn(name)
+			require.True(t, ok)
+			var args []object.Object
+			switch name {
+			case "int":
+				args = []object.Object{object.NewInt(1 << 40)}
+			default:
+				args = []object.Object{list(1, 2, 3, 4, 5, 6)}
+			}
+			results = append(results, attr.(*object.Builtin).Call(ctx, args...))
+		}
+		// Generators created without a seed derive theirs from the source
+		gen, _ := fn("generator")
+		results = append(results, object.NewInt(gen.(*object.Builtin).Call(ctx).(*Generator).rng.Int63()))
+		return results
+	}
+	require.Equal(t, draw(), draw())
+}
+
+func TestErrors(t *testing.T) {
+	g := NewGenerator(1)
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"empty range",
+			call(t, g, "int", object.NewInt(5), object.NewInt(5)),
+			"value error: random.generator.int requires min to be less than max (got 5 and 5)",
+		},
+		{
+			"float with only min",
+			call(t, g, "float", object.NewInt(5)),
+			"value error: random.generator.float requires both min and max, or neither",
+		},
+		{
+			"empty choice",
+			call(t, g, "choice", list()),
+			"value error: random.generator.choice requires a non-empty list",
+		},
+		{
+			"choice from int",
+			call(t, g, "choice", object.NewInt(3)),
+			"type error: random.generator.choice expected a list or string (int given)",
+		},
+		{
+			"oversized sample",
+			call(t, g, "sample", list(1, 2), object.NewInt(3)),
+			"value error: random.generator.sample sample size must be between 0 and 2 (got 3)",
+		},
+		{
+			"mismatched weights",
+			call(t, g, "weighted_choice", list(1, 2), list(1)),
+			"value error: random.generator.weighted_choice requires as many weights as items (got 1 and 2)",
+		},
+		{
+			"negative weight",
+			call(t, g, "weighted_choice", list(1, 2), list(1, -1)),
+			"value error: random.generator.weighted_choice weights must be non-negative numbers (got -1)",
+		},
+		{
+			"zero weights",
+			call(t, g, "weighted_choice", list(1, 2), list(0, 0)),
+			"value error: random.generator.weighted_choice requires at least one positive weight",
+		},
+		{
+			"token size",
+			TokenHex(context.Background(), object.NewInt(0)),
+			"value error: random.token_hex size must be between 1 and 1048576 (got 0)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
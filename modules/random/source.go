@@ -0,0 +1,62 @@
+package random
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+)
+
+// lockedSource makes a seeded source safe to share between threads.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+// NewSource returns a pseudo-random source seeded with the given value, which
+// is safe for concurrent use. Sources with the same seed produce the same
+// sequence of values.
+func NewSource(seed int64) rand.Source64 {
+	return &lockedSource{src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
+
+// cryptoSource draws from the operating system's secure random number
+// generator. It can't be seeded.
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (cryptoSource) Seed(int64) {}
+
+// randomSeed returns a seed for sources that weren't given one.
+func randomSeed() int64 {
+	return cryptoSource{}.Int63()
+}
@@ -0,0 +1,186 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const LIMITER object.Type = "ratelimit.limiter"
+
+// Limiter is a token bucket that is safe to share between threads. Tokens are
+// added at a fixed rate, up to the burst size, and each event takes tokens
+// from the bucket.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  int64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter that allows rate events per second, and up to
+// burst events at once. The bucket starts full.
+func NewLimiter(rate float64, burst int64) *Limiter {
+	return &Limiter{rate: rate, burst: burst, tokens: float64(burst), last: time.Now()}
+}
+
+// advance adds the tokens accrued since the last update. The caller must
+// hold the lock.
+func (l *Limiter) advance(now time.Time) {
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens = math.Min(float64(l.burst), l.tokens+elapsed*l.rate)
+	}
+	l.last = now
+}
+
+// Allow takes n tokens and returns true if they are available now.
+// Otherwise it takes nothing and returns false.
+func (l *Limiter) Allow(n int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(time.Now())
+	if l.tokens < float64(n) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
+
+// Wait blocks until n tokens are available and takes them, or until the
+// context is done. Callers waiting at the same time are served in order.
+func (l *Limiter) Wait(ctx context.Context, n int64) error {
+	if n > l.burst {
+		return fmt.Errorf("value error: %d tokens exceeds the limiter's burst of %d", n, l.burst)
+	}
+	l.mu.Lock()
+	l.advance(time.Now())
+	// Take the tokens now, even if the bucket goes negative, so that later
+	// callers wait behind this one
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.advance(time.Now())
+		l.tokens = math.Min(float64(l.burst), l.tokens+float64(n))
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Tokens returns the number of tokens available now.
+func (l *Limiter) Tokens() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.advance(time.Now())
+	return l.tokens
+}
+
+func (l *Limiter) Type() object.Type {
+	return LIMITER
+}
+
+func (l *Limiter) Inspect() string {
+	return fmt.Sprintf("ratelimit.limiter(rate=%g, burst=%d)", l.rate, l.burst)
+}
+
+func (l *Limiter) Interface() interface{} {
+	return nil
+}
+
+func (l *Limiter) Equals(other object.Object) object.Object {
+	return object.NewBool(l == other)
+}
+
+func (l *Limiter) IsTruthy() bool {
+	return true
+}
+
+func (l *Limiter) Cost() int {
+	return 0
+}
+
+func (l *Limiter) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", LIMITER)
+}
+
+func (l *Limiter) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", LIMITER, opType)
+}
+
+func (l *Limiter) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", LIMITER, name)
+}
+
+func (l *Limiter) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "rate":
+		return object.NewFloat(l.rate), true
+	case "burst":
+		return object.NewInt(l.burst), true
+	case "wait":
+		return object.NewBuiltin("ratelimit.limiter.wait", func(ctx context.Context, args ...object.Object) object.Object {
+			n, err := countArg("ratelimit.limiter.wait", args)
+			if err != nil {
+				return err
+			}
+			if err := l.Wait(ctx, n); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	case "allow":
+		return object.NewBuiltin("ratelimit.limiter.allow", func(ctx context.Context, args ...object.Object) object.Object {
+			n, err := countArg("ratelimit.limiter.allow", args)
+			if err != nil {
+				return err
+			}
+			return object.NewBool(l.Allow(n))
+		}), true
+	case "tokens":
+		return object.NewBuiltin("ratelimit.limiter.tokens", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("ratelimit.limiter.tokens", 0, args); err != nil {
+				return err
+			}
+			return object.NewFloat(l.Tokens())
+		}), true
+	}
+	return nil, false
+}
+
+// countArg returns the optional count of tokens or permits given to a
+// method, which defaults to 1.
+func countArg(name string, args []object.Object) (int64, *object.Error) {
+	if err := arg.RequireRange(name, 0, 1, args); err != nil {
+		return 0, err
+	}
+	if len(args) == 0 {
+		return 1, nil
+	}
+	n, err := object.AsInt(args[0])
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, object.Errorf("value error: %s() count must be at least 1 (got %d)", name, n)
+	}
+	return n, nil
+}
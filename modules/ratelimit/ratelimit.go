@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+var rateUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// parseRate converts a number of events per second, or a string such as
+// "100/m" or "5/250ms", to a number of events per second.
+func parseRate(obj object.Object) (float64, *object.Error) {
+	var rate float64
+	if s, ok := obj.(*object.String); ok {
+		count, per, found := strings.Cut(s.Value(), "/")
+		n, err := strconv.ParseFloat(strings.TrimSpace(count), 64)
+		if !found || err != nil {
+			return 0, object.Errorf("value error: invalid rate %q (expected a number or a string such as \"10/s\")", s.Value())
+		}
+		per = strings.TrimSpace(per)
+		d, ok := rateUnits[per]
+		if !ok {
+			if d, err = time.ParseDuration(per); err != nil || d <= 0 {
+				return 0, object.Errorf("value error: invalid rate %q (expected a number or a string such as \"10/s\")", s.Value())
+			}
+		}
+		rate = n / d.Seconds()
+	} else {
+		var err *object.Error
+		if rate, err = object.AsFloat(obj); err != nil {
+			return 0, err
+		}
+	}
+	if rate <= 0 {
+		return 0, object.Errorf("value error: rate must be greater than 0 (got %g)", rate)
+	}
+	return rate, nil
+}
+
+// NewLimiterBuiltin creates a token bucket rate limiter.
+func NewLimiterBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("ratelimit.limiter", 1, 2, args); err != nil {
+		return err
+	}
+	rate, err := parseRate(args[0])
+	if err != nil {
+		return err
+	}
+	burst := int64(1)
+	if len(args) > 1 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "burst":
+				if burst, err = object.AsInt(value); err == nil && burst < 1 {
+					err = object.Errorf("value error: burst must be at least 1 (got %d)", burst)
+				}
+			default:
+				err = object.Errorf("value error: unknown ratelimit.limiter option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return NewLimiter(rate, burst)
+}
+
+// NewSemaphoreBuiltin creates a weighted semaphore.
+func NewSemaphoreBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("ratelimit.semaphore", 1, args); err != nil {
+		return err
+	}
+	size, err := object.AsInt(args[0])
+	if err != nil {
+		return err
+	}
+	if size < 1 {
+		return object.Errorf("value error: semaphore size must be at least 1 (got %d)", size)
+	}
+	return NewSemaphore(size)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("ratelimit", map[string]object.Object{
+		"limiter":   object.NewBuiltin("limiter", NewLimiterBuiltin),
+		"semaphore": object.NewBuiltin("semaphore", NewSemaphoreBuiltin),
+	})
+}
@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		input    object.Object
+		expected float64
+	}{
+		{object.NewInt(5), 5},
+		{object.NewFloat(0.5), 0.5},
+		{object.NewString("10/s"), 10},
+		{object.NewString("120/m"), 2},
+		{object.NewString("5 / 250ms"), 20},
+	}
+	for _, tt := range tests {
+		rate, err := parseRate(tt.input)
+		require.Nil(t, err)
+		require.InDelta(t, tt.expected, rate, 1e-9)
+	}
+	for _, input := range []string{"10", "10/week", "x/s", "0/s"} {
+		_, err := parseRate(object.NewString(input))
+		require.NotNil(t, err, input)
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	l := NewLimiter(10, 3)
+	require.True(t, l.Allow(2))
+	require.True(t, l.Allow(1))
+	require.False(t, l.Allow(1))
+	time.Sleep(120 * time.Millisecond)
+	require.True(t, l.Allow(1))
+	require.InDelta(t, 0, l.Tokens(), 0.5)
+}
+
+func TestLimiterWait(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(100, 1)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.Nil(t, l.Wait(ctx, 1))
+		}()
+	}
+	wg.Wait()
+	// The first event is allowed at once, and the rest are spaced 10ms apart
+	require.GreaterOrEqual(t, time.Since(start), 35*time.Millisecond)
+
+	require.EqualError(t, l.Wait(ctx, 2), "value error: 2 tokens exceeds the limiter's burst of 1")
+}
+
+func TestLimiterWaitCancelled(t *testing.T) {
+	l := NewLimiter(1, 1)
+	require.True(t, l.Allow(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, l.Wait(ctx, 1), context.DeadlineExceeded)
+	// The cancelled wait gives its token back
+	require.Greater(t, l.Tokens(), -0.5)
+}
+
+func TestSemaphore(t *testing.T) {
+	ctx := context.Background()
+	s := NewSemaphore(3)
+	require.Nil(t, s.Acquire(ctx, 2))
+	require.False(t, s.TryAcquire(2))
+	require.True(t, s.TryAcquire(1))
+	require.Equal(t, int64(0), s.Available())
+
+	acquired := make(chan struct{})
+	go func() {
+		require.Nil(t, s.Acquire(ctx, 3))
+		close(acquired)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	// A waiter is queued, so smaller requests wait their turn
+	require.Nil(t, s.Release(1))
+	require.False(t, s.TryAcquire(1))
+	require.Nil(t, s.Release(2))
+	<-acquired
+	require.Nil(t, s.Release(3))
+	require.Equal(t, int64(3), s.Available())
+
+	require.EqualError(t, s.Release(1), "value error: released more permits than were acquired")
+	require.EqualError(t, s.Acquire(ctx, 4), "value error: 4 permits exceeds the semaphore's size of 3")
+}
+
+func TestSemaphoreCancelled(t *testing.T) {
+	s := NewSemaphore(2)
+	require.True(t, s.TryAcquire(1))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, s.Acquire(ctx, 2), context.DeadlineExceeded)
+	// The cancelled waiter no longer blocks the queue
+	require.True(t, s.TryAcquire(1))
+}
+
+func TestSemaphoreRun(t *testing.T) {
+	ctx := context.Background()
+	s := NewSemaphore(2)
+	run, ok := s.GetAttr("run")
+	require.True(t, ok)
+	fn := object.NewBuiltin("fn", func(ctx context.Context, args ...object.Object) object.Object {
+		require.Equal(t, int64(0), s.Available())
+		return object.NewInt(42)
+	})
+	result := run.(*object.Builtin).Call(ctx, fn, object.NewInt(2))
+	require.Equal(t, object.NewInt(42), result)
+	require.Equal(t, int64(2), s.Available())
+}
+
+func TestModuleBuiltins(t *testing.T) {
+	ctx := context.Background()
+	l, ok := NewLimiterBuiltin(ctx, object.NewString("60/m"), object.NewMap(map[string]object.Object{
+		"burst": object.NewInt(5),
+	})).(*Limiter)
+	require.True(t, ok)
+	require.Equal(t, "ratelimit.limiter(rate=1, burst=5)", l.Inspect())
+	result := NewLimiterBuiltin(ctx, object.NewInt(1), object.NewMap(map[string]object.Object{
+		"size": object.NewInt(5),
+	}))
+	require.Equal(t, object.Errorf(`value error: unknown ratelimit.limiter option "size"`), result)
+	result = NewSemaphoreBuiltin(ctx, object.NewInt(0))
+	require.Equal(t, object.Errorf("value error: semaphore size must be at least 1 (got 0)"), result)
+}
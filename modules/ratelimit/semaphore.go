@@ -0,0 +1,220 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const SEMAPHORE object.Type = "ratelimit.semaphore"
+
+type waiter struct {
+	n     int64
+	ready chan struct{}
+}
+
+// Semaphore is a weighted semaphore that is safe to share between threads.
+// Callers waiting to acquire permits are served in order, so a large request
+// isn't starved by a stream of small ones.
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int64
+	held    int64
+	waiters list.List
+}
+
+// NewSemaphore returns a Semaphore with the given number of permits.
+func NewSemaphore(size int64) *Semaphore {
+	return &Semaphore{size: size}
+}
+
+// Acquire blocks until n permits are available and takes them, or until the
+// context is done.
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	if n > s.size {
+		return fmt.Errorf("value error: %d permits exceeds the semaphore's size of %d", n, s.size)
+	}
+	s.mu.Lock()
+	if s.size-s.held >= n && s.waiters.Len() == 0 {
+		s.held += n
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(waiter{n: n, ready: ready})
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// The permits were granted as the context was cancelled, so give
+			// them back
+			s.held -= n
+		default:
+			front := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			if !front {
+				s.mu.Unlock()
+				return ctx.Err()
+			}
+		}
+		// Waiters queued behind this one may now be able to proceed
+		s.notify()
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire takes n permits and returns true if they are available now.
+// Otherwise it takes nothing and returns false.
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size-s.held >= n && s.waiters.Len() == 0 {
+		s.held += n
+		return true
+	}
+	return false
+}
+
+// Release returns n permits to the semaphore.
+func (s *Semaphore) Release(n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n > s.held {
+		return errors.New("value error: released more permits than were acquired")
+	}
+	s.held -= n
+	s.notify()
+	return nil
+}
+
+// Available returns the number of permits that aren't held.
+func (s *Semaphore) Available() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size - s.held
+}
+
+// notify grants permits to waiters in order, for as long as there are
+// enough. The caller must hold the lock.
+func (s *Semaphore) notify() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+		w := front.Value.(waiter)
+		if s.size-s.held < w.n {
+			return
+		}
+		s.held += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
+
+func (s *Semaphore) Type() object.Type {
+	return SEMAPHORE
+}
+
+func (s *Semaphore) Inspect() string {
+	return fmt.Sprintf("ratelimit.semaphore(size=%d, available=%d)", s.size, s.Available())
+}
+
+func (s *Semaphore) Interface() interface{} {
+	return nil
+}
+
+func (s *Semaphore) Equals(other object.Object) object.Object {
+	return object.NewBool(s == other)
+}
+
+func (s *Semaphore) IsTruthy() bool {
+	return true
+}
+
+func (s *Semaphore) Cost() int {
+	return 0
+}
+
+func (s *Semaphore) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", SEMAPHORE)
+}
+
+func (s *Semaphore) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", SEMAPHORE, opType)
+}
+
+func (s *Semaphore) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", SEMAPHORE, name)
+}
+
+func (s *Semaphore) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "size":
+		return object.NewInt(s.size), true
+	case "available":
+		return object.NewInt(s.Available()), true
+	case "acquire":
+		return object.NewBuiltin("ratelimit.semaphore.acquire", func(ctx context.Context, args ...object.Object) object.Object {
+			n, err := countArg("ratelimit.semaphore.acquire", args)
+			if err != nil {
+				return err
+			}
+			if err := s.Acquire(ctx, n); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	case "try_acquire":
+		return object.NewBuiltin("ratelimit.semaphore.try_acquire", func(ctx context.Context, args ...object.Object) object.Object {
+			n, err := countArg("ratelimit.semaphore.try_acquire", args)
+			if err != nil {
+				return err
+			}
+			return object.NewBool(s.TryAcquire(n))
+		}), true
+	case "release":
+		return object.NewBuiltin("ratelimit.semaphore.release", func(ctx context.Context, args ...object.Object) object.Object {
+			n, err := countArg("ratelimit.semaphore.release", args)
+			if err != nil {
+				return err
+			}
+			if err := s.Release(n); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	case "run":
+		return object.NewBuiltin("ratelimit.semaphore.run", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("ratelimit.semaphore.run", 1, 2, args); err != nil {
+				return err
+			}
+			n, err := countArg("ratelimit.semaphore.run", args[1:])
+			if err != nil {
+				return err
+			}
+			if err := s.Acquire(ctx, n); err != nil {
+				return object.NewError(err)
+			}
+			defer s.Release(n)
+			result, callErr := object.Call(ctx, args[0], nil)
+			if callErr != nil {
+				return object.NewError(callErr)
+			}
+			return result
+		}), true
+	}
+	return nil, false
+}
@@ -0,0 +1,120 @@
+package result
+
+import (
+	"context"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+func Ok(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("result.ok", 0, 1, args); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return object.NewOkResult(object.Nil)
+	}
+	return object.NewOkResult(args[0])
+}
+
+func Err(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("result.err", 1, args); err != nil {
+		return err
+	}
+	switch value := args[0].(type) {
+	case *object.Error:
+		return object.NewErrResult(value)
+	case *object.String:
+		return object.NewErrResult(object.Errorf(value.Value()))
+	default:
+		return object.NewErrResult(object.Errorf(value.Inspect()))
+	}
+}
+
+// Of calls the given function and captures the outcome as a result. Any
+// error raised during the call, including errors raised by unwrap() on
+// nested results, produces an err result instead of aborting execution.
+func Of(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("result.of", 1, 64, args); err != nil {
+		return err
+	}
+	value, err := object.Call(ctx, args[0], args[1:])
+	if err != nil {
+		return object.NewErrResult(object.NewError(err))
+	}
+	if r, ok := value.(*object.Result); ok {
+		return r
+	}
+	return object.NewOkResult(value)
+}
+
+// Collect converts an iterable of results into a single result. If all items
+// are ok, the returned result holds a list of their values. Otherwise, the
+// first err result is returned.
+func Collect(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("result.collect", 1, args); err != nil {
+		return err
+	}
+	iter, err := object.AsIterator(args[0])
+	if err != nil {
+		return err
+	}
+	var values []object.Object
+	for {
+		item, ok := iter.Next(ctx)
+		if !ok {
+			break
+		}
+		r, ok := item.(*object.Result)
+		if !ok {
+			values = append(values, item)
+			continue
+		}
+		if r.IsErr() {
+			return r
+		}
+		values = append(values, r.Value())
+	}
+	return object.NewOkResult(object.NewList(values))
+}
+
+// Partition splits an iterable of results into a list of ok values and a
+// list of error messages, returned as a two item list.
+func Partition(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("result.partition", 1, args); err != nil {
+		return err
+	}
+	iter, err := object.AsIterator(args[0])
+	if err != nil {
+		return err
+	}
+	oks := []object.Object{}
+	errs := []object.Object{}
+	for {
+		item, ok := iter.Next(ctx)
+		if !ok {
+			break
+		}
+		r, ok := item.(*object.Result)
+		if !ok {
+			oks = append(oks, item)
+			continue
+		}
+		if r.IsErr() {
+			errs = append(errs, r.Err())
+		} else {
+			oks = append(oks, r.Value())
+		}
+	}
+	return object.NewList([]object.Object{object.NewList(oks), object.NewList(errs)})
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("result", map[string]object.Object{
+		"collect":   object.NewBuiltin("collect", Collect),
+		"err":       object.NewBuiltin("err", Err),
+		"of":        object.NewBuiltin("of", Of),
+		"ok":        object.NewBuiltin("ok", Ok),
+		"partition": object.NewBuiltin("partition", Partition),
+	})
+}
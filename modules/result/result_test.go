@@ -0,0 +1,68 @@
+package result
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOf(t *testing.T) {
+	ctx := context.Background()
+	parse := object.NewBuiltin("parse", func(ctx context.Context, args ...object.Object) object.Object {
+		s, err := object.AsString(args[0])
+		if err != nil {
+			return err
+		}
+		if s == "bad" {
+			return object.Errorf("value error: bad input")
+		}
+		return object.NewString(s + "!")
+	})
+
+	ok := Of(ctx, parse, object.NewString("good"))
+	require.Equal(t, object.NewOkResult(object.NewString("good!")), ok)
+
+	bad, isResult := Of(ctx, parse, object.NewString("bad")).(*object.Result)
+	require.True(t, isResult)
+	require.True(t, bad.IsErr())
+	require.Equal(t, "value error: bad input", bad.Err().Value().Error())
+}
+
+func TestCollect(t *testing.T) {
+	ctx := context.Background()
+	items := object.NewList([]object.Object{
+		object.NewOkResult(object.NewInt(1)),
+		object.NewOkResult(object.NewInt(2)),
+	})
+	require.Equal(t,
+		object.NewOkResult(object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)})),
+		Collect(ctx, items))
+
+	failure := object.NewErrResult(object.Errorf("boom"))
+	items.Append(failure)
+	require.Same(t, failure, Collect(ctx, items))
+}
+
+func TestPartition(t *testing.T) {
+	ctx := context.Background()
+	failure := object.Errorf("boom")
+	items := object.NewList([]object.Object{
+		object.NewOkResult(object.NewInt(1)),
+		object.NewErrResult(failure),
+		object.NewOkResult(object.NewInt(3)),
+	})
+	result := Partition(ctx, items)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewList([]object.Object{object.NewInt(1), object.NewInt(3)}),
+		object.NewList([]object.Object{failure}),
+	}), result)
+}
+
+func TestErr(t *testing.T) {
+	ctx := context.Background()
+	r, ok := Err(ctx, object.NewString("bad record")).(*object.Result)
+	require.True(t, ok)
+	require.Equal(t, `err("bad record")`, r.Inspect())
+}
@@ -0,0 +1,230 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const BACKOFF object.Type = "retry.backoff"
+
+// Backoff computes the delay before each retry.
+type Backoff struct {
+	kind       string
+	initial    time.Duration
+	step       time.Duration
+	multiplier float64
+	max        time.Duration
+	jitter     float64
+}
+
+// NewConstant returns a Backoff that waits the same time before each retry.
+func NewConstant(delay time.Duration) *Backoff {
+	return &Backoff{kind: "constant", initial: delay}
+}
+
+// NewLinear returns a Backoff whose delay grows by step after each retry.
+func NewLinear(initial, step time.Duration) *Backoff {
+	return &Backoff{kind: "linear", initial: initial, step: step}
+}
+
+// NewExponential returns a Backoff whose delay is multiplied after each
+// retry.
+func NewExponential(initial time.Duration, multiplier float64) *Backoff {
+	return &Backoff{kind: "exponential", initial: initial, multiplier: multiplier}
+}
+
+// Delay returns the time to wait after the given failed attempt, starting
+// from 1. The delay is capped at the maximum, if there is one, and then
+// randomized by the jitter fraction in either direction.
+func (b *Backoff) Delay(attempt int) time.Duration {
+	n := float64(attempt - 1)
+	var delay float64
+	switch b.kind {
+	case "constant":
+		delay = float64(b.initial)
+	case "linear":
+		delay = float64(b.initial) + n*float64(b.step)
+	case "exponential":
+		delay = float64(b.initial) * math.Pow(b.multiplier, n)
+	}
+	if b.max > 0 && delay > float64(b.max) {
+		delay = float64(b.max)
+	}
+	if b.jitter > 0 {
+		delay *= 1 + b.jitter*(2*rand.Float64()-1)
+	}
+	if delay > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
+
+func (b *Backoff) Type() object.Type {
+	return BACKOFF
+}
+
+func (b *Backoff) Inspect() string {
+	var parts []string
+	switch b.kind {
+	case "constant":
+		parts = append(parts, fmt.Sprintf("delay=%s", b.initial))
+	case "linear":
+		parts = append(parts, fmt.Sprintf("initial=%s", b.initial), fmt.Sprintf("step=%s", b.step))
+	case "exponential":
+		parts = append(parts, fmt.Sprintf("initial=%s", b.initial), fmt.Sprintf("multiplier=%g", b.multiplier))
+	}
+	if b.max > 0 {
+		parts = append(parts, fmt.Sprintf("max=%s", b.max))
+	}
+	if b.jitter > 0 {
+		parts = append(parts, fmt.Sprintf("jitter=%g", b.jitter))
+	}
+	return fmt.Sprintf("retry.%s(%s)", b.kind, strings.Join(parts, ", "))
+}
+
+func (b *Backoff) Interface() interface{} {
+	return nil
+}
+
+func (b *Backoff) Equals(other object.Object) object.Object {
+	o, ok := other.(*Backoff)
+	return object.NewBool(ok && *o == *b)
+}
+
+func (b *Backoff) IsTruthy() bool {
+	return true
+}
+
+func (b *Backoff) Cost() int {
+	return 0
+}
+
+func (b *Backoff) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", BACKOFF)
+}
+
+func (b *Backoff) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", BACKOFF, opType)
+}
+
+func (b *Backoff) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", BACKOFF, name)
+}
+
+func (b *Backoff) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "kind":
+		return object.NewString(b.kind), true
+	case "delay":
+		return object.NewBuiltin("retry.backoff.delay", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("retry.backoff.delay", 1, args); err != nil {
+				return err
+			}
+			attempt, err := object.AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			if attempt < 1 {
+				return object.Errorf("value error: attempt must be at least 1 (got %d)", attempt)
+			}
+			return object.NewFloat(b.Delay(int(attempt)).Seconds())
+		}), true
+	}
+	return nil, false
+}
+
+// parseBackoffOptions applies the options shared by all backoffs, and those
+// given in allowed, to the backoff.
+func parseBackoffOptions(fn string, b *Backoff, args []object.Object, allowed ...string) *object.Error {
+	if len(args) == 0 {
+		return nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return err
+	}
+	for key, value := range m.Value() {
+		switch {
+		case key == "max":
+			b.max, err = arg.Duration("max", value)
+		case key == "jitter":
+			if b.jitter, err = object.AsFloat(value); err == nil && (b.jitter < 0 || b.jitter > 1) {
+				err = object.Errorf("value error: jitter must be between 0 and 1 (got %g)", b.jitter)
+			}
+		case key == "step" && contains(allowed, key):
+			b.step, err = arg.Duration("step", value)
+		case key == "multiplier" && contains(allowed, key):
+			if b.multiplier, err = object.AsFloat(value); err == nil && b.multiplier < 1 {
+				err = object.Errorf("value error: multiplier must be at least 1 (got %g)", b.multiplier)
+			}
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func Constant(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("retry.constant", 1, 2, args); err != nil {
+		return err
+	}
+	delay, err := arg.Duration("delay", args[0])
+	if err != nil {
+		return err
+	}
+	b := NewConstant(delay)
+	if err := parseBackoffOptions("retry.constant", b, args[1:]); err != nil {
+		return err
+	}
+	return b
+}
+
+func Linear(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("retry.linear", 1, 2, args); err != nil {
+		return err
+	}
+	initial, err := arg.Duration("delay", args[0])
+	if err != nil {
+		return err
+	}
+	b := NewLinear(initial, initial)
+	if err := parseBackoffOptions("retry.linear", b, args[1:], "step"); err != nil {
+		return err
+	}
+	return b
+}
+
+func Exponential(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("retry.exponential", 1, 2, args); err != nil {
+		return err
+	}
+	initial, err := arg.Duration("delay", args[0])
+	if err != nil {
+		return err
+	}
+	b := NewExponential(initial, 2)
+	if err := parseBackoffOptions("retry.exponential", b, args[1:], "multiplier"); err != nil {
+		return err
+	}
+	return b
+}
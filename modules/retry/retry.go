@@ -0,0 +1,146 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// options holds the options accepted by Do.
+type options struct {
+	attempts   int64
+	backoff    *Backoff
+	retryIf    object.Object
+	onRetry    object.Object
+	maxElapsed time.Duration
+}
+
+func isCallable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Partial, object.Callable:
+		return true
+	}
+	return false
+}
+
+func parseOptions(args []object.Object) (*options, *object.Error) {
+	opts := &options{
+		attempts: 5,
+		backoff:  NewExponential(100*time.Millisecond, 2),
+	}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "attempts":
+			if opts.attempts, err = object.AsInt(value); err == nil && opts.attempts < 1 {
+				err = object.Errorf("value error: attempts must be at least 1 (got %d)", opts.attempts)
+			}
+		case "backoff":
+			if b, ok := value.(*Backoff); ok {
+				opts.backoff = b
+				break
+			}
+			var delay time.Duration
+			if delay, err = arg.Duration("backoff", value); err == nil {
+				opts.backoff = NewConstant(delay)
+			}
+		case "retry_if", "on_retry":
+			if !isCallable(value) {
+				err = object.Errorf("type error: %s must be a function (%s given)", key, value.Type())
+			} else if key == "retry_if" {
+				opts.retryIf = value
+			} else {
+				opts.onRetry = value
+			}
+		case "max_elapsed":
+			opts.maxElapsed, err = arg.Duration("max_elapsed", value)
+		default:
+			err = object.Errorf("value error: unknown retry.do option %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// Do calls a function until it succeeds, waiting between attempts as the
+// backoff describes, and returns its result. Gives up when the attempts run
+// out, when the retry_if function returns false for an error, or when the
+// context is cancelled.
+func Do(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("retry.do", 1, 2, args); err != nil {
+		return err
+	}
+	fn := args[0]
+	if !isCallable(fn) {
+		return object.Errorf("type error: retry.do() expected a function (%s given)", fn.Type())
+	}
+	opts, errObj := parseOptions(args[1:])
+	if errObj != nil {
+		return errObj
+	}
+	clock := object.GetDefaultClock(ctx)
+	start := clock.Now()
+	for attempt := int64(1); ; attempt++ {
+		result, err := object.Call(ctx, fn, nil)
+		if err == nil {
+			return result
+		}
+		if ctx.Err() != nil {
+			return object.NewError(err)
+		}
+		if opts.retryIf != nil {
+			retry, callErr := object.Call(ctx, opts.retryIf, []object.Object{object.NewError(err)})
+			if callErr != nil {
+				return object.NewError(callErr)
+			}
+			if !retry.IsTruthy() {
+				return object.NewError(err)
+			}
+		}
+		if attempt >= opts.attempts {
+			return object.NewError(fmt.Errorf("retry error: giving up after %d attempts: %w", attempt, err))
+		}
+		delay := opts.backoff.Delay(int(attempt))
+		if elapsed := clock.Now().Sub(start); opts.maxElapsed > 0 && elapsed+delay > opts.maxElapsed {
+			return object.NewError(fmt.Errorf("retry error: giving up after %d attempts in %s: %w",
+				attempt, elapsed.Round(time.Millisecond), err))
+		}
+		if opts.onRetry != nil {
+			info := object.NewMap(map[string]object.Object{
+				"error":   object.NewError(err),
+				"attempt": object.NewInt(attempt),
+				"delay":   object.NewFloat(delay.Seconds()),
+			})
+			if _, err := object.Call(ctx, opts.onRetry, []object.Object{info}); err != nil {
+				return object.NewError(err)
+			}
+		}
+		timer := clock.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return object.NewError(fmt.Errorf("retry error: %w after %d attempts: %w", ctx.Err(), attempt, err))
+		case <-timer.C():
+		}
+	}
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("retry", map[string]object.Object{
+		"constant":    object.NewBuiltin("constant", Constant),
+		"do":          object.NewBuiltin("do", Do),
+		"exponential": object.NewBuiltin("exponential", Exponential),
+		"linear":      object.NewBuiltin("linear", Linear),
+	})
+}
@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+// flaky returns a builtin that fails until it has been called n times.
+func flaky(n int, calls *int) *object.Builtin {
+	return object.NewBuiltin("flaky", func(ctx context.Context, args ...object.Object) object.Object {
+		*calls++
+		if *calls < n {
+			return object.Errorf("attempt %d failed", *calls)
+		}
+		return object.NewString("ok")
+	})
+}
+
+func opts(m map[string]object.Object) *object.Map {
+	return object.NewMap(m)
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := NewExponential(100*time.Millisecond, 2)
+	require.Equal(t, 100*time.Millisecond, b.Delay(1))
+	require.Equal(t, 800*time.Millisecond, b.Delay(4))
+	b.max = 500 * time.Millisecond
+	require.Equal(t, 500*time.Millisecond, b.Delay(4))
+
+	linear := NewLinear(time.Second, 500*time.Millisecond)
+	require.Equal(t, 2*time.Second, linear.Delay(3))
+	require.Equal(t, time.Second, NewConstant(time.Second).Delay(10))
+
+	b.jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := b.Delay(2)
+		require.GreaterOrEqual(t, d, 100*time.Millisecond)
+		require.LessOrEqual(t, d, 300*time.Millisecond)
+	}
+}
+
+func TestBackoffBuiltins(t *testing.T) {
+	ctx := context.Background()
+	b, ok := Exponential(ctx, object.NewString("100ms"), opts(map[string]object.Object{
+		"multiplier": object.NewFloat(1.5),
+		"max":        object.NewInt(2),
+	})).(*Backoff)
+	require.True(t, ok)
+	require.Equal(t, "retry.exponential(initial=100ms, multiplier=1.5, max=2s)", b.Inspect())
+	require.Equal(t, 150*time.Millisecond, b.Delay(2))
+
+	l, ok := Linear(ctx, object.NewFloat(0.25)).(*Backoff)
+	require.True(t, ok)
+	require.Equal(t, "retry.linear(initial=250ms, step=250ms)", l.Inspect())
+
+	result := Constant(ctx, object.NewString("1s"), opts(map[string]object.Object{
+		"multiplier": object.NewInt(2),
+	}))
+	require.Equal(t, object.Errorf(`value error: unknown retry.constant option "multiplier"`), result)
+	result = Linear(ctx, object.NewString("-1s"))
+	require.Equal(t, object.Errorf("value error: delay must not be negative (got -1s)"), result)
+}
+
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	var calls int
+	var delays []float64
+	onRetry := object.NewBuiltin("on_retry", func(ctx context.Context, args ...object.Object) object.Object {
+		info := args[0].(*object.Map)
+		delays = append(delays, info.Get("delay").(*object.Float).Value())
+		return object.Nil
+	})
+	result := Do(ctx, flaky(3, &calls), opts(map[string]object.Object{
+		"backoff":  NewLinear(time.Millisecond, time.Millisecond),
+		"on_retry": onRetry,
+	}))
+	require.Equal(t, object.NewString("ok"), result)
+	require.Equal(t, 3, calls)
+	require.Equal(t, []float64{0.001, 0.002}, delays)
+}
+
+func TestDoGivesUp(t *testing.T) {
+	ctx := context.Background()
+	var calls int
+	result := Do(ctx, flaky(10, &calls), opts(map[string]object.Object{
+		"attempts": object.NewInt(3),
+		"backoff":  object.NewString("1ms"),
+	}))
+	require.Equal(t, 3, calls)
+	errObj, ok := result.(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "retry error: giving up after 3 attempts: attempt 3 failed", errObj.Message().Value())
+}
+
+func TestDoRetryIf(t *testing.T) {
+	ctx := context.Background()
+	var calls int
+	retryIf := object.NewBuiltin("retry_if", func(ctx context.Context, args ...object.Object) object.Object {
+		return object.NewBool(args[0].(*object.Error).Message().Value() != "attempt 2 failed")
+	})
+	result := Do(ctx, flaky(10, &calls), opts(map[string]object.Object{
+		"backoff":  object.NewInt(0),
+		"retry_if": retryIf,
+	}))
+	require.Equal(t, 2, calls)
+	require.Equal(t, object.Errorf("attempt 2 failed"), result)
+}
+
+func TestDoCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var calls int
+	start := time.Now()
+	result := Do(ctx, flaky(10, &calls), opts(map[string]object.Object{
+		"backoff": object.NewString("1h"),
+	}))
+	require.Less(t, time.Since(start), time.Second)
+	require.Equal(t, 1, calls)
+	errObj, ok := result.(*object.Error)
+	require.True(t, ok)
+	require.True(t, errors.Is(errObj.Value(), context.DeadlineExceeded))
+	require.Equal(t, "retry error: context deadline exceeded after 1 attempts: attempt 1 failed",
+		errObj.Message().Value())
+}
+
+func TestDoMaxElapsed(t *testing.T) {
+	ctx := context.Background()
+	var calls int
+	result := Do(ctx, flaky(10, &calls), opts(map[string]object.Object{
+		"attempts":    object.NewInt(100),
+		"backoff":     object.NewString("10ms"),
+		"max_elapsed": object.NewString("35ms"),
+	}))
+	// Timers may fire late, so only check that it stopped well before the
+	// attempts ran out
+	require.GreaterOrEqual(t, calls, 2)
+	require.LessOrEqual(t, calls, 4)
+	_, ok := result.(*object.Error)
+	require.True(t, ok)
+}
+
+func TestDoInvalidOptions(t *testing.T) {
+	ctx := context.Background()
+	var calls int
+	fn := flaky(1, &calls)
+	result := Do(ctx, fn, opts(map[string]object.Object{"attempts": object.NewInt(0)}))
+	require.Equal(t, object.Errorf("value error: attempts must be at least 1 (got 0)"), result)
+	result = Do(ctx, fn, opts(map[string]object.Object{"retry_if": object.NewInt(1)}))
+	require.Equal(t, object.Errorf("type error: retry_if must be a function (int given)"), result)
+	result = Do(ctx, object.NewInt(1))
+	require.Equal(t, object.Errorf("type error: retry.do() expected a function (int given)"), result)
+	require.Equal(t, 0, calls)
+}
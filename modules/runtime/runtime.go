@@ -0,0 +1,69 @@
+package runtime
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+func Sizeof(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("runtime.sizeof", 1, args); err != nil {
+		return err
+	}
+	return object.NewInt(object.Sizeof(args[0]))
+}
+
+func Stats(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("runtime.stats", 1, 64, args); err != nil {
+		return err
+	}
+	return statsToMap(object.CollectAllocStats(args...))
+}
+
+func MemStats(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("runtime.mem_stats", 0, args); err != nil {
+		return err
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return object.NewMap(map[string]object.Object{
+		"alloc":        object.NewInt(int64(m.Alloc)),
+		"total_alloc":  object.NewInt(int64(m.TotalAlloc)),
+		"sys":          object.NewInt(int64(m.Sys)),
+		"mallocs":      object.NewInt(int64(m.Mallocs)),
+		"frees":        object.NewInt(int64(m.Frees)),
+		"heap_alloc":   object.NewInt(int64(m.HeapAlloc)),
+		"heap_objects": object.NewInt(int64(m.HeapObjects)),
+		"num_gc":       object.NewInt(int64(m.NumGC)),
+	})
+}
+
+func GC(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("runtime.gc", 0, args); err != nil {
+		return err
+	}
+	runtime.GC()
+	return object.Nil
+}
+
+func statsToMap(stats object.AllocStats) *object.Map {
+	result := make(map[string]object.Object, len(stats))
+	for typ, s := range stats {
+		result[string(typ)] = object.NewMap(map[string]object.Object{
+			"count": object.NewInt(s.Count),
+			"bytes": object.NewInt(s.Bytes),
+		})
+	}
+	return object.NewMap(result)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("runtime", map[string]object.Object{
+		"gc":        object.NewBuiltin("gc", GC),
+		"mem_stats": object.NewBuiltin("mem_stats", MemStats),
+		"sizeof":    object.NewBuiltin("sizeof", Sizeof),
+		"stats":     object.NewBuiltin("stats", Stats),
+	})
+}
@@ -0,0 +1,35 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeof(t *testing.T) {
+	ctx := context.Background()
+	small := Sizeof(ctx, object.NewString("a"))
+	large := Sizeof(ctx, object.NewString("a much longer string value"))
+	require.IsType(t, &object.Int{}, small)
+	require.Greater(t, large.(*object.Int).Value(), small.(*object.Int).Value())
+}
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	shared := object.NewString("shared")
+	list := object.NewList([]object.Object{shared, shared, object.NewInt(1)})
+	result, ok := Stats(ctx, list).(*object.Map)
+	require.True(t, ok)
+	strStats := result.Get("string").(*object.Map)
+	require.Equal(t, object.NewInt(1), strStats.Get("count"))
+	listStats := result.Get("list").(*object.Map)
+	require.Equal(t, object.NewInt(1), listStats.Get("count"))
+}
+
+func TestMemStats(t *testing.T) {
+	result, ok := MemStats(context.Background()).(*object.Map)
+	require.True(t, ok)
+	require.Greater(t, result.Get("sys").(*object.Int).Value(), int64(0))
+}
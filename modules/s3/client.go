@@ -0,0 +1,649 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/limits"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+)
+
+const CLIENT object.Type = "s3.client"
+
+// deleteBatchSize is the most keys S3 accepts in one DeleteObjects request.
+const deleteBatchSize = 1000
+
+// Client wraps an S3 client along with the settings used for multipart
+// uploads.
+type Client struct {
+	client      *s3.Client
+	region      string
+	partSize    int64
+	concurrency int
+}
+
+// NewClient returns a Client for the given S3 client. A part size or
+// concurrency of 0 selects the upload manager's default.
+func NewClient(client *s3.Client, region string, partSize int64, concurrency int) *Client {
+	return &Client{
+		client:      client,
+		region:      region,
+		partSize:    partSize,
+		concurrency: concurrency,
+	}
+}
+
+// Value returns the underlying S3 client.
+func (c *Client) Value() *s3.Client {
+	return c.client
+}
+
+func (c *Client) Type() object.Type {
+	return CLIENT
+}
+
+func (c *Client) Inspect() string {
+	return fmt.Sprintf("s3.client(region=%s)", c.region)
+}
+
+func (c *Client) String() string {
+	return c.Inspect()
+}
+
+func (c *Client) Interface() interface{} {
+	return c.client
+}
+
+func (c *Client) Equals(other object.Object) object.Object {
+	return object.NewBool(c == other)
+}
+
+func (c *Client) IsTruthy() bool {
+	return true
+}
+
+func (c *Client) Cost() int {
+	return 0
+}
+
+func (c *Client) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", CLIENT)
+}
+
+func (c *Client) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", CLIENT, opType)
+}
+
+func (c *Client) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", CLIENT, name)
+}
+
+func (c *Client) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "region":
+		return object.NewString(c.region), true
+	case "buckets":
+		return object.NewBuiltin("s3.client.buckets", c.buckets), true
+	case "list":
+		return object.NewBuiltin("s3.client.list", c.list), true
+	case "get":
+		return object.NewBuiltin("s3.client.get", c.get), true
+	case "reader":
+		return object.NewBuiltin("s3.client.reader", c.reader), true
+	case "download":
+		return object.NewBuiltin("s3.client.download", c.download), true
+	case "put":
+		return object.NewBuiltin("s3.client.put", c.put), true
+	case "upload":
+		return object.NewBuiltin("s3.client.upload", c.upload), true
+	case "head":
+		return object.NewBuiltin("s3.client.head", c.head), true
+	case "exists":
+		return object.NewBuiltin("s3.client.exists", c.exists), true
+	case "delete":
+		return object.NewBuiltin("s3.client.delete", c.delete), true
+	case "copy":
+		return object.NewBuiltin("s3.client.copy", c.copy), true
+	case "presign":
+		return object.NewBuiltin("s3.client.presign", c.presign), true
+	}
+	return nil, false
+}
+
+func s3Error(err error) *object.Error {
+	return object.NewError(fmt.Errorf("s3 error: %w", err))
+}
+
+// isNotFound returns true if the error means the object doesn't exist.
+func isNotFound(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NotFound", "NoSuchKey":
+			return true
+		}
+	}
+	return false
+}
+
+// bucketAndKey returns the first two arguments as strings.
+func bucketAndKey(args []object.Object) (string, string, *object.Error) {
+	bucket, err := object.AsString(args[0])
+	if err != nil {
+		return "", "", err
+	}
+	key, err := object.AsString(args[1])
+	if err != nil {
+		return "", "", err
+	}
+	return bucket, key, nil
+}
+
+func (c *Client) buckets(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("s3.client.buckets", 0, args); err != nil {
+		return err
+	}
+	out, err := c.client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return s3Error(err)
+	}
+	items := make([]object.Object, 0, len(out.Buckets))
+	for _, b := range out.Buckets {
+		items = append(items, object.NewMap(map[string]object.Object{
+			"name":    object.NewString(aws.ToString(b.Name)),
+			"created": timeValue(b.CreationDate),
+		}))
+	}
+	return object.NewList(items)
+}
+
+// list returns a stream of the objects in a bucket. Pages are requested as
+// the stream is consumed, so large buckets may be listed without holding
+// every key in memory.
+func (c *Client) list(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("s3.client.list", 1, 2, args); err != nil {
+		return err
+	}
+	bucket, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	input := &s3.ListObjectsV2Input{Bucket: aws.String(bucket)}
+	var max int64
+	if len(args) > 1 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			var s string
+			switch key {
+			case "prefix":
+				s, err = object.AsString(value)
+				input.Prefix = aws.String(s)
+			case "delimiter":
+				s, err = object.AsString(value)
+				input.Delimiter = aws.String(s)
+			case "start_after":
+				s, err = object.AsString(value)
+				input.StartAfter = aws.String(s)
+			case "max":
+				if max, err = object.AsInt(value); err == nil && max < 0 {
+					err = object.Errorf("value error: max must not be negative (got %d)", max)
+				}
+			default:
+				err = object.Errorf("value error: unknown s3.client.list option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	paginator := s3.NewListObjectsV2Paginator(c.client, input)
+	var page []object.Object
+	var count int64
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		if max > 0 && count >= max {
+			return nil, false, nil
+		}
+		for len(page) == 0 {
+			if !paginator.HasMorePages() {
+				return nil, false, nil
+			}
+			out, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, false, fmt.Errorf("s3 error: %w", err)
+			}
+			for _, p := range out.CommonPrefixes {
+				page = append(page, object.NewMap(map[string]object.Object{
+					"prefix": object.NewString(aws.ToString(p.Prefix)),
+				}))
+			}
+			for _, o := range out.Contents {
+				page = append(page, object.NewMap(map[string]object.Object{
+					"key":           object.NewString(aws.ToString(o.Key)),
+					"size":          object.NewInt(o.Size),
+					"etag":          object.NewString(strings.Trim(aws.ToString(o.ETag), `"`)),
+					"last_modified": timeValue(o.LastModified),
+					"storage_class": object.NewString(string(o.StorageClass)),
+				}))
+			}
+		}
+		item := page[0]
+		page = page[1:]
+		count++
+		return item, true, nil
+	})
+}
+
+// getObject starts downloading an object. The caller must close the body.
+func (c *Client) getObject(ctx context.Context, fn string, args []object.Object) (*s3.GetObjectOutput, *object.Error) {
+	if err := arg.RequireRange(fn, 2, 3, args); err != nil {
+		return nil, err
+	}
+	bucket, key, errObj := bucketAndKey(args)
+	if errObj != nil {
+		return nil, errObj
+	}
+	opts, errObj := parseObjectOptions(fn, args[2:], "version_id", "range", "sse_customer_key")
+	if errObj != nil {
+		return nil, errObj
+	}
+	input := &s3.GetObjectInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		VersionId: optionalString(opts.versionID),
+		Range:     optionalString(opts.byteRange),
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = opts.customerKeyHeaders()
+	out, err := c.client.GetObject(ctx, input)
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return out, nil
+}
+
+func (c *Client) get(ctx context.Context, args ...object.Object) object.Object {
+	out, errObj := c.getObject(ctx, "s3.client.get", args)
+	if errObj != nil {
+		return errObj
+	}
+	defer out.Body.Close()
+	var data []byte
+	var err error
+	if lim, ok := limits.GetLimits(ctx); ok {
+		data, err = lim.ReadAll(out.Body)
+	} else {
+		data, err = io.ReadAll(out.Body)
+	}
+	if err != nil {
+		return s3Error(err)
+	}
+	return object.NewByteSlice(data)
+}
+
+func (c *Client) reader(ctx context.Context, args ...object.Object) object.Object {
+	out, errObj := c.getObject(ctx, "s3.client.reader", args)
+	if errObj != nil {
+		return errObj
+	}
+	return object.NewReader(out.Body)
+}
+
+func (c *Client) download(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("s3.client.download", 3, 4, args); err != nil {
+		return err
+	}
+	path, errObj := object.AsString(args[2])
+	if errObj != nil {
+		return errObj
+	}
+	out, errObj := c.getObject(ctx, "s3.client.download", append(args[:2:2], args[3:]...))
+	if errObj != nil {
+		return errObj
+	}
+	defer out.Body.Close()
+	f, err := ros.GetDefaultOS(ctx).Create(path)
+	if err != nil {
+		return object.NewError(err)
+	}
+	n, err := io.Copy(f, out.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return s3Error(err)
+	}
+	return object.NewInt(n)
+}
+
+// uploadOptions are the options accepted when writing an object.
+var uploadOptions = []string{
+	"content_type", "cache_control", "content_encoding", "storage_class",
+	"acl", "metadata", "sse", "kms_key_id", "sse_customer_key",
+}
+
+// uploadObject writes the body to an object, using a multipart upload when
+// the body is larger than the part size. The body is read as it is sent, so
+// readers of any size may be uploaded.
+func (c *Client) uploadObject(ctx context.Context, bucket, key string, body io.Reader, opts objectOptions) object.Object {
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(bucket),
+		Key:                  aws.String(key),
+		Body:                 body,
+		ContentType:          optionalString(opts.contentType),
+		CacheControl:         optionalString(opts.cacheControl),
+		ContentEncoding:      optionalString(opts.contentEncoding),
+		StorageClass:         types.StorageClass(opts.storageClass),
+		ACL:                  types.ObjectCannedACL(opts.acl),
+		Metadata:             opts.metadata,
+		ServerSideEncryption: types.ServerSideEncryption(opts.sse),
+		SSEKMSKeyId:          optionalString(opts.kmsKeyID),
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = opts.customerKeyHeaders()
+	uploader := manager.NewUploader(c.client, func(u *manager.Uploader) {
+		if c.partSize > 0 {
+			u.PartSize = c.partSize
+		}
+		if c.concurrency > 0 {
+			u.Concurrency = c.concurrency
+		}
+	})
+	out, err := uploader.Upload(ctx, input)
+	if err != nil {
+		return s3Error(err)
+	}
+	return object.NewMap(map[string]object.Object{
+		"etag":       object.NewString(strings.Trim(aws.ToString(out.ETag), `"`)),
+		"version_id": object.NewString(aws.ToString(out.VersionID)),
+		"location":   object.NewString(out.Location),
+		"upload_id":  object.NewString(out.UploadID),
+	})
+}
+
+func (c *Client) put(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("s3.client.put", 3, 4, args); err != nil {
+		return err
+	}
+	bucket, key, errObj := bucketAndKey(args)
+	if errObj != nil {
+		return errObj
+	}
+	var body io.Reader
+	switch data := args[2].(type) {
+	case *object.String:
+		body = strings.NewReader(data.Value())
+	case *object.ByteSlice:
+		body = bytes.NewReader(data.Value())
+	default:
+		if body, errObj = object.AsReader(data); errObj != nil {
+			return errObj
+		}
+	}
+	opts, errObj := parseObjectOptions("s3.client.put", args[3:], uploadOptions...)
+	if errObj != nil {
+		return errObj
+	}
+	return c.uploadObject(ctx, bucket, key, body, opts)
+}
+
+func (c *Client) upload(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("s3.client.upload", 3, 4, args); err != nil {
+		return err
+	}
+	bucket, key, errObj := bucketAndKey(args)
+	if errObj != nil {
+		return errObj
+	}
+	path, errObj := object.AsString(args[2])
+	if errObj != nil {
+		return errObj
+	}
+	opts, errObj := parseObjectOptions("s3.client.upload", args[3:], uploadOptions...)
+	if errObj != nil {
+		return errObj
+	}
+	f, err := ros.GetDefaultOS(ctx).Open(path)
+	if err != nil {
+		return object.NewError(err)
+	}
+	defer f.Close()
+	return c.uploadObject(ctx, bucket, key, f, opts)
+}
+
+func (c *Client) head(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("s3.client.head", 2, 3, args); err != nil {
+		return err
+	}
+	bucket, key, errObj := bucketAndKey(args)
+	if errObj != nil {
+		return errObj
+	}
+	opts, errObj := parseObjectOptions("s3.client.head", args[2:], "version_id", "sse_customer_key")
+	if errObj != nil {
+		return errObj
+	}
+	input := &s3.HeadObjectInput{
+		Bucket:    aws.String(bucket),
+		Key:       aws.String(key),
+		VersionId: optionalString(opts.versionID),
+	}
+	input.SSECustomerAlgorithm, input.SSECustomerKey, input.SSECustomerKeyMD5 = opts.customerKeyHeaders()
+	out, err := c.client.HeadObject(ctx, input)
+	if err != nil {
+		return s3Error(err)
+	}
+	metadata := make(map[string]object.Object, len(out.Metadata))
+	for name, value := range out.Metadata {
+		metadata[name] = object.NewString(value)
+	}
+	return object.NewMap(map[string]object.Object{
+		"size":          object.NewInt(out.ContentLength),
+		"etag":          object.NewString(strings.Trim(aws.ToString(out.ETag), `"`)),
+		"content_type":  object.NewString(aws.ToString(out.ContentType)),
+		"last_modified": timeValue(out.LastModified),
+		"version_id":    object.NewString(aws.ToString(out.VersionId)),
+		"storage_class": object.NewString(string(out.StorageClass)),
+		"sse":           object.NewString(string(out.ServerSideEncryption)),
+		"metadata":      object.NewMap(metadata),
+	})
+}
+
+func (c *Client) exists(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("s3.client.exists", 2, args); err != nil {
+		return err
+	}
+	bucket, key, errObj := bucketAndKey(args)
+	if errObj != nil {
+		return errObj
+	}
+	_, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return object.False
+		}
+		return s3Error(err)
+	}
+	return object.True
+}
+
+// delete removes one object, or a list of objects in batches.
+func (c *Client) delete(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("s3.client.delete", 2, args); err != nil {
+		return err
+	}
+	bucket, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	if key, ok := args[1].(*object.String); ok {
+		_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key.Value()),
+		})
+		if err != nil {
+			return s3Error(err)
+		}
+		return object.Nil
+	}
+	keys, errObj := object.AsStringSlice(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	for start := 0; start < len(keys); start += deleteBatchSize {
+		end := start + deleteBatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		ids := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			ids = append(ids, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+		out, err := c.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{Objects: ids, Quiet: true},
+		})
+		if err != nil {
+			return s3Error(err)
+		}
+		if len(out.Errors) > 0 {
+			e := out.Errors[0]
+			return object.Errorf("s3 error: failed to delete %q: %s", aws.ToString(e.Key), aws.ToString(e.Message))
+		}
+	}
+	return object.Nil
+}
+
+func (c *Client) copy(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("s3.client.copy", 4, 5, args); err != nil {
+		return err
+	}
+	srcBucket, srcKey, errObj := bucketAndKey(args[:2])
+	if errObj != nil {
+		return errObj
+	}
+	dstBucket, dstKey, errObj := bucketAndKey(args[2:4])
+	if errObj != nil {
+		return errObj
+	}
+	opts, errObj := parseObjectOptions("s3.client.copy", args[4:],
+		"storage_class", "acl", "metadata", "sse", "kms_key_id")
+	if errObj != nil {
+		return errObj
+	}
+	input := &s3.CopyObjectInput{
+		Bucket:               aws.String(dstBucket),
+		Key:                  aws.String(dstKey),
+		CopySource:           aws.String(srcBucket + "/" + srcKey),
+		StorageClass:         types.StorageClass(opts.storageClass),
+		ACL:                  types.ObjectCannedACL(opts.acl),
+		ServerSideEncryption: types.ServerSideEncryption(opts.sse),
+		SSEKMSKeyId:          optionalString(opts.kmsKeyID),
+	}
+	if opts.metadata != nil {
+		input.Metadata = opts.metadata
+		input.MetadataDirective = types.MetadataDirectiveReplace
+	}
+	out, err := c.client.CopyObject(ctx, input)
+	if err != nil {
+		return s3Error(err)
+	}
+	var etag string
+	if out.CopyObjectResult != nil {
+		etag = strings.Trim(aws.ToString(out.CopyObjectResult.ETag), `"`)
+	}
+	return object.NewMap(map[string]object.Object{
+		"etag":       object.NewString(etag),
+		"version_id": object.NewString(aws.ToString(out.VersionId)),
+	})
+}
+
+// presign returns a URL that grants temporary access to an object without
+// credentials.
+func (c *Client) presign(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("s3.client.presign", 2, 3, args); err != nil {
+		return err
+	}
+	bucket, key, errObj := bucketAndKey(args)
+	if errObj != nil {
+		return errObj
+	}
+	method := http.MethodGet
+	expires := 15 * time.Minute
+	var contentType string
+	if len(args) > 2 {
+		m, err := object.AsMap(args[2])
+		if err != nil {
+			return err
+		}
+		for name, value := range m.Value() {
+			switch name {
+			case "method":
+				if method, err = object.AsString(value); err == nil {
+					method = strings.ToUpper(method)
+				}
+			case "expires":
+				if expires, err = arg.Duration(key, value); err == nil && expires <= 0 {
+					err = object.Errorf("value error: expires must be positive (got %s)", expires)
+				}
+			case "content_type":
+				contentType, err = object.AsString(value)
+			default:
+				err = object.Errorf("value error: unknown s3.client.presign option %q", name)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	presigner := s3.NewPresignClient(c.client, s3.WithPresignExpires(expires))
+	var req *v4.PresignedHTTPRequest
+	var err error
+	switch method {
+	case http.MethodGet:
+		req, err = presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+	case http.MethodPut:
+		req, err = presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(bucket),
+			Key:         aws.String(key),
+			ContentType: optionalString(contentType),
+		})
+	case http.MethodHead:
+		req, err = presigner.PresignHeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+	case http.MethodDelete:
+		req, err = presigner.PresignDeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+	default:
+		return object.Errorf("value error: presign method must be GET, PUT, HEAD, or DELETE (got %q)", method)
+	}
+	if err != nil {
+		return s3Error(err)
+	}
+	return object.NewString(req.URL)
+}
@@ -0,0 +1,247 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// clientOptions holds the options for creating a client.
+type clientOptions struct {
+	region          string
+	endpoint        string
+	profile         string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	pathStyle       *bool
+	partSize        int64
+	concurrency     int
+}
+
+func parseClientOptions(args []object.Object) (clientOptions, *object.Error) {
+	var opts clientOptions
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "region":
+			opts.region, err = object.AsString(value)
+		case "endpoint":
+			opts.endpoint, err = object.AsString(value)
+		case "profile":
+			opts.profile, err = object.AsString(value)
+		case "access_key_id":
+			opts.accessKeyID, err = object.AsString(value)
+		case "secret_access_key":
+			opts.secretAccessKey, err = object.AsString(value)
+		case "session_token":
+			opts.sessionToken, err = object.AsString(value)
+		case "path_style":
+			var pathStyle bool
+			if pathStyle, err = object.AsBool(value); err == nil {
+				opts.pathStyle = &pathStyle
+			}
+		case "part_size":
+			if opts.partSize, err = object.AsInt(value); err == nil && opts.partSize < manager.MinUploadPartSize {
+				err = object.Errorf("value error: part_size must be at least %d bytes (got %d)", manager.MinUploadPartSize, opts.partSize)
+			}
+		case "concurrency":
+			var concurrency int64
+			if concurrency, err = object.AsInt(value); err == nil && concurrency < 1 {
+				err = object.Errorf("value error: concurrency must be at least 1 (got %d)", concurrency)
+			}
+			opts.concurrency = int(concurrency)
+		default:
+			err = object.Errorf("value error: unknown s3.client option %q", key)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// NewClientBuiltin creates a client for AWS S3 or an S3-compatible service.
+func NewClientBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("s3.client", 0, 1, args); err != nil {
+		return err
+	}
+	opts, errObj := parseClientOptions(args)
+	if errObj != nil {
+		return errObj
+	}
+	var loadOpts []func(*config.LoadOptions) error
+	if opts.region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(opts.region))
+	}
+	if opts.profile != "" {
+		loadOpts = append(loadOpts, config.WithSharedConfigProfile(opts.profile))
+	}
+	if opts.accessKeyID != "" || opts.secretAccessKey != "" {
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(opts.accessKeyID, opts.secretAccessKey, opts.sessionToken)))
+	}
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return object.NewError(fmt.Errorf("s3 error: %w", err))
+	}
+	if cfg.Region == "" {
+		// S3-compatible services often ignore the region, but requests must
+		// still be signed with one
+		cfg.Region = "us-east-1"
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if opts.endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.endpoint)
+			// Services such as MinIO are usually addressed by path
+			o.UsePathStyle = true
+		}
+		if opts.pathStyle != nil {
+			o.UsePathStyle = *opts.pathStyle
+		}
+	})
+	return NewClient(client, cfg.Region, opts.partSize, opts.concurrency)
+}
+
+// objectOptions holds the options for reading and writing objects.
+type objectOptions struct {
+	contentType     string
+	cacheControl    string
+	contentEncoding string
+	storageClass    string
+	acl             string
+	metadata        map[string]string
+	sse             string
+	kmsKeyID        string
+	customerKey     string
+	versionID       string
+	byteRange       string
+}
+
+// parseObjectOptions parses the options of a method, allowing only those
+// named in allowed.
+func parseObjectOptions(fn string, args []object.Object, allowed ...string) (objectOptions, *object.Error) {
+	var opts objectOptions
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		if !contains(allowed, key) {
+			return opts, object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		switch key {
+		case "content_type":
+			opts.contentType, err = object.AsString(value)
+		case "cache_control":
+			opts.cacheControl, err = object.AsString(value)
+		case "content_encoding":
+			opts.contentEncoding, err = object.AsString(value)
+		case "storage_class":
+			opts.storageClass, err = object.AsString(value)
+		case "acl":
+			opts.acl, err = object.AsString(value)
+		case "metadata":
+			var metadata *object.Map
+			if metadata, err = object.AsMap(value); err != nil {
+				break
+			}
+			opts.metadata = map[string]string{}
+			for name, value := range metadata.Value() {
+				if opts.metadata[name], err = object.AsString(value); err != nil {
+					break
+				}
+			}
+		case "sse":
+			if opts.sse, err = object.AsString(value); err == nil {
+				switch opts.sse {
+				case string(types.ServerSideEncryptionAes256), string(types.ServerSideEncryptionAwsKms), string(types.ServerSideEncryptionAwsKmsDsse):
+				default:
+					err = object.Errorf("value error: sse must be \"AES256\", \"aws:kms\", or \"aws:kms:dsse\" (got %q)", opts.sse)
+				}
+			}
+		case "kms_key_id":
+			opts.kmsKeyID, err = object.AsString(value)
+		case "sse_customer_key":
+			if opts.customerKey, err = asKey(value); err == nil && len(opts.customerKey) != 32 {
+				err = object.Errorf("value error: sse_customer_key must be 32 bytes (got %d)", len(opts.customerKey))
+			}
+		case "version_id":
+			opts.versionID, err = object.AsString(value)
+		case "range":
+			opts.byteRange, err = object.AsString(value)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func asKey(obj object.Object) (string, *object.Error) {
+	if b, ok := obj.(*object.ByteSlice); ok {
+		return string(b.Value()), nil
+	}
+	return object.AsString(obj)
+}
+
+// customerKeyHeaders returns the algorithm, key, and key MD5 headers for a
+// customer-provided encryption key, or nils if there isn't one.
+func (opts objectOptions) customerKeyHeaders() (*string, *string, *string) {
+	if opts.customerKey == "" {
+		return nil, nil, nil
+	}
+	sum := md5.Sum([]byte(opts.customerKey))
+	return aws.String("AES256"),
+		aws.String(base64.StdEncoding.EncodeToString([]byte(opts.customerKey))),
+		aws.String(base64.StdEncoding.EncodeToString(sum[:]))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return aws.String(s)
+}
+
+func timeValue(t *time.Time) object.Object {
+	if t == nil {
+		return object.Nil
+	}
+	return object.NewTime(*t)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("s3", map[string]object.Object{
+		"client": object.NewBuiltin("client", NewClientBuiltin),
+	})
+}
@@ -0,0 +1,322 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 is an in-memory, path-style S3 server supporting the requests the
+// module makes.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+	parts   map[int][]byte
+	uploads int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *Client) {
+	t.Helper()
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+	f := &fakeS3{
+		objects: map[string][]byte{},
+		headers: map[string]http.Header{},
+		parts:   map[int][]byte{},
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	client := NewClientBuiltin(context.Background(), object.NewMap(map[string]object.Object{
+		"endpoint":          object.NewString(server.URL),
+		"access_key_id":     object.NewString("key"),
+		"secret_access_key": object.NewString("secret"),
+		"part_size":         object.NewInt(5 * 1024 * 1024),
+	}))
+	c, ok := client.(*Client)
+	require.True(t, ok, "unexpected result: %s", client.Inspect())
+	return f, c
+}
+
+func etag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	query := r.URL.Query()
+	bucket, key, _ := strings.Cut(path, "/")
+	body, _ := io.ReadAll(r.Body)
+	switch {
+	case r.Method == http.MethodGet && key == "" && query.Get("list-type") == "2":
+		f.list(w, bucket, query)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.uploads++
+		f.parts = map[int][]byte{}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>", bucket, key)
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		n, _ := strconv.Atoi(query.Get("partNumber"))
+		f.parts[n] = body
+		w.Header().Set("ETag", etag(body))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		var numbers []int
+		for n := range f.parts {
+			numbers = append(numbers, n)
+		}
+		sort.Ints(numbers)
+		var data []byte
+		for _, n := range numbers {
+			data = append(data, f.parts[n]...)
+		}
+		f.objects[path] = data
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Location>http://%s/%s</Location><ETag>%s</ETag></CompleteMultipartUploadResult>", r.Host, path, etag(data))
+	case r.Method == http.MethodPost && query.Has("delete"):
+		var req struct {
+			Objects []struct{ Key string } `xml:"Object"`
+		}
+		xml.Unmarshal(body, &req)
+		for _, o := range req.Objects {
+			delete(f.objects, bucket+"/"+o.Key)
+		}
+		fmt.Fprint(w, "<DeleteResult></DeleteResult>")
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		src, _ := url.PathUnescape(strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/"))
+		data, ok := f.objects[src]
+		if !ok {
+			notFound(w)
+			return
+		}
+		f.objects[path] = data
+		f.headers[path] = r.Header.Clone()
+		fmt.Fprintf(w, "<CopyObjectResult><ETag>%s</ETag></CopyObjectResult>", etag(data))
+	case r.Method == http.MethodPut:
+		f.objects[path] = body
+		f.headers[path] = r.Header.Clone()
+		w.Header().Set("ETag", etag(body))
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := f.objects[path]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			notFound(w)
+			return
+		}
+		for name, values := range f.headers[path] {
+			if strings.HasPrefix(name, "X-Amz-Meta-") || name == "Content-Type" || name == "X-Amz-Server-Side-Encryption" {
+				w.Header()[name] = values
+			}
+		}
+		w.Header().Set("ETag", etag(data))
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>")
+}
+
+// list returns the keys of a bucket in pages of max-keys, continuing after
+// the key given as the continuation token.
+func (f *fakeS3) list(w http.ResponseWriter, bucket string, query url.Values) {
+	prefix := query.Get("prefix")
+	after := query.Get("continuation-token")
+	if after == "" {
+		after = query.Get("start-after")
+	}
+	var keys []string
+	for path := range f.objects {
+		if key, ok := strings.CutPrefix(path, bucket+"/"); ok && strings.HasPrefix(key, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	max, err := strconv.Atoi(query.Get("max-keys"))
+	if err != nil {
+		max = 2
+	}
+	truncated := len(keys) > max
+	if truncated {
+		keys = keys[:max]
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<ListBucketResult><Name>%s</Name><KeyCount>%d</KeyCount><IsTruncated>%t</IsTruncated>", bucket, len(keys), truncated)
+	if truncated {
+		fmt.Fprintf(&buf, "<NextContinuationToken>%s</NextContinuationToken>", keys[len(keys)-1])
+	}
+	for _, key := range keys {
+		data := f.objects[bucket+"/"+key]
+		fmt.Fprintf(&buf, "<Contents><Key>%s</Key><Size>%d</Size><ETag>%s</ETag><LastModified>2024-01-02T03:04:05.000Z</LastModified><StorageClass>STANDARD</StorageClass></Contents>",
+			key, len(data), etag(data))
+	}
+	buf.WriteString("</ListBucketResult>")
+	w.Write(buf.Bytes())
+}
+
+func call(t *testing.T, c *Client, method string, args ...object.Object) object.Object {
+	t.Helper()
+	fn, ok := c.GetAttr(method)
+	require.True(t, ok)
+	return fn.(*object.Builtin).Call(context.Background(), args...)
+}
+
+func TestPutGet(t *testing.T) {
+	f, c := newFakeS3(t)
+	result := call(t, c, "put", object.NewString("bucket"), object.NewString("a.txt"), object.NewString("hello"),
+		object.NewMap(map[string]object.Object{
+			"content_type": object.NewString("text/plain"),
+			"metadata":     object.NewMap(map[string]object.Object{"owner": object.NewString("ops")}),
+			"sse":          object.NewString("aws:kms"),
+			"kms_key_id":   object.NewString("alias/data"),
+		}))
+	require.Equal(t, object.NewString(strings.Trim(etag([]byte("hello")), `"`)), result.(*object.Map).Get("etag"))
+	require.Equal(t, 0, f.uploads)
+	require.Equal(t, "aws:kms", f.headers["bucket/a.txt"].Get("X-Amz-Server-Side-Encryption"))
+	require.Equal(t, "alias/data", f.headers["bucket/a.txt"].Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+
+	require.Equal(t, object.NewByteSlice([]byte("hello")), call(t, c, "get", object.NewString("bucket"), object.NewString("a.txt")))
+
+	head := call(t, c, "head", object.NewString("bucket"), object.NewString("a.txt")).(*object.Map)
+	require.Equal(t, object.NewInt(5), head.Get("size"))
+	require.Equal(t, object.NewString("text/plain"), head.Get("content_type"))
+	require.Equal(t, object.NewString("aws:kms"), head.Get("sse"))
+	require.Equal(t, object.NewString("ops"), head.Get("metadata").(*object.Map).Get("owner"))
+
+	require.Equal(t, object.True, call(t, c, "exists", object.NewString("bucket"), object.NewString("a.txt")))
+	require.Equal(t, object.False, call(t, c, "exists", object.NewString("bucket"), object.NewString("b.txt")))
+
+	result = call(t, c, "get", object.NewString("bucket"), object.NewString("b.txt"))
+	require.Contains(t, result.(*object.Error).Message().Value(), "s3 error:")
+	require.Contains(t, result.(*object.Error).Message().Value(), "NoSuchKey")
+
+	result = call(t, c, "copy", object.NewString("bucket"), object.NewString("a.txt"),
+		object.NewString("bucket"), object.NewString("c.txt"))
+	require.Equal(t, object.NewString(strings.Trim(etag([]byte("hello")), `"`)), result.(*object.Map).Get("etag"))
+	require.Equal(t, []byte("hello"), f.objects["bucket/c.txt"])
+
+	require.Equal(t, object.Nil, call(t, c, "delete", object.NewString("bucket"), object.NewString("a.txt")))
+	require.Equal(t, object.Nil, call(t, c, "delete", object.NewString("bucket"),
+		object.NewList([]object.Object{object.NewString("c.txt")})))
+	require.Empty(t, f.objects)
+}
+
+func TestMultipartUpload(t *testing.T) {
+	f, c := newFakeS3(t)
+	data := bytes.Repeat([]byte("0123456789abcdef"), 12*1024*1024/16)
+	// Readers are streamed, so the size isn't known up front
+	body := object.NewReader(io.MultiReader(bytes.NewReader(data)))
+	result := call(t, c, "put", object.NewString("bucket"), object.NewString("big.bin"), body)
+	require.Equal(t, object.NewString("upload-1"), result.(*object.Map).Get("upload_id"))
+	require.Equal(t, 1, f.uploads)
+	require.Len(t, f.parts, 3)
+	require.Equal(t, data, f.objects["bucket/big.bin"])
+
+	reader := call(t, c, "reader", object.NewString("bucket"), object.NewString("big.bin"))
+	downloaded, err := io.ReadAll(reader.(*object.Reader))
+	require.Nil(t, err)
+	require.Equal(t, data, downloaded)
+}
+
+func TestList(t *testing.T) {
+	f, c := newFakeS3(t)
+	for _, key := range []string{"logs/1", "logs/2", "logs/3", "logs/4", "logs/5", "other"} {
+		f.objects["bucket/"+key] = []byte(key)
+	}
+	collect := func(opts map[string]object.Object) []string {
+		stream := call(t, c, "list", object.NewString("bucket"), object.NewMap(opts)).(*object.Stream)
+		var keys []string
+		for {
+			item, ok := stream.Next(context.Background())
+			if !ok {
+				break
+			}
+			keys = append(keys, item.(*object.Map).Get("key").(*object.String).Value())
+		}
+		return keys
+	}
+	// The fake server returns two keys per page
+	require.Equal(t, []string{"logs/1", "logs/2", "logs/3", "logs/4", "logs/5"},
+		collect(map[string]object.Object{"prefix": object.NewString("logs/")}))
+	require.Equal(t, []string{"logs/3", "logs/4", "logs/5"},
+		collect(map[string]object.Object{"prefix": object.NewString("logs/"), "start_after": object.NewString("logs/2")}))
+	require.Equal(t, []string{"logs/1", "logs/2", "logs/3"},
+		collect(map[string]object.Object{"max": object.NewInt(3)}))
+}
+
+func TestPresign(t *testing.T) {
+	_, c := newFakeS3(t)
+	result := call(t, c, "presign", object.NewString("bucket"), object.NewString("a b.txt"),
+		object.NewMap(map[string]object.Object{"method": object.NewString("put"), "expires": object.NewString("1h")}))
+	u, err := url.Parse(result.(*object.String).Value())
+	require.Nil(t, err)
+	require.Equal(t, "/bucket/a%20b.txt", u.EscapedPath())
+	require.Equal(t, "3600", u.Query().Get("X-Amz-Expires"))
+	require.Contains(t, u.Query().Get("X-Amz-Credential"), "key/")
+	require.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+
+	result = call(t, c, "presign", object.NewString("bucket"), object.NewString("a.txt"),
+		object.NewMap(map[string]object.Object{"method": object.NewString("PATCH")}))
+	require.Equal(t, `value error: presign method must be GET, PUT, HEAD, or DELETE (got "PATCH")`,
+		result.(*object.Error).Message().Value())
+}
+
+func TestOptionErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		result object.Object
+		err    string
+	}{
+		{NewClientBuiltin(ctx, object.NewMap(map[string]object.Object{"part_size": object.NewInt(1024)})),
+			"value error: part_size must be at least 5242880 bytes (got 1024)"},
+		{NewClientBuiltin(ctx, object.NewMap(map[string]object.Object{"bucket": object.NewString("b")})),
+			`value error: unknown s3.client option "bucket"`},
+	}
+	_, c := newFakeS3(t)
+	tests = append(tests, []struct {
+		result object.Object
+		err    string
+	}{
+		{call(t, c, "put", object.NewString("b"), object.NewString("k"), object.NewString("v"),
+			object.NewMap(map[string]object.Object{"sse": object.NewString("des")})),
+			`value error: sse must be "AES256", "aws:kms", or "aws:kms:dsse" (got "des")`},
+		{call(t, c, "put", object.NewString("b"), object.NewString("k"), object.NewString("v"),
+			object.NewMap(map[string]object.Object{"sse_customer_key": object.NewString("short")})),
+			"value error: sse_customer_key must be 32 bytes (got 5)"},
+		{call(t, c, "get", object.NewString("b"), object.NewString("k"),
+			object.NewMap(map[string]object.Object{"acl": object.NewString("private")})),
+			`value error: unknown s3.client.get option "acl"`},
+	}...)
+	for _, tt := range tests {
+		errObj, ok := tt.result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", tt.result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+}
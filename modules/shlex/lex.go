@@ -0,0 +1,124 @@
+package shlex
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errUnterminatedSingle = errors.New("unterminated single quote")
+	errUnterminatedDouble = errors.New("unterminated double quote")
+	errTrailingBackslash  = errors.New("trailing backslash")
+)
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// Split splits a command line into words following the quoting rules of
+// POSIX shells. Single quotes keep their content as is, double quotes keep
+// their content except for backslash escapes of $, `, ", \ and newlines,
+// and a backslash outside quotes escapes the next character. If comments is
+// true, a # at the start of a word starts a comment up to the end of the
+// line. Expansions such as $VAR or globs aren't performed.
+func Split(s string, comments bool) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isSpace(c):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '#' && comments && !inWord:
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == '\\':
+			if i+1 == len(s) {
+				return nil, errTrailingBackslash
+			}
+			i++
+			if s[i] == '\n' {
+				// A line continuation
+				continue
+			}
+			word.WriteByte(s[i])
+			inWord = true
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errUnterminatedSingle
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					switch s[i+1] {
+					case '$', '`', '"', '\\':
+						i++
+					case '\n':
+						i++
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+			if i == len(s) {
+				return nil, errUnterminatedDouble
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// isSafe returns true if the string needs no quoting.
+func isSafe(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("@%+=:,./_-", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Quote returns the string quoted so that a POSIX shell reads it as one
+// word with the same value, without expanding anything in it.
+func Quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if isSafe(s) {
+		return s
+	}
+	// Single quotes can't be escaped inside single quotes, so each one
+	// closes the quoted part and is added within double quotes
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+// Join quotes the words and joins them with spaces into a command line.
+func Join(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = Quote(w)
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,71 @@
+package shlex
+
+import (
+	"context"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+func SplitBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("shlex.split", 1, 2, args); err != nil {
+		return err
+	}
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	var comments bool
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "comments":
+				comments, err = object.AsBool(value)
+			default:
+				err = object.Errorf("value error: unknown shlex.split option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	words, serr := Split(s, comments)
+	if serr != nil {
+		return object.Errorf("value error: shlex.split %v", serr)
+	}
+	return object.NewStringList(words)
+}
+
+func QuoteBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("shlex.quote", 1, args); err != nil {
+		return err
+	}
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewString(Quote(s))
+}
+
+func JoinBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("shlex.join", 1, args); err != nil {
+		return err
+	}
+	words, err := object.AsStringSlice(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewString(Join(words))
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("shlex", map[string]object.Object{
+		"join":  object.NewBuiltin("join", JoinBuiltin),
+		"quote": object.NewBuiltin("quote", QuoteBuiltin),
+		"split": object.NewBuiltin("split", SplitBuiltin),
+	})
+}
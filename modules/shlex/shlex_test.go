@@ -0,0 +1,118 @@
+package shlex
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"ls -la /tmp", []string{"ls", "-la", "/tmp"}},
+		{"  a\t b\n c  ", []string{"a", "b", "c"}},
+		{`echo 'hello world'`, []string{"echo", "hello world"}},
+		{`echo "hello world"`, []string{"echo", "hello world"}},
+		{`echo '' ""`, []string{"echo", "", ""}},
+		{`a'b'"c"d`, []string{"abcd"}},
+		{`echo 'a\b' "a\b"`, []string{"echo", `a\b`, `a\b`}},
+		{`echo "say \"hi\" \$HOME \\ \x"`, []string{"echo", `say "hi" $HOME \ \x`}},
+		{`echo it\'s a\ b`, []string{"echo", "it's", "a b"}},
+		{"echo a\\\nb", []string{"echo", "ab"}},
+		{`grep -e '#' file # comment`, []string{"grep", "-e", "#", "file", "#", "comment"}},
+	}
+	for _, tt := range tests {
+		words, err := Split(tt.input, false)
+		require.NoError(t, err, tt.input)
+		require.Equal(t, tt.expected, words, tt.input)
+	}
+}
+
+func TestSplitComments(t *testing.T) {
+	words, err := Split("a b#c # comment\nd", true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b#c", "d"}, words)
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "''"},
+		{"simple", "simple"},
+		{"/usr/bin/env", "/usr/bin/env"},
+		{"a=b,c:d@e%f+g", "a=b,c:d@e%f+g"},
+		{"hello world", "'hello world'"},
+		{"$(rm -rf /)", "'$(rm -rf /)'"},
+		{"it's", `'it'"'"'s'`},
+		{"a;b|c&d", "'a;b|c&d'"},
+		{"*.go", "'*.go'"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, Quote(tt.input), tt.input)
+		// Quoted strings split back to the original
+		words, err := Split(Quote(tt.input), false)
+		require.NoError(t, err)
+		require.Equal(t, []string{tt.input}, words)
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	ctx := context.Background()
+	words := object.NewStringList([]string{"git", "commit", "-m", "it's done"})
+	line := JoinBuiltin(ctx, words)
+	require.Equal(t, object.NewString(`git commit -m 'it'"'"'s done'`), line)
+	require.Equal(t, words, SplitBuiltin(ctx, line))
+	require.Equal(t, object.NewStringList([]string{"a"}),
+		SplitBuiltin(ctx, object.NewString("a # b"), object.FromGoType(map[string]interface{}{"comments": true})))
+	require.Equal(t, object.NewString("'a b'"), QuoteBuiltin(ctx, object.NewString("a b")))
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"unterminated single quote",
+			SplitBuiltin(ctx, object.NewString("echo 'oops")),
+			"value error: shlex.split unterminated single quote",
+		},
+		{
+			"unterminated double quote",
+			SplitBuiltin(ctx, object.NewString(`echo "oops\"`)),
+			"value error: shlex.split unterminated double quote",
+		},
+		{
+			"trailing backslash",
+			SplitBuiltin(ctx, object.NewString(`echo \`)),
+			"value error: shlex.split trailing backslash",
+		},
+		{
+			"unknown option",
+			SplitBuiltin(ctx, object.NewString("a"), object.FromGoType(map[string]interface{}{"posix": true})),
+			`value error: unknown shlex.split option "posix"`,
+		},
+		{
+			"join with a non-string",
+			JoinBuiltin(ctx, object.FromGoType([]interface{}{"a", 1})),
+			"type error: expected a string (int given)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
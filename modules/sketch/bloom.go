@@ -0,0 +1,197 @@
+package sketch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const BLOOM object.Type = "sketch.bloom"
+
+// maxBloomBits limits a Bloom filter to 512 MB.
+const maxBloomBits = 1 << 32
+
+// Bloom is a Bloom filter, which tells whether an item was possibly added
+// or definitely not. It is safe to share between threads.
+type Bloom struct {
+	mu        sync.Mutex
+	bits      []uint64
+	m         uint64 // number of bits
+	k         uint64 // number of hash functions
+	capacity  int64
+	errorRate float64
+	count     int64
+}
+
+// BloomSize returns the number of bits and hash functions a Bloom filter
+// needs to hold capacity items with the given false positive rate.
+func BloomSize(capacity int64, errorRate float64) (m, k uint64) {
+	bits := math.Ceil(-float64(capacity) * math.Log(errorRate) / (math.Ln2 * math.Ln2))
+	m = uint64(math.Min(math.Max(bits, 64), 1<<63))
+	k = uint64(math.Max(math.Round(float64(m)/float64(capacity)*math.Ln2), 1))
+	return m, k
+}
+
+// NewBloom returns an empty Bloom filter sized for capacity items with the
+// given false positive rate.
+func NewBloom(capacity int64, errorRate float64) *Bloom {
+	m, k := BloomSize(capacity, errorRate)
+	return &Bloom{
+		bits:      make([]uint64, (m+63)/64),
+		m:         m,
+		k:         k,
+		capacity:  capacity,
+		errorRate: errorRate,
+	}
+}
+
+// Add adds the item with the given hashes, and returns false if it was
+// possibly added before.
+func (b *Bloom) Add(h1, h2 uint64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	added := false
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if b.bits[word]&mask == 0 {
+			b.bits[word] |= mask
+			added = true
+		}
+	}
+	if added {
+		b.count++
+	}
+	return added
+}
+
+// Contains returns true if the item with the given hashes was possibly
+// added.
+func (b *Bloom) Contains(h1, h2 uint64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge adds the items of another filter with the same size to this one.
+func (b *Bloom) Merge(other *Bloom) error {
+	if other.m != b.m || other.k != b.k {
+		return fmt.Errorf("value error: unable to merge Bloom filters of different sizes")
+	}
+	if other == b {
+		return nil
+	}
+	other.mu.Lock()
+	bits := make([]uint64, len(other.bits))
+	copy(bits, other.bits)
+	count := other.count
+	other.mu.Unlock()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, word := range bits {
+		b.bits[i] |= word
+	}
+	b.count += count
+	return nil
+}
+
+func (b *Bloom) Type() object.Type {
+	return BLOOM
+}
+
+func (b *Bloom) Inspect() string {
+	return fmt.Sprintf("sketch.bloom(capacity=%d, error_rate=%g)", b.capacity, b.errorRate)
+}
+
+func (b *Bloom) Interface() interface{} {
+	return nil
+}
+
+func (b *Bloom) Equals(other object.Object) object.Object {
+	return object.NewBool(b == other)
+}
+
+func (b *Bloom) IsTruthy() bool {
+	return true
+}
+
+func (b *Bloom) Cost() int {
+	return len(b.bits) * 8
+}
+
+func (b *Bloom) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", BLOOM)
+}
+
+func (b *Bloom) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", BLOOM, opType)
+}
+
+func (b *Bloom) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", BLOOM, name)
+}
+
+func (b *Bloom) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "capacity":
+		return object.NewInt(b.capacity), true
+	case "error_rate":
+		return object.NewFloat(b.errorRate), true
+	case "bits":
+		return object.NewInt(int64(b.m)), true
+	case "hashes":
+		return object.NewInt(int64(b.k)), true
+	case "count":
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return object.NewInt(b.count), true
+	case "add":
+		return object.NewBuiltin("sketch.bloom.add", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sketch.bloom.add", 1, args); err != nil {
+				return err
+			}
+			h1, h2, err := hashItem("sketch.bloom.add", args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewBool(b.Add(h1, h2))
+		}), true
+	case "contains":
+		return object.NewBuiltin("sketch.bloom.contains", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sketch.bloom.contains", 1, args); err != nil {
+				return err
+			}
+			h1, h2, err := hashItem("sketch.bloom.contains", args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewBool(b.Contains(h1, h2))
+		}), true
+	case "merge":
+		return object.NewBuiltin("sketch.bloom.merge", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sketch.bloom.merge", 1, args); err != nil {
+				return err
+			}
+			other, ok := args[0].(*Bloom)
+			if !ok {
+				return object.Errorf("type error: sketch.bloom.merge expected a %s (%s given)", BLOOM, args[0].Type())
+			}
+			if err := b.Merge(other); err != nil {
+				return object.NewError(err)
+			}
+			return b
+		}), true
+	}
+	return nil, false
+}
@@ -0,0 +1,208 @@
+package sketch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const COUNT_MIN object.Type = "sketch.count_min"
+
+// maxCountMinCells limits a count-min sketch to 512 MB.
+const maxCountMinCells = 1 << 26
+
+// CountMin is a count-min sketch, which estimates how many times each item
+// was added. Estimates are never too low, and are too high by at most
+// error times the total count, with the given confidence. It is safe to
+// share between threads.
+type CountMin struct {
+	mu         sync.Mutex
+	width      uint64
+	depth      uint64
+	cells      []uint64
+	total      uint64
+	errRate    float64
+	confidence float64
+}
+
+// CountMinSize returns the width and depth of a count-min sketch with the
+// given error and confidence.
+func CountMinSize(errRate, confidence float64) (width, depth uint64) {
+	width = uint64(math.Min(math.Ceil(math.E/errRate), 1<<40))
+	depth = uint64(math.Max(math.Ceil(math.Log(1/(1-confidence))), 1))
+	return width, depth
+}
+
+// NewCountMin returns an empty count-min sketch with the given error and
+// confidence.
+func NewCountMin(errRate, confidence float64) *CountMin {
+	width, depth := CountMinSize(errRate, confidence)
+	return &CountMin{
+		width:      width,
+		depth:      depth,
+		cells:      make([]uint64, width*depth),
+		errRate:    errRate,
+		confidence: confidence,
+	}
+}
+
+// Add counts the item with the given hashes n more times, and returns its
+// new estimated count.
+func (c *CountMin) Add(h1, h2 uint64, n uint64) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.total += n
+	estimate := uint64(math.MaxUint64)
+	for i := uint64(0); i < c.depth; i++ {
+		cell := i*c.width + (h1+i*h2)%c.width
+		c.cells[cell] += n
+		if c.cells[cell] < estimate {
+			estimate = c.cells[cell]
+		}
+	}
+	return estimate
+}
+
+// Count returns the estimated count of the item with the given hashes.
+func (c *CountMin) Count(h1, h2 uint64) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	estimate := uint64(math.MaxUint64)
+	for i := uint64(0); i < c.depth; i++ {
+		if v := c.cells[i*c.width+(h1+i*h2)%c.width]; v < estimate {
+			estimate = v
+		}
+	}
+	return estimate
+}
+
+// Total returns the sum of all counts added.
+func (c *CountMin) Total() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// Merge adds the counts of another sketch with the same size to this one.
+func (c *CountMin) Merge(other *CountMin) error {
+	if other.width != c.width || other.depth != c.depth {
+		return fmt.Errorf("value error: unable to merge count-min sketches of different sizes")
+	}
+	if other == c {
+		return fmt.Errorf("value error: unable to merge a count-min sketch with itself")
+	}
+	other.mu.Lock()
+	cells := make([]uint64, len(other.cells))
+	copy(cells, other.cells)
+	total := other.total
+	other.mu.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, v := range cells {
+		c.cells[i] += v
+	}
+	c.total += total
+	return nil
+}
+
+func (c *CountMin) Type() object.Type {
+	return COUNT_MIN
+}
+
+func (c *CountMin) Inspect() string {
+	return fmt.Sprintf("sketch.count_min(width=%d, depth=%d)", c.width, c.depth)
+}
+
+func (c *CountMin) Interface() interface{} {
+	return nil
+}
+
+func (c *CountMin) Equals(other object.Object) object.Object {
+	return object.NewBool(c == other)
+}
+
+func (c *CountMin) IsTruthy() bool {
+	return true
+}
+
+func (c *CountMin) Cost() int {
+	return len(c.cells) * 8
+}
+
+func (c *CountMin) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", COUNT_MIN)
+}
+
+func (c *CountMin) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", COUNT_MIN, opType)
+}
+
+func (c *CountMin) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", COUNT_MIN, name)
+}
+
+func (c *CountMin) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "width":
+		return object.NewInt(int64(c.width)), true
+	case "depth":
+		return object.NewInt(int64(c.depth)), true
+	case "error":
+		return object.NewFloat(c.errRate), true
+	case "confidence":
+		return object.NewFloat(c.confidence), true
+	case "total":
+		return object.NewInt(int64(c.Total())), true
+	case "add":
+		return object.NewBuiltin("sketch.count_min.add", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("sketch.count_min.add", 1, 2, args); err != nil {
+				return err
+			}
+			h1, h2, err := hashItem("sketch.count_min.add", args[0])
+			if err != nil {
+				return err
+			}
+			n := int64(1)
+			if len(args) == 2 {
+				if n, err = object.AsInt(args[1]); err != nil {
+					return err
+				}
+				if n < 1 {
+					return object.Errorf("value error: sketch.count_min.add count must be at least 1 (got %d)", n)
+				}
+			}
+			return object.NewInt(int64(c.Add(h1, h2, uint64(n))))
+		}), true
+	case "count":
+		return object.NewBuiltin("sketch.count_min.count", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sketch.count_min.count", 1, args); err != nil {
+				return err
+			}
+			h1, h2, err := hashItem("sketch.count_min.count", args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewInt(int64(c.Count(h1, h2)))
+		}), true
+	case "merge":
+		return object.NewBuiltin("sketch.count_min.merge", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sketch.count_min.merge", 1, args); err != nil {
+				return err
+			}
+			other, ok := args[0].(*CountMin)
+			if !ok {
+				return object.Errorf("type error: sketch.count_min.merge expected a %s (%s given)", COUNT_MIN, args[0].Type())
+			}
+			if err := c.Merge(other); err != nil {
+				return object.NewError(err)
+			}
+			return c
+		}), true
+	}
+	return nil, false
+}
@@ -0,0 +1,61 @@
+package sketch
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"github.com/risor-io/risor/object"
+)
+
+// itemBytes returns the bytes that represent an item. Numbers are
+// represented by their string form, so 1 and "1" are the same item.
+func itemBytes(fn string, obj object.Object) ([]byte, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.String:
+		return []byte(obj.Value()), nil
+	case *object.ByteSlice:
+		return obj.Value(), nil
+	case *object.Int:
+		return strconv.AppendInt(nil, obj.Value(), 10), nil
+	case *object.Float:
+		return strconv.AppendFloat(nil, obj.Value(), 'g', -1, 64), nil
+	default:
+		return nil, object.Errorf("type error: %s expected a string, byte_slice, or number (%s given)", fn, obj.Type())
+	}
+}
+
+// hash returns two independent 64 bit hashes of the data. Sketches combine
+// them to derive as many hash functions as they need.
+func hash(data []byte) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write(data)
+	var sum [16]byte
+	h.Sum(sum[:0])
+	var h1, h2 uint64
+	for i := 0; i < 8; i++ {
+		h1 = h1<<8 | uint64(sum[i])
+		h2 = h2<<8 | uint64(sum[8+i])
+	}
+	return mix(h1), mix(h2)
+}
+
+// hashItem hashes an item given to a sketch method.
+func hashItem(fn string, obj object.Object) (uint64, uint64, *object.Error) {
+	data, err := itemBytes(fn, obj)
+	if err != nil {
+		return 0, 0, err
+	}
+	h1, h2 := hash(data)
+	return h1, h2, nil
+}
+
+// mix is the splitmix64 finalizer, which spreads the input bits across the
+// whole output. FNV alone leaves the high bits poorly mixed for short inputs.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
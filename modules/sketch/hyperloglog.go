@@ -0,0 +1,177 @@
+package sketch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/bits"
+	"sync"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const HYPERLOGLOG object.Type = "sketch.hyperloglog"
+
+// HyperLogLog estimates the number of distinct items added to it, using
+// 2^precision bytes. The standard error of the estimate is about
+// 1.04/sqrt(2^precision). It is safe to share between threads.
+type HyperLogLog struct {
+	mu        sync.Mutex
+	precision uint8
+	registers []uint8
+}
+
+// NewHyperLogLog returns an empty HyperLogLog with the given precision,
+// between 4 and 18.
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	return &HyperLogLog{precision: precision, registers: make([]uint8, 1<<precision)}
+}
+
+// Add adds the item with the given hash, and returns true if the estimate
+// may have changed.
+func (h *HyperLogLog) Add(hash uint64) bool {
+	// The first bits pick a register, which keeps the longest run of
+	// leading zeros seen in the remaining bits
+	index := hash >> (64 - h.precision)
+	rank := uint8(bits.LeadingZeros64(hash<<h.precision|1<<(h.precision-1))) + 1
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+		return true
+	}
+	return false
+}
+
+// Count returns the estimated number of distinct items.
+func (h *HyperLogLog) Count() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m := float64(len(h.registers))
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	var alpha float64
+	switch len(h.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
+
+// Merge adds the items of another HyperLogLog with the same precision to
+// this one.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if other.precision != h.precision {
+		return fmt.Errorf("value error: unable to merge HyperLogLogs of different precisions (%d and %d)", h.precision, other.precision)
+	}
+	if other == h {
+		return nil
+	}
+	other.mu.Lock()
+	registers := make([]uint8, len(other.registers))
+	copy(registers, other.registers)
+	other.mu.Unlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, r := range registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+	return nil
+}
+
+func (h *HyperLogLog) Type() object.Type {
+	return HYPERLOGLOG
+}
+
+func (h *HyperLogLog) Inspect() string {
+	return fmt.Sprintf("sketch.hyperloglog(precision=%d)", h.precision)
+}
+
+func (h *HyperLogLog) Interface() interface{} {
+	return nil
+}
+
+func (h *HyperLogLog) Equals(other object.Object) object.Object {
+	return object.NewBool(h == other)
+}
+
+func (h *HyperLogLog) IsTruthy() bool {
+	return true
+}
+
+func (h *HyperLogLog) Cost() int {
+	return len(h.registers)
+}
+
+func (h *HyperLogLog) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", HYPERLOGLOG)
+}
+
+func (h *HyperLogLog) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", HYPERLOGLOG, opType)
+}
+
+func (h *HyperLogLog) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", HYPERLOGLOG, name)
+}
+
+func (h *HyperLogLog) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "precision":
+		return object.NewInt(int64(h.precision)), true
+	case "add":
+		return object.NewBuiltin("sketch.hyperloglog.add", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sketch.hyperloglog.add", 1, args); err != nil {
+				return err
+			}
+			h1, _, err := hashItem("sketch.hyperloglog.add", args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewBool(h.Add(h1))
+		}), true
+	case "count":
+		return object.NewBuiltin("sketch.hyperloglog.count", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sketch.hyperloglog.count", 0, args); err != nil {
+				return err
+			}
+			return object.NewInt(h.Count())
+		}), true
+	case "merge":
+		return object.NewBuiltin("sketch.hyperloglog.merge", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sketch.hyperloglog.merge", 1, args); err != nil {
+				return err
+			}
+			other, ok := args[0].(*HyperLogLog)
+			if !ok {
+				return object.Errorf("type error: sketch.hyperloglog.merge expected a %s (%s given)", HYPERLOGLOG, args[0].Type())
+			}
+			if err := h.Merge(other); err != nil {
+				return object.NewError(err)
+			}
+			return h
+		}), true
+	}
+	return nil, false
+}
@@ -0,0 +1,122 @@
+package sketch
+
+import (
+	"context"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// parseOptions calls set for each entry of the options map given as the
+// last argument, if there is one.
+func parseOptions(fn string, args []object.Object, set func(key string, value object.Object) (bool, *object.Error)) *object.Error {
+	if len(args) == 0 {
+		return nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return err
+	}
+	for key, value := range m.Value() {
+		ok, err := set(key, value)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+	}
+	return nil
+}
+
+// probability reads an option that must be strictly between 0 and 1.
+func probability(fn, name string, value object.Object) (float64, *object.Error) {
+	p, err := object.AsFloat(value)
+	if err != nil {
+		return 0, err
+	}
+	if !(p > 0 && p < 1) {
+		return 0, object.Errorf("value error: %s %s must be between 0 and 1 (got %v)", fn, name, p)
+	}
+	return p, nil
+}
+
+func BloomBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("sketch.bloom", 1, 2, args); err != nil {
+		return err
+	}
+	capacity, err := object.AsInt(args[0])
+	if err != nil {
+		return err
+	}
+	if capacity < 1 {
+		return object.Errorf("value error: sketch.bloom capacity must be at least 1 (got %d)", capacity)
+	}
+	errorRate := 0.01
+	if err := parseOptions("sketch.bloom", args[1:], func(key string, value object.Object) (bool, *object.Error) {
+		if key != "error_rate" {
+			return false, nil
+		}
+		errorRate, err = probability("sketch.bloom", key, value)
+		return true, err
+	}); err != nil {
+		return err
+	}
+	if m, _ := BloomSize(capacity, errorRate); m > maxBloomBits {
+		return object.Errorf("value error: sketch.bloom would need %d bits, over the limit of %d", m, uint64(maxBloomBits))
+	}
+	return NewBloom(capacity, errorRate)
+}
+
+func HyperLogLogBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("sketch.hyperloglog", 0, 1, args); err != nil {
+		return err
+	}
+	precision := int64(14)
+	if err := parseOptions("sketch.hyperloglog", args, func(key string, value object.Object) (bool, *object.Error) {
+		if key != "precision" {
+			return false, nil
+		}
+		var err *object.Error
+		if precision, err = object.AsInt(value); err == nil && (precision < 4 || precision > 18) {
+			err = object.Errorf("value error: sketch.hyperloglog precision must be between 4 and 18 (got %d)", precision)
+		}
+		return true, err
+	}); err != nil {
+		return err
+	}
+	return NewHyperLogLog(uint8(precision))
+}
+
+func CountMinBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("sketch.count_min", 0, 1, args); err != nil {
+		return err
+	}
+	errRate, confidence := 0.001, 0.99
+	if err := parseOptions("sketch.count_min", args, func(key string, value object.Object) (bool, *object.Error) {
+		var err *object.Error
+		switch key {
+		case "error":
+			errRate, err = probability("sketch.count_min", key, value)
+		case "confidence":
+			confidence, err = probability("sketch.count_min", key, value)
+		default:
+			return false, nil
+		}
+		return true, err
+	}); err != nil {
+		return err
+	}
+	if width, depth := CountMinSize(errRate, confidence); width*depth > maxCountMinCells {
+		return object.Errorf("value error: sketch.count_min would need %d counters, over the limit of %d", width*depth, maxCountMinCells)
+	}
+	return NewCountMin(errRate, confidence)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("sketch", map[string]object.Object{
+		"bloom":       object.NewBuiltin("bloom", BloomBuiltin),
+		"count_min":   object.NewBuiltin("count_min", CountMinBuiltin),
+		"hyperloglog": object.NewBuiltin("hyperloglog", HyperLogLogBuiltin),
+	})
+}
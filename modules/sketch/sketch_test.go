@@ -0,0 +1,174 @@
+package sketch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func opts(m map[string]interface{}) object.Object {
+	return object.FromGoType(m)
+}
+
+func call(t *testing.T, obj object.Object, method string, args ...object.Object) object.Object {
+	t.Helper()
+	attr, ok := obj.GetAttr(method)
+	require.True(t, ok, method)
+	fn, ok := attr.(*object.Builtin)
+	require.True(t, ok)
+	return fn.Call(context.Background(), args...)
+}
+
+func TestBloom(t *testing.T) {
+	ctx := context.Background()
+	b, ok := BloomBuiltin(ctx, object.NewInt(1000), opts(map[string]interface{}{"error_rate": 0.01})).(*Bloom)
+	require.True(t, ok)
+	require.Equal(t, uint64(9586), b.m)
+	require.Equal(t, uint64(7), b.k)
+
+	for i := 0; i < 1000; i++ {
+		call(t, b, "add", object.NewString(fmt.Sprintf("item-%d", i)))
+	}
+	// No false negatives
+	for i := 0; i < 1000; i++ {
+		require.Equal(t, object.True, call(t, b, "contains", object.NewString(fmt.Sprintf("item-%d", i))))
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if call(t, b, "contains", object.NewString(fmt.Sprintf("other-%d", i))) == object.True {
+			falsePositives++
+		}
+	}
+	require.Less(t, falsePositives, 200)
+
+	require.Equal(t, object.False, call(t, b, "add", object.NewString("item-1")))
+	require.Equal(t, object.True, call(t, b, "add", object.NewInt(42)))
+	require.Equal(t, object.True, call(t, b, "contains", object.NewString("42")))
+}
+
+func TestBloomMerge(t *testing.T) {
+	a, b := NewBloom(100, 0.01), NewBloom(100, 0.01)
+	call(t, a, "add", object.NewString("a"))
+	call(t, b, "add", object.NewString("b"))
+	require.Equal(t, a, call(t, a, "merge", b))
+	require.Equal(t, object.True, call(t, a, "contains", object.NewString("b")))
+	count, _ := a.GetAttr("count")
+	require.Equal(t, object.NewInt(2), count)
+}
+
+func TestHyperLogLog(t *testing.T) {
+	ctx := context.Background()
+	h := HyperLogLogBuiltin(ctx).(*HyperLogLog)
+	require.Equal(t, int64(0), h.Count())
+	for i := 0; i < 100000; i++ {
+		// Every item is added twice
+		call(t, h, "add", object.NewInt(int64(i%50000)))
+	}
+	estimate := call(t, h, "count").(*object.Int).Value()
+	// Precision 14 has a standard error under 1%
+	require.InDelta(t, 50000, estimate, 1500)
+
+	small := NewHyperLogLog(10)
+	for i := 0; i < 10; i++ {
+		call(t, small, "add", object.NewString(fmt.Sprintf("user-%d", i)))
+	}
+	require.Equal(t, int64(10), small.Count())
+
+	other := NewHyperLogLog(14)
+	for i := 40000; i < 60000; i++ {
+		call(t, other, "add", object.NewInt(int64(i)))
+	}
+	call(t, h, "merge", other)
+	require.InDelta(t, 60000, h.Count(), 1800)
+}
+
+func TestCountMin(t *testing.T) {
+	ctx := context.Background()
+	c := CountMinBuiltin(ctx, opts(map[string]interface{}{"error": 0.01, "confidence": 0.99})).(*CountMin)
+	require.Equal(t, uint64(272), c.width)
+	require.Equal(t, uint64(5), c.depth)
+
+	require.Equal(t, object.NewInt(3), call(t, c, "add", object.NewString("GET /"), object.NewInt(3)))
+	require.Equal(t, object.NewInt(4), call(t, c, "add", object.NewString("GET /")))
+	for i := 0; i < 1000; i++ {
+		call(t, c, "add", object.NewString(fmt.Sprintf("path-%d", i)))
+	}
+	total, _ := c.GetAttr("total")
+	require.Equal(t, object.NewInt(1004), total)
+	// Estimates are never too low, and rarely more than error*total too high
+	estimate := call(t, c, "count", object.NewString("GET /")).(*object.Int).Value()
+	require.GreaterOrEqual(t, estimate, int64(4))
+	require.LessOrEqual(t, estimate, int64(4+math.Ceil(0.01*1004)))
+	require.Equal(t, object.NewInt(0), call(t, NewCountMin(0.01, 0.99), "count", object.NewString("GET /")))
+
+	other := NewCountMin(0.01, 0.99)
+	call(t, other, "add", object.NewString("GET /"), object.NewInt(10))
+	call(t, c, "merge", other)
+	require.GreaterOrEqual(t, call(t, c, "count", object.NewString("GET /")).(*object.Int).Value(), int64(14))
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"zero capacity",
+			BloomBuiltin(ctx, object.NewInt(0)),
+			"value error: sketch.bloom capacity must be at least 1 (got 0)",
+		},
+		{
+			"error rate out of range",
+			BloomBuiltin(ctx, object.NewInt(10), opts(map[string]interface{}{"error_rate": 1})),
+			"value error: sketch.bloom error_rate must be between 0 and 1 (got 1)",
+		},
+		{
+			"oversized filter",
+			BloomBuiltin(ctx, object.NewInt(1<<40)),
+			"value error: sketch.bloom would need 10538883138828 bits, over the limit of 4294967296",
+		},
+		{
+			"unknown option",
+			BloomBuiltin(ctx, object.NewInt(10), opts(map[string]interface{}{"size": 1})),
+			`value error: unknown sketch.bloom option "size"`,
+		},
+		{
+			"precision out of range",
+			HyperLogLogBuiltin(ctx, opts(map[string]interface{}{"precision": 20})),
+			"value error: sketch.hyperloglog precision must be between 4 and 18 (got 20)",
+		},
+		{
+			"unhashable item",
+			call(t, NewHyperLogLog(4), "add", object.NewList(nil)),
+			"type error: sketch.hyperloglog.add expected a string, byte_slice, or number (list given)",
+		},
+		{
+			"mismatched merge",
+			call(t, NewHyperLogLog(4), "merge", NewHyperLogLog(5)),
+			"value error: unable to merge HyperLogLogs of different precisions (4 and 5)",
+		},
+		{
+			"merge with another type",
+			call(t, NewBloom(10, 0.1), "merge", NewHyperLogLog(5)),
+			"type error: sketch.bloom.merge expected a sketch.bloom (sketch.hyperloglog given)",
+		},
+		{
+			"zero count",
+			call(t, NewCountMin(0.1, 0.9), "add", object.NewString("a"), object.NewInt(0)),
+			"value error: sketch.count_min.add count must be at least 1 (got 0)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
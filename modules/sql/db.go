@@ -5,12 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"sync"
+	"time"
 
-	// TODO: we can add more drivers from this list:
-	//  https://github.com/xo/dburl?tab=readme-ov-file#database-schemes-aliases-and-drivers
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq"
-	_ "github.com/microsoft/go-mssqldb"
 	"github.com/xo/dburl"
 
 	"github.com/risor-io/risor/internal/arg"
@@ -21,9 +17,20 @@ import (
 const DB_CONN object.Type = "db.conn"
 
 type DB struct {
-	conn   *sql.DB
-	once   sync.Once
-	closed chan bool
+	conn    *sql.DB
+	timeout time.Duration
+	once    sync.Once
+	closed  chan bool
+}
+
+// Options configures a database connection.
+type Options struct {
+	// Timeout limits the duration of each statement. Zero means no limit.
+	Timeout time.Duration
+
+	// MaxOpenConns and MaxIdleConns configure the connection pool.
+	MaxOpenConns int
+	MaxIdleConns int
 }
 
 func (db *DB) Type() object.Type {
@@ -69,8 +76,26 @@ func (db *DB) GetAttr(name string) (object.Object, bool) {
 	switch name {
 	case "query":
 		return object.NewBuiltin("sql.query", db.Query), true
+	case "rows":
+		return object.NewBuiltin("sql.rows", db.Rows), true
 	case "exec":
 		return object.NewBuiltin("sql.exec", db.Exec), true
+	case "begin":
+		return object.NewBuiltin("sql.begin", db.Begin), true
+	case "transaction":
+		return object.NewBuiltin("sql.transaction", db.Transaction), true
+	case "ping":
+		return object.NewBuiltin("sql.ping", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sql.ping", 0, args); err != nil {
+				return err
+			}
+			ctx, cancel := withTimeout(ctx, db.timeout)
+			defer cancel()
+			if err := db.conn.PingContext(ctx); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
 	case "close":
 		return object.NewBuiltin("sql.close", func(ctx context.Context, args ...object.Object) object.Object {
 			if err := arg.Require("sql.close", 0, args); err != nil {
@@ -85,85 +110,69 @@ func (db *DB) GetAttr(name string) (object.Object, bool) {
 	return nil, false
 }
 
+// Exec executes a statement and returns a map with the number of rows
+// affected and the last inserted id, when the driver reports them.
 func (db *DB) Exec(ctx context.Context, args ...object.Object) object.Object {
-	numArgs := len(args)
-	if numArgs < 1 {
-		return object.Errorf("type error: sql.exec() requires at least one argument")
-	}
+	return runExec(ctx, db.conn, db.timeout, "sql.exec", args)
+}
 
-	query, errObj := object.AsString(args[0])
-	if errObj != nil {
-		return errObj
-	}
+// Query runs a query and returns all rows as a list of maps.
+func (db *DB) Query(ctx context.Context, args ...object.Object) object.Object {
+	return runQuery(ctx, db.conn, db.timeout, "sql.query", args)
+}
+
+// Rows runs a query and returns a stream of row maps, so that large result
+// sets don't need to be held in memory.
+func (db *DB) Rows(ctx context.Context, args ...object.Object) object.Object {
+	return runRows(ctx, db.conn, db.timeout, "sql.rows", args)
+}
 
-	// Build list of query args as their Go types
-	var queryArgs []interface{}
-	for _, queryArg := range args[1:] {
-		queryArgs = append(queryArgs, queryArg.Interface())
+// Begin starts a transaction. The optional options map may contain
+// "read_only" and "isolation".
+func (db *DB) Begin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("sql.begin", 0, 1, args); err != nil {
+		return err
 	}
-	_, err := db.conn.Exec(query, queryArgs...)
+	var txOpts sql.TxOptions
+	if len(args) == 1 {
+		m, err := object.AsMap(args[0])
+		if err != nil {
+			return err
+		}
+		if err := parseTxOptions(m, &txOpts); err != nil {
+			return err
+		}
+	}
+	tx, err := db.conn.BeginTx(ctx, &txOpts)
 	if err != nil {
 		return object.NewError(err)
 	}
-
-	return nil
+	return newTx(tx, db.timeout)
 }
 
-func (db *DB) Query(ctx context.Context, args ...object.Object) object.Object {
-	numArgs := len(args)
-	if numArgs < 1 {
-		return object.Errorf("type error: sql.query() requires at least one argument")
-	}
-
-	query, errObj := object.AsString(args[0])
-	if errObj != nil {
-		return errObj
-	}
-
-	// Build list of query args as their Go types
-	var queryArgs []interface{}
-	for _, queryArg := range args[1:] {
-		queryArgs = append(queryArgs, queryArg.Interface())
+// Transaction calls the given function with a new transaction. The
+// transaction is committed if the function returns normally and rolled back
+// if it raises an error.
+func (db *DB) Transaction(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("sql.transaction", 1, 2, args); err != nil {
+		return err
 	}
-
-	// Start the query
-	rows, err := db.conn.Query(query, queryArgs...)
-	if err != nil || rows.Err() != nil {
-		return object.Errorf("failed to query db: %w", err)
+	result := db.Begin(ctx, args[1:]...)
+	tx, ok := result.(*Tx)
+	if !ok {
+		return result
 	}
-	defer rows.Close()
-
-	columns, err := rows.Columns()
+	value, err := object.Call(ctx, args[0], []object.Object{tx})
 	if err != nil {
-		return object.Errorf("failed to get columns: %w", err)
-	}
-
-	rowList := object.NewList(make([]object.Object, 0))
-	for rows.Next() {
-		rowValues := make([]interface{}, len(columns))
-		for i := range rowValues {
-			var s interface{}
-			rowValues[i] = &s
-		}
-		if err := rows.Scan(rowValues...); err != nil {
-			return object.NewError(err)
+		if rbErr := tx.tx.Rollback(); rbErr != nil && rbErr != sql.ErrTxDone {
+			return object.Errorf("%s (rollback failed: %s)", err, rbErr)
 		}
-
-		row := object.NewMap(make(map[string]object.Object))
-		for i := range rowValues {
-			val := *(rowValues[i].(*interface{}))
-			switch val := val.(type) {
-			case []byte:
-				row.Set(columns[i], object.NewString(string(val)))
-			default:
-				row.Set(columns[i], object.FromGoType(val))
-			}
-		}
-
-		rowList.Append(row)
+		return object.NewError(err)
 	}
-
-	return rowList
+	if err := tx.tx.Commit(); err != nil && err != sql.ErrTxDone {
+		return object.NewError(err)
+	}
+	return value
 }
 
 func (db *DB) Close() error {
@@ -185,15 +194,22 @@ func (db *DB) waitToClose(ctx context.Context) {
 	}()
 }
 
-func New(ctx context.Context, connection string) (*DB, error) {
+func New(ctx context.Context, connection string, opts Options) (*DB, error) {
 	db, err := dburl.Open(connection)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to db: %w", err)
 	}
+	if opts.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(opts.MaxIdleConns)
+	}
 
 	obj := &DB{
-		conn:   db,
-		closed: make(chan bool),
+		conn:    db,
+		timeout: opts.Timeout,
+		closed:  make(chan bool),
 	}
 	obj.waitToClose(ctx)
 	return obj, nil
@@ -0,0 +1,22 @@
+package sql
+
+import (
+	// TODO: we can add more drivers from this list:
+	//  https://github.com/xo/dburl?tab=readme-ov-file#database-schemes-aliases-and-drivers
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/microsoft/go-mssqldb"
+	"github.com/xo/dburl"
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	// Route SQLite URLs to the pure Go driver registered as "sqlite", so
+	// that cgo is not required.
+	for _, name := range []string{"sqlite3", "moderncsqlite"} {
+		if scheme := dburl.Unregister(name); scheme != nil {
+			scheme.Override = "sqlite"
+			dburl.Register(*scheme)
+		}
+	}
+}
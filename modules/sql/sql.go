@@ -2,6 +2,7 @@ package sql
 
 import (
 	"context"
+	"time"
 
 	"github.com/risor-io/risor/object"
 )
@@ -9,8 +10,8 @@ import (
 func Connect(ctx context.Context, args ...object.Object) object.Object {
 	numArgs := len(args)
 
-	if numArgs < 1 {
-		return object.NewArgsError("sql.connect", 1, numArgs)
+	if numArgs < 1 || numArgs > 2 {
+		return object.NewArgsRangeError("sql.connect", 1, 2, numArgs)
 	}
 
 	connStr, err := object.AsString(args[0])
@@ -18,7 +19,18 @@ func Connect(ctx context.Context, args ...object.Object) object.Object {
 		return err
 	}
 
-	db, connErr := New(ctx, connStr)
+	var opts Options
+	if numArgs == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		if err := parseOptions(m, &opts); err != nil {
+			return err
+		}
+	}
+
+	db, connErr := New(ctx, connStr, opts)
 	if connErr != nil {
 		return object.NewError(connErr)
 	}
@@ -26,6 +38,29 @@ func Connect(ctx context.Context, args ...object.Object) object.Object {
 	return db
 }
 
+func parseOptions(m *object.Map, opts *Options) *object.Error {
+	for key, value := range m.Value() {
+		n, err := object.AsInt(value)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return object.Errorf("value error: %s must be non-negative (got %d)", key, n)
+		}
+		switch key {
+		case "timeout":
+			opts.Timeout = time.Duration(n) * time.Millisecond
+		case "max_open_conns":
+			opts.MaxOpenConns = int(n)
+		case "max_idle_conns":
+			opts.MaxIdleConns = int(n)
+		default:
+			return object.Errorf("value error: unknown sql option %q", key)
+		}
+	}
+	return nil
+}
+
 func Module() *object.Module {
 	return object.NewBuiltinsModule("sql", map[string]object.Object{
 		"connect": object.NewBuiltin("sql.connect", Connect),
@@ -0,0 +1,103 @@
+package sql
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func connect(t *testing.T, ctx context.Context) *DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	result := Connect(ctx, object.NewString("sqlite:"+path),
+		object.NewMap(map[string]object.Object{"timeout": object.NewInt(5000)}))
+	db, ok := result.(*DB)
+	require.True(t, ok, result.Inspect())
+	t.Cleanup(func() { db.Close() })
+	result = db.Exec(ctx, object.NewString("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)"))
+	require.False(t, object.IsError(result), result.Inspect())
+	return db
+}
+
+func TestExecAndQuery(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t, ctx)
+
+	result := db.Exec(ctx, object.NewString("INSERT INTO items (name) VALUES (?), (?)"),
+		object.NewString("a"), object.NewString("b"))
+	require.Equal(t, object.NewMap(map[string]object.Object{
+		"rows_affected":  object.NewInt(2),
+		"last_insert_id": object.NewInt(2),
+	}), result)
+
+	result = db.Query(ctx, object.NewString("SELECT id, name FROM items WHERE id > ? ORDER BY id"), object.NewInt(1))
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{"id": object.NewInt(2), "name": object.NewString("b")}),
+	}), result)
+
+	result = db.Query(ctx, object.NewString("SELECT * FROM missing"))
+	require.True(t, object.IsError(result))
+}
+
+func TestRows(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t, ctx)
+	for _, name := range []string{"a", "b", "c"} {
+		db.Exec(ctx, object.NewString("INSERT INTO items (name) VALUES (?)"), object.NewString(name))
+	}
+	result := db.Rows(ctx, object.NewString("SELECT name FROM items ORDER BY id"))
+	stream, ok := result.(*object.Stream)
+	require.True(t, ok, result.Inspect())
+	var names []string
+	for {
+		row, ok := stream.Next(ctx)
+		if !ok {
+			break
+		}
+		names = append(names, row.(*object.Map).Get("name").(*object.String).Value())
+	}
+	require.Nil(t, stream.Err())
+	require.Equal(t, []string{"a", "b", "c"}, names)
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := connect(t, ctx)
+	count := func() object.Object {
+		rows := db.Query(ctx, object.NewString("SELECT count(*) AS n FROM items")).(*object.List)
+		return rows.Value()[0].(*object.Map).Get("n")
+	}
+
+	// Rolled back explicitly
+	tx := db.Begin(ctx).(*Tx)
+	exec, _ := tx.GetAttr("exec")
+	exec.(*object.Builtin).Call(ctx, object.NewString("INSERT INTO items (name) VALUES ('x')"))
+	rollback, _ := tx.GetAttr("rollback")
+	require.Equal(t, object.Nil, rollback.(*object.Builtin).Call(ctx))
+	require.Equal(t, object.NewInt(0), count())
+
+	// Committed when the function succeeds
+	insert := object.NewBuiltin("insert", func(ctx context.Context, args ...object.Object) object.Object {
+		exec, _ := args[0].GetAttr("exec")
+		return exec.(*object.Builtin).Call(ctx, object.NewString("INSERT INTO items (name) VALUES ('y')"))
+	})
+	result := db.Transaction(ctx, insert)
+	require.False(t, object.IsError(result), result.Inspect())
+	require.Equal(t, object.NewInt(1), count())
+
+	// Rolled back when the function fails
+	fail := object.NewBuiltin("fail", func(ctx context.Context, args ...object.Object) object.Object {
+		exec, _ := args[0].GetAttr("exec")
+		exec.(*object.Builtin).Call(ctx, object.NewString("INSERT INTO items (name) VALUES ('z')"))
+		return object.Errorf("boom")
+	})
+	result = db.Transaction(ctx, fail)
+	require.True(t, object.IsError(result))
+	require.Equal(t, object.NewInt(1), count())
+
+	result = db.Begin(ctx, object.NewMap(map[string]object.Object{"isolation": object.NewString("bogus")}))
+	require.True(t, object.IsError(result))
+}
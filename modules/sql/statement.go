@@ -0,0 +1,157 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/risor-io/risor/object"
+)
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// parseStatement returns the query string and its parameters as Go values.
+func parseStatement(name string, args []object.Object) (string, []any, *object.Error) {
+	if len(args) < 1 {
+		return "", nil, object.Errorf("type error: %s() requires at least one argument", name)
+	}
+	query, err := object.AsString(args[0])
+	if err != nil {
+		return "", nil, err
+	}
+	queryArgs := make([]any, 0, len(args)-1)
+	for _, queryArg := range args[1:] {
+		queryArgs = append(queryArgs, queryArg.Interface())
+	}
+	return query, queryArgs, nil
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// runQuery runs a query and returns all rows as a list of maps.
+func runQuery(ctx context.Context, q queryer, timeout time.Duration, name string, args []object.Object) object.Object {
+	query, queryArgs, errObj := parseStatement(name, args)
+	if errObj != nil {
+		return errObj
+	}
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+
+	rows, err := q.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return object.Errorf("failed to query db: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return object.Errorf("failed to get columns: %w", err)
+	}
+
+	rowList := object.NewList(make([]object.Object, 0))
+	for rows.Next() {
+		row, err := scanRow(rows, columns)
+		if err != nil {
+			return object.NewError(err)
+		}
+		rowList.Append(row)
+	}
+	if err := rows.Err(); err != nil {
+		return object.NewError(err)
+	}
+	return rowList
+}
+
+// runRows runs a query and returns a stream of row maps. Rows are read from
+// the database as the stream is consumed. The timeout, if any, applies to
+// consuming the whole stream.
+func runRows(ctx context.Context, q queryer, timeout time.Duration, name string, args []object.Object) object.Object {
+	query, queryArgs, errObj := parseStatement(name, args)
+	if errObj != nil {
+		return errObj
+	}
+	ctx, cancel := withTimeout(ctx, timeout)
+
+	rows, err := q.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		cancel()
+		return object.Errorf("failed to query db: %w", err)
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		cancel()
+		return object.Errorf("failed to get columns: %w", err)
+	}
+	return object.NewStream(func(context.Context) (object.Object, bool, error) {
+		if !rows.Next() {
+			err := rows.Err()
+			rows.Close()
+			cancel()
+			return nil, false, err
+		}
+		row, err := scanRow(rows, columns)
+		if err != nil {
+			rows.Close()
+			cancel()
+			return nil, false, err
+		}
+		return row, true, nil
+	})
+}
+
+// runExec executes a statement and returns a map describing the result.
+func runExec(ctx context.Context, q queryer, timeout time.Duration, name string, args []object.Object) object.Object {
+	query, queryArgs, errObj := parseStatement(name, args)
+	if errObj != nil {
+		return errObj
+	}
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := q.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return object.NewError(err)
+	}
+	summary := map[string]object.Object{}
+	// Not all drivers support these, so they are only included when known
+	if n, err := result.RowsAffected(); err == nil {
+		summary["rows_affected"] = object.NewInt(n)
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		summary["last_insert_id"] = object.NewInt(id)
+	}
+	return object.NewMap(summary)
+}
+
+func scanRow(rows *sql.Rows, columns []string) (*object.Map, error) {
+	rowValues := make([]interface{}, len(columns))
+	for i := range rowValues {
+		var s interface{}
+		rowValues[i] = &s
+	}
+	if err := rows.Scan(rowValues...); err != nil {
+		return nil, err
+	}
+
+	row := object.NewMap(make(map[string]object.Object))
+	for i := range rowValues {
+		val := *(rowValues[i].(*interface{}))
+		switch val := val.(type) {
+		case []byte:
+			row.Set(columns[i], object.NewString(string(val)))
+		default:
+			row.Set(columns[i], object.FromGoType(val))
+		}
+	}
+	return row, nil
+}
@@ -0,0 +1,140 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const DB_TX object.Type = "sql.tx"
+
+// Tx is a database transaction. It supports the same query methods as a
+// connection, plus commit and rollback.
+type Tx struct {
+	tx      *sql.Tx
+	timeout time.Duration
+}
+
+func (tx *Tx) Type() object.Type {
+	return DB_TX
+}
+
+func (tx *Tx) Inspect() string {
+	return "sql.tx"
+}
+
+func (tx *Tx) Interface() interface{} {
+	return tx.tx
+}
+
+func (tx *Tx) IsTruthy() bool {
+	return true
+}
+
+func (tx *Tx) Cost() int {
+	return 8
+}
+
+func (tx *Tx) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", DB_TX)
+}
+
+func (tx *Tx) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", DB_TX, opType)
+}
+
+func (tx *Tx) Equals(other object.Object) object.Object {
+	if tx == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (tx *Tx) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", DB_TX, name)
+}
+
+func (tx *Tx) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "query":
+		return object.NewBuiltin("sql.tx.query", func(ctx context.Context, args ...object.Object) object.Object {
+			return runQuery(ctx, tx.tx, tx.timeout, "sql.tx.query", args)
+		}), true
+	case "rows":
+		return object.NewBuiltin("sql.tx.rows", func(ctx context.Context, args ...object.Object) object.Object {
+			return runRows(ctx, tx.tx, tx.timeout, "sql.tx.rows", args)
+		}), true
+	case "exec":
+		return object.NewBuiltin("sql.tx.exec", func(ctx context.Context, args ...object.Object) object.Object {
+			return runExec(ctx, tx.tx, tx.timeout, "sql.tx.exec", args)
+		}), true
+	case "commit":
+		return object.NewBuiltin("sql.tx.commit", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sql.tx.commit", 0, args); err != nil {
+				return err
+			}
+			if err := tx.tx.Commit(); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	case "rollback":
+		return object.NewBuiltin("sql.tx.rollback", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("sql.tx.rollback", 0, args); err != nil {
+				return err
+			}
+			if err := tx.tx.Rollback(); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+func newTx(tx *sql.Tx, timeout time.Duration) *Tx {
+	return &Tx{tx: tx, timeout: timeout}
+}
+
+var isolationLevels = map[string]sql.IsolationLevel{
+	"default":          sql.LevelDefault,
+	"read_uncommitted": sql.LevelReadUncommitted,
+	"read_committed":   sql.LevelReadCommitted,
+	"write_committed":  sql.LevelWriteCommitted,
+	"repeatable_read":  sql.LevelRepeatableRead,
+	"snapshot":         sql.LevelSnapshot,
+	"serializable":     sql.LevelSerializable,
+	"linearizable":     sql.LevelLinearizable,
+}
+
+func parseTxOptions(m *object.Map, opts *sql.TxOptions) *object.Error {
+	for key, value := range m.Value() {
+		switch key {
+		case "read_only":
+			readOnly, err := object.AsBool(value)
+			if err != nil {
+				return err
+			}
+			opts.ReadOnly = readOnly
+		case "isolation":
+			name, err := object.AsString(value)
+			if err != nil {
+				return err
+			}
+			level, ok := isolationLevels[strings.ToLower(name)]
+			if !ok {
+				return object.Errorf("value error: unknown isolation level %q", name)
+			}
+			opts.Isolation = level
+		default:
+			return object.Errorf("value error: unknown transaction option %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"golang.org/x/crypto/ssh"
+)
+
+const CLIENT object.Type = "ssh.client"
+
+// Client is a connection to an SSH server.
+type Client struct {
+	address    string
+	user       string
+	client     *ssh.Client
+	once       sync.Once
+	closed     chan bool
+	closeAgent func()
+}
+
+func (c *Client) Type() object.Type {
+	return CLIENT
+}
+
+func (c *Client) Inspect() string {
+	return fmt.Sprintf("ssh.client(%q)", c.user+"@"+c.address)
+}
+
+func (c *Client) Interface() interface{} {
+	return c.client
+}
+
+func (c *Client) IsTruthy() bool {
+	return true
+}
+
+func (c *Client) Cost() int {
+	return 8
+}
+
+func (c *Client) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", CLIENT)
+}
+
+func (c *Client) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", CLIENT, opType)
+}
+
+func (c *Client) Equals(other object.Object) object.Object {
+	if c == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (c *Client) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", CLIENT, name)
+}
+
+func (c *Client) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "address":
+		return object.NewString(c.address), true
+	case "user":
+		return object.NewString(c.user), true
+	case "run":
+		return object.NewBuiltin("ssh.client.run", c.Run), true
+	case "stream":
+		return object.NewBuiltin("ssh.client.stream", c.Stream), true
+	case "sftp":
+		return object.NewBuiltin("ssh.client.sftp", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("ssh.client.sftp", 0, args); err != nil {
+				return err
+			}
+			client, err := sftp.NewClient(c.client)
+			if err != nil {
+				return object.NewError(err)
+			}
+			return NewSFTP(client)
+		}), true
+	case "close":
+		return object.NewBuiltin("ssh.client.close", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("ssh.client.close", 0, args); err != nil {
+				return err
+			}
+			c.Close()
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// Run runs a command on the server and waits for it to complete. Returns a
+// map with the stdout, stderr, and exit code of the command. A non-zero exit
+// code is not treated as an error.
+func (c *Client) Run(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("ssh.client.run", 1, 2, args); err != nil {
+		return err
+	}
+	session, command, errObj := c.newSession("ssh.client.run", args)
+	if errObj != nil {
+		return errObj
+	}
+	defer session.Close()
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	exitCode, err := c.wait(ctx, session, func() error { return session.Run(command) })
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewMap(map[string]object.Object{
+		"stdout":    object.NewByteSlice(stdout.Bytes()),
+		"stderr":    object.NewByteSlice(stderr.Bytes()),
+		"exit_code": object.NewInt(int64(exitCode)),
+	})
+}
+
+// Stream runs a command on the server and returns a stream of its output
+// lines as they are produced. Each item is a map with the "stream" the line
+// was written to, either "stdout" or "stderr", and the "line" itself. The
+// stream ends with an error if the command exits with a non-zero code.
+func (c *Client) Stream(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("ssh.client.stream", 1, 2, args); err != nil {
+		return err
+	}
+	session, command, errObj := c.newSession("ssh.client.stream", args)
+	if errObj != nil {
+		return errObj
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return object.NewError(err)
+	}
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		session.Close()
+		return object.NewError(err)
+	}
+	if err := session.Start(command); err != nil {
+		session.Close()
+		return object.NewError(err)
+	}
+	lines := make(chan *object.Map)
+	done := make(chan bool)
+	var wg sync.WaitGroup
+	scan := func(name string, r io.Reader) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := object.NewMap(map[string]object.Object{
+				"stream": object.NewString(name),
+				"line":   object.NewString(scanner.Text()),
+			})
+			select {
+			case lines <- line:
+			case <-done:
+				return
+			}
+		}
+	}
+	wg.Add(2)
+	go scan("stdout", stdout)
+	go scan("stderr", stderr)
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+	finished := false
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		if finished {
+			return nil, false, nil
+		}
+		select {
+		case line, ok := <-lines:
+			if ok {
+				return line, true, nil
+			}
+		case <-ctx.Done():
+			finished = true
+			close(done)
+			session.Close()
+			return nil, false, ctx.Err()
+		}
+		finished = true
+		defer session.Close()
+		exitCode, err := c.wait(ctx, session, session.Wait)
+		if err != nil {
+			return nil, false, err
+		}
+		if exitCode != 0 {
+			return nil, false, fmt.Errorf("ssh error: command exited with code %d", exitCode)
+		}
+		return nil, false, nil
+	})
+}
+
+// newSession opens a session for the command given in args, applying the
+// optional stdin given in the options map.
+func (c *Client) newSession(name string, args []object.Object) (*ssh.Session, string, *object.Error) {
+	command, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return nil, "", errObj
+	}
+	var stdin io.Reader
+	if len(args) == 2 {
+		m, errObj := object.AsMap(args[1])
+		if errObj != nil {
+			return nil, "", errObj
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "stdin":
+				if stdin, errObj = object.AsReader(value); errObj != nil {
+					return nil, "", errObj
+				}
+			default:
+				return nil, "", object.Errorf("value error: unknown %s option %q", name, key)
+			}
+		}
+	}
+	session, err := c.client.NewSession()
+	if err != nil {
+		return nil, "", object.NewError(err)
+	}
+	session.Stdin = stdin
+	return session, command, nil
+}
+
+// wait calls fn, which waits for the session's command to complete, and
+// returns the exit code of the command. The session is closed if the context
+// is cancelled first.
+func (c *Client) wait(ctx context.Context, session *ssh.Session, fn func() error) (int, error) {
+	result := make(chan error, 1)
+	go func() { result <- fn() }()
+	var err error
+	select {
+	case err = <-result:
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		return 0, ctx.Err()
+	}
+	if err == nil {
+		return 0, nil
+	}
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), nil
+	}
+	return 0, err
+}
+
+// Close closes the connection to the server.
+func (c *Client) Close() {
+	c.once.Do(func() {
+		close(c.closed)
+		c.client.Close()
+		c.closeAgent()
+	})
+}
+
+func (c *Client) waitToClose(ctx context.Context) {
+	go func() {
+		select {
+		case <-c.closed:
+		case <-ctx.Done():
+			c.Close()
+		}
+	}()
+}
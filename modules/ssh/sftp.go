@@ -0,0 +1,309 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+)
+
+const SFTP_CLIENT object.Type = "ssh.sftp"
+
+// SFTP is an SFTP session on an SSH connection.
+type SFTP struct {
+	client *sftp.Client
+	once   sync.Once
+}
+
+func NewSFTP(client *sftp.Client) *SFTP {
+	return &SFTP{client: client}
+}
+
+func (s *SFTP) Type() object.Type {
+	return SFTP_CLIENT
+}
+
+func (s *SFTP) Inspect() string {
+	return "ssh.sftp()"
+}
+
+func (s *SFTP) Interface() interface{} {
+	return s.client
+}
+
+func (s *SFTP) IsTruthy() bool {
+	return true
+}
+
+func (s *SFTP) Cost() int {
+	return 8
+}
+
+func (s *SFTP) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", SFTP_CLIENT)
+}
+
+func (s *SFTP) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", SFTP_CLIENT, opType)
+}
+
+func (s *SFTP) Equals(other object.Object) object.Object {
+	if s == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (s *SFTP) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", SFTP_CLIENT, name)
+}
+
+func (s *SFTP) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "read_file":
+		return s.pathMethod(name, func(path string) (object.Object, error) {
+			f, err := s.client.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			data, err := io.ReadAll(f)
+			if err != nil {
+				return nil, err
+			}
+			return object.NewByteSlice(data), nil
+		}), true
+	case "write_file":
+		return object.NewBuiltin("ssh.sftp.write_file", s.WriteFile), true
+	case "upload":
+		return object.NewBuiltin("ssh.sftp.upload", s.Upload), true
+	case "download":
+		return object.NewBuiltin("ssh.sftp.download", s.Download), true
+	case "list":
+		return s.pathMethod(name, func(path string) (object.Object, error) {
+			entries, err := s.client.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+			items := make([]object.Object, len(entries))
+			for i, entry := range entries {
+				items[i] = fileInfoToMap(entry)
+			}
+			return object.NewList(items), nil
+		}), true
+	case "stat":
+		return s.pathMethod(name, func(path string) (object.Object, error) {
+			info, err := s.client.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			return fileInfoToMap(info), nil
+		}), true
+	case "exists":
+		return s.pathMethod(name, func(path string) (object.Object, error) {
+			_, err := s.client.Stat(path)
+			if err == nil {
+				return object.True, nil
+			}
+			if os.IsNotExist(err) {
+				return object.False, nil
+			}
+			return nil, err
+		}), true
+	case "mkdir":
+		return s.pathMethod(name, func(path string) (object.Object, error) {
+			return object.Nil, s.client.MkdirAll(path)
+		}), true
+	case "remove":
+		return s.pathMethod(name, func(path string) (object.Object, error) {
+			return object.Nil, s.client.Remove(path)
+		}), true
+	case "rename":
+		return object.NewBuiltin("ssh.sftp.rename", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("ssh.sftp.rename", 2, args); err != nil {
+				return err
+			}
+			oldPath, errObj := object.AsString(args[0])
+			if errObj != nil {
+				return errObj
+			}
+			newPath, errObj := object.AsString(args[1])
+			if errObj != nil {
+				return errObj
+			}
+			if err := s.client.PosixRename(oldPath, newPath); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	case "close":
+		return object.NewBuiltin("ssh.sftp.close", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("ssh.sftp.close", 0, args); err != nil {
+				return err
+			}
+			if err := s.Close(); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// pathMethod returns a builtin that takes a single remote path argument.
+func (s *SFTP) pathMethod(name string, fn func(path string) (object.Object, error)) *object.Builtin {
+	fullName := "ssh.sftp." + name
+	return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+		if err := arg.Require(fullName, 1, args); err != nil {
+			return err
+		}
+		path, errObj := object.AsString(args[0])
+		if errObj != nil {
+			return errObj
+		}
+		result, err := fn(path)
+		if err != nil {
+			return object.NewError(err)
+		}
+		return result
+	})
+}
+
+// WriteFile writes data to a remote file, creating or truncating it. The
+// optional third argument sets the file mode of the file.
+func (s *SFTP) WriteFile(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("ssh.sftp.write_file", 2, 3, args); err != nil {
+		return err
+	}
+	path, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	data, errObj := object.AsReader(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	mode := fs.FileMode(0o644)
+	if len(args) == 3 {
+		perm, errObj := object.AsInt(args[2])
+		if errObj != nil {
+			return errObj
+		}
+		mode = fs.FileMode(perm)
+	}
+	if err := s.writeFile(path, data, mode); err != nil {
+		return object.NewError(err)
+	}
+	return object.Nil
+}
+
+// Upload copies a local file to the server. Returns the number of bytes
+// copied.
+func (s *SFTP) Upload(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("ssh.sftp.upload", 2, args); err != nil {
+		return err
+	}
+	local, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	remote, errObj := object.AsString(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	vos := ros.GetDefaultOS(ctx)
+	src, err := vos.Open(local)
+	if err != nil {
+		return object.NewError(err)
+	}
+	defer src.Close()
+	mode := fs.FileMode(0o644)
+	if info, err := src.Stat(); err == nil {
+		mode = info.Mode().Perm()
+	}
+	dst, err := s.client.OpenFile(remote, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return object.NewError(err)
+	}
+	defer dst.Close()
+	n, err := dst.ReadFrom(src)
+	if err != nil {
+		return object.NewError(err)
+	}
+	if err := dst.Chmod(mode); err != nil {
+		return object.NewError(err)
+	}
+	return object.NewInt(n)
+}
+
+// Download copies a remote file to the local filesystem. Returns the number
+// of bytes copied.
+func (s *SFTP) Download(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("ssh.sftp.download", 2, args); err != nil {
+		return err
+	}
+	remote, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	local, errObj := object.AsString(args[1])
+	if errObj != nil {
+		return errObj
+	}
+	src, err := s.client.Open(remote)
+	if err != nil {
+		return object.NewError(err)
+	}
+	defer src.Close()
+	dst, err := ros.GetDefaultOS(ctx).Create(local)
+	if err != nil {
+		return object.NewError(err)
+	}
+	defer dst.Close()
+	n, err := src.WriteTo(dst)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewInt(n)
+}
+
+func (s *SFTP) writeFile(path string, r io.Reader, mode fs.FileMode) error {
+	f, err := s.client.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return err
+	}
+	if _, err := f.ReadFrom(r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Close ends the SFTP session. The SSH connection remains open.
+func (s *SFTP) Close() error {
+	var err error
+	s.once.Do(func() { err = s.client.Close() })
+	return err
+}
+
+func fileInfoToMap(info fs.FileInfo) *object.Map {
+	return object.NewMap(map[string]object.Object{
+		"name":     object.NewString(info.Name()),
+		"size":     object.NewInt(info.Size()),
+		"mode":     object.NewInt(int64(info.Mode().Perm())),
+		"mod_time": object.NewTime(info.ModTime()),
+		"is_dir":   object.NewBool(info.IsDir()),
+	})
+}
@@ -0,0 +1,241 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Options configures a connection to an SSH server.
+type Options struct {
+	User                  string
+	Password              string
+	Key                   []byte
+	KeyFile               string
+	Passphrase            string
+	Agent                 bool
+	KnownHosts            string
+	HostKey               string
+	InsecureIgnoreHostKey bool
+	Timeout               time.Duration
+}
+
+func Connect(ctx context.Context, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 2 {
+		return object.NewArgsRangeError("ssh.connect", 1, 2, len(args))
+	}
+	address, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	opts := Options{Timeout: defaultTimeout}
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		if err := parseOptions(m, &opts); err != nil {
+			return err
+		}
+	}
+	client, connErr := NewClient(ctx, address, opts)
+	if connErr != nil {
+		return object.NewError(connErr)
+	}
+	return client
+}
+
+// NewClient connects to the SSH server at the given address. The address
+// may include the user, as in "user@host:port", and the port defaults to 22.
+func NewClient(ctx context.Context, address string, opts Options) (*Client, error) {
+	if user, host, ok := strings.Cut(address, "@"); ok {
+		if opts.User == "" {
+			opts.User = user
+		}
+		address = host
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "22")
+	}
+	if opts.User == "" {
+		opts.User = os.Getenv("USER")
+	}
+	auth, closeAgent, err := opts.authMethods()
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := opts.hostKeyCallback()
+	if err != nil {
+		closeAgent()
+		return nil, err
+	}
+	config := &ssh.ClientConfig{
+		User:            opts.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         opts.Timeout,
+	}
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		closeAgent()
+		return nil, err
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		closeAgent()
+		return nil, err
+	}
+	client := &Client{
+		address:    address,
+		user:       opts.User,
+		client:     ssh.NewClient(sshConn, chans, reqs),
+		closed:     make(chan bool),
+		closeAgent: closeAgent,
+	}
+	client.waitToClose(ctx)
+	return client, nil
+}
+
+// authMethods returns the configured authentication methods, along with a
+// function that closes the connection to the SSH agent, if one was opened.
+// The agent is used when requested, or when no other method is configured.
+func (o Options) authMethods() ([]ssh.AuthMethod, func(), error) {
+	var methods []ssh.AuthMethod
+	closeAgent := func() {}
+	key := o.Key
+	if o.KeyFile != "" {
+		data, err := os.ReadFile(expandHome(o.KeyFile))
+		if err != nil {
+			return nil, closeAgent, err
+		}
+		key = data
+	}
+	if len(key) > 0 {
+		var signer ssh.Signer
+		var err error
+		if o.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(o.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, closeAgent, fmt.Errorf("ssh error: invalid private key: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if o.Agent || (len(methods) == 0 && o.Password == "") {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			if o.Agent {
+				return nil, closeAgent, errors.New("ssh error: SSH_AUTH_SOCK is not set")
+			}
+			return nil, closeAgent, errors.New("ssh error: no authentication method configured")
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, closeAgent, fmt.Errorf("ssh error: unable to connect to agent: %w", err)
+		}
+		closeAgent = func() { conn.Close() }
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+	if o.Password != "" {
+		password := o.Password
+		methods = append(methods, ssh.Password(password),
+			ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}))
+	}
+	return methods, closeAgent, nil
+}
+
+// hostKeyCallback verifies the server's host key against the configured
+// key, the given known_hosts file, or ~/.ssh/known_hosts by default.
+func (o Options) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if o.InsecureIgnoreHostKey {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	if o.HostKey != "" {
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(o.HostKey))
+		if err != nil {
+			return nil, fmt.Errorf("ssh error: invalid host key: %w", err)
+		}
+		return ssh.FixedHostKey(key), nil
+	}
+	path := o.KnownHosts
+	if path == "" {
+		path = "~/.ssh/known_hosts"
+	}
+	callback, err := knownhosts.New(expandHome(path))
+	if err != nil {
+		return nil, fmt.Errorf("ssh error: unable to load known hosts: %w", err)
+	}
+	return callback, nil
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+func parseOptions(m *object.Map, opts *Options) *object.Error {
+	for key, value := range m.Value() {
+		var err *object.Error
+		switch key {
+		case "user":
+			opts.User, err = object.AsString(value)
+		case "password":
+			opts.Password, err = object.AsString(value)
+		case "key":
+			opts.Key, err = object.AsBytes(value)
+		case "key_file":
+			opts.KeyFile, err = object.AsString(value)
+		case "passphrase":
+			opts.Passphrase, err = object.AsString(value)
+		case "agent":
+			opts.Agent, err = object.AsBool(value)
+		case "known_hosts":
+			opts.KnownHosts, err = object.AsString(value)
+		case "host_key":
+			opts.HostKey, err = object.AsString(value)
+		case "insecure_ignore_host_key":
+			opts.InsecureIgnoreHostKey, err = object.AsBool(value)
+		case "timeout":
+			opts.Timeout, err = arg.Duration(key, value)
+		default:
+			err = object.Errorf("value error: unknown ssh option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("ssh", map[string]object.Object{
+		"connect": object.NewBuiltin("ssh.connect", Connect),
+	})
+}
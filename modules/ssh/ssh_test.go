@@ -0,0 +1,265 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+type testServer struct {
+	address string
+	hostKey string
+	userKey []byte
+}
+
+func newSigner(t *testing.T) (ssh.Signer, []byte) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	block, err := ssh.MarshalPrivateKey(key, "")
+	require.Nil(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.Nil(t, err)
+	return signer, pem.EncodeToMemory(block)
+}
+
+// startServer starts an SSH server that runs commands with sh and serves
+// SFTP from the local filesystem.
+func startServer(t *testing.T) *testServer {
+	t.Helper()
+	hostSigner, _ := newSigner(t)
+	userSigner, userKey := newSigner(t)
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "alice" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == string(userSigner.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+	}
+	config.AddHostKey(hostSigner)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+	return &testServer{
+		address: listener.Addr().String(),
+		hostKey: string(ssh.MarshalAuthorizedKey(hostSigner.PublicKey())),
+		userKey: userKey,
+	}
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go serveSession(channel, requests)
+	}
+}
+
+func serveSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	for req := range requests {
+		switch req.Type {
+		case "exec":
+			length := binary.BigEndian.Uint32(req.Payload)
+			command := string(req.Payload[4 : 4+length])
+			req.Reply(true, nil)
+			cmd := exec.Command("sh", "-c", command)
+			cmd.Stdin = channel
+			cmd.Stdout = channel
+			cmd.Stderr = channel.Stderr()
+			var status uint32
+			if err := cmd.Run(); err != nil {
+				var exitErr *exec.ExitError
+				if !errors.As(err, &exitErr) {
+					return
+				}
+				status = uint32(exitErr.ExitCode())
+			}
+			channel.CloseWrite()
+			channel.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, status))
+			return
+		case "subsystem":
+			req.Reply(true, nil)
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+			server.Serve()
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func connect(t *testing.T, ctx context.Context, server *testServer, opts map[string]object.Object) *Client {
+	t.Helper()
+	if opts == nil {
+		opts = map[string]object.Object{}
+	}
+	opts["host_key"] = object.NewString(server.hostKey)
+	result := Connect(ctx, object.NewString("alice@"+server.address), object.NewMap(opts))
+	client, ok := result.(*Client)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	t.Cleanup(client.Close)
+	return client
+}
+
+func call(ctx context.Context, obj object.Object, name string, args ...object.Object) object.Object {
+	method, ok := obj.GetAttr(name)
+	if !ok {
+		panic("missing method: " + name)
+	}
+	return method.(*object.Builtin).Call(ctx, args...)
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	server := startServer(t)
+	client := connect(t, ctx, server, map[string]object.Object{
+		"password": object.NewString("secret"),
+	})
+	user, _ := client.GetAttr("user")
+	require.Equal(t, object.NewString("alice"), user)
+
+	result := call(ctx, client, "run", object.NewString("echo hello; echo oops >&2; exit 3"))
+	m, ok := result.(*object.Map)
+	require.True(t, ok, "unexpected result: %s", result.Inspect())
+	require.Equal(t, object.NewByteSlice([]byte("hello\n")), m.Get("stdout"))
+	require.Equal(t, object.NewByteSlice([]byte("oops\n")), m.Get("stderr"))
+	require.Equal(t, object.NewInt(3), m.Get("exit_code"))
+
+	result = call(ctx, client, "run", object.NewString("tr a-z A-Z"), object.NewMap(map[string]object.Object{
+		"stdin": object.NewString("shout"),
+	}))
+	require.Equal(t, object.NewByteSlice([]byte("SHOUT")), result.(*object.Map).Get("stdout"))
+	require.Equal(t, object.NewInt(0), result.(*object.Map).Get("exit_code"))
+}
+
+func TestStream(t *testing.T) {
+	ctx := context.Background()
+	server := startServer(t)
+	client := connect(t, ctx, server, map[string]object.Object{
+		"key": object.NewByteSlice(server.userKey),
+	})
+
+	stream := call(ctx, client, "stream", object.NewString("echo one; echo two"))
+	items, ok := stream.(*object.Stream).Collect(ctx).(*object.List)
+	require.True(t, ok)
+	require.Equal(t, []object.Object{
+		object.NewMap(map[string]object.Object{"stream": object.NewString("stdout"), "line": object.NewString("one")}),
+		object.NewMap(map[string]object.Object{"stream": object.NewString("stdout"), "line": object.NewString("two")}),
+	}, items.Value())
+
+	stream = call(ctx, client, "stream", object.NewString("echo bad >&2; exit 1"))
+	result := stream.(*object.Stream).Collect(ctx)
+	require.True(t, object.IsError(result))
+	require.Equal(t, "ssh error: command exited with code 1", result.(*object.Error).Message().Value())
+}
+
+func TestSFTP(t *testing.T) {
+	ctx := context.Background()
+	server := startServer(t)
+	client := connect(t, ctx, server, map[string]object.Object{
+		"password": object.NewString("secret"),
+	})
+	fs, ok := call(ctx, client, "sftp").(*SFTP)
+	require.True(t, ok)
+	defer fs.Close()
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote", "data.txt")
+	require.Equal(t, object.Nil, call(ctx, fs, "mkdir", object.NewString(filepath.Dir(remote))))
+	require.Equal(t, object.Nil, call(ctx, fs, "write_file", object.NewString(remote), object.NewString("payload"), object.NewInt(0o600)))
+	require.Equal(t, object.NewByteSlice([]byte("payload")), call(ctx, fs, "read_file", object.NewString(remote)))
+	require.Equal(t, object.True, call(ctx, fs, "exists", object.NewString(remote)))
+
+	stat := call(ctx, fs, "stat", object.NewString(remote)).(*object.Map)
+	require.Equal(t, object.NewString("data.txt"), stat.Get("name"))
+	require.Equal(t, object.NewInt(7), stat.Get("size"))
+	require.Equal(t, object.NewInt(0o600), stat.Get("mode"))
+	require.Equal(t, object.False, stat.Get("is_dir"))
+
+	local := filepath.Join(dir, "local.txt")
+	require.Equal(t, object.NewInt(7), call(ctx, fs, "download", object.NewString(remote), object.NewString(local)))
+	copied := filepath.Join(dir, "remote", "copy.txt")
+	require.Equal(t, object.NewInt(7), call(ctx, fs, "upload", object.NewString(local), object.NewString(copied)))
+
+	list := call(ctx, fs, "list", object.NewString(filepath.Dir(remote))).(*object.List)
+	var names []string
+	for _, item := range list.Value() {
+		names = append(names, item.(*object.Map).Get("name").(*object.String).Value())
+	}
+	require.ElementsMatch(t, []string{"data.txt", "copy.txt"}, names)
+
+	renamed := filepath.Join(dir, "remote", "moved.txt")
+	require.Equal(t, object.Nil, call(ctx, fs, "rename", object.NewString(copied), object.NewString(renamed)))
+	require.Equal(t, object.Nil, call(ctx, fs, "remove", object.NewString(renamed)))
+	require.Equal(t, object.False, call(ctx, fs, "exists", object.NewString(renamed)))
+}
+
+func TestConnectErrors(t *testing.T) {
+	ctx := context.Background()
+	server := startServer(t)
+
+	result := Connect(ctx, object.NewString("alice@"+server.address), object.NewMap(map[string]object.Object{
+		"password": object.NewString("wrong"),
+		"host_key": object.NewString(server.hostKey),
+	}))
+	require.True(t, object.IsError(result))
+	require.Contains(t, result.(*object.Error).Message().Value(), "unable to authenticate")
+
+	other, _ := newSigner(t)
+	result = Connect(ctx, object.NewString("alice@"+server.address), object.NewMap(map[string]object.Object{
+		"password": object.NewString("secret"),
+		"host_key": object.NewString(string(ssh.MarshalAuthorizedKey(other.PublicKey()))),
+	}))
+	require.True(t, object.IsError(result))
+	require.True(t, strings.Contains(result.(*object.Error).Message().Value(), "host key mismatch"))
+
+	result = Connect(ctx, object.NewString(server.address), object.NewMap(map[string]object.Object{
+		"color": object.NewString("red"),
+	}))
+	require.Equal(t, `value error: unknown ssh option "color"`, result.(*object.Error).Message().Value())
+}
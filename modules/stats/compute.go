@@ -0,0 +1,153 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Mean returns the arithmetic mean of the values, which must not be empty.
+func Mean(values []float64) float64 {
+	// Kahan summation keeps the error from growing with the number of values
+	var sum, c float64
+	for _, v := range values {
+		y := v - c
+		t := sum + y
+		c = (t - sum) - y
+		sum = t
+	}
+	return sum / float64(len(values))
+}
+
+// Variance returns the variance of the values. The sample variance divides
+// by one less than the number of values, and needs at least two of them;
+// the population variance divides by the number of values.
+func Variance(values []float64, population bool) float64 {
+	mean := Mean(values)
+	var ss float64
+	for _, v := range values {
+		d := v - mean
+		ss += d * d
+	}
+	if population {
+		return ss / float64(len(values))
+	}
+	return ss / float64(len(values)-1)
+}
+
+// sorted returns a sorted copy of the values.
+func sorted(values []float64) []float64 {
+	s := make([]float64, len(values))
+	copy(s, values)
+	sort.Float64s(s)
+	return s
+}
+
+// Percentile returns the p-th percentile, between 0 and 100, of sorted
+// values, interpolating linearly between the closest ranks. This matches
+// the default method of NumPy and spreadsheet PERCENTILE functions.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := math.Floor(rank)
+	i := int(lo)
+	if i >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[i] + (rank-lo)*(sorted[i+1]-sorted[i])
+}
+
+// Covariance returns the covariance of two equally long lists of values.
+func Covariance(xs, ys []float64, population bool) float64 {
+	mx, my := Mean(xs), Mean(ys)
+	var s float64
+	for i := range xs {
+		s += (xs[i] - mx) * (ys[i] - my)
+	}
+	if population {
+		return s / float64(len(xs))
+	}
+	return s / float64(len(xs)-1)
+}
+
+// Correlation returns the Pearson correlation coefficient of two equally
+// long lists of values, or NaN if either list has no variation.
+func Correlation(xs, ys []float64) float64 {
+	mx, my := Mean(xs), Mean(ys)
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return math.NaN()
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// LinearRegression fits a line to the points by least squares, and returns
+// its slope and intercept, and the coefficient of determination.
+func LinearRegression(xs, ys []float64) (slope, intercept, r2 float64) {
+	mx, my := Mean(xs), Mean(ys)
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, ys[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	slope = sxy / sxx
+	intercept = my - slope*mx
+	if syy == 0 {
+		// Every point lies on the horizontal line
+		return slope, intercept, 1
+	}
+	return slope, intercept, sxy * sxy / (sxx * syy)
+}
+
+// Bin is a histogram bin, counting the values at or above Min and below
+// Max. The last bin also counts values equal to its Max.
+type Bin struct {
+	Min, Max float64
+	Count    int
+}
+
+// Histogram counts the values falling into each of the bins delimited by
+// the sorted edges. Values outside the edges aren't counted.
+func Histogram(values []float64, edges []float64) []Bin {
+	bins := make([]Bin, len(edges)-1)
+	for i := range bins {
+		bins[i] = Bin{Min: edges[i], Max: edges[i+1]}
+	}
+	last := len(edges) - 1
+	for _, v := range values {
+		if v < edges[0] || v > edges[last] || math.IsNaN(v) {
+			continue
+		}
+		// The first edge greater than v closes its bin
+		i := sort.SearchFloat64s(edges, math.Nextafter(v, math.Inf(1))) - 1
+		if i >= len(bins) {
+			i = len(bins) - 1
+		}
+		bins[i].Count++
+	}
+	return bins
+}
+
+// EvenEdges returns the edges of n bins of equal width spanning lo to hi.
+func EvenEdges(lo, hi float64, n int) []float64 {
+	if lo == hi {
+		// Give a single repeated value a bin of its own
+		lo, hi = lo-0.5, hi+0.5
+	}
+	edges := make([]float64, n+1)
+	width := (hi - lo) / float64(n)
+	for i := range edges {
+		edges[i] = lo + float64(i)*width
+	}
+	edges[n] = hi
+	return edges
+}
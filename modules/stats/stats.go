@@ -0,0 +1,398 @@
+package stats
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+var defaultPercentiles = []float64{50, 90, 95, 99}
+
+// asFloats converts a list or set of numbers, or a float_slice, to floats.
+func asFloats(fn string, obj object.Object) ([]float64, *object.Error) {
+	var items []object.Object
+	switch obj := obj.(type) {
+	case *object.FloatSlice:
+		return obj.Value(), nil
+	case *object.List:
+		items = obj.Value()
+	case *object.Set:
+		items = obj.List().Value()
+	default:
+		return nil, object.Errorf("type error: %s expected a list of numbers (%s given)", fn, obj.Type())
+	}
+	values := make([]float64, len(items))
+	for i, item := range items {
+		switch item := item.(type) {
+		case *object.Int:
+			values[i] = float64(item.Value())
+		case *object.Float:
+			values[i] = item.Value()
+		default:
+			return nil, object.Errorf("type error: %s expected a list of numbers (list contains %s)", fn, item.Type())
+		}
+	}
+	return values, nil
+}
+
+// values returns the numbers given as the first argument, which must
+// include at least min of them.
+func values(fn string, args []object.Object, min int) ([]float64, *object.Error) {
+	xs, err := asFloats(fn, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(xs) < min {
+		if min == 1 {
+			return nil, object.Errorf("value error: %s requires at least one value", fn)
+		}
+		return nil, object.Errorf("value error: %s requires at least %d values (got %d)", fn, min, len(xs))
+	}
+	return xs, nil
+}
+
+// pairs returns the two lists of numbers given as the first arguments,
+// which must be equally long and include at least two values.
+func pairs(fn string, args []object.Object) ([]float64, []float64, *object.Error) {
+	xs, err := asFloats(fn, args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	ys, err := asFloats(fn, args[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(xs) != len(ys) {
+		return nil, nil, object.Errorf("value error: %s requires lists of the same length (got %d and %d)", fn, len(xs), len(ys))
+	}
+	if len(xs) < 2 {
+		return nil, nil, object.Errorf("value error: %s requires at least 2 values (got %d)", fn, len(xs))
+	}
+	return xs, ys, nil
+}
+
+// population reads the options map of the variance functions, if there is
+// one, and returns whether the population variance was asked for.
+func population(fn string, args []object.Object) (bool, *object.Error) {
+	var pop bool
+	if len(args) == 0 {
+		return pop, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return pop, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "population":
+			pop, err = object.AsBool(value)
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return pop, err
+		}
+	}
+	return pop, nil
+}
+
+func asPercentile(fn string, obj object.Object) (float64, *object.Error) {
+	p, err := object.AsFloat(obj)
+	if err != nil {
+		return 0, err
+	}
+	if p < 0 || p > 100 || math.IsNaN(p) {
+		return 0, object.Errorf("value error: %s percentile must be between 0 and 100 (got %v)", fn, p)
+	}
+	return p, nil
+}
+
+func MeanBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("stats.mean", 1, args); err != nil {
+		return err
+	}
+	xs, err := values("stats.mean", args, 1)
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Mean(xs))
+}
+
+func Median(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("stats.median", 1, args); err != nil {
+		return err
+	}
+	xs, err := values("stats.median", args, 1)
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Percentile(sorted(xs), 50))
+}
+
+func PercentileBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("stats.percentile", 2, args); err != nil {
+		return err
+	}
+	xs, err := values("stats.percentile", args, 1)
+	if err != nil {
+		return err
+	}
+	s := sorted(xs)
+	// A list of percentiles shares the sorting
+	if list, ok := args[1].(*object.List); ok {
+		results := make([]object.Object, len(list.Value()))
+		for i, item := range list.Value() {
+			p, err := asPercentile("stats.percentile", item)
+			if err != nil {
+				return err
+			}
+			results[i] = object.NewFloat(Percentile(s, p))
+		}
+		return object.NewList(results)
+	}
+	p, err := asPercentile("stats.percentile", args[1])
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Percentile(s, p))
+}
+
+func VarianceBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("stats.variance", 1, 2, args); err != nil {
+		return err
+	}
+	pop, err := population("stats.variance", args[1:])
+	if err != nil {
+		return err
+	}
+	min := 2
+	if pop {
+		min = 1
+	}
+	xs, err := values("stats.variance", args, min)
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Variance(xs, pop))
+}
+
+func Stddev(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("stats.stddev", 1, 2, args); err != nil {
+		return err
+	}
+	pop, err := population("stats.stddev", args[1:])
+	if err != nil {
+		return err
+	}
+	min := 2
+	if pop {
+		min = 1
+	}
+	xs, err := values("stats.stddev", args, min)
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(math.Sqrt(Variance(xs, pop)))
+}
+
+// Summary describes the distribution of the values in one map.
+func Summary(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("stats.summary", 1, 2, args); err != nil {
+		return err
+	}
+	percentiles := defaultPercentiles
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "percentiles":
+				list, err := object.AsList(value)
+				if err != nil {
+					return err
+				}
+				percentiles = make([]float64, len(list.Value()))
+				for i, item := range list.Value() {
+					if percentiles[i], err = asPercentile("stats.summary", item); err != nil {
+						return err
+					}
+				}
+			default:
+				return object.Errorf("value error: unknown stats.summary option %q", key)
+			}
+		}
+	}
+	xs, err := values("stats.summary", args, 1)
+	if err != nil {
+		return err
+	}
+	s := sorted(xs)
+	var sum float64
+	for _, v := range s {
+		sum += v
+	}
+	stddev := 0.0
+	if len(s) > 1 {
+		stddev = math.Sqrt(Variance(s, false))
+	}
+	result := map[string]object.Object{
+		"count":  object.NewInt(int64(len(s))),
+		"sum":    object.NewFloat(sum),
+		"min":    object.NewFloat(s[0]),
+		"max":    object.NewFloat(s[len(s)-1]),
+		"mean":   object.NewFloat(Mean(s)),
+		"stddev": object.NewFloat(stddev),
+	}
+	for _, p := range percentiles {
+		result["p"+strconv.FormatFloat(p, 'f', -1, 64)] = object.NewFloat(Percentile(s, p))
+	}
+	return object.NewMap(result)
+}
+
+func HistogramBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("stats.histogram", 1, 2, args); err != nil {
+		return err
+	}
+	xs, err := asFloats("stats.histogram", args[0])
+	if err != nil {
+		return err
+	}
+	bins := int64(10)
+	var edges []float64
+	var lo, hi *float64
+	if len(args) == 2 {
+		m, err := object.AsMap(args[1])
+		if err != nil {
+			return err
+		}
+		for key, value := range m.Value() {
+			switch key {
+			case "bins":
+				// Either a number of bins, or the list of their edges
+				if list, ok := value.(*object.List); ok {
+					if edges, err = asFloats("stats.histogram", list); err == nil && len(edges) < 2 {
+						err = object.Errorf("value error: stats.histogram requires at least 2 bin edges (got %d)", len(edges))
+					}
+					if err == nil && !sort.Float64sAreSorted(edges) {
+						err = object.Errorf("value error: stats.histogram bin edges must be in increasing order")
+					}
+				} else if bins, err = object.AsInt(value); err == nil && (bins < 1 || bins > 10000) {
+					err = object.Errorf("value error: stats.histogram bins must be between 1 and 10000 (got %d)", bins)
+				}
+			case "min":
+				var f float64
+				if f, err = object.AsFloat(value); err == nil {
+					lo = &f
+				}
+			case "max":
+				var f float64
+				if f, err = object.AsFloat(value); err == nil {
+					hi = &f
+				}
+			default:
+				err = object.Errorf("value error: unknown stats.histogram option %q", key)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if edges == nil {
+		if len(xs) == 0 && (lo == nil || hi == nil) {
+			return object.NewList(nil)
+		}
+		var min, max float64
+		if len(xs) > 0 {
+			s := sorted(xs)
+			min, max = s[0], s[len(s)-1]
+		}
+		if lo != nil {
+			min = *lo
+		}
+		if hi != nil {
+			max = *hi
+		}
+		if min > max {
+			return object.Errorf("value error: stats.histogram min must not be greater than max")
+		}
+		edges = EvenEdges(min, max, int(bins))
+	} else if lo != nil || hi != nil {
+		return object.Errorf("value error: stats.histogram doesn't accept min or max with a list of bin edges")
+	}
+	result := Histogram(xs, edges)
+	items := make([]object.Object, len(result))
+	for i, bin := range result {
+		items[i] = object.NewMap(map[string]object.Object{
+			"min":   object.NewFloat(bin.Min),
+			"max":   object.NewFloat(bin.Max),
+			"count": object.NewInt(int64(bin.Count)),
+		})
+	}
+	return object.NewList(items)
+}
+
+func CovarianceBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("stats.covariance", 2, 3, args); err != nil {
+		return err
+	}
+	pop, err := population("stats.covariance", args[2:])
+	if err != nil {
+		return err
+	}
+	xs, ys, err := pairs("stats.covariance", args)
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Covariance(xs, ys, pop))
+}
+
+func CorrelationBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("stats.correlation", 2, args); err != nil {
+		return err
+	}
+	xs, ys, err := pairs("stats.correlation", args)
+	if err != nil {
+		return err
+	}
+	return object.NewFloat(Correlation(xs, ys))
+}
+
+func LinearRegressionBuiltin(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("stats.linear_regression", 2, args); err != nil {
+		return err
+	}
+	xs, ys, err := pairs("stats.linear_regression", args)
+	if err != nil {
+		return err
+	}
+	if Variance(xs, true) == 0 {
+		return object.Errorf("value error: stats.linear_regression requires x values that aren't all equal")
+	}
+	slope, intercept, r2 := LinearRegression(xs, ys)
+	return object.NewMap(map[string]object.Object{
+		"slope":     object.NewFloat(slope),
+		"intercept": object.NewFloat(intercept),
+		"r2":        object.NewFloat(r2),
+	})
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("stats", map[string]object.Object{
+		"correlation":       object.NewBuiltin("correlation", CorrelationBuiltin),
+		"covariance":        object.NewBuiltin("covariance", CovarianceBuiltin),
+		"histogram":         object.NewBuiltin("histogram", HistogramBuiltin),
+		"linear_regression": object.NewBuiltin("linear_regression", LinearRegressionBuiltin),
+		"mean":              object.NewBuiltin("mean", MeanBuiltin),
+		"median":            object.NewBuiltin("median", Median),
+		"percentile":        object.NewBuiltin("percentile", PercentileBuiltin),
+		"stddev":            object.NewBuiltin("stddev", Stddev),
+		"summary":           object.NewBuiltin("summary", Summary),
+		"variance":          object.NewBuiltin("variance", VarianceBuiltin),
+	})
+}
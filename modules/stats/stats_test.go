@@ -0,0 +1,199 @@
+package stats
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func floats(values ...float64) object.Object {
+	items := make([]object.Object, len(values))
+	for i, v := range values {
+		items[i] = object.NewFloat(v)
+	}
+	return object.NewList(items)
+}
+
+func opts(m map[string]interface{}) object.Object {
+	return object.FromGoType(m)
+}
+
+func value(t *testing.T, obj object.Object) float64 {
+	t.Helper()
+	f, ok := obj.(*object.Float)
+	require.True(t, ok, obj.Inspect())
+	return f.Value()
+}
+
+func TestCentralTendency(t *testing.T) {
+	ctx := context.Background()
+	ints := object.NewList([]object.Object{object.NewInt(1), object.NewInt(2), object.NewInt(4), object.NewInt(10)})
+	require.Equal(t, 4.25, value(t, MeanBuiltin(ctx, ints)))
+	require.Equal(t, 3.0, value(t, Median(ctx, ints)))
+	require.Equal(t, 4.0, value(t, Median(ctx, floats(7, 4, 1))))
+
+	// Typed arrays are accepted too
+	slice := object.NewFloatSlice([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	require.Equal(t, 5.0, value(t, MeanBuiltin(ctx, slice)))
+	require.Equal(t, 2.0, value(t, Stddev(ctx, slice, opts(map[string]interface{}{"population": true}))))
+	require.InDelta(t, 32.0/7, value(t, VarianceBuiltin(ctx, slice)), 1e-12)
+	require.Equal(t, 0.0, value(t, VarianceBuiltin(ctx, floats(3), opts(map[string]interface{}{"population": true}))))
+}
+
+func TestPercentile(t *testing.T) {
+	ctx := context.Background()
+	data := floats(15, 20, 35, 40, 50)
+	tests := []struct {
+		p, want float64
+	}{
+		{0, 15},
+		{25, 20},
+		{40, 29},
+		{50, 35},
+		{90, 46},
+		{100, 50},
+	}
+	for _, tt := range tests {
+		require.InDelta(t, tt.want, value(t, PercentileBuiltin(ctx, data, object.NewFloat(tt.p))), 1e-9, "p%v", tt.p)
+	}
+	result := PercentileBuiltin(ctx, data, object.NewList([]object.Object{object.NewInt(50), object.NewInt(100)}))
+	require.Equal(t, floats(35, 50), result)
+	require.Equal(t, 7.0, value(t, PercentileBuiltin(ctx, floats(7), object.NewInt(99))))
+}
+
+func TestSummary(t *testing.T) {
+	ctx := context.Background()
+	var latencies []float64
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, float64(i))
+	}
+	summary, ok := Summary(ctx, object.NewFloatSlice(latencies)).(*object.Map)
+	require.True(t, ok)
+	require.Equal(t, object.NewInt(100), summary.Get("count"))
+	require.Equal(t, 5050.0, value(t, summary.Get("sum")))
+	require.Equal(t, 1.0, value(t, summary.Get("min")))
+	require.Equal(t, 100.0, value(t, summary.Get("max")))
+	require.Equal(t, 50.5, value(t, summary.Get("mean")))
+	require.InDelta(t, 29.011, value(t, summary.Get("stddev")), 0.001)
+	require.Equal(t, 50.5, value(t, summary.Get("p50")))
+	require.InDelta(t, 99.01, value(t, summary.Get("p99")), 1e-9)
+
+	summary = Summary(ctx, floats(5), opts(map[string]interface{}{"percentiles": []interface{}{99.9}})).(*object.Map)
+	require.Equal(t, 5.0, value(t, summary.Get("p99.9")))
+	require.Equal(t, 0.0, value(t, summary.Get("stddev")))
+	require.Equal(t, object.Nil, summary.Get("p50"))
+}
+
+func TestHistogram(t *testing.T) {
+	ctx := context.Background()
+	data := floats(1, 2, 2, 3, 3, 3, 4, 4, 4, 4)
+	result := HistogramBuiltin(ctx, data, opts(map[string]interface{}{"bins": 3}))
+	require.Equal(t, object.FromGoType([]interface{}{
+		map[string]interface{}{"min": 1.0, "max": 2.0, "count": 1},
+		map[string]interface{}{"min": 2.0, "max": 3.0, "count": 2},
+		map[string]interface{}{"min": 3.0, "max": 4.0, "count": 7},
+	}), result)
+
+	// Values outside explicit edges aren't counted, and the last bin
+	// includes its upper edge
+	result = HistogramBuiltin(ctx, data, opts(map[string]interface{}{"bins": []interface{}{2, 3, 10}}))
+	require.Equal(t, object.FromGoType([]interface{}{
+		map[string]interface{}{"min": 2.0, "max": 3.0, "count": 2},
+		map[string]interface{}{"min": 3.0, "max": 10.0, "count": 7},
+	}), result)
+
+	result = HistogramBuiltin(ctx, floats(5, 5), opts(map[string]interface{}{"bins": 1}))
+	require.Equal(t, object.FromGoType([]interface{}{
+		map[string]interface{}{"min": 4.5, "max": 5.5, "count": 2},
+	}), result)
+
+	result = HistogramBuiltin(ctx, data, opts(map[string]interface{}{"bins": 2, "min": 0, "max": 10}))
+	require.Equal(t, object.FromGoType([]interface{}{
+		map[string]interface{}{"min": 0.0, "max": 5.0, "count": 10},
+		map[string]interface{}{"min": 5.0, "max": 10.0, "count": 0},
+	}), result)
+
+	require.Equal(t, object.NewList(nil), HistogramBuiltin(ctx, floats()))
+}
+
+func TestRelationships(t *testing.T) {
+	ctx := context.Background()
+	xs := floats(1, 2, 3, 4, 5)
+	ys := floats(2.1, 3.9, 6.2, 7.8, 10.1)
+	require.InDelta(t, 0.998, value(t, CorrelationBuiltin(ctx, xs, ys)), 0.001)
+	require.InDelta(t, -1.0, value(t, CorrelationBuiltin(ctx, xs, floats(5, 4, 3, 2, 1))), 1e-12)
+	require.True(t, math.IsNaN(value(t, CorrelationBuiltin(ctx, xs, floats(1, 1, 1, 1, 1)))))
+	require.InDelta(t, 4.975, value(t, CovarianceBuiltin(ctx, xs, ys)), 1e-9)
+	require.InDelta(t, 3.98, value(t, CovarianceBuiltin(ctx, xs, ys, opts(map[string]interface{}{"population": true}))), 1e-9)
+
+	fit, ok := LinearRegressionBuiltin(ctx, xs, ys).(*object.Map)
+	require.True(t, ok)
+	require.InDelta(t, 1.99, value(t, fit.Get("slope")), 1e-9)
+	require.InDelta(t, 0.05, value(t, fit.Get("intercept")), 1e-9)
+	require.InDelta(t, 0.997, value(t, fit.Get("r2")), 0.001)
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		result object.Object
+		err    string
+	}{
+		{
+			"empty mean",
+			MeanBuiltin(ctx, floats()),
+			"value error: stats.mean requires at least one value",
+		},
+		{
+			"sample variance of one value",
+			VarianceBuiltin(ctx, floats(1)),
+			"value error: stats.variance requires at least 2 values (got 1)",
+		},
+		{
+			"non-numeric item",
+			MeanBuiltin(ctx, object.NewList([]object.Object{object.NewInt(1), object.NewString("2")})),
+			"type error: stats.mean expected a list of numbers (list contains string)",
+		},
+		{
+			"not a list",
+			Median(ctx, object.NewString("1 2 3")),
+			"type error: stats.median expected a list of numbers (string given)",
+		},
+		{
+			"percentile out of range",
+			PercentileBuiltin(ctx, floats(1), object.NewInt(101)),
+			"value error: stats.percentile percentile must be between 0 and 100 (got 101)",
+		},
+		{
+			"mismatched lengths",
+			CorrelationBuiltin(ctx, floats(1, 2), floats(1, 2, 3)),
+			"value error: stats.correlation requires lists of the same length (got 2 and 3)",
+		},
+		{
+			"vertical regression",
+			LinearRegressionBuiltin(ctx, floats(1, 1), floats(1, 2)),
+			"value error: stats.linear_regression requires x values that aren't all equal",
+		},
+		{
+			"unsorted edges",
+			HistogramBuiltin(ctx, floats(1), opts(map[string]interface{}{"bins": []interface{}{3, 1}})),
+			"value error: stats.histogram bin edges must be in increasing order",
+		},
+		{
+			"unknown option",
+			Stddev(ctx, floats(1, 2), opts(map[string]interface{}{"sample": true})),
+			`value error: unknown stats.stddev option "sample"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, ok := tt.result.(*object.Error)
+			require.True(t, ok, tt.result.Inspect())
+			require.Equal(t, tt.err, err.Message().Value())
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package strings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/risor-io/risor/limits"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const BUILDER object.Type = "strings.builder"
+
+// Builder wraps a strings.Builder and implements object.Object. Appending to
+// a builder takes amortized constant time, unlike repeated string
+// concatenation which copies the whole string on every step.
+type Builder struct {
+	builder strings.Builder
+}
+
+func (b *Builder) Type() object.Type {
+	return BUILDER
+}
+
+func (b *Builder) Inspect() string {
+	return fmt.Sprintf("strings.builder(%q)", b.builder.String())
+}
+
+// String returns the accumulated string.
+func (b *Builder) String() string {
+	return b.builder.String()
+}
+
+func (b *Builder) Interface() interface{} {
+	return b.builder.String()
+}
+
+// WriteObjects appends the given objects. Strings are written as-is and other
+// objects are written in their printed form.
+func (b *Builder) WriteObjects(ctx context.Context, objs ...object.Object) *object.Error {
+	for _, obj := range objs {
+		var s string
+		switch obj := obj.(type) {
+		case *object.String:
+			s = obj.Value()
+		case *object.ByteSlice:
+			s = string(obj.Value())
+		case *object.Rune:
+			s = string(obj.Value())
+		case *Builder:
+			s = obj.builder.String()
+		default:
+			s = obj.Inspect()
+		}
+		if err := limits.TrackCost(ctx, len(s)); err != nil {
+			return object.NewError(err)
+		}
+		b.builder.WriteString(s)
+	}
+	return nil
+}
+
+func (b *Builder) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "write", "write_line":
+		fullName := "strings.builder." + name
+		return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+			if err := b.WriteObjects(ctx, args...); err != nil {
+				return err
+			}
+			if name == "write_line" {
+				b.builder.WriteByte('\n')
+			}
+			return object.Nil
+		}), true
+	case "string":
+		return builderMethod(name, func() object.Object {
+			return object.NewString(b.builder.String())
+		}), true
+	case "len":
+		return builderMethod(name, func() object.Object {
+			return object.NewInt(int64(b.builder.Len()))
+		}), true
+	case "reset":
+		return builderMethod(name, func() object.Object {
+			b.builder.Reset()
+			return object.Nil
+		}), true
+	case "grow":
+		return object.NewBuiltin("strings.builder.grow", func(ctx context.Context, args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return object.NewArgsError("strings.builder.grow", 1, len(args))
+			}
+			n, err := object.AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			if n < 0 {
+				return object.Errorf("value error: strings.builder.grow argument must be non-negative (got %d)", n)
+			}
+			if err := limits.TrackCost(ctx, int(n)); err != nil {
+				return object.NewError(err)
+			}
+			b.builder.Grow(int(n))
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+func (b *Builder) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: strings.builder object has no attribute %q", name)
+}
+
+func (b *Builder) Equals(other object.Object) object.Object {
+	if b == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (b *Builder) IsTruthy() bool {
+	return b.builder.Len() > 0
+}
+
+// RunOperation supports the + operator, which appends to the builder in place
+// and returns it, so that `b += "text"` does not copy the accumulated string.
+func (b *Builder) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	if opType != op.Add {
+		return object.Errorf("eval error: unsupported operation for strings.builder: %v on type %s",
+			opType, right.Type())
+	}
+	if err := b.WriteObjects(context.Background(), right); err != nil {
+		return err
+	}
+	return b
+}
+
+func (b *Builder) Cost() int {
+	return b.builder.Len()
+}
+
+func (b *Builder) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.builder.String())
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// BuilderFunc creates a new strings.builder, optionally initialized with
+// the given values.
+func BuilderFunc(ctx context.Context, args ...object.Object) object.Object {
+	b := NewBuilder()
+	if err := b.WriteObjects(ctx, args...); err != nil {
+		return err
+	}
+	return b
+}
+
+func builderMethod(name string, fn func() object.Object) *object.Builtin {
+	fullName := "strings.builder." + name
+	return object.NewBuiltin(fullName, func(ctx context.Context, args ...object.Object) object.Object {
+		if len(args) != 0 {
+			return object.NewArgsError(fullName, 0, len(args))
+		}
+		return fn()
+	})
+}
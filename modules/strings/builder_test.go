@@ -0,0 +1,38 @@
+package strings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	ctx := context.Background()
+	result := BuilderFunc(ctx, object.NewString("a"), object.NewInt(1))
+	b, ok := result.(*Builder)
+	require.True(t, ok)
+	require.Equal(t, "a1", b.String())
+
+	write, ok := b.GetAttr("write_line")
+	require.True(t, ok)
+	require.Equal(t, object.Nil, write.(*object.Builtin).Call(ctx, object.NewString("b")))
+	require.Equal(t, "a1b\n", b.String())
+
+	// The + operator appends in place and returns the same builder
+	require.Same(t, b, b.RunOperation(op.Add, object.NewString("c")))
+	require.Equal(t, "a1b\nc", b.String())
+	require.True(t, object.IsError(b.RunOperation(op.Subtract, object.NewString("c"))))
+
+	length, ok := b.GetAttr("len")
+	require.True(t, ok)
+	require.Equal(t, object.NewInt(5), length.(*object.Builtin).Call(ctx))
+
+	reset, ok := b.GetAttr("reset")
+	require.True(t, ok)
+	reset.(*object.Builtin).Call(ctx)
+	require.Equal(t, "", b.String())
+	require.False(t, b.IsTruthy())
+}
@@ -2,9 +2,11 @@ package strings
 
 import (
 	"strings"
+
+	"github.com/risor-io/risor/object"
 )
 
-//risor:generate
+//risor:generate no-module-func
 
 //risor:export
 func contains(s, substr string) bool {
@@ -95,3 +97,9 @@ func trimSuffix(s, prefix string) string {
 func trimSpace(s string) string {
 	return strings.TrimSpace(s)
 }
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("strings", addGeneratedBuiltins(map[string]object.Object{
+		"builder": object.NewBuiltin("strings.builder", BuilderFunc),
+	}))
+}
@@ -352,11 +352,3 @@ func addGeneratedBuiltins(builtins map[string]object.Object) map[string]object.O
 	builtins["trim_space"] = object.NewBuiltin("strings.trim_space", TrimSpace)
 	return builtins
 }
-
-// The "Module()" function can be disabled with "//risor:generate no-module-func"
-
-// Module returns the Risor module object with all the associated builtin
-// functions.
-func Module() *object.Module {
-	return object.NewBuiltinsModule("strings", addGeneratedBuiltins(map[string]object.Object{}))
-}
@@ -0,0 +1,52 @@
+package template
+
+import (
+	"context"
+
+	"github.com/risor-io/risor/object"
+)
+
+// toGo converts a Risor value to the Go value passed to a template. Maps and
+// lists are converted recursively and functions are wrapped so that they can
+// be invoked from the template with "call".
+func toGo(ctx context.Context, obj object.Object) any {
+	switch obj := obj.(type) {
+	case *object.Map:
+		m := make(map[string]any, obj.Size())
+		for k, v := range obj.Value() {
+			m[k] = toGo(ctx, v)
+		}
+		return m
+	case *object.List:
+		items := obj.Value()
+		result := make([]any, len(items))
+		for i, item := range items {
+			result[i] = toGo(ctx, item)
+		}
+		return result
+	case *object.Function, *object.Partial, object.Callable:
+		return wrapFunc(ctx, obj)
+	case nil:
+		return nil
+	}
+	return obj.Interface()
+}
+
+// wrapFunc returns a Go function that calls the given Risor function, for use
+// in a template funcmap or as data.
+func wrapFunc(ctx context.Context, fn object.Object) func(args ...any) (any, error) {
+	return func(args ...any) (any, error) {
+		callArgs := make([]object.Object, len(args))
+		for i, arg := range args {
+			callArgs[i] = object.FromGoType(arg)
+			if err, ok := callArgs[i].(*object.Error); ok {
+				return nil, err.Value()
+			}
+		}
+		result, err := object.Call(ctx, fn, callArgs)
+		if err != nil {
+			return nil, err
+		}
+		return toGo(ctx, result), nil
+	}
+}
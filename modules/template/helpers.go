@@ -1,6 +1,7 @@
 package template
 
 import (
+	htmltemplate "html/template"
 	"strings"
 	"text/template"
 
@@ -36,6 +37,29 @@ func newTemplate(name string) *template.Template {
 	return tpl.Funcs(funcMap)
 }
 
+// newHTMLTemplate returns an html/template with the same functions as
+// newTemplate, plus safeHTML to mark trusted strings as HTML that should not
+// be escaped.
+func newHTMLTemplate(name string) *htmltemplate.Template {
+	tpl := htmltemplate.New(name).Delims(DelimStart, DelimEnd)
+	funcMap := sprig.HtmlFuncMap()
+	funcMap["toYaml"] = toYaml
+	funcMap["fromYaml"] = fromYaml
+	funcMap["jsonPath"] = jsonPath
+	funcMap["k8sLookup"] = k8sLookup
+	funcMap["safeHTML"] = func(s string) htmltemplate.HTML {
+		return htmltemplate.HTML(s)
+	}
+	funcMap["include"] = func(name string, data any) (htmltemplate.HTML, error) {
+		buf := new(strings.Builder)
+		if err := tpl.ExecuteTemplate(buf, name, data); err != nil {
+			return "", err
+		}
+		return htmltemplate.HTML(buf.String()), nil
+	}
+	return tpl.Funcs(funcMap)
+}
+
 func toYaml(v any) (string, error) {
 	b, err := yaml.Marshal(v)
 	if err != nil {
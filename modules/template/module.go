@@ -26,7 +26,7 @@ func RenderTemplate(ctx context.Context, args ...object.Object) object.Object {
 
 	buf := new(strings.Builder)
 
-	if err := Render(ctx, buf, template, data.Interface()); err != nil {
+	if err := Render(ctx, buf, template, toGo(ctx, data)); err != nil {
 		return object.NewError(err)
 	}
 
@@ -41,6 +41,8 @@ func Builtins() map[string]object.Object {
 
 func Module() *object.Module {
 	return object.NewBuiltinsModule("template", map[string]object.Object{
-		"new": object.NewBuiltin("new", New),
+		"new":         object.NewBuiltin("new", New),
+		"parse":       object.NewBuiltin("parse", Parse),
+		"escape_html": object.NewBuiltin("escape_html", EscapeHTML),
 	})
 }
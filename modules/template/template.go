@@ -3,6 +3,9 @@ package template
 import (
 	"context"
 	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
 	"strings"
 	"text/template"
 
@@ -13,8 +16,13 @@ import (
 
 const TEMPLATE object.Type = "template"
 
+// Template is a set of named templates. Text templates use text/template,
+// while HTML templates use html/template, which escapes values according to
+// the context they appear in.
 type Template struct {
-	tpl *template.Template
+	tpl   *template.Template
+	html  *htmltemplate.Template
+	funcs map[string]object.Object
 }
 
 func (t *Template) Type() object.Type {
@@ -26,6 +34,9 @@ func (t *Template) Inspect() string {
 }
 
 func (t *Template) Interface() interface{} {
+	if t.html != nil {
+		return t.html
+	}
 	return t.tpl
 }
 
@@ -50,7 +61,7 @@ func (t *Template) Equals(other object.Object) object.Object {
 		return object.False
 	}
 
-	return object.NewBool(t.tpl == other.(*Template).tpl)
+	return object.NewBool(t == other.(*Template))
 }
 
 func (db *Template) SetAttr(name string, value object.Object) error {
@@ -59,6 +70,12 @@ func (db *Template) SetAttr(name string, value object.Object) error {
 
 func (t *Template) GetAttr(name string) (object.Object, bool) {
 	switch name {
+	case "name":
+		return object.NewString(t.name()), true
+	case "html":
+		return object.NewBool(t.html != nil), true
+	case "templates":
+		return object.NewStringList(t.templates()), true
 	case "delims":
 		return object.NewBuiltin("template.delims", func(ctx context.Context, args ...object.Object) object.Object {
 			if err := arg.Require("template.delims", 2, args); err != nil {
@@ -75,7 +92,24 @@ func (t *Template) GetAttr(name string) (object.Object, bool) {
 				return errObj
 			}
 
-			t.tpl.Delims(left, right)
+			t.delims(left, right)
+
+			return object.Nil
+		}), true
+	case "funcs":
+		return object.NewBuiltin("template.funcs", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("template.funcs", 1, args); err != nil {
+				return err
+			}
+
+			funcs, argsErr := object.AsMap(args[0])
+			if argsErr != nil {
+				return argsErr
+			}
+
+			if err := t.addFuncs(ctx, funcs); err != nil {
+				return err
+			}
 
 			return object.Nil
 		}), true
@@ -90,7 +124,7 @@ func (t *Template) GetAttr(name string) (object.Object, bool) {
 				return argsErr
 			}
 
-			if _, err := t.tpl.Parse(template); err != nil {
+			if err := t.parse("", template); err != nil {
 				return object.NewError(err)
 			}
 
@@ -112,7 +146,7 @@ func (t *Template) GetAttr(name string) (object.Object, bool) {
 				return argsErr
 			}
 
-			if _, err := t.tpl.New(name).Parse(template); err != nil {
+			if err := t.parse(name, template); err != nil {
 				return object.NewError(err)
 			}
 
@@ -124,11 +158,6 @@ func (t *Template) GetAttr(name string) (object.Object, bool) {
 				return err
 			}
 
-			data, argsErr := object.AsMap(args[0])
-			if argsErr != nil {
-				return argsErr
-			}
-
 			name, argsErr := object.AsString(args[1])
 			if argsErr != nil {
 				return argsErr
@@ -136,7 +165,7 @@ func (t *Template) GetAttr(name string) (object.Object, bool) {
 
 			buf := new(strings.Builder)
 
-			if err := t.tpl.ExecuteTemplate(buf, name, data.Interface()); err != nil {
+			if err := t.Execute(ctx, buf, name, args[0]); err != nil {
 				return object.NewError(err)
 			}
 
@@ -148,14 +177,9 @@ func (t *Template) GetAttr(name string) (object.Object, bool) {
 				return err
 			}
 
-			data, argsErr := object.AsMap(args[0])
-			if argsErr != nil {
-				return argsErr
-			}
-
 			buf := new(strings.Builder)
 
-			if err := t.tpl.Execute(buf, data.Interface()); err != nil {
+			if err := t.Execute(ctx, buf, "", args[0]); err != nil {
 				return object.NewError(err)
 			}
 
@@ -165,7 +189,212 @@ func (t *Template) GetAttr(name string) (object.Object, bool) {
 	return nil, false
 }
 
+// Execute renders the named template, or the root template if name is
+// empty, with the given data. Functions added with funcs, and functions
+// found in the data, are called using the given context.
+func (t *Template) Execute(ctx context.Context, w io.Writer, name string, data object.Object) error {
+	funcs := make(map[string]any, len(t.funcs)+1)
+	for fname, fn := range t.funcs {
+		funcs[fname] = wrapFunc(ctx, fn)
+	}
+	value := toGo(ctx, data)
+	// Templates are cloned so the functions can be bound to this call, and
+	// so HTML templates may still be extended after they are executed.
+	if t.html != nil {
+		tpl, err := t.html.Clone()
+		if err != nil {
+			return err
+		}
+		funcs["include"] = func(name string, data any) (htmltemplate.HTML, error) {
+			buf := new(strings.Builder)
+			if err := tpl.ExecuteTemplate(buf, name, data); err != nil {
+				return "", err
+			}
+			return htmltemplate.HTML(buf.String()), nil
+		}
+		tpl.Funcs(funcs)
+		if name == "" {
+			return tpl.Execute(w, value)
+		}
+		return tpl.ExecuteTemplate(w, name, value)
+	}
+	tpl, err := t.tpl.Clone()
+	if err != nil {
+		return err
+	}
+	funcs["include"] = func(name string, data any) (string, error) {
+		buf := new(strings.Builder)
+		if err := tpl.ExecuteTemplate(buf, name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	tpl.Funcs(funcs)
+	if name == "" {
+		return tpl.Execute(w, value)
+	}
+	return tpl.ExecuteTemplate(w, name, value)
+}
+
+func (t *Template) name() string {
+	if t.html != nil {
+		return t.html.Name()
+	}
+	return t.tpl.Name()
+}
+
+// templates returns the sorted names of the defined templates, omitting an
+// unnamed root template.
+func (t *Template) templates() []string {
+	names := []string{}
+	if t.html != nil {
+		for _, tpl := range t.html.Templates() {
+			if tpl.Name() != "" {
+				names = append(names, tpl.Name())
+			}
+		}
+	} else {
+		for _, tpl := range t.tpl.Templates() {
+			if tpl.Name() != "" {
+				names = append(names, tpl.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t *Template) delims(left, right string) {
+	if t.html != nil {
+		t.html.Delims(left, right)
+	} else {
+		t.tpl.Delims(left, right)
+	}
+}
+
+func (t *Template) option(opt string) {
+	if t.html != nil {
+		t.html.Option(opt)
+	} else {
+		t.tpl.Option(opt)
+	}
+}
+
+// parse parses a template with the given name, or the root template if name
+// is empty.
+func (t *Template) parse(name, text string) error {
+	var err error
+	if t.html != nil {
+		tpl := t.html
+		if name != "" {
+			tpl = tpl.New(name)
+		}
+		_, err = tpl.Parse(text)
+	} else {
+		tpl := t.tpl
+		if name != "" {
+			tpl = tpl.New(name)
+		}
+		_, err = tpl.Parse(text)
+	}
+	return err
+}
+
+// addFuncs makes Risor functions available to the template. Functions must
+// be added before the templates that use them are parsed.
+func (t *Template) addFuncs(ctx context.Context, funcs *object.Map) *object.Error {
+	funcMap := make(map[string]any, funcs.Size())
+	for name, fn := range funcs.Value() {
+		switch fn.(type) {
+		case *object.Function, *object.Partial, object.Callable:
+		default:
+			return object.Errorf("type error: template function %q must be callable (got %s)", name, fn.Type())
+		}
+		t.funcs[name] = fn
+		funcMap[name] = wrapFunc(ctx, fn)
+	}
+	if t.html != nil {
+		t.html.Funcs(funcMap)
+	} else {
+		t.tpl.Funcs(funcMap)
+	}
+	return nil
+}
+
+// parseOptions applies the options given to template.new and template.parse.
+func (t *Template) parseOptions(ctx context.Context, m *object.Map) *object.Error {
+	for key, value := range m.Value() {
+		switch key {
+		case "html":
+			// Handled when the template is created
+		case "delims":
+			delims, err := object.AsStringSlice(value)
+			if err != nil {
+				return err
+			}
+			if len(delims) != 2 {
+				return object.Errorf("value error: delims must be a list of two strings")
+			}
+			t.delims(delims[0], delims[1])
+		case "funcs":
+			funcs, err := object.AsMap(value)
+			if err != nil {
+				return err
+			}
+			if err := t.addFuncs(ctx, funcs); err != nil {
+				return err
+			}
+		case "missing_key":
+			mode, err := object.AsString(value)
+			if err != nil {
+				return err
+			}
+			switch mode {
+			case "default", "zero", "error":
+				t.option("missingkey=" + mode)
+			default:
+				return object.Errorf("value error: missing_key must be \"default\", \"zero\", or \"error\" (got %q)", mode)
+			}
+		default:
+			return object.Errorf("value error: unknown template option %q", key)
+		}
+	}
+	return nil
+}
+
+// newFromArgs creates a template with the given name and options map.
+func newFromArgs(ctx context.Context, name string, args []object.Object) (*Template, *object.Error) {
+	var opts *object.Map
+	html := false
+	if len(args) > 0 {
+		var err *object.Error
+		if opts, err = object.AsMap(args[0]); err != nil {
+			return nil, err
+		}
+		if value := opts.Get("html"); value != object.Nil {
+			if html, err = object.AsBool(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	t := &Template{funcs: map[string]object.Object{}}
+	if html {
+		t.html = newHTMLTemplate(name)
+	} else {
+		t.tpl = newTemplate(name)
+	}
+	if opts != nil {
+		if err := t.parseOptions(ctx, opts); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
 func New(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("template.new", 0, 2, args); err != nil {
+		return err
+	}
 	var name string
 	if len(args) > 0 {
 		var errObj *object.Error
@@ -174,7 +403,44 @@ func New(ctx context.Context, args ...object.Object) object.Object {
 			return errObj
 		}
 	}
-	return &Template{
-		tpl: newTemplate(name),
+	var opts []object.Object
+	if len(args) > 1 {
+		opts = args[1:]
+	}
+	t, err := newFromArgs(ctx, name, opts)
+	if err != nil {
+		return err
+	}
+	return t
+}
+
+// Parse creates a template and parses the given text as its root template.
+func Parse(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("template.parse", 1, 2, args); err != nil {
+		return err
+	}
+	text, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	t, errObj := newFromArgs(ctx, "", args[1:])
+	if errObj != nil {
+		return errObj
+	}
+	if err := t.parse("", text); err != nil {
+		return object.NewError(err)
+	}
+	return t
+}
+
+// EscapeHTML returns the string with HTML special characters escaped.
+func EscapeHTML(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("template.escape_html", 1, args); err != nil {
+		return err
+	}
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
 	}
+	return object.NewString(htmltemplate.HTMLEscapeString(s))
 }
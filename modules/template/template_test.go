@@ -0,0 +1,79 @@
+package template
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func call(ctx context.Context, t *Template, name string, args ...object.Object) object.Object {
+	method, _ := t.GetAttr(name)
+	return method.(*object.Builtin).Call(ctx, args...)
+}
+
+func upper(ctx context.Context, args ...object.Object) object.Object {
+	s, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	return object.NewString(s + "!")
+}
+
+func TestParseHTML(t *testing.T) {
+	ctx := context.Background()
+	opts := object.NewMap(map[string]object.Object{
+		"html":  object.True,
+		"funcs": object.NewMap(map[string]object.Object{"shout": object.NewBuiltin("shout", upper)}),
+	})
+	tpl, ok := Parse(ctx, object.NewString(`<p title="{{ .name }}">{{ shout .name }}</p>{{ safeHTML "<b>ok</b>" }}`), opts).(*Template)
+	require.True(t, ok)
+	result := call(ctx, tpl, "execute", object.NewMap(map[string]object.Object{
+		"name": object.NewString("<x>"),
+	}))
+	require.Equal(t, object.NewString(`<p title="&lt;x&gt;">&lt;x&gt;!</p><b>ok</b>`), result)
+
+	// HTML templates may be extended after they are executed
+	require.Equal(t, object.Nil, call(ctx, tpl, "add", object.NewString("row"), object.NewString("<li>{{ . }}</li>")))
+	result = call(ctx, tpl, "execute_template", object.NewString("<a>"), object.NewString("row"))
+	require.Equal(t, object.NewString("<li>&lt;a&gt;</li>"), result)
+}
+
+func TestFunctionsInData(t *testing.T) {
+	ctx := context.Background()
+	tpl := New(ctx, object.NewString("t")).(*Template)
+	require.Equal(t, object.Nil, call(ctx, tpl, "parse", object.NewString(`{{ call .f "hi" }} {{ range .items }}{{ . }}{{ end }}`)))
+	result := call(ctx, tpl, "execute", object.NewMap(map[string]object.Object{
+		"f":     object.NewBuiltin("f", upper),
+		"items": object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)}),
+	}))
+	require.Equal(t, object.NewString("hi! 12"), result)
+	templates, _ := tpl.GetAttr("templates")
+	require.Equal(t, object.NewStringList([]string{"t"}), templates)
+}
+
+func TestOptions(t *testing.T) {
+	ctx := context.Background()
+	tpl := New(ctx, object.NewString("t"), object.NewMap(map[string]object.Object{
+		"delims":      object.NewStringList([]string{"<%", "%>"}),
+		"missing_key": object.NewString("error"),
+	})).(*Template)
+	call(ctx, tpl, "parse", object.NewString("<% .a %> <% .b %>"))
+	result := call(ctx, tpl, "execute", object.NewMap(map[string]object.Object{"a": object.NewInt(1)}))
+	require.True(t, object.IsError(result))
+	require.Contains(t, result.(*object.Error).Message().Value(), `map has no entry for key "b"`)
+
+	result = New(ctx, object.NewString("t"), object.NewMap(map[string]object.Object{
+		"funcs": object.NewMap(map[string]object.Object{"f": object.NewInt(1)}),
+	}))
+	require.Equal(t, object.Errorf(`type error: template function "f" must be callable (got int)`), result)
+
+	result = Parse(ctx, object.NewString("{{ .a "))
+	require.True(t, object.IsError(result))
+}
+
+func TestEscapeHTML(t *testing.T) {
+	result := EscapeHTML(context.Background(), object.NewString(`<a href="x">&</a>`))
+	require.Equal(t, object.NewString("&lt;a href=&#34;x&#34;&gt;&amp;&lt;/a&gt;"), result)
+}
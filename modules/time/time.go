@@ -12,7 +12,7 @@ func Now(ctx context.Context, args ...object.Object) object.Object {
 	if err := arg.Require("time.now", 0, args); err != nil {
 		return err
 	}
-	return object.NewTime(time.Now())
+	return object.NewTime(object.GetDefaultClock(ctx).Now())
 }
 
 func Parse(ctx context.Context, args ...object.Object) object.Object {
@@ -42,11 +42,11 @@ func Sleep(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	timer := time.NewTimer(time.Duration(d*1000) * time.Millisecond)
+	timer := object.GetDefaultClock(ctx).NewTimer(time.Duration(d*1000) * time.Millisecond)
 	defer timer.Stop()
 	select {
 	case <-ctx.Done():
-	case <-timer.C:
+	case <-timer.C():
 	}
 	return object.Nil
 }
@@ -59,7 +59,7 @@ func Since(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	return object.NewFloat(time.Since(t).Seconds())
+	return object.NewFloat(object.GetDefaultClock(ctx).Now().Sub(t).Seconds())
 }
 
 func Module() *object.Module {
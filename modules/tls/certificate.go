@@ -0,0 +1,158 @@
+package tls
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const CERTIFICATE object.Type = "tls.certificate"
+
+// Certificate is a parsed X.509 certificate.
+type Certificate struct {
+	cert *x509.Certificate
+}
+
+func NewCertificate(cert *x509.Certificate) *Certificate {
+	return &Certificate{cert: cert}
+}
+
+func (c *Certificate) Type() object.Type {
+	return CERTIFICATE
+}
+
+func (c *Certificate) Inspect() string {
+	return fmt.Sprintf("tls.certificate(subject=%q, not_after=%s)",
+		c.cert.Subject.String(), c.cert.NotAfter.Format(time.RFC3339))
+}
+
+// Interface returns nil so that printing a certificate shows its summary
+// rather than the raw certificate structure. Use Value to access it.
+func (c *Certificate) Interface() interface{} {
+	return nil
+}
+
+// Value returns the underlying certificate.
+func (c *Certificate) Value() *x509.Certificate {
+	return c.cert
+}
+
+func (c *Certificate) IsTruthy() bool {
+	return true
+}
+
+func (c *Certificate) Cost() int {
+	return 0
+}
+
+func (c *Certificate) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", c.pem())), nil
+}
+
+func (c *Certificate) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", CERTIFICATE, opType)
+}
+
+func (c *Certificate) Equals(other object.Object) object.Object {
+	if o, ok := other.(*Certificate); ok {
+		return object.NewBool(bytes.Equal(c.cert.Raw, o.cert.Raw))
+	}
+	return object.False
+}
+
+func (c *Certificate) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", CERTIFICATE, name)
+}
+
+func (c *Certificate) GetAttr(name string) (object.Object, bool) {
+	cert := c.cert
+	switch name {
+	case "subject":
+		return object.NewString(cert.Subject.String()), true
+	case "issuer":
+		return object.NewString(cert.Issuer.String()), true
+	case "common_name":
+		return object.NewString(cert.Subject.CommonName), true
+	case "serial_number":
+		return object.NewString(strings.ToUpper(cert.SerialNumber.Text(16))), true
+	case "not_before":
+		return object.NewTime(cert.NotBefore), true
+	case "not_after":
+		return object.NewTime(cert.NotAfter), true
+	case "dns_names":
+		return object.NewStringList(cert.DNSNames), true
+	case "ip_addresses":
+		ips := make([]string, len(cert.IPAddresses))
+		for i, ip := range cert.IPAddresses {
+			ips[i] = ip.String()
+		}
+		return object.NewStringList(ips), true
+	case "email_addresses":
+		return object.NewStringList(cert.EmailAddresses), true
+	case "uris":
+		uris := make([]string, len(cert.URIs))
+		for i, uri := range cert.URIs {
+			uris[i] = uri.String()
+		}
+		return object.NewStringList(uris), true
+	case "is_ca":
+		return object.NewBool(cert.IsCA), true
+	case "self_signed":
+		selfSigned := bytes.Equal(cert.RawIssuer, cert.RawSubject) &&
+			cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+		return object.NewBool(selfSigned), true
+	case "signature_algorithm":
+		return object.NewString(cert.SignatureAlgorithm.String()), true
+	case "public_key_algorithm":
+		return object.NewString(cert.PublicKeyAlgorithm.String()), true
+	case "fingerprint":
+		sum := sha256.Sum256(cert.Raw)
+		return object.NewString(hex.EncodeToString(sum[:])), true
+	case "expired":
+		return object.NewBool(time.Now().After(cert.NotAfter)), true
+	case "days_remaining":
+		return object.NewInt(int64(time.Until(cert.NotAfter).Hours() / 24)), true
+	case "pem":
+		return object.NewString(c.pem()), true
+	case "der":
+		return object.NewByteSlice(cert.Raw), true
+	case "expires_within":
+		return object.NewBuiltin("tls.certificate.expires_within", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("tls.certificate.expires_within", 1, args); err != nil {
+				return err
+			}
+			days, err := object.AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			deadline := time.Now().Add(time.Duration(days) * 24 * time.Hour)
+			return object.NewBool(deadline.After(cert.NotAfter))
+		}), true
+	case "verify_hostname":
+		return object.NewBuiltin("tls.certificate.verify_hostname", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("tls.certificate.verify_hostname", 1, args); err != nil {
+				return err
+			}
+			host, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewBool(cert.VerifyHostname(host) == nil)
+		}), true
+	}
+	return nil, false
+}
+
+func (c *Certificate) pem() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}))
+}
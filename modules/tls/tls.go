@@ -0,0 +1,491 @@
+package tls
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+)
+
+// Parse parses one or more certificates from PEM or DER data.
+func Parse(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("tls.parse", 1, args); err != nil {
+		return err
+	}
+	data, errObj := object.AsBytes(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	certs, err := parseCertificates(data)
+	if err != nil {
+		return object.Errorf("value error: %s", err)
+	}
+	return certificateList(certs)
+}
+
+// Probe connects to a TLS server and returns details of the connection,
+// including the certificate chain the server presented. The chain is
+// returned even if it fails verification, in which case verified is false
+// and verify_error describes the problem.
+func Probe(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("tls.probe", 1, 2, args); err != nil {
+		return err
+	}
+	address, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, "443"
+	}
+	serverName := host
+	timeout := 10 * time.Second
+	if len(args) == 2 {
+		opts, errObj := object.AsMap(args[1])
+		if errObj != nil {
+			return errObj
+		}
+		for key, value := range opts.Value() {
+			switch key {
+			case "server_name":
+				if serverName, errObj = object.AsString(value); errObj != nil {
+					return errObj
+				}
+			case "timeout":
+				if timeout, errObj = arg.Duration(key, value); errObj != nil {
+					return errObj
+				}
+			default:
+				return object.Errorf("value error: unknown tls.probe option %q", key)
+			}
+		}
+	}
+	dialer := &gotls.Dialer{
+		NetDialer: &net.Dialer{Timeout: timeout},
+		// The chain is verified below so that invalid certificates can
+		// still be inspected.
+		Config: &gotls.Config{ServerName: serverName, InsecureSkipVerify: true},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return object.NewError(err)
+	}
+	defer conn.Close()
+	state := conn.(*gotls.Conn).ConnectionState()
+	var verifyErr object.Object = object.Nil
+	if err := verifyChain(state.PeerCertificates, nil, serverName, time.Time{}); err != nil {
+		verifyErr = object.NewString(err.Error())
+	}
+	return object.NewMap(map[string]object.Object{
+		"version":      object.NewString(gotls.VersionName(state.Version)),
+		"cipher_suite": object.NewString(gotls.CipherSuiteName(state.CipherSuite)),
+		"server_name":  object.NewString(serverName),
+		"protocol":     object.NewString(state.NegotiatedProtocol),
+		"certificates": certificateList(state.PeerCertificates),
+		"verified":     object.NewBool(verifyErr == object.Nil),
+		"verify_error": verifyErr,
+	})
+}
+
+// Verify verifies a certificate chain. The first certificate is the leaf and
+// any others are used as intermediates. Returns true if the chain is valid,
+// otherwise an error describing why it is not.
+func Verify(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("tls.verify", 1, 2, args); err != nil {
+		return err
+	}
+	certs, errObj := asCertificates(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	if len(certs) == 0 {
+		return object.Errorf("value error: tls.verify() requires at least one certificate")
+	}
+	var roots []*x509.Certificate
+	var dnsName string
+	var at time.Time
+	if len(args) == 2 {
+		opts, errObj := object.AsMap(args[1])
+		if errObj != nil {
+			return errObj
+		}
+		for key, value := range opts.Value() {
+			switch key {
+			case "roots":
+				if roots, errObj = asCertificates(value); errObj != nil {
+					return errObj
+				}
+			case "dns_name":
+				if dnsName, errObj = object.AsString(value); errObj != nil {
+					return errObj
+				}
+			case "time":
+				if at, errObj = object.AsTime(value); errObj != nil {
+					return errObj
+				}
+			default:
+				return object.Errorf("value error: unknown tls.verify option %q", key)
+			}
+		}
+	}
+	if err := verifyChain(certs, roots, dnsName, at); err != nil {
+		return object.NewError(err)
+	}
+	return object.True
+}
+
+// Generate creates a certificate and private key, returned as PEM strings.
+// The certificate is self-signed unless a CA certificate and key are given.
+func Generate(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("tls.generate", 0, 1, args); err != nil {
+		return err
+	}
+	opts := object.NewMap(nil)
+	if len(args) == 1 {
+		var errObj *object.Error
+		if opts, errObj = object.AsMap(args[0]); errObj != nil {
+			return errObj
+		}
+	}
+	tmpl := &x509.Certificate{
+		NotBefore:             time.Now().Add(-time.Minute),
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	days := int64(365)
+	keyType := "ecdsa"
+	var caCert *x509.Certificate
+	var caKey crypto.Signer
+	for key, value := range opts.Value() {
+		var errObj *object.Error
+		switch key {
+		case "common_name", "organization", "dns_names", "ip_addresses", "email_addresses", "uris":
+			errObj = applySubject(key, value, &tmpl.Subject, &tmpl.DNSNames, &tmpl.IPAddresses, &tmpl.EmailAddresses, &tmpl.URIs)
+		case "days":
+			days, errObj = object.AsInt(value)
+		case "key_type":
+			keyType, errObj = object.AsString(value)
+		case "is_ca":
+			tmpl.IsCA, errObj = object.AsBool(value)
+		case "ca_cert":
+			var certs []*x509.Certificate
+			if certs, errObj = asCertificates(value); errObj == nil {
+				if len(certs) == 0 {
+					return object.Errorf("value error: ca_cert contains no certificates")
+				}
+				caCert = certs[0]
+			}
+		case "ca_key":
+			caKey, errObj = asPrivateKey(value)
+		default:
+			return object.Errorf("value error: unknown tls.generate option %q", key)
+		}
+		if errObj != nil {
+			return errObj
+		}
+	}
+	if days <= 0 {
+		return object.Errorf("value error: days must be positive (got %d)", days)
+	}
+	if (caCert == nil) != (caKey == nil) {
+		return object.Errorf("value error: ca_cert and ca_key must be given together")
+	}
+	tmpl.NotAfter = tmpl.NotBefore.Add(time.Duration(days) * 24 * time.Hour)
+	if tmpl.IsCA {
+		tmpl.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return object.NewError(err)
+	}
+	tmpl.SerialNumber = serial
+	key, errObj := newPrivateKey(keyType)
+	if errObj != nil {
+		return errObj
+	}
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	parent, signer := tmpl, crypto.Signer(key)
+	if caCert != nil {
+		parent, signer = caCert, caKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), signer)
+	if err != nil {
+		return object.NewError(err)
+	}
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewMap(map[string]object.Object{
+		"cert": object.NewString(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))),
+		"key":  object.NewString(keyPEM),
+	})
+}
+
+// CSR creates a certificate signing request, returned as a PEM string along
+// with its private key. A new key is generated unless one is given.
+func CSR(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("tls.csr", 1, args); err != nil {
+		return err
+	}
+	opts, errObj := object.AsMap(args[0])
+	if errObj != nil {
+		return errObj
+	}
+	tmpl := &x509.CertificateRequest{}
+	keyType := "ecdsa"
+	var key crypto.Signer
+	for name, value := range opts.Value() {
+		var errObj *object.Error
+		switch name {
+		case "common_name", "organization", "dns_names", "ip_addresses", "email_addresses", "uris":
+			errObj = applySubject(name, value, &tmpl.Subject, &tmpl.DNSNames, &tmpl.IPAddresses, &tmpl.EmailAddresses, &tmpl.URIs)
+		case "key_type":
+			keyType, errObj = object.AsString(value)
+		case "key":
+			key, errObj = asPrivateKey(value)
+		default:
+			return object.Errorf("value error: unknown tls.csr option %q", name)
+		}
+		if errObj != nil {
+			return errObj
+		}
+	}
+	if key == nil {
+		if key, errObj = newPrivateKey(keyType); errObj != nil {
+			return errObj
+		}
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+	if err != nil {
+		return object.NewError(err)
+	}
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return object.NewError(err)
+	}
+	return object.NewMap(map[string]object.Object{
+		"csr": object.NewString(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))),
+		"key": object.NewString(keyPEM),
+	})
+}
+
+// applySubject sets a subject or subject alternative name option shared by
+// generate and csr.
+func applySubject(
+	key string,
+	value object.Object,
+	subject *pkix.Name,
+	dnsNames *[]string,
+	ips *[]net.IP,
+	emails *[]string,
+	uris *[]*url.URL,
+) *object.Error {
+	if key == "common_name" {
+		name, err := object.AsString(value)
+		if err != nil {
+			return err
+		}
+		subject.CommonName = name
+		return nil
+	}
+	values, err := object.AsStringSlice(value)
+	if err != nil {
+		return err
+	}
+	switch key {
+	case "organization":
+		subject.Organization = values
+	case "dns_names":
+		*dnsNames = values
+	case "email_addresses":
+		*emails = values
+	case "ip_addresses":
+		for _, s := range values {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return object.Errorf("value error: invalid ip address %q", s)
+			}
+			*ips = append(*ips, ip)
+		}
+	case "uris":
+		for _, s := range values {
+			u, err := url.Parse(s)
+			if err != nil {
+				return object.Errorf("value error: invalid uri %q", s)
+			}
+			*uris = append(*uris, u)
+		}
+	}
+	return nil
+}
+
+func verifyChain(certs, roots []*x509.Certificate, dnsName string, at time.Time) error {
+	if len(certs) == 0 {
+		return errors.New("no certificates presented")
+	}
+	opts := x509.VerifyOptions{
+		DNSName:       dnsName,
+		CurrentTime:   at,
+		Intermediates: x509.NewCertPool(),
+	}
+	if len(roots) > 0 {
+		opts.Roots = x509.NewCertPool()
+		for _, root := range roots {
+			opts.Roots.AddCert(root)
+		}
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) > 0 {
+		return certs, nil
+	}
+	// Not PEM, so try DER
+	return x509.ParseCertificates(data)
+}
+
+// asCertificates converts a certificate, a list of certificates, or PEM data
+// to a slice of certificates.
+func asCertificates(obj object.Object) ([]*x509.Certificate, *object.Error) {
+	switch obj := obj.(type) {
+	case *Certificate:
+		return []*x509.Certificate{obj.cert}, nil
+	case *object.List:
+		var certs []*x509.Certificate
+		for _, item := range obj.Value() {
+			itemCerts, err := asCertificates(item)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, itemCerts...)
+		}
+		return certs, nil
+	case *object.String, *object.ByteSlice:
+		data, errObj := object.AsBytes(obj)
+		if errObj != nil {
+			return nil, errObj
+		}
+		certs, err := parseCertificates(data)
+		if err != nil {
+			return nil, object.Errorf("value error: %s", err)
+		}
+		return certs, nil
+	}
+	return nil, object.Errorf("type error: expected a tls.certificate, list, or PEM data (%s given)", obj.Type())
+}
+
+func asPrivateKey(obj object.Object) (crypto.Signer, *object.Error) {
+	data, errObj := object.AsBytes(obj)
+	if errObj != nil {
+		return nil, errObj
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, object.Errorf("value error: private key must be PEM encoded")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, object.Errorf("value error: %s", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, object.Errorf("value error: unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+func newPrivateKey(keyType string) (crypto.Signer, *object.Error) {
+	var key crypto.Signer
+	var err error
+	switch keyType {
+	case "ecdsa":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, object.Errorf("value error: key_type must be \"ecdsa\", \"rsa\", or \"ed25519\" (got %q)", keyType)
+	}
+	if err != nil {
+		return nil, object.NewError(err)
+	}
+	return key, nil
+}
+
+func encodePrivateKey(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+func certificateList(certs []*x509.Certificate) *object.List {
+	items := make([]object.Object, len(certs))
+	for i, cert := range certs {
+		items[i] = NewCertificate(cert)
+	}
+	return object.NewList(items)
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("tls", map[string]object.Object{
+		"csr":      object.NewBuiltin("csr", CSR),
+		"generate": object.NewBuiltin("generate", Generate),
+		"parse":    object.NewBuiltin("parse", Parse),
+		"probe":    object.NewBuiltin("probe", Probe),
+		"verify":   object.NewBuiltin("verify", Verify),
+	})
+}
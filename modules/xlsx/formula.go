@@ -0,0 +1,61 @@
+package xlsx
+
+import (
+	"fmt"
+
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+const FORMULA object.Type = "xlsx.formula"
+
+// Formula is a formula to be written to a cell, such as "SUM(A1:A10)".
+type Formula struct {
+	expr string
+}
+
+func (f *Formula) Type() object.Type {
+	return FORMULA
+}
+
+func (f *Formula) Inspect() string {
+	return fmt.Sprintf("xlsx.formula(%q)", "="+f.expr)
+}
+
+func (f *Formula) Interface() interface{} {
+	return "=" + f.expr
+}
+
+// Value returns the formula without a leading "=".
+func (f *Formula) Value() string {
+	return f.expr
+}
+
+func (f *Formula) Equals(other object.Object) object.Object {
+	o, ok := other.(*Formula)
+	return object.NewBool(ok && o.expr == f.expr)
+}
+
+func (f *Formula) IsTruthy() bool {
+	return true
+}
+
+func (f *Formula) Cost() int {
+	return 0
+}
+
+func (f *Formula) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", "="+f.expr)), nil
+}
+
+func (f *Formula) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", FORMULA, opType)
+}
+
+func (f *Formula) GetAttr(name string) (object.Object, bool) {
+	return nil, false
+}
+
+func (f *Formula) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", FORMULA, name)
+}
@@ -0,0 +1,776 @@
+package xlsx
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+	"github.com/xuri/excelize/v2"
+)
+
+const WORKBOOK object.Type = "xlsx.workbook"
+
+// defaultSheet is the name of the sheet in a new workbook.
+const defaultSheet = "Sheet1"
+
+// timeFormat is the number format given to cells holding times, unless a
+// style sets a different one.
+const timeFormat = "yyyy-mm-dd hh:mm:ss"
+
+// Workbook is a spreadsheet that is read from or written to.
+type Workbook struct {
+	file *excelize.File
+	// fresh is true until the default sheet of a new workbook is used, so
+	// that the first sheet written to can take its place.
+	fresh    bool
+	styles   map[cellStyle]int
+	numFmts  map[int]string
+	date1904 bool
+}
+
+// NewWorkbook returns a Workbook for the given file.
+func NewWorkbook(file *excelize.File) *Workbook {
+	w := &Workbook{
+		file:    file,
+		styles:  map[cellStyle]int{},
+		numFmts: map[int]string{},
+	}
+	if props, err := file.GetWorkbookProps(); err == nil && props.Date1904 != nil {
+		w.date1904 = *props.Date1904
+	}
+	return w
+}
+
+func (w *Workbook) Type() object.Type {
+	return WORKBOOK
+}
+
+func (w *Workbook) Inspect() string {
+	return fmt.Sprintf("xlsx.workbook(sheets=%s)", object.NewStringList(w.file.GetSheetList()).Inspect())
+}
+
+func (w *Workbook) Interface() interface{} {
+	return nil
+}
+
+// Value returns the underlying excelize file.
+func (w *Workbook) Value() *excelize.File {
+	return w.file
+}
+
+func (w *Workbook) Equals(other object.Object) object.Object {
+	return object.NewBool(w == other)
+}
+
+func (w *Workbook) IsTruthy() bool {
+	return true
+}
+
+func (w *Workbook) Cost() int {
+	return 8
+}
+
+func (w *Workbook) MarshalJSON() ([]byte, error) {
+	return nil, fmt.Errorf("type error: unable to marshal %s", WORKBOOK)
+}
+
+func (w *Workbook) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", WORKBOOK, opType)
+}
+
+func (w *Workbook) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", WORKBOOK, name)
+}
+
+func (w *Workbook) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "sheets":
+		return object.NewStringList(w.file.GetSheetList()), true
+	case "rows":
+		return object.NewBuiltin("xlsx.workbook.rows", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("xlsx.workbook.rows", 0, 1, args); err != nil {
+				return err
+			}
+			opts, err := parseReadOptions("xlsx.workbook.rows", args)
+			if err != nil {
+				return err
+			}
+			return w.Rows(opts)
+		}), true
+	case "get":
+		return object.NewBuiltin("xlsx.workbook.get", func(ctx context.Context, args ...object.Object) object.Object {
+			sheet, ref, err := cellArgs("xlsx.workbook.get", 2, args)
+			if err != nil {
+				return err
+			}
+			cellType, ioErr := w.file.GetCellType(sheet, ref)
+			if ioErr != nil {
+				return object.NewError(fmt.Errorf("xlsx error: %w", ioErr))
+			}
+			raw, ioErr := w.file.GetCellValue(sheet, ref, excelize.Options{RawCellValue: true})
+			if ioErr != nil {
+				return object.NewError(fmt.Errorf("xlsx error: %w", ioErr))
+			}
+			value, ioErr := w.cellValue(sheet, ref, cellType, raw)
+			if ioErr != nil {
+				return object.NewError(ioErr)
+			}
+			return value
+		}), true
+	case "calc":
+		return object.NewBuiltin("xlsx.workbook.calc", func(ctx context.Context, args ...object.Object) object.Object {
+			sheet, ref, err := cellArgs("xlsx.workbook.calc", 2, args)
+			if err != nil {
+				return err
+			}
+			result, ioErr := w.file.CalcCellValue(sheet, ref, excelize.Options{RawCellValue: true})
+			if ioErr != nil {
+				return object.NewError(fmt.Errorf("xlsx error: %w", ioErr))
+			}
+			return calcValue(result)
+		}), true
+	case "set":
+		return object.NewBuiltin("xlsx.workbook.set", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("xlsx.workbook.set", 3, 4, args); err != nil {
+				return err
+			}
+			sheet, ref, err := cellArgs("xlsx.workbook.set", len(args), args)
+			if err != nil {
+				return err
+			}
+			var style cellStyle
+			if len(args) == 4 {
+				if style, err = parseStyle(args[3]); err != nil {
+					return err
+				}
+			}
+			if err := w.ensureSheet(sheet); err != nil {
+				return err
+			}
+			if err := w.setCell(sheet, ref, args[2], style); err != nil {
+				return err
+			}
+			return object.Nil
+		}), true
+	case "write":
+		return object.NewBuiltin("xlsx.workbook.write", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.RequireRange("xlsx.workbook.write", 2, 3, args); err != nil {
+				return err
+			}
+			sheet, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			iter, err := object.AsIterator(args[1])
+			if err != nil {
+				return err
+			}
+			opts, err := parseWriteOptions("xlsx.workbook.write", args[2:])
+			if err != nil {
+				return err
+			}
+			count, err := w.Write(ctx, sheet, iter, opts)
+			if err != nil {
+				return err
+			}
+			return object.NewInt(count)
+		}), true
+	case "add_sheet":
+		return object.NewBuiltin("xlsx.workbook.add_sheet", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("xlsx.workbook.add_sheet", 1, args); err != nil {
+				return err
+			}
+			name, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			if index, _ := w.file.GetSheetIndex(name); index != -1 {
+				return object.Errorf("value error: sheet %q already exists", name)
+			}
+			if err := w.ensureSheet(name); err != nil {
+				return err
+			}
+			return object.Nil
+		}), true
+	case "bytes":
+		return object.NewBuiltin("xlsx.workbook.bytes", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("xlsx.workbook.bytes", 0, args); err != nil {
+				return err
+			}
+			buf, err := w.file.WriteToBuffer()
+			if err != nil {
+				return object.NewError(fmt.Errorf("xlsx error: %w", err))
+			}
+			return object.NewByteSlice(buf.Bytes())
+		}), true
+	case "save":
+		return object.NewBuiltin("xlsx.workbook.save", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("xlsx.workbook.save", 1, args); err != nil {
+				return err
+			}
+			path, err := object.AsString(args[0])
+			if err != nil {
+				return err
+			}
+			buf, ioErr := w.file.WriteToBuffer()
+			if ioErr != nil {
+				return object.NewError(fmt.Errorf("xlsx error: %w", ioErr))
+			}
+			if ioErr := ros.GetDefaultOS(ctx).WriteFile(path, buf.Bytes(), 0644); ioErr != nil {
+				return object.NewError(ioErr)
+			}
+			return object.Nil
+		}), true
+	case "close":
+		return object.NewBuiltin("xlsx.workbook.close", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("xlsx.workbook.close", 0, args); err != nil {
+				return err
+			}
+			if err := w.file.Close(); err != nil {
+				return object.NewError(err)
+			}
+			return object.Nil
+		}), true
+	}
+	return nil, false
+}
+
+// cellArgs returns the sheet name and cell reference given as the first two
+// of the arguments.
+func cellArgs(fn string, nargs int, args []object.Object) (string, string, *object.Error) {
+	if err := arg.Require(fn, nargs, args); err != nil {
+		return "", "", err
+	}
+	sheet, err := object.AsString(args[0])
+	if err != nil {
+		return "", "", err
+	}
+	ref, err := object.AsString(args[1])
+	if err != nil {
+		return "", "", err
+	}
+	if _, _, err := excelize.CellNameToCoordinates(ref); err != nil {
+		return "", "", object.Errorf("value error: invalid cell reference %q", ref)
+	}
+	return sheet, ref, nil
+}
+
+// sheetName returns the named sheet, or the first sheet if name is empty.
+func (w *Workbook) sheetName(name string) (string, *object.Error) {
+	if name == "" {
+		return w.file.GetSheetList()[0], nil
+	}
+	if index, _ := w.file.GetSheetIndex(name); index == -1 {
+		return "", object.Errorf("value error: workbook has no sheet %q", name)
+	}
+	return name, nil
+}
+
+// ensureSheet creates the named sheet if it doesn't exist. The default sheet
+// of a new workbook is renamed rather than left empty.
+func (w *Workbook) ensureSheet(name string) *object.Error {
+	if index, _ := w.file.GetSheetIndex(name); index != -1 {
+		if name == defaultSheet {
+			w.fresh = false
+		}
+		return nil
+	}
+	var err error
+	if w.fresh {
+		err = w.file.SetSheetName(defaultSheet, name)
+	} else {
+		_, err = w.file.NewSheet(name)
+	}
+	w.fresh = false
+	if err != nil {
+		return object.NewError(fmt.Errorf("xlsx error: %w", err))
+	}
+	return nil
+}
+
+// Rows returns a stream of the rows of a sheet. Empty rows are skipped.
+func (w *Workbook) Rows(opts readOptions) object.Object {
+	sheet, errObj := w.sheetName(opts.sheet)
+	if errObj != nil {
+		return errObj
+	}
+	rows, err := w.file.Rows(sheet)
+	if err != nil {
+		return object.NewError(fmt.Errorf("xlsx error: %w", err))
+	}
+	header := opts.columns
+	rowNum := 0
+	return object.NewStream(func(ctx context.Context) (object.Object, bool, error) {
+		for rows.Next() {
+			rowNum++
+			values, err := w.rowValues(rows, sheet, rowNum, opts.formatted)
+			if err != nil {
+				rows.Close()
+				return nil, false, err
+			}
+			if len(values) == 0 {
+				continue
+			}
+			if !opts.header {
+				return object.NewList(values), true, nil
+			}
+			if header == nil {
+				for i, value := range values {
+					name, ok := value.(*object.String)
+					if !ok || name.Value() == "" {
+						name = object.NewString(columnName(i + 1))
+					}
+					header = append(header, name.Value())
+				}
+				continue
+			}
+			m := make(map[string]object.Object, len(header))
+			for i, name := range header {
+				if i < len(values) {
+					m[name] = values[i]
+				} else {
+					m[name] = object.Nil
+				}
+			}
+			return object.NewMap(m), true, nil
+		}
+		if err := rows.Error(); err != nil {
+			return nil, false, fmt.Errorf("xlsx error: %w", err)
+		}
+		rows.Close()
+		return nil, false, nil
+	})
+}
+
+// rowValues returns the values of the current row. Trailing empty cells are
+// omitted, so an empty row has no values.
+func (w *Workbook) rowValues(rows *excelize.Rows, sheet string, rowNum int, formatted bool) ([]object.Object, error) {
+	var cells []string
+	var err error
+	if formatted {
+		cells, err = rows.Columns()
+	} else {
+		cells, err = rows.Columns(excelize.Options{RawCellValue: true})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("xlsx error: %w", err)
+	}
+	values := make([]object.Object, len(cells))
+	for i, cell := range cells {
+		ref, err := excelize.CoordinatesToCellName(i+1, rowNum)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case formatted && cell == "":
+			values[i], err = w.formulaValue(sheet, ref, true)
+		case formatted:
+			values[i] = object.NewString(cell)
+		default:
+			var cellType excelize.CellType
+			if cellType, err = w.file.GetCellType(sheet, ref); err != nil {
+				return nil, fmt.Errorf("xlsx error: %w", err)
+			}
+			values[i], err = w.cellValue(sheet, ref, cellType, cell)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for len(values) > 0 && isEmpty(values[len(values)-1]) {
+		values = values[:len(values)-1]
+	}
+	return values, nil
+}
+
+func isEmpty(value object.Object) bool {
+	switch value := value.(type) {
+	case *object.NilType:
+		return true
+	case *object.String:
+		return value.Value() == ""
+	}
+	return false
+}
+
+// formulaValue returns the calculated value of an empty cell holding a
+// formula, as written by this module, since no result is stored with the
+// formula. Returns nil, or an empty string if formatted is true, for cells
+// without a formula.
+func (w *Workbook) formulaValue(sheet, ref string, formatted bool) (object.Object, error) {
+	formula, err := w.file.GetCellFormula(sheet, ref)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx error: %w", err)
+	}
+	if formula == "" {
+		if formatted {
+			return object.NewString(""), nil
+		}
+		return object.Nil, nil
+	}
+	result, err := w.file.CalcCellValue(sheet, ref, excelize.Options{RawCellValue: !formatted})
+	if err != nil {
+		return nil, fmt.Errorf("xlsx error: %w", err)
+	}
+	if formatted {
+		return object.NewString(result), nil
+	}
+	return calcValue(result), nil
+}
+
+// cellValue converts the raw value of a cell to a Risor object, based on its
+// type and number format.
+func (w *Workbook) cellValue(sheet, ref string, cellType excelize.CellType, raw string) (object.Object, error) {
+	if raw == "" {
+		return w.formulaValue(sheet, ref, false)
+	}
+	switch cellType {
+	case excelize.CellTypeBool:
+		return object.NewBool(raw == "1" || strings.EqualFold(raw, "true")), nil
+	case excelize.CellTypeSharedString, excelize.CellTypeInlineString,
+		excelize.CellTypeFormula, excelize.CellTypeError:
+		return object.NewString(raw), nil
+	case excelize.CellTypeDate:
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return object.NewString(raw), nil
+		}
+		return object.NewTime(t), nil
+	}
+	number, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return object.NewString(raw), nil
+	}
+	isDate, err := w.isDateCell(sheet, ref)
+	if err != nil {
+		return nil, err
+	}
+	if isDate {
+		t, err := excelize.ExcelDateToTime(number, w.date1904)
+		if err != nil {
+			return nil, fmt.Errorf("xlsx error: %w", err)
+		}
+		// Round to the nearest millisecond, as dates are stored as fractions
+		// of a day
+		return object.NewTime(t.Round(time.Millisecond)), nil
+	}
+	return numberValue(number), nil
+}
+
+// numberValue returns an int for whole numbers and a float otherwise.
+func numberValue(number float64) object.Object {
+	if number == math.Trunc(number) && math.Abs(number) < 1<<53 {
+		return object.NewInt(int64(number))
+	}
+	return object.NewFloat(number)
+}
+
+// calcValue converts the result of a calculated formula to a Risor object.
+func calcValue(result string) object.Object {
+	switch result {
+	case "":
+		return object.Nil
+	case "TRUE":
+		return object.True
+	case "FALSE":
+		return object.False
+	}
+	if number, err := strconv.ParseFloat(result, 64); err == nil {
+		return numberValue(number)
+	}
+	return object.NewString(result)
+}
+
+// isDateCell returns true if the cell has a date or time number format.
+func (w *Workbook) isDateCell(sheet, ref string) (bool, error) {
+	id, err := w.file.GetCellStyle(sheet, ref)
+	if err != nil {
+		return false, fmt.Errorf("xlsx error: %w", err)
+	}
+	format, ok := w.numFmts[id]
+	if !ok {
+		style, err := w.file.GetStyle(id)
+		if err != nil {
+			return false, fmt.Errorf("xlsx error: %w", err)
+		}
+		if style.CustomNumFmt != nil {
+			format = *style.CustomNumFmt
+		} else {
+			format = builtinFormat(style.NumFmt)
+		}
+		w.numFmts[id] = format
+	}
+	return isDateFormat(format), nil
+}
+
+// builtinFormat returns a date format for the built-in number formats that
+// display dates and times.
+func builtinFormat(id int) string {
+	switch {
+	case id >= 14 && id <= 17, id == 22:
+		return "yyyy-mm-dd"
+	case id >= 18 && id <= 21, id >= 45 && id <= 47:
+		return "hh:mm:ss"
+	}
+	return ""
+}
+
+// isDateFormat returns true if the number format displays a date or time.
+// Quoted text, escaped characters, and bracketed sections such as colors
+// are ignored.
+func isDateFormat(format string) bool {
+	var quoted, bracketed, escaped bool
+	for _, c := range strings.ToLower(format) {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted:
+			quoted = c != '"'
+		case bracketed:
+			bracketed = c != ']'
+		case c == '"':
+			quoted = true
+		case c == '[':
+			bracketed = true
+		case c == '\\':
+			escaped = true
+		case strings.ContainsRune("ymdhs", c):
+			return true
+		}
+	}
+	return false
+}
+
+// columnName returns the letters naming a column, starting from 1.
+func columnName(col int) string {
+	name, _ := excelize.ColumnNumberToName(col)
+	return name
+}
+
+// setCell sets the value of a cell and applies a style. Times are given a
+// date format unless the style has its own number format.
+func (w *Workbook) setCell(sheet, ref string, value object.Object, style cellStyle) *object.Error {
+	var err error
+	switch value := value.(type) {
+	case *Formula:
+		err = w.file.SetCellFormula(sheet, ref, value.Value())
+	case *object.NilType:
+		err = w.file.SetCellValue(sheet, ref, nil)
+	case *object.Int:
+		err = w.file.SetCellValue(sheet, ref, value.Value())
+	case *object.Float:
+		err = w.file.SetCellValue(sheet, ref, value.Value())
+	case *object.Bool:
+		err = w.file.SetCellValue(sheet, ref, value.Value())
+	case *object.String:
+		err = w.file.SetCellValue(sheet, ref, value.Value())
+	case *object.ByteSlice:
+		err = w.file.SetCellValue(sheet, ref, string(value.Value()))
+	case *object.Time:
+		err = w.file.SetCellValue(sheet, ref, value.Value())
+		if style.numFmt == "" {
+			style.numFmt = timeFormat
+		}
+	default:
+		err = w.file.SetCellValue(sheet, ref, value.Inspect())
+	}
+	if err != nil {
+		return object.NewError(fmt.Errorf("xlsx error: %w", err))
+	}
+	if style == (cellStyle{}) {
+		return nil
+	}
+	id, errObj := w.styleID(style)
+	if errObj != nil {
+		return errObj
+	}
+	if err := w.file.SetCellStyle(sheet, ref, ref, id); err != nil {
+		return object.NewError(fmt.Errorf("xlsx error: %w", err))
+	}
+	return nil
+}
+
+// styleID returns the id of a style, registering it with the workbook the
+// first time it is used.
+func (w *Workbook) styleID(style cellStyle) (int, *object.Error) {
+	if id, ok := w.styles[style]; ok {
+		return id, nil
+	}
+	id, err := w.file.NewStyle(style.excelize())
+	if err != nil {
+		return 0, object.NewError(fmt.Errorf("xlsx error: %w", err))
+	}
+	w.styles[style] = id
+	return id, nil
+}
+
+// Write appends rows to a sheet, after any rows it already contains, and
+// returns the number of rows written. Rows are maps or lists. A header row
+// is written first if the sheet is empty.
+func (w *Workbook) Write(ctx context.Context, sheet string, iter object.Iterator, opts writeOptions) (int64, *object.Error) {
+	if err := w.ensureSheet(sheet); err != nil {
+		return 0, err
+	}
+	existing, err := w.file.GetRows(sheet)
+	if err != nil {
+		return 0, object.NewError(fmt.Errorf("xlsx error: %w", err))
+	}
+	rowNum := len(existing)
+	empty := rowNum == 0
+	columns := opts.columns
+	header := opts.header
+	widths := map[int]int{}
+	isMaps := false
+	var count int64
+
+	writeRow := func(values []object.Object, isHeader bool) *object.Error {
+		rowNum++
+		for i, value := range values {
+			col := i + 1
+			ref, err := excelize.CoordinatesToCellName(col, rowNum)
+			if err != nil {
+				return object.NewError(fmt.Errorf("xlsx error: %w", err))
+			}
+			style := opts.headerStyle
+			if !isHeader {
+				style = opts.columnStyle(col, columns, header)
+			}
+			if err := w.setCell(sheet, ref, value, style); err != nil {
+				return err
+			}
+			widths[col] = max(widths[col], displayWidth(value))
+		}
+		return nil
+	}
+	writeHeader := func() *object.Error {
+		if !empty {
+			return nil
+		}
+		if err := writeRow(toObjects(header), true); err != nil {
+			return err
+		}
+		if opts.freeze {
+			if err := w.file.SetPanes(sheet, &excelize.Panes{
+				Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft",
+			}); err != nil {
+				return object.NewError(fmt.Errorf("xlsx error: %w", err))
+			}
+		}
+		return nil
+	}
+
+	first := true
+	for {
+		value, ok := iter.Next(ctx)
+		if !ok {
+			break
+		}
+		var values []object.Object
+		switch row := value.(type) {
+		case *object.Map:
+			if first {
+				isMaps = true
+				if columns == nil {
+					columns = row.StringKeys()
+					sort.Strings(columns)
+				}
+				if header == nil && opts.headerMode != "none" {
+					header = columns
+				}
+			} else if !isMaps {
+				return count, object.Errorf("type error: xlsx rows must all be lists or all be maps (row %d is a map)", count)
+			}
+			values = make([]object.Object, len(columns))
+			for i, column := range columns {
+				values[i] = row.Get(column)
+			}
+		case *object.List:
+			if isMaps {
+				return count, object.Errorf("type error: xlsx rows must all be lists or all be maps (row %d is a list)", count)
+			}
+			values = row.Value()
+			if first && header == nil && opts.headerMode == "first_row" {
+				// The first list holds the column names
+				for _, name := range values {
+					header = append(header, cellString(name))
+				}
+				first = false
+				if err := writeHeader(); err != nil {
+					return count, err
+				}
+				continue
+			}
+		default:
+			return count, object.Errorf("type error: xlsx rows must be lists or maps (%s given)", value.Type())
+		}
+		if first && header != nil {
+			if err := writeHeader(); err != nil {
+				return count, err
+			}
+		}
+		first = false
+		if err := writeRow(values, false); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if s, ok := iter.(interface{ Err() error }); ok && s.Err() != nil {
+		return count, object.NewError(s.Err())
+	}
+	if opts.filter && header != nil && empty && count > 0 {
+		last, _ := excelize.CoordinatesToCellName(len(header), rowNum)
+		if err := w.file.AutoFilter(sheet, "A1:"+last, nil); err != nil {
+			return count, object.NewError(fmt.Errorf("xlsx error: %w", err))
+		}
+	}
+	for col, chars := range widths {
+		width, ok := opts.width(col, columns, header)
+		if !ok {
+			width = float64(min(max(chars+2, 8), 60))
+		}
+		name := columnName(col)
+		if err := w.file.SetColWidth(sheet, name, name, width); err != nil {
+			return count, object.NewError(fmt.Errorf("xlsx error: %w", err))
+		}
+	}
+	return count, nil
+}
+
+// cellString returns the text of a value used as a column name.
+func cellString(value object.Object) string {
+	if s, ok := value.(*object.String); ok {
+		return s.Value()
+	}
+	return value.Inspect()
+}
+
+func toObjects(values []string) []object.Object {
+	result := make([]object.Object, len(values))
+	for i, value := range values {
+		result[i] = object.NewString(value)
+	}
+	return result
+}
+
+// displayWidth estimates the number of characters needed to display a value.
+func displayWidth(value object.Object) int {
+	switch value := value.(type) {
+	case *object.String:
+		return len([]rune(value.Value()))
+	case *object.Time:
+		return len(timeFormat)
+	case *Formula:
+		return 10
+	case *object.NilType:
+		return 0
+	}
+	return len(value.Inspect())
+}
@@ -0,0 +1,393 @@
+package xlsx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
+	"github.com/xuri/excelize/v2"
+)
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// readOptions holds the options for reading the rows of a sheet.
+type readOptions struct {
+	sheet     string
+	header    bool
+	columns   []string
+	formatted bool
+}
+
+func parseReadOptions(fn string, args []object.Object) (readOptions, *object.Error) {
+	opts := readOptions{header: true}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "sheet":
+			opts.sheet, err = object.AsString(value)
+		case "header":
+			if list, ok := value.(*object.List); ok {
+				opts.columns, err = object.AsStringSlice(list)
+			} else {
+				opts.header, err = object.AsBool(value)
+			}
+		case "formatted":
+			opts.formatted, err = object.AsBool(value)
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// writeOptions holds the options for writing rows to a sheet.
+type writeOptions struct {
+	sheet       string
+	columns     []string
+	header      []string
+	headerMode  string
+	headerStyle cellStyle
+	styles      map[string]cellStyle
+	widths      map[string]float64
+	freeze      bool
+	filter      bool
+}
+
+func parseWriteOptions(fn string, args []object.Object, allowed ...string) (writeOptions, *object.Error) {
+	opts := writeOptions{headerStyle: cellStyle{bold: true}}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "columns":
+			opts.columns, err = object.AsStringSlice(value)
+		case "header":
+			if list, ok := value.(*object.List); ok {
+				opts.header, err = object.AsStringSlice(list)
+			} else {
+				var header bool
+				if header, err = object.AsBool(value); err == nil && header {
+					opts.headerMode = "first_row"
+				} else if err == nil {
+					opts.headerMode = "none"
+				}
+			}
+		case "header_style":
+			opts.headerStyle, err = parseStyle(value)
+		case "styles":
+			var styles *object.Map
+			if styles, err = object.AsMap(value); err != nil {
+				break
+			}
+			opts.styles = map[string]cellStyle{}
+			for column, value := range styles.Value() {
+				if opts.styles[column], err = parseStyle(value); err != nil {
+					break
+				}
+			}
+		case "widths":
+			var widths *object.Map
+			if widths, err = object.AsMap(value); err != nil {
+				break
+			}
+			opts.widths = map[string]float64{}
+			for column, value := range widths.Value() {
+				if opts.widths[column], err = object.AsFloat(value); err != nil {
+					break
+				}
+			}
+		case "freeze":
+			opts.freeze, err = object.AsBool(value)
+		case "filter":
+			opts.filter, err = object.AsBool(value)
+		case "sheet":
+			if contains(allowed, key) {
+				opts.sheet, err = object.AsString(value)
+				break
+			}
+			fallthrough
+		default:
+			err = object.Errorf("value error: unknown %s option %q", fn, key)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// columnKeys returns the names a column may be referred to by in the styles
+// and widths options: its key, its header, and its letter.
+func columnKeys(col int, columns, header []string) []string {
+	var keys []string
+	if col <= len(columns) {
+		keys = append(keys, columns[col-1])
+	}
+	if col <= len(header) {
+		keys = append(keys, header[col-1])
+	}
+	return append(keys, columnName(col))
+}
+
+func (opts writeOptions) columnStyle(col int, columns, header []string) cellStyle {
+	for _, key := range columnKeys(col, columns, header) {
+		if style, ok := opts.styles[key]; ok {
+			return style
+		}
+	}
+	return cellStyle{}
+}
+
+func (opts writeOptions) width(col int, columns, header []string) (float64, bool) {
+	for _, key := range columnKeys(col, columns, header) {
+		if width, ok := opts.widths[key]; ok {
+			return width, true
+		}
+	}
+	return 0, false
+}
+
+// cellStyle describes the formatting of a cell.
+type cellStyle struct {
+	bold      bool
+	italic    bool
+	underline bool
+	wrap      bool
+	color     string
+	fill      string
+	numFmt    string
+	align     string
+}
+
+func parseStyle(obj object.Object) (cellStyle, *object.Error) {
+	var style cellStyle
+	m, err := object.AsMap(obj)
+	if err != nil {
+		return style, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "bold":
+			style.bold, err = object.AsBool(value)
+		case "italic":
+			style.italic, err = object.AsBool(value)
+		case "underline":
+			style.underline, err = object.AsBool(value)
+		case "wrap":
+			style.wrap, err = object.AsBool(value)
+		case "color", "fill":
+			var color string
+			if color, err = object.AsString(value); err == nil && !colorPattern.MatchString(color) {
+				err = object.Errorf("value error: invalid color %q (expected \"#rrggbb\")", color)
+			}
+			if key == "color" {
+				style.color = color
+			} else {
+				style.fill = color
+			}
+		case "number_format":
+			style.numFmt, err = object.AsString(value)
+		case "align":
+			if style.align, err = object.AsString(value); err == nil {
+				switch style.align {
+				case "left", "center", "right":
+				default:
+					err = object.Errorf("value error: align must be \"left\", \"center\", or \"right\" (got %q)", style.align)
+				}
+			}
+		default:
+			err = object.Errorf("value error: unknown style option %q", key)
+		}
+		if err != nil {
+			return style, err
+		}
+	}
+	return style, nil
+}
+
+func (s cellStyle) excelize() *excelize.Style {
+	style := &excelize.Style{
+		Font: &excelize.Font{
+			Bold:   s.bold,
+			Italic: s.italic,
+			Color:  s.color,
+		},
+	}
+	if s.underline {
+		style.Font.Underline = "single"
+	}
+	if s.fill != "" {
+		style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{s.fill}}
+	}
+	if s.numFmt != "" {
+		numFmt := s.numFmt
+		style.CustomNumFmt = &numFmt
+	}
+	if s.align != "" || s.wrap {
+		style.Alignment = &excelize.Alignment{Horizontal: s.align, WrapText: s.wrap}
+	}
+	return style
+}
+
+// Open opens a workbook from a path, a byte_slice, or a reader.
+func Open(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("xlsx.open", 1, args); err != nil {
+		return err
+	}
+	w, err := open(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return w
+}
+
+func open(ctx context.Context, src object.Object) (*Workbook, *object.Error) {
+	var data []byte
+	switch src := src.(type) {
+	case *object.String:
+		var err error
+		if data, err = ros.GetDefaultOS(ctx).ReadFile(src.Value()); err != nil {
+			return nil, object.NewError(err)
+		}
+	case *object.ByteSlice:
+		data = src.Value()
+	default:
+		r, errObj := object.AsReader(src)
+		if errObj != nil {
+			return nil, errObj
+		}
+		var err error
+		if data, err = io.ReadAll(r); err != nil {
+			return nil, object.NewError(err)
+		}
+	}
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, object.NewError(fmt.Errorf("xlsx error: %w", err))
+	}
+	return NewWorkbook(file), nil
+}
+
+// New creates an empty workbook.
+func New(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("xlsx.new", 0, args); err != nil {
+		return err
+	}
+	w := NewWorkbook(excelize.NewFile())
+	w.fresh = true
+	return w
+}
+
+// Reader returns a stream of the rows of a sheet in a workbook.
+func Reader(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("xlsx.reader", 1, 2, args); err != nil {
+		return err
+	}
+	opts, err := parseReadOptions("xlsx.reader", args[1:])
+	if err != nil {
+		return err
+	}
+	w, err := open(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return w.Rows(opts)
+}
+
+// Write writes rows to a new workbook with a single sheet, and returns the
+// number of rows written.
+func Write(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("xlsx.write", 2, 3, args); err != nil {
+		return err
+	}
+	iter, err := object.AsIterator(args[1])
+	if err != nil {
+		return err
+	}
+	opts, err := parseWriteOptions("xlsx.write", args[2:], "sheet")
+	if err != nil {
+		return err
+	}
+	if opts.sheet == "" {
+		opts.sheet = defaultSheet
+	}
+	w := New(ctx).(*Workbook)
+	defer w.file.Close()
+	count, err := w.Write(ctx, opts.sheet, iter, opts)
+	if err != nil {
+		return err
+	}
+	if path, ok := args[0].(*object.String); ok {
+		buf, ioErr := w.file.WriteToBuffer()
+		if ioErr != nil {
+			return object.NewError(fmt.Errorf("xlsx error: %w", ioErr))
+		}
+		if ioErr := ros.GetDefaultOS(ctx).WriteFile(path.Value(), buf.Bytes(), 0644); ioErr != nil {
+			return object.NewError(ioErr)
+		}
+		return object.NewInt(count)
+	}
+	dst, err := object.AsWriter(args[0])
+	if err != nil {
+		return err
+	}
+	if ioErr := w.file.Write(dst); ioErr != nil {
+		return object.NewError(fmt.Errorf("xlsx error: %w", ioErr))
+	}
+	return object.NewInt(count)
+}
+
+// NewFormula creates a formula to be written to a cell.
+func NewFormula(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("xlsx.formula", 1, args); err != nil {
+		return err
+	}
+	expr, err := object.AsString(args[0])
+	if err != nil {
+		return err
+	}
+	expr = strings.TrimPrefix(strings.TrimSpace(expr), "=")
+	if expr == "" {
+		return object.Errorf("value error: formula must not be empty")
+	}
+	return &Formula{expr: expr}
+}
+
+func Module() *object.Module {
+	return object.NewBuiltinsModule("xlsx", map[string]object.Object{
+		"formula": object.NewBuiltin("formula", NewFormula),
+		"new":     object.NewBuiltin("new", New),
+		"open":    object.NewBuiltin("open", Open),
+		"reader":  object.NewBuiltin("reader", Reader),
+		"write":   object.NewBuiltin("write", Write),
+	})
+}
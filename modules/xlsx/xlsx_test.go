@@ -0,0 +1,174 @@
+package xlsx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func call(t *testing.T, w *Workbook, name string, args ...object.Object) object.Object {
+	t.Helper()
+	fn, ok := w.GetAttr(name)
+	require.True(t, ok)
+	return fn.(*object.Builtin).Call(context.Background(), args...)
+}
+
+func collect(t *testing.T, stream object.Object) []object.Object {
+	t.Helper()
+	s, ok := stream.(*object.Stream)
+	require.True(t, ok, "unexpected result: %s", stream.Inspect())
+	list, ok := s.Collect(context.Background()).(*object.List)
+	require.True(t, ok)
+	return list.Value()
+}
+
+func TestWriteAndRead(t *testing.T) {
+	ctx := context.Background()
+	when := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	rows := object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{
+			"name":   object.NewString("widget"),
+			"price":  object.NewFloat(2.5),
+			"qty":    object.NewInt(4),
+			"active": object.True,
+			"added":  object.NewTime(when),
+		}),
+		object.NewMap(map[string]object.Object{
+			"name":   object.NewString("gadget"),
+			"price":  object.NewFloat(10),
+			"qty":    object.NewInt(1),
+			"active": object.False,
+			"added":  object.Nil,
+		}),
+	})
+	buf := object.NewBuffer(nil)
+	result := Write(ctx, buf, rows, object.NewMap(map[string]object.Object{
+		"sheet":   object.NewString("Products"),
+		"columns": object.NewStringList([]string{"name", "price", "qty", "active", "added"}),
+		"styles": object.NewMap(map[string]object.Object{
+			"price": object.NewMap(map[string]object.Object{"number_format": object.NewString("0.00")}),
+		}),
+		"freeze": object.True,
+		"filter": object.True,
+	}))
+	require.Equal(t, object.NewInt(2), result)
+
+	w, err := open(ctx, object.NewByteSlice(buf.Value().Bytes()))
+	require.Nil(t, err)
+	sheets, _ := w.GetAttr("sheets")
+	require.Equal(t, object.NewStringList([]string{"Products"}), sheets)
+
+	read := collect(t, call(t, w, "rows"))
+	require.Len(t, read, 2)
+	require.Equal(t, object.NewMap(map[string]object.Object{
+		"name":   object.NewString("widget"),
+		"price":  object.NewFloat(2.5),
+		"qty":    object.NewInt(4),
+		"active": object.True,
+		"added":  object.NewTime(when),
+	}), read[0])
+	require.Equal(t, object.NewInt(10), read[1].(*object.Map).Get("price"))
+	require.Equal(t, object.Nil, read[1].(*object.Map).Get("added"))
+
+	// Formatted values are the text displayed for each cell
+	read = collect(t, call(t, w, "rows", object.NewMap(map[string]object.Object{
+		"header":    object.False,
+		"formatted": object.True,
+	})))
+	require.Len(t, read, 3)
+	require.Equal(t, object.NewStringList([]string{"gadget", "10.00", "1", "FALSE"}), read[2])
+	require.Equal(t, object.NewString("2024-03-01 09:30:00"), read[1].(*object.List).Value()[4])
+}
+
+func TestWorkbook(t *testing.T) {
+	ctx := context.Background()
+	w := New(ctx).(*Workbook)
+	result := call(t, w, "write", object.NewString("Totals"), object.NewList([]object.Object{
+		object.NewStringList([]string{"item", "amount"}),
+		object.NewList([]object.Object{object.NewString("a"), object.NewInt(3)}),
+		object.NewList([]object.Object{object.NewString("b"), object.NewInt(4)}),
+	}), object.NewMap(map[string]object.Object{"header": object.True}))
+	require.Equal(t, object.NewInt(2), result)
+
+	// Rows written later are appended after the existing rows
+	result = call(t, w, "write", object.NewString("Totals"), object.NewList([]object.Object{
+		object.NewList([]object.Object{object.NewString("c"), object.NewInt(5)}),
+	}))
+	require.Equal(t, object.NewInt(1), result)
+
+	sum := NewFormula(ctx, object.NewString("=SUM(B2:B4)"))
+	require.Equal(t, `xlsx.formula("=SUM(B2:B4)")`, sum.Inspect())
+	require.Equal(t, object.Nil, call(t, w, "set", object.NewString("Totals"), object.NewString("B5"), sum,
+		object.NewMap(map[string]object.Object{"bold": object.True})))
+	require.Equal(t, object.NewInt(12), call(t, w, "calc", object.NewString("Totals"), object.NewString("B5")))
+	require.Equal(t, object.Nil, call(t, w, "add_sheet", object.NewString("Notes")))
+	require.Equal(t, object.Nil, call(t, w, "set", object.NewString("Notes"), object.NewString("A1"), object.NewString("draft")))
+
+	sheets, _ := w.GetAttr("sheets")
+	require.Equal(t, object.NewStringList([]string{"Totals", "Notes"}), sheets)
+	require.Equal(t, `xlsx.workbook(sheets=["Totals", "Notes"])`, w.Inspect())
+
+	data, ok := call(t, w, "bytes").(*object.ByteSlice)
+	require.True(t, ok)
+	read := collect(t, Reader(ctx, data, object.NewMap(map[string]object.Object{
+		"sheet":  object.NewString("Totals"),
+		"header": object.NewStringList([]string{"label", "value"}),
+	})))
+	require.Len(t, read, 5)
+	require.Equal(t, object.NewMap(map[string]object.Object{
+		"label": object.NewString("c"),
+		"value": object.NewInt(5),
+	}), read[3])
+	// Formulas written by the module are calculated when read
+	require.Equal(t, object.NewMap(map[string]object.Object{
+		"label": object.Nil,
+		"value": object.NewInt(12),
+	}), read[4])
+
+	reopened, errObj := open(ctx, data)
+	require.Nil(t, errObj)
+	require.Equal(t, object.NewString("draft"), call(t, reopened, "get", object.NewString("Notes"), object.NewString("A1")))
+	require.Equal(t, object.Nil, call(t, reopened, "get", object.NewString("Notes"), object.NewString("C9")))
+}
+
+func TestDateFormat(t *testing.T) {
+	require.True(t, isDateFormat("yyyy-mm-dd"))
+	require.True(t, isDateFormat("[$-409]h:mm AM/PM"))
+	require.False(t, isDateFormat("0.00"))
+	require.False(t, isDateFormat(`#,##0 "days"`))
+	require.False(t, isDateFormat("[Red]0.00"))
+	require.False(t, isDateFormat("General"))
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	w := New(ctx).(*Workbook)
+	tests := []struct {
+		result object.Object
+		err    string
+	}{
+		{Reader(ctx, object.NewByteSlice([]byte("not a workbook"))), "xlsx error: zip: not a valid zip file"},
+		{call(t, w, "rows", object.NewMap(map[string]object.Object{"sheet": object.NewString("Missing")})),
+			`value error: workbook has no sheet "Missing"`},
+		{call(t, w, "get", object.NewString("Sheet1"), object.NewString("1A")), `value error: invalid cell reference "1A"`},
+		{call(t, w, "set", object.NewString("Sheet1"), object.NewString("A1"), object.NewInt(1),
+			object.NewMap(map[string]object.Object{"fill": object.NewString("red")})),
+			`value error: invalid color "red" (expected "#rrggbb")`},
+		{call(t, w, "write", object.NewString("Sheet1"), object.NewList([]object.Object{
+			object.NewStringList([]string{"a"}),
+			object.NewMap(map[string]object.Object{"a": object.NewInt(1)}),
+		})), "type error: xlsx rows must all be lists or all be maps (row 1 is a map)"},
+		{call(t, w, "write", object.NewString("Sheet1"), object.NewList(nil),
+			object.NewMap(map[string]object.Object{"sheet": object.NewString("x")})),
+			`value error: unknown xlsx.workbook.write option "sheet"`},
+		{NewFormula(ctx, object.NewString("=")), "value error: formula must not be empty"},
+	}
+	for _, tt := range tests {
+		errObj, ok := tt.result.(*object.Error)
+		require.True(t, ok, "unexpected result: %s", tt.result.Inspect())
+		require.Equal(t, tt.err, errObj.Message().Value())
+	}
+}
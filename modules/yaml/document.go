@@ -0,0 +1,425 @@
+package yaml
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/risor-io/risor/internal/arg"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+	"gopkg.in/yaml.v3"
+)
+
+const DOCUMENT object.Type = "yaml.document"
+
+// Document is a parsed YAML document that can be edited and encoded again
+// while preserving its comments, anchors, aliases, key order, and scalar
+// styles.
+type Document struct {
+	node   *yaml.Node
+	indent int
+}
+
+func (d *Document) Type() object.Type {
+	return DOCUMENT
+}
+
+func (d *Document) Inspect() string {
+	return "yaml.document()"
+}
+
+func (d *Document) Interface() interface{} {
+	return d.node
+}
+
+func (d *Document) IsTruthy() bool {
+	return root(d.node) != nil
+}
+
+func (d *Document) Cost() int {
+	return 8
+}
+
+func (d *Document) MarshalJSON() ([]byte, error) {
+	var value interface{}
+	if err := d.node.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalize(value))
+}
+
+func (d *Document) RunOperation(opType op.BinaryOpType, right object.Object) object.Object {
+	return object.Errorf("eval error: unsupported operation for %s: %v", DOCUMENT, opType)
+}
+
+func (d *Document) Equals(other object.Object) object.Object {
+	if d == other {
+		return object.True
+	}
+	return object.False
+}
+
+func (d *Document) SetAttr(name string, value object.Object) error {
+	return fmt.Errorf("attribute error: %s object has no attribute %q", DOCUMENT, name)
+}
+
+func (d *Document) GetAttr(name string) (object.Object, bool) {
+	switch name {
+	case "get":
+		return object.NewBuiltin("yaml.document.get", d.Get), true
+	case "has":
+		return object.NewBuiltin("yaml.document.has", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("yaml.document.has", 1, args); err != nil {
+				return err
+			}
+			path, err := parsePath(args[0])
+			if err != nil {
+				return err
+			}
+			return object.NewBool(lookup(d.node, path) != nil)
+		}), true
+	case "set":
+		return object.NewBuiltin("yaml.document.set", d.Set), true
+	case "delete":
+		return object.NewBuiltin("yaml.document.delete", d.Delete), true
+	case "value":
+		return object.NewBuiltin("yaml.document.value", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("yaml.document.value", 0, args); err != nil {
+				return err
+			}
+			return d.Value()
+		}), true
+	case "string":
+		return object.NewBuiltin("yaml.document.string", func(ctx context.Context, args ...object.Object) object.Object {
+			if err := arg.Require("yaml.document.string", 0, args); err != nil {
+				return err
+			}
+			s, err := encode([]object.Object{d}, encodeOptions{indent: d.indent})
+			if err != nil {
+				return object.NewError(err)
+			}
+			return object.NewString(s)
+		}), true
+	}
+	return nil, false
+}
+
+// Value returns the contents of the document as Risor values.
+func (d *Document) Value() object.Object {
+	return nodeToObject(root(d.node))
+}
+
+// Get returns the value at the given path, or the default value (nil unless
+// given) if the path doesn't exist.
+func (d *Document) Get(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("yaml.document.get", 1, 2, args); err != nil {
+		return err
+	}
+	path, err := parsePath(args[0])
+	if err != nil {
+		return err
+	}
+	node := lookup(d.node, path)
+	if node == nil {
+		if len(args) == 2 {
+			return args[1]
+		}
+		return object.Nil
+	}
+	return nodeToObject(node)
+}
+
+// Set replaces the value at the given path, creating intermediate maps as
+// needed. Comments attached to a replaced value are kept.
+func (d *Document) Set(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("yaml.document.set", 2, args); err != nil {
+		return err
+	}
+	path, err := parsePath(args[0])
+	if err != nil {
+		return err
+	}
+	value := &yaml.Node{}
+	if err := value.Encode(args[1].Interface()); err != nil {
+		return object.NewError(err)
+	}
+	if len(path) == 0 {
+		if len(d.node.Content) == 0 {
+			d.node.Content = []*yaml.Node{value}
+		} else {
+			replace(d.node.Content[0], value)
+		}
+		return object.Nil
+	}
+	if len(d.node.Content) == 0 {
+		d.node.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	parent := root(d.node)
+	for i, elem := range path[:len(path)-1] {
+		next := child(parent, elem)
+		if next == nil {
+			if parent.Kind != yaml.MappingNode {
+				return object.Errorf("key error: %q not found", joinPath(path[:i+1]))
+			}
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			parent.Content = append(parent.Content, keyNode(elem), next)
+		}
+		parent = resolve(next)
+	}
+	last := path[len(path)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		if i := mappingIndex(parent, last); i >= 0 {
+			replace(parent.Content[i+1], value)
+		} else {
+			parent.Content = append(parent.Content, keyNode(last), value)
+		}
+	case yaml.SequenceNode:
+		index, ok := sequenceIndex(parent, last)
+		switch {
+		case ok:
+			replace(parent.Content[index], value)
+		case index == len(parent.Content):
+			parent.Content = append(parent.Content, value)
+		default:
+			return object.Errorf("index error: index out of range: %s", last)
+		}
+	default:
+		return object.Errorf("type error: cannot set %q on a scalar value", joinPath(path))
+	}
+	return object.Nil
+}
+
+// Delete removes the value at the given path and returns true if it existed.
+func (d *Document) Delete(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.Require("yaml.document.delete", 1, args); err != nil {
+		return err
+	}
+	path, err := parsePath(args[0])
+	if err != nil {
+		return err
+	}
+	if len(path) == 0 {
+		return object.Errorf("value error: yaml.document.delete requires a non-empty path")
+	}
+	parent := lookup(d.node, path[:len(path)-1])
+	if parent == nil {
+		return object.False
+	}
+	last := path[len(path)-1]
+	switch parent.Kind {
+	case yaml.MappingNode:
+		if i := mappingIndex(parent, last); i >= 0 {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return object.True
+		}
+	case yaml.SequenceNode:
+		if index, ok := sequenceIndex(parent, last); ok {
+			parent.Content = append(parent.Content[:index], parent.Content[index+1:]...)
+			return object.True
+		}
+	}
+	return object.False
+}
+
+func newDocument(node *yaml.Node) *Document {
+	untagMergeKeys(node)
+	indent := detectIndent(node)
+	if indent == 0 {
+		indent = 2
+	}
+	return &Document{node: node, indent: indent}
+}
+
+// parsePath accepts either a dotted string such as "spec.ports.0.name" or a
+// list of keys and indexes.
+func parsePath(obj object.Object) ([]string, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.String:
+		if obj.Value() == "" {
+			return nil, nil
+		}
+		return strings.Split(obj.Value(), "."), nil
+	case *object.List:
+		path := make([]string, 0, obj.Size())
+		for _, item := range obj.Value() {
+			switch item := item.(type) {
+			case *object.String:
+				path = append(path, item.Value())
+			case *object.Int:
+				path = append(path, strconv.FormatInt(item.Value(), 10))
+			default:
+				return nil, object.Errorf("type error: path elements must be strings or ints (got %s)", item.Type())
+			}
+		}
+		return path, nil
+	}
+	return nil, object.Errorf("type error: expected a string or list path (got %s)", obj.Type())
+}
+
+func joinPath(path []string) string {
+	return strings.Join(path, ".")
+}
+
+// resolve follows document and alias nodes to the node holding a value.
+func resolve(node *yaml.Node) *yaml.Node {
+	for node != nil {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			if len(node.Content) == 0 {
+				return nil
+			}
+			node = node.Content[0]
+		case yaml.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+	return nil
+}
+
+func root(doc *yaml.Node) *yaml.Node {
+	return resolve(doc)
+}
+
+func lookup(doc *yaml.Node, path []string) *yaml.Node {
+	node := root(doc)
+	for _, elem := range path {
+		if node == nil {
+			return nil
+		}
+		node = resolve(child(node, elem))
+	}
+	return node
+}
+
+// child returns the value of a mapping key, including keys inherited with
+// the "<<" merge key, or the item of a sequence at the given index.
+func child(node *yaml.Node, elem string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		if i := mappingIndex(node, elem); i >= 0 {
+			return node.Content[i+1]
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value != "<<" {
+				continue
+			}
+			merge := resolve(node.Content[i+1])
+			sources := []*yaml.Node{merge}
+			if merge != nil && merge.Kind == yaml.SequenceNode {
+				sources = merge.Content
+			}
+			for _, source := range sources {
+				if source = resolve(source); source != nil && source.Kind == yaml.MappingNode {
+					if value := child(source, elem); value != nil {
+						return value
+					}
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		if index, ok := sequenceIndex(node, elem); ok {
+			return node.Content[index]
+		}
+	}
+	return nil
+}
+
+// mappingIndex returns the position of the key in the mapping's content, or
+// -1 if the mapping doesn't define it.
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if k := resolve(node.Content[i]); k != nil && k.Kind == yaml.ScalarNode && k.Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// sequenceIndex parses a sequence index, counting negative indexes from the
+// end. The returned bool is false if the index is out of range.
+func sequenceIndex(node *yaml.Node, elem string) (int, bool) {
+	index, err := strconv.Atoi(elem)
+	if err != nil {
+		return -1, false
+	}
+	if index < 0 {
+		index += len(node.Content)
+	}
+	return index, index >= 0 && index < len(node.Content)
+}
+
+func keyNode(key string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+}
+
+// replace overwrites the node in place, so that aliases of it see the new
+// value, while keeping its anchor and comments.
+func replace(old, value *yaml.Node) {
+	value.Anchor = old.Anchor
+	if value.HeadComment == "" {
+		value.HeadComment = old.HeadComment
+	}
+	if value.LineComment == "" {
+		value.LineComment = old.LineComment
+	}
+	if value.FootComment == "" {
+		value.FootComment = old.FootComment
+	}
+	if old.Kind == yaml.ScalarNode && value.Kind == yaml.ScalarNode && old.Tag == value.Tag {
+		value.Style = old.Style
+	}
+	*old = *value
+}
+
+func nodeToObject(node *yaml.Node) object.Object {
+	if node == nil {
+		return object.Nil
+	}
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return object.NewError(err)
+	}
+	return toObject(value, decodeOptions{})
+}
+
+// detectIndent returns the indentation used by nested block mappings in
+// the document, or 0 if there are none.
+func detectIndent(node *yaml.Node) int {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 &&
+				value.Line > key.Line && value.Column > key.Column {
+				return value.Column - key.Column
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if indent := detectIndent(child); indent > 0 {
+			return indent
+		}
+	}
+	return 0
+}
+
+// untagMergeKeys clears the explicit tag of "<<" merge keys. The tag is
+// implied when decoding, but the encoder would otherwise write it out as
+// "!!merge <<".
+func untagMergeKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Tag == "!!merge" {
+				key.Tag = ""
+			}
+		}
+	}
+	for _, child := range node.Content {
+		untagMergeKeys(child)
+	}
+}
@@ -1,41 +1,110 @@
 package yaml
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
 
 	"github.com/risor-io/risor/internal/arg"
 	"github.com/risor-io/risor/object"
 	"gopkg.in/yaml.v3"
 )
 
+// decodeOptions control how YAML is decoded into Risor values.
+type decodeOptions struct {
+	strict bool
+	schema object.Object
+}
+
+// encodeOptions control how values are encoded as YAML.
+type encodeOptions struct {
+	indent int
+}
+
 func Unmarshal(ctx context.Context, args ...object.Object) object.Object {
-	if err := arg.Require("yaml.unmarshal", 1, args); err != nil {
+	if err := arg.RequireRange("yaml.unmarshal", 1, 2, args); err != nil {
 		return err
 	}
 	data, err := object.AsBytes(args[0])
 	if err != nil {
 		return err
 	}
+	opts, err := parseDecodeOptions(args[1:])
+	if err != nil {
+		return err
+	}
 	var obj interface{}
 	if err := yaml.Unmarshal(data, &obj); err != nil {
 		return object.Errorf("value error: yaml.unmarshal failed with: %s", err.Error())
 	}
-	scriptObj := object.FromGoType(obj)
-	if scriptObj == nil {
-		return object.Errorf("type error: yaml.unmarshal failed")
+	return toObject(obj, opts)
+}
+
+func UnmarshalAll(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("yaml.unmarshal_all", 1, 2, args); err != nil {
+		return err
 	}
-	return scriptObj
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	opts, err := parseDecodeOptions(args[1:])
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var items []object.Object
+	for {
+		var obj interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return object.Errorf("value error: yaml.unmarshal_all failed with: %s", err.Error())
+		}
+		item := toObject(obj, opts)
+		if object.IsError(item) {
+			return item
+		}
+		items = append(items, item)
+	}
+	return object.NewList(items)
 }
 
 func Marshal(ctx context.Context, args ...object.Object) object.Object {
-	if err := arg.Require("yaml.marshal", 1, args); err != nil {
+	if err := arg.RequireRange("yaml.marshal", 1, 2, args); err != nil {
 		return err
 	}
-	b, err := yaml.Marshal(args[0].Interface())
+	opts, err := parseEncodeOptions(args[1:], args[:1])
 	if err != nil {
-		return object.Errorf("value error: yaml.marshal failed: %s", object.NewError(err))
+		return err
+	}
+	s, encErr := encode(args[:1], opts)
+	if encErr != nil {
+		return object.Errorf("value error: yaml.marshal failed: %s", object.NewError(encErr))
 	}
-	return object.NewString(string(b))
+	return object.NewString(s)
+}
+
+func MarshalAll(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("yaml.marshal_all", 1, 2, args); err != nil {
+		return err
+	}
+	list, err := object.AsList(args[0])
+	if err != nil {
+		return err
+	}
+	opts, err := parseEncodeOptions(args[1:], list.Value())
+	if err != nil {
+		return err
+	}
+	s, encErr := encode(list.Value(), opts)
+	if encErr != nil {
+		return object.Errorf("value error: yaml.marshal_all failed: %s", object.NewError(encErr))
+	}
+	return object.NewString(s)
 }
 
 func Valid(ctx context.Context, args ...object.Object) object.Object {
@@ -50,10 +119,234 @@ func Valid(ctx context.Context, args ...object.Object) object.Object {
 	return object.NewBool(yaml.Unmarshal(data, &v) == nil)
 }
 
+func Parse(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("yaml.parse", 1, 2, args); err != nil {
+		return err
+	}
+	docs := parseDocuments("yaml.parse", args)
+	list, ok := docs.(*object.List)
+	if !ok {
+		return docs
+	}
+	switch items := list.Value(); len(items) {
+	case 0:
+		return newDocument(&yaml.Node{Kind: yaml.DocumentNode})
+	case 1:
+		return items[0]
+	default:
+		return object.Errorf("value error: yaml.parse found %d documents; use yaml.parse_all", len(items))
+	}
+}
+
+func ParseAll(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("yaml.parse_all", 1, 2, args); err != nil {
+		return err
+	}
+	return parseDocuments("yaml.parse_all", args)
+}
+
+func parseDocuments(name string, args []object.Object) object.Object {
+	data, err := object.AsBytes(args[0])
+	if err != nil {
+		return err
+	}
+	opts, err := parseDecodeOptions(args[1:])
+	if err != nil {
+		return err
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var docs []object.Object
+	for {
+		node := &yaml.Node{}
+		if err := decoder.Decode(node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return object.Errorf("value error: %s failed with: %s", name, err.Error())
+		}
+		doc := newDocument(node)
+		if opts.strict {
+			if err := checkKeys(doc.Value(), opts.schema, ""); err != nil {
+				return err
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return object.NewList(docs)
+}
+
+func parseDecodeOptions(args []object.Object) (decodeOptions, *object.Error) {
+	var opts decodeOptions
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "strict":
+			opts.strict, err = object.AsBool(value)
+			if err != nil {
+				return opts, err
+			}
+		case "schema":
+			opts.schema = value
+		default:
+			return opts, object.Errorf("value error: unknown yaml option %q", key)
+		}
+	}
+	if opts.strict && opts.schema == nil {
+		return opts, object.Errorf("value error: yaml strict mode requires a schema")
+	}
+	return opts, nil
+}
+
+// parseEncodeOptions reads the encoding options. When no indent is given,
+// the indentation of the first parsed document among values is used, so
+// that edited documents keep their original layout.
+func parseEncodeOptions(args []object.Object, values []object.Object) (encodeOptions, *object.Error) {
+	opts := encodeOptions{indent: 4}
+	for _, value := range values {
+		if doc, ok := value.(*Document); ok {
+			opts.indent = doc.indent
+			break
+		}
+	}
+	if len(args) == 0 {
+		return opts, nil
+	}
+	m, err := object.AsMap(args[0])
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range m.Value() {
+		switch key {
+		case "indent":
+			indent, err := object.AsInt(value)
+			if err != nil {
+				return opts, err
+			}
+			if indent < 1 {
+				return opts, object.Errorf("value error: indent must be positive (got %d)", indent)
+			}
+			opts.indent = int(indent)
+		default:
+			return opts, object.Errorf("value error: unknown yaml option %q", key)
+		}
+	}
+	return opts, nil
+}
+
+// encode writes the values as a stream of YAML documents.
+func encode(values []object.Object, opts encodeOptions) (string, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(opts.indent)
+	for _, value := range values {
+		if err := encoder.Encode(value.Interface()); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// toObject converts a decoded YAML value to a Risor object, checking it
+// against the schema in strict mode.
+func toObject(value interface{}, opts decodeOptions) object.Object {
+	obj := object.FromGoType(normalize(value))
+	if obj == nil {
+		return object.Errorf("type error: yaml.unmarshal failed")
+	}
+	if object.IsError(obj) {
+		return obj
+	}
+	if opts.strict {
+		if err := checkKeys(obj, opts.schema, ""); err != nil {
+			return err
+		}
+	}
+	return obj
+}
+
+// normalize converts mappings with non-string keys, which YAML allows, to
+// mappings keyed by the string form of each key.
+func normalize(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			m[fmt.Sprint(k)] = normalize(v)
+		}
+		return m
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = normalize(v)
+		}
+		return value
+	case []interface{}:
+		for i, v := range value {
+			value[i] = normalize(v)
+		}
+		return value
+	}
+	return value
+}
+
+// checkKeys returns an error if value contains a map key that is not present
+// at the same position in the schema. The first item of a list in the schema
+// describes every item of the corresponding list in the value. Other schema
+// values act as placeholders and are not checked.
+func checkKeys(value, schema object.Object, path string) *object.Error {
+	switch schema := schema.(type) {
+	case *object.Map:
+		m, ok := value.(*object.Map)
+		if !ok {
+			return nil
+		}
+		for _, key := range m.SortedKeys() {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			sub, ok := schema.Value()[key]
+			if !ok {
+				return object.Errorf("value error: unknown yaml key %q", keyPath)
+			}
+			if err := checkKeys(m.Get(key), sub, keyPath); err != nil {
+				return err
+			}
+		}
+	case *object.List:
+		list, ok := value.(*object.List)
+		if !ok || len(schema.Value()) == 0 {
+			return nil
+		}
+		for i, item := range list.Value() {
+			itemPath := fmt.Sprintf("%d", i)
+			if path != "" {
+				itemPath = path + "." + itemPath
+			}
+			if err := checkKeys(item, schema.Value()[0], itemPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func Module() *object.Module {
 	return object.NewBuiltinsModule("yaml", map[string]object.Object{
-		"unmarshal": object.NewBuiltin("unmarshal", Unmarshal),
-		"marshal":   object.NewBuiltin("marshal", Marshal),
-		"valid":     object.NewBuiltin("valid", Valid),
+		"unmarshal":     object.NewBuiltin("unmarshal", Unmarshal),
+		"unmarshal_all": object.NewBuiltin("unmarshal_all", UnmarshalAll),
+		"marshal":       object.NewBuiltin("marshal", Marshal),
+		"marshal_all":   object.NewBuiltin("marshal_all", MarshalAll),
+		"parse":         object.NewBuiltin("parse", Parse),
+		"parse_all":     object.NewBuiltin("parse_all", ParseAll),
+		"valid":         object.NewBuiltin("valid", Valid),
 	})
 }
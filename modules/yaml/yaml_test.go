@@ -0,0 +1,131 @@
+package yaml
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+const deployment = `# Deployment config
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web # the app name
+  labels: &labels
+    app: web
+spec:
+  replicas: 2
+  selector:
+    matchLabels: *labels
+  template:
+    metadata:
+      labels:
+        <<: *labels
+        tier: frontend
+`
+
+func callMethod(t *testing.T, obj object.Object, name string, args ...object.Object) object.Object {
+	t.Helper()
+	method, ok := obj.GetAttr(name)
+	require.True(t, ok)
+	return method.(*object.Builtin).Call(context.Background(), args...)
+}
+
+func TestUnmarshalAll(t *testing.T) {
+	ctx := context.Background()
+	result := UnmarshalAll(ctx, object.NewString("a: 1\n---\n- x\n- y\n---\n1: one\n"))
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{"a": object.NewInt(1)}),
+		object.NewList([]object.Object{object.NewString("x"), object.NewString("y")}),
+		object.NewMap(map[string]object.Object{"1": object.NewString("one")}),
+	}), result)
+
+	result = MarshalAll(ctx, object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{"a": object.NewInt(1)}),
+		object.NewString("b"),
+	}))
+	require.Equal(t, object.NewString("a: 1\n---\nb\n"), result)
+}
+
+func TestUnmarshalAliases(t *testing.T) {
+	result := Unmarshal(context.Background(), object.NewString(deployment))
+	m, ok := result.(*object.Map)
+	require.True(t, ok, result.Inspect())
+	labels := m.Get("spec").(*object.Map).Get("template").(*object.Map).
+		Get("metadata").(*object.Map).Get("labels")
+	require.Equal(t, object.NewMap(map[string]object.Object{
+		"app":  object.NewString("web"),
+		"tier": object.NewString("frontend"),
+	}), labels)
+}
+
+func TestUnmarshalStrict(t *testing.T) {
+	ctx := context.Background()
+	schema := object.NewMap(map[string]object.Object{
+		"name": object.NewString(""),
+		"ports": object.NewList([]object.Object{
+			object.NewMap(map[string]object.Object{"port": object.NewInt(0)}),
+		}),
+	})
+	opts := object.NewMap(map[string]object.Object{"strict": object.True, "schema": schema})
+
+	result := Unmarshal(ctx, object.NewString("name: web\nports:\n- port: 80\n"), opts)
+	require.False(t, object.IsError(result), result.Inspect())
+
+	result = Unmarshal(ctx, object.NewString("name: web\nports:\n- port: 80\n  prot: TCP\n"), opts)
+	require.Equal(t, object.Errorf("value error: unknown yaml key \"ports.0.prot\""), result)
+
+	result = Unmarshal(ctx, object.NewString("name: web\n"),
+		object.NewMap(map[string]object.Object{"strict": object.True}))
+	require.Equal(t, object.Errorf("value error: yaml strict mode requires a schema"), result)
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	doc, ok := Parse(context.Background(), object.NewString(deployment)).(*Document)
+	require.True(t, ok)
+
+	require.Equal(t, object.NewString("web"), callMethod(t, doc, "get", object.NewString("spec.template.metadata.labels.app")))
+	require.Equal(t, object.Nil, callMethod(t, doc, "get", object.NewString("spec.missing")))
+	require.Equal(t, object.False, callMethod(t, doc, "has", object.NewString("spec.missing")))
+
+	require.Equal(t, object.Nil, callMethod(t, doc, "set", object.NewString("spec.replicas"), object.NewInt(3)))
+	require.Equal(t, object.Nil, callMethod(t, doc, "set",
+		object.NewList([]object.Object{object.NewString("metadata"), object.NewString("name")}), object.NewString("api")))
+	require.Equal(t, object.Nil, callMethod(t, doc, "set", object.NewString("metadata.annotations.owner"), object.NewString("ops")))
+	require.Equal(t, object.True, callMethod(t, doc, "delete", object.NewString("spec.selector")))
+
+	expected := `# Deployment config
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api # the app name
+  labels: &labels
+    app: web
+  annotations:
+    owner: ops
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        <<: *labels
+        tier: frontend
+`
+	require.Equal(t, object.NewString(expected), callMethod(t, doc, "string"))
+	require.Equal(t, object.NewString(expected), Marshal(context.Background(), doc))
+}
+
+func TestParseAll(t *testing.T) {
+	ctx := context.Background()
+	result := ParseAll(ctx, object.NewString("a: 1 # one\n---\nb: 2\n"))
+	docs, ok := result.(*object.List)
+	require.True(t, ok)
+	require.Len(t, docs.Value(), 2)
+	callMethod(t, docs.Value()[1], "set", object.NewString("b"), object.NewInt(3))
+	require.Equal(t, object.NewString("a: 1 # one\n---\nb: 3\n"), MarshalAll(ctx, docs))
+
+	result = Parse(ctx, object.NewString("a: 1\n---\nb: 2\n"))
+	require.Equal(t, object.Errorf("value error: yaml.parse found 2 documents; use yaml.parse_all"), result)
+}
@@ -2,8 +2,11 @@ package object
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 
+	"github.com/risor-io/risor/limits"
+
 	"github.com/risor-io/risor/op"
 )
 
@@ -32,6 +35,120 @@ func (b *Buffer) String() string {
 	return fmt.Sprintf("buffer(%q)", b.value.String())
 }
 
+// WriteObjects appends the given objects to the buffer and returns the number
+// of bytes written. Strings and byte values are written as-is; anything else
+// is written in its printed form.
+func (b *Buffer) WriteObjects(ctx context.Context, objs ...Object) (int, *Error) {
+	var total int
+	for _, obj := range objs {
+		data := bufferBytes(obj)
+		if err := limits.TrackCost(ctx, len(data)); err != nil {
+			return total, NewError(err)
+		}
+		n, _ := b.value.Write(data)
+		total += n
+	}
+	return total, nil
+}
+
+// GetAttr returns the buffer methods. Writes append to the buffer in
+// amortized constant time, which makes buffers suitable for building large
+// outputs incrementally.
+func (b *Buffer) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "write":
+		return NewBuiltin("buffer.write", func(ctx context.Context, args ...Object) Object {
+			n, err := b.WriteObjects(ctx, args...)
+			if err != nil {
+				return err
+			}
+			return NewInt(int64(n))
+		}), true
+	case "write_byte":
+		return NewBuiltin("buffer.write_byte", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 1 {
+				return NewArgsError("buffer.write_byte", 1, len(args))
+			}
+			value, err := AsByte(args[0])
+			if err != nil {
+				return err
+			}
+			b.value.WriteByte(value)
+			return Nil
+		}), true
+	case "bytes":
+		return b.bufferMethod(name, func() Object {
+			return NewByteSlice(bytes.Clone(b.value.Bytes()))
+		}), true
+	case "string":
+		return b.bufferMethod(name, func() Object {
+			return NewString(b.value.String())
+		}), true
+	case "len":
+		return b.bufferMethod(name, func() Object {
+			return NewInt(int64(b.value.Len()))
+		}), true
+	case "reset":
+		return b.bufferMethod(name, func() Object {
+			b.value.Reset()
+			return Nil
+		}), true
+	case "truncate", "grow":
+		fullName := "buffer." + name
+		return NewBuiltin(fullName, func(ctx context.Context, args ...Object) Object {
+			if len(args) != 1 {
+				return NewArgsError(fullName, 1, len(args))
+			}
+			n, err := AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			if n < 0 {
+				return Errorf("value error: %s argument must be non-negative (got %d)", fullName, n)
+			}
+			if name == "truncate" {
+				if n > int64(b.value.Len()) {
+					return Errorf("value error: buffer.truncate out of range (%d > %d)", n, b.value.Len())
+				}
+				b.value.Truncate(int(n))
+				return Nil
+			}
+			if err := limits.TrackCost(ctx, int(n)); err != nil {
+				return NewError(err)
+			}
+			b.value.Grow(int(n))
+			return Nil
+		}), true
+	}
+	return nil, false
+}
+
+func (b *Buffer) bufferMethod(name string, fn func() Object) *Builtin {
+	fullName := "buffer." + name
+	return NewBuiltin(fullName, func(ctx context.Context, args ...Object) Object {
+		if len(args) != 0 {
+			return NewArgsError(fullName, 0, len(args))
+		}
+		return fn()
+	})
+}
+
+func bufferBytes(obj Object) []byte {
+	switch obj := obj.(type) {
+	case *String:
+		return []byte(obj.value)
+	case *ByteSlice:
+		return obj.value
+	case *Buffer:
+		return obj.value.Bytes()
+	case *Byte:
+		return []byte{obj.value}
+	case *Rune:
+		return []byte(string(obj.value))
+	}
+	return []byte(obj.Inspect())
+}
+
 func (b *Buffer) Compare(other Object) (int, error) {
 	switch other := other.(type) {
 	case *Buffer:
@@ -75,7 +192,7 @@ func (b *Buffer) runOperationBytes(opType op.BinaryOpType, right *Buffer) Object
 		if _, err := b.value.Write(right.value.Bytes()); err != nil {
 			return NewError(err)
 		}
-		return Nil
+		return b
 	default:
 		return NewError(fmt.Errorf("eval error: unsupported operation for buffer: %v on type %s", opType, right.Type()))
 	}
@@ -87,7 +204,7 @@ func (b *Buffer) runOperationByteSlice(opType op.BinaryOpType, right *ByteSlice)
 		if _, err := b.value.Write(right.value); err != nil {
 			return NewError(err)
 		}
-		return Nil
+		return b
 	default:
 		return NewError(fmt.Errorf("eval error: unsupported operation for buffer: %v on type %s", opType, right.Type()))
 	}
@@ -96,10 +213,10 @@ func (b *Buffer) runOperationByteSlice(opType op.BinaryOpType, right *ByteSlice)
 func (b *Buffer) runOperationString(opType op.BinaryOpType, right *String) Object {
 	switch opType {
 	case op.Add:
-		if _, err := b.value.Write([]byte(right.value)); err != nil {
+		if _, err := b.value.WriteString(right.value); err != nil {
 			return NewError(err)
 		}
-		return Nil
+		return b
 	default:
 		return NewError(fmt.Errorf("eval error: unsupported operation for buffer: %v on type %s", opType, right.Type()))
 	}
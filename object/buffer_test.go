@@ -0,0 +1,30 @@
+package object
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/op"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferWrite(t *testing.T) {
+	ctx := context.Background()
+	b := NewBuffer(nil)
+	n, err := b.WriteObjects(ctx, NewString("id="), NewInt(42), NewByteSlice([]byte(";")), NewByte('x'))
+	require.Nil(t, err)
+	require.Equal(t, 7, n)
+	require.Equal(t, "id=42;x", b.Value().String())
+
+	// The + operator appends in place and returns the same buffer
+	require.Same(t, b, b.RunOperation(op.Add, NewString("!")))
+	require.Equal(t, NewString("id=42;x!"), callMethod(t, b, "string"))
+	require.Equal(t, NewInt(8), callMethod(t, b, "len"))
+
+	require.Equal(t, Nil, callMethod(t, b, "truncate", NewInt(2)))
+	require.Equal(t, NewByteSlice([]byte("id")), callMethod(t, b, "bytes"))
+	require.True(t, IsError(callMethod(t, b, "truncate", NewInt(10))))
+
+	require.Equal(t, Nil, callMethod(t, b, "reset"))
+	require.False(t, b.IsTruthy())
+}
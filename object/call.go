@@ -0,0 +1,56 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Call invokes a callable object with the given arguments. Compiled Risor
+// functions are called via the CallFunc found in the context, while builtins
+// and other Callable objects are called directly, once the CallHook found in
+// the context, if any, allows it. If the call produces an
+// *Error object, it is returned as a Go error.
+func Call(ctx context.Context, fn Object, args []Object) (Object, error) {
+	var result Object
+	switch fn := fn.(type) {
+	case *Function:
+		callFunc, found := GetCallFunc(ctx)
+		if !found {
+			return nil, errors.New("eval error: context did not contain a call function")
+		}
+		var err error
+		result, err = callFunc(ctx, fn, args)
+		if err != nil {
+			return nil, err
+		}
+	case *Partial:
+		combined := make([]Object, 0, len(args)+len(fn.args))
+		combined = append(combined, args...)
+		combined = append(combined, fn.args...)
+		return Call(ctx, fn.fn, combined)
+	case Callable:
+		if err := CheckCall(ctx, fn.(Object), args); err != nil {
+			return nil, err
+		}
+		result = fn.Call(ctx, args...)
+	default:
+		return nil, fmt.Errorf("type error: object is not callable (got %s)", fn.Type())
+	}
+	if err, ok := result.(*Error); ok {
+		return nil, err.Value()
+	}
+	return result, nil
+}
+
+// CheckCall notifies the CallHook found in the context, if any, that a
+// builtin or other callable object is about to be called with the given
+// arguments. Objects that call callables other than compiled functions
+// directly, rather than with Call, must check the call first, so that the
+// hook can stop it by returning an error.
+func CheckCall(ctx context.Context, fn Object, args []Object) error {
+	if hook, found := GetCallHook(ctx); found {
+		return hook(ctx, fn, args)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package object
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is a source of the current time and of timers, which modules that
+// depend on time use instead of the time package, so that host programs
+// can freeze and advance time in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer returns a timer that sends the current time on its channel
+	// once the given duration has elapsed.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer of a Clock.
+type Timer interface {
+	// C returns the channel the time is sent on when the timer fires.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing, and reports whether it stopped
+	// the timer rather than it having fired or been stopped already.
+	Stop() bool
+}
+
+const clockKey = contextKey("risor:clock")
+
+// WithClock returns a context with a Clock associated, which modules read
+// the time from instead of the system clock.
+func WithClock(ctx context.Context, clock Clock) context.Context {
+	return context.WithValue(ctx, clockKey, clock)
+}
+
+// GetClock returns the Clock associated with the context, if it exists.
+func GetClock(ctx context.Context) (Clock, bool) {
+	clock, ok := ctx.Value(clockKey).(Clock)
+	return clock, ok
+}
+
+// GetDefaultClock returns the Clock associated with the context, if it
+// exists. Otherwise, it returns the system clock.
+func GetDefaultClock(ctx context.Context) Clock {
+	if clock, ok := GetClock(ctx); ok {
+		return clock
+	}
+	return SystemClock{}
+}
+
+// SystemClock is the Clock of the time package.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) NewTimer(d time.Duration) Timer {
+	return &systemTimer{timer: time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t *systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t *systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// ManualClock is a Clock whose time only changes when it's set or advanced,
+// firing the timers that are due, such as those of time.sleep. It is safe
+// for concurrent use.
+type ManualClock struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*manualTimer
+}
+
+// NewManualClock returns a clock frozen at the given time.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) NewTimer(d time.Duration) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	t := &manualTimer{clock: c, deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the time of the clock forward by the given duration.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.set(c.now.Add(d))
+}
+
+// Set sets the time of the clock.
+func (c *ManualClock) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.set(now)
+}
+
+// Pending returns the number of timers that are yet to fire, which lets
+// tests wait for a script to start sleeping before advancing the clock.
+func (c *ManualClock) Pending() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.timers)
+}
+
+// set sets the time with the lock held, and fires the timers that are due
+// in the order of their deadlines.
+func (c *ManualClock) set(now time.Time) {
+	c.now = now
+	sort.SliceStable(c.timers, func(i, j int) bool {
+		return c.timers[i].deadline.Before(c.timers[j].deadline)
+	})
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- now
+	}
+	c.timers = pending
+}
+
+type manualTimer struct {
+	clock    *ManualClock
+	deadline time.Time
+	c        chan time.Time
+}
+
+func (t *manualTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	for i, pending := range t.clock.timers {
+		if pending == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
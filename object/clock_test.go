@@ -0,0 +1,46 @@
+package object
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	require.Equal(t, start, clock.Now())
+
+	first := clock.NewTimer(time.Minute)
+	second := clock.NewTimer(time.Hour)
+	stopped := clock.NewTimer(time.Second)
+	require.Equal(t, 3, clock.Pending())
+	require.True(t, stopped.Stop())
+	require.False(t, stopped.Stop())
+
+	clock.Advance(2 * time.Minute)
+	require.Equal(t, start.Add(2*time.Minute), <-first.C())
+	require.False(t, first.Stop())
+	require.Equal(t, 1, clock.Pending())
+	select {
+	case <-second.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	clock.Set(start.Add(2 * time.Hour))
+	require.Equal(t, start.Add(2*time.Hour), <-second.C())
+	require.Equal(t, 0, clock.Pending())
+
+	// Timers without a duration fire at once
+	require.Equal(t, start.Add(2*time.Hour), <-clock.NewTimer(0).C())
+}
+
+func TestGetDefaultClock(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, SystemClock{}, GetDefaultClock(ctx))
+	clock := NewManualClock(time.Unix(0, 0))
+	require.Equal(t, clock, GetDefaultClock(WithClock(ctx, clock)))
+}
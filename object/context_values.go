@@ -2,6 +2,8 @@ package object
 
 import (
 	"context"
+	"log/slog"
+	"math/rand"
 )
 
 type contextKey string
@@ -29,6 +31,27 @@ func GetCallFunc(ctx context.Context) (CallFunc, bool) {
 
 ////////////////////////////////////////////////////////////////////////////////
 
+// CallHook is a type signature for a function that is notified before an
+// object calls a builtin or other callable object, and which stops the call
+// by returning an error.
+type CallHook func(ctx context.Context, fn Object, args []Object) error
+
+const callHookKey = contextKey("risor:call_hook")
+
+// WithCallHook adds a CallHook to the context, which objects that call
+// other callable objects check with CheckCall.
+func WithCallHook(ctx context.Context, hook CallHook) context.Context {
+	return context.WithValue(ctx, callHookKey, hook)
+}
+
+// GetCallHook returns the CallHook from the context, if it exists.
+func GetCallHook(ctx context.Context) (CallHook, bool) {
+	hook, ok := ctx.Value(callHookKey).(CallHook)
+	return hook, ok
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
 // SpawnFunc is a type signature for a function that can spawn a Risor thread.
 type SpawnFunc func(ctx context.Context, fn Callable, args []Object) (*Thread, error)
 
@@ -60,3 +83,38 @@ func GetThread(ctx context.Context) (*Thread, bool) {
 	t, ok := ctx.Value(threadKey).(*Thread)
 	return t, ok
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+const logHandlerKey = contextKey("risor:log_handler")
+
+// WithLogHandler returns a context with a slog.Handler associated, which the
+// log module writes records to.
+func WithLogHandler(ctx context.Context, h slog.Handler) context.Context {
+	return context.WithValue(ctx, logHandlerKey, h)
+}
+
+// GetLogHandler returns the slog.Handler associated with the context, if it
+// exists.
+func GetLogHandler(ctx context.Context) (slog.Handler, bool) {
+	h, ok := ctx.Value(logHandlerKey).(slog.Handler)
+	return h, ok
+}
+
+////////////////////////////////////////////////////////////////////////////////
+
+const randSourceKey = contextKey("risor:rand_source")
+
+// WithRandSource returns a context with a rand.Source associated, which the
+// random module draws from instead of its randomly seeded default. The source
+// must be safe for concurrent use.
+func WithRandSource(ctx context.Context, src rand.Source) context.Context {
+	return context.WithValue(ctx, randSourceKey, src)
+}
+
+// GetRandSource returns the rand.Source associated with the context, if it
+// exists.
+func GetRandSource(ctx context.Context) (rand.Source, bool) {
+	src, ok := ctx.Value(randSourceKey).(rand.Source)
+	return src, ok
+}
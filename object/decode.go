@@ -0,0 +1,329 @@
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// DecodeError is an error decoding a Risor object into a Go value, with the
+// path of the value that couldn't be decoded, such as "users[2].age".
+type DecodeError struct {
+	Path string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("decode error: %s", e.Err)
+	}
+	return fmt.Sprintf("decode error: %s: %s", e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Decode stores the Go equivalent of a Risor object in the value v points
+// to, which saves host programs from type switches on the results of
+// scripts:
+//
+//	var config struct {
+//		Name     string        `risor:"name"`
+//		Replicas int           `risor:"replicas"`
+//		Labels   map[string]string
+//	}
+//	err := object.Decode(result, &config)
+//
+// Maps are decoded into structs by field name, as given by the "risor" tag
+// of each field, or else by its "json" tag, or else by the name of the field,
+// matched case-insensitively. Fields tagged "-" are skipped, as are keys
+// without a field. Fields tagged readonly are decoded, since the option only
+// keeps scripts from changing them. Maps are also decoded into Go maps with
+// string keys, lists into slices and arrays, and proxies of Go values into values of
+// their type. Ints may be decoded into floats, but floats aren't decoded
+// into ints, and values are checked to fit the Go type. Nil decodes into
+// the zero value of pointers, interfaces, maps, and slices. Any object
+// decodes into an Object, and into an empty interface as the value returned
+// by its Interface method.
+//
+// A *DecodeError is returned for the first value that can't be decoded.
+func Decode(obj Object, v any) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return &DecodeError{Err: fmt.Errorf("expected a non-nil pointer (%T given)", v)}
+	}
+	return decodeValue(obj, value.Elem(), "")
+}
+
+func decodeValue(obj Object, value reflect.Value, path string) error {
+	typ := value.Type()
+	fail := func(format string, args ...any) error {
+		return &DecodeError{Path: path, Err: fmt.Errorf(format, args...)}
+	}
+	mismatch := func() error {
+		return fail("expected %s (%s given)", describeType(typ), obj.Type())
+	}
+	if typ == objectInterface {
+		value.Set(reflect.ValueOf(&obj).Elem())
+		return nil
+	}
+	if obj == nil {
+		obj = Nil
+	}
+	// Objects are kept as they are for their own types, and for interfaces
+	// with methods they implement, such as Callable
+	if reflect.TypeOf(obj).AssignableTo(typ) && (typ.Kind() != reflect.Interface || typ.NumMethod() > 0) {
+		value.Set(reflect.ValueOf(obj))
+		return nil
+	}
+	if obj == Nil {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			value.Set(reflect.Zero(typ))
+			return nil
+		}
+		return mismatch()
+	}
+	if proxy, ok := obj.(*Proxy); ok {
+		goValue := reflect.ValueOf(proxy.Interface())
+		if goValue.Type().AssignableTo(typ) {
+			value.Set(goValue)
+			return nil
+		}
+		if goValue.Kind() == reflect.Pointer && goValue.Type().Elem().AssignableTo(typ) && !goValue.IsNil() {
+			value.Set(goValue.Elem())
+			return nil
+		}
+	}
+	if typ == timeType {
+		t, ok := obj.(*Time)
+		if !ok {
+			return mismatch()
+		}
+		value.Set(reflect.ValueOf(t.Value()))
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		b, ok := obj.(*Bool)
+		if !ok {
+			return mismatch()
+		}
+		value.SetBool(b.Value())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := decodeInt(obj)
+		if !ok {
+			return mismatch()
+		}
+		if value.OverflowInt(n) {
+			return fail("%d overflows %s", n, typ)
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, ok := decodeInt(obj)
+		if !ok {
+			return mismatch()
+		}
+		if n < 0 || value.OverflowUint(uint64(n)) {
+			return fail("%d overflows %s", n, typ)
+		}
+		value.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch obj := obj.(type) {
+		case *Float:
+			f = obj.Value()
+		case *Int:
+			f = float64(obj.Value())
+		case *Byte:
+			f = float64(obj.Value())
+		default:
+			return mismatch()
+		}
+		if value.OverflowFloat(f) {
+			return fail("%g overflows %s", f, typ)
+		}
+		value.SetFloat(f)
+	case reflect.String:
+		s, ok := obj.(*String)
+		if !ok {
+			return mismatch()
+		}
+		value.SetString(s.Value())
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			switch obj := obj.(type) {
+			case *ByteSlice:
+				value.SetBytes(append([]byte(nil), obj.Value()...))
+				return nil
+			case *String:
+				value.SetBytes([]byte(obj.Value()))
+				return nil
+			}
+		}
+		list, ok := obj.(*List)
+		if !ok {
+			return mismatch()
+		}
+		items := list.Value()
+		slice := reflect.MakeSlice(typ, len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(item, slice.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+	case reflect.Array:
+		list, ok := obj.(*List)
+		if !ok {
+			return mismatch()
+		}
+		items := list.Value()
+		if len(items) != typ.Len() {
+			return fail("expected a list of %d items (%d given)", typ.Len(), len(items))
+		}
+		for i, item := range items {
+			if err := decodeValue(item, value.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := obj.(*Map)
+		if !ok || typ.Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		result := reflect.MakeMapWithSize(typ, m.Size())
+		for _, key := range m.SortedKeys() {
+			item := reflect.New(typ.Elem()).Elem()
+			if err := decodeValue(m.Get(key), item, joinPath(path, key)); err != nil {
+				return err
+			}
+			result.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), item)
+		}
+		value.Set(result)
+	case reflect.Struct:
+		m, ok := obj.(*Map)
+		if !ok {
+			return mismatch()
+		}
+		return decodeStruct(m, value, path)
+	case reflect.Pointer:
+		elem := reflect.New(typ.Elem())
+		if err := decodeValue(obj, elem.Elem(), path); err != nil {
+			return err
+		}
+		value.Set(elem)
+	case reflect.Interface:
+		goValue := obj.Interface()
+		if goValue == nil {
+			value.Set(reflect.Zero(typ))
+			return nil
+		}
+		if !reflect.TypeOf(goValue).AssignableTo(typ) {
+			return mismatch()
+		}
+		value.Set(reflect.ValueOf(goValue))
+	default:
+		return fail("unsupported type %s", typ)
+	}
+	return nil
+}
+
+// decodeStruct sets the fields of a struct from the items of a map.
+func decodeStruct(m *Map, value reflect.Value, path string) error {
+	items := m.Value()
+	for _, field := range reflect.VisibleFields(value.Type()) {
+		if !field.IsExported() || field.Anonymous && field.Type.Kind() == reflect.Struct {
+			continue
+		}
+		name, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+		item, found := items[name]
+		if !found {
+			for key, v := range items {
+				if strings.EqualFold(key, name) {
+					item, found = v, true
+					break
+				}
+			}
+		}
+		if !found {
+			continue
+		}
+		fieldValue, err := value.FieldByIndexErr(field.Index)
+		if err != nil {
+			// An embedded pointer to a struct is nil
+			continue
+		}
+		if err := decodeValue(item, fieldValue, joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldName returns the name of the map key decoded into a struct field,
+// and false if the field is skipped.
+func fieldName(field reflect.StructField) (string, bool) {
+	for _, key := range []string{"risor", "json"} {
+		tag, ok := lookupStructTag(field, key)
+		if !ok {
+			continue
+		}
+		if tag.skip {
+			return "", false
+		}
+		if tag.name != "" {
+			return tag.name, true
+		}
+	}
+	return field.Name, true
+}
+
+// decodeInt returns the value of an int or a byte.
+func decodeInt(obj Object) (int64, bool) {
+	switch obj := obj.(type) {
+	case *Int:
+		return obj.Value(), true
+	case *Byte:
+		return int64(obj.Value()), true
+	}
+	return 0, false
+}
+
+// describeType names the Risor equivalent of a Go type in errors.
+func describeType(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Map, reflect.Struct:
+		if typ == timeType {
+			return "time"
+		}
+		return "map"
+	}
+	return typ.String()
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,130 @@
+package object
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type decodeAddress struct {
+	City string `risor:"city"`
+	Zip  string `json:"zip_code"`
+}
+
+type decodeUser struct {
+	Name     string
+	Age      uint8 `risor:"age"`
+	Score    float64
+	Address  *decodeAddress    `risor:"address"`
+	Labels   map[string]string `risor:"labels"`
+	Extra    any               `risor:"extra"`
+	Raw      Object            `risor:"raw"`
+	Password string            `risor:"-"`
+	internal string
+}
+
+func TestDecodeStruct(t *testing.T) {
+	obj := NewMap(map[string]Object{
+		"name":  NewString("alice"),
+		"age":   NewInt(31),
+		"SCORE": NewInt(9),
+		"address": NewMap(map[string]Object{
+			"city":     NewString("Lisbon"),
+			"zip_code": NewString("1000"),
+		}),
+		"labels":   NewMap(map[string]Object{"team": NewString("ops")}),
+		"extra":    NewList([]Object{NewInt(1), NewString("two")}),
+		"raw":      NewFloat(1.5),
+		"Password": NewString("secret"),
+		"internal": NewString("x"),
+		"unknown":  NewString("ignored"),
+	})
+	var user decodeUser
+	require.Nil(t, Decode(obj, &user))
+	require.Equal(t, decodeUser{
+		Name:    "alice",
+		Age:     31,
+		Score:   9,
+		Address: &decodeAddress{City: "Lisbon", Zip: "1000"},
+		Labels:  map[string]string{"team": "ops"},
+		Extra:   []any{int64(1), "two"},
+		Raw:     NewFloat(1.5),
+	}, user)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	users := NewList([]Object{
+		NewMap(map[string]Object{"age": NewInt(31)}),
+		NewMap(map[string]Object{"age": NewInt(300)}),
+	})
+	var result []decodeUser
+	err := Decode(users, &result)
+	require.EqualError(t, err, "decode error: [1].age: 300 overflows uint8")
+
+	err = Decode(NewMap(map[string]Object{
+		"labels": NewMap(map[string]Object{"team": NewInt(1)}),
+	}), &decodeUser{})
+	require.EqualError(t, err, "decode error: labels.team: expected string (int given)")
+	var decodeErr *DecodeError
+	require.True(t, errors.As(err, &decodeErr))
+	require.Equal(t, "labels.team", decodeErr.Path)
+
+	var n int
+	require.EqualError(t, Decode(NewFloat(1.5), &n), "decode error: expected int (float given)")
+	require.EqualError(t, Decode(Nil, &n), "decode error: expected int (nil given)")
+	require.EqualError(t, Decode(NewInt(1), n), "decode error: expected a non-nil pointer (int given)")
+
+	var pair [2]int
+	require.EqualError(t, Decode(NewList([]Object{NewInt(1)}), &pair),
+		"decode error: expected a list of 2 items (1 given)")
+}
+
+func TestDecodeStructTags(t *testing.T) {
+	type record struct {
+		ID    int    `risor:"id,readonly"`
+		Dash  string `risor:"-,"`
+		Skip  string `json:"-"`
+		Alias string `risor:",omitempty" json:"alias"`
+	}
+	var decoded record
+	require.Nil(t, Decode(NewMap(map[string]Object{
+		"id":    NewInt(7),
+		"-":     NewString("dash"),
+		"Skip":  NewString("skipped"),
+		"alias": NewString("alias"),
+	}), &decoded))
+	require.Equal(t, record{ID: 7, Dash: "dash", Alias: "alias"}, decoded)
+}
+
+func TestDecodeValues(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var ts time.Time
+	require.Nil(t, Decode(NewTime(now), &ts))
+	require.Equal(t, now, ts)
+
+	var data []byte
+	require.Nil(t, Decode(NewString("abc"), &data))
+	require.Equal(t, []byte("abc"), data)
+
+	labels := map[string]string{"a": "b"}
+	require.Nil(t, Decode(Nil, &labels))
+	require.Nil(t, labels)
+
+	var ptr *int
+	require.Nil(t, Decode(NewInt(7), &ptr))
+	require.Equal(t, 7, *ptr)
+
+	var fn Callable
+	builtin := NewBuiltin("f", nil)
+	require.Nil(t, Decode(builtin, &fn))
+	require.Equal(t, builtin, fn)
+
+	type point struct{ X, Y int }
+	var p point
+	proxy, err := NewProxy(&point{X: 1, Y: 2})
+	require.Nil(t, err)
+	require.Nil(t, Decode(proxy, &p))
+	require.Equal(t, point{X: 1, Y: 2}, p)
+}
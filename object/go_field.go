@@ -16,6 +16,7 @@ type GoField struct {
 	name      *String
 	tag       *String
 	converter TypeConverter
+	options   structTag
 }
 
 func (f *GoField) Name() string {
@@ -34,6 +35,24 @@ func (f *GoField) Tag() reflect.StructTag {
 	return f.field.Tag
 }
 
+// AttrName returns the name of the attribute bound to the field, which is
+// set by its "risor" tag and defaults to the name of the field.
+func (f *GoField) AttrName() string {
+	return f.options.attrName(f.field)
+}
+
+// IsReadOnly returns true if the field is tagged readonly, meaning scripts
+// can't set it.
+func (f *GoField) IsReadOnly() bool {
+	return f.options.readOnly
+}
+
+// OmitsEmpty returns true if the field is tagged omitempty, meaning it reads
+// as nil when it holds the zero value of its type.
+func (f *GoField) OmitsEmpty() bool {
+	return f.options.omitEmpty
+}
+
 func (f *GoField) Type() Type {
 	return GO_TYPE
 }
@@ -73,6 +92,11 @@ func (f *GoField) RunOperation(opType op.BinaryOpType, right Object) Object {
 	return Errorf("type error: unsupported operation on go_field (%s)", opType)
 }
 
+// Value returns the value of this field on the given struct value.
+func (f *GoField) Value(structValue reflect.Value) reflect.Value {
+	return structValue.FieldByIndex(f.field.Index)
+}
+
 func (f *GoField) Converter() (TypeConverter, bool) {
 	return f.converter, f.converter != nil
 }
@@ -104,5 +128,6 @@ func newGoField(f reflect.StructField) (*GoField, error) {
 		name:      NewString(f.Name),
 		tag:       NewString(string(f.Tag)),
 		converter: conv,
+		options:   parseStructTag(f),
 	}, nil
 }
@@ -0,0 +1,159 @@
+package object
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var objectInterface = reflect.TypeOf((*Object)(nil)).Elem()
+
+// goFunction calls a Go function from Risor, converting its arguments and
+// results with the type converters of their types.
+type goFunction struct {
+	name       string
+	fn         reflect.Value
+	takesCtx   bool
+	params     []reflect.Type
+	converters []TypeConverter
+	variadic   bool
+	errIndex   int
+}
+
+// NewGoFunction returns a builtin with the given name that calls the Go
+// function fn, so that embedders don't need to write builtins by hand:
+//
+//	builtin, err := object.NewGoFunction("add", func(a, b int) int {
+//		return a + b
+//	})
+//
+// Arguments are converted from Risor objects to the types of the function's
+// parameters, and results back to Risor objects, with the type converters of
+// their types, including those installed with SetTypeConverter. Parameters
+// and results of type Object are passed as they are. A first parameter of
+// type context.Context receives the context of the call, and a variadic
+// function accepts any number of trailing arguments.
+//
+// When the last result is an error, the builtin returns a Risor error if it
+// isn't nil. Otherwise, the builtin returns nil for a function without other
+// results, the converted result for a function with one, and a list of the
+// converted results for a function with more.
+func NewGoFunction(name string, fn any) (*Builtin, error) {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return nil, fmt.Errorf("type error: expected a function for %s (%T given)", name, fn)
+	}
+	typ := value.Type()
+	f := &goFunction{name: name, fn: value, variadic: typ.IsVariadic(), errIndex: -1}
+	for i := 0; i < typ.NumIn(); i++ {
+		paramType := typ.In(i)
+		if i == 0 && paramType == contextInterface {
+			f.takesCtx = true
+			continue
+		}
+		if f.variadic && i == typ.NumIn()-1 {
+			paramType = paramType.Elem()
+		}
+		var conv TypeConverter
+		if paramType != objectInterface {
+			var err error
+			if conv, err = NewTypeConverter(paramType); err != nil {
+				return nil, fmt.Errorf("type error: unsupported type of parameter %d of %s: %w", i+1, name, err)
+			}
+		}
+		f.params = append(f.params, paramType)
+		f.converters = append(f.converters, conv)
+	}
+	for i := 0; i < typ.NumOut(); i++ {
+		outType := typ.Out(i)
+		if outType == errorInterface && i == typ.NumOut()-1 {
+			f.errIndex = i
+			continue
+		}
+		if outType.Implements(objectInterface) || outType.Kind() == reflect.Interface {
+			continue
+		}
+		if _, err := NewTypeConverter(outType); err != nil {
+			return nil, fmt.Errorf("type error: unsupported type of result %d of %s: %w", i+1, name, err)
+		}
+	}
+	return NewBuiltin(name, f.call), nil
+}
+
+func (f *goFunction) call(ctx context.Context, args ...Object) Object {
+	fixed := len(f.params)
+	if f.variadic {
+		fixed--
+		if len(args) < fixed {
+			return NewError(NewArgumentsError("type error: %s() takes at least %d arguments (%d given)",
+				f.name, fixed, len(args)))
+		}
+	} else if len(args) != fixed {
+		return NewArgsError(f.name, fixed, len(args))
+	}
+	inputs := make([]reflect.Value, 0, len(args)+1)
+	if f.takesCtx {
+		inputs = append(inputs, reflect.ValueOf(ctx))
+	}
+	for i, arg := range args {
+		param := i
+		if param > fixed {
+			param = fixed
+		}
+		input, err := f.convertArg(param, arg)
+		if err != nil {
+			return Errorf("type error: failed to convert argument %d in %s() call: %s", i+1, f.name, err)
+		}
+		inputs = append(inputs, input)
+	}
+	outputs := f.fn.Call(inputs)
+	if f.errIndex >= 0 {
+		if err, _ := outputs[f.errIndex].Interface().(error); err != nil {
+			return NewError(err)
+		}
+		outputs = append(outputs[:f.errIndex], outputs[f.errIndex+1:]...)
+	}
+	results := make([]Object, 0, len(outputs))
+	for _, output := range outputs {
+		var result Object
+		if (output.Kind() == reflect.Interface || output.Kind() == reflect.Pointer) && output.IsNil() {
+			result = Nil
+		} else if result = FromGoType(output.Interface()); IsError(result) {
+			return Errorf("call error: failed to convert output from %s() call: %s",
+				f.name, result.(*Error).Value())
+		}
+		results = append(results, result)
+	}
+	switch len(results) {
+	case 0:
+		return Nil
+	case 1:
+		return results[0]
+	default:
+		return NewList(results)
+	}
+}
+
+// convertArg converts an argument to the type of the given parameter.
+func (f *goFunction) convertArg(param int, arg Object) (reflect.Value, error) {
+	typ := f.params[param]
+	conv := f.converters[param]
+	if conv == nil {
+		return reflect.ValueOf(&arg).Elem(), nil
+	}
+	input, err := conv.To(arg)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	value := reflect.ValueOf(input)
+	if !value.IsValid() {
+		return reflect.Zero(typ), nil
+	}
+	if value.Type() != typ {
+		if !value.Type().ConvertibleTo(typ) {
+			return reflect.Value{}, fmt.Errorf("expected %s (%s given)", typ, arg.Type())
+		}
+		value = value.Convert(typ)
+	}
+	return value, nil
+}
@@ -0,0 +1,87 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoFunction(t *testing.T) {
+	ctx := context.Background()
+	add, err := NewGoFunction("add", func(a, b int) int { return a + b })
+	require.Nil(t, err)
+	require.Equal(t, "add", add.Name())
+	require.Equal(t, NewInt(5), add.Call(ctx, NewInt(2), NewInt(3)))
+
+	result := add.Call(ctx, NewInt(2))
+	require.Equal(t, "type error: add() takes exactly 2 arguments (1 given)", result.(*Error).Message().Value())
+	result = add.Call(ctx, NewInt(2), NewString("3"))
+	require.Equal(t, "type error: failed to convert argument 2 in add() call: type error: expected int (string given)",
+		result.(*Error).Message().Value())
+}
+
+func TestGoFunctionContextAndVariadic(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "> ")
+	join, err := NewGoFunction("join", func(ctx context.Context, sep string, parts ...string) string {
+		return ctx.Value(key{}).(string) + strings.Join(parts, sep)
+	})
+	require.Nil(t, err)
+	require.Equal(t, NewString("> a-b-c"), join.Call(ctx, NewString("-"), NewString("a"), NewString("b"), NewString("c")))
+	require.Equal(t, NewString("> "), join.Call(ctx, NewString("-")))
+	result := join.Call(ctx)
+	require.Equal(t, "type error: join() takes at least 1 arguments (0 given)", result.(*Error).Message().Value())
+}
+
+func TestGoFunctionResults(t *testing.T) {
+	ctx := context.Background()
+	div, err := NewGoFunction("div", func(a, b float64) (float64, error) {
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, NewFloat(2.5), div.Call(ctx, NewInt(5), NewInt(2)))
+	result := div.Call(ctx, NewInt(5), NewInt(0))
+	require.True(t, IsError(result))
+	require.Equal(t, "division by zero", result.(*Error).Message().Value())
+
+	split, err := NewGoFunction("split", func(s string) (string, string) {
+		a, b, _ := strings.Cut(s, "=")
+		return a, b
+	})
+	require.Nil(t, err)
+	require.Equal(t, NewList([]Object{NewString("k"), NewString("v")}), split.Call(ctx, NewString("k=v")))
+
+	noop, err := NewGoFunction("noop", func() error { return nil })
+	require.Nil(t, err)
+	require.Equal(t, Nil, noop.Call(ctx))
+
+	identity, err := NewGoFunction("identity", func(obj Object) Object { return obj })
+	require.Nil(t, err)
+	list := NewList([]Object{NewInt(1)})
+	require.Same(t, list, identity.Call(ctx, list))
+
+	sum, err := NewGoFunction("sum", func(values []int, weights map[string]float64) float64 {
+		total := 0.0
+		for _, v := range values {
+			total += float64(v) * weights["w"]
+		}
+		return total
+	})
+	require.Nil(t, err)
+	require.Equal(t, NewFloat(6), sum.Call(ctx, NewList([]Object{NewInt(1), NewInt(2)}),
+		NewMap(map[string]Object{"w": NewFloat(2)})))
+}
+
+func TestGoFunctionErrors(t *testing.T) {
+	_, err := NewGoFunction("f", 42)
+	require.EqualError(t, err, "type error: expected a function for f (int given)")
+	_, err = NewGoFunction("f", func(c chan int) {})
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unsupported type of parameter 1 of f")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"sync"
 
 	"github.com/risor-io/risor/op"
 )
@@ -22,6 +23,11 @@ type GoType struct {
 	converter      TypeConverter
 	isPointerType  bool
 	isDirectMethod map[string]bool
+
+	// methods caches the call plans of the methods of this type by name, so
+	// that proxies of the type share them and calls in loops do not look up
+	// converters again.
+	methods sync.Map
 }
 
 func (t *GoType) Type() Type {
@@ -213,14 +219,14 @@ func newGoType(typ reflect.Type) (*GoType, error) {
 		}
 		for i := 0; i < structType.NumField(); i++ {
 			field := structType.Field(i)
-			if !field.IsExported() {
+			if !field.IsExported() || parseStructTag(field).skip {
 				continue
 			}
 			goField, err := newGoField(field)
 			if err != nil {
 				return nil, err
 			}
-			goType.attributes[field.Name] = goField
+			goType.attributes[goField.AttrName()] = goField
 		}
 	}
 
@@ -257,6 +263,14 @@ func newGoType(typ reflect.Type) (*GoType, error) {
 // A type registry is maintained behind the scenes to ensure that each type
 // is only registered once.
 func NewGoType(typ reflect.Type) (*GoType, error) {
+	// Fast path for types that are already registered
+	goTypeMutex.RLock()
+	goType, ok := goTypeRegistry[typ]
+	goTypeMutex.RUnlock()
+	if ok {
+		return goType, nil
+	}
+
 	goTypeMutex.Lock()
 	defer goTypeMutex.Unlock()
 
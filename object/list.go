@@ -174,10 +174,17 @@ func (ls *List) GetAttr(name string) (Object, bool) {
 		return &Builtin{
 			name: "list.sort",
 			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("list.sort", 0, len(args))
+				if len(args) > 1 {
+					return NewArgsRangeError("list.sort", 0, 1, len(args))
+				}
+				var opts SortOptions
+				if len(args) == 1 {
+					var err *Error
+					if opts, err = NewSortOptions(args[0]); err != nil {
+						return err
+					}
 				}
-				if err := Sort(ls.items); err != nil {
+				if err := SortWith(ctx, ls.items, opts); err != nil {
 					return err
 				}
 				return ls
@@ -227,6 +234,9 @@ func (ls *List) Map(ctx context.Context, fn Object) Object {
 	case *Builtin:
 		result := make([]Object, 0, len(ls.items))
 		for _, value := range ls.items {
+			if err := CheckCall(ctx, obj, []Object{value}); err != nil {
+				return NewError(err)
+			}
 			outputValue := obj.fn(ctx, value)
 			if IsError(outputValue) {
 				return outputValue
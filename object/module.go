@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/risor-io/risor/compiler"
 	"github.com/risor-io/risor/op"
@@ -41,6 +42,21 @@ func (m *Module) GetAttr(name string) (Object, bool) {
 	return nil, false
 }
 
+// AttributeNames returns the sorted names of the module's attributes.
+func (m *Module) AttributeNames() []string {
+	names := make([]string, 0, len(m.builtins)+len(m.globalsIndex))
+	for name := range m.builtins {
+		names = append(names, name)
+	}
+	for name := range m.globalsIndex {
+		if _, found := m.builtins[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (m *Module) SetAttr(name string, value Object) error {
 	return fmt.Errorf("attribute error: cannot modify module attributes")
 }
@@ -126,6 +142,16 @@ func (m *Module) UseGlobals(globals []Object) {
 	m.globals = globals
 }
 
+// Update replaces the code and globals of the module with those of a newer
+// version of it, so that every reference to the module sees the newer
+// version. Values already taken from the module, such as functions bound
+// by a from-import, keep referring to the previous version.
+func (m *Module) Update(newer *Module) {
+	m.code = newer.code
+	m.globals = newer.globals
+	m.globalsIndex = newer.globalsIndex
+}
+
 func (m *Module) Call(ctx context.Context, args ...Object) Object {
 	if m.callable == nil {
 		return NewError(fmt.Errorf("exec error: module %q is not callable", m.name))
@@ -61,15 +61,20 @@ const (
 	MODULE        Type = "module"
 	NIL           Type = "nil"
 	PARTIAL       Type = "partial"
+	PATH          Type = "path"
 	PROXY         Type = "proxy"
+	READER        Type = "reader"
 	RESULT        Type = "result"
+	RUNE          Type = "rune"
 	SET           Type = "set"
 	SET_ITER      Type = "set_iter"
 	SLICE_ITER    Type = "slice_iter"
+	STREAM        Type = "stream"
 	STRING        Type = "string"
 	STRING_ITER   Type = "string_iter"
 	THREAD        Type = "thread"
 	TIME          Type = "time"
+	WRITER        Type = "writer"
 )
 
 var (
@@ -0,0 +1,324 @@
+package object
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor/op"
+	"github.com/risor-io/risor/os"
+)
+
+// Path represents a filesystem path. Paths use the separator of the host
+// operating system and are accepted anywhere a string path is expected.
+type Path struct {
+	*base
+	value string
+}
+
+func (p *Path) Type() Type {
+	return PATH
+}
+
+func (p *Path) Value() string {
+	return p.value
+}
+
+func (p *Path) Inspect() string {
+	return fmt.Sprintf("path(%q)", p.value)
+}
+
+func (p *Path) String() string {
+	return p.value
+}
+
+func (p *Path) Interface() interface{} {
+	return p.value
+}
+
+func (p *Path) HashKey() HashKey {
+	return HashKey{Type: p.Type(), StrValue: p.value}
+}
+
+func (p *Path) IsTruthy() bool {
+	return p.value != ""
+}
+
+func (p *Path) Cost() int {
+	return len(p.value)
+}
+
+// Name returns the final element of the path.
+func (p *Path) Name() string {
+	if p.value == "" {
+		return ""
+	}
+	return filepath.Base(p.value)
+}
+
+// Stem returns the final element of the path without its extension.
+func (p *Path) Stem() string {
+	name := p.Name()
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Parts returns the elements of the path. For absolute paths, the first
+// element is the volume name followed by the separator.
+func (p *Path) Parts() []string {
+	cleaned := filepath.Clean(p.value)
+	volume := filepath.VolumeName(cleaned)
+	rest := cleaned[len(volume):]
+	var parts []string
+	if strings.HasPrefix(rest, string(filepath.Separator)) {
+		parts = append(parts, volume+string(filepath.Separator))
+		rest = rest[1:]
+	} else if volume != "" {
+		parts = append(parts, volume)
+	}
+	if rest == "" || rest == "." && len(parts) > 0 {
+		return parts
+	}
+	return append(parts, strings.Split(rest, string(filepath.Separator))...)
+}
+
+// Join returns the path with the given elements appended.
+func (p *Path) Join(elems ...string) *Path {
+	return NewPath(filepath.Join(append([]string{p.value}, elems...)...))
+}
+
+// WithSuffix returns the path with its extension replaced. An empty suffix
+// removes the extension.
+func (p *Path) WithSuffix(suffix string) (*Path, error) {
+	if suffix != "" && (!strings.HasPrefix(suffix, ".") || suffix == ".") {
+		return nil, fmt.Errorf("value error: invalid suffix %q", suffix)
+	}
+	if p.Name() == "" || p.Name() == "." || p.Name() == string(filepath.Separator) {
+		return nil, fmt.Errorf("value error: %q has an empty name", p.value)
+	}
+	return NewPath(strings.TrimSuffix(p.value, filepath.Ext(p.value)) + suffix), nil
+}
+
+func (p *Path) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "name":
+		return NewString(p.Name()), true
+	case "stem":
+		return NewString(p.Stem()), true
+	case "ext", "suffix":
+		return NewString(filepath.Ext(p.value)), true
+	case "parent":
+		return NewPath(filepath.Dir(p.value)), true
+	case "parts":
+		return NewStringList(p.Parts()), true
+	case "join":
+		return &Builtin{
+			name: "path.join",
+			fn: func(ctx context.Context, args ...Object) Object {
+				elems := make([]string, 0, len(args))
+				for _, arg := range args {
+					elem, err := AsString(arg)
+					if err != nil {
+						return err
+					}
+					elems = append(elems, elem)
+				}
+				return p.Join(elems...)
+			},
+		}, true
+	case "clean":
+		return p.pathMethod(name, func() Object {
+			return NewPath(filepath.Clean(p.value))
+		}), true
+	case "abs":
+		return &Builtin{
+			name: "path.abs",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("path.abs", 0, len(args))
+				}
+				if filepath.IsAbs(p.value) {
+					return NewPath(filepath.Clean(p.value))
+				}
+				wd, err := os.GetDefaultOS(ctx).Getwd()
+				if err != nil {
+					return NewError(err)
+				}
+				return NewPath(filepath.Join(wd, p.value))
+			},
+		}, true
+	case "is_abs":
+		return p.pathMethod(name, func() Object {
+			return NewBool(filepath.IsAbs(p.value))
+		}), true
+	case "rel":
+		return &Builtin{
+			name: "path.rel",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("path.rel", 1, len(args))
+				}
+				base, err := AsString(args[0])
+				if err != nil {
+					return err
+				}
+				rel, relErr := filepath.Rel(base, p.value)
+				if relErr != nil {
+					return NewError(relErr)
+				}
+				return NewPath(rel)
+			},
+		}, true
+	case "match":
+		return &Builtin{
+			name: "path.match",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("path.match", 1, len(args))
+				}
+				pattern, err := AsString(args[0])
+				if err != nil {
+					return err
+				}
+				return p.match(pattern)
+			},
+		}, true
+	case "with_suffix":
+		return &Builtin{
+			name: "path.with_suffix",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("path.with_suffix", 1, len(args))
+				}
+				suffix, err := AsString(args[0])
+				if err != nil {
+					return err
+				}
+				result, suffixErr := p.WithSuffix(suffix)
+				if suffixErr != nil {
+					return NewError(suffixErr)
+				}
+				return result
+			},
+		}, true
+	case "with_name":
+		return &Builtin{
+			name: "path.with_name",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("path.with_name", 1, len(args))
+				}
+				name, err := AsString(args[0])
+				if err != nil {
+					return err
+				}
+				return NewPath(filepath.Join(filepath.Dir(p.value), name))
+			},
+		}, true
+	case "exists", "is_dir", "is_file":
+		fullName := "path." + name
+		return &Builtin{
+			name: fullName,
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError(fullName, 0, len(args))
+				}
+				info, err := os.GetDefaultOS(ctx).Stat(p.value)
+				if err != nil {
+					return False
+				}
+				switch name {
+				case "is_dir":
+					return NewBool(info.IsDir())
+				case "is_file":
+					return NewBool(info.Mode().IsRegular())
+				}
+				return True
+			},
+		}, true
+	case "string":
+		return p.pathMethod(name, func() Object {
+			return NewString(p.value)
+		}), true
+	}
+	return nil, false
+}
+
+// match reports whether the path matches the given glob pattern. Relative
+// patterns are matched against the trailing elements of the path, so
+// "*.go" matches "src/main.go".
+func (p *Path) match(pattern string) Object {
+	cleaned := filepath.Clean(p.value)
+	if !filepath.IsAbs(pattern) {
+		patternParts := len(strings.Split(filepath.Clean(pattern), string(filepath.Separator)))
+		parts := strings.Split(cleaned, string(filepath.Separator))
+		if len(parts) > patternParts {
+			cleaned = filepath.Join(parts[len(parts)-patternParts:]...)
+		}
+	}
+	matched, err := filepath.Match(pattern, cleaned)
+	if err != nil {
+		return NewError(err)
+	}
+	return NewBool(matched)
+}
+
+func (p *Path) pathMethod(name string, fn func() Object) *Builtin {
+	fullName := "path." + name
+	return &Builtin{
+		name: fullName,
+		fn: func(ctx context.Context, args ...Object) Object {
+			if len(args) != 0 {
+				return NewArgsError(fullName, 0, len(args))
+			}
+			return fn()
+		},
+	}
+}
+
+func (p *Path) Compare(other Object) (int, error) {
+	otherPath, ok := other.(*Path)
+	if !ok {
+		return CompareTypes(p, other), nil
+	}
+	return strings.Compare(p.value, otherPath.value), nil
+}
+
+func (p *Path) Equals(other Object) Object {
+	switch other := other.(type) {
+	case *Path:
+		return NewBool(filepath.Clean(p.value) == filepath.Clean(other.value))
+	case *String:
+		return NewBool(p.value == other.value)
+	}
+	return False
+}
+
+// RunOperation supports the / operator for joining paths, and + for
+// appending a string to the final element.
+func (p *Path) RunOperation(opType op.BinaryOpType, right Object) Object {
+	switch right := right.(type) {
+	case *Path:
+		if opType == op.Divide {
+			return p.Join(right.value)
+		}
+	case *String:
+		switch opType {
+		case op.Divide:
+			return p.Join(right.value)
+		case op.Add:
+			return NewPath(p.value + right.value)
+		}
+	}
+	return NewError(fmt.Errorf("eval error: unsupported operation for path: %v on type %s",
+		opType, right.Type()))
+}
+
+func (p *Path) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.value)
+}
+
+func NewPath(value string) *Path {
+	return &Path{value: value}
+}
@@ -0,0 +1,53 @@
+package object
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/op"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathAttributes(t *testing.T) {
+	p := NewPath("/data/2024/report.tar.gz")
+	require.Equal(t, PATH, p.Type())
+	require.Equal(t, `path("/data/2024/report.tar.gz")`, p.Inspect())
+	require.Equal(t, "report.tar.gz", p.Name())
+	require.Equal(t, "report.tar", p.Stem())
+	require.Equal(t, []string{"/", "data", "2024", "report.tar.gz"}, p.Parts())
+	require.Equal(t, []string{"a", "b"}, NewPath("a/b/").Parts())
+
+	parent, ok := p.GetAttr("parent")
+	require.True(t, ok)
+	require.Equal(t, NewPath("/data/2024"), parent)
+
+	withSuffix, err := p.WithSuffix(".zip")
+	require.Nil(t, err)
+	require.Equal(t, NewPath("/data/2024/report.tar.zip"), withSuffix)
+	_, err = p.WithSuffix("zip")
+	require.NotNil(t, err)
+}
+
+func TestPathOperations(t *testing.T) {
+	p := NewPath("/data")
+	require.Equal(t, NewPath("/data/x/y"), p.RunOperation(op.Divide, NewString("x/y")))
+	require.Equal(t, NewPath("/data.bak"), p.RunOperation(op.Add, NewString(".bak")))
+	require.True(t, IsError(p.RunOperation(op.Multiply, NewInt(2))))
+	require.Equal(t, True, p.Equals(NewPath("/data/")))
+	require.Equal(t, True, p.Equals(NewString("/data")))
+
+	s, err := AsString(p)
+	require.Nil(t, err)
+	require.Equal(t, "/data", s)
+}
+
+func TestPathMatch(t *testing.T) {
+	ctx := context.Background()
+	match, ok := NewPath("/src/pkg/main.go").GetAttr("match")
+	require.True(t, ok)
+	fn := match.(*Builtin)
+	require.Equal(t, True, fn.Call(ctx, NewString("*.go")))
+	require.Equal(t, True, fn.Call(ctx, NewString("pkg/*.go")))
+	require.Equal(t, False, fn.Call(ctx, NewString("src/*.go")))
+	require.Equal(t, True, fn.Call(ctx, NewString("/src/*/*.go")))
+}
@@ -75,30 +75,50 @@ func (p *Proxy) GetAttr(name string) (Object, bool) {
 	case *GoField:
 		conv, ok := attr.Converter()
 		if !ok {
-			return Errorf("type error: no converter for field %s", name), true
+			return attrError(name, fmt.Errorf("type error: no converter for field %s", name)), true
 		}
-		var value interface{}
-		if p.typ.IsPointerType() {
-			value = reflect.ValueOf(p.obj).Elem().FieldByName(name).Interface()
-		} else {
-			value = reflect.ValueOf(p.obj).FieldByName(name).Interface()
+		field := attr.Value(p.structValue())
+		if attr.OmitsEmpty() && field.IsZero() {
+			return Nil, true
 		}
-		result, err := conv.From(value)
+		result, err := conv.From(field.Interface())
 		if err != nil {
-			return NewError(err), true
+			return attrError(name, err), true
 		}
 		return result, true
 	case *GoMethod:
+		plan, err := p.typ.methodPlan(attr)
+		if err != nil {
+			return attrError(name, err), true
+		}
 		return &Builtin{
-			name: fmt.Sprintf("%s.%s", p.typ.Name(), name),
+			name: plan.name,
 			fn: func(ctx context.Context, args ...Object) Object {
-				return p.call(ctx, attr, args...)
+				return p.call(ctx, plan, args...)
 			},
 		}, true
 	}
 	return nil, false
 }
 
+// attrError returns an attribute that fails to resolve with the given
+// error, so that loading the attribute raises the error rather than
+// producing it as a value.
+func attrError(name string, err error) Object {
+	return NewDynamicAttr(name, func(ctx context.Context, name string) (Object, error) {
+		return nil, err
+	})
+}
+
+// structValue returns the reflect.Value of the proxied struct, dereferencing
+// it if the proxy wraps a pointer.
+func (p *Proxy) structValue() reflect.Value {
+	if p.typ.IsPointerType() {
+		return reflect.ValueOf(p.obj).Elem()
+	}
+	return reflect.ValueOf(p.obj)
+}
+
 func (p *Proxy) SetAttr(name string, value Object) error {
 	attr, found := p.typ.GetAttribute(name)
 	if !found {
@@ -106,26 +126,18 @@ func (p *Proxy) SetAttr(name string, value Object) error {
 	}
 	switch attr := attr.(type) {
 	case *GoField:
+		if attr.IsReadOnly() {
+			return fmt.Errorf("attribute error: field %s of %s is read-only", name, p.typ.Name())
+		}
 		conv, ok := attr.Converter()
 		if !ok {
 			return fmt.Errorf("type error: no converter for field %s", name)
 		}
-		var field reflect.Value
-		if p.typ.IsPointerType() {
-			field = reflect.ValueOf(p.obj).Elem().FieldByName(name)
-		} else {
-			field = reflect.ValueOf(p.obj).FieldByName(name)
-		}
-		result, err := conv.To(value)
-		if err != nil {
-			return err
-		}
-		if field.CanSet() {
-			field.Set(reflect.ValueOf(result))
-			return nil
-		} else {
+		field := attr.Value(p.structValue())
+		if !field.CanSet() {
 			return fmt.Errorf("type error: cannot set field %s", name)
 		}
+		return setField(field, conv, value)
 	case *GoMethod:
 		return fmt.Errorf("attribute error: cannot set method %s", name)
 	}
@@ -139,18 +151,94 @@ func (p *Proxy) Equals(other Object) Object {
 	return False
 }
 
+// Compare orders proxies whose Go values provide a Compare method, such as
+// time.Time or netip.Addr. The method must accept a value of the same type
+// and return an int.
+func (p *Proxy) Compare(other Object) (int, error) {
+	otherProxy, ok := other.(*Proxy)
+	if !ok {
+		return CompareTypes(p, other), nil
+	}
+	method := reflect.ValueOf(p.obj).MethodByName("Compare")
+	if !method.IsValid() {
+		return 0, fmt.Errorf("type error: %s is not comparable", reflect.TypeOf(p.obj))
+	}
+	methodType := method.Type()
+	otherValue := reflect.ValueOf(otherProxy.obj)
+	if methodType.NumIn() != 1 || methodType.NumOut() != 1 ||
+		methodType.Out(0).Kind() != reflect.Int ||
+		!otherValue.Type().AssignableTo(methodType.In(0)) {
+		return 0, fmt.Errorf("type error: unable to compare %s and %s",
+			reflect.TypeOf(p.obj), otherValue.Type())
+	}
+	result := method.Call([]reflect.Value{otherValue})[0].Int()
+	switch {
+	case result < 0:
+		return -1, nil
+	case result > 0:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
 func (p *Proxy) RunOperation(opType op.BinaryOpType, right Object) Object {
 	return NewError(fmt.Errorf("eval error: unsupported operation for proxy: %v", opType))
 }
 
-func (p *Proxy) call(ctx context.Context, m *GoMethod, args ...Object) Object {
+// methodPlan holds what a proxy needs to call a method of a Go type, which
+// is resolved once per type and method name.
+type methodPlan struct {
+	method        *GoMethod
+	name          string
+	isDirect      bool
+	inConverters  []TypeConverter
+	outConverters []TypeConverter
+}
+
+// methodPlan returns the cached call plan of the given method of the type,
+// resolving it on first use.
+func (t *GoType) methodPlan(m *GoMethod) (*methodPlan, error) {
+	methodName := m.Name()
+	if plan, ok := t.methods.Load(methodName); ok {
+		return plan.(*methodPlan), nil
+	}
+	plan := &methodPlan{
+		method:        m,
+		name:          t.Name() + "." + methodName,
+		isDirect:      t.HasDirectMethod(methodName),
+		inConverters:  make([]TypeConverter, len(m.inputTypes)),
+		outConverters: make([]TypeConverter, len(m.outputTypes)),
+	}
+	for i := 1; i < len(m.inputTypes); i++ {
+		conv, err := m.inputTypes[i].GetConverter()
+		if err != nil {
+			return nil, err
+		}
+		plan.inConverters[i] = conv
+	}
+	for i, outType := range m.outputTypes {
+		if m.IsOutputError(i) {
+			continue
+		}
+		conv, err := outType.GetConverter()
+		if err != nil {
+			return nil, err
+		}
+		plan.outConverters[i] = conv
+	}
+	actual, _ := t.methods.LoadOrStore(methodName, plan)
+	return actual.(*methodPlan), nil
+}
+
+func (p *Proxy) call(ctx context.Context, plan *methodPlan, args ...Object) Object {
+	m := plan.method
 	methodName := m.Name()
-	methodFullName := fmt.Sprintf("%s.%s", p.typ.Name(), methodName)
 	isVariadic := m.method.Type.IsVariadic()
 	var argIndex int
 	numIn := m.NumIn()
 	inputs := make([]reflect.Value, 1, numIn)
-	if p.typ.HasDirectMethod(methodName) {
+	if plan.isDirect {
 		inputs[0] = reflect.ValueOf(p.obj)
 	} else if p.typ.IsPointerType() {
 		inputs[0] = reflect.ValueOf(p.obj).Elem()
@@ -170,10 +258,7 @@ func (p *Proxy) call(ctx context.Context, m *GoMethod, args ...Object) Object {
 	}
 	for i := 1; i < numIn; i++ {
 		inType := m.inputTypes[i]
-		inConv, err := inType.GetConverter()
-		if err != nil {
-			return NewError(err)
-		}
+		inConv := plan.inConverters[i]
 		if _, ok := inConv.(*ContextConverter); ok {
 			inputs = append(inputs, reflect.ValueOf(ctx))
 			continue
@@ -185,12 +270,17 @@ func (p *Proxy) call(ctx context.Context, m *GoMethod, args ...Object) Object {
 		if err != nil {
 			return Errorf("type error: failed to convert argument %d in %s() call: %s", i, methodName, err)
 		}
-		inputs = append(inputs, reflect.ValueOf(input))
+		inputValue := reflect.ValueOf(input)
+		if inputValue.IsValid() && inputValue.Type() != inType.typ &&
+			inputValue.Type().ConvertibleTo(inType.typ) {
+			inputValue = inputValue.Convert(inType.typ)
+		}
+		inputs = append(inputs, inputValue)
 		argIndex++
 	}
 	if len(inputs) < minArgs {
-		return Errorf("type error: %s() requires %d arguments, but %d were given",
-			methodFullName, minArgs, len(inputs))
+		return Errorf("type error: %s.%s() requires %d arguments, but %d were given",
+			p.typ.Name(), methodName, minArgs, len(inputs))
 	}
 	outputs := m.method.Func.Call(inputs)
 	if len(outputs) == 0 {
@@ -206,12 +296,7 @@ func (p *Proxy) call(ctx context.Context, m *GoMethod, args ...Object) Object {
 	if outputCount <= 1 {
 		for i, output := range outputs {
 			if !m.IsOutputError(i) {
-				outType := m.outputTypes[i]
-				outConv, err := outType.GetConverter()
-				if err != nil {
-					return NewError(err)
-				}
-				result, err := outConv.From(output.Interface())
+				result, err := plan.outConverters[i].From(output.Interface())
 				if err != nil {
 					return Errorf("call error: failed to convert output from %s() call: %s", methodName, err)
 				}
@@ -223,12 +308,7 @@ func (p *Proxy) call(ctx context.Context, m *GoMethod, args ...Object) Object {
 	var results []Object
 	for i, output := range outputs {
 		if !m.IsOutputError(i) {
-			outType := m.outputTypes[i]
-			outConv, err := outType.GetConverter()
-			if err != nil {
-				return NewError(err)
-			}
-			result, err := outConv.From(output.Interface())
+			result, err := plan.outConverters[i].From(output.Interface())
 			if err != nil {
 				return Errorf("call error: failed to convert output from %s() call: %s", methodName, err)
 			}
@@ -222,6 +222,24 @@ func TestProxySetGetAttr(t *testing.T) {
 	require.Equal(t, object.NewInt(-3), value)
 }
 
+type proxyAnyType struct {
+	Value any
+}
+
+func TestProxyGetAttrError(t *testing.T) {
+	proxy, err := object.NewProxy(&proxyAnyType{Value: make(chan int)})
+	require.Nil(t, err)
+
+	// Fields that can't be converted fail to resolve, rather than being
+	// read as errors
+	value, ok := proxy.GetAttr("Value")
+	require.True(t, ok)
+	resolver, ok := value.(object.AttrResolver)
+	require.True(t, ok)
+	_, err = resolver.ResolveAttr(context.Background(), "Value")
+	require.NotNil(t, err)
+}
+
 func TestProxyOnStructValue(t *testing.T) {
 	p, err := object.NewProxy(proxyTestType2{A: 99})
 	require.NoError(t, err)
@@ -323,3 +341,70 @@ func TestProxyHasher(t *testing.T) {
 
 	require.Equal(t, expected, byte_slice.Value())
 }
+
+type proxyBenchType struct {
+	Padding1 string
+	Padding2 string
+	Padding3 int
+	Count    int
+}
+
+func (p *proxyBenchType) Add(n int) int {
+	p.Count += n
+	return p.Count
+}
+
+func BenchmarkProxyGetField(b *testing.B) {
+	proxy, err := object.NewProxy(&proxyBenchType{Count: 42})
+	require.Nil(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := proxy.GetAttr("Count"); !ok {
+			b.Fatal("missing attribute")
+		}
+	}
+}
+
+func BenchmarkProxySetField(b *testing.B) {
+	proxy, err := object.NewProxy(&proxyBenchType{})
+	require.Nil(b, err)
+	value := object.NewInt(7)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := proxy.SetAttr("Count", value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProxyCallMethod(b *testing.B) {
+	ctx := context.Background()
+	value := &proxyBenchType{}
+	arg := object.NewInt(1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		proxy, err := object.NewProxy(value)
+		if err != nil {
+			b.Fatal(err)
+		}
+		method, ok := proxy.GetAttr("Add")
+		if !ok {
+			b.Fatal("missing attribute")
+		}
+		method.(*object.Builtin).Call(ctx, arg)
+	}
+}
+
+func BenchmarkNewProxy(b *testing.B) {
+	value := &proxyBenchType{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := object.NewProxy(value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
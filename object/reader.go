@@ -0,0 +1,211 @@
+package object
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/risor-io/risor/limits"
+	"github.com/risor-io/risor/op"
+)
+
+// Reader wraps a Go io.Reader so that data can be consumed incrementally,
+// without loading an entire file or response body into memory. Iterating
+// over a reader yields its lines.
+type Reader struct {
+	*base
+	value  io.Reader
+	reader *bufio.Reader
+}
+
+func (r *Reader) Type() Type {
+	return READER
+}
+
+func (r *Reader) Inspect() string {
+	return "reader()"
+}
+
+func (r *Reader) String() string {
+	return r.Inspect()
+}
+
+// Value returns the underlying io.Reader.
+func (r *Reader) Value() io.Reader {
+	return r.value
+}
+
+func (r *Reader) Interface() interface{} {
+	return r.value
+}
+
+// Read implements io.Reader. Reads go through an internal buffer, so once
+// a value is wrapped in a Reader it should only be read through the Reader.
+func (r *Reader) Read(p []byte) (int, error) {
+	return r.reader.Read(p)
+}
+
+// ReadLine returns the next line without its line ending. The boolean is
+// false once the reader is exhausted.
+func (r *Reader) ReadLine() (string, bool, error) {
+	line, err := r.reader.ReadString('\n')
+	if err == io.EOF {
+		if line == "" {
+			return "", false, nil
+		}
+	} else if err != nil {
+		return "", false, err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return line, true, nil
+}
+
+func (r *Reader) Close() error {
+	if closer, ok := r.value.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (r *Reader) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "read":
+		return NewBuiltin("reader.read", func(ctx context.Context, args ...Object) Object {
+			if len(args) > 1 {
+				return NewArgsRangeError("reader.read", 0, 1, len(args))
+			}
+			if len(args) == 0 {
+				lim, ok := limits.GetLimits(ctx)
+				if !ok {
+					return NewError(limits.LimitsNotFound)
+				}
+				data, err := lim.ReadAll(r)
+				if err != nil {
+					return NewError(err)
+				}
+				return NewByteSlice(data)
+			}
+			size, err := AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			if size < 0 {
+				return Errorf("value error: reader.read size must be non-negative (got %d)", size)
+			}
+			if err := limits.TrackCost(ctx, int(size)); err != nil {
+				return NewError(err)
+			}
+			buf := make([]byte, size)
+			n, ioErr := io.ReadFull(r, buf)
+			if ioErr != nil && ioErr != io.EOF && ioErr != io.ErrUnexpectedEOF {
+				return NewError(ioErr)
+			}
+			return NewByteSlice(buf[:n])
+		}), true
+	case "read_line":
+		return NewBuiltin("reader.read_line", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 0 {
+				return NewArgsError("reader.read_line", 0, len(args))
+			}
+			line, ok, err := r.ReadLine()
+			if err != nil {
+				return NewError(err)
+			}
+			if !ok {
+				return Nil
+			}
+			return NewString(line)
+		}), true
+	case "copy":
+		return NewBuiltin("reader.copy", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 1 {
+				return NewArgsError("reader.copy", 1, len(args))
+			}
+			dst, err := AsWriter(args[0])
+			if err != nil {
+				return err
+			}
+			n, ioErr := io.Copy(dst, r)
+			if ioErr != nil {
+				return NewError(ioErr)
+			}
+			return NewInt(n)
+		}), true
+	case "limit":
+		return NewBuiltin("reader.limit", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 1 {
+				return NewArgsError("reader.limit", 1, len(args))
+			}
+			n, err := AsInt(args[0])
+			if err != nil {
+				return err
+			}
+			return NewReader(io.LimitReader(r, n))
+		}), true
+	case "tee":
+		return NewBuiltin("reader.tee", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 1 {
+				return NewArgsError("reader.tee", 1, len(args))
+			}
+			w, err := AsWriter(args[0])
+			if err != nil {
+				return err
+			}
+			return NewReader(io.TeeReader(r, w))
+		}), true
+	case "close":
+		return NewBuiltin("reader.close", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 0 {
+				return NewArgsError("reader.close", 0, len(args))
+			}
+			if err := r.Close(); err != nil {
+				return NewError(err)
+			}
+			return Nil
+		}), true
+	}
+	return nil, false
+}
+
+// Iter returns a stream over the remaining lines of the reader.
+func (r *Reader) Iter() Iterator {
+	return NewStream(func(ctx context.Context) (Object, bool, error) {
+		line, ok, err := r.ReadLine()
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		return NewString(line), true, nil
+	})
+}
+
+func (r *Reader) Equals(other Object) Object {
+	if r == other {
+		return True
+	}
+	return False
+}
+
+func (r *Reader) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return NewError(fmt.Errorf("eval error: unsupported operation for reader: %v", opType))
+}
+
+func (r *Reader) Cost() int {
+	return 8
+}
+
+func (r *Reader) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("type error: unable to marshal reader")
+}
+
+// NewReader returns a Reader wrapping the given io.Reader. Wrapping a Reader
+// returns it unchanged.
+func NewReader(value io.Reader) *Reader {
+	if r, ok := value.(*Reader); ok {
+		return r
+	}
+	return &Reader{value: value, reader: bufio.NewReader(value)}
+}
@@ -0,0 +1,81 @@
+package object
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func callMethod(t *testing.T, obj Object, name string, args ...Object) Object {
+	t.Helper()
+	attr, ok := obj.GetAttr(name)
+	require.True(t, ok, name)
+	return attr.(*Builtin).Call(context.Background(), args...)
+}
+
+func TestReaderRead(t *testing.T) {
+	r := NewReader(strings.NewReader("hello world"))
+	require.Equal(t, READER, r.Type())
+	require.Equal(t, NewByteSlice([]byte("hello")), callMethod(t, r, "read", NewInt(5)))
+	require.Equal(t, NewByteSlice([]byte(" world")), callMethod(t, r, "read", NewInt(100)))
+	require.Equal(t, NewByteSlice([]byte{}), callMethod(t, r, "read", NewInt(5)))
+	require.True(t, IsError(callMethod(t, r, "read", NewInt(-1))))
+}
+
+func TestReaderReadLine(t *testing.T) {
+	r := NewReader(strings.NewReader("one\r\ntwo\n\nthree"))
+	require.Equal(t, NewString("one"), callMethod(t, r, "read_line"))
+	require.Equal(t, NewString("two"), callMethod(t, r, "read_line"))
+	require.Equal(t, NewString(""), callMethod(t, r, "read_line"))
+	require.Equal(t, NewString("three"), callMethod(t, r, "read_line"))
+	require.Equal(t, Nil, callMethod(t, r, "read_line"))
+}
+
+func TestReaderIter(t *testing.T) {
+	r := NewReader(strings.NewReader("a\nb\nc\n"))
+	iter := r.Iter()
+	var lines []string
+	for {
+		line, ok := iter.Next(context.Background())
+		if !ok {
+			break
+		}
+		lines = append(lines, line.(*String).Value())
+	}
+	require.Equal(t, []string{"a", "b", "c"}, lines)
+}
+
+func TestReaderCopyLimitTee(t *testing.T) {
+	var dst, teed bytes.Buffer
+	r := NewReader(strings.NewReader("0123456789"))
+
+	limited := callMethod(t, r, "limit", NewInt(4))
+	require.IsType(t, &Reader{}, limited)
+	tee := callMethod(t, limited, "tee", NewWriter(&teed))
+	require.Equal(t, NewInt(4), callMethod(t, tee, "copy", NewWriter(&dst)))
+	require.Equal(t, "0123", dst.String())
+	require.Equal(t, "0123", teed.String())
+
+	// The remaining data is still available from the original reader
+	rest, err := AsBytes(r)
+	require.Nil(t, err)
+	require.Equal(t, "456789", string(rest))
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.Equal(t, WRITER, w.Type())
+	require.Same(t, w, NewWriter(w))
+	require.Equal(t, NewInt(3), callMethod(t, w, "write", NewString("abc")))
+	require.Equal(t, NewInt(3), callMethod(t, w, "write", NewReader(strings.NewReader("def"))))
+	require.Equal(t, Nil, callMethod(t, w, "close"))
+	require.Equal(t, "abcdef", buf.String())
+
+	writer, err := AsWriter(w)
+	require.Nil(t, err)
+	require.Equal(t, w, writer)
+}
@@ -0,0 +1,270 @@
+package object
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/risor-io/risor/op"
+)
+
+// Result holds either the successful outcome of an operation or the error
+// that caused it to fail. Results allow failures to be carried through a
+// pipeline of operations as values, rather than aborting execution.
+type Result struct {
+	*base
+	value Object
+	err   *Error
+}
+
+func (r *Result) Type() Type {
+	return RESULT
+}
+
+func (r *Result) Inspect() string {
+	if r.err != nil {
+		return fmt.Sprintf("err(%q)", r.err.Value().Error())
+	}
+	return fmt.Sprintf("ok(%s)", r.value.Inspect())
+}
+
+func (r *Result) String() string {
+	return r.Inspect()
+}
+
+// IsOk returns true if the Result holds a successful value.
+func (r *Result) IsOk() bool {
+	return r.err == nil
+}
+
+// IsErr returns true if the Result holds an error.
+func (r *Result) IsErr() bool {
+	return r.err != nil
+}
+
+// Value returns the successful value held by the Result, or nil if the
+// Result holds an error.
+func (r *Result) Value() Object {
+	return r.value
+}
+
+// Err returns the error held by the Result, or nil if the Result holds a
+// successful value.
+func (r *Result) Err() *Error {
+	return r.err
+}
+
+func (r *Result) Interface() interface{} {
+	if r.err != nil {
+		return r.err.Value()
+	}
+	return r.value.Interface()
+}
+
+func (r *Result) Equals(other Object) Object {
+	otherResult, ok := other.(*Result)
+	if !ok {
+		return False
+	}
+	if r.IsOk() != otherResult.IsOk() {
+		return False
+	}
+	if r.IsErr() {
+		return NewBool(r.err.Value().Error() == otherResult.err.Value().Error())
+	}
+	return r.value.Equals(otherResult.value)
+}
+
+func (r *Result) IsTruthy() bool {
+	return r.err == nil
+}
+
+func (r *Result) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "is_ok":
+		return &Builtin{
+			name: "result.is_ok",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("result.is_ok", 0, len(args))
+				}
+				return NewBool(r.IsOk())
+			},
+		}, true
+	case "is_err":
+		return &Builtin{
+			name: "result.is_err",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("result.is_err", 0, len(args))
+				}
+				return NewBool(r.IsErr())
+			},
+		}, true
+	case "unwrap":
+		return &Builtin{
+			name: "result.unwrap",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("result.unwrap", 0, len(args))
+				}
+				// Returning the error raises it, which propagates the failure
+				// to the nearest enclosing result.of() or try() call.
+				if r.err != nil {
+					return r.err
+				}
+				return r.value
+			},
+		}, true
+	case "unwrap_or":
+		return &Builtin{
+			name: "result.unwrap_or",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("result.unwrap_or", 1, len(args))
+				}
+				if r.err != nil {
+					return args[0]
+				}
+				return r.value
+			},
+		}, true
+	case "unwrap_or_else":
+		return &Builtin{
+			name: "result.unwrap_or_else",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("result.unwrap_or_else", 1, len(args))
+				}
+				if r.err == nil {
+					return r.value
+				}
+				value, err := Call(ctx, args[0], []Object{r.err})
+				if err != nil {
+					return NewError(err)
+				}
+				return value
+			},
+		}, true
+	case "unwrap_err":
+		return &Builtin{
+			name: "result.unwrap_err",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("result.unwrap_err", 0, len(args))
+				}
+				if r.err == nil {
+					return Errorf("value error: result.unwrap_err() called on an ok result")
+				}
+				return NewString(r.err.Value().Error())
+			},
+		}, true
+	case "map":
+		return &Builtin{
+			name: "result.map",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("result.map", 1, len(args))
+				}
+				return r.Map(ctx, args[0])
+			},
+		}, true
+	case "map_err":
+		return &Builtin{
+			name: "result.map_err",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("result.map_err", 1, len(args))
+				}
+				return r.MapErr(ctx, args[0])
+			},
+		}, true
+	case "and_then":
+		return &Builtin{
+			name: "result.and_then",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("result.and_then", 1, len(args))
+				}
+				return r.AndThen(ctx, args[0])
+			},
+		}, true
+	}
+	return nil, false
+}
+
+// Map applies the given function to the value of an ok Result and returns
+// a new Result holding the function output. If the function raises an error,
+// the returned Result holds that error. Err Results are returned unchanged.
+func (r *Result) Map(ctx context.Context, fn Object) *Result {
+	if r.err != nil {
+		return r
+	}
+	value, err := Call(ctx, fn, []Object{r.value})
+	if err != nil {
+		return NewErrResult(NewError(err))
+	}
+	return NewOkResult(value)
+}
+
+// MapErr applies the given function to the error of an err Result and
+// returns a new err Result. The function may return an error or a string,
+// which is used as the new error message. Ok Results are returned unchanged.
+func (r *Result) MapErr(ctx context.Context, fn Object) *Result {
+	if r.err == nil {
+		return r
+	}
+	value, err := Call(ctx, fn, []Object{r.err})
+	if err != nil {
+		return NewErrResult(NewError(err))
+	}
+	switch value := value.(type) {
+	case *Error:
+		return NewErrResult(value)
+	case *String:
+		return NewErrResult(Errorf(value.value))
+	default:
+		return NewErrResult(Errorf(value.Inspect()))
+	}
+}
+
+// AndThen calls the given function with the value of an ok Result. The
+// function may itself return a Result, in which case it is returned as-is
+// rather than being nested. Err Results are returned unchanged.
+func (r *Result) AndThen(ctx context.Context, fn Object) *Result {
+	if r.err != nil {
+		return r
+	}
+	value, err := Call(ctx, fn, []Object{r.value})
+	if err != nil {
+		return NewErrResult(NewError(err))
+	}
+	if result, ok := value.(*Result); ok {
+		return result
+	}
+	return NewOkResult(value)
+}
+
+func (r *Result) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return NewError(fmt.Errorf("eval error: unsupported operation for result: %v", opType))
+}
+
+func (r *Result) MarshalJSON() ([]byte, error) {
+	if r.err != nil {
+		return json.Marshal(map[string]string{"error": r.err.Value().Error()})
+	}
+	return json.Marshal(map[string]Object{"value": r.value})
+}
+
+// NewOkResult returns a Result that holds the given successful value.
+func NewOkResult(value Object) *Result {
+	if value == nil {
+		value = Nil
+	}
+	return &Result{value: value}
+}
+
+// NewErrResult returns a Result that holds the given error.
+func NewErrResult(err *Error) *Result {
+	return &Result{err: err}
+}
@@ -0,0 +1,75 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultBasics(t *testing.T) {
+	ok := NewOkResult(NewInt(3))
+	require.Equal(t, RESULT, ok.Type())
+	require.True(t, ok.IsOk())
+	require.False(t, ok.IsErr())
+	require.True(t, ok.IsTruthy())
+	require.Equal(t, "ok(3)", ok.Inspect())
+	require.Equal(t, int64(3), ok.Interface())
+
+	bad := NewErrResult(Errorf("boom"))
+	require.False(t, bad.IsOk())
+	require.True(t, bad.IsErr())
+	require.False(t, bad.IsTruthy())
+	require.Equal(t, `err("boom")`, bad.Inspect())
+
+	require.Equal(t, True, ok.Equals(NewOkResult(NewInt(3))))
+	require.Equal(t, False, ok.Equals(bad))
+	require.Equal(t, True, bad.Equals(NewErrResult(Errorf("boom"))))
+}
+
+func TestResultMap(t *testing.T) {
+	ctx := context.Background()
+	double := NewBuiltin("double", func(ctx context.Context, args ...Object) Object {
+		return NewInt(args[0].(*Int).Value() * 2)
+	})
+	fail := NewBuiltin("fail", func(ctx context.Context, args ...Object) Object {
+		return Errorf("failed")
+	})
+
+	require.Equal(t, NewOkResult(NewInt(4)), NewOkResult(NewInt(2)).Map(ctx, double))
+
+	mapped := NewOkResult(NewInt(2)).Map(ctx, fail)
+	require.True(t, mapped.IsErr())
+	require.Equal(t, "failed", mapped.Err().Value().Error())
+
+	bad := NewErrResult(Errorf("boom"))
+	require.Same(t, bad, bad.Map(ctx, double))
+}
+
+func TestResultAndThen(t *testing.T) {
+	ctx := context.Background()
+	wrap := NewBuiltin("wrap", func(ctx context.Context, args ...Object) Object {
+		return NewErrResult(NewError(errors.New("nested")))
+	})
+	result := NewOkResult(NewInt(1)).AndThen(ctx, wrap)
+	require.True(t, result.IsErr())
+	require.Equal(t, "nested", result.Err().Value().Error())
+}
+
+func TestResultUnwrap(t *testing.T) {
+	ctx := context.Background()
+
+	unwrap, ok := NewOkResult(NewString("x")).GetAttr("unwrap")
+	require.True(t, ok)
+	require.Equal(t, NewString("x"), unwrap.(*Builtin).Call(ctx))
+
+	unwrap, ok = NewErrResult(Errorf("boom")).GetAttr("unwrap")
+	require.True(t, ok)
+	value := unwrap.(*Builtin).Call(ctx)
+	require.True(t, IsError(value))
+
+	unwrapOr, ok := NewErrResult(Errorf("boom")).GetAttr("unwrap_or")
+	require.True(t, ok)
+	require.Equal(t, NewInt(0), unwrapOr.(*Builtin).Call(ctx, NewInt(0)))
+}
@@ -0,0 +1,177 @@
+package object
+
+import (
+	"context"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/risor-io/risor/op"
+)
+
+// Rune wraps a single Unicode code point and implements Object.
+type Rune struct {
+	*base
+	value rune
+}
+
+func (r *Rune) Type() Type {
+	return RUNE
+}
+
+func (r *Rune) Value() rune {
+	return r.value
+}
+
+func (r *Rune) Inspect() string {
+	return fmt.Sprintf("rune(%q)", r.value)
+}
+
+func (r *Rune) String() string {
+	return string(r.value)
+}
+
+// HashKey returns the hash key of the single character string holding the
+// rune, since the two are equal.
+func (r *Rune) HashKey() HashKey {
+	return HashKey{Type: STRING, StrValue: string(r.value)}
+}
+
+func (r *Rune) Interface() interface{} {
+	return r.value
+}
+
+func (r *Rune) IsTruthy() bool {
+	return r.value != 0
+}
+
+func (r *Rune) Compare(other Object) (int, error) {
+	var otherValue rune
+	switch other := other.(type) {
+	case *Rune:
+		otherValue = other.value
+	case *String:
+		value, ok := singleRune(other.value)
+		if !ok {
+			return 0, fmt.Errorf("type error: unable to compare rune and string of length %d",
+				utf8.RuneCountInString(other.value))
+		}
+		otherValue = value
+	default:
+		return CompareTypes(r, other), nil
+	}
+	if r.value == otherValue {
+		return 0, nil
+	}
+	if r.value > otherValue {
+		return 1, nil
+	}
+	return -1, nil
+}
+
+func (r *Rune) Equals(other Object) Object {
+	switch other := other.(type) {
+	case *Rune:
+		return NewBool(r.value == other.value)
+	case *String:
+		value, ok := singleRune(other.value)
+		return NewBool(ok && value == r.value)
+	default:
+		return False
+	}
+}
+
+func (r *Rune) GetAttr(name string) (Object, bool) {
+	var predicate func(rune) bool
+	switch name {
+	case "is_letter":
+		predicate = unicode.IsLetter
+	case "is_digit":
+		predicate = unicode.IsDigit
+	case "is_space":
+		predicate = unicode.IsSpace
+	case "is_upper":
+		predicate = unicode.IsUpper
+	case "is_lower":
+		predicate = unicode.IsLower
+	case "is_punct":
+		predicate = unicode.IsPunct
+	case "to_upper":
+		return r.method(name, func() Object { return NewRune(unicode.ToUpper(r.value)) }), true
+	case "to_lower":
+		return r.method(name, func() Object { return NewRune(unicode.ToLower(r.value)) }), true
+	case "ord":
+		return r.method(name, func() Object { return NewInt(int64(r.value)) }), true
+	case "byte_len":
+		return r.method(name, func() Object { return NewInt(int64(utf8.RuneLen(r.value))) }), true
+	default:
+		return nil, false
+	}
+	return r.method(name, func() Object { return NewBool(predicate(r.value)) }), true
+}
+
+func (r *Rune) method(name string, fn func() Object) *Builtin {
+	fullName := "rune." + name
+	return &Builtin{
+		name: fullName,
+		fn: func(ctx context.Context, args ...Object) Object {
+			if len(args) != 0 {
+				return NewArgsError(fullName, 0, len(args))
+			}
+			return fn()
+		},
+	}
+}
+
+func (r *Rune) RunOperation(opType op.BinaryOpType, right Object) Object {
+	switch right := right.(type) {
+	case *Rune:
+		switch opType {
+		case op.Add:
+			return NewString(string(r.value) + string(right.value))
+		case op.Subtract:
+			return NewInt(int64(r.value - right.value))
+		}
+	case *String:
+		if opType == op.Add {
+			return NewString(string(r.value) + right.value)
+		}
+	case *Int:
+		var value int64
+		switch opType {
+		case op.Add:
+			value = int64(r.value) + right.value
+		case op.Subtract:
+			value = int64(r.value) - right.value
+		default:
+			return NewError(fmt.Errorf("eval error: unsupported operation for rune: %v on type %s",
+				opType, right.Type()))
+		}
+		if value < 0 || value > utf8.MaxRune || !utf8.ValidRune(rune(value)) {
+			return Errorf("value error: rune out of range (%d)", value)
+		}
+		return NewRune(rune(value))
+	}
+	return NewError(fmt.Errorf("eval error: unsupported operation for rune: %v on type %s",
+		opType, right.Type()))
+}
+
+func (r *Rune) Cost() int {
+	return 4
+}
+
+func (r *Rune) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%q", string(r.value))), nil
+}
+
+func NewRune(value rune) *Rune {
+	return &Rune{value: value}
+}
+
+func singleRune(s string) (rune, bool) {
+	value, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) {
+		return 0, false
+	}
+	return value, true
+}
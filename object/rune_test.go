@@ -0,0 +1,92 @@
+package object
+
+import (
+	"context"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/risor-io/risor/op"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuneBasics(t *testing.T) {
+	r := NewRune('ü')
+	require.Equal(t, RUNE, r.Type())
+	require.Equal(t, 'ü', r.Value())
+	require.Equal(t, "ü", r.String())
+	require.Equal(t, `rune('ü')`, r.Inspect())
+	require.True(t, r.IsTruthy())
+	require.False(t, NewRune(0).IsTruthy())
+	require.Equal(t, True, r.Equals(NewRune('ü')))
+	require.Equal(t, True, r.Equals(NewString("ü")))
+	require.Equal(t, False, r.Equals(NewString("u")))
+	require.Equal(t, HashKey{Type: STRING, StrValue: "ü"}, r.HashKey())
+}
+
+func TestRuneCompare(t *testing.T) {
+	a := NewRune('a')
+	result, err := a.Compare(NewRune('b'))
+	require.Nil(t, err)
+	require.Equal(t, -1, result)
+
+	result, err = a.Compare(NewString("a"))
+	require.Nil(t, err)
+	require.Equal(t, 0, result)
+
+	_, err = a.Compare(NewString("abc"))
+	require.NotNil(t, err)
+
+	// Strings compare to runes as runes compare to strings
+	result, err = NewString("b").Compare(a)
+	require.Nil(t, err)
+	require.Equal(t, 1, result)
+	result, err = NewString("a").Compare(a)
+	require.Nil(t, err)
+	require.Equal(t, 0, result)
+	_, err = NewString("abc").Compare(a)
+	require.NotNil(t, err)
+
+	// Equal runes and strings have the same hash key
+	require.Equal(t, True, a.Equals(NewString("a")))
+	require.Equal(t, NewString("a").HashKey(), a.HashKey())
+	require.Equal(t, 1, NewSet([]Object{a, NewString("a")}).(*Set).Size())
+}
+
+func TestRuneOperations(t *testing.T) {
+	a := NewRune('a')
+	require.Equal(t, NewRune('c'), a.RunOperation(op.Add, NewInt(2)))
+	require.Equal(t, NewInt(2), NewRune('c').RunOperation(op.Subtract, a))
+	require.Equal(t, NewString("abc"), a.RunOperation(op.Add, NewString("bc")))
+	require.True(t, IsError(a.RunOperation(op.Multiply, NewInt(2))))
+
+	// Results outside of the range of valid runes are errors
+	require.True(t, IsError(a.RunOperation(op.Subtract, NewInt(98))))
+	require.True(t, IsError(a.RunOperation(op.Add, NewInt(utf8.MaxRune))))
+	require.True(t, IsError(a.RunOperation(op.Add, NewInt(1<<32))))
+	require.True(t, IsError(NewRune(0xD7FF).RunOperation(op.Add, NewInt(1))))
+}
+
+func TestRuneMethods(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		r        rune
+		method   string
+		expected Object
+	}{
+		{'a', "is_letter", True},
+		{'7', "is_digit", True},
+		{' ', "is_space", True},
+		{'A', "is_upper", True},
+		{'A', "is_lower", False},
+		{'!', "is_punct", True},
+		{'é', "to_upper", NewRune('É')},
+		{'É', "to_lower", NewRune('é')},
+		{'é', "ord", NewInt(233)},
+		{'é', "byte_len", NewInt(2)},
+	}
+	for _, tc := range tests {
+		method, ok := NewRune(tc.r).GetAttr(tc.method)
+		require.True(t, ok, tc.method)
+		require.Equal(t, tc.expected, method.(*Builtin).Call(ctx), tc.method)
+	}
+}
@@ -0,0 +1,150 @@
+package object
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+const (
+	ifaceSize     = int64(unsafe.Sizeof(Object(nil)))
+	stringHdrSize = int64(unsafe.Sizeof(""))
+	hashKeySize   = int64(unsafe.Sizeof(HashKey{}))
+	// Approximate per-entry overhead of a Go map bucket (tophash and
+	// overflow pointers amortized across entries).
+	mapEntryOverhead = 8
+)
+
+// TypeStats holds the number of objects of a type and the approximate
+// number of bytes they occupy.
+type TypeStats struct {
+	Count int64
+	Bytes int64
+}
+
+// AllocStats aggregates memory usage by object type.
+type AllocStats map[Type]TypeStats
+
+// Total returns the combined stats across all types.
+func (s AllocStats) Total() TypeStats {
+	var total TypeStats
+	for _, stats := range s {
+		total.Count += stats.Count
+		total.Bytes += stats.Bytes
+	}
+	return total
+}
+
+// Sizeof returns the approximate number of bytes used by the object and any
+// objects reachable from it, such as the items of a list. Objects that are
+// reachable more than once are counted once. Modules and builtins are not
+// traversed.
+func Sizeof(obj Object) int64 {
+	return CollectAllocStats(obj).Total().Bytes
+}
+
+// CollectAllocStats walks the given objects and everything reachable from
+// them, returning the approximate memory usage grouped by object type.
+func CollectAllocStats(objs ...Object) AllocStats {
+	w := &sizeWalker{stats: AllocStats{}, seen: map[uintptr]bool{}}
+	for _, obj := range objs {
+		w.walk(obj)
+	}
+	return w.stats
+}
+
+type sizeWalker struct {
+	stats AllocStats
+	seen  map[uintptr]bool
+}
+
+func (w *sizeWalker) walk(obj Object) {
+	if obj == nil {
+		return
+	}
+	switch obj.(type) {
+	case *Module, *Builtin, *NilType, *Bool:
+		return
+	}
+	value := reflect.ValueOf(obj)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() || w.seen[value.Pointer()] {
+			return
+		}
+		w.seen[value.Pointer()] = true
+	}
+	stats := w.stats[obj.Type()]
+	stats.Count++
+	stats.Bytes += shallowSize(obj)
+	w.stats[obj.Type()] = stats
+	for _, child := range children(obj) {
+		w.walk(child)
+	}
+}
+
+// shallowSize estimates the memory used by the object itself, excluding
+// other objects it references.
+func shallowSize(obj Object) int64 {
+	var size int64
+	typ := reflect.TypeOf(obj)
+	if typ.Kind() == reflect.Ptr {
+		size = int64(typ.Elem().Size())
+	} else {
+		size = int64(typ.Size())
+	}
+	switch obj := obj.(type) {
+	case *String:
+		size += int64(len(obj.value))
+	case *ByteSlice:
+		size += int64(cap(obj.value))
+	case *Buffer:
+		size += int64(obj.value.Cap())
+	case *List:
+		size += int64(cap(obj.items)) * ifaceSize
+	case *Map:
+		for k := range obj.items {
+			size += stringHdrSize + int64(len(k)) + ifaceSize + mapEntryOverhead
+		}
+	case *Set:
+		for k := range obj.items {
+			size += hashKeySize + int64(len(k.StrValue)) + ifaceSize + mapEntryOverhead
+		}
+	case *Partial:
+		size += int64(cap(obj.args)) * ifaceSize
+	case *FloatSlice:
+		size += int64(cap(obj.value)) * 8
+	case *Error:
+		size += int64(len(obj.err.Error()))
+	}
+	return size
+}
+
+func children(obj Object) []Object {
+	switch obj := obj.(type) {
+	case *List:
+		return obj.items
+	case *Map:
+		items := make([]Object, 0, len(obj.items))
+		for _, v := range obj.items {
+			items = append(items, v)
+		}
+		return items
+	case *Set:
+		items := make([]Object, 0, len(obj.items))
+		for _, v := range obj.items {
+			items = append(items, v)
+		}
+		return items
+	case *Cell:
+		if obj.value != nil {
+			return []Object{*obj.value}
+		}
+	case *Partial:
+		return append([]Object{obj.fn}, obj.args...)
+	case *Result:
+		if obj.err != nil {
+			return []Object{obj.err}
+		}
+		return []Object{obj.value}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeof(t *testing.T) {
+	empty := Sizeof(NewList(nil))
+	withItems := Sizeof(NewList([]Object{NewString("abc"), NewInt(1)}))
+	require.Greater(t, withItems, empty)
+	require.Equal(t, int64(0), Sizeof(Nil))
+}
+
+func TestSizeofCycle(t *testing.T) {
+	m := NewMap(nil)
+	ls := NewList([]Object{m})
+	m.Set("self", ls)
+	stats := CollectAllocStats(ls)
+	require.Equal(t, int64(1), stats[MAP].Count)
+	require.Equal(t, int64(1), stats[LIST].Count)
+	require.Equal(t, int64(2), stats.Total().Count)
+}
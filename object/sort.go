@@ -1,34 +1,156 @@
 package object
 
 import (
+	"context"
 	"fmt"
 	"sort"
 )
 
+// SortOptions controls how SortWith orders a slice of objects.
+type SortOptions struct {
+	// Key is an optional callable that is called once per item to produce
+	// the value used for comparisons.
+	Key Object
+
+	// Cmp is an optional callable that accepts two items and returns a
+	// negative, zero, or positive int. When set, it replaces the default
+	// comparison performed through the Comparable interface.
+	Cmp Object
+
+	// Reverse sorts the items in descending order. Items that compare as
+	// equal keep their original relative order.
+	Reverse bool
+}
+
+// NewSortOptions builds SortOptions from a Risor object. The object may be a
+// key function or a map with optional "key", "cmp", and "reverse" entries.
+func NewSortOptions(obj Object) (SortOptions, *Error) {
+	var opts SortOptions
+	switch obj := obj.(type) {
+	case *Map:
+		for k, v := range obj.Value() {
+			switch k {
+			case "key":
+				opts.Key = v
+			case "cmp":
+				opts.Cmp = v
+			case "reverse":
+				opts.Reverse = v.IsTruthy()
+			default:
+				return opts, Errorf("value error: unknown sort option %q", k)
+			}
+		}
+	case *Function, *Partial, Callable:
+		opts.Key = obj
+	default:
+		return opts, Errorf("type error: expected a function or a map of sort options (%s given)", obj.Type())
+	}
+	if opts.Key == Nil {
+		opts.Key = nil
+	}
+	if opts.Cmp == Nil {
+		opts.Cmp = nil
+	}
+	return opts, nil
+}
+
 // Sort a list in place. If the list contains a non-comparable object, an error
 // is returned.
 func Sort(items []Object) *Error {
-	var comparableErr string
-	sort.SliceStable(items, func(a, b int) bool {
-		itemA := items[a]
-		itemB := items[b]
-		compA, ok := itemA.(Comparable)
-		if !ok {
-			comparableErr = fmt.Sprintf(
-				"type error: sorted() encountered a non-comparable item (%s)", itemA.Type())
+	return SortWith(context.Background(), items, SortOptions{})
+}
+
+// SortWith sorts the items in place according to the given options. The sort
+// is stable. The first error encountered while computing keys or comparing
+// items is returned and leaves the items in an unspecified order.
+func SortWith(ctx context.Context, items []Object, opts SortOptions) *Error {
+	keys := items
+	if opts.Key != nil {
+		keys = make([]Object, len(items))
+		for i, item := range items {
+			key, err := Call(ctx, opts.Key, []Object{item})
+			if err != nil {
+				return NewError(err)
+			}
+			keys[i] = key
+		}
+	}
+	var sortErr error
+	compare := func(a, b Object) int {
+		if sortErr != nil {
+			return 0
 		}
-		if _, ok := itemB.(Comparable); !ok {
-			comparableErr = fmt.Sprintf(
-				"type error: sorted() encountered a non-comparable item (%s)", itemB.Type())
+		var result int
+		if opts.Cmp != nil {
+			result, sortErr = callCompare(ctx, opts.Cmp, a, b)
+		} else {
+			result, sortErr = compareObjects(a, b)
 		}
-		result, err := compA.Compare(itemB)
-		if err != nil {
-			comparableErr = err.Error()
+		return result
+	}
+	sorter := &keyedSorter{items: items, keys: keys, keyed: opts.Key != nil, less: func(a, b Object) bool {
+		if opts.Reverse {
+			return compare(b, a) < 0
 		}
-		return result == -1
-	})
-	if comparableErr != "" {
-		return Errorf(comparableErr)
+		return compare(a, b) < 0
+	}}
+	sort.Stable(sorter)
+	if sortErr != nil {
+		return NewError(sortErr)
 	}
 	return nil
 }
+
+func compareObjects(a, b Object) (int, error) {
+	compA, ok := a.(Comparable)
+	if !ok {
+		return 0, fmt.Errorf("type error: sorted() encountered a non-comparable item (%s)", a.Type())
+	}
+	if _, ok := b.(Comparable); !ok {
+		return 0, fmt.Errorf("type error: sorted() encountered a non-comparable item (%s)", b.Type())
+	}
+	return compA.Compare(b)
+}
+
+func callCompare(ctx context.Context, fn, a, b Object) (int, error) {
+	result, err := Call(ctx, fn, []Object{a, b})
+	if err != nil {
+		return 0, err
+	}
+	value, convErr := AsInt(result)
+	if convErr != nil {
+		return 0, fmt.Errorf("type error: sort comparison function must return an int (got %s)", result.Type())
+	}
+	switch {
+	case value < 0:
+		return -1, nil
+	case value > 0:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// keyedSorter sorts items by their corresponding keys, moving both slices in
+// lockstep. When no key function is used, keys and items are the same slice.
+type keyedSorter struct {
+	items []Object
+	keys  []Object
+	keyed bool
+	less  func(a, b Object) bool
+}
+
+func (s *keyedSorter) Len() int {
+	return len(s.items)
+}
+
+func (s *keyedSorter) Less(i, j int) bool {
+	return s.less(s.keys[i], s.keys[j])
+}
+
+func (s *keyedSorter) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	if s.keyed {
+		s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	}
+}
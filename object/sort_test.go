@@ -0,0 +1,55 @@
+package object
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortStable(t *testing.T) {
+	ctx := context.Background()
+	a := NewMap(map[string]Object{"name": NewString("a"), "age": NewInt(3)})
+	b := NewMap(map[string]Object{"name": NewString("b"), "age": NewInt(1)})
+	c := NewMap(map[string]Object{"name": NewString("c"), "age": NewInt(3)})
+	key := NewBuiltin("age", func(ctx context.Context, args ...Object) Object {
+		return args[0].(*Map).Get("age")
+	})
+
+	items := []Object{a, b, c}
+	require.Nil(t, SortWith(ctx, items, SortOptions{Key: key}))
+	require.Equal(t, []Object{b, a, c}, items)
+
+	items = []Object{a, b, c}
+	require.Nil(t, SortWith(ctx, items, SortOptions{Key: key, Reverse: true}))
+	require.Equal(t, []Object{a, c, b}, items)
+}
+
+func TestSortErrors(t *testing.T) {
+	ctx := context.Background()
+	err := SortWith(ctx, []Object{NewInt(1), NewMap(nil)}, SortOptions{})
+	require.NotNil(t, err)
+	require.Equal(t, "type error: sorted() encountered a non-comparable item (map)", err.Message().Value())
+
+	cmp := NewBuiltin("cmp", func(ctx context.Context, args ...Object) Object {
+		return NewString("nope")
+	})
+	err = SortWith(ctx, []Object{NewInt(1), NewInt(2)}, SortOptions{Cmp: cmp})
+	require.NotNil(t, err)
+
+	_, err = NewSortOptions(NewMap(map[string]Object{"bad": True}))
+	require.NotNil(t, err)
+	_, err = NewSortOptions(NewInt(1))
+	require.NotNil(t, err)
+}
+
+func TestSortProxyCompare(t *testing.T) {
+	early, err := NewProxy(time.Unix(0, 0))
+	require.Nil(t, err)
+	late, err := NewProxy(time.Unix(100, 0))
+	require.Nil(t, err)
+	items := []Object{late, early}
+	require.Nil(t, Sort(items))
+	require.Equal(t, []Object{early, late}, items)
+}
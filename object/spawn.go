@@ -42,6 +42,9 @@ func Spawn(ctx context.Context, fnObj Object, args []Object) (*Thread, error) {
 		}
 		return obj, nil
 	case Callable: // *Builtin is Callable
+		if err := CheckCall(ctx, fnObj, argsCopy); err != nil {
+			return nil, err
+		}
 		obj, err := spawnFunc(ctx, fn, argsCopy)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,457 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/risor-io/risor/op"
+)
+
+// StreamFunc produces the next value in a stream. It returns false once the
+// stream is exhausted. A non-nil error stops the stream.
+type StreamFunc func(ctx context.Context) (Object, bool, error)
+
+// Stream is a lazily evaluated sequence of objects. Transformations such as
+// map and filter return new streams that pull items from their source on
+// demand, so no intermediate lists are built between stages. Streams can be
+// consumed once.
+type Stream struct {
+	*base
+	next    StreamFunc
+	pos     int64
+	current Object
+	err     error
+	done    bool
+}
+
+func (s *Stream) Type() Type {
+	return STREAM
+}
+
+func (s *Stream) Inspect() string {
+	return "stream()"
+}
+
+func (s *Stream) String() string {
+	return s.Inspect()
+}
+
+// Err returns the error that stopped the stream, if any.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+func (s *Stream) Interface() interface{} {
+	ctx := context.Background()
+	var entries []any
+	for {
+		entry, ok := s.Next(ctx)
+		if !ok {
+			break
+		}
+		entries = append(entries, entry.Interface())
+	}
+	return entries
+}
+
+func (s *Stream) Equals(other Object) Object {
+	if s == other {
+		return True
+	}
+	return False
+}
+
+func (s *Stream) Next(ctx context.Context) (Object, bool) {
+	if s.done {
+		return nil, false
+	}
+	value, ok, err := s.next(ctx)
+	if err != nil {
+		s.err = err
+	}
+	if !ok || err != nil {
+		s.done = true
+		s.current = nil
+		return nil, false
+	}
+	s.pos++
+	s.current = value
+	return value, true
+}
+
+func (s *Stream) Entry() (IteratorEntry, bool) {
+	if s.current == nil {
+		return nil, false
+	}
+	return NewEntry(NewInt(s.pos), s.current), true
+}
+
+func (s *Stream) Iter() Iterator {
+	return s
+}
+
+// pull returns a StreamFunc that reads from this stream and reports any
+// error that caused this stream to stop.
+func (s *Stream) pull() StreamFunc {
+	return func(ctx context.Context) (Object, bool, error) {
+		value, ok := s.Next(ctx)
+		if !ok {
+			return nil, false, s.err
+		}
+		return value, true, nil
+	}
+}
+
+// Map returns a stream that applies fn to each item of this stream.
+func (s *Stream) Map(fn Object) *Stream {
+	src := s.pull()
+	return NewStream(func(ctx context.Context) (Object, bool, error) {
+		value, ok, err := src(ctx)
+		if !ok || err != nil {
+			return nil, false, err
+		}
+		result, err := Call(ctx, fn, []Object{value})
+		if err != nil {
+			return nil, false, err
+		}
+		return result, true, nil
+	})
+}
+
+// Filter returns a stream containing the items of this stream for which fn
+// returns a truthy value.
+func (s *Stream) Filter(fn Object) *Stream {
+	src := s.pull()
+	return NewStream(func(ctx context.Context) (Object, bool, error) {
+		for {
+			value, ok, err := src(ctx)
+			if !ok || err != nil {
+				return nil, false, err
+			}
+			decision, err := Call(ctx, fn, []Object{value})
+			if err != nil {
+				return nil, false, err
+			}
+			if decision.IsTruthy() {
+				return value, true, nil
+			}
+		}
+	})
+}
+
+// Take returns a stream containing at most the first n items of this stream.
+func (s *Stream) Take(n int64) *Stream {
+	src := s.pull()
+	var count int64
+	return NewStream(func(ctx context.Context) (Object, bool, error) {
+		if count >= n {
+			return nil, false, nil
+		}
+		count++
+		return src(ctx)
+	})
+}
+
+// Skip returns a stream that discards the first n items of this stream.
+func (s *Stream) Skip(n int64) *Stream {
+	src := s.pull()
+	skipped := false
+	return NewStream(func(ctx context.Context) (Object, bool, error) {
+		if !skipped {
+			skipped = true
+			for i := int64(0); i < n; i++ {
+				if _, ok, err := src(ctx); !ok || err != nil {
+					return nil, false, err
+				}
+			}
+		}
+		return src(ctx)
+	})
+}
+
+// Chunk returns a stream of lists, each containing up to size items.
+func (s *Stream) Chunk(size int64) *Stream {
+	src := s.pull()
+	return NewStream(func(ctx context.Context) (Object, bool, error) {
+		// The size is given by scripts, so it only bounds the preallocation
+		items := make([]Object, 0, min(size, 64))
+		for int64(len(items)) < size {
+			value, ok, err := src(ctx)
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				break
+			}
+			items = append(items, value)
+		}
+		if len(items) == 0 {
+			return nil, false, nil
+		}
+		return NewList(items), true, nil
+	})
+}
+
+// Zip returns a stream of two item lists pairing the items of this stream
+// with the items of the other iterator. The stream ends when either input
+// is exhausted.
+func (s *Stream) Zip(other Iterator) *Stream {
+	src := s.pull()
+	return NewStream(func(ctx context.Context) (Object, bool, error) {
+		left, ok, err := src(ctx)
+		if !ok || err != nil {
+			return nil, false, err
+		}
+		right, ok := other.Next(ctx)
+		if !ok {
+			return nil, false, nil
+		}
+		return NewList([]Object{left, right}), true, nil
+	})
+}
+
+// Reduce consumes the stream, combining items using fn and the given
+// initial value.
+func (s *Stream) Reduce(ctx context.Context, initial, fn Object) Object {
+	acc := initial
+	for {
+		value, ok := s.Next(ctx)
+		if !ok {
+			break
+		}
+		result, err := Call(ctx, fn, []Object{acc, value})
+		if err != nil {
+			return NewError(err)
+		}
+		acc = result
+	}
+	if s.err != nil {
+		return NewError(s.err)
+	}
+	return acc
+}
+
+// Collect consumes the stream and returns its items as a list.
+func (s *Stream) Collect(ctx context.Context) Object {
+	var items []Object
+	for {
+		value, ok := s.Next(ctx)
+		if !ok {
+			break
+		}
+		items = append(items, value)
+	}
+	if s.err != nil {
+		return NewError(s.err)
+	}
+	if items == nil {
+		items = []Object{}
+	}
+	return NewList(items)
+}
+
+// Each consumes the stream, calling fn with each item.
+func (s *Stream) Each(ctx context.Context, fn Object) Object {
+	for {
+		value, ok := s.Next(ctx)
+		if !ok {
+			break
+		}
+		if _, err := Call(ctx, fn, []Object{value}); err != nil {
+			return NewError(err)
+		}
+	}
+	if s.err != nil {
+		return NewError(s.err)
+	}
+	return Nil
+}
+
+// Count consumes the stream and returns the number of items it produced.
+func (s *Stream) Count(ctx context.Context) Object {
+	var count int64
+	for {
+		if _, ok := s.Next(ctx); !ok {
+			break
+		}
+		count++
+	}
+	if s.err != nil {
+		return NewError(s.err)
+	}
+	return NewInt(count)
+}
+
+func (s *Stream) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "map":
+		return &Builtin{
+			name: "stream.map",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("stream.map", 1, len(args))
+				}
+				return s.Map(args[0])
+			},
+		}, true
+	case "filter":
+		return &Builtin{
+			name: "stream.filter",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("stream.filter", 1, len(args))
+				}
+				return s.Filter(args[0])
+			},
+		}, true
+	case "take":
+		return &Builtin{
+			name: "stream.take",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("stream.take", 1, len(args))
+				}
+				n, err := AsInt(args[0])
+				if err != nil {
+					return err
+				}
+				if n < 0 {
+					return Errorf("value error: stream.take() count must be >= 0 (%d given)", n)
+				}
+				return s.Take(n)
+			},
+		}, true
+	case "skip":
+		return &Builtin{
+			name: "stream.skip",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("stream.skip", 1, len(args))
+				}
+				n, err := AsInt(args[0])
+				if err != nil {
+					return err
+				}
+				if n < 0 {
+					return Errorf("value error: stream.skip() count must be >= 0 (%d given)", n)
+				}
+				return s.Skip(n)
+			},
+		}, true
+	case "chunk":
+		return &Builtin{
+			name: "stream.chunk",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("stream.chunk", 1, len(args))
+				}
+				size, err := AsInt(args[0])
+				if err != nil {
+					return err
+				}
+				if size < 1 {
+					return Errorf("value error: stream.chunk() size must be > 0 (%d given)", size)
+				}
+				return s.Chunk(size)
+			},
+		}, true
+	case "zip":
+		return &Builtin{
+			name: "stream.zip",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("stream.zip", 1, len(args))
+				}
+				other, err := AsIterator(args[0])
+				if err != nil {
+					return err
+				}
+				return s.Zip(other)
+			},
+		}, true
+	case "reduce":
+		return &Builtin{
+			name: "stream.reduce",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 2 {
+					return NewArgsError("stream.reduce", 2, len(args))
+				}
+				return s.Reduce(ctx, args[0], args[1])
+			},
+		}, true
+	case "collect":
+		return &Builtin{
+			name: "stream.collect",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("stream.collect", 0, len(args))
+				}
+				return s.Collect(ctx)
+			},
+		}, true
+	case "each":
+		return &Builtin{
+			name: "stream.each",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("stream.each", 1, len(args))
+				}
+				return s.Each(ctx, args[0])
+			},
+		}, true
+	case "count":
+		return &Builtin{
+			name: "stream.count",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("stream.count", 0, len(args))
+				}
+				return s.Count(ctx)
+			},
+		}, true
+	case "next":
+		return &Builtin{
+			name: "stream.next",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("stream.next", 0, len(args))
+				}
+				value, ok := s.Next(ctx)
+				if !ok {
+					if s.err != nil {
+						return NewError(s.err)
+					}
+					return Nil
+				}
+				return value
+			},
+		}, true
+	}
+	return nil, false
+}
+
+func (s *Stream) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return NewError(fmt.Errorf("eval error: unsupported operation for stream: %v", opType))
+}
+
+func (s *Stream) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("type error: unable to marshal stream")
+}
+
+// NewStream returns a stream that produces values using the given function.
+func NewStream(next StreamFunc) *Stream {
+	return &Stream{next: next, pos: -1}
+}
+
+// NewIteratorStream returns a stream that produces the values of the given
+// iterator.
+func NewIteratorStream(iter Iterator) *Stream {
+	if s, ok := iter.(*Stream); ok {
+		return s
+	}
+	return NewStream(func(ctx context.Context) (Object, bool, error) {
+		value, ok := iter.Next(ctx)
+		return value, ok, nil
+	})
+}
@@ -0,0 +1,62 @@
+package object
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamLaziness(t *testing.T) {
+	ctx := context.Background()
+	var pulled int
+	source := NewStream(func(ctx context.Context) (Object, bool, error) {
+		pulled++
+		return NewInt(int64(pulled)), true, nil
+	})
+	double := NewBuiltin("double", func(ctx context.Context, args ...Object) Object {
+		return NewInt(args[0].(*Int).Value() * 2)
+	})
+	result := source.Map(double).Take(3).Collect(ctx)
+	require.Equal(t, NewList([]Object{NewInt(2), NewInt(4), NewInt(6)}), result)
+	require.Equal(t, 3, pulled)
+}
+
+func TestStreamChunkAndZip(t *testing.T) {
+	ctx := context.Background()
+	items := NewList([]Object{NewInt(1), NewInt(2), NewInt(3), NewInt(4), NewInt(5)})
+
+	chunks := NewIteratorStream(items.Iter()).Chunk(2).Collect(ctx)
+	require.Equal(t, "[[1, 2], [3, 4], [5]]", chunks.Inspect())
+
+	chunks = NewIteratorStream(items.Iter()).Chunk(9000000000000000000).Collect(ctx)
+	require.Equal(t, "[[1, 2, 3, 4, 5]]", chunks.Inspect())
+
+	names := NewStringList([]string{"a", "b"})
+	zipped := NewIteratorStream(items.Iter()).Zip(names.Iter()).Collect(ctx)
+	require.Equal(t, `[[1, "a"], [2, "b"]]`, zipped.Inspect())
+}
+
+func TestStreamReduce(t *testing.T) {
+	ctx := context.Background()
+	items := NewList([]Object{NewInt(1), NewInt(2), NewInt(3)})
+	sum := NewBuiltin("sum", func(ctx context.Context, args ...Object) Object {
+		return NewInt(args[0].(*Int).Value() + args[1].(*Int).Value())
+	})
+	require.Equal(t, NewInt(6), NewIteratorStream(items.Iter()).Reduce(ctx, NewInt(0), sum))
+}
+
+func TestStreamError(t *testing.T) {
+	ctx := context.Background()
+	items := NewList([]Object{NewInt(1), NewInt(2), NewInt(3)})
+	fail := NewBuiltin("fail", func(ctx context.Context, args ...Object) Object {
+		if args[0].(*Int).Value() == 2 {
+			return Errorf("bad item")
+		}
+		return args[0]
+	})
+	s := NewIteratorStream(items.Iter()).Map(fail).Skip(0)
+	result := s.Collect(ctx)
+	require.True(t, IsError(result))
+	require.Equal(t, "bad item", result.(*Error).Value().Error())
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/risor-io/risor/op"
 )
@@ -204,6 +205,56 @@ func (s *String) GetAttr(name string) (Object, bool) {
 				return s.TrimSuffix(args[0])
 			},
 		}, true
+	case "runes":
+		return &Builtin{
+			name: "string.runes",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("string.runes", 0, len(args))
+				}
+				return s.RuneList()
+			},
+		}, true
+	case "bytes":
+		return &Builtin{
+			name: "string.bytes",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("string.bytes", 0, len(args))
+				}
+				return NewByteSlice([]byte(s.value))
+			},
+		}, true
+	case "byte_len":
+		return &Builtin{
+			name: "string.byte_len",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("string.byte_len", 0, len(args))
+				}
+				return NewInt(int64(len(s.value)))
+			},
+		}, true
+	case "rune_index":
+		return &Builtin{
+			name: "string.rune_index",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("string.rune_index", 1, len(args))
+				}
+				return s.RuneIndex(args[0])
+			},
+		}, true
+	case "rune_last_index":
+		return &Builtin{
+			name: "string.rune_last_index",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 1 {
+					return NewArgsError("string.rune_last_index", 1, len(args))
+				}
+				return s.RuneLastIndex(args[0])
+			},
+		}, true
 	}
 	return nil, false
 }
@@ -213,6 +264,11 @@ func (s *String) Interface() interface{} {
 }
 
 func (s *String) Compare(other Object) (int, error) {
+	if r, ok := other.(*Rune); ok {
+		// Single character strings compare as runes
+		result, err := r.Compare(s)
+		return -result, err
+	}
 	typeComp := CompareTypes(s, other)
 	if typeComp != 0 {
 		return typeComp, nil
@@ -228,10 +284,14 @@ func (s *String) Compare(other Object) (int, error) {
 }
 
 func (s *String) Equals(other Object) Object {
-	if other.Type() == STRING && s.value == other.(*String).value {
-		return True
+	switch other := other.(type) {
+	case *String:
+		return NewBool(s.value == other.value)
+	case *Rune:
+		return other.Equals(s)
+	default:
+		return False
 	}
-	return False
 }
 
 func (s *String) IsTruthy() bool {
@@ -242,6 +302,8 @@ func (s *String) RunOperation(opType op.BinaryOpType, right Object) Object {
 	switch right := right.(type) {
 	case *String:
 		return s.runOperationString(opType, right)
+	case *Rune:
+		return s.runOperationString(opType, NewString(string(right.value)))
 	default:
 		return NewError(fmt.Errorf("eval error: unsupported operation for string: %v on type %s", opType, right.Type()))
 	}
@@ -371,6 +433,42 @@ func (s *String) LastIndex(obj Object) Object {
 	return NewInt(int64(strings.LastIndex(s.value, substr)))
 }
 
+// RuneIndex is like Index but returns the offset of the substring in runes
+// rather than bytes, so the result may be used to index or slice the string.
+func (s *String) RuneIndex(obj Object) Object {
+	substr, err := AsString(obj)
+	if err != nil {
+		return err
+	}
+	return NewInt(runeOffset(s.value, strings.Index(s.value, substr)))
+}
+
+// RuneLastIndex is like LastIndex but returns the offset in runes.
+func (s *String) RuneLastIndex(obj Object) Object {
+	substr, err := AsString(obj)
+	if err != nil {
+		return err
+	}
+	return NewInt(runeOffset(s.value, strings.LastIndex(s.value, substr)))
+}
+
+// RuneList returns a list containing a rune object for each code point in
+// the string.
+func (s *String) RuneList() *List {
+	items := make([]Object, 0, len(s.value))
+	for _, r := range s.value {
+		items = append(items, NewRune(r))
+	}
+	return NewList(items)
+}
+
+func runeOffset(s string, byteOffset int) int64 {
+	if byteOffset < 0 {
+		return int64(byteOffset)
+	}
+	return int64(utf8.RuneCountInString(s[:byteOffset]))
+}
+
 func (s *String) ReplaceAll(old, new Object) Object {
 	oldStr, err := AsString(old)
 	if err != nil {
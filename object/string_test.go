@@ -97,3 +97,18 @@ func TestStringHashKey(t *testing.T) {
 
 	require.Equal(t, HashKey{Type: STRING, StrValue: "hello"}, a.HashKey())
 }
+
+func TestStringRunes(t *testing.T) {
+	s := NewString("héllo")
+	runes := s.RuneList()
+	require.Equal(t, int64(5), runes.Len().Value())
+	require.Equal(t, NewRune('é'), runes.Value()[1])
+
+	require.Equal(t, NewInt(2), s.RuneIndex(NewString("l")))
+	require.Equal(t, NewInt(3), s.Index(NewString("l")))
+	require.Equal(t, NewInt(3), s.RuneLastIndex(NewString("l")))
+	require.Equal(t, NewInt(-1), s.RuneIndex(NewString("z")))
+
+	require.Equal(t, True, NewString("é").Equals(NewRune('é')))
+	require.Equal(t, False, NewString("ab").Equals(NewRune('a')))
+}
@@ -0,0 +1,68 @@
+package object
+
+import (
+	"reflect"
+	"strings"
+)
+
+// structTag holds the options of the "risor" tag of a struct field, which
+// controls how the field is bound to scripts:
+//
+//	type User struct {
+//		ID       int       `risor:"id,readonly"`
+//		Name     string    `risor:"name"`
+//		Nickname string    `risor:"nickname,omitempty"`
+//		Password string    `risor:"-"`
+//	}
+//
+// The name is the name of the attribute of proxies and of the map key
+// converted into the field. A field tagged "-" isn't visible to scripts.
+// A readonly field can't be set by scripts, neither by assigning to the
+// attribute nor from a map converted into the struct. An omitempty field
+// holding the zero value of its type reads as nil.
+type structTag struct {
+	name      string
+	skip      bool
+	omitEmpty bool
+	readOnly  bool
+}
+
+// parseStructTag returns the options of the "risor" tag of a field. The
+// name is empty when the tag doesn't rename the field.
+func parseStructTag(field reflect.StructField) structTag {
+	tag, _ := lookupStructTag(field, "risor")
+	return tag
+}
+
+// lookupStructTag parses the tag of a field with the given key, which
+// follows the format of "json" tags, and reports whether the field has one.
+// As with "json" tags, a field tagged "-" is skipped, while one tagged "-,"
+// is named "-".
+func lookupStructTag(field reflect.StructField, key string) (structTag, bool) {
+	tag, ok := field.Tag.Lookup(key)
+	if !ok {
+		return structTag{}, false
+	}
+	if tag == "-" {
+		return structTag{skip: true}, true
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	result := structTag{name: name}
+	for _, option := range strings.Split(options, ",") {
+		switch option {
+		case "omitempty":
+			result.omitEmpty = true
+		case "readonly":
+			result.readOnly = true
+		}
+	}
+	return result, true
+}
+
+// attrName returns the name of the attribute bound to the field.
+func (t structTag) attrName(field reflect.StructField) string {
+	if t.name != "" {
+		return t.name
+	}
+	return field.Name
+}
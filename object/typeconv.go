@@ -63,6 +63,10 @@ func AsString(obj Object) (string, *Error) {
 		return string(obj.value), nil
 	case *Buffer:
 		return obj.value.String(), nil
+	case *Rune:
+		return string(obj.value), nil
+	case *Path:
+		return obj.value, nil
 	default:
 		return "", Errorf("type error: expected a string (%s given)", obj.Type())
 	}
@@ -294,11 +298,31 @@ func FromGoType(obj interface{}) Object {
 	case Object:
 		return obj
 	default:
-		return Errorf("type error: unmarshaling %v (%v)",
-			obj, reflect.TypeOf(obj))
+		// Fall back to the converter registry, which includes any
+		// converters installed with SetTypeConverter.
+		conv, err := NewTypeConverter(reflect.TypeOf(obj))
+		if err != nil {
+			return Errorf("type error: unmarshaling %v (%v)",
+				obj, reflect.TypeOf(obj))
+		}
+		result, err := conv.From(obj)
+		if err != nil {
+			return NewError(err)
+		}
+		return result
 	}
 }
 
+// ToGoType converts a Risor object to a Go value of the given type, using the
+// converter registry.
+func ToGoType(obj Object, typ reflect.Type) (interface{}, error) {
+	conv, err := NewTypeConverter(typ)
+	if err != nil {
+		return nil, err
+	}
+	return conv.To(obj)
+}
+
 // AsObjects transform a map containing arbitrary Go types to a map of
 // Risor objects, using the best type converter for each type. If an item
 // in the map is of a type that can't be converted, an error is returned.
@@ -360,23 +384,75 @@ func createTypeConverter(typ reflect.Type) (TypeConverter, error) {
 	return conv, nil
 }
 
-// SetTypeConverter sets a TypeConverter for the given Go type. This is not
-// typically used, since the default converters should typically be sufficient.
+// SetTypeConverter sets a TypeConverter for the given Go type. Registered
+// converters take precedence over the built-in conversions and are used by
+// AsObjects, FromGoType, and when passing values to and from proxied Go
+// methods. Converters should be registered before the first use of the type.
 func SetTypeConverter(typ reflect.Type, conv TypeConverter) {
 	goTypeMutex.Lock()
 	defer goTypeMutex.Unlock()
 
 	typeConverters[typ] = conv
+	if goType, ok := goTypeRegistry[typ]; ok {
+		goType.converter = nil
+	}
+}
+
+// RegisterTypeConverter installs conversion functions for the Go type T.
+// For example, an embedder may register a converter that represents a
+// uuid.UUID as a Risor string:
+//
+//	object.RegisterTypeConverter(
+//		func(obj object.Object) (uuid.UUID, error) {
+//			s, err := object.AsString(obj)
+//			if err != nil {
+//				return uuid.Nil, err.Value()
+//			}
+//			return uuid.Parse(s)
+//		},
+//		func(id uuid.UUID) (object.Object, error) {
+//			return object.NewString(id.String()), nil
+//		},
+//	)
+func RegisterTypeConverter[T any](to func(Object) (T, error), from func(T) (Object, error)) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	SetTypeConverter(typ, &FuncConverter[T]{to: to, from: from})
+}
+
+// FuncConverter is a TypeConverter backed by a pair of functions.
+type FuncConverter[T any] struct {
+	to   func(Object) (T, error)
+	from func(T) (Object, error)
+}
+
+func (c *FuncConverter[T]) To(obj Object) (interface{}, error) {
+	if c.to == nil {
+		return nil, fmt.Errorf("type error: conversion to %s is not supported",
+			reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return c.to(obj)
+}
+
+func (c *FuncConverter[T]) From(obj interface{}) (Object, error) {
+	value, ok := obj.(T)
+	if !ok {
+		return nil, fmt.Errorf("type error: expected %s (%T given)",
+			reflect.TypeOf((*T)(nil)).Elem(), obj)
+	}
+	if c.from == nil {
+		return nil, fmt.Errorf("type error: conversion from %T is not supported", obj)
+	}
+	return c.from(value)
 }
 
 // getTypeConverter returns a TypeConverter for the given Go type.
 // The caller must hold the goTypeMutex lock.
 func getTypeConverter(typ reflect.Type) (TypeConverter, error) {
-	kind := typ.Kind()
-	if conv, ok := kindConverters[kind]; ok {
+	if conv, ok := typeConverters[typ]; ok {
 		return conv, nil
 	}
-	if conv, ok := typeConverters[typ]; ok {
+	kind := typ.Kind()
+	if conv, ok := kindConverters[kind]; ok {
 		return conv, nil
 	}
 	var err error
@@ -905,16 +981,19 @@ func (c *StructConverter) To(obj Object) (interface{}, error) {
 		// Get the underlying struct so that we can set its fields.
 		structValue := value.Elem()
 		for k, value := range obj.items {
-			// If the struct has a field with the same name as a key, set it.
-			if f := structValue.FieldByName(k); f.CanSet() {
-				if attr, ok := c.goType.GetAttribute(k); ok {
-					if attrField, ok := attr.(*GoField); ok {
-						attrValue, err := attrField.converter.To(value)
-						if err != nil {
-							return nil, err
-						}
-						f.Set(reflect.ValueOf(attrValue))
-					}
+			// If the struct has a field bound to an attribute with the same
+			// name as a key, set it, unless scripts can't.
+			attr, ok := c.goType.GetAttribute(k)
+			if !ok {
+				continue
+			}
+			attrField, ok := attr.(*GoField)
+			if !ok || attrField.IsReadOnly() {
+				continue
+			}
+			if f := attrField.Value(structValue); f.CanSet() {
+				if err := setField(f, attrField.converter, value); err != nil {
+					return nil, fmt.Errorf("%w (field %s)", err, k)
 				}
 			}
 		}
@@ -937,6 +1016,32 @@ func (c *StructConverter) From(obj interface{}) (Object, error) {
 	return NewProxy(obj)
 }
 
+// setField sets a struct field to the Go equivalent of a Risor object. Nil
+// sets the field to its zero value.
+func setField(field reflect.Value, conv TypeConverter, value Object) error {
+	if value == Nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	result, err := conv.To(value)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	converted := reflect.ValueOf(result)
+	if converted.Type() != field.Type() {
+		if !converted.Type().ConvertibleTo(field.Type()) {
+			return fmt.Errorf("type error: expected %s (%s given)", field.Type(), converted.Type())
+		}
+		converted = converted.Convert(field.Type())
+	}
+	field.Set(converted)
+	return nil
+}
+
 // newStructConverter creates a TypeConverter for a given type of struct.
 func newStructConverter(typ reflect.Type) (*StructConverter, error) {
 	goType, err := newGoType(typ)
@@ -323,6 +323,70 @@ func TestNestedStructsConverter(t *testing.T) {
 	require.Equal(t, NewInt(42), value)
 }
 
+type taggedInner struct {
+	Count int `risor:"count"`
+}
+
+type taggedOuter struct {
+	ID     string            `risor:"id,readonly"`
+	Name   string            `risor:"name,omitempty"`
+	Hidden string            `risor:"-"`
+	Inner  taggedInner       `risor:"inner"`
+	Items  []taggedInner     `risor:"items"`
+	Meta   map[string]string `risor:"meta"`
+	When   time.Time         `risor:"when"`
+}
+
+func TestStructTagConverter(t *testing.T) {
+	c, err := newStructConverter(reflect.TypeOf(taggedOuter{}))
+	require.Nil(t, err)
+
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	result, err := c.To(NewMap(map[string]Object{
+		"id":     NewString("ignored"),
+		"name":   NewString("outer"),
+		"Hidden": NewString("ignored"),
+		"inner":  NewMap(map[string]Object{"count": NewInt(1)}),
+		"items": NewList([]Object{
+			NewMap(map[string]Object{"count": NewInt(2)}),
+		}),
+		"meta": NewMap(map[string]Object{"k": NewString("v")}),
+		"when": NewTime(when),
+	}))
+	require.Nil(t, err)
+	require.Equal(t, taggedOuter{
+		Name:  "outer",
+		Inner: taggedInner{Count: 1},
+		Items: []taggedInner{{Count: 2}},
+		Meta:  map[string]string{"k": "v"},
+		When:  when,
+	}, result)
+
+	proxy, err := NewProxy(&taggedOuter{ID: "a"})
+	require.Nil(t, err)
+	value, ok := proxy.GetAttr("id")
+	require.True(t, ok)
+	require.Equal(t, NewString("a"), value)
+	value, ok = proxy.GetAttr("name")
+	require.True(t, ok)
+	require.Equal(t, Nil, value)
+	_, ok = proxy.GetAttr("Hidden")
+	require.False(t, ok)
+	_, ok = proxy.GetAttr("ID")
+	require.False(t, ok)
+
+	require.EqualError(t, proxy.SetAttr("id", NewString("b")),
+		"attribute error: field id of *object.taggedOuter is read-only")
+	require.Nil(t, proxy.SetAttr("name", NewString("x")))
+	value, ok = proxy.GetAttr("name")
+	require.True(t, ok)
+	require.Equal(t, NewString("x"), value)
+	require.Nil(t, proxy.SetAttr("name", Nil))
+	value, ok = proxy.GetAttr("name")
+	require.True(t, ok)
+	require.Equal(t, Nil, value)
+}
+
 func TestTimeConverter(t *testing.T) {
 	now := time.Now()
 	typ := reflect.TypeOf(now)
@@ -495,3 +559,66 @@ func TestGenericMapConverterFromJSON(t *testing.T) {
 		}),
 	}), tMap)
 }
+
+type testDecimal struct {
+	units int64
+	scale int
+}
+
+type testLedger struct {
+	balance testDecimal
+}
+
+func (l *testLedger) Deposit(amount testDecimal) testDecimal {
+	l.balance.units += amount.units
+	return l.balance
+}
+
+func TestRegisterTypeConverter(t *testing.T) {
+	RegisterTypeConverter(
+		func(obj Object) (testDecimal, error) {
+			f, err := AsFloat(obj)
+			if err != nil {
+				return testDecimal{}, err.Value()
+			}
+			return testDecimal{units: int64(f * 100), scale: 2}, nil
+		},
+		func(d testDecimal) (Object, error) {
+			return NewFloat(float64(d.units) / 100), nil
+		},
+	)
+
+	require.Equal(t, NewFloat(1.25), FromGoType(testDecimal{units: 125, scale: 2}))
+
+	objs, err := AsObjects(map[string]any{"d": testDecimal{units: 50, scale: 2}})
+	require.Nil(t, err)
+	require.Equal(t, NewFloat(0.5), objs["d"])
+
+	value, err := ToGoType(NewFloat(2.5), reflect.TypeOf(testDecimal{}))
+	require.Nil(t, err)
+	require.Equal(t, testDecimal{units: 250, scale: 2}, value)
+
+	proxy, err := NewProxy(&testLedger{})
+	require.Nil(t, err)
+	deposit, ok := proxy.GetAttr("Deposit")
+	require.True(t, ok)
+	result := deposit.(*Builtin).Call(context.Background(), NewFloat(1.5))
+	require.Equal(t, NewFloat(1.5), result)
+}
+
+type testStatus string
+
+type testStatusService struct{}
+
+func (s *testStatusService) Describe(status testStatus) string {
+	return "status: " + string(status)
+}
+
+func TestProxyNamedBasicType(t *testing.T) {
+	proxy, err := NewProxy(&testStatusService{})
+	require.Nil(t, err)
+	describe, ok := proxy.GetAttr("Describe")
+	require.True(t, ok)
+	result := describe.(*Builtin).Call(context.Background(), NewString("ok"))
+	require.Equal(t, NewString("status: ok"), result)
+}
@@ -0,0 +1,141 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/risor-io/risor/op"
+)
+
+// Writer wraps a Go io.Writer. Writes are passed directly to the underlying
+// writer; flush is only needed for writers that buffer internally.
+type Writer struct {
+	*base
+	value io.Writer
+}
+
+func (w *Writer) Type() Type {
+	return WRITER
+}
+
+func (w *Writer) Inspect() string {
+	return "writer()"
+}
+
+func (w *Writer) String() string {
+	return w.Inspect()
+}
+
+// Value returns the underlying io.Writer.
+func (w *Writer) Value() io.Writer {
+	return w.value
+}
+
+func (w *Writer) Interface() interface{} {
+	return w.value
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.value.Write(p)
+}
+
+// Flush flushes the underlying writer if it supports flushing.
+func (w *Writer) Flush() error {
+	switch value := w.value.(type) {
+	case interface{ Flush() error }:
+		return value.Flush()
+	case interface{ Flush() }:
+		value.Flush()
+	}
+	return nil
+}
+
+// Close flushes and closes the underlying writer if it supports closing.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if closer, ok := w.value.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (w *Writer) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "write":
+		return NewBuiltin("writer.write", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 1 {
+				return NewArgsError("writer.write", 1, len(args))
+			}
+			if r, ok := args[0].(*Reader); ok {
+				n, err := io.Copy(w, r)
+				if err != nil {
+					return NewError(err)
+				}
+				return NewInt(n)
+			}
+			data, err := AsBytes(args[0])
+			if err != nil {
+				return err
+			}
+			n, ioErr := w.Write(data)
+			if ioErr != nil {
+				return NewError(ioErr)
+			}
+			return NewInt(int64(n))
+		}), true
+	case "flush":
+		return NewBuiltin("writer.flush", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 0 {
+				return NewArgsError("writer.flush", 0, len(args))
+			}
+			if err := w.Flush(); err != nil {
+				return NewError(err)
+			}
+			return Nil
+		}), true
+	case "close":
+		return NewBuiltin("writer.close", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 0 {
+				return NewArgsError("writer.close", 0, len(args))
+			}
+			if err := w.Close(); err != nil {
+				return NewError(err)
+			}
+			return Nil
+		}), true
+	}
+	return nil, false
+}
+
+func (w *Writer) Equals(other Object) Object {
+	if w == other {
+		return True
+	}
+	return False
+}
+
+func (w *Writer) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return NewError(fmt.Errorf("eval error: unsupported operation for writer: %v", opType))
+}
+
+func (w *Writer) Cost() int {
+	return 8
+}
+
+func (w *Writer) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("type error: unable to marshal writer")
+}
+
+// NewWriter returns a Writer wrapping the given io.Writer. Wrapping a Writer
+// returns it unchanged.
+func NewWriter(value io.Writer) *Writer {
+	if w, ok := value.(*Writer); ok {
+		return w
+	}
+	return &Writer{value: value}
+}
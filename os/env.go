@@ -0,0 +1,113 @@
+package os
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+var _ OS = (*EnvOS)(nil)
+
+const envKey = contextKey("risor:env")
+
+// Env is a set of environment variables isolated from those of the process,
+// which is safe for concurrent use.
+type Env struct {
+	mutex sync.RWMutex
+	vars  map[string]string
+}
+
+// NewEnv returns an environment holding a copy of the given variables.
+func NewEnv(vars map[string]string) *Env {
+	env := &Env{vars: make(map[string]string, len(vars))}
+	for k, v := range vars {
+		env.vars[k] = v
+	}
+	return env
+}
+
+func (e *Env) Getenv(key string) string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.vars[key]
+}
+
+func (e *Env) LookupEnv(key string) (string, bool) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	value, ok := e.vars[key]
+	return value, ok
+}
+
+func (e *Env) Setenv(key, value string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.vars[key] = value
+	return nil
+}
+
+func (e *Env) Unsetenv(key string) error {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	delete(e.vars, key)
+	return nil
+}
+
+// Environ returns the variables as sorted "key=value" strings.
+func (e *Env) Environ() []string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	result := make([]string, 0, len(e.vars))
+	for k, v := range e.vars {
+		result = append(result, k+"="+v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// WithEnv returns a context whose scripts read and change the variables of
+// the given environment rather than those of the OS of the context. This
+// includes the environment of the commands run by the exec module.
+func WithEnv(ctx context.Context, env *Env) context.Context {
+	ctx = context.WithValue(ctx, envKey, env)
+	return WithOS(ctx, NewEnvOS(GetDefaultOS(ctx), env))
+}
+
+// GetEnv returns the environment set by WithEnv, if any.
+func GetEnv(ctx context.Context) (*Env, bool) {
+	env, ok := ctx.Value(envKey).(*Env)
+	return env, ok
+}
+
+// EnvOS is an OS whose environment variables are replaced with those of an
+// Env. Every other call goes to the underlying OS.
+type EnvOS struct {
+	OS
+	env *Env
+}
+
+// NewEnvOS returns an OS with the variables of env, and otherwise behaving
+// like the given OS.
+func NewEnvOS(osObj OS, env *Env) *EnvOS {
+	return &EnvOS{OS: osObj, env: env}
+}
+
+func (osObj *EnvOS) Environ() []string {
+	return osObj.env.Environ()
+}
+
+func (osObj *EnvOS) Getenv(key string) string {
+	return osObj.env.Getenv(key)
+}
+
+func (osObj *EnvOS) LookupEnv(key string) (string, bool) {
+	return osObj.env.LookupEnv(key)
+}
+
+func (osObj *EnvOS) Setenv(key, value string) error {
+	return osObj.env.Setenv(key, value)
+}
+
+func (osObj *EnvOS) Unsetenv(key string) error {
+	return osObj.env.Unsetenv(key)
+}
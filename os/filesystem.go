@@ -0,0 +1,291 @@
+package os
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"path"
+	"strings"
+	"sync"
+)
+
+var (
+	_ OS = (*FilesystemOS)(nil)
+	_ FS = (*ReadOnlyFS)(nil)
+)
+
+// ErrReadOnly is the error of writes to a read-only filesystem.
+var ErrReadOnly = errors.New("read-only file system")
+
+// WithFilesystem returns a context whose scripts access files in the given
+// filesystem, as described for FilesystemOS, rather than in that of the OS
+// of the context.
+func WithFilesystem(ctx context.Context, fsys FS) context.Context {
+	return WithOS(ctx, NewFilesystemOS(GetDefaultOS(ctx), fsys))
+}
+
+// FilesystemOS is an OS whose filesystem is replaced with another, such as
+// an in-memory or read-only one, so that untrusted scripts can't touch the
+// files of the host. Every other call goes to the underlying OS. Paths are
+// slash-separated and resolved from the root of the filesystem, against a
+// working directory that starts at "/", and temporary directories are made
+// under "/tmp".
+type FilesystemOS struct {
+	OS
+	fs    FS
+	cwd   string
+	mutex sync.RWMutex
+}
+
+// NewFilesystemOS returns an OS accessing files in fsys, and otherwise
+// behaving like the given OS.
+func NewFilesystemOS(osObj OS, fsys FS) *FilesystemOS {
+	return &FilesystemOS{OS: osObj, fs: fsys, cwd: "/"}
+}
+
+// resolve returns the absolute path of a name in the filesystem.
+func (osObj *FilesystemOS) resolve(name string) string {
+	if path.IsAbs(name) {
+		return path.Clean(name)
+	}
+	osObj.mutex.RLock()
+	defer osObj.mutex.RUnlock()
+	return path.Join(osObj.cwd, name)
+}
+
+func (osObj *FilesystemOS) Chdir(dir string) error {
+	dir = osObj.resolve(dir)
+	info, err := osObj.fs.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: "chdir", Path: dir, Err: errors.New("not a directory")}
+	}
+	osObj.mutex.Lock()
+	defer osObj.mutex.Unlock()
+	osObj.cwd = dir
+	return nil
+}
+
+func (osObj *FilesystemOS) Getwd() (string, error) {
+	osObj.mutex.RLock()
+	defer osObj.mutex.RUnlock()
+	return osObj.cwd, nil
+}
+
+func (osObj *FilesystemOS) TempDir() string {
+	return "/tmp"
+}
+
+func (osObj *FilesystemOS) MkdirTemp(dir, pattern string) (string, error) {
+	if dir == "" {
+		dir = osObj.TempDir()
+		if err := osObj.fs.MkdirAll(dir, 0o755); err != nil {
+			return "", err
+		}
+	}
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+	for i := 0; i < 100; i++ {
+		name := path.Join(osObj.resolve(dir), fmt.Sprintf("%s%d%s", prefix, rand.Uint32(), suffix))
+		err := osObj.fs.Mkdir(name, 0o700)
+		if err == nil {
+			return name, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return "", err
+		}
+	}
+	return "", &fs.PathError{Op: "mkdirtemp", Path: path.Join(dir, pattern), Err: fs.ErrExist}
+}
+
+func (osObj *FilesystemOS) Create(name string) (File, error) {
+	return osObj.fs.Create(osObj.resolve(name))
+}
+
+func (osObj *FilesystemOS) Mkdir(name string, perm FileMode) error {
+	return osObj.fs.Mkdir(osObj.resolve(name), perm)
+}
+
+func (osObj *FilesystemOS) MkdirAll(path string, perm FileMode) error {
+	return osObj.fs.MkdirAll(osObj.resolve(path), perm)
+}
+
+func (osObj *FilesystemOS) Open(name string) (File, error) {
+	return osObj.fs.Open(osObj.resolve(name))
+}
+
+func (osObj *FilesystemOS) OpenFile(name string, flag int, perm FileMode) (File, error) {
+	return osObj.fs.OpenFile(osObj.resolve(name), flag, perm)
+}
+
+func (osObj *FilesystemOS) ReadFile(name string) ([]byte, error) {
+	return osObj.fs.ReadFile(osObj.resolve(name))
+}
+
+func (osObj *FilesystemOS) Remove(name string) error {
+	return osObj.fs.Remove(osObj.resolve(name))
+}
+
+func (osObj *FilesystemOS) RemoveAll(path string) error {
+	return osObj.fs.RemoveAll(osObj.resolve(path))
+}
+
+func (osObj *FilesystemOS) Rename(oldpath, newpath string) error {
+	return osObj.fs.Rename(osObj.resolve(oldpath), osObj.resolve(newpath))
+}
+
+func (osObj *FilesystemOS) Stat(name string) (FileInfo, error) {
+	return osObj.fs.Stat(osObj.resolve(name))
+}
+
+func (osObj *FilesystemOS) Symlink(oldname, newname string) error {
+	return osObj.fs.Symlink(oldname, osObj.resolve(newname))
+}
+
+func (osObj *FilesystemOS) WriteFile(name string, data []byte, perm FileMode) error {
+	return osObj.fs.WriteFile(osObj.resolve(name), data, perm)
+}
+
+func (osObj *FilesystemOS) ReadDir(name string) ([]DirEntry, error) {
+	return osObj.fs.ReadDir(osObj.resolve(name))
+}
+
+func (osObj *FilesystemOS) WalkDir(root string, fn WalkDirFunc) error {
+	return osObj.fs.WalkDir(osObj.resolve(root), fn)
+}
+
+func (osObj *FilesystemOS) PathSeparator() rune {
+	return '/'
+}
+
+func (osObj *FilesystemOS) PathListSeparator() rune {
+	return ':'
+}
+
+// ReadOnlyFS is a filesystem serving the files of an io/fs.FS, such as an
+// embed.FS or an fstest.MapFS, which fails writes with ErrReadOnly.
+type ReadOnlyFS struct {
+	fs fs.FS
+}
+
+// NewReadOnlyFS returns a read-only filesystem serving the files of fsys.
+// Names may be rooted at "/", which is the root of fsys.
+func NewReadOnlyFS(fsys fs.FS) *ReadOnlyFS {
+	return &ReadOnlyFS{fs: fsys}
+}
+
+// name returns the unrooted name of a path in the io/fs.FS.
+func (f *ReadOnlyFS) name(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
+
+func (f *ReadOnlyFS) readOnly(op, name string) error {
+	return &fs.PathError{Op: op, Path: name, Err: ErrReadOnly}
+}
+
+func (f *ReadOnlyFS) Create(name string) (File, error) {
+	return nil, f.readOnly("create", name)
+}
+
+func (f *ReadOnlyFS) Mkdir(name string, perm FileMode) error {
+	return f.readOnly("mkdir", name)
+}
+
+func (f *ReadOnlyFS) MkdirAll(path string, perm FileMode) error {
+	return f.readOnly("mkdir", path)
+}
+
+func (f *ReadOnlyFS) Open(name string) (File, error) {
+	file, err := f.fs.Open(f.name(name))
+	if err != nil {
+		return nil, err
+	}
+	return &readOnlyFile{File: file, name: name}, nil
+}
+
+func (f *ReadOnlyFS) OpenFile(name string, flag int, perm FileMode) (File, error) {
+	if flag&(O_WRONLY|O_RDWR|O_APPEND|O_CREATE|O_TRUNC) != 0 {
+		return nil, f.readOnly("open", name)
+	}
+	return f.Open(name)
+}
+
+func (f *ReadOnlyFS) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(f.fs, f.name(name))
+}
+
+func (f *ReadOnlyFS) Remove(name string) error {
+	return f.readOnly("remove", name)
+}
+
+func (f *ReadOnlyFS) RemoveAll(path string) error {
+	return f.readOnly("remove", path)
+}
+
+func (f *ReadOnlyFS) Rename(oldpath, newpath string) error {
+	return f.readOnly("rename", oldpath)
+}
+
+func (f *ReadOnlyFS) Stat(name string) (FileInfo, error) {
+	return fs.Stat(f.fs, f.name(name))
+}
+
+func (f *ReadOnlyFS) Symlink(oldname, newname string) error {
+	return f.readOnly("symlink", newname)
+}
+
+func (f *ReadOnlyFS) WriteFile(name string, data []byte, perm FileMode) error {
+	return f.readOnly("write", name)
+}
+
+func (f *ReadOnlyFS) ReadDir(name string) ([]DirEntry, error) {
+	entries, err := fs.ReadDir(f.fs, f.name(name))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		results = append(results, &DirEntryWrapper{DirEntry: entry})
+	}
+	return results, nil
+}
+
+// WalkDir walks the tree at root as fs.WalkDir does, passing paths rooted
+// at "/" when root is.
+func (f *ReadOnlyFS) WalkDir(root string, fn WalkDirFunc) error {
+	rooted := path.IsAbs(root)
+	return fs.WalkDir(f.fs, f.name(root), func(p string, entry fs.DirEntry, err error) error {
+		if rooted {
+			p = path.Join("/", p)
+		}
+		if entry == nil {
+			return fn(p, nil, err)
+		}
+		return fn(p, &DirEntryWrapper{DirEntry: entry}, err)
+	})
+}
+
+// readOnlyFile is a file of a ReadOnlyFS.
+type readOnlyFile struct {
+	fs.File
+	name string
+}
+
+func (f *readOnlyFile) Write(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: f.name, Err: ErrReadOnly}
+}
+
+func (f *readOnlyFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	dir, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("not a directory")}
+	}
+	return dir.ReadDir(n)
+}
@@ -0,0 +1,61 @@
+package os
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyFS(t *testing.T) {
+	fsys := NewReadOnlyFS(fstest.MapFS{
+		"config/app.yaml": {Data: []byte("name: app")},
+		"README.md":       {Data: []byte("# readme")},
+	})
+	data, err := fsys.ReadFile("/config/app.yaml")
+	require.Nil(t, err)
+	require.Equal(t, "name: app", string(data))
+
+	f, err := fsys.Open("README.md")
+	require.Nil(t, err)
+	data, err = io.ReadAll(f)
+	require.Nil(t, err)
+	require.Equal(t, "# readme", string(data))
+	_, err = f.Write([]byte("x"))
+	require.True(t, errors.Is(err, ErrReadOnly))
+
+	require.EqualError(t, fsys.WriteFile("/new.txt", nil, 0o644), "write /new.txt: read-only file system")
+	_, err = fsys.OpenFile("/README.md", O_RDWR, 0)
+	require.True(t, errors.Is(err, ErrReadOnly))
+	require.True(t, errors.Is(fsys.Remove("/README.md"), ErrReadOnly))
+
+	var paths []string
+	err = fsys.WalkDir("/", func(path string, d fs.DirEntry, err error) error {
+		paths = append(paths, path)
+		return err
+	})
+	require.Nil(t, err)
+	require.Equal(t, []string{"/", "/README.md", "/config", "/config/app.yaml"}, paths)
+}
+
+func TestFilesystemOS(t *testing.T) {
+	ctx := WithFilesystem(context.Background(), NewReadOnlyFS(fstest.MapFS{
+		"etc/hosts": {Data: []byte("127.0.0.1 localhost")},
+	}))
+	osObj := GetDefaultOS(ctx)
+	require.Nil(t, osObj.Chdir("/etc"))
+	wd, err := osObj.Getwd()
+	require.Nil(t, err)
+	require.Equal(t, "/etc", wd)
+	data, err := osObj.ReadFile("hosts")
+	require.Nil(t, err)
+	require.Equal(t, "127.0.0.1 localhost", string(data))
+	require.NotNil(t, osObj.Chdir("hosts"))
+	_, err = osObj.Stat("../missing")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+	require.Equal(t, "/tmp", osObj.TempDir())
+}
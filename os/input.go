@@ -0,0 +1,61 @@
+package os
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+const stdinKey = contextKey("risor:stdin")
+
+// WithStdinReader returns a context whose scripts read their standard input
+// from the given reader rather than from the standard input of the OS.
+func WithStdinReader(ctx context.Context, r io.Reader) context.Context {
+	return context.WithValue(ctx, stdinKey, r)
+}
+
+// GetStdinReader returns the reader of standard input set by
+// WithStdinReader, if any.
+func GetStdinReader(ctx context.Context) (io.Reader, bool) {
+	r, ok := ctx.Value(stdinKey).(io.Reader)
+	return r, ok
+}
+
+// Stdin returns the file scripts read their standard input from, which is
+// the reader set by WithStdinReader, or else the standard input of the OS
+// in the context.
+func Stdin(ctx context.Context) File {
+	if r, ok := GetStdinReader(ctx); ok {
+		return NewReaderFile(r)
+	}
+	return GetDefaultOS(ctx).Stdin()
+}
+
+// ReaderFile is a read-only file backed by an io.Reader.
+type ReaderFile struct {
+	r io.Reader
+}
+
+// NewReaderFile returns a file reading from the given reader.
+func NewReaderFile(r io.Reader) *ReaderFile {
+	if f, ok := r.(*ReaderFile); ok {
+		return f
+	}
+	return &ReaderFile{r: r}
+}
+
+func (f *ReaderFile) Close() error {
+	return nil
+}
+
+func (f *ReaderFile) Read(p []byte) (n int, err error) {
+	return f.r.Read(p)
+}
+
+func (f *ReaderFile) Write(p []byte) (n int, err error) {
+	return 0, errors.New("io error: file is read-only")
+}
+
+func (f *ReaderFile) Stat() (FileInfo, error) {
+	return NewFileInfo(GenericFileInfoOpts{Name: ""}), nil
+}
@@ -0,0 +1,447 @@
+// Package memfs provides an in-memory filesystem for Risor scripts, so that
+// they can write files without touching the disk.
+package memfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ros "github.com/risor-io/risor/os"
+)
+
+var _ ros.FS = (*Filesystem)(nil)
+
+// Filesystem is an in-memory filesystem, safe for concurrent use. Paths are
+// slash-separated, and relative paths are resolved from its root. Symbolic
+// links aren't supported.
+type Filesystem struct {
+	mutex sync.RWMutex
+	root  *node
+}
+
+// node is a file or directory of the filesystem.
+type node struct {
+	name     string
+	mode     ros.FileMode
+	modTime  time.Time
+	data     []byte
+	children map[string]*node
+}
+
+// New returns an empty filesystem.
+func New() *Filesystem {
+	return &Filesystem{root: newDir("/", 0o755)}
+}
+
+func newDir(name string, perm ros.FileMode) *node {
+	return &node{
+		name:     name,
+		mode:     fs.ModeDir | perm.Perm(),
+		modTime:  time.Now(),
+		children: map[string]*node{},
+	}
+}
+
+func (n *node) info() ros.FileInfo {
+	return ros.NewFileInfo(ros.GenericFileInfoOpts{
+		Name:    n.name,
+		Size:    int64(len(n.data)),
+		Mode:    n.mode,
+		ModTime: n.modTime,
+		IsDir:   n.mode.IsDir(),
+	})
+}
+
+func (n *node) entries() []fs.DirEntry {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	entries := make([]fs.DirEntry, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, fs.FileInfoToDirEntry(n.children[name].info()))
+	}
+	return entries
+}
+
+// split returns the names of the elements of a path.
+func split(p string) []string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// lookup returns the node at the given path, with the lock held.
+func (f *Filesystem) lookup(op, p string) (*node, error) {
+	n := f.root
+	for _, name := range split(p) {
+		if !n.mode.IsDir() {
+			return nil, &fs.PathError{Op: op, Path: p, Err: errors.New("not a directory")}
+		}
+		child, ok := n.children[name]
+		if !ok {
+			return nil, &fs.PathError{Op: op, Path: p, Err: fs.ErrNotExist}
+		}
+		n = child
+	}
+	return n, nil
+}
+
+// lookupParent returns the directory holding the given path and the name
+// of its last element, with the lock held.
+func (f *Filesystem) lookupParent(op, p string) (*node, string, error) {
+	names := split(p)
+	if len(names) == 0 {
+		return nil, "", &fs.PathError{Op: op, Path: p, Err: fs.ErrInvalid}
+	}
+	dir, err := f.lookup(op, path.Join(names[:len(names)-1]...))
+	if err != nil {
+		return nil, "", &fs.PathError{Op: op, Path: p, Err: fs.ErrNotExist}
+	}
+	if !dir.mode.IsDir() {
+		return nil, "", &fs.PathError{Op: op, Path: p, Err: errors.New("not a directory")}
+	}
+	return dir, names[len(names)-1], nil
+}
+
+func (f *Filesystem) Create(name string) (ros.File, error) {
+	return f.OpenFile(name, ros.O_RDWR|ros.O_CREATE|ros.O_TRUNC, 0o666)
+}
+
+func (f *Filesystem) Mkdir(name string, perm ros.FileMode) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	dir, base, err := f.lookupParent("mkdir", name)
+	if err != nil {
+		return err
+	}
+	if _, ok := dir.children[base]; ok {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+	dir.children[base] = newDir(base, perm)
+	dir.modTime = time.Now()
+	return nil
+}
+
+func (f *Filesystem) MkdirAll(p string, perm ros.FileMode) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	n := f.root
+	for _, name := range split(p) {
+		child, ok := n.children[name]
+		if !ok {
+			child = newDir(name, perm)
+			n.children[name] = child
+			n.modTime = time.Now()
+		} else if !child.mode.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: p, Err: errors.New("not a directory")}
+		}
+		n = child
+	}
+	return nil
+}
+
+func (f *Filesystem) Open(name string) (ros.File, error) {
+	return f.OpenFile(name, ros.O_RDONLY, 0)
+}
+
+func (f *Filesystem) OpenFile(name string, flag int, perm ros.FileMode) (ros.File, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	n, err := f.lookup("open", name)
+	if err != nil && flag&ros.O_CREATE != 0 && errors.Is(err, fs.ErrNotExist) {
+		dir, base, err := f.lookupParent("open", name)
+		if err != nil {
+			return nil, err
+		}
+		n = &node{name: base, mode: perm.Perm(), modTime: time.Now()}
+		dir.children[base] = n
+		dir.modTime = n.modTime
+	} else if err != nil {
+		return nil, err
+	} else if flag&(ros.O_CREATE|ros.O_EXCL) == ros.O_CREATE|ros.O_EXCL {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+	writable := flag&(ros.O_WRONLY|ros.O_RDWR) != 0
+	if n.mode.IsDir() && writable {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	}
+	if writable && flag&ros.O_TRUNC != 0 {
+		n.data = nil
+		n.modTime = time.Now()
+	}
+	return &file{
+		fs:       f,
+		node:     n,
+		name:     name,
+		readable: flag&ros.O_WRONLY == 0,
+		writable: writable,
+		append:   flag&ros.O_APPEND != 0,
+	}, nil
+}
+
+func (f *Filesystem) ReadFile(name string) ([]byte, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	n, err := f.lookup("read", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	return append([]byte(nil), n.data...), nil
+}
+
+func (f *Filesystem) Remove(name string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	dir, base, err := f.lookupParent("remove", name)
+	if err != nil {
+		return err
+	}
+	n, ok := dir.children[base]
+	if !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if len(n.children) > 0 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+	}
+	delete(dir.children, base)
+	dir.modTime = time.Now()
+	return nil
+}
+
+func (f *Filesystem) RemoveAll(p string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if len(split(p)) == 0 {
+		f.root.children = map[string]*node{}
+		return nil
+	}
+	dir, base, err := f.lookupParent("remove", p)
+	if err != nil {
+		// Like os.RemoveAll, removing a path that doesn't exist succeeds
+		return nil
+	}
+	delete(dir.children, base)
+	dir.modTime = time.Now()
+	return nil
+}
+
+func (f *Filesystem) Rename(oldpath, newpath string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	oldDir, oldBase, err := f.lookupParent("rename", oldpath)
+	if err != nil {
+		return err
+	}
+	n, ok := oldDir.children[oldBase]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: fs.ErrNotExist}
+	}
+	targetDir, newBase, err := f.lookupParent("rename", newpath)
+	if err != nil {
+		return err
+	}
+	if n.mode.IsDir() && strings.HasPrefix(path.Clean("/"+newpath)+"/", path.Clean("/"+oldpath)+"/") {
+		return &fs.PathError{Op: "rename", Path: newpath, Err: fs.ErrInvalid}
+	}
+	if existing, ok := targetDir.children[newBase]; ok && existing.mode.IsDir() && len(existing.children) > 0 {
+		return &fs.PathError{Op: "rename", Path: newpath, Err: errors.New("directory not empty")}
+	}
+	delete(oldDir.children, oldBase)
+	n.name = newBase
+	targetDir.children[newBase] = n
+	oldDir.modTime, targetDir.modTime = time.Now(), time.Now()
+	return nil
+}
+
+func (f *Filesystem) Stat(name string) (ros.FileInfo, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	n, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return n.info(), nil
+}
+
+func (f *Filesystem) Symlink(oldname, newname string) error {
+	return &fs.PathError{Op: "symlink", Path: newname, Err: errors.ErrUnsupported}
+}
+
+func (f *Filesystem) WriteFile(name string, data []byte, perm ros.FileMode) error {
+	file, err := f.OpenFile(name, ros.O_WRONLY|ros.O_CREATE|ros.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (f *Filesystem) ReadDir(name string) ([]ros.DirEntry, error) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	n, err := f.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !n.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	entries := n.entries()
+	results := make([]ros.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		results = append(results, &ros.DirEntryWrapper{DirEntry: entry})
+	}
+	return results, nil
+}
+
+// WalkDir walks the tree at root in lexical order, as filepath.WalkDir does.
+// The lock isn't held while fn is called, so fn may change the filesystem.
+func (f *Filesystem) WalkDir(root string, fn ros.WalkDirFunc) error {
+	info, err := f.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = f.walk(root, &ros.DirEntryWrapper{DirEntry: fs.FileInfoToDirEntry(info)}, fn)
+	}
+	if err == fs.SkipDir || err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (f *Filesystem) walk(p string, entry ros.DirEntry, fn ros.WalkDirFunc) error {
+	if err := fn(p, entry, nil); err != nil || !entry.IsDir() {
+		if err == fs.SkipDir && entry.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	entries, err := f.ReadDir(p)
+	if err != nil {
+		// The directory was removed by fn
+		if err = fn(p, entry, err); err == fs.SkipDir {
+			err = nil
+		}
+		return err
+	}
+	for _, child := range entries {
+		if err := f.walk(path.Join(p, child.Name()), child, fn); err != nil {
+			if err == fs.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// file is an open file or directory of the filesystem.
+type file struct {
+	fs       *Filesystem
+	node     *node
+	name     string
+	offset   int64
+	readable bool
+	writable bool
+	append   bool
+	closed   bool
+	dirRead  int
+}
+
+func (f *file) Stat() (ros.FileInfo, error) {
+	f.fs.mutex.RLock()
+	defer f.fs.mutex.RUnlock()
+	return f.node.info(), nil
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrClosed}
+	}
+	if !f.readable {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrPermission}
+	}
+	f.fs.mutex.RLock()
+	defer f.fs.mutex.RUnlock()
+	if f.node.mode.IsDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.New("is a directory")}
+	}
+	if f.offset >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrClosed}
+	}
+	if !f.writable {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+	}
+	f.fs.mutex.Lock()
+	defer f.fs.mutex.Unlock()
+	if f.append {
+		f.offset = int64(len(f.node.data))
+	}
+	end := f.offset + int64(len(p))
+	if end > int64(len(f.node.data)) {
+		data := make([]byte, end)
+		copy(data, f.node.data)
+		f.node.data = data
+	}
+	copy(f.node.data[f.offset:], p)
+	f.offset = end
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *file) ReadDir(count int) ([]fs.DirEntry, error) {
+	f.fs.mutex.RLock()
+	defer f.fs.mutex.RUnlock()
+	if !f.node.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("not a directory")}
+	}
+	entries := f.node.entries()
+	if f.dirRead >= len(entries) {
+		entries = nil
+	} else {
+		entries = entries[f.dirRead:]
+	}
+	if count > 0 {
+		if len(entries) == 0 {
+			return nil, io.EOF
+		}
+		if len(entries) > count {
+			entries = entries[:count]
+		}
+	}
+	f.dirRead += len(entries)
+	return entries, nil
+}
+
+func (f *file) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	return nil
+}
@@ -0,0 +1,90 @@
+package memfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+
+	ros "github.com/risor-io/risor/os"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilesystem(t *testing.T) {
+	fsys := New()
+
+	_, err := fsys.Open("test.txt")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+
+	f, err := fsys.Create("/test.txt")
+	require.Nil(t, err)
+	n, err := f.Write([]byte("hello world"))
+	require.Nil(t, err)
+	require.Equal(t, 11, n)
+	require.Nil(t, f.Close())
+
+	data, err := fsys.ReadFile("test.txt")
+	require.Nil(t, err)
+	require.Equal(t, "hello world", string(data))
+
+	f, err = fsys.OpenFile("test.txt", ros.O_WRONLY|ros.O_APPEND, 0)
+	require.Nil(t, err)
+	_, err = f.Write([]byte("!"))
+	require.Nil(t, err)
+	require.Nil(t, f.Close())
+
+	f, err = fsys.Open("test.txt")
+	require.Nil(t, err)
+	data, err = io.ReadAll(f)
+	require.Nil(t, err)
+	require.Equal(t, "hello world!", string(data))
+	_, err = f.Write([]byte("x"))
+	require.True(t, errors.Is(err, fs.ErrPermission))
+
+	info, err := fsys.Stat("test.txt")
+	require.Nil(t, err)
+	require.Equal(t, "test.txt", info.Name())
+	require.Equal(t, int64(12), info.Size())
+	require.False(t, info.IsDir())
+
+	_, err = fsys.OpenFile("test.txt", ros.O_WRONLY|ros.O_CREATE|ros.O_EXCL, 0o644)
+	require.True(t, errors.Is(err, fs.ErrExist))
+	require.True(t, errors.Is(fsys.Symlink("test.txt", "link"), errors.ErrUnsupported))
+}
+
+func TestFilesystemDirectories(t *testing.T) {
+	fsys := New()
+	require.Nil(t, fsys.MkdirAll("/a/b", 0o755))
+	require.True(t, errors.Is(fsys.Mkdir("/a", 0o755), fs.ErrExist))
+	require.True(t, errors.Is(fsys.Mkdir("/x/y", 0o755), fs.ErrNotExist))
+	require.Nil(t, fsys.WriteFile("/a/b/c.txt", []byte("c"), 0o644))
+	require.Nil(t, fsys.WriteFile("/a/d.txt", []byte("d"), 0o644))
+
+	entries, err := fsys.ReadDir("/a")
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "b", entries[0].Name())
+	require.True(t, entries[0].IsDir())
+	require.Equal(t, "d.txt", entries[1].Name())
+
+	var paths []string
+	err = fsys.WalkDir("/", func(path string, entry fs.DirEntry, err error) error {
+		require.Nil(t, err)
+		paths = append(paths, path)
+		return nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, []string{"/", "/a", "/a/b", "/a/b/c.txt", "/a/d.txt"}, paths)
+
+	require.NotNil(t, fsys.Remove("/a/b"))
+	require.Nil(t, fsys.Rename("/a/b", "/e"))
+	data, err := fsys.ReadFile("/e/c.txt")
+	require.Nil(t, err)
+	require.Equal(t, "c", string(data))
+	require.True(t, errors.Is(fsys.Rename("/e", "/e/f"), fs.ErrInvalid))
+
+	require.Nil(t, fsys.RemoveAll("/a"))
+	_, err = fsys.Stat("/a/d.txt")
+	require.True(t, errors.Is(err, fs.ErrNotExist))
+	require.Nil(t, fsys.RemoveAll("/missing"))
+}
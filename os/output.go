@@ -0,0 +1,135 @@
+package os
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	stdoutKey = contextKey("risor:stdout")
+	stderrKey = contextKey("risor:stderr")
+)
+
+// WithStdoutWriter returns a context whose scripts write their standard
+// output, including that of print and printf, to the given writer rather
+// than to the standard output of the OS.
+func WithStdoutWriter(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, stdoutKey, w)
+}
+
+// WithStderrWriter returns a context whose scripts write their standard
+// error to the given writer rather than to the standard error of the
+// process.
+func WithStderrWriter(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, stderrKey, w)
+}
+
+// GetStdoutWriter returns the writer of standard output set by
+// WithStdoutWriter, if any.
+func GetStdoutWriter(ctx context.Context) (io.Writer, bool) {
+	w, ok := ctx.Value(stdoutKey).(io.Writer)
+	return w, ok
+}
+
+// GetStderrWriter returns the writer of standard error set by
+// WithStderrWriter, if any.
+func GetStderrWriter(ctx context.Context) (io.Writer, bool) {
+	w, ok := ctx.Value(stderrKey).(io.Writer)
+	return w, ok
+}
+
+// Stdout returns the file scripts write their standard output to, which is
+// the writer set by WithStdoutWriter, or else the standard output of the OS
+// in the context.
+func Stdout(ctx context.Context) File {
+	if w, ok := GetStdoutWriter(ctx); ok {
+		return NewWriterFile(w)
+	}
+	return GetDefaultOS(ctx).Stdout()
+}
+
+// Stderr returns the file scripts write their standard error to, which is
+// the writer set by WithStderrWriter, or else the standard error of the
+// process.
+func Stderr(ctx context.Context) File {
+	if w, ok := GetStderrWriter(ctx); ok {
+		return NewWriterFile(w)
+	}
+	return os.Stderr
+}
+
+// WriterFile is a write-only file backed by an io.Writer.
+type WriterFile struct {
+	w io.Writer
+}
+
+// NewWriterFile returns a file writing to the given writer.
+func NewWriterFile(w io.Writer) *WriterFile {
+	if f, ok := w.(*WriterFile); ok {
+		return f
+	}
+	return &WriterFile{w: w}
+}
+
+func (f *WriterFile) Close() error {
+	return nil
+}
+
+func (f *WriterFile) Read(p []byte) (n int, err error) {
+	return 0, errors.New("io error: file is write-only")
+}
+
+func (f *WriterFile) Write(p []byte) (n int, err error) {
+	return f.w.Write(p)
+}
+
+func (f *WriterFile) Stat() (FileInfo, error) {
+	return NewFileInfo(GenericFileInfoOpts{Name: ""}), nil
+}
+
+// LineWriter is a writer calling a function with each line written to it,
+// without its line ending, such as to prefix or stream the output of a
+// script. It is safe for concurrent use.
+type LineWriter struct {
+	fn    func(line string)
+	buf   []byte
+	mutex sync.Mutex
+}
+
+// NewLineWriter returns a writer calling fn with each line written to it.
+// A final line without a line ending is passed to fn by Flush.
+func NewLineWriter(fn func(line string)) *LineWriter {
+	return &LineWriter{fn: fn}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := bytes.TrimSuffix(w.buf[:i], []byte{'\r'})
+		w.fn(string(line))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush passes the final line written, if it had no line ending, to the
+// function of the writer.
+func (w *LineWriter) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if len(w.buf) > 0 {
+		w.fn(string(w.buf))
+		w.buf = nil
+	}
+	return nil
+}
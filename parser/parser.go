@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/risor-io/risor/ast"
 	"github.com/risor-io/risor/internal/tmpl"
@@ -26,12 +27,7 @@ type (
 // shorthand way to create a Lexer and Parser and then call Parse on that.
 func Parse(ctx context.Context, input string, options ...Option) (*ast.Program, error) {
 	l := lexer.New(input)
-	p := New(l, options...)
-	if p.filename != "" {
-		// If an option specified a filename, pass that through to the lexer.
-		l.SetFilename(p.filename)
-	}
-	return p.Parse(ctx)
+	return New(l, options...).Parse(ctx)
 }
 
 // Option is a configuration function for a Lexer.
@@ -97,6 +93,11 @@ func New(l *lexer.Lexer, options ...Option) *Parser {
 	for _, opt := range options {
 		opt(p)
 	}
+	if p.filename != "" {
+		// If an option specified a filename, pass that through to the lexer
+		// before it reads the first tokens
+		l.SetFilename(p.filename)
+	}
 
 	// Prime the token pump
 	p.nextToken() // makes curToken=<empty>, peekToken=token[0]
@@ -692,10 +693,25 @@ func (p *Parser) parseSwitch() ast.Node {
 
 func (p *Parser) parseImport() ast.Node {
 	importToken := p.curToken
-	if !p.expectPeek("an import statement", token.IDENT) {
+	// Modules may also be imported by a path or URL given as a string
+	if p.peekTokenIs(token.STRING) {
+		p.nextToken()
+	} else if !p.expectPeek("an import statement", token.IDENT) {
 		return nil
 	}
-	name := ast.NewIdent(p.curToken)
+	// A dotted name such as "a.b.c" imports the module at path "a/b/c"
+	nameToken := p.curToken
+	var lastToken token.Token
+	for nameToken.Type == token.IDENT && p.peekTokenIs(token.PERIOD) {
+		p.nextToken()
+		if !p.expectPeek("an import statement", token.IDENT) {
+			return nil
+		}
+		lastToken = p.curToken
+		nameToken.Literal += "." + lastToken.Literal
+		nameToken.EndPosition = lastToken.EndPosition
+	}
+	name := ast.NewIdent(nameToken)
 	var alias *ast.Ident
 	if p.peekTokenIs(token.AS) {
 		p.nextToken()
@@ -703,10 +719,59 @@ func (p *Parser) parseImport() ast.Node {
 			return nil
 		}
 		alias = ast.NewIdent(p.curToken)
+	} else if lastToken.Literal != "" {
+		// Without an alias, a dotted import is bound to its last name
+		alias = ast.NewIdent(lastToken)
+	} else if name.Token().Type == token.STRING {
+		aliasToken := name.Token()
+		aliasToken.Type = token.IDENT
+		aliasToken.Literal = importName(name.Literal())
+		if aliasToken.Literal == "" {
+			p.setError(NewParserError(ErrorOpts{
+				ErrType:       "parse error",
+				Message:       fmt.Sprintf("import of %q requires an alias", name.Literal()),
+				File:          p.l.Filename(),
+				StartPosition: p.curToken.StartPosition,
+				EndPosition:   p.curToken.EndPosition,
+				SourceCode:    p.l.GetLineText(p.curToken),
+			}))
+			return nil
+		}
+		alias = ast.NewIdent(aliasToken)
 	}
 	return ast.NewImport(importToken, name, alias)
 }
 
+// importName returns the name a module imported by path is bound to when no
+// alias is given: the last element of the path, without any version, query,
+// fragment, or file extension. For example, "github.com/org/lib@v1.2.0" and
+// "https://example.com/lib.risor#sha256=..." are both bound to "lib". An
+// empty string is returned if that isn't a valid identifier.
+func importName(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimRight(path, "/")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		path = path[i+1:]
+	}
+	if i := strings.Index(path, "@"); i >= 0 {
+		path = path[:i]
+	}
+	if i := strings.LastIndex(path, "."); i > 0 {
+		path = path[:i]
+	}
+	if path == "" || token.LookupIdentifier(path) != token.IDENT {
+		return ""
+	}
+	for i, r := range path {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return ""
+		}
+	}
+	return path
+}
+
 func (p *Parser) parseFromImport() ast.Node {
 	fromToken := p.curToken
 	if !p.expectPeek("a from-import statement", token.IDENT) {
@@ -747,6 +812,12 @@ func (p *Parser) parseFromImport() ast.Node {
 			p.nextToken()
 		}
 	}
+	// A wildcard imports everything the module exports
+	if !isGrouped && p.peekTokenIs(token.ASTERISK) {
+		p.nextToken()
+		wildcard := ast.NewImport(importToken, ast.NewIdent(p.curToken), nil)
+		return ast.NewFromImport(fromToken, parentModule, []*ast.Import{wildcard}, false)
+	}
 	// Move to the first identifier
 	if !p.expectPeek("a from-import statement", token.IDENT) {
 		return nil
@@ -1055,6 +1055,46 @@ func TestInvalidDeferStatements(t *testing.T) {
 	}
 }
 
+func TestImportPath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "https://example.com/lib.risor"`, `import "https://example.com/lib.risor" as lib`},
+		{`import "https://example.com/lib.risor#sha256=abc"`, `import "https://example.com/lib.risor#sha256=abc" as lib`},
+		{`import "github.com/org/lib@v1.2.0"`, `import "github.com/org/lib@v1.2.0" as lib`},
+		{`import "https://example.com/my-lib.risor" as mylib`, `import "https://example.com/my-lib.risor" as mylib`},
+		{`import util`, `import util`},
+		{`import very.long.module as m`, `import very.long.module as m`},
+		{`import text.strings`, `import text.strings as strings`},
+	}
+	for _, tt := range tests {
+		result, err := Parse(context.Background(), tt.input)
+		require.Nil(t, err)
+		require.Equal(t, tt.expected, result.String())
+		require.IsType(t, &ast.Import{}, result.Statements()[0])
+	}
+}
+
+func TestBadImportPath(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import "https://example.com/my-lib.risor"`, `parse error: import of "https://example.com/my-lib.risor" requires an alias`},
+		{`import "https://example.com/if.risor"`, `parse error: import of "https://example.com/if.risor" requires an alias`},
+		{`import "lib" as`, "parse error: unexpected end of file while parsing an import statement (expected identifier)"},
+		{`import text.`, "parse error: unexpected end of file while parsing an import statement (expected identifier)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
 func TestFromImport(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1067,6 +1107,8 @@ func TestFromImport(t *testing.T) {
 			min as a,
 			max as b,
 		  )`, "from math import (min as a, max as b)"},
+		{"from math import *", "from math import *"},
+		{"from a.b import *", "from a.b import *"},
 	}
 	for _, tt := range tests {
 		result, err := Parse(context.Background(), tt.input)
@@ -1086,6 +1128,7 @@ func TestBadFromImport(t *testing.T) {
 		{"from math import min as a,", "parse error: unexpected end of file while parsing a from-import statement (expected identifier)"},
 		{"from math import ", "parse error: unexpected end of file while parsing a from-import statement (expected identifier)"},
 		{"from math", "parse error: from-import is missing import statement"},
+		{"from math import (*)", "parse error: unexpected * while parsing a from-import statement (expected identifier)"},
 		{"from math import (a", "parse error: unexpected end of file while parsing a from-import statement (expected ))"},
 	}
 	for _, tt := range tests {
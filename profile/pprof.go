@@ -0,0 +1,219 @@
+package profile
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Kind selects the values of the samples written to a profile.
+type Kind int
+
+const (
+	// CPU profiles hold the sample counts and the time elapsed
+	CPU Kind = iota
+
+	// Memory profiles hold the objects and bytes allocated
+	Memory
+)
+
+// WritePprof writes the samples as a gzipped pprof profile of the given
+// kind, as read by "go tool pprof".
+func (p *Profiler) WritePprof(w io.Writer, kind Kind) error {
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(p.encode(kind)); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// WriteFolded writes the samples as folded stacks of the given kind, one
+// "outer;inner value" line per stack, as read by flamegraph.pl. The value
+// is in nanoseconds for CPU profiles and bytes for memory profiles.
+func (p *Profiler) WriteFolded(w io.Writer, kind Kind) error {
+	totals := map[string]int64{}
+	for _, sample := range p.Samples() {
+		names := make([]string, len(sample.Stack))
+		for i, frame := range sample.Stack {
+			names[len(names)-1-i] = strings.ReplaceAll(frame.Function, ";", ":")
+		}
+		value := sample.Nanoseconds
+		if kind == Memory {
+			value = sample.AllocBytes
+		}
+		totals[strings.Join(names, ";")] += value
+	}
+	stacks := make([]string, 0, len(totals))
+	for stack, value := range totals {
+		if value > 0 {
+			stacks = append(stacks, stack)
+		}
+	}
+	sort.Strings(stacks)
+	for _, stack := range stacks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, totals[stack]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encode returns the samples as an uncompressed pprof profile, a protocol
+// buffer described by github.com/google/pprof/proto/profile.proto.
+func (p *Profiler) encode(kind Kind) []byte {
+	var b protoBuffer
+	strs := map[string]int{}
+	var table []string
+	str := func(s string) uint64 {
+		if i, ok := strs[s]; ok {
+			return uint64(i)
+		}
+		strs[s] = len(table)
+		table = append(table, s)
+		return uint64(len(table) - 1)
+	}
+	str("")
+
+	valueType := func(field int, typ, unit string) {
+		var vt protoBuffer
+		vt.uint(1, str(typ))
+		vt.uint(2, str(unit))
+		b.message(field, vt)
+	}
+	if kind == Memory {
+		valueType(1, "alloc_objects", "count")
+		valueType(1, "alloc_space", "bytes")
+	} else {
+		valueType(1, "samples", "count")
+		valueType(1, "cpu", "nanoseconds")
+	}
+
+	// Functions are identified by their name and declaration, locations by
+	// their function and line
+	type function struct {
+		name      string
+		file      string
+		startLine int
+	}
+	type location struct {
+		function uint64
+		line     int
+	}
+	functions := map[function]uint64{}
+	locations := map[location]uint64{}
+	var functionsBuf, locationsBuf protoBuffer
+	locationID := func(frame Frame) uint64 {
+		fn := function{frame.Function, frame.File, frame.StartLine}
+		fnID, ok := functions[fn]
+		if !ok {
+			fnID = uint64(len(functions) + 1)
+			functions[fn] = fnID
+			var m protoBuffer
+			m.uint(1, fnID)
+			m.uint(2, str(fn.name))
+			m.uint(3, str(fn.name))
+			m.uint(4, str(fn.file))
+			m.uint(5, uint64(fn.startLine))
+			functionsBuf.message(5, m)
+		}
+		loc := location{fnID, frame.Line}
+		locID, ok := locations[loc]
+		if !ok {
+			locID = uint64(len(locations) + 1)
+			locations[loc] = locID
+			var line protoBuffer
+			line.uint(1, fnID)
+			line.uint(2, uint64(loc.line))
+			var m protoBuffer
+			m.uint(1, locID)
+			m.message(4, line)
+			locationsBuf.message(4, m)
+		}
+		return locID
+	}
+
+	for _, sample := range p.Samples() {
+		ids := make([]uint64, len(sample.Stack))
+		for i, frame := range sample.Stack {
+			ids[i] = locationID(frame)
+		}
+		values := []uint64{uint64(sample.Count), uint64(sample.Nanoseconds)}
+		if kind == Memory {
+			values = []uint64{uint64(sample.AllocObjects), uint64(sample.AllocBytes)}
+		}
+		var m protoBuffer
+		m.packed(1, ids)
+		m.packed(2, values)
+		b.message(2, m)
+	}
+	b.bytes(locationsBuf)
+	b.bytes(functionsBuf)
+
+	p.mutex.Lock()
+	start := p.start
+	p.mutex.Unlock()
+	b.uint(9, uint64(start.UnixNano()))
+	b.uint(10, uint64(p.Duration().Nanoseconds()))
+	if kind == Memory {
+		valueType(11, "space", "bytes")
+	} else {
+		valueType(11, "cpu", "nanoseconds")
+		b.uint(12, uint64(p.period.Nanoseconds()))
+	}
+	// The string table is written last, once all strings are known
+	for _, s := range table {
+		b.string(6, s)
+	}
+	return b
+}
+
+// protoBuffer encodes the protocol buffer wire format.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+func (b *protoBuffer) key(field, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+// uint writes a varint field, omitting zero values as proto3 does.
+func (b *protoBuffer) uint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.key(field, 0)
+	b.varint(v)
+}
+
+func (b *protoBuffer) string(field int, s string) {
+	b.key(field, 2)
+	b.varint(uint64(len(s)))
+	*b = append(*b, s...)
+}
+
+func (b *protoBuffer) message(field int, m protoBuffer) {
+	b.key(field, 2)
+	b.varint(uint64(len(m)))
+	*b = append(*b, m...)
+}
+
+func (b *protoBuffer) packed(field int, values []uint64) {
+	var m protoBuffer
+	for _, v := range values {
+		m.varint(v)
+	}
+	b.message(field, m)
+}
+
+// bytes appends fields encoded separately.
+func (b *protoBuffer) bytes(fields protoBuffer) {
+	*b = append(*b, fields...)
+}
@@ -0,0 +1,222 @@
+// Package profile finds where Risor code spends its time and allocates
+// memory, by Risor function and line rather than by the Go functions of the
+// interpreter, which is what Go's own profiles show.
+//
+// A Profiler is given to the VM with vm.WithProfiler, which samples the
+// Risor call stack at a fixed period. Each sample is attributed the time
+// elapsed and the memory allocated since the previous one. The samples are
+// written in the pprof format, read by "go tool pprof", which also renders
+// them as a flame graph, or as the folded stacks read by flamegraph.pl and
+// speedscope.
+//
+// A Tracer is given to the VM with vm.WithTracer, which records every call
+// of a Risor function. The calls are written in the trace event format read
+// by Perfetto and chrome://tracing.
+package profile
+
+import (
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPeriod is the period at which the call stack is sampled, unless
+// configured otherwise.
+const DefaultPeriod = 10 * time.Millisecond
+
+// Frame is a frame of a sampled call stack.
+type Frame struct {
+	// Name of the function, or the file of the main code of a script or
+	// module
+	Function string
+
+	// File and line of the function's declaration
+	File      string
+	StartLine int
+
+	// Line running in the frame
+	Line int
+}
+
+// Sample is the cost attributed to a call stack.
+type Sample struct {
+	// Frames of the stack, innermost first
+	Stack []Frame
+
+	// Number of times the stack was sampled
+	Count int64
+
+	// Time elapsed in the stack
+	Nanoseconds int64
+
+	// Memory allocated in the stack
+	AllocObjects int64
+	AllocBytes   int64
+}
+
+// Profiler accumulates samples of call stacks. Sampling is timed from when
+// Start is called until Stop is. It is safe for concurrent use.
+type Profiler struct {
+	period  time.Duration
+	ticks   atomic.Int64
+	stop    chan struct{}
+	mutex   sync.Mutex
+	samples map[string]*Sample
+	order   []string
+	start   time.Time
+	last    time.Time
+
+	// Allocation counters at the last sample
+	metrics []metrics.Sample
+	objects uint64
+	bytes   uint64
+}
+
+// New returns a Profiler sampling at the given period, or at DefaultPeriod
+// if it's zero.
+func New(period time.Duration) *Profiler {
+	if period <= 0 {
+		period = DefaultPeriod
+	}
+	p := &Profiler{
+		period:  period,
+		samples: map[string]*Sample{},
+		start:   time.Now(),
+		metrics: []metrics.Sample{
+			{Name: "/gc/heap/allocs:objects"},
+			{Name: "/gc/heap/allocs:bytes"},
+		},
+	}
+	p.last = p.start
+	p.objects, p.bytes = p.readAllocs()
+	return p
+}
+
+// Period returns the period at which the call stack is sampled.
+func (p *Profiler) Period() time.Duration {
+	return p.period
+}
+
+// Start starts timing samples, resetting the time and allocations counted
+// for the first one.
+func (p *Profiler) Start() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stop != nil {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.start = time.Now()
+	p.last = p.start
+	p.objects, p.bytes = p.readAllocs()
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(p.period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.ticks.Add(1)
+			case <-stop:
+				return
+			}
+		}
+	}(p.stop)
+}
+
+// Stop stops timing samples.
+func (p *Profiler) Stop() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+// Due reports whether a sample is due, at most once per period elapsed. The
+// VM checks it before each instruction, so it must be cheap.
+func (p *Profiler) Due() bool {
+	return p.ticks.Load() > 0 && p.ticks.Swap(0) > 0
+}
+
+// Duration returns the time from when sampling started to the last sample.
+func (p *Profiler) Duration() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.last.Sub(p.start)
+}
+
+// readAllocs returns the objects and bytes allocated by the process so far.
+func (p *Profiler) readAllocs() (uint64, uint64) {
+	metrics.Read(p.metrics)
+	var objects, bytes uint64
+	if p.metrics[0].Value.Kind() == metrics.KindUint64 {
+		objects = p.metrics[0].Value.Uint64()
+	}
+	if p.metrics[1].Value.Kind() == metrics.KindUint64 {
+		bytes = p.metrics[1].Value.Uint64()
+	}
+	return objects, bytes
+}
+
+// Add records a sample of the call stack, innermost frame first, which is
+// attributed the time elapsed and the memory allocated since the previous
+// sample. Allocations are counted for the whole process, so they are only
+// accurate for a single VM running at a time.
+func (p *Profiler) Add(stack []Frame) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := time.Now()
+	objects, bytes := p.readAllocs()
+	key := stackKey(stack)
+	sample, ok := p.samples[key]
+	if !ok {
+		sample = &Sample{Stack: append([]Frame(nil), stack...)}
+		p.samples[key] = sample
+		p.order = append(p.order, key)
+	}
+	sample.Count++
+	sample.Nanoseconds += now.Sub(p.last).Nanoseconds()
+	sample.AllocObjects += int64(objects - p.objects)
+	sample.AllocBytes += int64(bytes - p.bytes)
+	p.last, p.objects, p.bytes = now, objects, bytes
+}
+
+// Skip discards the time elapsed and memory allocated since the previous
+// sample, such as while no code was running.
+func (p *Profiler) Skip() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.last = time.Now()
+	p.objects, p.bytes = p.readAllocs()
+}
+
+// Samples returns the samples recorded so far, in the order their stacks
+// were first seen.
+func (p *Profiler) Samples() []Sample {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	samples := make([]Sample, 0, len(p.order))
+	for _, key := range p.order {
+		samples = append(samples, *p.samples[key])
+	}
+	return samples
+}
+
+func stackKey(stack []Frame) string {
+	var sb strings.Builder
+	for _, frame := range stack {
+		sb.WriteString(frame.Function)
+		sb.WriteByte(0)
+		sb.WriteString(frame.File)
+		sb.WriteByte(0)
+		sb.WriteString(strconv.Itoa(frame.StartLine))
+		sb.WriteByte(0)
+		sb.WriteString(strconv.Itoa(frame.Line))
+		sb.WriteByte(1)
+	}
+	return sb.String()
+}
@@ -0,0 +1,138 @@
+package profile_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/profile"
+	"github.com/risor-io/risor/vm"
+	"github.com/stretchr/testify/require"
+)
+
+const source = `func spin(n) {
+    total := 0
+    for i := 0; i < n; i++ {
+        total += i
+    }
+    return total
+}
+
+func outer() {
+    return spin(200000)
+}
+
+outer()
+`
+
+func run(t *testing.T, opts ...vm.Option) {
+	t.Helper()
+	ctx := context.Background()
+	program, err := parser.Parse(ctx, source, parser.WithFile("main.risor"))
+	require.Nil(t, err)
+	code, err := compiler.Compile(program)
+	require.Nil(t, err)
+	_, err = vm.Run(ctx, code, opts...)
+	require.Nil(t, err)
+}
+
+func TestProfiler(t *testing.T) {
+	profiler := profile.New(time.Millisecond)
+	profiler.Start()
+	run(t, vm.WithProfiler(profiler))
+	profiler.Stop()
+
+	samples := profiler.Samples()
+	require.NotEmpty(t, samples)
+	var inSpin bool
+	for _, sample := range samples {
+		require.Greater(t, sample.Count, int64(0))
+		stack := sample.Stack
+		require.Equal(t, "main.risor", stack[len(stack)-1].Function)
+		if stack[0].Function == "spin" {
+			inSpin = true
+			require.Equal(t, []string{"spin", "outer", "main.risor"},
+				[]string{stack[0].Function, stack[1].Function, stack[2].Function})
+			require.Equal(t, "main.risor", stack[0].File)
+			require.GreaterOrEqual(t, stack[0].Line, 2)
+			require.LessOrEqual(t, stack[0].Line, 6)
+			require.Equal(t, 10, stack[1].Line)
+			require.Equal(t, 13, stack[2].Line)
+		}
+	}
+	require.True(t, inSpin)
+}
+
+func TestWriteFolded(t *testing.T) {
+	profiler := profile.New(0)
+	profiler.Add([]profile.Frame{{Function: "inner"}, {Function: "main"}})
+	profiler.Add([]profile.Frame{{Function: "inner"}, {Function: "main"}})
+	profiler.Add([]profile.Frame{{Function: "main"}})
+
+	samples := profiler.Samples()
+	require.Len(t, samples, 2)
+	require.Equal(t, int64(2), samples[0].Count)
+
+	var buf bytes.Buffer
+	require.Nil(t, profiler.WriteFolded(&buf, profile.CPU))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "main "))
+	require.True(t, strings.HasPrefix(lines[1], "main;inner "))
+}
+
+func TestWritePprof(t *testing.T) {
+	profiler := profile.New(0)
+	profiler.Add([]profile.Frame{
+		{Function: "inner", File: "lib.risor", StartLine: 3, Line: 4},
+		{Function: "main.risor", File: "main.risor", Line: 1},
+	})
+	for _, kind := range []profile.Kind{profile.CPU, profile.Memory} {
+		var buf bytes.Buffer
+		require.Nil(t, profiler.WritePprof(&buf, kind))
+		r, err := gzip.NewReader(&buf)
+		require.Nil(t, err)
+		data, err := io.ReadAll(r)
+		require.Nil(t, err)
+		// The string table holds the names of the functions and values
+		require.Contains(t, string(data), "inner")
+		require.Contains(t, string(data), "lib.risor")
+		if kind == profile.CPU {
+			require.Contains(t, string(data), "nanoseconds")
+		} else {
+			require.Contains(t, string(data), "alloc_space")
+		}
+	}
+}
+
+func TestTracer(t *testing.T) {
+	tracer := profile.NewTracer()
+	run(t, vm.WithTracer(tracer))
+
+	var buf bytes.Buffer
+	require.Nil(t, tracer.Write(&buf))
+	var trace struct {
+		TraceEvents []struct {
+			Name     string  `json:"name"`
+			Phase    string  `json:"ph"`
+			Start    float64 `json:"ts"`
+			Duration float64 `json:"dur"`
+		} `json:"traceEvents"`
+	}
+	require.Nil(t, json.Unmarshal(buf.Bytes(), &trace))
+	// Calls end innermost first
+	require.Len(t, trace.TraceEvents, 2)
+	spin, outer := trace.TraceEvents[0], trace.TraceEvents[1]
+	require.Equal(t, "spin", spin.Name)
+	require.Equal(t, "outer", outer.Name)
+	require.Equal(t, "X", spin.Phase)
+	require.GreaterOrEqual(t, spin.Start, outer.Start)
+	require.LessOrEqual(t, spin.Duration, outer.Duration)
+}
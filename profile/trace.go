@@ -0,0 +1,74 @@
+package profile
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Tracer records the calls of Risor functions. It is safe for concurrent
+// use.
+type Tracer struct {
+	mutex  sync.Mutex
+	start  time.Time
+	events []traceEvent
+}
+
+// traceEvent is a complete event of the trace event format.
+type traceEvent struct {
+	Name      string            `json:"name"`
+	Category  string            `json:"cat,omitempty"`
+	Phase     string            `json:"ph"`
+	Timestamp float64           `json:"ts"`
+	Duration  float64           `json:"dur"`
+	Process   int               `json:"pid"`
+	Thread    int               `json:"tid"`
+	Args      map[string]string `json:"args,omitempty"`
+}
+
+// NewTracer returns a Tracer, timing events from now.
+func NewTracer() *Tracer {
+	return &Tracer{start: time.Now()}
+}
+
+// Begin records the start of a call of the named function, declared in the
+// given file, by the given thread, which distinguishes goroutines. It
+// returns a function that records the end of the call.
+func (t *Tracer) Begin(name, file string, thread int) func() {
+	start := time.Now()
+	return func() {
+		end := time.Now()
+		event := traceEvent{
+			Name:      name,
+			Category:  "function",
+			Phase:     "X",
+			Timestamp: micros(start.Sub(t.start)),
+			Duration:  micros(end.Sub(start)),
+			Process:   1,
+			Thread:    thread,
+		}
+		if file != "" {
+			event.Args = map[string]string{"file": file}
+		}
+		t.mutex.Lock()
+		t.events = append(t.events, event)
+		t.mutex.Unlock()
+	}
+}
+
+// Write writes the calls recorded in the JSON trace event format, as read
+// by Perfetto and chrome://tracing.
+func (t *Tracer) Write(w io.Writer) error {
+	t.mutex.Lock()
+	events := append([]traceEvent{}, t.events...)
+	t.mutex.Unlock()
+	return json.NewEncoder(w).Encode(map[string]any{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+	})
+}
+
+func micros(d time.Duration) float64 {
+	return float64(d.Nanoseconds()) / 1e3
+}
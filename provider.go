@@ -0,0 +1,108 @@
+package risor
+
+import (
+	"fmt"
+	"plugin"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/risor-io/risor/modules"
+	"github.com/risor-io/risor/object"
+)
+
+// ModuleProvider provides a Risor module implemented by a Go package other
+// than those built into Risor, so that third-party packages can add modules
+// without changes to the wiring of the builtins.
+type ModuleProvider interface {
+	// Name returns the name of the global the module is bound to.
+	Name() string
+
+	// Module returns the module, or nil if it isn't available, such as when
+	// its implementation wasn't compiled in because of build tags.
+	Module() *object.Module
+
+	// Docs returns the documentation of the module in Markdown, with a
+	// "### name" section per function as built-in modules have, or an empty
+	// string if it has none.
+	Docs() string
+}
+
+var (
+	providersMutex sync.RWMutex
+	providers      = map[string]ModuleProvider{}
+)
+
+// RegisterModuleProvider registers a module provider, whose module is then
+// a default global of evaluations, as built-in modules are. It's meant to be
+// called by the init function of the provider's package, so that importing
+// the package, possibly in a file with build tags, is enough to add it:
+//
+//	import _ "example.com/risor-redis"
+//
+// A provider registered with the name of another replaces it. Providers
+// registered after a Config is created aren't globals of that Config.
+func RegisterModuleProvider(provider ModuleProvider) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	providers[provider.Name()] = provider
+	modules.RegisterDocs(provider.Name(), provider.Docs())
+}
+
+// ModuleProviders returns the registered module providers, sorted by name.
+func ModuleProviders() []ModuleProvider {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+	result := make([]ModuleProvider, 0, len(providers))
+	for _, provider := range providers {
+		result = append(result, provider)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result
+}
+
+// WithModuleProvider adds the module of the given provider as a global of
+// the evaluation, if it's available, and makes its documentation available
+// to modules.Docs. Unlike RegisterModuleProvider, this doesn't affect other
+// evaluations.
+func WithModuleProvider(provider ModuleProvider) Option {
+	modules.RegisterDocs(provider.Name(), provider.Docs())
+	return func(cfg *Config) {
+		if module := provider.Module(); module != nil {
+			cfg.Globals[provider.Name()] = module
+		}
+	}
+}
+
+// LoadModulePlugin opens a Go plugin, built with -buildmode=plugin against
+// the same version of Risor, and returns the module provider it exports as
+// ModuleProvider, which is either a variable holding a ModuleProvider or a
+// function returning one. Go plugins are only supported on some platforms,
+// and an error is returned on others.
+func LoadModulePlugin(path string) (ModuleProvider, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := p.Lookup("ModuleProvider")
+	if err != nil {
+		return nil, err
+	}
+	switch symbol := symbol.(type) {
+	case func() ModuleProvider:
+		return symbol(), nil
+	case *ModuleProvider:
+		return *symbol, nil
+	}
+	// Variables of the concrete type of a provider are looked up as
+	// pointers to them
+	value := reflect.ValueOf(symbol)
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		if provider, ok := value.Elem().Interface().(ModuleProvider); ok {
+			return provider, nil
+		}
+	}
+	return nil, fmt.Errorf("exec error: plugin %s: ModuleProvider is not a module provider (got %T)", path, symbol)
+}
@@ -3,12 +3,21 @@ package risor
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
 	"strings"
 
 	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/coverage"
 	"github.com/risor-io/risor/importer"
+	"github.com/risor-io/risor/modfile"
+	modRandom "github.com/risor-io/risor/modules/random"
 	"github.com/risor-io/risor/object"
+	ros "github.com/risor-io/risor/os"
 	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/profile"
 	"github.com/risor-io/risor/vm"
 )
 
@@ -36,6 +45,26 @@ func WithGlobal(name string, value any) Option {
 	}
 }
 
+// WithFunction supplies a Go function as a named global builtin, converting
+// its arguments and results as described for object.NewGoFunction, so that
+// a non-nil error it returns is raised as a Risor error:
+//
+//	risor.WithFunction("fetch_user", func(ctx context.Context, id int) (*User, error) {
+//		return users.Get(ctx, id)
+//	})
+//
+// It panics if fn isn't a function, or if its parameters or results have
+// types that can't be converted.
+func WithFunction(name string, fn any) Option {
+	builtin, err := object.NewGoFunction(name, fn)
+	if err != nil {
+		panic(err)
+	}
+	return func(cfg *Config) {
+		cfg.Globals[name] = builtin
+	}
+}
+
 // WithoutGlobal opts out of a given global builtin or module. If the name can't
 // be resolved, this is a no-op. This does operate on nested modules.
 func WithoutGlobal(name string) Option {
@@ -126,6 +155,72 @@ func WithLocalImporter(path string) Option {
 	}
 }
 
+// WithFSImporter enables importing Risor modules from the given filesystem,
+// such as an embed.FS holding modules bundled into the program.
+func WithFSImporter(fsys fs.FS) Option {
+	return func(cfg *Config) {
+		cfg.ImportFS = fsys
+	}
+}
+
+// WithManifest imports remote modules at the versions required by the given
+// project manifest, alongside the modules of the local or fs.FS importer.
+func WithManifest(manifest *modfile.File) Option {
+	return func(cfg *Config) {
+		cfg.Manifest = manifest
+	}
+}
+
+// WithLock verifies the remote modules imported under a project manifest
+// against the checksums held by the given lock.
+func WithLock(lock *importer.Lock) Option {
+	return func(cfg *Config) {
+		cfg.Lock = lock
+	}
+}
+
+// WithTrustedKeys refuses to run the remote modules imported under a
+// project manifest unless they are signed by one of the given keys.
+func WithTrustedKeys(keys importer.TrustedKeys) Option {
+	return func(cfg *Config) {
+		cfg.TrustedKeys = keys
+	}
+}
+
+// WithVendor imports the remote modules required by a project manifest from
+// the given vendor directory, as written by importer.Vendor, when it holds
+// them.
+func WithVendor(fsys fs.FS) Option {
+	return func(cfg *Config) {
+		cfg.Vendor = fsys
+	}
+}
+
+// WithCredentials authenticates the imports of remote modules under a
+// project manifest with the credentials from the given provider.
+func WithCredentials(provider importer.CredentialProvider) Option {
+	return func(cfg *Config) {
+		cfg.Credentials = provider
+	}
+}
+
+// WithCompileCache caches the modules compiled by the local, fs.FS, and
+// project importers on disk, so later evaluations can skip compiling them.
+func WithCompileCache(cache *importer.CompileCache) Option {
+	return func(cfg *Config) {
+		cfg.CompileCache = cache
+	}
+}
+
+// WithImportAudit reports the modules imported by Risor evaluations, and
+// denies the imports its policy doesn't allow, as described for
+// importer.NewAuditImporter.
+func WithImportAudit(opts importer.AuditImporterOptions) Option {
+	return func(cfg *Config) {
+		cfg.ImportAudit = &opts
+	}
+}
+
 // WithConcurrency enables the use of concurrency in Risor evaluations.
 func WithConcurrency() Option {
 	return func(cfg *Config) {
@@ -133,14 +228,215 @@ func WithConcurrency() Option {
 	}
 }
 
+// WithHotReload reloads the modules imported from the local directory when
+// their files change, at the start of each evaluation by a long-running VM,
+// such as the one of a REPL. See vm.WithHotReload.
+func WithHotReload() Option {
+	return func(cfg *Config) {
+		cfg.HotReload = true
+	}
+}
+
+// WithLogHandler routes records written by the log module to the given
+// slog.Handler. By default, they go to the handler of slog.Default().
+func WithLogHandler(h slog.Handler) Option {
+	return func(cfg *Config) {
+		cfg.LogHandler = h
+	}
+}
+
+// WithRandomSeed seeds the source that the random module draws from, so
+// that evaluations with the same seed produce the same random values.
+func WithRandomSeed(seed int64) Option {
+	return func(cfg *Config) {
+		cfg.RandSource = modRandom.NewSource(seed)
+	}
+}
+
+// WithClock supplies the clock that the time, cron, ids, and retry modules
+// read the time from and sleep with, such as an object.ManualClock that
+// tests freeze and advance.
+func WithClock(clock object.Clock) Option {
+	return func(cfg *Config) {
+		cfg.Clock = clock
+	}
+}
+
+// WithFilename sets the name of the file the source code was read from,
+// which locates parse errors and the lines recorded by coverage.
+func WithFilename(name string) Option {
+	return func(cfg *Config) {
+		cfg.Filename = name
+	}
+}
+
+// WithCoverage records which lines of the source code run in the given
+// profile. Only code with a file name is recorded, as described for the
+// coverage package. See vm.WithCoverage.
+func WithCoverage(profile *coverage.Profile) Option {
+	return func(cfg *Config) {
+		cfg.Coverage = profile
+	}
+}
+
+// WithDebugger notifies the given debugger as source lines run, so it can
+// pause execution. See vm.WithDebugger.
+func WithDebugger(debugger vm.Debugger) Option {
+	return func(cfg *Config) {
+		cfg.Debugger = debugger
+	}
+}
+
+// WithProfiler samples the Risor call stack into the given profiler, which
+// must be started for samples to be taken. See vm.WithProfiler.
+func WithProfiler(profiler *profile.Profiler) Option {
+	return func(cfg *Config) {
+		cfg.Profiler = profiler
+	}
+}
+
+// WithTracer records the calls of Risor functions in the given tracer. See
+// vm.WithTracer.
+func WithTracer(tracer *profile.Tracer) Option {
+	return func(cfg *Config) {
+		cfg.Tracer = tracer
+	}
+}
+
+// WithStdin makes scripts read their standard input, as os.stdin, from the
+// given reader instead of the standard input of the process. See
+// vm.WithStdin.
+func WithStdin(r io.Reader) Option {
+	return func(cfg *Config) {
+		cfg.Stdin = r
+	}
+}
+
+// WithStdout routes the standard output of scripts, including that of print
+// and printf, to the given writer instead of the standard output of the
+// process. See vm.WithStdout.
+func WithStdout(w io.Writer) Option {
+	return func(cfg *Config) {
+		cfg.Stdout = w
+	}
+}
+
+// WithStderr routes the standard error of scripts, including the records
+// of the log module without a log handler, to the given writer instead of
+// the standard error of the process. See vm.WithStderr.
+func WithStderr(w io.Writer) Option {
+	return func(cfg *Config) {
+		cfg.Stderr = w
+	}
+}
+
+// WithOutputLines calls fn with each line scripts write to their standard
+// output and standard error, without its line ending, along with the name
+// of the stream, "stdout" or "stderr". A final line without a line ending
+// is passed once evaluation ends. It replaces the writers given with
+// WithStdout and WithStderr before it.
+func WithOutputLines(fn func(stream, line string)) Option {
+	return func(cfg *Config) {
+		stdout := ros.NewLineWriter(func(line string) { fn("stdout", line) })
+		stderr := ros.NewLineWriter(func(line string) { fn("stderr", line) })
+		cfg.Stdout, cfg.Stderr = stdout, stderr
+		cfg.lineWriters = append(cfg.lineWriters, stdout, stderr)
+	}
+}
+
+// WithEnv gives scripts their own environment variables instead of those of
+// the process, so that scripts running concurrently can't read or change
+// each other's environment. Each evaluation starts from a copy of env, which
+// is also the environment of the commands run by the exec module. See
+// vm.WithEnv.
+func WithEnv(env map[string]string) Option {
+	if env == nil {
+		env = map[string]string{}
+	}
+	return func(cfg *Config) {
+		cfg.Env = env
+	}
+}
+
+// WithFilesystem runs scripts in the given filesystem, such as an in-memory
+// memfs.Filesystem or a localfs.Filesystem with a base directory, so that
+// the os and filepath modules can't touch the files of the host. Other OS
+// calls are unaffected. See os.FilesystemOS.
+func WithFilesystem(fsys ros.FS) Option {
+	return func(cfg *Config) {
+		cfg.Filesystem = fsys
+	}
+}
+
+// WithReadOnlyFilesystem runs scripts in a read-only filesystem serving the
+// files of fsys, as described for WithFilesystem.
+func WithReadOnlyFilesystem(fsys fs.FS) Option {
+	return func(cfg *Config) {
+		cfg.Filesystem = ros.NewReadOnlyFS(fsys)
+	}
+}
+
+// WithContextValues gives scripts the values of the context they're run or
+// called with for the given keys, in the ctx.values map under the names the
+// keys are given with, so that scripts can act on the request or tenant
+// they run for without a global per value:
+//
+//	risor.WithContextValues(map[string]any{"tenant": tenantKey{}})
+//
+// Scripts then read ctx.values["tenant"]. Keys without a value in the
+// context are left out of the map. Values are converted with the type
+// converters of their types, and the map is copied for each run and call,
+// so scripts can't change the values seen by the host or by other calls.
+func WithContextValues(keys map[string]any) Option {
+	if keys == nil {
+		keys = map[string]any{}
+	}
+	return func(cfg *Config) {
+		cfg.ContextValues = keys
+		cfg.Globals["ctx"] = newContextModule(map[string]object.Object{})
+	}
+}
+
+// WithHooks notifies the given hooks of the function calls, imports, and
+// optionally attribute reads of scripts, which may stop them by returning
+// an error, such as to allow untrusted scripts only some builtins. See
+// vm.Hooks.
+func WithHooks(hooks vm.Hooks) Option {
+	return func(cfg *Config) {
+		cfg.Hooks = hooks
+	}
+}
+
+// WithErrorHandler calls fn with a report of each evaluation that fails
+// while running, holding the call stack of the error, the source location
+// it was raised at, the modules being imported, and the globals of the
+// script, so that host programs can report errors to script authors with
+// the context they need. The line of source is included for errors raised
+// in the source given to Eval. See vm.ErrorReport.
+func WithErrorHandler(fn func(report *vm.ErrorReport)) Option {
+	return func(cfg *Config) {
+		cfg.ErrorHandler = fn
+	}
+}
+
+// WithErrorRedactor replaces the globals of error reports with the values fn
+// returns for them, such as to hide secrets given as globals. Globals for
+// which fn returns nil are left out of reports.
+func WithErrorRedactor(fn func(name string, value object.Object) object.Object) Option {
+	return func(cfg *Config) {
+		cfg.ErrorRedactor = fn
+	}
+}
+
 // Eval evaluates the given source code and returns the result.
 func Eval(ctx context.Context, source string, options ...Option) (object.Object, error) {
 	cfg := NewConfig()
 	for _, opt := range options {
 		opt(cfg)
 	}
+	cfg.source = source
 	// Parse the source code to create the AST
-	ast, err := parser.Parse(ctx, source)
+	ast, err := parser.Parse(ctx, source, parser.WithFile(cfg.Filename))
 	if err != nil {
 		return nil, err
 	}
@@ -151,9 +447,34 @@ func Eval(ctx context.Context, source string, options ...Option) (object.Object,
 		return nil, err
 	}
 	// Eval the bytecode in a VM then return the top-of-stack (TOS) value
+	defer cfg.flushOutput()
 	return vm.Run(ctx, main, cfg.VMOpts()...)
 }
 
+// EvalAs evaluates the given source code and decodes the result into a
+// value of type T, as described for object.Decode:
+//
+//	type Config struct {
+//		Name     string   `risor:"name"`
+//		Replicas int      `risor:"replicas"`
+//		Regions  []string `risor:"regions"`
+//	}
+//	config, err := risor.EvalAs[Config](ctx, source)
+//
+// An error decoding the result is an *object.DecodeError holding the path
+// of the value that couldn't be decoded.
+func EvalAs[T any](ctx context.Context, source string, options ...Option) (T, error) {
+	var value T
+	result, err := Eval(ctx, source, options...)
+	if err != nil {
+		return value, err
+	}
+	if err := object.Decode(result, &value); err != nil {
+		return value, err
+	}
+	return value, nil
+}
+
 // EvalCode evaluates the precompiled code and returns the result.
 func EvalCode(ctx context.Context, main *compiler.Code, options ...Option) (object.Object, error) {
 	cfg := NewConfig()
@@ -161,6 +482,7 @@ func EvalCode(ctx context.Context, main *compiler.Code, options ...Option) (obje
 		opt(cfg)
 	}
 	// Eval the bytecode in a VM then return the top-of-stack (TOS) value
+	defer cfg.flushOutput()
 	return vm.Run(ctx, main, cfg.VMOpts()...)
 }
 
@@ -178,6 +500,7 @@ func Call(
 	for _, opt := range options {
 		opt(cfg)
 	}
+	defer cfg.flushOutput()
 	vm := vm.New(main, cfg.VMOpts()...)
 	if err := vm.Run(ctx); err != nil {
 		return nil, err
@@ -193,6 +516,30 @@ func Call(
 	return vm.Call(ctx, fn, args)
 }
 
+// Bundle writes the program with the given source code to w as a single
+// archive, along with every module it imports through the configured
+// importers, as described for importer.WriteBundle. The program's own
+// modules are those of the local or fs.FS importer. With precompile set,
+// they are bundled as compiled code rather than source code. The bundle is
+// run by opening it with importer.OpenArchive.
+func Bundle(ctx context.Context, w io.Writer, source string, precompile bool, options ...Option) error {
+	cfg := NewConfig()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	local := cfg.ImportFS
+	if local == nil && cfg.LocalImportPath != "" {
+		local = os.DirFS(cfg.LocalImportPath)
+	}
+	return importer.WriteBundle(ctx, w, source, importer.BundleOptions{
+		GlobalNames: cfg.GlobalNames(),
+		Exports:     cfg.moduleExports,
+		Local:       local,
+		Importer:    cfg.getImporter(),
+		Precompile:  precompile,
+	})
+}
+
 func resolveModule(m *object.Module, attr []string) (*object.Module, bool) {
 	if len(attr) == 0 {
 		return m, true
@@ -0,0 +1,294 @@
+package risor
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/limits"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/parser"
+	"github.com/risor-io/risor/vm"
+)
+
+// ServiceOptions configure a Service.
+type ServiceOptions struct {
+	// Options configure the evaluation of every script of the service,
+	// before the options each script is added with.
+	Options []Option
+
+	// Timeout bounds each call of Invoke, if set.
+	Timeout time.Duration
+
+	// MaxConcurrency bounds the number of calls running at once across the
+	// service, if set. Further calls wait for one to finish, or for their
+	// context to be cancelled.
+	MaxConcurrency int
+
+	// Limits returns the limits of a call, if set. It is called once per
+	// call, since limits track the resources used by a single evaluation.
+	Limits func() limits.Limits
+
+	// PoolSize is the number of idle VMs kept per script to run calls in,
+	// which defaults to GOMAXPROCS.
+	PoolSize int
+}
+
+// Service runs the functions of precompiled scripts on behalf of a host
+// program, and is safe for concurrent use. Each script is compiled and run
+// once when it's added, and each call then runs in a clone of its VM, taken
+// from a pool of idle clones, so calls share the globals of their script
+// but not its stack:
+//
+//	service := risor.NewService(risor.ServiceOptions{Timeout: time.Second})
+//	err := service.Add(ctx, "pricing", source)
+//	...
+//	result, err := service.Invoke(ctx, "pricing", "quote", order)
+//
+// Since cloned VMs can't import modules, scripts must import the modules
+// their functions use at the top level.
+type Service struct {
+	opts    ServiceOptions
+	sem     chan struct{}
+	mutex   sync.RWMutex
+	scripts map[string]*serviceScript
+}
+
+type serviceScript struct {
+	cfg  *Config
+	vm   *vm.VirtualMachine
+	pool chan *vm.VirtualMachine
+}
+
+// NewService returns a service without scripts.
+func NewService(opts ServiceOptions) *Service {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = runtime.GOMAXPROCS(0)
+	}
+	s := &Service{opts: opts, scripts: map[string]*serviceScript{}}
+	if opts.MaxConcurrency > 0 {
+		s.sem = make(chan struct{}, opts.MaxConcurrency)
+	}
+	return s
+}
+
+// Add compiles and runs the script with the given source code, to be called
+// by Invoke with the given ID. A script added with the same ID is replaced
+// once the calls running in it finish.
+func (s *Service) Add(ctx context.Context, id, source string, options ...Option) error {
+	cfg := s.config(options)
+	cfg.source = source
+	ast, err := parser.Parse(ctx, source, parser.WithFile(cfg.Filename))
+	if err != nil {
+		return err
+	}
+	main, err := compiler.Compile(ast, cfg.CompilerOpts()...)
+	if err != nil {
+		return err
+	}
+	return s.add(ctx, id, main, cfg)
+}
+
+// AddCode runs the precompiled code of a script, to be called by Invoke
+// with the given ID, as described for Add.
+func (s *Service) AddCode(ctx context.Context, id string, main *compiler.Code, options ...Option) error {
+	return s.add(ctx, id, main, s.config(options))
+}
+
+func (s *Service) config(options []Option) *Config {
+	cfg := NewConfig()
+	for _, opt := range s.opts.Options {
+		opt(cfg)
+	}
+	for _, opt := range options {
+		opt(cfg)
+	}
+	return cfg
+}
+
+func (s *Service) add(ctx context.Context, id string, main *compiler.Code, cfg *Config) error {
+	machine := vm.New(main, cfg.VMOpts()...)
+	if err := machine.Run(ctx); err != nil {
+		cfg.flushOutput()
+		return err
+	}
+	script := &serviceScript{
+		cfg:  cfg,
+		vm:   machine,
+		pool: make(chan *vm.VirtualMachine, s.opts.PoolSize),
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if previous, ok := s.scripts[id]; ok {
+		previous.cfg.flushOutput()
+	}
+	s.scripts[id] = script
+	return nil
+}
+
+// Remove removes the script with the given ID, and reports whether there
+// was one. Calls running in it are left to finish.
+func (s *Service) Remove(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	script, ok := s.scripts[id]
+	if ok {
+		script.cfg.flushOutput()
+		delete(s.scripts, id)
+	}
+	return ok
+}
+
+// Scripts returns the sorted IDs of the scripts of the service.
+func (s *Service) Scripts() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	ids := make([]string, 0, len(s.scripts))
+	for id := range s.scripts {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Invoke calls the named function of a script with the given arguments,
+// and returns its result. Arguments that aren't Risor objects are converted
+// with the type converters of their types. The call is cancelled when the
+// context is, or when the timeout of the service expires.
+func (s *Service) Invoke(ctx context.Context, scriptID, entrypoint string, args ...any) (object.Object, error) {
+	return s.invoke(ctx, scriptID, entrypoint, nil, args)
+}
+
+// InvokeWithGlobals calls the named function of a script as Invoke does,
+// with the given globals of the script set for this call only, which lets
+// one precompiled script serve calls with different inputs. Each global must
+// be declared by the script, such as with the WithGlobals option it was added
+// with, and is converted as arguments are.
+func (s *Service) InvokeWithGlobals(
+	ctx context.Context,
+	scriptID, entrypoint string,
+	globals map[string]any,
+	args ...any,
+) (object.Object, error) {
+	values, err := object.AsObjects(globals)
+	if err != nil {
+		return nil, err
+	}
+	return s.invoke(ctx, scriptID, entrypoint, values, args)
+}
+
+func (s *Service) invoke(
+	ctx context.Context,
+	scriptID, entrypoint string,
+	globals map[string]object.Object,
+	args []any,
+) (object.Object, error) {
+	s.mutex.RLock()
+	script, ok := s.scripts[scriptID]
+	s.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("exec error: script %q not found", scriptID)
+	}
+	obj, err := script.vm.Get(entrypoint)
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := obj.(*object.Function)
+	if !ok {
+		return nil, fmt.Errorf("object is not a function (got: %s)", obj.Type())
+	}
+	callArgs, err := serviceArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if s.sem != nil {
+		select {
+		case s.sem <- struct{}{}:
+			defer func() { <-s.sem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+	if s.opts.Limits != nil {
+		ctx = limits.WithLimits(ctx, s.opts.Limits())
+	}
+	machine, err := script.get()
+	if err != nil {
+		return nil, err
+	}
+	return script.call(ctx, machine, fn, callArgs, globals)
+}
+
+// get returns an idle clone of the script's VM.
+func (script *serviceScript) get() (*vm.VirtualMachine, error) {
+	select {
+	case machine := <-script.pool:
+		return machine, nil
+	default:
+		return script.vm.Clone()
+	}
+}
+
+// call calls the function in the given clone, which is returned to the pool
+// unless the call was cancelled or panicked.
+func (script *serviceScript) call(
+	ctx context.Context,
+	machine *vm.VirtualMachine,
+	fn *object.Function,
+	args []object.Object,
+	globals map[string]object.Object,
+) (result object.Object, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("panic: %v", r)
+			return
+		}
+		if ctx.Err() != nil {
+			// A halted VM can't run further calls
+			return
+		}
+		select {
+		case script.pool <- machine:
+		default:
+		}
+	}()
+	if globals != nil {
+		return machine.CallWithGlobals(ctx, fn, args, globals)
+	}
+	return machine.Call(ctx, fn, args)
+}
+
+// serviceArgs converts the arguments of a call to Risor objects.
+func serviceArgs(args []any) ([]object.Object, error) {
+	result := make([]object.Object, 0, len(args))
+	for _, arg := range args {
+		if obj, ok := arg.(object.Object); ok {
+			result = append(result, obj)
+			continue
+		}
+		if arg == nil {
+			result = append(result, object.Nil)
+			continue
+		}
+		converter, err := object.NewTypeConverter(reflect.TypeOf(arg))
+		if err != nil {
+			return nil, err
+		}
+		obj, err := converter.From(arg)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, obj)
+	}
+	return result, nil
+}
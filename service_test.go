@@ -0,0 +1,131 @@
+package risor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/risor-io/risor/limits"
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceInvoke(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(ServiceOptions{PoolSize: 2})
+	require.Nil(t, service.Add(ctx, "greet", `
+prefix := "hello"
+func greet(name, punctuation="!") {
+    return prefix + " " + name + punctuation
+}
+`, WithGlobal("unused", 1)))
+	require.Equal(t, []string{"greet"}, service.Scripts())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := service.Invoke(ctx, "greet", "greet", fmt.Sprintf("user%d", i))
+			if err != nil {
+				errs <- err
+				return
+			}
+			if expected := object.NewString(fmt.Sprintf("hello user%d!", i)); !expected.Equals(result).(*object.Bool).Value() {
+				errs <- fmt.Errorf("unexpected result %s", result.Inspect())
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.Nil(t, err)
+	}
+
+	result, err := service.Invoke(ctx, "greet", "greet", object.NewString("bob"), "?")
+	require.Nil(t, err)
+	require.Equal(t, object.NewString("hello bob?"), result)
+
+	_, err = service.Invoke(ctx, "greet", "prefix")
+	require.EqualError(t, err, "object is not a function (got: string)")
+	_, err = service.Invoke(ctx, "other", "greet")
+	require.EqualError(t, err, `exec error: script "other" not found`)
+
+	result, err = service.InvokeWithGlobals(ctx, "greet", "greet", map[string]any{"prefix": "hi"}, "amy")
+	require.Nil(t, err)
+	require.Equal(t, object.NewString("hi amy!"), result)
+	result, err = service.Invoke(ctx, "greet", "greet", "amy")
+	require.Nil(t, err)
+	require.Equal(t, object.NewString("hello amy!"), result)
+	_, err = service.InvokeWithGlobals(ctx, "greet", "greet", map[string]any{"missing": 1}, "amy")
+	require.EqualError(t, err, `global with name "missing" not found`)
+
+	require.True(t, service.Remove("greet"))
+	require.False(t, service.Remove("greet"))
+	require.Empty(t, service.Scripts())
+}
+
+func TestServiceRandomSeed(t *testing.T) {
+	ctx := context.Background()
+	draw := func() object.Object {
+		service := NewService(ServiceOptions{PoolSize: 1})
+		require.Nil(t, service.Add(ctx, "draw", `func draw() { return [random.int(1000000), random.int(1000000)] }`,
+			WithRandomSeed(7)))
+		result, err := service.Invoke(ctx, "draw", "draw")
+		require.Nil(t, err)
+		return result
+	}
+	require.Equal(t, draw(), draw())
+}
+
+func TestServiceTimeout(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(ServiceOptions{Timeout: 50 * time.Millisecond})
+	require.Nil(t, service.Add(ctx, "loop", `
+func spin(n) {
+    for { n++ }
+}
+func add(a, b) { return a + b }
+`))
+	_, err := service.Invoke(ctx, "loop", "spin", 0)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	// Calls after a timeout run in VMs that weren't halted
+	for i := 0; i < 10; i++ {
+		result, err := service.Invoke(ctx, "loop", "add", i, 1)
+		require.Nil(t, err)
+		require.Equal(t, object.NewInt(int64(i+1)), result)
+	}
+}
+
+func TestServiceLimits(t *testing.T) {
+	ctx := context.Background()
+	service := NewService(ServiceOptions{
+		MaxConcurrency: 1,
+		Limits: func() limits.Limits {
+			return limits.New(limits.WithMaxCost(100))
+		},
+	})
+	require.Nil(t, service.Add(ctx, "alloc", `func alloc(n) { return len(list(n)) }`))
+
+	// Each call has its own limits, so the cost isn't cumulative
+	for i := 0; i < 3; i++ {
+		result, err := service.Invoke(ctx, "alloc", "alloc", 10)
+		require.Nil(t, err)
+		require.Equal(t, object.NewInt(10), result)
+	}
+	_, err := service.Invoke(ctx, "alloc", "alloc", 1000)
+	require.EqualError(t, err, "limit error: reached maximum processing cost (100)")
+
+	// Calls waiting for a slot give up when their context is cancelled
+	service.sem <- struct{}{}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = service.Invoke(cancelled, "alloc", "alloc", 1)
+	require.Equal(t, context.Canceled, err)
+	<-service.sem
+}
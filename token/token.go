@@ -1,6 +1,8 @@
 // Package token defines language keywords and tokens used when lexing source code.
 package token
 
+import "sort"
+
 // Type describes the type of a token as a string.
 type Type string
 
@@ -44,6 +46,7 @@ const (
 	CASE            = "case"
 	COLON           = ":"
 	COMMA           = ","
+	COMMENT         = "COMMENT"
 	CONST           = "CONST"
 	DECLARE         = ":="
 	DEFAULT         = "DEFAULT"
@@ -133,6 +136,16 @@ var keywords = map[string]Type{
 	"var":      VAR,
 }
 
+// Keywords returns the reserved keywords, sorted.
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // LookupIdentifier used to determinate whether identifier is keyword nor not
 func LookupIdentifier(identifier string) Type {
 	if tok, ok := keywords[identifier]; ok {
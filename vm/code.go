@@ -14,6 +14,9 @@ type code struct {
 	Constants    []object.Object
 	Globals      []object.Object
 	Names        []string
+
+	// Execution counts of the instructions, when recording coverage
+	counts []uint64
 }
 
 func wrapCode(cc *compiler.Code) *code {
@@ -73,6 +76,7 @@ func (c *code) Clone() *code {
 		Constants:    make([]object.Object, len(c.Constants)),
 		Globals:      make([]object.Object, len(c.Globals)),
 		Names:        make([]string, len(c.Names)),
+		counts:       c.counts,
 	}
 	copy(clone.Instructions, c.Instructions)
 	copy(clone.Constants, c.Constants)
@@ -0,0 +1,110 @@
+package vm
+
+import (
+	"context"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/object"
+)
+
+// Debugger is notified as the VM reaches each source line, which lets it
+// pause execution, at breakpoints or while stepping, and inspect the state
+// of the VM with Frames.
+type Debugger interface {
+	// Line is called before the code of a source line runs, each time the
+	// line is entered, on the goroutine running the VM. Execution resumes
+	// when it returns, or stops with the error it returns.
+	Line(ctx context.Context, vm *VirtualMachine) error
+}
+
+// WithDebugger notifies the given debugger as source lines run. Only code
+// compiled with source locations, which the compiler records by default,
+// is reported.
+func WithDebugger(debugger Debugger) Option {
+	return func(vm *VirtualMachine) {
+		vm.debugger = debugger
+	}
+}
+
+// Frame describes a call frame of a VM, for debuggers.
+type Frame struct {
+	// Name of the function running in the frame, empty for the main code of
+	// a script or module
+	Name string
+
+	// File and location of the instruction running in the frame, or, for
+	// callers, of the call
+	File     string
+	Location compiler.Location
+
+	// Local variables of the frame by name, including those it shares with
+	// the functions enclosing it. The globals of the main code are returned
+	// by GlobalNames and Get instead.
+	Locals map[string]object.Object
+}
+
+// Frames returns the call frames of the VM, innermost first. It's meant to
+// be called while execution is paused by a Debugger.
+func (vm *VirtualMachine) Frames() []Frame {
+	return vm.callStack(vm.ip, true)
+}
+
+// callStack returns the call frames of the VM, innermost first, with the active
+// one at the given instruction, and with their local variables if asked.
+func (vm *VirtualMachine) callStack(ip int, locals bool) []Frame {
+	if vm.activeFrame == nil {
+		return nil
+	}
+	frames := make([]Frame, 0, vm.fp+1)
+	for fp := vm.fp; fp >= 0; fp-- {
+		f := &vm.frames[fp]
+		if f.code == nil {
+			break
+		}
+		frame := Frame{
+			File:     f.code.FileAt(ip),
+			Location: f.code.Location(ip),
+		}
+		if f.fn != nil {
+			frame.Name = f.fn.Name()
+			if frame.Name == "" {
+				frame.Name = "<anonymous>"
+			}
+		}
+		if locals {
+			frame.Locals = map[string]object.Object{}
+		}
+		if f.fn != nil && locals {
+			for i := 0; i < f.code.LocalsCount(); i++ {
+				symbol := f.code.Local(i)
+				if int(symbol.Index()) >= len(f.locals) {
+					continue
+				}
+				if value := f.locals[symbol.Index()]; value != nil {
+					frame.Locals[symbol.Name()] = value
+				}
+			}
+			freeVars := f.fn.FreeVars()
+			for i := 0; i < f.code.FreeCount() && i < len(freeVars); i++ {
+				if value := freeVars[i].Value(); value != nil {
+					frame.Locals[f.code.Free(i).Symbol().Name()] = value
+				}
+			}
+		}
+		frames = append(frames, frame)
+		// The caller is paused on the instruction after its call
+		ip = f.callerIP - 1
+	}
+	return frames
+}
+
+// debugLine notifies the debugger if the current instruction starts a new
+// source line in the active frame.
+func (vm *VirtualMachine) debugLine(ctx context.Context) error {
+	line := vm.activeCode.Location(vm.ip).Line
+	if line == 0 || line == vm.activeFrame.line {
+		return nil
+	}
+	vm.activeFrame.line = line
+	return vm.debugger.Line(ctx, vm)
+}
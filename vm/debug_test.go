@@ -0,0 +1,158 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/importer"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingDebugger struct {
+	lines  []int
+	frames [][]Frame
+	stopAt int
+}
+
+func (d *recordingDebugger) Line(ctx context.Context, vm *VirtualMachine) error {
+	frames := vm.Frames()
+	d.lines = append(d.lines, frames[0].Location.Line)
+	d.frames = append(d.frames, frames)
+	if d.stopAt > 0 && frames[0].Location.Line == d.stopAt {
+		return errors.New("stopped")
+	}
+	return nil
+}
+
+func debugRun(t *testing.T, source string, d *recordingDebugger) error {
+	t.Helper()
+	ctx := context.Background()
+	program, err := parser.Parse(ctx, source, parser.WithFile("main.risor"))
+	require.Nil(t, err)
+	code, err := compiler.Compile(program)
+	require.Nil(t, err)
+	_, err = Run(ctx, code, WithDebugger(d))
+	return err
+}
+
+func TestDebugger(t *testing.T) {
+	d := &recordingDebugger{}
+	err := debugRun(t, `func add(a, b) {
+    c := a + b
+    return c
+}
+x := 0
+for i := 0; i < 2; i++ {
+    x = add(x, i)
+}
+x`, d)
+	require.Nil(t, err)
+	require.Equal(t, []int{1, 5, 6, 7, 2, 3, 6, 7, 2, 3, 6, 9}, d.lines)
+
+	// Paused in the function on its second call
+	frames := d.frames[8]
+	require.Len(t, frames, 2)
+	require.Equal(t, "add", frames[0].Name)
+	require.Equal(t, "main.risor", frames[0].File)
+	require.Equal(t, 2, frames[0].Location.Line)
+	require.Equal(t, object.NewInt(0), frames[0].Locals["a"])
+	require.Equal(t, object.NewInt(1), frames[0].Locals["b"])
+	require.NotContains(t, frames[0].Locals, "c")
+	require.Equal(t, "", frames[1].Name)
+	require.Equal(t, 7, frames[1].Location.Line)
+}
+
+func TestDebuggerFreeVariables(t *testing.T) {
+	d := &recordingDebugger{}
+	err := debugRun(t, `func outer() {
+    y := 10
+    return func() {
+        return y
+    }
+}
+outer()()`, d)
+	require.Nil(t, err)
+	last := d.frames[len(d.frames)-1]
+	require.Equal(t, 4, last[0].Location.Line)
+	require.Equal(t, object.NewInt(10), last[0].Locals["y"])
+}
+
+func TestDebuggerStop(t *testing.T) {
+	d := &recordingDebugger{stopAt: 2}
+	err := debugRun(t, "x := 1\nx = 2\nx = 3", d)
+	require.EqualError(t, err, "stopped")
+	require.Equal(t, []int{1, 2}, d.lines)
+}
+
+func runtimeErrorStack(t *testing.T, source string) []Frame {
+	t.Helper()
+	ctx := context.Background()
+	program, err := parser.Parse(ctx, source, parser.WithFile("main.risor"))
+	require.Nil(t, err)
+	code, err := compiler.Compile(program)
+	require.Nil(t, err)
+	_, err = Run(ctx, code)
+	var runtimeErr *RuntimeError
+	require.True(t, errors.As(err, &runtimeErr))
+	require.NotNil(t, errors.Unwrap(err))
+	return runtimeErr.Stack()
+}
+
+func TestRuntimeErrorStack(t *testing.T) {
+	stack := runtimeErrorStack(t, `func f(x) {
+    return x.foo
+}
+func g() {
+    return f(1)
+}
+g()`)
+	require.Len(t, stack, 3)
+	require.Equal(t, "f", stack[0].Name)
+	require.Equal(t, 2, stack[0].Location.Line)
+	require.Equal(t, "g", stack[1].Name)
+	require.Equal(t, 5, stack[1].Location.Line)
+	require.Equal(t, "", stack[2].Name)
+	require.Equal(t, 7, stack[2].Location.Line)
+}
+
+func TestRuntimeErrorStackThroughBuiltins(t *testing.T) {
+	stack := runtimeErrorStack(t, `[1].map(func(n) {
+    return n.foo
+})`)
+	require.Len(t, stack, 2)
+	require.Equal(t, "<anonymous>", stack[0].Name)
+	require.Equal(t, 2, stack[0].Location.Line)
+	require.Equal(t, 1, stack[1].Location.Line)
+}
+
+func TestErrorHandler(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib.risor")
+	require.Nil(t, os.WriteFile(lib, []byte("x := 1\nfunc f() {\n    return x.foo\n}\nf()\n"), 0o644))
+	ctx := context.Background()
+	program, err := parser.Parse(ctx, "secret := \"abc\"\nimport lib\n")
+	require.Nil(t, err)
+	code, err := compiler.Compile(program)
+	require.Nil(t, err)
+	var report *ErrorReport
+	im := importer.NewLocalImporter(importer.LocalImporterOptions{SourceDir: dir})
+	machine := New(code, WithImporter(im), WithGlobals(map[string]any{"len": object.NewBuiltin("len", nil)}),
+		WithErrorHandler(func(r *ErrorReport) { report = r }))
+	err = machine.Run(ctx)
+	require.NotNil(t, err)
+	require.NotNil(t, report)
+	require.Equal(t, err, report.Err)
+	require.Equal(t, []string{"lib"}, report.Imports)
+	require.Len(t, report.Stack, 3)
+	require.Equal(t, "f", report.Stack[0].Name)
+	require.Equal(t, 2, report.Stack[2].Location.Line)
+	require.Equal(t, report.Stack[0].File, report.Span.File)
+	require.Equal(t, 3, report.Span.Location.Line)
+	require.Equal(t, map[string]object.Object{"secret": object.NewString("abc")}, report.Globals)
+}
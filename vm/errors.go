@@ -0,0 +1,147 @@
+package vm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/object"
+)
+
+// RuntimeError is returned by Run for errors raised while running code,
+// with the call stack where they were raised. Errors and Is and As see the
+// error raised through it.
+type RuntimeError struct {
+	err     error
+	stack   []Frame
+	imports []string
+}
+
+func (e *RuntimeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.err
+}
+
+// Stack returns the call frames where the error was raised, innermost
+// first, without their local variables.
+func (e *RuntimeError) Stack() []Frame {
+	return e.stack
+}
+
+// Imports returns the names of the modules being imported when the error
+// was raised, outermost first.
+func (e *RuntimeError) Imports() []string {
+	return e.imports
+}
+
+// failure is the innermost call stack an error was seen at.
+type failure struct {
+	err     error
+	stack   []Frame
+	imports []string
+}
+
+// recordFailure records the call stack of an error returned by the code
+// running, unless the error is one already recorded deeper in the same
+// stack. Builtins calling functions, such as list.map, may return a copy of
+// the error rather than wrap it, so errors with the same message match too.
+func (vm *VirtualMachine) recordFailure(err error) {
+	stack := vm.callStack(max(vm.ip-1, 0), false)
+	if f := vm.failure; f != nil && (errors.Is(err, f.err) || err.Error() == f.err.Error()) && hasSuffix(f.stack, stack) {
+		return
+	}
+	var imports []string
+	if len(vm.importing) > 0 {
+		imports = append(imports, vm.importing...)
+	}
+	vm.failure = &failure{err: err, stack: stack, imports: imports}
+}
+
+// hasSuffix reports whether a call stack ends with the given frames.
+func hasSuffix(stack, suffix []Frame) bool {
+	if len(suffix) > len(stack) {
+		return false
+	}
+	stack = stack[len(stack)-len(suffix):]
+	for i := range suffix {
+		if stack[i].Name != suffix[i].Name || stack[i].File != suffix[i].File || stack[i].Location != suffix[i].Location {
+			return false
+		}
+	}
+	return true
+}
+
+// runtimeError returns the error that stopped Run with the call stack it
+// was raised at. Cancellation is returned as is.
+func (vm *VirtualMachine) runtimeError(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	vm.recordFailure(err)
+	f := vm.failure
+	vm.failure = nil
+	return &RuntimeError{err: err, stack: f.stack, imports: f.imports}
+}
+
+// ErrorReport describes an error that stopped a run, for host programs to
+// report to the authors of scripts. See WithErrorHandler.
+type ErrorReport struct {
+	// Err is the error, which is a *RuntimeError for errors raised by code
+	Err error
+
+	// Stack is the call stack the error was raised at, innermost first
+	Stack []Frame
+
+	// Span is the source location the error was raised at
+	Span SourceSpan
+
+	// Imports are the names of the modules being imported when the error
+	// was raised, outermost first
+	Imports []string
+
+	// Globals are the global variables of the main code when the run
+	// stopped, except builtins and modules
+	Globals map[string]object.Object
+}
+
+// SourceSpan is a location in the source of a script.
+type SourceSpan struct {
+	File     string
+	Location compiler.Location
+
+	// Text is the line of source at the location, if known. The VM doesn't
+	// have the source of scripts, so it's left to hosts that do to set it.
+	Text string
+}
+
+// errorReport returns the report of an error returned by Run.
+func (vm *VirtualMachine) errorReport(err error) *ErrorReport {
+	report := &ErrorReport{Err: err, Globals: map[string]object.Object{}}
+	var runtimeErr *RuntimeError
+	if errors.As(err, &runtimeErr) {
+		report.Stack = runtimeErr.Stack()
+		report.Imports = runtimeErr.Imports()
+		if len(report.Stack) > 0 {
+			top := report.Stack[0]
+			report.Span = SourceSpan{File: top.File, Location: top.Location}
+		}
+	}
+	root, ok := vm.loadedCode[vm.main]
+	if !ok {
+		return report
+	}
+	for i, name := range vm.main.GlobalNames() {
+		if i >= len(root.Globals) {
+			break
+		}
+		switch value := root.Globals[i].(type) {
+		case nil, *object.Builtin, *object.Module:
+		default:
+			report.Globals[name] = value
+		}
+	}
+	return report
+}
@@ -17,6 +17,16 @@ type frame struct {
 	extendedLocals []object.Object
 	capturedLocals []object.Object
 	defers         []*object.Partial
+
+	// The source line last reported to the debugger
+	line int
+
+	// The instruction pointer of the caller after its call, which unlike
+	// returnAddr is kept when the call was made from Go
+	callerIP int
+
+	// Records the end of the call, when tracing
+	traceEnd func()
 }
 
 func (f *frame) ActivateCode(code *code) {
@@ -26,6 +36,9 @@ func (f *frame) ActivateCode(code *code) {
 	f.localsCount = uint16(code.LocalsCount())
 	f.capturedLocals = nil
 	f.defers = nil
+	f.line = 0
+	f.callerIP = 0
+	f.traceEnd = nil
 	for i := 0; i < DefaultFrameLocals; i++ {
 		f.storage[i] = nil
 	}
@@ -48,6 +61,7 @@ func (f *frame) ActivateFunction(fn *object.Function, code *code, returnAddr, re
 	// Save the instruction and stack pointers of the caller
 	f.returnAddr = returnAddr
 	f.returnSp = returnSp
+	f.callerIP = returnAddr
 	// Initialize any local variables that were provided
 	for i := 0; i < len(localValues); i++ {
 		f.locals[i] = localValues[i]
@@ -0,0 +1,66 @@
+package vm
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/risor-io/risor/object"
+)
+
+// Hooks are notified of the function calls and module imports of the code
+// running, such as to log them for auditing, or to enforce a policy on
+// untrusted scripts. An error returned by a hook stops the call or import,
+// and is raised in the script in its place. Hooks are called on the
+// goroutine running the code, which includes those of goroutines spawned by
+// scripts, so they must be safe for concurrent use.
+type Hooks interface {
+	// OnCall is called before code calls a function, which is either a
+	// function of a script or a builtin, such as json.marshal, named by its
+	// Key method. This includes the calls that builtins make, such as those
+	// of list.map and call, and functions started with go. Calls by the
+	// host with Call aren't reported.
+	OnCall(ctx context.Context, fn object.Object, args []object.Object) error
+
+	// OnImport is called before code imports a module, with the name it's
+	// imported by, including modules that are already loaded.
+	OnImport(ctx context.Context, name string) error
+}
+
+// AttrHooks are Hooks that are also notified as code reads the attributes
+// of objects provided by the host, which are builtin modules, proxies of Go
+// values, and objects of types defined outside the object package, such as
+// those returned by modules. This is optional, since attribute reads are far
+// more frequent than calls.
+type AttrHooks interface {
+	Hooks
+
+	// OnAttrAccess is called before code reads the named attribute of obj.
+	OnAttrAccess(ctx context.Context, obj object.Object, name string) error
+}
+
+// WithHooks notifies the given hooks of the function calls, imports and,
+// if they implement AttrHooks, attribute reads of the code running.
+func WithHooks(hooks Hooks) Option {
+	return func(vm *VirtualMachine) {
+		vm.hooks = hooks
+		vm.attrHooks, _ = hooks.(AttrHooks)
+	}
+}
+
+var objectPkgPath = reflect.TypeOf(object.Nil).Elem().PkgPath()
+
+// isHostObject reports whether an object is one whose attribute reads are
+// reported to AttrHooks.
+func isHostObject(obj object.Object) bool {
+	switch obj := obj.(type) {
+	case *object.Module:
+		return obj.Code() == nil
+	case *object.Proxy:
+		return true
+	}
+	typ := reflect.TypeOf(obj)
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.PkgPath() != objectPkgPath
+}
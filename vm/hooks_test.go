@@ -0,0 +1,131 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/risor-io/risor/object"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHooks struct {
+	mutex  sync.Mutex
+	events []string
+	denied map[string]bool
+}
+
+func (h *recordingHooks) record(event string) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.events = append(h.events, event)
+	if h.denied[event] {
+		return fmt.Errorf("policy error: %s is not allowed", event)
+	}
+	return nil
+}
+
+func (h *recordingHooks) OnCall(ctx context.Context, fn object.Object, args []object.Object) error {
+	switch fn := fn.(type) {
+	case *object.Builtin:
+		return h.record("call " + fn.Key())
+	case *object.Function:
+		return h.record("call " + fn.Name())
+	}
+	return h.record("call " + fn.Inspect())
+}
+
+func (h *recordingHooks) OnImport(ctx context.Context, name string) error {
+	return h.record("import " + name)
+}
+
+type recordingAttrHooks struct {
+	*recordingHooks
+}
+
+func (h recordingAttrHooks) OnAttrAccess(ctx context.Context, obj object.Object, name string) error {
+	return h.record(fmt.Sprintf("attr %s.%s", obj.Type(), name))
+}
+
+func hooksRun(t *testing.T, source string, hooks Hooks) error {
+	t.Helper()
+	ctx := context.Background()
+	globals := basicBuiltins()
+	globals["strings"] = object.NewBuiltinsModule("strings", map[string]object.Object{
+		"upper": object.NewBuiltin("upper", func(ctx context.Context, args ...object.Object) object.Object {
+			return object.NewString(strings.ToUpper(args[0].(*object.String).Value()))
+		}),
+	})
+	vm, err := newVM(ctx, source, runOpts{Globals: globals})
+	require.Nil(t, err)
+	WithHooks(hooks)(vm)
+	return vm.Run(ctx)
+}
+
+func TestHooks(t *testing.T) {
+	hooks := &recordingHooks{}
+	err := hooksRun(t, `
+	import simple_math
+	func shout(s) { return strings.upper(s) }
+	shout("hi")
+	len("abc")
+	"abc".to_upper()
+	`, hooks)
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		"import simple_math",
+		"call shout",
+		"call strings.upper",
+		"call len",
+		"call string.to_upper",
+	}, hooks.events)
+}
+
+func TestHooksDeny(t *testing.T) {
+	hooks := &recordingHooks{denied: map[string]bool{"call strings.upper": true}}
+	err := hooksRun(t, `strings.upper("hi")`, hooks)
+	require.EqualError(t, err, "policy error: call strings.upper is not allowed")
+
+	hooks = &recordingHooks{denied: map[string]bool{"import simple_math": true}}
+	err = hooksRun(t, `import simple_math`, hooks)
+	require.EqualError(t, err, "policy error: import simple_math is not allowed")
+}
+
+func TestAttrHooks(t *testing.T) {
+	hooks := recordingAttrHooks{&recordingHooks{}}
+	err := hooksRun(t, `
+	m := {"a": 1}
+	m.keys()
+	strings.upper("x")
+	`, hooks)
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		"call map.keys",
+		"attr module.upper",
+		"call strings.upper",
+	}, hooks.events)
+}
+
+func TestHooksCallsByBuiltins(t *testing.T) {
+	hooks := &recordingHooks{}
+	err := hooksRun(t, `
+	func shout(s) { return strings.upper(s) }
+	["a"].map(shout)
+	["b"].map(strings.upper)
+	`, hooks)
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		"call list.map",
+		"call shout",
+		"call strings.upper",
+		"call list.map",
+		"call strings.upper",
+	}, hooks.events)
+
+	// Builtins can't be used to call a denied function
+	hooks = &recordingHooks{denied: map[string]bool{"call strings.upper": true}}
+	err = hooksRun(t, `["hi"].map(strings.upper)`, hooks)
+	require.EqualError(t, err, "policy error: call strings.upper is not allowed")
+}
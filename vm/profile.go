@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"sync/atomic"
+
+	"github.com/risor-io/risor/profile"
+)
+
+// WithProfiler samples the Risor call stack into the given profiler, which
+// must be started for samples to be taken.
+func WithProfiler(profiler *profile.Profiler) Option {
+	return func(vm *VirtualMachine) {
+		vm.profiler = profiler
+	}
+}
+
+// WithTracer records the calls of Risor functions in the given tracer.
+func WithTracer(tracer *profile.Tracer) Option {
+	return func(vm *VirtualMachine) {
+		vm.tracer = tracer
+	}
+}
+
+// lastThread numbers the VMs cloned to run goroutines, for tracing.
+var lastThread atomic.Int64
+
+// sample adds the call stack to the profiler.
+func (vm *VirtualMachine) sample() {
+	stack := make([]profile.Frame, 0, vm.fp+1)
+	ip := vm.ip
+	for fp := vm.fp; fp >= 0; fp-- {
+		f := &vm.frames[fp]
+		if f.code == nil {
+			break
+		}
+		stack = append(stack, profile.Frame{
+			Function:  frameName(f),
+			File:      f.code.FileAt(ip),
+			StartLine: f.code.Location(0).Line,
+			Line:      f.code.Location(ip).Line,
+		})
+		ip = f.callerIP - 1
+	}
+	vm.profiler.Add(stack)
+}
+
+// traceCall records the start of a call in the active frame, which ends
+// when the frame returns.
+func (vm *VirtualMachine) traceCall() {
+	f := vm.activeFrame
+	f.traceEnd = vm.tracer.Begin(frameName(f), f.code.File(), vm.thread)
+}
+
+// traceReturn records the end of the calls of the frames above the given
+// frame pointer, which are returning.
+func (vm *VirtualMachine) traceReturn(fp int) {
+	for i := vm.fp; i > fp && i >= 0; i-- {
+		if end := vm.frames[i].traceEnd; end != nil {
+			vm.frames[i].traceEnd = nil
+			end()
+		}
+	}
+}
+
+// frameName names the function running in a frame, or, for the main code
+// of a script or module, its file.
+func frameName(f *frame) string {
+	if f.fn != nil {
+		if name := f.fn.Name(); name != "" {
+			return name
+		}
+		return "<anonymous>"
+	}
+	if file := f.code.File(); file != "" {
+		return file
+	}
+	return "<main>"
+}
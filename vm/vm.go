@@ -5,15 +5,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync/atomic"
 
 	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/coverage"
 	"github.com/risor-io/risor/importer"
 	"github.com/risor-io/risor/limits"
 	"github.com/risor-io/risor/object"
 	"github.com/risor-io/risor/op"
+	ros "github.com/risor-io/risor/os"
+	"github.com/risor-io/risor/profile"
 )
 
 const (
@@ -37,12 +44,35 @@ type VirtualMachine struct {
 	main         *compiler.Code
 	importer     importer.Importer
 	modules      map[string]*object.Module
+	importing    []string
+	hotReload    bool
+	reloads      []string
 	inputGlobals map[string]any
 	globals      map[string]object.Object
+	converted    bool
 	limits       limits.Limits
 	loadedCode   map[*compiler.Code]*code
 	running      bool
 	concAllowed  bool
+	logHandler   slog.Handler
+	randSource   rand.Source
+	clock        object.Clock
+	stdin        io.Reader
+	stdout       io.Writer
+	stderr       io.Writer
+	filesystem   ros.FS
+	env          *ros.Env
+	coverage     *coverage.Profile
+	debugger     Debugger
+	profiler     *profile.Profiler
+	tracer       *profile.Tracer
+	thread       int
+	failure      *failure
+	errorHandler func(report *ErrorReport)
+	hooks        Hooks
+	attrHooks    AttrHooks
+
+	contextGlobals func(ctx context.Context) (map[string]object.Object, error)
 }
 
 // Option is a configuration function for a Virtual Machine.
@@ -62,6 +92,18 @@ func WithImporter(importer importer.Importer) Option {
 	}
 }
 
+// WithHotReload reloads the modules whose source changed at the start of
+// each Run, when the importer can detect changes as an
+// importer.ChangeDetector does. The changed modules are evaluated again, and
+// updated in place, so variables referring to them see their new globals.
+// This supports edit-run loops in long-running VMs, such as a REPL. Runs fail
+// while a changed module fails to reload, until it is fixed.
+func WithHotReload() Option {
+	return func(vm *VirtualMachine) {
+		vm.hotReload = true
+	}
+}
+
 // WithLimits sets the limits for the Virtual Machine.
 func WithLimits(limits limits.Limits) Option {
 	return func(vm *VirtualMachine) {
@@ -85,6 +127,100 @@ func WithConcurrency() Option {
 	}
 }
 
+// WithContextGlobals sets a function deriving globals of the main code from
+// the context of each run and call, such as the ID of the request or the
+// tenant a script runs for. The globals are set for the run or call only
+// when it's made with Call or CallWithGlobals. Each name must be a global of
+// the main code.
+func WithContextGlobals(fn func(ctx context.Context) (map[string]object.Object, error)) Option {
+	return func(vm *VirtualMachine) {
+		vm.contextGlobals = fn
+	}
+}
+
+// WithErrorHandler sets a function called with a report of each error
+// that stops Run, including the cancellation of its context, so that host
+// programs can report errors with the context script authors need to fix
+// them. It isn't called for errors returned by Call.
+func WithErrorHandler(fn func(report *ErrorReport)) Option {
+	return func(vm *VirtualMachine) {
+		vm.errorHandler = fn
+	}
+}
+
+// WithLogHandler sets the slog.Handler that the log module writes to.
+func WithLogHandler(h slog.Handler) Option {
+	return func(vm *VirtualMachine) {
+		vm.logHandler = h
+	}
+}
+
+// WithRandSource sets the rand.Source that the random module draws from,
+// which must be safe for concurrent use.
+func WithRandSource(src rand.Source) Option {
+	return func(vm *VirtualMachine) {
+		vm.randSource = src
+	}
+}
+
+// WithStdin sets the reader that scripts read their standard input from,
+// instead of the standard input of the process.
+func WithStdin(r io.Reader) Option {
+	return func(vm *VirtualMachine) {
+		vm.stdin = r
+	}
+}
+
+// WithStdout sets the writer that scripts write their standard output to,
+// including that of print and printf, instead of the standard output of
+// the process.
+func WithStdout(w io.Writer) Option {
+	return func(vm *VirtualMachine) {
+		vm.stdout = w
+	}
+}
+
+// WithStderr sets the writer that scripts write their standard error to,
+// instead of the standard error of the process.
+func WithStderr(w io.Writer) Option {
+	return func(vm *VirtualMachine) {
+		vm.stderr = w
+	}
+}
+
+// WithEnv sets the environment variables that scripts read and change,
+// instead of those of the process. Changes made by scripts are kept in a
+// copy of the map, shared by the VM and its clones.
+func WithEnv(env map[string]string) Option {
+	return func(vm *VirtualMachine) {
+		vm.env = ros.NewEnv(env)
+	}
+}
+
+// WithClock sets the clock that modules read the time from and sleep with,
+// instead of the system clock.
+func WithClock(clock object.Clock) Option {
+	return func(vm *VirtualMachine) {
+		vm.clock = clock
+	}
+}
+
+// WithFilesystem sets the filesystem that scripts access files in, instead
+// of that of the OS. See os.FilesystemOS.
+func WithFilesystem(fsys ros.FS) Option {
+	return func(vm *VirtualMachine) {
+		vm.filesystem = fsys
+	}
+}
+
+// WithCoverage counts the executions of instructions in the given profile,
+// to report which lines of the source code ran.
+func WithCoverage(profile *coverage.Profile) Option {
+	return func(vm *VirtualMachine) {
+		vm.coverage = profile
+	}
+}
+
 func defaultLimits() limits.Limits {
 	return limits.New(limits.WithMaxBufferSize(100 * MB))
 }
@@ -122,6 +258,15 @@ func New(main *compiler.Code, options ...Option) *VirtualMachine {
 }
 
 func (vm *VirtualMachine) Run(ctx context.Context) (err error) {
+	// Report errors to the host, once any panic is translated below
+	if vm.errorHandler != nil {
+		defer func() {
+			if err != nil {
+				vm.errorHandler(vm.errorReport(err))
+			}
+		}()
+	}
+
 	// Translate any panic into an error so the caller has a good guarantee
 	defer func() {
 		if r := recover(); r != nil {
@@ -137,16 +282,20 @@ func (vm *VirtualMachine) Run(ctx context.Context) (err error) {
 		}()
 	}
 
-	// Convert globals
-	vm.globals, err = object.AsObjects(vm.inputGlobals)
-	if err != nil {
-		return err
-	}
+	// Convert globals on the first run. Later runs keep the globals of the
+	// previous one, including any set by SetGlobals.
+	if !vm.converted {
+		vm.globals, err = object.AsObjects(vm.inputGlobals)
+		if err != nil {
+			return err
+		}
+		vm.converted = true
 
-	// Add any globals that are modules cache
-	for name, value := range vm.globals {
-		if module, ok := value.(*object.Module); ok {
-			vm.modules[name] = module
+		// Add any globals that are modules cache
+		for name, value := range vm.globals {
+			if module, ok := value.(*object.Module); ok {
+				vm.modules[name] = module
+			}
 		}
 	}
 
@@ -172,12 +321,25 @@ func (vm *VirtualMachine) Run(ctx context.Context) (err error) {
 		code = vm.load(vm.main)
 	}
 	vm.activateCode(0, vm.ip, code)
-	ctx = object.WithCallFunc(ctx, vm.callFunction)
-	ctx = limits.WithLimits(ctx, vm.limits)
-	if vm.concAllowed {
-		ctx = object.WithSpawnFunc(ctx, vm.spawnFunction)
+	if vm.contextGlobals != nil {
+		var globals map[string]object.Object
+		if globals, err = vm.contextGlobals(ctx); err != nil {
+			return
+		}
+		if err = vm.setGlobals(globals); err != nil {
+			return
+		}
+	}
+	ctx = vm.runContext(ctx)
+	if vm.hotReload {
+		if err = vm.reloadChanged(ctx); err != nil {
+			return
+		}
+	}
+	vm.failure = nil
+	if err = vm.eval(ctx); err != nil {
+		err = vm.runtimeError(err)
 	}
-	err = vm.eval(ctx)
 	return
 }
 
@@ -196,6 +358,59 @@ func (vm *VirtualMachine) Get(name string) (object.Object, error) {
 	return nil, fmt.Errorf("global with name %q not found", name)
 }
 
+// SetGlobals sets global variables of the main code, which are seen by the
+// runs and calls that follow. This lets a VM run its main code again with
+// new inputs, after SetIP(0), without being rebuilt and without converting
+// the globals that don't change. Each name must be a global of the main
+// code, such as one given by WithGlobals, or else an error is returned and
+// no globals are set. Clones share the globals of the VM they were cloned
+// from once it has run, so use CallWithGlobals to set globals for a single
+// call of a clone instead.
+func (vm *VirtualMachine) SetGlobals(globals map[string]object.Object) error {
+	if vm.running {
+		return errors.New("exec error: cannot set globals while the vm is running")
+	}
+	return vm.setGlobals(globals)
+}
+
+func (vm *VirtualMachine) setGlobals(globals map[string]object.Object) error {
+	indexes, err := vm.globalIndexes(globals)
+	if err != nil {
+		return err
+	}
+	root, loaded := vm.loadedCode[vm.main]
+	for name, value := range globals {
+		if loaded {
+			root.Globals[indexes[name]] = value
+		} else {
+			vm.inputGlobals[name] = value
+		}
+	}
+	return nil
+}
+
+// globalIndexes returns the indexes of the given globals of the main code.
+func (vm *VirtualMachine) globalIndexes(globals map[string]object.Object) (map[string]int, error) {
+	names := vm.main.GlobalNames()
+	indexes := make(map[string]int, len(globals))
+	for i, name := range names {
+		if _, ok := globals[name]; ok {
+			indexes[name] = i
+		}
+	}
+	if len(indexes) == len(globals) {
+		return indexes, nil
+	}
+	missing := make([]string, 0, len(globals)-len(indexes))
+	for name := range globals {
+		if _, ok := indexes[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return nil, fmt.Errorf("global with name %q not found", missing[0])
+}
+
 // GlobalNames returns the names of all global variables in the active code.
 func (vm *VirtualMachine) GlobalNames() []string {
 	if vm.activeCode == nil {
@@ -209,6 +424,17 @@ func (vm *VirtualMachine) GlobalNames() []string {
 	return names
 }
 
+// AllocStats returns the approximate memory used by objects reachable from
+// the global variables of the active code, grouped by object type. This is
+// typically called after Run to find which data structures hold the most
+// memory.
+func (vm *VirtualMachine) AllocStats() object.AllocStats {
+	if vm.activeCode == nil {
+		return object.AllocStats{}
+	}
+	return object.CollectAllocStats(vm.activeCode.Globals...)
+}
+
 // Evaluate the active code. The caller must initialize the following variables
 // before calling this function:
 //   - vm.ip - instruction pointer within the active code
@@ -229,6 +455,23 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 		// The current instruction opcode
 		opcode := vm.activeCode.Instructions[vm.ip]
 
+		// Let the debugger pause on each new source line
+		if vm.debugger != nil {
+			if err := vm.debugLine(ctx); err != nil {
+				return err
+			}
+		}
+
+		// Sample the call stack when a sample is due
+		if vm.profiler != nil && vm.profiler.Due() {
+			vm.sample()
+		}
+
+		// Count its execution when recording coverage
+		if counts := vm.activeCode.counts; counts != nil {
+			atomic.AddUint64(&counts[vm.ip], 1)
+		}
+
 		// fmt.Println("ip", vm.ip, op.GetInfo(opcode).Name, "sp", vm.sp)
 
 		// Advance the instruction pointer to the next instruction. Note that
@@ -242,6 +485,11 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 		case op.LoadAttr:
 			obj := vm.pop()
 			name := vm.activeCode.Names[vm.fetch()]
+			if vm.attrHooks != nil && isHostObject(obj) {
+				if err := vm.attrHooks.OnAttrAccess(ctx, obj, name); err != nil {
+					return err
+				}
+			}
 			value, found := obj.GetAttr(name)
 			if !found {
 				return fmt.Errorf("exec error: attribute %q not found on %s object",
@@ -553,8 +801,11 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			for _, name := range names {
 				// check if the name matches a module
 				module, err := vm.loadModule(ctx, filepath.Join(filepath.Join(from...), name))
+				var cycleErr *importCycleError
 				if err == nil {
 					vm.push(module)
+				} else if errors.As(err, &cycleErr) {
+					return err
 				} else {
 					// otherwise, the name is a symbol inside a module
 					module, err := vm.loadModule(ctx, filepath.Join(from...))
@@ -608,6 +859,10 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			nameCount := vm.fetch()
 			iter := vm.pop().(object.Iterator)
 			if _, ok := iter.Next(ctx); !ok {
+				// Streams record the error, if any, that ended them
+				if s, ok := iter.(interface{ Err() error }); ok && s.Err() != nil {
+					return s.Err()
+				}
 				vm.ip = base + int(jumpAmount)
 			} else {
 				obj, _ := iter.Entry()
@@ -669,32 +924,102 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 
 func (vm *VirtualMachine) loadModule(ctx context.Context, name string) (*object.Module, error) {
 	if module, ok := vm.modules[name]; ok {
+		if vm.hooks != nil {
+			if err := vm.hooks.OnImport(ctx, name); err != nil {
+				return nil, err
+			}
+		}
 		return module, nil
 	}
 	if vm.importer == nil {
 		return nil, fmt.Errorf("exec error: imports are disabled")
 	}
+	// Modules are cached once fully evaluated, so finding the module in
+	// the chain of imports in progress means it imports itself
+	for i, importing := range vm.importing {
+		if importing == name {
+			cycle := append(append([]string{}, vm.importing[i:]...), name)
+			return nil, &importCycleError{cycle: cycle}
+		}
+	}
+	vm.importing = append(vm.importing, name)
+	defer func() { vm.importing = vm.importing[:len(vm.importing)-1] }()
 	// Load and compile the module code
 	module, err := vm.importer.Import(ctx, name)
 	if err != nil {
 		return nil, err
 	}
+	// Modules are reported once found, since from-imports look for a
+	// module by the name of each symbol imported
+	if vm.hooks != nil {
+		if err := vm.hooks.OnImport(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+	if err := vm.evalModule(ctx, module); err != nil {
+		return nil, err
+	}
+	// Cache the module
+	vm.modules[name] = module
+	return module, nil
+}
+
+// evalModule evaluates the code of a module in a new frame, and binds the
+// resulting globals to the module.
+func (vm *VirtualMachine) evalModule(ctx context.Context, module *object.Module) error {
 	// Activate a new frame to evaluate the module code
 	baseFP := vm.fp
 	baseIP := vm.ip
 	baseSP := vm.sp
 	code := vm.load(module.Code())
-	vm.activateCode(vm.fp+1, 0, code)
+	vm.activateCode(vm.fp+1, 0, code).callerIP = baseIP
 	// Restore the previous frame when done
 	defer vm.resumeFrame(baseFP, baseIP, baseSP)
 	// Evaluate the module code
 	if err := vm.eval(ctx); err != nil {
-		return nil, err
+		vm.recordFailure(err)
+		return err
 	}
 	module.UseGlobals(code.Globals)
-	// Cache the module
-	vm.modules[name] = module
-	return module, nil
+	return nil
+}
+
+// reloadChanged imports the loaded modules that changed again, and updates
+// them in place. Modules that fail to reload are retried on the next call,
+// and keep their previous version meanwhile.
+func (vm *VirtualMachine) reloadChanged(ctx context.Context) error {
+	detector, ok := vm.importer.(importer.ChangeDetector)
+	if !ok {
+		return nil
+	}
+	for _, name := range detector.Changed() {
+		if _, loaded := vm.modules[name]; loaded {
+			vm.reloads = append(vm.reloads, name)
+		}
+	}
+	for len(vm.reloads) > 0 {
+		name := vm.reloads[0]
+		module, err := vm.importer.Import(ctx, name)
+		if err == nil {
+			err = vm.evalModule(ctx, module)
+		}
+		if err != nil {
+			return fmt.Errorf("%w (while reloading module %q)", err, name)
+		}
+		vm.modules[name].Update(module)
+		vm.reloads = vm.reloads[1:]
+	}
+	return nil
+}
+
+// importCycleError is returned when a module imports itself, directly or
+// through other modules.
+type importCycleError struct {
+	cycle []string
+}
+
+func (e *importCycleError) Error() string {
+	return fmt.Sprintf("import error: import cycle: %s", strings.Join(e.cycle, " -> "))
 }
 
 // GetIP returns the current instruction pointer.
@@ -746,12 +1071,153 @@ func (vm *VirtualMachine) fetch() uint16 {
 // important to you, do not provide a function here that was obtained from
 // another VM, since it could be a closure over variables in that VM. This
 // method should only be called after this VM stops running. Otherwise, an
-// error is returned.
+// error is returned. The call halts when the context is cancelled, after
+// which the VM shouldn't be used again.
 func (vm *VirtualMachine) Call(ctx context.Context, fn *object.Function, args []object.Object) (object.Object, error) {
 	if vm.running {
 		return nil, errors.New("exec error: cannot call function while the vm is running")
 	}
-	return vm.callFunction(ctx, fn, args)
+	if vm.contextGlobals != nil {
+		return vm.CallWithGlobals(ctx, fn, args, nil)
+	}
+	return vm.callWithContext(ctx, fn, args)
+}
+
+// callWithContext calls a function on behalf of the host, halting the call
+// when the context is cancelled.
+func (vm *VirtualMachine) callWithContext(ctx context.Context, fn *object.Function, args []object.Object) (object.Object, error) {
+	if doneChan := ctx.Done(); doneChan != nil {
+		// Wait for the watcher to exit, so the VM is only halted by a
+		// context cancelled during the call
+		stop, stopped := make(chan struct{}), make(chan struct{})
+		defer func() {
+			close(stop)
+			<-stopped
+		}()
+		go func() {
+			defer close(stopped)
+			select {
+			case <-doneChan:
+				atomic.StoreInt32(&vm.halt, 1)
+			case <-stop:
+			}
+		}()
+	}
+	return vm.callFunction(vm.runContext(ctx), fn, args)
+}
+
+// CallWithGlobals calls a function as Call does, with the given globals of
+// the main code set for the duration of the call only. Other calls of the
+// VM and of its clones don't see them, and assignments the call makes to
+// globals are discarded when it returns, which makes this suited to calling
+// a script with inputs that vary per call, such as from a pool of clones.
+// Each name must be a global of the main code, and the VM must have run.
+// The given globals take precedence over those of WithContextGlobals.
+func (vm *VirtualMachine) CallWithGlobals(
+	ctx context.Context,
+	fn *object.Function,
+	args []object.Object,
+	globals map[string]object.Object,
+) (object.Object, error) {
+	if vm.running {
+		return nil, errors.New("exec error: cannot call function while the vm is running")
+	}
+	if vm.contextGlobals != nil {
+		values, err := vm.contextGlobals(ctx)
+		if err != nil {
+			return nil, err
+		}
+		merged := make(map[string]object.Object, len(values)+len(globals))
+		for name, value := range values {
+			merged[name] = value
+		}
+		for name, value := range globals {
+			merged[name] = value
+		}
+		globals = merged
+	}
+	root, ok := vm.loadedCode[vm.main]
+	if !ok {
+		return nil, errors.New("exec error: cannot set globals before the vm runs")
+	}
+	indexes, err := vm.globalIndexes(globals)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]object.Object, len(root.Globals))
+	copy(values, root.Globals)
+	for name, value := range globals {
+		values[indexes[name]] = value
+	}
+	// Load the call with copies of the code of the main module that share
+	// the new globals, and restore the code the VM had afterwards
+	loadedCode := make(map[*compiler.Code]*code, len(vm.loadedCode))
+	for cc, c := range vm.loadedCode {
+		if cc.Root() == vm.main {
+			copied := *c
+			copied.Globals = values
+			c = &copied
+		}
+		loadedCode[cc] = c
+	}
+	previous := vm.loadedCode
+	vm.loadedCode = loadedCode
+	defer func() { vm.loadedCode = previous }()
+	return vm.callWithContext(ctx, fn, args)
+}
+
+// runContext returns the context code runs with, which lets builtins call
+// functions and spawn goroutines in this VM.
+func (vm *VirtualMachine) runContext(ctx context.Context) context.Context {
+	ctx = object.WithCallFunc(ctx, vm.callFunc())
+	if vm.hooks != nil {
+		ctx = object.WithCallHook(ctx, vm.hooks.OnCall)
+	}
+	if vm.limits != nil {
+		ctx = limits.WithLimits(ctx, vm.limits)
+	}
+	if vm.concAllowed {
+		ctx = object.WithSpawnFunc(ctx, vm.spawnFunction)
+	}
+	if vm.logHandler != nil {
+		ctx = object.WithLogHandler(ctx, vm.logHandler)
+	}
+	if vm.randSource != nil {
+		ctx = object.WithRandSource(ctx, vm.randSource)
+	}
+	if vm.clock != nil {
+		ctx = object.WithClock(ctx, vm.clock)
+	}
+	if vm.stdin != nil {
+		ctx = ros.WithStdinReader(ctx, vm.stdin)
+	}
+	if vm.stdout != nil {
+		ctx = ros.WithStdoutWriter(ctx, vm.stdout)
+	}
+	if vm.stderr != nil {
+		ctx = ros.WithStderrWriter(ctx, vm.stderr)
+	}
+	if vm.filesystem != nil {
+		ctx = ros.WithFilesystem(ctx, vm.filesystem)
+	}
+	if vm.env != nil {
+		ctx = ros.WithEnv(ctx, vm.env)
+	}
+	return ctx
+}
+
+// callFunc returns the function that builtins call compiled functions with,
+// which reports the calls to the hooks, if any.
+func (vm *VirtualMachine) callFunc() object.CallFunc {
+	if vm.hooks == nil {
+		return vm.callFunction
+	}
+	return func(ctx context.Context, fn *object.Function, args []object.Object) (object.Object, error) {
+		if err := vm.hooks.OnCall(ctx, fn, args); err != nil {
+			return nil, err
+		}
+		return vm.callFunction(ctx, fn, args)
+	}
 }
 
 // Calls a compiled function with the given arguments. This is used internally
@@ -809,6 +1275,7 @@ func (vm *VirtualMachine) callFunction(ctx context.Context, fn *object.Function,
 
 	// Evaluate the function code then return the result from TOS
 	if err := vm.eval(ctx); err != nil {
+		vm.recordFailure(err)
 		return nil, err
 	}
 	return vm.pop(), nil
@@ -818,6 +1285,11 @@ func (vm *VirtualMachine) call(ctx context.Context, fn object.Object, args []obj
 	argc := len(args)
 	switch fn := fn.(type) {
 	case *object.Function:
+		if vm.hooks != nil {
+			if err := vm.hooks.OnCall(ctx, fn, args); err != nil {
+				return err
+			}
+		}
 		result, err := vm.callFunction(ctx, fn, args)
 		if err != nil {
 			return err
@@ -833,6 +1305,11 @@ func (vm *VirtualMachine) call(ctx context.Context, fn object.Object, args []obj
 		copy(vm.tmp[argc:], fn.Args())
 		return vm.call(ctx, fn.Function(), vm.tmp[:expandedCount])
 	case object.Callable:
+		if vm.hooks != nil {
+			if err := vm.hooks.OnCall(ctx, fn.(object.Object), args); err != nil {
+				return err
+			}
+		}
 		result := fn.Call(ctx, args...)
 		if err, ok := result.(*object.Error); ok {
 			return err.Value()
@@ -852,14 +1329,16 @@ func (vm *VirtualMachine) load(cc *compiler.Code) *code {
 	// Loading is slightly different if this is the "root" (entrypoint) code
 	// vs. a child of that. The root code owns the globals array, while the
 	// children will reuse the globals from the root.
+	var c *code
 	rootCompiled := cc.Root()
 	if rootCompiled == cc {
-		c := loadRootCode(cc, vm.globals)
-		vm.loadedCode[cc] = c
-		return c
+		c = loadRootCode(cc, vm.globals)
+	} else {
+		c = loadChildCode(vm.load(rootCompiled), cc)
+	}
+	if vm.coverage != nil {
+		c.counts = vm.coverage.Counters(cc)
 	}
-	rootLoaded := vm.load(rootCompiled)
-	c := loadChildCode(rootLoaded, cc)
 	vm.loadedCode[cc] = c
 	return c
 }
@@ -892,6 +1371,9 @@ func (vm *VirtualMachine) resumeFrame(fp, ip, sp int) *frame {
 	if frameResult != nil {
 		vm.push(frameResult)
 	}
+	if vm.tracer != nil {
+		vm.traceReturn(fp)
+	}
 	// Activate the resumed frame
 	vm.fp = fp
 	vm.ip = ip
@@ -921,6 +1403,9 @@ func (vm *VirtualMachine) activateFunction(fp, ip int, fn *object.Function, loca
 	vm.activeFrame = &vm.frames[fp]
 	vm.activeFrame.ActivateFunction(fn, code, returnAddr, returnSp, locals)
 	vm.activeCode = code
+	if vm.tracer != nil {
+		vm.traceCall()
+	}
 	return vm.activeFrame
 }
 
@@ -942,8 +1427,8 @@ func (vm *VirtualMachine) activateFunction(fp, ip int, fn *object.Function, loca
 // Do not use this if you want a strict guarantee of isolation between VMs.
 //
 // The VM limits are not currently copied from the original because limits
-// implementations are not currently thread safe. Consequently it's not safe to
-// use Clone when limits are required.
+// implementations are not currently thread safe. Instead, calls on the clone
+// use the limits of their context, if any, as set by limits.WithLimits.
 //
 // Another current limitation that may be addressed in the future is that
 // cloned VMs do not have the ability to import additional modules.
@@ -969,8 +1454,28 @@ func (vm *VirtualMachine) Clone() (*VirtualMachine, error) {
 		main:         vm.main,
 		inputGlobals: vm.inputGlobals,
 		globals:      vm.globals,
+		converted:    vm.converted,
 		loadedCode:   loadedCode,
 		modules:      modules,
+		coverage:     vm.coverage,
+		profiler:     vm.profiler,
+		tracer:       vm.tracer,
+		concAllowed:  vm.concAllowed,
+		logHandler:   vm.logHandler,
+		randSource:   vm.randSource,
+		clock:        vm.clock,
+		stdin:        vm.stdin,
+		stdout:       vm.stdout,
+		stderr:       vm.stderr,
+		filesystem:   vm.filesystem,
+		env:          vm.env,
+		hooks:        vm.hooks,
+		attrHooks:    vm.attrHooks,
+
+		contextGlobals: vm.contextGlobals,
+	}
+	if clone.tracer != nil {
+		clone.thread = int(lastThread.Add(1))
 	}
 	clone.activateCode(0, vm.ip, clone.load(clone.main))
 	return clone, nil
@@ -982,7 +1487,7 @@ func (vm *VirtualMachine) spawnFunction(ctx context.Context, fn object.Callable,
 		return nil, err
 	}
 	// Create a ctx with the call and spawn functions set to the clone's methods!
-	ctx = object.WithCallFunc(ctx, clone.callFunction)
+	ctx = object.WithCallFunc(ctx, clone.callFunc())
 	ctx = object.WithSpawnFunc(ctx, clone.spawnFunction)
 	ctx = limits.WithLimits(ctx, nil)
 	// NewThread runs a goroutine
@@ -2,10 +2,14 @@ package vm
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/importer"
+	modResult "github.com/risor-io/risor/modules/result"
 	"github.com/risor-io/risor/object"
 	"github.com/risor-io/risor/parser"
 	"github.com/stretchr/testify/require"
@@ -833,6 +837,26 @@ func TestBuiltins(t *testing.T) {
 			object.NewInt(2),
 			object.NewInt(3),
 		})},
+		{`sorted([3, -2, 2], {reverse: true})`, object.NewList([]object.Object{
+			object.NewInt(3),
+			object.NewInt(2),
+			object.NewInt(-2),
+		})},
+		{`sorted(["bb", "a", "ccc"], func(s) { return len(s) })`, object.NewList([]object.Object{
+			object.NewString("a"),
+			object.NewString("bb"),
+			object.NewString("ccc"),
+		})},
+		{`sorted([{n: 2}, {n: 1}], {key: func(m) { return m.n }})[0].n`, object.NewInt(1)},
+		{`sorted([1, 3, 2], {cmp: func(a, b) { return b - a }})`, object.NewList([]object.Object{
+			object.NewInt(3),
+			object.NewInt(2),
+			object.NewInt(1),
+		})},
+		{`l := [2, 1]; l.sort(); l`, object.NewList([]object.Object{
+			object.NewInt(1),
+			object.NewInt(2),
+		})},
 		{`any([])`, object.False},
 		{`any([0, false, {}])`, object.False},
 		{`any([0, false, {foo: 42}])`, object.True},
@@ -867,6 +891,74 @@ func TestTry(t *testing.T) {
 	runTests(t, tests)
 }
 
+func TestStream(t *testing.T) {
+	tests := []testCase{
+		{`stream([1, 2, 3, 4]).map(func(x) { x * x }).filter(func(x) { x > 1 }).collect()`,
+			object.NewList([]object.Object{object.NewInt(4), object.NewInt(9), object.NewInt(16)})},
+		{`stream(range(1000000000)).skip(2).take(2).collect()`,
+			object.NewList([]object.Object{object.NewInt(2), object.NewInt(3)})},
+		{`stream([1, 2, 3]).reduce(0, func(acc, x) { acc + x })`, object.NewInt(6)},
+		{`total := 0; for _, v := range stream([1, 2, 3]).map(func(x) { x * 10 }) { total += v }; total`,
+			object.NewInt(60)},
+		{`stream("ab").zip([1, 2, 3]).collect()`, object.NewList([]object.Object{
+			object.NewList([]object.Object{object.NewString("a"), object.NewInt(1)}),
+			object.NewList([]object.Object{object.NewString("b"), object.NewInt(2)}),
+		})},
+	}
+	runTests(t, tests)
+}
+
+func TestStreamErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []string{
+		`for _, v := range stream(["1", "x"]).map(int) { }`,
+		`list(stream(["1", "x"]).map(int))`,
+	}
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			_, err := run(ctx, input)
+			require.NotNil(t, err)
+			require.Equal(t, "value error: invalid literal for int(): \"x\"", err.Error())
+		})
+	}
+}
+
+func TestResultPropagation(t *testing.T) {
+	ctx := context.Background()
+	opts := runOpts{Globals: map[string]any{"result": modResult.Module()}}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`result.of(func() { result.ok(1).unwrap() + result.ok(2).unwrap() })`, "ok(3)"},
+		{`result.of(func() { result.ok(1).unwrap() + result.err("boom").unwrap() })`, `err("boom")`},
+		{`["1", "x", "3"].map(func(s) { result.of(int, s) }).filter(func(r) { r.is_ok() })`, "[ok(1), ok(3)]"},
+		{`result.of(int, "x").map(func(x) { x * 2 }).unwrap_or(-1)`, "-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := run(ctx, tt.input, opts)
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, result.Inspect())
+		})
+	}
+}
+
+func TestAllocStats(t *testing.T) {
+	ctx := context.Background()
+	vm, err := newVM(ctx, `
+	rows := []
+	for i := 0; i < 10; i++ { rows.append({"id": i}) }
+	name := "test"
+	`)
+	require.Nil(t, err)
+	require.Nil(t, vm.Run(ctx))
+	stats := vm.AllocStats()
+	require.Equal(t, int64(10), stats[object.MAP].Count)
+	require.Equal(t, int64(1), stats[object.LIST].Count)
+	require.Greater(t, stats[object.MAP].Bytes, stats[object.LIST].Bytes)
+}
+
 func TestMultiVarAssignment(t *testing.T) {
 	tests := []testCase{
 		{`a, b := [3, 4]; a`, object.NewInt(3)},
@@ -1350,6 +1442,24 @@ func TestCall(t *testing.T) {
 	require.Equal(t, object.NewInt(10), result)
 }
 
+func TestCallFunctionCallingBack(t *testing.T) {
+	ctx := context.Background()
+	source := `func double(items) { items.map(func(x) { x * 2 }) }`
+	vm, err := newVM(ctx, source)
+	require.Nil(t, err)
+	require.Nil(t, vm.Run(ctx))
+
+	obj, err := vm.Get("double")
+	require.Nil(t, err)
+	clone, err := vm.Clone()
+	require.Nil(t, err)
+	result, err := clone.Call(ctx, obj.(*object.Function), []object.Object{
+		object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)}),
+	})
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{object.NewInt(2), object.NewInt(4)}), result)
+}
+
 func TestCallWithClosure(t *testing.T) {
 	ctx := context.Background()
 	source := `
@@ -1480,6 +1590,87 @@ func TestClone(t *testing.T) {
 	require.Equal(t, object.NewInt(4), value)
 }
 
+func TestCallCancelled(t *testing.T) {
+	ctx := context.Background()
+	vm, err := newVM(ctx, `func spin() { for {} }`)
+	require.Nil(t, err)
+	require.Nil(t, vm.Run(ctx))
+	fn, err := vm.Get("spin")
+	require.Nil(t, err)
+	clone, err := vm.Clone()
+	require.Nil(t, err)
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = clone.Call(ctx, fn.(*object.Function), nil)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestSetGlobals(t *testing.T) {
+	ctx := context.Background()
+	vm, err := newVM(ctx, `result := x * 2; func get() { return x }`, runOpts{
+		Globals: map[string]any{"x": 1},
+	})
+	require.Nil(t, err)
+	require.Nil(t, vm.SetGlobals(map[string]object.Object{"x": object.NewInt(2)}))
+	require.Nil(t, vm.Run(ctx))
+	result, err := vm.Get("result")
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(4), result)
+
+	require.Nil(t, vm.SetGlobals(map[string]object.Object{"x": object.NewInt(5)}))
+	vm.SetIP(0)
+	require.Nil(t, vm.Run(ctx))
+	result, err = vm.Get("result")
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(10), result)
+
+	fn, err := vm.Get("get")
+	require.Nil(t, err)
+	result, err = vm.Call(ctx, fn.(*object.Function), nil)
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(5), result)
+
+	err = vm.SetGlobals(map[string]object.Object{"x": object.NewInt(1), "y": object.NewInt(1)})
+	require.EqualError(t, err, `global with name "y" not found`)
+	result, err = vm.Get("x")
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(5), result)
+}
+
+func TestCallWithGlobals(t *testing.T) {
+	ctx := context.Background()
+	vm, err := newVM(ctx, `
+	func helper(n) {
+		factor = factor + 1
+		return n * factor
+	}
+	func scale(n) { return helper(n) }
+	`, runOpts{Globals: map[string]any{"factor": 1}})
+	require.Nil(t, err)
+	require.Nil(t, vm.Run(ctx))
+	fn, err := vm.Get("scale")
+	require.Nil(t, err)
+	clone, err := vm.Clone()
+	require.Nil(t, err)
+
+	result, err := clone.CallWithGlobals(ctx, fn.(*object.Function), []object.Object{object.NewInt(3)},
+		map[string]object.Object{"factor": object.NewInt(9)})
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(30), result)
+
+	// The globals of the call, and its assignments, are discarded
+	factor, err := clone.Get("factor")
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(1), factor)
+	result, err = clone.Call(ctx, fn.(*object.Function), []object.Object{object.NewInt(3)})
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(6), result)
+
+	_, err = clone.CallWithGlobals(ctx, fn.(*object.Function), nil,
+		map[string]object.Object{"other": object.Nil})
+	require.EqualError(t, err, `global with name "other" not found`)
+}
+
 func TestCloneWithAnonymousFunc(t *testing.T) {
 	registered := map[string]*object.Function{}
 
@@ -1577,6 +1768,10 @@ func TestImports(t *testing.T) {
 		{`import data; data.mydata["count"] = 3; data.mydata["count"]`, object.NewInt(3)},
 		{`import data as d; d.mydata["count"]`, object.NewInt(1)},
 		{`import math as m; m.min(3,-7)`, object.NewFloat(-7)},
+		{`import diamond_left; import diamond_right; diamond_left.value + diamond_right.value`, object.NewInt(2)},
+		{`import a.function as f; f.plusOne(1)`, object.NewInt(2)},
+		{`import a.b.data; data.mapValue["1"]`, object.NewInt(1)},
+		{`import a.b; b.mapValue["1"]`, object.NewInt(1)},
 	}
 	runTests(t, tests)
 }
@@ -1611,12 +1806,16 @@ func TestBadImports(t *testing.T) {
 		{`import foo as bar`, `import error: module "foo" not found`},
 		{`import math as`, `parse error: unexpected end of file while parsing an import statement (expected identifier)`},
 		{`from foo import bar`, `import error: module "foo" not found`},
-		{`from a.b import c`, `import error: module "a/b" not found`},
-		{`from a.b import c as d`, `import error: module "a/b" not found`},
+		{`from a.x import c`, `import error: module "a/x" not found`},
+		{`from a.x import c as d`, `import error: module "a/x" not found`},
+		{`from a.b import c`, `import error: cannot import name "c" from "a/b"`},
 		{`from math import foo`, `import error: cannot import name "foo" from "math"`},
 		{`from math`, `parse error: from-import is missing import statement`},
 		{`from math import`, `parse error: unexpected end of file while parsing a from-import statement (expected identifier)`},
 		{`from math import min as`, `parse error: unexpected end of file while parsing a from-import statement (expected identifier)`},
+		{`import cycle_a`, `import error: import cycle: cycle_a -> cycle_b -> cycle_a`},
+		{`from cycle_b import value`, `import error: import cycle: cycle_b -> cycle_a -> cycle_b`},
+		{`import self_import`, `import error: import cycle: self_import -> self_import`},
 	}
 	for _, tt := range tests {
 		_, err := run(ctx, tt.input)
@@ -1960,3 +2159,53 @@ func runTests(t *testing.T, tests []testCase) {
 		})
 	}
 }
+
+func TestHotReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lib.risor")
+	write := func(source string, modTime time.Time) {
+		require.Nil(t, os.WriteFile(path, []byte(source), 0o644))
+		require.Nil(t, os.Chtimes(path, modTime, modTime))
+	}
+	write("value := 1", time.Now())
+	ctx := context.Background()
+	im := importer.NewLocalImporter(importer.LocalImporterOptions{SourceDir: dir})
+
+	// Evaluate lines of code in the same VM, as a REPL does
+	c, err := compiler.New()
+	require.Nil(t, err)
+	var machine *VirtualMachine
+	eval := func(source string) (object.Object, error) {
+		ast, err := parser.Parse(ctx, source)
+		require.Nil(t, err)
+		code, err := c.Compile(ast)
+		require.Nil(t, err)
+		if machine == nil {
+			machine = New(code, WithImporter(im), WithHotReload())
+		}
+		if err := machine.Run(ctx); err != nil {
+			machine.SetIP(code.InstructionCount())
+			return nil, err
+		}
+		result, _ := machine.TOS()
+		return result, nil
+	}
+	result, err := eval("import lib; lib.value")
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(1), result)
+
+	write("value := 2", time.Now().Add(time.Hour))
+	result, err = eval("lib.value")
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(2), result)
+
+	// Runs fail until a broken module is fixed
+	write("value := ", time.Now().Add(2*time.Hour))
+	_, err = eval("lib.value")
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), `(while reloading module "lib")`)
+	write("value := 3", time.Now().Add(3*time.Hour))
+	result, err = eval("lib.value")
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(3), result)
+}